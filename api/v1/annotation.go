@@ -0,0 +1,35 @@
+package v1
+
+import (
+	"errors"
+	"time"
+)
+
+// Annotation marks an event during the test run, like a deploy or a chaos
+// injection, which external tooling reported, so that it can be correlated
+// with the other metrics later.
+type Annotation struct {
+	Name string            `json:"name" yaml:"name"`
+	Text string            `json:"text,omitempty" yaml:"text,omitempty"`
+	Tags map[string]string `json:"tags,omitempty" yaml:"tags,omitempty"`
+
+	// Start is when the event started, the time of the request if it's zero.
+	Start time.Time `json:"start" yaml:"start"`
+	// End is when the event ended, if it wasn't instantaneous.
+	End *time.Time `json:"end,omitempty" yaml:"end,omitempty"`
+}
+
+func (a Annotation) validate() error {
+	if a.Name == "" {
+		return errors.New("the name of the annotation is required")
+	}
+	if a.End != nil && a.End.Before(a.Start) {
+		return errors.New("the end of the annotation can't be before its start")
+	}
+	for key := range a.Tags {
+		if key == "" {
+			return errors.New("the tags of the annotation can't have empty keys")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,28 @@
+package v1
+
+// AnnotationJSONAPI is JSON API envelop for annotations
+type AnnotationJSONAPI struct {
+	Data annotationData `json:"data"`
+}
+
+// NewAnnotationJSONAPI creates the JSON API annotation envelop
+func NewAnnotationJSONAPI(id string, a Annotation) AnnotationJSONAPI {
+	return AnnotationJSONAPI{
+		Data: annotationData{
+			ID:         id,
+			Type:       "annotations",
+			Attributes: a,
+		},
+	}
+}
+
+// Annotation extract the v1.Annotation from the JSON API envelop
+func (a AnnotationJSONAPI) Annotation() Annotation {
+	return a.Data.Attributes
+}
+
+type annotationData struct {
+	Type       string     `json:"type"`
+	ID         string     `json:"id"`
+	Attributes Annotation `json:"attributes"`
+}
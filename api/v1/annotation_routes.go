@@ -0,0 +1,71 @@
+package v1
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.k6.io/k6/metrics"
+)
+
+// handlePostAnnotation emits a sample of the annotations metric at the start of
+// the annotation, so the outputs, e.g. Prometheus for Grafana annotations,
+// receive it with the other metrics. The name and the tags of the annotation
+// are the tags of the sample, while its text and end are metadata.
+func handlePostAnnotation(cs *ControlSurface, rw http.ResponseWriter, r *http.Request) {
+	rw.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		apiError(rw, "Couldn't read request", err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var envelop AnnotationJSONAPI
+	if err = json.Unmarshal(body, &envelop); err != nil {
+		apiError(rw, "Invalid data", err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	annotation := envelop.Annotation()
+	if annotation.Start.IsZero() {
+		annotation.Start = time.Now()
+	}
+	if err = annotation.validate(); err != nil {
+		apiError(rw, "Invalid annotation", err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	id := strconv.FormatUint(cs.lastAnnotationID.Add(1), 10)
+	metadata := map[string]string{"annotation_id": id}
+	if annotation.Text != "" {
+		metadata["annotation_text"] = annotation.Text
+	}
+	if annotation.End != nil {
+		metadata["annotation_end"] = annotation.End.Format(time.RFC3339Nano)
+	}
+
+	sample := metrics.Sample{
+		TimeSeries: metrics.TimeSeries{
+			Metric: cs.RunState.BuiltinMetrics.Annotations,
+			Tags:   cs.RunState.RunTags.WithTagsFromMap(annotation.Tags).With("annotation", annotation.Name),
+		},
+		Time:     annotation.Start,
+		Metadata: metadata,
+		Value:    1,
+	}
+	if !metrics.PushIfNotDone(cs.RunCtx, cs.Samples, sample) {
+		apiError(rw, "Test run finished", "the annotation can't be added after the test run", http.StatusConflict)
+		return
+	}
+
+	data, err := json.Marshal(NewAnnotationJSONAPI(id, annotation))
+	if err != nil {
+		apiError(rw, "Encoding error", err.Error(), http.StatusInternalServerError)
+		return
+	}
+	rw.WriteHeader(http.StatusCreated)
+	_, _ = rw.Write(data)
+}
@@ -0,0 +1,110 @@
+package v1
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/internal/lib/testutils/minirunner"
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/metrics"
+)
+
+func TestPostAnnotation(t *testing.T) {
+	t.Parallel()
+
+	testState := getTestRunState(t, lib.Options{}, &minirunner.MiniRunner{})
+	testState.RunTags = testState.RunTags.With("testid", "123")
+	cs := getControlSurface(t, testState)
+
+	payload := `{"data":{"type":"annotations","attributes":{"name":"deploy","text":"v1.2.3",` +
+		`"tags":{"service":"api"},"start":"2024-01-02T03:04:05Z","end":"2024-01-02T03:05:05Z"}}}`
+	rw := httptest.NewRecorder()
+	NewHandler(cs).ServeHTTP(rw, httptest.NewRequest(http.MethodPost, "/v1/annotations", bytes.NewBufferString(payload)))
+	res := rw.Result()
+	t.Cleanup(func() {
+		assert.NoError(t, res.Body.Close())
+	})
+	require.Equal(t, http.StatusCreated, res.StatusCode, rw.Body.String())
+
+	var doc AnnotationJSONAPI
+	require.NoError(t, json.Unmarshal(rw.Body.Bytes(), &doc))
+	assert.Equal(t, "annotations", doc.Data.Type)
+	assert.Equal(t, "1", doc.Data.ID)
+	assert.Equal(t, "deploy", doc.Annotation().Name)
+
+	require.Len(t, cs.Samples, 1)
+	samples := (<-cs.Samples).GetSamples()
+	require.Len(t, samples, 1)
+	sample := samples[0]
+	assert.Equal(t, metrics.AnnotationsName, sample.Metric.Name)
+	assert.Equal(t, 1.0, sample.Value)
+	assert.Equal(t, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), sample.Time)
+	assert.Equal(t, map[string]string{
+		"annotation": "deploy",
+		"service":    "api",
+		"testid":     "123",
+	}, sample.Tags.Map())
+	assert.Equal(t, map[string]string{
+		"annotation_id":   "1",
+		"annotation_text": "v1.2.3",
+		"annotation_end":  "2024-01-02T03:05:05Z",
+	}, sample.Metadata)
+}
+
+func TestPostAnnotationDefaultStart(t *testing.T) {
+	t.Parallel()
+
+	cs := getControlSurface(t, getTestRunState(t, lib.Options{}, &minirunner.MiniRunner{}))
+
+	before := time.Now()
+	rw := httptest.NewRecorder()
+	NewHandler(cs).ServeHTTP(rw, httptest.NewRequest(http.MethodPost, "/v1/annotations",
+		bytes.NewBufferString(`{"data":{"attributes":{"name":"chaos"}}}`)))
+	require.Equal(t, http.StatusCreated, rw.Code, rw.Body.String())
+
+	var doc AnnotationJSONAPI
+	require.NoError(t, json.Unmarshal(rw.Body.Bytes(), &doc))
+	assert.False(t, doc.Annotation().Start.Before(before))
+	assert.Nil(t, doc.Annotation().End)
+
+	sample := (<-cs.Samples).GetSamples()[0]
+	assert.Equal(t, map[string]string{"annotation_id": "1"}, sample.Metadata)
+}
+
+func TestPostAnnotationInvalid(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]string{
+		"malformed":  `{"data":`,
+		"no name":    `{"data":{"attributes":{"text":"oops"}}}`,
+		"empty tag":  `{"data":{"attributes":{"name":"deploy","tags":{"":"x"}}}}`,
+		"end before": `{"data":{"attributes":{"name":"deploy","start":"2024-01-02T03:04:05Z","end":"2024-01-02T03:00:00Z"}}}`,
+	}
+	for name, payload := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			cs := getControlSurface(t, getTestRunState(t, lib.Options{}, &minirunner.MiniRunner{}))
+			rw := httptest.NewRecorder()
+			NewHandler(cs).ServeHTTP(rw, httptest.NewRequest(http.MethodPost, "/v1/annotations", bytes.NewBufferString(payload)))
+			assert.Equal(t, http.StatusBadRequest, rw.Code)
+			assert.Empty(t, cs.Samples)
+		})
+	}
+
+	t.Run("method", func(t *testing.T) {
+		t.Parallel()
+
+		cs := getControlSurface(t, getTestRunState(t, lib.Options{}, &minirunner.MiniRunner{}))
+		rw := httptest.NewRecorder()
+		NewHandler(cs).ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/v1/annotations", nil))
+		assert.Equal(t, http.StatusMethodNotAllowed, rw.Code)
+	})
+}
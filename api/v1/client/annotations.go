@@ -0,0 +1,22 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	v1 "go.k6.io/k6/api/v1"
+)
+
+// Annotate marks an event, like a deploy, during the test run and returns the
+// created annotation.
+func (c *Client) Annotate(ctx context.Context, annotation v1.Annotation) (ret v1.Annotation, err error) {
+	var resp v1.AnnotationJSONAPI
+
+	apiURL := &url.URL{Path: "/v1/annotations"}
+	if err = c.CallAPI(ctx, http.MethodPost, apiURL, v1.NewAnnotationJSONAPI("", annotation), &resp); err != nil {
+		return ret, err
+	}
+
+	return resp.Annotation(), nil
+}
@@ -2,6 +2,7 @@ package v1
 
 import (
 	"context"
+	"sync/atomic"
 
 	"go.k6.io/k6/internal/execution"
 	"go.k6.io/k6/internal/metrics/engine"
@@ -17,4 +18,7 @@ type ControlSurface struct {
 	MetricsEngine *engine.MetricsEngine
 	Scheduler     *execution.Scheduler
 	RunState      *lib.TestRunState
+
+	// the ID of the last annotation, which was added through the API
+	lastAnnotationID atomic.Uint64
 }
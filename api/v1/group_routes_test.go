@@ -53,7 +53,7 @@ func getControlSurface(tb testing.TB, testState *lib.TestRunState) *ControlSurfa
 
 	ctx, cancel := context.WithCancel(context.Background())
 	tb.Cleanup(cancel)
-	ctx, _ = execution.NewTestRunContext(ctx, testState.Logger)
+	ctx, _ = execution.NewTestRunContext(ctx, testState.Logger, nil)
 
 	return &ControlSurface{
 		RunCtx:        ctx,
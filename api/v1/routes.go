@@ -79,5 +79,14 @@ func NewHandler(cs *ControlSurface) http.Handler {
 		handleRunTeardown(cs, rw, r)
 	})
 
+	mux.HandleFunc("/v1/annotations", func(rw http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			rw.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		handlePostAnnotation(cs, rw, r)
+	})
+
 	return mux
 }
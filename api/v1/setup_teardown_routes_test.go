@@ -146,7 +146,7 @@ func TestSetupData(t *testing.T) {
 
 			globalCtx, globalCancel := context.WithCancel(context.Background())
 			defer globalCancel()
-			runCtx, runAbort := execution.NewTestRunContext(globalCtx, testState.Logger)
+			runCtx, runAbort := execution.NewTestRunContext(globalCtx, testState.Logger, nil)
 			defer runAbort(fmt.Errorf("unexpected abort"))
 
 			outputManager := output.NewManager([]output.Output{metricsEngine.CreateIngester()}, testState.Logger, runAbort)
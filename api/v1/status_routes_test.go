@@ -124,7 +124,7 @@ func TestPatchStatus(t *testing.T) {
 
 			globalCtx, globalCancel := context.WithCancel(context.Background())
 			defer globalCancel()
-			runCtx, runAbort := execution.NewTestRunContext(globalCtx, testState.Logger)
+			runCtx, runAbort := execution.NewTestRunContext(globalCtx, testState.Logger, nil)
 			defer runAbort(fmt.Errorf("unexpected abort"))
 
 			outputManager := output.NewManager([]output.Output{metricsEngine.CreateIngester()}, testState.Logger, runAbort)
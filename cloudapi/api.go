@@ -3,8 +3,10 @@ package cloudapi
 import (
 	"bytes"
 	"fmt"
+	"io"
 	"mime/multipart"
 	"net/http"
+	"path"
 	"strconv"
 
 	"github.com/sirupsen/logrus"
@@ -336,3 +338,39 @@ func (c *Client) ValidateToken() (*ValidateTokenResponse, error) {
 
 	return &vtr, nil
 }
+
+// UploadTestRunArtifact uploads a file, like a custom report, a HAR or a
+// screenshot, and attaches it to the test run with the provided referenceID.
+// The name is the slash-separated path of the artifact in the test run.
+func (c *Client) UploadTestRunArtifact(referenceID, name string, r io.Reader) error {
+	requestURL := fmt.Sprintf("%s/tests/%s/artifacts", c.baseURL, referenceID)
+
+	var buf bytes.Buffer
+	mp := multipart.NewWriter(&buf)
+
+	if err := mp.WriteField("name", name); err != nil {
+		return err
+	}
+
+	fw, err := mp.CreateFormFile("file", path.Base(name))
+	if err != nil {
+		return err
+	}
+
+	if _, err = io.Copy(fw, r); err != nil {
+		return err
+	}
+
+	if err = mp.Close(); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, requestURL, &buf) //nolint:noctx
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", mp.FormDataContentType())
+
+	return c.Do(req, nil)
+}
@@ -250,6 +250,29 @@ func TestFinished(t *testing.T) {
 	assert.Nil(t, err)
 }
 
+func TestUploadTestRunArtifact(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/tests/123/artifacts", r.URL.Path)
+		assert.Equal(t, "screenshots/home.png", r.FormValue("name"))
+
+		file, header, err := r.FormFile("file")
+		require.NoError(t, err)
+		assert.Equal(t, "home.png", header.Filename)
+		data, err := io.ReadAll(file)
+		require.NoError(t, err)
+		assert.Equal(t, "png", string(data))
+
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := NewClient(testutils.NewLogger(t), "token", server.URL, "1.0", 1*time.Second)
+	err := client.UploadTestRunArtifact("123", "screenshots/home.png", bytes.NewBufferString("png"))
+
+	assert.NoError(t, err)
+}
+
 func TestIdempotencyKey(t *testing.T) {
 	t.Parallel()
 	const idempotencyKey = "xxx"
@@ -6,7 +6,9 @@ import (
 	"os"
 	"os/signal"
 	"path/filepath"
+	"strconv"
 	"sync"
+	"time"
 
 	"github.com/mattn/go-colorable"
 	"github.com/mattn/go-isatty"
@@ -139,8 +141,13 @@ type GlobalFlags struct {
 	NoColor          bool
 	Address          string
 	ProfilingEnabled bool
+	ProfilesDir      string
+	ProfilesInterval time.Duration
 	LogOutput        string
 	LogFormat        string
+	LogLevels        string
+	LogRateLimit     int
+	Plugins          []string
 	Verbose          bool
 }
 
@@ -149,6 +156,7 @@ func GetDefaultFlags(homeDir string) GlobalFlags {
 	return GlobalFlags{
 		Address:          "localhost:6565",
 		ProfilingEnabled: false,
+		ProfilesInterval: time.Minute,
 		ConfigFilePath:   filepath.Join(homeDir, "loadimpact", "k6", defaultConfigFileName),
 		LogOutput:        "stderr",
 	}
@@ -169,6 +177,19 @@ func getFlags(defaultFlags GlobalFlags, env map[string]string) GlobalFlags {
 	if val, ok := env["K6_LOG_FORMAT"]; ok {
 		result.LogFormat = val
 	}
+	if val, ok := env["K6_LOG_LEVELS"]; ok {
+		result.LogLevels = val
+	}
+	if val, ok := env["K6_LOG_RATE_LIMIT"]; ok {
+		// an invalid value is rejected like a negative one, when the logger is set up
+		result.LogRateLimit = -1
+		if limit, err := strconv.Atoi(val); err == nil {
+			result.LogRateLimit = limit
+		}
+	}
+	if val, ok := env["K6_PLUGINS"]; ok {
+		result.Plugins = filepath.SplitList(val)
+	}
 	if env["K6_NO_COLOR"] != "" {
 		result.NoColor = true
 	}
@@ -180,5 +201,15 @@ func getFlags(defaultFlags GlobalFlags, env map[string]string) GlobalFlags {
 	if _, ok := env["K6_PROFILING_ENABLED"]; ok {
 		result.ProfilingEnabled = true
 	}
+	if val, ok := env["K6_PROFILES_DIR"]; ok {
+		result.ProfilesDir = val
+	}
+	if val, ok := env["K6_PROFILES_INTERVAL"]; ok {
+		// an invalid value is rejected like a non-positive one, when the profiles are written
+		result.ProfilesInterval = -1
+		if interval, err := time.ParseDuration(val); err == nil {
+			result.ProfilesInterval = interval
+		}
+	}
 	return result
 }
@@ -0,0 +1,20 @@
+package state
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetFlagsProfilesInterval(t *testing.T) {
+	t.Parallel()
+
+	defaultFlags := GetDefaultFlags(".config")
+	assert.Equal(t, time.Minute, getFlags(defaultFlags, map[string]string{}).ProfilesInterval)
+	assert.Equal(t, 30*time.Second,
+		getFlags(defaultFlags, map[string]string{"K6_PROFILES_INTERVAL": "30s"}).ProfilesInterval)
+	// invalid values are rejected later, like the non-positive ones
+	assert.Equal(t, time.Duration(-1),
+		getFlags(defaultFlags, map[string]string{"K6_PROFILES_INTERVAL": "often"}).ProfilesInterval)
+}
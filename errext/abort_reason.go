@@ -15,6 +15,7 @@ const (
 	AbortedByScriptAbort
 	AbortedByTimeout
 	AbortedByOutput
+	AbortedByResourceBudget
 )
 
 // HasAbortReason is a wrapper around an error with an attached abort reason.
@@ -53,4 +53,12 @@ const (
 
 	// GoPanic indicates the script was aborted by a panic in the Go runtime.
 	GoPanic ExitCode = 109
+
+	// ResourceBudgetExceeded indicates the test was aborted, because a scenario
+	// was over its resource budget.
+	ResourceBudgetExceeded ExitCode = 110
+
+	// InsufficientResources indicates the test wasn't started, because the
+	// local resources, e.g. the open files limit, weren't enough for its VUs.
+	InsufficientResources ExitCode = 111
 )
@@ -1,10 +1,25 @@
 package cmd
 
 import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 
 	"go.k6.io/k6/cmd/state"
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/fsext"
+)
+
+const (
+	// archivePassphraseEnv is the environment variable with the passphrase of
+	// the encrypted archives, and archivePassphraseFileEnv is the one with the
+	// path of a file with it, e.g. a mounted secret.
+	archivePassphraseEnv     = "K6_ARCHIVE_PASSPHRASE"
+	archivePassphraseFileEnv = "K6_ARCHIVE_PASSPHRASE_FILE"
 )
 
 // cmdArchive handles the `k6 archive` sub-command
@@ -13,6 +28,7 @@ type cmdArchive struct {
 
 	archiveOut     string
 	excludeEnvVars bool
+	encrypt        bool
 }
 
 func (c *cmdArchive) run(cmd *cobra.Command, args []string) error {
@@ -20,6 +36,7 @@ func (c *cmdArchive) run(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
+	defer test.removeExtractedFiles(c.gs.Logger)
 
 	// It's important to NOT set the derived options back to the runner
 	// here, only the consolidated ones. Otherwise, if the script used
@@ -40,8 +57,19 @@ func (c *cmdArchive) run(cmd *cobra.Command, args []string) error {
 		arc.Env = nil
 	}
 
+	var passphrase string
+	if c.encrypt {
+		if passphrase, err = archivePassphrase(c.gs); err != nil {
+			return err
+		}
+		if passphrase == "" {
+			return fmt.Errorf("encrypting the archive needs a passphrase in the %s or the %s environment variables",
+				archivePassphraseEnv, archivePassphraseFileEnv)
+		}
+	}
+
 	if c.archiveOut == "-" {
-		return arc.Write(c.gs.Stdout)
+		return writeArchive(arc, c.gs.Stdout, passphrase)
 	}
 
 	f, err := c.gs.FS.Create(c.archiveOut)
@@ -49,13 +77,50 @@ func (c *cmdArchive) run(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	err = arc.Write(f)
+	err = writeArchive(arc, f, passphrase)
 	if cerr := f.Close(); err == nil && cerr != nil {
 		err = cerr
 	}
 	return err
 }
 
+// writeArchive writes the archive, encrypted with the passphrase, if it isn't
+// empty.
+func writeArchive(arc *lib.Archive, out io.Writer, passphrase string) error {
+	if passphrase == "" {
+		return arc.Write(out)
+	}
+	w, err := lib.NewArchiveEncrypter(out, passphrase)
+	if err != nil {
+		return err
+	}
+	if err = arc.Write(w); err != nil {
+		return err
+	}
+	return w.Close()
+}
+
+// archivePassphrase returns the passphrase of the encrypted archives, from the
+// environment or from the file in it, or an empty string if there isn't one.
+func archivePassphrase(gs *state.GlobalState) (string, error) {
+	if passphrase := gs.Env[archivePassphraseEnv]; passphrase != "" {
+		return passphrase, nil
+	}
+	path := gs.Env[archivePassphraseFileEnv]
+	if path == "" {
+		return "", nil
+	}
+	data, err := fsext.ReadFile(gs.FS, path)
+	if err != nil {
+		return "", fmt.Errorf("couldn't read the passphrase of the archive from %s: %w", archivePassphraseFileEnv, err)
+	}
+	passphrase := strings.TrimRight(string(data), "\r\n")
+	if passphrase == "" {
+		return "", errors.New("the file in " + archivePassphraseFileEnv + " doesn't have a passphrase")
+	}
+	return passphrase, nil
+}
+
 func (c *cmdArchive) flagSet() *pflag.FlagSet {
 	flags := pflag.NewFlagSet("", pflag.ContinueOnError)
 	flags.SortFlags = false
@@ -72,6 +137,11 @@ func (c *cmdArchive) flagSet() *pflag.FlagSet {
 		false,
 		"do not embed any environment variables (either from --env or the actual environment) in the archive metadata",
 	)
+	flags.BoolVar(
+		&c.encrypt, "encrypt", false,
+		"encrypt the archive with the passphrase in the "+archivePassphraseEnv+" environment variable, "+
+			"or in the file in "+archivePassphraseFileEnv,
+	)
 
 	return flags
 }
@@ -87,7 +157,10 @@ func getCmdArchive(gs *state.GlobalState) *cobra.Command {
   {{.}} archive -u 10 -d 10s -O myarchive.tar script.js
 
   # Run the resulting archive.
-  {{.}} run myarchive.tar`[1:])
+  {{.}} run myarchive.tar
+
+  # Archive a test run, encrypted with a passphrase, which is needed to run it.
+  K6_ARCHIVE_PASSPHRASE=secret {{.}} archive --encrypt -O myarchive.tar script.js`[1:])
 
 	archiveCmd := &cobra.Command{
 		Use:   "archive",
@@ -1,16 +1,22 @@
 package cmd
 
 import (
+	"bytes"
 	"encoding/json"
+	"fmt"
 	"io/fs"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 
+	"github.com/spf13/afero"
 	"github.com/stretchr/testify/require"
 	"go.k6.io/k6/errext/exitcodes"
 	"go.k6.io/k6/internal/cmd/tests"
 	"go.k6.io/k6/internal/lib/testutils"
+	"go.k6.io/k6/lib"
 	"go.k6.io/k6/lib/fsext"
 )
 
@@ -275,3 +281,180 @@ func TestArchiveStdout(t *testing.T) {
 
 	require.GreaterOrEqual(t, len(ts.Stdout.Bytes()), 32)
 }
+
+func TestArchiveBigFiles(t *testing.T) {
+	t.Parallel()
+
+	// given a script with a file, which is extracted on disk when its archive is loaded
+	big := bytes.Repeat([]byte("k6"), lib.ArchiveExtractionSize)
+	testScript := []byte(fmt.Sprintf(`
+		const data = open("./data/big.bin", "b");
+		export default function () {
+			if (data.byteLength !== %d) {
+				throw new Error("unexpected size " + data.byteLength);
+			}
+		}
+	`, len(big)))
+	ts := tests.NewGlobalTestState(t)
+	require.NoError(t, fsext.WriteFile(ts.FS, filepath.Join(ts.Cwd, "script.js"), testScript, 0o644))
+	require.NoError(t, fsext.WriteFile(ts.FS, filepath.Join(ts.Cwd, "data", "big.bin"), big, 0o644))
+	ts.CmdArgs = []string{"k6", "archive", "-O", "archive.tar", "script.js"}
+	newRootCommand(ts.GlobalState).execute()
+	archive, err := fsext.ReadFile(ts.FS, "archive.tar")
+	require.NoError(t, err)
+
+	// when the archive is run
+	ts = tests.NewGlobalTestState(t)
+	require.NoError(t, fsext.WriteFile(ts.FS, filepath.Join(ts.Cwd, "archive.tar"), archive, 0o644))
+	ts.CmdArgs = []string{"k6", "run", "--iterations", "1", "archive.tar"}
+	newRootCommand(ts.GlobalState).execute()
+
+	// then the extracted files are removed after it
+	extracted, err := afero.Glob(ts.FS, filepath.Join(os.TempDir(), "k6-archive-*")) //nolint:forbidigo
+	require.NoError(t, err)
+	require.Empty(t, extracted)
+	require.Contains(t, ts.Stdout.String(), "1 complete and 0 interrupted iterations")
+}
+
+func TestArchiveEncrypted(t *testing.T) {
+	t.Parallel()
+
+	// given an archive encrypted with a passphrase
+	testScript := []byte(`export default function () { console.log("decrypted"); }`)
+	ts := tests.NewGlobalTestState(t)
+	require.NoError(t, fsext.WriteFile(ts.FS, filepath.Join(ts.Cwd, "script.js"), testScript, 0o644))
+	ts.Env["K6_ARCHIVE_PASSPHRASE"] = "secret"
+	ts.CmdArgs = []string{"k6", "archive", "--encrypt", "-O", "archive.tar", "script.js"}
+	newRootCommand(ts.GlobalState).execute()
+	archive, err := fsext.ReadFile(ts.FS, "archive.tar")
+	require.NoError(t, err)
+	require.True(t, lib.IsEncryptedArchive(archive))
+	require.NotContains(t, string(archive), "decrypted")
+
+	run := func(t *testing.T, env map[string]string, files map[string]string, exitCode int) *tests.GlobalTestState {
+		ts := tests.NewGlobalTestState(t)
+		require.NoError(t, fsext.WriteFile(ts.FS, filepath.Join(ts.Cwd, "archive.tar"), archive, 0o644))
+		for name, data := range files {
+			require.NoError(t, fsext.WriteFile(ts.FS, name, []byte(data), 0o600))
+		}
+		for k, v := range env {
+			ts.Env[k] = v
+		}
+		ts.ExpectedExitCode = exitCode
+		ts.CmdArgs = []string{"k6", "run", "--iterations", "1", "archive.tar"}
+		newRootCommand(ts.GlobalState).execute()
+		return ts
+	}
+
+	t.Run("passphrase", func(t *testing.T) {
+		t.Parallel()
+		ts := run(t, map[string]string{"K6_ARCHIVE_PASSPHRASE": "secret"}, nil, 0)
+		require.Contains(t, ts.Stderr.String(), "decrypted")
+	})
+
+	t.Run("passphrase file", func(t *testing.T) {
+		t.Parallel()
+		ts := run(t,
+			map[string]string{"K6_ARCHIVE_PASSPHRASE_FILE": "/run/secrets/k6"},
+			map[string]string{"/run/secrets/k6": "secret\n"}, 0)
+		require.Contains(t, ts.Stderr.String(), "decrypted")
+	})
+
+	// like the other archives, which can't be read, they fail with the generic exit code
+	t.Run("wrong passphrase", func(t *testing.T) {
+		t.Parallel()
+		ts := run(t, map[string]string{"K6_ARCHIVE_PASSPHRASE": "wrong"}, nil, -1)
+		require.Contains(t, ts.Stderr.String(), "the passphrase of the encrypted archive is wrong")
+	})
+
+	t.Run("no passphrase", func(t *testing.T) {
+		t.Parallel()
+		ts := run(t, nil, nil, -1)
+		require.Contains(t, ts.Stderr.String(), "the archive is encrypted, but there isn't a passphrase")
+	})
+}
+
+// mkdirRecordingFs records the directories, which are created in it.
+type mkdirRecordingFs struct {
+	fsext.Fs
+	mx   sync.Mutex
+	dirs []string
+}
+
+func (rfs *mkdirRecordingFs) Mkdir(name string, perm fs.FileMode) error {
+	rfs.mx.Lock()
+	rfs.dirs = append(rfs.dirs, name)
+	rfs.mx.Unlock()
+	return rfs.Fs.Mkdir(name, perm)
+}
+
+func TestArchiveEncryptedBigFiles(t *testing.T) {
+	t.Parallel()
+
+	// given an encrypted archive with a file, which would be extracted on disk if it wasn't
+	big := bytes.Repeat([]byte("k6"), lib.ArchiveExtractionSize)
+	testScript := []byte(fmt.Sprintf(`
+		const data = open("./data/big.bin", "b");
+		export default function () {
+			if (data.byteLength !== %d) {
+				throw new Error("unexpected size " + data.byteLength);
+			}
+		}
+	`, len(big)))
+	ts := tests.NewGlobalTestState(t)
+	require.NoError(t, fsext.WriteFile(ts.FS, filepath.Join(ts.Cwd, "script.js"), testScript, 0o644))
+	require.NoError(t, fsext.WriteFile(ts.FS, filepath.Join(ts.Cwd, "data", "big.bin"), big, 0o644))
+	ts.Env["K6_ARCHIVE_PASSPHRASE"] = "secret"
+	ts.CmdArgs = []string{"k6", "archive", "--encrypt", "-O", "archive.tar", "script.js"}
+	newRootCommand(ts.GlobalState).execute()
+	archive, err := fsext.ReadFile(ts.FS, "archive.tar")
+	require.NoError(t, err)
+
+	// when the archive is run
+	ts = tests.NewGlobalTestState(t)
+	require.NoError(t, fsext.WriteFile(ts.FS, filepath.Join(ts.Cwd, "archive.tar"), archive, 0o644))
+	rfs := &mkdirRecordingFs{Fs: ts.FS}
+	ts.FS = rfs
+	ts.Env["K6_ARCHIVE_PASSPHRASE"] = "secret"
+	ts.CmdArgs = []string{"k6", "run", "--iterations", "1", "archive.tar"}
+	newRootCommand(ts.GlobalState).execute()
+
+	// then its decrypted files aren't written on disk
+	require.Contains(t, ts.Stdout.String(), "1 complete and 0 interrupted iterations")
+	rfs.mx.Lock()
+	defer rfs.mx.Unlock()
+	for _, dir := range rfs.dirs {
+		require.False(t, strings.HasPrefix(filepath.Base(dir), "k6-archive-"), dir)
+	}
+}
+
+func TestArchiveTestType(t *testing.T) {
+	t.Parallel()
+
+	testScript := []byte(`export default function () { console.log("from the archive"); }`)
+	ts := tests.NewGlobalTestState(t)
+	require.NoError(t, fsext.WriteFile(ts.FS, filepath.Join(ts.Cwd, "script.js"), testScript, 0o644))
+	ts.CmdArgs = []string{"k6", "archive", "-O", "archive.tar", "script.js"}
+	newRootCommand(ts.GlobalState).execute()
+	archive, err := fsext.ReadFile(ts.FS, "archive.tar")
+	require.NoError(t, err)
+
+	t.Run("js type of a local archive", func(t *testing.T) {
+		t.Parallel()
+		ts := tests.NewGlobalTestState(t)
+		require.NoError(t, fsext.WriteFile(ts.FS, filepath.Join(ts.Cwd, "archive.tar"), archive, 0o644))
+		ts.ExpectedExitCode = int(exitcodes.ScriptException)
+		ts.CmdArgs = []string{"k6", "run", "--type", "js", "--iterations", "1", "archive.tar"}
+		newRootCommand(ts.GlobalState).execute()
+		require.Contains(t, ts.Stderr.String(), "Unexpected token")
+	})
+
+	t.Run("archive type from stdin", func(t *testing.T) {
+		t.Parallel()
+		ts := tests.NewGlobalTestState(t)
+		ts.Stdin = bytes.NewReader(archive)
+		ts.CmdArgs = []string{"k6", "run", "--type", "archive", "--iterations", "1", "-"}
+		newRootCommand(ts.GlobalState).execute()
+		require.Contains(t, ts.Stderr.String(), "from the archive")
+	})
+}
@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"context"
+	"os/exec"
+	"runtime"
+
+	"go.k6.io/k6/cmd/state"
+	"go.k6.io/k6/lib"
+)
+
+// artifactsDirIfWritten returns the directory of the artifacts, or an empty
+// string, if none were written.
+func artifactsDirIfWritten(artifacts *lib.Artifacts) string {
+	if !artifacts.Written() {
+		return ""
+	}
+	return artifacts.Dir()
+}
+
+// uploadArtifacts runs the command uploading the artifacts of the test run,
+// with their directory in the K6_ARTIFACTS_DIR environment variable, like
+// `aws s3 cp --recursive "$K6_ARTIFACTS_DIR" s3://bucket/` on CI.
+func uploadArtifacts(ctx context.Context, gs *state.GlobalState, command string, artifacts *lib.Artifacts) error {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command) //nolint:gosec
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command) //nolint:gosec
+	}
+	cmd.Env = make([]string, 0, len(gs.Env)+1)
+	for k, v := range gs.Env {
+		cmd.Env = append(cmd.Env, k+"="+v)
+	}
+	cmd.Env = append(cmd.Env, "K6_ARTIFACTS_DIR="+artifacts.Dir())
+	cmd.Stdout = gs.Stdout
+	cmd.Stderr = gs.Stderr
+	return cmd.Run()
+}
@@ -88,6 +88,7 @@ func (c *cmdCloud) run(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
+	defer test.removeExtractedFiles(c.gs.Logger)
 
 	// It's important to NOT set the derived options back to the runner
 	// here, only the consolidated ones. Otherwise, if the script used
@@ -371,7 +372,10 @@ func getCmdCloud(gs *state.GlobalState) *cobra.Command {
   $ {{.}} cloud run script.js
 
   # Run a k6 archive in the Grafana Cloud k6
-  $ {{.}} cloud run archive.tar`[1:])
+  $ {{.}} cloud run archive.tar
+
+  # Attach a local report to a test run in the Grafana Cloud k6
+  $ {{.}} cloud upload-artifacts 123 report.html`[1:])
 
 	cloudCmd := &cobra.Command{
 		Use:   "cloud",
@@ -394,6 +398,7 @@ service. Be sure to run the "k6 cloud login" command prior to authenticate with
 	cloudCmd.AddCommand(getCmdCloudRun(c))
 	cloudCmd.AddCommand(getCmdCloudLogin(gs))
 	cloudCmd.AddCommand(getCmdCloudUpload(c))
+	cloudCmd.AddCommand(getCmdCloudUploadArtifacts(gs))
 
 	cloudCmd.Flags().SortFlags = false
 	cloudCmd.Flags().AddFlagSet(c.flagSet())
@@ -138,6 +138,7 @@ func (c *cmdCloudRun) run(cmd *cobra.Command, args []string) error {
 			}
 
 			if err := createCloudTest(c.runCmd.gs, test); err != nil {
+				test.removeExtractedFiles(c.runCmd.gs.Logger)
 				return nil, nil, fmt.Errorf("could not create the cloud test run: %w", err)
 			}
 
@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"go.k6.io/k6/cloudapi"
+	"go.k6.io/k6/cmd/state"
+	"go.k6.io/k6/lib/consts"
+	"go.k6.io/k6/lib/fsext"
+)
+
+const cloudUploadArtifactsCommandName = "upload-artifacts"
+
+type cmdCloudUploadArtifacts struct {
+	globalState *state.GlobalState
+}
+
+func getCmdCloudUploadArtifacts(gs *state.GlobalState) *cobra.Command {
+	c := &cmdCloudUploadArtifacts{
+		globalState: gs,
+	}
+
+	// uploadArtifactsCloudCommand represents the 'cloud upload-artifacts' command
+	exampleText := getExampleText(gs, `
+  # Attach a custom report and a HAR file to the test run 123
+  $ {{.}} cloud upload-artifacts 123 report.html session.har
+
+  # Attach all the artifacts written by the test, like its screenshots
+  $ {{.}} cloud upload-artifacts 123 k6-artifacts/`[1:])
+
+	uploadArtifactsCloudCommand := &cobra.Command{
+		Use:   cloudUploadArtifactsCommandName + " <test-run-id> <path>...",
+		Short: "Attach local files to a test run in the Grafana Cloud k6",
+		Long: `Attach local files to a test run in the Grafana Cloud k6.
+
+This will upload the files, like custom reports, HAR files or screenshots, and associate
+them with the test run, so all the evidence of the test run lives in one place. The
+directories are uploaded recursively, with the paths of their files relative to them.
+Using this command requires to be authenticated against the Grafana Cloud k6.
+Use the "k6 cloud login" command to authenticate.
+`,
+		Example: exampleText,
+		Args: func(_ *cobra.Command, args []string) error {
+			if len(args) < 2 {
+				return errors.New("the command expects the ID of the test run, and at least one file or directory")
+			}
+			return nil
+		},
+		RunE: c.run,
+	}
+
+	return uploadArtifactsCloudCommand
+}
+
+// run is the code that runs when the user executes `k6 cloud upload-artifacts`
+func (c *cmdCloudUploadArtifacts) run(_ *cobra.Command, args []string) error {
+	diskConf, err := readDiskConfig(c.globalState)
+	if err != nil {
+		return err
+	}
+
+	cloudConfig, warn, err := cloudapi.GetConsolidatedConfig(
+		diskConf.Collectors["cloud"], c.globalState.Env, "", nil, nil)
+	if err != nil {
+		return err
+	}
+	if warn != "" {
+		c.globalState.Logger.Warn(warn)
+	}
+	if !cloudConfig.Token.Valid {
+		return errors.New( //nolint:golint
+			"not logged in, please login first to the Grafana Cloud k6 " +
+				"using the \"k6 cloud login\" command",
+		)
+	}
+
+	artifacts, err := c.collectArtifacts(args[1:])
+	if err != nil {
+		return err
+	}
+
+	client := cloudapi.NewClient(
+		c.globalState.Logger,
+		cloudConfig.Token.String,
+		cloudConfig.Host.String,
+		consts.Version,
+		cloudConfig.Timeout.TimeDuration(),
+	)
+
+	testRunID := args[0]
+	for _, artifact := range artifacts {
+		if err := c.upload(client, testRunID, artifact); err != nil {
+			return fmt.Errorf("can't upload the artifact %s: %w", artifact.path, err)
+		}
+		printToStdout(c.globalState, fmt.Sprintf("  uploaded: %s\n", artifact.name))
+	}
+
+	printToStdout(c.globalState, fmt.Sprintf("  output: %s\n", cloudapi.URLForResults(testRunID, cloudConfig)))
+	return nil
+}
+
+// cloudArtifact is a local file, which is uploaded with the name.
+type cloudArtifact struct {
+	path, name string
+}
+
+// collectArtifacts returns the files of the paths, before any of them is
+// uploaded, so a missing one doesn't leave the test run with only some of them.
+func (c *cmdCloudUploadArtifacts) collectArtifacts(paths []string) ([]cloudArtifact, error) {
+	pwd, err := c.globalState.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	var artifacts []cloudArtifact
+	for _, p := range paths {
+		p = fsext.Abs(pwd, p)
+		info, err := c.globalState.FS.Stat(p)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			artifacts = append(artifacts, cloudArtifact{path: p, name: filepath.Base(p)})
+			continue
+		}
+		err = fsext.Walk(c.globalState.FS, p, func(filePath string, info fs.FileInfo, err error) error {
+			if err != nil || info.IsDir() {
+				return err
+			}
+			name, err := filepath.Rel(p, filePath)
+			if err != nil {
+				return err
+			}
+			artifacts = append(artifacts, cloudArtifact{path: filePath, name: filepath.ToSlash(name)})
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return artifacts, nil
+}
+
+func (c *cmdCloudUploadArtifacts) upload(client *cloudapi.Client, testRunID string, artifact cloudArtifact) error {
+	f, err := c.globalState.FS.Open(artifact.path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+	return client.UploadTestRunArtifact(testRunID, artifact.name, f)
+}
@@ -35,6 +35,8 @@ func configFlagSet() *pflag.FlagSet {
 		false,
 		"don't send anonymous usage"+"stats (https://grafana.com/docs/k6/latest/set-up/usage-collection/)",
 	)
+	flags.String("thresholds-from", "", "`url` or file path of centrally defined thresholds, "+
+		"which apply to the metrics that the test doesn't define its own thresholds for")
 	return flags
 }
 
@@ -47,6 +49,11 @@ type Config struct {
 	NoUsageReport null.Bool `json:"noUsageReport" envconfig:"K6_NO_USAGE_REPORT"`
 	WebDashboard  null.Bool `json:"webDashboard" envconfig:"K6_WEB_DASHBOARD"`
 
+	// ThresholdsFrom is the URL or the file path of the centrally governed
+	// thresholds, which are merged with the thresholds of the test, with the
+	// latter having precedence for the same metric.
+	ThresholdsFrom null.String `json:"thresholdsFrom" envconfig:"K6_THRESHOLDS_FROM"`
+
 	// NoArchiveUpload is an option that is only used when running in local-execution mode with the cloud run
 	// command.
 	//
@@ -82,6 +89,9 @@ func (c Config) Apply(cfg Config) Config {
 	if cfg.WebDashboard.Valid {
 		c.WebDashboard = cfg.WebDashboard
 	}
+	if cfg.ThresholdsFrom.Valid {
+		c.ThresholdsFrom = cfg.ThresholdsFrom
+	}
 	if cfg.NoArchiveUpload.Valid {
 		c.NoArchiveUpload = cfg.NoArchiveUpload
 	}
@@ -117,6 +127,8 @@ func getConfig(flags *pflag.FlagSet) (Config, error) {
 		Linger:        getNullBool(flags, "linger"),
 		NoUsageReport: getNullBool(flags, "no-usage-report"),
 
+		ThresholdsFrom: getNullString(flags, "thresholds-from"),
+
 		// As the "run" and the "cloud run" commands share the same implementation
 		// we enforce the run command to ignore the no-archive-upload flag, and always
 		// set it to true (do not upload).
@@ -257,6 +269,9 @@ func applyDefault(conf Config) Config {
 	if !conf.TeardownTimeout.Valid {
 		conf.TeardownTimeout.Duration = types.Duration(60 * time.Second)
 	}
+	if !conf.OnAbortTimeout.Valid {
+		conf.OnAbortTimeout.Duration = types.Duration(60 * time.Second)
+	}
 	return conf
 }
 
@@ -301,5 +316,8 @@ func validateScenarioConfig(conf lib.ExecutorConfig, isExecutable func(string) b
 	if !isExecutable(execFn) {
 		return fmt.Errorf("executor %s: function '%s' not found in exports", conf.GetName(), execFn)
 	}
+	if hooked, ok := conf.(interface{ GetScheduleHook() string }); ok && !isExecutable(hooked.GetScheduleHook()) {
+		return fmt.Errorf("executor %s: function '%s' not found in exports", conf.GetName(), hooked.GetScheduleHook())
+	}
 	return nil
 }
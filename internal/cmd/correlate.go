@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"go.k6.io/k6/cmd/state"
+	"go.k6.io/k6/internal/correlation"
+)
+
+type correlateCmd struct {
+	gs *state.GlobalState
+}
+
+func (c *correlateCmd) readRecording(filename string) ([]correlation.Exchange, error) {
+	path := filename
+	if !filepath.IsAbs(path) {
+		pwd, err := c.gs.Getwd()
+		if err != nil {
+			return nil, err
+		}
+		path = filepath.Join(pwd, path)
+	}
+	f, err := c.gs.FS.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	exchanges, err := correlation.Read(f)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read the recording %s: %w", filename, err)
+	}
+	return exchanges, nil
+}
+
+func (c *correlateCmd) run(_ *cobra.Command, args []string) error {
+	first, err := c.readRecording(args[0])
+	if err != nil {
+		return err
+	}
+	second, err := c.readRecording(args[1])
+	if err != nil {
+		return err
+	}
+
+	return correlation.WriteReport(c.gs.Stdout, first, correlation.Find(first, second))
+}
+
+func getCmdCorrelate(gs *state.GlobalState) *cobra.Command {
+	c := &correlateCmd{gs: gs}
+
+	exampleText := getExampleText(gs, `
+    # Find the dynamic values of two HAR recordings of the same user flow
+    $ {{.}} correlate first.har second.har
+
+    # Find them in the logs of two test runs of a script
+    $ {{.}} run --http-debug=full --log-format=json script.js 2> first.log
+    $ {{.}} run --http-debug=full --log-format=json script.js 2> second.log
+    $ {{.}} correlate first.log second.log`[1:])
+
+	correlateCmd := &cobra.Command{
+		Use:   "correlate [first recording] [second recording]",
+		Short: "Find the dynamic values of two recordings of a user flow",
+		Long: `Find the dynamic values of two recordings of the same user flow.
+
+The recordings are HAR files or the logs of test runs with the --http-debug=full
+option. The values, which are received in the responses of both recordings, but
+are different in them, and which are sent back by their later requests, are
+printed with the code extracting them from the responses. The cookies aren't
+printed, since the VUs send them back.`,
+		Example: exampleText,
+		Args:    cobra.ExactArgs(2),
+		RunE:    c.run,
+	}
+
+	return correlateCmd
+}
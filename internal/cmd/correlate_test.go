@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/internal/cmd/tests"
+	"go.k6.io/k6/lib/fsext"
+)
+
+func TestCorrelateCmd(t *testing.T) {
+	t.Parallel()
+
+	recording := func(token string) []byte {
+		return []byte(`{"log": {"entries": [
+			{
+				"request": {"method": "POST", "url": "https://test.k6.io/login", "headers": []},
+				"response": {"status": 200, "headers": [], "content": {"text": "{\"token\": \"` + token + `\"}"}}
+			},
+			{
+				"request": {"method": "GET", "url": "https://test.k6.io/me",
+					"headers": [{"name": "Authorization", "value": "Bearer ` + token + `"}]},
+				"response": {"status": 200, "headers": [], "content": {"text": ""}}
+			}
+		]}}`)
+	}
+
+	ts := tests.NewGlobalTestState(t)
+	require.NoError(t, fsext.WriteFile(ts.FS, filepath.Join(ts.Cwd, "first.har"), recording("t0k3n-one"), 0o644))
+	require.NoError(t, fsext.WriteFile(ts.FS, filepath.Join(ts.Cwd, "second.har"), recording("t0k3n-two"), 0o644))
+	ts.CmdArgs = []string{"k6", "correlate", "first.har", "second.har"}
+
+	newRootCommand(ts.GlobalState).execute()
+
+	stdout := ts.Stdout.String()
+	assert.Contains(t, stdout, "Found 1 dynamic values to correlate.")
+	assert.Contains(t, stdout, "//   request 2 (GET https://test.k6.io/me) in the Authorization\n")
+	assert.Contains(t, stdout, `const token = res.json("token");`)
+}
+
+func TestCorrelateCmdInvalidRecording(t *testing.T) {
+	t.Parallel()
+
+	ts := tests.NewGlobalTestState(t)
+	require.NoError(t, fsext.WriteFile(ts.FS, filepath.Join(ts.Cwd, "first.har"), []byte("{}"), 0o644))
+	ts.CmdArgs = []string{"k6", "correlate", "first.har", "second.har"}
+	ts.ExpectedExitCode = -1
+
+	newRootCommand(ts.GlobalState).execute()
+
+	assert.Contains(t, ts.Stderr.String(), "couldn't read the recording first.har")
+}
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"encoding/json"
+	"errors"
 
 	"github.com/spf13/cobra"
 
@@ -12,19 +13,44 @@ import (
 
 // TODO: split apart like `k6 run` and `k6 archive`
 func getCmdInspect(gs *state.GlobalState) *cobra.Command {
-	var addExecReqs bool
+	var addExecReqs, printOptionsSchema bool
 
 	// inspectCmd represents the inspect command
 	inspectCmd := &cobra.Command{
 		Use:   "inspect [file]",
 		Short: "Inspect a script or archive",
-		Long:  `Inspect a script or archive.`,
-		Args:  cobra.ExactArgs(1),
+		Long: `Inspect a script or archive.
+
+With --options-schema, it prints the JSON schema of the options of this k6
+version instead, e.g. for the validation of the options in editors.`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if !printOptionsSchema {
+				return cobra.ExactArgs(1)(cmd, args)
+			}
+			if len(args) > 0 {
+				return errors.New("the options schema doesn't depend on a script or archive, so it can't be given one")
+			}
+			return nil
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if printOptionsSchema {
+				schema, err := optionsSchema()
+				if err != nil {
+					return err
+				}
+				data, err := json.MarshalIndent(schema, "", "  ")
+				if err != nil {
+					return err
+				}
+				printToStdout(gs, string(data))
+				return nil
+			}
+
 			test, err := loadLocalTest(gs, cmd, args)
 			if err != nil {
 				return err
 			}
+			defer test.removeExtractedFiles(gs.Logger)
 
 			// At the moment, `k6 inspect` output can take 2 forms: standard
 			// (equal to the lib.Options struct) and extended, with additional
@@ -55,6 +81,10 @@ func getCmdInspect(gs *state.GlobalState) *cobra.Command {
 		"execution-requirements",
 		false,
 		"include calculations of execution requirements for the test")
+	inspectCmd.Flags().BoolVar(&printOptionsSchema,
+		"options-schema",
+		false,
+		"print the JSON schema of the options, instead of inspecting a test")
 
 	return inspectCmd
 }
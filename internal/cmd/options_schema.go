@@ -0,0 +1,258 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/consts"
+	"go.k6.io/k6/lib/types"
+	"go.k6.io/k6/metrics"
+)
+
+// jsonSchemaDialect is the version of the JSON schema, which is emitted by
+// `k6 inspect --options-schema`.
+const jsonSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// durationSchema is the schema of the durations, which are either strings like
+// "1m30s" or numbers of milliseconds.
+func durationSchema(nullable bool) map[string]any {
+	schema := map[string]any{
+		"type":        []string{"string", "number"},
+		"description": `A duration like "1m30s", or a number of milliseconds.`,
+	}
+	if nullable {
+		schema["type"] = []string{"string", "number", "null"}
+	}
+	return schema
+}
+
+// enumSchema returns the schema of a string, which is one of the given values.
+func enumSchema(values []string) map[string]any {
+	values = slices.Clone(values)
+	slices.Sort(values)
+	return map[string]any{"type": "string", "enum": values}
+}
+
+// nullableEnumSchema returns the schema of a string, which is one of the given
+// values, or null.
+func nullableEnumSchema(values []string) map[string]any {
+	schema := enumSchema(values)
+	enum := make([]any, 0, len(values)+1)
+	for _, v := range schema["enum"].([]string) { //nolint:forcetypeassert // it's set above
+		enum = append(enum, v)
+	}
+	schema["enum"] = append(enum, nil)
+	schema["type"] = []string{"string", "null"}
+	return schema
+}
+
+// enumValues returns the names of the enum values.
+func enumValues[T fmt.Stringer](values []T) []string {
+	names := make([]string, len(values))
+	for i, v := range values {
+		names[i] = v.String()
+	}
+	return names
+}
+
+// customTypeSchemas returns the schemas of the types, which have custom JSON
+// unmarshaling, so their schemas can't be derived from their Go types.
+func customTypeSchemas() map[reflect.Type]map[string]any {
+	tlsVersion := enumSchema(mapKeys(lib.SupportedTLSVersions))
+	threshold := map[string]any{
+		"oneOf": []any{
+			map[string]any{"type": "string"},
+			map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"threshold":      map[string]any{"type": "string"},
+					"abortOnFail":    map[string]any{"type": "boolean"},
+					"delayAbortEval": durationSchema(true),
+				},
+				"required":             []string{"threshold"},
+				"additionalProperties": false,
+			},
+		},
+	}
+
+	return map[reflect.Type]map[string]any{
+		reflect.TypeOf(null.Bool{}):                    {"type": []string{"boolean", "null"}},
+		reflect.TypeOf(null.Int{}):                     {"type": []string{"integer", "null"}},
+		reflect.TypeOf(null.Float{}):                   {"type": []string{"number", "null"}},
+		reflect.TypeOf(null.String{}):                  {"type": []string{"string", "null"}},
+		reflect.TypeOf(types.Duration(0)):              durationSchema(false),
+		reflect.TypeOf(types.NullDuration{}):           durationSchema(true),
+		reflect.TypeOf(json.RawMessage{}):              {},
+		reflect.TypeOf(lib.ExecutionSegment{}):         {"type": "string"},
+		reflect.TypeOf(lib.ExecutionSegmentSequence{}): {"type": "string"},
+		reflect.TypeOf(lib.IPNet{}):                    {"type": "string"},
+		reflect.TypeOf(lib.TLSVersion(0)):              tlsVersion,
+		reflect.TypeOf(types.NullHostnameTrie{}):       {"type": "array", "items": map[string]any{"type": "string"}},
+		reflect.TypeOf(types.NullHosts{}):              {"type": "object", "additionalProperties": map[string]any{"type": "string"}},
+		reflect.TypeOf(types.DNSServer{}):              {"type": "string"},
+		reflect.TypeOf(types.NullDNSSelect{}):          nullableEnumSchema(enumValues(types.DNSSelectValues())),
+		reflect.TypeOf(types.NullDNSPolicy{}):          nullableEnumSchema(enumValues(types.DNSPolicyValues())),
+		reflect.TypeOf(metrics.Thresholds{}):           {"type": "array", "items": threshold},
+		reflect.TypeOf(metrics.SystemTagSet(0)):        {"type": "array", "items": enumSchema(enumValues(metrics.SystemTagValues()))},
+		reflect.TypeOf(lib.TLSCipherSuites{}):          {"type": "array", "items": enumSchema(mapKeys(lib.SupportedTLSCipherSuites))},
+		reflect.TypeOf(lib.TLSCurves{}):                {"type": "array", "items": enumSchema(mapKeys(lib.SupportedTLSCurves))},
+		reflect.TypeOf(lib.TLSVersions{}): {
+			"oneOf": []any{tlsVersion, map[string]any{
+				"type":                 "object",
+				"properties":           map[string]any{"min": tlsVersion, "max": tlsVersion},
+				"additionalProperties": false,
+			}},
+		},
+	}
+}
+
+func mapKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// optionsSchema returns the JSON schema of the test options, including the
+// specific fields of the scenarios of each executor, which is generated from
+// the lib.Options type of this k6 version.
+func optionsSchema() (map[string]any, error) {
+	scenarios, err := scenariosSchema()
+	if err != nil {
+		return nil, err
+	}
+	sg := schemaGenerator{
+		custom: customTypeSchemas(),
+	}
+	sg.custom[reflect.TypeOf(lib.ScenarioConfigs{})] = scenarios
+
+	schema := sg.typeSchema(reflect.TypeOf(lib.Options{}))
+	schema["$schema"] = jsonSchemaDialect
+	schema["title"] = "k6 v" + consts.Version + " options"
+	return schema, nil
+}
+
+// scenariosSchema returns the schema of the scenarios, which are one of the
+// registered executor configs, selected by their executor field.
+func scenariosSchema() (map[string]any, error) {
+	sg := schemaGenerator{custom: customTypeSchemas(), strict: true}
+
+	configTypes := lib.GetExecutorConfigTypes()
+	executors := make([]any, 0, len(configTypes))
+	for _, configType := range configTypes {
+		config, err := lib.GetParsedExecutorConfig(
+			"schema", configType, []byte(fmt.Sprintf(`{"executor":%q}`, configType)))
+		if err != nil {
+			return nil, fmt.Errorf("couldn't get the config of the executor %s: %w", configType, err)
+		}
+		schema := sg.typeSchema(reflect.TypeOf(config))
+		properties, _ := schema["properties"].(map[string]any)
+		properties["executor"] = map[string]any{"const": configType}
+		schema["required"] = []string{"executor"}
+		executors = append(executors, schema)
+	}
+
+	return map[string]any{
+		"type":                 "object",
+		"additionalProperties": map[string]any{"oneOf": executors},
+	}, nil
+}
+
+var jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem() //nolint:gochecknoglobals
+
+// schemaGenerator generates the JSON schemas of Go types from their fields and
+// json tags.
+type schemaGenerator struct {
+	custom map[reflect.Type]map[string]any
+	// strict disallows the properties, which aren't fields of the structs,
+	// since they're unmarshaled with lib.StrictJSONUnmarshal.
+	strict bool
+}
+
+func (sg schemaGenerator) typeSchema(t reflect.Type) map[string]any {
+	if schema, ok := sg.custom[t]; ok {
+		return schema
+	}
+
+	switch t.Kind() { //nolint:exhaustive // the other kinds aren't used in the options
+	case reflect.Pointer:
+		return sg.typeSchema(t.Elem())
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]any{"type": "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer", "minimum": 0}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": sg.typeSchema(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": sg.typeSchema(t.Elem())}
+	case reflect.Struct:
+		properties := make(map[string]any)
+		sg.addProperties(properties, t)
+		schema := map[string]any{"type": "object", "properties": properties}
+		// the custom unmarshalers of the structs don't inherit the strictness
+		if sg.strict && !reflect.PointerTo(t).Implements(jsonUnmarshalerType) {
+			schema["additionalProperties"] = false
+		}
+		return schema
+	default:
+		return map[string]any{}
+	}
+}
+
+// addProperties adds the schemas of the fields of the struct, including the
+// fields of its embedded structs, to the properties.
+func (sg schemaGenerator) addProperties(properties map[string]any, t reflect.Type) {
+	for i := range t.NumField() {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				if _, ok := sg.custom[embedded]; !ok {
+					sg.addProperties(properties, embedded)
+					continue
+				}
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		schema := sg.typeSchema(field.Type)
+		if env := field.Tag.Get("envconfig"); env != "" && field.Tag.Get("ignored") != "true" {
+			schema = cloneSchema(schema)
+			schema["x-k6-env"] = env
+		}
+		properties[name] = schema
+	}
+}
+
+func cloneSchema(schema map[string]any) map[string]any {
+	clone := make(map[string]any, len(schema)+1)
+	for k, v := range schema {
+		clone[k] = v
+	}
+	return clone
+}
@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/internal/cmd/tests"
+	"go.k6.io/k6/internal/lib/testutils"
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/consts"
+)
+
+func TestInspectOptionsSchema(t *testing.T) {
+	t.Parallel()
+
+	ts := tests.NewGlobalTestState(t)
+	ts.CmdArgs = []string{"k6", "inspect", "--options-schema"}
+	ExecuteWithGlobalState(ts.GlobalState)
+
+	var schema struct {
+		Schema     string                     `json:"$schema"`
+		Title      string                     `json:"title"`
+		Properties map[string]json.RawMessage `json:"properties"`
+	}
+	require.NoError(t, json.Unmarshal(ts.Stdout.Bytes(), &schema))
+	assert.Equal(t, jsonSchemaDialect, schema.Schema)
+	assert.Equal(t, "k6 v"+consts.Version+" options", schema.Title)
+
+	// all options are in the schema
+	optionsType := reflect.TypeOf(lib.Options{})
+	for i := range optionsType.NumField() {
+		name, _, _ := strings.Cut(optionsType.Field(i).Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		assert.Contains(t, schema.Properties, name)
+	}
+	assert.JSONEq(t, `{"type": ["integer", "null"], "x-k6-env": "K6_VUS"}`, string(schema.Properties["vus"]))
+	assert.JSONEq(t, `{
+		"type": "array", "x-k6-env": "K6_STAGES",
+		"items": {"type": "object", "properties": {
+			"duration": {"type": ["string", "number", "null"], "description": "A duration like \"1m30s\", or a number of milliseconds."},
+			"target": {"type": ["integer", "null"]}
+		}}
+	}`, string(schema.Properties["stages"]))
+}
+
+func TestInspectOptionsSchemaWithScript(t *testing.T) {
+	t.Parallel()
+
+	ts := tests.NewGlobalTestState(t)
+	ts.CmdArgs = []string{"k6", "inspect", "--options-schema", "script.js"}
+	ts.ExpectedExitCode = -1
+	ExecuteWithGlobalState(ts.GlobalState)
+	assert.Empty(t, ts.Stdout.Bytes())
+	assert.True(t, testutils.LogContains(ts.LoggerHook.Drain(), logrus.ErrorLevel, "the options schema doesn't depend on a script or archive"))
+}
+
+func TestScenariosSchema(t *testing.T) {
+	t.Parallel()
+
+	schema, err := scenariosSchema()
+	require.NoError(t, err)
+
+	data, err := json.Marshal(schema)
+	require.NoError(t, err)
+	var scenarios struct {
+		AdditionalProperties struct {
+			OneOf []struct {
+				Properties           map[string]json.RawMessage `json:"properties"`
+				Required             []string                   `json:"required"`
+				AdditionalProperties bool                       `json:"additionalProperties"`
+			} `json:"oneOf"`
+		} `json:"additionalProperties"`
+	}
+	require.NoError(t, json.Unmarshal(data, &scenarios))
+
+	executors := make(map[string]map[string]json.RawMessage)
+	for _, executor := range scenarios.AdditionalProperties.OneOf {
+		var executorType struct {
+			Const string `json:"const"`
+		}
+		require.NoError(t, json.Unmarshal(executor.Properties["executor"], &executorType))
+		assert.Equal(t, []string{"executor"}, executor.Required)
+		assert.False(t, executor.AdditionalProperties, "the scenario configs are strict")
+		executors[executorType.Const] = executor.Properties
+	}
+	for _, executorType := range lib.GetExecutorConfigTypes() {
+		assert.Contains(t, executors, executorType)
+	}
+
+	rampingVUs := executors["ramping-vus"]
+	for _, field := range []string{"startVUs", "stages", "gracefulRampDown", "gracefulStop", "startTime", "exec", "options"} {
+		assert.Contains(t, rampingVUs, field)
+	}
+	assert.NotContains(t, rampingVUs, "Name", "the fields without JSON are skipped")
+	assert.NotContains(t, executors["constant-vus"], "stages")
+}
@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestParseTagKeyValue(t *testing.T) {
@@ -63,3 +64,18 @@ func TestParseTagKeyValue(t *testing.T) {
 		})
 	}
 }
+
+func TestParseHostsFlag(t *testing.T) {
+	t.Parallel()
+
+	hosts, err := parseHostsFlag([]string{"replica-1.test=10.0.0.1", "replica-2.test=10.0.0.2:8080"})
+	require.NoError(t, err)
+	require.True(t, hosts.Valid)
+	assert.Equal(t, "10.0.0.1:0", hosts.Trie.Match("replica-1.test").String())
+	assert.Equal(t, "10.0.0.2:8080", hosts.Trie.Match("replica-2.test").String())
+
+	_, err = parseHostsFlag([]string{"replica-1.test"})
+	require.ErrorContains(t, err, "invalid host 'replica-1.test'")
+	_, err = parseHostsFlag([]string{"replica-1.test=replica-2.test"})
+	require.ErrorContains(t, err, "invalid address of host 'replica-1.test'")
+}
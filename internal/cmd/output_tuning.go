@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"go.k6.io/k6/output"
+)
+
+// withPrometheusRWTuning sets the push interval of the outputTuning option in
+// the JSON config of the Prometheus remote write output, unless its JSON config
+// or its environment variable sets it. The output doesn't support the other
+// values of the option.
+func withPrometheusRWTuning(params output.Params) (output.Params, error) {
+	name := builtinOutputExperimentalPrometheusRW.String()
+	tuning := params.ScriptOptions.OutputTuning[name]
+	if tuning.MaxBatchSize.Valid || tuning.MaxInFlight.Valid {
+		return params, fmt.Errorf("the %s output supports only the pushInterval of the outputTuning option", name)
+	}
+	if !tuning.PushInterval.Valid {
+		return params, nil
+	}
+	if _, ok := params.Environment["K6_PROMETHEUS_RW_PUSH_INTERVAL"]; ok {
+		return params, nil
+	}
+
+	conf := make(map[string]json.RawMessage)
+	if len(params.JSONConfig) > 0 {
+		if err := json.Unmarshal(params.JSONConfig, &conf); err != nil {
+			return params, fmt.Errorf("parse JSON options failed: %w", err)
+		}
+	}
+	if _, ok := conf["pushInterval"]; ok {
+		return params, nil
+	}
+	pushInterval, err := json.Marshal(tuning.PushInterval)
+	if err != nil {
+		return params, err
+	}
+	conf["pushInterval"] = pushInterval
+	if params.JSONConfig, err = json.Marshal(conf); err != nil {
+		return params, err
+	}
+	return params, nil
+}
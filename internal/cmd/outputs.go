@@ -9,6 +9,7 @@ import (
 	"go.k6.io/k6/cmd/state"
 	"go.k6.io/k6/ext"
 	"go.k6.io/k6/internal/output/cloud"
+	"go.k6.io/k6/internal/output/grafanalive"
 	"go.k6.io/k6/internal/output/influxdb"
 	"go.k6.io/k6/internal/output/json"
 	"go.k6.io/k6/lib"
@@ -37,6 +38,7 @@ const (
 	builtinOutputKafka
 	builtinOutputStatsd
 	builtinOutputExperimentalOpentelemetry
+	builtinOutputExperimentalGrafanaLive
 )
 
 // TODO: move this to an output sub-module after we get rid of the old collectors?
@@ -64,12 +66,17 @@ func getAllOutputConstructors() (map[string]output.Constructor, error) {
 			)
 		},
 		builtinOutputExperimentalPrometheusRW.String(): func(params output.Params) (output.Output, error) {
+			params, err := withPrometheusRWTuning(params)
+			if err != nil {
+				return nil, err
+			}
 			return remotewrite.New(params)
 		},
 		"web-dashboard": dashboard.New,
 		builtinOutputExperimentalOpentelemetry.String(): func(params output.Params) (output.Output, error) {
 			return opentelemetry.New(params)
 		},
+		builtinOutputExperimentalGrafanaLive.String(): grafanalive.New,
 	}
 
 	exts := ext.Get(ext.OutputExtension)
@@ -117,6 +124,7 @@ func createOutputs(
 		RuntimeOptions: test.preInitState.RuntimeOptions,
 		ExecutionPlan:  executionPlan,
 		Usage:          test.preInitState.Usage,
+		Events:         gs.Events,
 	}
 
 	outputs := test.derivedConfig.Out
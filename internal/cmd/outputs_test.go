@@ -1,9 +1,17 @@
 package cmd
 
 import (
+	"encoding/json"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/types"
+	"go.k6.io/k6/output"
 )
 
 func TestBuiltinOutputString(t *testing.T) {
@@ -11,6 +19,43 @@ func TestBuiltinOutputString(t *testing.T) {
 	exp := []string{
 		"cloud", "csv", "datadog", "experimental-prometheus-rw",
 		"influxdb", "json", "kafka", "statsd", "experimental-opentelemetry",
+		"experimental-grafana-live",
 	}
 	assert.Equal(t, exp, builtinOutputStrings())
 }
+
+func TestWithPrometheusRWTuning(t *testing.T) {
+	t.Parallel()
+
+	tuning := func(tuning lib.OutputTuning) lib.Options {
+		return lib.Options{OutputTuning: lib.OutputTunings{"experimental-prometheus-rw": tuning}}
+	}
+	pushInterval := lib.OutputTuning{PushInterval: types.NullDurationFrom(3 * time.Second)}
+
+	params, err := withPrometheusRWTuning(output.Params{
+		ScriptOptions: tuning(pushInterval),
+		JSONConfig:    json.RawMessage(`{"url":"http://localhost:9090"}`),
+	})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"url":"http://localhost:9090","pushInterval":"3s"}`, string(params.JSONConfig))
+
+	// the output's own config takes precedence
+	params, err = withPrometheusRWTuning(output.Params{
+		ScriptOptions: tuning(pushInterval),
+		JSONConfig:    json.RawMessage(`{"pushInterval":"1s"}`),
+	})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"pushInterval":"1s"}`, string(params.JSONConfig))
+
+	params, err = withPrometheusRWTuning(output.Params{
+		ScriptOptions: tuning(pushInterval),
+		Environment:   map[string]string{"K6_PROMETHEUS_RW_PUSH_INTERVAL": "1s"},
+	})
+	require.NoError(t, err)
+	assert.Nil(t, params.JSONConfig)
+
+	_, err = withPrometheusRWTuning(output.Params{
+		ScriptOptions: tuning(lib.OutputTuning{MaxInFlight: null.IntFrom(2)}),
+	})
+	require.ErrorContains(t, err, "supports only the pushInterval of the outputTuning option")
+}
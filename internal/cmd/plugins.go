@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"go.k6.io/k6/cmd/state"
+	"go.k6.io/k6/ext"
+)
+
+// loadPlugins opens the Go plugins from the --plugin flags, whose init()
+// functions register their JS modules and outputs, the same way the
+// extensions built in with xk6 do. Plugins are only supported on Linux,
+// macOS and FreeBSD, by k6 binaries built with cgo, which the official ones
+// aren't, so they need a custom build with CGO_ENABLED=1. The plugins have to
+// be built with the same Go version and versions of the shared dependencies.
+func loadPlugins(gs *state.GlobalState) error {
+	for _, path := range gs.Flags.Plugins {
+		before := ext.GetAll()
+		if err := openPlugin(path); err != nil {
+			return fmt.Errorf("couldn't load the plugin '%s': %w", path, err)
+		}
+
+		registered := newExtensions(before, ext.GetAll())
+		if len(registered) == 0 {
+			return fmt.Errorf("the plugin '%s' didn't register any extensions", path)
+		}
+		gs.Logger.Debugf("Loaded the plugin '%s' with the extensions: %s", path, strings.Join(registered, ", "))
+	}
+	return nil
+}
+
+func newExtensions(before, after []*ext.Extension) []string {
+	existing := make(map[*ext.Extension]bool, len(before))
+	for _, e := range before {
+		existing[e] = true
+	}
+	var result []string
+	for _, e := range after {
+		if !existing[e] {
+			result = append(result, fmt.Sprintf("%s [%s]", e.Name, e.Type))
+		}
+	}
+	return result
+}
@@ -0,0 +1,20 @@
+//go:build cgo && (linux || darwin || freebsd)
+
+package cmd
+
+import (
+	"fmt"
+	"plugin"
+)
+
+// openPlugin opens the plugin, turning the panics of its init() functions,
+// e.g. when an extension with the same name is already registered, into errors.
+func openPlugin(path string) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	_, err = plugin.Open(path)
+	return err
+}
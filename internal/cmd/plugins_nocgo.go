@@ -0,0 +1,11 @@
+//go:build !cgo || !(linux || darwin || freebsd)
+
+package cmd
+
+import "errors"
+
+// openPlugin fails, since the Go plugins can't be opened by this build.
+func openPlugin(string) error {
+	return errors.New("the plugins are not supported in this build of k6, " +
+		"they need a custom build with cgo enabled (CGO_ENABLED=1) on Linux, macOS or FreeBSD")
+}
@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/ext"
+	"go.k6.io/k6/internal/cmd/tests"
+)
+
+func TestLoadPluginsError(t *testing.T) {
+	t.Parallel()
+
+	ts := tests.NewGlobalTestState(t)
+	ts.Flags.Plugins = []string{"/does/not/exist.so"}
+	err := loadPlugins(ts.GlobalState)
+	require.ErrorContains(t, err, "couldn't load the plugin '/does/not/exist.so'")
+}
+
+func TestNewExtensions(t *testing.T) {
+	t.Parallel()
+
+	existing := &ext.Extension{Name: "k6/x/existing", Type: ext.JSExtension}
+	module := &ext.Extension{Name: "k6/x/plugin", Type: ext.JSExtension}
+	output := &ext.Extension{Name: "plugin", Type: ext.OutputExtension}
+
+	assert.Equal(t,
+		[]string{"k6/x/plugin [js]", "plugin [output]"},
+		newExtensions([]*ext.Extension{existing}, []*ext.Extension{existing, module, output}),
+	)
+	assert.Empty(t, newExtensions([]*ext.Extension{existing}, []*ext.Extension{existing}))
+}
@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"path/filepath"
+	"runtime/pprof"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"go.k6.io/k6/lib/fsext"
+)
+
+// profilesWriter periodically writes CPU and heap profiles of the k6 process
+// itself to a directory, so slowdowns of long-running tests can be diagnosed
+// after the fact, without having to catch them live on the pprof endpoints.
+type profilesWriter struct {
+	fs       fsext.Fs
+	dir      string
+	interval time.Duration
+	logger   logrus.FieldLogger
+
+	// cpu is nil if the CPU profiling couldn't be started, e.g. because
+	// something else, like the pprof endpoints, is already doing it
+	cpu *bytes.Buffer
+}
+
+// startProfilesWriter starts writing profiles in the background, until the
+// context is done or the returned function is called. The returned function
+// waits for the last profiles to be written.
+func startProfilesWriter(
+	ctx context.Context, fs fsext.Fs, dir string, interval time.Duration, logger logrus.FieldLogger,
+) (func(), error) {
+	if interval <= 0 {
+		return nil, fmt.Errorf("the profiles interval should be positive, but it was %s", interval)
+	}
+	if err := fs.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("couldn't create the profiles directory: %w", err)
+	}
+
+	pw := &profilesWriter{
+		fs:       fs,
+		dir:      dir,
+		interval: interval,
+		logger:   logger.WithField("component", "profiles"),
+	}
+	pw.startCPU()
+
+	ctx, cancel := context.WithCancel(ctx)
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case t := <-ticker.C:
+				pw.write(t)
+				pw.startCPU()
+			case <-ctx.Done():
+				pw.write(time.Now())
+				return
+			}
+		}
+	}()
+
+	return func() {
+		cancel()
+		wg.Wait()
+	}, nil
+}
+
+func (pw *profilesWriter) startCPU() {
+	buf := &bytes.Buffer{}
+	if err := pprof.StartCPUProfile(buf); err != nil {
+		pw.logger.WithError(err).Debug("Couldn't start the CPU profiling")
+		pw.cpu = nil
+		return
+	}
+	pw.cpu = buf
+}
+
+// write stops the current CPU profiling and writes its profile, together with
+// a heap profile, in files named after the given time.
+func (pw *profilesWriter) write(t time.Time) {
+	suffix := t.UTC().Format("20060102T150405.000Z") + ".pprof"
+
+	if pw.cpu != nil {
+		pprof.StopCPUProfile()
+		pw.writeFile("cpu-"+suffix, pw.cpu.Bytes())
+		pw.cpu = nil
+	}
+
+	heap := &bytes.Buffer{}
+	if err := pprof.Lookup("heap").WriteTo(heap, 0); err != nil {
+		pw.logger.WithError(err).Warn("Couldn't get the heap profile")
+		return
+	}
+	pw.writeFile("heap-"+suffix, heap.Bytes())
+}
+
+func (pw *profilesWriter) writeFile(name string, data []byte) {
+	path := filepath.Join(pw.dir, name)
+	if err := fsext.WriteFile(pw.fs, path, data, 0o600); err != nil {
+		pw.logger.WithError(err).Warnf("Couldn't write the profile %s", path)
+		return
+	}
+	pw.logger.Debugf("Wrote the profile %s", path)
+}
@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/internal/lib/testutils"
+	"go.k6.io/k6/lib/fsext"
+)
+
+func TestProfilesWriter(t *testing.T) {
+	t.Parallel()
+
+	fs := fsext.NewMemMapFs()
+	stop, err := startProfilesWriter(context.Background(), fs, "/profiles", 20*time.Millisecond, testutils.NewLogger(t))
+	require.NoError(t, err)
+	time.Sleep(70 * time.Millisecond)
+	stop()
+
+	files, err := fsext.ReadDir(fs, "/profiles")
+	require.NoError(t, err)
+
+	var heapProfiles int
+	for _, f := range files {
+		assert.True(t, strings.HasSuffix(f.Name(), ".pprof"), f.Name())
+		assert.NotZero(t, f.Size(), f.Name())
+		if strings.HasPrefix(f.Name(), "heap-") {
+			heapProfiles++
+		}
+	}
+	// the CPU profiles might be missing, if another test is profiling
+	assert.GreaterOrEqual(t, heapProfiles, 2)
+}
+
+func TestProfilesWriterInvalidInterval(t *testing.T) {
+	t.Parallel()
+
+	_, err := startProfilesWriter(context.Background(), fsext.NewMemMapFs(), "/profiles", 0, testutils.NewLogger(t))
+	require.ErrorContains(t, err, "should be positive")
+}
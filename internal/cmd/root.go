@@ -60,9 +60,9 @@ func newRootCommand(gs *state.GlobalState) *rootCommand {
 	rootCmd.SetIn(gs.Stdin)
 
 	subCommands := []func(*state.GlobalState) *cobra.Command{
-		getCmdArchive, getCmdCloud, getCmdNewScript, getCmdInspect,
+		getCmdArchive, getCmdCloud, getCmdCorrelate, getCmdNewScript, getCmdInspect,
 		getCmdLogin, getCmdPause, getCmdResume, getCmdScale, getCmdRun,
-		getCmdStats, getCmdStatus, getCmdVersion,
+		getCmdStats, getCmdStatus, getCmdSuite, getCmdVersion,
 	}
 
 	for _, sc := range subCommands {
@@ -73,13 +73,19 @@ func newRootCommand(gs *state.GlobalState) *rootCommand {
 	return c
 }
 
-func (c *rootCommand) persistentPreRunE(_ *cobra.Command, _ []string) error {
+func (c *rootCommand) persistentPreRunE(cmd *cobra.Command, _ []string) error {
 	err := c.setupLoggers(c.stopLoggersCh)
 	if err != nil {
 		return err
 	}
+	if val, ok := c.globalState.Env["K6_PROFILES_INTERVAL"]; ok && !cmd.Flags().Changed("profiles-interval") {
+		if _, perr := time.ParseDuration(val); perr != nil {
+			return errext.WithExitCodeIfNone(
+				fmt.Errorf("invalid K6_PROFILES_INTERVAL %q: %w", val, perr), exitcodes.InvalidConfig)
+		}
+	}
 	c.globalState.Logger.Debugf("k6 version: v%s", fullVersion())
-	return nil
+	return loadPlugins(c.globalState)
 }
 
 func (c *rootCommand) execute() {
@@ -163,12 +169,26 @@ func rootCmdPersistentFlagSet(gs *state.GlobalState) *pflag.FlagSet {
 	// not messed up...
 
 	flags.StringVar(&gs.Flags.LogOutput, "log-output", gs.Flags.LogOutput,
-		"change the output for k6 logs, possible values are stderr,stdout,none,loki[=host:port],file[=./path.fileformat]")
+		"change the output for k6 logs, possible values are "+
+			"stderr,stdout,none,loki[=host:port],otel[=url],file[=./path.fileformat]")
 	flags.Lookup("log-output").DefValue = gs.DefaultFlags.LogOutput
 
 	flags.StringVar(&gs.Flags.LogFormat, "log-format", gs.Flags.LogFormat, "log output format")
 	flags.Lookup("log-format").DefValue = gs.DefaultFlags.LogFormat
 
+	flags.StringVar(&gs.Flags.LogLevels, "log-levels", gs.Flags.LogLevels,
+		"override the log level of the log lines from the given sources, e.g. console=debug,http-debug=warn")
+	flags.Lookup("log-levels").DefValue = gs.DefaultFlags.LogLevels
+
+	flags.IntVar(&gs.Flags.LogRateLimit, "log-rate-limit", gs.Flags.LogRateLimit,
+		"the maximum number of log lines per second from each VU, the rest are dropped, 0 for no limit")
+	flags.Lookup("log-rate-limit").DefValue = strconv.Itoa(gs.DefaultFlags.LogRateLimit)
+
+	flags.StringArrayVar(&gs.Flags.Plugins, "plugin", gs.Flags.Plugins,
+		"load the JS modules and outputs of the Go plugin at the given `path`, can be used more than once, "+
+			"only by the k6 builds with cgo enabled")
+	flags.Lookup("plugin").DefValue = "[]"
+
 	flags.StringVarP(&gs.Flags.ConfigFilePath, "config", "c", gs.Flags.ConfigFilePath, "JSON config file")
 	// And we also need to explicitly set the default value for the usage message here, so things
 	// like `K6_CONFIG="blah" k6 run -h` don't produce a weird usage message
@@ -189,6 +209,20 @@ func rootCmdPersistentFlagSet(gs *state.GlobalState) *pflag.FlagSet {
 		gs.DefaultFlags.ProfilingEnabled,
 		"enable profiling (pprof) endpoints, k6's REST API should be enabled as well",
 	)
+	flags.StringVar(
+		&gs.Flags.ProfilesDir,
+		"profiles-dir",
+		gs.Flags.ProfilesDir,
+		"periodically write CPU and heap profiles (pprof) of k6 itself in the given `directory`",
+	)
+	flags.Lookup("profiles-dir").DefValue = gs.DefaultFlags.ProfilesDir
+	flags.DurationVar(
+		&gs.Flags.ProfilesInterval,
+		"profiles-interval",
+		gs.Flags.ProfilesInterval,
+		"how often profiles are written in the --profiles-dir directory",
+	)
+	flags.Lookup("profiles-interval").DefValue = gs.DefaultFlags.ProfilesInterval.String()
 
 	return flags
 }
@@ -231,6 +265,12 @@ func (c *rootCommand) setupLoggers(stop <-chan struct{}) error {
 			return err
 		}
 		c.globalState.Flags.LogFormat = "raw"
+	case strings.HasPrefix(line, "otel"):
+		c.loggerIsRemote = true
+		hook, err = log.OTelFromConfigLine(c.globalState.FallbackLogger, line)
+		if err != nil {
+			return err
+		}
 	case strings.HasPrefix(line, "file"):
 		hook, err = log.FileHookFromConfigLine(
 			c.globalState.FS, c.globalState.Getwd,
@@ -257,6 +297,21 @@ func (c *rootCommand) setupLoggers(stop <-chan struct{}) error {
 		c.globalState.Logger.Debug("Logger format: TEXT")
 	}
 
+	if c.globalState.Flags.LogLevels != "" || c.globalState.Flags.LogRateLimit != 0 {
+		filter, err := log.NewFilter(
+			c.globalState.Logger.GetLevel(), c.globalState.Flags.LogLevels, c.globalState.Flags.LogRateLimit,
+		)
+		if err != nil {
+			return err
+		}
+		c.globalState.Logger.SetLevel(filter.MaxLevel())
+		if hook != nil {
+			hook = filter.Hook(hook)
+		} else {
+			c.globalState.Logger.SetFormatter(filter.Formatter(c.globalState.Logger.Formatter))
+		}
+	}
+
 	cancel := func() {} // noop as default
 	if hook != nil {
 		ctx := context.Background()
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"go.k6.io/k6/errext/exitcodes"
@@ -88,3 +89,65 @@ func TestRootCommandHelpDisplayCommands(t *testing.T) {
 		})
 	}
 }
+
+func TestRootCommandProfilesInterval(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name         string
+		env          string
+		envInterval  time.Duration // the interval, which is parsed from env by state.NewGlobalState
+		extraArgs    []string
+		wantExitCode exitcodes.ExitCode
+		wantInterval time.Duration
+	}{
+		{
+			name:         "default",
+			wantInterval: time.Minute,
+		},
+		{
+			name:         "env",
+			env:          "30s",
+			envInterval:  30 * time.Second,
+			wantInterval: 30 * time.Second,
+		},
+		{
+			name:         "flag overrides env",
+			env:          "30s",
+			envInterval:  30 * time.Second,
+			extraArgs:    []string{"--profiles-interval", "10s"},
+			wantInterval: 10 * time.Second,
+		},
+		{
+			name:         "invalid env",
+			env:          "often",
+			envInterval:  -1,
+			wantExitCode: exitcodes.InvalidConfig,
+			wantInterval: -1,
+		},
+		{
+			name:         "flag overrides invalid env",
+			env:          "often",
+			envInterval:  -1,
+			extraArgs:    []string{"--profiles-interval", "10s"},
+			wantInterval: 10 * time.Second,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ts := tests.NewGlobalTestState(t)
+			if tc.env != "" {
+				ts.Env["K6_PROFILES_INTERVAL"] = tc.env
+				ts.Flags.ProfilesInterval = tc.envInterval
+			}
+			ts.ExpectedExitCode = int(tc.wantExitCode)
+			ts.CmdArgs = append([]string{"k6", "version"}, tc.extraArgs...)
+			newRootCommand(ts.GlobalState).execute()
+
+			assert.Equal(t, tc.wantInterval, ts.Flags.ProfilesInterval)
+		})
+	}
+}
@@ -7,6 +7,8 @@ import (
 	"io"
 	"net/http"
 	"os"
+	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 	"syscall"
@@ -28,6 +30,7 @@ import (
 	"go.k6.io/k6/internal/ui/pb"
 	"go.k6.io/k6/js/common"
 	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/consts"
 	"go.k6.io/k6/lib/fsext"
 	"go.k6.io/k6/metrics"
 	"go.k6.io/k6/output"
@@ -78,7 +81,7 @@ func (c *cmdRun) run(cmd *cobra.Command, args []string) (err error) {
 	// runCtx is used for the test run execution and is created with the special
 	// execution.NewTestRunContext() function so that it can be aborted even
 	// from sub-contexts while also attaching a reason for the abort.
-	runCtx, runAbort := execution.NewTestRunContext(lingerCtx, logger)
+	runCtx, runAbort := execution.NewTestRunContext(lingerCtx, logger, c.gs.Events)
 
 	emitEvent := func(evt *event.Event) func() {
 		waitDone := c.gs.Events.Emit(evt)
@@ -104,6 +107,12 @@ func (c *cmdRun) run(cmd *cobra.Command, args []string) (err error) {
 	if err != nil {
 		return err
 	}
+	defer test.removeExtractedFiles(logger)
+	if path := getTimelineFlag(cmd.Flags(), "replay-timeline"); path != "" {
+		if err = replayTimeline(c.gs, path, test); err != nil {
+			return err
+		}
+	}
 	if test.keyLogger != nil {
 		defer func() {
 			if klErr := test.keyLogger.Close(); klErr != nil {
@@ -129,6 +138,14 @@ func (c *cmdRun) run(cmd *cobra.Command, args []string) (err error) {
 	if err != nil {
 		return err
 	}
+	artifactsDir := conf.ArtifactsDir.String
+	if artifactsDir == "" {
+		artifactsDir = lib.DefaultArtifactsDir
+	}
+	if !filepath.IsAbs(artifactsDir) {
+		artifactsDir = filepath.Join(test.pwd, artifactsDir)
+	}
+	testRunState.Artifacts = lib.NewArtifacts(c.gs.FS, artifactsDir, time.Now())
 
 	// Create a local execution scheduler wrapping the runner.
 	logger.Debug("Initializing the execution scheduler...")
@@ -140,6 +157,16 @@ func (c *cmdRun) run(cmd *cobra.Command, args []string) (err error) {
 	backgroundProcesses := &sync.WaitGroup{}
 	defer backgroundProcesses.Wait()
 
+	if c.gs.Flags.ProfilesDir != "" {
+		stopProfiles, perr := startProfilesWriter(
+			globalCtx, c.gs.FS, c.gs.Flags.ProfilesDir, c.gs.Flags.ProfilesInterval, logger,
+		)
+		if perr != nil {
+			return perr
+		}
+		defer stopProfiles()
+	}
+
 	// This is manually triggered after the Engine's Run() has completed,
 	// and things like a single Ctrl+C don't affect it. We use it to make
 	// sure that the progressbars finish updating with the latest execution
@@ -158,8 +185,15 @@ func (c *cmdRun) run(cmd *cobra.Command, args []string) (err error) {
 		showProgress(progressCtx, c.gs, pbs, logger)
 	}()
 
-	// Create all outputs.
 	executionPlan := execScheduler.GetExecutionPlan()
+	if noPrecheck, _ := cmd.Flags().GetBool("no-resource-precheck"); !noPrecheck {
+		req := execution.EstimateResourceRequirements(lib.GetMaxPossibleVUs(executionPlan), conf.Options)
+		if err = execution.CheckResources(req, logger); err != nil {
+			return err
+		}
+	}
+
+	// Create all outputs.
 	outputs, err := createOutputs(c.gs, test, executionPlan)
 	if err != nil {
 		return err
@@ -171,11 +205,16 @@ func (c *cmdRun) run(cmd *cobra.Command, args []string) (err error) {
 	if err != nil {
 		return err
 	}
+	if conf.TrendCompression.Valid {
+		metricsEngine.SetTrendCompression(conf.TrendCompression.Float64)
+	}
+	metricsEngine.SetEventSystem(c.gs.Events)
 
 	// We'll need to pipe metrics to the MetricsEngine and process them if any
 	// of these are enabled: thresholds, end-of-test summary
+	profileMetricsMemory, _ := cmd.Flags().GetBool("profile-metrics-memory")
 	shouldProcessMetrics := (!testRunState.RuntimeOptions.NoSummary.Bool ||
-		!testRunState.RuntimeOptions.NoThresholds.Bool)
+		!testRunState.RuntimeOptions.NoThresholds.Bool || profileMetricsMemory)
 	var metricsIngester *engine.OutputIngester
 	if shouldProcessMetrics {
 		err = metricsEngine.InitSubMetricsAndThresholds(conf.Options, testRunState.RuntimeOptions.NoThresholds.Bool)
@@ -185,10 +224,51 @@ func (c *cmdRun) run(cmd *cobra.Command, args []string) (err error) {
 		// We'll need to pipe metrics to the MetricsEngine if either the
 		// thresholds or the end-of-test summary are enabled.
 		metricsIngester = metricsEngine.CreateIngester()
+		if profileMetricsMemory {
+			metricsIngester.EnableMemoryProfile()
+		}
 		outputs = append(outputs, metricsIngester)
+		if !testRunState.RuntimeOptions.NoSummary.Bool && test.initRunner.IsExecutable(consts.HandleSummaryFn) {
+			metricsEngine.EnableScenarioMetrics()
+		}
+		if interval := summaryExportInterval(testRunState.RuntimeOptions); interval > 0 {
+			metricsEngine.EnableTimeBuckets(interval)
+		}
 	}
 
 	executionState := execScheduler.GetState()
+	if path := getTimelineFlag(cmd.Flags(), "export-timeline"); path != "" {
+		scenarios := make([]string, 0, len(conf.Scenarios))
+		for name := range conf.Scenarios {
+			scenarios = append(scenarios, name)
+		}
+		executionState.Timeline = lib.NewTimeline(scenarios...)
+		defer func() {
+			logger.Debug("Exporting the timeline...")
+			if tErr := exportTimeline(c.gs, path, executionState.Timeline); tErr != nil {
+				logger.WithError(tErr).Error("Failed to export the timeline")
+			}
+		}()
+	}
+	// this runs after the summary, so it can be written to the artifacts
+	defer func() {
+		if !testRunState.Artifacts.Written() {
+			return
+		}
+		logger.Infof("The artifacts of the test run were written to %s", testRunState.Artifacts.Dir())
+		if command := testRunState.RuntimeOptions.ArtifactsUploadCommand.String; command != "" {
+			logger.Debug("Uploading the artifacts...")
+			if uErr := uploadArtifacts(globalCtx, c.gs, command, testRunState.Artifacts); uErr != nil {
+				logger.WithError(uErr).Error("Uploading the artifacts failed")
+			}
+		}
+	}()
+	if profileMetricsMemory {
+		// this runs after the summary, since the outputs have to be stopped
+		defer func() {
+			printToStdout(c.gs, metricsIngester.MemoryProfileReport())
+		}()
+	}
 	if !testRunState.RuntimeOptions.NoSummary.Bool {
 		defer func() {
 			logger.Debug("Generating the end-of-test summary...")
@@ -201,6 +281,11 @@ func (c *cmdRun) run(cmd *cobra.Command, args []string) (err error) {
 					IsStdOutTTY: c.gs.Stdout.IsTTY,
 					IsStdErrTTY: c.gs.Stderr.IsTTY,
 				},
+				ArtifactsDir: artifactsDirIfWritten(testRunState.Artifacts),
+				StartTime:    executionState.GetStartTime(),
+				Scenarios:    summarizeScenarios(execScheduler.GetExecutorConfigs(), metricsEngine.ScenarioMetrics),
+				TimeBuckets: summarizeTimeBuckets(
+					metricsEngine.TimeBuckets, summaryExportInterval(testRunState.RuntimeOptions)),
 			})
 			if hsErr == nil {
 				hsErr = handleSummaryResult(c.gs.FS, c.gs.Stdout, c.gs.Stderr, summaryResult)
@@ -224,6 +309,12 @@ func (c *cmdRun) run(cmd *cobra.Command, args []string) (err error) {
 		// TODO: attach run status and exit code?
 		runAbort(err)
 	})
+	for name := range conf.MetricSystemTags {
+		if testRunState.Registry.Get(name) == nil {
+			logger.Warnf("The metricSystemTags option has system tags for the unknown metric %q", name)
+		}
+	}
+	outputManager.SetMetricSystemTags(conf.MetricSystemTags)
 	samples := make(chan metrics.SampleContainer, test.derivedConfig.MetricSamplesBufferSize.Int64)
 	// Spin up the REST API server, if not disabled.
 	if c.gs.Flags.Address != "" { //nolint:nestif
@@ -440,6 +531,11 @@ func (c *cmdRun) flagSet() *pflag.FlagSet {
 	flags.AddFlagSet(optionFlagSet())
 	flags.AddFlagSet(runtimeOptionFlagSet(true))
 	flags.AddFlagSet(configFlagSet())
+	flags.AddFlagSet(timelineFlagSet())
+	flags.Bool("profile-metrics-memory", false,
+		"report the estimated memory of the time series of each metric and tag at the end of the test")
+	flags.Bool("no-resource-precheck", false,
+		"don't check that the open files limit and the available memory are enough for the VUs before the test")
 	return flags
 }
 
@@ -529,3 +625,48 @@ func handleSummaryResult(fs fsext.Fs, stdOut, stdErr io.Writer, result map[strin
 
 	return consolidateErrorMessage(errs, "Could not save some summary information:")
 }
+
+// summarizeScenarios returns the data of the scenarios for the summary handler,
+// with the metrics aggregated from their samples, if there are any.
+func summarizeScenarios(
+	configs []lib.ExecutorConfig, scenarioMetrics map[string]map[string]*metrics.Metric,
+) map[string]lib.ScenarioSummary {
+	scenarios := make(map[string]lib.ScenarioSummary, len(configs))
+	for _, config := range configs {
+		scenarios[config.GetName()] = lib.ScenarioSummary{
+			Executor:  config.GetType(),
+			StartTime: config.GetStartTime(),
+			Metrics:   scenarioMetrics[config.GetName()],
+		}
+	}
+	return scenarios
+}
+
+// summaryExportInterval returns the interval of the time buckets of the summary
+// export, or 0 if the summary isn't exported or they weren't enabled.
+func summaryExportInterval(opts lib.RuntimeOptions) time.Duration {
+	if opts.NoSummary.Bool || opts.SummaryExport.String == "" {
+		return 0
+	}
+	return opts.SummaryExportInterval.TimeDuration()
+}
+
+// summarizeTimeBuckets returns the time buckets for the summary handler,
+// sorted by their start times.
+func summarizeTimeBuckets(
+	buckets map[time.Time]map[string]*metrics.Metric, interval time.Duration,
+) []lib.TimeBucketSummary {
+	if len(buckets) == 0 {
+		return nil
+	}
+	summaries := make([]lib.TimeBucketSummary, 0, len(buckets))
+	for start, bucketMetrics := range buckets {
+		summaries = append(summaries, lib.TimeBucketSummary{
+			StartTime: start,
+			Duration:  interval,
+			Metrics:   bucketMetrics,
+		})
+	}
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].StartTime.Before(summaries[j].StartTime) })
+	return summaries
+}
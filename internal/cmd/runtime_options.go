@@ -10,6 +10,7 @@ import (
 
 	"go.k6.io/k6/cmd/state"
 	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/types"
 )
 
 // TODO: move this whole file out of the cmd package? maybe when fixing
@@ -36,8 +37,12 @@ extended: base + sets "global" as alias for "globalThis"
 		"",
 		"output the end-of-test summary report to JSON file",
 	)
+	flags.Duration("summary-export-interval", 0,
+		"aggregate the key metrics of the summary export in time buckets of this `duration`")
 	flags.String("traces-output", "none",
 		"set the output for k6 traces, possible values are none,otel[=host:port]")
+	flags.String("artifacts-upload-command", "",
+		"run the `command` uploading the artifacts after the test run, with their directory in K6_ARTIFACTS_DIR")
 	return flags
 }
 
@@ -61,14 +66,16 @@ func getRuntimeOptions(flags *pflag.FlagSet, environment map[string]string) (lib
 	// TODO: refactor with composable helpers as a part of #883, to reduce copy-paste
 	// TODO: get these options out of the JSON config file as well?
 	opts := lib.RuntimeOptions{
-		TestType:             getNullString(flags, "type"),
-		IncludeSystemEnvVars: getNullBool(flags, "include-system-env-vars"),
-		CompatibilityMode:    getNullString(flags, "compatibility-mode"),
-		NoThresholds:         getNullBool(flags, "no-thresholds"),
-		NoSummary:            getNullBool(flags, "no-summary"),
-		SummaryExport:        getNullString(flags, "summary-export"),
-		TracesOutput:         getNullString(flags, "traces-output"),
-		Env:                  make(map[string]string),
+		TestType:               getNullString(flags, "type"),
+		IncludeSystemEnvVars:   getNullBool(flags, "include-system-env-vars"),
+		CompatibilityMode:      getNullString(flags, "compatibility-mode"),
+		NoThresholds:           getNullBool(flags, "no-thresholds"),
+		NoSummary:              getNullBool(flags, "no-summary"),
+		SummaryExport:          getNullString(flags, "summary-export"),
+		SummaryExportInterval:  getNullDuration(flags, "summary-export-interval"),
+		TracesOutput:           getNullString(flags, "traces-output"),
+		ArtifactsUploadCommand: getNullString(flags, "artifacts-upload-command"),
+		Env:                    make(map[string]string),
 	}
 
 	if envVar, ok := environment["K6_TYPE"]; ok && !opts.TestType.Valid {
@@ -100,6 +107,17 @@ func getRuntimeOptions(flags *pflag.FlagSet, environment map[string]string) (lib
 		}
 	}
 
+	if envVar, ok := environment["K6_SUMMARY_EXPORT_INTERVAL"]; ok {
+		interval, err := types.ParseExtendedDuration(envVar)
+		if err != nil {
+			return opts, fmt.Errorf("env var 'K6_SUMMARY_EXPORT_INTERVAL' is not a valid duration: %w", err)
+		}
+		// Only override if not explicitly set via the CLI flag
+		if !opts.SummaryExportInterval.Valid {
+			opts.SummaryExportInterval = types.NullDurationFrom(interval)
+		}
+	}
+
 	if envVar, ok := environment["SSLKEYLOGFILE"]; ok {
 		if !opts.KeyWriter.Valid {
 			opts.KeyWriter = null.StringFrom(envVar)
@@ -112,6 +130,12 @@ func getRuntimeOptions(flags *pflag.FlagSet, environment map[string]string) (lib
 		}
 	}
 
+	if envVar, ok := environment["K6_ARTIFACTS_UPLOAD_COMMAND"]; ok {
+		if !opts.ArtifactsUploadCommand.Valid {
+			opts.ArtifactsUploadCommand = null.StringFrom(envVar)
+		}
+	}
+
 	if opts.IncludeSystemEnvVars.Bool { // If enabled, gather the actual system environment variables
 		opts.Env = environment
 	}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/url"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -15,6 +16,7 @@ import (
 	"go.k6.io/k6/internal/usage"
 	"go.k6.io/k6/lib"
 	"go.k6.io/k6/lib/fsext"
+	"go.k6.io/k6/lib/types"
 	"go.k6.io/k6/metrics"
 )
 
@@ -367,6 +369,36 @@ func TestRuntimeOptions(t *testing.T) {
 				TracesOutput:         defaultTracesOutput,
 			},
 		},
+		"summary export interval from env overwritten by CLI": {
+			useSysEnv: false,
+			systemEnv: map[string]string{"K6_SUMMARY_EXPORT": "foo", "K6_SUMMARY_EXPORT_INTERVAL": "30s"},
+			cliFlags:  []string{"--summary-export-interval", "10s"},
+			expRTOpts: lib.RuntimeOptions{
+				IncludeSystemEnvVars:  null.NewBool(false, false),
+				CompatibilityMode:     defaultCompatMode,
+				Env:                   map[string]string{},
+				SummaryExport:         null.NewString("foo", true),
+				SummaryExportInterval: types.NullDurationFrom(10 * time.Second),
+				TracesOutput:          defaultTracesOutput,
+			},
+		},
+		"summary export interval from env": {
+			useSysEnv: false,
+			systemEnv: map[string]string{"K6_SUMMARY_EXPORT_INTERVAL": "30s"},
+			expRTOpts: lib.RuntimeOptions{
+				IncludeSystemEnvVars:  null.NewBool(false, false),
+				CompatibilityMode:     defaultCompatMode,
+				Env:                   map[string]string{},
+				SummaryExportInterval: types.NullDurationFrom(30 * time.Second),
+				TracesOutput:          defaultTracesOutput,
+			},
+		},
+		"env var error detected even when CLI flags overwrite 3": {
+			useSysEnv: false,
+			systemEnv: map[string]string{"K6_SUMMARY_EXPORT_INTERVAL": "soon"},
+			cliFlags:  []string{"--summary-export-interval", "10s"},
+			expErr:    true,
+		},
 		"env var error detected even when CLI flags overwrite 1": {
 			useSysEnv: false,
 			systemEnv: map[string]string{"K6_NO_THRESHOLDS": "boo"},
@@ -409,6 +441,18 @@ func TestRuntimeOptions(t *testing.T) {
 				TracesOutput:         null.NewString("bar", true),
 			},
 		},
+		"artifacts upload command from env overwritten by CLI": {
+			useSysEnv: false,
+			systemEnv: map[string]string{"K6_ARTIFACTS_UPLOAD_COMMAND": "foo"},
+			cliFlags:  []string{"--artifacts-upload-command", "bar"},
+			expRTOpts: lib.RuntimeOptions{
+				IncludeSystemEnvVars:   null.NewBool(false, false),
+				CompatibilityMode:      defaultCompatMode,
+				Env:                    map[string]string{},
+				TracesOutput:           defaultTracesOutput,
+				ArtifactsUploadCommand: null.NewString("bar", true),
+			},
+		},
 	}
 	for name, tc := range runtimeOptionsTestCases {
 		tc := tc
@@ -0,0 +1,358 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"go.k6.io/k6/cmd/state"
+	"go.k6.io/k6/errext"
+	"go.k6.io/k6/errext/exitcodes"
+	"go.k6.io/k6/internal/event"
+	"go.k6.io/k6/lib/fsext"
+)
+
+// The statuses of the tests of a suite.
+const (
+	suiteTestPassed  = "passed"
+	suiteTestFailed  = "failed"
+	suiteTestAborted = "aborted"
+	suiteTestSkipped = "skipped"
+)
+
+// suiteConfig is a test suite, i.e. the named tests, which `k6 suite` runs
+// one after the other or in parallel.
+type suiteConfig struct {
+	Tests []suiteTest `json:"tests"`
+	// Parallel runs all the tests at the same time, instead of in the order
+	// they're declared.
+	Parallel bool `json:"parallel"`
+	// AbortOnFail skips the remaining tests, or aborts the running ones, when
+	// a test fails.
+	AbortOnFail bool `json:"abortOnFail"`
+}
+
+// suiteTest is a test of a suite, i.e. a script or an archive with its own
+// options, which is run like `k6 run [flags] script`.
+type suiteTest struct {
+	Name string `json:"name"`
+	// Script is relative to the directory of the suite file.
+	Script string            `json:"script"`
+	Flags  []string          `json:"flags"`
+	Env    map[string]string `json:"env"`
+}
+
+// suiteTestResult is the outcome of a test of a suite.
+type suiteTestResult struct {
+	Name       string  `json:"name"`
+	Script     string  `json:"script"`
+	Status     string  `json:"status"`
+	ExitCode   int     `json:"exitCode"`
+	DurationMs float64 `json:"durationMs"`
+	Error      string  `json:"error,omitempty"`
+}
+
+func (r suiteTestResult) passed() bool {
+	return r.Status == suiteTestPassed || r.Status == suiteTestSkipped
+}
+
+// readSuiteConfig reads and validates the suite file. The scripts of its
+// tests are resolved relative to the directory of the file.
+func readSuiteConfig(fs fsext.Fs, path string) (suiteConfig, error) {
+	var conf suiteConfig
+	data, err := fsext.ReadFile(fs, path)
+	if err != nil {
+		return conf, fmt.Errorf("couldn't read the suite file: %w", err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err = dec.Decode(&conf); err != nil {
+		return conf, fmt.Errorf("couldn't parse the suite file %s: %w", path, err)
+	}
+
+	if len(conf.Tests) == 0 {
+		return conf, fmt.Errorf("the suite file %s doesn't have any tests", path)
+	}
+	names := make(map[string]bool, len(conf.Tests))
+	for i, test := range conf.Tests {
+		if test.Script == "" {
+			return conf, fmt.Errorf("test #%d of the suite doesn't have a script", i+1)
+		}
+		if test.Name == "" {
+			test.Name = test.Script
+		}
+		if names[test.Name] {
+			return conf, fmt.Errorf("the suite has more than one test named %q", test.Name)
+		}
+		names[test.Name] = true
+		if !filepath.IsAbs(test.Script) {
+			test.Script = filepath.Join(filepath.Dir(path), test.Script)
+		}
+		conf.Tests[i] = test
+	}
+	return conf, nil
+}
+
+// cmdSuite handles the `k6 suite` sub-command
+type cmdSuite struct {
+	gs *state.GlobalState
+
+	summaryExport string
+}
+
+func (c *cmdSuite) run(_ *cobra.Command, args []string) error {
+	pwd, err := c.gs.Getwd()
+	if err != nil {
+		return err
+	}
+	suitePath := args[0]
+	if !filepath.IsAbs(suitePath) {
+		suitePath = filepath.Join(pwd, suitePath)
+	}
+	conf, err := readSuiteConfig(c.gs.FS, suitePath)
+	if err != nil {
+		return err
+	}
+
+	ctx, abort := context.WithCancel(c.gs.Ctx)
+	defer abort()
+	// failed returns the status of a failed test, which is aborted if the
+	// suite was already aborted, and aborts the suite if it has to.
+	failed := func() string {
+		if ctx.Err() != nil {
+			return suiteTestAborted
+		}
+		if conf.AbortOnFail {
+			abort()
+		}
+		return suiteTestFailed
+	}
+
+	start := time.Now()
+	results := make([]suiteTestResult, len(conf.Tests))
+	if conf.Parallel {
+		wg := sync.WaitGroup{}
+		for i, test := range conf.Tests {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				results[i] = c.runTest(ctx, test, true)
+				if results[i].Status == suiteTestFailed {
+					results[i].Status = failed()
+				}
+			}()
+		}
+		wg.Wait()
+	} else {
+		for i, test := range conf.Tests {
+			if ctx.Err() != nil {
+				results[i] = suiteTestResult{Name: test.Name, Script: test.Script, Status: suiteTestSkipped}
+				continue
+			}
+			if !c.gs.Flags.Quiet {
+				printToStdout(c.gs, fmt.Sprintf("\n  test %s (%d/%d): %s\n", test.Name, i+1, len(conf.Tests), test.Script))
+			}
+			results[i] = c.runTest(ctx, test, false)
+			if results[i].Status == suiteTestFailed {
+				results[i].Status = failed()
+			}
+		}
+	}
+
+	printToStdout(c.gs, formatSuiteSummary(results, time.Since(start)))
+	if c.summaryExport != "" {
+		if err = c.exportSummary(pwd, results); err != nil {
+			return err
+		}
+	}
+
+	var notPassed int
+	var firstFailure *suiteTestResult
+	for i, r := range results {
+		if r.passed() {
+			continue
+		}
+		notPassed++
+		if firstFailure == nil || (firstFailure.Status == suiteTestAborted && r.Status == suiteTestFailed) {
+			firstFailure = &results[i]
+		}
+	}
+	if firstFailure == nil {
+		return nil
+	}
+	err = fmt.Errorf("%d of the %d tests of the suite didn't pass", notPassed, len(results))
+	if firstFailure.ExitCode > 0 {
+		err = errext.WithExitCodeIfNone(err, exitcodes.ExitCode(firstFailure.ExitCode))
+	}
+	return err
+}
+
+// runTest runs the test like `k6 run` would, with its own copy of the global
+// state, so the tests don't interfere with each other.
+func (c *cmdSuite) runTest(ctx context.Context, test suiteTest, parallel bool) suiteTestResult {
+	gs := *c.gs
+	gs.Ctx = ctx
+	gs.Events = event.NewEventSystem(100, c.gs.Logger)
+	// the REST API can't be shared by the tests, and it isn't needed to
+	// control them, since the suite is what's run
+	gs.Flags.Address = ""
+	if parallel {
+		// the progress bars of the tests would overwrite each other
+		gs.Flags.Quiet = true
+	}
+
+	args := append([]string{}, test.Flags...)
+	envKeys := make([]string, 0, len(test.Env))
+	for k := range test.Env {
+		envKeys = append(envKeys, k)
+	}
+	sort.Strings(envKeys)
+	for _, k := range envKeys {
+		args = append(args, "--env", k+"="+test.Env[k])
+	}
+	args = append(args, test.Script)
+
+	runCmd := getCmdRun(&gs)
+	runCmd.SetArgs(args)
+	runCmd.SetOut(gs.Stdout)
+	runCmd.SetErr(gs.Stderr)
+	runCmd.SilenceUsage = true
+	runCmd.SilenceErrors = true
+
+	start := time.Now()
+	err := runCmd.Execute()
+	result := suiteTestResult{
+		Name:       test.Name,
+		Script:     test.Script,
+		Status:     suiteTestPassed,
+		DurationMs: float64(time.Since(start)) / float64(time.Millisecond),
+	}
+	if err == nil {
+		return result
+	}
+
+	result.Status = suiteTestFailed
+	result.ExitCode = -1
+	var ecerr errext.HasExitCode
+	if errors.As(err, &ecerr) {
+		result.ExitCode = int(ecerr.ExitCode())
+	}
+	errText, fields := errext.Format(err)
+	result.Error = errText
+	c.gs.Logger.WithFields(fields).WithField("test", test.Name).Error(errText)
+	return result
+}
+
+func (c *cmdSuite) exportSummary(pwd string, results []suiteTestResult) error {
+	passed := true
+	for _, r := range results {
+		passed = passed && r.passed()
+	}
+	data, err := json.MarshalIndent(struct {
+		Passed bool              `json:"passed"`
+		Tests  []suiteTestResult `json:"tests"`
+	}{passed, results}, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := c.summaryExport
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(pwd, path)
+	}
+	return fsext.WriteFile(c.gs.FS, path, append(data, '\n'), 0o644)
+}
+
+// formatSuiteSummary returns the summary of the suite, with the status of
+// each test.
+func formatSuiteSummary(results []suiteTestResult, duration time.Duration) string {
+	counts := make(map[string]int)
+	for _, r := range results {
+		counts[r.Status]++
+	}
+	buf := &strings.Builder{}
+	fmt.Fprintf(buf, "\n     suite: %d passed, %d failed, %d aborted, %d skipped in %s\n\n",
+		counts[suiteTestPassed], counts[suiteTestFailed], counts[suiteTestAborted], counts[suiteTestSkipped],
+		duration.Round(100*time.Millisecond))
+
+	tw := tabwriter.NewWriter(buf, 0, 0, 3, ' ', 0)
+	for _, r := range results {
+		mark := "✗"
+		switch r.Status {
+		case suiteTestPassed:
+			mark = "✓"
+		case suiteTestSkipped:
+			mark = "-"
+		}
+		details := ""
+		if r.Status != suiteTestSkipped {
+			details = (time.Duration(r.DurationMs) * time.Millisecond).Round(100 * time.Millisecond).String()
+		}
+		if r.ExitCode > 0 {
+			details += fmt.Sprintf(", exit code %d", r.ExitCode)
+		}
+		fmt.Fprintf(tw, "     %s %s\t%s\t%s\n", mark, r.Name, r.Status, details)
+	}
+	_ = tw.Flush()
+	return buf.String()
+}
+
+func (c *cmdSuite) flagSet() *pflag.FlagSet {
+	flags := pflag.NewFlagSet("", pflag.ContinueOnError)
+	flags.SortFlags = false
+	flags.StringVar(&c.summaryExport, "summary-export", "",
+		"output the results of the tests of the suite to a JSON file")
+	return flags
+}
+
+func getCmdSuite(gs *state.GlobalState) *cobra.Command {
+	c := &cmdSuite{gs: gs}
+
+	exampleText := getExampleText(gs, `
+  # Run the tests of a suite, e.g. smoke, load and soak tests, one after the other.
+  {{.}} suite suite.json
+
+  # Export the results of the tests.
+  {{.}} suite --summary-export results.json suite.json`[1:])
+
+	suiteCmd := &cobra.Command{
+		Use:   "suite",
+		Short: "Run a test suite",
+		Long: `Run a test suite.
+
+A suite is a JSON file with named tests, i.e. scripts or archives with their
+own options, which are run like with "k6 run", either one after the other or
+in parallel:
+
+  {
+    "tests": [
+      {"name": "smoke", "script": "smoke.js", "flags": ["--vus", "1"]},
+      {"name": "load", "script": "load.js", "env": {"BASE_URL": "https://test.k6.io"}}
+    ],
+    "parallel": false,
+    "abortOnFail": true
+  }
+
+With abortOnFail, a failed test skips the remaining tests, or aborts the other
+running tests, if they're run in parallel. The exit code of the suite is the
+exit code of the first failed test. The tests don't expose the REST API.`,
+		Example: exampleText,
+		Args:    exactArgsWithMsg(1, "arg should be a path to a suite file"),
+		RunE:    c.run,
+	}
+
+	suiteCmd.Flags().SortFlags = false
+	suiteCmd.Flags().AddFlagSet(c.flagSet())
+
+	return suiteCmd
+}
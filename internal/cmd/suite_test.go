@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/errext/exitcodes"
+	"go.k6.io/k6/internal/cmd/tests"
+	"go.k6.io/k6/lib/fsext"
+)
+
+const (
+	passingSuiteScript = `
+		export const options = { thresholds: { checks: ["rate==1"] } };
+		export default function () { console.log("running " + __ENV.TEST_NAME); }
+	`
+	failingSuiteScript = `
+		import { Counter } from "k6/metrics";
+		const failures = new Counter("failures");
+		export const options = { thresholds: { failures: ["count==0"] } };
+		export default function () { failures.add(1); }
+	`
+)
+
+func newSuiteTestState(t *testing.T, suite string, scripts map[string]string) *tests.GlobalTestState {
+	t.Helper()
+	ts := tests.NewGlobalTestState(t)
+	dir := filepath.Join(ts.Cwd, "suite")
+	require.NoError(t, fsext.WriteFile(ts.FS, filepath.Join(dir, "suite.json"), []byte(suite), 0o644))
+	for name, script := range scripts {
+		require.NoError(t, fsext.WriteFile(ts.FS, filepath.Join(dir, name), []byte(script), 0o644))
+	}
+	ts.CmdArgs = []string{"k6", "suite", "--quiet", "--summary-export", "results.json", "suite/suite.json"}
+	return ts
+}
+
+type suiteResults struct {
+	Passed bool              `json:"passed"`
+	Tests  []suiteTestResult `json:"tests"`
+}
+
+func readSuiteResults(t *testing.T, ts *tests.GlobalTestState) suiteResults {
+	t.Helper()
+	data, err := fsext.ReadFile(ts.FS, filepath.Join(ts.Cwd, "results.json"))
+	require.NoError(t, err)
+	var results suiteResults
+	require.NoError(t, json.Unmarshal(data, &results))
+	return results
+}
+
+func suiteStatuses(results suiteResults) map[string]string {
+	statuses := make(map[string]string)
+	for _, r := range results.Tests {
+		statuses[r.Name] = r.Status
+	}
+	return statuses
+}
+
+func TestSuiteSequential(t *testing.T) {
+	t.Parallel()
+
+	ts := newSuiteTestState(t, `{"tests": [
+		{"name": "smoke", "script": "test.js", "env": {"TEST_NAME": "smoke"}},
+		{"name": "load", "script": "test.js", "flags": ["--iterations", "3"], "env": {"TEST_NAME": "load"}}
+	]}`, map[string]string{"test.js": passingSuiteScript})
+	ExecuteWithGlobalState(ts.GlobalState)
+
+	results := readSuiteResults(t, ts)
+	assert.True(t, results.Passed)
+	require.Len(t, results.Tests, 2)
+	assert.Equal(t, map[string]string{"smoke": "passed", "load": "passed"}, suiteStatuses(results))
+	assert.Equal(t, filepath.Join(ts.Cwd, "suite", "test.js"), results.Tests[0].Script)
+
+	var smokeRuns, loadRuns int
+	for _, entry := range ts.LoggerHook.Drain() {
+		switch entry.Message {
+		case "running smoke":
+			smokeRuns++
+		case "running load":
+			loadRuns++
+		}
+	}
+	assert.Equal(t, 1, smokeRuns)
+	assert.Equal(t, 3, loadRuns)
+
+	stdout := ts.Stdout.String()
+	assert.Contains(t, stdout, "suite: 2 passed, 0 failed, 0 aborted, 0 skipped")
+	assert.Contains(t, stdout, "✓ smoke")
+}
+
+func TestSuiteAbortOnFail(t *testing.T) {
+	t.Parallel()
+
+	ts := newSuiteTestState(t, `{"abortOnFail": true, "tests": [
+		{"name": "smoke", "script": "fail.js"},
+		{"name": "load", "script": "pass.js"}
+	]}`, map[string]string{"pass.js": passingSuiteScript, "fail.js": failingSuiteScript})
+	ts.ExpectedExitCode = int(exitcodes.ThresholdsHaveFailed)
+	ExecuteWithGlobalState(ts.GlobalState)
+
+	results := readSuiteResults(t, ts)
+	assert.False(t, results.Passed)
+	assert.Equal(t, map[string]string{"smoke": "failed", "load": "skipped"}, suiteStatuses(results))
+	assert.Equal(t, int(exitcodes.ThresholdsHaveFailed), results.Tests[0].ExitCode)
+	assert.Contains(t, results.Tests[0].Error, "thresholds on metrics 'failures' have been crossed")
+	assert.Contains(t, ts.Stdout.String(), "✗ smoke")
+}
+
+func TestSuiteParallel(t *testing.T) {
+	t.Parallel()
+
+	ts := newSuiteTestState(t, `{"parallel": true, "tests": [
+		{"name": "first", "script": "pass.js"},
+		{"name": "second", "script": "fail.js"},
+		{"name": "third", "script": "pass.js"}
+	]}`, map[string]string{"pass.js": passingSuiteScript, "fail.js": failingSuiteScript})
+	ts.ExpectedExitCode = int(exitcodes.ThresholdsHaveFailed)
+	ExecuteWithGlobalState(ts.GlobalState)
+
+	results := readSuiteResults(t, ts)
+	assert.False(t, results.Passed)
+	assert.Equal(t, map[string]string{"first": "passed", "second": "failed", "third": "passed"}, suiteStatuses(results))
+}
+
+func TestReadSuiteConfig(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]string{
+		`{"tests": []}`:                                                                 "doesn't have any tests",
+		`{"tests": [{"name": "smoke"}]}`:                                                "test #1 of the suite doesn't have a script",
+		`{"tests": [{"script": "a.js"}, {"script": "a.js"}]}`:                           `more than one test named "a.js"`,
+		`{"tests": [{"script": "a.js"}], "sequential": true}`:                           `unknown field "sequential"`,
+		`{"tests": [{"name": "a", "script": "a.js"}, {"name": "a", "script": "b.js"}]}`: `more than one test named "a"`,
+	}
+	for suite, expErr := range testCases {
+		t.Run(suite, func(t *testing.T) {
+			t.Parallel()
+			fs := fsext.NewMemMapFs()
+			require.NoError(t, fsext.WriteFile(fs, "/suite.json", []byte(suite), 0o644))
+			_, err := readSuiteConfig(fs, "/suite.json")
+			require.ErrorContains(t, err, expErr)
+		})
+	}
+}
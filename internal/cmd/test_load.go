@@ -2,10 +2,12 @@ package cmd
 
 import (
 	"archive/tar"
+	"bufio"
 	"bytes"
 	"crypto/x509"
 	"fmt"
 	"io"
+	"net/url"
 	"path/filepath"
 	"sync"
 	"syscall"
@@ -28,6 +30,10 @@ import (
 const (
 	testTypeJS      = "js"
 	testTypeArchive = "archive"
+
+	// encryptedArchivePeekSize is how much of the start of a test is read, to
+	// detect if it's an encrypted archive.
+	encryptedArchivePeekSize = 64
 )
 
 // loadedTest contains all of data, details and dependencies of a loaded
@@ -42,6 +48,12 @@ type loadedTest struct {
 	initRunner     lib.Runner // TODO: rename to something more appropriate
 	keyLogger      io.Closer
 	moduleResolver *modules.ModuleResolver
+
+	// archivePath is the path of a local archive, which is streamed when it's
+	// loaded, instead of being read in memory, and extractedDir is the
+	// temporary directory, where its big files are extracted.
+	archivePath  string
+	extractedDir string
 }
 
 func loadLocalTest(gs *state.GlobalState, cmd *cobra.Command, args []string) (*loadedTest, error) {
@@ -51,7 +63,7 @@ func loadLocalTest(gs *state.GlobalState, cmd *cobra.Command, args []string) (*l
 
 	sourceRootPath := args[0]
 	gs.Logger.Debugf("Resolving and reading test '%s'...", sourceRootPath)
-	src, fileSystems, pwd, err := readSource(gs, sourceRootPath)
+	src, fileSystems, pwd, archivePath, err := readSource(gs, sourceRootPath)
 	if err != nil {
 		return nil, err
 	}
@@ -94,10 +106,12 @@ func loadLocalTest(gs *state.GlobalState, cmd *cobra.Command, args []string) (*l
 		fs:             gs.FS,
 		fileSystems:    fileSystems,
 		preInitState:   state,
+		archivePath:    archivePath,
 	}
 
 	gs.Logger.Debugf("Initializing k6 runner for '%s' (%s)...", sourceRootPath, resolvedPath)
 	if err := test.initializeFirstRunner(gs); err != nil {
+		test.removeExtractedFiles(gs.Logger)
 		return nil, fmt.Errorf("could not initialize '%s': %w", sourceRootPath, err)
 	}
 	gs.Logger.Debug("Runner successfully initialized!")
@@ -109,9 +123,19 @@ func (lt *loadedTest) initializeFirstRunner(gs *state.GlobalState) error {
 	logger := gs.Logger.WithField("test_path", testPath)
 
 	testType := lt.preInitState.RuntimeOptions.TestType.String
-	if testType == "" {
+	switch {
+	case testType == "" && lt.archivePath != "":
+		testType = testTypeArchive
+	case testType == "":
 		logger.Debug("Detecting test type for...")
 		testType = detectTestType(lt.source.Data)
+	case testType != testTypeArchive && lt.archivePath != "":
+		// the local archive wasn't read, since it was going to be streamed
+		src, err := loader.ReadSource(gs.Logger, lt.archivePath, lt.pwd, lt.fileSystems, gs.Stdin)
+		if err != nil {
+			return err
+		}
+		lt.source, lt.archivePath = src, ""
 	}
 
 	if lt.preInitState.RuntimeOptions.KeyWriter.Valid {
@@ -146,8 +170,7 @@ func (lt *loadedTest) initializeFirstRunner(gs *state.GlobalState) error {
 	case testTypeArchive:
 		logger.Debug("Trying to load test as an archive bundle...")
 
-		var arc *lib.Archive
-		arc, err := lib.ReadArchive(bytes.NewReader(lt.source.Data))
+		arc, err := lt.readArchive(gs)
 		if err != nil {
 			return fmt.Errorf("could not load test archive bundle '%s': %w", testPath, err)
 		}
@@ -171,20 +194,118 @@ func (lt *loadedTest) initializeFirstRunner(gs *state.GlobalState) error {
 	}
 }
 
+// readArchive reads the test archive, from its local file if it's one, so its
+// big files are extracted on disk instead of being kept in memory. Encrypted
+// archives are decrypted with the passphrase from the environment and always
+// kept in memory, so their decrypted files are never written on disk.
+func (lt *loadedTest) readArchive(gs *state.GlobalState) (*lib.Archive, error) {
+	if lt.archivePath == "" {
+		in, _, err := decryptArchive(gs, bytes.NewReader(lt.source.Data))
+		if err != nil {
+			return nil, err
+		}
+		return lib.ReadArchive(in)
+	}
+
+	f, err := lt.fs.Open(lt.archivePath)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+	in, encrypted, err := decryptArchive(gs, f)
+	if err != nil {
+		return nil, err
+	}
+	if encrypted {
+		return lib.ReadArchive(in)
+	}
+
+	lt.extractedDir, err = fsext.TempDir(lt.fs, "k6-archive-")
+	if err != nil {
+		return nil, fmt.Errorf("couldn't create a directory for the files of the archive: %w", err)
+	}
+	return lib.ReadArchiveTo(in, lt.fs, lt.extractedDir)
+}
+
+// decryptArchive returns the reader of the archive, which is decrypted, if it's
+// an encrypted one, and if it was.
+func decryptArchive(gs *state.GlobalState, in io.Reader) (io.Reader, bool, error) {
+	br := bufio.NewReader(in)
+	if head, _ := br.Peek(encryptedArchivePeekSize); !lib.IsEncryptedArchive(head) {
+		return br, false, nil
+	}
+	passphrase, err := archivePassphrase(gs)
+	if err != nil {
+		return nil, true, err
+	}
+	if passphrase == "" {
+		return nil, true, fmt.Errorf("the archive is encrypted, but there isn't a passphrase "+
+			"in the %s or the %s environment variables", archivePassphraseEnv, archivePassphraseFileEnv)
+	}
+	in, err = lib.NewArchiveDecrypter(br, passphrase)
+	return in, true, err
+}
+
+// removeExtractedFiles removes the files, which were extracted from a local
+// archive, if there are any.
+func (lt *loadedTest) removeExtractedFiles(logger logrus.FieldLogger) {
+	if lt.extractedDir == "" {
+		return
+	}
+	if err := lt.fs.RemoveAll(lt.extractedDir); err != nil {
+		logger.WithError(err).Warnf("Couldn't remove the files extracted from the archive in '%s'", lt.extractedDir)
+	}
+	lt.extractedDir = ""
+}
+
 // readSource is a small wrapper around loader.ReadSource returning
-// result of the load and filesystems map
-func readSource(gs *state.GlobalState, filename string) (*loader.SourceData, map[string]fsext.Fs, string, error) {
-	pwd, err := gs.Getwd()
+// result of the load and filesystems map. The local archives aren't read,
+// because they're streamed when they're loaded, and their path is returned.
+func readSource(
+	gs *state.GlobalState, filename string,
+) (src *loader.SourceData, filesystems map[string]fsext.Fs, pwd string, archivePath string, err error) {
+	pwd, err = gs.Getwd()
 	if err != nil {
-		return nil, nil, "", err
+		return nil, nil, "", "", err
+	}
+
+	filesystems = loader.CreateFilesystems(gs.FS)
+	if archivePath = localArchivePath(gs.FS, filename, pwd); archivePath != "" {
+		// 'ToSlash' is here as URL only use '/' as separators, but on Windows paths use '\'
+		srcURL := &url.URL{Scheme: "file", Path: filepath.ToSlash(filepath.Clean(fsext.FilePathSeparator + archivePath))}
+		return &loader.SourceData{URL: srcURL}, filesystems, pwd, archivePath, nil
 	}
+	src, err = loader.ReadSource(gs.Logger, filename, pwd, filesystems, gs.Stdin)
+	return src, filesystems, pwd, "", err
+}
 
-	filesystems := loader.CreateFilesystems(gs.FS)
-	src, err := loader.ReadSource(gs.Logger, filename, pwd, filesystems, gs.Stdin)
-	return src, filesystems, pwd, err
+// localArchivePath returns the path of the file, if it's a local archive.
+func localArchivePath(osFs fsext.Fs, filename, pwd string) string {
+	if filename == "-" {
+		return ""
+	}
+	if !filepath.IsAbs(filename) {
+		filename = filepath.Join(pwd, filename)
+	}
+	f, err := osFs.Open(filename)
+	if err != nil {
+		return ""
+	}
+	defer func() { _ = f.Close() }()
+	br := bufio.NewReader(f)
+	if head, _ := br.Peek(encryptedArchivePeekSize); lib.IsEncryptedArchive(head) {
+		return filename
+	}
+	if _, err = tar.NewReader(br).Next(); err != nil {
+		return ""
+	}
+	return filename
 }
 
 func detectTestType(data []byte) string {
+	if lib.IsEncryptedArchive(data) {
+		return testTypeArchive
+	}
 	if _, err := tar.NewReader(bytes.NewReader(data)).Next(); err == nil {
 		return testTypeArchive
 	}
@@ -211,6 +332,10 @@ func (lt *loadedTest) consolidateDeriveAndValidateConfig(
 		return nil, err
 	}
 
+	if err = lt.syncCentralThresholds(gs.Ctx, gs.Logger, &consolidatedConfig); err != nil {
+		return nil, err
+	}
+
 	gs.Logger.Debug("Parsing thresholds and validating config...")
 	// Parse the thresholds, only if the --no-threshold flag is not set.
 	// If parsing the threshold expressions failed, consider it as an
@@ -258,7 +383,12 @@ func loadAndConfigureLocalTest(
 		return nil, err
 	}
 
-	return test.consolidateDeriveAndValidateConfig(gs, cmd, cliConfigGetter)
+	configuredTest, err := test.consolidateDeriveAndValidateConfig(gs, cmd, cliConfigGetter)
+	if err != nil {
+		test.removeExtractedFiles(gs.Logger)
+		return nil, err
+	}
+	return configuredTest, nil
 }
 
 // loadSystemCertPool attempts to load system certificates.
@@ -292,7 +422,7 @@ func (lct *loadedAndConfiguredTest) buildTestRunState(
 		TestPreInitState: lct.preInitState,
 		Runner:           lct.initRunner,
 		Options:          lct.derivedConfig.Options, // we will always run with the derived options
-		RunTags:          lct.preInitState.Registry.RootTagSet().WithTagsFromMap(configToReinject.RunTags),
+		RunTags:          lct.preInitState.Registry.RootTagSet().WithTagsFromMap(configToReinject.RunTagsWithMetadata()),
 		GroupSummary:     lib.NewGroupSummary(lct.preInitState.Logger),
 	}, nil
 }
@@ -6,16 +6,19 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"os"
 	"path/filepath"
 	"strconv"
 	"testing"
 
+	"github.com/spf13/afero"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"go.k6.io/k6/cloudapi"
 	"go.k6.io/k6/errext/exitcodes"
 	"go.k6.io/k6/internal/cmd"
+	"go.k6.io/k6/lib"
 	"go.k6.io/k6/lib/fsext"
 )
 
@@ -199,6 +202,48 @@ export default function() {
 		assert.Contains(t, stdout, "output: cloud (https://app.k6.io/runs/1337)")
 		assert.Contains(t, stdout, "The test run id is "+strconv.Itoa(testRunID))
 	})
+
+	t.Run("removes the extracted files of the archive when the cloud test can't be created", func(t *testing.T) {
+		t.Parallel()
+
+		script := `
+export const options = {
+  cloud: {
+      name: 'Hello k6 Cloud!',
+      projectID: 123456,
+  },
+};
+
+const data = open("./big.bin", "b");
+
+export default function() {};`
+
+		ts := makeTestState(t, script, nil, 0)
+		big := bytes.Repeat([]byte("k6"), lib.ArchiveExtractionSize)
+		require.NoError(t, fsext.WriteFile(ts.FS, filepath.Join(ts.Cwd, "big.bin"), big, 0o644))
+		ts.CmdArgs = []string{"k6", "archive", "-O", "archive.tar", "test.js"}
+		cmd.ExecuteWithGlobalState(ts.GlobalState)
+		archive, err := fsext.ReadFile(ts.FS, "archive.tar")
+		require.NoError(t, err)
+		require.NoError(t, fsext.WriteFile(ts.FS, filepath.Join(ts.Cwd, "archive.tar"), archive, 0o644))
+
+		srv := getTestServer(t, map[string]http.Handler{
+			"POST ^/v1/archive-upload$": http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+				_, _ = io.Copy(io.Discard, req.Body)
+				resp.WriteHeader(http.StatusBadRequest)
+			}),
+		})
+		t.Cleanup(srv.Close)
+		ts.Env["K6_CLOUD_HOST"] = srv.URL
+		ts.ExpectedExitCode = -1
+		ts.CmdArgs = []string{"k6", "cloud", "run", "--local-execution", "archive.tar"}
+		cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+		assert.Contains(t, ts.Stderr.String(), "could not create the cloud test run")
+		extracted, err := afero.Glob(ts.FS, filepath.Join(os.TempDir(), "k6-archive-*")) //nolint:forbidigo
+		require.NoError(t, err)
+		assert.Empty(t, extracted)
+	})
 }
 
 func makeTestState(tb testing.TB, script string, cliFlags []string, expExitCode exitcodes.ExitCode) *GlobalTestState {
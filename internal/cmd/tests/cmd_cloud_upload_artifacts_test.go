@@ -0,0 +1,87 @@
+package tests
+
+import (
+	"io"
+	"net/http"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/internal/cmd"
+	"go.k6.io/k6/lib/fsext"
+)
+
+func TestK6CloudUploadArtifacts(t *testing.T) {
+	t.Parallel()
+
+	t.Run("TestCloudUploadArtifactsNotLoggedIn", func(t *testing.T) {
+		t.Parallel()
+
+		ts := NewGlobalTestState(t)
+		require.NoError(t, fsext.WriteFile(ts.FS, filepath.Join(ts.Cwd, "report.html"), []byte("<html>"), 0o644))
+		ts.CmdArgs = []string{"k6", "cloud", "upload-artifacts", "123", "report.html"}
+		ts.ExpectedExitCode = -1
+		cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+		assert.Contains(t, ts.Stderr.String(), `not logged in`)
+	})
+
+	t.Run("TestCloudUploadArtifactsFilesAndDirectories", func(t *testing.T) {
+		t.Parallel()
+
+		var mx sync.Mutex
+		uploaded := make(map[string]string)
+		srv := getTestServer(t, map[string]http.Handler{
+			"POST ^/v1/tests/123/artifacts$": http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+				file, _, err := req.FormFile("file")
+				require.NoError(t, err)
+				data, err := io.ReadAll(file)
+				require.NoError(t, err)
+
+				mx.Lock()
+				uploaded[req.FormValue("name")] = string(data)
+				mx.Unlock()
+				resp.WriteHeader(http.StatusOK)
+			}),
+		})
+		t.Cleanup(srv.Close)
+
+		ts := NewGlobalTestState(t)
+		for name, data := range map[string]string{
+			"report.html":                       "<html>",
+			"k6-artifacts/home.png":             "png",
+			"k6-artifacts/screenshots/cart.png": "cart",
+		} {
+			require.NoError(t, fsext.WriteFile(ts.FS, filepath.Join(ts.Cwd, name), []byte(data), 0o644))
+		}
+		ts.CmdArgs = []string{"k6", "cloud", "upload-artifacts", "123", "report.html", "k6-artifacts"}
+		ts.Env["K6_CLOUD_HOST"] = srv.URL
+		ts.Env["K6_CLOUD_TOKEN"] = "foo" // doesn't matter, we mock the cloud
+		cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+		stdout := ts.Stdout.String()
+		t.Log(stdout)
+		assert.Contains(t, stdout, `uploaded: screenshots/cart.png`)
+		assert.Contains(t, stdout, `output: https://app.k6.io/runs/123`)
+		assert.Equal(t, map[string]string{
+			"report.html":          "<html>",
+			"home.png":             "png",
+			"screenshots/cart.png": "cart",
+		}, uploaded)
+	})
+
+	t.Run("TestCloudUploadArtifactsMissingFile", func(t *testing.T) {
+		t.Parallel()
+
+		ts := NewGlobalTestState(t)
+		ts.CmdArgs = []string{"k6", "cloud", "upload-artifacts", "123", "missing.har"}
+		ts.Env["K6_CLOUD_TOKEN"] = "foo"
+		ts.ExpectedExitCode = -1
+		cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+		assert.Contains(t, ts.Stderr.String(), `missing.har`)
+	})
+}
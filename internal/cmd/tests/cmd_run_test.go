@@ -31,6 +31,7 @@ import (
 	"go.k6.io/k6/internal/lib/testutils"
 	"go.k6.io/k6/internal/lib/testutils/httpmultibin"
 	"go.k6.io/k6/js/modules"
+	"go.k6.io/k6/lib"
 	"go.k6.io/k6/lib/consts"
 	"go.k6.io/k6/lib/fsext"
 )
@@ -207,6 +208,57 @@ func TestRelativeLogPathWithSetupAndTeardown(t *testing.T) {
 	assert.Equal(t, "init\ninit\ninit\nbar\nfoo\nfoo\ninit\nbaz\ninit\n", string(logContents)) //nolint:dupword
 }
 
+func TestStructuredLogsWithLevelsAndRateLimit(t *testing.T) {
+	t.Parallel()
+
+	ts := NewGlobalTestState(t)
+	ts.CmdArgs = []string{
+		"k6", "--quiet", "--log-output=stdout", "--log-format=json",
+		"--log-levels=console=debug", "--log-rate-limit=3", "run", "--no-summary", "-",
+	}
+	ts.Stdin = bytes.NewBufferString(`
+		import { group } from 'k6';
+
+		export default function() {
+			group('g', () => console.debug('in group'));
+			for (let i = 0; i < 10; i++) {
+				console.log('spam');
+			}
+		};
+	`)
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+	var lines []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(ts.Stdout.String()), "\n") {
+		var fields map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &fields), line)
+		if fields["source"] != "console" {
+			// the debug messages of k6 itself aren't logged
+			assert.NotEqual(t, "debug", fields["level"], line)
+			continue
+		}
+		delete(fields, "time")
+		lines = append(lines, fields)
+	}
+
+	fields := map[string]any{"source": "console", "scenario": "default", "vu": 1.0, "iteration": 0.0}
+	withFields := func(extra map[string]any) map[string]any {
+		result := map[string]any{}
+		for k, v := range fields {
+			result[k] = v
+		}
+		for k, v := range extra {
+			result[k] = v
+		}
+		return result
+	}
+	assert.Equal(t, []map[string]any{
+		withFields(map[string]any{"level": "debug", "msg": "in group", "group": "::g"}),
+		withFields(map[string]any{"level": "info", "msg": "spam"}),
+		withFields(map[string]any{"level": "info", "msg": "spam"}),
+	}, lines)
+}
+
 func TestWrongCliFlagIterations(t *testing.T) {
 	t.Parallel()
 
@@ -338,8 +390,12 @@ func TestMetricsAndThresholds(t *testing.T) {
 	teardownThresholds, ok := teardownCounter["thresholds"].(map[string]interface{})
 	require.True(t, ok)
 
-	expected := map[string]interface{}{"count == 1": map[string]interface{}{"ok": true}}
-	require.Equal(t, expected, teardownThresholds)
+	threshold, ok := teardownThresholds["count == 1"].(map[string]interface{})
+	require.True(t, ok)
+	require.Len(t, teardownThresholds, 1)
+	assert.Equal(t, true, threshold["ok"])
+	assert.Equal(t, 1.0, threshold["value"])
+	assert.Len(t, threshold["evaluations"], 1)
 }
 
 func TestSSLKEYLOGFILEAbsolute(t *testing.T) {
@@ -441,7 +497,7 @@ func TestExecutionTestOptionsDefaultValues(t *testing.T) {
 	loglines := ts.LoggerHook.Drain()
 	require.Len(t, loglines, 1)
 
-	expected := `{"paused":null,"executionSegment":null,"executionSegmentSequence":null,"noSetup":null,"setupTimeout":null,"noTeardown":null,"teardownTimeout":null,"rps":null,"dns":{"ttl":null,"select":null,"policy":null},"maxRedirects":null,"userAgent":null,"batch":null,"batchPerHost":null,"httpDebug":null,"insecureSkipTLSVerify":null,"tlsCipherSuites":null,"tlsVersion":null,"tlsAuth":null,"throw":null,"thresholds":null,"blacklistIPs":null,"blockHostnames":null,"hosts":null,"noConnectionReuse":null,"noVUConnectionReuse":null,"minIterationDuration":null,"ext":null,"summaryTrendStats":["avg", "min", "med", "max", "p(90)", "p(95)"],"summaryTimeUnit":null,"systemTags":["check","error","error_code","expected_response","group","method","name","proto","scenario","service","status","subproto","tls_version","url"],"tags":null,"metricSamplesBufferSize":null,"noCookiesReset":null,"discardResponseBodies":null,"consoleOutput":null,"scenarios":{"default":{"vus":null,"iterations":1,"executor":"shared-iterations","maxDuration":null,"startTime":null,"env":null,"tags":null,"gracefulStop":null,"exec":null}},"localIPs":null}`
+	expected := `{"paused":null,"executionSegment":null,"executionSegmentSequence":null,"noSetup":null,"setupTimeout":null,"noTeardown":null,"teardownTimeout":null,"onAbortTimeout":null,"scenarioSetupData":null,"rps":null,"dns":{"ttl":null,"select":null,"policy":null,"servers":null,"negativeTTL":null,"maxStale":null},"maxRedirects":null,"userAgent":null,"httpProfile":null,"batch":null,"batchPerHost":null,"httpDebug":null,"httpFlightRecorder":null,"staticAssetCache":null,"authSessions":null,"clusterStore":null,"insecureSkipTLSVerify":null,"ocspStapling":null,"tlsCipherSuites":null,"tlsVersion":null,"tlsCurves":null,"tlsClientHello":null,"tlsSessionResumption":null,"tlsAuth":null,"throw":null,"thresholds":null,"blacklistIPs":null,"blockHostnames":null,"hosts":null,"noConnectionReuse":null,"noVUConnectionReuse":null,"sharedConnectionPool":null,"maxConnsPerHost":null,"maxIdleConns":null,"maxIdleConnsPerHost":null,"idleConnTimeout":null,"proxy":null,"networkConditions":null,"minIterationDuration":null,"vuMemoryLimit":null,"clock":null,"randomSeed":null,"ext":null,"outputTuning":null,"summaryTrendStats":["avg", "min", "med", "max", "p(90)", "p(95)"],"summaryTimeUnit":null,"systemTags":["check","error","error_code","expected_response","group","method","name","proto","scenario","service","status","subproto","tls_version","url"],"metricSystemTags":null,"dataAttribution":null,"tags":null,"metadata":{"name":null,"version":null,"environment":null,"gitSHA":null},"metricSamplesBufferSize":null,"metricSamplesPerVUBufferSize":null,"trendCompression":null,"noCookiesReset":null,"discardResponseBodies":null,"artifactsDir":null,"consoleOutput":null,"scenarios":{"default":{"vus":null,"iterations":1,"executor":"shared-iterations","maxDuration":null,"startTime":null,"env":null,"tags":null,"gracefulStop":null,"exec":null,"iterationTimeout":null,"onIterationTimeout":null}},"localIPs":null}`
 	assert.JSONEq(t, expected, loglines[0].Message)
 }
 
@@ -1506,6 +1562,92 @@ func TestActiveVUsCount(t *testing.T) {
 	}
 }
 
+func TestCheckSeverities(t *testing.T) {
+	t.Parallel()
+
+	script := `
+		import { check } from 'k6';
+
+		export const options = {
+			iterations: 2,
+			thresholds: {
+				'checks{severity:critical}': ['rate==1'],
+			},
+		};
+
+		export default function () {
+			check(null, { 'is critical': () => true }, { severity: 'critical' });
+			check(null, { 'is a warning': () => false }, { severity: 'warn' });
+		}
+
+		export function handleSummary(data) {
+			const checks = data.root_group.checks.map((c) => c.name + ':' + c.severity);
+			console.log(checks.join(','));
+			return {};
+		}
+	`
+
+	ts := getSingleFileTestState(t, script, nil, 0)
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+	assert.True(t, testutils.LogContains(ts.LoggerHook.Drain(), logrus.InfoLevel,
+		"is critical:critical,is a warning:warn"))
+}
+
+func TestIterationTimeout(t *testing.T) {
+	t.Parallel()
+
+	script := `
+		import { sleep } from 'k6';
+
+		export const options = {
+			scenarios: {
+				failed: {
+					executor: 'shared-iterations',
+					iterations: 2,
+					iterationTimeout: '100ms',
+				},
+				skipped: {
+					executor: 'shared-iterations',
+					iterations: 3,
+					iterationTimeout: '100ms',
+					onIterationTimeout: 'skip',
+				},
+				fast: {
+					executor: 'shared-iterations',
+					iterations: 1,
+					iterationTimeout: '10s',
+				},
+			},
+		};
+
+		export default function () {
+			sleep(2);
+		}
+	`
+
+	ts := getSingleFileTestState(t, script, []string{"--out", "json=results.json"}, 0)
+	start := time.Now()
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+	assert.Less(t, time.Since(start), 5*time.Second)
+
+	jsonResults, err := fsext.ReadFile(ts.FS, "results.json")
+	require.NoError(t, err)
+	assert.Equal(t, 2.0, sum(getSampleValues(t, jsonResults, "iteration_timeout", map[string]string{"scenario": "failed"})))
+	assert.Equal(t, 3.0, sum(getSampleValues(t, jsonResults, "iteration_timeout", map[string]string{"scenario": "skipped"})))
+	assert.Equal(t, 0.0, sum(getSampleValues(t, jsonResults, "iteration_timeout", map[string]string{"scenario": "fast"})))
+	assert.Equal(t, 1.0, sum(getSampleValues(t, jsonResults, "iterations", nil)))
+
+	timeoutErrors := 0
+	for _, entry := range ts.LoggerHook.Drain() {
+		if strings.Contains(entry.Message, "ran longer than the iterationTimeout of 100ms") {
+			assert.Equal(t, logrus.ErrorLevel, entry.Level)
+			timeoutErrors++
+		}
+	}
+	assert.Equal(t, 2, timeoutErrors)
+}
+
 func TestMinIterationDuration(t *testing.T) {
 	t.Parallel()
 	script := `
@@ -1686,6 +1828,48 @@ func TestRunTags(t *testing.T) {
 	assert.Equal(t, float64(3), sum(getSampleValues(t, jsonResults, "checks", expTags)))
 }
 
+func TestRunMetadata(t *testing.T) {
+	t.Parallel()
+
+	script := `
+		import { Counter } from 'k6/metrics';
+
+		const myCounter = new Counter('mycounter');
+
+		export const options = {
+			metadata: { name: 'checkout', version: '1.0' },
+		};
+
+		export default function () {
+			myCounter.add(1);
+		};
+
+		export function handleSummary(data) {
+			return { stdout: "metadata " + JSON.stringify(data.metadata, ["name", "version", "environment", "gitSHA"]) + "\n" };
+		}
+	`
+
+	ts := getSingleFileTestState(t, script, []string{
+		"--out", "json=results.json", "--test-environment", "staging", "--test-version", "1.1",
+		"--tag", "environment=prod",
+	}, 0)
+	ts.Env["K6_TEST_GIT_SHA"] = "4f5e283"
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+	assert.Contains(t, ts.Stdout.String(),
+		`metadata {"name":"checkout","version":"1.1","environment":"staging","gitSHA":"4f5e283"}`)
+
+	jsonResults, err := fsext.ReadFile(ts.FS, "results.json")
+	require.NoError(t, err)
+	// the explicitly set tags take precedence over the metadata
+	assert.Equal(t, []float64{1}, getSampleValues(t, jsonResults, "mycounter", map[string]string{
+		"test_name":    "checkout",
+		"test_version": "1.1",
+		"environment":  "prod",
+		"git_sha":      "4f5e283",
+	}))
+}
+
 func TestRunWithCloudOutputOverrides(t *testing.T) {
 	t.Parallel()
 
@@ -1829,6 +2013,46 @@ func TestPrometheusRemoteWriteOutput(t *testing.T) {
 	assert.Contains(t, stdout, "output: Prometheus remote write")
 }
 
+func TestRunArtifacts(t *testing.T) {
+	t.Parallel()
+	if runtime.GOOS == "windows" {
+		t.Skip("the upload command uses the syntax of sh")
+	}
+
+	ts := NewGlobalTestState(t)
+	ts.CmdArgs = []string{
+		"k6", "run", "--quiet", "--log-output=stdout",
+		"--artifacts-upload-command", "echo uploading $K6_ARTIFACTS_DIR", "-",
+	}
+	ts.Stdin = bytes.NewBufferString(`
+		import exec from "k6/execution";
+
+		export default function () {
+			exec.test.artifacts.write("report.txt", "done");
+		};
+
+		export function handleSummary(data) {
+			return { stdout: "summary of " + data.state.artifactsDir + "\n" };
+		}
+	`)
+
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+	dirs, err := fsext.ReadDir(ts.FS, filepath.Join(ts.Cwd, "k6-artifacts"))
+	require.NoError(t, err)
+	require.Len(t, dirs, 1)
+	dir := filepath.Join(ts.Cwd, "k6-artifacts", dirs[0].Name())
+	data, err := fsext.ReadFile(ts.FS, filepath.Join(dir, "report.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "done", string(data))
+
+	stdout := ts.Stdout.String()
+	assert.Contains(t, stdout, "summary of "+dir+"\n")
+	assert.Contains(t, stdout, "The artifacts of the test run were written to "+dir)
+	assert.Contains(t, stdout, "uploading "+dir+"\n")
+	assert.Less(t, strings.Index(stdout, "summary of"), strings.Index(stdout, "uploading"))
+}
+
 func BenchmarkReadResponseBody(b *testing.B) {
 	httpSrv := httpmultibin.NewHTTPMultiBin(b)
 
@@ -2174,6 +2398,157 @@ func TestEventSystemError(t *testing.T) {
 	}
 }
 
+func TestLifecycleEvents(t *testing.T) {
+	t.Parallel()
+
+	ts := NewGlobalTestState(t)
+
+	moduleName := fmt.Sprintf("k6/x/testevents-%d", atomic.AddUint64(&uniqueModuleNumber, 1))
+	mod := events.New(event.LifecycleEvents, nil)
+	modules.Register(moduleName, mod)
+
+	ts.CmdArgs = []string{"k6", "--quiet", "run", "-"}
+	ts.ExpectedExitCode = int(exitcodes.ScriptAborted)
+	ts.Stdin = bytes.NewBuffer([]byte(fmt.Sprintf(`
+		import events from '%s';
+		import { test } from 'k6/execution';
+
+		export let options = {
+			vus: 1,
+			iterations: 5,
+		}
+
+		export default function () {
+			test.abort('oops!');
+		}
+	`, moduleName)))
+
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+	doneCh := make(chan struct{})
+	go func() {
+		mod.WG.Wait()
+		close(doneCh)
+	}()
+
+	select {
+	case <-doneCh:
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+
+	expLog := []string{
+		"got event ScenarioStart with data '&{Name:default Executor:shared-iterations Error:<nil>}'",
+		"got event Abort with data '&{Reason:test aborted: oops! at default (file:///-:11:14(5))}'",
+		"got event ScenarioEnd with data '&{Name:default Executor:shared-iterations Error:<nil>}'",
+		"test aborted: oops! at default (file:///-:11:14(5))",
+	}
+	log := ts.LoggerHook.Lines()
+	assert.Equal(t, expLog, log)
+}
+
+func TestLifecycleEventsInScript(t *testing.T) {
+	t.Parallel()
+
+	ts := NewGlobalTestState(t)
+	ts.CmdArgs = []string{"k6", "--quiet", "--log-output=stdout", "run", "-"}
+	ts.Stdin = bytes.NewBufferString(`
+		import exec from 'k6/execution';
+
+		exec.on('testStart', (e) => console.log(e.type));
+		exec.on('scenarioStart', (e) => console.log(e.type, e.scenario, e.executor));
+
+		export let options = {
+			vus: 1,
+			iterations: 2,
+		}
+
+		export default function () {}
+	`)
+
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+	stdout := ts.Stdout.String()
+	t.Log(stdout)
+	assert.Equal(t, 1, strings.Count(stdout, `msg=testStart`))
+	assert.Equal(t, 1, strings.Count(stdout, `msg="scenarioStart default shared-iterations"`))
+}
+
+func TestNestedGroupThresholdsAndSummary(t *testing.T) {
+	t.Parallel()
+
+	ts := NewGlobalTestState(t)
+	ts.CmdArgs = []string{"k6", "--quiet", "run", "-"}
+	ts.Stdin = bytes.NewBufferString(`
+		import { group, check } from 'k6';
+
+		export const options = {
+			iterations: 2,
+			summaryTrendStats: ['avg', 'count'],
+			thresholds: {
+				'group_duration{group:::login::submit}': ['avg<10000'],
+				'group_duration{group:login}': ['avg<10000'],
+				'checks{group:"::login::submit, again"}': ['rate==1'],
+			},
+		};
+
+		export default function () {
+			group('login', () => {
+				group('submit', () => { check(1, { 'is one': (v) => v === 1 }); });
+				group('submit, again', () => { check(1, { 'is one': (v) => v === 1 }); });
+			});
+		}
+	`)
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+	stdout := ts.Stdout.String()
+	t.Log(stdout)
+	assert.Contains(t, stdout, "     █ login\n       ↳  checks: 100% — ✓ 4 / ✗ 0\n       ↳  duration: avg=")
+	assert.Contains(t, stdout, "       █ submit\n         ↳  checks: 100% — ✓ 2 / ✗ 0\n")
+	assert.Regexp(t, `✓ \{ group:::login::submit \}\.+: avg=\S+ +count=2\n`, stdout)
+	assert.Regexp(t, `✓ \{ group:login \}\.+: avg=\S+ +count=2\n`, stdout)
+	assert.Regexp(t, `✓ \{ group:"::login::submit, again" \}\.+: 100\.00% 2 out of 2\n`, stdout)
+}
+
+func TestScenarioResourceBudgets(t *testing.T) {
+	t.Parallel()
+	if runtime.GOOS != "linux" {
+		t.Skip("the resource usage is measured only on Linux")
+	}
+
+	ts := NewGlobalTestState(t)
+	ts.CmdArgs = []string{"k6", "--quiet", "run", "-"}
+	ts.ExpectedExitCode = int(exitcodes.ResourceBudgetExceeded)
+	ts.Stdin = bytes.NewBufferString(`
+		import { sleep } from 'k6';
+
+		export const options = {
+			scenarios: {
+				browser: {
+					executor: 'constant-vus',
+					vus: 1,
+					duration: '30s',
+					options: { resources: { maxMemory: 1, onExceed: 'abort' } },
+				},
+				protocol: {
+					executor: 'constant-vus',
+					vus: 1,
+					duration: '30s',
+					options: { resources: { maxOpenFiles: 1000000 } },
+				},
+			},
+		};
+
+		export default function () { sleep(0.1); }
+	`)
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+	stderr := ts.Stderr.String()
+	t.Log(stderr)
+	assert.Contains(t, stderr, "scenario browser is over its resource budget: memory")
+	assert.NotContains(t, stderr, "scenario protocol")
+}
+
 func BenchmarkRun(b *testing.B) {
 	b.StopTimer()
 
@@ -2402,3 +2777,403 @@ func TestTypeScriptSupport(t *testing.T) {
 	t.Log(stderr)
 	assert.Contains(t, stderr, `something 42`)
 }
+
+func TestHTTPFlightRecorder(t *testing.T) {
+	t.Parallel()
+
+	tb := httpmultibin.NewHTTPMultiBin(t)
+	script := tb.Replacer.Replace(`
+		import http from 'k6/http';
+		import { check } from 'k6';
+
+		export const options = {
+			iterations: 4,
+			httpFlightRecorder: 2,
+			hosts: {
+				"HTTPBIN_DOMAIN": "HTTPBIN_IP",
+			},
+		};
+
+		export default function () {
+			const params = { headers: { Authorization: 'Bearer secret-token' } };
+			http.get('HTTPBIN_URL/get?iter=' + __ITER, params);
+			const status = __ITER === 2 ? 418 : 200;
+			check(http.get('HTTPBIN_URL/status/' + status), { 'status is 200': (r) => r.status === 200 });
+		}
+	`)
+
+	ts := getSingleFileTestState(t, script, nil, 0)
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+	var records []string
+	for _, entry := range ts.LoggerHook.Drain() {
+		if entry.Data["source"] == "http-flight-recorder" {
+			records = append(records, entry.Message)
+		}
+	}
+	// only the last 2 requests of the failed iteration are logged
+	require.Len(t, records, 2)
+	assert.Contains(t, records[0], "GET /get?iter=2 HTTP/1.1")
+	assert.Contains(t, records[0], "Authorization: [REDACTED]")
+	assert.NotContains(t, records[0], "Authorization: Bearer")
+	assert.Contains(t, records[0], "HTTP/1.1 200 OK")
+	assert.Contains(t, records[1], "GET /status/418 HTTP/1.1")
+	assert.Contains(t, records[1], "HTTP/1.1 418 I'm a teapot")
+}
+
+func TestStaticAssetCache(t *testing.T) {
+	t.Parallel()
+
+	tb := httpmultibin.NewHTTPMultiBin(t)
+	var assetRequests, apiRequests atomic.Int64
+	tb.Mux.HandleFunc("/assets/", func(w http.ResponseWriter, _ *http.Request) {
+		assetRequests.Add(1)
+		w.Header().Set("Content-Type", "text/css")
+		_, _ = w.Write([]byte("body { color: red; }"))
+	})
+	tb.Mux.HandleFunc("/api", func(w http.ResponseWriter, _ *http.Request) {
+		apiRequests.Add(1)
+		_, _ = w.Write([]byte(`{"ok": true}`))
+	})
+
+	script := tb.Replacer.Replace(`
+		import http from 'k6/http';
+		import { check } from 'k6';
+
+		export const options = {
+			iterations: 4,
+			staticAssetCache: { timing: "5ms" },
+			thresholds: { checks: ["rate==1"] },
+			hosts: {
+				"HTTPBIN_DOMAIN": "HTTPBIN_IP",
+			},
+		};
+
+		export default function () {
+			for (const url of ['HTTPBIN_URL/assets/app.css', 'HTTPBIN_URL/assets/app.css?v=2']) {
+				const res = http.get(url);
+				check(res, {
+					'the asset is served': (r) => r.status === 200 && r.body === 'body { color: red; }'
+						&& r.headers['Content-Type'] === 'text/css',
+					'the cached asset has the synthetic timing': (r) => __ITER === 0 || r.timings.waiting === 5,
+				});
+			}
+			http.get('HTTPBIN_URL/api');
+		}
+	`)
+
+	ts := getSingleFileTestState(t, script, []string{"--out", "json=results.json"}, 0)
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+	// only the first iteration requests the assets, but all of them request the API
+	assert.Equal(t, int64(2), assetRequests.Load())
+	assert.Equal(t, int64(4), apiRequests.Load())
+
+	jsonResults, err := fsext.ReadFile(ts.FS, "results.json")
+	require.NoError(t, err)
+	var httpReqs int
+	for _, line := range bytes.Split(jsonResults, []byte("\n")) {
+		if gjson.GetBytes(line, "metric").String() == "http_reqs" && gjson.GetBytes(line, "type").String() == "Point" {
+			httpReqs++
+		}
+	}
+	assert.Equal(t, 12, httpReqs, "the cached responses are still counted")
+}
+
+func TestProfileMetricsMemory(t *testing.T) {
+	t.Parallel()
+
+	script := `
+		import { Counter } from 'k6/metrics';
+		const requests = new Counter('my_requests');
+
+		export const options = { iterations: 5 };
+
+		export default function () {
+			requests.add(1, { user: 'user-' + __ITER, kind: 'api' });
+		}
+	`
+	ts := getSingleFileTestState(t, script, []string{"--profile-metrics-memory", "--no-summary"}, 0)
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+	stdout := ts.Stdout.String()
+	assert.Contains(t, stdout, "metrics memory profile:")
+	assert.Regexp(t, `my_requests\s+5\s+`, stdout)
+	assert.Regexp(t, `user\s+5\s+5\s+`, stdout)
+	assert.Regexp(t, `kind\s+1\s+5\s+`, stdout)
+	assert.NotContains(t, stdout, "checks_total", "the summary is disabled")
+}
+
+func TestTimelineExportAndReplay(t *testing.T) {
+	t.Parallel()
+
+	script := `
+		export const options = {
+			scenarios: {
+				arrivals: {
+					executor: 'constant-arrival-rate',
+					rate: 20,
+					timeUnit: '1s',
+					duration: '1s',
+					preAllocatedVUs: 2,
+				},
+			},
+		};
+
+		export default function () {}
+	`
+
+	ts := getSingleFileTestState(t, script, []string{"--export-timeline", "timeline.json"}, 0)
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+	data, err := fsext.ReadFile(ts.FS, "timeline.json")
+	require.NoError(t, err)
+	exported, err := lib.ParseTimeline(data)
+	require.NoError(t, err)
+	require.Contains(t, exported.Scenarios, "arrivals")
+	iterations := exported.Scenarios["arrivals"].Iterations
+	require.NotEmpty(t, iterations)
+
+	replayTS := getSingleFileTestState(t, script,
+		[]string{"--replay-timeline", "timeline.json", "--export-timeline", "replayed.json"}, 0)
+	require.NoError(t, fsext.WriteFile(replayTS.FS, "timeline.json", data, 0o644))
+	cmd.ExecuteWithGlobalState(replayTS.GlobalState)
+	assert.Contains(t, replayTS.Stdout.String(), fmt.Sprintf("%d replayed iterations over", len(iterations)))
+
+	data, err = fsext.ReadFile(replayTS.FS, "replayed.json")
+	require.NoError(t, err)
+	replayed, err := lib.ParseTimeline(data)
+	require.NoError(t, err)
+	require.Len(t, replayed.Scenarios["arrivals"].Iterations, len(iterations))
+	for i, offset := range iterations {
+		assert.InDelta(t, time.Duration(offset), time.Duration(replayed.Scenarios["arrivals"].Iterations[i]),
+			float64(50*time.Millisecond))
+	}
+}
+
+func TestTimelineReplayUnknownScenario(t *testing.T) {
+	t.Parallel()
+
+	ts := getSingleFileTestState(t, `export default function () {}`,
+		[]string{"--replay-timeline", "timeline.json"}, exitcodes.InvalidConfig)
+	require.NoError(t, fsext.WriteFile(ts.FS, "timeline.json",
+		[]byte(`{"scenarios":{"other":{"iterations":["0s"],"maxVUs":1}}}`), 0o644))
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+	assert.True(t, testutils.LogContains(ts.LoggerHook.Drain(), logrus.ErrorLevel,
+		"the scenario other of the timeline to replay isn't in the test"))
+}
+
+func TestHandleSummaryScenarios(t *testing.T) {
+	t.Parallel()
+
+	script := `
+		import { Counter } from 'k6/metrics';
+
+		const items = new Counter('items');
+
+		export const options = {
+			scenarios: {
+				browse: { executor: 'shared-iterations', iterations: 3, exec: 'browse' },
+				checkout: { executor: 'per-vu-iterations', iterations: 1, exec: 'checkout', startTime: '100ms' },
+			},
+			thresholds: { items: ['count<100'] },
+		};
+
+		export function browse() { items.add(1); }
+		export function checkout() { items.add(10); }
+
+		export function handleSummary(data) {
+			const summary = {
+				startTime: data.state.startTime,
+				threshold: data.metrics.items.thresholds['count<100'],
+				scenarios: {},
+			};
+			for (const [name, scenario] of Object.entries(data.scenarios)) {
+				summary.scenarios[name] = {
+					executor: scenario.executor,
+					startTimeMs: scenario.startTimeMs,
+					items: scenario.metrics.items.values.count,
+					iterations: scenario.metrics.iterations.values.count,
+				};
+			}
+			return { 'summary.json': JSON.stringify(summary) };
+		}
+	`
+	ts := getSingleFileTestState(t, script, nil, 0)
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+	data, err := fsext.ReadFile(ts.FS, "summary.json")
+	require.NoError(t, err)
+	var summary struct {
+		StartTime string `json:"startTime"`
+		Threshold struct {
+			OK          bool              `json:"ok"`
+			Value       float64           `json:"value"`
+			Evaluations []json.RawMessage `json:"evaluations"`
+		} `json:"threshold"`
+		Scenarios map[string]map[string]any `json:"scenarios"`
+	}
+	require.NoError(t, json.Unmarshal(data, &summary))
+
+	_, err = time.Parse(time.RFC3339Nano, summary.StartTime)
+	require.NoError(t, err)
+	assert.True(t, summary.Threshold.OK)
+	assert.Equal(t, 13.0, summary.Threshold.Value)
+	assert.NotEmpty(t, summary.Threshold.Evaluations)
+	assert.Equal(t, map[string]map[string]any{
+		"browse":   {"executor": "shared-iterations", "startTimeMs": 0.0, "items": 3.0, "iterations": 3.0},
+		"checkout": {"executor": "per-vu-iterations", "startTimeMs": 100.0, "items": 10.0, "iterations": 1.0},
+	}, summary.Scenarios)
+}
+
+func TestThresholdsFrom(t *testing.T) {
+	t.Parallel()
+
+	const central = `{"thresholds": {
+		"checks": ["rate==0"],
+		"iterations": ["count<1"],
+		"data_sent": ["count==0"]
+	}}`
+	script := `
+		import { check } from 'k6';
+
+		export const options = {
+			iterations: 1,
+			thresholds: { checks: ["rate==1"] },
+		};
+
+		export default function () {
+			check(true, { 'is true': (v) => v });
+		}
+	`
+
+	assertSynced := func(t *testing.T, ts *GlobalTestState) {
+		cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+		stdout := ts.Stdout.String()
+		assert.Contains(t, stdout, "The test overrides the central thresholds of the metrics 'checks'")
+		assert.Contains(t, stdout, `level=error msg="thresholds on metrics 'iterations' have been crossed"`)
+		assert.Contains(t, stdout, "✓ checks")
+		assert.Contains(t, stdout, "✓ data_sent")
+		assert.Contains(t, stdout, "✗ iterations")
+	}
+
+	t.Run("url", func(t *testing.T) {
+		t.Parallel()
+
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = w.Write([]byte(central))
+		}))
+		t.Cleanup(srv.Close)
+
+		ts := getSingleFileTestState(t, script,
+			[]string{"-v", "--log-output=stdout", "--thresholds-from", srv.URL}, exitcodes.ThresholdsHaveFailed)
+		assertSynced(t, ts)
+	})
+
+	t.Run("file", func(t *testing.T) {
+		t.Parallel()
+
+		ts := getSingleFileTestState(t, script, nil, exitcodes.ThresholdsHaveFailed)
+		require.NoError(t, fsext.WriteFile(ts.FS, filepath.Join(ts.Cwd, "criteria.json"), []byte(central), 0o644))
+		ts.Env["K6_THRESHOLDS_FROM"] = "criteria.json"
+		assertSynced(t, ts)
+	})
+
+	t.Run("unreachable", func(t *testing.T) {
+		t.Parallel()
+
+		ts := getSingleFileTestState(t, script,
+			[]string{"--thresholds-from", "missing.json"}, exitcodes.InvalidConfig)
+		cmd.ExecuteWithGlobalState(ts.GlobalState)
+		assert.Contains(t, ts.Stderr.String(), "couldn't read the thresholds from")
+	})
+}
+
+func TestAuthSessions(t *testing.T) {
+	t.Parallel()
+
+	tb := httpmultibin.NewHTTPMultiBin(t)
+	var tokenRequests atomic.Int64
+	tb.Mux.HandleFunc("/oauth/token", func(w http.ResponseWriter, r *http.Request) {
+		tokenRequests.Add(1)
+		if r.FormValue("grant_type") != "client_credentials" || r.FormValue("client_id") != "k6" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write([]byte(`{"access_token":"secret-token","expires_in":3600}`))
+	})
+	tb.Mux.HandleFunc("/protected", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Header.Get("Authorization")))
+	})
+
+	script := tb.Replacer.Replace(`
+		import http from 'k6/http';
+		import { check } from 'k6';
+
+		export const options = {
+			vus: 2,
+			iterations: 6,
+			thresholds: { checks: ["rate==1"] },
+			hosts: {
+				"HTTPBIN_DOMAIN": "HTTPBIN_IP",
+			},
+			authSessions: {
+				api: {
+					flow: "client_credentials",
+					tokenURL: "HTTPBIN_URL/oauth/token",
+					clientID: "k6",
+					hosts: ["HTTPBIN_DOMAIN"],
+				},
+			},
+		};
+
+		export default function () {
+			check(http.get('HTTPBIN_URL/protected'), {
+				'the token is added': (r) => r.body === 'Bearer secret-token',
+			});
+			check(http.get('HTTPBIN_URL/protected', { headers: { Authorization: 'Basic xyz' } }), {
+				'an explicit authorization is kept': (r) => r.body === 'Basic xyz',
+			});
+		}
+	`)
+
+	ts := getSingleFileTestState(t, script, nil, 0)
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+	assert.Equal(t, int64(1), tokenRequests.Load(), "the shared token is requested once")
+}
+
+func TestScriptedExecutor(t *testing.T) {
+	t.Parallel()
+
+	script := `
+		const arrivals = [0, 50, 100];
+		export const options = {
+			scenarios: {
+				replay: {
+					executor: "experimental-scripted",
+					nextIteration: "nextArrival",
+					duration: "5s",
+					preAllocatedVUs: 2,
+				},
+			},
+			thresholds: { iterations: ["count == 3"] },
+		};
+		export function nextArrival(ctx) {
+			return ctx.iteration < arrivals.length ? arrivals[ctx.iteration] : null;
+		}
+		export default function () {}
+	`
+	ts := getSingleFileTestState(t, script, nil, 0)
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+	stdout := ts.Stdout.String()
+	assert.Contains(t, stdout, "replay: Iterations scheduled by nextArrival() for 5s")
+	assert.Contains(t, stdout, "3 complete and 0 interrupted iterations")
+
+	ts = getSingleFileTestState(t, strings.ReplaceAll(script, `"nextArrival"`, `"missing"`), nil,
+		exitcodes.InvalidConfig)
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+	assert.Contains(t, ts.Stdout.String(), "executor replay: function 'missing' not found in exports")
+}
@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"go.k6.io/k6/errext"
+	"go.k6.io/k6/errext/exitcodes"
+	"go.k6.io/k6/lib/fsext"
+	"go.k6.io/k6/metrics"
+)
+
+// thresholdsFetchTimeout is how long fetching the thresholds from a URL can
+// take at most.
+const thresholdsFetchTimeout = 30 * time.Second
+
+// centralThresholds is the document with the centrally governed thresholds,
+// which --thresholds-from reads. It has the same shape as the options, so the
+// definitions can be exported from a test, or served by a test management
+// service.
+type centralThresholds struct {
+	Thresholds map[string]metrics.Thresholds `json:"thresholds"`
+}
+
+// readCentralThresholds reads the thresholds from the source, which is either
+// an http(s) URL or the path of a file, relative to the working directory.
+func readCentralThresholds(
+	ctx context.Context, fs fsext.Fs, pwd, source string,
+) (map[string]metrics.Thresholds, error) {
+	var (
+		data []byte
+		err  error
+	)
+	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		data, err = fetchCentralThresholds(ctx, source)
+	} else {
+		if !filepath.IsAbs(source) {
+			source = filepath.Join(pwd, source)
+		}
+		data, err = fsext.ReadFile(fs, source)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read the thresholds from '%s': %w", source, err)
+	}
+
+	var doc centralThresholds
+	if err = json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("couldn't parse the thresholds from '%s': %w", source, err)
+	}
+	return doc.Thresholds, nil
+}
+
+func fetchCentralThresholds(ctx context.Context, url string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, thresholdsFetchTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = res.Body.Close() }()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected response status %s", res.Status)
+	}
+	return io.ReadAll(res.Body)
+}
+
+// mergeCentralThresholds returns the central thresholds of the metrics, for
+// which the test doesn't define its own, together with the local ones, which
+// have precedence, so a script can still tighten or relax a single criterion.
+func mergeCentralThresholds(
+	logger logrus.FieldLogger, local, central map[string]metrics.Thresholds,
+) map[string]metrics.Thresholds {
+	merged := make(map[string]metrics.Thresholds, len(local)+len(central))
+	var overridden []string
+	for name, thresholds := range central {
+		if _, ok := local[name]; ok {
+			overridden = append(overridden, name)
+			continue
+		}
+		merged[name] = thresholds
+	}
+	for name, thresholds := range local {
+		merged[name] = thresholds
+	}
+
+	if len(overridden) > 0 {
+		sort.Strings(overridden)
+		logger.Warnf("The test overrides the central thresholds of the metrics '%s'",
+			strings.Join(overridden, "', '"))
+	}
+	return merged
+}
+
+// syncCentralThresholds merges the thresholds from the --thresholds-from
+// source, if there is one, into the consolidated config.
+func (lt *loadedTest) syncCentralThresholds(ctx context.Context, logger logrus.FieldLogger, conf *Config) error {
+	if !conf.ThresholdsFrom.Valid || conf.ThresholdsFrom.String == "" {
+		return nil
+	}
+
+	logger.Debugf("Reading the central thresholds from '%s'...", conf.ThresholdsFrom.String)
+	central, err := readCentralThresholds(ctx, lt.fs, lt.pwd, conf.ThresholdsFrom.String)
+	if err != nil {
+		return errext.WithExitCodeIfNone(err, exitcodes.InvalidConfig)
+	}
+	conf.Options.Thresholds = mergeCentralThresholds(logger, conf.Options.Thresholds, central)
+	return nil
+}
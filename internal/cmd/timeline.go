@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/spf13/pflag"
+
+	"go.k6.io/k6/cmd/state"
+	"go.k6.io/k6/errext"
+	"go.k6.io/k6/errext/exitcodes"
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/executor"
+	"go.k6.io/k6/lib/fsext"
+)
+
+func timelineFlagSet() *pflag.FlagSet {
+	flags := pflag.NewFlagSet("", 0)
+	flags.SortFlags = false
+	flags.String("export-timeline", "", "export the achieved start times of the iterations of the scenarios to a JSON `file`")
+	flags.String("replay-timeline", "",
+		"replay the start times of the iterations of the scenarios from a `file` exported with --export-timeline")
+	return flags
+}
+
+// getTimelineFlag returns the value of a timeline flag, or an empty string for
+// the commands which don't have it.
+func getTimelineFlag(flags *pflag.FlagSet, name string) string {
+	value, err := flags.GetString(name)
+	if err != nil {
+		return ""
+	}
+	return value
+}
+
+// replayTimeline replaces the executors of the scenarios in the timeline file
+// with the timeline-replay executor, which starts their iterations at the same
+// offsets. Their other options, e.g. their startTime or exec, are kept.
+func replayTimeline(gs *state.GlobalState, path string, test *loadedAndConfiguredTest) error {
+	data, err := fsext.ReadFile(gs.FS, path)
+	if err != nil {
+		return fmt.Errorf("couldn't read the timeline to replay: %w", err)
+	}
+	timeline, err := lib.ParseTimeline(data)
+	if err != nil {
+		return errext.WithExitCodeIfNone(err, exitcodes.InvalidConfig)
+	}
+
+	scenarios := make(lib.ScenarioConfigs, len(test.derivedConfig.Scenarios))
+	for name, conf := range test.derivedConfig.Scenarios {
+		scenarios[name] = conf
+	}
+	names := make([]string, 0, len(timeline.Scenarios))
+	for name := range timeline.Scenarios {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		conf, ok := scenarios[name]
+		if !ok {
+			return errext.WithExitCodeIfNone(
+				fmt.Errorf("the scenario %s of the timeline to replay isn't in the test", name), exitcodes.InvalidConfig)
+		}
+		replayConf, err := executor.NewTimelineReplayConfigFrom(conf, timeline.Scenarios[name])
+		if err != nil {
+			return errext.WithExitCodeIfNone(err, exitcodes.InvalidConfig)
+		}
+		if errs := replayConf.Validate(); len(errs) > 0 {
+			return errext.WithExitCodeIfNone(
+				consolidateErrorMessage(errs, "The timeline of the scenario "+name+" can't be replayed:"),
+				exitcodes.InvalidConfig)
+		}
+		scenarios[name] = replayConf
+		gs.Logger.Debugf("Replaying %d iterations of the scenario %s", len(replayConf.Iterations), name)
+	}
+	test.derivedConfig.Scenarios = scenarios
+	return nil
+}
+
+// exportTimeline writes the recorded timeline to the file.
+func exportTimeline(gs *state.GlobalState, path string, timeline *lib.Timeline) error {
+	data, err := json.Marshal(timeline)
+	if err != nil {
+		return err
+	}
+	if err := fsext.WriteFile(gs.FS, path, data, 0o644); err != nil { //nolint:gosec
+		return fmt.Errorf("couldn't export the timeline: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,338 @@
+package correlation
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// minValueLength is the length of the shortest values, which are correlated,
+// since shorter ones are found by chance in the requests.
+const minValueLength = 4
+
+// LocationKind is the kind of the part of a response, which has a value.
+type LocationKind string
+
+// The kinds of the locations of the values.
+const (
+	// JSONValue is a value of the JSON body, at the path of the key.
+	JSONValue LocationKind = "json"
+	// HeaderValue is the value of the header, which is the key.
+	HeaderValue LocationKind = "header"
+	// InputValue is the value of the input of an HTML form, with the name,
+	// which is the key.
+	InputValue LocationKind = "input"
+	// MetaValue is the content of the meta tag of an HTML page, with the name,
+	// which is the key.
+	MetaValue LocationKind = "meta"
+)
+
+// Location is the location of a value in a response.
+type Location struct {
+	Kind LocationKind
+	Key  string
+}
+
+// Use is a part of a request, which sends a value.
+type Use struct {
+	// Request is the index of the exchange of the request.
+	Request int
+	// Part is "url", "body" or the name of the header.
+	Part string
+}
+
+// Correlation is a dynamic value, which a response received and the later
+// requests sent back.
+type Correlation struct {
+	// Name of the variable of the value in the snippets.
+	Name string
+	// Source is the index of the exchange, which received the value.
+	Source int
+	// Location of the value in the response.
+	Location Location
+	// Values in the first and the second recording.
+	Values [2]string
+	// Uses of the value by the requests of the first recording.
+	Uses []Use
+}
+
+// Find compares the recordings of the same user flow and returns the values,
+// which are received by a response in both of them, but are different, and
+// which are sent back by the later requests in both of them. The cookies
+// aren't correlated, since they are sent back by the cookie jars of the VUs.
+func Find(first, second []Exchange) []Correlation {
+	var correlations []Correlation
+	names := make(map[string]bool)
+	seen := make(map[string]bool)
+	for _, pair := range align(first, second) {
+		i, j := pair[0], pair[1]
+		values := responseValues(first[i])
+		others := make(map[Location]string)
+		for _, v := range responseValues(second[j]) {
+			if _, ok := others[v.Location]; !ok {
+				others[v.Location] = v.value
+			}
+		}
+
+		for _, v := range values {
+			other, ok := others[v.Location]
+			if !ok || other == v.value || len(v.value) < minValueLength || seen[v.value] {
+				continue
+			}
+			uses := findUses(first, i+1, v.value)
+			if len(uses) == 0 || len(findUses(second, j+1, other)) == 0 {
+				continue
+			}
+			seen[v.value] = true
+			correlations = append(correlations, Correlation{
+				Name:     variableName(v.Location, names),
+				Source:   i,
+				Location: v.Location,
+				Values:   [2]string{v.value, other},
+				Uses:     uses,
+			})
+		}
+	}
+	return correlations
+}
+
+// exchangeKey is the key of the exchanges, which are aligned. Their paths can
+// have dynamic values, like IDs, so the segments with digits aren't compared.
+func exchangeKey(e Exchange) string {
+	segments := strings.Split(e.path(), "/")
+	for i, segment := range segments {
+		if strings.ContainsFunc(segment, unicode.IsDigit) {
+			segments[i] = "*"
+		}
+	}
+	return e.Method + " " + e.host() + " " + strings.Join(segments, "/")
+}
+
+// align returns the indexes of the matching exchanges of the recordings, which
+// are the longest common subsequence of their keys.
+func align(first, second []Exchange) [][2]int {
+	keys := func(exchanges []Exchange) []string {
+		k := make([]string, len(exchanges))
+		for i, e := range exchanges {
+			k[i] = exchangeKey(e)
+		}
+		return k
+	}
+	a, b := keys(first), keys(second)
+
+	// lengths[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lengths := make([][]int, len(a)+1)
+	for i := range lengths {
+		lengths[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lengths[i][j] = lengths[i+1][j+1] + 1
+			} else {
+				lengths[i][j] = max(lengths[i+1][j], lengths[i][j+1])
+			}
+		}
+	}
+
+	var pairs [][2]int
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i] == b[j]:
+			pairs = append(pairs, [2]int{i, j})
+			i++
+			j++
+		case lengths[i+1][j] >= lengths[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+	return pairs
+}
+
+type responseValue struct {
+	Location
+	value string
+}
+
+// responseValues returns the values of the response, in the order of their
+// locations in it.
+func responseValues(e Exchange) []responseValue {
+	var values []responseValue
+
+	headers := make([]string, 0, len(e.ResponseHeader))
+	for name := range e.ResponseHeader {
+		headers = append(headers, name)
+	}
+	sort.Strings(headers)
+	for _, name := range headers {
+		// the redirects are followed and the cookies are sent back by the VUs
+		if name == "Set-Cookie" || (name == "Location" && e.Status >= 300 && e.Status < 400) {
+			continue
+		}
+		values = append(values, responseValue{Location{HeaderValue, name}, e.ResponseHeader.Get(name)})
+	}
+
+	body := strings.TrimSpace(e.ResponseBody)
+	switch {
+	case strings.HasPrefix(body, "{") || strings.HasPrefix(body, "["):
+		dec := json.NewDecoder(strings.NewReader(body))
+		dec.UseNumber()
+		var v any
+		if dec.Decode(&v) == nil {
+			values = appendJSONValues(values, "", v)
+		}
+	case strings.HasPrefix(body, "<"):
+		values = appendHTMLValues(values, body)
+	}
+	return values
+}
+
+// appendJSONValues appends the scalar values of the JSON value, at the paths
+// of the selectors of Response.json() of the k6/http module.
+func appendJSONValues(values []responseValue, path string, v any) []responseValue {
+	join := func(key string) string {
+		if path == "" {
+			return key
+		}
+		return path + "." + key
+	}
+
+	switch v := v.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			values = appendJSONValues(values, join(escapeJSONPathKey(k)), v[k])
+		}
+	case []any:
+		for i, e := range v {
+			values = appendJSONValues(values, join(strconv.Itoa(i)), e)
+		}
+	case string:
+		values = append(values, responseValue{Location{JSONValue, path}, v})
+	case json.Number:
+		values = append(values, responseValue{Location{JSONValue, path}, v.String()})
+	}
+	return values
+}
+
+// splitJSONPath splits the path of a selector to its unescaped keys.
+func splitJSONPath(path string) []string {
+	var keys []string
+	var key strings.Builder
+	escaped := false
+	for _, r := range path {
+		switch {
+		case escaped:
+			key.WriteRune(r)
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == '.':
+			keys = append(keys, key.String())
+			key.Reset()
+		default:
+			key.WriteRune(r)
+		}
+	}
+	return append(keys, key.String())
+}
+
+// escapeJSONPathKey escapes the characters of the key, which are special in
+// the paths of the selectors.
+func escapeJSONPathKey(key string) string {
+	var b strings.Builder
+	for _, r := range key {
+		if strings.ContainsRune(`.*?|#@!\`, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// appendHTMLValues appends the values of the inputs of the forms and of the
+// meta tags of the HTML page.
+func appendHTMLValues(values []responseValue, body string) []responseValue {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(body))
+	if err != nil {
+		return values
+	}
+	doc.Find("input[name][value], meta[name][content]").Each(func(_ int, s *goquery.Selection) {
+		name, _ := s.Attr("name")
+		if goquery.NodeName(s) == "meta" {
+			content, _ := s.Attr("content")
+			values = append(values, responseValue{Location{MetaValue, name}, content})
+			return
+		}
+		value, _ := s.Attr("value")
+		values = append(values, responseValue{Location{InputValue, name}, value})
+	})
+	return values
+}
+
+// findUses returns the parts of the requests, from the one of the exchange
+// with the index, which send the value. The cookies aren't searched, since
+// they are sent back by the cookie jars of the VUs.
+func findUses(exchanges []Exchange, from int, value string) []Use {
+	var uses []Use
+	sends := func(s string) bool {
+		return containsValue(s, value) || containsValue(s, url.QueryEscape(value)) ||
+			containsValue(s, url.PathEscape(value))
+	}
+	for i := from; i < len(exchanges); i++ {
+		e := exchanges[i]
+		if sends(e.URL) {
+			uses = append(uses, Use{Request: i, Part: "url"})
+		}
+
+		headers := make([]string, 0, len(e.RequestHeader))
+		for name := range e.RequestHeader {
+			headers = append(headers, name)
+		}
+		sort.Strings(headers)
+		for _, name := range headers {
+			if name != "Cookie" && sends(strings.Join(e.RequestHeader.Values(name), ", ")) {
+				uses = append(uses, Use{Request: i, Part: http.CanonicalHeaderKey(name)})
+			}
+		}
+
+		if sends(e.RequestBody) {
+			uses = append(uses, Use{Request: i, Part: "body"})
+		}
+	}
+	return uses
+}
+
+// containsValue returns whether the value is in the string, as a whole token,
+// which isn't a part of a longer word or number.
+func containsValue(s, value string) bool {
+	isWordRune := func(r rune) bool {
+		return unicode.IsLetter(r) || unicode.IsDigit(r)
+	}
+	for offset := 0; ; {
+		i := strings.Index(s[offset:], value)
+		if i < 0 {
+			return false
+		}
+		start, end := offset+i, offset+i+len(value)
+		before, _ := utf8.DecodeLastRuneInString(s[:start])
+		after, _ := utf8.DecodeRuneInString(s[end:])
+		if !isWordRune(before) && !isWordRune(after) {
+			return true
+		}
+		offset = start + 1
+	}
+}
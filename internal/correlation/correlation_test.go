@@ -0,0 +1,148 @@
+package correlation
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// harRecording returns a HAR file with a login flow, where the session token,
+// the CSRF token and the order ID are dynamic.
+func harRecording(t *testing.T, csrf, session, orderID string, extra bool) []byte {
+	t.Helper()
+
+	type nv = map[string]string
+	entry := func(method, url string, reqHeaders []nv, postData string, status int, respHeaders []nv, body string) any {
+		request := map[string]any{"method": method, "url": url, "headers": reqHeaders}
+		if postData != "" {
+			request["postData"] = map[string]any{"mimeType": "application/x-www-form-urlencoded", "text": postData}
+		}
+		return map[string]any{
+			"request": request,
+			"response": map[string]any{
+				"status": status, "headers": respHeaders,
+				"content": map[string]any{"text": body},
+			},
+		}
+	}
+
+	entries := []any{
+		entry("GET", "https://test.k6.io/login", nil, "", http.StatusOK,
+			[]nv{{"name": "Set-Cookie", "value": "sid=" + session}},
+			`<html><head><meta name="csrf-token" content="meta`+csrf+`"></head><body><form>
+			<input type="hidden" name="csrf_token" value="`+csrf+`">
+			<input name="user" value="admin">
+			</form></body></html>`),
+		entry("POST", "https://test.k6.io/login", []nv{{"name": "Cookie", "value": "sid=" + session}},
+			"user=admin&csrf_token="+csrf, http.StatusOK,
+			[]nv{{"name": "X-Session-Token", "value": session}, {"name": "Date", "value": "now" + session}},
+			`{"user": {"name": "admin"}, "order": {"id": `+orderID+`, "status": "created"}}`),
+	}
+	if extra {
+		entries = append(entries, entry("GET", "https://test.k6.io/static/app.js", nil, "", http.StatusOK, nil, ""))
+	}
+	entries = append(entries,
+		entry("GET", "https://test.k6.io/orders/"+orderID, []nv{{"name": "Authorization", "value": "Bearer " + session}},
+			"", http.StatusOK, nil, `{}`))
+
+	data, err := json.Marshal(map[string]any{"log": map[string]any{"entries": entries}})
+	require.NoError(t, err)
+	return data
+}
+
+func TestFind(t *testing.T) {
+	t.Parallel()
+
+	first, err := Read(bytes.NewReader(harRecording(t, "Xq2fT9a", "s3ss10n-one", "1234", false)))
+	require.NoError(t, err)
+	second, err := Read(bytes.NewReader(harRecording(t, "Lm7pR2z", "s3ss10n-two", "5678", true)))
+	require.NoError(t, err)
+	require.Len(t, first, 3)
+	require.Len(t, second, 4)
+
+	assert.Equal(t, [][2]int{{0, 0}, {1, 1}, {2, 3}}, align(first, second))
+
+	correlations := Find(first, second)
+	assert.Equal(t, []Correlation{
+		{
+			Name:     "csrfToken",
+			Source:   0,
+			Location: Location{InputValue, "csrf_token"},
+			Values:   [2]string{"Xq2fT9a", "Lm7pR2z"},
+			Uses:     []Use{{Request: 1, Part: "body"}},
+		},
+		{
+			Name:     "xSessionToken",
+			Source:   1,
+			Location: Location{HeaderValue, "X-Session-Token"},
+			Values:   [2]string{"s3ss10n-one", "s3ss10n-two"},
+			Uses:     []Use{{Request: 2, Part: "Authorization"}},
+		},
+		{
+			Name:     "id",
+			Source:   1,
+			Location: Location{JSONValue, "order.id"},
+			Values:   [2]string{"1234", "5678"},
+			Uses:     []Use{{Request: 2, Part: "url"}},
+		},
+	}, correlations)
+
+	var report bytes.Buffer
+	require.NoError(t, WriteReport(&report, first, correlations))
+	assert.Contains(t, report.String(), "Found 3 dynamic values to correlate.")
+	assert.Contains(t, report.String(), `// csrfToken: the form input "csrf_token" of the response to `+
+		"request 1 (GET https://test.k6.io/login),\n"+
+		`// recorded as "Xq2fT9a" and "Lm7pR2z", which is sent by:`+"\n"+
+		"//   request 2 (POST https://test.k6.io/login) in the body\n"+
+		`const csrfToken = res.html().find("input[name=\"csrf_token\"]").first().attr("value");`+"\n"+
+		`check(res, { "csrfToken is extracted": () => csrfToken !== undefined });`)
+	assert.Contains(t, report.String(), `const xSessionToken = res.headers["X-Session-Token"];`)
+	assert.Contains(t, report.String(), `const id = res.json("order.id");`)
+
+	var empty bytes.Buffer
+	require.NoError(t, WriteReport(&empty, first, Find(first, first)))
+	assert.Equal(t, "No dynamic values to correlate were found.\n", empty.String())
+}
+
+func TestReadInvalidRecording(t *testing.T) {
+	t.Parallel()
+
+	_, err := Read(bytes.NewReader([]byte("not a recording")))
+	assert.ErrorContains(t, err, "the recording is neither a HAR file nor a log")
+}
+
+func TestVariableName(t *testing.T) {
+	t.Parallel()
+
+	names := make(map[string]bool)
+	for _, tc := range []struct {
+		loc  Location
+		name string
+	}{
+		{Location{JSONValue, `data.items.0.orderId`}, "orderId"},
+		{Location{JSONValue, `data.items.1.orderId`}, "orderId2"},
+		{Location{JSONValue, `0.1`}, "value"},
+		{Location{JSONValue, `meta.user\.name`}, "userName"},
+		{Location{HeaderValue, "X-CSRF-Token"}, "xCsrfToken"},
+		{Location{InputValue, "__VIEWSTATE"}, "viewstate"},
+		{Location{InputValue, "2fa"}, "v2fa"},
+		{Location{MetaValue, "class"}, "classValue"},
+	} {
+		assert.Equal(t, tc.name, variableName(tc.loc, names), tc.loc)
+	}
+}
+
+func TestContainsValue(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, containsValue("/orders/1234", "1234"))
+	assert.True(t, containsValue("id=1234&x=12345", "12345"))
+	assert.True(t, containsValue("Bearer s3ss10n", "s3ss10n"))
+	assert.False(t, containsValue("/orders/12345", "1234"))
+	assert.False(t, containsValue("a1234", "1234"))
+	assert.False(t, containsValue("", "1234"))
+}
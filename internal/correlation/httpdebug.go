@@ -0,0 +1,171 @@
+package correlation
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/textproto"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	debugRequestPrefix  = "Request:\n"
+	debugResponsePrefix = "Response:\n"
+)
+
+// debugLogEntry is an entry of the log of a test run, with the dump of an
+// HTTP request or response.
+type debugLogEntry struct {
+	Msg       string `json:"msg"`
+	RequestID string `json:"request_id"`
+}
+
+var requestIDField = regexp.MustCompile(`(?:^|\s)request_id=(\S+)`)
+
+// parseDebugLogLine parses a line of the JSON or the text log format. The text
+// format only quotes the messages, so they are on a single line, when the log
+// isn't written to a terminal.
+func parseDebugLogLine(line string) (debugLogEntry, bool) {
+	var entry debugLogEntry
+	if strings.HasPrefix(line, "{") {
+		return entry, json.Unmarshal([]byte(line), &entry) == nil
+	}
+
+	i := strings.Index(line, " msg=")
+	if i < 0 {
+		return entry, false
+	}
+	quoted, err := strconv.QuotedPrefix(line[i+len(" msg="):])
+	if err != nil {
+		return entry, false
+	}
+	if entry.Msg, err = strconv.Unquote(quoted); err != nil {
+		return entry, false
+	}
+	if m := requestIDField.FindStringSubmatch(line[i+len(" msg=")+len(quoted):]); m != nil {
+		entry.RequestID = m[1]
+	}
+	return entry, true
+}
+
+// readHTTPDebug reads the exchanges of the log of a test run with the
+// --http-debug=full option, where the responses are matched to the requests by
+// their IDs. The dumps don't have the scheme of the URLs, so the URLs of the
+// exchanges don't have one. The compressed response bodies can't be read from
+// the dumps, so they are left empty.
+func readHTTPDebug(r io.Reader) ([]Exchange, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+
+	var exchanges []Exchange
+	pending := make(map[string]int)
+	for n := 1; scanner.Scan(); n++ {
+		entry, ok := parseDebugLogLine(scanner.Text())
+		if !ok {
+			continue
+		}
+		switch {
+		case strings.HasPrefix(entry.Msg, debugRequestPrefix):
+			e, err := parseRequestDump(strings.TrimPrefix(entry.Msg, debugRequestPrefix))
+			if err != nil {
+				return nil, fmt.Errorf("invalid request dump on line %d: %w", n, err)
+			}
+			pending[entry.RequestID] = len(exchanges)
+			exchanges = append(exchanges, e)
+		case strings.HasPrefix(entry.Msg, debugResponsePrefix):
+			i, ok := pending[entry.RequestID]
+			if !ok {
+				continue
+			}
+			delete(pending, entry.RequestID)
+			if err := parseResponseDump(strings.TrimPrefix(entry.Msg, debugResponsePrefix), &exchanges[i]); err != nil {
+				return nil, fmt.Errorf("invalid response dump on line %d: %w", n, err)
+			}
+		}
+	}
+	return exchanges, scanner.Err()
+}
+
+// splitDump splits a dump to its first line, its header and its body.
+func splitDump(dump string) (string, http.Header, string, error) {
+	head, body, _ := strings.Cut(dump, "\n\n")
+	tp := textproto.NewReader(bufio.NewReader(strings.NewReader(head + "\n\n")))
+	line, err := tp.ReadLine()
+	if err != nil {
+		return "", nil, "", err
+	}
+	header, err := tp.ReadMIMEHeader()
+	if err != nil {
+		return "", nil, "", err
+	}
+	// the dumps end with the new line of the log message
+	return line, http.Header(header), strings.TrimSuffix(body, "\n"), nil
+}
+
+// readDumpBody returns the body of a dump, decoding it if it's chunked.
+func readDumpBody(header http.Header, body string) string {
+	if header.Get("Content-Encoding") != "" {
+		return ""
+	}
+	if strings.EqualFold(header.Get("Transfer-Encoding"), "chunked") {
+		return decodeChunks(body)
+	}
+	return body
+}
+
+// decodeChunks decodes the chunked body of a dump. The \r\n of the dumps were
+// replaced with \n, so unlike the standard chunked reader, it accepts either
+// after the chunks, and the chunks with them are shorter than their sizes.
+func decodeChunks(body string) string {
+	var b strings.Builder
+	for {
+		line, rest, ok := strings.Cut(body, "\n")
+		size, _, _ := strings.Cut(strings.TrimSpace(line), ";")
+		n, err := strconv.ParseInt(size, 16, 64)
+		if !ok || err != nil || n <= 0 {
+			return b.String()
+		}
+		n = min(n, int64(len(rest)))
+		b.WriteString(rest[:n])
+		body = strings.TrimPrefix(strings.TrimPrefix(rest[n:], "\r"), "\n")
+	}
+}
+
+func parseRequestDump(dump string) (Exchange, error) {
+	line, header, body, err := splitDump(dump)
+	if err != nil {
+		return Exchange{}, err
+	}
+	method, rest, ok := strings.Cut(line, " ")
+	uri, _, _ := strings.Cut(rest, " ")
+	if !ok || uri == "" {
+		return Exchange{}, fmt.Errorf("malformed request line %q", line)
+	}
+	host := header.Get("Host")
+	header.Del("Host")
+	return Exchange{
+		Method:        method,
+		URL:           "//" + host + uri,
+		RequestHeader: header,
+		RequestBody:   readDumpBody(header, body),
+	}, nil
+}
+
+func parseResponseDump(dump string, e *Exchange) error {
+	line, header, body, err := splitDump(dump)
+	if err != nil {
+		return err
+	}
+	_, status, _ := strings.Cut(line, " ")
+	status, _, _ = strings.Cut(status, " ")
+	if e.Status, err = strconv.Atoi(status); err != nil {
+		return fmt.Errorf("malformed status line %q", line)
+	}
+	e.ResponseHeader = header
+	e.ResponseBody = readDumpBody(header, body)
+	return nil
+}
@@ -0,0 +1,87 @@
+package correlation
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// logHTTPDebug logs the dumps, like the --http-debug=full option does, where
+// the responses are logged in the reverse order of the requests.
+func logHTTPDebug(formatter logrus.Formatter) string {
+	var buf bytes.Buffer
+	logger := logrus.New()
+	logger.SetOutput(&buf)
+	logger.SetFormatter(formatter)
+
+	logger.Info("starting the test run")
+	logger.WithField("request_id", "1").Infof("Request:\n%s\n",
+		"POST /login HTTP/1.1\nHost: test.k6.io\nUser-Agent: k6\nContent-Length: 15\n"+
+			"Content-Type: application/x-www-form-urlencoded\n\nuser=admin&id=1")
+	logger.WithField("request_id", "2").Infof("Request:\n%s\n",
+		"GET /orders/42?page=1 HTTP/1.1\nHost: test.k6.io\nAuthorization: Bearer token\n\n")
+	logger.WithField("request_id", "2").Infof("Response:\n%s\n",
+		"HTTP/1.1 200 OK\nContent-Type: application/json\nContent-Encoding: gzip\n\n\x1f\x8b")
+	logger.WithField("request_id", "1").Infof("Response:\n%s\n",
+		"HTTP/1.1 201 Created\nContent-Type: application/json\nTransfer-Encoding: chunked\n\n"+
+			"d\n{\"token\": 42}\n0\n\n")
+	return buf.String()
+}
+
+func TestReadHTTPDebug(t *testing.T) {
+	t.Parallel()
+
+	expected := []Exchange{
+		{
+			Method: http.MethodPost,
+			URL:    "//test.k6.io/login",
+			RequestHeader: http.Header{
+				"User-Agent":     {"k6"},
+				"Content-Length": {"15"},
+				"Content-Type":   {"application/x-www-form-urlencoded"},
+			},
+			RequestBody: "user=admin&id=1",
+			Status:      http.StatusCreated,
+			ResponseHeader: http.Header{
+				"Content-Type":      {"application/json"},
+				"Transfer-Encoding": {"chunked"},
+			},
+			ResponseBody: `{"token": 42}`,
+		},
+		{
+			Method:        http.MethodGet,
+			URL:           "//test.k6.io/orders/42?page=1",
+			RequestHeader: http.Header{"Authorization": {"Bearer token"}},
+			Status:        http.StatusOK,
+			ResponseHeader: http.Header{
+				"Content-Type":     {"application/json"},
+				"Content-Encoding": {"gzip"},
+			},
+		},
+	}
+
+	for name, formatter := range map[string]logrus.Formatter{
+		"json": &logrus.JSONFormatter{},
+		"text": &logrus.TextFormatter{DisableColors: true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			exchanges, err := Read(strings.NewReader(logHTTPDebug(formatter)))
+			require.NoError(t, err)
+			assert.Equal(t, expected, exchanges)
+		})
+	}
+}
+
+func TestReadHTTPDebugInvalidDump(t *testing.T) {
+	t.Parallel()
+
+	_, err := Read(strings.NewReader(`{"level":"info","msg":"Request:\nGET\n","request_id":"1"}` + "\n"))
+	assert.ErrorContains(t, err, `invalid request dump on line 1: malformed request line "GET"`)
+}
@@ -0,0 +1,131 @@
+// Package correlation finds the dynamic values, like session or CSRF tokens and
+// resource IDs, which a recorded user flow receives in its responses and sends
+// back in its later requests, by comparing two recordings of the same flow.
+// It suggests the code extracting them, which the script of the flow needs.
+package correlation
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// Exchange is a recorded HTTP request and its response.
+type Exchange struct {
+	Method         string
+	URL            string
+	RequestHeader  http.Header
+	RequestBody    string
+	Status         int
+	ResponseHeader http.Header
+	ResponseBody   string
+}
+
+// host returns the host of the URL of the request.
+func (e Exchange) host() string {
+	u, err := url.Parse(e.URL)
+	if err != nil {
+		return ""
+	}
+	return u.Host
+}
+
+// path returns the path of the URL of the request.
+func (e Exchange) path() string {
+	u, err := url.Parse(e.URL)
+	if err != nil {
+		return e.URL
+	}
+	return u.Path
+}
+
+// Read reads a recording, which is either a HAR file or the log of a test run
+// with the --http-debug=full option, in the JSON or text log format.
+func Read(r io.Reader) ([]Exchange, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if exchanges, ok := readHAR(data); ok {
+		return exchanges, nil
+	}
+	exchanges, err := readHTTPDebug(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if len(exchanges) == 0 {
+		return nil, errors.New("the recording is neither a HAR file nor a log with the HTTP requests " +
+			"of the --http-debug=full option, in the JSON or text log format")
+	}
+	return exchanges, nil
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harFile struct {
+	Log *struct {
+		Entries []struct {
+			Request struct {
+				Method   string         `json:"method"`
+				URL      string         `json:"url"`
+				Headers  []harNameValue `json:"headers"`
+				PostData *struct {
+					Text string `json:"text"`
+				} `json:"postData"`
+			} `json:"request"`
+			Response struct {
+				Status  int            `json:"status"`
+				Headers []harNameValue `json:"headers"`
+				Content struct {
+					Text     string `json:"text"`
+					Encoding string `json:"encoding"`
+				} `json:"content"`
+			} `json:"response"`
+		} `json:"entries"`
+	} `json:"log"`
+}
+
+// readHAR reads the exchanges of a HAR file, and returns whether it was one.
+func readHAR(data []byte) ([]Exchange, bool) {
+	var har harFile
+	if err := json.Unmarshal(data, &har); err != nil || har.Log == nil {
+		return nil, false
+	}
+
+	header := func(values []harNameValue) http.Header {
+		h := make(http.Header, len(values))
+		for _, v := range values {
+			h.Add(v.Name, v.Value)
+		}
+		return h
+	}
+
+	exchanges := make([]Exchange, 0, len(har.Log.Entries))
+	for _, entry := range har.Log.Entries {
+		e := Exchange{
+			Method:         entry.Request.Method,
+			URL:            entry.Request.URL,
+			RequestHeader:  header(entry.Request.Headers),
+			Status:         entry.Response.Status,
+			ResponseHeader: header(entry.Response.Headers),
+			ResponseBody:   entry.Response.Content.Text,
+		}
+		if entry.Request.PostData != nil {
+			e.RequestBody = entry.Request.PostData.Text
+		}
+		if entry.Response.Content.Encoding == "base64" {
+			// an invalid body can't have values to correlate, so it's left empty
+			body, _ := base64.StdEncoding.DecodeString(e.ResponseBody)
+			e.ResponseBody = string(body)
+		}
+		exchanges = append(exchanges, e)
+	}
+	return exchanges, true
+}
@@ -0,0 +1,138 @@
+package correlation
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// reservedWords are the JavaScript words, which can't be the names of the
+// variables.
+var reservedWords = map[string]bool{ //nolint:gochecknoglobals
+	"await": true, "break": true, "case": true, "catch": true, "class": true, "const": true,
+	"continue": true, "debugger": true, "default": true, "delete": true, "do": true, "else": true,
+	"enum": true, "export": true, "extends": true, "false": true, "finally": true, "for": true,
+	"function": true, "if": true, "import": true, "in": true, "instanceof": true, "let": true,
+	"new": true, "null": true, "return": true, "super": true, "switch": true, "this": true,
+	"throw": true, "true": true, "try": true, "typeof": true, "var": true, "void": true,
+	"while": true, "with": true, "yield": true, "res": true, "check": true,
+}
+
+// variableName returns a unique camel case name of the variable of the value at
+// the location, which isn't one of the names, and adds it to them.
+func variableName(loc Location, names map[string]bool) string {
+	key := loc.Key
+	if loc.Kind == JSONValue {
+		// the last key of the path, which isn't an index of an array
+		segments := splitJSONPath(key)
+		key = ""
+		for i := len(segments) - 1; i >= 0; i-- {
+			if _, err := strconv.Atoi(segments[i]); err != nil {
+				key = segments[i]
+				break
+			}
+		}
+	}
+
+	words := strings.FieldsFunc(key, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	var b strings.Builder
+	for i, word := range words {
+		if strings.ToUpper(word) == word {
+			word = strings.ToLower(word)
+		}
+		runes := []rune(word)
+		if i == 0 {
+			runes[0] = unicode.ToLower(runes[0])
+		} else {
+			runes[0] = unicode.ToUpper(runes[0])
+		}
+		b.WriteString(string(runes))
+	}
+
+	name := b.String()
+	switch {
+	case name == "":
+		name = "value"
+	case unicode.IsDigit([]rune(name)[0]):
+		name = "v" + name
+	case reservedWords[name]:
+		name += "Value"
+	}
+	unique := name
+	for n := 2; names[unique]; n++ {
+		unique = name + strconv.Itoa(n)
+	}
+	names[unique] = true
+	return unique
+}
+
+// Snippet returns the code, which extracts the value from the response in the
+// res variable, and checks that it did.
+func (c Correlation) Snippet() string {
+	var extraction string
+	switch c.Location.Kind {
+	case JSONValue:
+		extraction = fmt.Sprintf("res.json(%s)", strconv.Quote(c.Location.Key))
+	case HeaderValue:
+		extraction = fmt.Sprintf("res.headers[%s]", strconv.Quote(http.CanonicalHeaderKey(c.Location.Key)))
+	case InputValue:
+		extraction = fmt.Sprintf(`res.html().find(%s).first().attr("value")`,
+			strconv.Quote("input[name="+strconv.Quote(c.Location.Key)+"]"))
+	case MetaValue:
+		extraction = fmt.Sprintf(`res.html().find(%s).first().attr("content")`,
+			strconv.Quote("meta[name="+strconv.Quote(c.Location.Key)+"]"))
+	}
+	return fmt.Sprintf("const %s = %s;\ncheck(res, { %s: () => %s !== undefined });\n",
+		c.Name, extraction, strconv.Quote(c.Name+" is extracted"), c.Name)
+}
+
+// describe returns the description of the location.
+func (loc Location) describe() string {
+	switch loc.Kind {
+	case JSONValue:
+		return fmt.Sprintf("the JSON value %q", loc.Key)
+	case HeaderValue:
+		return fmt.Sprintf("the header %q", loc.Key)
+	case InputValue:
+		return fmt.Sprintf("the form input %q", loc.Key)
+	default:
+		return fmt.Sprintf("the meta tag %q", loc.Key)
+	}
+}
+
+// WriteReport writes the correlations of the recording, with the snippets
+// extracting their values and the requests, which have to send them instead
+// of the recorded ones.
+func WriteReport(w io.Writer, recording []Exchange, correlations []Correlation) error {
+	if len(correlations) == 0 {
+		_, err := fmt.Fprintln(w, "No dynamic values to correlate were found.")
+		return err
+	}
+
+	describe := func(i int) string {
+		return fmt.Sprintf("request %d (%s %s)", i+1, recording[i].Method, recording[i].URL)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Found %d dynamic values to correlate.\n", len(correlations))
+	for _, c := range correlations {
+		fmt.Fprintf(&b, "\n// %s: %s of the response to %s,\n", c.Name, c.Location.describe(), describe(c.Source))
+		fmt.Fprintf(&b, "// recorded as %q and %q, which is sent by:\n", c.Values[0], c.Values[1])
+		for i := 0; i < len(c.Uses); {
+			request := c.Uses[i].Request
+			var parts []string
+			for ; i < len(c.Uses) && c.Uses[i].Request == request; i++ {
+				parts = append(parts, c.Uses[i].Part)
+			}
+			fmt.Fprintf(&b, "//   %s in the %s\n", describe(request), strings.Join(parts, ", "))
+		}
+		b.WriteString(c.Snippet())
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
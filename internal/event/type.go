@@ -18,6 +18,16 @@ const (
 	IterEnd
 	// Exit is emitted when the k6 process is about to exit.
 	Exit
+	// ScenarioStart is emitted when a scenario starts running, after its startTime.
+	ScenarioStart
+	// ScenarioEnd is emitted when a scenario finishes running.
+	ScenarioEnd
+	// ThresholdCrossed is emitted when the thresholds of a metric fail, after
+	// they were passing or weren't evaluated before.
+	ThresholdCrossed
+	// Abort is emitted when the test run is aborted, e.g. by test.abort(), by a
+	// threshold with abortOnFail or by the REST API.
+	Abort
 )
 
 //nolint:gochecknoglobals
@@ -26,6 +36,9 @@ var (
 	GlobalEvents = []Type{Init, TestStart, TestEnd, Exit}
 	// VUEvents are emitted multiple times per each VU.
 	VUEvents = []Type{IterStart, IterEnd}
+	// LifecycleEvents are emitted during the test run, as the scenarios and
+	// the thresholds change their states.
+	LifecycleEvents = []Type{ScenarioStart, ScenarioEnd, ThresholdCrossed, Abort}
 )
 
 // ExitData is the data sent in the Exit event. Error is the error returned by
@@ -41,3 +54,25 @@ type IterData struct {
 	ScenarioName string
 	Error        error
 }
+
+// ScenarioData is the data sent in the ScenarioStart and ScenarioEnd events.
+// Error is the error returned by the executor of the scenario, if any.
+type ScenarioData struct {
+	Name     string
+	Executor string
+	Error    error
+}
+
+// ThresholdData is the data sent in the ThresholdCrossed event. Thresholds are
+// the sources of the thresholds of the metric that failed.
+type ThresholdData struct {
+	Metric      string
+	Thresholds  []string
+	AbortOnFail bool
+}
+
+// AbortData is the data sent in the Abort event. Reason is the error that the
+// test run was aborted with.
+type AbortData struct {
+	Reason error
+}
@@ -6,9 +6,9 @@ import (
 	"fmt"
 )
 
-const _TypeName = "InitTestStartTestEndIterStartIterEndExit"
+const _TypeName = "InitTestStartTestEndIterStartIterEndExitScenarioStartScenarioEndThresholdCrossedAbort"
 
-var _TypeIndex = [...]uint8{0, 4, 13, 20, 29, 36, 40}
+var _TypeIndex = [...]uint8{0, 4, 13, 20, 29, 36, 40, 53, 64, 80, 85}
 
 func (i Type) String() string {
 	i -= 1
@@ -18,7 +18,7 @@ func (i Type) String() string {
 	return _TypeName[_TypeIndex[i]:_TypeIndex[i+1]]
 }
 
-var _TypeValues = []Type{1, 2, 3, 4, 5, 6}
+var _TypeValues = []Type{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
 
 var _TypeNameToValueMap = map[string]Type{
 	_TypeName[0:4]:   1,
@@ -27,6 +27,10 @@ var _TypeNameToValueMap = map[string]Type{
 	_TypeName[20:29]: 4,
 	_TypeName[29:36]: 5,
 	_TypeName[36:40]: 6,
+	_TypeName[40:53]: 7,
+	_TypeName[53:64]: 8,
+	_TypeName[64:80]: 9,
+	_TypeName[80:85]: 10,
 }
 
 // TypeString retrieves an enum value from the enum constants string name.
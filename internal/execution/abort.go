@@ -5,6 +5,8 @@ import (
 	"sync"
 
 	"github.com/sirupsen/logrus"
+
+	"go.k6.io/k6/internal/event"
 )
 
 // testAbortKey is the key used to store the abort function for the context of
@@ -15,6 +17,7 @@ type testAbortKey struct{}
 
 type testAbortController struct {
 	cancel context.CancelFunc
+	events *event.System
 
 	logger logrus.FieldLogger
 	lock   sync.Mutex // only the first reason will be kept, other will be logged
@@ -23,16 +26,21 @@ type testAbortController struct {
 
 func (tac *testAbortController) abort(err error) {
 	tac.lock.Lock()
-	defer tac.lock.Unlock()
 	if tac.reason != nil {
 		tac.logger.Debugf(
 			"test abort with reason '%s' was attempted when the test was already aborted due to '%s'",
 			err.Error(), tac.reason.Error(),
 		)
+		tac.lock.Unlock()
 		return
 	}
 	tac.reason = err
 	tac.cancel()
+	tac.lock.Unlock()
+
+	if tac.events != nil {
+		tac.events.Emit(&event.Event{Type: event.Abort, Data: &event.AbortData{Reason: err}})
+	}
 }
 
 func (tac *testAbortController) getReason() error {
@@ -45,14 +53,16 @@ func (tac *testAbortController) getReason() error {
 // returned TestAbortFunc or by calling CancelTestRunContext() on the returned
 // context or a sub-context of it. Use this to initialize the context that will
 // be passed to the ExecutionScheduler, so `execution.test.abort()` and the REST
-// API test stopping both work.
+// API test stopping both work. If events isn't nil, the first abort is emitted
+// as the Abort event.
 func NewTestRunContext(
-	ctx context.Context, logger logrus.FieldLogger,
+	ctx context.Context, logger logrus.FieldLogger, events *event.System,
 ) (newCtx context.Context, abortTest func(reason error)) {
 	ctx, cancel := context.WithCancel(ctx)
 
 	controller := &testAbortController{
 		cancel: cancel,
+		events: events,
 		logger: logger,
 	}
 
@@ -0,0 +1,285 @@
+package execution
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"go.k6.io/k6/lib"
+)
+
+// defaultHealthGateInterval is the time between the probes of a health gate,
+// if its interval isn't set.
+const defaultHealthGateInterval = 5 * time.Second
+
+// gatedScenario is the state of a scenario with a health gate.
+type gatedScenario struct {
+	gate    lib.HealthGate
+	healthy bool
+	// running is the number of the running iterations and limit is how many
+	// of them can run while the system under test is degraded.
+	running, limit int64
+	changed        chan struct{} // closed when healthy, running or limit change
+}
+
+// notify wakes up the iterations waiting for a change of the scenario.
+func (gs *gatedScenario) notify() {
+	close(gs.changed)
+	gs.changed = make(chan struct{})
+}
+
+// healthGateMonitor periodically probes the health of the system under test
+// for the scenarios with health gates, and pauses or holds their iterations
+// while it's degraded.
+type healthGateMonitor struct {
+	client *http.Client
+	logger logrus.FieldLogger
+
+	mx        sync.Mutex
+	scenarios map[string]*gatedScenario
+}
+
+// newHealthGateMonitor returns a monitor for the scenarios with health gates,
+// or nil if there aren't any.
+func newHealthGateMonitor(state *lib.ExecutionState, configs []lib.ExecutorConfig) *healthGateMonitor {
+	scenarios := make(map[string]*gatedScenario)
+	for _, config := range configs {
+		if so := config.GetScenarioOptions(); so != nil && so.HealthGate != nil {
+			scenarios[config.GetName()] = &gatedScenario{
+				gate:    *so.HealthGate,
+				healthy: true,
+				changed: make(chan struct{}),
+			}
+		}
+	}
+	if len(scenarios) == 0 {
+		return nil
+	}
+	return &healthGateMonitor{
+		client:    &http.Client{},
+		logger:    state.Test.Logger.WithField("component", "health-gate"),
+		scenarios: scenarios,
+	}
+}
+
+// throttle blocks while the system under test is degraded and the scenario
+// can't start more iterations, or until the context is done. The returned
+// function should be called when the iteration ends.
+func (hm *healthGateMonitor) throttle(ctx context.Context, scenario string) func() {
+	hm.mx.Lock()
+	defer hm.mx.Unlock()
+	gs, ok := hm.scenarios[scenario]
+	if !ok {
+		return nil
+	}
+	for !gs.healthy && gs.running >= gs.limit {
+		changed := gs.changed
+		hm.mx.Unlock()
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			hm.mx.Lock()
+			return nil
+		}
+		hm.mx.Lock()
+	}
+
+	gs.running++
+	return func() {
+		hm.mx.Lock()
+		defer hm.mx.Unlock()
+		gs.running--
+		if !gs.healthy {
+			gs.notify()
+		}
+	}
+}
+
+// start probes the health of each gated scenario every its interval, until
+// the returned function is called, which resumes all of them.
+func (hm *healthGateMonitor) start(ctx context.Context) (stop func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	wg := sync.WaitGroup{}
+	for scenario, gs := range hm.scenarios {
+		interval := defaultHealthGateInterval
+		if gs.gate.Interval.Valid {
+			interval = gs.gate.Interval.TimeDuration()
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			for {
+				hm.check(ctx, scenario)
+				select {
+				case <-ticker.C:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+
+	return func() {
+		cancel()
+		wg.Wait()
+		hm.mx.Lock()
+		defer hm.mx.Unlock()
+		for _, gs := range hm.scenarios {
+			gs.healthy = true
+			gs.notify()
+		}
+	}
+}
+
+// check probes the health gate of the scenario and pauses, holds or resumes
+// it, depending on the result.
+func (hm *healthGateMonitor) check(ctx context.Context, scenario string) {
+	hm.mx.Lock()
+	gate := hm.scenarios[scenario].gate
+	hm.mx.Unlock()
+
+	err := hm.probe(ctx, gate)
+	if ctx.Err() != nil {
+		return
+	}
+
+	hm.mx.Lock()
+	defer hm.mx.Unlock()
+	gs := hm.scenarios[scenario]
+	switch {
+	case err != nil && gs.healthy:
+		gs.healthy = false
+		gs.limit = 0
+		action := "its new iterations are paused"
+		if gs.gate.OnUnhealthy == lib.HealthGateHold {
+			gs.limit = gs.running
+			action = fmt.Sprintf("it's held at %d concurrent iterations", gs.limit)
+		}
+		gs.notify()
+		hm.logger.WithError(err).Warnf("The system under test is degraded, so %s of scenario %s", action, scenario)
+	case err == nil && !gs.healthy:
+		gs.healthy = true
+		gs.notify()
+		hm.logger.Infof("The system under test is healthy again, so scenario %s is resumed", scenario)
+	}
+}
+
+// probe returns an error describing the degradation of the system under test,
+// or nil if it's healthy. A probe, which fails, e.g. because of a timeout,
+// means that it's degraded too.
+func (hm *healthGateMonitor) probe(ctx context.Context, gate lib.HealthGate) error {
+	timeout := defaultHealthGateInterval
+	switch {
+	case gate.Timeout.Valid:
+		timeout = gate.Timeout.TimeDuration()
+	case gate.Interval.Valid:
+		timeout = gate.Interval.TimeDuration()
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	probeURL := gate.URL
+	if gate.Query != "" {
+		probeURL = strings.TrimSuffix(gate.URL, "/") + "/api/v1/query?query=" + url.QueryEscape(gate.Query)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, probeURL, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := hm.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("the health probe responded with the status %d", resp.StatusCode)
+	}
+	if gate.Query == "" {
+		return nil
+	}
+
+	values, err := parsePromQLValues(body)
+	if err != nil {
+		return err
+	}
+	for _, value := range values {
+		if !gate.MaxValue.Valid {
+			return fmt.Errorf("the query %q has results", gate.Query)
+		}
+		if value > gate.MaxValue.Float64 {
+			return fmt.Errorf("the query %q has the result %g, which is over %g", gate.Query, value, gate.MaxValue.Float64)
+		}
+	}
+	return nil
+}
+
+// parsePromQLValues returns the values of the response of the instant query
+// API of Prometheus, which has a scalar or a vector result.
+func parsePromQLValues(body []byte) ([]float64, error) {
+	var resp struct {
+		Status string `json:"status"`
+		Error  string `json:"error"`
+		Data   struct {
+			ResultType string          `json:"resultType"`
+			Result     json.RawMessage `json:"result"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("couldn't parse the query response: %w", err)
+	}
+	if resp.Status != "success" {
+		return nil, fmt.Errorf("the query failed: %s", resp.Error)
+	}
+
+	// the values are [timestamp, "value"] pairs
+	var samples [][2]any
+	switch resp.Data.ResultType {
+	case "scalar":
+		var sample [2]any
+		if err := json.Unmarshal(resp.Data.Result, &sample); err != nil {
+			return nil, fmt.Errorf("couldn't parse the scalar result: %w", err)
+		}
+		samples = append(samples, sample)
+	case "vector":
+		var vector []struct {
+			Value [2]any `json:"value"`
+		}
+		if err := json.Unmarshal(resp.Data.Result, &vector); err != nil {
+			return nil, fmt.Errorf("couldn't parse the vector result: %w", err)
+		}
+		for _, s := range vector {
+			samples = append(samples, s.Value)
+		}
+	default:
+		return nil, fmt.Errorf("the query result must be a scalar or a vector, but it was %q", resp.Data.ResultType)
+	}
+
+	values := make([]float64, 0, len(samples))
+	for _, sample := range samples {
+		s, ok := sample[1].(string)
+		if !ok {
+			return nil, fmt.Errorf("the query result has an invalid value %v", sample[1])
+		}
+		value, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil, fmt.Errorf("the query result has an invalid value: %w", err)
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
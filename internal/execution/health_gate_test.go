@@ -0,0 +1,201 @@
+package execution
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/types"
+)
+
+// gatedConfig is a scenario config with only a name and a health gate.
+type gatedConfig struct {
+	lib.ExecutorConfig
+	name string
+	gate *lib.HealthGate
+}
+
+func (gc gatedConfig) GetName() string { return gc.name }
+
+func (gc gatedConfig) GetScenarioOptions() *lib.ScenarioOptions {
+	return &lib.ScenarioOptions{HealthGate: gc.gate}
+}
+
+func newTestHealthGateMonitor(t *testing.T, gates map[string]*lib.HealthGate) *healthGateMonitor {
+	t.Helper()
+	configs := []lib.ExecutorConfig{gatedConfig{name: "no-gate"}}
+	for name, gate := range gates {
+		configs = append(configs, gatedConfig{name: name, gate: gate})
+	}
+	hm := newHealthGateMonitor(&lib.ExecutionState{Test: getBogusTestRunState(t)}, configs)
+	require.NotNil(t, hm)
+	return hm
+}
+
+// throttled starts an iteration of the scenario in a goroutine, and returns a
+// channel, which receives its done function when it starts.
+func throttled(ctx context.Context, hm *healthGateMonitor, scenario string) <-chan func() {
+	started := make(chan func(), 1)
+	go func() {
+		started <- hm.throttle(ctx, scenario)
+	}()
+	return started
+}
+
+func requireBlocked(t *testing.T, started <-chan func()) {
+	t.Helper()
+	select {
+	case <-started:
+		t.Fatal("the iteration wasn't blocked")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func requireStarted(t *testing.T, started <-chan func()) func() {
+	t.Helper()
+	select {
+	case done := <-started:
+		require.NotNil(t, done)
+		return done
+	case <-time.After(time.Second):
+		t.Fatal("the iteration wasn't started")
+		return nil
+	}
+}
+
+// newHealthServer returns a health endpoint, which responds with 503 while the
+// returned flag is set.
+func newHealthServer(t *testing.T) (*httptest.Server, *atomic.Bool) {
+	t.Helper()
+	var down atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if down.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &down
+}
+
+func TestHealthGateMonitor(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	t.Run("Pause", func(t *testing.T) {
+		t.Parallel()
+		srv, down := newHealthServer(t)
+		hm := newTestHealthGateMonitor(t, map[string]*lib.HealthGate{"ramp": {URL: srv.URL}})
+		assert.NotContains(t, hm.scenarios, "no-gate")
+		assert.Nil(t, hm.throttle(ctx, "no-gate"))
+
+		done := requireStarted(t, throttled(ctx, hm, "ramp"))
+		down.Store(true)
+		hm.check(ctx, "ramp")
+		started := throttled(ctx, hm, "ramp")
+		requireBlocked(t, started)
+		done() // an iteration ending doesn't resume a paused scenario
+		requireBlocked(t, started)
+
+		down.Store(false)
+		hm.check(ctx, "ramp")
+		requireStarted(t, started)()
+	})
+
+	t.Run("Hold", func(t *testing.T) {
+		t.Parallel()
+		srv, down := newHealthServer(t)
+		hm := newTestHealthGateMonitor(t, map[string]*lib.HealthGate{
+			"ramp": {URL: srv.URL, OnUnhealthy: lib.HealthGateHold},
+		})
+		first := requireStarted(t, throttled(ctx, hm, "ramp"))
+		second := requireStarted(t, throttled(ctx, hm, "ramp"))
+
+		down.Store(true)
+		hm.check(ctx, "ramp")
+		started := throttled(ctx, hm, "ramp")
+		requireBlocked(t, started)
+
+		// the scenario is held at 2 concurrent iterations
+		first()
+		third := requireStarted(t, started)
+		started = throttled(ctx, hm, "ramp")
+		requireBlocked(t, started)
+
+		cancelCtx, cancel := context.WithCancel(ctx)
+		cancel()
+		assert.Nil(t, hm.throttle(cancelCtx, "ramp"))
+
+		down.Store(false)
+		hm.check(ctx, "ramp")
+		requireStarted(t, started)()
+		second()
+		third()
+	})
+
+	t.Run("Stop", func(t *testing.T) {
+		t.Parallel()
+		srv, down := newHealthServer(t)
+		down.Store(true)
+		hm := newTestHealthGateMonitor(t, map[string]*lib.HealthGate{
+			"ramp": {URL: srv.URL, Interval: types.NullDurationFrom(time.Hour)},
+		})
+		stop := hm.start(ctx)
+		require.Eventually(t, func() bool {
+			hm.mx.Lock()
+			defer hm.mx.Unlock()
+			return !hm.scenarios["ramp"].healthy
+		}, time.Second, 10*time.Millisecond)
+
+		started := throttled(ctx, hm, "ramp")
+		requireBlocked(t, started)
+		stop()
+		requireStarted(t, started)()
+	})
+}
+
+func TestHealthGateProbe(t *testing.T) {
+	t.Parallel()
+
+	responses := map[string]string{
+		"errors": `{"status":"success","data":{"resultType":"vector","result":[` +
+			`{"metric":{"service":"a"},"value":[1700000000,"0.01"]},` +
+			`{"metric":{"service":"b"},"value":[1700000000,"0.2"]}]}}`,
+		"latency": `{"status":"success","data":{"resultType":"scalar","result":[1700000000,"0.3"]}}`,
+		"alerts":  `{"status":"success","data":{"resultType":"vector","result":[]}}`,
+		"invalid": `{"status":"error","error":"parse error"}`,
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/query" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(responses[r.URL.Query().Get("query")]))
+	}))
+	defer srv.Close()
+
+	hm := newTestHealthGateMonitor(t, map[string]*lib.HealthGate{"ramp": {URL: srv.URL}})
+	ctx := context.Background()
+
+	err := hm.probe(ctx, lib.HealthGate{URL: srv.URL, Query: "errors", MaxValue: null.FloatFrom(0.5)})
+	require.NoError(t, err)
+	err = hm.probe(ctx, lib.HealthGate{URL: srv.URL + "/", Query: "errors", MaxValue: null.FloatFrom(0.1)})
+	require.ErrorContains(t, err, `the query "errors" has the result 0.2, which is over 0.1`)
+	err = hm.probe(ctx, lib.HealthGate{URL: srv.URL, Query: "latency", MaxValue: null.FloatFrom(0.25)})
+	require.ErrorContains(t, err, "0.3, which is over 0.25")
+	err = hm.probe(ctx, lib.HealthGate{URL: srv.URL, Query: "alerts"})
+	require.NoError(t, err)
+	err = hm.probe(ctx, lib.HealthGate{URL: srv.URL, Query: "errors"})
+	require.ErrorContains(t, err, `the query "errors" has results`)
+	err = hm.probe(ctx, lib.HealthGate{URL: srv.URL, Query: "invalid"})
+	require.ErrorContains(t, err, "the query failed: parse error")
+	err = hm.probe(ctx, lib.HealthGate{URL: srv.URL + "/health"})
+	require.ErrorContains(t, err, "the health probe responded with the status 404")
+}
@@ -0,0 +1,152 @@
+package execution
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"go.k6.io/k6/errext"
+	"go.k6.io/k6/errext/exitcodes"
+	"go.k6.io/k6/lib"
+)
+
+const (
+	// precheckBaseOpenFiles are the files, which k6 itself keeps open, e.g. the
+	// outputs, the logs and the REST API, regardless of the number of VUs.
+	precheckBaseOpenFiles = 256
+	// precheckVUMemory is the least memory, which a VU with a simple script
+	// uses. The real usage depends on the script, so it's only a lower bound.
+	precheckVUMemory = 1 << 20
+)
+
+// ResourceRequirements are the local resources, which a test run is estimated
+// to need, from the number of its VUs and its options.
+type ResourceRequirements struct {
+	VUs uint64
+	// MinOpenFiles are the open files with one connection per VU, without
+	// which the test is sure to fail with "too many open files".
+	MinOpenFiles uint64
+	// OpenFiles are the open files with as many connections per VU, as the
+	// batch options allow.
+	OpenFiles uint64
+	// Memory is the least memory the VUs need.
+	Memory uint64
+}
+
+// EstimateResourceRequirements estimates the local resources, which a test
+// run with the given maximum number of VUs and options needs.
+func EstimateResourceRequirements(maxVUs uint64, opts lib.Options) ResourceRequirements {
+	// every VU has its own connections, of which there are as many, as the
+	// requests it makes in parallel with http.batch()
+	connsPerVU := uint64(1)
+	if opts.Batch.Int64 > 1 {
+		connsPerVU = uint64(opts.Batch.Int64)
+	}
+	if opts.BatchPerHost.Int64 > 0 && uint64(opts.BatchPerHost.Int64) < connsPerVU {
+		connsPerVU = uint64(opts.BatchPerHost.Int64)
+	}
+	return ResourceRequirements{
+		VUs:          maxVUs,
+		MinOpenFiles: precheckBaseOpenFiles + maxVUs,
+		OpenFiles:    precheckBaseOpenFiles + maxVUs*connsPerVU,
+		Memory:       maxVUs * precheckVUMemory,
+	}
+}
+
+// systemLimits are the limits of the local resources, with zero for the ones
+// that are unknown.
+type systemLimits struct {
+	// openFiles and maxOpenFiles are the soft and the hard limits of the open
+	// files, i.e. `ulimit -Sn` and `ulimit -Hn`
+	openFiles, maxOpenFiles uint64
+	// memory is the available memory, in the container if k6 runs in one
+	memory uint64
+	// ephemeralPorts is the size of the range of the local ports, which the
+	// connections to the same address can use
+	ephemeralPorts uint64
+}
+
+// CheckResources compares the requirements of the test run with the limits of
+// the system, before the VUs are initialized. If the soft limit of the open
+// files is too low, it's raised up to the hard limit. It returns an error, if
+// the test run is sure to run out of some resource, and it warns, if it could.
+func CheckResources(req ResourceRequirements, logger logrus.FieldLogger) error {
+	limits, err := readSystemLimits()
+	if err != nil {
+		logger.WithError(err).Debug("Couldn't read the limits of the system, skipping some of the resource prechecks")
+	}
+	return checkResources(req, limits, raiseOpenFilesLimit, logger)
+}
+
+func checkResources(
+	req ResourceRequirements, limits systemLimits, raise func(uint64) error, logger logrus.FieldLogger,
+) error {
+	logger = logger.WithField("component", "resource-precheck")
+	var problems []string
+
+	if limits.maxOpenFiles > 0 && limits.maxOpenFiles < req.MinOpenFiles {
+		problems = append(problems, fmt.Sprintf(
+			"the %d VUs need at least %d open files, but the hard limit of the open files is %d; "+
+				"raise it, e.g. with `ulimit -n %d` as root or in /etc/security/limits.conf",
+			req.VUs, req.MinOpenFiles, limits.maxOpenFiles, req.OpenFiles))
+	} else if limits.openFiles > 0 && limits.openFiles < req.OpenFiles {
+		raised := req.OpenFiles
+		if limits.maxOpenFiles > 0 {
+			raised = min(raised, limits.maxOpenFiles)
+		}
+		if err := raise(raised); err != nil {
+			logger.WithError(err).Debugf("Couldn't raise the soft limit of the open files to %d", raised)
+		} else {
+			logger.Debugf("Raised the soft limit of the open files from %d to %d", limits.openFiles, raised)
+			limits.openFiles = raised
+		}
+		switch {
+		case limits.openFiles < req.MinOpenFiles:
+			problems = append(problems, fmt.Sprintf(
+				"the %d VUs need at least %d open files, but the limit of the open files is %d; "+
+					"raise it, e.g. with `ulimit -n %d`",
+				req.VUs, req.MinOpenFiles, limits.openFiles, req.OpenFiles))
+		case limits.openFiles < req.OpenFiles:
+			logger.Warnf("The %d VUs could need up to %d open files with their batch options, "+
+				"but the limit of the open files is %d, so the test could fail with \"too many open files\"; "+
+				"raise it, e.g. with `ulimit -n %d`", req.VUs, req.OpenFiles, limits.openFiles, req.OpenFiles)
+		}
+	}
+
+	if limits.memory > 0 && limits.memory < req.Memory {
+		problems = append(problems, fmt.Sprintf(
+			"the %d VUs need at least %s of memory, but only %s is available; "+
+				"run the test with fewer VUs, or split it between more machines with execution segments",
+			req.VUs, formatBytes(req.Memory), formatBytes(limits.memory)))
+	}
+
+	if limits.ephemeralPorts > 0 && req.MinOpenFiles-precheckBaseOpenFiles > limits.ephemeralPorts {
+		logger.Warnf("The %d VUs could make more connections to the same address than the %d local ports "+
+			"in the ephemeral port range, so they could fail with \"cannot assign requested address\"; "+
+			"widen the range with the net.ipv4.ip_local_port_range sysctl, or use more local IPs "+
+			"with the localIPs option", req.VUs, limits.ephemeralPorts)
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+	return errext.WithExitCodeIfNone(
+		fmt.Errorf("the test run doesn't have enough resources: %s; "+
+			"the checks can be skipped with --no-resource-precheck", strings.Join(problems, "; ")),
+		exitcodes.InsufficientResources,
+	)
+}
+
+func formatBytes(b uint64) string {
+	const unit = 1 << 10
+	if b < unit {
+		return fmt.Sprintf("%d B", b)
+	}
+	div, exp := uint64(unit), 0
+	for n := b / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(b)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,128 @@
+package execution
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// readSystemLimits reads the limits of the open files with getrlimit(2), and
+// the available memory and the ephemeral port range from /proc and /sys.
+func readSystemLimits() (systemLimits, error) {
+	var limits systemLimits
+	var errs []error
+
+	var rlimit syscall.Rlimit
+	if err := syscall.Getrlimit(syscall.RLIMIT_NOFILE, &rlimit); err != nil {
+		errs = append(errs, fmt.Errorf("couldn't get the open files limit: %w", err))
+	} else {
+		limits.openFiles, limits.maxOpenFiles = rlimit.Cur, rlimit.Max
+	}
+
+	memory, err := readAvailableMemory()
+	if err != nil {
+		errs = append(errs, err)
+	}
+	limits.memory = memory
+
+	ports, err := readEphemeralPorts()
+	if err != nil {
+		errs = append(errs, err)
+	}
+	limits.ephemeralPorts = ports
+
+	return limits, errors.Join(errs...)
+}
+
+// raiseOpenFilesLimit raises the soft limit of the open files, which can be
+// done without privileges, up to the hard limit.
+func raiseOpenFilesLimit(limit uint64) error {
+	var rlimit syscall.Rlimit
+	if err := syscall.Getrlimit(syscall.RLIMIT_NOFILE, &rlimit); err != nil {
+		return err
+	}
+	rlimit.Cur = limit
+	return syscall.Setrlimit(syscall.RLIMIT_NOFILE, &rlimit)
+}
+
+// readAvailableMemory returns the available memory of the system, or of the
+// cgroup (v2) of k6, if it's limited to less.
+func readAvailableMemory() (uint64, error) {
+	data, err := os.ReadFile("/proc/meminfo") //nolint:forbidigo
+	if err != nil {
+		return 0, err
+	}
+	var available uint64
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		// e.g. "MemAvailable:   12345678 kB"
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 3 && fields[0] == "MemAvailable:" {
+			kb, err := strconv.ParseUint(fields[1], 10, 64)
+			if err != nil {
+				return 0, fmt.Errorf("invalid MemAvailable in /proc/meminfo: %w", err)
+			}
+			available = kb << 10
+			break
+		}
+	}
+
+	if free, ok := cgroupFreeMemory(); ok && (available == 0 || free < available) {
+		available = free
+	}
+	return available, nil
+}
+
+// cgroupFreeMemory returns the memory, which can still be used in the cgroup
+// (v2) of k6, if its memory is limited.
+func cgroupFreeMemory() (uint64, bool) {
+	limit, ok := readCgroupValue("memory.max") // it's "max" if it's unlimited
+	if !ok {
+		return 0, false
+	}
+	current, ok := readCgroupValue("memory.current")
+	if !ok || current > limit {
+		return 0, false
+	}
+	return limit - current, true
+}
+
+func readCgroupValue(name string) (uint64, bool) {
+	data, err := os.ReadFile(filepath.Join("/sys/fs/cgroup", name)) //nolint:forbidigo
+	if err != nil {
+		return 0, false
+	}
+	value, err := strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+	return value, err == nil
+}
+
+// readEphemeralPorts returns the size of the range of the local ports, from
+// which the ports of the outgoing connections are picked.
+func readEphemeralPorts() (uint64, error) {
+	data, err := os.ReadFile("/proc/sys/net/ipv4/ip_local_port_range") //nolint:forbidigo
+	if err != nil {
+		return 0, err
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) != 2 {
+		return 0, fmt.Errorf("invalid ip_local_port_range %q", data)
+	}
+	low, err := strconv.ParseUint(fields[0], 10, 16)
+	if err != nil {
+		return 0, fmt.Errorf("invalid ip_local_port_range: %w", err)
+	}
+	high, err := strconv.ParseUint(fields[1], 10, 16)
+	if err != nil {
+		return 0, fmt.Errorf("invalid ip_local_port_range: %w", err)
+	}
+	if high < low {
+		return 0, fmt.Errorf("invalid ip_local_port_range %q", data)
+	}
+	return high - low + 1, nil
+}
@@ -0,0 +1,14 @@
+//go:build !linux
+
+package execution
+
+import "errors"
+
+// readSystemLimits isn't implemented outside of Linux yet.
+func readSystemLimits() (systemLimits, error) {
+	return systemLimits{}, errors.New("reading the limits of the system is supported only on Linux")
+}
+
+func raiseOpenFilesLimit(uint64) error {
+	return errors.New("raising the open files limit is supported only on Linux")
+}
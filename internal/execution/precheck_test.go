@@ -0,0 +1,121 @@
+package execution
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/errext"
+	"go.k6.io/k6/errext/exitcodes"
+	"go.k6.io/k6/internal/lib/testutils"
+	"go.k6.io/k6/lib"
+)
+
+func TestEstimateResourceRequirements(t *testing.T) {
+	t.Parallel()
+
+	req := EstimateResourceRequirements(100, lib.Options{Batch: null.IntFrom(20), BatchPerHost: null.IntFrom(6)})
+	assert.Equal(t, ResourceRequirements{
+		VUs:          100,
+		MinOpenFiles: precheckBaseOpenFiles + 100,
+		OpenFiles:    precheckBaseOpenFiles + 600,
+		Memory:       100 << 20,
+	}, req)
+
+	req = EstimateResourceRequirements(100, lib.Options{Batch: null.IntFrom(4)})
+	assert.Equal(t, uint64(precheckBaseOpenFiles+400), req.OpenFiles)
+
+	req = EstimateResourceRequirements(100, lib.Options{})
+	assert.Equal(t, req.MinOpenFiles, req.OpenFiles)
+}
+
+func TestCheckResources(t *testing.T) {
+	t.Parallel()
+
+	req := EstimateResourceRequirements(1000, lib.Options{Batch: null.IntFrom(2)})
+	newLogger := func() (*logrus.Logger, *testutils.SimpleLogrusHook) {
+		logger := logrus.New()
+		logger.SetLevel(logrus.DebugLevel)
+		logger.SetOutput(io.Discard)
+		hook := testutils.NewLogHook()
+		logger.AddHook(hook)
+		return logger, hook
+	}
+	failRaise := func(uint64) error { return errors.New("not permitted") }
+
+	t.Run("enough", func(t *testing.T) {
+		t.Parallel()
+		logger, hook := newLogger()
+		limits := systemLimits{openFiles: 1 << 20, maxOpenFiles: 1 << 20, memory: 8 << 30, ephemeralPorts: 28232}
+		require.NoError(t, checkResources(req, limits, failRaise, logger))
+		assert.Empty(t, hook.Drain())
+	})
+
+	t.Run("unknown", func(t *testing.T) {
+		t.Parallel()
+		logger, _ := newLogger()
+		require.NoError(t, checkResources(req, systemLimits{}, failRaise, logger))
+	})
+
+	t.Run("raised", func(t *testing.T) {
+		t.Parallel()
+		logger, hook := newLogger()
+		var raised uint64
+		raise := func(limit uint64) error {
+			raised = limit
+			return nil
+		}
+		limits := systemLimits{openFiles: 1024, maxOpenFiles: 1 << 20}
+		require.NoError(t, checkResources(req, limits, raise, logger))
+		assert.Equal(t, req.OpenFiles, raised)
+		for _, entry := range hook.Drain() {
+			assert.NotEqual(t, logrus.WarnLevel, entry.Level)
+		}
+	})
+
+	t.Run("raised up to the hard limit", func(t *testing.T) {
+		t.Parallel()
+		logger, hook := newLogger()
+		var raised uint64
+		raise := func(limit uint64) error {
+			raised = limit
+			return nil
+		}
+		limits := systemLimits{openFiles: 1024, maxOpenFiles: 2000}
+		require.NoError(t, checkResources(req, limits, raise, logger))
+		assert.Equal(t, uint64(2000), raised)
+		assert.True(t, testutils.LogContains(hook.Drain(), logrus.WarnLevel, "could need up to 2256 open files"))
+	})
+
+	t.Run("not raised", func(t *testing.T) {
+		t.Parallel()
+		logger, _ := newLogger()
+		limits := systemLimits{openFiles: 1024, maxOpenFiles: 1 << 20}
+		err := checkResources(req, limits, failRaise, logger)
+		require.ErrorContains(t, err, "the 1000 VUs need at least 1256 open files, but the limit of the open files is 1024")
+		var ecerr errext.HasExitCode
+		require.ErrorAs(t, err, &ecerr)
+		assert.Equal(t, exitcodes.InsufficientResources, ecerr.ExitCode())
+	})
+
+	t.Run("hard limit", func(t *testing.T) {
+		t.Parallel()
+		logger, _ := newLogger()
+		limits := systemLimits{openFiles: 1024, maxOpenFiles: 1024, memory: 100 << 20}
+		err := checkResources(req, limits, failRaise, logger)
+		require.ErrorContains(t, err, "the hard limit of the open files is 1024; raise it, e.g. with `ulimit -n 2256`")
+		require.ErrorContains(t, err, "the 1000 VUs need at least 1000.0 MiB of memory, but only 100.0 MiB is available")
+	})
+
+	t.Run("ephemeral ports", func(t *testing.T) {
+		t.Parallel()
+		logger, hook := newLogger()
+		require.NoError(t, checkResources(req, systemLimits{ephemeralPorts: 500}, failRaise, logger))
+		assert.True(t, testutils.LogContains(hook.Drain(), logrus.WarnLevel, "net.ipv4.ip_local_port_range"))
+	})
+}
@@ -0,0 +1,241 @@
+package execution
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"go.k6.io/k6/errext"
+	"go.k6.io/k6/errext/exitcodes"
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/metrics"
+)
+
+// resourceSamplingInterval is how often the resource usage is measured and
+// compared with the budgets of the scenarios.
+const resourceSamplingInterval = time.Second
+
+// resourceUsage is the usage of the local resources by k6 and its child
+// processes at some point in time.
+type resourceUsage struct {
+	cpuTime   time.Duration // since the start of the processes
+	memory    int64
+	openFiles int64
+}
+
+// resourceMonitor periodically measures the resource usage and throttles or
+// aborts the scenarios with resource budgets, when the usage is over them.
+type resourceMonitor struct {
+	budgets map[string]lib.ResourceBudget
+	read    func() (resourceUsage, error)
+	state   *lib.ExecutionState
+	logger  logrus.FieldLogger
+
+	last     resourceUsage
+	lastTime time.Time
+
+	mx        sync.RWMutex
+	throttled map[string]chan struct{} // closed when the scenario isn't throttled anymore
+	// the scenarios which are running, i.e. after their startTime and until
+	// they finish, since only their budgets are checked
+	active map[string]bool
+}
+
+// newResourceMonitor returns a monitor for the scenarios with resource
+// budgets, or nil if there aren't any.
+func newResourceMonitor(state *lib.ExecutionState, configs []lib.ExecutorConfig) *resourceMonitor {
+	budgets := make(map[string]lib.ResourceBudget)
+	for _, config := range configs {
+		if so := config.GetScenarioOptions(); so != nil && so.Resources != nil {
+			budgets[config.GetName()] = *so.Resources
+		}
+	}
+	if len(budgets) == 0 {
+		return nil
+	}
+	return &resourceMonitor{
+		budgets:   budgets,
+		read:      readResourceUsage,
+		state:     state,
+		logger:    state.Test.Logger.WithField("component", "resource-monitor"),
+		throttled: make(map[string]chan struct{}),
+		active:    make(map[string]bool),
+	}
+}
+
+// scenarioStarted starts checking the budget of the scenario.
+func (rm *resourceMonitor) scenarioStarted(scenario string) {
+	rm.mx.Lock()
+	defer rm.mx.Unlock()
+	rm.active[scenario] = true
+}
+
+// scenarioEnded stops checking the budget of the scenario, and resumes it if
+// it's throttled.
+func (rm *resourceMonitor) scenarioEnded(scenario string) {
+	rm.mx.Lock()
+	defer rm.mx.Unlock()
+	delete(rm.active, scenario)
+	if resume, ok := rm.throttled[scenario]; ok {
+		close(resume)
+		delete(rm.throttled, scenario)
+	}
+}
+
+// throttle blocks while the scenario is over its resource budget, or until the
+// context is done.
+func (rm *resourceMonitor) throttle(ctx context.Context, scenario string) func() {
+	rm.mx.RLock()
+	resume, ok := rm.throttled[scenario]
+	rm.mx.RUnlock()
+	if !ok {
+		return nil
+	}
+	select {
+	case <-resume:
+	case <-ctx.Done():
+	}
+	return nil
+}
+
+// start measures the usage every resourceSamplingInterval, until the returned
+// function is called. The context should be a test run one, so a scenario
+// with the abort action can abort the test.
+func (rm *resourceMonitor) start(ctx context.Context, out chan<- metrics.SampleContainer) (stop func()) {
+	usage, err := rm.read()
+	if err != nil {
+		rm.logger.WithError(err).Warn("The resource usage can't be measured, so the resource budgets are ignored")
+		return func() {}
+	}
+	rm.last, rm.lastTime = usage, time.Now()
+
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(resourceSamplingInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				rm.sample(ctx, out)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return func() {
+		cancel()
+		<-done
+		rm.mx.Lock()
+		defer rm.mx.Unlock()
+		for scenario, resume := range rm.throttled {
+			close(resume)
+			delete(rm.throttled, scenario)
+		}
+	}
+}
+
+// sample measures the usage, emits it as metrics and checks it against the
+// budgets of the scenarios.
+func (rm *resourceMonitor) sample(ctx context.Context, out chan<- metrics.SampleContainer) {
+	usage, err := rm.read()
+	if err != nil {
+		rm.logger.WithError(err).Debug("Couldn't measure the resource usage")
+		return
+	}
+	now := time.Now()
+	cpu := 0.0
+	if elapsed := now.Sub(rm.lastTime); elapsed > 0 && usage.cpuTime > rm.last.cpuTime {
+		cpu = float64(usage.cpuTime-rm.last.cpuTime) / float64(elapsed)
+	}
+	rm.last, rm.lastTime = usage, now
+
+	builtin := rm.state.Test.BuiltinMetrics
+	tags := rm.state.Test.RunTags
+	newSample := func(metric *metrics.Metric, tags *metrics.TagSet, value float64) metrics.Sample {
+		return metrics.Sample{
+			TimeSeries: metrics.TimeSeries{Metric: metric, Tags: tags},
+			Time:       now,
+			Value:      value,
+		}
+	}
+	samples := []metrics.Sample{
+		newSample(builtin.ResourceCPU, tags, cpu),
+		newSample(builtin.ResourceMemory, tags, float64(usage.memory)),
+		newSample(builtin.ResourceOpenFiles, tags, float64(usage.openFiles)),
+	}
+
+	for scenario, budget := range rm.budgets {
+		if !rm.isActive(scenario) {
+			continue
+		}
+		exceeded := budgetExceeded(budget, cpu, usage)
+		if len(exceeded) == 0 {
+			rm.setThrottled(scenario, false)
+			continue
+		}
+		samples = append(samples, newSample(builtin.ResourceBudgetExceeded, tags.With("scenario", scenario), 1))
+		reason := strings.Join(exceeded, ", ")
+		if budget.OnExceed == lib.ResourceBudgetAbort {
+			err := fmt.Errorf("scenario %s is over its resource budget: %s", scenario, reason)
+			AbortTestRun(ctx, errext.WithAbortReasonIfNone(
+				errext.WithExitCodeIfNone(err, exitcodes.ResourceBudgetExceeded),
+				errext.AbortedByResourceBudget,
+			))
+			continue
+		}
+		if rm.setThrottled(scenario, true) {
+			rm.logger.Warnf("Scenario %s is over its resource budget (%s), its iterations are delayed", scenario, reason)
+		}
+	}
+
+	metrics.PushIfNotDone(ctx, out, metrics.ConnectedSamples{Samples: samples, Tags: tags, Time: now})
+}
+
+func (rm *resourceMonitor) isActive(scenario string) bool {
+	rm.mx.RLock()
+	defer rm.mx.RUnlock()
+	return rm.active[scenario]
+}
+
+// setThrottled throttles or resumes the scenario, and returns whether it
+// wasn't throttled before.
+func (rm *resourceMonitor) setThrottled(scenario string, throttled bool) bool {
+	rm.mx.Lock()
+	defer rm.mx.Unlock()
+	resume, wasThrottled := rm.throttled[scenario]
+	if !rm.active[scenario] {
+		return false // it ended meanwhile
+	}
+	switch {
+	case throttled && !wasThrottled:
+		rm.throttled[scenario] = make(chan struct{})
+	case !throttled && wasThrottled:
+		close(resume)
+		delete(rm.throttled, scenario)
+		rm.logger.Infof("Scenario %s is within its resource budget again", scenario)
+	}
+	return throttled && !wasThrottled
+}
+
+// budgetExceeded returns a description of each limit of the budget, which the
+// usage is over.
+func budgetExceeded(budget lib.ResourceBudget, cpu float64, usage resourceUsage) []string {
+	var exceeded []string
+	if budget.MaxCPU.Valid && cpu > budget.MaxCPU.Float64 {
+		exceeded = append(exceeded, fmt.Sprintf("cpu %.2f > %.2f cores", cpu, budget.MaxCPU.Float64))
+	}
+	if budget.MaxMemory.Valid && usage.memory > budget.MaxMemory.Int64 {
+		exceeded = append(exceeded, fmt.Sprintf("memory %d > %d bytes", usage.memory, budget.MaxMemory.Int64))
+	}
+	if budget.MaxOpenFiles.Valid && usage.openFiles > budget.MaxOpenFiles.Int64 {
+		exceeded = append(exceeded, fmt.Sprintf("open files %d > %d", usage.openFiles, budget.MaxOpenFiles.Int64))
+	}
+	return exceeded
+}
@@ -0,0 +1,94 @@
+package execution
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// clockTicks is the number of clock ticks per second, in which the CPU time
+// is reported by /proc. It's 100 on all the supported architectures, and it
+// can't be read without cgo.
+const clockTicks = 100
+
+type procStat struct {
+	ppid     int
+	cpuTicks int64
+	rssPages int64
+}
+
+// readResourceUsage sums the usage of the k6 process and all of its
+// descendants, e.g. the browsers, from /proc.
+func readResourceUsage() (resourceUsage, error) {
+	entries, err := os.ReadDir("/proc") //nolint:forbidigo
+	if err != nil {
+		return resourceUsage{}, err
+	}
+	stats := make(map[int]procStat)
+	children := make(map[int][]int)
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		stat, err := readProcStat(pid)
+		if err != nil {
+			continue // the process could have exited in the meantime
+		}
+		stats[pid] = stat
+		children[stat.ppid] = append(children[stat.ppid], pid)
+	}
+
+	self := os.Getpid() //nolint:forbidigo
+	if _, ok := stats[self]; !ok {
+		return resourceUsage{}, errors.New("couldn't read the stats of the k6 process from /proc")
+	}
+
+	var usage resourceUsage
+	pageSize := int64(os.Getpagesize()) //nolint:forbidigo
+	for pids := []int{self}; len(pids) > 0; pids = pids[1:] {
+		pid := pids[0]
+		stat := stats[pid]
+		usage.cpuTime += time.Duration(stat.cpuTicks) * time.Second / clockTicks
+		usage.memory += stat.rssPages * pageSize
+		fds, err := os.ReadDir(filepath.Join("/proc", strconv.Itoa(pid), "fd")) //nolint:forbidigo
+		if err == nil {
+			usage.openFiles += int64(len(fds))
+		}
+		pids = append(pids, children[pid]...)
+	}
+	return usage, nil
+}
+
+// readProcStat reads the parent, the user and system CPU time and the resident
+// memory of the process from /proc/<pid>/stat, see proc(5).
+func readProcStat(pid int) (procStat, error) {
+	data, err := os.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "stat")) //nolint:forbidigo
+	if err != nil {
+		return procStat{}, err
+	}
+	// The command can contain spaces and parentheses, so the fields after it
+	// are found from the last closing parenthesis, starting with the state.
+	end := strings.LastIndexByte(string(data), ')')
+	if end < 0 {
+		return procStat{}, fmt.Errorf("invalid stat of process %d", pid)
+	}
+	fields := strings.Fields(string(data[end+1:]))
+	if len(fields) < 22 {
+		return procStat{}, fmt.Errorf("invalid stat of process %d", pid)
+	}
+	// ppid, utime, stime and rss
+	values := make([]int64, 0, 4)
+	for _, field := range []int{1, 11, 12, 21} {
+		value, err := strconv.ParseInt(fields[field], 10, 64)
+		if err != nil {
+			return procStat{}, fmt.Errorf("invalid stat of process %d: %w", pid, err)
+		}
+		values = append(values, value)
+	}
+	return procStat{ppid: int(values[0]), cpuTicks: values[1] + values[2], rssPages: values[3]}, nil
+}
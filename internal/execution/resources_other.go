@@ -0,0 +1,10 @@
+//go:build !linux
+
+package execution
+
+import "errors"
+
+// readResourceUsage isn't implemented outside of Linux yet.
+func readResourceUsage() (resourceUsage, error) {
+	return resourceUsage{}, errors.New("measuring the resource usage is supported only on Linux")
+}
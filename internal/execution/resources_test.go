@@ -0,0 +1,177 @@
+package execution
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/errext"
+	"go.k6.io/k6/errext/exitcodes"
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/metrics"
+)
+
+// budgetConfig is a scenario config with only a name and a resource budget.
+type budgetConfig struct {
+	lib.ExecutorConfig
+	name   string
+	budget *lib.ResourceBudget
+}
+
+func (bc budgetConfig) GetName() string { return bc.name }
+
+func (bc budgetConfig) GetScenarioOptions() *lib.ScenarioOptions {
+	return &lib.ScenarioOptions{Resources: bc.budget}
+}
+
+func newTestResourceMonitor(t *testing.T, budgets map[string]*lib.ResourceBudget) *resourceMonitor {
+	t.Helper()
+	configs := []lib.ExecutorConfig{budgetConfig{name: "no-budget"}}
+	for name, budget := range budgets {
+		configs = append(configs, budgetConfig{name: name, budget: budget})
+	}
+	rm := newResourceMonitor(&lib.ExecutionState{Test: getBogusTestRunState(t)}, configs)
+	require.NotNil(t, rm)
+	rm.lastTime = time.Now().Add(-time.Second)
+	for name := range budgets {
+		rm.scenarioStarted(name)
+	}
+	return rm
+}
+
+func TestResourceMonitorThrottle(t *testing.T) {
+	t.Parallel()
+
+	rm := newTestResourceMonitor(t, map[string]*lib.ResourceBudget{
+		"browser":  {MaxMemory: null.IntFrom(1000)},
+		"protocol": {MaxOpenFiles: null.IntFrom(100)},
+	})
+	usage := resourceUsage{memory: 2000, openFiles: 10}
+	rm.read = func() (resourceUsage, error) { return usage, nil }
+	assert.NotContains(t, rm.budgets, "no-budget")
+
+	out := make(chan metrics.SampleContainer, 10)
+	rm.sample(context.Background(), out)
+
+	samples := (<-out).GetSamples()
+	values := make(map[string]float64)
+	for _, s := range samples {
+		values[s.Metric.Name] = s.Value
+		if s.Metric.Name == metrics.ResourceBudgetExceededName {
+			scenario, _ := s.Tags.Get("scenario")
+			assert.Equal(t, "browser", scenario)
+		}
+	}
+	assert.Equal(t, map[string]float64{
+		metrics.ResourceCPUName:            0,
+		metrics.ResourceMemoryName:         2000,
+		metrics.ResourceOpenFilesName:      10,
+		metrics.ResourceBudgetExceededName: 1,
+	}, values)
+
+	// the other scenario isn't throttled
+	rm.throttle(context.Background(), "protocol")
+
+	throttled := make(chan struct{})
+	go func() {
+		defer close(throttled)
+		rm.throttle(context.Background(), "browser")
+	}()
+	select {
+	case <-throttled:
+		t.Fatal("the scenario over its budget wasn't throttled")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	usage.memory = 500
+	rm.sample(context.Background(), out)
+	select {
+	case <-throttled:
+	case <-time.After(time.Second):
+		t.Fatal("the scenario within its budget wasn't resumed")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	usage.memory = 2000
+	rm.sample(context.Background(), out)
+	rm.throttle(ctx, "browser") // returns, since the context is done
+}
+
+func TestResourceMonitorAbort(t *testing.T) {
+	t.Parallel()
+
+	rm := newTestResourceMonitor(t, map[string]*lib.ResourceBudget{
+		"browser": {MaxCPU: null.FloatFrom(1), OnExceed: lib.ResourceBudgetAbort},
+	})
+	rm.read = func() (resourceUsage, error) { return resourceUsage{cpuTime: 10 * time.Second}, nil }
+
+	parentCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ctx, _ := NewTestRunContext(parentCtx, rm.logger, nil)
+	rm.sample(ctx, make(chan metrics.SampleContainer, 1))
+
+	err := GetCancelReasonIfTestAborted(ctx)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "scenario browser is over its resource budget: cpu")
+
+	var exitErr errext.HasExitCode
+	require.True(t, errors.As(err, &exitErr))
+	assert.Equal(t, exitcodes.ResourceBudgetExceeded, exitErr.ExitCode())
+	var abortErr errext.HasAbortReason
+	require.True(t, errors.As(err, &abortErr))
+	assert.Equal(t, errext.AbortedByResourceBudget, abortErr.AbortReason())
+}
+
+func TestResourceMonitorInactiveScenarios(t *testing.T) {
+	t.Parallel()
+
+	rm := newTestResourceMonitor(t, map[string]*lib.ResourceBudget{
+		"delayed": {MaxMemory: null.IntFrom(1000), OnExceed: lib.ResourceBudgetAbort},
+	})
+	delete(rm.active, "delayed") // it's waiting for its startTime
+	rm.read = func() (resourceUsage, error) { return resourceUsage{memory: 2000}, nil }
+	exceeded := func(out chan metrics.SampleContainer) bool {
+		for _, s := range (<-out).GetSamples() {
+			if s.Metric.Name == metrics.ResourceBudgetExceededName {
+				return true
+			}
+		}
+		return false
+	}
+
+	parentCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ctx, _ := NewTestRunContext(parentCtx, rm.logger, nil)
+	out := make(chan metrics.SampleContainer, 1)
+
+	rm.sample(ctx, out)
+	assert.False(t, exceeded(out))
+	require.NoError(t, GetCancelReasonIfTestAborted(ctx))
+
+	rm.scenarioStarted("delayed")
+	rm.scenarioEnded("delayed")
+	rm.sample(ctx, out)
+	assert.False(t, exceeded(out))
+	require.NoError(t, GetCancelReasonIfTestAborted(ctx))
+
+	rm.scenarioStarted("delayed")
+	rm.sample(ctx, out)
+	require.Error(t, GetCancelReasonIfTestAborted(ctx))
+}
+
+func TestReadResourceUsage(t *testing.T) {
+	t.Parallel()
+
+	usage, err := readResourceUsage()
+	if err != nil {
+		t.Skipf("the resource usage can't be measured: %s", err)
+	}
+	assert.Positive(t, usage.memory)
+	assert.Positive(t, usage.openFiles)
+}
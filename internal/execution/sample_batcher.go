@@ -0,0 +1,129 @@
+package execution
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"go.k6.io/k6/metrics"
+)
+
+// sampleBatchDrainInterval is how often the per-VU sample buffers are drained.
+const sampleBatchDrainInterval = 10 * time.Millisecond
+
+// sampleBatcher gives every VU its own buffer for metric samples and drains
+// all of them in batches into the shared samples channel. This way, at very
+// high sample rates, VUs don't contend with each other over the shared channel
+// and the batcher is its only producer during the test run.
+//
+// No samples are ever dropped, since that would skew the thresholds and the
+// summary, so there isn't any drop accounting either: a VU, whose buffer is
+// full, waits for the next drain. The drains, which found full buffers, are
+// counted and logged at the end, as a hint to make the buffers bigger.
+type sampleBatcher struct {
+	out        chan<- metrics.SampleContainer
+	bufferSize int
+	logger     logrus.FieldLogger
+
+	// buffers is copy-on-write, so the drain loop can read it without locking
+	buffersMx sync.Mutex
+	buffers   atomic.Pointer[[]chan metrics.SampleContainer]
+
+	// fullBuffers counts how many times a VU buffer was found full when it
+	// was drained, i.e. the VU was likely blocked waiting for free space in it
+	fullBuffers atomic.Uint64
+	drained     atomic.Uint64
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+func newSampleBatcher(
+	out chan<- metrics.SampleContainer, bufferSize int, logger logrus.FieldLogger,
+) *sampleBatcher {
+	sb := &sampleBatcher{
+		out:        out,
+		bufferSize: bufferSize,
+		logger:     logger,
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+	sb.buffers.Store(&[]chan metrics.SampleContainer{})
+	return sb
+}
+
+// newBuffer creates a new per-VU buffer and registers it for draining.
+func (sb *sampleBatcher) newBuffer() chan metrics.SampleContainer {
+	buf := make(chan metrics.SampleContainer, sb.bufferSize)
+
+	sb.buffersMx.Lock()
+	defer sb.buffersMx.Unlock()
+	old := *sb.buffers.Load()
+	buffers := make([]chan metrics.SampleContainer, len(old), len(old)+1)
+	copy(buffers, old)
+	buffers = append(buffers, buf)
+	sb.buffers.Store(&buffers)
+
+	return buf
+}
+
+// start drains the buffers in the background until the batcher is stopped.
+func (sb *sampleBatcher) start() {
+	go func() {
+		defer close(sb.done)
+		ticker := time.NewTicker(sampleBatchDrainInterval)
+		defer ticker.Stop()
+
+		var batch []metrics.SampleContainer
+		for {
+			select {
+			case <-ticker.C:
+				batch = sb.drain(batch[:0])
+			case <-sb.stop:
+				sb.drain(batch[:0])
+				return
+			}
+		}
+	}()
+}
+
+// drain moves everything that is currently buffered by the VUs into the
+// shared samples channel and returns the used batch, so it can be reused.
+func (sb *sampleBatcher) drain(batch []metrics.SampleContainer) []metrics.SampleContainer {
+	for _, buf := range *sb.buffers.Load() {
+		n := len(buf)
+		if n == 0 {
+			continue
+		}
+		if n == cap(buf) {
+			sb.fullBuffers.Add(1)
+		}
+		for i := 0; i < n; i++ {
+			batch = append(batch, <-buf)
+		}
+	}
+	for _, sc := range batch {
+		sb.out <- sc
+	}
+	sb.drained.Add(uint64(len(batch)))
+	return batch
+}
+
+// stopAndWait stops the background draining, after all buffered samples have
+// been sent to the shared channel. It must be called only after the VUs have
+// stopped sending samples to their buffers.
+func (sb *sampleBatcher) stopAndWait() {
+	close(sb.stop)
+	<-sb.done
+
+	if fullBuffers := sb.fullBuffers.Load(); fullBuffers > 0 {
+		sb.logger.Warnf(
+			"The per-VU metric sample buffers were full %d times while %d sample containers were processed, "+
+				"so the VUs waited for them to be drained; "+
+				"you might want to increase the metricSamplesPerVUBufferSize option",
+			fullBuffers, sb.drained.Load(),
+		)
+	}
+}
@@ -0,0 +1,86 @@
+package execution
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/internal/lib/testutils"
+	"go.k6.io/k6/metrics"
+)
+
+func TestSampleBatcher(t *testing.T) {
+	t.Parallel()
+
+	registry := metrics.NewRegistry()
+	metric := registry.MustNewMetric("my_metric", metrics.Counter)
+	sample := metrics.Sample{
+		TimeSeries: metrics.TimeSeries{Metric: metric, Tags: registry.RootTagSet()},
+		Time:       time.Now(),
+		Value:      1,
+	}
+
+	out := make(chan metrics.SampleContainer, 10)
+	sb := newSampleBatcher(out, 5, testutils.NewLogger(t))
+	sb.start()
+
+	const vus, samplesPerVU = 10, 100
+	wg := &sync.WaitGroup{}
+	for i := 0; i < vus; i++ {
+		buf := sb.newBuffer()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < samplesPerVU; j++ {
+				buf <- sample
+			}
+		}()
+	}
+
+	received := make(chan int)
+	go func() {
+		count := 0
+		for range out {
+			count++
+		}
+		received <- count
+	}()
+
+	wg.Wait()
+	sb.stopAndWait()
+	close(out)
+
+	assert.Equal(t, vus*samplesPerVU, <-received)
+	assert.Equal(t, uint64(vus*samplesPerVU), sb.drained.Load())
+	require.Len(t, *sb.buffers.Load(), vus)
+	for _, buf := range *sb.buffers.Load() {
+		assert.Empty(t, buf)
+	}
+}
+
+func TestSampleBatcherFullBuffers(t *testing.T) {
+	t.Parallel()
+
+	registry := metrics.NewRegistry()
+	metric := registry.MustNewMetric("my_metric", metrics.Counter)
+	sample := metrics.Sample{
+		TimeSeries: metrics.TimeSeries{Metric: metric, Tags: registry.RootTagSet()},
+		Time:       time.Now(),
+		Value:      1,
+	}
+
+	out := make(chan metrics.SampleContainer, 10)
+	sb := newSampleBatcher(out, 2, testutils.NewLogger(t))
+	buf := sb.newBuffer()
+	buf <- sample
+	buf <- sample
+
+	// nothing is dropped, the full buffer is only counted
+	sb.drain(nil)
+	assert.Equal(t, uint64(1), sb.fullBuffers.Load())
+	assert.Equal(t, uint64(2), sb.drained.Load())
+	assert.Len(t, out, 2)
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/sirupsen/logrus"
 
 	"go.k6.io/k6/errext"
+	"go.k6.io/k6/internal/event"
 	"go.k6.io/k6/internal/ui/pb"
 	"go.k6.io/k6/lib"
 	"go.k6.io/k6/metrics"
@@ -29,6 +30,14 @@ type Scheduler struct {
 	maxDuration     time.Duration // cached value derived from the execution plan
 	maxPossibleVUs  uint64        // cached value derived from the execution plan
 	state           *lib.ExecutionState
+
+	// sampleBatcher is used only if per-VU metric sample buffers are enabled
+	sampleBatcher *sampleBatcher
+
+	// resourceMonitor is used only if some scenarios have resource budgets
+	resourceMonitor *resourceMonitor
+	// healthGateMonitor is used only if some scenarios have health gates
+	healthGateMonitor *healthGateMonitor
 }
 
 // NewScheduler creates and returns a new Scheduler instance, without
@@ -75,15 +84,31 @@ func NewScheduler(trs *lib.TestRunState, controller Controller) (*Scheduler, err
 		}
 	}
 
+	resourceMonitor := newResourceMonitor(executionState, executorConfigs)
+	healthGateMonitor := newHealthGateMonitor(executionState, executorConfigs)
+	switch {
+	case resourceMonitor != nil && healthGateMonitor != nil:
+		executionState.ThrottleScenario = func(ctx context.Context, scenario string) func() {
+			resourceMonitor.throttle(ctx, scenario)
+			return healthGateMonitor.throttle(ctx, scenario)
+		}
+	case resourceMonitor != nil:
+		executionState.ThrottleScenario = resourceMonitor.throttle
+	case healthGateMonitor != nil:
+		executionState.ThrottleScenario = healthGateMonitor.throttle
+	}
+
 	return &Scheduler{
-		initProgress:    pb.New(pb.WithConstLeft("Init")),
-		executors:       executors,
-		executorConfigs: executorConfigs,
-		executionPlan:   executionPlan,
-		maxDuration:     maxDuration,
-		maxPossibleVUs:  maxPossibleVUs,
-		state:           executionState,
-		controller:      controller,
+		initProgress:      pb.New(pb.WithConstLeft("Init")),
+		executors:         executors,
+		executorConfigs:   executorConfigs,
+		executionPlan:     executionPlan,
+		maxDuration:       maxDuration,
+		maxPossibleVUs:    maxPossibleVUs,
+		state:             executionState,
+		controller:        controller,
+		resourceMonitor:   resourceMonitor,
+		healthGateMonitor: healthGateMonitor,
 	}, nil
 }
 
@@ -130,6 +155,9 @@ func (e *Scheduler) initVU(
 	// Get the VU IDs here, so that the VUs are (mostly) ordered by their
 	// number in the channel buffer
 	vuIDLocal, vuIDGlobal := e.state.GetUniqueVUIdentifiers()
+	if e.sampleBatcher != nil {
+		samplesOut = e.sampleBatcher.newBuffer()
+	}
 	vu, err := e.state.Test.Runner.NewVU(ctx, vuIDLocal, vuIDGlobal, samplesOut)
 	if err != nil {
 		return nil, errext.WithHint(err, fmt.Sprintf("error while initializing VU #%d", vuIDGlobal))
@@ -366,15 +394,46 @@ func (e *Scheduler) runExecutor(
 		pb.WithConstProgress(0, "started"),
 	)
 	executorLogger.Debugf("Starting executor")
+	waitScenarioStartDone := e.emitScenarioEvent(event.ScenarioStart, executorConfig, nil)
+	waitScenarioStartDone(runCtx, executorLogger)
+	if e.resourceMonitor != nil {
+		e.resourceMonitor.scenarioStarted(executorConfig.GetName())
+	}
 	err := executor.Run(runCtx, engineOut) // executor should handle context cancel itself
+	if e.resourceMonitor != nil {
+		e.resourceMonitor.scenarioEnded(executorConfig.GetName())
+	}
 	if err == nil {
 		executorLogger.Debugf("Executor finished successfully")
 	} else {
 		executorLogger.WithField("error", err).Errorf("Executor error")
 	}
+	e.emitScenarioEvent(event.ScenarioEnd, executorConfig, err)
 	runResults <- err
 }
 
+// emitScenarioEvent notifies the subscribers of the global events that the
+// scenario started or ended. The returned function waits for them to process
+// the event, so they know about the scenario before its first iteration.
+func (e *Scheduler) emitScenarioEvent(
+	evtType event.Type, config lib.ExecutorConfig, err error,
+) (waitDone func(context.Context, logrus.FieldLogger)) {
+	if e.state.Test.Events == nil {
+		return func(context.Context, logrus.FieldLogger) {}
+	}
+	wait := e.state.Test.Events.Emit(&event.Event{
+		Type: evtType,
+		Data: &event.ScenarioData{Name: config.GetName(), Executor: config.GetType(), Error: err},
+	})
+	return func(ctx context.Context, logger logrus.FieldLogger) {
+		waitCtx, waitCancel := context.WithTimeout(ctx, 30*time.Minute)
+		defer waitCancel()
+		if werr := wait(waitCtx); werr != nil {
+			logger.WithError(werr).Warn()
+		}
+	}
+}
+
 // Init concurrently initializes all of the planned VUs and then sequentially
 // initializes all of the configured executors. It also starts the measurement
 // and emission of the `vus` and `vus_max` metrics.
@@ -390,12 +449,21 @@ func (e *Scheduler) Init(
 		initErr = SignalErrorOrWait(e.controller, "scheduler-init-done", initErr)
 	}()
 
+	if bufSize := e.state.Test.Options.MetricSamplesPerVUBufferSize; bufSize.Valid && bufSize.Int64 > 0 {
+		e.sampleBatcher = newSampleBatcher(samplesOut, int(bufSize.Int64), logger)
+		e.sampleBatcher.start()
+	}
+
 	execSchedRunCtx, execSchedRunCancel := context.WithCancel(runCtx)
 	waitForVUsMetricPush := e.emitVUsAndVUsMax(execSchedRunCtx, samplesOut)
 	stopVUEmission = func() {
 		logger.Debugf("Stopping vus and vux_max metrics emission...")
 		execSchedRunCancel()
 		waitForVUsMetricPush()
+		if e.sampleBatcher != nil {
+			logger.Debugf("Draining the per-VU metric sample buffers...")
+			e.sampleBatcher.stopAndWait()
+		}
 	}
 
 	defer func() {
@@ -477,6 +545,12 @@ func (e *Scheduler) Run(globalCtx, runCtx context.Context, samplesOut chan<- met
 			return e.state.Test.Runner.GetSetupData(), nil
 		})
 		if err != nil {
+			// teardown() doesn't run without the setup data, but onAbort() does
+			reason := GetCancelReasonIfTestAborted(runCtx)
+			if reason == nil {
+				reason = err
+			}
+			e.runOnAbort(lib.WithAbortReason(globalCtx, reason), samplesOut, logger)
 			return err
 		}
 		if !actuallyRanSetup {
@@ -496,6 +570,14 @@ func (e *Scheduler) Run(globalCtx, runCtx context.Context, samplesOut chan<- met
 
 	executorsRunCtx, executorsRunCancel := context.WithCancel(withExecStateCtx)
 	defer executorsRunCancel()
+	stopResourceMonitor := func() {}
+	if e.resourceMonitor != nil {
+		stopResourceMonitor = e.resourceMonitor.start(executorsRunCtx, samplesOut)
+	}
+	stopHealthGateMonitor := func() {}
+	if e.healthGateMonitor != nil {
+		stopHealthGateMonitor = e.healthGateMonitor.start(executorsRunCtx)
+	}
 	for _, exec := range e.executors {
 		go e.runExecutor(executorsRunCtx, runResults, samplesOut, exec)
 	}
@@ -512,21 +594,34 @@ func (e *Scheduler) Run(globalCtx, runCtx context.Context, samplesOut chan<- met
 			executorsRunCancel()
 		}
 	}
+	stopResourceMonitor()
+	stopHealthGateMonitor()
 
 	if err := SignalAndWait(e.controller, "execution-done"); err != nil {
 		return err
 	}
 
+	// We run onAbort() and teardown() with the global context, so they aren't
+	// interrupted by thresholds or test.abort() or even Ctrl+C (unless used
+	// twice), with the reason of the abort of the test run, if it was.
+	teardownCtx := globalCtx
+	reason := GetCancelReasonIfTestAborted(runCtx)
+	if reason == nil {
+		reason = firstErr
+	}
+	if reason != nil {
+		teardownCtx = lib.WithAbortReason(globalCtx, reason)
+		e.runOnAbort(teardownCtx, samplesOut, logger)
+	}
+
 	// Run teardown() after all executors are done, if it's not disabled
 	if !e.state.Test.Options.NoTeardown.Bool {
 		e.state.SetExecutionStatus(lib.ExecutionStatusTeardown)
 		e.initProgress.Modify(pb.WithConstProgress(1, "teardown()"))
 
-		// We run teardown() with the global context, so it isn't interrupted by
-		// thresholds or test.abort() or even Ctrl+C (unless used twice).
 		// TODO: add a `sync.Once` equivalent?
 		_, err := e.controller.GetOrCreateData("teardown", func() ([]byte, error) {
-			if err := e.state.Test.Runner.Teardown(globalCtx, samplesOut); err != nil {
+			if err := e.state.Test.Runner.Teardown(teardownCtx, samplesOut); err != nil {
 				logger.WithField("error", err).Debug("teardown() aborted by error")
 				return nil, err
 			}
@@ -544,6 +639,20 @@ func (e *Scheduler) Run(globalCtx, runCtx context.Context, samplesOut chan<- met
 	return firstErr
 }
 
+// runOnAbort runs onAbort(), with the reason of the abort of the test run in
+// the context. Its errors are only logged, so that teardown() still runs.
+func (e *Scheduler) runOnAbort(
+	ctx context.Context, samplesOut chan<- metrics.SampleContainer, logger logrus.FieldLogger,
+) {
+	e.initProgress.Modify(pb.WithConstProgress(1, "onAbort()"))
+	_, err := e.controller.GetOrCreateData("onAbort", func() ([]byte, error) {
+		return nil, e.state.Test.Runner.OnAbort(ctx, samplesOut)
+	})
+	if err != nil {
+		logger.WithError(err).Error("onAbort() failed")
+	}
+}
+
 // SetPaused pauses the test, or start/resumes it. To check if a test is paused,
 // use GetState().IsPaused().
 //
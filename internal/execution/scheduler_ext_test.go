@@ -18,6 +18,7 @@ import (
 	"github.com/stretchr/testify/require"
 	"gopkg.in/guregu/null.v3"
 
+	"go.k6.io/k6/errext"
 	"go.k6.io/k6/internal/execution"
 	"go.k6.io/k6/internal/execution/local"
 	"go.k6.io/k6/internal/js"
@@ -759,6 +760,77 @@ func TestSchedulerSetupTeardownRun(t *testing.T) {
 		defer cancel()
 		assert.EqualError(t, execScheduler.Run(ctx, ctx, samples), "setup error")
 	})
+	t.Run("Setup Error Runs onAbort", func(t *testing.T) {
+		t.Parallel()
+		setupErr := errors.New("setup error")
+		var onAbortReason error
+		runner := &minirunner.MiniRunner{
+			SetupFn: func(_ context.Context, _ chan<- metrics.SampleContainer) ([]byte, error) {
+				return nil, setupErr
+			},
+			OnAbortFn: func(ctx context.Context, _ chan<- metrics.SampleContainer) error {
+				onAbortReason = lib.GetAbortReason(ctx)
+				return nil
+			},
+			TeardownFn: func(_ context.Context, _ chan<- metrics.SampleContainer) error {
+				return errors.New("teardown without setup data")
+			},
+		}
+		ctx, cancel, execScheduler, samples := newTestScheduler(t, runner, nil, lib.Options{})
+		defer cancel()
+		assert.EqualError(t, execScheduler.Run(ctx, ctx, samples), "setup error")
+		assert.Equal(t, setupErr, onAbortReason)
+	})
+	t.Run("Abort", func(t *testing.T) {
+		t.Parallel()
+		abortErr := errext.WithAbortReasonIfNone(errors.New("thresholds crossed"), errext.AbortedByThreshold)
+		var onAbortReason, teardownReason error
+		runner := &minirunner.MiniRunner{
+			Fn: func(ctx context.Context, _ *lib.State, _ chan<- metrics.SampleContainer) error {
+				execution.AbortTestRun(ctx, abortErr)
+				return nil
+			},
+			OnAbortFn: func(ctx context.Context, _ chan<- metrics.SampleContainer) error {
+				onAbortReason = lib.GetAbortReason(ctx)
+				return errors.New("onAbort error")
+			},
+			TeardownFn: func(ctx context.Context, _ chan<- metrics.SampleContainer) error {
+				teardownReason = lib.GetAbortReason(ctx)
+				return nil
+			},
+		}
+		ctx, cancel, execScheduler, samples := newTestScheduler(t, runner, nil, lib.Options{
+			VUs:        null.IntFrom(1),
+			Iterations: null.IntFrom(1),
+		})
+		defer cancel()
+		runCtx, _ := execution.NewTestRunContext(ctx, testutils.NewLogger(t), nil)
+
+		assert.ErrorIs(t, execScheduler.Run(ctx, runCtx, samples), abortErr)
+		assert.Equal(t, abortErr, onAbortReason)
+		// teardown() still runs, even though onAbort() failed
+		assert.Equal(t, abortErr, teardownReason)
+	})
+	t.Run("Don't Run onAbort", func(t *testing.T) {
+		t.Parallel()
+		ranOnAbort := false
+		runner := &minirunner.MiniRunner{
+			OnAbortFn: func(_ context.Context, _ chan<- metrics.SampleContainer) error {
+				ranOnAbort = true
+				return nil
+			},
+			TeardownFn: func(ctx context.Context, _ chan<- metrics.SampleContainer) error {
+				return lib.GetAbortReason(ctx)
+			},
+		}
+		ctx, cancel, execScheduler, samples := newTestScheduler(t, runner, nil, lib.Options{
+			VUs:        null.IntFrom(1),
+			Iterations: null.IntFrom(1),
+		})
+		defer cancel()
+		assert.NoError(t, execScheduler.Run(ctx, ctx, samples))
+		assert.False(t, ranOnAbort)
+	})
 	t.Run("Don't Run Setup", func(t *testing.T) {
 		t.Parallel()
 		runner := &minirunner.MiniRunner{
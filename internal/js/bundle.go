@@ -226,6 +226,9 @@ func (b *Bundle) populateExports(updateOptions bool, bi *BundleInstance) error {
 			case consts.TeardownFn:
 				err = errors.New("exported 'teardown' must be a function")
 				return
+			case consts.OnAbortFn:
+				err = errors.New("exported 'onAbort' must be a function")
+				return
 			}
 		}
 	})
@@ -395,6 +398,12 @@ func (b *Bundle) setupJSRuntime(rt *sobek.Runtime, vuID uint64, logger logrus.Fi
 	if err != nil {
 		return err
 	}
+	// Sobek doesn't define it, but the async iteration of the scripts is
+	// transformed to use it, so it has to be defined before they run.
+	common.AsyncIteratorSymbol(rt)
+	if err = allowNullApplyArguments(rt); err != nil {
+		return err
+	}
 
 	if b.CompatibilityMode == lib.CompatibilityModeExtended {
 		globalThis := rt.GlobalObject()
@@ -519,3 +528,26 @@ func generateFileLoad(b *Bundle) modules.FileLoader {
 		return d.Data, nil
 	}
 }
+
+// allowNullApplyArguments makes Function.prototype.apply accept null and
+// undefined as the arguments, as the spec requires, unlike Sobek's one. The
+// async generators of the scripts are lowered to generators, which are called
+// with null arguments, when they don't use them.
+func allowNullApplyArguments(rt *sobek.Runtime) error {
+	proto := rt.Get("Function").ToObject(rt).Get("prototype").ToObject(rt)
+	apply, ok := sobek.AssertFunction(proto.Get("apply"))
+	if !ok {
+		return errors.New("Function.prototype.apply isn't a function")
+	}
+	return proto.DefineDataProperty("apply", rt.ToValue(func(call sobek.FunctionCall) sobek.Value {
+		args := call.Argument(1)
+		if sobek.IsNull(args) || sobek.IsUndefined(args) {
+			args = rt.NewArray()
+		}
+		res, err := apply(call.This, call.Argument(0), args)
+		if err != nil {
+			panic(err)
+		}
+		return res
+	}), sobek.FLAG_TRUE, sobek.FLAG_TRUE, sobek.FLAG_FALSE)
+}
@@ -59,6 +59,8 @@ type parsingState struct {
 	compatibilityMode lib.CompatibilityMode
 	compiler          *Compiler
 	esm               bool
+	// whether the source has been transformed through esbuild
+	transformed bool
 
 	loader func(string) ([]byte, error)
 }
@@ -119,25 +121,52 @@ func (ps *parsingState) parseImpl(src, filename string, commonJSWrap bool) (*ast
 		return prg, code, nil
 	}
 
+	if ps.transformed {
+		return nil, "", err
+	}
 	if strings.HasSuffix(filename, ".ts") {
 		if err := ps.compiler.usage.Uint64(usageParsedTSFilesKey, 1); err != nil {
 			ps.compiler.logger.WithError(err).Warn("couldn't report usage for " + usageParsedTSFilesKey)
 		}
-		code, ps.srcMap, err = StripTypes(src, filename)
-		if err != nil {
-			return nil, "", err
-		}
-		if ps.loader != nil {
-			// This hack is required for the source map to work
-			code += "\n//# sourceMappingURL=" + internalSourceMapURL
+		saved := *ps
+		prg, code, tsErr := ps.transform(src, filename, commonJSWrap, StripTypes)
+		if tsErr == nil || !ps.transformed { // the types couldn't be stripped
+			return prg, code, tsErr
 		}
-		ps.commonJSWrapped = false
-		ps.compatibilityMode = lib.CompatibilityModeBase
-		return ps.parseImpl(code, filename, commonJSWrap)
+		*ps = saved
+		err = tsErr
+	}
+	// the source may use the async iteration, which Sobek doesn't support, but
+	// otherwise the error of the original source is the one to return
+	saved := *ps
+	if prg, code, lowerErr := ps.transform(src, filename, commonJSWrap, LowerAsyncIteration); lowerErr == nil {
+		return prg, code, nil
 	}
+	*ps = saved
 	return nil, "", err
 }
 
+// transform parses the source after transforming it with the esbuild based
+// transformation.
+func (ps *parsingState) transform(
+	src, filename string, commonJSWrap bool,
+	transformation func(src, filename string) (string, []byte, error),
+) (*ast.Program, string, error) {
+	code, srcMap, err := transformation(src, filename)
+	if err != nil {
+		return nil, "", err
+	}
+	ps.srcMap = srcMap
+	if ps.loader != nil {
+		// This hack is required for the source map to work
+		code += "\n//# sourceMappingURL=" + internalSourceMapURL
+	}
+	ps.transformed = true
+	ps.commonJSWrapped = false
+	ps.compatibilityMode = lib.CompatibilityModeBase
+	return ps.parseImpl(code, filename, commonJSWrap)
+}
+
 func (ps *parsingState) wrap(code, filename string) string {
 	conditionalNewLine := ""
 	if index := strings.LastIndex(code, "//# sourceMappingURL="); index != -1 {
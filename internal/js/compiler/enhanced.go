@@ -1,18 +1,52 @@
 package compiler
 
 import (
+	"strings"
+
 	"github.com/evanw/esbuild/pkg/api"
 	"github.com/grafana/sobek/file"
 	"github.com/grafana/sobek/parser"
 )
 
+// unsupportedFeatures are the features, which Sobek doesn't support, so
+// esbuild lowers them to the ones it does.
+//
+//nolint:gochecknoglobals
+var unsupportedFeatures = map[string]bool{
+	"async-generator": false,
+	"for-await":       false,
+}
+
 // StripTypes transpiles the input source string and strip types from it.
 // this is done using esbuild
 func StripTypes(src, filename string) (code string, srcMap []byte, err error) {
+	return esbuildTransform(src, filename, api.LoaderTS, nil)
+}
+
+// LowerAsyncIteration transpiles the async generators and the for await loops
+// of the input source string, which Sobek doesn't support, to generators and
+// promises, stripping the types too, if it's a TypeScript one. The async
+// iterators are looked up with the Symbol.asyncIterator, or with the symbol
+// registered as "Symbol.asyncIterator", if it isn't defined.
+// this is done using esbuild
+func LowerAsyncIteration(src, filename string) (code string, srcMap []byte, err error) {
+	loader := api.LoaderJS
+	if strings.HasSuffix(filename, ".ts") {
+		loader = api.LoaderTS
+	}
+	// esbuild changes the yield* of the other generators too, so only the
+	// sources, which Sobek can't parse, are lowered
+	return esbuildTransform(src, filename, loader, unsupportedFeatures)
+}
+
+func esbuildTransform(
+	src, filename string, loader api.Loader, supported map[string]bool,
+) (code string, srcMap []byte, err error) {
 	opts := api.TransformOptions{
-		Loader:         api.LoaderTS,
+		Loader:         loader,
 		Sourcefile:     filename,
 		Target:         api.ESNext,
+		Supported:      supported,
 		Format:         api.FormatDefault,
 		Sourcemap:      api.SourceMapExternal,
 		SourcesContent: api.SourcesContentInclude,
@@ -76,3 +76,47 @@ require(t);
 //# sourceMappingURL=k6://internal-should-not-leak/file.map`, code)
 	})
 }
+
+func TestCompile_asyncIteration(t *testing.T) {
+	t.Parallel()
+
+	src := `async function* gen() { yield 1; }
+export default async function() { for await (const x of gen()) {} }`
+	for _, filename := range []string{"script.js", "script.ts"} {
+		t.Run(filename, func(t *testing.T) {
+			t.Parallel()
+			c := New(testutils.NewLogger(t))
+			_, code, err := c.Parse(src, filename, false, true)
+			require.NoError(t, err)
+			assert.Contains(t, code, "__forAwait(gen())")
+		})
+	}
+
+	t.Run("Invalid", func(t *testing.T) {
+		t.Parallel()
+		c := New(testutils.NewLogger(t))
+		_, _, err := c.Parse(`for await (const x of y) {`, "script.js", false, true)
+		// the error of the original source is returned, when it can't be lowered
+		assert.ErrorContains(t, err, "Unexpected token await")
+	})
+}
+
+func TestCompile_asyncIterationNotUsed(t *testing.T) {
+	t.Parallel()
+
+	// the sources without the async iteration aren't lowered, since esbuild
+	// changes the yield* of all generators then
+	src := `interface Point { x: number; y?: number }
+function* gen(n: number) { yield* [n, n + 1]; }
+export default async function(p?: Point): Promise<number> {
+	for (const x of gen(p?.x ?? 0)) { await Promise.resolve(x); }
+	return 1;
+}`
+	c := New(testutils.NewLogger(t))
+	_, code, err := c.Parse(src, "script.ts", false, true)
+	require.NoError(t, err)
+	stripped, _, err := StripTypes(src, "script.ts")
+	require.NoError(t, err)
+	assert.Equal(t, stripped, code)
+	assert.NotContains(t, code, "__yieldStar")
+}
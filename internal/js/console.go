@@ -12,11 +12,15 @@ import (
 // console represents a JS console implemented as a logrus.FieldLogger.
 type console struct {
 	logger logrus.FieldLogger
+
+	// if set, returns the fields of the VU, e.g. its scenario and iteration,
+	// that are added to the messages
+	fields func() logrus.Fields
 }
 
 // Creates a console with the standard logrus logger.
 func newConsole(logger logrus.FieldLogger) *console {
-	return &console{logger.WithField("source", "console")}
+	return &console{logger: logger.WithField("source", "console")}
 }
 
 // Creates a console logger with its output set to the file at the provided `filepath`.
@@ -32,7 +36,14 @@ func newFileConsole(filepath string, formatter logrus.Formatter, level logrus.Le
 	l.SetOutput(f)
 	l.SetFormatter(formatter)
 
-	return &console{l}, nil
+	return &console{logger: l}, nil
+}
+
+// withFields returns a copy of the console, which adds the fields returned by
+// the given function to the messages.
+func (c console) withFields(fields func() logrus.Fields) *console {
+	c.fields = fields
+	return &c
 }
 
 func (c console) log(level logrus.Level, args ...sobek.Value) {
@@ -45,15 +56,21 @@ func (c console) log(level logrus.Level, args ...sobek.Value) {
 	}
 	msg := strs.String()
 
+	logger := c.logger
+	if c.fields != nil {
+		if fields := c.fields(); len(fields) > 0 {
+			logger = logger.WithFields(fields)
+		}
+	}
 	switch level { //nolint:exhaustive
 	case logrus.DebugLevel:
-		c.logger.Debug(msg)
+		logger.Debug(msg)
 	case logrus.InfoLevel:
-		c.logger.Info(msg)
+		logger.Info(msg)
 	case logrus.WarnLevel:
-		c.logger.Warn(msg)
+		logger.Warn(msg)
 	case logrus.ErrorLevel:
-		c.logger.Error(msg)
+		logger.Error(msg)
 	}
 }
 
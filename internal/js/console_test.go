@@ -30,7 +30,7 @@ func TestConsoleContext(t *testing.T) {
 	rt.SetFieldNameMapper(common.FieldNameMapper{})
 
 	logger, hook := logtest.NewNullLogger()
-	_ = rt.Set("console", &console{logger})
+	_ = rt.Set("console", &console{logger: logger})
 
 	_, err := rt.RunString(`console.log("a")`)
 	require.NoError(t, err)
@@ -118,7 +118,18 @@ func getSimpleArchiveRunner(tb testing.TB, arc *lib.Archive, opts ...interface{}
 
 // TODO: remove the need for this function, see https://github.com/grafana/k6/issues/2968
 //
+// vuLogFields are the fields that the console adds to the messages logged in
+// the first iteration of the VU with ID 1, without a scenario.
+//
 //nolint:forbidigo
+func vuLogFields(fields logrus.Fields) logrus.Fields {
+	result := logrus.Fields{"scenario": "", "vu": uint64(1), "iteration": int64(0)}
+	for k, v := range fields {
+		result[k] = v
+	}
+	return result
+}
+
 func extractLogger(vu lib.ActiveVU) *logrus.Logger {
 	vuSpecific, ok := vu.(*ActiveVU)
 	if !ok {
@@ -271,7 +282,7 @@ func TestConsoleLog(t *testing.T) {
 
 			require.NotNil(t, entry, "nothing logged")
 			assert.Equal(t, tt.expected, entry.Message)
-			assert.Equal(t, logrus.Fields{"source": "console"}, entry.Data)
+			assert.Equal(t, vuLogFields(logrus.Fields{"source": "console"}), entry.Data)
 		})
 	}
 }
@@ -330,7 +341,7 @@ func TestConsoleLevels(t *testing.T) {
 
 					assert.Equal(t, level, entry.Level)
 					assert.Equal(t, result, entry.Message)
-					assert.Equal(t, logrus.Fields{"source": "console"}, entry.Data)
+					assert.Equal(t, vuLogFields(logrus.Fields{"source": "console"}), entry.Data)
 				})
 			}
 		})
@@ -426,11 +437,7 @@ func TestFileConsole(t *testing.T) {
 							assert.Equal(t, level, entry.Level)
 							assert.Equal(t, result.Message, entry.Message)
 
-							data := result.Data
-							if data == nil {
-								data = make(logrus.Fields)
-							}
-							require.Equal(t, data, entry.Data)
+							require.Equal(t, vuLogFields(result.Data), entry.Data)
 
 							// Test if what we logged to the hook is the same as what we logged
 							// to the file.
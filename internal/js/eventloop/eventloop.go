@@ -28,6 +28,15 @@ type EventLoop struct {
 	registeredCallbacks int
 	vu                  modules.VU
 
+	// daemons are the stop functions of the registered callbacks of the
+	// daemons, which the event loop doesn't wait for
+	daemons  map[uint64]func()
+	daemonID uint64
+
+	// queued are the callbacks of QueueCallback, which are kept between the
+	// runs of the event loop
+	queued []func() error
+
 	// pendingPromiseRejections are rejected promises with no handler,
 	// if there is something in this map at an end of an event loop then it will exit with an error.
 	// It's similar to what Deno and Node do.
@@ -131,6 +140,65 @@ func (e *EventLoop) RegisterCallback() (enqueueCallback func(func() error)) {
 	}
 }
 
+// RegisterDaemonCallback is like RegisterCallback, but for daemons, i.e. the
+// background work like the schedules of k6/timers, which the iteration doesn't
+// wait for. When only the callbacks of the daemons are left, the event loop
+// calls their stop functions, which must enqueue them, e.g. with a no-op, so
+// the loop can end.
+//
+// It *must* be called from the main runtime thread, and the stop functions are
+// called on it too.
+func (e *EventLoop) RegisterDaemonCallback(stop func()) (enqueueCallback func(func() error)) {
+	e.lock.Lock()
+	e.daemonID++
+	id := e.daemonID
+	if e.daemons == nil {
+		e.daemons = make(map[uint64]func())
+	}
+	e.daemons[id] = stop
+	e.lock.Unlock()
+
+	enqueue := e.RegisterCallback()
+	return func(f func() error) {
+		e.lock.Lock()
+		delete(e.daemons, id)
+		e.lock.Unlock()
+		enqueue(f)
+	}
+}
+
+// QueueCallback queues the callback to be run on the main runtime thread, in
+// the current run of the event loop, or in the next one if it isn't running.
+// Unlike with RegisterCallback, the event loop doesn't wait for it, and it
+// can be called from any goroutine.
+func (e *EventLoop) QueueCallback(f func() error) {
+	e.lock.Lock()
+	e.queued = append(e.queued, f)
+	e.lock.Unlock()
+	e.wakeup()
+}
+
+// stopDaemons calls the stop functions of the daemons, if only their
+// callbacks are left, and returns whether it did.
+func (e *EventLoop) stopDaemons() bool {
+	e.lock.Lock()
+	if len(e.daemons) == 0 || e.registeredCallbacks != len(e.daemons) {
+		e.lock.Unlock()
+		return false
+	}
+	stops := make([]func(), 0, len(e.daemons))
+	for id, stop := range e.daemons {
+		stops = append(stops, stop)
+		delete(e.daemons, id)
+	}
+	e.lock.Unlock()
+
+	for _, stop := range stops {
+		stop()
+	}
+	return true
+}
+
 func (e *EventLoop) promiseRejectionTracker(p *sobek.Promise, op sobek.PromiseRejectionOperation) {
 	// No locking necessary here as the Sobek runtime will call this synchronously
 	// Read Notes on https://tc39.es/ecma262/#sec-host-promise-rejection-tracker
@@ -144,6 +212,10 @@ func (e *EventLoop) promiseRejectionTracker(p *sobek.Promise, op sobek.PromiseRe
 func (e *EventLoop) popAll() (queue []func() error, awaiting bool) {
 	e.lock.Lock()
 	queue = e.queue
+	if len(e.queued) > 0 {
+		queue = append(e.queued, queue...)
+		e.queued = nil
+	}
 	e.queue = make([]func() error, 0, len(queue))
 	awaiting = e.registeredCallbacks != 0
 	e.lock.Unlock()
@@ -169,7 +241,9 @@ func (e *EventLoop) Start(firstCallback func() error) error {
 			if !awaiting {
 				return nil
 			}
-			<-e.wakeupCh
+			if !e.stopDaemons() {
+				<-e.wakeupCh
+			}
 			continue
 		}
 
@@ -209,7 +283,9 @@ func (e *EventLoop) WaitOnRegistered() {
 			if !awaiting {
 				return
 			}
-			<-e.wakeupCh
+			if !e.stopDaemons() {
+				<-e.wakeupCh
+			}
 			continue
 		}
 
@@ -85,6 +85,36 @@ func TestEventLoopWaitOnRegistered(t *testing.T) {
 	require.Greater(t, time.Second+time.Millisecond*100, took2)
 }
 
+func TestEventLoopDaemonCallback(t *testing.T) {
+	t.Parallel()
+	loop := eventloop.New(&modulestest.VU{RuntimeField: sobek.New()})
+	var ran, stopped int
+	f := func() error {
+		var daemon func(func() error)
+		daemon = loop.RegisterDaemonCallback(func() {
+			stopped++
+			daemon(func() error { return nil })
+		})
+		r := loop.RegisterCallback()
+		go func() {
+			time.Sleep(100 * time.Millisecond)
+			r(func() error {
+				ran++
+				return nil
+			})
+		}()
+		return nil
+	}
+	start := time.Now()
+	require.NoError(t, loop.Start(f))
+	took := time.Since(start)
+	// the loop waits for the other callbacks, but not for the daemon
+	require.Equal(t, 1, ran)
+	require.Equal(t, 1, stopped)
+	require.Less(t, 100*time.Millisecond, took)
+	require.Greater(t, time.Second, took)
+}
+
 func TestEventLoopAllCallbacksGetCalled(t *testing.T) {
 	t.Parallel()
 	sleepTime := time.Millisecond * 500
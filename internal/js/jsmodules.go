@@ -11,9 +11,23 @@ import (
 	"go.k6.io/k6/internal/js/modules/k6/data"
 	"go.k6.io/k6/internal/js/modules/k6/encoding"
 	"go.k6.io/k6/internal/js/modules/k6/execution"
+	"go.k6.io/k6/internal/js/modules/k6/experimental/binary"
+	"go.k6.io/k6/internal/js/modules/k6/experimental/callbacks"
+	"go.k6.io/k6/internal/js/modules/k6/experimental/cluster"
 	"go.k6.io/k6/internal/js/modules/k6/experimental/csv"
+	"go.k6.io/k6/internal/js/modules/k6/experimental/dns"
+	"go.k6.io/k6/internal/js/modules/k6/experimental/flags"
 	"go.k6.io/k6/internal/js/modules/k6/experimental/fs"
+	"go.k6.io/k6/internal/js/modules/k6/experimental/graphql"
+	"go.k6.io/k6/internal/js/modules/k6/experimental/journey"
+	"go.k6.io/k6/internal/js/modules/k6/experimental/mockserver"
+	"go.k6.io/k6/internal/js/modules/k6/experimental/mqtt"
+	expnet "go.k6.io/k6/internal/js/modules/k6/experimental/net"
+	"go.k6.io/k6/internal/js/modules/k6/experimental/random"
+	"go.k6.io/k6/internal/js/modules/k6/experimental/socketio"
 	"go.k6.io/k6/internal/js/modules/k6/experimental/streams"
+	"go.k6.io/k6/internal/js/modules/k6/experimental/utils"
+	"go.k6.io/k6/internal/js/modules/k6/experimental/validator"
 	expws "go.k6.io/k6/internal/js/modules/k6/experimental/websockets"
 	"go.k6.io/k6/internal/js/modules/k6/grpc"
 	"go.k6.io/k6/internal/js/modules/k6/metrics"
@@ -31,18 +45,32 @@ import (
 
 func getInternalJSModules() map[string]interface{} {
 	return map[string]interface{}{
-		"k6":                         k6.New(),
-		"k6/crypto":                  crypto.New(),
-		"k6/crypto/x509":             x509.New(),
-		"k6/data":                    data.New(),
-		"k6/encoding":                encoding.New(),
-		"k6/timers":                  timers.New(),
-		"k6/execution":               execution.New(),
-		"k6/experimental/csv":        csv.New(),
-		"k6/experimental/redis":      redis.New(),
-		"k6/experimental/streams":    streams.New(),
-		"k6/experimental/webcrypto":  webcrypto.New(),
-		"k6/experimental/websockets": expws.New(),
+		"k6":                         newLazyModule(k6.New),
+		"k6/crypto":                  newLazyModule(crypto.New),
+		"k6/crypto/x509":             newLazyModule(x509.New),
+		"k6/data":                    newLazyModule(data.New),
+		"k6/encoding":                newLazyModule(encoding.New),
+		"k6/timers":                  newLazyModule(timers.New),
+		"k6/execution":               newLazyModule(execution.New),
+		"k6/experimental/binary":     newLazyModule(binary.New),
+		"k6/experimental/callbacks":  newLazyModule(callbacks.New),
+		"k6/experimental/cluster":    newLazyModule(cluster.New),
+		"k6/experimental/csv":        newLazyModule(csv.New),
+		"k6/experimental/dns":        newLazyModule(dns.New),
+		"k6/experimental/flags":      newLazyModule(flags.New),
+		"k6/experimental/graphql":    newLazyModule(graphql.New),
+		"k6/experimental/journey":    newLazyModule(journey.New),
+		"k6/experimental/mockserver": newLazyModule(mockserver.New),
+		"k6/experimental/mqtt":       newLazyModule(mqtt.New),
+		"k6/experimental/net":        newLazyModule(expnet.New),
+		"k6/experimental/random":     newLazyModule(random.New),
+		"k6/experimental/redis":      newLazyModule(redis.New),
+		"k6/experimental/socketio":   newLazyModule(socketio.New),
+		"k6/experimental/streams":    newLazyModule(streams.New),
+		"k6/experimental/utils":      newLazyModule(utils.New),
+		"k6/experimental/validator":  newLazyModule(validator.New),
+		"k6/experimental/webcrypto":  newLazyModule(webcrypto.New),
+		"k6/experimental/websockets": newLazyModule(expws.New),
 		"k6/experimental/timers": newRemovedModule(
 			"k6/experimental/timers has been graduated, please use k6/timers instead."),
 		"k6/experimental/tracing": newRemovedModule(
@@ -54,13 +82,13 @@ func getInternalJSModules() map[string]interface{} {
 				"Please update your imports to use k6/browser instead of k6/experimental/browser," +
 				" For more information, see the migration guide at the link:" +
 				" https://grafana.com/docs/k6/latest/using-k6-browser/migrating-to-k6-v0-52/"),
-		"k6/browser":         browser.New(),
-		"k6/experimental/fs": fs.New(),
-		"k6/net/grpc":        grpc.New(),
-		"k6/html":            html.New(),
-		"k6/http":            http.New(),
-		"k6/metrics":         metrics.New(),
-		"k6/ws":              ws.New(),
+		"k6/browser":         newLazyModule(browser.New),
+		"k6/experimental/fs": newLazyModule(fs.New),
+		"k6/net/grpc":        newLazyModule(grpc.New),
+		"k6/html":            newLazyModule(html.New),
+		"k6/http":            newLazyModule(http.New),
+		"k6/metrics":         newLazyModule(metrics.New),
+		"k6/ws":              newLazyModule(ws.New),
 		"k6/experimental/grpc": newRemovedModule(
 			"k6/experimental/grpc has been graduated, please use k6/net/grpc instead." +
 				" See https://grafana.com/docs/k6/latest/javascript-api/k6-net-grpc/ for more information.",
@@ -80,6 +108,24 @@ func getJSModules() map[string]interface{} {
 	return result
 }
 
+// lazyModule defers the creation of a module until the first time it's imported,
+// so scripts don't pay the initialization cost of all of the modules k6 has,
+// regardless of whether they use them or not.
+type lazyModule struct {
+	once   sync.Once
+	newFn  func() modules.Module
+	module modules.Module
+}
+
+func newLazyModule[M modules.Module](newFn func() M) modules.Module {
+	return &lazyModule{newFn: func() modules.Module { return newFn() }}
+}
+
+func (lm *lazyModule) NewModuleInstance(vu modules.VU) modules.Instance {
+	lm.once.Do(func() { lm.module = lm.newFn() })
+	return lm.module.NewModuleInstance(vu)
+}
+
 //nolint:unused // this is likely going to be used again even if isn't currently used
 type warnExperimentalModule struct {
 	once *sync.Once
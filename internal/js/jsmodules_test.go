@@ -0,0 +1,57 @@
+package js
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"go.k6.io/k6/js/modules"
+)
+
+type countingModule struct {
+	instances atomic.Int64
+}
+
+func (cm *countingModule) NewModuleInstance(_ modules.VU) modules.Instance {
+	cm.instances.Add(1)
+	return nil
+}
+
+func TestLazyModule(t *testing.T) {
+	t.Parallel()
+
+	var created atomic.Int64
+	root := &countingModule{}
+	lazy := newLazyModule(func() *countingModule {
+		created.Add(1)
+		return root
+	})
+	assert.Zero(t, created.Load(), "the module shouldn't be created before it's imported")
+
+	wg := &sync.WaitGroup{}
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			lazy.NewModuleInstance(nil)
+		}()
+	}
+	wg.Wait()
+
+	assert.EqualValues(t, 1, created.Load())
+	assert.EqualValues(t, 10, root.instances.Load())
+}
+
+func TestInternalJSModulesAreLazy(t *testing.T) {
+	t.Parallel()
+
+	for name, mod := range getInternalJSModules() {
+		switch mod.(type) {
+		case *lazyModule, *removedModule:
+		default:
+			t.Errorf("module %q is created eagerly", name)
+		}
+	}
+}
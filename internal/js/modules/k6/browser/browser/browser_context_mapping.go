@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"strings"
 	"time"
 
 	"github.com/grafana/sobek"
@@ -67,7 +68,17 @@ func mapBrowserContext(vu moduleVU, bc *common.BrowserContext) mapping { //nolin
 		},
 		"close": func() *sobek.Promise {
 			return k6ext.Promise(vu.Context(), func() (any, error) {
-				return nil, bc.Close() //nolint:wrapcheck
+				if err := bc.Close(); err != nil {
+					return nil, err //nolint:wrapcheck
+				}
+				path, script := bc.ProtocolScript()
+				if path == "" {
+					return nil, nil
+				}
+				if err := vu.filePersister.Persist(vu.Context(), path, strings.NewReader(script)); err != nil {
+					return nil, fmt.Errorf("writing the protocol script: %w", err)
+				}
+				return nil, nil
 			})
 		},
 		"cookies": func(urls ...string) *sobek.Promise {
@@ -84,6 +95,10 @@ func mapBrowserContext(vu moduleVU, bc *common.BrowserContext) mapping { //nolin
 				return nil, bc.GrantPermissions(permissions, popts)
 			}), nil
 		},
+		"protocolScript": func() string {
+			_, script := bc.ProtocolScript()
+			return script
+		},
 		"setDefaultNavigationTimeout": bc.SetDefaultNavigationTimeout,
 		"setDefaultTimeout":           bc.SetDefaultTimeout,
 		"setGeolocation": func(geolocation sobek.Value) (*sobek.Promise, error) {
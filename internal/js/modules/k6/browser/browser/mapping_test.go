@@ -293,6 +293,7 @@ type browserContextAPI interface { //nolint:interfacebloat
 	GrantPermissions(permissions []string, opts sobek.Value) error
 	NewPage() (*common.Page, error)
 	Pages() []*common.Page
+	ProtocolScript() string
 	SetDefaultNavigationTimeout(timeout int64)
 	SetDefaultTimeout(timeout int64)
 	SetGeolocation(geolocation *common.Geolocation) error
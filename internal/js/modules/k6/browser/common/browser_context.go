@@ -85,6 +85,9 @@ type BrowserContext struct {
 
 	evaluateOnNewDocumentSources []string
 
+	// records the requests for the protocol-level script, if it's enabled.
+	protocolScript *protocolScriptRecorder
+
 	// DownloadsPath is the path where downloads will be stored.
 	DownloadsPath string
 }
@@ -145,6 +148,10 @@ func NewBrowserContext(
 		timeoutSettings:  NewTimeoutSettings(nil),
 	}
 
+	if opts.RecordProtocolScript != nil {
+		b.protocolScript = newProtocolScriptRecorder(*opts.RecordProtocolScript)
+	}
+
 	if len(opts.Permissions) > 0 {
 		err := b.GrantPermissions(opts.Permissions, GrantPermissionsOptions{})
 		if err != nil {
@@ -220,6 +227,25 @@ func (b *BrowserContext) Close() error {
 	return nil
 }
 
+// ProtocolScript returns the protocol-level k6 script, which makes the requests
+// the browser context made so far, and the path of its file, or empty strings
+// if the recordProtocolScript option isn't set.
+func (b *BrowserContext) ProtocolScript() (path string, script string) {
+	if b.protocolScript == nil {
+		return "", ""
+	}
+	return b.protocolScript.opts.Path, b.protocolScript.script()
+}
+
+// recordProtocolScriptRequest records the finished request for the
+// protocol-level script, if it's enabled.
+func (b *BrowserContext) recordProtocolScriptRequest(req *Request, end time.Time) {
+	if b == nil || b.protocolScript == nil {
+		return
+	}
+	b.protocolScript.record(req, end)
+}
+
 // GrantPermissions enables the specified permissions, all others will be disabled.
 func (b *BrowserContext) GrantPermissions(permissions []string, opts GrantPermissionsOptions) error {
 	b.logger.Debugf("BrowserContext:GrantPermissions", "bctxid:%v", b.id)
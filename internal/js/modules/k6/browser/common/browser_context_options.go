@@ -20,11 +20,14 @@ type BrowserContextOptions struct {
 	Offline           bool              `js:"offline"`
 	Permissions       []string          `js:"permissions"`
 	ReducedMotion     ReducedMotion     `js:"reducedMotion"`
-	Screen            Screen            `js:"screen"`
-	TimezoneID        string            `js:"timezoneID"`
-	UserAgent         string            `js:"userAgent"`
-	VideosPath        string            `js:"videosPath"`
-	Viewport          Viewport          `js:"viewport"`
+	// RecordProtocolScript generates a protocol-level script from the network
+	// traffic of the browser context, if it's set.
+	RecordProtocolScript *RecordProtocolScriptOptions `js:"recordProtocolScript"`
+	Screen               Screen                       `js:"screen"`
+	TimezoneID           string                       `js:"timezoneID"`
+	UserAgent            string                       `js:"userAgent"`
+	VideosPath           string                       `js:"videosPath"`
+	Viewport             Viewport                     `js:"viewport"`
 }
 
 // DefaultBrowserContextOptions returns the default browser context options.
@@ -407,6 +407,9 @@ func (m *NetworkManager) onLoadingFinished(event *network.EventLoadingFinished)
 	if isInternalURL(req.url) {
 		return
 	}
+	if page := m.frameManager.page; page != nil {
+		page.browserCtx.recordProtocolScriptRequest(req, event.Timestamp.Time().Add(req.offset))
+	}
 	emitResponseMetrics := func() {
 		req.responseMu.RLock()
 		m.emitResponseMetrics(req.response, req)
@@ -0,0 +1,193 @@
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RecordProtocolScriptOptions configure the generation of a protocol-level
+// k6 script from the network traffic of a browser context, so that a flow,
+// which a browser test recorded, can be turned into a high-VU protocol test.
+type RecordProtocolScriptOptions struct {
+	// Path is where the script is written when the browser context closes.
+	Path string `js:"path"`
+	// IncludeStaticAssets includes the requests for stylesheets, scripts,
+	// images, fonts and other static assets, which are skipped by default.
+	IncludeStaticAssets bool `js:"includeStaticAssets"`
+}
+
+// minProtocolScriptSleep is the shortest pause between two requests, which is
+// turned into a sleep() call in the generated script.
+const minProtocolScriptSleep = 100 * time.Millisecond
+
+// protocolScriptResourceTypes are the resource types of the requests, which
+// are included in the generated script by default.
+var protocolScriptResourceTypes = map[string]bool{ //nolint:gochecknoglobals
+	ResourceTypeDocument:    true,
+	ResourceTypeXHR:         true,
+	ResourceTypeFetch:       true,
+	ResourceTypeEventSource: true,
+}
+
+// protocolScriptSkippedResourceTypes are the resource types of the requests,
+// which the browser makes on its own, or which k6/http can't replay.
+var protocolScriptSkippedResourceTypes = map[string]bool{ //nolint:gochecknoglobals
+	ResourceTypeWebSocket:          true,
+	ResourceTypePreflight:          true,
+	ResourceTypePing:               true,
+	ResourceTypeCSPViolationReport: true,
+}
+
+// protocolScriptSkippedHeaders are the request headers, which k6/http sets on
+// its own, like the cookies that its cookie jar manages.
+var protocolScriptSkippedHeaders = map[string]bool{ //nolint:gochecknoglobals
+	"host":           true,
+	"cookie":         true,
+	"content-length": true,
+	"connection":     true,
+}
+
+// protocolScriptRequest is a finished request of the browser context.
+type protocolScriptRequest struct {
+	method       string
+	url          string
+	headers      map[string][]string
+	body         string
+	resourceType string
+	status       int64
+	start        time.Time
+	end          time.Time
+}
+
+// protocolScriptRecorder records the finished requests of a browser context
+// and generates the equivalent k6/http script from them.
+type protocolScriptRecorder struct {
+	opts RecordProtocolScriptOptions
+
+	mu       sync.Mutex
+	requests []protocolScriptRequest
+}
+
+func newProtocolScriptRecorder(opts RecordProtocolScriptOptions) *protocolScriptRecorder {
+	return &protocolScriptRecorder{opts: opts}
+}
+
+// record adds the finished request, unless its resource type is skipped. A
+// redirected request is recorded with the method, the URL, the headers and
+// the body of the first request of its redirect chain, since k6/http follows
+// the redirects on its own.
+func (r *protocolScriptRecorder) record(req *Request, end time.Time) {
+	if protocolScriptSkippedResourceTypes[req.resourceType] ||
+		(!r.opts.IncludeStaticAssets && !protocolScriptResourceTypes[req.resourceType]) {
+		return
+	}
+
+	first := req
+	if len(req.redirectChain) > 0 {
+		first = req.redirectChain[0]
+	}
+	rec := protocolScriptRequest{
+		method:       first.method,
+		url:          first.url.String(),
+		headers:      first.headers,
+		body:         first.PostData(),
+		resourceType: req.resourceType,
+		start:        first.wallTime,
+		end:          end,
+	}
+	req.responseMu.RLock()
+	if req.response != nil {
+		rec.status = req.response.status
+	}
+	req.responseMu.RUnlock()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requests = append(r.requests, rec)
+}
+
+// script returns the k6 script, which makes the recorded requests in the order
+// the browser made them, with the pauses between them.
+func (r *protocolScriptRecorder) script() string {
+	r.mu.Lock()
+	requests := make([]protocolScriptRequest, len(r.requests))
+	copy(requests, r.requests)
+	r.mu.Unlock()
+
+	sort.SliceStable(requests, func(i, j int) bool {
+		return requests[i].start.Before(requests[j].start)
+	})
+
+	var b strings.Builder
+	b.WriteString("// Generated by the k6 browser module from the network traffic of a browser\n")
+	b.WriteString("// context, as a starting point for a protocol-level test.\n")
+	b.WriteString("import http from 'k6/http';\n")
+	b.WriteString("import { sleep } from 'k6';\n\n")
+	b.WriteString("export default function () {\n")
+
+	var lastEnd time.Time
+	for i, req := range requests {
+		if i > 0 {
+			b.WriteString("\n")
+			if gap := req.start.Sub(lastEnd); gap >= minProtocolScriptSleep {
+				fmt.Fprintf(&b, "  sleep(%.1f);\n\n", gap.Seconds())
+			}
+		}
+		if req.end.After(lastEnd) {
+			lastEnd = req.end
+		}
+		writeProtocolScriptRequest(&b, req)
+	}
+
+	b.WriteString("}\n")
+	return b.String()
+}
+
+func writeProtocolScriptRequest(b *strings.Builder, req protocolScriptRequest) {
+	fmt.Fprintf(b, "  // %s, %d in %s\n", req.resourceType, req.status,
+		req.end.Sub(req.start).Round(time.Millisecond))
+
+	body := "null"
+	if req.body != "" {
+		body = jsString(req.body)
+	}
+	switch req.method {
+	case http.MethodGet:
+		fmt.Fprintf(b, "  http.get(%s, {\n", jsString(req.url))
+	case http.MethodDelete:
+		fmt.Fprintf(b, "  http.del(%s, %s, {\n", jsString(req.url), body)
+	case http.MethodPost, http.MethodPut, http.MethodPatch:
+		fmt.Fprintf(b, "  http.%s(%s, %s, {\n", strings.ToLower(req.method), jsString(req.url), body)
+	default:
+		fmt.Fprintf(b, "  http.request(%s, %s, %s, {\n", jsString(req.method), jsString(req.url), body)
+	}
+
+	names := make([]string, 0, len(req.headers))
+	for name := range req.headers {
+		if protocolScriptSkippedHeaders[strings.ToLower(name)] || strings.HasPrefix(name, ":") {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	b.WriteString("    headers: {\n")
+	for _, name := range names {
+		fmt.Fprintf(b, "      %s: %s,\n", jsString(name), jsString(strings.Join(req.headers[name], ", ")))
+	}
+	b.WriteString("    },\n")
+	b.WriteString("  });\n")
+}
+
+// jsString returns the string as a JavaScript string literal.
+func jsString(s string) string {
+	// JSON strings are valid JavaScript string literals, since the encoder
+	// escapes the line and paragraph separators.
+	data, _ := json.Marshal(s) //nolint:errchkjson
+	return string(data)
+}
@@ -0,0 +1,79 @@
+package common
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProtocolScriptRecorder(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	newReq := func(method, rawURL, resourceType string, offset time.Duration) *Request {
+		u, err := url.Parse(rawURL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &Request{
+			method:       method,
+			url:          u,
+			resourceType: resourceType,
+			wallTime:     start.Add(offset),
+			headers: map[string][]string{
+				"Accept": {"*/*"},
+				"Cookie": {"session=123"},
+			},
+			response: &Response{status: 200},
+		}
+	}
+
+	rec := newProtocolScriptRecorder(RecordProtocolScriptOptions{Path: "flow.js"})
+
+	// the document was redirected from the root
+	redirected := newReq("GET", "https://example.com/", ResourceTypeDocument, 0)
+	doc := newReq("GET", "https://example.com/home", ResourceTypeDocument, 10*time.Millisecond)
+	doc.redirectChain = []*Request{redirected}
+	rec.record(doc, start.Add(50*time.Millisecond))
+
+	rec.record(newReq("GET", "https://example.com/app.css", ResourceTypeStylesheet, 60*time.Millisecond),
+		start.Add(70*time.Millisecond))
+	rec.record(newReq("GET", "wss://example.com/ws", ResourceTypeWebSocket, 60*time.Millisecond),
+		start.Add(70*time.Millisecond))
+
+	login := newReq("POST", "https://example.com/login", ResourceTypeFetch, 2*time.Second)
+	login.postDataEntries = []string{`{"user":"alice"}`}
+	rec.record(login, start.Add(2100*time.Millisecond))
+
+	assert.Equal(t, `// Generated by the k6 browser module from the network traffic of a browser
+// context, as a starting point for a protocol-level test.
+import http from 'k6/http';
+import { sleep } from 'k6';
+
+export default function () {
+  // Document, 200 in 50ms
+  http.get("https://example.com/", {
+    headers: {
+      "Accept": "*/*",
+    },
+  });
+
+  sleep(1.9);
+
+  // Fetch, 200 in 100ms
+  http.post("https://example.com/login", "{\"user\":\"alice\"}", {
+    headers: {
+      "Accept": "*/*",
+    },
+  });
+}
+`, rec.script())
+
+	rec = newProtocolScriptRecorder(RecordProtocolScriptOptions{IncludeStaticAssets: true})
+	rec.record(newReq("GET", "https://example.com/app.css", ResourceTypeStylesheet, 0), start)
+	rec.record(newReq("OPTIONS", "https://example.com/api", ResourceTypePreflight, 0), start)
+	assert.Contains(t, rec.script(), `http.get("https://example.com/app.css"`)
+	assert.NotContains(t, rec.script(), "OPTIONS")
+}
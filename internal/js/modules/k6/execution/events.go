@@ -0,0 +1,135 @@
+package execution
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/grafana/sobek"
+	"github.com/sirupsen/logrus"
+
+	"go.k6.io/k6/internal/event"
+	"go.k6.io/k6/js/common"
+)
+
+// scriptEvents are the names of the events that the scripts can subscribe to.
+//
+//nolint:gochecknoglobals
+var scriptEvents = map[string]event.Type{
+	"testStart":        event.TestStart,
+	"scenarioStart":    event.ScenarioStart,
+	"scenarioEnd":      event.ScenarioEnd,
+	"thresholdCrossed": event.ThresholdCrossed,
+	"abort":            event.Abort,
+}
+
+// callbackQueuer is implemented by the VUs, whose event loops can run the
+// callbacks queued from other goroutines.
+type callbackQueuer interface {
+	QueueCallback(f func() error)
+}
+
+// eventHandlers keeps the handlers that the script registered with on(). The
+// VU's runtime can only be used by the VU, so the handlers are queued to its
+// event loop, which calls them during its current or next iteration.
+type eventHandlers struct {
+	handlers map[event.Type][]sobek.Callable
+	queuer   callbackQueuer
+}
+
+// on registers the handler to be called with the events of the given name.
+func (mi *ModuleInstance) on(name string, handler sobek.Value) {
+	rt := mi.vu.Runtime()
+	evtType, ok := scriptEvents[name]
+	if !ok {
+		common.Throw(rt, fmt.Errorf("unknown event '%s'", name))
+	}
+	callable, ok := sobek.AssertFunction(handler)
+	if !ok {
+		common.Throw(rt, fmt.Errorf("the handler of the '%s' event must be a function", name))
+	}
+
+	if mi.events == nil {
+		queuer, ok := mi.vu.(callbackQueuer)
+		if !ok {
+			common.Throw(rt, errors.New("the events aren't supported by this VU"))
+		}
+		mi.events = &eventHandlers{handlers: make(map[event.Type][]sobek.Callable), queuer: queuer}
+		mi.subscribeEvents()
+	}
+	mi.events.handlers[evtType] = append(mi.events.handlers[evtType], callable)
+}
+
+func (mi *ModuleInstance) subscribeEvents() {
+	events := mi.vu.Events().Global
+	types := make([]event.Type, 0, len(scriptEvents)+1)
+	for _, evtType := range scriptEvents {
+		types = append(types, evtType)
+	}
+	subID, ch := events.Subscribe(append(types, event.Exit)...)
+
+	go func() {
+		for evt := range ch {
+			if evt.Type == event.Exit {
+				evt.Done()
+				events.Unsubscribe(subID)
+				continue
+			}
+			mi.events.queuer.QueueCallback(func() error {
+				mi.callEventHandlers(evt)
+				return nil
+			})
+			evt.Done()
+		}
+	}()
+}
+
+// callEventHandlers calls the handlers for the event. It must only be called
+// on the VU's event loop, and the failures of the handlers don't interrupt
+// the iteration.
+func (mi *ModuleInstance) callEventHandlers(evt *event.Event) {
+	rt := mi.vu.Runtime()
+	obj := rt.ToValue(scriptEventObject(evt))
+	for _, handler := range mi.events.handlers[evt.Type] {
+		if _, err := handler(sobek.Undefined(), obj); err != nil {
+			mi.logger().WithError(err).Errorf("The handler of the %s event failed", evt.Type)
+		}
+	}
+}
+
+// logger returns the logger of the VU, which may still be in the init context.
+func (mi *ModuleInstance) logger() logrus.FieldLogger {
+	if state := mi.vu.State(); state != nil {
+		return state.Logger
+	}
+	return mi.vu.InitEnv().Logger
+}
+
+// scriptEventObject returns what the handlers of the event are called with.
+func scriptEventObject(evt *event.Event) map[string]any {
+	obj := map[string]any{}
+	for name, evtType := range scriptEvents {
+		if evtType == evt.Type {
+			obj["type"] = name
+		}
+	}
+	errorMessage := func(err error) any {
+		if err == nil {
+			return nil
+		}
+		return err.Error()
+	}
+
+	switch data := evt.Data.(type) {
+	case *event.ScenarioData:
+		obj["scenario"] = data.Name
+		obj["executor"] = data.Executor
+		obj["error"] = errorMessage(data.Error)
+	case *event.ThresholdData:
+		obj["metric"] = data.Metric
+		obj["thresholds"] = data.Thresholds
+		obj["abortOnFail"] = data.AbortOnFail
+	case *event.AbortData:
+		obj["reason"] = errorMessage(data.Reason)
+	}
+	return obj
+}
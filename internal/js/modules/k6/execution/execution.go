@@ -19,12 +19,18 @@ import (
 type (
 	// RootModule is the global module instance that will create module
 	// instances for each VU.
-	RootModule struct{}
+	RootModule struct {
+		leases *leases
+	}
 
 	// ModuleInstance represents an instance of the execution module.
 	ModuleInstance struct {
-		vu  modules.VU
-		obj *sobek.Object
+		vu     modules.VU
+		obj    *sobek.Object
+		leases *leases
+
+		// created when the script registers its first event handler
+		events *eventHandlers
 	}
 )
 
@@ -35,13 +41,13 @@ var (
 
 // New returns a pointer to a new RootModule instance.
 func New() *RootModule {
-	return &RootModule{}
+	return &RootModule{leases: newLeases()}
 }
 
 // NewModuleInstance implements the modules.Module interface to return
 // a new instance for each VU.
-func (*RootModule) NewModuleInstance(vu modules.VU) modules.Instance {
-	mi := &ModuleInstance{vu: vu}
+func (r *RootModule) NewModuleInstance(vu modules.VU) modules.Instance {
+	mi := &ModuleInstance{vu: vu, leases: r.leases}
 	rt := vu.Runtime()
 	o := rt.NewObject()
 	defProp := func(name string, newInfo func() (*sobek.Object, error)) {
@@ -60,6 +66,9 @@ func (*RootModule) NewModuleInstance(vu modules.VU) modules.Instance {
 	defProp("scenario", mi.newScenarioInfo)
 	defProp("test", mi.newTestInfo)
 	defProp("vu", mi.newVUInfo)
+	if err := o.Set("on", mi.on); err != nil {
+		common.Throw(rt, err)
+	}
 
 	mi.obj = o
 
@@ -108,6 +117,13 @@ func (mi *ModuleInstance) newScenarioInfo() (*sobek.Object, error) {
 			p, _ := getScenarioState().ProgressFn()
 			return p
 		},
+		"options": func() interface{} {
+			obj, err := scenarioOptionsAsObject(rt, vuState.Options, getScenarioState().Name)
+			if err != nil {
+				common.Throw(rt, err)
+			}
+			return obj
+		},
 		"iterationInInstance": func() interface{} {
 			if vuState.GetScenarioLocalVUIter == nil {
 				common.Throw(rt, errRunInInitContext)
@@ -180,6 +196,9 @@ func (mi *ModuleInstance) newTestInfo() (*sobek.Object, error) {
 				rt.Interrupt(&errext.InterruptError{Reason: reason})
 			}
 		},
+		"artifacts": func() interface{} {
+			return map[string]interface{}{"write": mi.writeArtifact}
+		},
 		"options": func() interface{} {
 			vuState := mi.vu.State()
 			if vuState == nil {
@@ -199,6 +218,33 @@ func (mi *ModuleInstance) newTestInfo() (*sobek.Object, error) {
 	return newInfoObj(rt, ti)
 }
 
+var errArtifactsInitContext = common.NewInitContextError("writing artifacts in the init context is not supported")
+
+// writeArtifact writes a file to the artifact directory of the test run and
+// returns its path.
+func (mi *ModuleInstance) writeArtifact(name string, data sobek.Value) string {
+	rt := mi.vu.Runtime()
+	if mi.vu.State() == nil {
+		common.Throw(rt, errArtifactsInitContext)
+	}
+	es := lib.GetExecutionState(mi.vu.Context())
+	if es == nil || es.Test.Artifacts == nil {
+		common.Throw(rt, errors.New("artifacts aren't supported in this test run"))
+	}
+	if data == nil || sobek.IsUndefined(data) || sobek.IsNull(data) {
+		common.Throw(rt, errors.New("the data of the artifact is required"))
+	}
+	b, err := common.ToBytes(data.Export())
+	if err != nil {
+		common.Throw(rt, err)
+	}
+	path, err := es.Test.Artifacts.Write(name, b)
+	if err != nil {
+		common.Throw(rt, err)
+	}
+	return path
+}
+
 var errVUInfoInitContex = common.NewInitContextError("getting VU information in the init context is not supported")
 
 // newVUInfo returns a sobek.Object with property accessors to retrieve
@@ -222,6 +268,26 @@ func (mi *ModuleInstance) newVUInfo() (*sobek.Object, error) {
 
 			return vuState.GetScenarioVUIter()
 		},
+		// lease an item of some data, like a row of a list of users, for the
+		// lifetime of the VU
+		"lease": func() interface{} {
+			return func(name string, size int64) int64 {
+				es := lib.GetExecutionState(mi.vu.Context())
+				if es == nil {
+					common.Throw(rt, errVUInfoInitContex)
+				}
+				item, err := mi.leases.lease(es.ExecutionTuple, name, size, vuState.VUIDGlobal)
+				if err != nil {
+					common.Throw(rt, err)
+				}
+				return item
+			}
+		},
+		"release": func() interface{} {
+			return func(name string) bool {
+				return mi.leases.release(name, vuState.VUIDGlobal)
+			}
+		},
 	}
 
 	o, err := newInfoObj(rt, vi)
@@ -257,9 +323,44 @@ func (mi *ModuleInstance) newVUInfo() (*sobek.Object, error) {
 		return o, err
 	}
 
+	if vuState.Clock != nil {
+		clock, err := newClockInfo(rt, vuState.Clock)
+		if err != nil {
+			return o, err
+		}
+		err = o.Set("clock", clock)
+		if err != nil {
+			return o, err
+		}
+	}
+
 	return o, err
 }
 
+// newClockInfo returns a sobek.Object with the skew of the clock of the VU,
+// in milliseconds, and the functions to change it.
+func newClockInfo(rt *sobek.Runtime, clock *lib.Clock) (*sobek.Object, error) {
+	toDuration := func(ms float64) time.Duration {
+		return time.Duration(ms * float64(time.Millisecond))
+	}
+	return newInfoObj(rt, map[string]func() interface{}{
+		"skew": func() interface{} {
+			return float64(clock.Skew()) / float64(time.Millisecond)
+		},
+		"setSkew": func() interface{} {
+			return func(ms float64) { clock.SetSkew(toDuration(ms)) }
+		},
+		"advance": func() interface{} {
+			return func(ms float64) {
+				if ms < 0 {
+					common.Throw(rt, errors.New("the clock can't be advanced backwards, set its skew instead"))
+				}
+				clock.Advance(toDuration(ms))
+			}
+		},
+	})
+}
+
 func newInfoObj(rt *sobek.Runtime, props map[string]func() interface{}) (*sobek.Object, error) {
 	o := rt.NewObject()
 
@@ -284,15 +385,7 @@ func optionsAsObject(rt *sobek.Runtime, options lib.Options) (*sobek.Object, err
 		return nil, fmt.Errorf("failed to encode the lib.Options as json: %w", err)
 	}
 
-	// Using the native JS parser function guarantees getting
-	// the supported types for deep freezing the complex object.
-	jsonParse, _ := sobek.AssertFunction(rt.GlobalObject().Get("JSON").ToObject(rt).Get("parse"))
-	parsed, err := jsonParse(sobek.Undefined(), rt.ToValue(string(b)))
-	if err != nil {
-		common.Throw(rt, err)
-	}
-
-	obj := parsed.ToObject(rt)
+	obj := parseJSONObject(rt, b)
 
 	mustDelete := func(prop string) {
 		delErr := obj.Delete(prop)
@@ -336,6 +429,36 @@ func optionsAsObject(rt *sobek.Runtime, options lib.Options) (*sobek.Object, err
 	return obj, nil
 }
 
+// scenarioOptionsAsObject maps the consolidated and derived configuration of the
+// scenario, like its stages or VU targets, in a frozen sobek.Object.
+func scenarioOptionsAsObject(rt *sobek.Runtime, options lib.Options, name string) (*sobek.Object, error) {
+	config, ok := options.Scenarios[name]
+	if !ok {
+		return nil, fmt.Errorf("the options of the scenario %q are unknown", name)
+	}
+	b, err := json.Marshal(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode the options of the scenario %q as json: %w", name, err)
+	}
+
+	obj := parseJSONObject(rt, b)
+	if err = common.FreezeObject(rt, obj); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// parseJSONObject parses the JSON object. Using the native JS parser function
+// guarantees getting the supported types for deep freezing the complex object.
+func parseJSONObject(rt *sobek.Runtime, b []byte) *sobek.Object {
+	jsonParse, _ := sobek.AssertFunction(rt.GlobalObject().Get("JSON").ToObject(rt).Get("parse"))
+	parsed, err := jsonParse(sobek.Undefined(), rt.ToValue(string(b)))
+	if err != nil {
+		common.Throw(rt, err)
+	}
+	return parsed.ToObject(rt)
+}
+
 type tagsDynamicObject struct {
 	runtime *sobek.Runtime
 	state   *lib.State
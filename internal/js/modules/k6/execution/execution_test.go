@@ -4,9 +4,11 @@ import (
 	"context"
 	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -15,11 +17,13 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.k6.io/k6/errext"
+	"go.k6.io/k6/internal/event"
 	"go.k6.io/k6/internal/lib/testutils"
 	"go.k6.io/k6/js/common"
 	"go.k6.io/k6/js/modulestest"
 	"go.k6.io/k6/lib"
 	"go.k6.io/k6/lib/executor"
+	"go.k6.io/k6/lib/fsext"
 	"go.k6.io/k6/lib/types"
 	"go.k6.io/k6/metrics"
 	"gopkg.in/guregu/null.v3"
@@ -223,7 +227,7 @@ func TestAbortTest(t *testing.T) { //nolint:tparallel
 func TestOptionsTestFull(t *testing.T) {
 	t.Parallel()
 
-	expected := `{"paused":true,"scenarios":{"const-vus":{"executor":"constant-vus","options":{"browser":{"someOption":true}},"startTime":"10s","gracefulStop":"30s","env":{"FOO":"bar"},"exec":"default","tags":{"tagkey":"tagvalue"},"vus":50,"duration":"10m0s"}},"executionSegment":"0:1/4","executionSegmentSequence":"0,1/4,1/2,1","noSetup":true,"setupTimeout":"1m0s","noTeardown":true,"teardownTimeout":"5m0s","rps":100,"dns":{"ttl":"1m","select":"roundRobin","policy":"any"},"maxRedirects":3,"userAgent":"k6-user-agent","batch":15,"batchPerHost":5,"httpDebug":"full","insecureSkipTLSVerify":true,"tlsCipherSuites":["TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"],"tlsVersion":{"min":"tls1.2","max":"tls1.3"},"tlsAuth":[{"domains":["example.com"],"cert":"mycert.pem","key":"mycert-key.pem","password":"mypwd"}],"throw":true,"thresholds":{"http_req_duration":[{"threshold":"rate>0.01","abortOnFail":true,"delayAbortEval":"10s"}]},"blacklistIPs":["192.0.2.0/24"],"blockHostnames":["test.k6.io","*.example.com"],"hosts":{"test.k6.io":"1.2.3.4:8443"},"noConnectionReuse":true,"noVUConnectionReuse":true,"minIterationDuration":"10s","ext":{"ext-one":{"rawkey":"rawvalue"}},"summaryTrendStats":["avg","min","max"],"summaryTimeUnit":"ms","systemTags":["iter","vu"],"tags":null,"metricSamplesBufferSize":8,"noCookiesReset":true,"discardResponseBodies":true,"consoleOutput":"loadtest.log","tags":{"runtag-key":"runtag-value"},"localIPs":"192.168.20.12-192.168.20.15,192.168.10.0/27"}`
+	expected := `{"paused":true,"scenarios":{"const-vus":{"executor":"constant-vus","options":{"browser":{"someOption":true}},"startTime":"10s","gracefulStop":"30s","env":{"FOO":"bar"},"exec":"default","iterationTimeout":null,"onIterationTimeout":null,"tags":{"tagkey":"tagvalue"},"vus":50,"duration":"10m0s"}},"executionSegment":"0:1/4","executionSegmentSequence":"0,1/4,1/2,1","noSetup":true,"setupTimeout":"1m0s","noTeardown":true,"teardownTimeout":"5m0s","onAbortTimeout":null,"scenarioSetupData":null,"rps":100,"dns":{"ttl":"1m","select":"roundRobin","policy":"any","servers":null,"negativeTTL":null,"maxStale":null},"maxRedirects":3,"userAgent":"k6-user-agent","httpProfile":null,"batch":15,"batchPerHost":5,"httpDebug":"full","httpFlightRecorder":null,"staticAssetCache":null,"authSessions":null,"clusterStore":null,"insecureSkipTLSVerify":true,"ocspStapling":null,"tlsCipherSuites":["TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"],"tlsVersion":{"min":"tls1.2","max":"tls1.3"},"tlsCurves":null,"tlsClientHello":null,"tlsSessionResumption":null,"tlsAuth":[{"domains":["example.com"],"cert":"mycert.pem","key":"mycert-key.pem","password":"mypwd"}],"throw":true,"thresholds":{"http_req_duration":[{"threshold":"rate>0.01","abortOnFail":true,"delayAbortEval":"10s"}]},"blacklistIPs":["192.0.2.0/24"],"blockHostnames":["test.k6.io","*.example.com"],"hosts":{"test.k6.io":"1.2.3.4:8443"},"noConnectionReuse":true,"noVUConnectionReuse":true,"sharedConnectionPool":null,"maxConnsPerHost":null,"maxIdleConns":null,"maxIdleConnsPerHost":null,"idleConnTimeout":null,"proxy":null,"networkConditions":null,"minIterationDuration":"10s","vuMemoryLimit":null,"clock":null,"randomSeed":null,"ext":{"ext-one":{"rawkey":"rawvalue"}},"outputTuning":null,"summaryTrendStats":["avg","min","max"],"summaryTimeUnit":"ms","systemTags":["iter","vu"],"metricSystemTags":null,"dataAttribution":null,"tags":null,"metadata":{"name":null,"version":null,"environment":null,"gitSHA":null},"metricSamplesBufferSize":8,"metricSamplesPerVUBufferSize":16,"trendCompression":100,"noCookiesReset":true,"discardResponseBodies":true,"artifactsDir":null,"consoleOutput":"loadtest.log","tags":{"runtag-key":"runtag-value"},"localIPs":"192.168.20.12-192.168.20.15,192.168.10.0/27"}`
 
 	var (
 		rt    = sobek.New()
@@ -349,9 +353,11 @@ func TestOptionsTestFull(t *testing.T) {
 					sysm := metrics.SystemTagSet(metrics.TagIter | metrics.TagVU)
 					return &sysm
 				}(),
-				RunTags:                 map[string]string{"runtag-key": "runtag-value"},
-				MetricSamplesBufferSize: null.IntFrom(8),
-				ConsoleOutput:           null.StringFrom("loadtest.log"),
+				RunTags:                      map[string]string{"runtag-key": "runtag-value"},
+				MetricSamplesBufferSize:      null.IntFrom(8),
+				MetricSamplesPerVUBufferSize: null.IntFrom(16),
+				TrendCompression:             null.FloatFrom(100),
+				ConsoleOutput:                null.StringFrom("loadtest.log"),
 				LocalIPs: func() types.NullIPPool {
 					npool := types.NullIPPool{}
 					err := npool.UnmarshalText([]byte("192.168.20.12-192.168.20.15,192.168.10.0/27"))
@@ -434,7 +440,7 @@ func TestScenarioNoAvailableInInitContext(t *testing.T) {
 	require.True(t, ok)
 	require.NoError(t, rt.Set("exec", m.Exports().Default))
 
-	scenarioExportedProps := []string{"name", "executor", "startTime", "progress", "iterationInInstance", "iterationInTest"}
+	scenarioExportedProps := []string{"name", "executor", "startTime", "progress", "iterationInInstance", "iterationInTest", "options"}
 
 	for _, code := range scenarioExportedProps {
 		prop := fmt.Sprintf("exec.scenario.%s", code)
@@ -444,6 +450,78 @@ func TestScenarioNoAvailableInInitContext(t *testing.T) {
 	}
 }
 
+func TestScenarioOptions(t *testing.T) {
+	t.Parallel()
+
+	ramping := executor.NewRampingVUsConfig("ramp")
+	ramping.StartVUs = null.IntFrom(2)
+	ramping.Stages = []executor.Stage{
+		{Duration: types.NullDurationFrom(10 * time.Second), Target: null.IntFrom(5)},
+		{Duration: types.NullDurationFrom(20 * time.Second), Target: null.IntFrom(0)},
+	}
+
+	rt := sobek.New()
+	ctx := lib.WithScenarioState(context.Background(), &lib.ScenarioState{Name: "ramp", Executor: ramping.Type})
+	m, ok := New().NewModuleInstance(
+		&modulestest.VU{
+			RuntimeField: rt,
+			CtxField:     ctx,
+			StateField: &lib.State{
+				Options: lib.Options{
+					Scenarios: lib.ScenarioConfigs{
+						"ramp":  ramping,
+						"other": executor.NewSharedIterationsConfig("other"),
+					},
+				},
+			},
+		},
+	).(*ModuleInstance)
+	require.True(t, ok)
+	require.NoError(t, rt.Set("exec", m.Exports().Default))
+
+	v, err := rt.RunString(`
+		const o = exec.scenario.options;
+		o.startVUs = 10;
+		[o.executor, o.startVUs, o.stages.length, o.stages[0].duration, o.stages[0].target].join()
+	`)
+	require.NoError(t, err)
+	assert.Equal(t, "ramping-vus,2,2,10s,5", v.String())
+}
+
+func TestArtifactsWrite(t *testing.T) {
+	t.Parallel()
+
+	fs := fsext.NewMemMapFs()
+	artifacts := lib.NewArtifacts(fs, "/results", time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC))
+	rt := sobek.New()
+	ctx := lib.WithExecutionState(context.Background(), &lib.ExecutionState{
+		Test: &lib.TestRunState{Artifacts: artifacts},
+	})
+	m, ok := New().NewModuleInstance(
+		&modulestest.VU{
+			RuntimeField: rt,
+			CtxField:     ctx,
+			StateField:   &lib.State{},
+		},
+	).(*ModuleInstance)
+	require.True(t, ok)
+	require.NoError(t, rt.Set("exec", m.Exports().Default))
+
+	v, err := rt.RunString(`exec.test.artifacts.write("screenshots/home.txt", "hello")`)
+	require.NoError(t, err)
+	path := filepath.Join("/results", "20240506T070809Z", "screenshots", "home.txt")
+	assert.Equal(t, path, v.String())
+	b, err := fsext.ReadFile(fs, path)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(b))
+
+	_, err = rt.RunString(`exec.test.artifacts.write("../escape.txt", "hello")`)
+	assert.ErrorContains(t, err, "outside of the artifact directory")
+
+	_, err = rt.RunString(`exec.test.artifacts.write("empty.txt")`)
+	assert.ErrorContains(t, err, "the data of the artifact is required")
+}
+
 func TestOptionsNoAvailableInInitContext(t *testing.T) {
 	t.Parallel()
 
@@ -489,6 +567,63 @@ func TestVUDefaultDetails(t *testing.T) {
 	}
 }
 
+func TestVULease(t *testing.T) {
+	t.Parallel()
+
+	et, err := lib.NewExecutionTuple(nil, nil)
+	require.NoError(t, err)
+	ctx := lib.WithExecutionState(context.Background(), &lib.ExecutionState{ExecutionTuple: et})
+	root := New()
+	newVU := func(id uint64) *sobek.Runtime {
+		rt := sobek.New()
+		m, ok := root.NewModuleInstance(
+			&modulestest.VU{
+				RuntimeField: rt,
+				CtxField:     ctx,
+				StateField:   &lib.State{VUIDGlobal: id},
+			},
+		).(*ModuleInstance)
+		require.True(t, ok)
+		require.NoError(t, rt.Set("exec", m.Exports().Default))
+		return rt
+	}
+	vu1, vu2, vu3 := newVU(1), newVU(2), newVU(3)
+
+	lease := func(rt *sobek.Runtime) (int64, error) {
+		v, err := rt.RunString(`exec.vu.lease("users", 2)`)
+		if err != nil {
+			return 0, err
+		}
+		return v.ToInteger(), nil
+	}
+
+	item, err := lease(vu2)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), item)
+	item, err = lease(vu1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), item)
+
+	// the VUs keep their items
+	item, err = lease(vu2)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), item)
+
+	_, err = lease(vu3)
+	require.ErrorContains(t, err, `all 2 items of the lease "users", which this instance can lease, are leased by other VUs`)
+
+	v, err := vu2.RunString(`exec.vu.release("users")`)
+	require.NoError(t, err)
+	assert.True(t, v.ToBoolean())
+	v, err = vu2.RunString(`exec.vu.release("users")`)
+	require.NoError(t, err)
+	assert.False(t, v.ToBoolean())
+
+	item, err = lease(vu3)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), item)
+}
+
 func TestTagsDynamicObjectGet(t *testing.T) {
 	t.Parallel()
 	rt := sobek.New()
@@ -518,3 +653,44 @@ func TestTagsDynamicObjectSet(t *testing.T) {
 	require.NotNil(t, val)
 	assert.Equal(t, val.String(), "v1")
 }
+
+func TestEventHandlersDuringIteration(t *testing.T) {
+	t.Parallel()
+
+	tenv := setupTagsExecEnv(t)
+	logger := testutils.NewLogger(t)
+	globalEvents := event.NewEventSystem(10, logger)
+	tenv.VU.EventsField = common.Events{
+		Global: globalEvents,
+		Local:  event.NewEventSystem(10, logger),
+	}
+	_, err := tenv.VU.Runtime().RunString(`
+		var reasons = [];
+		exec.on('abort', (e) => reasons.push(e.reason));
+	`)
+	require.NoError(t, err)
+	tenv.MoveToVUContext(&lib.State{Logger: logger})
+
+	// the event is emitted from another goroutine while the iteration waits,
+	// and its handler is called on the event loop before the iteration goes on
+	err = tenv.EventLoop.Start(func() error {
+		enqueue := tenv.VU.RegisterCallback()
+		go func() {
+			waitDone := globalEvents.Emit(&event.Event{
+				Type: event.Abort,
+				Data: &event.AbortData{Reason: errors.New("aborted")},
+			})
+			assert.NoError(t, waitDone(context.Background()))
+			enqueue(func() error {
+				_, err := tenv.VU.Runtime().RunString(`
+					if (reasons.length !== 1 || reasons[0] !== "aborted") {
+						throw new Error("unexpected reasons " + JSON.stringify(reasons));
+					}
+				`)
+				return err
+			})
+		}()
+		return nil
+	})
+	require.NoError(t, err)
+}
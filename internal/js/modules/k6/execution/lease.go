@@ -0,0 +1,98 @@
+package execution
+
+import (
+	"fmt"
+	"sync"
+
+	"go.k6.io/k6/lib"
+)
+
+// leases keeps track of the items of the data, like the rows of a list of
+// users, which are leased by the VUs. A VU keeps the items it leased for its
+// whole lifetime, over all its iterations and scenarios, until it releases
+// them, so it can simulate a returning user.
+type leases struct {
+	mu    sync.Mutex
+	pools map[string]*leasePool
+}
+
+// leasePool is the data with a given name. In distributed runs, each instance
+// only leases the items of its execution segment, so the VUs of different
+// instances never lease the same item.
+type leasePool struct {
+	size   int64
+	items  []int64          // the items of the instance, in the order they're leased
+	leased map[int64]uint64 // the VUs leasing the items
+	byVU   map[uint64]int64 // the items leased by the VUs
+}
+
+func newLeases() *leases {
+	return &leases{pools: make(map[string]*leasePool)}
+}
+
+func newLeasePool(et *lib.ExecutionTuple, size int64) *leasePool {
+	pool := &leasePool{
+		size:   size,
+		leased: make(map[int64]uint64),
+		byVU:   make(map[uint64]int64),
+	}
+	segIdx := lib.NewSegmentedIndex(et)
+	for {
+		_, unscaled := segIdx.Next()
+		if unscaled > size {
+			return pool
+		}
+		pool.items = append(pool.items, unscaled-1)
+	}
+}
+
+// lease returns the item of the data with the given name and size, which is
+// leased by the VU, leasing the first free one, if the VU doesn't have one.
+func (l *leases) lease(et *lib.ExecutionTuple, name string, size int64, vuID uint64) (int64, error) {
+	if size <= 0 {
+		return 0, fmt.Errorf("the size of the lease %q must be more than 0, but is %d", name, size)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	pool, ok := l.pools[name]
+	if !ok {
+		pool = newLeasePool(et, size)
+		l.pools[name] = pool
+	} else if pool.size != size {
+		return 0, fmt.Errorf("the size of the lease %q is %d, but it was %d", name, size, pool.size)
+	}
+
+	if item, ok := pool.byVU[vuID]; ok {
+		return item, nil
+	}
+	for _, item := range pool.items {
+		if _, ok := pool.leased[item]; !ok {
+			pool.leased[item] = vuID
+			pool.byVU[vuID] = item
+			return item, nil
+		}
+	}
+	return 0, fmt.Errorf("all %d items of the lease %q, which this instance can lease, are leased by other VUs",
+		len(pool.items), name)
+}
+
+// release frees the item of the data with the given name, which is leased by
+// the VU, so other VUs can lease it. It returns whether the VU had one.
+func (l *leases) release(name string, vuID uint64) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	pool, ok := l.pools[name]
+	if !ok {
+		return false
+	}
+	item, ok := pool.byVU[vuID]
+	if !ok {
+		return false
+	}
+	delete(pool.byVU, vuID)
+	delete(pool.leased, item)
+	return true
+}
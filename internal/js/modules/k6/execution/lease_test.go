@@ -0,0 +1,51 @@
+package execution
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/lib"
+)
+
+func TestLeasesSegments(t *testing.T) {
+	t.Parallel()
+
+	seq, err := lib.NewExecutionSegmentSequenceFromString("0,1/2,1")
+	require.NoError(t, err)
+	leased := make(map[int64]bool)
+	for _, segment := range []string{"0:1/2", "1/2:1"} {
+		seg, err := lib.NewExecutionSegmentFromString(segment)
+		require.NoError(t, err)
+		et, err := lib.NewExecutionTuple(seg, &seq)
+		require.NoError(t, err)
+
+		// each instance has its own leases and only leases its share of the items
+		l := newLeases()
+		for vuID := uint64(1); vuID <= 3; vuID++ {
+			item, err := l.lease(et, "users", 6, vuID)
+			require.NoError(t, err)
+			assert.False(t, leased[item], "item %d leased by two instances", item)
+			leased[item] = true
+		}
+		_, err = l.lease(et, "users", 6, 4)
+		assert.ErrorContains(t, err, `all 3 items of the lease "users", which this instance can lease, are leased by other VUs`)
+	}
+	assert.Len(t, leased, 6)
+}
+
+func TestLeasesInvalidSize(t *testing.T) {
+	t.Parallel()
+
+	et, err := lib.NewExecutionTuple(nil, nil)
+	require.NoError(t, err)
+	l := newLeases()
+	_, err = l.lease(et, "users", 0, 1)
+	assert.ErrorContains(t, err, `the size of the lease "users" must be more than 0, but is 0`)
+
+	_, err = l.lease(et, "users", 2, 1)
+	require.NoError(t, err)
+	_, err = l.lease(et, "users", 3, 2)
+	assert.ErrorContains(t, err, `the size of the lease "users" is 3, but it was 2`)
+}
@@ -0,0 +1,264 @@
+// Package binary provides helpers for validating binary data, like the bodies
+// of the responses of binary APIs: struct definitions, which parse the data into
+// objects, checksums and hexdumps, which show where the data differs.
+package binary
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"hash/adler32"
+	"hash/crc32"
+	"hash/fnv"
+	"strings"
+
+	"github.com/grafana/sobek"
+
+	"go.k6.io/k6/js/common"
+	"go.k6.io/k6/js/modules"
+)
+
+type (
+	// RootModule is the global module instance that will create instances of our
+	// module for each VU.
+	RootModule struct{}
+
+	// ModuleInstance represents an instance of the binary module for a single VU.
+	ModuleInstance struct {
+		vu modules.VU
+	}
+)
+
+var (
+	_ modules.Module   = &RootModule{}
+	_ modules.Instance = &ModuleInstance{}
+)
+
+// New returns a pointer to a new [RootModule] instance.
+func New() *RootModule {
+	return &RootModule{}
+}
+
+// NewModuleInstance implements the modules.Module interface and returns a new
+// instance of our module for the given VU.
+func (*RootModule) NewModuleInstance(vu modules.VU) modules.Instance {
+	return &ModuleInstance{vu: vu}
+}
+
+// Exports implements the modules.Module interface and returns the exports of
+// our module.
+func (mi *ModuleInstance) Exports() modules.Exports {
+	return modules.Exports{
+		Named: map[string]any{
+			"struct":  mi.Struct,
+			"crc16":   mi.checksum(crc16),
+			"crc32":   mi.checksum(crc32.ChecksumIEEE),
+			"crc32c":  mi.checksum(crc32c),
+			"adler32": mi.checksum(adler32.Checksum),
+			"fnv1a32": mi.checksum(fnv1a32),
+			"hexdump": mi.Hexdump,
+			"diff":    mi.Diff,
+			"equal":   mi.Equal,
+		},
+	}
+}
+
+// Struct returns a struct with the given definition, which is an object with
+// the names of the fields and their types, in the order they're in the data.
+func (mi *ModuleInstance) Struct(definition sobek.Value) *Struct {
+	rt := mi.vu.Runtime()
+	s, err := newStruct(rt, definition)
+	if err != nil {
+		common.Throw(rt, err)
+	}
+	return s
+}
+
+func (mi *ModuleInstance) checksum(fn func([]byte) uint32) func(sobek.Value) uint32 {
+	return func(data sobek.Value) uint32 {
+		rt := mi.vu.Runtime()
+		b, err := toBytes(rt, data)
+		if err != nil {
+			common.Throw(rt, err)
+		}
+		return fn(b)
+	}
+}
+
+// crc16 is the CRC-16/CCITT-FALSE checksum, with the 0x1021 polynomial and
+// the 0xFFFF initial value.
+func crc16(data []byte) uint32 {
+	crc := uint16(0xFFFF)
+	for _, b := range data {
+		crc ^= uint16(b) << 8
+		for range 8 {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return uint32(crc)
+}
+
+var castagnoliTable = crc32.MakeTable(crc32.Castagnoli)
+
+func crc32c(data []byte) uint32 {
+	return crc32.Checksum(data, castagnoliTable)
+}
+
+func fnv1a32(data []byte) uint32 {
+	h := fnv.New32a()
+	_, _ = h.Write(data)
+	return h.Sum32()
+}
+
+// Hexdump returns the hexdump of the data, in the format of `hexdump -C`.
+func (mi *ModuleInstance) Hexdump(data sobek.Value) string {
+	rt := mi.vu.Runtime()
+	b, err := toBytes(rt, data)
+	if err != nil {
+		common.Throw(rt, err)
+	}
+	return hex.Dump(b)
+}
+
+// Diff returns the rows of the hexdumps of the actual and the expected data,
+// which differ, with the differing bytes marked, or an empty string if the data
+// is the same.
+func (mi *ModuleInstance) Diff(actual, expected sobek.Value) string {
+	rt := mi.vu.Runtime()
+	actualBytes, err := toBytes(rt, actual)
+	if err != nil {
+		common.Throw(rt, err)
+	}
+	expectedBytes, err := toBytes(rt, expected)
+	if err != nil {
+		common.Throw(rt, err)
+	}
+	return diff(actualBytes, expectedBytes)
+}
+
+// Equal returns whether the actual and the expected data are the same, and
+// logs their diff, if they aren't, so the failures of the checks using it show
+// where the data differs.
+func (mi *ModuleInstance) Equal(actual, expected sobek.Value) bool {
+	d := mi.Diff(actual, expected)
+	if d == "" {
+		return true
+	}
+	if state := mi.vu.State(); state != nil {
+		state.Logger.Warn(d)
+	}
+	return false
+}
+
+const bytesPerRow = 16
+
+func diff(actual, expected []byte) string {
+	if bytes.Equal(actual, expected) {
+		return ""
+	}
+
+	var rows strings.Builder
+	differing := 0
+	for offset := 0; offset < max(len(actual), len(expected)); offset += bytesPerRow {
+		actualRow := row(actual, offset)
+		expectedRow := row(expected, offset)
+		marks, rowDiffering := markDifferences(actualRow, expectedRow)
+		if rowDiffering == 0 {
+			continue
+		}
+		differing += rowDiffering
+		rows.WriteString("-" + formatRow(offset, expectedRow) + "\n")
+		rows.WriteString("+" + formatRow(offset, actualRow) + "\n")
+		rows.WriteString(" " + marks + "\n")
+	}
+
+	header := fmt.Sprintf("the actual data (+) differs from the expected data (-) in %d bytes", differing)
+	if len(actual) != len(expected) {
+		header += fmt.Sprintf(", and it has %d bytes instead of %d", len(actual), len(expected))
+	}
+	return header + ":\n" + rows.String()
+}
+
+func row(data []byte, offset int) []byte {
+	if offset >= len(data) {
+		return nil
+	}
+	return data[offset:min(offset+bytesPerRow, len(data))]
+}
+
+// formatRow formats a row of data like the hexdumps, with the missing bytes
+// at the end of the data as blank space.
+func formatRow(offset int, data []byte) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%08x  ", offset)
+	for i := range bytesPerRow {
+		if i < len(data) {
+			fmt.Fprintf(&sb, "%02x ", data[i])
+		} else {
+			sb.WriteString("   ")
+		}
+		if i == bytesPerRow/2-1 {
+			sb.WriteByte(' ')
+		}
+	}
+	sb.WriteString(" |")
+	for _, b := range data {
+		if b < 32 || b > 126 {
+			b = '.'
+		}
+		sb.WriteByte(b)
+	}
+	sb.WriteByte('|')
+	return strings.TrimRight(sb.String(), " ")
+}
+
+// markDifferences returns a line, which marks the differing bytes of the rows
+// under their hexdumps, and the number of them.
+func markDifferences(actual, expected []byte) (string, int) {
+	var sb strings.Builder
+	sb.WriteString(strings.Repeat(" ", 10))
+	differing := 0
+	for i := range bytesPerRow {
+		if i < max(len(actual), len(expected)) &&
+			(i >= len(actual) || i >= len(expected) || actual[i] != expected[i]) {
+			sb.WriteString("^^ ")
+			differing++
+		} else {
+			sb.WriteString("   ")
+		}
+		if i == bytesPerRow/2-1 {
+			sb.WriteByte(' ')
+		}
+	}
+	return strings.TrimRight(sb.String(), " "), differing
+}
+
+// toBytes returns the bytes of a string, an ArrayBuffer, a typed array or a
+// DataView, without copying them.
+func toBytes(rt *sobek.Runtime, v sobek.Value) ([]byte, error) {
+	if common.IsNullish(v) {
+		return nil, fmt.Errorf("the data must be a string, an ArrayBuffer, a typed array or a DataView, but is %v", v)
+	}
+	if ab, ok := v.Export().(sobek.ArrayBuffer); ok {
+		return ab.Bytes(), nil
+	}
+	if s, ok := v.Export().(string); ok {
+		return []byte(s), nil
+	}
+
+	obj := v.ToObject(rt)
+	ab, ok := obj.Get("buffer").Export().(sobek.ArrayBuffer)
+	if !ok {
+		return nil, fmt.Errorf("the data must be a string, an ArrayBuffer, a typed array or a DataView, but is %v", v)
+	}
+	offset, length := obj.Get("byteOffset").ToInteger(), obj.Get("byteLength").ToInteger()
+	b := ab.Bytes()
+	if offset < 0 || length < 0 || offset+length > int64(len(b)) {
+		return nil, fmt.Errorf("the view of %d bytes at offset %d is out of its buffer", length, offset)
+	}
+	return b[offset : offset+length], nil
+}
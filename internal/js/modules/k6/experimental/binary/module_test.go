@@ -0,0 +1,170 @@
+package binary
+
+import (
+	"io"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/internal/lib/testutils"
+	"go.k6.io/k6/js/modulestest"
+	"go.k6.io/k6/lib"
+)
+
+func newTestRuntime(t *testing.T) (*modulestest.Runtime, *testutils.SimpleLogrusHook) {
+	t.Helper()
+
+	runtime := modulestest.NewRuntime(t)
+	require.NoError(t, runtime.SetupModule(
+		"k6/experimental/binary", New(), `const binary = require("k6/experimental/binary");`))
+
+	hook := testutils.NewLogHook(logrus.WarnLevel)
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	logger.AddHook(hook)
+	runtime.MoveToVUContext(&lib.State{Logger: logger})
+	return runtime, hook
+}
+
+func TestStruct(t *testing.T) {
+	t.Parallel()
+
+	t.Run("ParseAndPack", func(t *testing.T) {
+		t.Parallel()
+		runtime, _ := newTestRuntime(t)
+
+		_, err := runtime.RunOnEventLoop(`
+			const header = binary.struct({
+				magic: "string[4]",
+				version: "uint8",
+				flags: "int8",
+				length: "uint16",
+				port: "uint16le",
+				delta: "int32le",
+				id: "uint64",
+				ratio: "float32",
+				raw: "bytes[2]",
+			});
+			if (header.size !== 28) {
+				throw new Error("unexpected size: " + header.size);
+			}
+
+			const bytes = new Uint8Array([
+				0xff, 0xff, // padding before the struct
+				0x4b, 0x36, 0x00, 0x00, // "K6" and NULs
+				0x02, 0xfe, // 2, -2
+				0x01, 0x00, // 256
+				0x50, 0x00, // 80
+				0xfe, 0xff, 0xff, 0xff, // -2
+				0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, // 2^32
+				0x3f, 0xc0, 0x00, 0x00, // 1.5
+				0xca, 0xfe,
+			]);
+			const parsed = header.parse(new DataView(bytes.buffer, 2), 0);
+			const expected = {
+				magic: "K6", version: 2, flags: -2, length: 256, port: 80, delta: -2, id: 4294967296n, ratio: 1.5,
+			};
+			for (const [name, value] of Object.entries(expected)) {
+				if (parsed[name] !== value) {
+					throw new Error("unexpected " + name + ": " + parsed[name]);
+				}
+			}
+			if (binary.diff(parsed.raw, new Uint8Array([0xca, 0xfe])) !== "") {
+				throw new Error("unexpected raw: " + binary.hexdump(parsed.raw));
+			}
+
+			const packed = header.pack(parsed);
+			if (!binary.equal(packed, bytes.subarray(2))) {
+				throw new Error("the packed data differs");
+			}
+			if (header.parse(bytes, 2).id !== 4294967296n) {
+				throw new Error("unexpected id at offset 2");
+			}
+		`)
+		require.NoError(t, err)
+	})
+
+	t.Run("InvalidDefinition", func(t *testing.T) {
+		t.Parallel()
+		runtime, _ := newTestRuntime(t)
+
+		_, err := runtime.RunOnEventLoop(`binary.struct({a: "uint24"})`)
+		require.ErrorContains(t, err, `the type "uint24" of the field "a" isn't one of`)
+
+		_, err = runtime.RunOnEventLoop(`binary.struct({a: "float16"})`)
+		require.ErrorContains(t, err, `the type "float16" of the field "a" isn't float32 or float64`)
+
+		_, err = runtime.RunOnEventLoop(`binary.struct({a: "bytes[0]"})`)
+		require.ErrorContains(t, err, `the size of the field "a" must be more than 0`)
+
+		_, err = runtime.RunOnEventLoop(`binary.struct({})`)
+		require.ErrorContains(t, err, `the struct must have at least one field`)
+	})
+
+	t.Run("OutOfRange", func(t *testing.T) {
+		t.Parallel()
+		runtime, _ := newTestRuntime(t)
+
+		_, err := runtime.RunOnEventLoop(`binary.struct({a: "uint32"}).parse(new ArrayBuffer(5), 2)`)
+		require.ErrorContains(t, err, `the struct of 4 bytes doesn't fit in the 5 bytes of the data at offset 2`)
+
+		_, err = runtime.RunOnEventLoop(`binary.struct({a: "string[2]"}).pack({a: "abc"})`)
+		require.ErrorContains(t, err, `the value of the field "a" has 3 bytes, but the field only has 2`)
+	})
+}
+
+func TestChecksums(t *testing.T) {
+	t.Parallel()
+	runtime, _ := newTestRuntime(t)
+
+	// the check values of the checksums, for the ASCII "123456789"
+	_, err := runtime.RunOnEventLoop(`
+		const data = new Uint8Array([..."123456789"].map((c) => c.charCodeAt(0)));
+		const expected = {
+			crc16: 0x29b1, crc32: 0xcbf43926, crc32c: 0xe3069283, adler32: 0x091e01de, fnv1a32: 0xbb86b11c,
+		};
+		for (const [name, value] of Object.entries(expected)) {
+			if (binary[name](data) !== value || binary[name](data.buffer) !== value || binary[name]("123456789") !== value) {
+				throw new Error("unexpected " + name + ": " + binary[name](data).toString(16));
+			}
+		}
+	`)
+	require.NoError(t, err)
+}
+
+func TestEqual(t *testing.T) {
+	t.Parallel()
+	runtime, hook := newTestRuntime(t)
+
+	v, err := runtime.RunOnEventLoop(`binary.equal(new Uint8Array([1, 2, 3]), new Uint8Array([1, 2, 3]).buffer)`)
+	require.NoError(t, err)
+	assert.True(t, v.ToBoolean())
+	assert.Empty(t, hook.Drain())
+
+	v, err = runtime.RunOnEventLoop(`binary.equal(new Uint8Array([1, 2, 4]), new Uint8Array([1, 2, 3]))`)
+	require.NoError(t, err)
+	assert.False(t, v.ToBoolean())
+	entries := hook.Drain()
+	require.Len(t, entries, 1)
+	assert.Contains(t, entries[0].Message, "differs from the expected data (-) in 1 bytes")
+}
+
+func TestDiff(t *testing.T) {
+	t.Parallel()
+
+	assert.Empty(t, diff([]byte("same"), []byte("same")))
+
+	expected := []byte("0123456789abcdef0123456789abcdef0123")
+	actual := []byte("0123456789abcdef0123X56789abcdef01")
+	assert.Equal(t, "the actual data (+) differs from the expected data (-) in 3 bytes, "+
+		"and it has 34 bytes instead of 36:\n"+
+		"-00000010  30 31 32 33 34 35 36 37  38 39 61 62 63 64 65 66  |0123456789abcdef|\n"+
+		"+00000010  30 31 32 33 58 35 36 37  38 39 61 62 63 64 65 66  |0123X56789abcdef|\n"+
+		"                       ^^\n"+
+		"-00000020  30 31 32 33                                       |0123|\n"+
+		"+00000020  30 31                                             |01|\n"+
+		"                 ^^ ^^\n",
+		diff(actual, expected))
+}
@@ -0,0 +1,242 @@
+package binary
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/big"
+	"regexp"
+	"strconv"
+
+	"github.com/grafana/sobek"
+
+	"go.k6.io/k6/js/common"
+)
+
+// Struct is a definition of the layout of binary data, with the names and the
+// types of its fields, which parses the data into objects and packs the objects
+// into data.
+type Struct struct {
+	rt     *sobek.Runtime
+	fields []field
+
+	// The size of the data, in bytes.
+	Size int `js:"size"`
+}
+
+type field struct {
+	name   string
+	kind   fieldKind
+	size   int
+	order  binary.ByteOrder
+	offset int
+}
+
+type fieldKind int
+
+const (
+	kindInt fieldKind = iota
+	kindUint
+	kindFloat
+	kindBytes
+	kindString
+)
+
+var (
+	numberTypeRe = regexp.MustCompile(`^(u?int|float)(8|16|32|64)(le|be)?$`)
+	sizedTypeRe  = regexp.MustCompile(`^(bytes|string)\[(\d+)\]$`)
+)
+
+// parseFieldType parses the type of a field: an integer or a float of the given
+// bits, e.g. `uint16`, in big-endian byte order, unless it ends with `le`, or
+// `bytes[N]` or `string[N]` of the given number of bytes.
+func parseFieldType(name, typ string) (field, error) {
+	f := field{name: name, order: binary.BigEndian}
+	if m := sizedTypeRe.FindStringSubmatch(typ); m != nil {
+		size, err := strconv.Atoi(m[2])
+		if err != nil || size <= 0 {
+			return f, fmt.Errorf("the size of the field %q must be more than 0, but is %q", name, m[2])
+		}
+		f.size = size
+		f.kind = kindBytes
+		if m[1] == "string" {
+			f.kind = kindString
+		}
+		return f, nil
+	}
+
+	m := numberTypeRe.FindStringSubmatch(typ)
+	if m == nil {
+		return f, fmt.Errorf("the type %q of the field %q isn't one of int8-64, uint8-64, float32, "+
+			"float64 (with an optional le or be suffix), bytes[N] or string[N]", typ, name)
+	}
+	bits, _ := strconv.Atoi(m[2])
+	f.size = bits / 8
+	switch m[1] {
+	case "int":
+		f.kind = kindInt
+	case "uint":
+		f.kind = kindUint
+	default:
+		if bits != 32 && bits != 64 {
+			return f, fmt.Errorf("the type %q of the field %q isn't float32 or float64", typ, name)
+		}
+		f.kind = kindFloat
+	}
+	if m[3] == "le" {
+		f.order = binary.LittleEndian
+	}
+	return f, nil
+}
+
+func newStruct(rt *sobek.Runtime, definition sobek.Value) (*Struct, error) {
+	if common.IsNullish(definition) {
+		return nil, fmt.Errorf("the definition of the struct must be an object with the types of its fields")
+	}
+	obj := definition.ToObject(rt)
+	s := &Struct{rt: rt}
+	for _, name := range obj.Keys() {
+		f, err := parseFieldType(name, obj.Get(name).String())
+		if err != nil {
+			return nil, err
+		}
+		f.offset = s.Size
+		s.Size += f.size
+		s.fields = append(s.fields, f)
+	}
+	if len(s.fields) == 0 {
+		return nil, fmt.Errorf("the struct must have at least one field")
+	}
+	return s, nil
+}
+
+// Parse returns an object with the values of the fields of the struct, which
+// are read from the data at the given offset. The 64-bit integers are BigInts.
+func (s *Struct) Parse(data sobek.Value, offset int) *sobek.Object {
+	b, err := toBytes(s.rt, data)
+	if err != nil {
+		common.Throw(s.rt, err)
+	}
+	if offset < 0 || offset+s.Size > len(b) {
+		common.Throw(s.rt, fmt.Errorf("the struct of %d bytes doesn't fit in the %d bytes of the data at offset %d",
+			s.Size, len(b), offset))
+	}
+
+	result := s.rt.NewObject()
+	for _, f := range s.fields {
+		if err := result.Set(f.name, s.parseField(f, b[offset+f.offset:offset+f.offset+f.size])); err != nil {
+			common.Throw(s.rt, err)
+		}
+	}
+	return result
+}
+
+func (s *Struct) parseField(f field, b []byte) sobek.Value {
+	var u uint64
+	if f.kind == kindInt || f.kind == kindUint || f.kind == kindFloat {
+		switch f.size {
+		case 1:
+			u = uint64(b[0])
+		case 2:
+			u = uint64(f.order.Uint16(b))
+		case 4:
+			u = uint64(f.order.Uint32(b))
+		default:
+			u = f.order.Uint64(b)
+		}
+	}
+
+	switch f.kind {
+	case kindInt:
+		// sign-extend the value from its bits
+		shift := 64 - 8*f.size
+		i := int64(u<<shift) >> shift //nolint:gosec
+		if f.size == 8 {
+			return s.rt.ToValue(big.NewInt(i))
+		}
+		return s.rt.ToValue(i)
+	case kindUint:
+		if f.size == 8 {
+			return s.rt.ToValue(new(big.Int).SetUint64(u))
+		}
+		return s.rt.ToValue(u)
+	case kindFloat:
+		if f.size == 4 {
+			return s.rt.ToValue(float64(math.Float32frombits(uint32(u)))) //nolint:gosec
+		}
+		return s.rt.ToValue(math.Float64frombits(u))
+	case kindString:
+		return s.rt.ToValue(string(bytes.TrimRight(b, "\x00")))
+	default:
+		return s.rt.ToValue(s.rt.NewArrayBuffer(append([]byte(nil), b...)))
+	}
+}
+
+// Pack returns an ArrayBuffer with the values of the fields of the struct from
+// the given object. The missing fields are zeroes.
+func (s *Struct) Pack(values sobek.Value) sobek.ArrayBuffer {
+	if common.IsNullish(values) {
+		common.Throw(s.rt, fmt.Errorf("the values of the struct must be an object"))
+	}
+	obj := values.ToObject(s.rt)
+	b := make([]byte, s.Size)
+	for _, f := range s.fields {
+		v := obj.Get(f.name)
+		if common.IsNullish(v) {
+			continue
+		}
+		if err := s.packField(f, v, b[f.offset:f.offset+f.size]); err != nil {
+			common.Throw(s.rt, err)
+		}
+	}
+	return s.rt.NewArrayBuffer(b)
+}
+
+func (s *Struct) packField(f field, v sobek.Value, b []byte) error {
+	var u uint64
+	switch f.kind {
+	case kindInt, kindUint:
+		if bi, ok := v.Export().(*big.Int); ok {
+			u = bi.Uint64()
+			if bi.Sign() < 0 {
+				u = uint64(bi.Int64()) //nolint:gosec
+			}
+		} else {
+			u = uint64(v.ToInteger()) //nolint:gosec
+		}
+	case kindFloat:
+		if f.size == 4 {
+			u = uint64(math.Float32bits(float32(v.ToFloat())))
+		} else {
+			u = math.Float64bits(v.ToFloat())
+		}
+	default:
+		var data []byte
+		if f.kind == kindString {
+			data = []byte(v.String())
+		} else {
+			var err error
+			if data, err = toBytes(s.rt, v); err != nil {
+				return fmt.Errorf("the value of the field %q: %w", f.name, err)
+			}
+		}
+		if len(data) > f.size {
+			return fmt.Errorf("the value of the field %q has %d bytes, but the field only has %d", f.name, len(data), f.size)
+		}
+		copy(b, data)
+		return nil
+	}
+
+	switch f.size {
+	case 1:
+		b[0] = byte(u)
+	case 2:
+		f.order.PutUint16(b, uint16(u)) //nolint:gosec
+	case 4:
+		f.order.PutUint32(b, uint32(u)) //nolint:gosec
+	default:
+		f.order.PutUint64(b, u)
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package callbacks
+
+import "go.k6.io/k6/metrics"
+
+// instanceMetrics contains the metrics of the callbacks.
+type instanceMetrics struct {
+	CallbacksReceived *metrics.Metric
+	// CallbackDuration is the time from when the callbacks were expected until
+	// they arrived, which isn't known for the ones that arrived before.
+	CallbackDuration  *metrics.Metric
+	CallbacksTimedOut *metrics.Metric
+}
+
+// registerMetrics registers and returns the metrics in the provided registry
+func registerMetrics(registry *metrics.Registry) (*instanceMetrics, error) {
+	var err error
+	m := &instanceMetrics{}
+
+	if m.CallbacksReceived, err = registry.NewMetric("callbacks_received", metrics.Counter); err != nil {
+		return nil, err
+	}
+
+	if m.CallbackDuration, err = registry.NewMetric("callback_duration", metrics.Trend, metrics.Time); err != nil {
+		return nil, err
+	}
+
+	if m.CallbacksTimedOut, err = registry.NewMetric("callbacks_timed_out", metrics.Counter); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
@@ -0,0 +1,292 @@
+// Package callbacks provides an embedded HTTP and gRPC server, which scripts
+// can start in setup() to receive the webhooks and callbacks of the system
+// under test, so asynchronous workflows can be tested in a closed loop without
+// external mock servers.
+package callbacks
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grafana/sobek"
+
+	"go.k6.io/k6/internal/event"
+	"go.k6.io/k6/js/common"
+	"go.k6.io/k6/js/modules"
+	"go.k6.io/k6/lib/types"
+	"go.k6.io/k6/metrics"
+)
+
+const (
+	defaultServerName = "default"
+	defaultAddress    = "127.0.0.1:0"
+	defaultTimeout    = 30 * time.Second
+)
+
+type (
+	// RootModule is the global module instance that will create instances of our
+	// module for each VU.
+	RootModule struct {
+		// servers are the listening servers by their names, which are shared by
+		// the VUs, so the ones started in setup() receive all the callbacks
+		mx      sync.Mutex
+		servers map[string]*server
+	}
+
+	// ModuleInstance represents an instance of the callbacks module for a single VU.
+	ModuleInstance struct {
+		vu      modules.VU
+		root    *RootModule
+		metrics *instanceMetrics
+	}
+)
+
+var (
+	_ modules.Module   = &RootModule{}
+	_ modules.Instance = &ModuleInstance{}
+)
+
+// New returns a pointer to a new [RootModule] instance.
+func New() *RootModule {
+	return &RootModule{servers: make(map[string]*server)}
+}
+
+// NewModuleInstance implements the modules.Module interface and returns a new
+// instance of our module for the given VU.
+func (r *RootModule) NewModuleInstance(vu modules.VU) modules.Instance {
+	m, err := registerMetrics(vu.InitEnv().Registry)
+	if err != nil {
+		common.Throw(vu.Runtime(), fmt.Errorf("failed to register the callbacks module metrics: %w", err))
+	}
+	return &ModuleInstance{vu: vu, root: r, metrics: m}
+}
+
+// Exports implements the modules.Module interface and returns the exports of
+// our module.
+func (mi *ModuleInstance) Exports() modules.Exports {
+	return modules.Exports{
+		Named: map[string]any{
+			"listen": mi.Listen,
+			"expect": mi.Expect,
+		},
+	}
+}
+
+type listenOptions struct {
+	name, address, protocol, idHeader string
+}
+
+func parseListenOptions(rt *sobek.Runtime, options sobek.Value) (listenOptions, error) {
+	opts := listenOptions{name: defaultServerName, address: defaultAddress, protocol: protocolHTTP}
+	if common.IsNullish(options) {
+		return opts, nil
+	}
+	obj := options.ToObject(rt)
+	for _, k := range obj.Keys() {
+		v := obj.Get(k).String()
+		switch k {
+		case "name":
+			opts.name = v
+		case "address":
+			opts.address = v
+		case "protocol":
+			if v != protocolHTTP && v != protocolGRPC {
+				return opts, fmt.Errorf("unsupported callback server protocol %q, it must be http or grpc", v)
+			}
+			opts.protocol = v
+		case "idHeader":
+			opts.idHeader = v
+		default:
+			return opts, fmt.Errorf("unknown callback server option %q", k)
+		}
+	}
+	return opts, nil
+}
+
+// Listen starts the callback server with the name, or returns the one that's
+// already listening with it. It's closed when the test ends.
+func (mi *ModuleInstance) Listen(options sobek.Value) *sobek.Object {
+	rt := mi.vu.Runtime()
+	if mi.vu.State() == nil {
+		common.Throw(rt, errors.New("listening for callbacks in the init context is not supported, "+
+			"call listen() in setup()"))
+	}
+	opts, err := parseListenOptions(rt, options)
+	if err != nil {
+		common.Throw(rt, err)
+	}
+	s, err := mi.root.listen(mi.vu, opts)
+	if err != nil {
+		common.Throw(rt, err)
+	}
+
+	obj := rt.NewObject()
+	must(rt, obj.Set("name", s.name))
+	must(rt, obj.Set("protocol", s.protocol))
+	must(rt, obj.Set("address", s.address))
+	must(rt, obj.Set("url", s.url()))
+	return obj
+}
+
+func (r *RootModule) listen(vu modules.VU, opts listenOptions) (*server, error) {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+
+	if s, ok := r.servers[opts.name]; ok {
+		if s.protocol != opts.protocol {
+			return nil, fmt.Errorf("the callback server %q is already listening for %s", s.name, s.protocol)
+		}
+		return s, nil
+	}
+	s, err := startServer(opts)
+	if err != nil {
+		return nil, fmt.Errorf("the callback server %q can't listen: %w", opts.name, err)
+	}
+	r.servers[opts.name] = s
+
+	events := vu.Events().Global
+	subID, ch := events.Subscribe(event.Exit)
+	go func() {
+		for evt := range ch {
+			r.mx.Lock()
+			delete(r.servers, s.name)
+			r.mx.Unlock()
+			s.close()
+			evt.Done()
+			events.Unsubscribe(subID)
+		}
+	}()
+	return s, nil
+}
+
+func (r *RootModule) server(name string) *server {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+
+	return r.servers[name]
+}
+
+type expectOptions struct {
+	server  string
+	timeout time.Duration
+}
+
+func parseExpectOptions(rt *sobek.Runtime, options sobek.Value) (expectOptions, error) {
+	opts := expectOptions{server: defaultServerName, timeout: defaultTimeout}
+	if common.IsNullish(options) {
+		return opts, nil
+	}
+	obj := options.ToObject(rt)
+	for _, k := range obj.Keys() {
+		v := obj.Get(k)
+		switch k {
+		case "server":
+			opts.server = v.String()
+		case "timeout":
+			d, err := types.GetDurationValue(v.Export())
+			if err != nil {
+				return opts, fmt.Errorf("invalid timeout value: %w", err)
+			}
+			if d <= 0 {
+				return opts, errors.New("the timeout must be positive")
+			}
+			opts.timeout = d
+		default:
+			return opts, fmt.Errorf("unknown callback option %q", k)
+		}
+	}
+	return opts, nil
+}
+
+// Expect returns a promise of the callback with the ID, which is resolved when
+// it arrives, or right away, if it already did. It's called before the system
+// under test is asked to send the callback, so the time until it arrives is
+// measured.
+func (mi *ModuleInstance) Expect(id string, options sobek.Value) *sobek.Promise {
+	rt := mi.vu.Runtime()
+	state := mi.vu.State()
+	if state == nil {
+		common.Throw(rt, errors.New("expecting callbacks in the init context is not supported"))
+	}
+	opts, err := parseExpectOptions(rt, options)
+	if err != nil {
+		common.Throw(rt, err)
+	}
+	s := mi.root.server(opts.server)
+	if s == nil {
+		common.Throw(rt, fmt.Errorf("the callback server %q isn't listening, call listen() in setup() first",
+			opts.server))
+	}
+	ch, err := s.expect(id)
+	if err != nil {
+		common.Throw(rt, err)
+	}
+
+	expectedAt := time.Now()
+	tagsAndMeta := state.Tags.GetCurrentValues()
+	tagsAndMeta.SetTag("callback_server", s.name)
+	ctx := mi.vu.Context()
+	push := func(metric *metrics.Metric, t time.Time, value float64) {
+		metrics.PushIfNotDone(ctx, state.Samples, metrics.Sample{
+			TimeSeries: metrics.TimeSeries{Metric: metric, Tags: tagsAndMeta.Tags},
+			Time:       t,
+			Metadata:   tagsAndMeta.Metadata,
+			Value:      value,
+		})
+	}
+
+	promise, resolve, reject := rt.NewPromise()
+	callback := mi.vu.RegisterCallback()
+	go func() {
+		cb, err := s.waitCallback(ctx, id, ch, opts.timeout)
+		if err != nil {
+			if ctx.Err() == nil {
+				push(mi.metrics.CallbacksTimedOut, time.Now(), 1)
+			}
+			callback(func() error { return reject(err) })
+			return
+		}
+		push(mi.metrics.CallbacksReceived, cb.receivedAt, 1)
+		if cb.receivedAt.After(expectedAt) {
+			push(mi.metrics.CallbackDuration, cb.receivedAt, metrics.D(cb.receivedAt.Sub(expectedAt)))
+		}
+		callback(func() error { return resolve(callbackObject(rt, cb)) })
+	}()
+	return promise
+}
+
+// callbackObject returns the JS object of the callback, with its body as an
+// ArrayBuffer, and the text() and json() methods reading it.
+func callbackObject(rt *sobek.Runtime, cb *callback) *sobek.Object {
+	headers := rt.NewObject()
+	for k, v := range cb.headers {
+		must(rt, headers.Set(strings.ToLower(k), strings.Join(v, ", ")))
+	}
+
+	obj := rt.NewObject()
+	must(rt, obj.Set("id", cb.id))
+	must(rt, obj.Set("method", cb.method))
+	must(rt, obj.Set("path", cb.path))
+	must(rt, obj.Set("headers", headers))
+	must(rt, obj.Set("body", rt.NewArrayBuffer(cb.body)))
+	must(rt, obj.Set("receivedAt", cb.receivedAt.UnixMilli()))
+	must(rt, obj.Set("text", func() string { return string(cb.body) }))
+	must(rt, obj.Set("json", func() sobek.Value {
+		var v any
+		if err := json.Unmarshal(cb.body, &v); err != nil {
+			common.Throw(rt, fmt.Errorf("the body of the callback %q isn't JSON: %w", cb.id, err))
+		}
+		return rt.ToValue(v)
+	}))
+	return obj
+}
+
+func must(rt *sobek.Runtime, err error) {
+	if err != nil {
+		common.Throw(rt, err)
+	}
+}
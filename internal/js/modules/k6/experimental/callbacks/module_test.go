@@ -0,0 +1,164 @@
+package callbacks
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+
+	"go.k6.io/k6/internal/event"
+	"go.k6.io/k6/internal/js/compiler"
+	"go.k6.io/k6/js/common"
+	"go.k6.io/k6/js/modulestest"
+	"go.k6.io/k6/metrics"
+)
+
+func newTestRuntime(t *testing.T) (*modulestest.Runtime, *event.System, chan metrics.SampleContainer) {
+	t.Helper()
+
+	runtime := modulestest.NewRuntime(t)
+	events := event.NewEventSystem(10, logrus.New())
+	runtime.VU.EventsField = common.Events{Global: events, Local: event.NewEventSystem(10, logrus.New())}
+	require.NoError(t, runtime.SetupModule("k6/experimental/callbacks", New(),
+		`const { listen, expect } = require("k6/experimental/callbacks");`))
+
+	t.Cleanup(func() {
+		require.NoError(t, events.Emit(&event.Event{Type: event.Exit})(context.Background()))
+	})
+
+	state, samples := modulestest.NewVUState(t, 100)
+	runtime.MoveToVUContext(state)
+	return runtime, events, samples
+}
+
+func countSamples(samples chan metrics.SampleContainer) map[string]float64 {
+	counts := make(map[string]float64)
+	for _, sc := range metrics.GetBufferedSamples(samples) {
+		for _, s := range sc.GetSamples() {
+			counts[s.Metric.Name]++
+		}
+	}
+	return counts
+}
+
+func TestCallbacks(t *testing.T) {
+	t.Parallel()
+
+	t.Run("http", func(t *testing.T) {
+		t.Parallel()
+		runtime, _, samples := newTestRuntime(t)
+		require.NoError(t, runtime.VU.Runtime().Set("send", func(url, body string) int {
+			resp, err := http.Post(url, "application/json", strings.NewReader(body)) //nolint:noctx
+			require.NoError(t, err)
+			_ = resp.Body.Close()
+			return resp.StatusCode
+		}))
+
+		_, err := runtime.RunOnEventLoop(`
+			const server = listen();
+			if (listen().url !== server.url) throw new Error("the server isn't shared");
+			var received = [];
+			if (send(server.url + "/early", "{}") !== 200) throw new Error("the callback wasn't accepted");
+			expect("early").then((cb) => received.push(cb.id + " " + cb.method + " " + cb.text()));
+			expect("orders/1", { timeout: "5s" }).then((cb) => {
+				received.push(cb.id + " " + cb.path + " " + cb.json().status + " " + cb.headers["content-type"]);
+			});
+			send(server.url + "/orders/1?attempt=1", '{"status":"shipped"}');
+		`)
+		require.NoError(t, err)
+		v, err := runtime.VU.Runtime().RunString(`received.join()`)
+		require.NoError(t, err)
+		assert.Equal(t, "early POST {},orders/1 /orders/1?attempt=1 shipped application/json", v.String())
+		assert.Equal(t, map[string]float64{"callbacks_received": 2, "callback_duration": 1}, countSamples(samples))
+	})
+
+	t.Run("grpc", func(t *testing.T) {
+		t.Parallel()
+		runtime, _, _ := newTestRuntime(t)
+		require.NoError(t, runtime.VU.Runtime().Set("send", func(address, id, body string) {
+			conn, err := grpc.NewClient(address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+			require.NoError(t, err)
+			defer func() { _ = conn.Close() }()
+			ctx := metadata.AppendToOutgoingContext(context.Background(), "callback-id", id)
+			req, reply := []byte(body), []byte{}
+			require.NoError(t, conn.Invoke(ctx, "/payments.Webhooks/Notify", &req, &reply, grpc.ForceCodec(rawCodec{})))
+		}))
+
+		_, err := runtime.RunOnEventLoop(`
+			const server = listen({ name: "payments", protocol: "grpc" });
+			var received;
+			expect("payment-7", { server: "payments" }).then((cb) => {
+				received = cb.method + " " + new Uint8Array(cb.body).length + " " + cb.headers["callback-id"];
+			});
+			send(server.address, "payment-7", "\x08\x01");
+		`)
+		require.NoError(t, err)
+		v, err := runtime.VU.Runtime().RunString(`received`)
+		require.NoError(t, err)
+		assert.Equal(t, "/payments.Webhooks/Notify 2 payment-7", v.String())
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		t.Parallel()
+		runtime, _, samples := newTestRuntime(t)
+
+		_, err := runtime.RunOnEventLoop(`
+			listen();
+			var error;
+			expect("never", { timeout: "50ms" }).catch((e) => { error = e.toString() });
+		`)
+		require.NoError(t, err)
+		v, err := runtime.VU.Runtime().RunString(`error`)
+		require.NoError(t, err)
+		assert.Contains(t, v.String(), `the callback "never" didn't arrive in 50ms`)
+		assert.Equal(t, map[string]float64{"callbacks_timed_out": 1}, countSamples(samples))
+	})
+
+	t.Run("closed on exit", func(t *testing.T) {
+		t.Parallel()
+		runtime, events, _ := newTestRuntime(t)
+		v, err := runtime.VU.Runtime().RunString(`listen({ name: "exit" }).url`)
+		require.NoError(t, err)
+
+		require.NoError(t, events.Emit(&event.Event{Type: event.Exit})(context.Background()))
+		_, err = http.Get(v.String() + "/id") //nolint:noctx,bodyclose
+		require.Error(t, err)
+		_, err = runtime.VU.Runtime().RunString(`expect("id", { server: "exit" })`)
+		require.ErrorContains(t, err, `the callback server "exit" isn't listening`)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		t.Parallel()
+		runtime, _, _ := newTestRuntime(t)
+
+		for script, msg := range map[string]string{
+			`listen({ port: 80 })`:                      `unknown callback server option "port"`,
+			`listen({ protocol: "ws" })`:                `unsupported callback server protocol "ws"`,
+			`listen(); listen({ protocol: "grpc" })`:    `the callback server "default" is already listening for http`,
+			`expect("a", { server: "missing" })`:        `the callback server "missing" isn't listening`,
+			`listen(); expect("b"); expect("b")`:        `the callback "b" is already expected`,
+			`listen(); expect("c", { timeout: "-1s" })`: "the timeout must be positive",
+		} {
+			_, err := runtime.VU.Runtime().RunString(script)
+			assert.ErrorContains(t, err, msg, script)
+		}
+	})
+}
+
+func TestListenInInitContext(t *testing.T) {
+	t.Parallel()
+
+	runtime := modulestest.NewRuntime(t)
+	err := runtime.SetupModuleSystem(
+		map[string]any{"k6/experimental/callbacks": New()}, nil, compiler.New(runtime.VU.InitEnv().Logger))
+	require.NoError(t, err)
+	_, err = runtime.VU.Runtime().RunString(`require("k6/experimental/callbacks").listen()`)
+	require.ErrorContains(t, err, "listening for callbacks in the init context is not supported")
+}
@@ -0,0 +1,255 @@
+package callbacks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	protocolHTTP = "http"
+	protocolGRPC = "grpc"
+
+	// defaultGRPCIDKey is the metadata key of the callback IDs of the gRPC
+	// calls, since their path is the method.
+	defaultGRPCIDKey = "callback-id"
+
+	// maxUnexpected is how many of the callbacks, which arrived before they
+	// were expected, are kept, so the ones of the abandoned expectations don't
+	// use more and more memory.
+	maxUnexpected = 10_000
+
+	// maxBodySize is the largest body of a callback.
+	maxBodySize = 10 << 20
+)
+
+// callback is a request, which the server received from the system under test.
+type callback struct {
+	id, method, path string
+	headers          map[string][]string
+	body             []byte
+	receivedAt       time.Time
+}
+
+// server receives the callbacks, and delivers them to the VUs expecting them,
+// or keeps them until they're expected.
+type server struct {
+	name, protocol, address string
+	// idHeader is the header, or the gRPC metadata key, with the IDs of the
+	// callbacks. Without it, the path of the HTTP requests is their ID.
+	idHeader string
+
+	mx         sync.Mutex
+	waiters    map[string]chan *callback
+	unexpected map[string]*callback
+	order      []string
+
+	close func()
+}
+
+func startServer(opts listenOptions) (*server, error) {
+	listener, err := net.Listen("tcp", opts.address)
+	if err != nil {
+		return nil, err
+	}
+	s := &server{
+		name:       opts.name,
+		protocol:   opts.protocol,
+		address:    listener.Addr().String(),
+		idHeader:   opts.idHeader,
+		waiters:    make(map[string]chan *callback),
+		unexpected: make(map[string]*callback),
+	}
+
+	switch opts.protocol {
+	case protocolGRPC:
+		if s.idHeader == "" {
+			s.idHeader = defaultGRPCIDKey
+		}
+		grpcServer := grpc.NewServer(
+			grpc.ForceServerCodec(rawCodec{}),
+			grpc.UnknownServiceHandler(s.handleGRPC),
+		)
+		go func() { _ = grpcServer.Serve(listener) }()
+		s.close = grpcServer.Stop
+	default:
+		httpServer := &http.Server{Handler: http.HandlerFunc(s.handleHTTP), ReadHeaderTimeout: 10 * time.Second}
+		go func() { _ = httpServer.Serve(listener) }()
+		s.close = func() { _ = httpServer.Close() }
+	}
+	return s, nil
+}
+
+// url returns the URL, which the system under test should send the callbacks
+// to. For gRPC, it's the address of the server.
+func (s *server) url() string {
+	if s.protocol == protocolGRPC {
+		return s.address
+	}
+	return "http://" + s.address
+}
+
+func (s *server) handleHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxBodySize+1))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(body) > maxBodySize {
+		http.Error(w, "the callback body is too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	id := strings.TrimPrefix(r.URL.Path, "/")
+	if s.idHeader != "" {
+		id = r.Header.Get(s.idHeader)
+	}
+	if id == "" {
+		http.Error(w, "the callback has no ID", http.StatusBadRequest)
+		return
+	}
+	s.deliver(&callback{
+		id:         id,
+		method:     r.Method,
+		path:       r.URL.RequestURI(),
+		headers:    r.Header,
+		body:       body,
+		receivedAt: time.Now(),
+	})
+	w.WriteHeader(http.StatusOK)
+}
+
+// handleGRPC receives the unary calls of any method, and replies to them with
+// an empty message.
+func (s *server) handleGRPC(_ any, stream grpc.ServerStream) error {
+	var body []byte
+	if err := stream.RecvMsg(&body); err != nil {
+		return err
+	}
+	receivedAt := time.Now()
+	method, _ := grpc.MethodFromServerStream(stream)
+	md, _ := metadata.FromIncomingContext(stream.Context())
+
+	ids := md.Get(s.idHeader)
+	if len(ids) == 0 || ids[0] == "" {
+		return status.Errorf(codes.InvalidArgument, "the callback has no %s metadata", s.idHeader)
+	}
+	s.deliver(&callback{
+		id:         ids[0],
+		method:     method,
+		path:       method,
+		headers:    md,
+		body:       body,
+		receivedAt: receivedAt,
+	})
+	return stream.SendMsg(&[]byte{})
+}
+
+func (s *server) deliver(cb *callback) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	if ch, ok := s.waiters[cb.id]; ok {
+		delete(s.waiters, cb.id)
+		ch <- cb
+		return
+	}
+	if _, ok := s.unexpected[cb.id]; !ok {
+		s.order = append(s.order, cb.id)
+	}
+	s.unexpected[cb.id] = cb
+	for len(s.order) > maxUnexpected {
+		delete(s.unexpected, s.order[0])
+		s.order = s.order[1:]
+	}
+}
+
+// expect returns the channel, which the callback with the ID is sent to. If it
+// already arrived, it's sent right away.
+func (s *server) expect(id string) (chan *callback, error) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	if _, ok := s.waiters[id]; ok {
+		return nil, fmt.Errorf("the callback %q is already expected", id)
+	}
+	ch := make(chan *callback, 1)
+	if cb, ok := s.unexpected[id]; ok {
+		delete(s.unexpected, id)
+		ch <- cb
+		return ch, nil
+	}
+	s.waiters[id] = ch
+	return ch, nil
+}
+
+// cancel stops expecting the callback, e.g. after it timed out.
+func (s *server) cancel(id string, ch chan *callback) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	if s.waiters[id] == ch {
+		delete(s.waiters, id)
+	}
+}
+
+// waitCallback waits for the callback, until the timeout or the context is
+// done, when it stops expecting it.
+func (s *server) waitCallback(
+	ctx context.Context, id string, ch chan *callback, timeout time.Duration,
+) (cb *callback, err error) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case cb = <-ch:
+		return cb, nil
+	case <-timer.C:
+		err = fmt.Errorf("the callback %q didn't arrive in %s", id, timeout)
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	s.cancel(id, ch)
+	select {
+	case cb = <-ch: // it arrived before it was canceled
+		return cb, nil
+	default:
+		return nil, err
+	}
+}
+
+// rawCodec passes the bytes of the gRPC messages as they are, so the calls of
+// any method are received without their descriptors.
+type rawCodec struct{}
+
+func (rawCodec) Marshal(v any) ([]byte, error) {
+	b, ok := v.(*[]byte)
+	if !ok {
+		return nil, errors.New("the gRPC callback message must be bytes")
+	}
+	return *b, nil
+}
+
+func (rawCodec) Unmarshal(data []byte, v any) error {
+	b, ok := v.(*[]byte)
+	if !ok {
+		return errors.New("the gRPC callback message must be bytes")
+	}
+	*b = append((*b)[:0], data...)
+	return nil
+}
+
+func (rawCodec) Name() string {
+	return "proto"
+}
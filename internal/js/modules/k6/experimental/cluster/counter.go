@@ -0,0 +1,51 @@
+package cluster
+
+import (
+	"math"
+
+	"github.com/grafana/sobek"
+
+	"go.k6.io/k6/js/common"
+	"go.k6.io/k6/js/modules"
+)
+
+// Counter is an integer counter, which all the VUs of all the instances of a
+// test share.
+type Counter struct {
+	vu   modules.VU
+	name string
+}
+
+// Add adds the delta, 1 by default, to the counter and returns its value.
+func (c *Counter) Add(delta sobek.Value) (int64, error) {
+	value, _, err := c.add(delta, math.MaxInt64)
+	return value, err
+}
+
+// TryAdd adds the delta to the counter, unless that would make it more than
+// the limit, and returns whether it was added.
+func (c *Counter) TryAdd(delta sobek.Value, limit int64) (bool, error) {
+	_, added, err := c.add(delta, limit)
+	return added, err
+}
+
+// Value returns the value of the counter.
+func (c *Counter) Value() (int64, error) {
+	s, err := store(c.vu)
+	if err != nil {
+		return 0, err
+	}
+	return s.Counter(c.vu.Context(), c.name)
+}
+
+func (c *Counter) add(delta sobek.Value, limit int64) (int64, bool, error) {
+	s, err := store(c.vu)
+	if err != nil {
+		return 0, false, err
+	}
+	d := int64(1)
+	if !common.IsNullish(delta) {
+		d = delta.ToInteger()
+	}
+	return s.AddToCounter(c.vu.Context(), c.name, d, limit)
+}
@@ -0,0 +1,107 @@
+// Package cluster provides the counters and the rate limiters, which all the
+// VUs of all the instances of a test share, so the scripts can keep test-wide
+// invariants, like creating at most 10k orders in total.
+package cluster
+
+import (
+	"errors"
+	"math"
+
+	"github.com/grafana/sobek"
+
+	"go.k6.io/k6/js/common"
+	"go.k6.io/k6/js/modules"
+	"go.k6.io/k6/lib"
+)
+
+type (
+	// RootModule is the global module instance that will create instances of our
+	// module for each VU.
+	RootModule struct{}
+
+	// ModuleInstance represents an instance of the cluster module for a single VU.
+	ModuleInstance struct {
+		vu modules.VU
+	}
+)
+
+var (
+	_ modules.Module   = &RootModule{}
+	_ modules.Instance = &ModuleInstance{}
+)
+
+// New returns a pointer to a new [RootModule] instance.
+func New() *RootModule {
+	return &RootModule{}
+}
+
+// NewModuleInstance implements the modules.Module interface and returns a new
+// instance of our module for the given VU.
+func (*RootModule) NewModuleInstance(vu modules.VU) modules.Instance {
+	return &ModuleInstance{vu: vu}
+}
+
+// Exports implements the modules.Module interface and returns the exports of
+// our module.
+func (mi *ModuleInstance) Exports() modules.Exports {
+	return modules.Exports{
+		Named: map[string]any{
+			"Counter":     mi.NewCounter,
+			"RateLimiter": mi.NewRateLimiter,
+		},
+	}
+}
+
+// NewCounter is the JS constructor of the Counter, whose only argument is its
+// name. The counters with the same name are the same counter.
+func (mi *ModuleInstance) NewCounter(call sobek.ConstructorCall) *sobek.Object {
+	rt := mi.vu.Runtime()
+	name := call.Argument(0)
+	if common.IsNullish(name) || name.String() == "" {
+		common.Throw(rt, errors.New("the name of the counter is required"))
+	}
+	return rt.ToValue(&Counter{vu: mi.vu, name: name.String()}).ToObject(rt)
+}
+
+// NewRateLimiter is the JS constructor of the RateLimiter, whose arguments are
+// its name and its rate and burst options. The rate limiters with the same
+// name share their tokens.
+func (mi *ModuleInstance) NewRateLimiter(call sobek.ConstructorCall) *sobek.Object {
+	rt := mi.vu.Runtime()
+	name := call.Argument(0)
+	if common.IsNullish(name) || name.String() == "" {
+		common.Throw(rt, errors.New("the name of the rate limiter is required"))
+	}
+	limiter := &RateLimiter{vu: mi.vu, name: name.String()}
+	if opts := call.Argument(1); !common.IsNullish(opts) {
+		obj := opts.ToObject(rt)
+		if rate := obj.Get("rate"); !common.IsNullish(rate) {
+			limiter.bucket.Rate = rate.ToFloat()
+		}
+		if burst := obj.Get("burst"); !common.IsNullish(burst) {
+			limiter.bucket.Burst = burst.ToFloat()
+		}
+	}
+	if !(limiter.bucket.Rate > 0) || math.IsInf(limiter.bucket.Rate, 0) {
+		common.Throw(rt, errors.New("the rate of the rate limiter must be a positive number of tokens per second"))
+	}
+	if limiter.bucket.Burst == 0 {
+		limiter.bucket.Burst = math.Max(1, limiter.bucket.Rate)
+	}
+	if !(limiter.bucket.Burst > 0) || math.IsInf(limiter.bucket.Burst, 0) {
+		common.Throw(rt, errors.New("the burst of the rate limiter must be a positive number of tokens"))
+	}
+	return rt.ToValue(limiter).ToObject(rt)
+}
+
+// store returns the store of the VU, or an error in the init context.
+func store(vu modules.VU) (lib.ClusterStore, error) {
+	state := vu.State()
+	if state == nil {
+		return nil, errors.New("the counters and the rate limiters can't be used in the init context")
+	}
+	if state.ClusterStore == nil {
+		return nil, errors.New("the cluster store isn't available")
+	}
+	return state.ClusterStore, nil
+}
@@ -0,0 +1,93 @@
+package cluster
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/internal/lib/testutils"
+	"go.k6.io/k6/js/modulestest"
+	"go.k6.io/k6/lib"
+)
+
+func newTestRuntime(t *testing.T, store lib.ClusterStore, initScript string) (*modulestest.Runtime, error) {
+	t.Helper()
+
+	runtime := modulestest.NewRuntime(t)
+	err := runtime.SetupModule("k6/experimental/cluster", New(),
+		`const { Counter, RateLimiter } = require("k6/experimental/cluster");`+initScript)
+	runtime.MoveToVUContext(&lib.State{Logger: testutils.NewLogger(t), ClusterStore: store})
+	return runtime, err
+}
+
+func TestCounter(t *testing.T) {
+	t.Parallel()
+
+	// the VUs share the counters through the store
+	store := lib.NewMemoryClusterStore()
+	init := `const orders = new Counter("orders");`
+	vu1, err := newTestRuntime(t, store, init)
+	require.NoError(t, err)
+	vu2, err := newTestRuntime(t, store, init)
+	require.NoError(t, err)
+
+	_, err = vu1.RunOnEventLoop(`
+		if (orders.add() !== 1 || orders.add(4) !== 5) {
+			throw new Error("unexpected value " + orders.value());
+		}
+	`)
+	require.NoError(t, err)
+
+	v, err := vu2.RunOnEventLoop(`
+		const results = [orders.tryAdd(3, 8), orders.tryAdd(1, 8), orders.tryAdd(1, 9)];
+		results.join(",") + " " + orders.value();
+	`)
+	require.NoError(t, err)
+	assert.Equal(t, "true,false,true 9", v.String())
+}
+
+func TestRateLimiter(t *testing.T) {
+	t.Parallel()
+
+	runtime, err := newTestRuntime(t, lib.NewMemoryClusterStore(), `
+		const limiter = new RateLimiter("api", { rate: 100, burst: 2 });
+	`)
+	require.NoError(t, err)
+
+	v, err := runtime.RunOnEventLoop(`
+		const results = [limiter.tryTake(), limiter.tryTake(), limiter.tryTake()];
+		let waited = false;
+		limiter.take().then(() => { waited = true; });
+		results.join(",");
+	`)
+	require.NoError(t, err)
+	assert.Equal(t, "true,true,false", v.String())
+
+	waited, err := runtime.RunOnEventLoop(`waited`)
+	require.NoError(t, err)
+	assert.True(t, waited.ToBoolean())
+
+	_, err = runtime.RunOnEventLoop(`limiter.tryTake(3)`)
+	assert.ErrorContains(t, err, "the tokens must be more than 0 and at most the burst 2, but they were 3")
+}
+
+func TestInvalid(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]string{
+		`new Counter()`:                                  "the name of the counter is required",
+		`new RateLimiter("api")`:                         "the rate of the rate limiter must be a positive number",
+		`new RateLimiter("api", { rate: -1 })`:           "the rate of the rate limiter must be a positive number",
+		`new RateLimiter("api", { rate: 1, burst: -1 })`: "the burst of the rate limiter must be a positive number",
+		`new Counter("orders").add()`:                    "the counters and the rate limiters can't be used in the init context",
+	}
+	for script, expected := range tests {
+		t.Run(script, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := newTestRuntime(t, lib.NewMemoryClusterStore(), script)
+			assert.ErrorContains(t, err, expected)
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package cluster
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/grafana/sobek"
+
+	"go.k6.io/k6/js/common"
+	"go.k6.io/k6/js/modules"
+	"go.k6.io/k6/js/promises"
+	"go.k6.io/k6/lib"
+)
+
+// RateLimiter is a token bucket, which all the VUs of all the instances of a
+// test share, so they make at most rate requests per second in total.
+type RateLimiter struct {
+	vu     modules.VU
+	name   string
+	bucket lib.TokenBucket
+}
+
+// TryTake takes the tokens, 1 by default, if they are available, and returns
+// whether it took them, without waiting for them.
+func (r *RateLimiter) TryTake(tokens sobek.Value) (bool, error) {
+	s, err := store(r.vu)
+	if err != nil {
+		return false, err
+	}
+	n, err := r.tokens(tokens)
+	if err != nil {
+		return false, err
+	}
+	taken, _, err := s.TakeTokens(r.vu.Context(), r.name, r.bucket, n)
+	return taken, err
+}
+
+// Take returns a promise, which is resolved when the tokens, 1 by default,
+// are taken, after waiting for them if they aren't available.
+func (r *RateLimiter) Take(tokens sobek.Value) *sobek.Promise {
+	promise, resolve, reject := promises.New(r.vu)
+	s, err := store(r.vu)
+	if err == nil {
+		var n float64
+		if n, err = r.tokens(tokens); err == nil {
+			go r.wait(s, n, resolve, reject)
+			return promise
+		}
+	}
+	reject(err)
+	return promise
+}
+
+func (r *RateLimiter) wait(s lib.ClusterStore, tokens float64, resolve func(any), reject func(any)) {
+	ctx := r.vu.Context()
+	for {
+		taken, wait, err := s.TakeTokens(ctx, r.name, r.bucket, tokens)
+		if err != nil {
+			reject(err)
+			return
+		}
+		if taken {
+			resolve(nil)
+			return
+		}
+		// the other VUs can take the tokens in the meantime, so they're
+		// taken again after the wait
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			reject(ctx.Err())
+			return
+		}
+	}
+}
+
+func (r *RateLimiter) tokens(tokens sobek.Value) (float64, error) {
+	if common.IsNullish(tokens) {
+		return 1, nil
+	}
+	n := tokens.ToFloat()
+	if !(n > 0) || n > r.bucket.Burst {
+		return 0, fmt.Errorf("the tokens must be more than 0 and at most the burst %v, but they were %v", r.bucket.Burst, n)
+	}
+	return n, nil
+}
@@ -0,0 +1,237 @@
+// Package dns provides explicit DNS lookups for k6 scripts, so DNS servers
+// can be tested directly, instead of only as a part of the HTTP requests, and
+// access to the DNS cache of the VUs, e.g. to flush it in failover tests.
+package dns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/grafana/sobek"
+
+	"go.k6.io/k6/js/common"
+	"go.k6.io/k6/js/modules"
+	"go.k6.io/k6/js/promises"
+	"go.k6.io/k6/lib/netext"
+	"go.k6.io/k6/lib/types"
+	"go.k6.io/k6/metrics"
+)
+
+type (
+	// RootModule is the global module instance that will create instances of our
+	// module for each VU.
+	RootModule struct{}
+
+	// ModuleInstance represents an instance of the dns module for a single VU.
+	ModuleInstance struct {
+		vu modules.VU
+	}
+)
+
+var (
+	_ modules.Module   = &RootModule{}
+	_ modules.Instance = &ModuleInstance{}
+)
+
+// New returns a pointer to a new [RootModule] instance.
+func New() *RootModule {
+	return &RootModule{}
+}
+
+// NewModuleInstance implements the modules.Module interface and returns a new
+// instance of our module for the given VU.
+func (*RootModule) NewModuleInstance(vu modules.VU) modules.Instance {
+	return &ModuleInstance{vu: vu}
+}
+
+// Exports implements the modules.Module interface and returns the exports of
+// our module.
+func (mi *ModuleInstance) Exports() modules.Exports {
+	return modules.Exports{
+		Named: map[string]any{
+			"resolve":    mi.Resolve,
+			"lookup":     mi.Lookup,
+			"flush":      mi.Flush,
+			"cacheStats": mi.CacheStats,
+		},
+	}
+}
+
+// SRVRecord is a single record of the result of a SRV lookup.
+type SRVRecord struct {
+	Target   string `js:"target"`
+	Port     uint16 `js:"port"`
+	Priority uint16 `js:"priority"`
+	Weight   uint16 `js:"weight"`
+}
+
+// Resolve looks up the records of the given type (A, AAAA, SRV or TXT) for the
+// given name, and returns a promise that resolves to them. If a nameserver is
+// given, in the same format as the servers of the dns option, the query is sent
+// to it, otherwise to the servers of the dns option or the system ones.
+func (mi *ModuleInstance) Resolve(name string, recordType string, nameserver sobek.Value) *sobek.Promise {
+	state := mi.vu.State()
+	if state == nil {
+		common.Throw(mi.vu.Runtime(), errors.New("dns lookups can't be made in the init context"))
+	}
+
+	promise, resolve, reject := promises.New(mi.vu)
+
+	recordType = strings.ToUpper(recordType)
+	switch recordType {
+	case "A", "AAAA", "SRV", "TXT":
+	default:
+		reject(fmt.Errorf("unsupported DNS record type %q, it should be one of A, AAAA, SRV or TXT", recordType))
+		return promise
+	}
+
+	servers := state.Options.DNS.Servers
+	if !common.IsNullish(nameserver) {
+		server, err := types.ParseDNSServer(nameserver.String())
+		if err != nil {
+			reject(err)
+			return promise
+		}
+		servers = []types.DNSServer{server}
+	}
+
+	resolver := net.DefaultResolver
+	if len(servers) > 0 {
+		resolver = netext.NewServersResolver(servers)
+	}
+
+	ctx := mi.vu.Context()
+	tagsAndMeta := state.Tags.GetCurrentValues()
+	tags := tagsAndMeta.Tags.With("server", netext.DNSServersTag(servers)).With("record_type", recordType)
+
+	go func() {
+		start := time.Now()
+		records, err := lookup(ctx, resolver, name, recordType)
+		duration := time.Since(start)
+
+		metrics.PushIfNotDone(ctx, state.Samples, metrics.Sample{
+			TimeSeries: metrics.TimeSeries{
+				Metric: state.BuiltinMetrics.DNSLookupDuration,
+				Tags:   tags,
+			},
+			Time:     start,
+			Metadata: tagsAndMeta.Metadata,
+			Value:    metrics.D(duration),
+		})
+
+		if err != nil {
+			reject(err)
+			return
+		}
+		resolve(records)
+	}()
+
+	return promise
+}
+
+// lookuper is implemented by the dialers of the VUs, which resolve the hosts
+// the way their connections do.
+type lookuper interface {
+	Lookup(host string) (net.IP, error)
+}
+
+// Lookup returns a promise that resolves to the IP address, which the
+// connections of the VU to the given hostname would be made to, i.e. the one
+// the hosts option maps it to, or the one resolved with the dns option and
+// the DNS cache. The lookups are measured with the dns_lookup_duration metric,
+// like the ones of the connections.
+func (mi *ModuleInstance) Lookup(hostname string) *sobek.Promise {
+	state := mi.vu.State()
+	if state == nil {
+		common.Throw(mi.vu.Runtime(), errors.New("dns lookups can't be made in the init context"))
+	}
+	dialer, ok := state.Dialer.(lookuper)
+	if !ok {
+		common.Throw(mi.vu.Runtime(), errors.New("dns.lookup() isn't supported by the dialer of the VU"))
+	}
+
+	promise, resolve, reject := promises.New(mi.vu)
+	go func() {
+		ip, err := dialer.Lookup(hostname)
+		if err != nil {
+			reject(err)
+			return
+		}
+		resolve(ip.String())
+	}()
+	return promise
+}
+
+// dnsCache is implemented by the dialers of the VUs, which keep the statistics
+// of their lookups and can flush the DNS cache of their resolvers.
+type dnsCache interface {
+	DNSCacheStats() netext.DNSCacheStats
+	FlushDNSCache(host string)
+}
+
+func (mi *ModuleInstance) dnsCache(method string) dnsCache {
+	state := mi.vu.State()
+	if state == nil {
+		common.Throw(mi.vu.Runtime(), fmt.Errorf("dns.%s() can't be called in the init context", method))
+	}
+	cache, ok := state.Dialer.(dnsCache)
+	if !ok {
+		common.Throw(mi.vu.Runtime(), fmt.Errorf("dns.%s() isn't supported by the dialer of the VU", method))
+	}
+	return cache
+}
+
+// Flush removes the lookups of the given host, or of all hosts if it isn't
+// given, from the DNS cache, so that the next connections to them resolve
+// them again, e.g. to measure the impact of a DNS-based failover. The
+// connections, which are already open, keep being used. The DNS cache is
+// shared by the VUs, so this affects all of them.
+func (mi *ModuleInstance) Flush(host sobek.Value) {
+	cache := mi.dnsCache("flush")
+	name := ""
+	if !common.IsNullish(host) {
+		name = host.String()
+	}
+	cache.FlushDNSCache(name)
+}
+
+// CacheStats returns the numbers of the DNS lookups of the VU, which were
+// served from the DNS cache, and the ones, which weren't, since it started.
+func (mi *ModuleInstance) CacheStats() netext.DNSCacheStats {
+	return mi.dnsCache("cacheStats").DNSCacheStats()
+}
+
+func lookup(ctx context.Context, resolver *net.Resolver, name, recordType string) (any, error) {
+	switch recordType {
+	case "A", "AAAA":
+		network := "ip4"
+		if recordType == "AAAA" {
+			network = "ip6"
+		}
+		ips, err := resolver.LookupIP(ctx, network, name)
+		if err != nil {
+			return nil, err
+		}
+		result := make([]string, len(ips))
+		for i, ip := range ips {
+			result[i] = ip.String()
+		}
+		return result, nil
+	case "SRV":
+		_, srvs, err := resolver.LookupSRV(ctx, "", "", name)
+		if err != nil {
+			return nil, err
+		}
+		result := make([]SRVRecord, len(srvs))
+		for i, srv := range srvs {
+			result[i] = SRVRecord{Target: srv.Target, Port: srv.Port, Priority: srv.Priority, Weight: srv.Weight}
+		}
+		return result, nil
+	default:
+		return resolver.LookupTXT(ctx, name)
+	}
+}
@@ -0,0 +1,270 @@
+package dns
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/internal/js/compiler"
+	"go.k6.io/k6/js/modulestest"
+	"go.k6.io/k6/lib/netext"
+	"go.k6.io/k6/lib/types"
+	"go.k6.io/k6/metrics"
+)
+
+// startTestDNSServer starts a DNS server that answers A queries with 10.1.2.3
+// and TXT queries with "hello", and all other ones with no records.
+func startTestDNSServer(t *testing.T) string {
+	t.Helper()
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			_, _ = conn.WriteTo(testDNSResponse(buf[:n]), addr)
+		}
+	}()
+
+	return conn.LocalAddr().String()
+}
+
+func testDNSResponse(query []byte) []byte {
+	// skip the labels of the question name, then its type and class
+	end := 12
+	for query[end] != 0 {
+		end += int(query[end]) + 1
+	}
+	end += 5
+	qtype := binary.BigEndian.Uint16(query[end-4:])
+
+	var rdata []byte
+	switch qtype {
+	case 1: // A
+		rdata = []byte{10, 1, 2, 3}
+	case 16: // TXT
+		rdata = append([]byte{5}, "hello"...)
+	}
+
+	resp := make([]byte, 0, 512)
+	resp = append(resp, query[0], query[1], 0x81, 0x80) // same ID, standard response
+	resp = binary.BigEndian.AppendUint16(resp, 1)       // questions
+	if rdata != nil {
+		resp = binary.BigEndian.AppendUint16(resp, 1) // answers
+	} else {
+		resp = binary.BigEndian.AppendUint16(resp, 0)
+	}
+	resp = append(resp, 0, 0, 0, 0) // authority and additional records
+	resp = append(resp, query[12:end]...)
+	if rdata != nil {
+		resp = append(resp, 0xc0, 0x0c)                   // the question name
+		resp = binary.BigEndian.AppendUint16(resp, qtype) // the same type
+		resp = append(resp, 0, 1)                         // IN
+		resp = binary.BigEndian.AppendUint32(resp, 60)
+		resp = binary.BigEndian.AppendUint16(resp, uint16(len(rdata))) //nolint:gosec
+		resp = append(resp, rdata...)
+	}
+	return resp
+}
+
+func newTestRuntime(t *testing.T) (*modulestest.Runtime, chan metrics.SampleContainer) {
+	t.Helper()
+
+	runtime := modulestest.NewRuntime(t)
+	require.NoError(t, runtime.SetupModule("k6/experimental/dns", New(), `const dns = require("k6/experimental/dns");`))
+
+	state, samples := modulestest.NewVUState(t, 10)
+	state.Tags.Modify(func(tagsAndMeta *metrics.TagsAndMeta) { tagsAndMeta.SetTag("scenario", "default") })
+	runtime.MoveToVUContext(state)
+	return runtime, samples
+}
+
+func TestResolve(t *testing.T) {
+	t.Parallel()
+
+	server := startTestDNSServer(t)
+
+	t.Run("A", func(t *testing.T) {
+		t.Parallel()
+		runtime, samples := newTestRuntime(t)
+
+		_, err := runtime.RunOnEventLoop(fmt.Sprintf(`(async () => {
+			const ips = await dns.resolve("example.test", "A", %q);
+			if (ips.length !== 1 || ips[0] !== "10.1.2.3") {
+				throw new Error("unexpected result: " + JSON.stringify(ips));
+			}
+		})()`, server))
+		require.NoError(t, err)
+
+		require.Len(t, samples, 1)
+		sample := (<-samples).GetSamples()[0]
+		assert.Equal(t, metrics.DNSLookupDurationName, sample.Metric.Name)
+		tags := sample.Tags.Map()
+		assert.Equal(t, "udp://"+server, tags["server"])
+		assert.Equal(t, "A", tags["record_type"])
+		assert.Equal(t, "default", tags["scenario"])
+	})
+
+	t.Run("TXT", func(t *testing.T) {
+		t.Parallel()
+		runtime, _ := newTestRuntime(t)
+
+		_, err := runtime.RunOnEventLoop(fmt.Sprintf(`(async () => {
+			const txts = await dns.resolve("example.test", "txt", %q);
+			if (txts.length !== 1 || txts[0] !== "hello") {
+				throw new Error("unexpected result: " + JSON.stringify(txts));
+			}
+		})()`, server))
+		require.NoError(t, err)
+	})
+
+	t.Run("no records", func(t *testing.T) {
+		t.Parallel()
+		runtime, samples := newTestRuntime(t)
+
+		_, err := runtime.RunOnEventLoop(fmt.Sprintf(`(async () => {
+			try {
+				await dns.resolve("example.test", "SRV", %q);
+			} catch (e) {
+				return;
+			}
+			throw new Error("the lookup should have failed");
+		})()`, server))
+		require.NoError(t, err)
+		// failed lookups are measured too
+		require.Len(t, samples, 1)
+	})
+
+	t.Run("unsupported record type", func(t *testing.T) {
+		t.Parallel()
+		runtime, samples := newTestRuntime(t)
+
+		_, err := runtime.RunOnEventLoop(`dns.resolve("example.test", "MX")`)
+		require.ErrorContains(t, err, "unsupported DNS record type")
+		assert.Empty(t, samples)
+	})
+
+	t.Run("invalid nameserver", func(t *testing.T) {
+		t.Parallel()
+		runtime, _ := newTestRuntime(t)
+
+		_, err := runtime.RunOnEventLoop(`dns.resolve("example.test", "A", "ftp://1.1.1.1")`)
+		require.Error(t, err)
+	})
+}
+
+func TestResolveInInitContext(t *testing.T) {
+	t.Parallel()
+
+	runtime := modulestest.NewRuntime(t)
+	err := runtime.SetupModuleSystem(
+		map[string]any{"k6/experimental/dns": New()}, nil, compiler.New(runtime.VU.InitEnv().Logger))
+	require.NoError(t, err)
+
+	_, err = runtime.VU.Runtime().RunString(`require("k6/experimental/dns").resolve("example.test", "A")`)
+	require.ErrorContains(t, err, "init context")
+}
+
+func TestCacheStatsAndFlush(t *testing.T) {
+	t.Parallel()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = listener.Close() })
+	_, port, err := net.SplitHostPort(listener.Addr().String())
+	require.NoError(t, err)
+
+	var lookups int
+	dialer := netext.NewDialer(net.Dialer{}, netext.NewCachingResolver(func(string) ([]net.IP, error) {
+		lookups++
+		return []net.IP{net.ParseIP("127.0.0.1")}, nil
+	}, netext.ResolverCache{TTL: time.Hour}, types.DNSfirst, types.DNSany))
+	dial := func() {
+		t.Helper()
+		conn, err := dialer.DialContext(context.Background(), "tcp", net.JoinHostPort("example.test", port))
+		require.NoError(t, err)
+		require.NoError(t, conn.Close())
+	}
+
+	runtime, _ := newTestRuntime(t)
+	runtime.VU.StateField.Dialer = dialer
+	assertStats := func(hits, misses int) {
+		t.Helper()
+		v, err := runtime.RunOnEventLoop(`JSON.stringify(dns.cacheStats())`)
+		require.NoError(t, err)
+		assert.JSONEq(t, fmt.Sprintf(`{"hits":%d,"misses":%d}`, hits, misses), v.String())
+	}
+
+	assertStats(0, 0)
+	dial()
+	dial()
+	assertStats(1, 1)
+
+	_, err = runtime.RunOnEventLoop(`dns.flush("example.test")`)
+	require.NoError(t, err)
+	dial()
+	assertStats(1, 2)
+	_, err = runtime.RunOnEventLoop(`dns.flush()`)
+	require.NoError(t, err)
+	dial()
+	assertStats(1, 3)
+	assert.Equal(t, 3, lookups)
+}
+
+func TestCacheStatsWithoutDialer(t *testing.T) {
+	t.Parallel()
+
+	runtime, _ := newTestRuntime(t)
+	_, err := runtime.RunOnEventLoop(`dns.flush()`)
+	require.ErrorContains(t, err, "dns.flush() isn't supported by the dialer of the VU")
+
+	runtime = modulestest.NewRuntime(t)
+	err = runtime.SetupModuleSystem(
+		map[string]any{"k6/experimental/dns": New()}, nil, compiler.New(runtime.VU.InitEnv().Logger))
+	require.NoError(t, err)
+	_, err = runtime.VU.Runtime().RunString(`require("k6/experimental/dns").cacheStats()`)
+	require.ErrorContains(t, err, "dns.cacheStats() can't be called in the init context")
+}
+
+func TestLookup(t *testing.T) {
+	t.Parallel()
+
+	hosts, err := types.NewHosts(map[string]types.Host{"replica-1.test": {IP: net.ParseIP("10.0.0.1")}})
+	require.NoError(t, err)
+	dialer := netext.NewDialer(net.Dialer{}, netext.NewResolver(func(string) ([]net.IP, error) {
+		return []net.IP{net.ParseIP("10.1.2.3")}, nil
+	}, 0, types.DNSfirst, types.DNSany))
+	dialer.Hosts = hosts
+
+	runtime, _ := newTestRuntime(t)
+	runtime.VU.StateField.Dialer = dialer
+	_, err = runtime.RunOnEventLoop(`(async () => {
+		const resolved = await dns.lookup("example.test");
+		if (resolved !== "10.1.2.3") {
+			throw new Error("unexpected resolved IP: " + resolved);
+		}
+		const mapped = await dns.lookup("replica-1.test");
+		if (mapped !== "10.0.0.1") {
+			throw new Error("unexpected mapped IP: " + mapped);
+		}
+	})()`)
+	require.NoError(t, err)
+	// only the lookup with the resolver is measured
+	assert.Equal(t, netext.DNSCacheStats{Misses: 1}, dialer.DNSCacheStats())
+
+	runtime, _ = newTestRuntime(t)
+	_, err = runtime.RunOnEventLoop(`dns.lookup("example.test")`)
+	require.ErrorContains(t, err, "dns.lookup() isn't supported by the dialer of the VU")
+}
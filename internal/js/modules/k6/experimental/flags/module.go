@@ -0,0 +1,323 @@
+// Package flags evaluates the feature flags of the system under test, by an
+// OpenFeature compatible flag service, so the tests take the same paths, like
+// A/B variants, as the production users during the test.
+package flags
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grafana/sobek"
+
+	"go.k6.io/k6/js/common"
+	"go.k6.io/k6/js/modules"
+	"go.k6.io/k6/lib/types"
+)
+
+const (
+	defaultCacheTTL = time.Minute
+	defaultTimeout  = 5 * time.Second
+)
+
+type (
+	// RootModule is the global module instance that will create instances of our
+	// module for each VU. It caches the evaluations of the flags, so every VU
+	// doesn't request its own.
+	RootModule struct {
+		mu    sync.Mutex
+		cache map[string]cachedEvaluation
+	}
+
+	// ModuleInstance represents an instance of the flags module for a single VU.
+	ModuleInstance struct {
+		vu   modules.VU
+		root *RootModule
+	}
+
+	cachedEvaluation struct {
+		eval    evaluation
+		expires time.Time
+	}
+)
+
+var (
+	_ modules.Module   = &RootModule{}
+	_ modules.Instance = &ModuleInstance{}
+)
+
+// New returns a pointer to a new [RootModule] instance.
+func New() *RootModule {
+	return &RootModule{cache: make(map[string]cachedEvaluation)}
+}
+
+// NewModuleInstance implements the modules.Module interface and returns a new
+// instance of our module for the given VU.
+func (r *RootModule) NewModuleInstance(vu modules.VU) modules.Instance {
+	return &ModuleInstance{vu: vu, root: r}
+}
+
+// Exports implements the modules.Module interface and returns the exports of
+// our module.
+func (mi *ModuleInstance) Exports() modules.Exports {
+	return modules.Exports{
+		Named: map[string]any{
+			"Client": mi.NewClient,
+		},
+	}
+}
+
+// EvaluationDetails is the result of the evaluation of a flag, like the
+// OpenFeature SDKs return it. When the evaluation fails, the value is the
+// default one and the error code says why.
+type EvaluationDetails struct {
+	FlagKey      string `js:"flagKey"`
+	Value        any    `js:"value"`
+	Variant      string `js:"variant"`
+	Reason       string `js:"reason"`
+	ErrorCode    string `js:"errorCode"`
+	ErrorMessage string `js:"errorMessage"`
+}
+
+// Client evaluates the flags by its provider, with its evaluation context.
+type Client struct {
+	mi       *ModuleInstance
+	provider provider
+	// cacheKey identifies the provider in the cache; the evaluations aren't
+	// cached, if it's empty.
+	cacheKey string
+	cacheTTL time.Duration
+	context  map[string]any
+}
+
+// NewClient is the JS constructor of the Client. Its options are either the
+// url of the flag service, or the static flags.
+func (mi *ModuleInstance) NewClient(call sobek.ConstructorCall) *sobek.Object {
+	rt := mi.vu.Runtime()
+	client, err := mi.newClient(call.Argument(0))
+	if err != nil {
+		common.Throw(rt, err)
+	}
+	return rt.ToValue(client).ToObject(rt)
+}
+
+func (mi *ModuleInstance) newClient(options sobek.Value) (*Client, error) {
+	if common.IsNullish(options) {
+		return nil, errors.New("the options of the client are required")
+	}
+	rt := mi.vu.Runtime()
+	opts := options.ToObject(rt)
+	client := &Client{mi: mi, cacheTTL: defaultCacheTTL}
+
+	if v := opts.Get("context"); !common.IsNullish(v) {
+		evalCtx, ok := v.Export().(map[string]any)
+		if !ok {
+			return nil, errors.New("the context of the client must be an object")
+		}
+		client.context = evalCtx
+	}
+	if v := opts.Get("cacheTTL"); !common.IsNullish(v) {
+		ttl, err := types.GetDurationValue(v.Export())
+		if err != nil {
+			return nil, fmt.Errorf("invalid cacheTTL: %w", err)
+		}
+		client.cacheTTL = ttl
+	}
+
+	if v := opts.Get("flags"); !common.IsNullish(v) {
+		static, ok := v.Export().(map[string]any)
+		if !ok {
+			return nil, errors.New("the flags of the client must be an object")
+		}
+		client.provider = staticProvider(static)
+		return client, nil
+	}
+
+	v := opts.Get("url")
+	if common.IsNullish(v) || v.String() == "" {
+		return nil, errors.New("either the url of the flag service or the static flags are required")
+	}
+	provider := &ofrepProvider{
+		url:     strings.TrimSuffix(v.String(), "/"),
+		headers: make(map[string]string),
+		client:  &http.Client{Timeout: defaultTimeout},
+	}
+	if v := opts.Get("timeout"); !common.IsNullish(v) {
+		timeout, err := types.GetDurationValue(v.Export())
+		if err != nil {
+			return nil, fmt.Errorf("invalid timeout: %w", err)
+		}
+		provider.client.Timeout = timeout
+	}
+	if v := opts.Get("headers"); !common.IsNullish(v) {
+		headers := v.ToObject(rt)
+		for _, name := range headers.Keys() {
+			provider.headers[name] = headers.Get(name).String()
+		}
+	}
+	client.provider = provider
+	client.cacheKey = provider.url + "\x00" + fmt.Sprint(provider.headers)
+	return client, nil
+}
+
+// GetBooleanValue returns the value of the boolean flag, or the default value.
+func (c *Client) GetBooleanValue(key string, defaultValue bool, evalCtx sobek.Value) (any, error) {
+	details, err := c.GetBooleanDetails(key, defaultValue, evalCtx)
+	return details.Value, err
+}
+
+// GetStringValue returns the value of the string flag, or the default value.
+func (c *Client) GetStringValue(key string, defaultValue string, evalCtx sobek.Value) (any, error) {
+	details, err := c.GetStringDetails(key, defaultValue, evalCtx)
+	return details.Value, err
+}
+
+// GetNumberValue returns the value of the number flag, or the default value.
+func (c *Client) GetNumberValue(key string, defaultValue float64, evalCtx sobek.Value) (any, error) {
+	details, err := c.GetNumberDetails(key, defaultValue, evalCtx)
+	return details.Value, err
+}
+
+// GetObjectValue returns the value of the object flag, or the default value.
+func (c *Client) GetObjectValue(key string, defaultValue sobek.Value, evalCtx sobek.Value) (any, error) {
+	details, err := c.GetObjectDetails(key, defaultValue, evalCtx)
+	return details.Value, err
+}
+
+// GetBooleanDetails returns the details of the evaluation of the boolean flag.
+func (c *Client) GetBooleanDetails(key string, defaultValue bool, evalCtx sobek.Value) (EvaluationDetails, error) {
+	return c.details(key, defaultValue, evalCtx, "boolean", func(v any) bool {
+		_, ok := v.(bool)
+		return ok
+	})
+}
+
+// GetStringDetails returns the details of the evaluation of the string flag.
+func (c *Client) GetStringDetails(key string, defaultValue string, evalCtx sobek.Value) (EvaluationDetails, error) {
+	return c.details(key, defaultValue, evalCtx, "string", func(v any) bool {
+		_, ok := v.(string)
+		return ok
+	})
+}
+
+// GetNumberDetails returns the details of the evaluation of the number flag.
+func (c *Client) GetNumberDetails(key string, defaultValue float64, evalCtx sobek.Value) (EvaluationDetails, error) {
+	return c.details(key, defaultValue, evalCtx, "number", func(v any) bool {
+		switch v.(type) {
+		case float64, int64:
+			return true
+		}
+		return false
+	})
+}
+
+// GetObjectDetails returns the details of the evaluation of the object flag.
+func (c *Client) GetObjectDetails(key string, defaultValue sobek.Value, evalCtx sobek.Value) (EvaluationDetails, error) {
+	var def any
+	if !common.IsNullish(defaultValue) {
+		def = defaultValue.Export()
+	}
+	return c.details(key, def, evalCtx, "object", func(v any) bool {
+		switch v.(type) {
+		case map[string]any, []any:
+			return true
+		}
+		return false
+	})
+}
+
+// details evaluates the flag, whose value must be of the type, which the
+// check checks, and returns the default value, if it couldn't be evaluated.
+func (c *Client) details(
+	key string, defaultValue any, evalCtx sobek.Value, typeName string, check func(any) bool,
+) (EvaluationDetails, error) {
+	if key == "" {
+		return EvaluationDetails{}, errors.New("the key of the flag is required")
+	}
+	merged, err := c.evaluationContext(evalCtx)
+	if err != nil {
+		return EvaluationDetails{}, err
+	}
+
+	eval := c.evaluate(key, merged)
+	details := EvaluationDetails{
+		FlagKey:      key,
+		Value:        eval.Value,
+		Variant:      eval.Variant,
+		Reason:       eval.Reason,
+		ErrorCode:    eval.ErrorCode,
+		ErrorMessage: eval.ErrorMessage,
+	}
+	if details.ErrorCode == "" && !check(details.Value) {
+		details.Reason = reasonError
+		details.ErrorCode = errorTypeMismatch
+		details.ErrorMessage = fmt.Sprintf("the value of the flag %q isn't a %s", key, typeName)
+	}
+	if details.ErrorCode != "" {
+		details.Value = defaultValue
+		details.Variant = ""
+	}
+	return details, nil
+}
+
+// evaluationContext returns the context of the client, with the properties of
+// the evaluation context of the call.
+func (c *Client) evaluationContext(evalCtx sobek.Value) (map[string]any, error) {
+	if common.IsNullish(evalCtx) {
+		return c.context, nil
+	}
+	callCtx, ok := evalCtx.Export().(map[string]any)
+	if !ok {
+		return nil, errors.New("the evaluation context must be an object")
+	}
+	merged := make(map[string]any, len(c.context)+len(callCtx))
+	for k, v := range c.context {
+		merged[k] = v
+	}
+	for k, v := range callCtx {
+		merged[k] = v
+	}
+	return merged, nil
+}
+
+// evaluate evaluates the flag by the provider, or returns its cached
+// evaluation. Only the successful evaluations are cached.
+func (c *Client) evaluate(key string, evalCtx map[string]any) evaluation {
+	ctx := c.mi.vu.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if c.cacheKey == "" || c.cacheTTL <= 0 {
+		return c.provider.evaluate(ctx, key, evalCtx)
+	}
+
+	// the keys of maps are marshaled sorted, so the same contexts have the
+	// same JSON
+	ctxJSON, err := json.Marshal(evalCtx)
+	if err != nil {
+		return evaluation{Reason: reasonError, ErrorCode: errorGeneral, ErrorMessage: err.Error()}
+	}
+	cacheKey := c.cacheKey + "\x00" + key + "\x00" + string(ctxJSON)
+	root := c.mi.root
+	root.mu.Lock()
+	cached, ok := root.cache[cacheKey]
+	root.mu.Unlock()
+	if ok && time.Now().Before(cached.expires) {
+		eval := cached.eval
+		eval.Reason = reasonCached
+		return eval
+	}
+
+	eval := c.provider.evaluate(ctx, key, evalCtx)
+	if eval.ErrorCode == "" {
+		root.mu.Lock()
+		root.cache[cacheKey] = cachedEvaluation{eval: eval, expires: time.Now().Add(c.cacheTTL)}
+		root.mu.Unlock()
+	}
+	return eval
+}
@@ -0,0 +1,181 @@
+package flags
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/internal/js/compiler"
+	"go.k6.io/k6/js/modulestest"
+)
+
+// newOFREPServer returns a flag service, whose "new-checkout" flag is on for
+// the users of the beta group, and which counts its evaluations.
+func newOFREPServer(t *testing.T) (*httptest.Server, *int64) {
+	t.Helper()
+
+	var evaluations int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&evaluations, 1)
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method != http.MethodPost || r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var body struct {
+			Context map[string]any `json:"context"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"errorCode":"PARSE_ERROR","errorDetails":"invalid body"}`))
+			return
+		}
+
+		switch strings.TrimPrefix(r.URL.Path, "/ofrep/v1/evaluate/flags/") {
+		case "new-checkout":
+			if body.Context["group"] == "beta" {
+				_, _ = w.Write([]byte(`{"key":"new-checkout","value":true,"variant":"on","reason":"TARGETING_MATCH"}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"key":"new-checkout","value":false,"variant":"off","reason":"DEFAULT"}`))
+		case "banner":
+			_, _ = w.Write([]byte(`{"key":"banner","value":{"color":"red"},"variant":"red","reason":"STATIC"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"errorCode":"FLAG_NOT_FOUND","errorDetails":"flag not found"}`))
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &evaluations
+}
+
+func newTestRuntime(t *testing.T, initScript string) *modulestest.Runtime {
+	t.Helper()
+
+	runtime := modulestest.NewRuntime(t)
+	require.NoError(t, runtime.SetupModule("k6/experimental/flags", New(),
+		`const { Client } = require("k6/experimental/flags");`+initScript))
+	return runtime
+}
+
+func TestClientOFREP(t *testing.T) {
+	t.Parallel()
+
+	srv, evaluations := newOFREPServer(t)
+	runtime := newTestRuntime(t, `
+		const flags = new Client({
+			url: "`+srv.URL+`/",
+			headers: { Authorization: "Bearer secret" },
+			context: { targetingKey: "user-1", group: "beta" },
+		});
+		if (flags.getBooleanValue("new-checkout", false) !== true) {
+			throw new Error("the flag isn't on for the beta group in the init context");
+		}
+	`)
+
+	_, err := runtime.RunOnEventLoop(`
+		let details = flags.getBooleanDetails("new-checkout", false);
+		if (details.value !== true || details.variant !== "on" || details.reason !== "CACHED") {
+			throw new Error("unexpected cached details: " + JSON.stringify(details));
+		}
+
+		details = flags.getBooleanDetails("new-checkout", true, { group: "stable" });
+		if (details.value !== false || details.variant !== "off" || details.reason !== "DEFAULT") {
+			throw new Error("unexpected details for the stable group: " + JSON.stringify(details));
+		}
+
+		const banner = flags.getObjectValue("banner", {});
+		if (banner.color !== "red") {
+			throw new Error("unexpected banner: " + JSON.stringify(banner));
+		}
+
+		details = flags.getStringDetails("banner", "blue");
+		if (details.value !== "blue" || details.errorCode !== "TYPE_MISMATCH" || details.reason !== "ERROR") {
+			throw new Error("unexpected details of the mismatched type: " + JSON.stringify(details));
+		}
+
+		details = flags.getNumberDetails("missing", 42);
+		if (details.value !== 42 || details.errorCode !== "FLAG_NOT_FOUND") {
+			throw new Error("unexpected details of the missing flag: " + JSON.stringify(details));
+		}
+	`)
+	require.NoError(t, err)
+	// new-checkout for both groups, banner once and the missing flag
+	assert.Equal(t, int64(4), atomic.LoadInt64(evaluations))
+}
+
+func TestClientOFREPErrors(t *testing.T) {
+	t.Parallel()
+
+	srv, evaluations := newOFREPServer(t)
+	runtime := newTestRuntime(t, `
+		const flags = new Client({ url: "`+srv.URL+`", cacheTTL: "1m" });
+	`)
+
+	_, err := runtime.RunOnEventLoop(`
+		for (let i = 0; i < 2; i++) {
+			const details = flags.getBooleanDetails("new-checkout", true);
+			if (details.value !== true || details.errorCode !== "GENERAL" ||
+				!details.errorMessage.includes("401 Unauthorized")) {
+				throw new Error("unexpected details of the unauthorized evaluation: " + JSON.stringify(details));
+			}
+		}
+	`)
+	require.NoError(t, err)
+	// the failed evaluations aren't cached
+	assert.Equal(t, int64(2), atomic.LoadInt64(evaluations))
+}
+
+func TestClientStatic(t *testing.T) {
+	t.Parallel()
+
+	runtime := newTestRuntime(t, `
+		const flags = new Client({ flags: { "new-checkout": true, "max-items": 3, "theme": "dark" } });
+	`)
+
+	_, err := runtime.RunOnEventLoop(`
+		if (flags.getBooleanValue("new-checkout", false) !== true) {
+			throw new Error("the static flag isn't on");
+		}
+		if (flags.getNumberValue("max-items", 1) !== 3) {
+			throw new Error("unexpected max-items");
+		}
+		const details = flags.getStringDetails("theme", "light");
+		if (details.value !== "dark" || details.reason !== "STATIC") {
+			throw new Error("unexpected details: " + JSON.stringify(details));
+		}
+		if (flags.getStringValue("missing", "light") !== "light") {
+			throw new Error("the missing flag isn't the default value");
+		}
+	`)
+	require.NoError(t, err)
+}
+
+func TestClientInvalidOptions(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]string{
+		"no options":    `new Client()`,
+		"no provider":   `new Client({ context: {} })`,
+		"invalid ttl":   `new Client({ url: "http://localhost", cacheTTL: "never" })`,
+		"invalid flags": `new Client({ flags: "on" })`,
+	}
+	for name, script := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			runtime := modulestest.NewRuntime(t)
+			err := runtime.SetupModuleSystem(
+				map[string]any{"k6/experimental/flags": New()}, nil, compiler.New(runtime.VU.InitEnv().Logger))
+			require.NoError(t, err)
+			_, err = runtime.VU.Runtime().RunString(`const { Client } = require("k6/experimental/flags");` + script)
+			require.Error(t, err)
+		})
+	}
+}
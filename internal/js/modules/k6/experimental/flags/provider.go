@@ -0,0 +1,116 @@
+package flags
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// The reasons and the error codes of the evaluations, as OpenFeature defines
+// them.
+const (
+	reasonStatic = "STATIC"
+	reasonCached = "CACHED"
+	reasonError  = "ERROR"
+
+	errorFlagNotFound = "FLAG_NOT_FOUND"
+	errorTypeMismatch = "TYPE_MISMATCH"
+	errorGeneral      = "GENERAL"
+)
+
+// evaluation is the result of the evaluation of a flag by a provider.
+type evaluation struct {
+	Value        any    `json:"value"`
+	Variant      string `json:"variant"`
+	Reason       string `json:"reason"`
+	ErrorCode    string `json:"errorCode"`
+	ErrorMessage string `json:"errorDetails"`
+}
+
+// provider evaluates the flags for the evaluation contexts.
+type provider interface {
+	evaluate(ctx context.Context, key string, evalCtx map[string]any) evaluation
+}
+
+// staticProvider evaluates the flags to the values given to the client, e.g.
+// for running the tests without the flag service.
+type staticProvider map[string]any
+
+func (p staticProvider) evaluate(_ context.Context, key string, _ map[string]any) evaluation {
+	value, ok := p[key]
+	if !ok {
+		return evaluation{
+			Reason:       reasonError,
+			ErrorCode:    errorFlagNotFound,
+			ErrorMessage: fmt.Sprintf("the flag %q isn't one of the static flags", key),
+		}
+	}
+	return evaluation{Value: value, Reason: reasonStatic}
+}
+
+// ofrepProvider evaluates the flags remotely by the OpenFeature Remote
+// Evaluation Protocol, which the OpenFeature compatible flag services, like
+// flagd, GO Feature Flag and flipt, support.
+type ofrepProvider struct {
+	url     string
+	headers map[string]string
+	client  *http.Client
+}
+
+func (p *ofrepProvider) evaluate(ctx context.Context, key string, evalCtx map[string]any) evaluation {
+	eval, err := p.request(ctx, key, evalCtx)
+	if err != nil {
+		return evaluation{Reason: reasonError, ErrorCode: errorGeneral, ErrorMessage: err.Error()}
+	}
+	return eval
+}
+
+func (p *ofrepProvider) request(ctx context.Context, key string, evalCtx map[string]any) (evaluation, error) {
+	var eval evaluation
+	if evalCtx == nil {
+		evalCtx = map[string]any{}
+	}
+	body, err := json.Marshal(map[string]any{"context": evalCtx})
+	if err != nil {
+		return eval, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		p.url+"/ofrep/v1/evaluate/flags/"+url.PathEscape(key), bytes.NewReader(body))
+	if err != nil {
+		return eval, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range p.headers {
+		req.Header.Set(name, value)
+	}
+
+	res, err := p.client.Do(req)
+	if err != nil {
+		return eval, err
+	}
+	defer func() { _ = res.Body.Close() }()
+	data, err := io.ReadAll(io.LimitReader(res.Body, 1<<20))
+	if err != nil {
+		return eval, err
+	}
+
+	switch res.StatusCode {
+	case http.StatusOK, http.StatusBadRequest, http.StatusNotFound:
+		if err := json.Unmarshal(data, &eval); err != nil {
+			return eval, fmt.Errorf("invalid response of the flag service: %w", err)
+		}
+		if eval.ErrorCode != "" {
+			eval.Reason = reasonError
+		} else if res.StatusCode != http.StatusOK {
+			return eval, fmt.Errorf("the flag service responded with %s", res.Status)
+		}
+		return eval, nil
+	default:
+		return eval, fmt.Errorf("the flag service responded with %s: %s", res.Status, strings.TrimSpace(string(data)))
+	}
+}
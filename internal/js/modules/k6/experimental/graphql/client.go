@@ -0,0 +1,264 @@
+package graphql
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/grafana/sobek"
+
+	"go.k6.io/k6/js/common"
+	"go.k6.io/k6/lib/netext/httpext"
+	"go.k6.io/k6/lib/types"
+	"go.k6.io/k6/metrics"
+)
+
+// The tags of the metrics of the operations.
+const (
+	operationTag     = "graphql_operation"
+	operationTypeTag = "graphql_operation_type"
+)
+
+// ErrInitContext is returned by the methods of the clients, which are called
+// in the init context.
+var ErrInitContext = errors.New("the GraphQL operations can't be executed in the init context")
+
+// Client is a GraphQL client of an endpoint. Its queries and mutations are
+// POST requests, which emit the HTTP metrics, like the ones of k6/http, and
+// block the VU until their responses are received.
+type Client struct {
+	mi   *ModuleInstance
+	url  string
+	opts clientOptions
+}
+
+// Result is the result of a query or of a mutation. The GraphQL errors are
+// usually returned with the 200 status, so a request can be successful, while
+// its operation failed.
+type Result struct {
+	Status  int               `js:"status"`
+	Headers map[string]string `js:"headers"`
+	Body    string            `js:"body"`
+	Data    any               `js:"data"`
+	// Errors are null, when there are none
+	Errors     any `js:"errors"`
+	Extensions any `js:"extensions"`
+	// Error is the error of the request, when it failed without a response,
+	// and it wasn't thrown, because of the throw option.
+	Error string `js:"error"`
+}
+
+// requestOptions are the options of the operations.
+type requestOptions struct {
+	operationName string
+	headers       http.Header
+	tags          sobek.Value
+	timeout       time.Duration
+}
+
+// parseRequestOptions parses the options of an operation, passing the ones
+// specific to it to the given function.
+func parseRequestOptions(
+	rt *sobek.Runtime, options sobek.Value, defaultTimeout time.Duration, parse func(key string, value sobek.Value) error,
+) (requestOptions, error) {
+	opts := requestOptions{timeout: defaultTimeout}
+	if common.IsNullish(options) {
+		return opts, nil
+	}
+	obj := options.ToObject(rt)
+	for _, k := range obj.Keys() {
+		v := obj.Get(k)
+		var err error
+		switch k {
+		case "operationName":
+			opts.operationName = v.String()
+		case "headers":
+			opts.headers, err = parseHeaders(rt, v)
+		case "tags":
+			opts.tags = v
+		case "timeout":
+			opts.timeout, err = types.GetDurationValue(v.Export())
+			if err == nil && opts.timeout <= 0 {
+				err = errors.New("it must be positive")
+			}
+		default:
+			if parse == nil {
+				return opts, fmt.Errorf("unknown GraphQL request option %q", k)
+			}
+			err = parse(k, v)
+		}
+		if err != nil {
+			return opts, fmt.Errorf("invalid %s value: %w", k, err)
+		}
+	}
+	return opts, nil
+}
+
+// request is the body of the requests, and the payload of the subscribe
+// messages.
+type request struct {
+	Query         string `json:"query"`
+	Variables     any    `json:"variables,omitempty"`
+	OperationName string `json:"operationName,omitempty"`
+}
+
+func newRequest(document string, variables sobek.Value, operationName string) request {
+	r := request{Query: document, OperationName: operationName}
+	if !common.IsNullish(variables) {
+		r.Variables = variables.Export()
+	}
+	return r
+}
+
+// Query executes the query of the document.
+func (c *Client) Query(document string, variables sobek.Value, options sobek.Value) (*Result, error) {
+	return c.execute(operationQuery, document, variables, options)
+}
+
+// Mutate executes the mutation of the document.
+func (c *Client) Mutate(document string, variables sobek.Value, options sobek.Value) (*Result, error) {
+	return c.execute(operationMutation, document, variables, options)
+}
+
+// Execute executes the query or the mutation of the document.
+func (c *Client) Execute(document string, variables sobek.Value, options sobek.Value) (*Result, error) {
+	return c.execute("", document, variables, options)
+}
+
+func (c *Client) execute(kind, document string, variables sobek.Value, options sobek.Value) (*Result, error) {
+	state := c.mi.vu.State()
+	if state == nil {
+		return nil, ErrInitContext
+	}
+	rt := c.mi.vu.Runtime()
+	opts, err := parseRequestOptions(rt, options, c.opts.timeout, nil)
+	if err != nil {
+		return nil, err
+	}
+	op, err := parseOperation(document, opts.operationName)
+	if err != nil {
+		return nil, err
+	}
+	if op.kind == operationSubscription {
+		return nil, errors.New("the subscriptions can only be executed with subscribe()")
+	}
+	if kind != "" && op.kind != kind {
+		return nil, fmt.Errorf("the operation is a %s, instead of a %s", op.kind, kind)
+	}
+	body, err := json.Marshal(newRequest(document, variables, opts.operationName))
+	if err != nil {
+		return nil, fmt.Errorf("invalid variables: %w", err)
+	}
+
+	u, err := httpext.NewURL(c.url, c.url)
+	if err != nil {
+		return nil, err
+	}
+	preq := &httpext.ParsedHTTPRequest{
+		URL: &u,
+		Req: &http.Request{
+			Method: http.MethodPost,
+			URL:    u.GetURL(),
+			Header: c.headers(opts),
+		},
+		Body:             bytes.NewBuffer(body),
+		Timeout:          opts.timeout,
+		Throw:            state.Options.Throw.Bool,
+		Redirects:        state.Options.MaxRedirects,
+		ResponseType:     httpext.ResponseTypeText,
+		ResponseCallback: expectedStatus,
+		ActiveJar:        state.CookieJar,
+		Cookies:          make(map[string]*httpext.HTTPRequestCookie),
+	}
+	preq.Req.Header.Set("Content-Type", "application/json")
+	if preq.Req.Header.Get("Accept") == "" {
+		preq.Req.Header.Set("Accept", "application/graphql-response+json, application/json")
+	}
+	if preq.TagsAndMeta, err = c.tagsAndMeta(opts, op); err != nil {
+		return nil, err
+	}
+	if preq.ActiveJar != nil {
+		httpext.SetRequestCookies(preq.Req, preq.ActiveJar, preq.Cookies)
+	}
+
+	resp, err := httpext.MakeRequest(c.mi.vu.Context(), state, preq)
+	if err != nil {
+		return nil, err
+	}
+	result := &Result{Status: resp.Status, Headers: resp.Headers, Error: resp.Error}
+	if b, ok := resp.Body.(string); ok {
+		result.Body = b
+		var errs []any
+		result.Data, errs, result.Extensions = parseResponse([]byte(b))
+		if len(errs) > 0 {
+			result.Errors = errs
+			c.push(c.mi.metrics.Errors, preq.TagsAndMeta, time.Now(), 1)
+		}
+	}
+	return result, nil
+}
+
+// headers returns the headers of the client, with the ones of the operation.
+func (c *Client) headers(opts requestOptions) http.Header {
+	headers := c.opts.headers.Clone()
+	for name, values := range opts.headers {
+		headers[name] = values
+	}
+	return headers
+}
+
+// tagsAndMeta returns the tags of the VU, with the ones of the client, of the
+// options, and of the operation.
+func (c *Client) tagsAndMeta(opts requestOptions, op operation) (metrics.TagsAndMeta, error) {
+	rt := c.mi.vu.Runtime()
+	tagsAndMeta := c.mi.vu.State().Tags.GetCurrentValues()
+	for _, tags := range []sobek.Value{c.opts.tags, opts.tags} {
+		if common.IsNullish(tags) {
+			continue
+		}
+		if err := common.ApplyCustomUserTags(rt, &tagsAndMeta, tags); err != nil {
+			return tagsAndMeta, fmt.Errorf("invalid tags: %w", err)
+		}
+	}
+	tagsAndMeta.SetTag(operationTypeTag, op.kind)
+	if op.name != "" {
+		tagsAndMeta.SetTag(operationTag, op.name)
+	}
+	return tagsAndMeta, nil
+}
+
+func (c *Client) push(metric *metrics.Metric, tagsAndMeta metrics.TagsAndMeta, t time.Time, value float64) {
+	state := c.mi.vu.State()
+	if state == nil {
+		return
+	}
+	metrics.PushIfNotDone(c.mi.vu.Context(), state.Samples, metrics.Sample{
+		TimeSeries: metrics.TimeSeries{Metric: metric, Tags: tagsAndMeta.Tags},
+		Time:       t,
+		Metadata:   tagsAndMeta.Metadata,
+		Value:      value,
+	})
+}
+
+// parseResponse returns the data, the errors and the extensions of a GraphQL
+// response, which are all nil, if it isn't one.
+func parseResponse(body []byte) (data any, errs []any, extensions any) {
+	var response struct {
+		Data       any   `json:"data"`
+		Errors     []any `json:"errors"`
+		Extensions any   `json:"extensions"`
+	}
+	if json.Unmarshal(body, &response) != nil {
+		return nil, nil, nil
+	}
+	return response.Data, response.Errors, response.Extensions
+}
+
+// expectedStatus is whether the status is the one of a successful request,
+// like for the default expected statuses of k6/http.
+func expectedStatus(status int) bool {
+	return status >= 200 && status < 400
+}
@@ -0,0 +1,28 @@
+package graphql
+
+import "go.k6.io/k6/metrics"
+
+// instanceMetrics contains the metrics of the GraphQL clients, besides the
+// HTTP ones of their requests.
+type instanceMetrics struct {
+	// Errors are the responses and the subscription messages with GraphQL
+	// errors, which are usually returned with the 200 status.
+	Errors               *metrics.Metric
+	SubscriptionMessages *metrics.Metric
+}
+
+// registerMetrics registers and returns the metrics in the provided registry
+func registerMetrics(registry *metrics.Registry) (*instanceMetrics, error) {
+	var err error
+	m := &instanceMetrics{}
+
+	if m.Errors, err = registry.NewMetric("graphql_errors", metrics.Counter); err != nil {
+		return nil, err
+	}
+
+	if m.SubscriptionMessages, err = registry.NewMetric("graphql_subscription_messages", metrics.Counter); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
@@ -0,0 +1,153 @@
+// Package graphql provides a GraphQL client, with queries and mutations over
+// HTTP, and subscriptions over the graphql-transport-ws protocol of
+// graphql-ws, whose metrics are tagged with the names and the types of their
+// operations.
+package graphql
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/grafana/sobek"
+
+	"go.k6.io/k6/js/common"
+	"go.k6.io/k6/js/modules"
+	"go.k6.io/k6/lib/types"
+)
+
+const defaultTimeout = 60 * time.Second
+
+type (
+	// RootModule is the global module instance that will create instances of our
+	// module for each VU.
+	RootModule struct{}
+
+	// ModuleInstance represents an instance of the graphql module for a single VU.
+	ModuleInstance struct {
+		vu      modules.VU
+		metrics *instanceMetrics
+	}
+)
+
+var (
+	_ modules.Module   = &RootModule{}
+	_ modules.Instance = &ModuleInstance{}
+)
+
+// New returns a pointer to a new [RootModule] instance.
+func New() *RootModule {
+	return &RootModule{}
+}
+
+// NewModuleInstance implements the modules.Module interface and returns a new
+// instance of our module for the given VU.
+func (*RootModule) NewModuleInstance(vu modules.VU) modules.Instance {
+	m, err := registerMetrics(vu.InitEnv().Registry)
+	if err != nil {
+		common.Throw(vu.Runtime(), fmt.Errorf("failed to register the graphql module metrics: %w", err))
+	}
+	return &ModuleInstance{vu: vu, metrics: m}
+}
+
+// Exports implements the modules.Module interface and returns the exports of
+// our module.
+func (mi *ModuleInstance) Exports() modules.Exports {
+	return modules.Exports{
+		Named: map[string]any{
+			"Client": mi.NewClient,
+		},
+	}
+}
+
+// clientOptions are the options of the Client constructor.
+type clientOptions struct {
+	headers http.Header
+	tags    sobek.Value
+	timeout time.Duration
+	// wsURL is the URL of the subscriptions, the ws(s):// one of the endpoint
+	// by default
+	wsURL string
+	// connectionParams is the payload of the connection_init messages of the
+	// subscriptions
+	connectionParams any
+}
+
+func parseClientOptions(rt *sobek.Runtime, options sobek.Value) (clientOptions, error) {
+	opts := clientOptions{headers: make(http.Header), timeout: defaultTimeout}
+	if common.IsNullish(options) {
+		return opts, nil
+	}
+	obj := options.ToObject(rt)
+	for _, k := range obj.Keys() {
+		v := obj.Get(k)
+		var err error
+		switch k {
+		case "headers":
+			opts.headers, err = parseHeaders(rt, v)
+		case "tags":
+			opts.tags = v
+		case "timeout":
+			opts.timeout, err = types.GetDurationValue(v.Export())
+			if err == nil && opts.timeout <= 0 {
+				err = errors.New("it must be positive")
+			}
+		case "wsUrl":
+			opts.wsURL = v.String()
+		case "connectionParams":
+			opts.connectionParams = v.Export()
+		default:
+			return opts, fmt.Errorf("unknown GraphQL client option %q", k)
+		}
+		if err != nil {
+			return opts, fmt.Errorf("invalid %s value: %w", k, err)
+		}
+	}
+	return opts, nil
+}
+
+func parseHeaders(rt *sobek.Runtime, v sobek.Value) (http.Header, error) {
+	var values map[string]string
+	if err := rt.ExportTo(v, &values); err != nil {
+		return nil, err
+	}
+	headers := make(http.Header, len(values))
+	for name, value := range values {
+		headers.Set(name, value)
+	}
+	return headers, nil
+}
+
+// NewClient is the JS constructor of the Client.
+func (mi *ModuleInstance) NewClient(call sobek.ConstructorCall) *sobek.Object {
+	rt := mi.vu.Runtime()
+	endpoint := call.Argument(0)
+	if common.IsNullish(endpoint) || endpoint.String() == "" {
+		common.Throw(rt, errors.New("the URL of the GraphQL endpoint is required"))
+	}
+	u, err := url.Parse(endpoint.String())
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		common.Throw(rt, fmt.Errorf("the GraphQL endpoint must be an http(s):// URL, but it's %q", endpoint))
+	}
+	opts, err := parseClientOptions(rt, call.Argument(1))
+	if err != nil {
+		common.Throw(rt, err)
+	}
+	if opts.wsURL == "" {
+		ws := *u
+		ws.Scheme = "ws"
+		if u.Scheme == "https" {
+			ws.Scheme = "wss"
+		}
+		opts.wsURL = ws.String()
+	}
+	return rt.ToValue(&Client{mi: mi, url: u.String(), opts: opts}).ToObject(rt)
+}
+
+func must(rt *sobek.Runtime, err error) {
+	if err != nil {
+		common.Throw(rt, err)
+	}
+}
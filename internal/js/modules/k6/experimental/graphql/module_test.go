@@ -0,0 +1,328 @@
+package graphql
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/internal/js/compiler"
+	"go.k6.io/k6/js/modulestest"
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/metrics"
+)
+
+func newTestRuntime(t *testing.T) (*modulestest.Runtime, chan metrics.SampleContainer) {
+	t.Helper()
+
+	runtime := modulestest.NewRuntime(t)
+	require.NoError(t, runtime.SetupModule("k6/experimental/graphql", New(),
+		`const { Client } = require("k6/experimental/graphql");`))
+
+	state, samples := modulestest.NewVUState(t, 1000)
+	state.Dialer = modulestest.NewLocalDialer()
+	state.Transport = &http.Transport{DialContext: state.Dialer.DialContext}
+	state.BufferPool = lib.NewBufferPool()
+	runtime.MoveToVUContext(state)
+	return runtime, samples
+}
+
+// startTestServer starts a GraphQL server for the tests. It answers the user
+// queries with the user of the ID variable, and the mutations with a new one,
+// and it returns an error for the queries of the broken field, and for the
+// requests without the token. Its subscriptions send three counts, and
+// complete, or fail, for the failing field, or count until they're
+// unsubscribed, for the endless field.
+func startTestServer(t *testing.T) string {
+	t.Helper()
+	upgrader := websocket.Upgrader{Subprotocols: []string{subprotocol}}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if websocket.IsWebSocketUpgrade(r) {
+			ws, err := upgrader.Upgrade(w, r, nil)
+			if err != nil {
+				return
+			}
+			defer func() { _ = ws.Close() }()
+			serveTestSubscription(ws)
+			return
+		}
+		var req struct {
+			Query     string         `json:"query"`
+			Variables map[string]any `json:"variables"`
+		}
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" ||
+			json.NewDecoder(r.Body).Decode(&req) != nil {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Header.Get("Authorization") != "Bearer token":
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"errors":[{"message":"unauthorized"}]}`))
+		case strings.Contains(req.Query, "broken"):
+			_, _ = w.Write([]byte(`{"data":null,"errors":[{"message":"boom","path":["broken"]}]}`))
+		case strings.HasPrefix(req.Query, "mutation"):
+			_, _ = w.Write([]byte(`{"data":{"createUser":{"id":"2"}}}`))
+		default:
+			b, _ := json.Marshal(map[string]any{"data": map[string]any{"user": map[string]any{
+				"id": req.Variables["id"], "name": "k6",
+			}}})
+			_, _ = w.Write(b)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv.URL
+}
+
+func serveTestSubscription(ws *websocket.Conn) {
+	var m message
+	if ws.ReadJSON(&m) != nil || m.Type != messageConnectionInit || !strings.Contains(string(m.Payload), "token") {
+		return
+	}
+	_ = ws.WriteJSON(message{Type: messagePing})
+	if ws.ReadJSON(&m) != nil || m.Type != messagePong {
+		return
+	}
+	_ = ws.WriteJSON(message{Type: messageConnectionAck})
+	if ws.ReadJSON(&m) != nil || m.Type != messageSubscribe {
+		return
+	}
+	var payload request
+	_ = json.Unmarshal(m.Payload, &payload)
+	next := func(count int) error {
+		b, _ := json.Marshal(map[string]any{"data": map[string]any{"count": count}})
+		return ws.WriteJSON(message{ID: m.ID, Type: messageNext, Payload: b})
+	}
+
+	switch {
+	case strings.Contains(payload.Query, "failing"):
+		_ = next(1)
+		_ = ws.WriteJSON(message{ID: m.ID, Type: messageError, Payload: json.RawMessage(`[{"message":"failed"}]`)})
+	case strings.Contains(payload.Query, "endless"):
+		go func() {
+			for count := 1; count <= 100 && next(count) == nil; count++ {
+				time.Sleep(10 * time.Millisecond)
+			}
+		}()
+		for ws.ReadJSON(&m) == nil {
+			if m.Type == messageComplete {
+				_ = ws.WriteControl(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.CloseNormalClosure, "unsubscribed"), time.Time{})
+				return
+			}
+		}
+	default:
+		for count := range 3 {
+			_ = next(count + 1)
+		}
+		_ = ws.WriteJSON(message{ID: m.ID, Type: messageComplete})
+		_, _, _ = ws.ReadMessage()
+	}
+}
+
+func TestQueries(t *testing.T) {
+	t.Parallel()
+
+	runtime, samples := newTestRuntime(t)
+	require.NoError(t, runtime.VU.Runtime().Set("url", startTestServer(t)))
+
+	v, err := runtime.VU.Runtime().RunString(`
+		const client = new Client(url, { headers: { Authorization: "Bearer token" }, tags: { api: "users" } });
+		const user = client.query(` + "`" + `
+			# the user of the ID
+			query GetUser($id: ID!) { user(id: $id) { id name } }
+		` + "`" + `, { id: "1" });
+		const created = client.mutate("mutation CreateUser { createUser(name: \"k6\") { id } }");
+		const broken = client.execute("{ broken }");
+		const unauthorized = new Client(url).query("query Me { me { id } }");
+		JSON.stringify([
+			user.status, user.data.user, user.errors,
+			created.data.createUser.id,
+			broken.status, broken.data, broken.errors[0].message,
+			unauthorized.status, unauthorized.errors[0].message,
+		]);
+	`)
+	require.NoError(t, err)
+	assert.JSONEq(t, `[
+		200, {"id": "1", "name": "k6"}, null,
+		"2",
+		200, null, "boom",
+		401, "unauthorized"
+	]`, v.String())
+
+	operations := make(map[string][]string)
+	for _, sc := range metrics.GetBufferedSamples(samples) {
+		for _, s := range sc.GetSamples() {
+			if s.Metric.Name != "http_reqs" && s.Metric.Name != "graphql_errors" {
+				continue
+			}
+			name, _ := s.Tags.Get(operationTag)
+			kind, _ := s.Tags.Get(operationTypeTag)
+			api, _ := s.Tags.Get("api")
+			operations[s.Metric.Name] = append(operations[s.Metric.Name], name+" "+kind+" "+api)
+		}
+	}
+	assert.Equal(t, map[string][]string{
+		"http_reqs":      {"GetUser query users", "CreateUser mutation users", " query users", "Me query "},
+		"graphql_errors": {" query users", "Me query "},
+	}, operations)
+}
+
+func TestSubscriptions(t *testing.T) {
+	t.Parallel()
+
+	t.Run("complete", func(t *testing.T) {
+		t.Parallel()
+		runtime, samples := newTestRuntime(t)
+		require.NoError(t, runtime.VU.Runtime().Set("url", startTestServer(t)))
+
+		_, err := runtime.RunOnEventLoop(`
+			var got = [];
+			const client = new Client(url, { connectionParams: { token: "token" } });
+			client.subscribe("subscription Counts { count }", null, {
+				next: (data, result) => got.push(data.count, result.errors),
+				complete: () => got.push("complete"),
+			});
+		`)
+		require.NoError(t, err)
+		got, err := runtime.VU.Runtime().RunString(`JSON.stringify(got)`)
+		require.NoError(t, err)
+		assert.JSONEq(t, `[1, null, 2, null, 3, null, "complete"]`, got.String())
+
+		messages := 0
+		for _, sc := range metrics.GetBufferedSamples(samples) {
+			for _, s := range sc.GetSamples() {
+				if s.Metric.Name == "graphql_subscription_messages" {
+					messages++
+					name, _ := s.Tags.Get(operationTag)
+					assert.Equal(t, "Counts", name)
+				}
+			}
+		}
+		assert.Equal(t, 3, messages)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		t.Parallel()
+		runtime, _ := newTestRuntime(t)
+		require.NoError(t, runtime.VU.Runtime().Set("url", startTestServer(t)))
+
+		_, err := runtime.RunOnEventLoop(`
+			var got = [];
+			const client = new Client(url, { connectionParams: { token: "token" } });
+			client.subscribe("subscription { failing }", null, {
+				next: (data) => got.push(data.count),
+				error: (errors) => got.push(errors[0].message),
+				complete: () => got.push("complete"),
+			});
+		`)
+		require.NoError(t, err)
+		got, err := runtime.VU.Runtime().RunString(`JSON.stringify(got)`)
+		require.NoError(t, err)
+		assert.JSONEq(t, `[1, "failed"]`, got.String())
+
+		_, err = runtime.RunOnEventLoop(`client.subscribe("subscription { failing }")`)
+		require.ErrorContains(t, err, `the GraphQL subscription failed: [{"message":"failed"}]`)
+	})
+
+	t.Run("unsubscribe", func(t *testing.T) {
+		t.Parallel()
+		runtime, _ := newTestRuntime(t)
+		require.NoError(t, runtime.VU.Runtime().Set("url", startTestServer(t)))
+
+		_, err := runtime.RunOnEventLoop(`
+			var got = [];
+			const client = new Client(url, { connectionParams: { token: "token" } });
+			const subscription = client.subscribe("subscription { endless }", null, {
+				next: (data) => {
+					got.push(data.count);
+					if (data.count === 2) {
+						subscription.unsubscribe();
+					}
+				},
+				complete: () => got.push("complete"),
+			});
+		`)
+		require.NoError(t, err)
+		got, err := runtime.VU.Runtime().RunString(`JSON.stringify(got)`)
+		require.NoError(t, err)
+		assert.JSONEq(t, `[1, 2, "complete"]`, got.String())
+	})
+}
+
+func TestInvalid(t *testing.T) {
+	t.Parallel()
+
+	runtime, _ := newTestRuntime(t)
+	require.NoError(t, runtime.VU.Runtime().Set("url", startTestServer(t)))
+	_, err := runtime.VU.Runtime().RunString(`const client = new Client(url);`)
+	require.NoError(t, err)
+
+	for script, msg := range map[string]string{
+		`new Client()`:                                              "the URL of the GraphQL endpoint is required",
+		`new Client("ws://localhost")`:                              "the GraphQL endpoint must be an http(s):// URL",
+		`new Client(url, { retries: 1 })`:                           `unknown GraphQL client option "retries"`,
+		`client.query("{ a }", null, { retries: 1 })`:               `unknown GraphQL request option "retries"`,
+		`client.query("mutation { a }")`:                            "the operation is a mutation, instead of a query",
+		`client.execute("subscription { a }")`:                      "can only be executed with subscribe()",
+		`client.subscribe("{ a }")`:                                 "the operation is a query, instead of a subscription",
+		`client.query("query A { a } query B { b }")`:               "an operationName is required",
+		`client.query("{ a }", null, { operationName: "B" })`:       `the document doesn't define the operation "B"`,
+		`client.query("{ a ")`:                                      "the document has an unbalanced {",
+		`client.subscribe("subscription { a }", null, { next: 1 })`: "invalid next value: it must be a function",
+		`client.subscribe("subscription { a }")`:                    "the graphql-transport-ws connection failed",
+	} {
+		_, err := runtime.VU.Runtime().RunString(script)
+		assert.ErrorContains(t, err, msg, script)
+	}
+}
+
+func TestParseOperation(t *testing.T) {
+	t.Parallel()
+
+	for document, expected := range map[string]operation{
+		`{ user { id } }`:       {kind: operationQuery},
+		`query { user { id } }`: {kind: operationQuery},
+		`query GetUser($id: ID!) @cached { user(id: $id) { id } }`: {kind: operationQuery, name: "GetUser"},
+		`mutation($query: String) { search(query: $query) }`:       {kind: operationMutation},
+		`subscription OnMessage { message(text: "query A {") }`:    {kind: operationSubscription, name: "OnMessage"},
+		`
+			# query Commented
+			fragment User on User { id mutation }
+			mutation Create { create { ...User } }
+		`: {kind: operationMutation, name: "Create"},
+		`query A """ query B """ { a }`: {kind: operationQuery, name: "A"},
+	} {
+		op, err := parseOperation(document, "")
+		require.NoError(t, err, document)
+		assert.Equal(t, expected, op, document)
+	}
+
+	op, err := parseOperation(`query A { a } mutation B { b }`, "B")
+	require.NoError(t, err)
+	assert.Equal(t, operation{kind: operationMutation, name: "B"}, op)
+
+	_, err = parseOperation(`fragment F on User { id }`, "")
+	require.ErrorContains(t, err, "the document doesn't define any operation")
+}
+
+func TestInitContext(t *testing.T) {
+	t.Parallel()
+
+	runtime := modulestest.NewRuntime(t)
+	err := runtime.SetupModuleSystem(
+		map[string]any{"k6/experimental/graphql": New()}, nil, compiler.New(runtime.VU.InitEnv().Logger))
+	require.NoError(t, err)
+	_, err = runtime.VU.Runtime().RunString(`
+		const { Client } = require("k6/experimental/graphql");
+		new Client("http://localhost/graphql").query("{ a }");
+	`)
+	require.ErrorContains(t, err, "the GraphQL operations can't be executed in the init context")
+}
@@ -0,0 +1,164 @@
+package graphql
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// The types of the GraphQL operations.
+const (
+	operationQuery        = "query"
+	operationMutation     = "mutation"
+	operationSubscription = "subscription"
+)
+
+// operation is an operation defined by a GraphQL document.
+type operation struct {
+	kind string
+	// name is empty for the anonymous operations
+	name string
+}
+
+// parseOperation returns the operation of the document, which is executed,
+// which is the one with the name, or the only one without a name. It scans
+// only the definitions of the document, without validating it, which is left
+// to the server.
+func parseOperation(document, operationName string) (operation, error) {
+	operations, err := scanOperations(document)
+	if err != nil {
+		return operation{}, err
+	}
+	if operationName != "" {
+		for _, op := range operations {
+			if op.name == operationName {
+				return op, nil
+			}
+		}
+		return operation{}, fmt.Errorf("the document doesn't define the operation %q", operationName)
+	}
+	switch len(operations) {
+	case 0:
+		return operation{}, errors.New("the document doesn't define any operation")
+	case 1:
+		return operations[0], nil
+	default:
+		return operation{}, errors.New("an operationName is required for the documents with multiple operations")
+	}
+}
+
+// scanOperations returns the operations defined in the document, by the
+// keywords and the names of the definitions outside of the selection sets.
+func scanOperations(document string) ([]operation, error) {
+	var operations []operation
+	// depth is the one of the selection sets, and parens of the variables
+	depth, parens := 0, 0
+	// pending is whether a definition started, without its selection set
+	pending := false
+	// expectName is whether the last token was an operation keyword
+	expectName := false
+	for i := 0; i < len(document); {
+		c := document[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',':
+			i++
+		case c == '#':
+			if end := strings.IndexAny(document[i:], "\r\n"); end >= 0 {
+				i += end
+			} else {
+				i = len(document)
+			}
+		case c == '"':
+			end, err := skipString(document, i)
+			if err != nil {
+				return nil, err
+			}
+			i = end
+		case c == '{':
+			if depth == 0 && !pending {
+				// the shorthand of an anonymous query
+				operations = append(operations, operation{kind: operationQuery})
+			}
+			pending, expectName = false, false
+			depth++
+			i++
+		case c == '}':
+			if depth == 0 {
+				return nil, errors.New("the document has an unbalanced }")
+			}
+			depth--
+			i++
+		case isNameStart(c):
+			start := i
+			for i < len(document) && isNameContinue(document[i]) {
+				i++
+			}
+			word := document[start:i]
+			if depth > 0 || parens > 0 {
+				continue
+			}
+			switch {
+			case expectName:
+				operations[len(operations)-1].name = word
+				expectName = false
+			case word == operationQuery || word == operationMutation || word == operationSubscription:
+				operations = append(operations, operation{kind: word})
+				pending, expectName = true, true
+			case word == "fragment":
+				// the fragments and their selection sets aren't operations
+				pending = true
+			}
+		default:
+			// a parenthesis, a variable, a directive or a type of a variable,
+			// after which an operation doesn't have a name anymore
+			switch c {
+			case '(':
+				parens++
+			case ')':
+				parens--
+			}
+			expectName = false
+			i++
+		}
+	}
+	if depth != 0 {
+		return nil, errors.New("the document has an unbalanced {")
+	}
+	return operations, nil
+}
+
+// skipString returns the end of the string or of the block string, which
+// starts at the index.
+func skipString(document string, start int) (int, error) {
+	if strings.HasPrefix(document[start:], `"""`) {
+		for i := start + 3; i < len(document); i++ {
+			if document[i] == '\\' && strings.HasPrefix(document[i:], `\"""`) {
+				i += 3
+				continue
+			}
+			if strings.HasPrefix(document[i:], `"""`) {
+				return i + 3, nil
+			}
+		}
+		return 0, errors.New("the document has an unterminated block string")
+	}
+	for i := start + 1; i < len(document); i++ {
+		switch document[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1, nil
+		case '\n', '\r':
+			return 0, errors.New("the document has an unterminated string")
+		}
+	}
+	return 0, errors.New("the document has an unterminated string")
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isNameContinue(c byte) bool {
+	return isNameStart(c) || (c >= '0' && c <= '9')
+}
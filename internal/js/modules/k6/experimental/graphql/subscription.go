@@ -0,0 +1,361 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/grafana/sobek"
+	"github.com/mstoykov/k6-taskqueue-lib/taskqueue"
+
+	"go.k6.io/k6/js/common"
+	"go.k6.io/k6/metrics"
+)
+
+// subprotocol is the WebSocket subprotocol of graphql-ws.
+const subprotocol = "graphql-transport-ws"
+
+// The types of the messages of the graphql-transport-ws protocol.
+const (
+	messageConnectionInit = "connection_init"
+	messageConnectionAck  = "connection_ack"
+	messagePing           = "ping"
+	messagePong           = "pong"
+	messageSubscribe      = "subscribe"
+	messageNext           = "next"
+	messageError          = "error"
+	messageComplete       = "complete"
+)
+
+// subscriptionID is the ID of the subscription of each connection, since the
+// subscriptions don't share their connections.
+const subscriptionID = "1"
+
+// message is a message of the graphql-transport-ws protocol.
+type message struct {
+	ID      string          `json:"id,omitempty"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// Subscription is a subscription, with its own WebSocket connection, whose
+// results are delivered to its handlers on the event loop, which keeps running
+// until it's completed by the server, or unsubscribed.
+type Subscription struct {
+	c           *Client
+	ws          *websocket.Conn
+	tagsAndMeta metrics.TagsAndMeta
+	// stop stops closing the connection, when the VU context is done
+	stop    func() bool
+	samples chan<- metrics.SampleContainer
+	tq      *taskqueue.TaskQueue
+
+	onNext, onError, onComplete sobek.Callable
+	// unsubscribed is only accessed on the event loop
+	unsubscribed bool
+
+	writeMx sync.Mutex
+
+	mx      sync.Mutex
+	closing bool
+	// completed is whether the complete or the error message was received,
+	// which ends the subscription, before its connection is closed
+	completed bool
+}
+
+// Subscribe subscribes with the subscription of the document, and calls the
+// next handler with the data and the whole result of each of its events, the
+// error handler with the errors, which ended it, and the complete handler,
+// when it ended without errors. It's an error, which fails the iteration, if
+// it ends with errors without an error handler.
+func (c *Client) Subscribe(document string, variables sobek.Value, options sobek.Value) (*Subscription, error) {
+	state := c.mi.vu.State()
+	if state == nil {
+		return nil, ErrInitContext
+	}
+	rt := c.mi.vu.Runtime()
+	s := &Subscription{c: c, samples: state.Samples}
+	opts, err := parseRequestOptions(rt, options, c.opts.timeout, func(key string, value sobek.Value) error {
+		fn, ok := sobek.AssertFunction(value)
+		if !ok && !common.IsNullish(value) {
+			return errors.New("it must be a function")
+		}
+		switch key {
+		case "next":
+			s.onNext = fn
+		case "error":
+			s.onError = fn
+		case "complete":
+			s.onComplete = fn
+		default:
+			return fmt.Errorf("unknown GraphQL subscription option %q", key)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	op, err := parseOperation(document, opts.operationName)
+	if err != nil {
+		return nil, err
+	}
+	if op.kind != operationSubscription {
+		return nil, fmt.Errorf("the operation is a %s, instead of a %s", op.kind, operationSubscription)
+	}
+	payload, err := json.Marshal(newRequest(document, variables, opts.operationName))
+	if err != nil {
+		return nil, fmt.Errorf("invalid variables: %w", err)
+	}
+	if s.tagsAndMeta, err = c.tagsAndMeta(opts, op); err != nil {
+		return nil, err
+	}
+	s.tagsAndMeta.SetSystemTagOrMetaIfEnabled(state.Options.SystemTags, metrics.TagURL, c.opts.wsURL)
+
+	ctx, cancel := context.WithTimeout(c.mi.vu.Context(), opts.timeout)
+	defer cancel()
+	if err = s.connect(ctx, c.headers(opts)); err != nil {
+		return nil, err
+	}
+	if err = s.write(message{ID: subscriptionID, Type: messageSubscribe, Payload: payload}); err != nil {
+		_ = s.ws.Close()
+		return nil, fmt.Errorf("subscribing failed: %w", err)
+	}
+	s.tq = taskqueue.New(c.mi.vu.RegisterCallback)
+	s.stop = context.AfterFunc(c.mi.vu.Context(), func() { _ = s.ws.Close() })
+	go s.readLoop()
+	return s, nil
+}
+
+// connect opens the WebSocket, with the dialer and the TLS options of the VU,
+// and initializes the graphql-transport-ws connection.
+func (s *Subscription) connect(ctx context.Context, headers http.Header) error {
+	state := s.c.mi.vu.State()
+	tlsConfig := state.TLSConfig.Clone()
+	if tlsConfig != nil {
+		tlsConfig.NextProtos = []string{"http/1.1"}
+	}
+	wsd := websocket.Dialer{
+		NetDialContext:  state.Dialer.DialContext,
+		Proxy:           http.ProxyFromEnvironment,
+		TLSClientConfig: tlsConfig,
+		Subprotocols:    []string{subprotocol},
+	}
+	ws, resp, err := wsd.DialContext(ctx, s.c.opts.wsURL, headers)
+	if resp != nil && resp.Body != nil {
+		_ = resp.Body.Close()
+	}
+	if err != nil {
+		return fmt.Errorf("the WebSocket handshake with %s failed: %w", s.c.opts.wsURL, err)
+	}
+	if ws.Subprotocol() != subprotocol {
+		_ = ws.Close()
+		return fmt.Errorf("the server doesn't support the %s subprotocol", subprotocol)
+	}
+	s.ws = ws
+
+	init := message{Type: messageConnectionInit}
+	if s.c.opts.connectionParams != nil {
+		if init.Payload, err = json.Marshal(s.c.opts.connectionParams); err != nil {
+			_ = ws.Close()
+			return fmt.Errorf("invalid connectionParams: %w", err)
+		}
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = ws.SetReadDeadline(deadline)
+		defer func() { _ = ws.SetReadDeadline(time.Time{}) }()
+	}
+	if err = s.write(init); err == nil {
+		err = s.waitAck()
+	}
+	if err != nil {
+		_ = ws.Close()
+		return fmt.Errorf("the graphql-transport-ws connection failed: %w", err)
+	}
+	return nil
+}
+
+// waitAck waits for the connection_ack message, answering the pings.
+func (s *Subscription) waitAck() error {
+	for {
+		var m message
+		if err := s.ws.ReadJSON(&m); err != nil {
+			return err
+		}
+		switch m.Type {
+		case messageConnectionAck:
+			return nil
+		case messagePing:
+			if err := s.write(message{Type: messagePong}); err != nil {
+				return err
+			}
+		case messagePong:
+		default:
+			return fmt.Errorf("the server sent %s, instead of %s", m.Type, messageConnectionAck)
+		}
+	}
+}
+
+// Unsubscribe completes the subscription, and closes its connection. The
+// complete handler is called, unless it was already completed.
+func (s *Subscription) Unsubscribe() error {
+	s.mx.Lock()
+	if s.closing || s.completed {
+		s.mx.Unlock()
+		return nil
+	}
+	s.closing = true
+	s.mx.Unlock()
+	s.unsubscribed = true
+	_ = s.write(message{ID: subscriptionID, Type: messageComplete})
+	s.close()
+	if s.onComplete != nil {
+		_, err := s.onComplete(sobek.Undefined())
+		return err
+	}
+	return nil
+}
+
+func (s *Subscription) write(m message) error {
+	s.writeMx.Lock()
+	defer s.writeMx.Unlock()
+	_ = s.ws.SetWriteDeadline(time.Now().Add(s.c.opts.timeout))
+	return s.ws.WriteJSON(m)
+}
+
+// close closes the connection, which stops the reading goroutine.
+func (s *Subscription) close() {
+	s.writeMx.Lock()
+	_ = s.ws.WriteControl(websocket.CloseMessage,
+		websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(time.Second))
+	s.writeMx.Unlock()
+	_ = s.ws.Close()
+}
+
+func (s *Subscription) readLoop() {
+	var err error
+	for err == nil {
+		var m message
+		if err = s.ws.ReadJSON(&m); err == nil {
+			err = s.handle(m)
+		}
+	}
+	s.closed(err)
+}
+
+// errCompleted stops reading, after the subscription was completed.
+var errCompleted = errors.New("the subscription was completed")
+
+func (s *Subscription) handle(m message) error {
+	switch m.Type {
+	case messagePing:
+		return s.write(message{Type: messagePong})
+	case messagePong:
+		return nil
+	case messageNext:
+		if m.ID != subscriptionID {
+			return nil
+		}
+		s.push(s.c.mi.metrics.SubscriptionMessages, 1)
+		data, errs, extensions := parseResponse(m.Payload)
+		if len(errs) > 0 {
+			s.push(s.c.mi.metrics.Errors, 1)
+		}
+		if s.onNext == nil {
+			return nil
+		}
+		s.tq.Queue(func() error {
+			if s.unsubscribed {
+				return nil
+			}
+			rt := s.c.mi.vu.Runtime()
+			result := rt.NewObject()
+			must(rt, result.Set("data", data))
+			if len(errs) > 0 {
+				must(rt, result.Set("errors", errs))
+			} else {
+				must(rt, result.Set("errors", sobek.Null()))
+			}
+			must(rt, result.Set("extensions", extensions))
+			_, err := s.onNext(sobek.Undefined(), rt.ToValue(data), result)
+			return err
+		})
+		return nil
+	case messageError:
+		var errs []any
+		if err := json.Unmarshal(m.Payload, &errs); err != nil {
+			return fmt.Errorf("the server sent a malformed error message: %w", err)
+		}
+		s.push(s.c.mi.metrics.Errors, 1)
+		s.ended()
+		s.tq.Queue(func() error {
+			if s.onError == nil {
+				b, _ := json.Marshal(errs)
+				return fmt.Errorf("the GraphQL subscription failed: %s", b)
+			}
+			_, err := s.onError(sobek.Undefined(), s.c.mi.vu.Runtime().ToValue(errs))
+			return err
+		})
+		return errCompleted
+	case messageComplete:
+		s.ended()
+		if s.onComplete != nil {
+			s.tq.Queue(func() error {
+				_, err := s.onComplete(sobek.Undefined())
+				return err
+			})
+		}
+		return errCompleted
+	default:
+		return fmt.Errorf("unexpected graphql-transport-ws message %q", m.Type)
+	}
+}
+
+// push pushes the metric from the reading goroutine.
+func (s *Subscription) push(metric *metrics.Metric, value float64) {
+	metrics.PushIfNotDone(s.c.mi.vu.Context(), s.samples, metrics.Sample{
+		TimeSeries: metrics.TimeSeries{Metric: metric, Tags: s.tagsAndMeta.Tags},
+		Time:       time.Now(),
+		Metadata:   s.tagsAndMeta.Metadata,
+		Value:      value,
+	})
+}
+
+func (s *Subscription) ended() {
+	s.mx.Lock()
+	s.completed = true
+	s.mx.Unlock()
+}
+
+// closed stops the subscription, after its connection was closed, or after
+// the error, which is delivered to the error handler, or which fails the
+// iteration, if there isn't one.
+func (s *Subscription) closed(err error) {
+	s.mx.Lock()
+	if s.closing || s.completed {
+		err = nil
+	}
+	s.closing = true
+	s.mx.Unlock()
+	s.stop()
+	s.close()
+
+	if err != nil && s.c.mi.vu.Context().Err() == nil {
+		if errors.Is(err, net.ErrClosed) || websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+			err = errors.New("the server closed the connection, without completing the subscription")
+		}
+		s.tq.Queue(func() error {
+			if s.onError == nil {
+				return fmt.Errorf("the GraphQL subscription failed: %w", err)
+			}
+			_, lerr := s.onError(sobek.Undefined(), s.c.mi.vu.Runtime().NewGoError(err))
+			return lerr
+		})
+	}
+	s.tq.Close()
+}
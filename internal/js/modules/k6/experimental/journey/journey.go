@@ -0,0 +1,313 @@
+package journey
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/grafana/sobek"
+
+	"go.k6.io/k6/js/common"
+	"go.k6.io/k6/metrics"
+)
+
+const (
+	// endState is the name of the state, which ends a journey.
+	endState = "end"
+
+	defaultJourneyName = "default"
+	defaultMaxSteps    = 100
+
+	// the tags, which the metrics of the exec functions of the states get
+	journeyTag      = "journey"
+	journeyStateTag = "journey_state"
+)
+
+// transition is a weighted transition to a state.
+type transition struct {
+	to     string
+	weight float64
+}
+
+// state is a state of a journey, whose exec function runs when the journey
+// gets to it.
+type state struct {
+	name string
+	exec sobek.Callable
+	next []transition
+}
+
+// Journey is a weighted random walk through its states, from the start state
+// until the end one, or until it has gone through the maximum steps.
+type Journey struct {
+	mi       *ModuleInstance
+	name     string
+	start    []transition
+	maxSteps int
+	states   map[string]*state
+}
+
+// Result is the result of a run of a journey.
+type Result struct {
+	// Path is the states, which the journey went through, in order.
+	Path []string `js:"path"`
+	// Completed is whether the journey got to the end state, instead of
+	// stopping after the maximum steps.
+	Completed bool `js:"completed"`
+}
+
+func newJourney(rt *sobek.Runtime, mi *ModuleInstance, def *sobek.Object) (*Journey, error) {
+	j := &Journey{mi: mi, name: defaultJourneyName, maxSteps: defaultMaxSteps, states: make(map[string]*state)}
+
+	if name := def.Get("name"); !common.IsNullish(name) {
+		j.name = name.String()
+	}
+	if maxSteps := def.Get("maxSteps"); !common.IsNullish(maxSteps) {
+		j.maxSteps = int(maxSteps.ToInteger())
+		if j.maxSteps < 1 {
+			return nil, fmt.Errorf("maxSteps must be at least 1, but it was %d", j.maxSteps)
+		}
+	}
+
+	states := def.Get("states")
+	if common.IsNullish(states) {
+		return nil, errors.New("the states are required")
+	}
+	statesObj := states.ToObject(rt)
+	names := statesObj.Keys()
+	if len(names) == 0 {
+		return nil, errors.New("the states are required")
+	}
+	for _, name := range names {
+		if name == endState {
+			return nil, fmt.Errorf("the state %q is reserved for the end of the journey", endState)
+		}
+		s, err := parseState(rt, name, statesObj.Get(name))
+		if err != nil {
+			return nil, fmt.Errorf("state %q: %w", name, err)
+		}
+		j.states[name] = s
+	}
+
+	start := def.Get("start")
+	if common.IsNullish(start) {
+		return nil, errors.New("the start state is required")
+	}
+	var err error
+	if j.start, err = parseTransitions(rt, start); err != nil {
+		return nil, fmt.Errorf("start: %w", err)
+	}
+	if err = j.validateTransitions("start", j.start); err != nil {
+		return nil, err
+	}
+	for _, name := range names {
+		if err = j.validateTransitions(fmt.Sprintf("state %q", name), j.states[name].next); err != nil {
+			return nil, err
+		}
+	}
+	return j, nil
+}
+
+// parseState parses a state, which is either its exec function, or an object
+// with the exec function and the next states.
+func parseState(rt *sobek.Runtime, name string, v sobek.Value) (*state, error) {
+	s := &state{name: name}
+	exec := v
+	if _, isFunc := sobek.AssertFunction(v); !isFunc && !common.IsNullish(v) {
+		obj := v.ToObject(rt)
+		exec = obj.Get("exec")
+		if next := obj.Get("next"); !common.IsNullish(next) {
+			var err error
+			if s.next, err = parseTransitions(rt, next); err != nil {
+				return nil, fmt.Errorf("next: %w", err)
+			}
+		}
+	}
+	if common.IsNullish(exec) {
+		return nil, errors.New("the exec function is required")
+	}
+	fn, isFunc := sobek.AssertFunction(exec)
+	if !isFunc {
+		return nil, errors.New("exec must be a function")
+	}
+	if common.IsAsyncFunction(rt, exec) {
+		return nil, errors.New("exec doesn't support async functions")
+	}
+	s.exec = fn
+	return s, nil
+}
+
+// parseTransitions parses a state name, an array of state names with the
+// same weights, or an object of the state names and their weights.
+func parseTransitions(rt *sobek.Runtime, v sobek.Value) ([]transition, error) {
+	switch exported := v.Export().(type) {
+	case string:
+		return []transition{{to: exported, weight: 1}}, nil
+	case []any:
+		transitions := make([]transition, len(exported))
+		for i, to := range exported {
+			name, ok := to.(string)
+			if !ok {
+				return nil, fmt.Errorf("the state names must be strings, but it was %v", to)
+			}
+			transitions[i] = transition{to: name, weight: 1}
+		}
+		return transitions, nil
+	case map[string]any:
+		obj := v.ToObject(rt)
+		keys := obj.Keys()
+		transitions := make([]transition, len(keys))
+		for i, to := range keys {
+			weight := obj.Get(to).ToFloat()
+			if math.IsNaN(weight) || math.IsInf(weight, 0) || weight < 0 {
+				return nil, fmt.Errorf("the weight of %q must be a non-negative number", to)
+			}
+			transitions[i] = transition{to: to, weight: weight}
+		}
+		return transitions, nil
+	default:
+		return nil, fmt.Errorf("invalid transitions %v", v)
+	}
+}
+
+func (j *Journey) validateTransitions(from string, transitions []transition) error {
+	total := 0.0
+	for _, t := range transitions {
+		if _, ok := j.states[t.to]; !ok && t.to != endState {
+			return fmt.Errorf("%s goes to the state %q, which doesn't exist", from, t.to)
+		}
+		total += t.weight
+	}
+	if len(transitions) > 0 && total == 0 {
+		return fmt.Errorf("the weights of the transitions of %s can't all be 0", from)
+	}
+	return nil
+}
+
+// pick picks one of the transitions randomly, by their weights, with the
+// Math.random of the runtime, so the journeys are reproducible with a seed.
+func (j *Journey) pick(random sobek.Callable, transitions []transition) (string, error) {
+	total := 0.0
+	for _, t := range transitions {
+		total += t.weight
+	}
+	r, err := random(sobek.Undefined())
+	if err != nil {
+		return "", err
+	}
+	point := r.ToFloat() * total
+	for _, t := range transitions {
+		if t.weight == 0 {
+			continue
+		}
+		if point < t.weight {
+			return t.to, nil
+		}
+		point -= t.weight
+	}
+	// rounding can leave the point past the last weight
+	for i := len(transitions) - 1; i >= 0; i-- {
+		if transitions[i].weight > 0 {
+			return transitions[i].to, nil
+		}
+	}
+	return endState, nil
+}
+
+// Run runs the journey, calling the exec functions of the states with the
+// data, until the journey gets to the end state. An exec function can return
+// the name of the next state, instead of letting the journey pick it.
+func (j *Journey) Run(data sobek.Value) (*Result, error) {
+	vuState := j.mi.vu.State()
+	if vuState == nil {
+		return nil, errors.New("running a journey in the init context is not supported")
+	}
+	rt := j.mi.vu.Runtime()
+	random, ok := sobek.AssertFunction(rt.Get("Math").ToObject(rt).Get("random"))
+	if !ok {
+		return nil, errors.New("the runtime doesn't have Math.random")
+	}
+	if data == nil {
+		data = sobek.Undefined()
+	}
+
+	current, err := j.pick(random, j.start)
+	if err != nil {
+		return nil, err
+	}
+	result := &Result{Path: []string{}}
+	startTime := time.Now()
+	for current != endState && len(result.Path) < j.maxSteps {
+		s := j.states[current]
+		result.Path = append(result.Path, current)
+
+		ret, err := j.exec(s, data)
+		if err != nil {
+			return result, err
+		}
+
+		next := endState
+		if name, isString := ret.Export().(string); isString && !common.IsNullish(ret) {
+			if _, exists := j.states[name]; !exists && name != endState {
+				return result, fmt.Errorf("the state %q returned the state %q, which doesn't exist", current, name)
+			}
+			next = name
+		} else if len(s.next) > 0 {
+			if next, err = j.pick(random, s.next); err != nil {
+				return result, err
+			}
+		}
+		j.push(j.mi.metrics.Transitions, 1, map[string]string{"from": current, "to": next})
+		current = next
+	}
+	result.Completed = current == endState
+
+	j.push(j.mi.metrics.Duration, metrics.D(time.Since(startTime)), nil)
+	j.push(j.mi.metrics.Steps, float64(len(result.Path)), nil)
+	return result, nil
+}
+
+// exec calls the exec function of the state, with the tags of the journey and
+// the state on the metrics of the requests, which it makes.
+func (j *Journey) exec(s *state, data sobek.Value) (sobek.Value, error) {
+	vuState := j.mi.vu.State()
+	oldTags := vuState.Tags.GetCurrentValues().Tags
+	oldJourney, hadJourney := oldTags.Get(journeyTag)
+	oldState, hadState := oldTags.Get(journeyStateTag)
+	vuState.Tags.Modify(func(tagsAndMeta *metrics.TagsAndMeta) {
+		tagsAndMeta.SetTag(journeyTag, j.name)
+		tagsAndMeta.SetTag(journeyStateTag, s.name)
+	})
+	defer vuState.Tags.Modify(func(tagsAndMeta *metrics.TagsAndMeta) {
+		restoreTag(tagsAndMeta, journeyTag, oldJourney, hadJourney)
+		restoreTag(tagsAndMeta, journeyStateTag, oldState, hadState)
+	})
+
+	startTime := time.Now()
+	ret, err := s.exec(sobek.Undefined(), data)
+	j.push(j.mi.metrics.StateDuration, metrics.D(time.Since(startTime)), map[string]string{journeyStateTag: s.name})
+	return ret, err
+}
+
+func restoreTag(tagsAndMeta *metrics.TagsAndMeta, key, value string, had bool) {
+	if had {
+		tagsAndMeta.SetTag(key, value)
+	} else {
+		tagsAndMeta.DeleteTag(key)
+	}
+}
+
+// push pushes a sample of the metric with the tags of the VU, the journey's
+// name and the extra tags.
+func (j *Journey) push(metric *metrics.Metric, value float64, extraTags map[string]string) {
+	vuState := j.mi.vu.State()
+	tagsAndMeta := vuState.Tags.GetCurrentValues()
+	tags := tagsAndMeta.Tags.With(journeyTag, j.name).WithTagsFromMap(extraTags)
+	metrics.PushIfNotDone(j.mi.vu.Context(), vuState.Samples, metrics.Sample{
+		TimeSeries: metrics.TimeSeries{Metric: metric, Tags: tags},
+		Time:       time.Now(),
+		Value:      value,
+		Metadata:   tagsAndMeta.Metadata,
+	})
+}
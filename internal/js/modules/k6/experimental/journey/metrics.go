@@ -0,0 +1,41 @@
+package journey
+
+import "go.k6.io/k6/metrics"
+
+// instanceMetrics contains the metrics of the journeys.
+type instanceMetrics struct {
+	// Transitions counts the transitions between the states, tagged with
+	// their from and to states.
+	Transitions *metrics.Metric
+	// StateDuration is how long the exec functions of the states took.
+	StateDuration *metrics.Metric
+	// Duration is how long the journeys took, from their start state until
+	// their end.
+	Duration *metrics.Metric
+	// Steps is how many states the journeys went through.
+	Steps *metrics.Metric
+}
+
+// registerMetrics registers and returns the metrics in the provided registry
+func registerMetrics(registry *metrics.Registry) (*instanceMetrics, error) {
+	var err error
+	m := &instanceMetrics{}
+
+	if m.Transitions, err = registry.NewMetric("journey_transitions", metrics.Counter); err != nil {
+		return nil, err
+	}
+
+	if m.StateDuration, err = registry.NewMetric("journey_state_duration", metrics.Trend, metrics.Time); err != nil {
+		return nil, err
+	}
+
+	if m.Duration, err = registry.NewMetric("journey_duration", metrics.Trend, metrics.Time); err != nil {
+		return nil, err
+	}
+
+	if m.Steps, err = registry.NewMetric("journey_steps", metrics.Trend); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
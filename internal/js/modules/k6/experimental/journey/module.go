@@ -0,0 +1,70 @@
+// Package journey runs user journeys, which are weighted random walks through
+// states, like the pages of a site, so the tests can mix realistic navigation
+// patterns without writing their own state machines.
+package journey
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/grafana/sobek"
+
+	"go.k6.io/k6/js/common"
+	"go.k6.io/k6/js/modules"
+)
+
+type (
+	// RootModule is the global module instance that will create instances of our
+	// module for each VU.
+	RootModule struct{}
+
+	// ModuleInstance represents an instance of the journey module for a single VU.
+	ModuleInstance struct {
+		vu      modules.VU
+		metrics *instanceMetrics
+	}
+)
+
+var (
+	_ modules.Module   = &RootModule{}
+	_ modules.Instance = &ModuleInstance{}
+)
+
+// New returns a pointer to a new [RootModule] instance.
+func New() *RootModule {
+	return &RootModule{}
+}
+
+// NewModuleInstance implements the modules.Module interface and returns a new
+// instance of our module for the given VU.
+func (*RootModule) NewModuleInstance(vu modules.VU) modules.Instance {
+	m, err := registerMetrics(vu.InitEnv().Registry)
+	if err != nil {
+		common.Throw(vu.Runtime(), fmt.Errorf("failed to register the journey module metrics: %w", err))
+	}
+	return &ModuleInstance{vu: vu, metrics: m}
+}
+
+// Exports implements the modules.Module interface and returns the exports of
+// our module.
+func (mi *ModuleInstance) Exports() modules.Exports {
+	return modules.Exports{
+		Named: map[string]any{
+			"journey": mi.Journey,
+		},
+	}
+}
+
+// Journey returns the journey with the definition. It's usually created once
+// in the init context and run in every iteration.
+func (mi *ModuleInstance) Journey(definition sobek.Value) *Journey {
+	rt := mi.vu.Runtime()
+	if common.IsNullish(definition) {
+		common.Throw(rt, errors.New("journey() requires a definition"))
+	}
+	j, err := newJourney(rt, mi, definition.ToObject(rt))
+	if err != nil {
+		common.Throw(rt, fmt.Errorf("invalid journey: %w", err))
+	}
+	return j
+}
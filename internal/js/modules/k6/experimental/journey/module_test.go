@@ -0,0 +1,159 @@
+package journey
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/js/modulestest"
+	"go.k6.io/k6/metrics"
+)
+
+func newTestRuntime(t *testing.T, initScript string) (*modulestest.Runtime, chan metrics.SampleContainer, error) {
+	t.Helper()
+
+	runtime := modulestest.NewRuntime(t)
+	err := runtime.SetupModule("k6/experimental/journey", New(),
+		`const { journey } = require("k6/experimental/journey");`+initScript)
+
+	state, samples := modulestest.NewVUState(t, 100)
+	state.Tags.Modify(func(tagsAndMeta *metrics.TagsAndMeta) { tagsAndMeta.SetTag("scenario", "default") })
+	runtime.MoveToVUContext(state)
+	return runtime, samples, err
+}
+
+func TestJourneyRun(t *testing.T) {
+	t.Parallel()
+
+	runtime, samples, err := newTestRuntime(t, `
+		const visits = [];
+		const shop = journey({
+			name: "shop",
+			start: { home: 3, search: 1 },
+			states: {
+				home: { exec: (data) => { visits.push("home:" + data.user); }, next: { browse: 1, search: 1 } },
+				search: { exec: () => { visits.push("search"); }, next: ["browse"] },
+				browse: {
+					exec: () => { visits.push("browse"); return visits.length < 4 ? "home" : undefined; },
+					next: { cart: 0, end: 1 },
+				},
+				cart: () => { visits.push("cart"); },
+			},
+		});
+	`)
+	require.NoError(t, err)
+
+	_, err = runtime.RunOnEventLoop(`
+		// the random points pick home from the start, browse from home, search
+		// from the second home, browse from search and the end from browse
+		const points = [0.5, 0.2, 0.9, 0.1, 0.3];
+		Math.random = () => points.shift();
+		const result = shop.run({ user: "alice" });
+		globalThis.got = { visits, path: result.path, completed: result.completed };
+	`)
+	require.NoError(t, err)
+
+	var got struct {
+		Visits    []string
+		Path      []string
+		Completed bool
+	}
+	rt := runtime.VU.Runtime()
+	require.NoError(t, rt.ExportTo(rt.Get("got"), &got))
+	assert.Equal(t, []string{"home:alice", "browse", "home:alice", "search", "browse"}, got.Visits)
+	assert.Equal(t, []string{"home", "browse", "home", "search", "browse"}, got.Path)
+	assert.True(t, got.Completed)
+
+	transitions := map[string]float64{}
+	var stateDurations, durations int
+	for _, container := range metrics.GetBufferedSamples(samples) {
+		for _, sample := range container.GetSamples() {
+			tags := sample.Tags.Map()
+			assert.Equal(t, "shop", tags["journey"])
+			assert.Equal(t, "default", tags["scenario"])
+			switch sample.Metric.Name {
+			case "journey_transitions":
+				transitions[tags["from"]+">"+tags["to"]] += sample.Value
+			case "journey_state_duration":
+				stateDurations++
+				assert.NotEmpty(t, tags["journey_state"])
+			case "journey_duration":
+				durations++
+			case "journey_steps":
+				assert.Equal(t, 5.0, sample.Value)
+			}
+		}
+	}
+	assert.Equal(t, map[string]float64{
+		"home>browse": 1, "browse>home": 1, "home>search": 1, "search>browse": 1, "browse>end": 1,
+	}, transitions)
+	assert.Equal(t, 5, stateDurations)
+	assert.Equal(t, 1, durations)
+
+	// the tags of the journey are removed after it
+	_, hasJourneyTag := runtime.VU.State().Tags.GetCurrentValues().Tags.Get("journey")
+	assert.False(t, hasJourneyTag)
+}
+
+func TestJourneyMaxSteps(t *testing.T) {
+	t.Parallel()
+
+	runtime, _, err := newTestRuntime(t, `
+		const loop = journey({ start: "a", maxSteps: 3, states: { a: { exec: () => {}, next: "b" }, b: () => "a" } });
+	`)
+	require.NoError(t, err)
+
+	v, err := runtime.RunOnEventLoop(`
+		const result = loop.run();
+		result.path.join(",") + " " + result.completed;
+	`)
+	require.NoError(t, err)
+	assert.Equal(t, "a,b,a false", v.String())
+}
+
+func TestJourneyInvalid(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		definition string
+		err        string
+	}{
+		"no states": {
+			definition: `{ start: "a" }`,
+			err:        "invalid journey: the states are required",
+		},
+		"no start": {
+			definition: `{ states: { a: () => {} } }`,
+			err:        "invalid journey: the start state is required",
+		},
+		"unknown state": {
+			definition: `{ start: "a", states: { a: { exec: () => {}, next: { b: 1 } } } }`,
+			err:        `invalid journey: state "a" goes to the state "b", which doesn't exist`,
+		},
+		"reserved state": {
+			definition: `{ start: "end", states: { end: () => {} } }`,
+			err:        `invalid journey: the state "end" is reserved for the end of the journey`,
+		},
+		"negative weight": {
+			definition: `{ start: { a: -1 }, states: { a: () => {} } }`,
+			err:        `invalid journey: start: the weight of "a" must be a non-negative number`,
+		},
+		"zero weights": {
+			definition: `{ start: { a: 0 }, states: { a: () => {} } }`,
+			err:        `invalid journey: the weights of the transitions of start can't all be 0`,
+		},
+		"async exec": {
+			definition: `{ start: "a", states: { a: async () => {} } }`,
+			err:        `invalid journey: state "a": exec doesn't support async functions`,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			_, _, err := newTestRuntime(t, "journey("+tc.definition+");")
+			assert.ErrorContains(t, err, tc.err)
+		})
+	}
+}
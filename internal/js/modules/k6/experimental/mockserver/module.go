@@ -0,0 +1,212 @@
+// Package mockserver provides an HTTP server with canned, templated and echoed
+// responses, and fault injection, which runs inside the k6 process, so the
+// tests of proxies and gateways are self-contained, with k6 being both their
+// client and their origin.
+package mockserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/grafana/sobek"
+
+	"go.k6.io/k6/internal/event"
+	"go.k6.io/k6/js/common"
+	"go.k6.io/k6/js/modules"
+)
+
+const (
+	defaultServerName = "default"
+	defaultAddress    = "127.0.0.1:0"
+)
+
+type (
+	// RootModule is the global module instance that will create instances of our
+	// module for each VU.
+	RootModule struct {
+		// servers are the listening servers by their names, which are shared by
+		// the VUs, so the ones started in setup() keep serving until the end
+		mx      sync.Mutex
+		servers map[string]*server
+	}
+
+	// ModuleInstance represents an instance of the mockserver module for a single VU.
+	ModuleInstance struct {
+		vu   modules.VU
+		root *RootModule
+	}
+)
+
+var (
+	_ modules.Module   = &RootModule{}
+	_ modules.Instance = &ModuleInstance{}
+)
+
+// New returns a pointer to a new [RootModule] instance.
+func New() *RootModule {
+	return &RootModule{servers: make(map[string]*server)}
+}
+
+// NewModuleInstance implements the modules.Module interface and returns a new
+// instance of our module for the given VU.
+func (r *RootModule) NewModuleInstance(vu modules.VU) modules.Instance {
+	return &ModuleInstance{vu: vu, root: r}
+}
+
+// Exports implements the modules.Module interface and returns the exports of
+// our module.
+func (mi *ModuleInstance) Exports() modules.Exports {
+	return modules.Exports{
+		Named: map[string]any{
+			"start": mi.Start,
+		},
+	}
+}
+
+// serverOptions are the options of a mock server. Its routes are by the
+// patterns of http.ServeMux, like "GET /users/{id}", and without any, it
+// echoes all the requests.
+type serverOptions struct {
+	Name    string            `json:"name"`
+	Address string            `json:"address"`
+	Routes  map[string]*route `json:"routes"`
+}
+
+type server struct {
+	name, address string
+	close         func()
+	// the JSON of the options it was started with
+	options []byte
+}
+
+func parseServerOptions(rt *sobek.Runtime, options sobek.Value) (serverOptions, error) {
+	opts := serverOptions{Name: defaultServerName, Address: defaultAddress}
+	if !common.IsNullish(options) {
+		b, err := json.Marshal(options.ToObject(rt).Export())
+		if err != nil {
+			return opts, err
+		}
+		dec := json.NewDecoder(bytes.NewReader(b))
+		dec.DisallowUnknownFields()
+		if err = dec.Decode(&opts); err != nil {
+			return opts, fmt.Errorf("invalid mock server options: %w", err)
+		}
+	}
+	if len(opts.Routes) == 0 {
+		opts.Routes = map[string]*route{"/": {response: response{Echo: true}}}
+	}
+	for pattern, r := range opts.Routes {
+		if r == nil {
+			return opts, fmt.Errorf("the route %q has no response", pattern)
+		}
+		if err := r.validate(); err != nil {
+			return opts, fmt.Errorf("invalid route %q: %w", pattern, err)
+		}
+	}
+	return opts, nil
+}
+
+// Start starts the mock server with the name, or returns the one that's
+// already listening with it, if it was started with the same options. It's
+// closed when the test ends.
+func (mi *ModuleInstance) Start(options sobek.Value) *sobek.Object {
+	rt := mi.vu.Runtime()
+	if mi.vu.State() == nil {
+		common.Throw(rt, errors.New("starting a mock server in the init context is not supported, "+
+			"call start() in setup()"))
+	}
+	opts, err := parseServerOptions(rt, options)
+	if err != nil {
+		common.Throw(rt, err)
+	}
+	s, err := mi.root.start(mi.vu, opts)
+	if err != nil {
+		common.Throw(rt, err)
+	}
+
+	obj := rt.NewObject()
+	must(rt, obj.Set("name", s.name))
+	must(rt, obj.Set("address", s.address))
+	must(rt, obj.Set("url", "http://"+s.address))
+	return obj
+}
+
+func (r *RootModule) start(vu modules.VU, opts serverOptions) (*server, error) {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+
+	options, err := json.Marshal(opts)
+	if err != nil {
+		return nil, err
+	}
+	if s, ok := r.servers[opts.Name]; ok {
+		if !bytes.Equal(s.options, options) {
+			return nil, fmt.Errorf("the mock server %q is already started with other options, "+
+				"start the other one with another name", opts.Name)
+		}
+		return s, nil
+	}
+	mux, err := newServeMux(opts.Routes)
+	if err != nil {
+		return nil, err
+	}
+	listener, err := net.Listen("tcp", opts.Address)
+	if err != nil {
+		return nil, fmt.Errorf("the mock server %q can't listen: %w", opts.Name, err)
+	}
+	httpServer := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() { _ = httpServer.Serve(listener) }()
+	s := &server{
+		name:    opts.Name,
+		address: listener.Addr().String(),
+		close:   func() { _ = httpServer.Close() },
+		options: options,
+	}
+	r.servers[opts.Name] = s
+
+	events := vu.Events().Global
+	subID, ch := events.Subscribe(event.Exit)
+	go func() {
+		for evt := range ch {
+			r.mx.Lock()
+			delete(r.servers, s.name)
+			r.mx.Unlock()
+			s.close()
+			evt.Done()
+			events.Unsubscribe(subID)
+		}
+	}()
+	return s, nil
+}
+
+// newServeMux returns the mux of the routes, or the error of an invalid or a
+// conflicting pattern, for which http.ServeMux panics.
+func newServeMux(routes map[string]*route) (mux *http.ServeMux, err error) {
+	mux = http.NewServeMux()
+	for pattern, r := range routes {
+		func() {
+			defer func() {
+				if p := recover(); p != nil {
+					err = fmt.Errorf("invalid route %q: %v", pattern, p)
+				}
+			}()
+			mux.Handle(pattern, r)
+		}()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return mux, nil
+}
+
+func must(rt *sobek.Runtime, err error) {
+	if err != nil {
+		common.Throw(rt, err)
+	}
+}
@@ -0,0 +1,182 @@
+package mockserver
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/internal/event"
+	"go.k6.io/k6/internal/js/compiler"
+	"go.k6.io/k6/internal/lib/testutils"
+	"go.k6.io/k6/js/common"
+	"go.k6.io/k6/js/modulestest"
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/metrics"
+)
+
+func newTestRuntime(t *testing.T) (*modulestest.Runtime, *event.System) {
+	t.Helper()
+
+	runtime := modulestest.NewRuntime(t)
+	events := event.NewEventSystem(10, logrus.New())
+	runtime.VU.EventsField = common.Events{Global: events, Local: event.NewEventSystem(10, logrus.New())}
+	require.NoError(t, runtime.SetupModule("k6/experimental/mockserver", New(),
+		`const { start } = require("k6/experimental/mockserver");`))
+
+	t.Cleanup(func() {
+		require.NoError(t, events.Emit(&event.Event{Type: event.Exit})(context.Background()))
+	})
+
+	registry := metrics.NewRegistry()
+	runtime.MoveToVUContext(&lib.State{
+		Options: lib.Options{SystemTags: &metrics.DefaultSystemTagSet},
+		Logger:  testutils.NewLogger(t),
+		Tags:    lib.NewVUStateTags(registry.RootTagSet()),
+	})
+	return runtime, events
+}
+
+func startServer(t *testing.T, runtime *modulestest.Runtime, script string) string {
+	t.Helper()
+	v, err := runtime.VU.Runtime().RunString(script)
+	require.NoError(t, err)
+	return v.String()
+}
+
+func request(t *testing.T, method, url, body string) (int, http.Header, string) {
+	t.Helper()
+	req, err := http.NewRequest(method, url, strings.NewReader(body)) //nolint:noctx
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+	b, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp.StatusCode, resp.Header, string(b)
+}
+
+func TestMockServer(t *testing.T) {
+	t.Parallel()
+
+	t.Run("routes", func(t *testing.T) {
+		t.Parallel()
+		runtime, _ := newTestRuntime(t)
+		url := startServer(t, runtime, `start({ routes: {
+			"GET /health": { body: "ok", headers: { "X-Mock": "1" } },
+			"GET /users/{id}": { template: '{"id": "{{.PathValue "id"}}", "q": "{{.Query "q"}}"}' },
+			"POST /orders": { status: 201, json: { created: true } },
+			"/flaky": { responses: [{ status: 502 }, { status: 502 }, { body: "recovered" }] },
+		} }).url`)
+
+		status, header, body := request(t, http.MethodGet, url+"/health", "")
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, "1", header.Get("X-Mock"))
+		assert.Equal(t, "ok", body)
+
+		_, _, body = request(t, http.MethodGet, url+"/users/42?q=k6", "")
+		assert.JSONEq(t, `{"id": "42", "q": "k6"}`, body)
+
+		status, header, body = request(t, http.MethodPost, url+"/orders", "")
+		assert.Equal(t, http.StatusCreated, status)
+		assert.Equal(t, "application/json", header.Get("Content-Type"))
+		assert.JSONEq(t, `{"created": true}`, body)
+
+		status, _, _ = request(t, http.MethodPost, url+"/health", "")
+		assert.Equal(t, http.StatusMethodNotAllowed, status)
+		status, _, _ = request(t, http.MethodGet, url+"/missing", "")
+		assert.Equal(t, http.StatusNotFound, status)
+
+		var statuses []int
+		for range 4 {
+			status, _, _ = request(t, http.MethodGet, url+"/flaky", "")
+			statuses = append(statuses, status)
+		}
+		assert.Equal(t, []int{502, 502, 200, 200}, statuses)
+	})
+
+	t.Run("echo", func(t *testing.T) {
+		t.Parallel()
+		runtime, _ := newTestRuntime(t)
+		url := startServer(t, runtime, `start().url`)
+		require.Equal(t, url, startServer(t, runtime, `start().url`))
+		_, err := runtime.VU.Runtime().RunString(`start({ routes: { "/other": { body: "" } } })`)
+		require.ErrorContains(t, err, `the mock server "default" is already started with other options`)
+
+		_, _, body := request(t, http.MethodPut, url+"/anything?x=1", "payload")
+		var echoed struct {
+			Method, Path, Body string
+		}
+		require.NoError(t, json.Unmarshal([]byte(body), &echoed))
+		assert.Equal(t, http.MethodPut, echoed.Method)
+		assert.Equal(t, "/anything?x=1", echoed.Path)
+		assert.Equal(t, "payload", echoed.Body)
+	})
+
+	t.Run("faults", func(t *testing.T) {
+		t.Parallel()
+		runtime, _ := newTestRuntime(t)
+		url := startServer(t, runtime, `start({ name: "faults", routes: {
+			"/errors": { body: "ok", faults: { errorRate: 1, errorStatus: 500 } },
+			"/aborts": { body: "ok", faults: { abortRate: 1 } },
+			"/slow": { body: "ok", delay: "50ms", faults: { latency: 50 } },
+		} }).url`)
+
+		status, _, _ := request(t, http.MethodGet, url+"/errors", "")
+		assert.Equal(t, http.StatusInternalServerError, status)
+
+		_, err := http.Get(url + "/aborts") //nolint:noctx,bodyclose
+		require.Error(t, err)
+
+		start := time.Now()
+		_, _, body := request(t, http.MethodGet, url+"/slow", "")
+		assert.Equal(t, "ok", body)
+		assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+	})
+
+	t.Run("closed on exit", func(t *testing.T) {
+		t.Parallel()
+		runtime, events := newTestRuntime(t)
+		url := startServer(t, runtime, `start({ name: "exit" }).url`)
+
+		require.NoError(t, events.Emit(&event.Event{Type: event.Exit})(context.Background()))
+		_, err := http.Get(url) //nolint:noctx,bodyclose
+		require.Error(t, err)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		t.Parallel()
+		runtime, _ := newTestRuntime(t)
+
+		for script, msg := range map[string]string{
+			`start({ port: 80 })`: `unknown field "port"`,
+			`start({ routes: { "GET /a": { body: "a", echo: true } } })`:                  "a response can have only one of body, json, template or echo",
+			`start({ routes: { "/a": { status: 700 } } })`:                                "invalid status 700",
+			`start({ routes: { "/a": { body: "a", responses: [{}] } } })`:                 "a route can't have both a response and a list of responses",
+			`start({ routes: { "/a": { template: "{{.Foo" } } })`:                         "invalid template",
+			`start({ routes: { "/a": { faults: { errorRate: 0.6, abortRate: 0.6 } } } })`: "their sum can't exceed 1",
+			`start({ routes: { "GET /{x}": {}, "GET /{y}": {} } })`:                       "conflicts with pattern",
+		} {
+			_, err := runtime.VU.Runtime().RunString(script)
+			assert.ErrorContains(t, err, msg, script)
+		}
+	})
+}
+
+func TestStartInInitContext(t *testing.T) {
+	t.Parallel()
+
+	runtime := modulestest.NewRuntime(t)
+	err := runtime.SetupModuleSystem(
+		map[string]any{"k6/experimental/mockserver": New()}, nil, compiler.New(runtime.VU.InitEnv().Logger))
+	require.NoError(t, err)
+	_, err = runtime.VU.Runtime().RunString(`require("k6/experimental/mockserver").start()`)
+	require.ErrorContains(t, err, "starting a mock server in the init context is not supported")
+}
@@ -0,0 +1,229 @@
+package mockserver
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"text/template"
+	"time"
+
+	"go.k6.io/k6/lib/types"
+)
+
+// maxBodySize is the largest request body, which is read for the echoed and
+// the templated responses.
+const maxBodySize = 10 << 20
+
+// response is a canned response of a route. Its body is either the body, the
+// json value, the result of the template, or the echoed request.
+type response struct {
+	Status   int               `json:"status"`
+	Headers  map[string]string `json:"headers"`
+	Body     string            `json:"body"`
+	JSON     json.RawMessage   `json:"json"`
+	Template string            `json:"template"`
+	Echo     bool              `json:"echo"`
+	Delay    types.Duration    `json:"delay"`
+
+	template *template.Template
+}
+
+// faults are injected in the responses of a route, so the clients, proxies or
+// gateways can be tested with a misbehaving origin.
+type faults struct {
+	// Latency and Jitter delay all the responses, by the latency and a random
+	// part of the jitter.
+	Latency types.Duration `json:"latency"`
+	Jitter  types.Duration `json:"jitter"`
+	// ErrorRate is the fraction of the requests, which get the ErrorStatus
+	// response, 503 by default.
+	ErrorRate   float64 `json:"errorRate"`
+	ErrorStatus int     `json:"errorStatus"`
+	// AbortRate is the fraction of the requests, which connections are closed
+	// without a response.
+	AbortRate float64 `json:"abortRate"`
+}
+
+// route responds to the requests, which match its pattern. If it has a list of
+// responses, they're returned in turn, and the last one is repeated.
+type route struct {
+	response
+	Responses []*response `json:"responses"`
+	Faults    faults      `json:"faults"`
+
+	calls atomic.Int64
+}
+
+func (r *route) validate() error {
+	if len(r.Responses) > 0 && r.response.isSet() {
+		return errors.New("a route can't have both a response and a list of responses")
+	}
+	if len(r.Responses) == 0 {
+		r.Responses = []*response{&r.response}
+	}
+	for _, resp := range r.Responses {
+		if err := resp.validate(); err != nil {
+			return err
+		}
+	}
+
+	f := &r.Faults
+	if f.ErrorRate < 0 || f.AbortRate < 0 || f.ErrorRate+f.AbortRate > 1 {
+		return fmt.Errorf("the errorRate and the abortRate must be between 0 and 1, and their sum can't exceed 1, "+
+			"but they're %g and %g", f.ErrorRate, f.AbortRate)
+	}
+	if f.ErrorStatus == 0 {
+		f.ErrorStatus = http.StatusServiceUnavailable
+	}
+	if f.ErrorStatus < 100 || f.ErrorStatus > 599 {
+		return fmt.Errorf("invalid errorStatus %d", f.ErrorStatus)
+	}
+	if f.Latency < 0 || f.Jitter < 0 {
+		return errors.New("the latency and the jitter can't be negative")
+	}
+	return nil
+}
+
+func (resp *response) isSet() bool {
+	return resp.Status != 0 || len(resp.Headers) > 0 || resp.Body != "" || len(resp.JSON) > 0 ||
+		resp.Template != "" || resp.Echo || resp.Delay != 0
+}
+
+func (resp *response) validate() error {
+	bodies := 0
+	for _, set := range []bool{resp.Body != "", len(resp.JSON) > 0, resp.Template != "", resp.Echo} {
+		if set {
+			bodies++
+		}
+	}
+	if bodies > 1 {
+		return errors.New("a response can have only one of body, json, template or echo")
+	}
+	if resp.Status == 0 {
+		resp.Status = http.StatusOK
+	}
+	if resp.Status < 100 || resp.Status > 599 {
+		return fmt.Errorf("invalid status %d", resp.Status)
+	}
+	if resp.Delay < 0 {
+		return errors.New("the delay can't be negative")
+	}
+	if resp.Template != "" {
+		var err error
+		if resp.template, err = template.New("").Parse(resp.Template); err != nil {
+			return fmt.Errorf("invalid template: %w", err)
+		}
+	}
+	return nil
+}
+
+func (r *route) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	resp := r.Responses[min(int(r.calls.Add(1)), len(r.Responses))-1]
+
+	delay := time.Duration(r.Faults.Latency + resp.Delay)
+	if r.Faults.Jitter > 0 {
+		delay += time.Duration(rand.Int64N(int64(r.Faults.Jitter))) //nolint:gosec
+	}
+	if delay > 0 {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+			return
+		}
+	}
+
+	if r.Faults.AbortRate > 0 || r.Faults.ErrorRate > 0 {
+		roll := rand.Float64() //nolint:gosec
+		if roll < r.Faults.AbortRate {
+			panic(http.ErrAbortHandler) // it closes the connection without a response
+		}
+		if roll < r.Faults.AbortRate+r.Faults.ErrorRate {
+			http.Error(w, "simulated error", r.Faults.ErrorStatus)
+			return
+		}
+	}
+
+	body, contentType, err := resp.body(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if contentType != "" {
+		w.Header().Set("Content-Type", contentType)
+	}
+	for k, v := range resp.Headers {
+		w.Header().Set(k, v)
+	}
+	w.WriteHeader(resp.Status)
+	_, _ = w.Write(body)
+}
+
+// body returns the body of the response to the request, and its content type.
+func (resp *response) body(req *http.Request) ([]byte, string, error) {
+	switch {
+	case len(resp.JSON) > 0:
+		return resp.JSON, "application/json", nil
+	case resp.Echo:
+		reqBody, err := readBody(req)
+		if err != nil {
+			return nil, "", err
+		}
+		b, err := json.Marshal(map[string]any{
+			"method":  req.Method,
+			"path":    req.URL.RequestURI(),
+			"headers": req.Header,
+			"body":    reqBody,
+		})
+		return b, "application/json", err
+	case resp.template != nil:
+		reqBody, err := readBody(req)
+		if err != nil {
+			return nil, "", err
+		}
+		var b strings.Builder
+		err = resp.template.Execute(&b, templateData{req: req, Method: req.Method, Path: req.URL.Path, Body: reqBody})
+		return []byte(b.String()), "", err
+	default:
+		return []byte(resp.Body), "", nil
+	}
+}
+
+func readBody(req *http.Request) (string, error) {
+	b, err := io.ReadAll(io.LimitReader(req.Body, maxBodySize+1))
+	if err != nil {
+		return "", err
+	}
+	if len(b) > maxBodySize {
+		return "", errors.New("the request body is too large")
+	}
+	return string(b), nil
+}
+
+// templateData is the request in the templates of the responses, e.g.
+// `{"id": "{{.PathValue "id"}}", "q": "{{.Query "q"}}"}`.
+type templateData struct {
+	req                *http.Request
+	Method, Path, Body string
+}
+
+// PathValue returns the value of the wildcard of the route's pattern.
+func (d templateData) PathValue(name string) string {
+	return d.req.PathValue(name)
+}
+
+// Query returns the value of the query parameter.
+func (d templateData) Query(name string) string {
+	return d.req.URL.Query().Get(name)
+}
+
+// Header returns the value of the request header.
+func (d templateData) Header(name string) string {
+	return d.req.Header.Get(name)
+}
@@ -0,0 +1,713 @@
+package mqtt
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"net/url"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/grafana/sobek"
+	"github.com/mstoykov/k6-taskqueue-lib/taskqueue"
+
+	"go.k6.io/k6/js/common"
+	"go.k6.io/k6/lib/types"
+	"go.k6.io/k6/metrics"
+)
+
+const (
+	defaultKeepAlive = 60 * time.Second
+	defaultTimeout   = 10 * time.Second
+)
+
+// The events, which the listeners of the clients can be added for.
+const (
+	eventMessage = "message"
+	eventError   = "error"
+	eventClose   = "close"
+)
+
+// ErrNotConnected is returned by the methods of the clients, which need a
+// connection, before connect() or after close().
+var ErrNotConnected = errors.New("the MQTT client isn't connected")
+
+// clientOptions are the options of the Client constructor.
+type clientOptions struct {
+	clientID           string
+	username, password string
+	cleanSession       bool
+	keepAlive          time.Duration
+	// timeout is the timeout of connecting and of the acknowledgements
+	timeout time.Duration
+	tags    sobek.Value
+}
+
+func parseClientOptions(rt *sobek.Runtime, options sobek.Value) (clientOptions, error) {
+	opts := clientOptions{
+		clientID:     fmt.Sprintf("k6-%016x", rand.Uint64()), //nolint:gosec
+		cleanSession: true,
+		keepAlive:    defaultKeepAlive,
+		timeout:      defaultTimeout,
+	}
+	if common.IsNullish(options) {
+		return opts, nil
+	}
+	obj := options.ToObject(rt)
+	for _, k := range obj.Keys() {
+		v := obj.Get(k)
+		var err error
+		switch k {
+		case "clientId":
+			opts.clientID = v.String()
+		case "username":
+			opts.username = v.String()
+		case "password":
+			opts.password = v.String()
+		case "cleanSession":
+			opts.cleanSession = v.ToBoolean()
+		case "keepAlive":
+			opts.keepAlive, err = types.GetDurationValue(v.Export())
+			if err == nil && (opts.keepAlive < 0 || opts.keepAlive > 65535*time.Second) {
+				err = errors.New("it must be between 0 and 65535 seconds")
+			}
+		case "timeout":
+			opts.timeout, err = types.GetDurationValue(v.Export())
+			if err == nil && opts.timeout <= 0 {
+				err = errors.New("it must be positive")
+			}
+		case "tags":
+			opts.tags = v
+		default:
+			return opts, fmt.Errorf("unknown MQTT client option %q", k)
+		}
+		if err != nil {
+			return opts, fmt.Errorf("invalid %s value: %w", k, err)
+		}
+	}
+	if opts.clientID == "" && !opts.cleanSession {
+		return opts, errors.New("a clientId is required without a clean session")
+	}
+	return opts, nil
+}
+
+// Client is an MQTT 3.1.1 client. Connecting, publishing and subscribing block
+// the VU, like the HTTP requests, until they're acknowledged by the broker,
+// while the received messages are delivered to the listeners on the event
+// loop, which keeps running after a subscription until the client is closed.
+type Client struct {
+	mi   *ModuleInstance
+	opts clientOptions
+
+	conn *connection
+	// listeners are only accessed on the event loop
+	listeners map[string][]sobek.Callable
+
+	tagsAndMeta metrics.TagsAndMeta
+}
+
+// connection is the connection of a client to the broker, which is read by its
+// own goroutine.
+type connection struct {
+	client *Client
+	conn   net.Conn
+	// stop stops closing the connection, when the VU context is done
+	stop    func() bool
+	samples chan<- metrics.SampleContainer
+
+	writeMx   sync.Mutex
+	lastWrite time.Time
+
+	mx     sync.Mutex
+	lastID uint16
+	// pending are the requests, which are waiting for their acknowledgements
+	pending map[uint16]chan packet
+	// received are the messages with QoS 2, whose PUBREL wasn't received yet
+	received map[uint16]bool
+	// tq delivers the messages, since the client subscribed
+	tq      *taskqueue.TaskQueue
+	closing bool
+	err     error
+	done    chan struct{}
+}
+
+// Connect connects to the broker at the URL, e.g. mqtt://broker:1883, or
+// mqtts://broker:8883 with TLS, with the dialer and the TLS options of the VU.
+func (c *Client) Connect(brokerURL string) error {
+	state := c.mi.vu.State()
+	if state == nil {
+		return errors.New("the MQTT clients can't connect in the init context")
+	}
+	if c.conn != nil && !c.conn.isClosed() {
+		return errors.New("the MQTT client is already connected")
+	}
+	u, err := url.Parse(brokerURL)
+	if err != nil {
+		return fmt.Errorf("invalid broker URL: %w", err)
+	}
+	var useTLS bool
+	port := "1883"
+	switch u.Scheme {
+	case "mqtt", "tcp":
+	case "mqtts", "ssl", "tls":
+		useTLS, port = true, "8883"
+	default:
+		return fmt.Errorf("the broker URL must be mqtt:// or mqtts://, but it's %q", brokerURL)
+	}
+	if u.Port() != "" {
+		port = u.Port()
+	}
+	addr := net.JoinHostPort(u.Hostname(), port)
+
+	c.tagsAndMeta = state.Tags.GetCurrentValues()
+	c.tagsAndMeta.SetSystemTagOrMetaIfEnabled(state.Options.SystemTags, metrics.TagURL, u.Scheme+"://"+addr)
+	if !common.IsNullish(c.opts.tags) {
+		if err = common.ApplyCustomUserTags(c.mi.vu.Runtime(), &c.tagsAndMeta, c.opts.tags); err != nil {
+			return fmt.Errorf("invalid tags: %w", err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(c.mi.vu.Context(), c.opts.timeout)
+	defer cancel()
+	start := time.Now()
+	netConn, err := state.Dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	if useTLS {
+		config := state.TLSConfig.Clone()
+		if config == nil {
+			config = &tls.Config{} //nolint:gosec // the VU's TLS options are used, when there are some
+		}
+		config.ServerName = u.Hostname()
+		tlsConn := tls.Client(netConn, config)
+		if err = tlsConn.HandshakeContext(ctx); err != nil {
+			_ = netConn.Close()
+			return fmt.Errorf("the TLS handshake failed: %w", err)
+		}
+		netConn = tlsConn
+	}
+	reader := bufio.NewReader(netConn)
+	if err = c.handshake(ctx, netConn, reader); err != nil {
+		_ = netConn.Close()
+		return err
+	}
+
+	conn := &connection{
+		client:    c,
+		conn:      netConn,
+		samples:   state.Samples,
+		lastWrite: time.Now(),
+		pending:   make(map[uint16]chan packet),
+		received:  make(map[uint16]bool),
+		done:      make(chan struct{}),
+	}
+	conn.stop = context.AfterFunc(c.mi.vu.Context(), func() { _ = netConn.Close() })
+	c.conn = conn
+	go conn.readLoop(reader)
+	if c.opts.keepAlive > 0 {
+		go conn.keepAlive(c.opts.keepAlive)
+	}
+	c.push(c.mi.metrics.ConnectDuration, c.tagsAndMeta, start, metrics.D(time.Since(start)))
+	return nil
+}
+
+// handshake sends CONNECT and waits for CONNACK.
+func (c *Client) handshake(ctx context.Context, conn net.Conn, reader *bufio.Reader) error {
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+		defer func() { _ = conn.SetDeadline(time.Time{}) }()
+	}
+	if err := writePacket(conn, connectPacket(c.opts, uint16(c.opts.keepAlive/time.Second))); err != nil {
+		return err
+	}
+	p, err := readPacket(reader)
+	if err != nil {
+		return fmt.Errorf("the broker didn't acknowledge the connection: %w", err)
+	}
+	if p.kind != packetConnAck || len(p.body) != 2 {
+		return fmt.Errorf("the broker responded to CONNECT with the packet type %d, instead of CONNACK", p.kind)
+	}
+	if code := p.body[1]; code != 0 {
+		if msg, ok := connackErrors[code]; ok {
+			return fmt.Errorf("the broker refused the connection: %s", msg)
+		}
+		return fmt.Errorf("the broker refused the connection with the return code %d", code)
+	}
+	return nil
+}
+
+// Publish publishes the payload, which is a string or an ArrayBuffer, to the
+// topic, and waits for its acknowledgement, with QoS 1 or 2.
+func (c *Client) Publish(topic string, payload sobek.Value, options sobek.Value) error {
+	if c.conn == nil {
+		return ErrNotConnected
+	}
+	if topic == "" {
+		return errors.New("publish() requires a topic")
+	}
+	var data []byte
+	if !common.IsNullish(payload) {
+		var err error
+		if data, err = common.ToBytes(payload.Export()); err != nil {
+			return err
+		}
+	}
+	m := message{topic: topic, payload: data}
+	if !common.IsNullish(options) {
+		obj := options.ToObject(c.mi.vu.Runtime())
+		for _, k := range obj.Keys() {
+			v := obj.Get(k)
+			switch k {
+			case "qos":
+				qos, err := parseQoS(v)
+				if err != nil {
+					return err
+				}
+				m.qos = qos
+			case "retain":
+				m.retain = v.ToBoolean()
+			default:
+				return fmt.Errorf("unknown publish option %q", k)
+			}
+		}
+	}
+
+	tagsAndMeta := c.tagsAndMeta
+	tagsAndMeta.SetTag("topic", topic)
+	start := time.Now()
+	var err error
+	if m.qos == 0 {
+		err = c.conn.write(publishPacket(m))
+	} else {
+		_, err = c.conn.request(func(id uint16) packet {
+			m.id = id
+			return publishPacket(m)
+		})
+		acknowledged := 0.0
+		if err == nil {
+			acknowledged = 1
+		}
+		c.push(c.mi.metrics.PublishAcknowledged, tagsAndMeta, start, acknowledged)
+	}
+	if err != nil {
+		return fmt.Errorf("publishing to %s failed: %w", topic, err)
+	}
+	end := time.Now()
+	c.push(c.mi.metrics.Publishes, tagsAndMeta, end, 1)
+	c.push(c.mi.metrics.PublishDuration, tagsAndMeta, end, metrics.D(end.Sub(start)))
+	return nil
+}
+
+// Subscribe subscribes to the topic or the array of topics, which can have
+// wildcards, and returns the QoS granted by the broker, or an array of them.
+func (c *Client) Subscribe(topics sobek.Value, options sobek.Value) (any, error) {
+	if c.conn == nil {
+		return nil, ErrNotConnected
+	}
+	list, single, err := c.topics(topics)
+	if err != nil {
+		return nil, err
+	}
+	var qos byte
+	if !common.IsNullish(options) {
+		obj := options.ToObject(c.mi.vu.Runtime())
+		for _, k := range obj.Keys() {
+			if k != "qos" {
+				return nil, fmt.Errorf("unknown subscribe option %q", k)
+			}
+			if qos, err = parseQoS(obj.Get(k)); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// the messages can be received as soon as the subscription is
+	// acknowledged, so they have to be delivered before it returns
+	created := c.conn.startDelivery(c.mi.vu.RegisterCallback)
+	p, err := c.conn.request(func(id uint16) packet { return subscribePacket(id, list, qos) })
+	if err == nil && len(p.body) != 2+len(list) {
+		err = errMalformedPacket
+	}
+	if err != nil {
+		if created {
+			c.conn.stopDelivery()
+		}
+		return nil, fmt.Errorf("subscribing to %v failed: %w", list, err)
+	}
+
+	granted := make([]int, len(list))
+	for i, code := range p.body[2:] {
+		if code == subscribeFailure {
+			return nil, fmt.Errorf("the broker rejected the subscription to %s", list[i])
+		}
+		granted[i] = int(code)
+	}
+	if single {
+		return granted[0], nil
+	}
+	return granted, nil
+}
+
+// Unsubscribe unsubscribes from the topic or the array of topics.
+func (c *Client) Unsubscribe(topics sobek.Value) error {
+	if c.conn == nil {
+		return ErrNotConnected
+	}
+	list, _, err := c.topics(topics)
+	if err != nil {
+		return err
+	}
+	if _, err = c.conn.request(func(id uint16) packet { return unsubscribePacket(id, list) }); err != nil {
+		return fmt.Errorf("unsubscribing from %v failed: %w", list, err)
+	}
+	return nil
+}
+
+// On adds a listener of the received messages, of the error, which closed the
+// connection, or of its closing.
+func (c *Client) On(event string, listener sobek.Value) error {
+	switch event {
+	case eventMessage, eventError, eventClose:
+	default:
+		return fmt.Errorf("unknown MQTT client event %q", event)
+	}
+	fn, ok := sobek.AssertFunction(listener)
+	if !ok {
+		return fmt.Errorf("the listener of the %s event must be a function", event)
+	}
+	c.listeners[event] = append(c.listeners[event], fn)
+	return nil
+}
+
+// Close disconnects from the broker.
+func (c *Client) Close() error {
+	conn := c.conn
+	if conn == nil {
+		return nil
+	}
+	c.conn = nil
+	conn.mx.Lock()
+	conn.closing = true
+	conn.mx.Unlock()
+	_ = conn.write(packet{kind: packetDisconnect})
+	conn.stop()
+	return conn.conn.Close()
+}
+
+func (c *Client) topics(value sobek.Value) ([]string, bool, error) {
+	if common.IsNullish(value) {
+		return nil, false, errors.New("a topic is required")
+	}
+	var list []string
+	topic, single := value.Export().(string)
+	if single {
+		list = []string{topic}
+	} else if err := c.mi.vu.Runtime().ExportTo(value, &list); err != nil {
+		return nil, false, fmt.Errorf("the topics must be a string or an array of them: %w", err)
+	}
+	if len(list) == 0 || slices.Contains(list, "") {
+		return nil, false, errors.New("the topics can't be empty")
+	}
+	return list, single, nil
+}
+
+func (c *Client) push(metric *metrics.Metric, tagsAndMeta metrics.TagsAndMeta, t time.Time, value float64) {
+	state := c.mi.vu.State()
+	if state == nil {
+		return
+	}
+	metrics.PushIfNotDone(c.mi.vu.Context(), state.Samples, metrics.Sample{
+		TimeSeries: metrics.TimeSeries{Metric: metric, Tags: tagsAndMeta.Tags},
+		Time:       t,
+		Metadata:   tagsAndMeta.Metadata,
+		Value:      value,
+	})
+}
+
+func parseQoS(v sobek.Value) (byte, error) {
+	qos := v.ToInteger()
+	if qos < 0 || qos > 2 {
+		return 0, fmt.Errorf("the QoS must be 0, 1 or 2, but it's %d", qos)
+	}
+	return byte(qos), nil
+}
+
+// write writes the packet, from the VU or from the reading goroutine.
+func (conn *connection) write(p packet) error {
+	conn.writeMx.Lock()
+	defer conn.writeMx.Unlock()
+	conn.lastWrite = time.Now()
+	return writePacket(conn.conn, p)
+}
+
+// request writes the packet with a new packet identifier, and waits for its
+// acknowledgement.
+func (conn *connection) request(build func(id uint16) packet) (packet, error) {
+	ack := make(chan packet, 1)
+	conn.mx.Lock()
+	if conn.err != nil {
+		conn.mx.Unlock()
+		return packet{}, conn.closedError()
+	}
+	for {
+		conn.lastID++
+		if _, ok := conn.pending[conn.lastID]; conn.lastID != 0 && !ok {
+			break
+		}
+	}
+	id := conn.lastID
+	conn.pending[id] = ack
+	conn.mx.Unlock()
+	forget := func() {
+		conn.mx.Lock()
+		delete(conn.pending, id)
+		conn.mx.Unlock()
+	}
+
+	if err := conn.write(build(id)); err != nil {
+		forget()
+		return packet{}, err
+	}
+	timeout := conn.client.opts.timeout
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case p := <-ack:
+		return p, nil
+	case <-conn.done:
+		return packet{}, conn.closedError()
+	case <-timer.C:
+		forget()
+		return packet{}, fmt.Errorf("the broker didn't acknowledge it in %s", timeout)
+	}
+}
+
+func (conn *connection) isClosed() bool {
+	select {
+	case <-conn.done:
+		return true
+	default:
+		return false
+	}
+}
+
+func (conn *connection) closedError() error {
+	conn.mx.Lock()
+	defer conn.mx.Unlock()
+	if conn.err == nil || errors.Is(conn.err, net.ErrClosed) {
+		return errors.New("the connection was closed")
+	}
+	return fmt.Errorf("the connection was closed: %w", conn.err)
+}
+
+// startDelivery starts delivering the received messages on the event loop,
+// and returns whether it wasn't started before.
+func (conn *connection) startDelivery(registerCallback func() func(func() error)) bool {
+	conn.mx.Lock()
+	defer conn.mx.Unlock()
+	if conn.tq != nil || conn.err != nil {
+		return false
+	}
+	conn.tq = taskqueue.New(registerCallback)
+	return true
+}
+
+func (conn *connection) stopDelivery() {
+	conn.mx.Lock()
+	tq := conn.tq
+	conn.tq = nil
+	conn.mx.Unlock()
+	if tq != nil {
+		tq.Close()
+	}
+}
+
+func (conn *connection) readLoop(reader *bufio.Reader) {
+	for {
+		p, err := readPacket(reader)
+		if err == nil {
+			err = conn.handle(p)
+		}
+		if err != nil {
+			conn.closed(err)
+			return
+		}
+	}
+}
+
+func (conn *connection) handle(p packet) error {
+	if p.kind == packetPublish {
+		return conn.handlePublish(p)
+	}
+	if p.kind == packetPingResp {
+		return nil
+	}
+	id, err := p.id()
+	if err != nil {
+		return err
+	}
+	switch p.kind {
+	case packetPubAck, packetPubComp, packetSubAck, packetUnsubAck:
+		conn.mx.Lock()
+		ack := conn.pending[id]
+		delete(conn.pending, id)
+		conn.mx.Unlock()
+		if ack != nil {
+			ack <- p
+		}
+		return nil
+	case packetPubRec:
+		return conn.write(idPacket(packetPubRel, id))
+	case packetPubRel:
+		conn.mx.Lock()
+		delete(conn.received, id)
+		conn.mx.Unlock()
+		return conn.write(idPacket(packetPubComp, id))
+	default:
+		return fmt.Errorf("unexpected MQTT packet type %d", p.kind)
+	}
+}
+
+// handlePublish delivers a received message, and acknowledges it, depending on
+// its QoS. The messages with QoS 2 are delivered only once, even if they're
+// sent again, until their PUBREL.
+func (conn *connection) handlePublish(p packet) error {
+	m, err := parsePublish(p)
+	if err != nil {
+		return err
+	}
+	switch m.qos {
+	case 0:
+		conn.deliver(m)
+		return nil
+	case 1:
+		conn.deliver(m)
+		return conn.write(idPacket(packetPubAck, m.id))
+	default:
+		conn.mx.Lock()
+		duplicate := conn.received[m.id]
+		conn.received[m.id] = true
+		conn.mx.Unlock()
+		if !duplicate {
+			conn.deliver(m)
+		}
+		return conn.write(idPacket(packetPubRec, m.id))
+	}
+}
+
+// deliver calls the message listeners with the message on the event loop. The
+// messages received before subscribing in this connection, e.g. of a
+// persistent session, are dropped.
+func (conn *connection) deliver(m message) {
+	conn.mx.Lock()
+	tq := conn.tq
+	conn.mx.Unlock()
+	if tq == nil {
+		return
+	}
+
+	c := conn.client
+	tagsAndMeta := c.tagsAndMeta
+	tagsAndMeta.SetTag("topic", m.topic)
+	metrics.PushIfNotDone(c.mi.vu.Context(), conn.samples, metrics.Sample{
+		TimeSeries: metrics.TimeSeries{Metric: c.mi.metrics.MessagesReceived, Tags: tagsAndMeta.Tags},
+		Time:       time.Now(),
+		Metadata:   tagsAndMeta.Metadata,
+		Value:      1,
+	})
+	tq.Queue(func() error {
+		rt := c.mi.vu.Runtime()
+		obj := rt.NewObject()
+		for k, v := range map[string]any{
+			"topic":   m.topic,
+			"payload": string(m.payload),
+			"data":    rt.NewArrayBuffer(m.payload),
+			"qos":     int(m.qos),
+			"retain":  m.retain,
+		} {
+			if err := obj.Set(k, v); err != nil {
+				return err
+			}
+		}
+		for _, listener := range c.listeners[eventMessage] {
+			if _, err := listener(sobek.Undefined(), obj); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// closed stops the connection, after it was closed, or after the error. The
+// error is delivered to the error listeners, or it fails the iteration, if
+// there are none, and the client is still subscribed.
+func (conn *connection) closed(err error) {
+	conn.mx.Lock()
+	if conn.closing {
+		err = nil
+	}
+	conn.err = err
+	if conn.err == nil {
+		conn.err = net.ErrClosed
+	}
+	tq := conn.tq
+	conn.tq = nil
+	conn.mx.Unlock()
+	close(conn.done)
+	_ = conn.conn.Close()
+	if tq == nil {
+		return
+	}
+
+	c := conn.client
+	tq.Queue(func() error {
+		rt := c.mi.vu.Runtime()
+		if err != nil && c.mi.vu.Context().Err() == nil {
+			listeners := c.listeners[eventError]
+			if len(listeners) == 0 {
+				return fmt.Errorf("the MQTT connection failed: %w", err)
+			}
+			for _, listener := range listeners {
+				if _, lerr := listener(sobek.Undefined(), rt.NewGoError(err)); lerr != nil {
+					return lerr
+				}
+			}
+		}
+		if c.conn == conn {
+			c.conn = nil
+		}
+		for _, listener := range c.listeners[eventClose] {
+			if _, lerr := listener(sobek.Undefined()); lerr != nil {
+				return lerr
+			}
+		}
+		return nil
+	})
+	tq.Close()
+}
+
+// keepAlive sends PINGREQ, when nothing else was sent for half of the keep
+// alive interval, so the broker doesn't close the idle connection.
+func (conn *connection) keepAlive(interval time.Duration) {
+	ticker := time.NewTicker(interval / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-conn.done:
+			return
+		case <-ticker.C:
+			conn.writeMx.Lock()
+			idle := time.Since(conn.lastWrite) >= interval/2
+			conn.writeMx.Unlock()
+			if idle {
+				_ = conn.write(packet{kind: packetPingReq})
+			}
+		}
+	}
+}
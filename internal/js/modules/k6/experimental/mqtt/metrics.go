@@ -0,0 +1,44 @@
+package mqtt
+
+import "go.k6.io/k6/metrics"
+
+// instanceMetrics contains the metrics of the MQTT clients.
+type instanceMetrics struct {
+	ConnectDuration *metrics.Metric
+	Publishes       *metrics.Metric
+	// PublishDuration is how long the messages took to be acknowledged, or to
+	// be written to the connection with QoS 0.
+	PublishDuration *metrics.Metric
+	// PublishAcknowledged is whether the messages with QoS 1 and 2 were
+	// acknowledged by the broker.
+	PublishAcknowledged *metrics.Metric
+	MessagesReceived    *metrics.Metric
+}
+
+// registerMetrics registers and returns the metrics in the provided registry
+func registerMetrics(registry *metrics.Registry) (*instanceMetrics, error) {
+	var err error
+	m := &instanceMetrics{}
+
+	if m.ConnectDuration, err = registry.NewMetric("mqtt_connect_duration", metrics.Trend, metrics.Time); err != nil {
+		return nil, err
+	}
+
+	if m.Publishes, err = registry.NewMetric("mqtt_publishes", metrics.Counter); err != nil {
+		return nil, err
+	}
+
+	if m.PublishDuration, err = registry.NewMetric("mqtt_publish_duration", metrics.Trend, metrics.Time); err != nil {
+		return nil, err
+	}
+
+	if m.PublishAcknowledged, err = registry.NewMetric("mqtt_publish_acknowledged", metrics.Rate); err != nil {
+		return nil, err
+	}
+
+	if m.MessagesReceived, err = registry.NewMetric("mqtt_messages_received", metrics.Counter); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
@@ -0,0 +1,64 @@
+// Package mqtt provides an MQTT 3.1.1 client, so IoT platforms and other
+// MQTT brokers can be load tested without an extension.
+package mqtt
+
+import (
+	"fmt"
+
+	"github.com/grafana/sobek"
+
+	"go.k6.io/k6/js/common"
+	"go.k6.io/k6/js/modules"
+)
+
+type (
+	// RootModule is the global module instance that will create instances of our
+	// module for each VU.
+	RootModule struct{}
+
+	// ModuleInstance represents an instance of the mqtt module for a single VU.
+	ModuleInstance struct {
+		vu      modules.VU
+		metrics *instanceMetrics
+	}
+)
+
+var (
+	_ modules.Module   = &RootModule{}
+	_ modules.Instance = &ModuleInstance{}
+)
+
+// New returns a pointer to a new [RootModule] instance.
+func New() *RootModule {
+	return &RootModule{}
+}
+
+// NewModuleInstance implements the modules.Module interface and returns a new
+// instance of our module for the given VU.
+func (*RootModule) NewModuleInstance(vu modules.VU) modules.Instance {
+	m, err := registerMetrics(vu.InitEnv().Registry)
+	if err != nil {
+		common.Throw(vu.Runtime(), fmt.Errorf("failed to register the mqtt module metrics: %w", err))
+	}
+	return &ModuleInstance{vu: vu, metrics: m}
+}
+
+// Exports implements the modules.Module interface and returns the exports of
+// our module.
+func (mi *ModuleInstance) Exports() modules.Exports {
+	return modules.Exports{
+		Named: map[string]any{
+			"Client": mi.NewClient,
+		},
+	}
+}
+
+// NewClient is the JS constructor of the Client.
+func (mi *ModuleInstance) NewClient(call sobek.ConstructorCall) *sobek.Object {
+	rt := mi.vu.Runtime()
+	opts, err := parseClientOptions(rt, call.Argument(0))
+	if err != nil {
+		common.Throw(rt, err)
+	}
+	return rt.ToValue(&Client{mi: mi, opts: opts, listeners: make(map[string][]sobek.Callable)}).ToObject(rt)
+}
@@ -0,0 +1,230 @@
+package mqtt
+
+import (
+	"bufio"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/js/modulestest"
+	"go.k6.io/k6/metrics"
+)
+
+func newTestRuntime(t *testing.T) (*modulestest.Runtime, chan metrics.SampleContainer) {
+	t.Helper()
+
+	runtime := modulestest.NewRuntime(t)
+	require.NoError(t, runtime.SetupModule("k6/experimental/mqtt", New(),
+		`const { Client } = require("k6/experimental/mqtt");`))
+
+	state, samples := modulestest.NewVUState(t, 100)
+	state.Dialer = modulestest.NewLocalDialer()
+	runtime.MoveToVUContext(state)
+	return runtime, samples
+}
+
+// testBroker is a broker for the tests, which sends the published messages
+// back to the client, if it subscribed to them. It requires the secret
+// password, and it closes the connections, which publish to "disconnect".
+type testBroker struct {
+	topics []string
+	qos    byte
+	lastID uint16
+}
+
+func startTestBroker(t *testing.T) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer func() { _ = conn.Close() }()
+				(&testBroker{}).serve(conn)
+			}()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+func (b *testBroker) serve(conn net.Conn) {
+	r := bufio.NewReader(conn)
+	p, err := readPacket(r)
+	if err != nil || p.kind != packetConnect {
+		return
+	}
+	code := byte(0)
+	if !strings.HasSuffix(string(p.body), "secret") {
+		code = 5
+	}
+	if writePacket(conn, packet{kind: packetConnAck, body: []byte{0, code}}) != nil || code != 0 {
+		return
+	}
+
+	for {
+		p, err := readPacket(r)
+		if err != nil {
+			return
+		}
+		var reply []packet
+		switch p.kind {
+		case packetSubscribe:
+			id, _ := p.id()
+			rest := p.body[2:]
+			codes := []byte{}
+			for len(rest) > 0 {
+				var topic string
+				if topic, rest, err = readString(rest); err != nil || len(rest) == 0 {
+					return
+				}
+				b.topics, b.qos, rest = append(b.topics, topic), rest[0], rest[1:]
+				codes = append(codes, b.qos)
+			}
+			reply = append(reply, packet{kind: packetSubAck, body: append(idPacket(0, id).body, codes...)})
+		case packetPublish:
+			m, err := parsePublish(p)
+			if err != nil || m.topic == "disconnect" {
+				return
+			}
+			switch m.qos {
+			case 1:
+				reply = append(reply, idPacket(packetPubAck, m.id))
+			case 2:
+				reply = append(reply, idPacket(packetPubRec, m.id))
+			}
+			if b.subscribed(m.topic) {
+				m.qos = min(m.qos, b.qos)
+				b.lastID++
+				m.id = b.lastID
+				reply = append(reply, publishPacket(m))
+			}
+		case packetPubRel:
+			id, _ := p.id()
+			reply = append(reply, idPacket(packetPubComp, id))
+		case packetPubRec:
+			id, _ := p.id()
+			reply = append(reply, idPacket(packetPubRel, id))
+		case packetDisconnect:
+			return
+		}
+		for _, p := range reply {
+			if writePacket(conn, p) != nil {
+				return
+			}
+		}
+	}
+}
+
+// subscribed matches the topic with the filters, which can have "+" wildcards.
+func (b *testBroker) subscribed(topic string) bool {
+	levels := strings.Split(topic, "/")
+	for _, filter := range b.topics {
+		parts := strings.Split(filter, "/")
+		if len(parts) != len(levels) {
+			continue
+		}
+		matches := true
+		for i, part := range parts {
+			matches = matches && (part == "+" || part == levels[i])
+		}
+		if matches {
+			return true
+		}
+	}
+	return false
+}
+
+func TestClient(t *testing.T) {
+	t.Parallel()
+
+	t.Run("publish and subscribe", func(t *testing.T) {
+		t.Parallel()
+		addr := startTestBroker(t)
+		runtime, samples := newTestRuntime(t)
+
+		_, err := runtime.RunOnEventLoop(strings.ReplaceAll(`
+			const client = new Client({ clientId: "k6", username: "user", password: "secret", keepAlive: "10s" });
+			client.connect("mqtt://ADDR");
+			const received = [];
+			let closed = false;
+			client.on("message", (msg) => {
+				received.push(msg.topic + "=" + msg.payload + "@" + msg.qos);
+				if (received.length === 3) client.close();
+			});
+			client.on("close", () => {
+				closed = true;
+				if (received.join() !== "sensors/a/temp=21@0,sensors/b/temp=22@1,sensors/c/temp=23@2") {
+					throw new Error("unexpected messages: " + received.join());
+				}
+			});
+			if (client.subscribe("sensors/+/temp", { qos: 2 }) !== 2) throw new Error("unexpected granted QoS");
+			client.publish("sensors/a/temp", "21");
+			client.publish("sensors/b/temp", "22", { qos: 1 });
+			client.publish("sensors/c/temp", new Uint8Array([50, 51]).buffer, { qos: 2, retain: true });
+			client.publish("other", "ignored");
+		`, "ADDR", addr))
+		require.NoError(t, err)
+		v, err := runtime.VU.Runtime().RunString(`closed`)
+		require.NoError(t, err)
+		assert.True(t, v.ToBoolean())
+
+		counts := make(map[string]float64)
+		for _, sc := range metrics.GetBufferedSamples(samples) {
+			for _, s := range sc.GetSamples() {
+				if s.Metric.Type == metrics.Trend {
+					counts[s.Metric.Name]++
+				} else {
+					counts[s.Metric.Name] += s.Value
+				}
+			}
+		}
+		assert.Equal(t, map[string]float64{
+			"mqtt_connect_duration":     1,
+			"mqtt_publishes":            4,
+			"mqtt_publish_duration":     4,
+			"mqtt_publish_acknowledged": 2,
+			"mqtt_messages_received":    3,
+		}, counts)
+	})
+
+	t.Run("connection lost", func(t *testing.T) {
+		t.Parallel()
+		addr := startTestBroker(t)
+		runtime, _ := newTestRuntime(t)
+
+		_, err := runtime.RunOnEventLoop(strings.ReplaceAll(`
+			const client = new Client({ password: "secret" });
+			client.connect("mqtt://ADDR");
+			client.subscribe(["a", "b"]);
+			client.publish("disconnect", "");
+		`, "ADDR", addr))
+		require.ErrorContains(t, err, "the MQTT connection failed")
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		t.Parallel()
+		addr := startTestBroker(t)
+		runtime, _ := newTestRuntime(t)
+
+		for script, msg := range map[string]string{
+			`new Client({ password: "wrong" }).connect("mqtt://ADDR")`: "the client isn't authorized to connect",
+			`new Client().connect("http://ADDR")`:                      "the broker URL must be mqtt:// or mqtts://",
+			`new Client({ qos: 1 })`:                                   `unknown MQTT client option "qos"`,
+			`new Client().publish("a", "b")`:                           "the MQTT client isn't connected",
+			`new Client({ keepAlive: "-1s" })`:                         "invalid keepAlive value",
+			`new Client().on("connect", () => {})`:                     `unknown MQTT client event "connect"`,
+			`const c = new Client({ password: "secret" }); c.connect("mqtt://ADDR"); c.publish("a", "b", { qos: 3 })`: "the QoS must be 0, 1 or 2",
+		} {
+			_, err := runtime.VU.Runtime().RunString(strings.ReplaceAll("{"+script+"}", "ADDR", addr))
+			assert.ErrorContains(t, err, msg, script)
+		}
+	})
+}
@@ -0,0 +1,226 @@
+package mqtt
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// The types of the MQTT 3.1.1 control packets.
+const (
+	packetConnect     byte = 1
+	packetConnAck     byte = 2
+	packetPublish     byte = 3
+	packetPubAck      byte = 4
+	packetPubRec      byte = 5
+	packetPubRel      byte = 6
+	packetPubComp     byte = 7
+	packetSubscribe   byte = 8
+	packetSubAck      byte = 9
+	packetUnsubscribe byte = 10
+	packetUnsubAck    byte = 11
+	packetPingReq     byte = 12
+	packetPingResp    byte = 13
+	packetDisconnect  byte = 14
+)
+
+// maxRemainingLength is the largest remaining length, which can be encoded in
+// the fixed header of a packet.
+const maxRemainingLength = 268_435_455
+
+// subscribeFailure is the return code of SUBACK, for a rejected subscription.
+const subscribeFailure = 0x80
+
+var errMalformedPacket = errors.New("malformed MQTT packet")
+
+// connackErrors are the errors of the return codes of CONNACK.
+var connackErrors = map[byte]string{ //nolint:gochecknoglobals
+	1: "the broker doesn't support the MQTT 3.1.1 protocol",
+	2: "the client identifier was rejected",
+	3: "the MQTT service is unavailable",
+	4: "the username or the password is malformed",
+	5: "the client isn't authorized to connect",
+}
+
+// packet is a control packet, without its remaining length.
+type packet struct {
+	kind, flags byte
+	body        []byte
+}
+
+// id returns the packet identifier, which the acknowledgements start with.
+func (p packet) id() (uint16, error) {
+	if len(p.body) < 2 {
+		return 0, errMalformedPacket
+	}
+	return binary.BigEndian.Uint16(p.body), nil
+}
+
+func writePacket(w io.Writer, p packet) error {
+	if len(p.body) > maxRemainingLength {
+		return fmt.Errorf("the MQTT packet is too large, %d bytes", len(p.body))
+	}
+	header := []byte{p.kind<<4 | p.flags}
+	for n := len(p.body); ; {
+		b := byte(n % 128)
+		n /= 128
+		if n > 0 {
+			b |= 0x80
+		}
+		header = append(header, b)
+		if n == 0 {
+			break
+		}
+	}
+	_, err := w.Write(append(header, p.body...))
+	return err
+}
+
+func readPacket(r *bufio.Reader) (packet, error) {
+	first, err := r.ReadByte()
+	if err != nil {
+		return packet{}, err
+	}
+	length, multiplier := 0, 1
+	for i := 0; ; i++ {
+		if i == 4 {
+			return packet{}, errMalformedPacket
+		}
+		b, err := r.ReadByte()
+		if err != nil {
+			return packet{}, unexpectedEOF(err)
+		}
+		length += int(b&0x7f) * multiplier
+		multiplier *= 128
+		if b&0x80 == 0 {
+			break
+		}
+	}
+	p := packet{kind: first >> 4, flags: first & 0x0f, body: make([]byte, length)}
+	if _, err = io.ReadFull(r, p.body); err != nil {
+		return packet{}, unexpectedEOF(err)
+	}
+	return p, nil
+}
+
+func unexpectedEOF(err error) error {
+	if errors.Is(err, io.EOF) {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+func appendString(b []byte, s string) []byte {
+	b = binary.BigEndian.AppendUint16(b, uint16(len(s))) //nolint:gosec
+	return append(b, s...)
+}
+
+func readString(b []byte) (string, []byte, error) {
+	if len(b) < 2 {
+		return "", nil, errMalformedPacket
+	}
+	n := int(binary.BigEndian.Uint16(b))
+	if len(b) < 2+n {
+		return "", nil, errMalformedPacket
+	}
+	return string(b[2 : 2+n]), b[2+n:], nil
+}
+
+// idPacket returns an acknowledgement, or another packet that has only the
+// packet identifier.
+func idPacket(kind byte, id uint16) packet {
+	var flags byte
+	if kind == packetPubRel {
+		flags = 0x02
+	}
+	return packet{kind: kind, flags: flags, body: binary.BigEndian.AppendUint16(nil, id)}
+}
+
+func connectPacket(opts clientOptions, keepAlive uint16) packet {
+	body := appendString(nil, "MQTT")
+	body = append(body, 4) // the protocol level of MQTT 3.1.1
+	var flags byte
+	if opts.cleanSession {
+		flags |= 0x02
+	}
+	if opts.username != "" {
+		flags |= 0x80
+	}
+	if opts.password != "" {
+		flags |= 0x40
+	}
+	body = append(body, flags)
+	body = binary.BigEndian.AppendUint16(body, keepAlive)
+	body = appendString(body, opts.clientID)
+	if opts.username != "" {
+		body = appendString(body, opts.username)
+	}
+	if opts.password != "" {
+		body = appendString(body, opts.password)
+	}
+	return packet{kind: packetConnect, body: body}
+}
+
+// message is an application message, which is published or received.
+type message struct {
+	topic   string
+	payload []byte
+	qos     byte
+	retain  bool
+	dup     bool
+	id      uint16
+}
+
+func publishPacket(m message) packet {
+	flags := m.qos << 1
+	if m.retain {
+		flags |= 0x01
+	}
+	if m.dup {
+		flags |= 0x08
+	}
+	body := appendString(nil, m.topic)
+	if m.qos > 0 {
+		body = binary.BigEndian.AppendUint16(body, m.id)
+	}
+	return packet{kind: packetPublish, flags: flags, body: append(body, m.payload...)}
+}
+
+func parsePublish(p packet) (message, error) {
+	m := message{qos: (p.flags >> 1) & 0x03, retain: p.flags&0x01 != 0, dup: p.flags&0x08 != 0}
+	if m.qos > 2 {
+		return m, errMalformedPacket
+	}
+	var rest []byte
+	var err error
+	if m.topic, rest, err = readString(p.body); err != nil {
+		return m, err
+	}
+	if m.qos > 0 {
+		if len(rest) < 2 {
+			return m, errMalformedPacket
+		}
+		m.id, rest = binary.BigEndian.Uint16(rest), rest[2:]
+	}
+	m.payload = rest
+	return m, nil
+}
+
+func subscribePacket(id uint16, topics []string, qos byte) packet {
+	body := binary.BigEndian.AppendUint16(nil, id)
+	for _, topic := range topics {
+		body = appendString(body, topic)
+		body = append(body, qos)
+	}
+	return packet{kind: packetSubscribe, flags: 0x02, body: body}
+}
+
+func unsubscribePacket(id uint16, topics []string) packet {
+	body := binary.BigEndian.AppendUint16(nil, id)
+	for _, topic := range topics {
+		body = appendString(body, topic)
+	}
+	return packet{kind: packetUnsubscribe, flags: 0x02, body: body}
+}
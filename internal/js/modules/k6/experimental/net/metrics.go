@@ -0,0 +1,34 @@
+package net
+
+import "go.k6.io/k6/metrics"
+
+// instanceMetrics contains the metrics of the sockets.
+type instanceMetrics struct {
+	// BytesSent and BytesReceived are the data written to and read from the
+	// sockets, after the TLS decryption, if they use it.
+	BytesSent     *metrics.Metric
+	BytesReceived *metrics.Metric
+	// ConnectDuration is how long the sockets took to connect, including the
+	// TLS handshake, if it's done when connecting.
+	ConnectDuration *metrics.Metric
+}
+
+// registerMetrics registers and returns the metrics in the provided registry
+func registerMetrics(registry *metrics.Registry) (*instanceMetrics, error) {
+	var err error
+	m := &instanceMetrics{}
+
+	if m.BytesSent, err = registry.NewMetric("net_bytes_sent", metrics.Counter, metrics.Data); err != nil {
+		return nil, err
+	}
+
+	if m.BytesReceived, err = registry.NewMetric("net_bytes_received", metrics.Counter, metrics.Data); err != nil {
+		return nil, err
+	}
+
+	if m.ConnectDuration, err = registry.NewMetric("net_connect_duration", metrics.Trend, metrics.Time); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
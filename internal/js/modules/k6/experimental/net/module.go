@@ -0,0 +1,67 @@
+// Package net provides raw TCP and UDP sockets, so services with custom binary
+// or text protocols, like syslog, can be tested without writing an extension.
+package net
+
+import (
+	"fmt"
+
+	"github.com/grafana/sobek"
+
+	"go.k6.io/k6/js/common"
+	"go.k6.io/k6/js/modules"
+)
+
+type (
+	// RootModule is the global module instance that will create instances of our
+	// module for each VU.
+	RootModule struct{}
+
+	// ModuleInstance represents an instance of the net module for a single VU.
+	ModuleInstance struct {
+		vu      modules.VU
+		metrics *instanceMetrics
+	}
+)
+
+var (
+	_ modules.Module   = &RootModule{}
+	_ modules.Instance = &ModuleInstance{}
+)
+
+// New returns a pointer to a new [RootModule] instance.
+func New() *RootModule {
+	return &RootModule{}
+}
+
+// NewModuleInstance implements the modules.Module interface and returns a new
+// instance of our module for the given VU.
+func (*RootModule) NewModuleInstance(vu modules.VU) modules.Instance {
+	m, err := registerMetrics(vu.InitEnv().Registry)
+	if err != nil {
+		common.Throw(vu.Runtime(), fmt.Errorf("failed to register the net module metrics: %w", err))
+	}
+	return &ModuleInstance{vu: vu, metrics: m}
+}
+
+// Exports implements the modules.Module interface and returns the exports of
+// our module.
+func (mi *ModuleInstance) Exports() modules.Exports {
+	return modules.Exports{
+		Named: map[string]any{
+			"Socket":    mi.NewSocket,
+			"UDPSocket": mi.NewUDPSocket,
+		},
+	}
+}
+
+// NewSocket is the JS constructor of the TCP Socket.
+func (mi *ModuleInstance) NewSocket(sobek.ConstructorCall) *sobek.Object {
+	rt := mi.vu.Runtime()
+	return rt.ToValue(&Socket{socket: socket{mi: mi, network: "tcp"}}).ToObject(rt)
+}
+
+// NewUDPSocket is the JS constructor of the UDPSocket.
+func (mi *ModuleInstance) NewUDPSocket(sobek.ConstructorCall) *sobek.Object {
+	rt := mi.vu.Runtime()
+	return rt.ToValue(&UDPSocket{socket: socket{mi: mi, network: "udp"}}).ToObject(rt)
+}
@@ -0,0 +1,241 @@
+package net
+
+import (
+	"bufio"
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/js/modulestest"
+	"go.k6.io/k6/metrics"
+)
+
+func newTestRuntime(t *testing.T, tlsConfig *tls.Config) (*modulestest.Runtime, chan metrics.SampleContainer) {
+	t.Helper()
+
+	runtime := modulestest.NewRuntime(t)
+	require.NoError(t, runtime.SetupModule("k6/experimental/net", New(),
+		`const { Socket, UDPSocket } = require("k6/experimental/net");`))
+
+	state, samples := modulestest.NewVUState(t, 100)
+	state.Dialer = modulestest.NewLocalDialer()
+	state.TLSConfig = tlsConfig
+	runtime.MoveToVUContext(state)
+	return runtime, samples
+}
+
+// serve accepts the connections of the listener and handles them with the
+// function, until the test ends.
+func serve(t *testing.T, listener net.Listener, handle func(net.Conn)) string {
+	t.Helper()
+	t.Cleanup(func() { _ = listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer func() { _ = conn.Close() }()
+				handle(conn)
+			}()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+// sums returns the sums of the values of the net metrics in the samples.
+func sums(samples chan metrics.SampleContainer) map[string]float64 {
+	result := make(map[string]float64)
+	for _, sc := range metrics.GetBufferedSamples(samples) {
+		for _, s := range sc.GetSamples() {
+			if s.Metric.Name == "net_connect_duration" {
+				result[s.Metric.Name]++
+				continue
+			}
+			result[s.Metric.Name] += s.Value
+		}
+	}
+	return result
+}
+
+func TestSocket(t *testing.T) {
+	t.Parallel()
+
+	t.Run("read and write", func(t *testing.T) {
+		t.Parallel()
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		addr := serve(t, listener, func(conn net.Conn) {
+			line, err := bufio.NewReader(conn).ReadString('\n')
+			if err != nil {
+				return
+			}
+			_, _ = conn.Write([]byte("HELLO " + strings.ToUpper(line) + "\x00\x01\x02"))
+		})
+		runtime, samples := newTestRuntime(t, nil)
+
+		_, err = runtime.VU.Runtime().RunString(strings.ReplaceAll(`
+			const socket = new Socket();
+			socket.connect("ADDR", { timeout: "1s", tags: { service: "echo" } });
+			if (socket.write("k6\n") !== 3) throw new Error("unexpected written size");
+			const line = socket.read({ until: "\n", type: "text", timeout: "1s" });
+			if (line !== "HELLO K6\n") throw new Error("unexpected line: " + JSON.stringify(line));
+			const bytes = new Uint8Array(socket.read({ size: 3 }));
+			if (bytes.join() !== "0,1,2") throw new Error("unexpected bytes: " + bytes.join());
+			if (socket.read() !== null) throw new Error("the connection wasn't closed");
+			if (socket.remoteAddress() !== "ADDR") throw new Error("unexpected address: " + socket.remoteAddress());
+			socket.close();
+		`, "ADDR", addr))
+		require.NoError(t, err)
+
+		assert.Equal(t, map[string]float64{
+			"net_connect_duration": 1,
+			"net_bytes_sent":       3,
+			"net_bytes_received":   12,
+		}, sums(samples))
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		t.Parallel()
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		addr := serve(t, listener, func(conn net.Conn) {
+			_, _ = conn.Write([]byte("partial"))
+			_, _ = conn.Read(make([]byte, 1))
+		})
+		runtime, _ := newTestRuntime(t, nil)
+
+		_, err = runtime.VU.Runtime().RunString(strings.ReplaceAll(`
+			const socket = new Socket();
+			socket.connect("ADDR");
+			socket.read({ until: "\n", timeout: "50ms" });
+		`, "ADDR", addr))
+		require.ErrorContains(t, err, "reading from "+addr+" timed out after 50ms")
+	})
+
+	t.Run("tls", func(t *testing.T) {
+		t.Parallel()
+		srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = w.Write([]byte("ok"))
+		}))
+		t.Cleanup(srv.Close)
+		pool := x509.NewCertPool()
+		pool.AddCert(srv.Certificate())
+		runtime, _ := newTestRuntime(t, &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12})
+
+		_, err := runtime.VU.Runtime().RunString(strings.ReplaceAll(`
+			const socket = new Socket();
+			socket.connect("ADDR", { tls: true });
+			socket.write("GET / HTTP/1.0\r\n\r\n");
+			const status = socket.read({ until: "\r\n", type: "text" });
+			if (status !== "HTTP/1.0 200 OK\r\n") throw new Error("unexpected status: " + JSON.stringify(status));
+			socket.close();
+		`, "ADDR", srv.Listener.Addr().String()))
+		require.NoError(t, err)
+	})
+
+	t.Run("start tls", func(t *testing.T) {
+		t.Parallel()
+		srv := httptest.NewUnstartedServer(nil)
+		srv.StartTLS()
+		certs := srv.TLS.Certificates
+		pool := x509.NewCertPool()
+		pool.AddCert(srv.Certificate())
+		srv.Close()
+
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		addr := serve(t, listener, func(conn net.Conn) {
+			r := bufio.NewReader(conn)
+			if line, err := r.ReadString('\n'); err != nil || line != "STARTTLS\n" {
+				return
+			}
+			_, _ = conn.Write([]byte("OK\n"))
+			tlsConn := tls.Server(conn, &tls.Config{Certificates: certs, MinVersion: tls.VersionTLS12})
+			line, err := bufio.NewReader(tlsConn).ReadString('\n')
+			if err != nil {
+				return
+			}
+			_, _ = tlsConn.Write([]byte("secure " + line))
+		})
+		runtime, _ := newTestRuntime(t, &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12})
+
+		_, err = runtime.VU.Runtime().RunString(strings.ReplaceAll(`
+			const socket = new Socket();
+			socket.connect("ADDR");
+			socket.write("STARTTLS\n");
+			if (socket.read({ until: "\n", type: "text" }) !== "OK\n") throw new Error("STARTTLS wasn't accepted");
+			socket.startTLS({ serverName: "example.com" });
+			socket.write("hello\n");
+			const line = socket.read({ until: "\n", type: "text" });
+			if (line !== "secure hello\n") throw new Error("unexpected line: " + JSON.stringify(line));
+		`, "ADDR", addr))
+		require.NoError(t, err)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		t.Parallel()
+		runtime, _ := newTestRuntime(t, nil)
+
+		for script, msg := range map[string]string{
+			`new Socket().write("a")`:                                "the socket isn't connected",
+			`new Socket().connect("127.0.0.1:1", { retries: 1 })`:    `unknown connect option "retries"`,
+			`new UDPSocket().connect("127.0.0.1:1", { tls: true })`:  "only the TCP sockets support TLS",
+			`new Socket().connect("127.0.0.1:1", { timeout: "1s" })`: "connection refused",
+			`new Socket().startTLS()`:                                "the socket isn't connected",
+		} {
+			_, err := runtime.VU.Runtime().RunString(script)
+			assert.ErrorContains(t, err, msg, script)
+		}
+	})
+}
+
+func TestUDPSocket(t *testing.T) {
+	t.Parallel()
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	go func() {
+		buf := make([]byte, 1024)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			_, _ = conn.WriteTo([]byte("<14>"+string(buf[:n])), addr)
+		}
+	}()
+	runtime, samples := newTestRuntime(t, nil)
+
+	_, err = runtime.VU.Runtime().RunString(strings.ReplaceAll(`
+		const socket = new UDPSocket();
+		socket.connect("ADDR");
+		socket.write("first");
+		socket.write("second");
+		const first = socket.read({ type: "text", timeout: "1s" });
+		const second = socket.read({ type: "text", timeout: "1s" });
+		if (first !== "<14>first" || second !== "<14>second") {
+			throw new Error("unexpected datagrams: " + first + ", " + second);
+		}
+		socket.close();
+	`, "ADDR", conn.LocalAddr().String()))
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]float64{
+		"net_connect_duration": 1,
+		"net_bytes_sent":       11,
+		"net_bytes_received":   19,
+	}, sums(samples))
+
+	_, err = runtime.VU.Runtime().RunString(`new UDPSocket().read({ size: 1 })`)
+	require.ErrorContains(t, err, "the socket isn't connected")
+}
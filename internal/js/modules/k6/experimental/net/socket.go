@@ -0,0 +1,467 @@
+package net
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"time"
+
+	"github.com/grafana/sobek"
+
+	"go.k6.io/k6/js/common"
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/types"
+	"go.k6.io/k6/metrics"
+)
+
+const (
+	defaultConnectTimeout = 60 * time.Second
+	// maxReadSize is the most, which a read without a size or a delimiter
+	// returns, of the data that's available.
+	maxReadSize = 64 << 10
+)
+
+// ErrNotConnected is returned by the methods of the sockets, which need a
+// connection, before connect() or after close().
+var ErrNotConnected = errors.New("the socket isn't connected")
+
+// socket is the connection of a TCP or UDP socket, which is used only by the
+// VU, without blocking the event loop, like the HTTP requests.
+type socket struct {
+	mi      *ModuleInstance
+	network string
+
+	conn   net.Conn
+	reader *bufio.Reader
+	// stop stops closing the connection, when the VU context is done
+	stop func() bool
+
+	addr        string
+	tagsAndMeta metrics.TagsAndMeta
+}
+
+// connectOptions are the options of connect().
+type connectOptions struct {
+	timeout time.Duration
+	// tls makes the TCP sockets do the TLS handshake after connecting
+	tls  bool
+	tags sobek.Value
+}
+
+func (s *socket) parseConnectOptions(options sobek.Value) (connectOptions, error) {
+	opts := connectOptions{timeout: defaultConnectTimeout}
+	if common.IsNullish(options) {
+		return opts, nil
+	}
+	rt := s.mi.vu.Runtime()
+	obj := options.ToObject(rt)
+	for _, k := range obj.Keys() {
+		v := obj.Get(k)
+		switch k {
+		case "timeout":
+			var err error
+			if opts.timeout, err = types.GetDurationValue(v.Export()); err != nil {
+				return opts, fmt.Errorf("invalid timeout value: %w", err)
+			}
+		case "tls":
+			if s.network != "tcp" {
+				return opts, errors.New("only the TCP sockets support TLS")
+			}
+			opts.tls = v.ToBoolean()
+		case "tags":
+			opts.tags = v
+		default:
+			return opts, fmt.Errorf("unknown connect option %q", k)
+		}
+	}
+	return opts, nil
+}
+
+// connect connects the socket to the address with the dialer of the VU, so the
+// hosts, the DNS and the blocking options apply to it.
+func (s *socket) connect(addr string, options sobek.Value) error {
+	state := s.mi.vu.State()
+	if state == nil {
+		return errors.New("the sockets can't connect in the init context")
+	}
+	if s.conn != nil {
+		return errors.New("the socket is already connected")
+	}
+	opts, err := s.parseConnectOptions(options)
+	if err != nil {
+		return err
+	}
+
+	s.addr = addr
+	s.tagsAndMeta = state.Tags.GetCurrentValues()
+	s.tagsAndMeta.SetSystemTagOrMetaIfEnabled(state.Options.SystemTags, metrics.TagURL, s.network+"://"+addr)
+	if !common.IsNullish(opts.tags) {
+		if err = common.ApplyCustomUserTags(s.mi.vu.Runtime(), &s.tagsAndMeta, opts.tags); err != nil {
+			return fmt.Errorf("invalid tags: %w", err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(s.mi.vu.Context(), opts.timeout)
+	defer cancel()
+	start := time.Now()
+	conn, err := state.Dialer.DialContext(ctx, s.network, addr)
+	if err != nil {
+		return err
+	}
+	s.setConn(conn)
+	if opts.tls {
+		if err = s.handshake(ctx, state, nil); err != nil {
+			_ = s.close()
+			return err
+		}
+	}
+	s.push(s.mi.metrics.ConnectDuration, metrics.D(time.Since(start)))
+	return nil
+}
+
+// setConn sets the connection, which is closed when the VU context is done,
+// so the reads and the writes don't block the VU after it.
+func (s *socket) setConn(conn net.Conn) {
+	s.conn = conn
+	s.reader = bufio.NewReaderSize(conn, maxReadSize)
+	if s.stop != nil {
+		s.stop()
+	}
+	s.stop = context.AfterFunc(s.mi.vu.Context(), func() { _ = conn.Close() })
+}
+
+// handshake upgrades the connection to TLS, with the TLS options of the VU.
+func (s *socket) handshake(ctx context.Context, state *lib.State, options sobek.Value) error {
+	if s.reader.Buffered() > 0 {
+		return errors.New("the socket can't start TLS, when it has data, which wasn't read")
+	}
+	host, _, err := net.SplitHostPort(s.addr)
+	if err != nil {
+		return err
+	}
+	config := state.TLSConfig.Clone()
+	if config == nil {
+		config = &tls.Config{} //nolint:gosec // the VU's TLS options are used, when there are some
+	}
+	config.ServerName = host
+	config.NextProtos = nil
+	if !common.IsNullish(options) {
+		obj := options.ToObject(s.mi.vu.Runtime())
+		for _, k := range obj.Keys() {
+			v := obj.Get(k)
+			switch k {
+			case "serverName":
+				config.ServerName = v.String()
+			case "alpn":
+				var protos []string
+				if err = s.mi.vu.Runtime().ExportTo(v, &protos); err != nil {
+					return fmt.Errorf("invalid alpn value: %w", err)
+				}
+				config.NextProtos = protos
+			default:
+				return fmt.Errorf("unknown TLS option %q", k)
+			}
+		}
+	}
+
+	tlsConn := tls.Client(s.conn, config)
+	if err = tlsConn.HandshakeContext(ctx); err != nil {
+		return fmt.Errorf("the TLS handshake failed: %w", err)
+	}
+	s.setConn(tlsConn)
+	return nil
+}
+
+// write writes the data, which is a string or an ArrayBuffer.
+func (s *socket) write(data sobek.Value) (int, error) {
+	if s.conn == nil {
+		return 0, ErrNotConnected
+	}
+	if common.IsNullish(data) {
+		return 0, errors.New("write() requires the data")
+	}
+	b, err := common.ToBytes(data.Export())
+	if err != nil {
+		return 0, err
+	}
+	n, err := s.conn.Write(b)
+	if n > 0 {
+		s.push(s.mi.metrics.BytesSent, float64(n))
+	}
+	if err != nil {
+		return n, s.connError(err)
+	}
+	return n, nil
+}
+
+// readOptions are the options of read().
+type readOptions struct {
+	timeout time.Duration
+	// size is the exact number of bytes to read
+	size int
+	// until is the delimiter, until which the data is read, including it
+	until []byte
+	text  bool
+}
+
+func (s *socket) parseReadOptions(options sobek.Value) (readOptions, error) {
+	var opts readOptions
+	if common.IsNullish(options) {
+		return opts, nil
+	}
+	obj := options.ToObject(s.mi.vu.Runtime())
+	for _, k := range obj.Keys() {
+		v := obj.Get(k)
+		switch k {
+		case "timeout":
+			var err error
+			if opts.timeout, err = types.GetDurationValue(v.Export()); err != nil {
+				return opts, fmt.Errorf("invalid timeout value: %w", err)
+			}
+		case "size":
+			if s.network != "tcp" {
+				return opts, errors.New("the UDP sockets read whole datagrams, without a size")
+			}
+			size := v.ToInteger()
+			if size <= 0 {
+				return opts, fmt.Errorf("invalid size value: it must be positive, but it's %d", size)
+			}
+			opts.size = int(size)
+		case "until":
+			if s.network != "tcp" {
+				return opts, errors.New("the UDP sockets read whole datagrams, without a delimiter")
+			}
+			until, err := common.ToBytes(v.Export())
+			if err != nil || len(until) == 0 {
+				return opts, errors.New("invalid until value: it must be a non-empty string or ArrayBuffer")
+			}
+			opts.until = until
+		case "type":
+			switch t := v.String(); t {
+			case "text":
+				opts.text = true
+			case "binary":
+			default:
+				return opts, fmt.Errorf("invalid type value %q: it must be text or binary", t)
+			}
+		default:
+			return opts, fmt.Errorf("unknown read option %q", k)
+		}
+	}
+	if opts.size > 0 && opts.until != nil {
+		return opts, errors.New("a read can have either a size or a delimiter")
+	}
+	return opts, nil
+}
+
+// read reads the data, which is returned as an ArrayBuffer or a string, or
+// null, when the connection was closed by the other side.
+func (s *socket) read(options sobek.Value) (sobek.Value, error) {
+	if s.conn == nil {
+		return nil, ErrNotConnected
+	}
+	opts, err := s.parseReadOptions(options)
+	if err != nil {
+		return nil, err
+	}
+	var deadline time.Time
+	if opts.timeout > 0 {
+		deadline = time.Now().Add(opts.timeout)
+	}
+	if err = s.conn.SetReadDeadline(deadline); err != nil {
+		return nil, err
+	}
+
+	var data []byte
+	switch {
+	case opts.size > 0:
+		data = make([]byte, opts.size)
+		var n int
+		n, err = io.ReadFull(s.reader, data)
+		data = data[:n]
+	case opts.until != nil:
+		data, err = s.readUntil(opts.until)
+	default:
+		data = make([]byte, maxReadSize)
+		var n int
+		n, err = s.reader.Read(data)
+		data = data[:n]
+	}
+	if len(data) > 0 {
+		s.push(s.mi.metrics.BytesReceived, float64(len(data)))
+	}
+
+	rt := s.mi.vu.Runtime()
+	switch {
+	case errors.Is(err, io.EOF) && len(data) == 0:
+		return sobek.Null(), nil
+	case errors.Is(err, os.ErrDeadlineExceeded):
+		return nil, fmt.Errorf("reading from %s timed out after %s", s.addr, opts.timeout)
+	case errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF):
+		return nil, fmt.Errorf("%s closed the connection, after %d bytes of the data were read", s.addr, len(data))
+	case err != nil:
+		return nil, s.connError(err)
+	}
+	if opts.text {
+		return rt.ToValue(string(data)), nil
+	}
+	return rt.ToValue(rt.NewArrayBuffer(data)), nil
+}
+
+// readUntil reads until the end of the delimiter.
+func (s *socket) readUntil(until []byte) ([]byte, error) {
+	last := until[len(until)-1]
+	var data []byte
+	for {
+		chunk, err := s.reader.ReadSlice(last)
+		data = append(data, chunk...)
+		if err == nil && len(data) >= len(until) && string(data[len(data)-len(until):]) == string(until) {
+			return data, nil
+		}
+		if err != nil && !errors.Is(err, bufio.ErrBufferFull) {
+			return data, err
+		}
+	}
+}
+
+// connError returns the error of the connection, or the one of the VU
+// context, if the connection was closed because it's done.
+func (s *socket) connError(err error) error {
+	if ctxErr := s.mi.vu.Context().Err(); ctxErr != nil {
+		return ctxErr
+	}
+	return err
+}
+
+func (s *socket) push(metric *metrics.Metric, value float64) {
+	state := s.mi.vu.State()
+	metrics.PushIfNotDone(s.mi.vu.Context(), state.Samples, metrics.Sample{
+		TimeSeries: metrics.TimeSeries{Metric: metric, Tags: s.tagsAndMeta.Tags},
+		Time:       time.Now(),
+		Metadata:   s.tagsAndMeta.Metadata,
+		Value:      value,
+	})
+}
+
+func (s *socket) close() error {
+	if s.conn == nil {
+		return nil
+	}
+	s.stop()
+	err := s.conn.Close()
+	s.conn, s.reader, s.stop = nil, nil, nil
+	return err
+}
+
+// localAddress returns the local address of the connection, or an empty
+// string if it isn't connected.
+func (s *socket) localAddress() string {
+	if s.conn == nil {
+		return ""
+	}
+	return s.conn.LocalAddr().String()
+}
+
+// remoteAddress returns the remote address of the connection, or an empty
+// string if it isn't connected.
+func (s *socket) remoteAddress() string {
+	if s.conn == nil {
+		return ""
+	}
+	return s.conn.RemoteAddr().String()
+}
+
+// Socket is a TCP socket.
+type Socket struct {
+	socket
+}
+
+// Connect connects the socket to the address, like host:port, optionally
+// with TLS.
+func (s *Socket) Connect(addr string, options sobek.Value) error {
+	return s.connect(addr, options)
+}
+
+// StartTLS upgrades the connection to TLS, e.g. after the STARTTLS command of
+// a protocol.
+func (s *Socket) StartTLS(options sobek.Value) error {
+	if s.conn == nil {
+		return ErrNotConnected
+	}
+	ctx, cancel := context.WithTimeout(s.mi.vu.Context(), defaultConnectTimeout)
+	defer cancel()
+	if err := s.handshake(ctx, s.mi.vu.State(), options); err != nil {
+		// the connection can't be used after a failed handshake
+		_ = s.close()
+		return err
+	}
+	return nil
+}
+
+// Write writes the data, which is a string or an ArrayBuffer, and returns how
+// many bytes were written.
+func (s *Socket) Write(data sobek.Value) (int, error) {
+	return s.write(data)
+}
+
+// Read reads data of the size, until the delimiter, or the data that's
+// available, and returns it, or null, if the connection was closed.
+func (s *Socket) Read(options sobek.Value) (sobek.Value, error) {
+	return s.read(options)
+}
+
+// Close closes the connection.
+func (s *Socket) Close() error {
+	return s.close()
+}
+
+// LocalAddress returns the local address of the connection.
+func (s *Socket) LocalAddress() string {
+	return s.localAddress()
+}
+
+// RemoteAddress returns the remote address of the connection.
+func (s *Socket) RemoteAddress() string {
+	return s.remoteAddress()
+}
+
+// UDPSocket is a connected UDP socket, which sends its datagrams to one
+// address and receives only the ones from it.
+type UDPSocket struct {
+	socket
+}
+
+// Connect sets the address of the datagrams of the socket.
+func (s *UDPSocket) Connect(addr string, options sobek.Value) error {
+	return s.connect(addr, options)
+}
+
+// Write sends the data, which is a string or an ArrayBuffer, as a datagram.
+func (s *UDPSocket) Write(data sobek.Value) (int, error) {
+	return s.write(data)
+}
+
+// Read receives a datagram.
+func (s *UDPSocket) Read(options sobek.Value) (sobek.Value, error) {
+	return s.read(options)
+}
+
+// Close closes the socket.
+func (s *UDPSocket) Close() error {
+	return s.close()
+}
+
+// LocalAddress returns the local address of the socket.
+func (s *UDPSocket) LocalAddress() string {
+	return s.localAddress()
+}
+
+// RemoteAddress returns the address of the datagrams of the socket.
+func (s *UDPSocket) RemoteAddress() string {
+	return s.remoteAddress()
+}
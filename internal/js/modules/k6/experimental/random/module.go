@@ -0,0 +1,133 @@
+// Package random provides named streams of random data, which are seeded from
+// the seed of the test, the VU and the iteration, so the data generated by a
+// test is the same in every run and on every instance of a distributed test.
+package random
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/grafana/sobek"
+
+	"go.k6.io/k6/js/common"
+	"go.k6.io/k6/js/modules"
+)
+
+type (
+	// RootModule is the global module instance that will create instances of our
+	// module for each VU.
+	RootModule struct {
+		// defaultSeed is the seed of the tests without the randomSeed option,
+		// which is the same for all the VUs of the instance
+		defaultSeedOnce sync.Once
+		defaultSeed     int64
+	}
+
+	// ModuleInstance represents an instance of the random module for a single VU.
+	ModuleInstance struct {
+		vu   modules.VU
+		root *RootModule
+	}
+)
+
+var (
+	_ modules.Module   = &RootModule{}
+	_ modules.Instance = &ModuleInstance{}
+)
+
+// New returns a pointer to a new [RootModule] instance.
+func New() *RootModule {
+	return &RootModule{}
+}
+
+// NewModuleInstance implements the modules.Module interface and returns a new
+// instance of our module for the given VU.
+func (r *RootModule) NewModuleInstance(vu modules.VU) modules.Instance {
+	return &ModuleInstance{vu: vu, root: r}
+}
+
+// Exports implements the modules.Module interface and returns the exports of
+// our module.
+func (mi *ModuleInstance) Exports() modules.Exports {
+	return modules.Exports{
+		Named: map[string]any{
+			"stream": mi.Stream,
+			"seed":   mi.Seed,
+		},
+	}
+}
+
+// Stream returns the named stream, usually created in the init context, whose
+// values are generated only in the VU context, where its seed is known.
+func (mi *ModuleInstance) Stream(name string, options sobek.Value) *sobek.Object {
+	rt := mi.vu.Runtime()
+	if name == "" {
+		common.Throw(rt, errors.New("stream() requires a name"))
+	}
+	scope, err := parseScope(rt, options)
+	if err != nil {
+		common.Throw(rt, fmt.Errorf("invalid options of the %s stream: %w", name, err))
+	}
+	s := &Stream{mi: mi, name: name, scope: scope}
+
+	obj := rt.NewObject()
+	for name, method := range map[string]any{
+		"float":   s.Float,
+		"int":     s.Int,
+		"bool":    s.Bool,
+		"pick":    s.Pick,
+		"shuffle": s.Shuffle,
+		"string":  s.String,
+		"uuid":    s.UUID,
+	} {
+		if err := obj.Set(name, method); err != nil {
+			common.Throw(rt, err)
+		}
+	}
+	if err := obj.Set("name", name); err != nil {
+		common.Throw(rt, err)
+	}
+	return obj
+}
+
+// Seed returns the seed of the test, which is either the randomSeed option, or
+// a random one, which is logged, so the test can be reproduced with it.
+func (mi *ModuleInstance) Seed() (int64, error) {
+	state := mi.vu.State()
+	if state == nil {
+		return 0, errors.New("the seed of the test is only known in the VU context")
+	}
+	if state.Options.RandomSeed.Valid {
+		return state.Options.RandomSeed.Int64, nil
+	}
+
+	r := mi.root
+	r.defaultSeedOnce.Do(func() {
+		var b [8]byte
+		_, _ = rand.Read(b[:])
+		// it's a safe integer in JS, so it can be used as the option as it is
+		r.defaultSeed = int64(binary.BigEndian.Uint64(b[:]) >> 11) //nolint:gosec
+		state.Logger.Infof("The random streams use the seed %d, "+
+			"which can be set with the randomSeed option to generate the same data again", r.defaultSeed)
+	})
+	return r.defaultSeed, nil
+}
+
+// deriveSeed returns the seed of the PCG generator of a stream, from the seed
+// of the test and the values of the scope of the stream.
+func deriveSeed(seed int64, name string, values ...int64) (uint64, uint64) {
+	h := sha256.New()
+	buf := binary.BigEndian.AppendUint64(nil, uint64(seed)) //nolint:gosec
+	buf = binary.AppendUvarint(buf, uint64(len(name)))
+	buf = append(buf, name...)
+	for _, v := range values {
+		buf = binary.BigEndian.AppendUint64(buf, uint64(v)) //nolint:gosec
+	}
+	h.Write(buf)
+	sum := h.Sum(nil)
+	return binary.BigEndian.Uint64(sum[:8]), binary.BigEndian.Uint64(sum[8:16])
+}
@@ -0,0 +1,168 @@
+package random
+
+import (
+	"io"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/internal/js/compiler"
+	"go.k6.io/k6/internal/lib/testutils"
+	"go.k6.io/k6/js/modulestest"
+	"go.k6.io/k6/lib"
+)
+
+// newTestRuntime returns a runtime of the VU, in the VU context, where the
+// streams are created by the init script.
+func newTestRuntime(t *testing.T, root *RootModule, seed null.Int, vuID uint64, initScript string) (*modulestest.Runtime, *lib.State) {
+	t.Helper()
+
+	runtime := modulestest.NewRuntime(t)
+	require.NoError(t, runtime.SetupModule("k6/experimental/random", root,
+		`const random = require("k6/experimental/random");`+initScript))
+
+	state := &lib.State{
+		Options:    lib.Options{RandomSeed: seed},
+		Logger:     testutils.NewLogger(t),
+		VUIDGlobal: vuID,
+	}
+	runtime.MoveToVUContext(state)
+	return runtime, state
+}
+
+func generate(t *testing.T, runtime *modulestest.Runtime, script string) string {
+	t.Helper()
+	v, err := runtime.VU.Runtime().RunString(script)
+	require.NoError(t, err)
+	return v.String()
+}
+
+func TestStream(t *testing.T) {
+	t.Parallel()
+
+	const initScript = `
+		const users = random.stream("users");
+		const orders = random.stream("orders");
+		const sessions = random.stream("sessions", { scope: "vu" });
+		const catalog = random.stream("catalog", { scope: "test" });
+	`
+	const usersScript = `[users.int(1, 1000), users.float(), users.string(8), users.uuid()].join()`
+	seed := null.IntFrom(42)
+
+	t.Run("reproducible", func(t *testing.T) {
+		t.Parallel()
+
+		runtime, state := newTestRuntime(t, New(), seed, 3, initScript)
+		state.Iteration = 7
+		first := generate(t, runtime, usersScript)
+
+		// the values don't depend on the other streams or on the earlier
+		// iterations, but only on the seed, the VU and the iteration
+		runtime, state = newTestRuntime(t, New(), seed, 3, initScript)
+		generate(t, runtime, `orders.float()`)
+		state.Iteration = 7
+		generate(t, runtime, `orders.float()`)
+		assert.Equal(t, first, generate(t, runtime, usersScript))
+
+		state.Iteration = 8
+		assert.NotEqual(t, first, generate(t, runtime, usersScript))
+
+		runtime, state = newTestRuntime(t, New(), seed, 4, initScript)
+		state.Iteration = 7
+		assert.NotEqual(t, first, generate(t, runtime, usersScript))
+
+		runtime, state = newTestRuntime(t, New(), null.IntFrom(43), 3, initScript)
+		state.Iteration = 7
+		assert.NotEqual(t, first, generate(t, runtime, usersScript))
+	})
+
+	t.Run("scopes", func(t *testing.T) {
+		t.Parallel()
+
+		runtime, state := newTestRuntime(t, New(), seed, 1, initScript)
+		session := generate(t, runtime, `sessions.float()`)
+		catalog := generate(t, runtime, `catalog.float()`)
+		state.Iteration = 1
+		// the stream of the VU continues, instead of starting again
+		assert.NotEqual(t, session, generate(t, runtime, `sessions.float()`))
+
+		runtime, state = newTestRuntime(t, New(), seed, 2, initScript)
+		state.Iteration = 5
+		assert.NotEqual(t, session, generate(t, runtime, `sessions.float()`))
+		assert.Equal(t, catalog, generate(t, runtime, `catalog.float()`))
+	})
+
+	t.Run("values", func(t *testing.T) {
+		t.Parallel()
+
+		runtime, _ := newTestRuntime(t, New(), seed, 1, initScript)
+		_, err := runtime.VU.Runtime().RunString(`
+			for (let i = 0; i < 100; i++) {
+				const n = users.int(-2, 2);
+				if (n < -2 || n > 2 || !Number.isInteger(n)) throw new Error("int out of range: " + n);
+				const s = users.string(5, "ab");
+				if (!/^[ab]{5}$/.test(s)) throw new Error("unexpected string: " + s);
+				if (!["x", "y"].includes(users.pick(["x", "y"]))) throw new Error("unexpected pick");
+				if (users.bool(0) || !users.bool(1)) throw new Error("unexpected bool");
+			}
+			const shuffled = users.shuffle([1, 2, 3, 4, 5]);
+			if (shuffled.slice().sort().join() !== "1,2,3,4,5") throw new Error("unexpected shuffle: " + shuffled);
+			if (!/^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$/.test(users.uuid())) {
+				throw new Error("invalid uuid");
+			}
+			if (users.pick([]) !== undefined) throw new Error("unexpected pick of an empty array");
+		`)
+		require.NoError(t, err)
+	})
+
+	t.Run("default seed", func(t *testing.T) {
+		t.Parallel()
+
+		root := New()
+		runtime, state := newTestRuntime(t, root, null.Int{}, 1, initScript)
+		hook := testutils.NewLogHook(logrus.InfoLevel)
+		logger := logrus.New()
+		logger.SetOutput(io.Discard)
+		logger.AddHook(hook)
+		state.Logger = logger
+		first := generate(t, runtime, `random.seed()`)
+
+		// the VUs of the instance share the seed, which is logged only once
+		runtime, _ = newTestRuntime(t, root, null.Int{}, 2, initScript)
+		assert.Equal(t, first, generate(t, runtime, `random.seed()`))
+		assert.True(t, testutils.LogContains(hook.Drain(), logrus.InfoLevel, "The random streams use the seed "+first))
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		t.Parallel()
+
+		runtime := modulestest.NewRuntime(t)
+		require.NoError(t, runtime.SetupModuleSystem(
+			map[string]any{"k6/experimental/random": New()}, nil, compiler.New(runtime.VU.InitEnv().Logger)))
+		rt := runtime.VU.Runtime()
+		_, err := rt.RunString(`const random = require("k6/experimental/random"); random.stream("users").float()`)
+		require.ErrorContains(t, err, "the values of the users stream can only be generated in the VU context")
+
+		for script, msg := range map[string]string{
+			`random.stream("")`:                         "stream() requires a name",
+			`random.stream("a", { scope: "scenario" })`: `the scope must be iteration, vu or test, but it's "scenario"`,
+			`random.stream("a", { seed: 1 })`:           `unknown option "seed"`,
+		} {
+			_, err = rt.RunString(script)
+			assert.ErrorContains(t, err, msg, script)
+		}
+
+		runtime.MoveToVUContext(&lib.State{Options: lib.Options{RandomSeed: seed}})
+		for script, msg := range map[string]string{
+			`random.stream("a").int(2, 1)`:  "the min 2 is bigger than the max 1",
+			`random.stream("a").pick("ab")`: "an array is required",
+			`random.stream("a").string(-1)`: "string() requires a positive length",
+		} {
+			_, err = rt.RunString(script)
+			assert.ErrorContains(t, err, msg, script)
+		}
+	})
+}
@@ -0,0 +1,180 @@
+package random
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+
+	"github.com/grafana/sobek"
+
+	"go.k6.io/k6/js/common"
+)
+
+// scope is what the values of a stream depend on, besides the seed of the
+// test and the name of the stream.
+type scope string
+
+const (
+	// scopeIteration streams start again in every iteration of every VU.
+	scopeIteration scope = "iteration"
+	// scopeVU streams continue through the iterations of a VU.
+	scopeVU scope = "vu"
+	// scopeTest streams are the same in all the VUs.
+	scopeTest scope = "test"
+)
+
+const defaultAlphabet = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+func parseScope(rt *sobek.Runtime, options sobek.Value) (scope, error) {
+	if common.IsNullish(options) {
+		return scopeIteration, nil
+	}
+	obj := options.ToObject(rt)
+	s := scopeIteration
+	for _, k := range obj.Keys() {
+		if k != "scope" {
+			return "", fmt.Errorf("unknown option %q", k)
+		}
+		switch v := scope(obj.Get(k).String()); v {
+		case scopeIteration, scopeVU, scopeTest:
+			s = v
+		default:
+			return "", fmt.Errorf("the scope must be iteration, vu or test, but it's %q", v)
+		}
+	}
+	return s, nil
+}
+
+// Stream is a named stream of random values. Its generator is seeded from the
+// seed of the test, its name, and the global ID of the VU and its iteration,
+// depending on its scope, so the values don't depend on the other streams or
+// on the timing of the test.
+type Stream struct {
+	mi    *ModuleInstance
+	name  string
+	scope scope
+
+	rng *rand.Rand
+	// vuID and iteration are the ones the generator was seeded for
+	vuID      uint64
+	iteration int64
+}
+
+// source returns the generator of the stream, which is seeded again, when the
+// VU or the iteration of its scope changed.
+func (s *Stream) source() *rand.Rand {
+	rt := s.mi.vu.Runtime()
+	state := s.mi.vu.State()
+	if state == nil {
+		common.Throw(rt, fmt.Errorf("the values of the %s stream can only be generated in the VU context", s.name))
+	}
+
+	vuID, iteration := state.VUIDGlobal, state.Iteration
+	switch s.scope {
+	case scopeTest:
+		vuID, iteration = 0, 0
+	case scopeVU:
+		iteration = 0
+	case scopeIteration:
+	}
+	if s.rng != nil && s.vuID == vuID && s.iteration == iteration {
+		return s.rng
+	}
+
+	seed, err := s.mi.Seed()
+	if err != nil {
+		common.Throw(rt, err)
+	}
+	s.rng = rand.New(rand.NewPCG(deriveSeed(seed, s.name, int64(vuID), iteration))) //nolint:gosec
+	s.vuID, s.iteration = vuID, iteration
+	return s.rng
+}
+
+// Float returns a number in [0, 1).
+func (s *Stream) Float() float64 {
+	return s.source().Float64()
+}
+
+// Int returns an integer between min and max, both included.
+func (s *Stream) Int(minValue, maxValue int64) int64 {
+	if minValue > maxValue {
+		common.Throw(s.mi.vu.Runtime(), fmt.Errorf("the min %d is bigger than the max %d", minValue, maxValue))
+	}
+	return minValue + s.source().Int64N(maxValue-minValue+1)
+}
+
+// Bool returns true with the probability, which is 0.5 by default.
+func (s *Stream) Bool(probability sobek.Value) bool {
+	p := 0.5
+	if !common.IsNullish(probability) {
+		p = probability.ToFloat()
+	}
+	return s.source().Float64() < p
+}
+
+// Pick returns a random element of the array, or undefined if it's empty.
+func (s *Stream) Pick(array sobek.Value) sobek.Value {
+	obj := s.array(array)
+	n := obj.Get("length").ToInteger()
+	if n == 0 {
+		return sobek.Undefined()
+	}
+	return obj.Get(fmt.Sprint(s.source().Int64N(n)))
+}
+
+// Shuffle returns a shuffled copy of the array.
+func (s *Stream) Shuffle(array sobek.Value) []sobek.Value {
+	obj := s.array(array)
+	n := int(obj.Get("length").ToInteger())
+	values := make([]sobek.Value, n)
+	for i := range values {
+		values[i] = obj.Get(fmt.Sprint(i))
+	}
+	s.source().Shuffle(n, func(i, j int) { values[i], values[j] = values[j], values[i] })
+	return values
+}
+
+// String returns a string of the length, with characters from the alphabet,
+// which has the ASCII letters and digits by default.
+func (s *Stream) String(length int, alphabet sobek.Value) string {
+	chars := []rune(defaultAlphabet)
+	if !common.IsNullish(alphabet) {
+		chars = []rune(alphabet.String())
+	}
+	if len(chars) == 0 || length < 0 {
+		common.Throw(s.mi.vu.Runtime(), errors.New("string() requires a positive length and a non-empty alphabet"))
+	}
+	rng := s.source()
+	result := make([]rune, length)
+	for i := range result {
+		result[i] = chars[rng.IntN(len(chars))]
+	}
+	return string(result)
+}
+
+// UUID returns a version 4 UUID.
+func (s *Stream) UUID() string {
+	rng := s.source()
+	var b [16]byte
+	for i := 0; i < len(b); i += 8 {
+		v := rng.Uint64()
+		for j := range 8 {
+			b[i+j] = byte(v >> (8 * j))
+		}
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+func (s *Stream) array(value sobek.Value) *sobek.Object {
+	rt := s.mi.vu.Runtime()
+	if common.IsNullish(value) {
+		common.Throw(rt, errors.New("an array is required"))
+	}
+	obj := value.ToObject(rt)
+	if obj.ClassName() != "Array" {
+		common.Throw(rt, errors.New("an array is required"))
+	}
+	return obj
+}
@@ -0,0 +1,101 @@
+package socketio
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strconv"
+
+	"github.com/grafana/sobek"
+)
+
+// encodeArguments returns the JSON array of the arguments of an event or of an
+// acknowledgement, whose ArrayBuffers and Uint8Arrays are replaced with the
+// placeholders of the returned binary attachments.
+func encodeArguments(rt *sobek.Runtime, args []sobek.Value) (string, [][]byte, error) {
+	stringify, err := jsonFunction(rt, "stringify")
+	if err != nil {
+		return "", nil, err
+	}
+	var attachments [][]byte
+	replacer := func(call sobek.FunctionCall) sobek.Value {
+		v := call.Argument(1)
+		var b []byte
+		switch data := v.Export().(type) {
+		case sobek.ArrayBuffer:
+			b = data.Bytes()
+		case []byte:
+			b = data
+		default:
+			return v
+		}
+		placeholder := rt.NewObject()
+		must(rt, placeholder.Set("_placeholder", true))
+		must(rt, placeholder.Set("num", len(attachments)))
+		attachments = append(attachments, bytes.Clone(b))
+		return placeholder
+	}
+	items := make([]any, len(args))
+	for i, arg := range args {
+		items[i] = arg
+	}
+	data, err := stringify(sobek.Undefined(), rt.NewArray(items...), rt.ToValue(replacer))
+	if err != nil {
+		return "", nil, err
+	}
+	return data.String(), attachments, nil
+}
+
+// decodeArguments parses the JSON array of the arguments of an event or of an
+// acknowledgement, whose placeholders are replaced with the ArrayBuffers of
+// their binary attachments.
+func decodeArguments(rt *sobek.Runtime, data string, attachments [][]byte) ([]sobek.Value, error) {
+	parse, err := jsonFunction(rt, "parse")
+	if err != nil {
+		return nil, err
+	}
+	reviver := func(call sobek.FunctionCall) sobek.Value {
+		v := call.Argument(1)
+		obj, ok := v.(*sobek.Object)
+		if !ok || obj.Get("_placeholder") == nil || !obj.Get("_placeholder").ToBoolean() {
+			return v
+		}
+		num := obj.Get("num")
+		if num == nil || num.ToInteger() < 0 || num.ToInteger() >= int64(len(attachments)) {
+			return v
+		}
+		return rt.ToValue(rt.NewArrayBuffer(attachments[num.ToInteger()]))
+	}
+	v, err := parse(sobek.Undefined(), rt.ToValue(data), rt.ToValue(reviver))
+	if err != nil {
+		return nil, err
+	}
+	obj, ok := v.(*sobek.Object)
+	if !ok || obj.ClassName() != "Array" {
+		return nil, errMalformedPacket
+	}
+	args := make([]sobek.Value, obj.Get("length").ToInteger())
+	for i := range args {
+		args[i] = obj.Get(strconv.Itoa(i))
+	}
+	return args, nil
+}
+
+// eventName returns the name of the event of the JSON array of its arguments,
+// without parsing the others.
+func eventName(data string) string {
+	var args []json.RawMessage
+	var name string
+	if json.Unmarshal([]byte(data), &args) != nil || len(args) == 0 || json.Unmarshal(args[0], &name) != nil {
+		return ""
+	}
+	return name
+}
+
+func jsonFunction(rt *sobek.Runtime, name string) (sobek.Callable, error) {
+	fn, ok := sobek.AssertFunction(rt.Get("JSON").ToObject(rt).Get(name))
+	if !ok {
+		return nil, errors.New("JSON." + name + " isn't a function")
+	}
+	return fn, nil
+}
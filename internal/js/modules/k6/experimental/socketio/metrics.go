@@ -0,0 +1,39 @@
+package socketio
+
+import "go.k6.io/k6/metrics"
+
+// instanceMetrics contains the metrics of the Socket.IO clients.
+type instanceMetrics struct {
+	// Connecting is how long the WebSocket and the Engine.IO handshakes, and
+	// the connection to the namespace took.
+	Connecting     *metrics.Metric
+	EventsSent     *metrics.Metric
+	EventsReceived *metrics.Metric
+	// AckDuration is the time from emitting the events until their
+	// acknowledgements were received.
+	AckDuration *metrics.Metric
+}
+
+// registerMetrics registers and returns the metrics in the provided registry
+func registerMetrics(registry *metrics.Registry) (*instanceMetrics, error) {
+	var err error
+	m := &instanceMetrics{}
+
+	if m.Connecting, err = registry.NewMetric("socketio_connecting", metrics.Trend, metrics.Time); err != nil {
+		return nil, err
+	}
+
+	if m.EventsSent, err = registry.NewMetric("socketio_events_sent", metrics.Counter); err != nil {
+		return nil, err
+	}
+
+	if m.EventsReceived, err = registry.NewMetric("socketio_events_received", metrics.Counter); err != nil {
+		return nil, err
+	}
+
+	if m.AckDuration, err = registry.NewMetric("socketio_ack_duration", metrics.Trend, metrics.Time); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
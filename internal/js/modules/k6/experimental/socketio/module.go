@@ -0,0 +1,141 @@
+// Package socketio provides a Socket.IO v4 client, with the Engine.IO
+// handshake over WebSockets, namespaces, acknowledgements and binary
+// attachments, so the realtime apps built with Socket.IO can be load tested
+// without decoding its framing in the scripts.
+package socketio
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/grafana/sobek"
+
+	"go.k6.io/k6/js/common"
+	"go.k6.io/k6/js/modules"
+	"go.k6.io/k6/lib/types"
+)
+
+const (
+	defaultPath    = "/socket.io/"
+	defaultTimeout = 20 * time.Second
+)
+
+type (
+	// RootModule is the global module instance that will create instances of our
+	// module for each VU.
+	RootModule struct{}
+
+	// ModuleInstance represents an instance of the socketio module for a single VU.
+	ModuleInstance struct {
+		vu      modules.VU
+		metrics *instanceMetrics
+	}
+)
+
+var (
+	_ modules.Module   = &RootModule{}
+	_ modules.Instance = &ModuleInstance{}
+)
+
+// New returns a pointer to a new [RootModule] instance.
+func New() *RootModule {
+	return &RootModule{}
+}
+
+// NewModuleInstance implements the modules.Module interface and returns a new
+// instance of our module for the given VU.
+func (*RootModule) NewModuleInstance(vu modules.VU) modules.Instance {
+	m, err := registerMetrics(vu.InitEnv().Registry)
+	if err != nil {
+		common.Throw(vu.Runtime(), fmt.Errorf("failed to register the socketio module metrics: %w", err))
+	}
+	return &ModuleInstance{vu: vu, metrics: m}
+}
+
+// Exports implements the modules.Module interface and returns the exports of
+// our module.
+func (mi *ModuleInstance) Exports() modules.Exports {
+	return modules.Exports{
+		Named: map[string]any{
+			"connect": mi.Connect,
+		},
+	}
+}
+
+// connectOptions are the options of connect(). The namespace is the path of
+// the URL, unless the namespace option is set, like with socket.io-client.
+type connectOptions struct {
+	namespace string
+	auth      sobek.Value
+	path      string
+	query     map[string]string
+	headers   http.Header
+	timeout   time.Duration
+	tags      sobek.Value
+}
+
+func parseConnectOptions(rt *sobek.Runtime, options sobek.Value) (connectOptions, error) {
+	opts := connectOptions{path: defaultPath, headers: make(http.Header), timeout: defaultTimeout}
+	if common.IsNullish(options) {
+		return opts, nil
+	}
+	obj := options.ToObject(rt)
+	for _, k := range obj.Keys() {
+		v := obj.Get(k)
+		var err error
+		switch k {
+		case "namespace":
+			opts.namespace = v.String()
+			if opts.namespace == "" || opts.namespace[0] != '/' {
+				err = errors.New("it must start with /")
+			}
+		case "auth":
+			opts.auth = v
+		case "path":
+			opts.path = v.String()
+		case "query":
+			err = rt.ExportTo(v, &opts.query)
+		case "headers":
+			var headers map[string]string
+			if err = rt.ExportTo(v, &headers); err == nil {
+				for name, value := range headers {
+					opts.headers.Set(name, value)
+				}
+			}
+		case "timeout":
+			opts.timeout, err = types.GetDurationValue(v.Export())
+			if err == nil && opts.timeout <= 0 {
+				err = errors.New("it must be positive")
+			}
+		case "tags":
+			opts.tags = v
+		default:
+			return opts, fmt.Errorf("unknown Socket.IO option %q", k)
+		}
+		if err != nil {
+			return opts, fmt.Errorf("invalid %s value: %w", k, err)
+		}
+	}
+	return opts, nil
+}
+
+// Connect connects to the Socket.IO server at the URL, like
+// http://localhost:3000/chat, and to the namespace of its path.
+func (mi *ModuleInstance) Connect(serverURL string, options sobek.Value) (*Socket, error) {
+	if mi.vu.State() == nil {
+		return nil, errors.New("the Socket.IO clients can't connect in the init context")
+	}
+	opts, err := parseConnectOptions(mi.vu.Runtime(), options)
+	if err != nil {
+		return nil, err
+	}
+	return dial(mi, serverURL, opts)
+}
+
+func must(rt *sobek.Runtime, err error) {
+	if err != nil {
+		common.Throw(rt, err)
+	}
+}
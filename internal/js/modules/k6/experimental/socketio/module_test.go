@@ -0,0 +1,298 @@
+package socketio
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/internal/js/compiler"
+	"go.k6.io/k6/js/modulestest"
+	"go.k6.io/k6/metrics"
+)
+
+func newTestRuntime(t *testing.T) (*modulestest.Runtime, chan metrics.SampleContainer) {
+	t.Helper()
+
+	runtime := modulestest.NewRuntime(t)
+	require.NoError(t, runtime.SetupModule("k6/experimental/socketio", New(),
+		`const { connect } = require("k6/experimental/socketio");`))
+
+	state, samples := modulestest.NewVUState(t, 100)
+	state.Dialer = modulestest.NewLocalDialer()
+	runtime.MoveToVUContext(state)
+	return runtime, samples
+}
+
+// startTestServer starts a Socket.IO server for the tests, which pings the
+// clients, and connects them to the namespaces only after their pong. It
+// accepts the default namespace, and the /admin namespace with the secret
+// token. It sends the echo and the binary events back, acknowledges the sum
+// events with the sum of their arguments, asks the client a question, when it
+// emits ask, and disconnects the namespace, when the client emits kick, or the
+// whole connection, when it emits close.
+func startTestServer(t *testing.T) string {
+	t.Helper()
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != defaultPath || r.URL.Query().Get("EIO") != "4" ||
+			r.URL.Query().Get("transport") != "websocket" {
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+			return
+		}
+		ws, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer func() { _ = ws.Close() }()
+		serveTestConnection(ws)
+	}))
+	t.Cleanup(srv.Close)
+	return srv.URL
+}
+
+func serveTestConnection(ws *websocket.Conn) {
+	send := func(p packet, attachments ...[]byte) {
+		_ = ws.WriteMessage(websocket.TextMessage, []byte(p.encode()))
+		for _, attachment := range attachments {
+			_ = ws.WriteMessage(websocket.BinaryMessage, attachment)
+		}
+	}
+	_ = ws.WriteMessage(websocket.TextMessage,
+		[]byte(`0{"sid":"engine","upgrades":[],"pingInterval":25000,"pingTimeout":20000}`))
+	_ = ws.WriteMessage(websocket.TextMessage, []byte{enginePing})
+
+	ponged := false
+	for {
+		_, data, err := ws.ReadMessage()
+		if err != nil || len(data) == 0 {
+			return
+		}
+		if data[0] == enginePong {
+			ponged = true
+		}
+		if data[0] != engineMessage {
+			continue
+		}
+		p, err := decodePacket(string(data[1:]))
+		if err != nil {
+			return
+		}
+		var attachments [][]byte
+		for range p.attachments {
+			if _, b, err := ws.ReadMessage(); err == nil {
+				attachments = append(attachments, b)
+			}
+		}
+
+		switch p.kind {
+		case packetConnect:
+			for !ponged {
+				if _, data, err = ws.ReadMessage(); err != nil {
+					return
+				}
+				ponged = len(data) > 0 && data[0] == enginePong
+			}
+			if p.namespace == "/admin" && !strings.Contains(p.data, `"secret"`) {
+				send(packet{kind: packetConnectError, namespace: p.namespace, id: -1, data: `{"message":"unauthorized"}`})
+				continue
+			}
+			send(packet{kind: packetConnect, namespace: p.namespace, id: -1, data: `{"sid":"sid` + p.namespace + `"}`})
+		case packetEvent, packetBinaryEvent:
+			var args []json.RawMessage
+			_ = json.Unmarshal([]byte(p.data), &args)
+			switch eventName(p.data) {
+			case "echo", "binary":
+				send(packet{kind: p.kind, namespace: p.namespace, id: -1, data: p.data, attachments: p.attachments},
+					attachments...)
+			case "sum":
+				var a, b int
+				_ = json.Unmarshal(args[1], &a)
+				_ = json.Unmarshal(args[2], &b)
+				send(packet{kind: packetAck, namespace: p.namespace, id: p.id, data: "[" + strconv.Itoa(a+b) + "]"})
+			case "ask":
+				send(packet{kind: packetEvent, namespace: p.namespace, id: 7, data: `["question","why"]`})
+			case "kick":
+				send(packet{kind: packetDisconnect, namespace: p.namespace, id: -1})
+			case "close":
+				_ = ws.WriteMessage(websocket.TextMessage, []byte{engineClose})
+				return
+			}
+		case packetAck:
+			send(packet{kind: packetEvent, namespace: p.namespace, id: -1, data: `["answered",` + p.data[1:]})
+		}
+	}
+}
+
+func TestSocketIO(t *testing.T) {
+	t.Parallel()
+
+	t.Run("events and acknowledgements", func(t *testing.T) {
+		t.Parallel()
+		runtime, samples := newTestRuntime(t)
+		url := startTestServer(t)
+		require.NoError(t, runtime.VU.Runtime().Set("url", url))
+
+		_, err := runtime.RunOnEventLoop(`
+			var got = [];
+			const socket = connect(url);
+			if (!socket.connected || socket.id !== "sid/" || socket.namespace !== "/") {
+				throw new Error("unexpected socket " + JSON.stringify(socket));
+			}
+			socket.on("echo", (text, obj) => got.push(text, obj.n));
+			socket.on("disconnect", (reason) => got.push(reason));
+			socket.emit("echo", "hi", { n: 1 });
+			socket.emit("sum", 1, 2, (sum) => got.push(sum));
+			socket.emitWithAck("sum", 3, 4).then((sum) => {
+				got.push(sum);
+				socket.disconnect();
+				got.push(socket.connected);
+			});
+		`)
+		require.NoError(t, err)
+		got, err := runtime.VU.Runtime().RunString(`JSON.stringify(got)`)
+		require.NoError(t, err)
+		assert.JSONEq(t, `["hi", 1, 3, 7, "io client disconnect", false]`, got.String())
+
+		counts := make(map[string]int)
+		for _, sc := range metrics.GetBufferedSamples(samples) {
+			for _, s := range sc.GetSamples() {
+				counts[s.Metric.Name]++
+				namespace, _ := s.Tags.Get("namespace")
+				assert.Equal(t, "/", namespace)
+				if s.Metric.Name != "socketio_connecting" {
+					event, _ := s.Tags.Get("event")
+					assert.Contains(t, []string{"echo", "sum"}, event)
+				}
+			}
+		}
+		assert.Equal(t, map[string]int{
+			"socketio_connecting":      1,
+			"socketio_events_sent":     3,
+			"socketio_events_received": 1,
+			"socketio_ack_duration":    2,
+		}, counts)
+	})
+
+	t.Run("binary attachments", func(t *testing.T) {
+		t.Parallel()
+		runtime, _ := newTestRuntime(t)
+		require.NoError(t, runtime.VU.Runtime().Set("url", startTestServer(t)))
+
+		_, err := runtime.RunOnEventLoop(`
+			var got;
+			const socket = connect(url + "/");
+			socket.on("binary", (obj, data) => {
+				got = [obj.name, Array.from(new Uint8Array(obj.file)), Array.from(new Uint8Array(data))];
+				socket.disconnect();
+			});
+			socket.emit("binary", { name: "a.bin", file: new Uint8Array([1, 2, 3]).buffer }, new Uint8Array([4]));
+		`)
+		require.NoError(t, err)
+		got, err := runtime.VU.Runtime().RunString(`JSON.stringify(got)`)
+		require.NoError(t, err)
+		assert.JSONEq(t, `["a.bin", [1, 2, 3], [4]]`, got.String())
+	})
+
+	t.Run("server acknowledgements", func(t *testing.T) {
+		t.Parallel()
+		runtime, _ := newTestRuntime(t)
+		require.NoError(t, runtime.VU.Runtime().Set("url", startTestServer(t)))
+
+		_, err := runtime.RunOnEventLoop(`
+			var got;
+			const socket = connect(url);
+			socket.on("question", (q, ack) => ack(q + "?", 42));
+			socket.on("answered", (answer, n) => {
+				got = [answer, n];
+				socket.disconnect();
+			});
+			socket.emit("ask");
+		`)
+		require.NoError(t, err)
+		got, err := runtime.VU.Runtime().RunString(`JSON.stringify(got)`)
+		require.NoError(t, err)
+		assert.JSONEq(t, `["why?", 42]`, got.String())
+	})
+
+	t.Run("namespaces", func(t *testing.T) {
+		t.Parallel()
+		runtime, _ := newTestRuntime(t)
+		require.NoError(t, runtime.VU.Runtime().Set("url", startTestServer(t)))
+
+		_, err := runtime.RunOnEventLoop(`
+			var got = [];
+			const socket = connect(url);
+			const admin = socket.of("/admin", { auth: { token: "secret" } });
+			got.push(admin.id);
+			admin.on("disconnect", (reason) => {
+				got.push("admin " + reason, admin.connected, socket.connected);
+				socket.disconnect();
+			});
+			admin.emit("kick");
+		`)
+		require.NoError(t, err)
+		got, err := runtime.VU.Runtime().RunString(`JSON.stringify(got)`)
+		require.NoError(t, err)
+		assert.JSONEq(t, `["sid/admin", "admin io server disconnect", false, true]`, got.String())
+
+		_, err = runtime.VU.Runtime().RunString(`connect(url, { namespace: "/admin", auth: { token: "wrong" } })`)
+		require.ErrorContains(t, err, "the server refused the connection to the namespace /admin: unauthorized")
+	})
+
+	t.Run("transport close", func(t *testing.T) {
+		t.Parallel()
+		runtime, _ := newTestRuntime(t)
+		require.NoError(t, runtime.VU.Runtime().Set("url", startTestServer(t)))
+
+		_, err := runtime.RunOnEventLoop(`
+			var got = [];
+			const socket = connect(url);
+			socket.on("disconnect", (reason) => got.push(reason));
+			socket.emitWithAck("close").catch((e) => got.push(e.message));
+		`)
+		require.NoError(t, err)
+		got, err := runtime.VU.Runtime().RunString(`JSON.stringify(got)`)
+		require.NoError(t, err)
+		assert.JSONEq(t, `["the socket was disconnected before the acknowledgement of the close event: transport close",
+			"transport close"]`, got.String())
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		t.Parallel()
+		runtime, _ := newTestRuntime(t)
+		require.NoError(t, runtime.VU.Runtime().Set("url", startTestServer(t)))
+
+		for script, msg := range map[string]string{
+			`connect(url, { reconnection: true })`:                   `unknown Socket.IO option "reconnection"`,
+			`connect(url, { namespace: "admin" })`:                   "invalid namespace value: it must start with /",
+			`connect(url, { timeout: -1 })`:                          "invalid timeout value: it must be positive",
+			`connect("ftp://localhost")`:                             "the Socket.IO URL must be http(s):// or ws(s)://",
+			`connect(url, { path: "/missing/" })`:                    "the WebSocket handshake",
+			`connect(url).of("admin")`:                               `the namespace "admin" must start with /`,
+			`connect(url).emit()`:                                    "an event name is required",
+			`const s = connect(url); s.of("/")`:                      "the socket is already connected to the namespace /",
+			`const d = connect(url); d.disconnect(); d.emit("echo")`: "the Socket.IO socket isn't connected",
+		} {
+			_, err := runtime.VU.Runtime().RunString(script)
+			assert.ErrorContains(t, err, msg, script)
+		}
+	})
+}
+
+func TestConnectInInitContext(t *testing.T) {
+	t.Parallel()
+
+	runtime := modulestest.NewRuntime(t)
+	err := runtime.SetupModuleSystem(
+		map[string]any{"k6/experimental/socketio": New()}, nil, compiler.New(runtime.VU.InitEnv().Logger))
+	require.NoError(t, err)
+	_, err = runtime.VU.Runtime().RunString(`require("k6/experimental/socketio").connect("http://localhost")`)
+	require.ErrorContains(t, err, "the Socket.IO clients can't connect in the init context")
+}
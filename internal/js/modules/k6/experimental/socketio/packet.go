@@ -0,0 +1,123 @@
+package socketio
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// The types of the Engine.IO v4 packets, which carry the Socket.IO packets in
+// their messages.
+const (
+	engineOpen    byte = '0'
+	engineClose   byte = '1'
+	enginePing    byte = '2'
+	enginePong    byte = '3'
+	engineMessage byte = '4'
+	engineNoop    byte = '6'
+)
+
+// The types of the Socket.IO v5 protocol packets, which Socket.IO v4 uses.
+const (
+	packetConnect      byte = 0
+	packetDisconnect   byte = 1
+	packetEvent        byte = 2
+	packetAck          byte = 3
+	packetConnectError byte = 4
+	packetBinaryEvent  byte = 5
+	packetBinaryAck    byte = 6
+)
+
+const defaultNamespace = "/"
+
+var errMalformedPacket = errors.New("malformed Socket.IO packet")
+
+// openPacket is the data of the Engine.IO open packet.
+type openPacket struct {
+	SID          string `json:"sid"`
+	PingInterval int64  `json:"pingInterval"`
+	PingTimeout  int64  `json:"pingTimeout"`
+}
+
+// packet is a Socket.IO packet. Its binary attachments are sent in their own
+// WebSocket messages after it, and they're referenced in its data by the
+// placeholders, like {"_placeholder":true,"num":0}.
+type packet struct {
+	kind        byte
+	namespace   string
+	id          int64 // the acknowledgement ID, or -1 without one
+	data        string
+	attachments int
+}
+
+// encode returns the Engine.IO message of the packet.
+func (p packet) encode() string {
+	var b strings.Builder
+	b.WriteByte(engineMessage)
+	b.WriteByte('0' + p.kind)
+	if p.attachments > 0 {
+		b.WriteString(strconv.Itoa(p.attachments))
+		b.WriteByte('-')
+	}
+	if p.namespace != defaultNamespace && p.namespace != "" {
+		b.WriteString(p.namespace)
+		b.WriteByte(',')
+	}
+	if p.id >= 0 {
+		b.WriteString(strconv.FormatInt(p.id, 10))
+	}
+	b.WriteString(p.data)
+	return b.String()
+}
+
+// decodePacket decodes a Socket.IO packet, without the Engine.IO message type.
+func decodePacket(s string) (packet, error) {
+	p := packet{namespace: defaultNamespace, id: -1}
+	if s == "" || s[0] < '0' || s[0] > '6' {
+		return p, errMalformedPacket
+	}
+	p.kind, s = s[0]-'0', s[1:]
+
+	if p.kind == packetBinaryEvent || p.kind == packetBinaryAck {
+		n, rest, ok := strings.Cut(s, "-")
+		attachments, err := strconv.Atoi(n)
+		if !ok || err != nil || attachments < 0 {
+			return p, errMalformedPacket
+		}
+		p.attachments, s = attachments, rest
+	}
+	if strings.HasPrefix(s, "/") {
+		end := strings.IndexByte(s, ',')
+		if end < 0 {
+			p.namespace, s = s, ""
+		} else {
+			p.namespace, s = s[:end], s[end+1:]
+		}
+	}
+	digits := 0
+	for digits < len(s) && s[digits] >= '0' && s[digits] <= '9' {
+		digits++
+	}
+	if digits > 0 {
+		id, err := strconv.ParseInt(s[:digits], 10, 64)
+		if err != nil {
+			return p, errMalformedPacket
+		}
+		p.id, s = id, s[digits:]
+	}
+	p.data = s
+	return p, nil
+}
+
+// connectError returns the error of a CONNECT_ERROR packet.
+func (p packet) connectError() error {
+	var data struct {
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal([]byte(p.data), &data); err != nil || data.Message == "" {
+		return fmt.Errorf("the server refused the connection to the namespace %s", p.namespace)
+	}
+	return fmt.Errorf("the server refused the connection to the namespace %s: %s", p.namespace, data.Message)
+}
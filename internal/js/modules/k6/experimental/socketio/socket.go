@@ -0,0 +1,725 @@
+package socketio
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/grafana/sobek"
+	"github.com/mstoykov/k6-taskqueue-lib/taskqueue"
+
+	"go.k6.io/k6/js/common"
+	"go.k6.io/k6/metrics"
+)
+
+// eventDisconnect is the event of the sockets, which is emitted with the
+// reason, when they're disconnected from their namespace.
+const eventDisconnect = "disconnect"
+
+// The reasons of the disconnections, which are the same as the ones of
+// socket.io-client.
+const (
+	reasonServerDisconnect = "io server disconnect"
+	reasonClientDisconnect = "io client disconnect"
+	reasonTransportClose   = "transport close"
+	reasonTransportError   = "transport error"
+	reasonPingTimeout      = "ping timeout"
+)
+
+var (
+	errNotConnected = errors.New("the Socket.IO socket isn't connected")
+	errServerClosed = errors.New("the server closed the Engine.IO session")
+)
+
+// Socket is the connection to a namespace, like the sockets of
+// socket.io-client. The sockets of the namespaces of a server share its
+// connection. The received events and acknowledgements are delivered to the
+// listeners on the event loop, which keeps running until the connection is
+// closed.
+type Socket struct {
+	ID        string `js:"id"`
+	Namespace string `js:"namespace"`
+	Connected bool   `js:"connected"`
+
+	conn        *connection
+	tagsAndMeta metrics.TagsAndMeta
+
+	// these are only accessed on the event loop, after the socket is connected
+	listeners map[string][]sobek.Callable
+	lastAckID int64
+	acks      map[int64]*pendingAck
+}
+
+// pendingAck is an emitted event, which is waiting for its acknowledgement.
+type pendingAck struct {
+	event    string
+	sent     time.Time
+	callback func(args []sobek.Value) error
+	// fail is called, when the acknowledgement won't be received, if it's set
+	fail func(err error) error
+}
+
+// connection is the Engine.IO connection to a server, which is read by its own
+// goroutine.
+type connection struct {
+	mi          *ModuleInstance
+	ws          *websocket.Conn
+	opts        connectOptions
+	readTimeout time.Duration
+	// stop stops closing the connection, when the VU context is done
+	stop        func() bool
+	samples     chan<- metrics.SampleContainer
+	tagsAndMeta metrics.TagsAndMeta
+	tq          *taskqueue.TaskQueue
+
+	writeMx sync.Mutex
+
+	mx      sync.Mutex
+	sockets map[string]*Socket
+	// connecting are the namespaces, which are waiting for CONNECT
+	connecting map[string]chan connectResult
+	closing    bool
+	err        error
+	done       chan struct{}
+}
+
+type connectResult struct {
+	socket *Socket
+	err    error
+}
+
+// dial opens the WebSocket of the Engine.IO session, with the dialer and the
+// TLS options of the VU, and connects to the namespace.
+func dial(mi *ModuleInstance, serverURL string, opts connectOptions) (*Socket, error) {
+	state := mi.vu.State()
+	u, err := url.Parse(serverURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Socket.IO URL: %w", err)
+	}
+	endpoint := *u
+	switch u.Scheme {
+	case "http", "ws":
+		endpoint.Scheme = "ws"
+	case "https", "wss":
+		endpoint.Scheme = "wss"
+	default:
+		return nil, fmt.Errorf("the Socket.IO URL must be http(s):// or ws(s)://, but it's %q", serverURL)
+	}
+	namespace := opts.namespace
+	if namespace == "" {
+		namespace = defaultNamespace
+		if u.Path != "" {
+			namespace = u.Path
+		}
+	}
+	endpoint.Path, endpoint.RawPath = opts.path, ""
+	query := u.Query()
+	for k, v := range opts.query {
+		query.Set(k, v)
+	}
+	query.Set("EIO", "4")
+	query.Set("transport", "websocket")
+	endpoint.RawQuery = query.Encode()
+
+	tagsAndMeta := state.Tags.GetCurrentValues()
+	tagsAndMeta.SetSystemTagOrMetaIfEnabled(state.Options.SystemTags, metrics.TagURL, u.Scheme+"://"+u.Host+opts.path)
+	if !common.IsNullish(opts.tags) {
+		if err = common.ApplyCustomUserTags(mi.vu.Runtime(), &tagsAndMeta, opts.tags); err != nil {
+			return nil, fmt.Errorf("invalid tags: %w", err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(mi.vu.Context(), opts.timeout)
+	defer cancel()
+	start := time.Now()
+	tlsConfig := state.TLSConfig.Clone()
+	if tlsConfig != nil {
+		tlsConfig.NextProtos = []string{"http/1.1"}
+	}
+	wsd := websocket.Dialer{
+		NetDialContext:  state.Dialer.DialContext,
+		Proxy:           http.ProxyFromEnvironment,
+		TLSClientConfig: tlsConfig,
+	}
+	ws, resp, err := wsd.DialContext(ctx, endpoint.String(), opts.headers)
+	if resp != nil && resp.Body != nil {
+		_ = resp.Body.Close()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("the WebSocket handshake with %s failed: %w", serverURL, err)
+	}
+	open, err := readOpen(ctx, ws)
+	if err != nil {
+		_ = ws.Close()
+		return nil, err
+	}
+
+	conn := &connection{
+		mi:          mi,
+		ws:          ws,
+		opts:        opts,
+		readTimeout: time.Duration(open.PingInterval+open.PingTimeout) * time.Millisecond,
+		samples:     state.Samples,
+		tagsAndMeta: tagsAndMeta,
+		tq:          taskqueue.New(mi.vu.RegisterCallback),
+		sockets:     make(map[string]*Socket),
+		connecting:  make(map[string]chan connectResult),
+		done:        make(chan struct{}),
+	}
+	conn.stop = context.AfterFunc(mi.vu.Context(), func() { _ = ws.Close() })
+	go conn.readLoop()
+
+	s, err := conn.connect(namespace, opts.auth, start)
+	if err != nil {
+		conn.close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// readOpen reads the open packet of the Engine.IO session.
+func readOpen(ctx context.Context, ws *websocket.Conn) (openPacket, error) {
+	var open openPacket
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = ws.SetReadDeadline(deadline)
+	}
+	_, data, err := ws.ReadMessage()
+	if err != nil {
+		return open, fmt.Errorf("the Engine.IO handshake failed: %w", err)
+	}
+	if len(data) == 0 || data[0] != engineOpen {
+		return open, fmt.Errorf("the server responded with %q, instead of the Engine.IO open packet", data)
+	}
+	if err = json.Unmarshal(data[1:], &open); err != nil || open.PingInterval <= 0 || open.PingTimeout <= 0 {
+		return open, fmt.Errorf("the Engine.IO open packet %q is malformed", data)
+	}
+	return open, nil
+}
+
+// connect connects to the namespace, and waits for the server to accept it.
+func (conn *connection) connect(namespace string, auth sobek.Value, start time.Time) (*Socket, error) {
+	var data []byte
+	if !common.IsNullish(auth) {
+		var err error
+		if data, err = json.Marshal(auth.Export()); err != nil {
+			return nil, fmt.Errorf("invalid auth: %w", err)
+		}
+	}
+
+	ch := make(chan connectResult, 1)
+	conn.mx.Lock()
+	if conn.err != nil {
+		conn.mx.Unlock()
+		return nil, conn.closedError()
+	}
+	if _, ok := conn.sockets[namespace]; ok || conn.connecting[namespace] != nil {
+		conn.mx.Unlock()
+		return nil, fmt.Errorf("the socket is already connected to the namespace %s", namespace)
+	}
+	conn.connecting[namespace] = ch
+	conn.mx.Unlock()
+	forget := func() {
+		conn.mx.Lock()
+		delete(conn.connecting, namespace)
+		conn.mx.Unlock()
+	}
+
+	if err := conn.write(packet{kind: packetConnect, namespace: namespace, id: -1, data: string(data)}, nil); err != nil {
+		forget()
+		return nil, err
+	}
+	timer := time.NewTimer(conn.opts.timeout)
+	defer timer.Stop()
+	select {
+	case r := <-ch:
+		if r.err != nil {
+			return nil, r.err
+		}
+		now := time.Now()
+		conn.push(conn.mi.metrics.Connecting, r.socket.tagsAndMeta, now, metrics.D(now.Sub(start)))
+		return r.socket, nil
+	case <-conn.done:
+		return nil, conn.closedError()
+	case <-timer.C:
+		forget()
+		return nil, fmt.Errorf("the server didn't accept the connection to the namespace %s in %s",
+			namespace, conn.opts.timeout)
+	}
+}
+
+// On adds a listener of the events with the name, which the server emits, or
+// of the disconnect event.
+func (s *Socket) On(event string, listener sobek.Value) error {
+	fn, ok := sobek.AssertFunction(listener)
+	if !ok {
+		return fmt.Errorf("the listener of the %s event must be a function", event)
+	}
+	s.listeners[event] = append(s.listeners[event], fn)
+	return nil
+}
+
+// Emit emits the event with the arguments, whose ArrayBuffers are sent as
+// binary attachments. When the last argument is a function, it's called with
+// the arguments of the acknowledgement of the server.
+func (s *Socket) Emit(call sobek.FunctionCall) sobek.Value {
+	rt := s.conn.mi.vu.Runtime()
+	event, args := s.eventArguments(call)
+	var callback func([]sobek.Value) error
+	if n := len(args); n > 0 {
+		if fn, ok := sobek.AssertFunction(args[n-1]); ok {
+			args = args[:n-1]
+			callback = func(ackArgs []sobek.Value) error {
+				_, err := fn(sobek.Undefined(), ackArgs...)
+				return err
+			}
+		}
+	}
+	must(rt, s.emit(event, args, callback, nil))
+	return sobek.Undefined()
+}
+
+// EmitWithAck emits the event, and returns a promise of the first argument of
+// its acknowledgement, which is rejected, if the acknowledgement isn't received
+// in the timeout, or the socket is disconnected.
+func (s *Socket) EmitWithAck(call sobek.FunctionCall) sobek.Value {
+	rt := s.conn.mi.vu.Runtime()
+	event, args := s.eventArguments(call)
+	promise, resolve, reject := rt.NewPromise()
+	var timer *time.Timer
+	ack := func(ackArgs []sobek.Value) error {
+		timer.Stop()
+		if len(ackArgs) == 0 {
+			return resolve(sobek.Undefined())
+		}
+		return resolve(ackArgs[0])
+	}
+	fail := func(err error) error {
+		timer.Stop()
+		return reject(rt.NewGoError(err))
+	}
+	if err := s.emit(event, args, ack, fail); err != nil {
+		must(rt, reject(rt.NewGoError(err)))
+		return rt.ToValue(promise)
+	}
+
+	id, timeout := s.lastAckID, s.conn.opts.timeout
+	timer = time.AfterFunc(timeout, func() {
+		s.conn.tq.Queue(func() error {
+			if _, ok := s.acks[id]; !ok {
+				return nil
+			}
+			delete(s.acks, id)
+			return reject(rt.NewGoError(fmt.Errorf("the server didn't acknowledge the %s event in %s", event, timeout)))
+		})
+	})
+	return rt.ToValue(promise)
+}
+
+// Of connects to another namespace of the server, with the same connection.
+func (s *Socket) Of(namespace string, options sobek.Value) (*Socket, error) {
+	if namespace == "" || namespace[0] != '/' {
+		return nil, fmt.Errorf("the namespace %q must start with /", namespace)
+	}
+	var auth sobek.Value
+	if !common.IsNullish(options) {
+		obj := options.ToObject(s.conn.mi.vu.Runtime())
+		for _, k := range obj.Keys() {
+			if k != "auth" {
+				return nil, fmt.Errorf("unknown namespace option %q", k)
+			}
+			auth = obj.Get(k)
+		}
+	}
+	return s.conn.connect(namespace, auth, time.Now())
+}
+
+// Disconnect disconnects from the namespace. The connection is closed, when it
+// was the last namespace connected with it.
+func (s *Socket) Disconnect() error {
+	if !s.Connected {
+		return nil
+	}
+	_ = s.conn.write(packet{kind: packetDisconnect, namespace: s.Namespace, id: -1}, nil)
+	s.conn.remove(s)
+	return s.disconnected(reasonClientDisconnect)
+}
+
+func (s *Socket) eventArguments(call sobek.FunctionCall) (string, []sobek.Value) {
+	rt := s.conn.mi.vu.Runtime()
+	if common.IsNullish(call.Argument(0)) || call.Argument(0).String() == "" {
+		common.Throw(rt, errors.New("an event name is required"))
+	}
+	if len(call.Arguments) < 2 {
+		return call.Argument(0).String(), nil
+	}
+	return call.Argument(0).String(), call.Arguments[1:]
+}
+
+// emit sends the event, with an acknowledgement ID, if there's a callback.
+func (s *Socket) emit(
+	event string, args []sobek.Value, callback func([]sobek.Value) error, fail func(error) error,
+) error {
+	if !s.Connected {
+		return errNotConnected
+	}
+	rt := s.conn.mi.vu.Runtime()
+	data, attachments, err := encodeArguments(rt, append([]sobek.Value{rt.ToValue(event)}, args...))
+	if err != nil {
+		return err
+	}
+	p := packet{kind: packetEvent, namespace: s.Namespace, id: -1, data: data, attachments: len(attachments)}
+	if len(attachments) > 0 {
+		p.kind = packetBinaryEvent
+	}
+	if callback != nil {
+		s.lastAckID++
+		p.id = s.lastAckID
+		s.acks[p.id] = &pendingAck{event: event, sent: time.Now(), callback: callback, fail: fail}
+	}
+	if err = s.conn.write(p, attachments); err != nil {
+		delete(s.acks, p.id)
+		return fmt.Errorf("emitting the %s event failed: %w", event, err)
+	}
+	s.push(s.conn.mi.metrics.EventsSent, event, time.Now(), 1)
+	return nil
+}
+
+// dispatch calls the listeners of a received event. When the server requested
+// an acknowledgement, the last argument of the listeners is the function,
+// which sends it.
+func (s *Socket) dispatch(p packet, attachments [][]byte) error {
+	rt := s.conn.mi.vu.Runtime()
+	args, err := decodeArguments(rt, p.data, attachments)
+	if err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		return errMalformedPacket
+	}
+	event, args := args[0].String(), args[1:]
+	if p.id >= 0 {
+		args = append(args, rt.ToValue(s.acknowledge(p.id)))
+	}
+	for _, listener := range s.listeners[event] {
+		if _, err = listener(sobek.Undefined(), args...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// acknowledge returns the function, which sends the acknowledgement with the
+// ID, once.
+func (s *Socket) acknowledge(id int64) func(call sobek.FunctionCall) sobek.Value {
+	sent := false
+	return func(call sobek.FunctionCall) sobek.Value {
+		rt := s.conn.mi.vu.Runtime()
+		if sent || !s.Connected {
+			return sobek.Undefined()
+		}
+		sent = true
+		data, attachments, err := encodeArguments(rt, call.Arguments)
+		must(rt, err)
+		p := packet{kind: packetAck, namespace: s.Namespace, id: id, data: data, attachments: len(attachments)}
+		if len(attachments) > 0 {
+			p.kind = packetBinaryAck
+		}
+		must(rt, s.conn.write(p, attachments))
+		return sobek.Undefined()
+	}
+}
+
+// acknowledged calls the callback of a received acknowledgement.
+func (s *Socket) acknowledged(p packet, attachments [][]byte) error {
+	ack := s.acks[p.id]
+	if ack == nil {
+		return nil
+	}
+	delete(s.acks, p.id)
+	now := time.Now()
+	s.push(s.conn.mi.metrics.AckDuration, ack.event, now, metrics.D(now.Sub(ack.sent)))
+	args, err := decodeArguments(s.conn.mi.vu.Runtime(), p.data, attachments)
+	if err != nil {
+		return err
+	}
+	return ack.callback(args)
+}
+
+// disconnected rejects the pending acknowledgements, and calls the disconnect
+// listeners with the reason, once.
+func (s *Socket) disconnected(reason string) error {
+	if !s.Connected {
+		return nil
+	}
+	s.Connected = false
+	acks := s.acks
+	s.acks = make(map[int64]*pendingAck)
+	for _, ack := range acks {
+		if ack.fail == nil {
+			continue
+		}
+		if err := ack.fail(fmt.Errorf("the socket was disconnected before the acknowledgement of the %s event: %s",
+			ack.event, reason)); err != nil {
+			return err
+		}
+	}
+	if s.conn.mi.vu.Context().Err() != nil {
+		return nil
+	}
+	rt := s.conn.mi.vu.Runtime()
+	for _, listener := range s.listeners[eventDisconnect] {
+		if _, err := listener(sobek.Undefined(), rt.ToValue(reason)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Socket) push(metric *metrics.Metric, event string, t time.Time, value float64) {
+	tagsAndMeta := s.tagsAndMeta
+	tagsAndMeta.SetTag("event", event)
+	s.conn.push(metric, tagsAndMeta, t, value)
+}
+
+func (conn *connection) push(metric *metrics.Metric, tagsAndMeta metrics.TagsAndMeta, t time.Time, value float64) {
+	metrics.PushIfNotDone(conn.mi.vu.Context(), conn.samples, metrics.Sample{
+		TimeSeries: metrics.TimeSeries{Metric: metric, Tags: tagsAndMeta.Tags},
+		Time:       t,
+		Metadata:   tagsAndMeta.Metadata,
+		Value:      value,
+	})
+}
+
+// write writes the packet and its binary attachments, from the VU or from the
+// reading goroutine.
+func (conn *connection) write(p packet, attachments [][]byte) error {
+	conn.writeMx.Lock()
+	defer conn.writeMx.Unlock()
+	_ = conn.ws.SetWriteDeadline(time.Now().Add(conn.opts.timeout))
+	if err := conn.ws.WriteMessage(websocket.TextMessage, []byte(p.encode())); err != nil {
+		return err
+	}
+	for _, attachment := range attachments {
+		if err := conn.ws.WriteMessage(websocket.BinaryMessage, attachment); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (conn *connection) writeEngine(kind byte, data string) error {
+	conn.writeMx.Lock()
+	defer conn.writeMx.Unlock()
+	_ = conn.ws.SetWriteDeadline(time.Now().Add(conn.opts.timeout))
+	return conn.ws.WriteMessage(websocket.TextMessage, append([]byte{kind}, data...))
+}
+
+// remove removes the disconnected socket, and closes the connection, when it
+// was the last one.
+func (conn *connection) remove(s *Socket) {
+	conn.mx.Lock()
+	if conn.sockets[s.Namespace] == s {
+		delete(conn.sockets, s.Namespace)
+	}
+	last := len(conn.sockets) == 0 && len(conn.connecting) == 0
+	conn.mx.Unlock()
+	if last {
+		conn.close()
+	}
+}
+
+// close closes the connection, which stops the reading goroutine.
+func (conn *connection) close() {
+	conn.mx.Lock()
+	conn.closing = true
+	conn.mx.Unlock()
+	conn.writeMx.Lock()
+	_ = conn.ws.WriteControl(websocket.CloseMessage,
+		websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(time.Second))
+	conn.writeMx.Unlock()
+	_ = conn.ws.Close()
+}
+
+func (conn *connection) closedError() error {
+	conn.mx.Lock()
+	defer conn.mx.Unlock()
+	if conn.err == nil || errors.Is(conn.err, net.ErrClosed) {
+		return errors.New("the connection was closed")
+	}
+	return fmt.Errorf("the connection was closed: %w", conn.err)
+}
+
+func (conn *connection) readLoop() {
+	var (
+		binary      *packet
+		attachments [][]byte
+	)
+	for {
+		_ = conn.ws.SetReadDeadline(time.Now().Add(conn.readTimeout))
+		kind, data, err := conn.ws.ReadMessage()
+		if err == nil {
+			switch {
+			case kind == websocket.BinaryMessage && binary != nil:
+				attachments = append(attachments, data)
+				if len(attachments) == binary.attachments {
+					err = conn.handle(*binary, attachments)
+					binary, attachments = nil, nil
+				}
+			case kind == websocket.BinaryMessage:
+				err = errors.New("the server sent a binary message, which isn't an attachment of a packet")
+			default:
+				var p packet
+				var ok bool
+				if p, ok, err = conn.handleEngine(data); ok && err == nil {
+					if p.attachments > 0 {
+						binary = &p
+					} else {
+						err = conn.handle(p, nil)
+					}
+				}
+			}
+		}
+		if err != nil {
+			conn.closed(err)
+			return
+		}
+	}
+}
+
+// handleEngine handles an Engine.IO packet, and returns the Socket.IO packet of
+// its message, if it's one.
+func (conn *connection) handleEngine(data []byte) (packet, bool, error) {
+	if len(data) == 0 {
+		return packet{}, false, errMalformedPacket
+	}
+	switch data[0] {
+	case enginePing:
+		return packet{}, false, conn.writeEngine(enginePong, string(data[1:]))
+	case engineClose:
+		return packet{}, false, errServerClosed
+	case engineMessage:
+		p, err := decodePacket(string(data[1:]))
+		return p, err == nil, err
+	default:
+		return packet{}, false, nil
+	}
+}
+
+func (conn *connection) handle(p packet, attachments [][]byte) error {
+	switch p.kind {
+	case packetConnect, packetConnectError:
+		conn.mx.Lock()
+		ch := conn.connecting[p.namespace]
+		delete(conn.connecting, p.namespace)
+		var r connectResult
+		if ch != nil && p.kind == packetConnect {
+			var data struct {
+				SID string `json:"sid"`
+			}
+			_ = json.Unmarshal([]byte(p.data), &data)
+			r.socket = conn.newSocket(p.namespace, data.SID)
+			conn.sockets[p.namespace] = r.socket
+		} else {
+			r.err = p.connectError()
+		}
+		conn.mx.Unlock()
+		if ch != nil {
+			ch <- r
+		}
+		return nil
+	case packetEvent, packetBinaryEvent:
+		s := conn.socket(p.namespace)
+		if s == nil {
+			return nil
+		}
+		s.push(conn.mi.metrics.EventsReceived, eventName(p.data), time.Now(), 1)
+		conn.tq.Queue(func() error { return s.dispatch(p, attachments) })
+		return nil
+	case packetAck, packetBinaryAck:
+		if s := conn.socket(p.namespace); s != nil {
+			conn.tq.Queue(func() error { return s.acknowledged(p, attachments) })
+		}
+		return nil
+	case packetDisconnect:
+		conn.mx.Lock()
+		s := conn.sockets[p.namespace]
+		delete(conn.sockets, p.namespace)
+		last := len(conn.sockets) == 0 && len(conn.connecting) == 0
+		conn.mx.Unlock()
+		if s == nil {
+			return nil
+		}
+		conn.tq.Queue(func() error { return s.disconnected(reasonServerDisconnect) })
+		if last {
+			conn.close()
+		}
+		return nil
+	default:
+		return errMalformedPacket
+	}
+}
+
+func (conn *connection) newSocket(namespace, sid string) *Socket {
+	tagsAndMeta := conn.tagsAndMeta
+	tagsAndMeta.SetTag("namespace", namespace)
+	return &Socket{
+		ID:          sid,
+		Namespace:   namespace,
+		Connected:   true,
+		conn:        conn,
+		tagsAndMeta: tagsAndMeta,
+		listeners:   make(map[string][]sobek.Callable),
+		acks:        make(map[int64]*pendingAck),
+	}
+}
+
+func (conn *connection) socket(namespace string) *Socket {
+	conn.mx.Lock()
+	defer conn.mx.Unlock()
+	return conn.sockets[namespace]
+}
+
+// closed stops the connection, after it was closed, or after the error, and
+// disconnects the sockets with its reason.
+func (conn *connection) closed(err error) {
+	conn.mx.Lock()
+	var reason string
+	var netErr net.Error
+	switch {
+	case conn.closing:
+		reason, err = reasonClientDisconnect, nil
+	case errors.As(err, &netErr) && netErr.Timeout():
+		reason = reasonPingTimeout
+	case errors.Is(err, errServerClosed) || websocket.IsCloseError(err, websocket.CloseNormalClosure):
+		reason = reasonTransportClose
+	default:
+		reason = reasonTransportError
+	}
+	conn.err = err
+	if conn.err == nil {
+		conn.err = net.ErrClosed
+	}
+	sockets := conn.sockets
+	conn.sockets = make(map[string]*Socket)
+	conn.mx.Unlock()
+	close(conn.done)
+	conn.stop()
+	_ = conn.ws.Close()
+
+	conn.tq.Queue(func() error {
+		for _, s := range sockets {
+			if err := s.disconnected(reason); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	conn.tq.Close()
+}
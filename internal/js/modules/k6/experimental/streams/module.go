@@ -127,6 +127,14 @@ func newReadableStream(vu modules.VU, call sobek.ConstructorCall) *sobek.Object
 		}
 	}
 
+	// The async iterator is defined on the stream itself, since the streams
+	// created from Go don't have their own prototype.
+	err = streamObj.DefineDataPropertySymbol(common.AsyncIteratorSymbol(rt), rt.ToValue(stream.Values),
+		sobek.FLAG_TRUE, sobek.FLAG_TRUE, sobek.FLAG_FALSE)
+	if err != nil {
+		common.Throw(rt, newError(RuntimeError, err.Error()))
+	}
+
 	err = streamObj.SetPrototype(proto)
 	if err != nil {
 		common.Throw(rt, newError(RuntimeError, err.Error()))
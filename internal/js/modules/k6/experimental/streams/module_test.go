@@ -39,3 +39,55 @@ func TestNewReadableStreamFromReader(t *testing.T) {
 	require.True(t, ok)
 	assert.Equal(t, exp, p.Result().String())
 }
+
+func TestReadableStreamAsyncIterator(t *testing.T) {
+	t.Parallel()
+
+	r := modulestest.NewRuntime(t)
+	m := new(RootModule).NewModuleInstance(r.VU)
+	for k, v := range m.Exports().Named {
+		require.NoError(t, r.VU.RuntimeField.Set(k, v))
+	}
+
+	var ret sobek.Value
+	err := r.EventLoop.Start(func() (err error) {
+		ret, err = r.VU.Runtime().RunString(`(async () => {
+  const newStream = (cancelled) => new ReadableStream({
+    start(controller) {
+      ["a", "b", "c"].forEach((chunk) => controller.enqueue(chunk));
+      controller.close();
+    },
+    cancel(reason) { cancelled.push(reason) },
+  });
+
+  const chunks = [];
+  const stream = newStream([]);
+  const it = stream[Symbol.asyncIterator]();
+  if (it[Symbol.asyncIterator]() !== it) throw new Error("the iterator isn't async iterable");
+  for (let res = await it.next(); !res.done; res = await it.next()) {
+    chunks.push(res.value);
+  }
+  if (!(await it.next()).done) throw new Error("the iterator isn't done");
+  if (stream.locked) throw new Error("the stream is still locked");
+
+  const cancelled = [];
+  const returned = newStream(cancelled).values();
+  chunks.push((await returned.next()).value);
+  const res = await returned.return("stop");
+  chunks.push(res.value, res.done, cancelled.join());
+
+  const prevented = newStream(cancelled).values({ preventCancel: true });
+  await prevented.next();
+  await prevented.return();
+  chunks.push(cancelled.length);
+  return chunks.join(",");
+})()`)
+		return err
+	})
+	require.NoError(t, err)
+
+	p, ok := ret.Export().(*sobek.Promise)
+	require.True(t, ok)
+	require.Equal(t, sobek.PromiseStateFulfilled, p.State(), p.Result())
+	assert.Equal(t, "a,b,c,a,stop,true,stop,1", p.Result().String())
+}
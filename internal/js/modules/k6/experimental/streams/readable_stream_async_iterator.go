@@ -0,0 +1,131 @@
+package streams
+
+import (
+	"github.com/grafana/sobek"
+
+	"go.k6.io/k6/js/common"
+)
+
+// Values implements the [values] operation, returning an async iterator over
+// the chunks of the stream, which is also returned by its Symbol.asyncIterator
+// method, so it can be read with a for await loop.
+//
+// [values]: https://streams.spec.whatwg.org/#rs-asynciterator
+func (stream *ReadableStream) Values(options *sobek.Object) *sobek.Object {
+	rt := stream.runtime
+
+	// 1. Let reader be ? AcquireReadableStreamDefaultReader(stream).
+	reader := stream.acquireDefaultReader()
+
+	// 2. Set iterator's reader to reader.
+	// 3. Let preventCancel be args[0]["preventCancel"].
+	// 4. Set iterator's prevent cancel to preventCancel.
+	preventCancel := false
+	if options != nil && !common.IsNullish(options) {
+		if v := options.Get("preventCancel"); v != nil {
+			preventCancel = v.ToBoolean()
+		}
+	}
+
+	finished := false
+	result := func(value any) map[string]any {
+		return map[string]any{"value": value, "done": true}
+	}
+
+	// [get the next iteration result] steps.
+	//
+	// [get the next iteration result]: https://streams.spec.whatwg.org/#ref-for-dfn-get-the-next-iteration-result
+	next := func() *sobek.Promise {
+		if finished {
+			return newResolvedPromise(stream.vu, rt.ToValue(result(sobek.Undefined())))
+		}
+
+		// 1. Let reader be iterator's reader.
+		// 2. Assert: reader.[[stream]] is not undefined.
+		// 3. Let promise be a new promise.
+		promise, resolve, reject := rt.NewPromise()
+
+		// 4. Let readRequest be a new read request with the following items:
+		readRequest := ReadRequest{
+			chunkSteps: func(chunk any) {
+				// Resolve promise with chunk.
+				if err := resolve(map[string]any{"value": chunk, "done": false}); err != nil {
+					panic(err)
+				}
+			},
+			closeSteps: func() {
+				// Perform ! ReadableStreamDefaultReaderRelease(reader).
+				reader.release()
+				finished = true
+				// Resolve promise with end of iteration.
+				if err := resolve(result(sobek.Undefined())); err != nil {
+					panic(err)
+				}
+			},
+			errorSteps: func(e any) {
+				// Perform ! ReadableStreamDefaultReaderRelease(reader).
+				reader.release()
+				finished = true
+				// Reject promise with e.
+				if err := reject(e); err != nil {
+					panic(err)
+				}
+			},
+		}
+
+		// 5. Perform ! ReadableStreamDefaultReaderRead(this, readRequest).
+		reader.read(readRequest)
+
+		// 6. Return promise.
+		return promise
+	}
+
+	// [asynchronous iterator return] steps.
+	//
+	// [asynchronous iterator return]: https://streams.spec.whatwg.org/#ref-for-asynchronous-iterator-return
+	ret := func(arg sobek.Value) *sobek.Promise {
+		if finished {
+			return newResolvedPromise(stream.vu, rt.ToValue(result(arg)))
+		}
+		finished = true
+
+		// 1. Let reader be iterator's reader.
+		// 2. Assert: reader.[[stream]] is not undefined.
+		// 3. Assert: reader.[[readRequests]] is empty.
+		// 4. If iterator's prevent cancel is false:
+		if !preventCancel {
+			// 4.1. Let result be ! ReadableStreamReaderGenericCancel(reader, arg).
+			cancelPromise := reader.cancel(arg)
+
+			// 4.2. Perform ! ReadableStreamDefaultReaderRelease(reader).
+			reader.release()
+
+			// 4.3. Return result.
+			promise, resolve, reject := rt.NewPromise()
+			_, err := promiseThen(rt, cancelPromise,
+				func(sobek.Value) {
+					if err := resolve(result(arg)); err != nil {
+						panic(err)
+					}
+				},
+				func(e sobek.Value) {
+					if err := reject(e); err != nil {
+						panic(err)
+					}
+				},
+			)
+			if err != nil {
+				common.Throw(rt, err)
+			}
+			return promise
+		}
+
+		// 5. Perform ! ReadableStreamDefaultReaderRelease(reader).
+		reader.release()
+
+		// 6. Return a promise resolved with undefined.
+		return newResolvedPromise(stream.vu, rt.ToValue(result(arg)))
+	}
+
+	return common.NewAsyncIterator(rt, next, ret)
+}
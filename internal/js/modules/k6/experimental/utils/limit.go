@@ -0,0 +1,117 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/grafana/sobek"
+
+	"go.k6.io/k6/js/common"
+)
+
+// ErrCleared is the rejection reason of the tasks, which were cleared before
+// they started.
+var ErrCleared = errors.New("the task was cleared before it started")
+
+// limiter runs at most concurrency tasks at a time, in the order in which
+// they were added.
+type limiter struct {
+	rt          *sobek.Runtime
+	concurrency int
+	active      int
+	pending     []task
+}
+
+// task is an async function, with its arguments, and the promise returned for
+// it.
+type task struct {
+	fn              sobek.Callable
+	args            []sobek.Value
+	resolve, reject func(any) error
+}
+
+// Limit returns the function, which runs the async functions, with at most
+// concurrency of them running at a time, and returns the promises of their
+// results. A function starts synchronously, when it's added and there's a free
+// slot, or once the promise of another one is settled. The returned function
+// has the activeCount and the pendingCount properties, and the clearQueue
+// method, which rejects the promises of the functions, which didn't start.
+func (mi *ModuleInstance) Limit(concurrency sobek.Value) *sobek.Object {
+	rt := mi.vu.Runtime()
+	if concurrency == nil {
+		concurrency = sobek.Undefined()
+	}
+	if common.IsNullish(concurrency) || concurrency.ToInteger() < 1 ||
+		float64(concurrency.ToInteger()) != concurrency.ToFloat() {
+		common.Throw(rt, fmt.Errorf("the concurrency must be a positive integer, but it's %s", concurrency))
+	}
+	l := &limiter{rt: rt, concurrency: int(concurrency.ToInteger())}
+
+	obj := rt.ToValue(l.run).ToObject(rt)
+	for name, getter := range map[string]func() int{
+		"activeCount":  func() int { return l.active },
+		"pendingCount": func() int { return len(l.pending) },
+		"concurrency":  func() int { return l.concurrency },
+	} {
+		must(rt, obj.DefineAccessorProperty(name, rt.ToValue(getter), nil, sobek.FLAG_FALSE, sobek.FLAG_TRUE))
+	}
+	must(rt, obj.Set("clearQueue", l.clearQueue))
+	return obj
+}
+
+// run adds the task, and returns the promise of its result.
+func (l *limiter) run(call sobek.FunctionCall) sobek.Value {
+	fn, ok := sobek.AssertFunction(call.Argument(0))
+	if !ok {
+		common.Throw(l.rt, errors.New("the task must be a function"))
+	}
+	promise, resolve, reject := l.rt.NewPromise()
+	var args []sobek.Value
+	if len(call.Arguments) > 1 {
+		// the arguments are copied, since the runtime reuses their slice
+		args = append(args, call.Arguments[1:]...)
+	}
+	l.pending = append(l.pending, task{fn: fn, args: args, resolve: resolve, reject: reject})
+	l.next()
+	return l.rt.ToValue(promise)
+}
+
+// next starts the pending tasks, while there are free slots.
+func (l *limiter) next() {
+	for l.active < l.concurrency && len(l.pending) > 0 {
+		t := l.pending[0]
+		l.pending = l.pending[1:]
+		l.start(t)
+	}
+}
+
+func (l *limiter) start(t task) {
+	l.active++
+	result, err := t.fn(sobek.Undefined(), t.args...)
+	if err != nil {
+		l.active--
+		must(l.rt, t.reject(reason(l.rt, err)))
+		return
+	}
+	then(l.rt, result,
+		func(v sobek.Value) {
+			l.active--
+			must(l.rt, t.resolve(v))
+			l.next()
+		},
+		func(e sobek.Value) {
+			l.active--
+			must(l.rt, t.reject(e))
+			l.next()
+		},
+	)
+}
+
+// clearQueue rejects the promises of the pending tasks, which won't start.
+func (l *limiter) clearQueue() {
+	pending := l.pending
+	l.pending = nil
+	for _, t := range pending {
+		must(l.rt, t.reject(l.rt.NewGoError(ErrCleared)))
+	}
+}
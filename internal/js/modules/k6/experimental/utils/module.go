@@ -0,0 +1,79 @@
+// Package utils provides the concurrency primitives of the scripts, a limit of
+// the concurrent async tasks, like p-limit, and an async queue. They're built
+// on the promises of the runtime, so they run on the event loop of the VU,
+// without any locks or goroutines.
+package utils
+
+import (
+	"errors"
+
+	"github.com/grafana/sobek"
+
+	"go.k6.io/k6/js/common"
+	"go.k6.io/k6/js/modules"
+)
+
+type (
+	// RootModule is the global module instance that will create instances of our
+	// module for each VU.
+	RootModule struct{}
+
+	// ModuleInstance represents an instance of the utils module for a single VU.
+	ModuleInstance struct {
+		vu modules.VU
+	}
+)
+
+var (
+	_ modules.Module   = &RootModule{}
+	_ modules.Instance = &ModuleInstance{}
+)
+
+// New returns a pointer to a new [RootModule] instance.
+func New() *RootModule {
+	return &RootModule{}
+}
+
+// NewModuleInstance implements the modules.Module interface and returns a new
+// instance of our module for the given VU.
+func (*RootModule) NewModuleInstance(vu modules.VU) modules.Instance {
+	return &ModuleInstance{vu: vu}
+}
+
+// Exports implements the modules.Module interface and returns the exports of
+// our module.
+func (mi *ModuleInstance) Exports() modules.Exports {
+	return modules.Exports{
+		Named: map[string]any{
+			"limit": mi.Limit,
+			"Queue": mi.NewQueue,
+		},
+	}
+}
+
+// then calls one of the handlers, once the value is settled, like
+// Promise.resolve(value).then(onFulfilled, onRejected) does.
+func then(rt *sobek.Runtime, value sobek.Value, onFulfilled, onRejected func(sobek.Value)) {
+	promise, resolve, _ := rt.NewPromise()
+	must(rt, resolve(value))
+	obj := rt.ToValue(promise).ToObject(rt)
+	fn, _ := sobek.AssertFunction(obj.Get("then"))
+	_, err := fn(obj, rt.ToValue(onFulfilled), rt.ToValue(onRejected))
+	must(rt, err)
+}
+
+// reason returns the rejection reason of the error of a call, i.e. the value
+// thrown by it.
+func reason(rt *sobek.Runtime, err error) sobek.Value {
+	var exception *sobek.Exception
+	if errors.As(err, &exception) {
+		return exception.Value()
+	}
+	return rt.NewGoError(err)
+}
+
+func must(rt *sobek.Runtime, err error) {
+	if err != nil {
+		common.Throw(rt, err)
+	}
+}
@@ -0,0 +1,163 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/internal/lib/testutils"
+	"go.k6.io/k6/js/modulestest"
+	"go.k6.io/k6/lib"
+)
+
+// newTestRuntime returns a runtime in the VU context, with the utils module
+// and setTimeout.
+func newTestRuntime(t *testing.T) *modulestest.Runtime {
+	t.Helper()
+
+	runtime := modulestest.NewRuntime(t)
+	require.NoError(t, runtime.SetupModule("k6/experimental/utils", New(),
+		`const { limit, Queue } = require("k6/experimental/utils");`))
+	runtime.MoveToVUContext(&lib.State{Logger: testutils.NewLogger(t)})
+	return runtime
+}
+
+// run runs the async script on the event loop, and returns the value of its
+// result variable.
+func run(t *testing.T, runtime *modulestest.Runtime, script string) any {
+	t.Helper()
+	_, err := runtime.RunOnEventLoop(`let result; (async () => {` + script + `})().catch((e) => { result = "error: " + e; });`)
+	require.NoError(t, err)
+	return runtime.VU.Runtime().Get("result").Export()
+}
+
+func TestLimit(t *testing.T) {
+	t.Parallel()
+
+	t.Run("concurrency", func(t *testing.T) {
+		t.Parallel()
+		result := run(t, newTestRuntime(t), `
+			const run = limit(2);
+			let active = 0, max = 0;
+			const task = async (i) => {
+				active++;
+				max = Math.max(max, active);
+				await new Promise((resolve) => setTimeout(resolve, 5));
+				active--;
+				return i * 10;
+			};
+			const promises = [1, 2, 3, 4, 5].map((i) => run(task, i));
+			if (run.activeCount !== 2 || run.pendingCount !== 3) {
+				throw new Error("unexpected counts: " + run.activeCount + " " + run.pendingCount);
+			}
+			const values = await Promise.all(promises);
+			result = values.join() + " " + max + " " + run.activeCount;
+		`)
+		assert.Equal(t, "10,20,30,40,50 2 0", result)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		t.Parallel()
+		result := run(t, newTestRuntime(t), `
+			const run = limit(1);
+			const results = await Promise.allSettled([
+				run(() => { throw new Error("sync"); }),
+				run(async () => { throw new Error("async"); }),
+				run(() => "value"),
+			]);
+			result = results.map((r) => r.status === "fulfilled" ? r.value : r.reason.message).join();
+		`)
+		assert.Equal(t, "sync,async,value", result)
+	})
+
+	t.Run("clearQueue", func(t *testing.T) {
+		t.Parallel()
+		result := run(t, newTestRuntime(t), `
+			const run = limit(1);
+			const first = run(() => new Promise((resolve) => setTimeout(() => resolve("first"), 5)));
+			const second = run(() => "second");
+			run.clearQueue();
+			const results = await Promise.allSettled([first, second]);
+			result = results.map((r) => r.status === "fulfilled" ? r.value : r.reason.toString()).join();
+		`)
+		assert.Equal(t, "first,GoError: the task was cleared before it started", result)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		t.Parallel()
+		runtime := newTestRuntime(t)
+		for script, message := range map[string]string{
+			`limit(0)`:          "the concurrency must be a positive integer, but it's 0",
+			`limit(1.5)`:        "the concurrency must be a positive integer, but it's 1.5",
+			`limit()`:           "the concurrency must be a positive integer, but it's undefined",
+			`limit(1)("value")`: "the task must be a function",
+		} {
+			_, err := runtime.VU.Runtime().RunString(script)
+			assert.ErrorContains(t, err, message, script)
+		}
+	})
+}
+
+func TestQueue(t *testing.T) {
+	t.Parallel()
+
+	t.Run("producer and consumer", func(t *testing.T) {
+		t.Parallel()
+		result := run(t, newTestRuntime(t), `
+			const queue = new Queue({ capacity: 2 });
+			const consumed = [];
+			const consumer = (async () => {
+				const it = queue[Symbol.asyncIterator]();
+				for (let next = await it.next(); !next.done; next = await it.next()) {
+					consumed.push(next.value);
+					await new Promise((resolve) => setTimeout(resolve, 1));
+				}
+			})();
+			let sizes = [];
+			for (let i = 1; i <= 5; i++) {
+				await queue.push(i);
+				sizes.push(queue.size);
+			}
+			queue.close();
+			await consumer;
+			result = consumed.join() + " " + sizes.every((s) => s <= 2) + " " + queue.closed;
+		`)
+		assert.Equal(t, "1,2,3,4,5 true true", result)
+	})
+
+	t.Run("close", func(t *testing.T) {
+		t.Parallel()
+		result := run(t, newTestRuntime(t), `
+			const queue = new Queue({ capacity: 1 });
+			await queue.push("first");
+			const blocked = queue.push("second");
+			queue.close();
+			const results = await Promise.allSettled([blocked, queue.pop(), queue.pop(), queue.push("third")]);
+			result = results.map((r) => r.status === "fulfilled" ? r.value : r.reason.toString()).join();
+		`)
+		assert.Equal(t, "GoError: the queue is closed,first,GoError: the queue is closed,GoError: the queue is closed",
+			result)
+	})
+
+	t.Run("waiting pop", func(t *testing.T) {
+		t.Parallel()
+		result := run(t, newTestRuntime(t), `
+			const queue = new Queue();
+			const pops = [queue.pop(), queue.pop()];
+			setTimeout(() => { queue.push("a"); queue.close(); }, 1);
+			const results = await Promise.allSettled(pops);
+			result = results.map((r) => r.status === "fulfilled" ? r.value : r.reason.toString()).join();
+		`)
+		assert.Equal(t, "a,GoError: the queue is closed", result)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		t.Parallel()
+		runtime := newTestRuntime(t)
+		_, err := runtime.VU.Runtime().RunString(`new Queue({ capacity: -1 })`)
+		assert.ErrorContains(t, err, "the capacity must be a non-negative integer, but it's -1")
+		_, err = runtime.VU.Runtime().RunString(`new Queue({ size: 1 })`)
+		assert.ErrorContains(t, err, `unknown queue option "size"`)
+	})
+}
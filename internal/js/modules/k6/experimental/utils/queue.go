@@ -0,0 +1,163 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/grafana/sobek"
+
+	"go.k6.io/k6/js/common"
+)
+
+// ErrQueueClosed is the rejection reason of the pushes to a closed queue, and
+// of the pops from a closed queue without items.
+var ErrQueueClosed = errors.New("the queue is closed")
+
+// Queue is an async FIFO queue of the values of a VU, whose pops wait for the
+// pushes, and whose pushes wait for the pops, when it's at its capacity. It's
+// closed by its producers, after which its items can still be popped, e.g.
+// with its async iterator, which ends once it's empty.
+type Queue struct {
+	rt *sobek.Runtime
+	// capacity is unlimited, if it's 0
+	capacity int
+	items    []sobek.Value
+	closed   bool
+	// pops are the pops waiting for an item, when the queue is empty, and
+	// pushes are the pushes waiting for the capacity, when it's full
+	pops   []waitingPop
+	pushes []waitingPush
+}
+
+type waitingPop struct {
+	onItem   func(sobek.Value)
+	onClosed func()
+}
+
+type waitingPush struct {
+	item            sobek.Value
+	resolve, reject func(any) error
+}
+
+// NewQueue is the JS constructor of the Queue, whose options have its
+// capacity, which is unlimited by default.
+func (mi *ModuleInstance) NewQueue(call sobek.ConstructorCall) *sobek.Object {
+	rt := mi.vu.Runtime()
+	q := &Queue{rt: rt}
+	if options := call.Argument(0); !common.IsNullish(options) {
+		obj := options.ToObject(rt)
+		for _, k := range obj.Keys() {
+			v := obj.Get(k)
+			switch k {
+			case "capacity":
+				if c := v.ToInteger(); c < 0 || float64(c) != v.ToFloat() {
+					common.Throw(rt, fmt.Errorf("the capacity must be a non-negative integer, but it's %s", v))
+				}
+				q.capacity = int(v.ToInteger())
+			default:
+				common.Throw(rt, fmt.Errorf("unknown queue option %q", k))
+			}
+		}
+	}
+
+	obj := rt.NewObject()
+	for name, method := range map[string]any{
+		"push":  q.Push,
+		"pop":   q.Pop,
+		"close": q.Close,
+	} {
+		must(rt, obj.Set(name, method))
+	}
+	for name, getter := range map[string]any{
+		"size":     func() int { return len(q.items) },
+		"capacity": func() int { return q.capacity },
+		"closed":   func() bool { return q.closed },
+	} {
+		must(rt, obj.DefineAccessorProperty(name, rt.ToValue(getter), nil, sobek.FLAG_FALSE, sobek.FLAG_TRUE))
+	}
+	must(rt, obj.SetSymbol(common.AsyncIteratorSymbol(rt), func() *sobek.Object {
+		return common.NewAsyncIterator(rt, q.next, nil)
+	}))
+	return obj
+}
+
+// Push returns the promise, which is resolved, once the item is in the queue,
+// or handed to a waiting pop.
+func (q *Queue) Push(item sobek.Value) *sobek.Promise {
+	promise, resolve, reject := q.rt.NewPromise()
+	switch {
+	case q.closed:
+		must(q.rt, reject(q.rt.NewGoError(ErrQueueClosed)))
+	case len(q.pops) > 0:
+		pop := q.pops[0]
+		q.pops = q.pops[1:]
+		pop.onItem(item)
+		must(q.rt, resolve(sobek.Undefined()))
+	case q.capacity == 0 || len(q.items) < q.capacity:
+		q.items = append(q.items, item)
+		must(q.rt, resolve(sobek.Undefined()))
+	default:
+		q.pushes = append(q.pushes, waitingPush{item: item, resolve: resolve, reject: reject})
+	}
+	return promise
+}
+
+// Pop returns the promise of the next item, which is rejected, if the queue is
+// closed without any.
+func (q *Queue) Pop() *sobek.Promise {
+	promise, resolve, reject := q.rt.NewPromise()
+	q.take(
+		func(item sobek.Value) { must(q.rt, resolve(item)) },
+		func() { must(q.rt, reject(q.rt.NewGoError(ErrQueueClosed))) },
+	)
+	return promise
+}
+
+// next is the next method of the async iterator of the queue.
+func (q *Queue) next() *sobek.Promise {
+	promise, resolve, _ := q.rt.NewPromise()
+	q.take(
+		func(item sobek.Value) { must(q.rt, resolve(map[string]any{"value": item, "done": false})) },
+		func() { must(q.rt, resolve(map[string]any{"value": sobek.Undefined(), "done": true})) },
+	)
+	return promise
+}
+
+// take passes the next item to onItem, or calls onClosed, if the queue is
+// closed without any, once either happens.
+func (q *Queue) take(onItem func(sobek.Value), onClosed func()) {
+	if len(q.items) == 0 {
+		if q.closed {
+			onClosed()
+		} else {
+			q.pops = append(q.pops, waitingPop{onItem: onItem, onClosed: onClosed})
+		}
+		return
+	}
+	item := q.items[0]
+	q.items = q.items[1:]
+	if len(q.pushes) > 0 {
+		push := q.pushes[0]
+		q.pushes = q.pushes[1:]
+		q.items = append(q.items, push.item)
+		must(q.rt, push.resolve(sobek.Undefined()))
+	}
+	onItem(item)
+}
+
+// Close closes the queue, rejecting the waiting pushes, and ending the
+// waiting pops, which are waiting only when the queue is empty.
+func (q *Queue) Close() {
+	if q.closed {
+		return
+	}
+	q.closed = true
+	pops, pushes := q.pops, q.pushes
+	q.pops, q.pushes = nil, nil
+	for _, pop := range pops {
+		pop.onClosed()
+	}
+	for _, push := range pushes {
+		must(q.rt, push.reject(q.rt.NewGoError(ErrQueueClosed)))
+	}
+}
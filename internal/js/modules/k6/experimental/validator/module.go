@@ -0,0 +1,290 @@
+// Package validator validates values against JSON Schemas and responses
+// against OpenAPI documents, with the paths of the parts, which don't match, so
+// the tests can catch the drift of the APIs from their contracts.
+package validator
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grafana/sobek"
+
+	"go.k6.io/k6/js/common"
+	"go.k6.io/k6/js/modules"
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/metrics"
+)
+
+const (
+	defaultSchemaCheckName  = "matches the JSON schema"
+	defaultOpenAPICheckName = "matches the OpenAPI document"
+)
+
+type (
+	// RootModule is the global module instance that will create instances of our
+	// module for each VU. It caches the parsed documents, so every VU doesn't
+	// parse its own copy of them.
+	RootModule struct {
+		mu   sync.Mutex
+		docs map[[sha256.Size]byte]*document
+	}
+
+	// ModuleInstance represents an instance of the validator module for a single VU.
+	ModuleInstance struct {
+		vu   modules.VU
+		root *RootModule
+	}
+)
+
+var (
+	_ modules.Module   = &RootModule{}
+	_ modules.Instance = &ModuleInstance{}
+)
+
+// New returns a pointer to a new [RootModule] instance.
+func New() *RootModule {
+	return &RootModule{docs: make(map[[sha256.Size]byte]*document)}
+}
+
+// NewModuleInstance implements the modules.Module interface and returns a new
+// instance of our module for the given VU.
+func (r *RootModule) NewModuleInstance(vu modules.VU) modules.Instance {
+	return &ModuleInstance{vu: vu, root: r}
+}
+
+// Exports implements the modules.Module interface and returns the exports of
+// our module.
+func (mi *ModuleInstance) Exports() modules.Exports {
+	return modules.Exports{
+		Named: map[string]any{
+			"jsonSchema": mi.JSONSchema,
+			"openAPI":    mi.OpenAPI,
+		},
+	}
+}
+
+// document returns the parsed document of the source, which is a JSON or YAML
+// string, or an object.
+func (r *RootModule) document(source sobek.Value) (*document, error) {
+	if common.IsNullish(source) {
+		return nil, errors.New("the document is required")
+	}
+
+	var data []byte
+	switch exported := source.Export().(type) {
+	case string:
+		data = []byte(exported)
+	case []byte:
+		data = exported
+	case sobek.ArrayBuffer:
+		data = exported.Bytes()
+	default:
+		// the objects are parsed from their JSON, which YAML is a superset of
+		var err error
+		if data, err = json.Marshal(exported); err != nil {
+			return nil, err
+		}
+	}
+
+	key := sha256.Sum256(data)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if doc, ok := r.docs[key]; ok {
+		return doc, nil
+	}
+	doc, err := parseDocument(data)
+	if err != nil {
+		return nil, err
+	}
+	r.docs[key] = doc
+	return doc, nil
+}
+
+// JSONSchema returns a JSON Schema, which validates the values. It's usually
+// created once in the init context.
+func (mi *ModuleInstance) JSONSchema(source sobek.Value) *Schema {
+	rt := mi.vu.Runtime()
+	doc, err := mi.root.document(source)
+	if err != nil {
+		common.Throw(rt, fmt.Errorf("invalid JSON schema: %w", err))
+	}
+	return &Schema{mi: mi, doc: doc}
+}
+
+// OpenAPI returns an OpenAPI 3 document, which validates the responses. It's
+// usually created once in the init context.
+func (mi *ModuleInstance) OpenAPI(source sobek.Value) *OpenAPI {
+	rt := mi.vu.Runtime()
+	doc, err := mi.root.document(source)
+	if err != nil {
+		common.Throw(rt, fmt.Errorf("invalid OpenAPI document: %w", err))
+	}
+	o, err := newOpenAPI(doc)
+	if err != nil {
+		common.Throw(rt, fmt.Errorf("invalid OpenAPI document: %w", err))
+	}
+	return &OpenAPI{mi: mi, api: o}
+}
+
+// Result is the result of a validation.
+type Result struct {
+	Valid  bool              `js:"valid"`
+	Errors []ValidationError `js:"errors"`
+}
+
+func newResult(errs []ValidationError) *Result {
+	if errs == nil {
+		errs = []ValidationError{}
+	}
+	return &Result{Valid: len(errs) == 0, Errors: errs}
+}
+
+// String returns the errors, one per line.
+func (r *Result) String() string {
+	lines := make([]string, len(r.Errors))
+	for i, err := range r.Errors {
+		lines[i] = err.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Schema is a JSON Schema.
+type Schema struct {
+	mi  *ModuleInstance
+	doc *document
+}
+
+// Validate validates the value against the schema.
+func (s *Schema) Validate(value sobek.Value) *Result {
+	rt := s.mi.vu.Runtime()
+	var exported any
+	if value != nil {
+		exported = value.Export()
+	}
+	instance, err := normalize(exported)
+	if err != nil {
+		common.Throw(rt, err)
+	}
+	v := &validation{doc: s.doc}
+	v.validate(s.doc.root, instance, "", 0)
+	return newResult(v.errors)
+}
+
+// Check validates the value against the schema and records the result as a
+// check, with the name or the default one.
+func (s *Schema) Check(value sobek.Value, name sobek.Value) *Result {
+	result := s.Validate(value)
+	s.mi.check(checkName(name, defaultSchemaCheckName), result)
+	return result
+}
+
+// OpenAPI is an OpenAPI 3 document.
+type OpenAPI struct {
+	mi  *ModuleInstance
+	api *openAPI
+}
+
+// Validate validates the status, the headers and the body of the k6/http
+// response against the operation of its request.
+func (o *OpenAPI) Validate(res sobek.Value) *Result {
+	rt := o.mi.vu.Runtime()
+	r, err := exportResponse(rt, res)
+	if err != nil {
+		common.Throw(rt, err)
+	}
+	return newResult(o.api.validate(r))
+}
+
+// Check validates the response against the OpenAPI document and records the
+// result as a check, with the name or the default one.
+func (o *OpenAPI) Check(res sobek.Value, name sobek.Value) *Result {
+	result := o.Validate(res)
+	o.mi.check(checkName(name, defaultOpenAPICheckName), result)
+	return result
+}
+
+// exportResponse returns the parts of a k6/http response, which are validated.
+func exportResponse(rt *sobek.Runtime, res sobek.Value) (response, error) {
+	if common.IsNullish(res) {
+		return response{}, errors.New("the response is required")
+	}
+	obj := res.ToObject(rt)
+	r := response{
+		URL:     obj.Get("url").String(),
+		Status:  int(obj.Get("status").ToInteger()),
+		Headers: make(map[string]string),
+		Method:  "GET",
+	}
+	if req := obj.Get("request"); !common.IsNullish(req) {
+		if method := req.ToObject(rt).Get("method"); !common.IsNullish(method) {
+			r.Method = method.String()
+		}
+	}
+	if headers := obj.Get("headers"); !common.IsNullish(headers) {
+		h := headers.ToObject(rt)
+		for _, key := range h.Keys() {
+			r.Headers[key] = h.Get(key).String()
+		}
+	}
+	if body := obj.Get("body"); !common.IsNullish(body) {
+		switch b := body.Export().(type) {
+		case string:
+			r.Body = b
+		case []byte:
+			r.Body = string(b)
+		case sobek.ArrayBuffer:
+			r.Body = string(b.Bytes())
+		default:
+			r.Body = body.String()
+		}
+	}
+	return r, nil
+}
+
+func checkName(name sobek.Value, defaultName string) string {
+	if common.IsNullish(name) {
+		return defaultName
+	}
+	return name.String()
+}
+
+// check records the result of a validation as a check, like the check of the
+// k6 module does.
+func (mi *ModuleInstance) check(name string, result *Result) {
+	state := mi.vu.State()
+	rt := mi.vu.Runtime()
+	if state == nil {
+		common.Throw(rt, errors.New("checking in the init context is not supported"))
+	}
+	if strings.Contains(name, lib.GroupSeparator) {
+		common.Throw(rt, lib.ErrNameContainsGroupSeparator)
+	}
+
+	tagsAndMeta := state.Tags.GetCurrentValues()
+	tags := tagsAndMeta.Tags
+	if state.Options.SystemTags.Has(metrics.TagCheck) {
+		tags = tags.With("check", name)
+	}
+	sample := metrics.Sample{
+		TimeSeries: metrics.TimeSeries{
+			Metric: state.BuiltinMetrics.Checks,
+			Tags:   tags,
+		},
+		Time:     time.Now(),
+		Metadata: tagsAndMeta.Metadata,
+	}
+	if result.Valid {
+		sample.Value = 1
+	} else {
+		if state.FlightRecorder != nil {
+			state.FlightRecorder.Fail()
+		}
+		state.Logger.WithField("check", name).Debugf("The validation failed:\n%s", result)
+	}
+	metrics.PushIfNotDone(mi.vu.Context(), state.Samples, sample)
+}
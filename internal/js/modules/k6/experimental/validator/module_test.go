@@ -0,0 +1,197 @@
+package validator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/js/modulestest"
+	"go.k6.io/k6/metrics"
+)
+
+func newTestRuntime(t *testing.T, initScript string) (*modulestest.Runtime, chan metrics.SampleContainer) {
+	t.Helper()
+
+	runtime := modulestest.NewRuntime(t)
+	require.NoError(t, runtime.SetupModule("k6/experimental/validator", New(),
+		`const validator = require("k6/experimental/validator");`+initScript))
+
+	state, samples := modulestest.NewVUState(t, 100)
+	runtime.MoveToVUContext(state)
+	return runtime, samples
+}
+
+func TestJSONSchema(t *testing.T) {
+	t.Parallel()
+
+	runtime, samples := newTestRuntime(t, `
+		const user = validator.jsonSchema({
+			type: "object",
+			required: ["id", "name", "roles"],
+			additionalProperties: false,
+			properties: {
+				id: { type: "integer", minimum: 1 },
+				name: { type: "string", minLength: 1 },
+				email: { type: "string", format: "email" },
+				roles: { type: "array", items: { $ref: "#/$defs/role" }, uniqueItems: true },
+				manager: { anyOf: [{ type: "null" }, { type: "integer" }] },
+			},
+			$defs: { role: { enum: ["admin", "user"] } },
+		});
+	`)
+
+	_, err := runtime.RunOnEventLoop(`
+		const valid = user.check({ id: 1, name: "alice", roles: ["admin"], manager: null });
+		if (!valid.valid || valid.errors.length !== 0) {
+			throw new Error("unexpected errors: " + JSON.stringify(valid.errors));
+		}
+
+		const invalid = user.validate({
+			id: 1.5, name: "", email: "alice", roles: ["admin", "root", "admin"], manager: "bob", extra: true,
+		});
+		const got = invalid.errors.map((e) => e.path + ": " + e.message);
+		globalThis.got = got;
+	`)
+	require.NoError(t, err)
+
+	var got []string
+	require.NoError(t, runtime.VU.Runtime().ExportTo(runtime.VU.Runtime().Get("got"), &got))
+	assert.Equal(t, []string{
+		`/email: "alice" isn't a valid email`,
+		`/extra: the property "extra" isn't allowed`,
+		`/id: expected integer, but got number`,
+		`/manager: doesn't match any of the schemas of anyOf`,
+		`/name: expected at least 1 characters, but got 0`,
+		`/roles: the items 0 and 2 are the same`,
+		`/roles/1: "root" isn't one of the allowed values`,
+	}, got)
+
+	checks := metrics.GetBufferedSamples(samples)
+	require.Len(t, checks, 1)
+	sample := checks[0].GetSamples()[0]
+	assert.Equal(t, "checks", sample.Metric.Name)
+	assert.Equal(t, 1.0, sample.Value)
+	assert.Equal(t, defaultSchemaCheckName, sample.Tags.Map()["check"])
+}
+
+const testOpenAPI = `
+openapi: 3.0.3
+servers:
+  - url: https://api.example.com/v1
+paths:
+  /users/{id}:
+    get:
+      responses:
+        "200":
+          description: the user
+          headers:
+            X-Rate-Limit:
+              required: true
+              schema: { type: integer, maximum: 100 }
+          content:
+            application/json:
+              schema: { $ref: "#/components/schemas/User" }
+        4XX:
+          $ref: "#/components/responses/Error"
+  /users/me:
+    get:
+      responses:
+        default:
+          description: the current user
+          content:
+            application/json:
+              schema: { $ref: "#/components/schemas/User" }
+components:
+  schemas:
+    User:
+      type: object
+      required: [id, name]
+      properties:
+        id: { type: integer }
+        name: { type: string }
+        team: { type: string, nullable: true }
+  responses:
+    Error:
+      description: an error
+      content:
+        application/problem+json:
+          schema:
+            type: object
+            required: [title]
+`
+
+func TestOpenAPI(t *testing.T) {
+	t.Parallel()
+
+	runtime, samples := newTestRuntime(t, "const api = validator.openAPI(`"+testOpenAPI+"`);")
+
+	_, err := runtime.RunOnEventLoop(`
+		function res(method, url, status, headers, body) {
+			return { request: { method }, url, status, headers, body };
+		}
+		const json = { "Content-Type": "application/json; charset=utf-8" };
+
+		const results = [
+			api.check(res("GET", "https://api.example.com/v1/users/1", 200,
+				{ ...json, "X-Rate-Limit": "10" }, '{"id":1,"name":"alice","team":null}')),
+			api.validate(res("GET", "https://api.example.com/v1/users/me", 200, json, '{"id":"1","name":"bob"}')),
+			api.validate(res("GET", "https://api.example.com/v1/users/1", 200,
+				{ ...json, "X-Rate-Limit": "1000" }, '{"id":1}')),
+			api.validate(res("GET", "https://api.example.com/v1/users/1", 200, {}, "")),
+			api.validate(res("GET", "https://api.example.com/v1/users/1", 404,
+				{ "Content-Type": "application/problem+json" }, '{"detail":"no such user"}')),
+			api.validate(res("GET", "https://api.example.com/v1/users/1", 500, json, "")),
+			api.validate(res("DELETE", "https://api.example.com/v1/users/1", 204, {}, "")),
+			api.validate(res("GET", "https://api.example.com/v1/teams", 200, json, "[]")),
+			api.check(res("GET", "https://api.example.com/v1/users/1", 200, json, "{}"), "user contract"),
+		];
+		globalThis.got = results.map((r) => r.errors.map((e) => e.path + ": " + e.message));
+	`)
+	require.NoError(t, err)
+
+	var got [][]string
+	require.NoError(t, runtime.VU.Runtime().ExportTo(runtime.VU.Runtime().Get("got"), &got))
+	assert.Equal(t, [][]string{
+		{},
+		{`/body/id: expected integer, but got string`},
+		{
+			`/headers/X-Rate-Limit: expected at most 100, but got 1000`,
+			`/body: the required property "name" is missing`,
+		},
+		{
+			`/headers/X-Rate-Limit: the required header "X-Rate-Limit" is missing`,
+			`/headers/Content-Type: the content type "" isn't documented`,
+		},
+		{`/body: the required property "title" is missing`},
+		{`/status: the status 500 isn't documented for GET /users/{id}`},
+		{`/: the OpenAPI document doesn't have the operation DELETE /users/{id}`},
+		{`/: no path of the OpenAPI document matches /teams`},
+		{
+			`/headers/X-Rate-Limit: the required header "X-Rate-Limit" is missing`,
+			`/body: the required property "id" is missing`,
+			`/body: the required property "name" is missing`,
+		},
+	}, got)
+
+	checks := metrics.GetBufferedSamples(samples)
+	require.Len(t, checks, 2)
+	first, second := checks[0].GetSamples()[0], checks[1].GetSamples()[0]
+	assert.Equal(t, 1.0, first.Value)
+	assert.Equal(t, defaultOpenAPICheckName, first.Tags.Map()["check"])
+	assert.Equal(t, 0.0, second.Value)
+	assert.Equal(t, "user contract", second.Tags.Map()["check"])
+}
+
+func TestInvalidDocuments(t *testing.T) {
+	t.Parallel()
+
+	runtime, _ := newTestRuntime(t, "")
+	_, err := runtime.VU.Runtime().RunString(`validator.openAPI({ paths: {} })`)
+	assert.ErrorContains(t, err, "invalid OpenAPI document: the document isn't an OpenAPI 3 document")
+	_, err = runtime.VU.Runtime().RunString(`validator.jsonSchema("{ type: [")`)
+	assert.ErrorContains(t, err, "invalid JSON schema: parsing the document")
+	v, err := runtime.VU.Runtime().RunString(`validator.jsonSchema({ $ref: "other.json#/a" }).validate(1).errors[0].message`)
+	require.NoError(t, err)
+	assert.Equal(t, `only local $refs are supported, but it was "other.json#/a"`, v.String())
+}
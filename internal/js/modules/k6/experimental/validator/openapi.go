@@ -0,0 +1,275 @@
+package validator
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// response is the part of a response, which is validated against an OpenAPI
+// document.
+type response struct {
+	Method  string
+	URL     string
+	Status  int
+	Headers map[string]string
+	Body    string
+}
+
+// pathTemplate is a path of an OpenAPI document, e.g. /users/{id}.
+type pathTemplate struct {
+	template string
+	segments []string
+	literals int
+	item     map[string]any
+}
+
+func (p pathTemplate) matches(segments []string) bool {
+	if len(segments) != len(p.segments) {
+		return false
+	}
+	for i, segment := range p.segments {
+		isParam := strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}")
+		if isParam && segments[i] == "" || !isParam && segment != segments[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// openAPI is an OpenAPI 3 document, against which the responses are validated.
+type openAPI struct {
+	doc      *document
+	basePath string
+	paths    []pathTemplate
+}
+
+func newOpenAPI(doc *document) (*openAPI, error) {
+	root, ok := doc.root.(map[string]any)
+	if !ok {
+		return nil, errors.New("the OpenAPI document must be an object")
+	}
+	if _, ok = root["openapi"].(string); !ok {
+		return nil, errors.New("the document isn't an OpenAPI 3 document, it doesn't have an openapi version")
+	}
+	paths, ok := root["paths"].(map[string]any)
+	if !ok {
+		return nil, errors.New("the OpenAPI document doesn't have any paths")
+	}
+
+	o := &openAPI{doc: doc, paths: make([]pathTemplate, 0, len(paths))}
+	if servers, ok := root["servers"].([]any); ok && len(servers) > 0 {
+		if server, ok := servers[0].(map[string]any); ok {
+			if serverURL, ok := server["url"].(string); ok {
+				if u, err := url.Parse(serverURL); err == nil {
+					o.basePath = strings.TrimSuffix(u.Path, "/")
+				}
+			}
+		}
+	}
+	for template, item := range paths {
+		item, ok := item.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("the path %q must be an object", template)
+		}
+		p := pathTemplate{template: template, segments: strings.Split(template, "/"), item: item}
+		for _, segment := range p.segments {
+			if !strings.HasPrefix(segment, "{") {
+				p.literals++
+			}
+		}
+		o.paths = append(o.paths, p)
+	}
+	// the paths with more literal segments are matched first, so /users/me
+	// matches before /users/{id}
+	sort.Slice(o.paths, func(i, j int) bool {
+		if o.paths[i].literals != o.paths[j].literals {
+			return o.paths[i].literals > o.paths[j].literals
+		}
+		return o.paths[i].template < o.paths[j].template
+	})
+	return o, nil
+}
+
+// findPath returns the path of the document, which the URL matches.
+func (o *openAPI) findPath(rawURL string) (pathTemplate, string, bool) {
+	path := rawURL
+	if u, err := url.Parse(rawURL); err == nil {
+		path = u.Path
+	}
+	if trimmed, ok := strings.CutPrefix(path, o.basePath); ok && o.basePath != "" {
+		path = trimmed
+	}
+	segments := strings.Split(path, "/")
+	for _, p := range o.paths {
+		if p.matches(segments) {
+			return p, path, true
+		}
+	}
+	return pathTemplate{}, path, false
+}
+
+// validate validates the status, the headers and the body of the response
+// against the operation of its method and path.
+func (o *openAPI) validate(res response) []ValidationError {
+	v := &validation{doc: o.doc}
+	p, path, ok := o.findPath(res.URL)
+	if !ok {
+		v.fail("", "no path of the OpenAPI document matches %s", path)
+		return v.errors
+	}
+	method := strings.ToLower(res.Method)
+	operation, ok := v.object(p.item[method])
+	if !ok {
+		v.fail("", "the OpenAPI document doesn't have the operation %s %s", strings.ToUpper(method), p.template)
+		return v.errors
+	}
+	responses, _ := v.object(operation["responses"])
+	status := strconv.Itoa(res.Status)
+	spec, ok := v.object(responses[status])
+	if !ok {
+		spec, ok = v.object(responses[status[:1]+"XX"])
+	}
+	if !ok {
+		spec, ok = v.object(responses["default"])
+	}
+	if !ok {
+		v.fail("/status", "the status %d isn't documented for %s %s", res.Status, strings.ToUpper(method), p.template)
+		return v.errors
+	}
+
+	v.validateHeaders(spec, res.Headers)
+	v.validateBody(spec, res)
+	return v.errors
+}
+
+// object returns the value as an object, following its $ref if it has one.
+func (v *validation) object(value any) (map[string]any, bool) {
+	for range maxRefDepth {
+		obj, ok := value.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		ref, ok := obj["$ref"].(string)
+		if !ok {
+			return obj, true
+		}
+		resolved, err := v.doc.resolve(ref)
+		if err != nil {
+			v.fail("", "%s", err)
+			return nil, false
+		}
+		value = resolved
+	}
+	return nil, false
+}
+
+func (v *validation) validateHeaders(spec map[string]any, headers map[string]string) {
+	documented, _ := v.object(spec["headers"])
+	names := make([]string, 0, len(documented))
+	for name := range documented {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		header, ok := v.object(documented[name])
+		if !ok {
+			continue
+		}
+		path := "/headers/" + escapePointer(name)
+		value, found := headers[http.CanonicalHeaderKey(name)]
+		if !found {
+			for key, val := range headers {
+				if strings.EqualFold(key, name) {
+					value, found = val, true
+					break
+				}
+			}
+		}
+		if !found {
+			if header["required"] == true {
+				v.fail(path, "the required header %q is missing", name)
+			}
+			continue
+		}
+		if schema, ok := header["schema"]; ok {
+			v.validate(schema, headerValue(schema, value), path, 0)
+		}
+	}
+}
+
+// headerValue converts the value of a header to the type of its schema, since
+// the values of the headers are always strings.
+func headerValue(schema any, value string) any {
+	s, _ := schema.(map[string]any)
+	types, _ := schemaTypes(s["type"])
+	for _, t := range types {
+		switch t {
+		case "integer", "number":
+			if n, err := strconv.ParseFloat(value, 64); err == nil {
+				return n
+			}
+		case "boolean":
+			if b, err := strconv.ParseBool(value); err == nil {
+				return b
+			}
+		}
+	}
+	return value
+}
+
+func (v *validation) validateBody(spec map[string]any, res response) {
+	content, ok := v.object(spec["content"])
+	if !ok || len(content) == 0 {
+		// the responses without a documented content aren't validated
+		return
+	}
+
+	contentType := ""
+	for key, value := range res.Headers {
+		if strings.EqualFold(key, "Content-Type") {
+			contentType = value
+		}
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = strings.TrimSpace(contentType)
+	}
+	mediaType = strings.ToLower(mediaType)
+
+	media, ok := v.object(content[mediaType])
+	if !ok {
+		if major, _, found := strings.Cut(mediaType, "/"); found {
+			media, ok = v.object(content[major+"/*"])
+		}
+	}
+	if !ok {
+		media, ok = v.object(content["*/*"])
+	}
+	if !ok {
+		v.fail("/headers/Content-Type", "the content type %q isn't documented", contentType)
+		return
+	}
+
+	schema, ok := media["schema"]
+	if !ok || !isJSON(mediaType) {
+		return
+	}
+	var body any
+	if err = json.Unmarshal([]byte(res.Body), &body); err != nil {
+		v.fail("/body", "the body isn't valid JSON: %s", err)
+		return
+	}
+	v.validate(schema, body, "/body", 0)
+}
+
+func isJSON(mediaType string) bool {
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
@@ -0,0 +1,502 @@
+package validator
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// maxRefDepth is how many $refs can be followed without validating a nested
+// value, so a schema, which refers to itself, doesn't loop forever.
+const maxRefDepth = 64
+
+// ValidationError is a part of a value, which doesn't match its schema.
+type ValidationError struct {
+	// Path is the JSON pointer of the part of the value, e.g. /items/0/id.
+	Path    string `js:"path"`
+	Message string `js:"message"`
+}
+
+func (e ValidationError) String() string {
+	return e.Path + ": " + e.Message
+}
+
+// document is a parsed JSON Schema or OpenAPI document, which the VUs share,
+// since it's never modified after it's parsed.
+type document struct {
+	root     any
+	patterns sync.Map // the compiled patterns, by their sources
+}
+
+// parseDocument parses a JSON or YAML document into the values, which
+// encoding/json would decode it into.
+func parseDocument(source []byte) (*document, error) {
+	var root any
+	if err := yaml.Unmarshal(source, &root); err != nil {
+		return nil, fmt.Errorf("parsing the document: %w", err)
+	}
+	root, err := normalize(root)
+	if err != nil {
+		return nil, err
+	}
+	return &document{root: root}, nil
+}
+
+// normalize converts the numbers to float64 and the maps to map[string]any,
+// like encoding/json decodes them.
+func normalize(v any) (any, error) {
+	switch v := v.(type) {
+	case map[string]any:
+		m := make(map[string]any, len(v))
+		for key, value := range v {
+			n, err := normalize(value)
+			if err != nil {
+				return nil, err
+			}
+			m[key] = n
+		}
+		return m, nil
+	case map[any]any:
+		m := make(map[string]any, len(v))
+		for key, value := range v {
+			n, err := normalize(value)
+			if err != nil {
+				return nil, err
+			}
+			m[fmt.Sprint(key)] = n
+		}
+		return m, nil
+	case []any:
+		s := make([]any, len(v))
+		for i, value := range v {
+			n, err := normalize(value)
+			if err != nil {
+				return nil, err
+			}
+			s[i] = n
+		}
+		return s, nil
+	case int:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case uint64:
+		return float64(v), nil
+	case float32:
+		return float64(v), nil
+	case nil, bool, float64, string:
+		return v, nil
+	case time.Time:
+		// YAML timestamps are strings in JSON
+		return v.Format(time.RFC3339Nano), nil
+	default:
+		// anything else is converted like it would be in a JSON document
+		data, err := json.Marshal(v)
+		if err != nil {
+			return nil, fmt.Errorf("unsupported value %T: %w", v, err)
+		}
+		var decoded any
+		if err = json.Unmarshal(data, &decoded); err != nil {
+			return nil, err
+		}
+		return decoded, nil
+	}
+}
+
+// resolve returns the value of the local JSON pointer reference in the document.
+func (d *document) resolve(ref string) (any, error) {
+	pointer, ok := strings.CutPrefix(ref, "#")
+	if !ok {
+		return nil, fmt.Errorf("only local $refs are supported, but it was %q", ref)
+	}
+	node := d.root
+	if pointer == "" {
+		return node, nil
+	}
+	for _, token := range strings.Split(strings.TrimPrefix(pointer, "/"), "/") {
+		token, err := url.PathUnescape(token)
+		if err != nil {
+			return nil, fmt.Errorf("invalid $ref %q: %w", ref, err)
+		}
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+		switch n := node.(type) {
+		case map[string]any:
+			if node, ok = n[token]; !ok {
+				return nil, fmt.Errorf("the $ref %q doesn't exist", ref)
+			}
+		case []any:
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(n) {
+				return nil, fmt.Errorf("the $ref %q doesn't exist", ref)
+			}
+			node = n[i]
+		default:
+			return nil, fmt.Errorf("the $ref %q doesn't exist", ref)
+		}
+	}
+	return node, nil
+}
+
+func (d *document) pattern(source string) (*regexp.Regexp, error) {
+	if re, ok := d.patterns.Load(source); ok {
+		return re.(*regexp.Regexp), nil //nolint:forcetypeassert
+	}
+	re, err := regexp.Compile(source)
+	if err != nil {
+		return nil, err
+	}
+	d.patterns.Store(source, re)
+	return re, nil
+}
+
+// validation collects the errors of validating a value against a schema.
+type validation struct {
+	doc    *document
+	errors []ValidationError
+}
+
+func (v *validation) fail(path, format string, args ...any) {
+	if path == "" {
+		path = "/"
+	}
+	v.errors = append(v.errors, ValidationError{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// matches returns whether the value matches the schema, without recording
+// the errors, for anyOf, oneOf and not.
+func (v *validation) matches(schema, value any, path string, depth int) bool {
+	sub := &validation{doc: v.doc}
+	sub.validate(schema, value, path, depth)
+	return len(sub.errors) == 0
+}
+
+//nolint:gocognit,funlen,cyclop
+func (v *validation) validate(schema, value any, path string, depth int) {
+	switch s := schema.(type) {
+	case bool:
+		if !s {
+			v.fail(path, "no value is allowed")
+		}
+		return
+	case map[string]any:
+		schema = s
+	default:
+		v.fail(path, "invalid schema %T", schema)
+		return
+	}
+	s := schema.(map[string]any) //nolint:forcetypeassert
+
+	if ref, ok := s["$ref"].(string); ok {
+		if depth >= maxRefDepth {
+			v.fail(path, "too many nested $refs at %q", ref)
+			return
+		}
+		resolved, err := v.doc.resolve(ref)
+		if err != nil {
+			v.fail(path, "%s", err)
+			return
+		}
+		v.validate(resolved, value, path, depth+1)
+		// since draft 2019-09, the keywords next to a $ref apply too
+	}
+
+	if value == nil && s["nullable"] == true {
+		return
+	}
+	if types, ok := schemaTypes(s["type"]); ok && !matchesType(types, value) {
+		v.fail(path, "expected %s, but got %s", strings.Join(types, " or "), typeOf(value))
+		return
+	}
+	if enum, ok := s["enum"].([]any); ok && !containsValue(enum, value) {
+		v.fail(path, "%s isn't one of the allowed values", formatValue(value))
+	}
+	if c, ok := s["const"]; ok && !reflect.DeepEqual(c, value) {
+		v.fail(path, "expected %s, but got %s", formatValue(c), formatValue(value))
+	}
+
+	switch value := value.(type) {
+	case string:
+		v.validateString(s, value, path)
+	case float64:
+		v.validateNumber(s, value, path)
+	case map[string]any:
+		v.validateObject(s, value, path, depth)
+	case []any:
+		v.validateArray(s, value, path, depth)
+	}
+
+	if all, ok := s["allOf"].([]any); ok {
+		for _, sub := range all {
+			v.validate(sub, value, path, depth)
+		}
+	}
+	if anyOf, ok := s["anyOf"].([]any); ok {
+		matched := false
+		for _, sub := range anyOf {
+			if v.matches(sub, value, path, depth) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			v.fail(path, "doesn't match any of the schemas of anyOf")
+		}
+	}
+	if oneOf, ok := s["oneOf"].([]any); ok {
+		matched := 0
+		for _, sub := range oneOf {
+			if v.matches(sub, value, path, depth) {
+				matched++
+			}
+		}
+		if matched != 1 {
+			v.fail(path, "matches %d of the schemas of oneOf instead of exactly one", matched)
+		}
+	}
+	if not, ok := s["not"]; ok && v.matches(not, value, path, depth) {
+		v.fail(path, "matches the schema of not")
+	}
+}
+
+func (v *validation) validateString(s map[string]any, value string, path string) {
+	length := float64(utf8.RuneCountInString(value))
+	if minLength, ok := s["minLength"].(float64); ok && length < minLength {
+		v.fail(path, "expected at least %v characters, but got %v", minLength, length)
+	}
+	if maxLength, ok := s["maxLength"].(float64); ok && length > maxLength {
+		v.fail(path, "expected at most %v characters, but got %v", maxLength, length)
+	}
+	if pattern, ok := s["pattern"].(string); ok {
+		re, err := v.doc.pattern(pattern)
+		switch {
+		case err != nil:
+			v.fail(path, "invalid pattern %q: %s", pattern, err)
+		case !re.MatchString(value):
+			v.fail(path, "%q doesn't match the pattern %q", value, pattern)
+		}
+	}
+	if format, ok := s["format"].(string); ok && !matchesFormat(format, value) {
+		v.fail(path, "%q isn't a valid %s", value, format)
+	}
+}
+
+func (v *validation) validateNumber(s map[string]any, value float64, path string) {
+	if minimum, ok := s["minimum"].(float64); ok {
+		if s["exclusiveMinimum"] == true && value <= minimum {
+			v.fail(path, "expected more than %v, but got %v", minimum, value)
+		} else if value < minimum {
+			v.fail(path, "expected at least %v, but got %v", minimum, value)
+		}
+	}
+	if maximum, ok := s["maximum"].(float64); ok {
+		if s["exclusiveMaximum"] == true && value >= maximum {
+			v.fail(path, "expected less than %v, but got %v", maximum, value)
+		} else if value > maximum {
+			v.fail(path, "expected at most %v, but got %v", maximum, value)
+		}
+	}
+	if minimum, ok := s["exclusiveMinimum"].(float64); ok && value <= minimum {
+		v.fail(path, "expected more than %v, but got %v", minimum, value)
+	}
+	if maximum, ok := s["exclusiveMaximum"].(float64); ok && value >= maximum {
+		v.fail(path, "expected less than %v, but got %v", maximum, value)
+	}
+	if multipleOf, ok := s["multipleOf"].(float64); ok && multipleOf > 0 {
+		if q := value / multipleOf; math.Abs(q-math.Round(q)) > 1e-9 {
+			v.fail(path, "expected a multiple of %v, but got %v", multipleOf, value)
+		}
+	}
+}
+
+func (v *validation) validateObject(s map[string]any, value map[string]any, path string, depth int) {
+	if required, ok := s["required"].([]any); ok {
+		for _, name := range required {
+			if name, ok := name.(string); ok {
+				if _, ok := value[name]; !ok {
+					v.fail(path, "the required property %q is missing", name)
+				}
+			}
+		}
+	}
+	count := float64(len(value))
+	if minProperties, ok := s["minProperties"].(float64); ok && count < minProperties {
+		v.fail(path, "expected at least %v properties, but got %v", minProperties, count)
+	}
+	if maxProperties, ok := s["maxProperties"].(float64); ok && count > maxProperties {
+		v.fail(path, "expected at most %v properties, but got %v", maxProperties, count)
+	}
+
+	properties, _ := s["properties"].(map[string]any)
+	patternProperties, _ := s["patternProperties"].(map[string]any)
+	additional, hasAdditional := s["additionalProperties"]
+
+	names := make([]string, 0, len(value))
+	for name := range value {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		propertyPath := path + "/" + escapePointer(name)
+		matched := false
+		if schema, ok := properties[name]; ok {
+			matched = true
+			v.validate(schema, value[name], propertyPath, depth)
+		}
+		for pattern, schema := range patternProperties {
+			re, err := v.doc.pattern(pattern)
+			if err == nil && re.MatchString(name) {
+				matched = true
+				v.validate(schema, value[name], propertyPath, depth)
+			}
+		}
+		if !matched && hasAdditional {
+			if additional == false {
+				v.fail(propertyPath, "the property %q isn't allowed", name)
+			} else {
+				v.validate(additional, value[name], propertyPath, depth)
+			}
+		}
+	}
+}
+
+func (v *validation) validateArray(s map[string]any, value []any, path string, depth int) {
+	count := float64(len(value))
+	if minItems, ok := s["minItems"].(float64); ok && count < minItems {
+		v.fail(path, "expected at least %v items, but got %v", minItems, count)
+	}
+	if maxItems, ok := s["maxItems"].(float64); ok && count > maxItems {
+		v.fail(path, "expected at most %v items, but got %v", maxItems, count)
+	}
+	if s["uniqueItems"] == true {
+		for i := range value {
+			for j := i + 1; j < len(value); j++ {
+				if reflect.DeepEqual(value[i], value[j]) {
+					v.fail(path, "the items %d and %d are the same", i, j)
+				}
+			}
+		}
+	}
+
+	// the items before draft 2020-12 can be a list of schemas, like prefixItems
+	prefix, _ := s["prefixItems"].([]any)
+	items := s["items"]
+	if tuple, ok := items.([]any); ok {
+		prefix, items = tuple, s["additionalItems"]
+	}
+	for i, item := range value {
+		itemPath := path + "/" + strconv.Itoa(i)
+		switch {
+		case i < len(prefix):
+			v.validate(prefix[i], item, itemPath, depth)
+		case items != nil:
+			v.validate(items, item, itemPath, depth)
+		}
+	}
+}
+
+func schemaTypes(t any) ([]string, bool) {
+	switch t := t.(type) {
+	case string:
+		return []string{t}, true
+	case []any:
+		types := make([]string, 0, len(t))
+		for _, item := range t {
+			if item, ok := item.(string); ok {
+				types = append(types, item)
+			}
+		}
+		return types, len(types) > 0
+	default:
+		return nil, false
+	}
+}
+
+func matchesType(types []string, value any) bool {
+	actual := typeOf(value)
+	for _, t := range types {
+		if t == actual || (t == "number" && actual == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+func typeOf(value any) string {
+	switch value := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		if value == math.Trunc(value) && !math.IsInf(value, 0) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+func containsValue(values []any, value any) bool {
+	for _, v := range values {
+		if reflect.DeepEqual(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func formatValue(value any) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// matchesFormat returns whether the value has the format, or true for the
+// formats, which aren't validated.
+func matchesFormat(format, value string) bool {
+	switch format {
+	case "date-time":
+		_, err := time.Parse(time.RFC3339, value)
+		return err == nil
+	case "date":
+		_, err := time.Parse(time.DateOnly, value)
+		return err == nil
+	case "email":
+		_, err := mail.ParseAddress(value)
+		return err == nil
+	case "uuid":
+		return uuidPattern.MatchString(value)
+	case "uri":
+		u, err := url.Parse(value)
+		return err == nil && u.IsAbs()
+	default:
+		return true
+	}
+}
+
+func escapePointer(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+}
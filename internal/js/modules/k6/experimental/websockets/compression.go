@@ -0,0 +1,173 @@
+package websockets
+
+import (
+	"compress/flate"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/grafana/sobek"
+
+	"go.k6.io/k6/metrics"
+)
+
+// defaultCompressionLevel is the compression level of gorilla/websocket.
+const defaultCompressionLevel = 1
+
+// parseCompression parses the compression param, which is either the name of
+// the algorithm, or an object with it and the compression level. Only the
+// permessage-deflate algorithm, as defined in RFC 7692, is supported, with
+// the implementation of gorilla/websocket, which negotiates only the "no
+// context takeover" variant of it.
+func parseCompression(v sobek.Value) (int, error) {
+	level := defaultCompressionLevel
+	algorithm := v
+	if obj, ok := v.(*sobek.Object); ok {
+		if algorithm = obj.Get("algorithm"); algorithm == nil {
+			algorithm = sobek.Undefined()
+		}
+		for _, k := range obj.Keys() {
+			switch k {
+			case "algorithm":
+			case "level":
+				level = int(obj.Get(k).ToInteger())
+				if level < flate.HuffmanOnly || level > flate.BestCompression {
+					return 0, fmt.Errorf("the compression level must be between %d and %d, but it's %d",
+						flate.HuffmanOnly, flate.BestCompression, level)
+				}
+			default:
+				return 0, fmt.Errorf("unknown compression option %s", k)
+			}
+		}
+	}
+	algoString := strings.TrimSpace(algorithm.ToString().String())
+	if algoString != "deflate" {
+		return 0, fmt.Errorf("unsupported compression algorithm '%s', supported algorithm is 'deflate'", algoString)
+	}
+	return level, nil
+}
+
+// wireConn counts the bytes of the frames of a WebSocket, which are written to
+// and read from its connection, after they're compressed and before the TLS
+// encryption, if it's used.
+type wireConn struct {
+	net.Conn
+	written, read atomic.Int64
+	// compressed is whether permessage-deflate was negotiated, which is set
+	// before the messages are written and read
+	compressed bool
+}
+
+func (c *wireConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	c.read.Add(int64(n))
+	return n, err
+}
+
+func (c *wireConn) Write(b []byte) (int, error) {
+	n, err := c.Conn.Write(b)
+	c.written.Add(int64(n))
+	return n, err
+}
+
+// countFrames makes the dialer wrap the connections with a wireConn. For the
+// wss URLs, it does the TLS handshake itself, like gorilla/websocket does,
+// so the encrypted bytes aren't counted, unless the connection is made
+// through a proxy from the environment, when the frames aren't counted.
+func countFrames(wsd *websocket.Dialer, u string, tlsConfig *tls.Config) {
+	dial := wsd.NetDialContext
+	wrap := func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := dial(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+		return &wireConn{Conn: conn}, nil
+	}
+	if !strings.HasPrefix(u, "wss://") {
+		wsd.NetDialContext = wrap
+		return
+	}
+
+	req, err := http.NewRequest(http.MethodGet, "https://"+strings.TrimPrefix(u, "wss://"), nil)
+	if err != nil {
+		return
+	}
+	if proxyURL, err := wsd.Proxy(req); err != nil || proxyURL != nil {
+		return
+	}
+	wsd.NetDialTLSContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := dial(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+		config := tlsConfig.Clone()
+		if config == nil {
+			config = &tls.Config{} //nolint:gosec // the VU's TLS options are used, when there are some
+		}
+		if config.ServerName == "" {
+			config.ServerName = req.URL.Hostname()
+		}
+		tlsConn := tls.Client(conn, config)
+		if err = tlsConn.HandshakeContext(ctx); err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+		return &wireConn{Conn: tlsConn}, nil
+	}
+}
+
+// compressedWireConn returns the wireConn of the connection, if it uses the
+// compression and its frames are counted.
+func compressedWireConn(conn *websocket.Conn) *wireConn {
+	if wire, ok := conn.NetConn().(*wireConn); ok && wire.compressed {
+		return wire
+	}
+	return nil
+}
+
+// isCompressionNegotiated returns whether the extensions of the handshake
+// response include permessage-deflate.
+func isCompressionNegotiated(extensions []string) bool {
+	for _, extension := range extensions {
+		for _, ext := range strings.Split(extension, ",") {
+			name, _, _ := strings.Cut(ext, ";")
+			if strings.TrimSpace(name) == "permessage-deflate" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// pushMessageSizes pushes the size of a message and of its frames on the wire,
+// which is the difference of the counted bytes since the previous message,
+// so it includes the control frames between them.
+func (w *webSocket) pushMessageSizes(
+	ctx context.Context, samples chan<- metrics.SampleContainer, t time.Time,
+	length int, wireLength int64, lengthMetric, wireLengthMetric *metrics.Metric,
+) {
+	metrics.PushIfNotDone(ctx, samples, metrics.ConnectedSamples{
+		Samples: []metrics.Sample{
+			{
+				TimeSeries: metrics.TimeSeries{Metric: lengthMetric, Tags: w.tagsAndMeta.Tags},
+				Time:       t,
+				Metadata:   w.tagsAndMeta.Metadata,
+				Value:      float64(length),
+			},
+			{
+				TimeSeries: metrics.TimeSeries{Metric: wireLengthMetric, Tags: w.tagsAndMeta.Tags},
+				Time:       t,
+				Metadata:   w.tagsAndMeta.Metadata,
+				Value:      float64(wireLength),
+			},
+		},
+		Tags: w.tagsAndMeta.Tags,
+		Time: t,
+	})
+}
@@ -0,0 +1,112 @@
+package websockets
+
+import (
+	"github.com/grafana/sobek"
+
+	"go.k6.io/k6/js/common"
+)
+
+// messageIterator is an async iterator over the message events of a
+// WebSocket, which are received after it's created. It's only accessed on the
+// event loop.
+type messageIterator struct {
+	// the received events, which haven't been consumed yet
+	events []*sobek.Object
+	// the resolve functions of the next() calls waiting for an event
+	waiting []func(any) error
+	done    bool
+}
+
+// deliver resolves the oldest waiting next() call with the event, or queues
+// the event, if there isn't one.
+func (it *messageIterator) deliver(ev *sobek.Object) error {
+	if len(it.waiting) == 0 {
+		it.events = append(it.events, ev)
+		return nil
+	}
+	resolve := it.waiting[0]
+	it.waiting = it.waiting[1:]
+	return resolve(map[string]any{"value": ev, "done": false})
+}
+
+// finish ends the iteration, after the already received events are consumed.
+func (it *messageIterator) finish() error {
+	it.done = true
+	waiting := it.waiting
+	it.waiting = nil
+	for _, resolve := range waiting {
+		if err := resolve(map[string]any{"value": sobek.Undefined(), "done": true}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// messages returns an async iterator over the message events of the
+// WebSocket, so they can be consumed with a for await loop. The iteration is
+// done when the WebSocket is closed. Leaving the loop early stops the
+// iteration, but it doesn't close the WebSocket.
+func (w *webSocket) messages() *sobek.Object {
+	rt := w.vu.Runtime()
+	it := &messageIterator{done: w.readyState == CLOSED}
+	if !it.done {
+		w.messageIterators = append(w.messageIterators, it)
+	}
+
+	next := func() *sobek.Promise {
+		promise, resolve, _ := rt.NewPromise()
+		switch {
+		case len(it.events) > 0:
+			ev := it.events[0]
+			it.events = it.events[1:]
+			must(rt, resolve(map[string]any{"value": ev, "done": false}))
+		case it.done:
+			must(rt, resolve(map[string]any{"value": sobek.Undefined(), "done": true}))
+		default:
+			it.waiting = append(it.waiting, resolve)
+		}
+		return promise
+	}
+	ret := func(value sobek.Value) *sobek.Promise {
+		w.removeMessageIterator(it)
+		it.events = nil
+		must(rt, it.finish())
+		promise, resolve, _ := rt.NewPromise()
+		must(rt, resolve(map[string]any{"value": value, "done": true}))
+		return promise
+	}
+	return common.NewAsyncIterator(rt, next, ret)
+}
+
+// deliverToMessageIterators delivers the message event to the iterators. It's
+// run on the event loop.
+func (w *webSocket) deliverToMessageIterators(ev *sobek.Object) error {
+	for _, it := range w.messageIterators {
+		if err := it.deliver(ev); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// finishMessageIterators ends the iterations, when the connection is closed.
+// It's run on the event loop.
+func (w *webSocket) finishMessageIterators() error {
+	iterators := w.messageIterators
+	w.messageIterators = nil
+	for _, it := range iterators {
+		if err := it.finish(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *webSocket) removeMessageIterator(it *messageIterator) {
+	for i, iterator := range w.messageIterators {
+		if iterator == it {
+			w.messageIterators = append(w.messageIterators[:i], w.messageIterators[i+1:]...)
+			return
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package websockets
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessageIterator(t *testing.T) {
+	t.Parallel()
+	ts := newTestState(t)
+	ts.addRPCHandler("/ws/rpc")
+	sr := ts.tb.Replacer.Replace
+
+	_, err := ts.runtime.RunOnEventLoop(sr(`
+		var ws = new WebSocket("WSBIN_URL/ws/rpc");
+		ws.onopen = async () => {
+			var all = ws[Symbol.asyncIterator]();
+			if (all[Symbol.asyncIterator]() !== all) {
+				throw new Error("the iterator isn't async iterable");
+			}
+			var first = ws[Symbol.asyncIterator]();
+			ws.send(JSON.stringify({ id: 1 }));
+			ws.send(JSON.stringify({ id: 2 }));
+
+			var received = [];
+			for (var res = await first.next(); !res.done; res = await first.next()) {
+				received.push(JSON.parse(res.value.data).id);
+				if (received.length === 3) {
+					var returned = await first.return("stop");
+					if (returned.value !== "stop" || !returned.done) {
+						throw new Error("unexpected return result: " + JSON.stringify(returned));
+					}
+				}
+			}
+			if (received.join() !== "101,1,102") {
+				throw new Error("unexpected messages: " + received.join());
+			}
+
+			var ids = [];
+			for (var i = 0; i < 4; i++) {
+				ids.push(JSON.parse((await all.next()).value.data).id);
+			}
+			if (ids.join() !== "101,1,102,2") {
+				throw new Error("unexpected messages: " + ids.join());
+			}
+			var pending = all.next();
+			ws.close();
+			if (!(await pending).done) {
+				throw new Error("the iteration isn't done after the WebSocket is closed");
+			}
+			if (!(await all.next()).done) {
+				throw new Error("the iteration isn't done after the WebSocket is closed");
+			}
+		}
+	`))
+	require.NoError(t, err)
+}
@@ -2,15 +2,18 @@ package websockets
 
 import (
 	"fmt"
+	"math"
+	"math/rand/v2"
 	"net/http"
 	"net/http/cookiejar"
-	"strings"
+	"time"
 
 	"github.com/grafana/sobek"
 
 	"go.k6.io/k6/js/common"
 	httpModule "go.k6.io/k6/js/modules/k6/http"
 	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/types"
 	"go.k6.io/k6/metrics"
 )
 
@@ -20,7 +23,94 @@ type wsParams struct {
 	cookieJar         *cookiejar.Jar
 	tagsAndMeta       *metrics.TagsAndMeta
 	enableCompression bool
+	compressionLevel  int
 	subprocotols      []string
+	reconnect         *reconnectPolicy
+}
+
+// reconnectPolicy is how a WebSocket reconnects, when its connection is lost
+// without the script closing it, e.g. when a load balancer restarts. The
+// delays between the attempts back off exponentially, and the jitter shortens
+// them randomly by up to its fraction, so the VUs don't reconnect all at once
+// after a server's rolling restart.
+type reconnectPolicy struct {
+	maxRetries   int64
+	initialDelay time.Duration
+	maxDelay     time.Duration
+	factor       float64
+	jitter       float64
+}
+
+func defaultReconnectPolicy() *reconnectPolicy {
+	return &reconnectPolicy{
+		maxRetries:   10,
+		initialDelay: 500 * time.Millisecond,
+		maxDelay:     30 * time.Second,
+		factor:       2,
+	}
+}
+
+// delay returns how long to wait before the attempt, starting from 1.
+func (p *reconnectPolicy) delay(attempt int64) time.Duration {
+	d := math.Min(float64(p.initialDelay)*math.Pow(p.factor, float64(attempt-1)), float64(p.maxDelay))
+	if p.jitter > 0 {
+		d -= d * p.jitter * rand.Float64() //nolint:gosec
+	}
+	return time.Duration(d)
+}
+
+// parseReconnectPolicy parses the reconnect option, which is either a boolean,
+// which enables the default policy, or an object, which overrides some of it.
+func parseReconnectPolicy(rt *sobek.Runtime, v sobek.Value) (*reconnectPolicy, error) {
+	if common.IsNullish(v) {
+		return nil, nil //nolint:nilnil
+	}
+	if b, ok := v.Export().(bool); ok {
+		if !b {
+			return nil, nil //nolint:nilnil
+		}
+		return defaultReconnectPolicy(), nil
+	}
+
+	policy := defaultReconnectPolicy()
+	obj := v.ToObject(rt)
+	for _, k := range obj.Keys() {
+		value := obj.Get(k)
+		switch k {
+		case "maxRetries":
+			policy.maxRetries = value.ToInteger()
+			if policy.maxRetries < 1 {
+				return nil, fmt.Errorf("the reconnect maxRetries must be positive, but it was %d", policy.maxRetries)
+			}
+		case "initialDelay", "maxDelay":
+			d, err := types.GetDurationValue(value.Export())
+			if err != nil {
+				return nil, fmt.Errorf("invalid reconnect %s: %w", k, err)
+			}
+			if d <= 0 {
+				return nil, fmt.Errorf("the reconnect %s must be positive", k)
+			}
+			if k == "initialDelay" {
+				policy.initialDelay = d
+			} else {
+				policy.maxDelay = d
+			}
+		case "factor":
+			policy.factor = value.ToFloat()
+			if policy.factor < 1 {
+				return nil, fmt.Errorf("the reconnect factor can't be less than 1, but it was %g", policy.factor)
+			}
+		case "jitter":
+			policy.jitter = value.ToFloat()
+			if policy.jitter < 0 || policy.jitter > 1 {
+				return nil, fmt.Errorf("the reconnect jitter must be between 0 and 1, but it was %g", policy.jitter)
+			}
+		default:
+			return nil, fmt.Errorf("unknown reconnect option %s, it must be one of "+
+				"maxRetries, initialDelay, maxDelay, factor or jitter", k)
+		}
+	}
+	return policy, nil
 }
 
 // buildParams builds WebSocket params and configure some of them
@@ -67,17 +157,20 @@ func buildParams(state *lib.State, rt *sobek.Runtime, raw sobek.Value) (*wsParam
 				parsed.cookieJar = v.Jar
 			}
 		case "compression":
-			// deflate compression algorithm is supported - as defined in RFC7692
 			// compression here relies on the implementation in gorilla/websocket package, usage is
-			// experimental and may result in decreased performance. package supports
-			// only "no context takeover" scenario
-
-			algoString := strings.TrimSpace(params.Get(k).ToString().String())
-			if algoString != "deflate" {
-				return nil, fmt.Errorf("unsupported compression algorithm '%s', supported algorithm is 'deflate'", algoString)
+			// experimental and may result in decreased performance.
+			level, err := parseCompression(params.Get(k))
+			if err != nil {
+				return nil, err
 			}
-
 			parsed.enableCompression = true
+			parsed.compressionLevel = level
+		case "reconnect":
+			policy, err := parseReconnectPolicy(rt, params.Get(k))
+			if err != nil {
+				return nil, err
+			}
+			parsed.reconnect = policy
 		default:
 			return nil, fmt.Errorf("unknown WebSocket's option %s", k)
 		}
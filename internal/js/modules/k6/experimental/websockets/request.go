@@ -0,0 +1,221 @@
+package websockets
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/grafana/sobek"
+
+	"go.k6.io/k6/js/common"
+	"go.k6.io/k6/lib/types"
+	"go.k6.io/k6/metrics"
+)
+
+const defaultRequestTimeout = 10 * time.Second
+
+// operationTag is the tag of the ws_request_duration samples with the name of
+// the request, if it has one.
+const operationTag = "operation"
+
+// pendingRequest is a sent message, which waits for its reply. It's only
+// accessed on the event loop.
+type pendingRequest struct {
+	// matches returns whether the message event is the reply
+	matches func(ev *sobek.Object, data []byte, binary bool) (bool, error)
+	name    string
+	sent    time.Time
+	resolve func(any) error
+	reject  func(any) error
+	timer   *time.Timer
+}
+
+// request sends the message and returns a promise, which is resolved with the
+// event of the first message matching it, or rejected after the timeout.
+// The message events are still dispatched to the listeners, matching or not,
+// before the promise is resolved.
+func (w *webSocket) request(data sobek.Value, options sobek.Value) *sobek.Promise {
+	w.assertStateOpen()
+	rt := w.vu.Runtime()
+
+	pr, timeout, err := w.parseRequestOptions(data, options)
+	if err != nil {
+		common.Throw(rt, err)
+	}
+	promise, resolve, reject := rt.NewPromise()
+	pr.resolve, pr.reject = resolve, reject
+	pr.sent = time.Now()
+	w.send(data)
+	// the reply can only be read on the event loop, so after it's pending
+	w.pendingRequests = append(w.pendingRequests, pr)
+	pr.timer = time.AfterFunc(timeout, func() {
+		w.tq.Queue(func() error {
+			if !w.removePendingRequest(pr) {
+				return nil
+			}
+			return pr.reject(fmt.Errorf("no reply to the WebSocket request within %s", timeout))
+		})
+	})
+	return promise
+}
+
+func (w *webSocket) parseRequestOptions(
+	data sobek.Value, options sobek.Value,
+) (*pendingRequest, time.Duration, error) {
+	rt := w.vu.Runtime()
+	if common.IsNullish(options) {
+		return nil, 0, errors.New("a WebSocket request requires the match option")
+	}
+	pr := &pendingRequest{}
+	timeout := defaultRequestTimeout
+	opts := options.ToObject(rt)
+	for _, k := range opts.Keys() {
+		v := opts.Get(k)
+		switch k {
+		case "match":
+			matches, err := newReplyMatcher(data, v)
+			if err != nil {
+				return nil, 0, err
+			}
+			pr.matches = matches
+		case "timeout":
+			var err error
+			if timeout, err = types.GetDurationValue(v.Export()); err != nil {
+				return nil, 0, fmt.Errorf("invalid timeout value: %w", err)
+			}
+		case "name":
+			pr.name = v.String()
+		default:
+			return nil, 0, fmt.Errorf("unknown WebSocket request option %q", k)
+		}
+	}
+	if pr.matches == nil {
+		return nil, 0, errors.New("a WebSocket request requires the match option")
+	}
+	return pr, timeout, nil
+}
+
+// newReplyMatcher returns the matching function for the match option, which
+// is either a predicate called with the message events, or a JSON path, like
+// "id" or "meta.requests.0", whose value in the reply must be equal to the
+// one in the sent message.
+func newReplyMatcher(
+	data sobek.Value, match sobek.Value,
+) (func(ev *sobek.Object, data []byte, binary bool) (bool, error), error) {
+	if predicate, ok := sobek.AssertFunction(match); ok {
+		return func(ev *sobek.Object, _ []byte, _ bool) (bool, error) {
+			matches, err := predicate(sobek.Undefined(), ev)
+			if err != nil {
+				return false, err
+			}
+			return matches.ToBoolean(), nil
+		}, nil
+	}
+
+	path := match.String()
+	sent, ok := data.Export().(string)
+	if !ok {
+		return nil, errors.New("matching the reply by a JSON path requires a string message")
+	}
+	want, ok := lookupJSONPath([]byte(sent), path)
+	if !ok {
+		return nil, fmt.Errorf("the message doesn't have a JSON value at %q", path)
+	}
+	return func(_ *sobek.Object, data []byte, binary bool) (bool, error) {
+		if binary {
+			return false, nil
+		}
+		got, ok := lookupJSONPath(data, path)
+		return ok && reflect.DeepEqual(want, got), nil
+	}, nil
+}
+
+// lookupJSONPath returns the value at the dot separated path in the JSON
+// document, where the array elements are selected by their indexes.
+func lookupJSONPath(data []byte, path string) (any, bool) {
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, false
+	}
+	for _, key := range strings.Split(path, ".") {
+		switch node := v.(type) {
+		case map[string]any:
+			var ok bool
+			if v, ok = node[key]; !ok {
+				return nil, false
+			}
+		case []any:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil, false
+			}
+			v = node[i]
+		default:
+			return nil, false
+		}
+	}
+	return v, true
+}
+
+// resolvePendingRequests resolves the pending requests, which the message is
+// the reply of, and emits their durations. It's run on the event loop.
+func (w *webSocket) resolvePendingRequests(ev *sobek.Object, msg *message) error {
+	for _, pr := range append([]*pendingRequest(nil), w.pendingRequests...) {
+		matches, err := pr.matches(ev, msg.data, msg.mtype == websocket.BinaryMessage)
+		if err != nil {
+			w.removePendingRequest(pr)
+			if err = pr.reject(err); err != nil {
+				return err
+			}
+			continue
+		}
+		if !matches {
+			continue
+		}
+		w.removePendingRequest(pr)
+
+		tags := w.tagsAndMeta.Tags
+		if pr.name != "" {
+			tags = tags.With(operationTag, pr.name)
+		}
+		metrics.PushIfNotDone(w.vu.Context(), w.vu.State().Samples, metrics.Sample{
+			TimeSeries: metrics.TimeSeries{Metric: w.builtinMetrics.WSRequestDuration, Tags: tags},
+			Time:       msg.t,
+			Metadata:   w.tagsAndMeta.Metadata,
+			Value:      metrics.D(msg.t.Sub(pr.sent)),
+		})
+		if err := pr.resolve(ev); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rejectPendingRequests rejects all the pending requests, when the connection
+// is closed. It's run on the event loop.
+func (w *webSocket) rejectPendingRequests() {
+	pending := w.pendingRequests
+	w.pendingRequests = nil
+	for _, pr := range pending {
+		pr.timer.Stop()
+		_ = pr.reject(errors.New("the WebSocket was closed before the reply to the request"))
+	}
+}
+
+// removePendingRequest removes the request, and returns whether it was still
+// pending.
+func (w *webSocket) removePendingRequest(pr *pendingRequest) bool {
+	for i, pending := range w.pendingRequests {
+		if pending == pr {
+			pr.timer.Stop()
+			w.pendingRequests = append(w.pendingRequests[:i], w.pendingRequests[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,173 @@
+package websockets
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/metrics"
+)
+
+// addRPCHandler adds a handler, which replies to the JSON messages with an
+// unrelated message and then with their result, except to the ignored ones.
+func (ts *testState) addRPCHandler(uri string) {
+	ts.tb.Mux.HandleFunc(uri, func(w http.ResponseWriter, req *http.Request) {
+		conn, err := (&websocket.Upgrader{}).Upgrade(w, req, w.Header())
+		if err != nil {
+			return
+		}
+		defer func() {
+			_ = conn.Close()
+		}()
+
+		for {
+			var msg struct {
+				ID     int    `json:"id"`
+				Method string `json:"method"`
+			}
+			if err := conn.ReadJSON(&msg); err != nil {
+				return
+			}
+			if msg.Method == "ignore" {
+				continue
+			}
+			replies := []string{
+				fmt.Sprintf(`{"id": %d, "result": "unrelated"}`, msg.ID+100),
+				fmt.Sprintf(`{"id": %d, "result": {"doubled": %d}}`, msg.ID, msg.ID*2),
+			}
+			for _, reply := range replies {
+				if err := conn.WriteMessage(websocket.TextMessage, []byte(reply)); err != nil {
+					return
+				}
+			}
+		}
+	})
+}
+
+func TestRequest(t *testing.T) {
+	t.Parallel()
+	ts := newTestState(t)
+	ts.addRPCHandler("/ws/rpc")
+	sr := ts.tb.Replacer.Replace
+
+	_, err := ts.runtime.RunOnEventLoop(sr(`
+		var ws = new WebSocket("WSBIN_URL/ws/rpc");
+		var messages = 0;
+		ws.onmessage = () => { messages++; };
+		ws.onopen = async () => {
+			var replies = await Promise.all([
+				ws.request(JSON.stringify({ id: 1, method: "double" }), { match: "id", name: "double" }),
+				ws.request(JSON.stringify({ id: 2, method: "double" }), { match: "id", name: "double" }),
+			]);
+			var results = replies.map((e) => JSON.parse(e.data).result.doubled);
+			if (results.join() !== "2,4") {
+				throw new Error("unexpected results: " + results.join());
+			}
+
+			var reply = await ws.request(JSON.stringify({ id: 3 }), {
+				match: (e) => JSON.parse(e.data).result.doubled === 6,
+			});
+			if (JSON.parse(reply.data).id !== 3) {
+				throw new Error("unexpected reply: " + reply.data);
+			}
+			if (messages !== 6) {
+				throw new Error("the replies weren't dispatched to the listeners: " + messages);
+			}
+
+			try {
+				await ws.request(JSON.stringify({ id: 4, method: "ignore" }), { match: "id", timeout: "50ms" });
+				throw new Error("the request didn't time out");
+			} catch (e) {
+				if (!String(e).includes("no reply to the WebSocket request within 50ms")) {
+					throw e;
+				}
+			}
+
+			var pending = ws.request(JSON.stringify({ id: 5, method: "ignore" }), { match: "id" });
+			ws.close();
+			try {
+				await pending;
+				throw new Error("the pending request wasn't rejected");
+			} catch (e) {
+				if (!String(e).includes("the WebSocket was closed before the reply")) {
+					throw e;
+				}
+			}
+		};
+	`))
+	require.NoError(t, err)
+
+	var operations []string
+	for _, sc := range metrics.GetBufferedSamples(ts.samples) {
+		for _, s := range sc.GetSamples() {
+			if s.Metric.Name == metrics.WSRequestDurationName {
+				operation, _ := s.Tags.Get(operationTag)
+				operations = append(operations, operation)
+				assert.Positive(t, s.Value)
+			}
+		}
+	}
+	assert.Equal(t, []string{"double", "double", ""}, operations)
+}
+
+func TestRequestInvalidOptions(t *testing.T) {
+	t.Parallel()
+
+	for options, msg := range map[string]string{
+		`undefined`:                   "a WebSocket request requires the match option",
+		`{ name: "rpc" }`:             "a WebSocket request requires the match option",
+		`{ match: "params.id" }`:      `the message doesn't have a JSON value at "params.id"`,
+		`{ match: "id", timeout: 1 }`: "",
+		`{ match: "id", retries: 1 }`: `unknown WebSocket request option "retries"`,
+	} {
+		t.Run(options, func(t *testing.T) {
+			t.Parallel()
+			ts := newTestState(t)
+			ts.addRPCHandler("/ws/rpc")
+
+			_, err := ts.runtime.RunOnEventLoop(ts.tb.Replacer.Replace(fmt.Sprintf(`
+				var ws = new WebSocket("WSBIN_URL/ws/rpc");
+				ws.onopen = () => {
+					ws.request(%s, %s).catch(() => {}).finally(() => ws.close());
+				};
+			`, mustJSON(t, `{"id": 1}`), options)))
+			if msg == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorContains(t, err, msg)
+		})
+	}
+}
+
+func TestLookupJSONPath(t *testing.T) {
+	t.Parallel()
+
+	doc := []byte(`{"id": 7, "meta": {"ids": ["a", "b"]}}`)
+	for path, want := range map[string]any{
+		"id":         float64(7),
+		"meta.ids.1": "b",
+	} {
+		got, ok := lookupJSONPath(doc, path)
+		assert.True(t, ok, path)
+		assert.Equal(t, want, got, path)
+	}
+	for _, path := range []string{"name", "meta.ids.2", "meta.ids.x", "id.value"} {
+		_, ok := lookupJSONPath(doc, path)
+		assert.False(t, ok, path)
+	}
+	_, ok := lookupJSONPath([]byte("not json"), "id")
+	assert.False(t, ok)
+}
+
+func mustJSON(t *testing.T, s string) string {
+	t.Helper()
+	b, err := json.Marshal(s)
+	require.NoError(t, err)
+	return string(b)
+}
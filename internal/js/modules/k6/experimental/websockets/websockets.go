@@ -81,15 +81,25 @@ type webSocket struct {
 	tq             *taskqueue.TaskQueue
 	builtinMetrics *metrics.BuiltinMetrics
 	obj            *sobek.Object // the object that is given to js to interact with the WebSocket
-	started        time.Time
+	params         *wsParams
 
 	done         chan struct{}
 	writeQueueCh chan message
+	// stopReconnect is closed when the WebSocket is closed while it's reconnecting
+	stopReconnect chan struct{}
+	// reconnecting is whether the connection was lost and the WebSocket is
+	// reconnecting, only accessed on the event loop
+	reconnecting bool
 
 	eventListeners *eventListeners
 
 	sendPings ping
 
+	// the requests waiting for their replies, only accessed on the event loop
+	pendingRequests []*pendingRequest
+	// the async iterators over the messages, only accessed on the event loop
+	messageIterators []*messageIterator
+
 	// fields that should be seen by js only be updated on the event loop
 	readyState     ReadyState
 	bufferedAmount int
@@ -138,6 +148,8 @@ func (r *WebSocketsAPI) websocket(c sobek.ConstructorCall) *sobek.Object {
 		builtinMetrics:  r.vu.State().BuiltinMetrics,
 		done:            make(chan struct{}),
 		writeQueueCh:    make(chan message),
+		stopReconnect:   make(chan struct{}),
+		params:          params,
 		eventListeners:  newEventListeners(),
 		obj:             rt.NewObject(),
 		tagsAndMeta:     params.tagsAndMeta,
@@ -148,7 +160,7 @@ func (r *WebSocketsAPI) websocket(c sobek.ConstructorCall) *sobek.Object {
 	// Maybe have this after the goroutine below ?!?
 	defineWebsocket(rt, w)
 
-	go w.establishConnection(params)
+	go w.establishConnection()
 	return w.obj
 }
 
@@ -185,12 +197,16 @@ func defineWebsocket(rt *sobek.Runtime, w *webSocket) {
 		"addEventListener", rt.ToValue(w.addEventListener), sobek.FLAG_FALSE, sobek.FLAG_FALSE, sobek.FLAG_TRUE))
 	must(rt, w.obj.DefineDataProperty(
 		"send", rt.ToValue(w.send), sobek.FLAG_FALSE, sobek.FLAG_FALSE, sobek.FLAG_TRUE))
+	must(rt, w.obj.DefineDataProperty(
+		"request", rt.ToValue(w.request), sobek.FLAG_FALSE, sobek.FLAG_FALSE, sobek.FLAG_TRUE))
 	must(rt, w.obj.DefineDataProperty(
 		"ping", rt.ToValue(w.ping), sobek.FLAG_FALSE, sobek.FLAG_FALSE, sobek.FLAG_TRUE))
 	must(rt, w.obj.DefineDataProperty(
 		"close", rt.ToValue(w.close), sobek.FLAG_FALSE, sobek.FLAG_FALSE, sobek.FLAG_TRUE))
 	must(rt, w.obj.DefineDataProperty(
 		"url", rt.ToValue(w.url.String()), sobek.FLAG_FALSE, sobek.FLAG_FALSE, sobek.FLAG_TRUE))
+	must(rt, w.obj.DefineDataPropertySymbol(
+		common.AsyncIteratorSymbol(rt), rt.ToValue(w.messages), sobek.FLAG_FALSE, sobek.FLAG_FALSE, sobek.FLAG_FALSE))
 	must(rt, w.obj.DefineAccessorProperty( // this needs to be with an accessor as we change the value
 		"readyState", rt.ToValue(func() sobek.Value {
 			return rt.ToValue((uint)(w.readyState))
@@ -260,9 +276,33 @@ type message struct {
 }
 
 // documented https://websockets.spec.whatwg.org/#concept-websocket-establish
-func (w *webSocket) establishConnection(params *wsParams) {
+func (w *webSocket) establishConnection() {
+	conn, extensions, started, connErr := w.dial()
+	if conn != nil {
+		w.protocol = conn.Subprotocol()
+	}
+	w.extensions = extensions
+	w.conn = conn
+	if connErr != nil {
+		// Pass the error to the user script before exiting immediately
+		w.tq.Queue(func() error {
+			return w.connectionClosedWithError(connErr)
+		})
+		w.tq.Close()
+		return
+	}
+	go w.loop(conn, make(chan struct{}), started)
+	w.tq.Queue(func() error {
+		return w.connectionConnected()
+	})
+}
+
+// dial opens a connection to the server, tags the metrics of the WebSocket
+// with it and emits its metrics. It returns the extensions of the handshake
+// response and when the connection started.
+func (w *webSocket) dial() (*websocket.Conn, []string, time.Time, error) {
 	state := w.vu.State()
-	w.started = time.Now()
+	params := w.params
 	var tlsConfig *tls.Config
 	if state.TLSConfig != nil {
 		tlsConfig = state.TLSConfig.Clone()
@@ -280,14 +320,31 @@ func (w *webSocket) establishConnection(params *wsParams) {
 		Subprotocols:      params.subprocotols,
 	}
 
+	if params.enableCompression {
+		countFrames(&wsd, w.url.String(), tlsConfig)
+	}
+
 	// this is needed because of how interfaces work and that wsd.Jar is http.Cookiejar
 	if params.cookieJar != nil {
 		wsd.Jar = params.cookieJar
 	}
 
 	ctx := w.vu.Context()
+	// the header is cloned, so a reconnection gets a fresh access token
+	header := params.headers
+	if state.AuthSessions != nil && header.Get("Authorization") == "" {
+		authorization, err := state.AuthSessions.Authorization(ctx, state.Transport, w.url.Host)
+		if err != nil {
+			return nil, nil, time.Time{}, err
+		}
+		if authorization != "" {
+			header = header.Clone()
+			header.Set("Authorization", authorization)
+		}
+	}
+
 	start := time.Now()
-	conn, httpResponse, connErr := wsd.DialContext(ctx, w.url.String(), params.headers)
+	conn, httpResponse, connErr := wsd.DialContext(ctx, w.url.String(), header)
 	connectionEnd := time.Now()
 	connectionDuration := metrics.D(connectionEnd.Sub(start))
 
@@ -299,19 +356,26 @@ func (w *webSocket) establishConnection(params *wsParams) {
 		}
 	}
 
+	var extensions []string
 	if httpResponse != nil {
 		defer func() {
 			_ = httpResponse.Body.Close()
 		}()
 
 		w.tagsAndMeta.SetSystemTagOrMetaIfEnabled(systemTags, metrics.TagStatus, strconv.Itoa(httpResponse.StatusCode))
+		var protocol string
 		if conn != nil {
-			w.protocol = conn.Subprotocol()
+			protocol = conn.Subprotocol()
+		}
+		extensions = httpResponse.Header.Values("Sec-WebSocket-Extensions")
+		if conn != nil && isCompressionNegotiated(extensions) {
+			_ = conn.SetCompressionLevel(params.compressionLevel)
+			if wire, ok := conn.NetConn().(*wireConn); ok {
+				wire.compressed = true
+			}
 		}
-		w.extensions = httpResponse.Header.Values("Sec-WebSocket-Extensions")
-		w.tagsAndMeta.SetSystemTagOrMetaIfEnabled(systemTags, metrics.TagSubproto, w.protocol)
+		w.tagsAndMeta.SetSystemTagOrMetaIfEnabled(systemTags, metrics.TagSubproto, protocol)
 	}
-	w.conn = conn
 
 	nameTagValue, nameTagManuallySet := params.tagsAndMeta.Tags.Get(metrics.TagName.String())
 	// After k6 v0.41.0, the `name` and `url` tags have the exact same values:
@@ -323,18 +387,54 @@ func (w *webSocket) establishConnection(params *wsParams) {
 	}
 
 	w.emitConnectionMetrics(ctx, start, connectionDuration)
-	if connErr != nil {
-		// Pass the error to the user script before exiting immediately
+	return conn, extensions, start, connErr
+}
+
+// reconnect dials the server again, with the backoff of the reconnect option
+// between the attempts, after the connection was lost at lostAt. It gives up
+// and closes the WebSocket after the last attempt, or when the WebSocket is
+// closed or the VU is done meanwhile.
+func (w *webSocket) reconnect(lostAt time.Time) {
+	policy := w.params.reconnect
+	ctx := w.vu.Context()
+	giveUp := func(err error) {
 		w.tq.Queue(func() error {
-			return w.connectionClosedWithError(connErr)
+			return w.connectionClosedWithError(err)
 		})
 		w.tq.Close()
-		return
 	}
-	go w.loop()
-	w.tq.Queue(func() error {
-		return w.connectionConnected()
-	})
+
+	var lastErr error
+	for attempt := int64(1); attempt <= policy.maxRetries; attempt++ {
+		timer := time.NewTimer(policy.delay(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			giveUp(nil)
+			return
+		case <-w.stopReconnect:
+			timer.Stop()
+			giveUp(nil)
+			return
+		case <-timer.C:
+		}
+
+		metrics.PushIfNotDone(ctx, w.vu.State().Samples, metrics.Sample{
+			TimeSeries: metrics.TimeSeries{Metric: w.builtinMetrics.WSReconnects, Tags: w.tagsAndMeta.Tags},
+			Time:       time.Now(),
+			Metadata:   w.tagsAndMeta.Metadata,
+			Value:      1,
+		})
+		conn, extensions, started, err := w.dial()
+		if err == nil {
+			w.tq.Queue(func() error {
+				return w.connectionResumed(conn, extensions, started, lostAt)
+			})
+			return
+		}
+		lastErr = err
+	}
+	giveUp(lastErr)
 }
 
 // emitConnectionMetrics emits the metrics for a websocket connection.
@@ -363,17 +463,20 @@ func (w *webSocket) emitConnectionMetrics(ctx context.Context, start time.Time,
 
 const writeWait = 10 * time.Second
 
-func (w *webSocket) loop() {
+// loop handles the connection, until the WebSocket is closed or, if it
+// reconnects, until connDone is closed, because the connection was lost.
+func (w *webSocket) loop(conn *websocket.Conn, connDone chan struct{}, started time.Time) {
 	// Pass ping/pong events through the main control loop
 	pingChan := make(chan string)
 	pongChan := make(chan string)
-	w.conn.SetPingHandler(func(msg string) error { pingChan <- msg; return nil })
-	w.conn.SetPongHandler(func(pingID string) error { pongChan <- pingID; return nil })
+	conn.SetPingHandler(func(msg string) error { pingChan <- msg; return nil })
+	conn.SetPongHandler(func(pingID string) error { pongChan <- pingID; return nil })
 
 	ctx := w.vu.Context()
 	wg := new(sync.WaitGroup)
 
 	defer func() {
+		lostAt := time.Now()
 		metrics.PushIfNotDone(ctx, w.vu.State().Samples, metrics.Sample{
 			TimeSeries: metrics.TimeSeries{
 				Metric: w.builtinMetrics.WSSessionDuration,
@@ -381,15 +484,21 @@ func (w *webSocket) loop() {
 			},
 			Time:     time.Now(),
 			Metadata: w.tagsAndMeta.Metadata,
-			Value:    metrics.D(time.Since(w.started)),
+			Value:    metrics.D(lostAt.Sub(started)),
 		})
-		_ = w.conn.Close()
+		_ = conn.Close()
 		wg.Wait()
-		w.tq.Close()
+		select {
+		case <-w.done:
+			w.tq.Close()
+		default:
+			// the connection was lost and the WebSocket reconnects
+			w.reconnect(lostAt)
+		}
 	}()
 	wg.Add(2)
-	go w.readPump(wg)
-	go w.writePump(wg)
+	go w.readPump(conn, connDone, wg)
+	go w.writePump(conn, connDone, wg)
 
 	ctxDone := ctx.Done()
 	for {
@@ -401,13 +510,15 @@ func (w *webSocket) loop() {
 			ctxDone = nil // this is to block this branch and get through w.done
 		case <-w.done:
 			return
+		case <-connDone:
+			return
 		case pingData := <-pingChan:
 
 			// Handle pings received from the server
 			// - trigger the `ping` event
 			// - reply with pong (needed when `SetPingHandler` is overwritten)
 			// WriteControl is okay to be concurrent so we don't need to gsend this over writeChannel
-			err := w.conn.WriteControl(websocket.PongMessage, []byte(pingData), time.Now().Add(writeWait))
+			err := conn.WriteControl(websocket.PongMessage, []byte(pingData), time.Now().Add(writeWait))
 			w.tq.Queue(func() error {
 				if err != nil {
 					return w.callErrorListeners(err)
@@ -479,19 +590,35 @@ func (w *webSocket) queueMessage(msg *message) {
 				return err
 			}
 		}
-		return nil
+		if err := w.deliverToMessageIterators(ev); err != nil {
+			return err
+		}
+		return w.resolvePendingRequests(ev, msg)
 	})
 }
 
-func (w *webSocket) readPump(wg *sync.WaitGroup) {
+func (w *webSocket) readPump(conn *websocket.Conn, connDone chan struct{}, wg *sync.WaitGroup) {
 	defer wg.Done()
+	wire := compressedWireConn(conn)
+	var lastRead int64
+	if wire != nil {
+		lastRead = wire.read.Load()
+	}
 	for {
-		messageType, data, err := w.conn.ReadMessage()
+		messageType, data, readErr := conn.ReadMessage()
+		err := readErr
 		if err == nil {
+			t := time.Now()
+			if wire != nil {
+				read := wire.read.Load()
+				w.pushMessageSizes(w.vu.Context(), w.vu.State().Samples, t, len(data), read-lastRead,
+					w.builtinMetrics.WSMessageBytesReceived, w.builtinMetrics.WSMessageCompressedBytesReceived)
+				lastRead = read
+			}
 			w.queueMessage(&message{
 				mtype: messageType,
 				data:  data,
-				t:     time.Now(),
+				t:     t,
 			})
 
 			continue
@@ -505,25 +632,30 @@ func (w *webSocket) readPump(wg *sync.WaitGroup) {
 
 		if err != nil {
 			w.tq.Queue(func() error {
-				_ = w.conn.Close() // TODO fix this
+				_ = conn.Close() // TODO fix this
 				return nil
 			})
 		}
 
 		w.tq.Queue(func() error {
-			return w.connectionClosedWithError(err)
+			return w.connectionLost(connDone, readErr, err)
 		})
 
 		return
 	}
 }
 
-func (w *webSocket) writePump(wg *sync.WaitGroup) {
+func (w *webSocket) writePump(conn *websocket.Conn, connDone chan struct{}, wg *sync.WaitGroup) {
 	defer wg.Done()
 	wg.Add(1)
 	samplesOutput := w.vu.State().Samples
 	ctx := w.vu.Context()
 	writeChannel := make(chan message)
+	wire := compressedWireConn(conn)
+	var lastWritten int64
+	if wire != nil {
+		lastWritten = wire.written.Load()
+	}
 	go func() {
 		defer wg.Done()
 		for {
@@ -536,17 +668,16 @@ func (w *webSocket) writePump(wg *sync.WaitGroup) {
 
 				err := func() error {
 					if msg.mtype != websocket.PingMessage {
-						return w.conn.WriteMessage(msg.mtype, msg.data)
+						return conn.WriteMessage(msg.mtype, msg.data)
 					}
 
 					// WriteControl is concurrently okay
-					return w.conn.WriteControl(msg.mtype, msg.data, msg.t.Add(writeWait))
+					return conn.WriteControl(msg.mtype, msg.data, msg.t.Add(writeWait))
 				}()
 				if err != nil {
 					w.tq.Queue(func() error {
-						_ = w.conn.Close() // TODO fix
-						closeErr := w.connectionClosedWithError(err)
-						return closeErr
+						_ = conn.Close() // TODO fix
+						return w.connectionLost(connDone, err, err)
 					})
 					return
 				}
@@ -557,6 +688,12 @@ func (w *webSocket) writePump(wg *sync.WaitGroup) {
 					return nil
 				})
 
+				if wire != nil && (msg.mtype == websocket.TextMessage || msg.mtype == websocket.BinaryMessage) {
+					written := wire.written.Load()
+					w.pushMessageSizes(ctx, samplesOutput, time.Now(), size, written-lastWritten,
+						w.builtinMetrics.WSMessageBytesSent, w.builtinMetrics.WSMessageCompressedBytesSent)
+					lastWritten = written
+				}
 				metrics.PushIfNotDone(ctx, samplesOutput, metrics.Sample{
 					TimeSeries: metrics.TimeSeries{
 						Metric: w.builtinMetrics.WSMessagesSent,
@@ -568,6 +705,8 @@ func (w *webSocket) writePump(wg *sync.WaitGroup) {
 				})
 			case <-w.done:
 				return
+			case <-connDone:
+				return
 			}
 		}
 	}()
@@ -589,6 +728,8 @@ func (w *webSocket) writePump(wg *sync.WaitGroup) {
 				queue = queue[:copy(queue, queue[1:])]
 			case <-w.done:
 				return
+			case <-connDone:
+				return
 			}
 		}
 	}
@@ -732,6 +873,11 @@ func (w *webSocket) close(code int, reason string) {
 		return
 	}
 	w.readyState = CLOSING
+	if w.reconnecting {
+		// there isn't a connection to send the close message over
+		close(w.stopReconnect)
+		return
+	}
 	if code == 0 {
 		code = websocket.CloseNormalClosure
 	}
@@ -759,6 +905,72 @@ func (w *webSocket) connectionConnected() error {
 	return w.callOpenListeners(time.Now()) // TODO fix time
 }
 
+// to be run only on the eventloop
+// connectionLost handles the loss of the connection, which connDone tracks,
+// with the error of reading from or writing to it. If the reconnect option is
+// set, the WebSocket reconnects, unless the script closed it, the VU is done or
+// the server closed it normally. Otherwise, it's closed with closeErr.
+func (w *webSocket) connectionLost(connDone chan struct{}, err, closeErr error) error {
+	select {
+	case <-connDone:
+		return nil // the WebSocket is already reconnecting
+	default:
+	}
+	if w.params.reconnect == nil || w.readyState != OPEN || w.vu.Context().Err() != nil ||
+		websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+		return w.connectionClosedWithError(closeErr)
+	}
+
+	w.readyState = CONNECTING
+	w.reconnecting = true
+	close(connDone)
+	w.rejectPendingRequests()
+	return nil
+}
+
+// to be run only on the eventloop
+// connectionResumed opens the WebSocket again with the new connection, after
+// the previous one was lost at lostAt.
+func (w *webSocket) connectionResumed(
+	conn *websocket.Conn, extensions []string, started, lostAt time.Time,
+) error {
+	w.reconnecting = false
+	if w.readyState != CONNECTING {
+		// the WebSocket was closed while it was reconnecting
+		_ = conn.Close()
+		err := w.connectionClosedWithError(nil)
+		w.tq.Close()
+		return err
+	}
+
+	w.conn = conn
+	w.protocol = conn.Subprotocol()
+	w.extensions = extensions
+	w.readyState = OPEN
+	now := time.Now()
+	metrics.PushIfNotDone(w.vu.Context(), w.vu.State().Samples, metrics.ConnectedSamples{
+		Samples: []metrics.Sample{
+			{
+				TimeSeries: metrics.TimeSeries{Metric: w.builtinMetrics.WSConnectionsResumed, Tags: w.tagsAndMeta.Tags},
+				Time:       now,
+				Metadata:   w.tagsAndMeta.Metadata,
+				Value:      1,
+			},
+			{
+				TimeSeries: metrics.TimeSeries{Metric: w.builtinMetrics.WSReconnectGap, Tags: w.tagsAndMeta.Tags},
+				Time:       now,
+				Metadata:   w.tagsAndMeta.Metadata,
+				Value:      metrics.D(now.Sub(lostAt)),
+			},
+		},
+		Tags: w.tagsAndMeta.Tags,
+		Time: now,
+	})
+
+	go w.loop(conn, make(chan struct{}), started)
+	return w.callOpenListeners(now)
+}
+
 // to be run only on the eventloop
 func (w *webSocket) connectionClosedWithError(err error) error {
 	if w.readyState == CLOSED {
@@ -766,6 +978,10 @@ func (w *webSocket) connectionClosedWithError(err error) error {
 	}
 	w.readyState = CLOSED
 	close(w.done)
+	w.rejectPendingRequests()
+	if err := w.finishMessageIterators(); err != nil {
+		return err
+	}
 
 	if err != nil {
 		if errList := w.callErrorListeners(err); errList != nil {
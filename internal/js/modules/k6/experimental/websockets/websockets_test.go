@@ -9,6 +9,7 @@ import (
 	"strings"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/gorilla/websocket"
 	"github.com/sirupsen/logrus"
@@ -16,6 +17,7 @@ import (
 	"github.com/stretchr/testify/require"
 	"gopkg.in/guregu/null.v3"
 
+	"go.k6.io/k6/internal/js/modules/k6/timers"
 	"go.k6.io/k6/internal/lib/testutils"
 	"go.k6.io/k6/internal/lib/testutils/httpmultibin"
 	httpModule "go.k6.io/k6/js/modules/k6/http"
@@ -1235,6 +1237,57 @@ func TestCompressionSession(t *testing.T) {
 	assert.Len(t, ts.errors, 0)
 }
 
+func TestCompressionMessageSizes(t *testing.T) {
+	t.Parallel()
+	ts := newTestState(t)
+	sr := ts.tb.Replacer.Replace
+
+	upgrader := &websocket.Upgrader{EnableCompression: true}
+	ts.tb.Mux.HandleFunc("/ws-compression-echo", func(w http.ResponseWriter, req *http.Request) {
+		conn, err := upgrader.Upgrade(w, req, w.Header())
+		if err != nil {
+			ts.errors <- err
+			return
+		}
+		defer func() { _ = conn.Close() }()
+		for {
+			kind, data, err := conn.ReadMessage()
+			if err != nil || conn.WriteMessage(kind, data) != nil {
+				return
+			}
+		}
+	})
+
+	_, err := ts.runtime.RunOnEventLoop(sr(`
+		const text = "k6 ".repeat(1000);
+		var ws = new WebSocket("WSBIN_URL/ws-compression-echo", null, {
+			compression: { algorithm: "deflate", level: 9 },
+		})
+		ws.onopen = () => ws.send(text)
+		ws.onmessage = (event) => {
+			if (event.data !== text) {
+				throw new Error("wrong message received from server: " + event.data)
+			}
+			ws.close()
+		}
+	`))
+	require.NoError(t, err)
+
+	sizes := make(map[string]float64)
+	for _, sc := range metrics.GetBufferedSamples(ts.samples) {
+		for _, s := range sc.GetSamples() {
+			sizes[s.Metric.Name] += s.Value
+		}
+	}
+	assert.Equal(t, 3000.0, sizes[metrics.WSMessageBytesSentName])
+	assert.Equal(t, 3000.0, sizes[metrics.WSMessageBytesReceivedName])
+	for _, name := range []string{metrics.WSMessageCompressedBytesSentName, metrics.WSMessageCompressedBytesReceivedName} {
+		assert.Positive(t, sizes[name], name)
+		assert.Less(t, sizes[name], 100.0, name)
+	}
+	assert.Len(t, ts.errors, 0)
+}
+
 func TestServerWithoutCompression(t *testing.T) {
 	t.Parallel()
 	const text string = `Lorem ipsum dolor sit amet, consectetur adipiscing elit. Maecenas sed pharetra sapien. Nunc laoreet molestie ante ac gravida. Etiam interdum dui viverra posuere egestas. Pellentesque at dolor tristique, mattis turpis eget, commodo purus. Nunc orci aliquam.`
@@ -1308,6 +1361,19 @@ func TestCompressionParams(t *testing.T) {
 			compression:   `"deflate, "`,
 			expectedError: `unsupported compression algorithm 'deflate,', supported algorithm is 'deflate'`,
 		},
+		{compression: `{"algorithm": "deflate", "level": 9}`},
+		{
+			compression:   `{"algorithm": "deflate", "level": 10}`,
+			expectedError: `the compression level must be between -2 and 9, but it's 10`,
+		},
+		{
+			compression:   `{"level": 1}`,
+			expectedError: `unsupported compression algorithm 'undefined', supported algorithm is 'deflate'`,
+		},
+		{
+			compression:   `{"algorithm": "deflate", "window": 15}`,
+			expectedError: `unknown compression option window`,
+		},
 	}
 
 	for _, testCase := range testCases {
@@ -1550,3 +1616,178 @@ func TestReadyStateSwitch(t *testing.T) {
 	logs := hook.Drain()
 	require.Len(t, logs, 0)
 }
+
+// addDroppingHandler adds a handler, which drops the first drops connections
+// abruptly after they're opened, and rejects the handshakes after the first
+// rejectAfter connections, if it's positive.
+func (ts *testState) addDroppingHandler(uri string, drops, rejectAfter int) {
+	var mx sync.Mutex
+	var connections int
+	ts.tb.Mux.HandleFunc(uri, func(w http.ResponseWriter, req *http.Request) {
+		mx.Lock()
+		connections++
+		n := connections
+		mx.Unlock()
+		if rejectAfter > 0 && n > rejectAfter {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		conn, err := (&websocket.Upgrader{}).Upgrade(w, req, w.Header())
+		if err != nil {
+			ts.errors <- fmt.Errorf("%s cannot upgrade request: %w", uri, err)
+			return
+		}
+		if n <= drops {
+			// like a restarted server, without a close message
+			_ = conn.UnderlyingConn().Close()
+			return
+		}
+		defer func() { _ = conn.Close() }()
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	})
+}
+
+func countSamples(containers []metrics.SampleContainer, metricName string) int {
+	var count int
+	for _, container := range containers {
+		for _, sample := range container.GetSamples() {
+			if sample.Metric.Name == metricName {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+func TestReconnect(t *testing.T) {
+	t.Parallel()
+	ts := newTestState(t)
+	ts.addDroppingHandler("/ws/drop", 2, 0)
+	_, err := ts.runtime.RunOnEventLoop(ts.tb.Replacer.Replace(`
+		var opens = 0;
+		var ws = new WebSocket("WSBIN_URL/ws/drop", null, { reconnect: { initialDelay: "10ms", factor: 1.5 } })
+		ws.onopen = () => {
+			opens++;
+			call("open " + opens + " " + ws.readyState);
+			if (opens == 3) {
+				ws.close()
+			}
+		}
+		ws.onerror = (e) => { call("error " + e.error) }
+		ws.onclose = () => { call("close " + ws.readyState) }
+	`))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"open 1 1", "open 2 1", "open 3 1", "close 3"}, ts.callRecorder.Recorded())
+
+	samples := metrics.GetBufferedSamples(ts.samples)
+	assert.Equal(t, 3, countSamples(samples, metrics.WSSessionsName))
+	assert.Equal(t, 3, countSamples(samples, metrics.WSSessionDurationName))
+	assert.Equal(t, 2, countSamples(samples, metrics.WSReconnectsName))
+	assert.Equal(t, 2, countSamples(samples, metrics.WSConnectionsResumedName))
+	assert.Equal(t, 2, countSamples(samples, metrics.WSReconnectGapName))
+	for _, container := range samples {
+		for _, sample := range container.GetSamples() {
+			if sample.Metric.Name == metrics.WSReconnectGapName {
+				assert.GreaterOrEqual(t, sample.Value, 10.0, "the gap includes the backoff")
+			}
+		}
+	}
+}
+
+func TestReconnectGivesUp(t *testing.T) {
+	t.Parallel()
+	ts := newTestState(t)
+	ts.addDroppingHandler("/ws/drop", 1, 1)
+	_, err := ts.runtime.RunOnEventLoop(ts.tb.Replacer.Replace(`
+		var ws = new WebSocket("WSBIN_URL/ws/drop", null, { reconnect: { maxRetries: 2, initialDelay: 10 } })
+		ws.onopen = () => { call("open") }
+		ws.onerror = (e) => { call("error " + e.error) }
+		ws.onclose = () => { call("close") }
+	`))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"open", "error websocket: bad handshake", "close"}, ts.callRecorder.Recorded())
+
+	samples := metrics.GetBufferedSamples(ts.samples)
+	assert.Equal(t, 3, countSamples(samples, metrics.WSSessionsName))
+	assert.Equal(t, 2, countSamples(samples, metrics.WSReconnectsName))
+	assert.Zero(t, countSamples(samples, metrics.WSConnectionsResumedName))
+}
+
+func TestReconnectCloseWhileReconnecting(t *testing.T) {
+	t.Parallel()
+	ts := newTestState(t)
+	timersModule := timers.New().NewModuleInstance(ts.runtime.VU)
+	require.NoError(t, ts.runtime.VU.RuntimeField.Set("setTimeout", timersModule.Exports().Named["setTimeout"]))
+	ts.addDroppingHandler("/ws/drop", 1, 0)
+	_, err := ts.runtime.RunOnEventLoop(ts.tb.Replacer.Replace(`
+		var ws = new WebSocket("WSBIN_URL/ws/drop", null, { reconnect: { initialDelay: "1h" } })
+		ws.onopen = () => {
+			call("open")
+			setTimeout(() => {
+				call("state " + ws.readyState)
+				ws.close()
+			}, 50)
+		}
+		ws.onclose = () => { call("close") }
+	`))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"open", "state 0", "close"}, ts.callRecorder.Recorded())
+}
+
+func TestWithoutReconnect(t *testing.T) {
+	t.Parallel()
+	ts := newTestState(t)
+	ts.addDroppingHandler("/ws/drop", 1, 0)
+	_, err := ts.runtime.RunOnEventLoop(ts.tb.Replacer.Replace(`
+		var ws = new WebSocket("WSBIN_URL/ws/drop")
+		ws.onopen = () => { call("open") }
+		ws.onclose = () => { call("close") }
+	`))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"open", "close"}, ts.callRecorder.Recorded())
+	assert.Zero(t, countSamples(metrics.GetBufferedSamples(ts.samples), metrics.WSConnectionsResumedName))
+}
+
+func TestReconnectInvalidParams(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]string{
+		`{ maxRetries: 0 }`:       "the reconnect maxRetries must be positive",
+		`{ initialDelay: "-1s" }`: "the reconnect initialDelay must be positive",
+		`{ maxDelay: "abc" }`:     "invalid reconnect maxDelay",
+		`{ factor: 0.5 }`:         "the reconnect factor can't be less than 1",
+		`{ jitter: 1.5 }`:         "the reconnect jitter must be between 0 and 1",
+		`{ retries: 3 }`:          "unknown reconnect option retries",
+	}
+	for reconnect, expErr := range testCases {
+		t.Run(reconnect, func(t *testing.T) {
+			t.Parallel()
+			ts := newTestState(t)
+			_, err := ts.runtime.RunOnEventLoop(ts.tb.Replacer.Replace(
+				`new WebSocket("WSBIN_URL/ws-echo", null, { reconnect: ` + reconnect + ` })`))
+			require.ErrorContains(t, err, expErr)
+		})
+	}
+}
+
+func TestReconnectPolicyDelay(t *testing.T) {
+	t.Parallel()
+
+	policy := defaultReconnectPolicy()
+	assert.Equal(t, 500*time.Millisecond, policy.delay(1))
+	assert.Equal(t, time.Second, policy.delay(2))
+	assert.Equal(t, 4*time.Second, policy.delay(4))
+	assert.Equal(t, 30*time.Second, policy.delay(10))
+
+	policy.jitter = 0.5
+	for range 100 {
+		d := policy.delay(2)
+		assert.GreaterOrEqual(t, d, 500*time.Millisecond)
+		assert.LessOrEqual(t, d, time.Second)
+	}
+}
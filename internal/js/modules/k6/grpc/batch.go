@@ -0,0 +1,114 @@
+package grpc
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/grafana/sobek"
+
+	"go.k6.io/k6/internal/lib/netext/grpcext"
+)
+
+// Batch makes multiple unary RPCs concurrently over the connection of the
+// client, at most as many at once as the batch option allows. The calls are
+// an array or an object of [method, request, params] arrays or of
+// {method, request, params} objects, and the responses have the same shape.
+func (c *Client) Batch(calls sobek.Value) (any, error) {
+	if c.vu.State() == nil {
+		return nil, errors.New("invoking RPC methods in the init context is not supported")
+	}
+	if calls == nil || sobek.IsUndefined(calls) || sobek.IsNull(calls) {
+		return nil, errors.New("batch requires an array or an object of calls")
+	}
+
+	rt := c.vu.Runtime()
+	obj := calls.ToObject(rt)
+
+	var keys []string
+	switch exported := calls.Export().(type) {
+	case []any:
+		keys = make([]string, len(exported))
+		for i := range exported {
+			keys[i] = strconv.Itoa(i)
+		}
+	case map[string]any:
+		keys = obj.Keys()
+	default:
+		return nil, fmt.Errorf("invalid batch argument type %T", exported)
+	}
+
+	reqs := make([]grpcext.InvokeRequest, len(keys))
+	for i, key := range keys {
+		req, err := c.buildBatchRequest(obj.Get(key))
+		if err != nil {
+			return nil, fmt.Errorf("invalid batch call %q: %w", key, err)
+		}
+		reqs[i] = req
+	}
+
+	resps, err := c.invokeBatch(reqs)
+
+	if _, isArray := calls.Export().([]any); isArray {
+		return resps, err
+	}
+	results := make(map[string]*grpcext.InvokeResponse, len(keys))
+	for i, key := range keys {
+		results[key] = resps[i]
+	}
+	return results, err
+}
+
+// buildBatchRequest creates the request of a single call of a batch.
+func (c *Client) buildBatchRequest(call sobek.Value) (grpcext.InvokeRequest, error) {
+	if call == nil || sobek.IsUndefined(call) || sobek.IsNull(call) {
+		return grpcext.InvokeRequest{}, errors.New("the call can't be empty")
+	}
+	obj := call.ToObject(c.vu.Runtime())
+
+	var method, req, params sobek.Value
+	if _, isArray := call.Export().([]any); isArray {
+		method, req, params = obj.Get("0"), obj.Get("1"), obj.Get("2")
+	} else {
+		method, req, params = obj.Get("method"), obj.Get("request"), obj.Get("params")
+	}
+	if method == nil || sobek.IsUndefined(method) {
+		return grpcext.InvokeRequest{}, errors.New("the method of the call is required")
+	}
+	if req != nil && sobek.IsUndefined(req) {
+		req = nil
+	}
+
+	return c.buildInvokeRequest(method.String(), req, params)
+}
+
+// invokeBatch makes the RPCs concurrently and returns their responses in the
+// same order, together with the errors of the calls, which failed.
+func (c *Client) invokeBatch(reqs []grpcext.InvokeRequest) ([]*grpcext.InvokeResponse, error) {
+	parallel := len(reqs)
+	if limit := int(c.vu.State().Options.Batch.Int64); limit > 0 && limit < parallel {
+		parallel = limit
+	}
+
+	var (
+		resps    = make([]*grpcext.InvokeResponse, len(reqs))
+		errs     = make([]error, len(reqs))
+		wg       sync.WaitGroup
+		inFlight = make(chan struct{}, parallel)
+	)
+	for i, req := range reqs {
+		wg.Add(1)
+		inFlight <- struct{}{}
+		go func() {
+			defer func() {
+				<-inFlight
+				wg.Done()
+			}()
+			resps[i], errs[i] = c.conn.Invoke(c.vu.Context(), req)
+		}()
+	}
+	wg.Wait()
+
+	return resps, errors.Join(errs...)
+}
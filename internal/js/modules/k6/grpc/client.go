@@ -14,13 +14,17 @@ import (
 	"go.k6.io/k6/internal/lib/netext/grpcext"
 	"go.k6.io/k6/js/common"
 	"go.k6.io/k6/js/modules"
+	"go.k6.io/k6/lib/types"
+	"go.k6.io/k6/metrics"
 
 	"github.com/grafana/sobek"
 	"github.com/jhump/protoreflect/desc"            //nolint:staticcheck // FIXME: #4035
 	"github.com/jhump/protoreflect/desc/protoparse" //nolint:staticcheck // FIXME: #4035
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/reflect/protodesc"
@@ -32,10 +36,13 @@ import (
 
 // Client represents a gRPC client that can be used to make RPC requests
 type Client struct {
-	mds  map[string]protoreflect.MethodDescriptor
-	conn *grpcext.Conn
-	vu   modules.VU
-	addr string
+	mds     map[string]protoreflect.MethodDescriptor
+	conn    *grpcext.Conn
+	vu      modules.VU
+	addr    string
+	metrics *instanceMetrics
+	// descriptors are the descriptors reflected by the clients of all VUs
+	descriptors *descriptorCache
 }
 
 // Load will parse the given proto files and make the file descriptors available to request.
@@ -254,23 +261,24 @@ func (c *Client) Connect(addr string, params sobek.Value) (bool, error) {
 	if err != nil {
 		return false, err
 	}
+	go c.watchReconnects(c.conn)
 
 	if !p.UseReflectionProtocol {
 		return true, nil
 	}
 
-	ctx = metadata.NewOutgoingContext(ctx, p.ReflectionMetadata)
-
-	fdset, err := c.conn.Reflect(ctx)
+	// the descriptors are reflected once per test, and shared by the clients
+	// of all VUs, unless they're refreshed
+	key := newDescriptorKey(addr, p.ReflectServices)
+	entry, err := c.descriptors.get(ctx, key, p.ReflectRefresh, func() (*descriptorpb.FileDescriptorSet, error) {
+		return c.conn.Reflect(metadata.NewOutgoingContext(ctx, p.ReflectionMetadata), p.ReflectServices...)
+	})
 	if err != nil {
 		return false, err
 	}
-	_, err = c.convertToMethodInfo(fdset)
-	if err != nil {
-		return false, fmt.Errorf("can't convert method info: %w", err)
-	}
+	c.addMethods(entry.mds)
 
-	return true, err
+	return true, nil
 }
 
 // Invoke creates and calls a unary RPC by fully qualified method name
@@ -316,6 +324,127 @@ func (c *Client) AsyncInvoke(
 	return promise, nil
 }
 
+// HealthCheck checks if the service, or the whole server if it's nullish, is
+// serving, with the standard grpc.health.v1 protocol. The proto file of the
+// protocol doesn't need to be loaded.
+func (c *Client) HealthCheck(service sobek.Value, params sobek.Value) (*grpcext.InvokeResponse, error) {
+	var name string
+	if !common.IsNullish(service) {
+		name = service.String()
+	}
+	req := c.vu.Runtime().ToValue(map[string]any{"service": name})
+	return c.Invoke(healthpb.Health_Check_FullMethodName, req, params)
+}
+
+// ConnectivityState returns the connectivity state of the connection, e.g.
+// READY or TRANSIENT_FAILURE, or SHUTDOWN if the client isn't connected.
+func (c *Client) ConnectivityState() string {
+	if c.conn == nil {
+		return connectivity.Shutdown.String()
+	}
+	return c.conn.ConnectivityState().String()
+}
+
+// WatchConnectivityState returns a promise of the connectivity state of the
+// connection, which is resolved when it's different from the given one, or
+// with the unchanged state, when the timeout passes first.
+func (c *Client) WatchConnectivityState(state string, timeout sobek.Value) *sobek.Promise {
+	promise, resolve, reject := c.vu.Runtime().NewPromise()
+	if c.conn == nil {
+		must(c.vu.Runtime(), reject(errors.New("no gRPC connection, you must call connect first")))
+		return promise
+	}
+	source, ok := parseConnectivityState(state)
+	if !ok {
+		must(c.vu.Runtime(), reject(fmt.Errorf("invalid connectivity state %q", state)))
+		return promise
+	}
+	var d time.Duration
+	if !common.IsNullish(timeout) {
+		var err error
+		d, err = types.GetDurationValue(timeout.Export())
+		if err == nil && d <= 0 {
+			err = errors.New("it must be positive")
+		}
+		if err != nil {
+			must(c.vu.Runtime(), reject(fmt.Errorf("invalid timeout value: %w", err)))
+			return promise
+		}
+	}
+
+	conn := c.conn
+	callback := c.vu.RegisterCallback()
+	go func() {
+		ctx := c.vu.Context()
+		if d > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, d)
+			defer cancel()
+		}
+		conn.WaitForStateChange(ctx, source)
+		current := conn.ConnectivityState().String()
+		callback(func() error { return resolve(current) })
+	}()
+	return promise
+}
+
+func parseConnectivityState(name string) (connectivity.State, bool) {
+	for state := connectivity.Idle; state <= connectivity.Shutdown; state++ {
+		if state.String() == name {
+			return state, true
+		}
+	}
+	return 0, false
+}
+
+// watchReconnects counts the reconnections of the connection, i.e. the times
+// it becomes ready again, after it wasn't, until it's closed.
+func (c *Client) watchReconnects(conn *grpcext.Conn) {
+	ctx, state := c.vu.Context(), c.vu.State()
+	current := conn.ConnectivityState()
+	for conn.WaitForStateChange(ctx, current) {
+		current = conn.ConnectivityState()
+		switch current { //nolint:exhaustive
+		case connectivity.Shutdown:
+			return
+		case connectivity.Ready:
+			ctm := state.Tags.GetCurrentValues()
+			metrics.PushIfNotDone(ctx, state.Samples, metrics.Sample{
+				TimeSeries: metrics.TimeSeries{
+					Metric: c.metrics.Reconnects,
+					Tags:   ctm.Tags,
+				},
+				Time:     time.Now(),
+				Metadata: ctm.Metadata,
+				Value:    1,
+			})
+		}
+	}
+}
+
+// defaultStreamBufferSize is the number of the received messages, which aren't
+// consumed yet, after which the streams of InvokeStream stop reading.
+const defaultStreamBufferSize = 10
+
+// InvokeStream begins a stream of the method, like the Stream constructor, but
+// its received messages are meant to be consumed with `for await`, and it
+// stops reading them from the server, when bufferSize of them (10 by default)
+// weren't consumed yet.
+func (c *Client) InvokeStream(method string, params sobek.Value) (*sobek.Object, error) {
+	if c.vu.State() == nil {
+		return nil, common.NewInitContextError("invoking RPC methods in the init context is not supported")
+	}
+	if c.conn == nil {
+		return nil, errors.New("no gRPC connection, you must call connect first")
+	}
+
+	s, err := newStream(c, method, params, defaultStreamBufferSize)
+	if err != nil {
+		return nil, err
+	}
+	return s.obj, nil
+}
+
 // buildInvokeRequest creates a new InvokeRequest from the given method name, request object and parameters
 func (c *Client) buildInvokeRequest(
 	method string,
@@ -337,7 +466,7 @@ func (c *Client) buildInvokeRequest(
 	if method[0] != '/' {
 		method = "/" + method
 	}
-	methodDesc := c.mds[method]
+	methodDesc := c.lookupMethod(method)
 	if methodDesc == nil {
 		return grpcReq, fmt.Errorf("method %q not found in file descriptors", method)
 	}
@@ -346,6 +475,9 @@ func (c *Client) buildInvokeRequest(
 	if err != nil {
 		return grpcReq, fmt.Errorf("invalid GRPC's client.invoke() parameters: %w", err)
 	}
+	if p.BufferSize > 0 {
+		return grpcReq, errors.New("invalid GRPC's client.invoke() parameters: bufferSize is only supported by the streams")
+	}
 
 	// k6 GRPC Invoke's default timeout is 2 minutes
 	if p.Timeout == time.Duration(0) {
@@ -361,6 +493,9 @@ func (c *Client) buildInvokeRequest(
 	}
 
 	p.SetSystemTags(state, c.addr, method)
+	if err = p.SetAuthorization(c.vu.Context(), state, c.addr); err != nil {
+		return grpcReq, err
+	}
 
 	return grpcext.InvokeRequest{
 		Method:                 method,
@@ -370,6 +505,7 @@ func (c *Client) buildInvokeRequest(
 		Message:                b,
 		TagsAndMeta:            &p.TagsAndMeta,
 		Metadata:               p.Metadata,
+		Compression:            p.Compression,
 	}, nil
 }
 
@@ -393,23 +529,44 @@ type MethodInfo struct {
 }
 
 func (c *Client) convertToMethodInfo(fdset *descriptorpb.FileDescriptorSet) ([]MethodInfo, error) {
-	files, err := protodesc.NewFiles(fdset)
+	rtn, mds, err := linkMethods(fdset)
 	if err != nil {
 		return nil, err
 	}
-	var rtn []MethodInfo
+	c.addMethods(mds)
+	return rtn, nil
+}
+
+// addMethods makes the methods available to the requests of the client.
+func (c *Client) addMethods(mds map[string]protoreflect.MethodDescriptor) {
 	if c.mds == nil {
 		// This allows us to call load() multiple times, without overwriting the
 		// previously loaded definitions.
-		c.mds = make(map[string]protoreflect.MethodDescriptor)
+		c.mds = make(map[string]protoreflect.MethodDescriptor, len(mds))
+	}
+	for name, md := range mds {
+		c.mds[name] = md
+	}
+}
+
+// linkMethods links the file descriptors and returns their methods, with their
+// descriptors by their full names.
+func linkMethods(
+	fdset *descriptorpb.FileDescriptorSet,
+) ([]MethodInfo, map[string]protoreflect.MethodDescriptor, error) {
+	files, err := protodesc.NewFiles(fdset)
+	if err != nil {
+		return nil, nil, err
 	}
+	var rtn []MethodInfo
+	mds := make(map[string]protoreflect.MethodDescriptor)
 	appendMethodInfo := func(
 		fd protoreflect.FileDescriptor,
 		sd protoreflect.ServiceDescriptor,
 		md protoreflect.MethodDescriptor,
 	) {
 		name := fmt.Sprintf("/%s/%s", sd.FullName(), md.Name())
-		c.mds[name] = md
+		mds[name] = md
 		rtn = append(rtn, MethodInfo{
 			MethodInfo: grpc.MethodInfo{
 				Name:           string(md.Name()),
@@ -460,9 +617,9 @@ func (c *Client) convertToMethodInfo(fdset *descriptorpb.FileDescriptorSet) ([]M
 		return true
 	})
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	return rtn, nil
+	return rtn, mds, nil
 }
 
 func walkFileDescriptors(seen map[string]struct{}, fd *desc.FileDescriptor) []*descriptorpb.FileDescriptorProto {
@@ -503,7 +660,7 @@ func (c *Client) getMethodDescriptor(method string) (protoreflect.MethodDescript
 		return nil, errors.New("method to invoke cannot be empty")
 	}
 
-	methodDesc := c.mds[method]
+	methodDesc := c.lookupMethod(method)
 
 	if methodDesc == nil {
 		return nil, fmt.Errorf("method %q not found in file descriptors", method)
@@ -511,3 +668,16 @@ func (c *Client) getMethodDescriptor(method string) (protoreflect.MethodDescript
 
 	return methodDesc, nil
 }
+
+// lookupMethod returns the descriptor of the method with the full name, from
+// the loaded or reflected ones, or from the health checking protocol, which is
+// always available.
+func (c *Client) lookupMethod(method string) protoreflect.MethodDescriptor {
+	if md, ok := c.mds[method]; ok {
+		return md
+	}
+	if method == healthpb.Health_Check_FullMethodName {
+		return healthpb.File_grpc_health_v1_health_proto.Services().ByName("Health").Methods().ByName("Check")
+	}
+	return nil
+}
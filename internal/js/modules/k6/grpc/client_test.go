@@ -7,8 +7,11 @@ import (
 	"crypto/x509"
 	"encoding/pem"
 	"fmt"
+	"net"
 	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	k6grpc "go.k6.io/k6/internal/js/modules/k6/grpc"
 	"go.k6.io/k6/internal/lib/netext/grpcext"
@@ -20,6 +23,7 @@ import (
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/reflection"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/reflect/protoreflect"
@@ -31,7 +35,9 @@ import (
 	"github.com/golang/protobuf/ptypes/wrappers"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc/metadata"
+	v1grpc "google.golang.org/grpc/reflection/grpc_reflection_v1"
 	v1alphagrpc "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
 	grpcstats "google.golang.org/grpc/stats"
 	"gopkg.in/guregu/null.v3"
@@ -400,6 +406,65 @@ func TestClient(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "Batch",
+			initString: codeBlock{code: `
+				var client = new grpc.Client();
+				client.load([], "../../../../lib/testutils/httpmultibin/grpc_testing/test.proto");`},
+			setup: func(tb *httpmultibin.HTTPMultiBin) {
+				tb.GRPCStub.EmptyCallFunc = func(context.Context, *grpc_testing.Empty) (*grpc_testing.Empty, error) {
+					return &grpc_testing.Empty{}, nil
+				}
+				tb.GRPCStub.UnaryCallFunc = func(_ context.Context, req *grpc_testing.SimpleRequest) (*grpc_testing.SimpleResponse, error) {
+					return &grpc_testing.SimpleResponse{Username: string(req.GetPayload().GetBody())}, nil
+				}
+			},
+			vuString: codeBlock{
+				code: `
+				client.connect("GRPCBIN_ADDR");
+				var resps = client.batch([
+					["grpc.testing.TestService/EmptyCall", {}],
+					{ method: "grpc.testing.TestService/UnaryCall", request: { payload: { body: "YWxpY2U=" } } },
+					["grpc.testing.TestService/UnaryCall", { payload: { body: "Ym9i" } }, { tags: { who: "bob" } }],
+				]);
+				if (resps.length !== 3) {
+					throw new Error("unexpected responses: " + JSON.stringify(resps))
+				}
+				resps.forEach(function(resp) {
+					if (resp.status !== grpc.StatusOK) {
+						throw new Error("unexpected error: " + JSON.stringify(resp.error) + "or status: " + resp.status)
+					}
+				})
+				if (resps[1].message.username !== "alice" || resps[2].message.username !== "bob") {
+					throw new Error("unexpected messages: " + JSON.stringify(resps))
+				}
+
+				var named = client.batch({
+					empty: { method: "grpc.testing.TestService/EmptyCall", request: {} },
+					unary: ["grpc.testing.TestService/UnaryCall", { payload: { body: "Y2Fyb2w=" } }],
+				});
+				if (named.empty.status !== grpc.StatusOK || named.unary.message.username !== "carol") {
+					throw new Error("unexpected responses: " + JSON.stringify(named))
+				}`,
+				asserts: func(t *testing.T, rb *httpmultibin.HTTPMultiBin, samples chan metrics.SampleContainer, _ error) {
+					samplesBuf := metrics.GetBufferedSamples(samples)
+					assertMetricEmitted(t, metrics.GRPCReqDurationName, samplesBuf, rb.Replacer.Replace("GRPCBIN_ADDR/grpc.testing.TestService/EmptyCall"))
+					assertMetricEmitted(t, metrics.GRPCReqDurationName, samplesBuf, rb.Replacer.Replace("GRPCBIN_ADDR/grpc.testing.TestService/UnaryCall"))
+				},
+			},
+		},
+		{
+			name: "BatchInvalidCall",
+			initString: codeBlock{code: `
+				var client = new grpc.Client();
+				client.load([], "../../../../lib/testutils/httpmultibin/grpc_testing/test.proto");`},
+			vuString: codeBlock{
+				code: `
+				client.connect("GRPCBIN_ADDR");
+				client.batch([["grpc.testing.TestService/EmptyCall", {}], ["foo/bar", {}]])`,
+				err: `invalid batch call "1": method "/foo/bar" not found in file descriptors`,
+			},
+		},
 		{
 			name: "AsyncInvokeDiscardResponseMessage",
 			initString: codeBlock{code: `
@@ -1444,3 +1509,251 @@ func TestClientConnectionReflectMetadata(t *testing.T) {
 
 	assert.True(t, foundReflectionCall, "expected to find a reflection call in the logs, but didn't")
 }
+
+// countingReflection counts the reflections of the clients.
+type countingReflection struct {
+	v1grpc.ServerReflectionServer
+
+	reflections atomic.Int32
+}
+
+func (r *countingReflection) ServerReflectionInfo(stream v1grpc.ServerReflection_ServerReflectionInfoServer) error {
+	r.reflections.Add(1)
+	return r.ServerReflectionServer.ServerReflectionInfo(stream)
+}
+
+func TestClientReflectionCache(t *testing.T) {
+	t.Parallel()
+
+	ts := newTestState(t)
+	reflections := &countingReflection{
+		ServerReflectionServer: reflection.NewServerV1(reflection.ServerOptions{Services: ts.httpBin.ServerGRPC}),
+	}
+	v1grpc.RegisterServerReflectionServer(ts.httpBin.ServerGRPC, reflections)
+	ts.httpBin.GRPCStub.EmptyCallFunc = func(context.Context, *grpc_testing.Empty) (*grpc_testing.Empty, error) {
+		return &grpc_testing.Empty{}, nil
+	}
+
+	_, err := ts.Run(`var client = new grpc.Client(), other = new grpc.Client();`)
+	require.NoError(t, err)
+	ts.ToVUContext()
+
+	// the descriptors reflected by a client are used by the others
+	_, err = ts.Run(`
+		client.connect("GRPCBIN_ADDR", { reflect: true });
+		other.connect("GRPCBIN_ADDR", { reflect: true });
+		other.invoke("grpc.testing.TestService/EmptyCall", {});
+	`)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), reflections.reflections.Load())
+
+	_, err = ts.Run(`
+		other.connect("GRPCBIN_ADDR", { reflect: true, reflectRefresh: true });
+		other.invoke("grpc.testing.TestService/EmptyCall", {});
+	`)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), reflections.reflections.Load())
+
+	// other services are reflected separately
+	_, err = ts.Run(`
+		client.connect("GRPCBIN_ADDR", { reflect: true, reflectServices: ["grpc.testing.TestService"] });
+		other.connect("GRPCBIN_ADDR", { reflect: true, reflectServices: ["grpc.testing.TestService"] });
+		other.invoke("grpc.testing.TestService/EmptyCall", {});
+	`)
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), reflections.reflections.Load())
+
+	_, err = ts.Run(`client.connect("GRPCBIN_ADDR", { reflect: true, reflectServices: "grpc.testing.TestService" })`)
+	require.ErrorContains(t, err, "invalid reflectServices value")
+}
+
+// healthStub serves the health checks with the statuses of the services.
+type healthStub struct {
+	healthpb.UnimplementedHealthServer
+
+	statuses map[string]healthpb.HealthCheckResponse_ServingStatus
+}
+
+func (s *healthStub) Check(_ context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	st, ok := s.statuses[req.GetService()]
+	if !ok {
+		return nil, status.Error(codes.NotFound, "unknown service")
+	}
+	return &healthpb.HealthCheckResponse{Status: st}, nil
+}
+
+func TestClientHealthCheck(t *testing.T) {
+	t.Parallel()
+
+	ts := newTestState(t)
+	healthpb.RegisterHealthServer(ts.httpBin.ServerGRPC, &healthStub{
+		statuses: map[string]healthpb.HealthCheckResponse_ServingStatus{
+			"":                         healthpb.HealthCheckResponse_SERVING,
+			"grpc.testing.TestService": healthpb.HealthCheckResponse_NOT_SERVING,
+		},
+	})
+
+	_, err := ts.Run(`var client = new grpc.Client();`)
+	require.NoError(t, err)
+	ts.ToVUContext()
+
+	// the health checking protocol doesn't need to be loaded
+	_, err = ts.Run(`
+		client.connect("GRPCBIN_ADDR");
+		var res = client.healthCheck();
+		if (res.status !== grpc.StatusOK || res.message.status !== grpc.HealthCheckServing) {
+			throw new Error("unexpected response of the server: " + JSON.stringify(res));
+		}
+		res = client.healthCheck("grpc.testing.TestService", { tags: { check: "service" } });
+		if (res.message.status !== grpc.HealthCheckNotServing) {
+			throw new Error("unexpected response of the service: " + JSON.stringify(res));
+		}
+		res = client.healthCheck("unknown");
+		if (res.status !== grpc.StatusNotFound) {
+			throw new Error("unexpected response of an unknown service: " + JSON.stringify(res));
+		}
+	`)
+	require.NoError(t, err)
+
+	var checks int
+	for _, sc := range metrics.GetBufferedSamples(ts.samples) {
+		for _, sample := range sc.GetSamples() {
+			if sample.Metric.Name != metrics.GRPCReqDurationName {
+				continue
+			}
+			checks++
+			url, _ := sample.Tags.Get("url")
+			assert.Equal(t, ts.httpBin.Replacer.Replace("GRPCBIN_ADDR/grpc.health.v1.Health/Check"), url)
+		}
+	}
+	assert.Equal(t, 3, checks)
+}
+
+func TestClientCompression(t *testing.T) {
+	t.Parallel()
+
+	ts := newTestState(t)
+	ts.httpBin.GRPCStub.UnaryCallFunc = func(_ context.Context, req *grpc_testing.SimpleRequest) (*grpc_testing.SimpleResponse, error) {
+		return &grpc_testing.SimpleResponse{Username: string(req.GetPayload().GetBody())}, nil
+	}
+
+	_, err := ts.Run(`
+		var client = new grpc.Client();
+		client.load([], "../../../../lib/testutils/httpmultibin/grpc_testing/test.proto");`)
+	require.NoError(t, err)
+	ts.ToVUContext()
+
+	_, err = ts.Run(`
+		client.connect("GRPCBIN_ADDR");
+		var body = "A".repeat(4096);
+		var res = client.invoke("grpc.testing.TestService/UnaryCall", { payload: { body: body } }, { compression: "gzip" });
+		if (res.status !== grpc.StatusOK || res.message.username.length !== 3072) {
+			throw new Error("unexpected response: " + res.status);
+		}
+		client.invoke("grpc.testing.TestService/UnaryCall", { payload: { body: body } });
+	`)
+	require.NoError(t, err)
+
+	sizes := make(map[string]float64)
+	for _, sc := range metrics.GetBufferedSamples(ts.samples) {
+		for _, sample := range sc.GetSamples() {
+			switch sample.Metric.Name {
+			case metrics.GRPCMessageBytesSentName, metrics.GRPCMessageCompressedBytesSentName,
+				metrics.GRPCMessageBytesReceivedName, metrics.GRPCMessageCompressedBytesReceivedName:
+				sizes[sample.Metric.Name] += sample.Value
+			}
+		}
+	}
+	// only the call with compression is measured
+	require.Len(t, sizes, 4)
+	assert.Greater(t, sizes[metrics.GRPCMessageBytesSentName], float64(3072))
+	assert.Less(t, sizes[metrics.GRPCMessageCompressedBytesSentName], float64(1024))
+	assert.Greater(t, sizes[metrics.GRPCMessageBytesReceivedName], float64(3072))
+	assert.Less(t, sizes[metrics.GRPCMessageCompressedBytesReceivedName], float64(1024))
+
+	_, err = ts.Run(`client.invoke("grpc.testing.TestService/UnaryCall", {}, { compression: "zip" })`)
+	require.ErrorContains(t, err, `invalid compression value: "zip" isn't a registered compressor`)
+}
+
+func TestClientConnectivityState(t *testing.T) {
+	t.Parallel()
+
+	ts := newTestState(t)
+
+	// a server, which can be restarted at the same address
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := lis.Addr().String()
+	serve := func(lis net.Listener) *grpc.Server {
+		srv := grpc.NewServer()
+		healthpb.RegisterHealthServer(srv, &healthStub{
+			statuses: map[string]healthpb.HealthCheckResponse_ServingStatus{"": healthpb.HealthCheckResponse_SERVING},
+		})
+		go func() { _ = srv.Serve(lis) }()
+		return srv
+	}
+	srv := serve(lis)
+
+	_, err = ts.Run(`var client = new grpc.Client();`)
+	require.NoError(t, err)
+	ts.ToVUContext()
+
+	_, err = ts.RunOnEventLoop(fmt.Sprintf(`
+		if (client.connectivityState() !== "SHUTDOWN") {
+			throw new Error("the client isn't connected yet");
+		}
+		client.connect(%q, { plaintext: true });
+		call(client.connectivityState());
+		client.watchConnectivityState("READY", "10ms").then(call);
+	`, addr))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"READY", "READY"}, ts.callRecorder.Recorded())
+
+	// the connection is lost, and it's reconnected by the next call
+	srv.Stop()
+	_, err = ts.RunOnEventLoop(`client.watchConnectivityState("READY", "5s").then(call);`)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"READY", "READY", "IDLE"}, ts.callRecorder.Recorded())
+
+	lis, err = net.Listen("tcp", addr)
+	require.NoError(t, err)
+	srv = serve(lis)
+	defer srv.Stop()
+
+	_, err = ts.Run(`
+		var res = client.healthCheck();
+		if (res.message.status !== grpc.HealthCheckServing) {
+			throw new Error("unexpected response: " + JSON.stringify(res));
+		}
+	`)
+	require.NoError(t, err)
+
+	reconnects := func() (n float64) {
+		for _, sc := range metrics.GetBufferedSamples(ts.samples) {
+			for _, sample := range sc.GetSamples() {
+				if sample.Metric.Name == "grpc_reconnects" {
+					n += sample.Value
+				}
+			}
+		}
+		return n
+	}
+	var total float64
+	assert.Eventually(t, func() bool {
+		total += reconnects()
+		return total == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	_, err = ts.Run(`
+		client.close();
+		if (client.connectivityState() !== "SHUTDOWN") {
+			throw new Error("the client isn't closed");
+		}
+	`)
+	require.NoError(t, err)
+
+	_, err = ts.RunOnEventLoop(`client.watchConnectivityState("INVALID").catch((e) => call(String(e)))`)
+	require.NoError(t, err)
+	recorded := ts.callRecorder.Recorded()
+	assert.Contains(t, recorded[len(recorded)-1], "no gRPC connection, you must call connect first")
+}
@@ -0,0 +1,99 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// descriptorCache keeps the method descriptors, which the clients got with the
+// reflection protocol, so that they're reflected and linked only once per
+// test, instead of by every VU connecting to the same target.
+type descriptorCache struct {
+	mx      sync.Mutex
+	entries map[descriptorKey]*descriptorEntry
+}
+
+type descriptorKey struct {
+	target string
+	// services are the sorted names of the reflected services, joined with
+	// commas, or empty if all services of the server were reflected
+	services string
+}
+
+// descriptorEntry is the result of a reflection, which is ready when its done
+// channel is closed.
+type descriptorEntry struct {
+	done chan struct{}
+	mds  map[string]protoreflect.MethodDescriptor
+	err  error
+}
+
+func newDescriptorCache() *descriptorCache {
+	return &descriptorCache{entries: make(map[descriptorKey]*descriptorEntry)}
+}
+
+func newDescriptorKey(target string, services []string) descriptorKey {
+	services = slices.Clone(services)
+	slices.Sort(services)
+	return descriptorKey{target: target, services: strings.Join(services, ",")}
+}
+
+// get returns the descriptors of the key, which are reflected with the reflect
+// function, if they weren't yet, or if they should be refreshed. While they're
+// reflected, the other callers with the same key wait for them.
+func (dc *descriptorCache) get(
+	ctx context.Context, key descriptorKey, refresh bool,
+	reflect func() (*descriptorpb.FileDescriptorSet, error),
+) (*descriptorEntry, error) {
+	for {
+		dc.mx.Lock()
+		entry, ok := dc.entries[key]
+		if !ok || refresh {
+			entry = &descriptorEntry{done: make(chan struct{})}
+			dc.entries[key] = entry
+			dc.mx.Unlock()
+			return entry, dc.reflect(key, entry, reflect)
+		}
+		dc.mx.Unlock()
+
+		select {
+		case <-entry.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if entry.err == nil {
+			return entry, nil
+		}
+		// the reflection of another client failed, e.g. because its
+		// iteration was interrupted, so it's tried again
+	}
+}
+
+func (dc *descriptorCache) reflect(
+	key descriptorKey, entry *descriptorEntry, reflect func() (*descriptorpb.FileDescriptorSet, error),
+) error {
+	defer close(entry.done)
+
+	fdset, err := reflect()
+	if err == nil {
+		_, entry.mds, err = linkMethods(fdset)
+		if err != nil {
+			err = fmt.Errorf("can't convert method info: %w", err)
+		}
+	}
+	if err != nil {
+		entry.err = err
+		dc.mx.Lock()
+		if dc.entries[key] == entry {
+			delete(dc.entries, key)
+		}
+		dc.mx.Unlock()
+	}
+	return err
+}
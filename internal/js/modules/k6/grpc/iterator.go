@@ -0,0 +1,151 @@
+package grpc
+
+import (
+	"errors"
+	"io"
+
+	"github.com/grafana/sobek"
+
+	"go.k6.io/k6/internal/lib/netext/grpcext"
+	"go.k6.io/k6/js/common"
+)
+
+// streamIterator delivers the received messages of a stream to its async
+// iterator, so they can be consumed with `for await`. It's only accessed on
+// the event loop, except for its credits.
+type streamIterator struct {
+	obj *sobek.Object
+
+	// messages are the received messages, which weren't consumed yet
+	messages []sobek.Value
+	// waiting are the calls of next, which wait for a message
+	waiting []iteratorCall
+
+	// finished is set when the stream ends, or when the iteration is stopped
+	finished bool
+	// returned is set when the iteration is stopped, e.g. with break
+	returned bool
+	// err is the error of the stream, with which the next call is rejected
+	err sobek.Value
+
+	// credits are the messages, which the stream can still read, before the
+	// received ones are consumed, or nil if the stream reads them all.
+	credits chan struct{}
+}
+
+type iteratorCall struct {
+	resolve, reject func(any) error
+}
+
+// newStreamIterator returns an iterator, with which the stream stops reading
+// when bufferSize of its received messages weren't consumed yet.
+func newStreamIterator(bufferSize int) *streamIterator {
+	it := &streamIterator{credits: make(chan struct{}, bufferSize)}
+	for range bufferSize {
+		it.credits <- struct{}{}
+	}
+	return it
+}
+
+func iteratorResult(value any, done bool) map[string]any {
+	return map[string]any{"value": value, "done": done}
+}
+
+// asyncIterator returns the async iterator of the stream. The messages are
+// delivered to it, only after it's requested, if the stream wasn't iterated
+// from the start.
+func (s *stream) asyncIterator() *sobek.Object {
+	if s.iter == nil {
+		s.iter = &streamIterator{}
+	}
+	if s.iter.obj == nil {
+		s.iter.obj = common.NewAsyncIterator(s.vu.Runtime(), s.next, s.stopIteration)
+	}
+	return s.iter.obj
+}
+
+// next returns a promise of the next received message.
+func (s *stream) next() *sobek.Promise {
+	it := s.iter
+	promise, resolve, reject := s.vu.Runtime().NewPromise()
+	switch {
+	case len(it.messages) > 0:
+		msg := it.messages[0]
+		it.messages = it.messages[1:]
+		it.release()
+		must(s.vu.Runtime(), resolve(iteratorResult(msg, false)))
+	case it.err != nil:
+		err := it.err
+		it.err = nil
+		must(s.vu.Runtime(), reject(err))
+	case it.finished:
+		must(s.vu.Runtime(), resolve(iteratorResult(sobek.Undefined(), true)))
+	default:
+		it.waiting = append(it.waiting, iteratorCall{resolve: resolve, reject: reject})
+	}
+	return promise
+}
+
+// stopIteration stops the iteration before the end of the stream, e.g. on a
+// break out of the for await loop, cancelling the stream.
+func (s *stream) stopIteration(value sobek.Value) *sobek.Promise {
+	it := s.iter
+	if !it.finished {
+		it.returned = true
+		it.finish(s.vu.Runtime(), nil)
+		s.cancel()
+	}
+	promise, resolve, _ := s.vu.Runtime().NewPromise()
+	must(s.vu.Runtime(), resolve(iteratorResult(value, true)))
+	return promise
+}
+
+// push delivers a received message to the iterator.
+func (it *streamIterator) push(rt *sobek.Runtime, msg sobek.Value) {
+	if it.finished {
+		it.release()
+		return
+	}
+	if len(it.waiting) == 0 {
+		it.messages = append(it.messages, msg)
+		return
+	}
+	call := it.waiting[0]
+	it.waiting = it.waiting[1:]
+	it.release()
+	must(rt, call.resolve(iteratorResult(msg, false)))
+}
+
+// finish ends the iteration, with the error of the stream, if it isn't a
+// regular closing.
+func (it *streamIterator) finish(rt *sobek.Runtime, err error) {
+	if it.finished {
+		return
+	}
+	it.finished = true
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, grpcext.ErrCanceled) {
+		it.err = rt.ToValue(extractError(err))
+	}
+
+	waiting := it.waiting
+	it.waiting = nil
+	for i, call := range waiting {
+		if i == 0 && it.err != nil {
+			must(rt, call.reject(it.err))
+			it.err = nil
+			continue
+		}
+		must(rt, call.resolve(iteratorResult(sobek.Undefined(), true)))
+	}
+}
+
+// release lets the stream read one more message.
+func (it *streamIterator) release() {
+	if it.credits == nil {
+		return
+	}
+	select {
+	case it.credits <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,192 @@
+package grpc_test
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"go.k6.io/k6/internal/js/compiler"
+	"go.k6.io/k6/internal/lib/testutils/grpcservice"
+	"go.k6.io/k6/metrics"
+)
+
+type routeGuideStub struct {
+	grpcservice.UnimplementedRouteGuideServer
+
+	routeChat func(stream grpcservice.RouteGuide_RouteChatServer) error
+}
+
+func (s *routeGuideStub) RouteChat(stream grpcservice.RouteGuide_RouteChatServer) error {
+	return s.routeChat(stream)
+}
+
+// runAsyncIteration runs the code on the event loop, after lowering its for
+// await loops, as the scripts are.
+func runAsyncIteration(t *testing.T, ts testState, code string) error {
+	t.Helper()
+	lowered, _, err := compiler.LowerAsyncIteration(code, "script.js")
+	require.NoError(t, err)
+	_, err = ts.RunOnEventLoop(lowered)
+	return err
+}
+
+func newRouteGuideTestState(t *testing.T, routeChat func(grpcservice.RouteGuide_RouteChatServer) error) testState {
+	t.Helper()
+	ts := newTestState(t)
+	grpcservice.RegisterRouteGuideServer(ts.httpBin.ServerGRPC, &routeGuideStub{routeChat: routeChat})
+	grpcservice.RegisterFeatureExplorerServer(ts.httpBin.ServerGRPC, &featureExplorerStub{
+		listFeatures: func(_ *grpcservice.Rectangle, stream grpcservice.FeatureExplorer_ListFeaturesServer) error {
+			for range 20 {
+				if err := stream.Send(&grpcservice.Feature{Name: "feature"}); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	})
+
+	_, err := ts.Run(`
+		var client = new grpc.Client();
+		client.load([], "../../../../lib/testutils/grpcservice/route_guide.proto");`)
+	require.NoError(t, err)
+	ts.ToVUContext()
+	return ts
+}
+
+func echoRouteChat(stream grpcservice.RouteGuide_RouteChatServer) error {
+	for {
+		note, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		note.Message = "echo: " + note.Message
+		if err = stream.Send(note); err != nil {
+			return err
+		}
+	}
+}
+
+func TestStream_AsyncIteration(t *testing.T) {
+	t.Parallel()
+
+	t.Run("bidirectional", func(t *testing.T) {
+		t.Parallel()
+		ts := newRouteGuideTestState(t, echoRouteChat)
+
+		err := runAsyncIteration(t, ts, `
+			client.connect("GRPCBIN_ADDR");
+			(async () => {
+				const stream = client.invokeStream("main.RouteGuide/RouteChat");
+				for (const message of ["a", "b", "c"]) {
+					await stream.send({ message: message });
+				}
+				stream.end();
+				for await (const note of stream) {
+					call(note.message);
+				}
+				call("done");
+			})();
+		`)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"echo: a", "echo: b", "echo: c", "done"}, ts.callRecorder.Recorded())
+	})
+
+	t.Run("stream", func(t *testing.T) {
+		t.Parallel()
+		ts := newRouteGuideTestState(t, echoRouteChat)
+
+		// the messages are delivered both to the listeners and to the iterator
+		err := runAsyncIteration(t, ts, `
+			client.connect("GRPCBIN_ADDR");
+			(async () => {
+				const stream = new grpc.Stream(client, "main.RouteGuide/RouteChat");
+				stream.on("data", (note) => call("data " + note.message));
+				const notes = stream[Symbol.asyncIterator]();
+				stream.write({ message: "a" });
+				call("next " + (await notes.next()).value.message);
+				stream.end();
+				const last = await notes.next();
+				call("done " + last.done);
+			})();
+		`)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"data echo: a", "next echo: a", "done true"}, ts.callRecorder.Recorded())
+	})
+
+	t.Run("error", func(t *testing.T) {
+		t.Parallel()
+		ts := newRouteGuideTestState(t, func(grpcservice.RouteGuide_RouteChatServer) error {
+			return status.Error(codes.PermissionDenied, "no chats")
+		})
+
+		err := runAsyncIteration(t, ts, `
+			client.connect("GRPCBIN_ADDR");
+			(async () => {
+				const stream = client.invokeStream("main.RouteGuide/RouteChat");
+				try {
+					for await (const note of stream) {
+						call(note.message);
+					}
+				} catch (e) {
+					call(e.code + " " + e.message);
+				}
+			})();
+		`)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"7 no chats"}, ts.callRecorder.Recorded())
+		assert.Empty(t, ts.loggerHook.Drain())
+	})
+
+	t.Run("break", func(t *testing.T) {
+		t.Parallel()
+		ts := newRouteGuideTestState(t, nil)
+
+		// the stream reads only the buffered messages, and it's cancelled by the break
+		err := runAsyncIteration(t, ts, `
+			client.connect("GRPCBIN_ADDR");
+			(async () => {
+				const stream = client.invokeStream("main.FeatureExplorer/ListFeatures", { bufferSize: 2 });
+				stream.write({});
+				for await (const feature of stream) {
+					call(feature.name);
+					break;
+				}
+				call("done");
+			})();
+		`)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"feature", "done"}, ts.callRecorder.Recorded())
+
+		received := 0
+		for _, samples := range metrics.GetBufferedSamples(ts.samples) {
+			for _, sample := range samples.GetSamples() {
+				if sample.Metric.Name == "grpc_streams_msgs_received" {
+					received++
+				}
+			}
+		}
+		assert.Less(t, received, 20)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		t.Parallel()
+		ts := newRouteGuideTestState(t, nil)
+
+		_, err := ts.Run(`
+			client.connect("GRPCBIN_ADDR");
+			client.invokeStream("main.RouteGuide/RouteChat", { bufferSize: 0 });
+		`)
+		require.ErrorContains(t, err, "invalid bufferSize value")
+
+		_, err = ts.Run(`client.invoke("main.FeatureExplorer/GetFeature", {}, { bufferSize: 1 })`)
+		require.ErrorContains(t, err, "bufferSize is only supported by the streams")
+	})
+}
@@ -7,6 +7,14 @@ type instanceMetrics struct {
 	Streams                 *metrics.Metric
 	StreamsMessagesSent     *metrics.Metric
 	StreamsMessagesReceived *metrics.Metric
+	// StreamDuration is the time from the start of a stream until it's closed.
+	StreamDuration *metrics.Metric
+	// StreamTimeToFirstMessage is the time from the start of a stream until
+	// the first message is received.
+	StreamTimeToFirstMessage *metrics.Metric
+	// Reconnects are the times the connections became ready again, after
+	// they were lost.
+	Reconnects *metrics.Metric
 }
 
 // registerMetrics registers and returns the metrics in the provided registry
@@ -26,5 +34,18 @@ func registerMetrics(registry *metrics.Registry) (*instanceMetrics, error) {
 		return nil, err
 	}
 
+	if m.StreamDuration, err = registry.NewMetric("grpc_stream_duration", metrics.Trend, metrics.Time); err != nil {
+		return nil, err
+	}
+
+	if m.StreamTimeToFirstMessage, err = registry.NewMetric(
+		"grpc_stream_time_to_first_msg", metrics.Trend, metrics.Time); err != nil {
+		return nil, err
+	}
+
+	if m.Reconnects, err = registry.NewMetric("grpc_reconnects", metrics.Counter); err != nil {
+		return nil, err
+	}
+
 	return m, nil
 }
@@ -1,6 +1,7 @@
 package grpc
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"strings"
@@ -12,6 +13,7 @@ import (
 	"go.k6.io/k6/lib"
 	"go.k6.io/k6/lib/types"
 	"go.k6.io/k6/metrics"
+	"google.golang.org/grpc/encoding"
 	"google.golang.org/grpc/metadata"
 )
 
@@ -22,6 +24,11 @@ type callParams struct {
 	TagsAndMeta            metrics.TagsAndMeta
 	Timeout                time.Duration
 	DiscardResponseMessage bool
+	// BufferSize is the number of the received messages of a stream, which
+	// aren't consumed yet by its iterator, after which it stops reading.
+	BufferSize int
+	// Compression is the name of the compressor of the messages, e.g. gzip.
+	Compression string
 }
 
 // newCallParams constructs the call parameters from the input value.
@@ -61,6 +68,18 @@ func newCallParams(vu modules.VU, input sobek.Value) (*callParams, error) {
 			}
 		case "discardResponseMessage":
 			result.DiscardResponseMessage = params.Get(k).ToBoolean()
+		case "bufferSize":
+			size := params.Get(k).ToInteger()
+			if size <= 0 {
+				return result, fmt.Errorf("invalid bufferSize value: it must be positive, but it's %d", size)
+			}
+			result.BufferSize = int(size)
+		case "compression":
+			name := params.Get(k).String()
+			if encoding.GetCompressor(name) == nil {
+				return result, fmt.Errorf("invalid compression value: %q isn't a registered compressor", name)
+			}
+			result.Compression = name
 		default:
 			return result, fmt.Errorf("unknown param: %q", k)
 		}
@@ -106,6 +125,22 @@ func newMetadata(input sobek.Value) (metadata.MD, error) {
 	return md, nil
 }
 
+// SetAuthorization adds the access token of the auth session of the address
+// to the metadata, unless it already has an authorization.
+func (p *callParams) SetAuthorization(ctx context.Context, state *lib.State, addr string) error {
+	if state.AuthSessions == nil || len(p.Metadata.Get("authorization")) > 0 {
+		return nil
+	}
+	authorization, err := state.AuthSessions.Authorization(ctx, state.Transport, addr)
+	if err != nil {
+		return err
+	}
+	if authorization != "" {
+		p.Metadata.Set("authorization", authorization)
+	}
+	return nil
+}
+
 // SetSystemTags sets the system tags for the call.
 func (p *callParams) SetSystemTags(state *lib.State, addr string, methodName string) {
 	if state.Options.SystemTags.Has(metrics.TagURL) {
@@ -131,6 +166,13 @@ type connectParams struct {
 	MaxReceiveSize        int64
 	MaxSendSize           int64
 	TLS                   map[string]interface{}
+
+	// ReflectServices are the services, which are reflected, or all the
+	// services of the server, if it's empty.
+	ReflectServices []string
+	// ReflectRefresh makes the client reflect the services again, instead of
+	// using the descriptors, which were already reflected in the test.
+	ReflectRefresh bool
 }
 
 func newConnectParams(vu modules.VU, input sobek.Value) (*connectParams, error) { //nolint:gocognit
@@ -179,6 +221,25 @@ func newConnectParams(vu modules.VU, input sobek.Value) (*connectParams, error)
 			}
 
 			result.ReflectionMetadata = md
+		case "reflectServices":
+			services, ok := v.([]any)
+			if !ok {
+				return result, fmt.Errorf("invalid reflectServices value: '%#v', it needs to be an array of strings", v)
+			}
+			result.ReflectServices = make([]string, 0, len(services))
+			for _, service := range services {
+				name, ok := service.(string)
+				if !ok || name == "" {
+					return result, fmt.Errorf("invalid reflectServices value: '%#v', it needs to be an array of strings", v)
+				}
+				result.ReflectServices = append(result.ReflectServices, name)
+			}
+		case "reflectRefresh":
+			var ok bool
+			result.ReflectRefresh, ok = v.(bool)
+			if !ok {
+				return result, fmt.Errorf("invalid reflectRefresh value: '%#v', it needs to be boolean", v)
+			}
 		case "maxReceiveSize":
 			var ok bool
 			result.MaxReceiveSize, ok = v.(int64)
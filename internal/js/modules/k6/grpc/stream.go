@@ -26,6 +26,8 @@ import (
 type message struct {
 	isClosing bool
 	msg       []byte
+	// sent is called on the event loop, when the message was sent, if it isn't nil
+	sent func(error) error
 }
 
 const (
@@ -63,7 +65,20 @@ type stream struct {
 
 	eventListeners *eventListeners
 
-	timeoutCancel context.CancelFunc
+	// cancel cancels the context of the stream
+	cancel context.CancelFunc
+	ctx    context.Context
+
+	started time.Time
+	// whether a message was received, only accessed by the reading goroutine
+	receivedMessage bool
+
+	// iter is the async iterator of the received messages, or nil, if it
+	// wasn't requested
+	iter *streamIterator
+	// credits are the credits of the iterator, if the stream is iterated from
+	// the start, which is set before the reading begins
+	credits chan struct{}
 }
 
 // defineStream defines the sobek.Object that is given to js to interact with the Stream
@@ -76,6 +91,12 @@ func defineStream(rt *sobek.Runtime, s *stream) {
 
 	must(rt, s.obj.DefineDataProperty(
 		"end", rt.ToValue(s.end), sobek.FLAG_FALSE, sobek.FLAG_FALSE, sobek.FLAG_TRUE))
+
+	must(rt, s.obj.DefineDataProperty(
+		"send", rt.ToValue(s.send), sobek.FLAG_FALSE, sobek.FLAG_FALSE, sobek.FLAG_TRUE))
+
+	must(rt, s.obj.DefineDataPropertySymbol(
+		common.AsyncIteratorSymbol(rt), rt.ToValue(s.asyncIterator), sobek.FLAG_FALSE, sobek.FLAG_FALSE, sobek.FLAG_TRUE))
 }
 
 func (s *stream) beginStream(p *callParams) error {
@@ -85,19 +106,25 @@ func (s *stream) beginStream(p *callParams) error {
 		DiscardResponseMessage: p.DiscardResponseMessage,
 		TagsAndMeta:            &p.TagsAndMeta,
 		Metadata:               p.Metadata,
+		Compression:            p.Compression,
 	}
 
-	ctx := s.vu.Context()
+	var ctx context.Context
 	var cancel context.CancelFunc
 
 	if p.Timeout != time.Duration(0) {
-		ctx, cancel = context.WithTimeout(ctx, p.Timeout)
+		ctx, cancel = context.WithTimeout(s.vu.Context(), p.Timeout)
+	} else {
+		ctx, cancel = context.WithCancel(s.vu.Context())
 	}
 
-	s.timeoutCancel = cancel
+	s.ctx = ctx
+	s.cancel = cancel
 
+	s.started = time.Now()
 	stream, err := s.client.conn.NewStream(ctx, *req)
 	if err != nil {
+		cancel()
 		return fmt.Errorf("failed to create a new stream: %w", err)
 	}
 	s.stream = stream
@@ -106,7 +133,7 @@ func (s *stream) beginStream(p *callParams) error {
 			Metric: s.instanceMetrics.Streams,
 			Tags:   s.tagsAndMeta.Tags,
 		},
-		Time:     time.Now(),
+		Time:     s.started,
 		Metadata: s.tagsAndMeta.Metadata,
 		Value:    1,
 	})
@@ -148,7 +175,7 @@ func (s *stream) loop() {
 
 func (s *stream) queueMessage(msg interface{}) {
 	now := time.Now()
-	metrics.PushIfNotDone(s.vu.Context(), s.vu.State().Samples, metrics.Sample{
+	samples := []metrics.Sample{{
 		TimeSeries: metrics.TimeSeries{
 			Metric: s.instanceMetrics.StreamsMessagesReceived,
 			Tags:   s.tagsAndMeta.Tags,
@@ -156,6 +183,23 @@ func (s *stream) queueMessage(msg interface{}) {
 		Time:     now,
 		Metadata: s.tagsAndMeta.Metadata,
 		Value:    1,
+	}}
+	if !s.receivedMessage {
+		s.receivedMessage = true
+		samples = append(samples, metrics.Sample{
+			TimeSeries: metrics.TimeSeries{
+				Metric: s.instanceMetrics.StreamTimeToFirstMessage,
+				Tags:   s.tagsAndMeta.Tags,
+			},
+			Time:     now,
+			Metadata: s.tagsAndMeta.Metadata,
+			Value:    metrics.D(now.Sub(s.started)),
+		})
+	}
+	metrics.PushIfNotDone(s.vu.Context(), s.vu.State().Samples, metrics.ConnectedSamples{
+		Samples: samples,
+		Tags:    s.tagsAndMeta.Tags,
+		Time:    now,
 	})
 
 	s.tq.Queue(func() error {
@@ -176,6 +220,9 @@ func (s *stream) queueMessage(msg interface{}) {
 				return err
 			}
 		}
+		if s.iter != nil {
+			s.iter.push(rt, rt.ToValue(msg))
+		}
 		return nil
 	})
 }
@@ -185,6 +232,26 @@ func (s *stream) readData(wg *sync.WaitGroup) {
 	defer wg.Done()
 
 	for {
+		if s.credits != nil {
+			// the messages aren't read, while the iterator has enough of them
+			select {
+			case <-s.credits:
+			case <-s.ctx.Done():
+				if s.vu.Context().Err() != nil {
+					return // the loop closes the stream, when the VU is shutting down
+				}
+				err := s.ctx.Err()
+				if errors.Is(err, context.Canceled) {
+					err = grpcext.ErrCanceled
+				} else {
+					err = status.FromContextError(err).Err()
+				}
+				s.tq.Queue(func() error {
+					return s.closeWithError(err)
+				})
+				return
+			}
+		}
 		msg, err := s.stream.ReceiveConverted()
 
 		if err != nil && !isRegularClosing(err) {
@@ -247,6 +314,9 @@ func (s *stream) writeData(wg *sync.WaitGroup) {
 				}
 
 				err := s.stream.Send(msg.msg)
+				if msg.sent != nil {
+					s.tq.Queue(func() error { return msg.sent(err) })
+				}
 				if err != nil {
 					s.processSendError(err)
 					return
@@ -287,6 +357,11 @@ func (s *stream) writeData(wg *sync.WaitGroup) {
 				queue = queue[:copy(queue, queue[1:])]
 
 			case <-s.done:
+				for _, msg := range queue {
+					if msg.sent != nil {
+						s.tq.Queue(func() error { return msg.sent(errStreamClosed) })
+					}
+				}
 				return
 			}
 		}
@@ -336,6 +411,48 @@ func (s *stream) write(input sobek.Value) {
 	s.writeQueueCh <- message{msg: b}
 }
 
+// errStreamClosed is the error of the messages, which weren't sent before the
+// stream was closed.
+var errStreamClosed = errors.New("the stream is closed")
+
+// send writes a message to the stream, returning a promise, which is resolved
+// when it's sent, so the writing of many messages can wait for the previous ones.
+func (s *stream) send(input sobek.Value) *sobek.Promise {
+	rt := s.vu.Runtime()
+	promise, resolve, reject := rt.NewPromise()
+
+	switch {
+	case s.writingState != opened:
+		must(rt, reject(errors.New("the stream is closed for writing")))
+		return promise
+	case common.IsNullish(input):
+		must(rt, reject(errors.New("can't send an empty message")))
+		return promise
+	}
+
+	b, err := input.ToObject(rt).MarshalJSON()
+	if err != nil {
+		must(rt, reject(fmt.Errorf("can't marshal the message: %w", err)))
+		return promise
+	}
+
+	msg := message{msg: b, sent: func(err error) error {
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				err = errStreamClosed
+			}
+			return reject(err)
+		}
+		return resolve(sobek.Undefined())
+	}}
+	select {
+	case s.writeQueueCh <- msg:
+	case <-s.done:
+		must(rt, reject(errStreamClosed))
+	}
+	return promise
+}
+
 // end closes client the stream
 func (s *stream) end() {
 	if s.writingState == closed {
@@ -371,19 +488,35 @@ func (s *stream) close(err error) {
 	s.logger.Debugf("stream %s is closing", s.method)
 	close(s.done)
 
+	now := time.Now()
+	metrics.PushIfNotDone(s.vu.Context(), s.vu.State().Samples, metrics.Sample{
+		TimeSeries: metrics.TimeSeries{
+			Metric: s.instanceMetrics.StreamDuration,
+			Tags:   s.tagsAndMeta.Tags,
+		},
+		Time:     now,
+		Metadata: s.tagsAndMeta.Metadata,
+		Value:    metrics.D(now.Sub(s.started)),
+	})
+
 	s.tq.Queue(func() error {
+		if s.iter != nil {
+			s.iter.finish(s.vu.Runtime(), err)
+		}
 		return s.callEventListeners(eventEnd)
 	})
 
-	if s.timeoutCancel != nil {
-		s.timeoutCancel()
-	}
+	s.cancel()
 }
 
 func (s *stream) callErrorListeners(e error) error {
 	if e == nil || errors.Is(e, io.EOF) {
 		return nil
 	}
+	if s.iter != nil && s.iter.returned {
+		// the stream was cancelled, because its iteration was stopped
+		return nil
+	}
 
 	rt := s.vu.Runtime()
 
@@ -391,7 +524,8 @@ func (s *stream) callErrorListeners(e error) error {
 
 	list := s.eventListeners.all(eventError)
 
-	if len(list) == 0 {
+	// the errors are also thrown by the async iterators
+	if len(list) == 0 && s.iter == nil {
 		s.logger.Warnf("no handlers for error registered, but an error happened: %s", e)
 	}
 
@@ -648,7 +648,7 @@ func TestStream_MetricsTagsMetadata(t *testing.T) {
 
 	samplesBuf := metrics.GetBufferedSamples(ts.samples)
 
-	assert.Len(t, samplesBuf, 4)
+	assert.Len(t, samplesBuf, 5)
 	for _, samples := range samplesBuf {
 		for _, sample := range samples.GetSamples() {
 			assertTags(t, sample, expTags)
@@ -656,6 +656,75 @@ func TestStream_MetricsTagsMetadata(t *testing.T) {
 	}
 }
 
+// TestStream_Metrics tests that the streams emit their metrics, tagged by
+// their method name like the ones of the unary calls.
+func TestStream_Metrics(t *testing.T) {
+	t.Parallel()
+
+	ts := newTestState(t)
+
+	stub := &featureExplorerStub{}
+
+	stub.listFeatures = func(_ *grpcservice.Rectangle, stream grpcservice.FeatureExplorer_ListFeaturesServer) error {
+		for _, name := range []string{"foo", "bar"} {
+			if err := stream.Send(&grpcservice.Feature{Name: name}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	grpcservice.RegisterFeatureExplorerServer(ts.httpBin.ServerGRPC, stub)
+
+	initString := codeBlock{
+		code: `
+		var client = new grpc.Client();
+		client.load([], "../../../../lib/testutils/grpcservice/route_guide.proto");`,
+	}
+	vuString := codeBlock{
+		code: `
+		client.connect("GRPCBIN_ADDR");
+
+		let stream = new grpc.Stream(client, "main.FeatureExplorer/ListFeatures")
+		stream.on('data', function (data) {
+			call('Feature:' + data.name);
+		});
+		stream.on('end', function () {
+			call('End called');
+		});
+
+		stream.write({});
+		stream.end();
+		`,
+	}
+
+	val, err := ts.Run(initString.code)
+	assertResponse(t, initString, err, val, ts)
+
+	ts.ToVUContext()
+
+	val, err = ts.RunOnEventLoop(vuString.code)
+
+	assertResponse(t, vuString, err, val, ts)
+	assert.Equal(t, []string{"Feature:foo", "Feature:bar", "End called"}, ts.callRecorder.Recorded())
+
+	counts := make(map[string]int)
+	for _, samples := range metrics.GetBufferedSamples(ts.samples) {
+		for _, sample := range samples.GetSamples() {
+			counts[sample.Metric.Name]++
+			name, ok := sample.Tags.Get("name")
+			assert.True(t, ok)
+			assert.Equal(t, "/main.FeatureExplorer/ListFeatures", name)
+		}
+	}
+
+	assert.Equal(t, 1, counts["grpc_streams"])
+	assert.Equal(t, 1, counts["grpc_streams_msgs_sent"])
+	assert.Equal(t, 2, counts["grpc_streams_msgs_received"])
+	assert.Equal(t, 1, counts["grpc_stream_time_to_first_msg"])
+	assert.Equal(t, 1, counts["grpc_stream_duration"])
+}
+
 func assertTags(t *testing.T, sample metrics.Sample, tags map[string]string) {
 	for k, v := range tags {
 		tag, ok := sample.Tags.Get(k)
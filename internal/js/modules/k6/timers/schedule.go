@@ -0,0 +1,187 @@
+package timers
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/grafana/sobek"
+	"github.com/mstoykov/k6-taskqueue-lib/taskqueue"
+
+	"go.k6.io/k6/js/common"
+	"go.k6.io/k6/lib/types"
+)
+
+// daemonRegisterer is implemented by the VUs, whose event loops don't wait for
+// the daemons. With the other VUs, the schedules keep the iteration running,
+// until they're cleared.
+type daemonRegisterer interface {
+	RegisterDaemonCallback(stop func()) func(func() error)
+}
+
+// schedule is a callback called periodically or at a date, alongside the main
+// flow of the iteration. Unlike the timers, the iteration doesn't wait for the
+// schedules, which are cleared when it ends.
+type schedule struct {
+	callback sobek.Callable
+	args     []sobek.Value
+	// interval is the period of a repeated schedule, or zero
+	interval time.Duration
+	next     time.Time
+	timer    *time.Timer
+}
+
+// scheduleEvery calls the callback every interval, e.g. "30s", until the
+// schedule is cleared or the iteration ends.
+func (e *Timers) scheduleEvery(interval sobek.Value, callback sobek.Callable, args ...sobek.Value) uint64 {
+	rt := e.vu.Runtime()
+	if common.IsNullish(interval) {
+		common.Throw(rt, errors.New("scheduleEvery's interval is required"))
+	}
+	d, err := types.GetDurationValue(interval.Export())
+	if err != nil {
+		common.Throw(rt, fmt.Errorf("scheduleEvery's interval is invalid: %w", err))
+	}
+	if d <= 0 {
+		common.Throw(rt, fmt.Errorf("scheduleEvery's interval must be positive, but it's %s", d))
+	}
+	return e.addSchedule("scheduleEvery", &schedule{
+		callback: callback,
+		args:     args,
+		interval: d,
+		next:     time.Now().Add(d),
+	})
+}
+
+// scheduleAt calls the callback once at the date, which is a Date, a timestamp
+// in milliseconds or an RFC 3339 string, unless the iteration ends before it.
+// The dates in the past are called as soon as possible.
+func (e *Timers) scheduleAt(date sobek.Value, callback sobek.Callable, args ...sobek.Value) uint64 {
+	rt := e.vu.Runtime()
+	at, err := scheduleDate(date)
+	if err != nil {
+		common.Throw(rt, fmt.Errorf("scheduleAt's date is invalid: %w", err))
+	}
+	return e.addSchedule("scheduleAt", &schedule{callback: callback, args: args, next: at})
+}
+
+func scheduleDate(date sobek.Value) (time.Time, error) {
+	if common.IsNullish(date) {
+		return time.Time{}, errors.New("it's required")
+	}
+	switch v := date.Export().(type) {
+	case time.Time:
+		return v, nil
+	case string:
+		return time.Parse(time.RFC3339Nano, v)
+	case int64:
+		return time.UnixMilli(v), nil
+	case float64:
+		return time.UnixMilli(int64(v)), nil
+	default:
+		return time.Time{}, fmt.Errorf("it must be a Date, a timestamp or a string, but it's %s", date)
+	}
+}
+
+// clearSchedule stops the schedule with the id.
+func (e *Timers) clearSchedule(id uint64) {
+	s, ok := e.schedules[id]
+	if !ok {
+		return
+	}
+	s.timer.Stop()
+	delete(e.schedules, id)
+	if len(e.schedules) == 0 {
+		e.stopSchedules()
+	}
+}
+
+func (e *Timers) addSchedule(name string, s *schedule) uint64 {
+	if s.callback == nil {
+		common.Throw(e.vu.Runtime(), fmt.Errorf("%s's callback isn't a callable function", name))
+	}
+	if e.scheduleQueue == nil {
+		e.startSchedules()
+	}
+	id := e.nextID()
+	e.schedules[id] = s
+	e.armSchedule(id, s)
+	return id
+}
+
+// armSchedule queues the call of the schedule on the event loop at its next
+// date.
+func (e *Timers) armSchedule(id uint64, s *schedule) {
+	q := e.scheduleQueue
+	s.timer = time.AfterFunc(time.Until(s.next), func() {
+		q.Queue(func() error { return e.runSchedule(id) })
+	})
+}
+
+func (e *Timers) runSchedule(id uint64) error {
+	s, ok := e.schedules[id]
+	if !ok {
+		return nil // it was cleared
+	}
+	if s.interval > 0 {
+		// the calls, which were missed while the event loop was busy, are
+		// skipped, instead of being made all at once
+		now := time.Now()
+		for !s.next.After(now) {
+			s.next = s.next.Add(s.interval)
+		}
+		e.armSchedule(id, s)
+	} else {
+		delete(e.schedules, id)
+	}
+
+	err := e.call(s.callback, s.args)
+	if len(e.schedules) == 0 && e.scheduleQueue != nil {
+		e.stopSchedules()
+	}
+	return err
+}
+
+// startSchedules starts the queue of the schedules, which is stopped when the
+// main flow of the iteration ends, or when the iteration is interrupted.
+func (e *Timers) startSchedules() {
+	var q *taskqueue.TaskQueue
+	register := e.vu.RegisterCallback
+	if d, ok := e.vu.(daemonRegisterer); ok {
+		register = func() func(func() error) {
+			return d.RegisterDaemonCallback(e.stopSchedules)
+		}
+	}
+	q = taskqueue.New(register)
+	e.scheduleQueue = q
+	e.schedules = make(map[uint64]*schedule)
+
+	done := make(chan struct{})
+	e.schedulesDone = done
+	ctx := e.vu.Context()
+	go func() {
+		select {
+		case <-ctx.Done():
+			q.Queue(func() error {
+				if e.scheduleQueue == q {
+					e.stopSchedules()
+				}
+				return nil
+			})
+		case <-done:
+		}
+	}()
+}
+
+// stopSchedules clears the schedules and closes their queue. It's called on
+// the event loop.
+func (e *Timers) stopSchedules() {
+	for _, s := range e.schedules {
+		s.timer.Stop()
+	}
+	e.schedules = nil
+	close(e.schedulesDone)
+	e.schedulesDone = nil
+	e.scheduleQueue.Close()
+	e.scheduleQueue = nil
+}
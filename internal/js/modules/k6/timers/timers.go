@@ -33,6 +33,11 @@ type Timers struct {
 	taskQueue *taskqueue.TaskQueue
 	// used to synchronize around context closing
 	taskQueueCh chan struct{}
+
+	// the schedules have their own queue, which doesn't keep the iteration running
+	schedules     map[uint64]*schedule
+	scheduleQueue *taskqueue.TaskQueue
+	schedulesDone chan struct{}
 }
 
 var (
@@ -70,6 +75,9 @@ func (e *Timers) Exports() modules.Exports {
 			"clearTimeout":  e.vu.Runtime().ToValue(e.clearTimeout),
 			"setInterval":   e.vu.Runtime().ToValue(e.setInterval),
 			"clearInterval": e.vu.Runtime().ToValue(e.clearInterval),
+			"scheduleEvery": e.vu.Runtime().ToValue(e.scheduleEvery),
+			"scheduleAt":    e.vu.Runtime().ToValue(e.scheduleAt),
+			"clearSchedule": e.vu.Runtime().ToValue(e.clearSchedule),
 		},
 	}
 }
@@ -137,11 +145,19 @@ func (e *Timers) timerInitialization(
 		common.Throw(e.vu.Runtime(), fmt.Errorf("%s's callback isn't a callable function", name))
 	}
 
+	delay := time.Duration(timeout * float64(time.Millisecond))
+	// a frozen clock of the VU is advanced to the time the timer fires at
+	fired := func() {}
+	if state := e.vu.State(); state != nil && state.Clock != nil {
+		fired = state.Clock.After(delay)
+	}
+
 	task := func() error {
 		// Specification 8.1: If id does not exist in global's map of active timers, then abort these steps.
 		if _, exist := e.timers[id]; !exist {
 			return nil
 		}
+		fired()
 
 		err := e.call(callback, args)
 
@@ -161,7 +177,7 @@ func (e *Timers) timerInitialization(
 	e.runAfterTimeout(&timer{
 		id:          id,
 		task:        task,
-		nextTrigger: time.Now().Add(time.Duration(timeout * float64(time.Millisecond))),
+		nextTrigger: time.Now().Add(delay),
 		name:        name,
 	})
 }
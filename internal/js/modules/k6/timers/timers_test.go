@@ -200,3 +200,75 @@ func TestSetTimeoutContextCancel(t *testing.T) {
 		require.Empty(t, log)
 	}
 }
+
+func TestScheduleEvery(t *testing.T) {
+	t.Parallel()
+	runtime := newRuntime(t)
+
+	rt := runtime.VU.Runtime()
+	var log []string
+	require.NoError(t, rt.Set("print", func(s string) { log = append(log, s) }))
+
+	start := time.Now()
+	_, err := runtime.RunOnEventLoop(`
+		let timers = require("k6/x/timers");
+		timers.scheduleEvery("5ms", (s) => print(s), "heartbeat");
+		timers.setTimeout(() => print("main"), 50);
+	`)
+	require.NoError(t, err)
+	// the schedule doesn't keep the iteration running after the timeout
+	require.Less(t, time.Since(start), time.Second)
+	require.Contains(t, log, "main")
+	heartbeats := 0
+	for _, l := range log {
+		if l == "heartbeat" {
+			heartbeats++
+		}
+	}
+	require.Greater(t, heartbeats, 2)
+}
+
+func TestScheduleAt(t *testing.T) {
+	t.Parallel()
+	runtime := newRuntime(t)
+
+	rt := runtime.VU.Runtime()
+	var log []string
+	require.NoError(t, rt.Set("print", func(s string) { log = append(log, s) }))
+
+	_, err := runtime.RunOnEventLoop(`
+		let timers = require("k6/x/timers");
+		timers.scheduleAt(new Date(Date.now() + 10), () => print("date"));
+		timers.scheduleAt(Date.now() + 20, () => print("timestamp"));
+		timers.scheduleAt(new Date(Date.now() + 30).toISOString(), () => print("string"));
+		let cleared = timers.scheduleAt(Date.now() + 10, () => print("cleared"));
+		timers.clearSchedule(cleared);
+		timers.scheduleAt(Date.now() + 60000, () => print("after the iteration"));
+		timers.setTimeout(() => print("main"), 100);
+	`)
+	require.NoError(t, err)
+	require.Len(t, log, 4)
+	require.ElementsMatch(t, []string{"date", "timestamp", "string"}, log[:3])
+	require.Equal(t, "main", log[3])
+}
+
+func TestScheduleInvalid(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]string{
+		`scheduleEvery("0s", () => {})`:    "scheduleEvery's interval must be positive",
+		`scheduleEvery("often", () => {})`: "scheduleEvery's interval is invalid",
+		`scheduleEvery("1s", undefined)`:   "scheduleEvery's callback isn't a callable function",
+		`scheduleAt("tomorrow", () => {})`: "scheduleAt's date is invalid",
+		`scheduleAt(undefined, () => {})`:  "scheduleAt's date is invalid",
+		`scheduleAt(Date.now(), null)`:     "scheduleAt's callback isn't a callable function",
+	}
+	for code, expected := range tests {
+		t.Run(code, func(t *testing.T) {
+			t.Parallel()
+			runtime := newRuntime(t)
+			_, err := runtime.RunOnEventLoop(`require("k6/x/timers").` + code)
+			require.ErrorContains(t, err, expected)
+		})
+	}
+}
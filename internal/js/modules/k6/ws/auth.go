@@ -0,0 +1,146 @@
+package ws
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	neturl "net/url"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/netext/httpext"
+)
+
+// takeCredentials takes the credentials of the auth param from the URL, since
+// the ones of the WebSocket URLs aren't sent by the dialer, and returns the
+// URL without them.
+func (args *wsConnectArgs) takeCredentials(url string) (string, error) {
+	u, err := neturl.Parse(url)
+	if err != nil {
+		return "", err
+	}
+	if u.User == nil || u.User.Username() == "" {
+		return "", fmt.Errorf("the %s auth requires the credentials in the URL", args.auth)
+	}
+	args.username = u.User.Username()
+	args.password, _ = u.User.Password()
+	u.User = nil
+	return u.String(), nil
+}
+
+// handshake opens the WebSocket, with the authentication of the args, if
+// there's one. The digest one repeats the handshake with the answer to the
+// challenge of the first one, while the NTLM one is negotiated before it, on
+// the connection of the handshake, since it authenticates the connections.
+func handshake(
+	ctx context.Context, state *lib.State, wsd *websocket.Dialer, url string, args *wsConnectArgs,
+) (*websocket.Conn, *http.Response, error) {
+	switch args.auth {
+	case "ntlm":
+		release, err := negotiateNTLM(ctx, state, wsd, url, args)
+		if err != nil {
+			return nil, nil, fmt.Errorf("the NTLM negotiation failed: %w", err)
+		}
+		defer release()
+	case "digest":
+		conn, res, err := wsd.DialContext(ctx, url, args.headers)
+		if !errors.Is(err, websocket.ErrBadHandshake) || res.StatusCode != http.StatusUnauthorized {
+			return conn, res, err
+		}
+		u, err := neturl.Parse(url)
+		if err != nil {
+			return nil, nil, err
+		}
+		args.headers.Set("Authorization", httpext.DigestAuthorization(
+			res, http.MethodGet, u.RequestURI(), args.username, args.password))
+	}
+	return wsd.DialContext(ctx, url, args.headers)
+}
+
+// negotiateNTLM negotiates the NTLM authentication on a new connection, which
+// the dialer uses for the handshake, with the Authorization header, which
+// authenticates it. The connection isn't proxied. It returns the function,
+// which closes the connection, if the dialer didn't use it.
+func negotiateNTLM(
+	ctx context.Context, state *lib.State, wsd *websocket.Dialer, url string, args *wsConnectArgs,
+) (func(), error) {
+	u, err := neturl.Parse(url)
+	if err != nil {
+		return nil, err
+	}
+	secure := u.Scheme == "wss"
+	port := u.Port()
+	if port == "" {
+		port = "80"
+		if secure {
+			port = "443"
+		}
+	}
+	conn, err := state.Dialer.DialContext(ctx, "tcp", net.JoinHostPort(u.Hostname(), port))
+	if err != nil {
+		return nil, err
+	}
+	if secure {
+		cfg := wsd.TLSClientConfig.Clone()
+		if cfg == nil {
+			cfg = &tls.Config{} //nolint:gosec
+		}
+		if cfg.ServerName == "" {
+			cfg.ServerName = u.Hostname()
+		}
+		tlsConn := tls.Client(conn, cfg)
+		if err = tlsConn.HandshakeContext(ctx); err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+		conn = tlsConn
+	}
+
+	_ = conn.SetDeadline(time.Now().Add(wsd.HandshakeTimeout))
+	negotiation := *u
+	negotiation.Scheme = "http"
+	if secure {
+		negotiation.Scheme = "https"
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, negotiation.String(), nil)
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	req.Header = args.headers.Clone()
+	authorization, err := httpext.NegotiateNTLM(conn, req, args.username, args.password)
+	if err != nil || authorization == "" {
+		// the handshake doesn't need the authentication, if it wasn't
+		// challenged, so it's made on a new connection
+		_ = conn.Close()
+		return func() {}, err
+	}
+	_ = conn.SetDeadline(time.Time{})
+	args.headers.Set("Authorization", authorization)
+
+	negotiated := conn
+	dial := func(context.Context, string, string) (net.Conn, error) {
+		if negotiated == nil {
+			return nil, errors.New("the connection of the NTLM negotiation was already used")
+		}
+		c := negotiated
+		negotiated = nil
+		return c, nil
+	}
+	if secure {
+		wsd.NetDialTLSContext = dial
+	} else {
+		wsd.NetDialContext = dial
+	}
+	wsd.Proxy = nil
+	return func() {
+		if negotiated != nil {
+			_ = negotiated.Close()
+		}
+	}, nil
+}
@@ -11,6 +11,7 @@ import (
 	"net"
 	"net/http"
 	"net/http/cookiejar"
+	neturl "net/url"
 	"strconv"
 	"strings"
 	"sync"
@@ -105,6 +106,10 @@ type wsConnectArgs struct {
 	enableCompression bool
 	cookieJar         *cookiejar.Jar
 	tagsAndMeta       *metrics.TagsAndMeta
+	// auth is the authentication of the handshake, digest or ntlm, with the
+	// credentials of the URL
+	auth               string
+	username, password string
 }
 
 const writeWait = 10 * time.Second
@@ -130,6 +135,12 @@ func (mi *WS) Connect(url string, args ...sobek.Value) (*HTTPResponse, error) {
 		return nil, err
 	}
 
+	if parsedArgs.auth != "" {
+		if url, err = parsedArgs.takeCredentials(url); err != nil {
+			return nil, err
+		}
+	}
+
 	parsedArgs.tagsAndMeta.SetSystemTagOrMetaIfEnabled(state.Options.SystemTags, metrics.TagURL, url)
 
 	socket, httpResponse, connEndHook, err := mi.dial(ctx, state, rt, url, parsedArgs)
@@ -268,8 +279,22 @@ func (mi *WS) dial(
 		wsd.Jar = args.cookieJar
 	}
 
+	if state.AuthSessions != nil && args.headers.Get("Authorization") == "" {
+		u, err := neturl.Parse(url)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		authorization, err := state.AuthSessions.Authorization(ctx, state.Transport, u.Host)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if authorization != "" {
+			args.headers.Set("Authorization", authorization)
+		}
+	}
+
 	connStart := time.Now()
-	conn, httpResponse, dialErr := wsd.DialContext(ctx, url, args.headers)
+	conn, httpResponse, dialErr := handshake(ctx, state, &wsd, url, args)
 	connEnd := time.Now()
 
 	if state.Options.SystemTags.Has(metrics.TagIP) && conn.RemoteAddr() != nil {
@@ -699,6 +724,12 @@ func parseConnectArgs(state *lib.State, rt *sobek.Runtime, args ...sobek.Value)
 			}
 
 			parsedArgs.enableCompression = true
+		case "auth":
+			auth := params.Get(k).String()
+			if auth != "digest" && auth != "ntlm" {
+				return nil, fmt.Errorf("unsupported auth '%s', supported ones are 'digest' and 'ntlm'", auth)
+			}
+			parsedArgs.auth = auth
 		}
 	}
 
@@ -2,13 +2,18 @@ package ws
 
 import (
 	"bytes"
+	"context"
 	"crypto/tls"
+	"encoding/base64"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"net/http/cookiejar"
 	"net/http/httptest"
 	"strconv"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -722,6 +727,104 @@ func TestConnectWrongStatusCode(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestDigestAuth(t *testing.T) {
+	t.Parallel()
+	test := newTestState(t)
+	sr := test.tb.Replacer.Replace
+	test.tb.Mux.HandleFunc("/ws-digest", func(w http.ResponseWriter, r *http.Request) {
+		authorization := r.Header.Get("Authorization")
+		if !strings.HasPrefix(authorization, "Digest ") || !strings.Contains(authorization, `username="bob"`) ||
+			!strings.Contains(authorization, `uri="/ws-digest?q=1"`) {
+			w.Header().Set("Www-Authenticate", `Digest realm="k6", nonce="abc", qop="auth"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		conn, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		_ = conn.Close()
+	})
+
+	_, err := test.VU.Runtime().RunString(sr(`
+	var res = ws.connect("ws://bob:pass@HTTPBIN_IP:HTTPBIN_PORT/ws-digest?q=1", { auth: "digest" }, function(socket){
+		socket.close()
+	});
+	if (res.status != 101) {
+		throw new Error("unexpected status: " + res.status);
+	}
+	`))
+	require.NoError(t, err)
+	assertSessionMetricsEmitted(t, metrics.GetBufferedSamples(test.samples), "",
+		sr("ws://HTTPBIN_IP:HTTPBIN_PORT/ws-digest?q=1"), statusProtocolSwitch, "")
+
+	_, err = test.VU.Runtime().RunString(sr(`ws.connect("WSBIN_URL/ws-digest", { auth: "digest" }, function(socket){});`))
+	require.ErrorContains(t, err, "the digest auth requires the credentials in the URL")
+	_, err = test.VU.Runtime().RunString(sr(`ws.connect("WSBIN_URL/ws-digest", { auth: "basic" }, function(socket){});`))
+	require.ErrorContains(t, err, "unsupported auth 'basic'")
+}
+
+type ntlmConnKey struct{}
+
+func TestNTLMAuth(t *testing.T) {
+	t.Parallel()
+	test := newTestState(t)
+	// the server challenges the NTLM negotiation, and upgrades the connection,
+	// if it's answered on it
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		negotiated, _ := r.Context().Value(ntlmConnKey{}).(*atomic.Bool)
+		data, _ := strings.CutPrefix(r.Header.Get("Authorization"), "NTLM ")
+		msg, _ := base64.StdEncoding.DecodeString(data)
+		switch {
+		case len(msg) > 8 && msg[8] == 1:
+			negotiated.Store(true)
+			var challenge bytes.Buffer
+			challenge.WriteString("NTLMSSP\x00\x02\x00\x00\x00")
+			challenge.Write(make([]byte, 8))
+			challenge.Write([]byte{0x01, 0x02, 0x00, 0x00})
+			challenge.WriteString("12345678")
+			challenge.Write(make([]byte, 16))
+			w.Header().Set("Www-Authenticate", "NTLM "+base64.StdEncoding.EncodeToString(challenge.Bytes()))
+		case len(msg) > 8 && msg[8] == 3 && negotiated.Load():
+			conn, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+			if err != nil {
+				return
+			}
+			_ = conn.Close()
+			return
+		default:
+			w.Header().Set("Www-Authenticate", "NTLM")
+		}
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	srv.Config.ConnContext = func(ctx context.Context, _ net.Conn) context.Context {
+		return context.WithValue(ctx, ntlmConnKey{}, new(atomic.Bool))
+	}
+	srv.Start()
+	t.Cleanup(srv.Close)
+	url := strings.Replace(srv.URL, "http://", "ws://", 1)
+
+	_, err := test.VU.Runtime().RunString(fmt.Sprintf(`
+	var res = ws.connect(%q, { auth: "ntlm" }, function(socket){
+		socket.close()
+	});
+	if (res.status != 101) {
+		throw new Error("unexpected status: " + res.status);
+	}
+	`, strings.Replace(url, "ws://", "ws://DOMAIN%5Cbob:pass@", 1)))
+	require.NoError(t, err)
+	assertSessionMetricsEmitted(t, metrics.GetBufferedSamples(test.samples), "", url, statusProtocolSwitch, "")
+
+	test.VU.StateField.Options.Throw = null.BoolFrom(false)
+	_, err = test.VU.Runtime().RunString(fmt.Sprintf(`
+	var res = ws.connect(%q, function(socket){});
+	if (res.status != 401) {
+		throw new Error("unexpected status: " + res.status);
+	}
+	`, url))
+	require.NoError(t, err)
+}
+
 func TestSystemTags(t *testing.T) {
 	t.Parallel()
 	testedSystemTags := []string{"status", "subproto", "url", "ip"}
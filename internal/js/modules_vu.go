@@ -46,3 +46,15 @@ func (m *moduleVUImpl) Runtime() *sobek.Runtime {
 func (m *moduleVUImpl) RegisterCallback() func(func() error) {
 	return m.eventLoop.RegisterCallback()
 }
+
+// RegisterDaemonCallback registers a callback, which the event loop doesn't
+// wait for, see eventloop.RegisterDaemonCallback.
+func (m *moduleVUImpl) RegisterDaemonCallback(stop func()) func(func() error) {
+	return m.eventLoop.RegisterDaemonCallback(stop)
+}
+
+// QueueCallback queues a callback, which the event loop doesn't wait for, see
+// eventloop.QueueCallback.
+func (m *moduleVUImpl) QueueCallback(f func() error) {
+	m.eventLoop.QueueCallback(f)
+}
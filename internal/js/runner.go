@@ -7,13 +7,18 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math"
 	"net"
 	"net/http"
 	"net/http/cookiejar"
+	"net/url"
 	"reflect"
+	runtimemetrics "runtime/metrics"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/grafana/sobek"
@@ -25,6 +30,7 @@ import (
 	"go.k6.io/k6/errext/exitcodes"
 	"go.k6.io/k6/internal/event"
 	"go.k6.io/k6/internal/js/eventloop"
+	"go.k6.io/k6/internal/lib/clusterstore"
 	"go.k6.io/k6/internal/loader"
 	"go.k6.io/k6/js/common"
 	"go.k6.io/k6/lib"
@@ -59,6 +65,79 @@ type Runner struct {
 	console    *console
 	setupData  []byte
 	BufferPool *lib.BufferPool
+
+	// the setup data of the scenarios, with the scenarioSetupData option
+	scenarioSetupData map[string]json.RawMessage
+
+	sharedConnPoolMx sync.Mutex
+	sharedConnPool   *connPool
+
+	// scenarioResolvers are the resolvers of the scenarios with their own DNS options
+	scenarioResolvers map[string]netext.Resolver
+
+	// the SOCKS5 proxy from the options and the ones of the scenarios with their own
+	proxy           *url.URL
+	scenarioProxies map[string]*url.URL
+
+	// the blacklisted IPs and the blocked hostnames of the scenarios with
+	// their own, instead of the ones from the options
+	scenarioRestrictions map[string]*networkRestrictions
+
+	// the network conditions of the scenarios with their own, applied over
+	// the ones from the options
+	scenarioConditions map[string]*lib.NetworkConditions
+
+	// clockStart is when the clocks of the VUs start, i.e. when the first VU
+	// was initialized, so that all of them show the same time
+	clockStartOnce sync.Once
+	clockStart     time.Time
+
+	// staticAssetCache is shared by the VUs, with the staticAssetCache option
+	staticAssetCache *lib.StaticAssetCache
+	// authSessions are the auth sessions of the authSessions option, whose
+	// shared tokens the VUs share
+	authSessions *lib.AuthSessions
+	// clusterStore keeps the counters and the rate limiters of the
+	// k6/experimental/cluster module, in memory or in the clusterStore.
+	clusterStore lib.ClusterStore
+	// connStats counts the connections of all VUs, for the http_conns_active
+	// and http_conns_idle metrics
+	connStats *netext.ConnStats
+
+	// vus counts the VUs initialized for the scenarios, which share the heap
+	// with the vuMemoryLimit option
+	vus atomic.Int64
+	// readLiveHeap returns the bytes of the heap, which were still reachable
+	// after the last garbage collection
+	readLiveHeap func() uint64
+}
+
+// connPool is what the VUs need for making their connections.
+type connPool struct {
+	dialer    *netext.Dialer
+	tlsConfig *tls.Config
+	transport *http.Transport
+	shared    bool
+
+	// verifies the stapled OCSP responses, with the ocspStapling option
+	ocspVerifier *netext.OCSPVerifier
+
+	// the transports for the requests which select their own tlsAuth
+	// certificate, proxy or hosts, created when they are first needed, and
+	// dropped when they are the least recently used of too many
+	transportsMx   sync.Mutex
+	transports     map[lib.TransportOptions]*optionsTransport
+	transportsUsed uint64
+}
+
+// maxOptionsTransports bounds the transports of a pool for the requests with
+// their own options, since the proxies and hosts can be different for every
+// request.
+const maxOptionsTransports = 100
+
+type optionsTransport struct {
+	*http.Transport
+	lastUsed uint64
 }
 
 // New returns a new Runner for the provided source
@@ -96,6 +175,8 @@ func NewFromBundle(piState *lib.TestPreInitState, b *Bundle) (*Runner, error) {
 			net.LookupIP, 0, defDNS.Select.DNSSelect, defDNS.Policy.DNSPolicy),
 		ActualResolver: net.LookupIP,
 		BufferPool:     lib.NewBufferPool(),
+		connStats:      netext.NewConnStats(),
+		readLiveHeap:   readLiveHeap,
 	}
 
 	err := r.SetOptions(r.Bundle.Options)
@@ -117,10 +198,10 @@ func (r *Runner) NewVU(
 	if err != nil {
 		return nil, err
 	}
+	r.vus.Add(1)
 	return lib.InitializedVU(vu), nil
 }
 
-//nolint:funlen
 func (r *Runner) newVU(
 	ctx context.Context, idLocal, idGlobal uint64, samplesOut chan<- metrics.SampleContainer,
 ) (*VU, error) {
@@ -130,6 +211,113 @@ func (r *Runner) newVU(
 		return nil, err
 	}
 
+	pool, err := r.getConnPool(idLocal)
+	if err != nil {
+		return nil, err
+	}
+
+	cookieJar, err := cookiejar.New(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	r.clockStartOnce.Do(func() { r.clockStart = time.Now() })
+	clock, err := lib.NewClock(r.Bundle.Options.Clock, r.clockStart)
+	if err != nil {
+		return nil, err
+	}
+	bi.Runtime.SetTimeSource(clock.Now)
+
+	vu := &VU{
+		ID:             idLocal,
+		IDGlobal:       idGlobal,
+		iteration:      int64(-1),
+		BundleInstance: *bi,
+		Runner:         r,
+		Transport:      pool.transport,
+		Dialer:         pool.dialer,
+		CookieJar:      cookieJar,
+		TLSConfig:      pool.tlsConfig,
+		Console:        r.console,
+		BufferPool:     r.BufferPool,
+		Samples:        samplesOut,
+		scenarioIter:   make(map[string]uint64),
+		setupData:      make(map[string]sobek.Value),
+
+		connPool:                 pool,
+		tlsSessionCache:          pool.tlsConfig.ClientSessionCache,
+		scenarioTLSSessionCaches: make(map[string]tls.ClientSessionCache),
+	}
+
+	vu.state = &lib.State{
+		Logger:    vu.Runner.preInitState.Logger,
+		Options:   vu.Runner.Bundle.Options,
+		Transport: vu.Transport,
+		Dialer:    vu.Dialer,
+		TLSConfig: vu.TLSConfig,
+		CookieJar: cookieJar,
+		TransportFor: func(opts lib.TransportOptions) (http.RoundTripper, error) {
+			return vu.connPool.transportFor(r, opts)
+		},
+		Clock:            clock,
+		RPSLimit:         vu.Runner.RPSLimit,
+		StaticAssetCache: vu.Runner.staticAssetCache,
+		ClusterStore:     vu.Runner.clusterStore,
+		BufferPool:       vu.BufferPool,
+		VUID:             vu.ID,
+		VUIDGlobal:       vu.IDGlobal,
+		Samples:          vu.Samples,
+		Tags:             lib.NewVUStateTags(vu.Runner.RunTags),
+		BuiltinMetrics:   r.preInitState.BuiltinMetrics,
+		TracerProvider:   r.preInitState.TracerProvider,
+		Usage:            r.preInitState.Usage,
+	}
+	if r.authSessions != nil {
+		vu.state.AuthSessions = r.authSessions.ForVU()
+	}
+	// the data of a shared pool can't be attributed to the groups of its VUs
+	if vu.Dialer.DataAttribution != nil && !pool.shared &&
+		slices.Contains(r.Bundle.Options.DataAttribution, "group") {
+		vu.Dialer.DataAttribution.Group = func() string {
+			group, _ := vu.state.Tags.GetCurrentValues().Tags.Get(metrics.TagGroup.String())
+			return group
+		}
+	}
+	if size := r.Bundle.Options.HTTPFlightRecorder.Int64; size > 0 {
+		vu.state.FlightRecorder = lib.NewFlightRecorder(int(size))
+	}
+	if pool.ocspVerifier != nil {
+		vu.state.TLSRevocationCheck = pool.ocspVerifier.CheckDuration
+	}
+	vu.moduleVUImpl.state = vu.state
+	vu.Console = vu.Console.withFields(vu.logFields)
+	_ = vu.Runtime.Set("console", vu.Console)
+
+	return vu, nil
+}
+
+// getConnPool returns the dialer, the TLS config and the HTTP transport that the
+// VU with the given local ID should use. Normally, every VU gets its own ones,
+// but with the sharedConnectionPool option, all VUs get the same ones, so they
+// share their connections and the data sent and received is tracked together.
+func (r *Runner) getConnPool(idLocal uint64) (*connPool, error) {
+	if !r.Bundle.Options.SharedConnectionPool.Bool {
+		return r.newConnPool(idLocal, false)
+	}
+
+	r.sharedConnPoolMx.Lock()
+	defer r.sharedConnPoolMx.Unlock()
+	if r.sharedConnPool == nil {
+		pool, err := r.newConnPool(0, true)
+		if err != nil {
+			return nil, err
+		}
+		r.sharedConnPool = pool
+	}
+	return r.sharedConnPool, nil
+}
+
+func (r *Runner) newConnPool(idLocal uint64, shared bool) (*connPool, error) {
 	var cipherSuites []uint16
 	if r.Bundle.Options.TLSCipherSuites != nil {
 		cipherSuites = *r.Bundle.Options.TLSCipherSuites
@@ -140,13 +328,18 @@ func (r *Runner) newVU(
 		tlsVersions = *r.Bundle.Options.TLSVersion
 	}
 
+	var curves []tls.CurveID
+	if r.Bundle.Options.TLSCurves != nil {
+		curves = *r.Bundle.Options.TLSCurves
+	}
+
 	tlsAuth := r.Bundle.Options.TLSAuth
 	certs := make([]tls.Certificate, len(tlsAuth))
 	nameToCert := make(map[string]*tls.Certificate)
 	for i, auth := range tlsAuth {
-		cert, errC := auth.Certificate()
-		if errC != nil {
-			return nil, errC
+		cert, err := auth.Certificate()
+		if err != nil {
+			return nil, err
 		}
 		certs[i] = *cert
 		for _, name := range auth.Domains {
@@ -160,6 +353,9 @@ func (r *Runner) newVU(
 		Blacklist:        r.Bundle.Options.BlacklistIPs,
 		BlockedHostnames: r.Bundle.Options.BlockedHostnames.Trie,
 		Hosts:            r.Bundle.Options.Hosts.Trie,
+		Proxy:            r.proxy,
+		ConnStats:        r.connStats,
+		Conditions:       r.Bundle.Options.NetworkConditions,
 	}
 	if r.Bundle.Options.LocalIPs.Valid {
 		var ipIndex uint64
@@ -168,15 +364,23 @@ func (r *Runner) newVU(
 		}
 		dialer.Dialer.LocalAddr = &net.TCPAddr{IP: r.Bundle.Options.LocalIPs.Pool.GetIP(ipIndex)}
 	}
+	if slices.Contains(r.Bundle.Options.DataAttribution, "group") ||
+		slices.Contains(r.Bundle.Options.DataAttribution, "host") {
+		dialer.DataAttribution = &netext.DataAttribution{
+			Hosts: slices.Contains(r.Bundle.Options.DataAttribution, "host"),
+		}
+	}
 
 	tlsConfig := &tls.Config{
 		InsecureSkipVerify: r.Bundle.Options.InsecureSkipTLSVerify.Bool, //nolint:gosec
 		CipherSuites:       cipherSuites,
+		CurvePreferences:   curves,
 		MinVersion:         uint16(tlsVersions.Min), //nolint:gosec
 		MaxVersion:         uint16(tlsVersions.Max), //nolint:gosec
 		Certificates:       certs,
 		Renegotiation:      tls.RenegotiateFreelyAsClient,
 		KeyLogWriter:       r.preInitState.KeyLogger,
+		ClientSessionCache: newTLSSessionCache(r.Bundle.Options.TLSSessionResumption),
 	}
 	// Follow NameToCertificate in https://pkg.go.dev/crypto/tls@go1.17.6#Config, leave this field nil
 	// when it is empty
@@ -190,14 +394,72 @@ func (r *Runner) newVU(
 		})
 		tlsConfig.NameToCertificate = nameToCert //nolint:staticcheck
 	}
+
+	var ocspVerifier *netext.OCSPVerifier
+	if r.Bundle.Options.OCSPStapling.Valid {
+		ocspVerifier = netext.NewOCSPVerifier(r.Bundle.Options.OCSPStapling.String)
+		tlsConfig.VerifyConnection = ocspVerifier.VerifyConnection
+	}
+
+	transport, err := r.newTransport(dialer.DialContext, tlsConfig, shared)
+	if err != nil {
+		return nil, err
+	}
+	return &connPool{
+		dialer:       dialer,
+		tlsConfig:    tlsConfig,
+		transport:    transport,
+		ocspVerifier: ocspVerifier,
+		shared:       shared,
+	}, nil
+}
+
+func (r *Runner) newTransport(
+	dialContext func(ctx context.Context, network, addr string) (net.Conn, error), tlsConfig *tls.Config, shared bool,
+) (*http.Transport, error) {
 	transport := &http.Transport{
 		Proxy:               http.ProxyFromEnvironment,
 		TLSClientConfig:     tlsConfig,
-		DialContext:         dialer.DialContext,
+		DialContext:         dialContext,
 		DisableCompression:  true,
 		DisableKeepAlives:   r.Bundle.Options.NoConnectionReuse.Bool,
 		MaxIdleConns:        int(r.Bundle.Options.Batch.Int64),
 		MaxIdleConnsPerHost: int(r.Bundle.Options.BatchPerHost.Int64),
+		MaxConnsPerHost:     int(r.Bundle.Options.MaxConnsPerHost.Int64),
+	}
+	if shared {
+		// all VUs keep their idle connections in the same pool, so the
+		// batch limits, which are meant for a single VU, would be too low
+		transport.MaxIdleConns = 0
+		transport.MaxIdleConnsPerHost = int(r.Bundle.Options.MaxConnsPerHost.Int64)
+	}
+	if r.Bundle.Options.MaxIdleConns.Valid {
+		transport.MaxIdleConns = int(r.Bundle.Options.MaxIdleConns.Int64)
+	}
+	if r.Bundle.Options.MaxIdleConnsPerHost.Valid {
+		transport.MaxIdleConnsPerHost = int(r.Bundle.Options.MaxIdleConnsPerHost.Int64)
+	}
+	transport.IdleConnTimeout = r.Bundle.Options.IdleConnTimeout.TimeDuration()
+
+	if r.Bundle.Options.TLSClientHello.Valid {
+		// the handshakes are made with uTLS, whose connections can only be
+		// used for http/1.1, and without the TLS options of the proxies
+		var cipherSuites []uint16
+		if r.Bundle.Options.TLSCipherSuites != nil {
+			cipherSuites = *r.Bundle.Options.TLSCipherSuites
+		}
+		var curves []tls.CurveID
+		if r.Bundle.Options.TLSCurves != nil {
+			curves = *r.Bundle.Options.TLSCurves
+		}
+		clientHelloDialer, err := netext.NewTLSClientHelloDialer(
+			dialContext, tlsConfig, r.Bundle.Options.TLSClientHello.String, cipherSuites, curves)
+		if err != nil {
+			return nil, err
+		}
+		transport.DialTLSContext = clientHelloDialer.DialTLSContext
+		transport.TLSNextProto = make(map[string]func(string, *tls.Conn) http.RoundTripper)
+		return transport, nil
 	}
 
 	if r.forceHTTP1() {
@@ -206,48 +468,144 @@ func (r *Runner) newVU(
 		_ = http2.ConfigureTransport(transport) // send over h2 protocol
 	}
 
-	cookieJar, err := cookiejar.New(nil)
+	return transport, nil
+}
+
+// transportFor returns the transport for the requests with the given options,
+// which present only the selected tlsAuth certificate, regardless of the
+// domains it's for, connect through the selected proxy, and to the addresses
+// of the selected hosts. The connections are still made by the pool's dialer,
+// so they are measured the same way.
+func (p *connPool) transportFor(r *Runner, opts lib.TransportOptions) (*http.Transport, error) {
+	p.transportsMx.Lock()
+	defer p.transportsMx.Unlock()
+	p.transportsUsed++
+	if transport, ok := p.transports[opts]; ok {
+		transport.lastUsed = p.transportsUsed
+		return transport.Transport, nil
+	}
+
+	tlsConfig := p.tlsConfig
+	if opts.TLSAuth != "" {
+		var err error
+		if tlsConfig, err = p.tlsAuthConfig(r, opts.TLSAuth); err != nil {
+			return nil, err
+		}
+	}
+	dialContext := p.dialer.DialContext
+	if opts.Proxy != "" {
+		proxyURL, err := types.ParseSOCKSProxy(opts.Proxy)
+		if err != nil {
+			return nil, err
+		}
+		proxyDialContext := dialContext
+		dialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return proxyDialContext(netext.WithProxy(ctx, proxyURL), network, addr)
+		}
+	}
+	if opts.Hosts != "" {
+		var hosts types.NullHosts
+		if err := hosts.UnmarshalJSON([]byte(opts.Hosts)); err != nil {
+			return nil, err
+		}
+		hostsDialContext := dialContext
+		dialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return hostsDialContext(netext.WithHosts(ctx, hosts.Trie), network, addr)
+		}
+	}
+
+	transport, err := r.newTransport(dialContext, tlsConfig, p.shared)
 	if err != nil {
 		return nil, err
 	}
+	if p.transports == nil {
+		p.transports = make(map[lib.TransportOptions]*optionsTransport)
+	}
+	if len(p.transports) >= maxOptionsTransports {
+		p.evictTransport()
+	}
+	p.transports[opts] = &optionsTransport{Transport: transport, lastUsed: p.transportsUsed}
+	return transport, nil
+}
 
-	vu := &VU{
-		ID:             idLocal,
-		IDGlobal:       idGlobal,
-		iteration:      int64(-1),
-		BundleInstance: *bi,
-		Runner:         r,
-		Transport:      transport,
-		Dialer:         dialer,
-		CookieJar:      cookieJar,
-		TLSConfig:      tlsConfig,
-		Console:        r.console,
-		BufferPool:     r.BufferPool,
-		Samples:        samplesOut,
-		scenarioIter:   make(map[string]uint64),
+// evictTransport drops the least recently used transport and closes its idle
+// connections.
+func (p *connPool) evictTransport() {
+	var (
+		oldestOpts lib.TransportOptions
+		oldest     *optionsTransport
+	)
+	for opts, transport := range p.transports {
+		if oldest == nil || transport.lastUsed < oldest.lastUsed {
+			oldestOpts, oldest = opts, transport
+		}
+	}
+	delete(p.transports, oldestOpts)
+	oldest.CloseIdleConnections()
+}
+
+// tlsAuthConfig returns the TLS config that presents only the tlsAuth
+// certificate with the given name.
+func (p *connPool) tlsAuthConfig(r *Runner, name string) (*tls.Config, error) {
+	var cert *tls.Certificate
+	for _, auth := range r.Bundle.Options.TLSAuth {
+		if auth.Name != name {
+			continue
+		}
+		var err error
+		if cert, err = auth.Certificate(); err != nil {
+			return nil, err
+		}
+		break
+	}
+	if cert == nil {
+		return nil, fmt.Errorf("there is no tlsAuth certificate with the name %q", name)
 	}
 
-	vu.state = &lib.State{
-		Logger:         vu.Runner.preInitState.Logger,
-		Options:        vu.Runner.Bundle.Options,
-		Transport:      vu.Transport,
-		Dialer:         vu.Dialer,
-		TLSConfig:      vu.TLSConfig,
-		CookieJar:      cookieJar,
-		RPSLimit:       vu.Runner.RPSLimit,
-		BufferPool:     vu.BufferPool,
-		VUID:           vu.ID,
-		VUIDGlobal:     vu.IDGlobal,
-		Samples:        vu.Samples,
-		Tags:           lib.NewVUStateTags(vu.Runner.RunTags),
-		BuiltinMetrics: r.preInitState.BuiltinMetrics,
-		TracerProvider: r.preInitState.TracerProvider,
-		Usage:          r.preInitState.Usage,
+	tlsConfig := p.tlsConfig.Clone()
+	tlsConfig.Certificates = nil
+	tlsConfig.NameToCertificate = nil //nolint:staticcheck
+	tlsConfig.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+		return cert, nil
 	}
-	vu.moduleVUImpl.state = vu.state
-	_ = vu.Runtime.Set("console", vu.Console)
+	// the sessions can't be shared with the other transports, since the
+	// resumed sessions keep the client certificate of their full handshakes
+	tlsConfig.ClientSessionCache = newTLSSessionCache(r.Bundle.Options.TLSSessionResumption)
+	return tlsConfig, nil
+}
 
-	return vu, nil
+func (p *connPool) closeIdleConnections() {
+	p.transport.CloseIdleConnections()
+
+	p.transportsMx.Lock()
+	defer p.transportsMx.Unlock()
+	for _, transport := range p.transports {
+		transport.CloseIdleConnections()
+	}
+}
+
+// tlsAuthRoundTripper makes the requests of the scenarios with their own
+// tlsAuth option with the transport for the selected certificate.
+type tlsAuthRoundTripper struct {
+	vu   *VU
+	name string
+}
+
+func (rt *tlsAuthRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	transport, err := rt.vu.connPool.transportFor(rt.vu.Runner, lib.TransportOptions{TLSAuth: rt.name})
+	if err != nil {
+		return nil, err
+	}
+	return transport.RoundTrip(req)
+}
+
+// newTLSSessionCache returns the TLS session cache for the given resumption
+// options, or nil if the session resumption is disabled.
+func newTLSSessionCache(c *lib.TLSSessionResumption) tls.ClientSessionCache {
+	if c == nil || !c.Enabled.Bool {
+		return nil
+	}
+	return netext.NewTLSSessionCache(uint64(c.FullHandshakeEvery.Int64)) //nolint:gosec
 }
 
 // forceHTTP1 checks if force http1 env variable has been set in order to force requests to be sent over h1
@@ -290,7 +648,7 @@ func (r *Runner) Setup(ctx context.Context, out chan<- metrics.SampleContainer)
 	// r.setupData = nil is special it means undefined from this moment forward
 	if sobek.IsUndefined(v) {
 		r.setupData = nil
-		return nil
+		return r.splitScenarioSetupData()
 	}
 
 	r.setupData, err = json.Marshal(v.Export())
@@ -298,7 +656,35 @@ func (r *Runner) Setup(ctx context.Context, out chan<- metrics.SampleContainer)
 		return fmt.Errorf("error marshaling setup() data to JSON: %w", err)
 	}
 	var tmp interface{}
-	return json.Unmarshal(r.setupData, &tmp)
+	if err = json.Unmarshal(r.setupData, &tmp); err != nil {
+		return err
+	}
+	return r.splitScenarioSetupData()
+}
+
+// splitScenarioSetupData splits the setup data to the data of the scenarios,
+// with the scenarioSetupData option, so that the VUs only unmarshal the data
+// of the scenarios, which they run.
+func (r *Runner) splitScenarioSetupData() error {
+	r.scenarioSetupData = nil
+	if !r.Bundle.Options.ScenarioSetupData.Bool {
+		return nil
+	}
+
+	var data struct {
+		Scenarios map[string]json.RawMessage `json:"scenarios"`
+	}
+	if r.setupData == nil || json.Unmarshal(r.setupData, &data) != nil || data.Scenarios == nil {
+		return fmt.Errorf("with the scenarioSetupData option, %s() has to return an object "+
+			"with the data of the scenarios in its scenarios property", consts.SetupFn)
+	}
+	for name := range data.Scenarios {
+		if _, ok := r.Bundle.Options.Scenarios[name]; !ok {
+			return fmt.Errorf("%s() returned the data of the scenario %q, which doesn't exist", consts.SetupFn, name)
+		}
+	}
+	r.scenarioSetupData = data.Scenarios
+	return nil
 }
 
 // GetSetupData returns the setup data as json if Setup() was specified and executed, nil otherwise
@@ -309,6 +695,20 @@ func (r *Runner) GetSetupData() []byte {
 // SetSetupData saves the externally supplied setup data as json in the runner, so it can be used in VUs
 func (r *Runner) SetSetupData(data []byte) {
 	r.setupData = data
+	// the data was returned by setup() in another instance, where it was split
+	if err := r.splitScenarioSetupData(); err != nil {
+		r.preInitState.Logger.WithError(err).Error("Invalid setup data")
+	}
+}
+
+// setupDataFor returns the setup data, which the iterations of the scenario
+// receive as json, or nil if they receive undefined. Without the
+// scenarioSetupData option, all the scenarios receive the whole data.
+func (r *Runner) setupDataFor(scenario string) []byte {
+	if !r.Bundle.Options.ScenarioSetupData.Bool {
+		return r.setupData
+	}
+	return r.scenarioSetupData[scenario]
 }
 
 // Teardown runs the teardown function if there is one.
@@ -332,10 +732,55 @@ func (r *Runner) Teardown(ctx context.Context, out chan<- metrics.SampleContaine
 	} else {
 		data = sobek.Undefined()
 	}
-	_, err := r.runPart(teardownCtx, out, consts.TeardownFn, data)
+	_, err := r.runPart(teardownCtx, out, consts.TeardownFn, data, abortArg(ctx))
 	return err
 }
 
+// OnAbort runs the onAbort function if there is one, with the reason of the
+// abort of the test run.
+func (r *Runner) OnAbort(ctx context.Context, out chan<- metrics.SampleContainer) error {
+	if !r.IsExecutable(consts.OnAbortFn) {
+		r.preInitState.Logger.Debugf("%s() is not defined or not exported, skipping!", consts.OnAbortFn)
+		return nil
+	}
+	r.preInitState.Logger.Debugf("Running %s()...", consts.OnAbortFn)
+
+	onAbortCtx, onAbortCancel := context.WithTimeout(ctx, r.getTimeoutFor(consts.OnAbortFn))
+	defer onAbortCancel()
+
+	_, err := r.runPart(onAbortCtx, out, consts.OnAbortFn, abortArg(ctx))
+	return err
+}
+
+// abortReasons are the names of the reasons of the test run aborts, which
+// onAbort() and teardown() receive.
+var abortReasons = map[errext.AbortReason]string{ //nolint:gochecknoglobals
+	errext.AbortedByUser:           "user",
+	errext.AbortedByThreshold:      "threshold",
+	errext.AbortedByScriptError:    "script-error",
+	errext.AbortedByScriptAbort:    "script-abort",
+	errext.AbortedByTimeout:        "timeout",
+	errext.AbortedByOutput:         "output",
+	errext.AbortedByResourceBudget: "resource-budget",
+}
+
+// abortArg returns the argument of onAbort() and teardown() with the reason of
+// the abort of the test run in the context, or undefined if it wasn't aborted.
+func abortArg(ctx context.Context) interface{} {
+	err := lib.GetAbortReason(ctx)
+	if err == nil {
+		return sobek.Undefined()
+	}
+	reason := "unknown"
+	var arErr errext.HasAbortReason
+	if errors.As(err, &arErr) {
+		if name, ok := abortReasons[arErr.AbortReason()]; ok {
+			reason = name
+		}
+	}
+	return map[string]interface{}{"reason": reason, "message": err.Error()}
+}
+
 // GetOptions returns the currently calculated [lib.Options] for the given Runner.
 func (r *Runner) GetOptions() lib.Options {
 	return r.Bundle.Options
@@ -438,6 +883,19 @@ func (r *Runner) SetOptions(opts lib.Options) error {
 	if rps := opts.RPS; rps.Valid && rps.Int64 > 0 {
 		r.RPSLimit = rate.NewLimiter(rate.Limit(rps.Int64), 1)
 	}
+	r.staticAssetCache = nil
+	if opts.StaticAssetCache != nil {
+		r.staticAssetCache = lib.NewStaticAssetCache(*opts.StaticAssetCache)
+	}
+	r.authSessions = nil
+	if len(opts.AuthSessions) > 0 {
+		r.authSessions = lib.NewAuthSessions(opts.AuthSessions)
+	}
+	clusterStore, err := clusterstore.New(opts.ClusterStore.String)
+	if err != nil {
+		return err
+	}
+	r.clusterStore = clusterStore
 
 	// TODO: validate that all exec values are either nil or valid exported methods (or HTTP requests in the future)
 
@@ -471,18 +929,154 @@ func (r *Runner) SetOptions(opts lib.Options) error {
 	if err := r.setResolver(opts.DNS); err != nil {
 		return err
 	}
+	if err := r.setProxies(opts); err != nil {
+		return err
+	}
+	r.setNetworkRestrictions(opts)
+	r.setNetworkConditions(opts)
 
 	// FIXME: add tests
-	r.RunTags = r.preInitState.Registry.RootTagSet().WithTagsFromMap(r.Bundle.Options.RunTags)
+	r.RunTags = r.preInitState.Registry.RootTagSet().WithTagsFromMap(r.Bundle.Options.RunTagsWithMetadata())
 
 	return nil
 }
 
 func (r *Runner) setResolver(dns types.DNSConfig) error {
-	ttl, err := parseTTL(dns.TTL.String)
+	resolver, err := r.newResolver(dns)
 	if err != nil {
 		return err
 	}
+	r.Resolver = resolver
+
+	// scenarios with their own DNS options get their own resolvers, with
+	// their own caches, which the VUs switch to when they're activated
+	r.scenarioResolvers = nil
+	for name, sc := range r.Bundle.Options.Scenarios {
+		so := sc.GetScenarioOptions()
+		if so == nil || so.DNS == nil {
+			continue
+		}
+		resolver, err = r.newResolver(dns.Apply(*so.DNS))
+		if err != nil {
+			return fmt.Errorf("invalid dns options for scenario %s: %w", name, err)
+		}
+		if r.scenarioResolvers == nil {
+			r.scenarioResolvers = make(map[string]netext.Resolver)
+		}
+		r.scenarioResolvers[name] = resolver
+	}
+
+	return nil
+}
+
+func (r *Runner) setProxies(opts lib.Options) error {
+	r.proxy = nil
+	if opts.Proxy.String != "" {
+		proxyURL, err := types.ParseSOCKSProxy(opts.Proxy.String)
+		if err != nil {
+			return err
+		}
+		r.proxy = proxyURL
+	}
+
+	r.scenarioProxies = nil
+	for name, sc := range opts.Scenarios {
+		so := sc.GetScenarioOptions()
+		if so == nil || so.Proxy == "" {
+			continue
+		}
+		proxyURL, err := types.ParseSOCKSProxy(so.Proxy)
+		if err != nil {
+			return fmt.Errorf("invalid proxy of scenario %s: %w", name, err)
+		}
+		if r.scenarioProxies == nil {
+			r.scenarioProxies = make(map[string]*url.URL)
+		}
+		r.scenarioProxies[name] = proxyURL
+	}
+	return nil
+}
+
+// networkRestrictions are the IPs and the hostnames, which the VUs can't
+// connect to.
+type networkRestrictions struct {
+	blacklist        []*lib.IPNet
+	blockedHostnames *types.HostnameTrie
+}
+
+func (r *Runner) setNetworkRestrictions(opts lib.Options) {
+	r.scenarioRestrictions = nil
+	for name, sc := range opts.Scenarios {
+		so := sc.GetScenarioOptions()
+		if so == nil || (so.BlacklistIPs == nil && so.BlockHostnames == nil) {
+			continue
+		}
+		restrictions := &networkRestrictions{
+			blacklist:        opts.BlacklistIPs,
+			blockedHostnames: opts.BlockedHostnames.Trie,
+		}
+		if so.BlacklistIPs != nil {
+			restrictions.blacklist = *so.BlacklistIPs
+		}
+		if so.BlockHostnames != nil {
+			restrictions.blockedHostnames = so.BlockHostnames.Trie
+		}
+		if r.scenarioRestrictions == nil {
+			r.scenarioRestrictions = make(map[string]*networkRestrictions)
+		}
+		r.scenarioRestrictions[name] = restrictions
+	}
+}
+
+func (r *Runner) setNetworkConditions(opts lib.Options) {
+	r.scenarioConditions = nil
+	for name, sc := range opts.Scenarios {
+		so := sc.GetScenarioOptions()
+		if so == nil || so.NetworkConditions == nil {
+			continue
+		}
+		var conditions lib.NetworkConditions
+		if opts.NetworkConditions != nil {
+			conditions = *opts.NetworkConditions
+		}
+		conditions = conditions.Apply(*so.NetworkConditions)
+		if r.scenarioConditions == nil {
+			r.scenarioConditions = make(map[string]*lib.NetworkConditions)
+		}
+		r.scenarioConditions[name] = &conditions
+	}
+}
+
+// conditionsFor returns the network conditions of the VUs in the given
+// scenario, or nil if the network isn't degraded.
+func (r *Runner) conditionsFor(scenario string) *lib.NetworkConditions {
+	if conditions, ok := r.scenarioConditions[scenario]; ok {
+		return conditions
+	}
+	return r.Bundle.Options.NetworkConditions
+}
+
+// proxyFor returns the proxy that the VUs should use in the given scenario.
+func (r *Runner) proxyFor(scenario string) *url.URL {
+	if proxyURL, ok := r.scenarioProxies[scenario]; ok {
+		return proxyURL
+	}
+	return r.proxy
+}
+
+func (r *Runner) newResolver(dns types.DNSConfig) (netext.Resolver, error) {
+	ttl, err := parseTTL(dns.TTL.String)
+	if err != nil {
+		return nil, err
+	}
+	negativeTTL, err := parseOptionalTTL("negativeTTL", dns.NegativeTTL.String)
+	if err != nil {
+		return nil, err
+	}
+	maxStale, err := parseOptionalTTL("maxStale", dns.MaxStale.String)
+	if err != nil {
+		return nil, err
+	}
 
 	dnsSel := dns.Select
 	if !dnsSel.Valid {
@@ -492,10 +1086,33 @@ func (r *Runner) setResolver(dns types.DNSConfig) error {
 	if !dnsPol.Valid {
 		dnsPol = types.DefaultDNSConfig().Policy
 	}
-	r.Resolver = netext.NewResolver(
-		r.ActualResolver, ttl, dnsSel.DNSSelect, dnsPol.DNSPolicy)
+	cache := netext.ResolverCache{TTL: ttl, NegativeTTL: negativeTTL, MaxStale: maxStale}
+	if len(dns.Servers) > 0 {
+		return netext.NewServersCachingResolver(
+			dns.Servers, cache, dnsSel.DNSSelect, dnsPol.DNSPolicy), nil
+	}
+	return netext.NewCachingResolver(
+		r.ActualResolver, cache, dnsSel.DNSSelect, dnsPol.DNSPolicy), nil
+}
 
-	return nil
+// resolverFor returns the resolver that the VUs should use in the given scenario.
+func (r *Runner) resolverFor(scenario string) netext.Resolver {
+	if resolver, ok := r.scenarioResolvers[scenario]; ok {
+		return resolver
+	}
+	return r.Resolver
+}
+
+// parseOptionalTTL parses the DNS cache durations that are disabled by default.
+func parseOptionalTTL(name, ttlS string) (time.Duration, error) {
+	if ttlS == "" {
+		return 0, nil
+	}
+	ttl, err := parseTTL(ttlS)
+	if err != nil {
+		return 0, fmt.Errorf("invalid DNS %s: %s", name, ttlS)
+	}
+	return ttl, nil
 }
 
 func parseTTL(ttlS string) (time.Duration, error) {
@@ -525,7 +1142,7 @@ func (r *Runner) runPart(
 	parentCtx context.Context,
 	out chan<- metrics.SampleContainer,
 	name string,
-	arg interface{},
+	args ...interface{},
 ) (sobek.Value, error) {
 	ctx, cancel := context.WithCancel(parentCtx)
 	defer cancel()
@@ -554,7 +1171,11 @@ func (r *Runner) runPart(
 			tagsAndMeta.SetSystemTagOrMeta(metrics.TagGroup, groupPath)
 		})
 	}
-	v, _, _, err := vu.runFn(ctx, false, fn, nil, vu.Runtime.ToValue(arg))
+	values := make([]sobek.Value, len(args))
+	for i, arg := range args {
+		values[i] = vu.Runtime.ToValue(arg)
+	}
+	v, _, _, err := vu.runFn(ctx, false, fn, nil, values...)
 
 	if deadlineError := r.checkDeadline(ctx, name, v, err); deadlineError != nil {
 		return nil, deadlineError
@@ -563,6 +1184,67 @@ func (r *Runner) runPart(
 	return v, err
 }
 
+// NewScheduleHook returns the hook calling the exported function, which
+// schedules the iterations of a scripted executor, in a VU of its own.
+func (r *Runner) NewScheduleHook(
+	ctx context.Context, name string, out chan<- metrics.SampleContainer,
+) (lib.ScheduleHook, error) {
+	vu, err := r.newVU(ctx, 0, 0, out)
+	if err != nil {
+		return nil, err
+	}
+	fn, ok := sobek.AssertFunction(vu.getExported(name))
+	if !ok {
+		return nil, fmt.Errorf("function '%s' not found in exports", name)
+	}
+	vu.moduleVUImpl.ctx = ctx
+
+	return func(callCtx context.Context, sc lib.ScheduleContext) (time.Duration, bool, error) {
+		stop := context.AfterFunc(callCtx, func() {
+			vu.Runtime.Interrupt(callCtx.Err())
+		})
+		defer func() {
+			stop()
+			vu.Runtime.ClearInterrupt()
+		}()
+
+		rt := vu.Runtime
+		arg := rt.NewObject()
+		previous := sobek.Null()
+		if sc.Previous >= 0 {
+			previous = rt.ToValue(metrics.D(sc.Previous))
+		}
+		for key, value := range map[string]sobek.Value{
+			"iteration": rt.ToValue(sc.Iteration),
+			"elapsed":   rt.ToValue(metrics.D(sc.Elapsed)),
+			"previous":  previous,
+		} {
+			if err := arg.Set(key, value); err != nil {
+				return 0, false, err
+			}
+		}
+		v, _, _, err := vu.runFn(callCtx, false, fn, nil, arg)
+		if err != nil {
+			if callCtx.Err() != nil {
+				return 0, false, fmt.Errorf("%s() was interrupted: %w", name, callCtx.Err())
+			}
+			return 0, false, err
+		}
+		if common.IsNullish(v) {
+			return 0, false, nil
+		}
+		offset, ok := v.Export().(float64)
+		if i, isInt := v.Export().(int64); isInt {
+			offset, ok = float64(i), true
+		}
+		if !ok || math.IsNaN(offset) || math.IsInf(offset, 0) || offset < 0 {
+			return 0, false, fmt.Errorf("%s() must return a non-negative number of milliseconds or null, "+
+				"but it returned %s", name, v.String())
+		}
+		return time.Duration(offset * float64(time.Millisecond)), true, nil
+	}, nil
+}
+
 //nolint:gochecknoglobals
 var sobekPromiseType = reflect.TypeOf((*sobek.Promise)(nil))
 
@@ -589,6 +1271,8 @@ func (r *Runner) getTimeoutFor(stage string) time.Duration {
 		return r.Bundle.Options.SetupTimeout.TimeDuration()
 	case consts.TeardownFn:
 		return r.Bundle.Options.TeardownTimeout.TimeDuration()
+	case consts.OnAbortFn:
+		return r.Bundle.Options.OnAbortTimeout.TimeDuration()
 	case consts.HandleSummaryFn:
 		return 2 * time.Minute // TODO: make configurable
 	}
@@ -613,11 +1297,23 @@ type VU struct {
 
 	Samples chan<- metrics.SampleContainer
 
-	setupData sobek.Value
+	// the unmarshaled setup data, by the scenarios with the scenarioSetupData
+	// option, or with the empty key without it
+	setupData map[string]sobek.Value
 
 	state *lib.State
 	// count of iterations executed by this VU in each scenario
 	scenarioIter map[string]uint64
+
+	connPool *connPool
+	// restrictions are the network restrictions of the scenario, which the
+	// dialer of the VU enforces at the moment, or nil for the global ones
+	restrictions *networkRestrictions
+
+	// the TLS session cache from the global options and the ones of the
+	// scenarios with their own tlsSessionResumption options
+	tlsSessionCache          tls.ClientSessionCache
+	scenarioTLSSessionCaches map[string]tls.ClientSessionCache
 }
 
 // Verify that interfaces are implemented
@@ -635,6 +1331,10 @@ type ActiveVU struct {
 	scenarioName              string
 	getNextIterationCounters  func() (uint64, uint64)
 	scIterLocal, scIterGlobal uint64
+
+	// logger has the fields of the VU for the activation, the iterations only
+	// add theirs
+	logger *logrus.Entry
 }
 
 // GetID returns the unique VU ID.
@@ -642,6 +1342,31 @@ func (u *VU) GetID() uint64 {
 	return u.ID
 }
 
+// tlsSessionCacheFor returns the TLS session cache that the VU should use in the
+// given scenario. The sessions are kept between the activations of the VU.
+func (u *VU) tlsSessionCacheFor(scenario string) tls.ClientSessionCache {
+	opts := u.Runner.Bundle.Options
+	var so *lib.ScenarioOptions
+	if sc, ok := opts.Scenarios[scenario]; ok {
+		so = sc.GetScenarioOptions()
+	}
+	if so == nil || so.TLSSessionResumption == nil {
+		return u.tlsSessionCache
+	}
+	if cache, ok := u.scenarioTLSSessionCaches[scenario]; ok {
+		return cache
+	}
+
+	var resumption lib.TLSSessionResumption
+	if opts.TLSSessionResumption != nil {
+		resumption = *opts.TLSSessionResumption
+	}
+	resumption = resumption.Apply(*so.TLSSessionResumption)
+	cache := newTLSSessionCache(&resumption)
+	u.scenarioTLSSessionCaches[scenario] = cache
+	return cache
+}
+
 // Activate the VU so it will be able to run code.
 func (u *VU) Activate(params *lib.VUActivationParams) lib.ActiveVU {
 	u.Runtime.ClearInterrupt()
@@ -663,6 +1388,43 @@ func (u *VU) Activate(params *lib.VUActivationParams) lib.ActiveVU {
 
 	opts := u.Runner.Bundle.Options
 
+	if !opts.SharedConnectionPool.Bool {
+		u.Dialer.Resolver = u.Runner.resolverFor(params.Scenario)
+		u.TLSConfig.ClientSessionCache = u.tlsSessionCacheFor(params.Scenario)
+		if proxyURL := u.Runner.proxyFor(params.Scenario); proxyURL != u.Dialer.Proxy {
+			// the idle connections were made through the previous proxy
+			u.Dialer.Proxy = proxyURL
+			u.connPool.closeIdleConnections()
+		}
+		if restrictions := u.Runner.scenarioRestrictions[params.Scenario]; restrictions != u.restrictions {
+			// the idle connections may be to the hosts, which are now blocked
+			u.restrictions = restrictions
+			if restrictions != nil {
+				u.Dialer.Blacklist = restrictions.blacklist
+				u.Dialer.BlockedHostnames = restrictions.blockedHostnames
+			} else {
+				u.Dialer.Blacklist = opts.BlacklistIPs
+				u.Dialer.BlockedHostnames = opts.BlockedHostnames.Trie
+			}
+			u.connPool.closeIdleConnections()
+		}
+		if conditions := u.Runner.conditionsFor(params.Scenario); conditions != u.Dialer.Conditions {
+			// the idle connections simulate the previous conditions
+			u.Dialer.Conditions = conditions
+			u.connPool.closeIdleConnections()
+		}
+	}
+	u.state.Transport = u.Transport
+	u.state.HTTPProfile = opts.HTTPProfile.String
+	if sc, ok := opts.Scenarios[params.Scenario]; ok {
+		if so := sc.GetScenarioOptions(); so != nil && so.TLSAuth != "" {
+			u.state.Transport = &tlsAuthRoundTripper{vu: u, name: so.TLSAuth}
+		}
+		if so := sc.GetScenarioOptions(); so != nil && so.HTTPProfile != "" {
+			u.state.HTTPProfile = so.HTTPProfile
+		}
+	}
+
 	u.state.Tags.Modify(func(tagsAndMeta *metrics.TagsAndMeta) {
 		// Deliberately overwrite tags from previous activations, i.e. ones that
 		// might have come from previous scenarios. We also intentionally clear
@@ -695,6 +1457,10 @@ func (u *VU) Activate(params *lib.VUActivationParams) lib.ActiveVU {
 		scIterLocal:              ^uint64(0),
 		scIterGlobal:             ^uint64(0),
 		getNextIterationCounters: params.GetNextIterationCounters,
+		logger: u.Runner.preInitState.Logger.WithFields(logrus.Fields{
+			"scenario": params.Scenario,
+			"vu":       u.ID,
+		}),
 	}
 
 	u.state.GetScenarioLocalVUIter = func() uint64 {
@@ -732,18 +1498,25 @@ func (u *ActiveVU) RunOnce() error {
 		<-u.busy // unlock deactivation again
 	}()
 
-	// Unmarshall the setupData only the first time for each VU so that VUs are isolated but we
-	// still don't use too much CPU in the middle test
-	if u.setupData == nil {
-		if u.Runner.setupData != nil {
-			var data interface{}
-			if err := json.Unmarshal(u.Runner.setupData, &data); err != nil {
+	// Unmarshall the setupData only the first time for each VU (and scenario, with the
+	// scenarioSetupData option) so that VUs are isolated but we still don't use too much
+	// CPU in the middle test
+	var scenario string
+	if u.Runner.Bundle.Options.ScenarioSetupData.Bool {
+		scenario = u.scenarioName
+	}
+	setupData, ok := u.setupData[scenario]
+	if !ok {
+		if data := u.Runner.setupDataFor(scenario); data != nil {
+			var v interface{}
+			if err := json.Unmarshal(data, &v); err != nil {
 				return fmt.Errorf("error unmarshaling setup data for the iteration from JSON: %w", err)
 			}
-			u.setupData = u.Runtime.ToValue(data)
+			setupData = u.Runtime.ToValue(v)
 		} else {
-			u.setupData = sobek.Undefined()
+			setupData = sobek.Undefined()
 		}
+		u.setupData[scenario] = setupData
 	}
 
 	fn := u.getCallableExport(u.Exec)
@@ -756,9 +1529,12 @@ func (u *ActiveVU) RunOnce() error {
 	if err := u.Runtime.Set("__ITER", u.iteration); err != nil {
 		panic(fmt.Errorf("error setting __ITER in Sobek runtime: %w", err))
 	}
+	u.state.Logger = u.logger.WithField("iteration", u.iteration)
 
 	ctx, cancel := context.WithCancel(u.RunContext)
 	defer cancel()
+	timedOut := u.interruptOnIterationTimeout(ctx, cancel)
+	overMemoryLimit := u.interruptOnMemoryLimit(ctx, cancel)
 	u.moduleVUImpl.ctx = ctx
 
 	eventIterData := event.IterData{
@@ -770,7 +1546,13 @@ func (u *ActiveVU) RunOnce() error {
 	u.emitAndWaitEvent(&event.Event{Type: event.IterStart, Data: eventIterData})
 
 	// Call the exported function.
-	_, isFullIteration, totalTime, err := u.runFn(ctx, true, fn, cancel, u.setupData)
+	_, isFullIteration, totalTime, err := u.runFn(ctx, true, fn, cancel, setupData)
+	memoryErr := overMemoryLimit()
+	if timeoutErr := timedOut(); timeoutErr != nil {
+		err = timeoutErr
+	} else if memoryErr != nil {
+		err = memoryErr
+	}
 	if err != nil {
 		var x *sobek.InterruptedError
 		if errors.As(err, &x) {
@@ -783,6 +1565,7 @@ func (u *ActiveVU) RunOnce() error {
 	}
 
 	u.emitAndWaitEvent(&event.Event{Type: event.IterEnd, Data: eventIterData})
+	u.flushFlightRecorder(err)
 
 	// If MinIterationDuration is specified and the iteration wasn't canceled
 	// and was less than it, sleep for the remainder
@@ -799,6 +1582,157 @@ func (u *ActiveVU) RunOnce() error {
 	return err
 }
 
+// flushFlightRecorder logs the HTTP requests and responses recorded by the
+// flight recorder of the VU, if the iteration failed a check or returned the
+// given error. The iterations interrupted by the end of the test aren't failed.
+func (u *ActiveVU) flushFlightRecorder(err error) {
+	if u.state.FlightRecorder == nil {
+		return
+	}
+	if u.RunContext.Err() != nil {
+		err = nil
+	}
+	records := u.state.FlightRecorder.Flush(err)
+	logger := u.state.Logger.WithField("source", "http-flight-recorder")
+	for i, record := range records {
+		logger.WithField("record", fmt.Sprintf("%d/%d", i+1, len(records))).Info(record)
+	}
+}
+
+// interruptOnIterationTimeout interrupts the iteration with the given context,
+// if it runs longer than the iterationTimeout of the scenario. The returned
+// function must be called after the iteration, and returns the timeout error,
+// if it was interrupted, after emitting the iteration_timeout metric.
+func (u *ActiveVU) interruptOnIterationTimeout(ctx context.Context, cancel func()) func() error {
+	if u.IterationTimeout <= 0 {
+		return func() error { return nil }
+	}
+
+	var (
+		mu                 sync.Mutex
+		finished, timedOut bool
+	)
+	timer := time.AfterFunc(u.IterationTimeout, func() {
+		mu.Lock()
+		defer mu.Unlock()
+		if finished || ctx.Err() != nil {
+			return
+		}
+		timedOut = true
+		u.Runtime.Interrupt(&lib.IterationTimeoutError{Timeout: u.IterationTimeout})
+		cancel()
+	})
+
+	return func() error {
+		mu.Lock()
+		defer mu.Unlock()
+		// the timer can't interrupt the iteration anymore once it's finished,
+		// and it only did, if it had fired before
+		finished = true
+		if timer.Stop() || !timedOut {
+			return nil
+		}
+
+		if u.RunContext.Err() == nil {
+			// The runtime may still be interrupted, if the iteration finished
+			// before the interruption got to it
+			u.Runtime.ClearInterrupt()
+		}
+		ctm := u.state.Tags.GetCurrentValues()
+		metrics.PushIfNotDone(u.RunContext, u.state.Samples, metrics.Sample{
+			TimeSeries: metrics.TimeSeries{
+				Metric: u.Runner.preInitState.BuiltinMetrics.IterationTimeout,
+				Tags:   ctm.Tags,
+			},
+			Time:     time.Now(),
+			Metadata: ctm.Metadata,
+			Value:    1,
+		})
+		return &lib.IterationTimeoutError{Timeout: u.IterationTimeout}
+	}
+}
+
+// vuMemoryCheckInterval is how often the VU's share of the heap is checked
+// against the vuMemoryLimit, while an iteration is running.
+const vuMemoryCheckInterval = time.Second
+
+// interruptOnMemoryLimit interrupts the iteration with the given context, if
+// the VU's share of the live heap is over the vuMemoryLimit while it's running.
+// The returned function must be called after the iteration. It checks the share
+// once more, emits it as the vu_heap_bytes gauge, and returns the limit error,
+// if it was over the limit during or at the end of the iteration.
+func (u *ActiveVU) interruptOnMemoryLimit(ctx context.Context, cancel func()) func() error {
+	limit := u.Runner.Bundle.Options.VUMemoryLimit
+	if !limit.Valid {
+		return func() error { return nil }
+	}
+
+	var interrupted *lib.VUMemoryLimitError
+	stop, done := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(vuMemoryCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+			case <-stop:
+				return
+			case <-ctx.Done():
+				return
+			}
+			if usage := u.Runner.heapShare(); usage > limit.Int64 {
+				interrupted = &lib.VUMemoryLimitError{Usage: usage, Limit: limit.Int64}
+				u.Runtime.Interrupt(interrupted)
+				cancel()
+				return
+			}
+		}
+	}()
+
+	return func() error {
+		close(stop)
+		<-done
+		usage := u.Runner.heapShare()
+		ctm := u.state.Tags.GetCurrentValues()
+		metrics.PushIfNotDone(u.RunContext, u.state.Samples, metrics.Sample{
+			TimeSeries: metrics.TimeSeries{
+				Metric: u.Runner.preInitState.BuiltinMetrics.VUHeapBytes,
+				Tags:   ctm.Tags,
+			},
+			Time:     time.Now(),
+			Metadata: ctm.Metadata,
+			Value:    float64(usage),
+		})
+		if interrupted != nil {
+			if u.RunContext.Err() == nil {
+				// the interruption may not have got to the finished iteration
+				u.Runtime.ClearInterrupt()
+			}
+			return interrupted
+		}
+		if usage > limit.Int64 {
+			return &lib.VUMemoryLimitError{Usage: usage, Limit: limit.Int64}
+		}
+		return nil
+	}
+}
+
+// heapShare returns the share of the live heap of each VU, since the memory
+// of the VUs isn't tracked separately.
+func (r *Runner) heapShare() int64 {
+	return int64(r.readLiveHeap()) / max(r.vus.Load(), 1) //nolint:gosec
+}
+
+func readLiveHeap() uint64 {
+	samples := []runtimemetrics.Sample{{Name: "/gc/heap/live:bytes"}}
+	runtimemetrics.Read(samples)
+	if samples[0].Value.Kind() != runtimemetrics.KindUint64 {
+		return 0
+	}
+	return samples[0].Value.Uint64()
+}
+
 func (u *ActiveVU) emitAndWaitEvent(evt *event.Event) {
 	waitDone := u.moduleVUImpl.events.local.Emit(evt)
 	waitCtx, waitCancel := context.WithTimeout(u.RunContext, 30*time.Minute)
@@ -808,6 +1742,15 @@ func (u *ActiveVU) emitAndWaitEvent(evt *event.Event) {
 	}
 }
 
+// logFields returns the fields of the VU's logger, which are set at the start
+// of each iteration and by group(), so the console messages have them as well.
+func (u *VU) logFields() logrus.Fields {
+	if entry, ok := u.state.Logger.(*logrus.Entry); ok {
+		return entry.Data
+	}
+	return nil
+}
+
 func (u *VU) getExported(name string) sobek.Value {
 	return u.BundleInstance.getExported(name)
 }
@@ -860,12 +1803,21 @@ func (u *VU) runFn(
 	}
 
 	if u.Runner.Bundle.Options.NoVUConnectionReuse.Bool {
-		u.Transport.CloseIdleConnections()
+		u.connPool.closeIdleConnections()
 	}
 
 	builtinMetrics := u.Runner.preInitState.BuiltinMetrics
 	ctm := u.state.Tags.GetCurrentValues()
-	u.state.Samples <- u.Dialer.IOSamples(endTime, ctm, builtinMetrics)
+	ioCtm := ctm
+	scState := lib.GetScenarioState(ctx)
+	if scState != nil && slices.Contains(u.Runner.Bundle.Options.DataAttribution, "scenario") {
+		ioCtm.Tags = ioCtm.Tags.With(metrics.TagScenario.String(), scState.Name)
+	}
+	ioSamples := u.Dialer.IOSamples(endTime, ioCtm, builtinMetrics)
+	if scState != nil && scState.DataTransfer != nil {
+		addDataTransfer(scState.DataTransfer, ioSamples, builtinMetrics)
+	}
+	u.state.Samples <- ioSamples
 
 	if isFullIteration && isDefault {
 		u.state.Samples <- iterationSamples(startTime, endTime, ctm, builtinMetrics)
@@ -876,6 +1828,21 @@ func (u *VU) runFn(
 	return v, isFullIteration, endTime.Sub(startTime), err
 }
 
+// addDataTransfer counts the data of a finished iteration for the executor of
+// its scenario.
+func addDataTransfer(dt *lib.DataTransfer, ioSamples metrics.SampleContainer, builtinMetrics *metrics.BuiltinMetrics) {
+	var sent, received int64
+	for _, s := range ioSamples.GetSamples() {
+		switch s.Metric {
+		case builtinMetrics.DataSent:
+			sent += int64(s.Value)
+		case builtinMetrics.DataReceived:
+			received += int64(s.Value)
+		}
+	}
+	dt.Add(sent, received)
+}
+
 func iterationSamples(
 	startTime, endTime time.Time, ctm metrics.TagsAndMeta, builtinMetrics *metrics.BuiltinMetrics,
 ) metrics.Samples {
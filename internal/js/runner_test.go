@@ -9,6 +9,7 @@ import (
 	"crypto/x509"
 	"crypto/x509/pkix"
 	"encoding/pem"
+	"errors"
 	"fmt"
 	"go/build"
 	"io"
@@ -20,8 +21,10 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"os"
+	"slices"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -29,6 +32,7 @@ import (
 	logtest "github.com/sirupsen/logrus/hooks/test"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ocsp"
 	"golang.org/x/time/rate"
 	"gopkg.in/guregu/null.v3"
 
@@ -46,6 +50,7 @@ import (
 	"go.k6.io/k6/lib"
 	_ "go.k6.io/k6/lib/executor" // TODO: figure out something better
 	"go.k6.io/k6/lib/fsext"
+	"go.k6.io/k6/lib/netext"
 	"go.k6.io/k6/lib/types"
 	"go.k6.io/k6/metrics"
 	"go.k6.io/k6/output"
@@ -358,7 +363,7 @@ func TestDataIsolation(t *testing.T) {
 
 	globalCtx, globalCancel := context.WithCancel(context.Background())
 	defer globalCancel()
-	runCtx, runAbort := execution.NewTestRunContext(globalCtx, testRunState.Logger)
+	runCtx, runAbort := execution.NewTestRunContext(globalCtx, testRunState.Logger, nil)
 
 	mockOutput := mockoutput.New()
 	outputManager := output.NewManager([]output.Output{mockOutput}, testRunState.Logger, runAbort)
@@ -458,6 +463,166 @@ func TestSetupDataNoSetup(t *testing.T) {
 	};`)
 }
 
+func TestSetupDataScenarios(t *testing.T) {
+	t.Parallel()
+	r, err := getSimpleRunner(t, "/script.js", `
+	exports.options = {
+		setupTimeout: "1s",
+		teardownTimeout: "1s",
+		scenarioSetupData: true,
+		scenarios: {
+			checkout: { executor: "per-vu-iterations", exec: "checkout" },
+			browse: { executor: "per-vu-iterations", exec: "browse" },
+			other: { executor: "per-vu-iterations", exec: "other" },
+		},
+	};
+	exports.setup = function() {
+		return { scenarios: { checkout: { cart: [1, 2] }, browse: { page: "home" } } };
+	}
+	exports.checkout = function(data) {
+		if (JSON.stringify(data) !== '{"cart":[1,2]}') {
+			throw new Error("checkout: wrong data: " + JSON.stringify(data));
+		}
+	};
+	exports.browse = function(data) {
+		if (JSON.stringify(data) !== '{"page":"home"}') {
+			throw new Error("browse: wrong data: " + JSON.stringify(data));
+		}
+	};
+	exports.other = function(data) {
+		if (data !== undefined) {
+			throw new Error("other: wrong data: " + JSON.stringify(data));
+		}
+	};
+	exports.teardown = function(data) {
+		if (data.scenarios.browse.page !== "home") {
+			throw new Error("teardown: wrong data: " + JSON.stringify(data));
+		}
+	};`)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	samples := make(chan metrics.SampleContainer, 100)
+
+	require.NoError(t, r.Setup(ctx, samples))
+	initVU, err := r.NewVU(ctx, 1, 1, samples)
+	require.NoError(t, err)
+	for _, scenario := range []string{"checkout", "browse", "other"} {
+		activationCtx, deactivate := context.WithCancel(ctx)
+		vu := initVU.Activate(&lib.VUActivationParams{RunContext: activationCtx, Scenario: scenario, Exec: scenario})
+		require.NoError(t, vu.RunOnce())
+		require.NoError(t, vu.RunOnce())
+		deactivate()
+	}
+	require.NoError(t, r.Teardown(ctx, samples))
+}
+
+func TestSetupDataScenariosInvalid(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		setup, err string
+	}{
+		"no scenarios": {
+			setup: `return { checkout: {} };`,
+			err:   "with the scenarioSetupData option, setup() has to return an object",
+		},
+		"not an object": {
+			setup: `return 42;`,
+			err:   "with the scenarioSetupData option, setup() has to return an object",
+		},
+		"unknown scenario": {
+			setup: `return { scenarios: { checkout: {}, browse: {} } };`,
+			err:   `setup() returned the data of the scenario "browse", which doesn't exist`,
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			r, err := getSimpleRunner(t, "/script.js", `
+			exports.options = {
+				setupTimeout: "1s",
+				scenarioSetupData: true,
+				scenarios: { checkout: { executor: "per-vu-iterations" } },
+			};
+			exports.setup = function() { `+tc.setup+` }
+			exports.default = function() {};`)
+			require.NoError(t, err)
+
+			require.ErrorContains(t, r.Setup(context.Background(), make(chan metrics.SampleContainer, 100)), tc.err)
+		})
+	}
+}
+
+func TestOnAbort(t *testing.T) {
+	t.Parallel()
+	r, err := getSimpleRunner(t, "/script.js", `
+	exports.options = { setupTimeout: "1s", teardownTimeout: "1s", onAbortTimeout: "1s" };
+	exports.default = function() {};
+	exports.onAbort = function(abort) {
+		if (abort.reason !== "threshold" || abort.message !== "thresholds crossed") {
+			throw new Error("onAbort: wrong abort: " + JSON.stringify(abort));
+		}
+	};
+	exports.teardown = function(data, abort) {
+		if (abort !== undefined && abort.reason !== "threshold") {
+			throw new Error("teardown: wrong abort: " + JSON.stringify(abort));
+		}
+	};`)
+	require.NoError(t, err)
+
+	samples := make(chan metrics.SampleContainer, 100)
+	abortCtx := lib.WithAbortReason(context.Background(), errext.WithAbortReasonIfNone(
+		errors.New("thresholds crossed"), errext.AbortedByThreshold,
+	))
+	require.NoError(t, r.OnAbort(abortCtx, samples))
+	require.NoError(t, r.Teardown(abortCtx, samples))
+	require.NoError(t, r.Teardown(context.Background(), samples))
+
+	unknownCtx := lib.WithAbortReason(context.Background(), errors.New("some error"))
+	require.ErrorContains(t, r.OnAbort(unknownCtx, samples), `"reason":"unknown"`)
+}
+
+func TestAsyncIteration(t *testing.T) {
+	t.Parallel()
+	r, err := getSimpleRunner(t, "/script.js", `
+	import { ReadableStream } from "k6/experimental/streams";
+
+	async function* numbers() {
+		yield 1;
+		yield await Promise.resolve(2);
+	}
+
+	export default async function() {
+		const values = [];
+		for await (const n of numbers()) {
+			values.push(n);
+		}
+		const stream = new ReadableStream({
+			start(controller) {
+				controller.enqueue("a");
+				controller.enqueue("b");
+				controller.close();
+			},
+		});
+		for await (const chunk of stream) {
+			values.push(chunk);
+		}
+		if (values.join() !== "1,2,a,b") {
+			throw new Error("unexpected values: " + values.join());
+		}
+	}`)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	initVU, err := r.NewVU(ctx, 1, 1, make(chan metrics.SampleContainer, 100))
+	require.NoError(t, err)
+	vu := initVU.Activate(&lib.VUActivationParams{RunContext: ctx})
+	require.NoError(t, vu.RunOnce())
+}
+
 func TestConsoleInInitContext(t *testing.T) {
 	t.Parallel()
 	r1, err := getSimpleRunner(t, "/script.js", `
@@ -773,6 +938,79 @@ func TestVUIntegrationMetrics(t *testing.T) {
 	}
 }
 
+func TestVUDataTransfer(t *testing.T) {
+	t.Parallel()
+	tb := httpmultibin.NewHTTPMultiBin(t)
+
+	r, err := getSimpleRunner(t, "/script.js", tb.Replacer.Replace(`
+		var http = require("k6/http");
+		exports.default = function() { http.get("HTTPBIN_IP_URL/bytes/1000"); }
+	`))
+	require.NoError(t, err)
+
+	samples := make(chan metrics.SampleContainer, 100)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	dataTransfer := new(lib.DataTransfer)
+	ctx = lib.WithScenarioState(ctx, &lib.ScenarioState{Name: "default", DataTransfer: dataTransfer})
+
+	vu, err := r.NewVU(ctx, 1, 1, samples)
+	require.NoError(t, err)
+	activeVU := vu.Activate(&lib.VUActivationParams{RunContext: ctx})
+	require.NoError(t, activeVU.RunOnce())
+	require.NoError(t, activeVU.RunOnce())
+
+	iterations, sent, received := dataTransfer.Get()
+	assert.Equal(t, int64(2), iterations)
+	assert.Positive(t, sent)
+	assert.Greater(t, received, int64(2000))
+}
+
+func TestVUDataAttribution(t *testing.T) {
+	t.Parallel()
+	tb := httpmultibin.NewHTTPMultiBin(t)
+
+	r, err := getSimpleRunner(t, "/script.js", tb.Replacer.Replace(`
+		var http = require("k6/http");
+		var group = require("k6").group;
+		exports.default = function() {
+			group("download", function() { http.get("HTTPBIN_IP_URL/bytes/1000"); });
+			http.get("HTTPBIN_IP_URL/bytes/10");
+		}
+	`))
+	require.NoError(t, err)
+	require.NoError(t, r.SetOptions(r.GetOptions().Apply(lib.Options{
+		SystemTags:      &metrics.DefaultSystemTagSet,
+		DataAttribution: []string{"scenario", "group", "host"},
+	})))
+
+	samples := make(chan metrics.SampleContainer, 100)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ctx = lib.WithScenarioState(ctx, &lib.ScenarioState{Name: "downloads"})
+
+	vu, err := r.NewVU(ctx, 1, 1, samples)
+	require.NoError(t, err)
+	activeVU := vu.Activate(&lib.VUActivationParams{RunContext: ctx})
+	require.NoError(t, activeVU.RunOnce())
+
+	received := make(map[string]float64)
+	for _, sampleC := range metrics.GetBufferedSamples(samples) {
+		for _, s := range sampleC.GetSamples() {
+			if s.Metric != r.preInitState.BuiltinMetrics.DataReceived {
+				continue
+			}
+			tags := s.Tags.Map()
+			assert.Equal(t, "downloads", tags["scenario"])
+			assert.Equal(t, tb.Replacer.Replace("HTTPBIN_IP:HTTPBIN_PORT"), tags["host"])
+			received[tags["group"]] += s.Value
+		}
+	}
+	require.Len(t, received, 2)
+	assert.Greater(t, received["::download"], 1000.0)
+	assert.Less(t, received[""], 1000.0)
+}
+
 func generateTLSCertificate(t *testing.T, host string, notBefore time.Time, validFor time.Duration) ([]byte, []byte) {
 	return generateTLSCertificateWithCA(t, host, notBefore, validFor, nil, nil)
 }
@@ -1611,138 +1849,1032 @@ func TestVUIntegrationVUID(t *testing.T) {
 	}
 }
 
-func TestVUIntegrationClientCerts(t *testing.T) {
+func TestVUIntegrationSharedConnectionPool(t *testing.T) {
 	t.Parallel()
 
-	// Generate CA key and certificate
-	caCertPem, caKeyPem := generateTLSCertificate(t, "127.0.0.1", time.Now(), time.Hour)
-
-	caCertBlock, _ := pem.Decode(caCertPem)
-	caCert, err := x509.ParseCertificate(caCertBlock.Bytes)
-	require.NoError(t, err)
-
-	caKeyBlock, _ := pem.Decode(caKeyPem)
-	caKeyAny, err := x509.ParsePKCS8PrivateKey(caKeyBlock.Bytes)
+	tb := httpmultibin.NewHTTPMultiBin(t)
+	script := tb.Replacer.Replace(`
+		var http = require("k6/http");
+		exports.default = function() {
+			var res = http.get("HTTPBIN_URL/get");
+			if (res.status != 200) { throw new Error("wrong status: " + res.status); }
+		}
+	`)
+	r, err := getSimpleRunner(t, "/script.js", script)
 	require.NoError(t, err)
-	caKey, ok := caKeyAny.(*rsa.PrivateKey)
-	require.True(t, ok)
+	require.NoError(t, r.SetOptions(lib.Options{
+		Throw:                null.BoolFrom(true),
+		Hosts:                types.NullHosts{Trie: tb.Dialer.Hosts, Valid: true},
+		SharedConnectionPool: null.BoolFrom(true),
+		MaxConnsPerHost:      null.IntFrom(2),
+	}))
 
-	// Generate server key and certificate
-	srvCertPem, srvKeyPem := generateTLSCertificateWithCA(t, "127.0.0.1", time.Now(), time.Hour, caCert, caKey)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
 
-	// Generate client Key and Certificate
-	clCertPem, clKeyPem := generateTLSCertificateWithCA(t, "127.0.0.1", time.Now(), time.Hour, caCert, caKey)
+	vus := make([]*VU, 3)
+	for i := range vus {
+		vus[i], err = r.newVU(ctx, uint64(i+1), uint64(i+1), make(chan metrics.SampleContainer, 100))
+		require.NoError(t, err)
+		require.NoError(t, vus[i].Activate(&lib.VUActivationParams{RunContext: ctx}).RunOnce())
+	}
 
-	clientCAPool := x509.NewCertPool()
-	assert.True(t, clientCAPool.AppendCertsFromPEM(caCertPem))
+	transport := vus[0].Transport
+	assert.Equal(t, 2, transport.MaxConnsPerHost)
+	assert.Equal(t, 2, transport.MaxIdleConnsPerHost)
+	for _, vu := range vus[1:] {
+		assert.Same(t, transport, vu.Transport)
+		assert.Same(t, vus[0].Dialer, vu.Dialer)
+		assert.Same(t, vus[0].TLSConfig, vu.TLSConfig)
+	}
 
-	serverCert, err := tls.X509KeyPair(append(srvCertPem, caCertPem...), srvKeyPem)
+	r.Bundle.Options.SharedConnectionPool = null.BoolFrom(false)
+	vu, err := r.newVU(ctx, 4, 4, make(chan metrics.SampleContainer, 100))
 	require.NoError(t, err)
+	assert.NotSame(t, transport, vu.Transport)
+	assert.Equal(t, 2, vu.Transport.MaxConnsPerHost)
+}
 
-	testdata := map[string]struct {
-		withClientCert     bool
-		withDomains        bool
-		insecureSkipVerify bool
-		errMsg             string
-	}{
-		"WithoutCert":      {false, false, true, "remote error: tls:"},
-		"WithCert":         {true, true, true, ""},
-		"VerifyServerCert": {true, false, false, "certificate signed by unknown authority"},
-		"WithoutDomains":   {true, false, true, ""},
-	}
+func TestVUConnectionPoolMetrics(t *testing.T) {
+	t.Parallel()
 
-	listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{ //nolint:gosec
-		Certificates: []tls.Certificate{serverCert},
-		ClientAuth:   tls.RequireAndVerifyClientCert,
-		ClientCAs:    clientCAPool,
+	tb := httpmultibin.NewHTTPMultiBin(t)
+	tb.Mux.HandleFunc("/slow", func(w http.ResponseWriter, _ *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
 	})
-	require.NoError(t, err)
-	srv := &http.Server{ //nolint:gosec
-		Handler: http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
-			_, _ = fmt.Fprintf(w, "ok")
-		}),
-		ErrorLog: stdlog.New(io.Discard, "", 0),
+	r, err := getSimpleRunner(t, "/script.js", tb.Replacer.Replace(`
+		var http = require("k6/http");
+		exports.default = function() {
+			http.batch(["HTTPBIN_URL/slow", "HTTPBIN_URL/slow", "HTTPBIN_URL/slow"]);
+		}
+	`))
+	require.NoError(t, err)
+	require.NoError(t, r.SetOptions(lib.Options{
+		Throw:               null.BoolFrom(true),
+		Hosts:               types.NullHosts{Trie: tb.Dialer.Hosts, Valid: true},
+		SystemTags:          &metrics.DefaultSystemTagSet,
+		Batch:               null.IntFrom(3),
+		BatchPerHost:        null.IntFrom(3),
+		MaxConnsPerHost:     null.IntFrom(1),
+		MaxIdleConns:        null.IntFrom(10),
+		MaxIdleConnsPerHost: null.IntFrom(1),
+		IdleConnTimeout:     types.NullDurationFrom(time.Minute),
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	samples := make(chan metrics.SampleContainer, 100)
+	vu, err := r.newVU(ctx, 1, 1, samples)
+	require.NoError(t, err)
+	assert.Equal(t, 10, vu.Transport.MaxIdleConns)
+	assert.Equal(t, 1, vu.Transport.MaxIdleConnsPerHost)
+	assert.Equal(t, time.Minute, vu.Transport.IdleConnTimeout)
+	require.NoError(t, vu.Activate(&lib.VUActivationParams{RunContext: ctx}).RunOnce())
+
+	var waited, gauges int
+	for _, sampleC := range metrics.GetBufferedSamples(samples) {
+		for _, s := range sampleC.GetSamples() {
+			switch s.Metric {
+			case r.preInitState.BuiltinMetrics.HTTPReqConnWaiting:
+				// the requests wait for the single connection in turn
+				if s.Value >= 90 {
+					waited++
+				}
+			case r.preInitState.BuiltinMetrics.HTTPConnsActive, r.preInitState.BuiltinMetrics.HTTPConnsIdle:
+				gauges++
+				name, _ := s.Tags.Get("name")
+				assert.Equal(t, tb.Replacer.Replace("HTTPBIN_URL/slow"), name)
+				assert.LessOrEqual(t, s.Value, 1.0)
+			}
+		}
 	}
-	go func() { _ = srv.Serve(listener) }()
-	t.Cleanup(func() { _ = listener.Close() })
-	for name, data := range testdata {
-		data := data
+	assert.Equal(t, 2, waited)
+	assert.Equal(t, 6, gauges)
 
-		t.Run(name, func(t *testing.T) {
+	active, idle := r.connStats.Get(tb.Replacer.Replace("HTTPBIN_DOMAIN:HTTPBIN_PORT"))
+	assert.Equal(t, int64(0), active)
+	assert.Equal(t, int64(1), idle)
+}
+
+func TestVUIntegrationTLSCurves(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	srv.TLS = &tls.Config{CurvePreferences: []tls.CurveID{tls.CurveP384}} //nolint:gosec
+	srv.Config.ErrorLog = stdlog.New(io.Discard, "", 0)
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+
+	testCases := []struct {
+		curves, expStatus string
+	}{
+		{curves: `["X25519"]`, expStatus: "0"},
+		{curves: `["X25519", "P-384"]`, expStatus: "200"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.curves, func(t *testing.T) {
 			t.Parallel()
 
-			r1, err := getSimpleRunner(t, "/script.js", fmt.Sprintf(`
-			var http = require("k6/http");
-			var k6 = require("k6");
-			var check = k6.check;
-			exports.default = function() {
-				const res = http.get("https://%s")
-				check(res, {
-					'is status 200': (r) => r.status === 200,
-					'verify resp': (r) => r.body.includes('ok'),
-				})
-			}`, listener.Addr().String()))
+			r, err := getSimpleRunner(t, "/script.js", fmt.Sprintf(`
+				var http = require("k6/http");
+				exports.options = { tlsCurves: %s };
+				exports.default = function() {
+					var res = http.get("%s");
+					if (res.status != %s) {
+						throw new Error("wrong status: " + res.status + " " + res.error);
+					}
+				};
+			`, tc.curves, srv.URL, tc.expStatus))
 			require.NoError(t, err)
+			require.NoError(t, r.SetOptions(r.GetOptions().Apply(lib.Options{
+				Throw:                 null.BoolFrom(false),
+				InsecureSkipTLSVerify: null.BoolFrom(true),
+			})))
 
-			opt := lib.Options{Throw: null.BoolFrom(true)}
-			if data.insecureSkipVerify {
-				opt.InsecureSkipTLSVerify = null.BoolFrom(true)
-			}
-			if data.withClientCert {
-				opt.TLSAuth = []*lib.TLSAuth{
-					{
-						TLSAuthFields: lib.TLSAuthFields{
-							Cert: string(clCertPem),
-							Key:  string(clKeyPem),
-						},
-					},
-				}
-				if data.withDomains {
-					opt.TLSAuth[0].TLSAuthFields.Domains = []string{"127.0.0.1"}
-				}
-				_, _ = opt.TLSAuth[0].Certificate()
-			}
-			require.NoError(t, r1.SetOptions(opt))
-			r2, err := getSimpleArchiveRunner(t, r1.MakeArchive())
+			initVU, err := r.NewVU(context.Background(), 1, 1, make(chan metrics.SampleContainer, 100))
 			require.NoError(t, err)
-
-			runners := map[string]*Runner{"Source": r1, "Archive": r2}
-			for name, r := range runners {
-				r := r
-				t.Run(name, func(t *testing.T) {
-					t.Parallel()
-					r.preInitState.Logger, _ = logtest.NewNullLogger()
-					ctx, cancel := context.WithCancel(context.Background())
-					defer cancel()
-					initVU, err := r.NewVU(ctx, 1, 1, make(chan metrics.SampleContainer, 100))
-					require.NoError(t, err)
-					vu := initVU.Activate(&lib.VUActivationParams{RunContext: ctx})
-					err = vu.RunOnce()
-					if len(data.errMsg) > 0 {
-						require.Error(t, err)
-						assert.ErrorContains(t, err, data.errMsg)
-					} else {
-						require.NoError(t, err)
-					}
-				})
-			}
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			vu := initVU.Activate(&lib.VUActivationParams{RunContext: ctx})
+			require.NoError(t, vu.RunOnce())
 		})
 	}
 }
 
-func TestHTTPRequestInInitContext(t *testing.T) {
+func TestVUIntegrationTLSClientHello(t *testing.T) {
 	t.Parallel()
-	tb := httpmultibin.NewHTTPMultiBin(t)
 
-	_, err := getSimpleRunner(t, "/script.js", tb.Replacer.Replace(`
-					var k6 = require("k6");
-					var check = k6.check;
-					var fail = k6.fail;
-					var http = require("k6/http");;
-					var res = http.get("HTTPBIN_URL/");
-					exports.default = function() {
-						console.log(test);
-					}
+	isGREASE := func(v uint16) bool { return v&0x0f0f == 0x0a0a }
+	testCases := []struct {
+		name       string
+		opts       lib.Options
+		expGREASE  bool
+		expProtos  []string
+		expSuites  []uint16
+		expCurves  []tls.CurveID
+		expVersion string
+	}{
+		{
+			name:       "go",
+			expProtos:  []string{"h2", "http/1.1"},
+			expVersion: "tls1.3",
+		},
+		{
+			name:       "chrome",
+			opts:       lib.Options{TLSClientHello: null.StringFrom("chrome")},
+			expGREASE:  true,
+			expProtos:  []string{"http/1.1"},
+			expVersion: "tls1.3",
+		},
+		{
+			name:       "firefox",
+			opts:       lib.Options{TLSClientHello: null.StringFrom("firefox")},
+			expProtos:  []string{"http/1.1"},
+			expVersion: "tls1.3",
+		},
+		{
+			name: "chrome with cipher suites and curves",
+			opts: lib.Options{
+				TLSClientHello: null.StringFrom("chrome"),
+				TLSCipherSuites: &lib.TLSCipherSuites{
+					tls.TLS_AES_256_GCM_SHA384, tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256, tls.TLS_AES_128_GCM_SHA256,
+				},
+				TLSCurves: &lib.TLSCurves{tls.CurveP384, tls.X25519},
+			},
+			expGREASE: true,
+			expProtos: []string{"http/1.1"},
+			expSuites: []uint16{
+				tls.TLS_AES_256_GCM_SHA384, tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256, tls.TLS_AES_128_GCM_SHA256,
+			},
+			expCurves:  []tls.CurveID{tls.CurveP384, tls.X25519},
+			expVersion: "tls1.3",
+		},
+		{
+			name: "chrome with tls1.2",
+			opts: lib.Options{
+				TLSClientHello: null.StringFrom("chrome"),
+				TLSVersion:     &lib.TLSVersions{Min: tls.VersionTLS12, Max: tls.VersionTLS12},
+			},
+			expGREASE:  true,
+			expProtos:  []string{"http/1.1"},
+			expVersion: "tls1.2",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			hellos := make(chan *tls.ClientHelloInfo, 10)
+			srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+			srv.EnableHTTP2 = true
+			srv.TLS = &tls.Config{ //nolint:gosec
+				GetConfigForClient: func(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+					hellos <- hello
+					return nil, nil //nolint:nilnil
+				},
+			}
+			srv.Config.ErrorLog = stdlog.New(io.Discard, "", 0)
+			srv.StartTLS()
+			t.Cleanup(srv.Close)
+
+			r, err := getSimpleRunner(t, "/script.js", `
+				var http = require("k6/http");
+				exports.default = function() {
+					var res = http.get("`+srv.URL+`");
+					if (res.status != 200) { throw new Error("wrong status: " + res.status + " " + res.error); }
+					if (res.tls_version != "`+tc.expVersion+`") { throw new Error("wrong TLS version: " + res.tls_version); }
+				};
+			`)
+			require.NoError(t, err)
+			require.NoError(t, r.SetOptions(r.GetOptions().Apply(tc.opts).Apply(lib.Options{
+				Throw:                 null.BoolFrom(true),
+				InsecureSkipTLSVerify: null.BoolFrom(true),
+			})))
+
+			samples := make(chan metrics.SampleContainer, 100)
+			initVU, err := r.NewVU(context.Background(), 1, 1, samples)
+			require.NoError(t, err)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			vu := initVU.Activate(&lib.VUActivationParams{RunContext: ctx})
+			require.NoError(t, vu.RunOnce())
+
+			require.Len(t, hellos, 1)
+			hello := <-hellos
+			assert.Equal(t, tc.expGREASE, slices.ContainsFunc(hello.CipherSuites, isGREASE))
+			assert.Equal(t, tc.expProtos, hello.SupportedProtos)
+			if tc.expSuites != nil {
+				assert.Equal(t, tc.expSuites, slices.DeleteFunc(hello.CipherSuites, isGREASE))
+			}
+			if tc.expCurves != nil {
+				assert.Equal(t, tc.expCurves, slices.DeleteFunc(hello.SupportedCurves, func(c tls.CurveID) bool {
+					return isGREASE(uint16(c))
+				}))
+			}
+
+			var handshakes int
+			for _, sample := range metrics.GetBufferedSamples(samples) {
+				for _, s := range sample.GetSamples() {
+					if s.Metric.Name == metrics.HTTPReqTLSHandshakingName && s.Value > 0 {
+						handshakes++
+					}
+				}
+			}
+			assert.Equal(t, 1, handshakes)
+		})
+	}
+}
+
+func TestVUIntegrationOCSPStapling(t *testing.T) {
+	t.Parallel()
+
+	newServer := func(status int) string {
+		srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}))
+		srv.TLS = &tls.Config{ //nolint:gosec
+			Certificates: []tls.Certificate{testutils.NewStapledCertificate(t, status, time.Now().Add(time.Hour))},
+		}
+		srv.Config.ErrorLog = stdlog.New(io.Discard, "", 0)
+		srv.StartTLS()
+		t.Cleanup(srv.Close)
+		return srv.URL
+	}
+	goodURL, revokedURL := newServer(ocsp.Good), newServer(ocsp.Revoked)
+
+	testCases := []struct {
+		mode, script string
+		expChecks    int
+	}{
+		{
+			mode: "soft-fail",
+			script: `
+				var res = http.get("` + revokedURL + `");
+				if (res.error_code != 1320) { throw new Error("wrong error code: " + res.error_code); }
+			`,
+		},
+		{
+			mode: "strict",
+			script: `
+				var res = http.get("` + goodURL + `");
+				if (res.status != 200) { throw new Error("wrong status: " + res.status + " " + res.error); }
+				if (!res.tls.ocsp.verified) { throw new Error("the OCSP response wasn't verified"); }
+				if (res.tls.ocsp.status != http.OCSP_STATUS_GOOD) { throw new Error("wrong status: " + res.tls.ocsp.status); }
+				if (res.tls.ocsp.serial_number != "2") { throw new Error("wrong serial: " + res.tls.ocsp.serial_number); }
+				if (res.tls.version != "tls1.3") { throw new Error("wrong TLS version: " + res.tls.version); }
+			`,
+			expChecks: 1,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.mode, func(t *testing.T) {
+			t.Parallel()
+
+			r, err := getSimpleRunner(t, "/script.js", `
+				var http = require("k6/http");
+				exports.default = function() {`+tc.script+`};
+			`)
+			require.NoError(t, err)
+			require.NoError(t, r.SetOptions(r.GetOptions().Apply(lib.Options{
+				Throw:                 null.BoolFrom(false),
+				InsecureSkipTLSVerify: null.BoolFrom(true),
+				OCSPStapling:          null.StringFrom(tc.mode),
+			})))
+
+			samples := make(chan metrics.SampleContainer, 100)
+			initVU, err := r.NewVU(context.Background(), 1, 1, samples)
+			require.NoError(t, err)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			vu := initVU.Activate(&lib.VUActivationParams{RunContext: ctx})
+			require.NoError(t, vu.RunOnce())
+
+			var checks int
+			for _, sample := range metrics.GetBufferedSamples(samples) {
+				for _, s := range sample.GetSamples() {
+					if s.Metric.Name == metrics.TLSRevocationCheckDurationName {
+						checks++
+					}
+				}
+			}
+			assert.Equal(t, tc.expChecks, checks)
+		})
+	}
+}
+
+func TestVUIntegrationTLSSessionResumption(t *testing.T) {
+	t.Parallel()
+
+	tb := httpmultibin.NewHTTPMultiBin(t)
+	r, err := getSimpleRunner(t, "/script.js", tb.Replacer.Replace(`
+		var http = require("k6/http");
+		exports.options = {
+			tlsSessionResumption: { enabled: true, fullHandshakeEvery: 3 },
+			scenarios: {
+				returning: { executor: "shared-iterations" },
+				new: {
+					executor: "shared-iterations",
+					options: { tlsSessionResumption: { enabled: false } },
+				},
+			},
+		};
+		exports.default = function() {
+			for (var i = 0; i < 4; i++) {
+				http.get("HTTPSBIN_URL/get");
+			}
+		};
+	`))
+	require.NoError(t, err)
+	require.NoError(t, r.SetOptions(r.GetOptions().Apply(lib.Options{
+		Throw:                 null.BoolFrom(true),
+		Hosts:                 types.NullHosts{Trie: tb.Dialer.Hosts, Valid: true},
+		InsecureSkipTLSVerify: null.BoolFrom(true),
+		NoConnectionReuse:     null.BoolFrom(true),
+	})))
+
+	countHandshakes := func(scenario string) (full, resumed int) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		samples := make(chan metrics.SampleContainer, 100)
+		vu, err := r.newVU(ctx, 1, 1, samples)
+		require.NoError(t, err)
+		require.NoError(t, vu.Activate(&lib.VUActivationParams{RunContext: ctx, Scenario: scenario}).RunOnce())
+
+		for _, sample := range metrics.GetBufferedSamples(samples) {
+			for _, s := range sample.GetSamples() {
+				switch s.Metric.Name {
+				case metrics.TLSHandshakeFullName:
+					full++
+				case metrics.TLSHandshakeResumedName:
+					resumed++
+				}
+			}
+		}
+		return full, resumed
+	}
+
+	full, resumed := countHandshakes("returning")
+	assert.Equal(t, 2, full) // the first one, without a session, and the third one
+	assert.Equal(t, 2, resumed)
+
+	full, resumed = countHandshakes("new")
+	assert.Equal(t, 4, full)
+	assert.Equal(t, 0, resumed)
+}
+
+func TestVUIntegrationSOCKS5Proxy(t *testing.T) {
+	t.Parallel()
+
+	tb := httpmultibin.NewHTTPMultiBin(t)
+	globalProxy := testutils.NewSOCKS5Server(t, "", "")
+	scenarioProxy := testutils.NewSOCKS5Server(t, "user", "pass")
+	requestProxy := testutils.NewSOCKS5Server(t, "", "")
+
+	r, err := getSimpleRunner(t, "/script.js", tb.Replacer.Replace(fmt.Sprintf(`
+		var http = require("k6/http");
+		exports.options = {
+			proxy: "socks5://%s",
+			scenarios: {
+				bastion: { executor: "shared-iterations", options: { proxy: "socks5://user:pass@%s" } },
+			},
+		};
+		exports.default = function() {
+			http.get("HTTPBIN_URL/get");
+			http.get("HTTPBIN_URL/get", { proxy: "socks5://%s" });
+		};
+	`, globalProxy.Addr, scenarioProxy.Addr, requestProxy.Addr)))
+	require.NoError(t, err)
+	opts := r.GetOptions().Apply(lib.Options{
+		Throw: null.BoolFrom(true),
+		Hosts: types.NullHosts{Trie: tb.Dialer.Hosts, Valid: true},
+	})
+	require.Empty(t, opts.Validate())
+	require.NoError(t, r.SetOptions(opts))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	vu, err := r.NewVU(ctx, 1, 1, make(chan metrics.SampleContainer, 100))
+	require.NoError(t, err)
+
+	require.NoError(t, vu.Activate(&lib.VUActivationParams{RunContext: ctx, Scenario: "default"}).RunOnce())
+	assert.Len(t, globalProxy.Targets(), 1)
+	assert.Len(t, requestProxy.Targets(), 1)
+
+	require.NoError(t, vu.Activate(&lib.VUActivationParams{RunContext: ctx, Scenario: "bastion"}).RunOnce())
+	assert.Len(t, globalProxy.Targets(), 1)
+	assert.Len(t, scenarioProxy.Targets(), 1)
+	// all idle connections are closed when the VU switches to another proxy
+	assert.Len(t, requestProxy.Targets(), 2)
+}
+
+func TestVUIntegrationHTTPProfile(t *testing.T) {
+	t.Parallel()
+
+	tb := httpmultibin.NewHTTPMultiBin(t)
+	r, err := getSimpleRunner(t, "/script.js", tb.Replacer.Replace(`
+		var http = require("k6/http");
+		exports.options = {
+			httpProfile: "chrome",
+			scenarios: {
+				mobile: { executor: "shared-iterations", options: { httpProfile: "chrome-android" } },
+			},
+		};
+		exports.default = function() {
+			var headers = http.get("HTTPBIN_URL/headers").json().headers;
+			if (headers["Sec-Ch-Ua-Mobile"] != __ENV.MOBILE) {
+				throw new Error("unexpected sec-ch-ua-mobile: " + headers["Sec-Ch-Ua-Mobile"]);
+			}
+		};
+	`))
+	require.NoError(t, err)
+	opts := r.GetOptions().Apply(lib.Options{
+		Throw: null.BoolFrom(true),
+		Hosts: types.NullHosts{Trie: tb.Dialer.Hosts, Valid: true},
+	})
+	require.Empty(t, opts.Validate())
+	require.NoError(t, r.SetOptions(opts))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	vu, err := r.NewVU(ctx, 1, 1, make(chan metrics.SampleContainer, 100))
+	require.NoError(t, err)
+
+	require.NoError(t, vu.Activate(&lib.VUActivationParams{
+		RunContext: ctx, Scenario: "default", Env: map[string]string{"MOBILE": "?0"},
+	}).RunOnce())
+	require.NoError(t, vu.Activate(&lib.VUActivationParams{
+		RunContext: ctx, Scenario: "mobile", Env: map[string]string{"MOBILE": "?1"},
+	}).RunOnce())
+}
+
+func TestVUIterationTimeout(t *testing.T) {
+	t.Parallel()
+
+	r, err := getSimpleRunner(t, "/script.js", `
+		exports.default = function() {
+			if (__ITER % 2 === 1) {
+				while (true) {}
+			}
+		};
+	`)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	initVU, err := r.NewVU(ctx, 1, 1, make(chan metrics.SampleContainer, 100))
+	require.NoError(t, err)
+	vu := initVU.Activate(&lib.VUActivationParams{RunContext: ctx, IterationTimeout: 50 * time.Millisecond})
+
+	for i := range 4 {
+		err = vu.RunOnce()
+		if i%2 == 0 {
+			require.NoError(t, err)
+			// the timer of the finished iteration doesn't interrupt the next one
+			time.Sleep(60 * time.Millisecond)
+			continue
+		}
+		var timeoutErr *lib.IterationTimeoutError
+		require.ErrorAs(t, err, &timeoutErr)
+	}
+}
+
+func TestVUMemoryLimit(t *testing.T) {
+	t.Parallel()
+
+	r, err := getSimpleRunner(t, "/script.js", `
+		exports.default = function() {
+			if (__ITER === 1) {
+				while (true) {}
+			}
+		};
+	`)
+	require.NoError(t, err)
+	require.NoError(t, r.SetOptions(r.GetOptions().Apply(lib.Options{VUMemoryLimit: null.IntFrom(1000)})))
+	var liveHeap atomic.Uint64
+	r.readLiveHeap = liveHeap.Load
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	samples := make(chan metrics.SampleContainer, 100)
+	initVU, err := r.NewVU(ctx, 1, 1, samples)
+	require.NoError(t, err)
+	_, err = r.NewVU(ctx, 2, 2, make(chan metrics.SampleContainer, 100))
+	require.NoError(t, err)
+	vu := initVU.Activate(&lib.VUActivationParams{RunContext: ctx})
+
+	// the heap is shared by the two VUs, so their limit is exceeded over 2000 bytes
+	liveHeap.Store(1900)
+	require.NoError(t, vu.RunOnce())
+
+	liveHeap.Store(3000)
+	err = vu.RunOnce()
+	var limitErr *lib.VUMemoryLimitError
+	require.ErrorAs(t, err, &limitErr)
+	assert.Equal(t, lib.VUMemoryLimitError{Usage: 1500, Limit: 1000}, *limitErr)
+
+	// an iteration which ends while the share is over the limit fails as well
+	require.ErrorAs(t, vu.RunOnce(), &limitErr)
+
+	liveHeap.Store(0)
+	require.NoError(t, vu.RunOnce())
+
+	var usages []float64
+	for _, sample := range metrics.GetBufferedSamples(samples) {
+		for _, s := range sample.GetSamples() {
+			if s.Metric.Name == metrics.VUHeapBytesName {
+				usages = append(usages, s.Value)
+			}
+		}
+	}
+	assert.Equal(t, []float64{950, 1500, 1500, 0}, usages)
+}
+
+func TestVUIntegrationRequestHosts(t *testing.T) {
+	t.Parallel()
+
+	tb := httpmultibin.NewHTTPMultiBin(t)
+	r, err := getSimpleRunner(t, "/script.js", tb.Replacer.Replace(`
+		var http = require("k6/http");
+		exports.default = function() {
+			var res = http.get("http://replica.test:HTTPBIN_PORT/get", { hosts: { "replica.test": "HTTPBIN_IP" } });
+			if (res.status !== 200) {
+				throw new Error("unexpected status " + res.status);
+			}
+			res = http.get("http://replica.test:HTTPBIN_PORT/get", { hosts: { "replica.test": "127.0.0.2:1" } });
+			if (res.error_code === 0) {
+				throw new Error("the request should have been made to the other address");
+			}
+			res = http.get("http://replica.test:HTTPBIN_PORT/get", { hosts: { "replica.test": "not-an-ip" } });
+			if (!res.error.includes("invalid hosts address")) {
+				throw new Error("unexpected error " + res.error);
+			}
+		};
+	`))
+	require.NoError(t, err)
+	opts := r.GetOptions().Apply(lib.Options{
+		Hosts: types.NullHosts{Trie: tb.Dialer.Hosts, Valid: true},
+	})
+	require.Empty(t, opts.Validate())
+	require.NoError(t, r.SetOptions(opts))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	vu, err := r.NewVU(ctx, 1, 1, make(chan metrics.SampleContainer, 100))
+	require.NoError(t, err)
+
+	require.NoError(t, vu.Activate(&lib.VUActivationParams{RunContext: ctx}).RunOnce())
+}
+
+func TestConnPoolTransportsEviction(t *testing.T) {
+	t.Parallel()
+
+	r, err := getSimpleRunner(t, "/script.js", `exports.default = function() {};`)
+	require.NoError(t, err)
+	pool := &connPool{dialer: &netext.Dialer{}, tlsConfig: &tls.Config{}} //nolint:gosec
+	proxy := func(i int) lib.TransportOptions {
+		return lib.TransportOptions{Proxy: fmt.Sprintf("socks5://127.0.0.1:%d", 1000+i)}
+	}
+
+	first, err := pool.transportFor(r, proxy(0))
+	require.NoError(t, err)
+	for i := 1; i < maxOptionsTransports; i++ {
+		_, err = pool.transportFor(r, proxy(i))
+		require.NoError(t, err)
+	}
+	// the first transport is used again, so the second one is evicted
+	again, err := pool.transportFor(r, proxy(0))
+	require.NoError(t, err)
+	assert.Same(t, first, again)
+	_, err = pool.transportFor(r, proxy(maxOptionsTransports))
+	require.NoError(t, err)
+
+	assert.Len(t, pool.transports, maxOptionsTransports)
+	assert.Contains(t, pool.transports, proxy(0))
+	assert.NotContains(t, pool.transports, proxy(1))
+}
+
+func TestVUIntegrationScenarioDNS(t *testing.T) {
+	t.Parallel()
+
+	r, err := getSimpleRunner(t, "/script.js", `
+		exports.options = {
+			dns: { ttl: "1m" },
+			scenarios: {
+				pinned: {
+					executor: "shared-iterations",
+					options: { dns: { select: "first", negativeTTL: "10s" } },
+				},
+				other: { executor: "shared-iterations" },
+			},
+		};
+		exports.default = function() {};
+	`)
+	require.NoError(t, err)
+	require.NoError(t, r.SetOptions(r.Bundle.Options))
+
+	require.Len(t, r.scenarioResolvers, 1)
+	pinned := r.scenarioResolvers["pinned"]
+	require.NotNil(t, pinned)
+	assert.NotSame(t, r.Resolver, pinned)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	vu, err := r.newVU(ctx, 1, 1, make(chan metrics.SampleContainer, 100))
+	require.NoError(t, err)
+
+	vu.Activate(&lib.VUActivationParams{RunContext: ctx, Scenario: "pinned"})
+	assert.Same(t, pinned, vu.Dialer.Resolver)
+	vu.Activate(&lib.VUActivationParams{RunContext: ctx, Scenario: "other"})
+	assert.Same(t, r.Resolver, vu.Dialer.Resolver)
+
+	r.Bundle.Options.Scenarios["pinned"].GetScenarioOptions().DNS.MaxStale = null.StringFrom("-1s")
+	require.ErrorContains(t, r.SetOptions(r.Bundle.Options), "invalid dns options for scenario pinned")
+}
+
+func TestVUIntegrationScenarioNetworkRestrictions(t *testing.T) {
+	t.Parallel()
+	tb := httpmultibin.NewHTTPMultiBin(t)
+
+	r, err := getSimpleRunner(t, "/script.js", tb.Replacer.Replace(`
+		var http = require("k6/http");
+		exports.options = {
+			throw: true,
+			blockHostnames: ["*.io"],
+			hosts: { "cdn.test.io": "127.0.0.1" },
+			scenarios: {
+				browser: { executor: "shared-iterations", options: { blockHostnames: [] } },
+				api: {
+					executor: "shared-iterations",
+					options: { blockHostnames: [], blacklistIPs: ["127.0.0.0/8"] },
+				},
+				other: { executor: "shared-iterations" },
+			},
+		};
+		exports.default = function() { http.get("http://cdn.test.io:HTTPBIN_PORT/"); };
+	`))
+	require.NoError(t, err)
+	require.NoError(t, r.SetOptions(r.Bundle.Options))
+	require.Len(t, r.scenarioRestrictions, 2)
+
+	samples := make(chan metrics.SampleContainer, 1000)
+	initVU, err := r.NewVU(context.Background(), 1, 1, samples)
+	require.NoError(t, err)
+
+	// the connection of the browser scenario isn't reused by the other ones
+	run := func(scenario string) error {
+		ctx, cancel := context.WithCancel(context.Background())
+		deactivated := make(chan struct{})
+		vu := initVU.Activate(&lib.VUActivationParams{
+			RunContext:         ctx,
+			Scenario:           scenario,
+			DeactivateCallback: func(lib.InitializedVU) { close(deactivated) },
+		})
+		err := vu.RunOnce()
+		cancel()
+		<-deactivated
+		return err
+	}
+	require.NoError(t, run("browser"))
+	require.ErrorContains(t, run("api"), "IP (127.0.0.1) is in a blacklisted range (127.0.0.0/8)")
+	require.ErrorContains(t, run("other"), "hostname (cdn.test.io) is in a blocked pattern (*.io)")
+	require.NoError(t, run("browser"))
+
+	var blocked float64
+	for _, sc := range metrics.GetBufferedSamples(samples) {
+		for _, sample := range sc.GetSamples() {
+			if sample.Metric.Name == metrics.BlockedRequestsName {
+				blocked += sample.Value
+			}
+		}
+	}
+	assert.Equal(t, 2.0, blocked)
+
+	r.Bundle.Options.SharedConnectionPool = null.BoolFrom(true)
+	assert.Contains(t, r.Bundle.Options.Validate(), fmt.Errorf(
+		"scenario api can't have its own blacklistIPs or blockHostnames when sharedConnectionPool is used"))
+}
+
+func TestVUIntegrationScenarioNetworkConditions(t *testing.T) {
+	t.Parallel()
+	tb := httpmultibin.NewHTTPMultiBin(t)
+
+	r, err := getSimpleRunner(t, "/script.js", tb.Replacer.Replace(`
+		var http = require("k6/http");
+		exports.options = {
+			throw: true,
+			networkConditions: { latency: "150ms", jitter: "10ms" },
+			scenarios: {
+				fast: { executor: "shared-iterations", options: { networkConditions: { latency: "0s", jitter: "0s" } } },
+				lossy: { executor: "shared-iterations", options: { networkConditions: { errorRate: 1 } } },
+				other: { executor: "shared-iterations" },
+			},
+		};
+		exports.default = function() { http.get("HTTPBIN_IP_URL/get"); };
+	`))
+	require.NoError(t, err)
+	require.NoError(t, r.SetOptions(r.Bundle.Options))
+	require.Len(t, r.scenarioConditions, 2)
+	assert.Equal(t, types.NullDurationFrom(150*time.Millisecond), r.scenarioConditions["lossy"].Latency)
+
+	initVU, err := r.NewVU(context.Background(), 1, 1, make(chan metrics.SampleContainer, 1000))
+	require.NoError(t, err)
+
+	run := func(scenario string) (time.Duration, error) {
+		ctx, cancel := context.WithCancel(context.Background())
+		deactivated := make(chan struct{})
+		vu := initVU.Activate(&lib.VUActivationParams{
+			RunContext:         ctx,
+			Scenario:           scenario,
+			DeactivateCallback: func(lib.InitializedVU) { close(deactivated) },
+		})
+		start := time.Now()
+		err := vu.RunOnce()
+		duration := time.Since(start)
+		cancel()
+		<-deactivated
+		return duration, err
+	}
+	// the new connection is delayed, and then the response to the request
+	duration, err := run("other")
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, duration, 280*time.Millisecond)
+	_, err = run("lossy")
+	require.ErrorContains(t, err, netext.ErrSimulatedNetworkError.Error())
+	duration, err = run("fast")
+	require.NoError(t, err)
+	assert.Less(t, duration, 140*time.Millisecond)
+
+	r.Bundle.Options.Scenarios["lossy"].GetScenarioOptions().NetworkConditions.ErrorRate = null.FloatFrom(2)
+	r.Bundle.Options.SharedConnectionPool = null.BoolFrom(true)
+	errs := r.Bundle.Options.Validate()
+	assert.Contains(t, errs, fmt.Errorf(
+		"scenario lossy can't have its own networkConditions when sharedConnectionPool is used"))
+	assert.Contains(t, errs, fmt.Errorf(
+		"invalid networkConditions of scenario lossy: %w", errors.New("errorRate must be between 0 and 1")))
+}
+
+func TestVUIntegrationClientCerts(t *testing.T) {
+	t.Parallel()
+
+	// Generate CA key and certificate
+	caCertPem, caKeyPem := generateTLSCertificate(t, "127.0.0.1", time.Now(), time.Hour)
+
+	caCertBlock, _ := pem.Decode(caCertPem)
+	caCert, err := x509.ParseCertificate(caCertBlock.Bytes)
+	require.NoError(t, err)
+
+	caKeyBlock, _ := pem.Decode(caKeyPem)
+	caKeyAny, err := x509.ParsePKCS8PrivateKey(caKeyBlock.Bytes)
+	require.NoError(t, err)
+	caKey, ok := caKeyAny.(*rsa.PrivateKey)
+	require.True(t, ok)
+
+	// Generate server key and certificate
+	srvCertPem, srvKeyPem := generateTLSCertificateWithCA(t, "127.0.0.1", time.Now(), time.Hour, caCert, caKey)
+
+	// Generate client Key and Certificate
+	clCertPem, clKeyPem := generateTLSCertificateWithCA(t, "127.0.0.1", time.Now(), time.Hour, caCert, caKey)
+
+	clientCAPool := x509.NewCertPool()
+	assert.True(t, clientCAPool.AppendCertsFromPEM(caCertPem))
+
+	serverCert, err := tls.X509KeyPair(append(srvCertPem, caCertPem...), srvKeyPem)
+	require.NoError(t, err)
+
+	testdata := map[string]struct {
+		withClientCert     bool
+		withDomains        bool
+		insecureSkipVerify bool
+		errMsg             string
+	}{
+		"WithoutCert":      {false, false, true, "remote error: tls:"},
+		"WithCert":         {true, true, true, ""},
+		"VerifyServerCert": {true, false, false, "certificate signed by unknown authority"},
+		"WithoutDomains":   {true, false, true, ""},
+	}
+
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{ //nolint:gosec
+		Certificates: []tls.Certificate{serverCert},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    clientCAPool,
+	})
+	require.NoError(t, err)
+	srv := &http.Server{ //nolint:gosec
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = fmt.Fprintf(w, "ok")
+		}),
+		ErrorLog: stdlog.New(io.Discard, "", 0),
+	}
+	go func() { _ = srv.Serve(listener) }()
+	t.Cleanup(func() { _ = listener.Close() })
+	for name, data := range testdata {
+		data := data
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			r1, err := getSimpleRunner(t, "/script.js", fmt.Sprintf(`
+			var http = require("k6/http");
+			var k6 = require("k6");
+			var check = k6.check;
+			exports.default = function() {
+				const res = http.get("https://%s")
+				check(res, {
+					'is status 200': (r) => r.status === 200,
+					'verify resp': (r) => r.body.includes('ok'),
+				})
+			}`, listener.Addr().String()))
+			require.NoError(t, err)
+
+			opt := lib.Options{Throw: null.BoolFrom(true)}
+			if data.insecureSkipVerify {
+				opt.InsecureSkipTLSVerify = null.BoolFrom(true)
+			}
+			if data.withClientCert {
+				opt.TLSAuth = []*lib.TLSAuth{
+					{
+						TLSAuthFields: lib.TLSAuthFields{
+							Cert: string(clCertPem),
+							Key:  string(clKeyPem),
+						},
+					},
+				}
+				if data.withDomains {
+					opt.TLSAuth[0].TLSAuthFields.Domains = []string{"127.0.0.1"}
+				}
+				_, _ = opt.TLSAuth[0].Certificate()
+			}
+			require.NoError(t, r1.SetOptions(opt))
+			r2, err := getSimpleArchiveRunner(t, r1.MakeArchive())
+			require.NoError(t, err)
+
+			runners := map[string]*Runner{"Source": r1, "Archive": r2}
+			for name, r := range runners {
+				r := r
+				t.Run(name, func(t *testing.T) {
+					t.Parallel()
+					r.preInitState.Logger, _ = logtest.NewNullLogger()
+					ctx, cancel := context.WithCancel(context.Background())
+					defer cancel()
+					initVU, err := r.NewVU(ctx, 1, 1, make(chan metrics.SampleContainer, 100))
+					require.NoError(t, err)
+					vu := initVU.Activate(&lib.VUActivationParams{RunContext: ctx})
+					err = vu.RunOnce()
+					if len(data.errMsg) > 0 {
+						require.Error(t, err)
+						assert.ErrorContains(t, err, data.errMsg)
+					} else {
+						require.NoError(t, err)
+					}
+				})
+			}
+		})
+	}
+}
+
+func TestVUIntegrationClientCertsByName(t *testing.T) {
+	t.Parallel()
+
+	caCertPem, caKeyPem := generateTLSCertificate(t, "127.0.0.1", time.Now(), time.Hour)
+	caCertBlock, _ := pem.Decode(caCertPem)
+	caCert, err := x509.ParseCertificate(caCertBlock.Bytes)
+	require.NoError(t, err)
+	caKeyBlock, _ := pem.Decode(caKeyPem)
+	caKeyAny, err := x509.ParsePKCS8PrivateKey(caKeyBlock.Bytes)
+	require.NoError(t, err)
+	caKey, ok := caKeyAny.(*rsa.PrivateKey)
+	require.True(t, ok)
+
+	srvCertPem, srvKeyPem := generateTLSCertificateWithCA(t, "127.0.0.1", time.Now(), time.Hour, caCert, caKey)
+	serverCert, err := tls.X509KeyPair(append(srvCertPem, caCertPem...), srvKeyPem)
+	require.NoError(t, err)
+	clientCAPool := x509.NewCertPool()
+	require.True(t, clientCAPool.AppendCertsFromPEM(caCertPem))
+
+	// the server responds with the name of the client certificate
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{ //nolint:gosec
+		Certificates: []tls.Certificate{serverCert},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    clientCAPool,
+	})
+	require.NoError(t, err)
+	srv := &http.Server{ //nolint:gosec
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = fmt.Fprint(w, r.TLS.PeerCertificates[0].DNSNames[0])
+		}),
+		ErrorLog: stdlog.New(io.Discard, "", 0),
+	}
+	go func() { _ = srv.Serve(listener) }()
+	t.Cleanup(func() { _ = listener.Close() })
+
+	r, err := getSimpleRunner(t, "/script.js", fmt.Sprintf(`
+		var http = require("k6/http");
+		exports.options = {
+			scenarios: {
+				tenantB: { executor: "shared-iterations", options: { tlsAuth: "tenant-b" } },
+			},
+		};
+		function expect(res, name) {
+			if (res.body !== name) { throw new Error("expected " + name + ", got " + res.body); }
+		}
+		exports.default = function() {
+			expect(http.get("https://%[1]s"), "tenant-a");
+			expect(http.get("https://%[1]s", { tlsAuth: "tenant-b" }), "tenant-b");
+			expect(http.get("https://%[1]s", { tlsAuth: "tenant-a" }), "tenant-a");
+		};
+		exports.scenario = function() {
+			expect(http.get("https://%[1]s"), "tenant-b");
+			expect(http.get("https://%[1]s", { tlsAuth: "tenant-a" }), "tenant-a");
+		};
+		exports.unknown = function() {
+			http.get("https://%[1]s", { tlsAuth: "tenant-c" });
+		};
+	`, listener.Addr().String()))
+	require.NoError(t, err)
+
+	opts := lib.Options{Throw: null.BoolFrom(true), InsecureSkipTLSVerify: null.BoolFrom(true)}
+	for _, name := range []string{"tenant-a", "tenant-b"} {
+		certPem, keyPem := generateTLSCertificateWithCA(t, name, time.Now(), time.Hour, caCert, caKey)
+		opts.TLSAuth = append(opts.TLSAuth, &lib.TLSAuth{
+			TLSAuthFields: lib.TLSAuthFields{Cert: string(certPem), Key: string(keyPem), Name: name},
+		})
+	}
+	opts = r.GetOptions().Apply(opts)
+	require.Empty(t, opts.Validate())
+	require.NoError(t, r.SetOptions(opts))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	vu, err := r.NewVU(ctx, 1, 1, make(chan metrics.SampleContainer, 100))
+	require.NoError(t, err)
+
+	require.NoError(t, vu.Activate(&lib.VUActivationParams{RunContext: ctx, Scenario: "default"}).RunOnce())
+	require.NoError(t, vu.Activate(&lib.VUActivationParams{
+		RunContext: ctx, Scenario: "tenantB", Exec: "scenario",
+	}).RunOnce())
+	err = vu.Activate(&lib.VUActivationParams{RunContext: ctx, Scenario: "default", Exec: "unknown"}).RunOnce()
+	require.ErrorContains(t, err, `there is no tlsAuth certificate with the name "tenant-c"`)
+}
+
+func TestHTTPRequestInInitContext(t *testing.T) {
+	t.Parallel()
+	tb := httpmultibin.NewHTTPMultiBin(t)
+
+	_, err := getSimpleRunner(t, "/script.js", tb.Replacer.Replace(`
+					var k6 = require("k6");
+					var check = k6.check;
+					var fail = k6.fail;
+					var http = require("k6/http");;
+					var res = http.get("HTTPBIN_URL/");
+					exports.default = function() {
+						console.log(test);
+					}
 				`))
 	require.Error(t, err)
 	assert.Contains(
@@ -2034,6 +3166,7 @@ func TestSystemTags(t *testing.T) {
 		{"iter", "noop", "0"},
 		{"tls_version", "https_get", "tls1.3"},
 		{"ocsp_status", "https_get", "unknown"},
+		{"tls_cipher", "https_get", "TLS_AES_128_GCM_SHA256"},
 		{"error", "bad_url_get", `dial: connection refused`},
 		{"error_code", "bad_url_get", "1212"},
 		{"scenario", "http_get", "default"},
@@ -2547,3 +3680,90 @@ func TestArchivingAnArchiveWorks(t *testing.T) {
 	require.NoError(t, err)
 	require.NotNil(t, r3)
 }
+
+func TestVUIntegrationClock(t *testing.T) {
+	t.Parallel()
+	r, err := getSimpleRunner(t, "/script.js", `
+		var exec = require("k6/execution");
+		var sleep = require("k6").sleep;
+		var setTimeout = require("k6/timers").setTimeout;
+
+		exports.options = { clock: { start: "2024-01-01T00:00:00Z", frozen: true } };
+
+		function expect(expected) {
+			var now = new Date(Date.now()).toISOString();
+			if (now !== expected) {
+				throw new Error("expected " + expected + " but it was " + now);
+			}
+		}
+
+		exports.default = async function() {
+			expect("2024-01-01T00:00:00.000Z");
+			sleep(0.01);
+			expect("2024-01-01T00:00:00.010Z");
+			await new Promise((resolve) => setTimeout(resolve, 20));
+			expect("2024-01-01T00:00:00.030Z");
+			exec.vu.clock.advance(60000);
+			expect("2024-01-01T00:01:00.030Z");
+			exec.vu.clock.setSkew(-30);
+			expect("2024-01-01T00:01:00.000Z");
+			if (exec.vu.clock.skew !== -30) {
+				throw new Error("unexpected skew " + exec.vu.clock.skew);
+			}
+		}
+	`)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	vu, err := r.newVU(ctx, 1, 1, make(chan metrics.SampleContainer, 100))
+	require.NoError(t, err)
+	activeVU := vu.Activate(&lib.VUActivationParams{RunContext: ctx})
+	require.NoError(t, activeVU.RunOnce())
+}
+
+func TestRunnerScheduleHook(t *testing.T) {
+	t.Parallel()
+
+	r, err := getSimpleRunner(t, "/script.js", `
+		var arrivals = [0, 1.5, 250];
+		exports.nextIteration = function(ctx) {
+			if (ctx.iteration === 3) { return ctx.previous === 250 ? "soon" : -1; }
+			if (ctx.iteration === 4) { while (true) {} }
+			return ctx.iteration < arrivals.length ? arrivals[ctx.iteration] : null;
+		};
+		exports.default = function() {};
+	`)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	hook, err := r.NewScheduleHook(ctx, "nextIteration", make(chan metrics.SampleContainer, 100))
+	require.NoError(t, err)
+
+	previous := time.Duration(-1)
+	for i, expected := range []time.Duration{0, 1500 * time.Microsecond, 250 * time.Millisecond} {
+		offset, ok, err := hook(ctx, lib.ScheduleContext{Iteration: int64(i), Previous: previous})
+		require.NoError(t, err)
+		require.True(t, ok)
+		assert.Equal(t, expected, offset)
+		previous = offset
+	}
+
+	_, _, err = hook(ctx, lib.ScheduleContext{Iteration: 3, Previous: previous})
+	assert.ErrorContains(t, err,
+		"nextIteration() must return a non-negative number of milliseconds or null, but it returned soon")
+
+	timeoutCtx, timeoutCancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer timeoutCancel()
+	_, _, err = hook(timeoutCtx, lib.ScheduleContext{Iteration: 4})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// the hook can still be used after an interruption
+	_, ok, err := hook(ctx, lib.ScheduleContext{Iteration: 5})
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	_, err = r.NewScheduleHook(ctx, "missing", make(chan metrics.SampleContainer, 100))
+	assert.ErrorContains(t, err, "function 'missing' not found in exports")
+}
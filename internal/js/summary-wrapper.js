@@ -32,11 +32,21 @@
         return group;
     };
 
+    var oldFormatMetricValues = function (metric) {
+        var values = metric.values;
+        if (metric.type == 'rate' && values.hasOwnProperty('rate')) {
+            values.value = values.rate; // sigh...
+            delete values.rate;
+        }
+        return values;
+    };
+
     var oldJSONSummary = function (data) {
         // Quick copy of the data, since it's easiest to modify it in place.
         var results = JSON.parse(JSON.stringify(data));
         delete results.options;
         delete results.state;
+        delete results.scenarios;
 
         forEach(results.metrics, function (metricName, metric) {
             var oldFormatMetric = metric.values;
@@ -47,13 +57,18 @@
                     oldFormatMetric.thresholds[thresholdName] = !threshold.ok;
                 });
             }
-            if (metric.type == 'rate' && oldFormatMetric.hasOwnProperty('rate')) {
-                oldFormatMetric.value = oldFormatMetric.rate; // sigh...
-                delete oldFormatMetric.rate;
-            }
-            results.metrics[metricName] = oldFormatMetric;
+            results.metrics[metricName] = oldFormatMetricValues(metric);
         });
 
+        if (Array.isArray(results.timeBuckets)) {
+            for (var i = 0; i < results.timeBuckets.length; i++) {
+                var bucketMetrics = results.timeBuckets[i].metrics;
+                forEach(bucketMetrics, function (metricName, metric) {
+                    bucketMetrics[metricName] = oldFormatMetricValues(metric);
+                });
+            }
+        }
+
         results.root_group = transformGroup(results.root_group);
 
         return JSON.stringify(results, null, 4);
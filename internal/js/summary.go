@@ -8,6 +8,8 @@ import (
 	"time"
 
 	"github.com/grafana/sobek"
+	"gopkg.in/guregu/null.v3"
+
 	"go.k6.io/k6/js/common"
 	"go.k6.io/k6/lib"
 	"go.k6.io/k6/metrics"
@@ -60,40 +62,78 @@ func metricValueGetter(summaryTrendStats []string) func(metrics.Sink, time.Durat
 // summarizeMetricsToObject transforms the summary objects in a way that's
 // suitable to pass to the JS runtime or export to JSON.
 func summarizeMetricsToObject(data *lib.Summary, options lib.Options, setupData []byte) map[string]interface{} {
+	getMetricValues := metricValueGetter(options.SummaryTrendStats)
+
 	m := make(map[string]interface{})
-	m["root_group"] = exportGroup(data.RootGroup)
+	m["root_group"] = exportGroup(data.RootGroup, getMetricValues)
 	m["options"] = map[string]interface{}{
 		// TODO: improve when we can easily export all option values, including defaults?
 		"summaryTrendStats": options.SummaryTrendStats,
 		"summaryTimeUnit":   options.SummaryTimeUnit.String,
 		"noColor":           data.NoColor, // TODO: move to the (runtime) options
 	}
-	m["state"] = map[string]interface{}{
+	metadata := make(map[string]interface{})
+	for name, value := range map[string]null.String{
+		"name":        options.Metadata.Name,
+		"version":     options.Metadata.Version,
+		"environment": options.Metadata.Environment,
+		"gitSHA":      options.Metadata.GitSHA,
+	} {
+		if value.Valid {
+			metadata[name] = value.String
+		}
+	}
+	if len(metadata) > 0 {
+		m["metadata"] = metadata
+	}
+	state := map[string]interface{}{
 		"isStdOutTTY":       data.UIState.IsStdOutTTY,
 		"isStdErrTTY":       data.UIState.IsStdErrTTY,
 		"testRunDurationMs": float64(data.TestRunDuration) / float64(time.Millisecond),
 	}
-
-	getMetricValues := metricValueGetter(options.SummaryTrendStats)
-
-	metricsData := make(map[string]interface{})
-	for name, m := range data.Metrics {
-		metricData := map[string]interface{}{
-			"type":     m.Type.String(),
-			"contains": m.Contains.String(),
-			"values":   getMetricValues(m.Sink, data.TestRunDuration),
+	if data.ArtifactsDir != "" {
+		state["artifactsDir"] = data.ArtifactsDir
+	}
+	if !data.StartTime.IsZero() {
+		state["startTime"] = data.StartTime.UTC().Format(time.RFC3339Nano)
+	}
+	m["state"] = state
+
+	if len(data.Scenarios) > 0 {
+		scenarios := make(map[string]interface{}, len(data.Scenarios))
+		for name, scenario := range data.Scenarios {
+			scenarioMetrics := make(map[string]interface{}, len(scenario.Metrics))
+			for metricName, metric := range scenario.Metrics {
+				scenarioMetrics[metricName] = exportMetric(metric, getMetricValues, data.TestRunDuration)
+			}
+			scenarios[name] = map[string]interface{}{
+				"executor":    scenario.Executor,
+				"startTimeMs": float64(scenario.StartTime) / float64(time.Millisecond),
+				"metrics":     scenarioMetrics,
+			}
 		}
+		m["scenarios"] = scenarios
+	}
 
-		if len(m.Thresholds.Thresholds) > 0 {
-			thresholds := make(map[string]interface{})
-			for _, threshold := range m.Thresholds.Thresholds {
-				thresholds[threshold.Source] = map[string]interface{}{
-					"ok": !threshold.LastFailed,
-				}
+	if len(data.TimeBuckets) > 0 {
+		timeBuckets := make([]interface{}, len(data.TimeBuckets))
+		for i, bucket := range data.TimeBuckets {
+			bucketMetrics := make(map[string]interface{}, len(bucket.Metrics))
+			for metricName, metric := range bucket.Metrics {
+				bucketMetrics[metricName] = exportMetric(metric, getMetricValues, bucket.Duration)
+			}
+			timeBuckets[i] = map[string]interface{}{
+				"startTime":  bucket.StartTime.UTC().Format(time.RFC3339Nano),
+				"durationMs": float64(bucket.Duration) / float64(time.Millisecond),
+				"metrics":    bucketMetrics,
 			}
-			metricData["thresholds"] = thresholds
 		}
-		metricsData[name] = metricData
+		m["timeBuckets"] = timeBuckets
+	}
+
+	metricsData := make(map[string]interface{})
+	for name, m := range data.Metrics {
+		metricsData[name] = exportMetric(m, getMetricValues, data.TestRunDuration)
 	}
 	m["metrics"] = metricsData
 
@@ -112,10 +152,50 @@ func summarizeMetricsToObject(data *lib.Summary, options lib.Options, setupData
 	return m
 }
 
-func exportGroup(group *lib.Group) map[string]interface{} {
+// exportMetric exports the values of the metric and its thresholds, with the
+// actual values they were evaluated against during the test.
+func exportMetric(
+	m *metrics.Metric, getMetricValues func(metrics.Sink, time.Duration) map[string]float64, t time.Duration,
+) map[string]interface{} {
+	metricData := map[string]interface{}{
+		"type":     m.Type.String(),
+		"contains": m.Contains.String(),
+		"values":   getMetricValues(m.Sink, t),
+	}
+	if len(m.Thresholds.Thresholds) == 0 {
+		return metricData
+	}
+
+	thresholds := make(map[string]interface{})
+	for _, threshold := range m.Thresholds.Thresholds {
+		thresholdData := map[string]interface{}{"ok": !threshold.LastFailed}
+		if len(threshold.Evaluations) > 0 {
+			evaluations := make([]map[string]interface{}, len(threshold.Evaluations))
+			for i, evaluation := range threshold.Evaluations {
+				evaluations[i] = map[string]interface{}{
+					"timeMs": float64(evaluation.Time) / float64(time.Millisecond),
+					"value":  evaluation.Value,
+					"ok":     evaluation.OK,
+				}
+			}
+			thresholdData["value"] = threshold.Evaluations[len(threshold.Evaluations)-1].Value
+			thresholdData["evaluations"] = evaluations
+		}
+		thresholds[threshold.Source] = thresholdData
+	}
+	metricData["thresholds"] = thresholds
+	return metricData
+}
+
+// exportGroup exports the group and its subgroups as a tree. Besides its
+// checks, each group has the totals of its and its subgroups' checks, and the
+// trend stats of its durations, if it was run.
+func exportGroup(
+	group *lib.Group, getMetricValues func(metrics.Sink, time.Duration) map[string]float64,
+) map[string]interface{} {
 	subGroups := make([]map[string]interface{}, len(group.OrderedGroups))
 	for i, subGroup := range group.OrderedGroups {
-		subGroups[i] = exportGroup(subGroup)
+		subGroups[i] = exportGroup(subGroup, getMetricValues)
 	}
 
 	checks := make([]map[string]interface{}, len(group.OrderedChecks))
@@ -127,15 +207,27 @@ func exportGroup(group *lib.Group) map[string]interface{} {
 			"passes": check.Passes,
 			"fails":  check.Fails,
 		}
+		if check.Severity != "" {
+			checks[i]["severity"] = check.Severity
+		}
 	}
 
-	return map[string]interface{}{
+	passes, fails := group.CheckTotals()
+	result := map[string]interface{}{
 		"name":   group.Name,
 		"path":   group.Path,
 		"id":     group.ID,
 		"groups": subGroups,
 		"checks": checks,
+		"passes": passes,
+		"fails":  fails,
 	}
+	group.Durations(func(durations *metrics.TrendSink) {
+		if durations != nil {
+			result["duration"] = getMetricValues(durations, 0)
+		}
+	})
+	return result
 }
 
 func getSummaryResult(rawResult sobek.Value) (map[string]io.Reader, error) {
@@ -96,21 +96,86 @@ function summarizeCheck(indent, check, decorate) {
   )
 }
 
-function summarizeGroup(indent, group, decorate) {
+function summarizeGroupDetails(indent, group, options) {
+  var result = ''
+  if (group.passes + group.fails > 0) {
+    var succPercent = Math.floor((100 * group.passes) / (group.passes + group.fails))
+    result +=
+      '\n' +
+      indent +
+      detailsPrefix +
+      '  checks: ' +
+      succPercent +
+      '% — ' +
+      succMark +
+      ' ' +
+      group.passes +
+      ' / ' +
+      failMark +
+      ' ' +
+      group.fails
+  }
+  if (group.duration) {
+    var stats = []
+    for (var i = 0; i < options.summaryTrendStats.length; i++) {
+      var tc = options.summaryTrendStats[i]
+      var value = group.duration[tc]
+      if (tc === 'count') {
+        value = value.toString()
+      } else {
+        value = humanizeDuration(value, options.summaryTimeUnit)
+      }
+      stats.push(tc + '=' + value)
+    }
+    result += '\n' + indent + detailsPrefix + '  duration: ' + stats.join(' ')
+  }
+  return result
+}
+
+var checkSeverities = ['critical', 'warn', 'info']
+
+// groupChecksBySeverity returns the checks grouped by their severity, from the
+// most severe, with the checks without one last. If none of the checks has a
+// severity, they're all in a single group without one.
+function groupChecksBySeverity(checks) {
+  var groups = []
+  var severities = checkSeverities.concat([undefined])
+  for (var i = 0; i < severities.length; i++) {
+    var severityChecks = checks.filter(function (check) {
+      return check.severity === severities[i]
+    })
+    if (severityChecks.length > 0) {
+      groups.push({ severity: severities[i], checks: severityChecks })
+    }
+  }
+  if (groups.length > 1 && groups[groups.length - 1].severity === undefined) {
+    groups[groups.length - 1].severity = 'other'
+  }
+  return groups
+}
+
+function summarizeGroup(indent, group, decorate, options) {
   var result = []
   if (group.name != '') {
-    result.push(indent + groupPrefix + ' ' + group.name + '\n')
+    var details = summarizeGroupDetails(indent + '  ', group, options)
+    result.push(indent + groupPrefix + ' ' + group.name + details + '\n')
     indent = indent + '  '
   }
 
-  for (var i = 0; i < group.checks.length; i++) {
-    result.push(summarizeCheck(indent, group.checks[i], decorate))
+  var bySeverity = groupChecksBySeverity(group.checks)
+  for (var i = 0; i < bySeverity.length; i++) {
+    if (bySeverity[i].severity) {
+      result.push(decorate(indent + bySeverity[i].severity + ':', palette.faint))
+    }
+    for (var j = 0; j < bySeverity[i].checks.length; j++) {
+      result.push(summarizeCheck(indent, bySeverity[i].checks[j], decorate))
+    }
   }
   if (group.checks.length > 0) {
     result.push('')
   }
   for (var i = 0; i < group.groups.length; i++) {
-    Array.prototype.push.apply(result, summarizeGroup(indent, group.groups[i], decorate))
+    Array.prototype.push.apply(result, summarizeGroup(indent, group.groups[i], decorate, options))
   }
 
   return result
@@ -401,7 +466,7 @@ function generateTextSummary(data, options) {
 
   Array.prototype.push.apply(
     lines,
-    summarizeGroup(mergedOpts.indent + '    ', data.root_group, decorate)
+    summarizeGroup(mergedOpts.indent + '    ', data.root_group, decorate, mergedOpts)
   )
 
   Array.prototype.push.apply(lines, summarizeMetrics(mergedOpts, data, decorate))
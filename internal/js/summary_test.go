@@ -19,7 +19,8 @@ import (
 )
 
 const (
-	checksOut = "     █ child\n\n" +
+	checksOut = "     █ child\n" +
+		"       ↳  checks: 75% — ✓ 45 / ✗ 15\n\n" +
 		"       ✓ check1\n" +
 		"       ✗ check3\n        ↳  66% — ✓ 10 / ✗ 5\n" +
 		"       ✗ check2\n        ↳  33% — ✓ 5 / ✗ 10\n\n" +
@@ -133,6 +134,119 @@ func TestTextSummaryWithSubMetrics(t *testing.T) {
 	assert.Equal(t, "\n"+expected+"\n", string(summaryOut))
 }
 
+func TestTextSummaryWithGroupTree(t *testing.T) {
+	t.Parallel()
+
+	rootG, err := lib.NewGroup("", nil)
+	require.NoError(t, err)
+	loginG, err := rootG.Group("login")
+	require.NoError(t, err)
+	loginG.AddDuration(100)
+	loginG.AddDuration(200)
+	submitG, err := loginG.Group("submit")
+	require.NoError(t, err)
+	submitG.AddDuration(50)
+	check, err := submitG.Check("ok")
+	require.NoError(t, err)
+	check.Passes = 3
+	check.Fails = 1
+
+	summary := &lib.Summary{
+		Metrics:         map[string]*metrics.Metric{},
+		RootGroup:       rootG,
+		TestRunDuration: time.Second,
+	}
+
+	runner, err := getSimpleRunner(
+		t,
+		"/script.js",
+		`
+		exports.options = {summaryTrendStats: ["avg", "max", "count"]};
+		exports.default = function() {/* we don't run this, metrics are mocked */};
+		`,
+		lib.RuntimeOptions{CompatibilityMode: null.NewString("base", true)},
+	)
+	require.NoError(t, err)
+
+	result, err := runner.HandleSummary(context.Background(), summary)
+	require.NoError(t, err)
+
+	require.Len(t, result, 1)
+	stdout := result["stdout"]
+	require.NotNil(t, stdout)
+
+	summaryOut, err := io.ReadAll(stdout)
+	require.NoError(t, err)
+
+	expected := "     █ login\n" +
+		"       ↳  checks: 75% — ✓ 3 / ✗ 1\n" +
+		"       ↳  duration: avg=150ms max=200ms count=2\n\n" +
+		"       █ submit\n" +
+		"         ↳  checks: 75% — ✓ 3 / ✗ 1\n" +
+		"         ↳  duration: avg=50ms max=50ms count=1\n\n" +
+		"         ✗ ok\n" +
+		"          ↳  75% — ✓ 3 / ✗ 1\n\n"
+	assert.Equal(t, "\n"+expected+"\n", string(summaryOut))
+}
+
+func TestTextSummaryWithCheckSeverities(t *testing.T) {
+	t.Parallel()
+
+	rootG, err := lib.NewGroup("", nil)
+	require.NoError(t, err)
+	for _, c := range []struct {
+		name, severity string
+		passes, fails  int64
+	}{
+		{name: "cached", severity: lib.CheckSeverityInfo, passes: 1, fails: 3},
+		{name: "no severity", passes: 4},
+		{name: "status is 200", severity: lib.CheckSeverityCritical, passes: 4},
+		{name: "fast enough", severity: lib.CheckSeverityWarn, passes: 2, fails: 2},
+		{name: "body is valid", severity: lib.CheckSeverityCritical, passes: 4},
+	} {
+		check, err := rootG.Check(c.name)
+		require.NoError(t, err)
+		check.Severity, check.Passes, check.Fails = c.severity, c.passes, c.fails
+	}
+
+	summary := &lib.Summary{
+		Metrics:         map[string]*metrics.Metric{},
+		RootGroup:       rootG,
+		TestRunDuration: time.Second,
+	}
+
+	runner, err := getSimpleRunner(
+		t,
+		"/script.js",
+		"exports.default = function() {/* we don't run this, metrics are mocked */};",
+		lib.RuntimeOptions{CompatibilityMode: null.NewString("base", true)},
+	)
+	require.NoError(t, err)
+
+	result, err := runner.HandleSummary(context.Background(), summary)
+	require.NoError(t, err)
+
+	require.Len(t, result, 1)
+	stdout := result["stdout"]
+	require.NotNil(t, stdout)
+
+	summaryOut, err := io.ReadAll(stdout)
+	require.NoError(t, err)
+
+	expected := "     critical:\n" +
+		"     ✓ status is 200\n" +
+		"     ✓ body is valid\n" +
+		"     warn:\n" +
+		"     ✗ fast enough\n" +
+		"      ↳  50% — ✓ 2 / ✗ 2\n" +
+		"     info:\n" +
+		"     ✗ cached\n" +
+		"      ↳  25% — ✓ 1 / ✗ 3\n" +
+		"     other:\n" +
+		"     ✓ no severity\n\n"
+	assert.Equal(t, "\n"+expected+"\n", string(summaryOut))
+}
+
 func createTestMetrics(t *testing.T) (map[string]*metrics.Metric, *lib.Group) {
 	registry := metrics.NewRegistry()
 	testMetrics := make(map[string]*metrics.Metric)
@@ -225,6 +339,8 @@ const expectedOldJSONExportResult = `{
                 "path": "::child",
                 "id": "f41cbb53a398ec1c9fb3d33e20c9b040",
                 "groups": {},
+                "passes": 45,
+                "fails": 15,
                 "checks": {
                     "check1": {
                         "name": "check1",
@@ -250,7 +366,9 @@ const expectedOldJSONExportResult = `{
                 }
             }
         },
-        "checks": {}
+        "checks": {},
+        "passes": 45,
+        "fails": 15
     },
     "metrics": {
         "checks": {
@@ -330,6 +448,8 @@ const expectedHandleSummaryRawData = `
                 "path": "::child",
                 "id": "f41cbb53a398ec1c9fb3d33e20c9b040",
                 "groups": [],
+                "passes": 45,
+                "fails": 15,
                 "checks": [
                         {
                             "id": "6289a7a06253a1c3f6137dfb25695563",
@@ -356,6 +476,8 @@ const expectedHandleSummaryRawData = `
             }
         ],
         "checks": [],
+        "passes": 45,
+        "fails": 15,
         "name": "",
         "path": "",
         "id": "d41d8cd98f00b204e9800998ecf8427e"
@@ -447,6 +569,8 @@ const expectedHandleSummaryDataWithSetup = `
                 "path": "::child",
                 "id": "f41cbb53a398ec1c9fb3d33e20c9b040",
                 "groups": [],
+                "passes": 45,
+                "fails": 15,
                 "checks": [
                         {
                             "id": "6289a7a06253a1c3f6137dfb25695563",
@@ -473,6 +597,8 @@ const expectedHandleSummaryDataWithSetup = `
             }
         ],
         "checks": [],
+        "passes": 45,
+        "fails": 15,
         "name": "",
         "path": "",
         "id": "d41d8cd98f00b204e9800998ecf8427e"
@@ -593,6 +719,142 @@ func TestRawHandleSummaryData(t *testing.T) {
 	assert.JSONEq(t, expectedHandleSummaryRawData, string(newRawData))
 }
 
+func TestRawHandleSummaryDataWithScenariosAndThresholdEvaluations(t *testing.T) {
+	t.Parallel()
+	runner, err := getSimpleRunner(
+		t, "/script.js",
+		`
+		exports.options = {summaryTrendStats: ["avg", "min", "med", "max", "p(90)", "p(95)", "p(99)", "count"]};
+		exports.default = function() { /* we don't run this, metrics are mocked */ };
+		exports.handleSummary = function(data) {
+			return {
+				'scenarios.json': JSON.stringify(data.scenarios),
+				'thresholds.json': JSON.stringify(data.metrics.http_reqs.thresholds),
+				'startTime.txt': data.state.startTime,
+			};
+		};
+		`,
+		lib.RuntimeOptions{
+			CompatibilityMode: null.NewString("base", true),
+			SummaryExport:     null.StringFrom("old-export.json"),
+		},
+	)
+	require.NoError(t, err)
+
+	summary := createTestSummary(t)
+	summary.StartTime = time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	summary.Metrics["http_reqs"].Thresholds.Thresholds[0].Evaluations = []metrics.ThresholdEvaluation{
+		{Time: 2 * time.Second, Value: 50, OK: true},
+		{Time: 4 * time.Second, Value: 150, OK: false},
+	}
+	reqs := &metrics.Metric{Name: "http_reqs", Type: metrics.Counter, Sink: metrics.NewSink(metrics.Counter)}
+	reqs.Sink.Add(metrics.Sample{Value: 2})
+	summary.Scenarios = map[string]lib.ScenarioSummary{
+		"browse": {Executor: "ramping-vus", StartTime: 500 * time.Millisecond, Metrics: map[string]*metrics.Metric{
+			"http_reqs": reqs,
+		}},
+		"idle": {Executor: "shared-iterations"},
+	}
+
+	result, err := runner.HandleSummary(context.Background(), summary)
+	require.NoError(t, err)
+	require.Len(t, result, 4)
+
+	read := func(name string) string {
+		require.NotNil(t, result[name])
+		data, err := io.ReadAll(result[name])
+		require.NoError(t, err)
+		return string(data)
+	}
+	assert.JSONEq(t, expectedOldJSONExportResult, read("old-export.json"))
+	assert.Equal(t, "2026-10-17T12:00:00Z", read("startTime.txt"))
+	assert.JSONEq(t, `{
+		"rate<100": {"ok": false, "value": 150, "evaluations": [
+			{"timeMs": 2000, "value": 50, "ok": true},
+			{"timeMs": 4000, "value": 150, "ok": false}
+		]}
+	}`, read("thresholds.json"))
+	assert.JSONEq(t, `{
+		"browse": {"executor": "ramping-vus", "startTimeMs": 500, "metrics": {
+			"http_reqs": {"type": "counter", "contains": "default", "values": {"count": 2, "rate": 2}}
+		}},
+		"idle": {"executor": "shared-iterations", "startTimeMs": 0, "metrics": {}}
+	}`, read("scenarios.json"))
+}
+
+func TestOldJSONExportWithTimeBuckets(t *testing.T) {
+	t.Parallel()
+	runner, err := getSimpleRunner(
+		t, "/script.js",
+		`
+		exports.options = {summaryTrendStats: ["avg", "max"]};
+		exports.default = function() { /* we don't run this, metrics are mocked */ };
+		exports.handleSummary = function(data) {
+			return {'timeBuckets.json': JSON.stringify(data.timeBuckets)};
+		};
+		`,
+		lib.RuntimeOptions{
+			CompatibilityMode: null.NewString("base", true),
+			SummaryExport:     null.StringFrom("export.json"),
+		},
+	)
+	require.NoError(t, err)
+
+	bucketMetrics := func(durations []float64, failed bool) map[string]*metrics.Metric {
+		duration := &metrics.Metric{Type: metrics.Trend, Contains: metrics.Time, Sink: metrics.NewSink(metrics.Trend)}
+		for _, d := range durations {
+			duration.Sink.Add(metrics.Sample{Value: d})
+		}
+		failures := &metrics.Metric{Type: metrics.Rate, Sink: metrics.NewSink(metrics.Rate)}
+		failures.Sink.Add(metrics.Sample{Value: 0})
+		if failed {
+			failures.Sink.Add(metrics.Sample{Value: 1})
+		}
+		return map[string]*metrics.Metric{"http_req_duration": duration, "http_req_failed": failures}
+	}
+	start := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	summary := createTestSummary(t)
+	summary.TimeBuckets = []lib.TimeBucketSummary{
+		{StartTime: start, Duration: time.Minute, Metrics: bucketMetrics([]float64{100, 200}, false)},
+		{StartTime: start.Add(time.Minute), Duration: time.Minute, Metrics: bucketMetrics([]float64{900}, true)},
+	}
+
+	result, err := runner.HandleSummary(context.Background(), summary)
+	require.NoError(t, err)
+
+	read := func(name string) string {
+		require.NotNil(t, result[name])
+		data, err := io.ReadAll(result[name])
+		require.NoError(t, err)
+		return string(data)
+	}
+	assert.JSONEq(t, `[
+		{"startTime": "2026-10-17T12:00:00Z", "durationMs": 60000, "metrics": {
+			"http_req_duration": {"type": "trend", "contains": "time", "values": {"avg": 150, "max": 200}},
+			"http_req_failed": {"type": "rate", "contains": "default",
+				"values": {"rate": 0, "passes": 0, "fails": 1}}
+		}},
+		{"startTime": "2026-10-17T12:01:00Z", "durationMs": 60000, "metrics": {
+			"http_req_duration": {"type": "trend", "contains": "time", "values": {"avg": 900, "max": 900}},
+			"http_req_failed": {"type": "rate", "contains": "default",
+				"values": {"rate": 0.5, "passes": 1, "fails": 1}}
+		}}
+	]`, read("timeBuckets.json"))
+
+	var export struct {
+		TimeBuckets []struct {
+			StartTime string                        `json:"startTime"`
+			Metrics   map[string]map[string]float64 `json:"metrics"`
+		} `json:"timeBuckets"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(read("export.json")), &export))
+	require.Len(t, export.TimeBuckets, 2)
+	assert.Equal(t, "2026-10-17T12:01:00Z", export.TimeBuckets[1].StartTime)
+	assert.Equal(t, map[string]float64{"avg": 900, "max": 900}, export.TimeBuckets[1].Metrics["http_req_duration"])
+	assert.Equal(t, map[string]float64{"value": 0.5, "passes": 1, "fails": 1},
+		export.TimeBuckets[1].Metrics["http_req_failed"])
+}
+
 func TestRawHandleSummaryDataWithSetupData(t *testing.T) {
 	t.Parallel()
 	runner, err := getSimpleRunner(
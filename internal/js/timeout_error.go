@@ -41,6 +41,8 @@ func (t timeoutError) Hint() string {
 		hint = "You can increase the time limit via the setupTimeout option"
 	case consts.TeardownFn:
 		hint = "You can increase the time limit via the teardownTimeout option"
+	case consts.OnAbortFn:
+		hint = "You can increase the time limit via the onAbortTimeout option"
 	}
 	return hint
 }
@@ -0,0 +1,125 @@
+// Package clusterstore implements the lib.ClusterStore of the clusterStore
+// option, which is either in memory, for a single instance, or in Redis, for
+// the tests, whose instances share the counters and the token buckets.
+package clusterstore
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"go.k6.io/k6/lib"
+)
+
+// defaultKeyPrefix is the prefix of the Redis keys, unless the prefix query
+// parameter of the URL overrides it.
+const defaultKeyPrefix = "k6:cluster:"
+
+// New returns the store with the URL, or an in-memory store if it's empty.
+func New(rawURL string) (lib.ClusterStore, error) {
+	if rawURL == "" {
+		return lib.NewMemoryClusterStore(), nil
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid clusterStore URL: %w", err)
+	}
+	switch u.Scheme {
+	case "redis", "rediss":
+		return newRedisStore(u)
+	default:
+		return nil, fmt.Errorf("the clusterStore URL must be a redis:// or rediss:// URL, but it was %q", u.Redacted())
+	}
+}
+
+// The scripts make the changes atomic, so the instances can't race each other.
+var (
+	addToCounterScript = redis.NewScript(`
+local value = tonumber(redis.call("GET", KEYS[1]) or "0")
+local delta = tonumber(ARGV[1])
+if delta > 0 and value > tonumber(ARGV[2]) - delta then
+	return {value, 0}
+end
+return {redis.call("INCRBY", KEYS[1], delta), 1}
+`)
+
+	takeTokensScript = redis.NewScript(`
+local rate, burst, tokens = tonumber(ARGV[1]), tonumber(ARGV[2]), tonumber(ARGV[3])
+local time = redis.call("TIME")
+local now = tonumber(time[1]) + tonumber(time[2]) / 1000000
+local bucket = redis.call("HMGET", KEYS[1], "tokens", "updated_at")
+local available = tonumber(bucket[1]) or burst
+local updatedAt = tonumber(bucket[2]) or now
+available = math.min(burst, available + (now - updatedAt) * rate)
+local wait = 0
+if available >= tokens then
+	available = available - tokens
+else
+	wait = (tokens - available) / rate
+end
+redis.call("HSET", KEYS[1], "tokens", tostring(available), "updated_at", tostring(now))
+return tostring(wait)
+`)
+)
+
+type redisStore struct {
+	client *redis.Client
+	prefix string
+}
+
+func newRedisStore(u *url.URL) (*redisStore, error) {
+	prefix := defaultKeyPrefix
+	query := u.Query()
+	if query.Has("prefix") {
+		prefix = query.Get("prefix")
+		query.Del("prefix")
+		u.RawQuery = query.Encode()
+	}
+	opts, err := redis.ParseURL(u.String())
+	if err != nil {
+		return nil, fmt.Errorf("invalid clusterStore URL: %w", err)
+	}
+	return &redisStore{client: redis.NewClient(opts), prefix: prefix}, nil
+}
+
+func (s *redisStore) AddToCounter(ctx context.Context, name string, delta, limit int64) (int64, bool, error) {
+	result, err := addToCounterScript.Run(ctx, s.client, []string{s.prefix + "counter:" + name}, delta, limit).
+		Int64Slice()
+	if err != nil {
+		return 0, false, fmt.Errorf("adding to the counter %q: %w", name, err)
+	}
+	if len(result) != 2 {
+		return 0, false, fmt.Errorf("adding to the counter %q: unexpected result %v", name, result)
+	}
+	return result[0], result[1] == 1, nil
+}
+
+func (s *redisStore) Counter(ctx context.Context, name string) (int64, error) {
+	value, err := s.client.Get(ctx, s.prefix+"counter:"+name).Int64()
+	if err == redis.Nil { //nolint:errorlint
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("getting the counter %q: %w", name, err)
+	}
+	return value, nil
+}
+
+func (s *redisStore) TakeTokens(
+	ctx context.Context, name string, bucket lib.TokenBucket, tokens float64,
+) (bool, time.Duration, error) {
+	result, err := takeTokensScript.Run(ctx, s.client, []string{s.prefix + "bucket:" + name},
+		bucket.Rate, bucket.Burst, tokens).Text()
+	if err != nil {
+		return false, 0, fmt.Errorf("taking the tokens of the rate limiter %q: %w", name, err)
+	}
+	wait, err := strconv.ParseFloat(result, 64)
+	if err != nil {
+		return false, 0, fmt.Errorf("taking the tokens of the rate limiter %q: %w", name, err)
+	}
+	return wait == 0, time.Duration(wait * float64(time.Second)), nil
+}
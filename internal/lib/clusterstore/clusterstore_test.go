@@ -0,0 +1,33 @@
+package clusterstore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/lib"
+)
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	store, err := New("")
+	require.NoError(t, err)
+	assert.IsType(t, &lib.MemoryClusterStore{}, store)
+
+	store, err = New("redis://:secret@localhost:6379/2?prefix=run-1:&dial_timeout=1s")
+	require.NoError(t, err)
+	rs, ok := store.(*redisStore)
+	require.True(t, ok)
+	assert.Equal(t, "run-1:", rs.prefix)
+	assert.Equal(t, 2, rs.client.Options().DB)
+	assert.Equal(t, "secret", rs.client.Options().Password)
+
+	store, err = New("redis://localhost:6379")
+	require.NoError(t, err)
+	assert.Equal(t, defaultKeyPrefix, store.(*redisStore).prefix) //nolint:forcetypeassert
+
+	_, err = New("memcached://localhost:11211")
+	assert.ErrorContains(t, err, `the clusterStore URL must be a redis:// or rediss:// URL, but it was "memcached://localhost:11211"`)
+}
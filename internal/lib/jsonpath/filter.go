@@ -0,0 +1,232 @@
+package jsonpath
+
+import (
+	"reflect"
+	"regexp"
+	"unicode/utf8"
+)
+
+// logicalExpr is an expression of a filter, which is tested with the current
+// value, i.e. `@`, and the root of the document, i.e. `$`.
+type logicalExpr interface {
+	test(current, root interface{}) bool
+}
+
+// comparable is an operand of the comparisons, whose value may be Nothing,
+// e.g. for queries, which don't select anything.
+type comparable interface {
+	value(current, root interface{}) (v interface{}, ok bool)
+}
+
+type orExpr []logicalExpr
+
+func (e orExpr) test(current, root interface{}) bool {
+	for _, expr := range e {
+		if expr.test(current, root) {
+			return true
+		}
+	}
+	return false
+}
+
+type andExpr []logicalExpr
+
+func (e andExpr) test(current, root interface{}) bool {
+	for _, expr := range e {
+		if !expr.test(current, root) {
+			return false
+		}
+	}
+	return true
+}
+
+type notExpr struct {
+	expr logicalExpr
+}
+
+func (e notExpr) test(current, root interface{}) bool {
+	return !e.expr.test(current, root)
+}
+
+// filterQuery is a query of a filter, which is relative to the current value,
+// if it starts with `@`, or to the root of the document, if it starts with `$`.
+type filterQuery struct {
+	relative bool
+	segments []segment
+}
+
+func (q *filterQuery) nodes(current, root interface{}) []interface{} {
+	if q.relative {
+		return selectSegments(q.segments, current, root)
+	}
+	return selectSegments(q.segments, root, root)
+}
+
+// test reports whether the query selects anything.
+func (q *filterQuery) test(current, root interface{}) bool {
+	return len(q.nodes(current, root)) > 0
+}
+
+// value returns the value, which a singular query selects, if any.
+func (q *filterQuery) value(current, root interface{}) (interface{}, bool) {
+	nodes := q.nodes(current, root)
+	if len(nodes) != 1 {
+		return nil, false
+	}
+	return nodes[0], true
+}
+
+type literal struct {
+	v interface{}
+}
+
+func (l literal) value(_, _ interface{}) (interface{}, bool) {
+	return l.v, true
+}
+
+type comparisonExpr struct {
+	op          string
+	left, right comparable
+}
+
+func (e comparisonExpr) test(current, root interface{}) bool {
+	left, leftOK := e.left.value(current, root)
+	right, rightOK := e.right.value(current, root)
+	equal := func() bool {
+		if !leftOK || !rightOK {
+			return leftOK == rightOK
+		}
+		return reflect.DeepEqual(left, right)
+	}
+	less := func(a, b interface{}) bool {
+		if !leftOK || !rightOK {
+			return false
+		}
+		switch a := a.(type) {
+		case float64:
+			b, ok := b.(float64)
+			return ok && a < b
+		case string:
+			b, ok := b.(string)
+			return ok && a < b
+		default:
+			return false
+		}
+	}
+
+	switch e.op {
+	case "==":
+		return equal()
+	case "!=":
+		return !equal()
+	case "<":
+		return less(left, right)
+	case ">":
+		return less(right, left)
+	case "<=":
+		return less(left, right) || equal()
+	case ">=":
+		return less(right, left) || equal()
+	default:
+		return false
+	}
+}
+
+// The types of the parameters and the results of the functions.
+type funcType int
+
+const (
+	valueType funcType = iota
+	logicalType
+	nodesType
+)
+
+type funcSignature struct {
+	params []funcType
+	result funcType
+}
+
+var functions = map[string]funcSignature{
+	"length": {params: []funcType{valueType}, result: valueType},
+	"count":  {params: []funcType{nodesType}, result: valueType},
+	"match":  {params: []funcType{valueType, valueType}, result: logicalType},
+	"search": {params: []funcType{valueType, valueType}, result: logicalType},
+	"value":  {params: []funcType{nodesType}, result: valueType},
+}
+
+// funcArg is an argument of a function, which is either a comparable, for the
+// parameters of the value type, or a query, for the ones of the nodes type.
+type funcArg struct {
+	value comparable
+	query *filterQuery
+}
+
+type funcExpr struct {
+	name string
+	args []funcArg
+	// the regular expression of match() or search(), if it's a literal
+	re *regexp.Regexp
+}
+
+func (f *funcExpr) value(current, root interface{}) (interface{}, bool) {
+	switch f.name {
+	case "length":
+		v, ok := f.args[0].value.value(current, root)
+		if !ok {
+			return nil, false
+		}
+		switch v := v.(type) {
+		case string:
+			return float64(utf8.RuneCountInString(v)), true
+		case []interface{}:
+			return float64(len(v)), true
+		case map[string]interface{}:
+			return float64(len(v)), true
+		default:
+			return nil, false
+		}
+	case "count":
+		return float64(len(f.args[0].query.nodes(current, root))), true
+	case "value":
+		return f.args[0].query.value(current, root)
+	default:
+		return nil, false
+	}
+}
+
+func (f *funcExpr) test(current, root interface{}) bool {
+	v, ok := f.args[0].value.value(current, root)
+	if !ok {
+		return false
+	}
+	s, ok := v.(string)
+	if !ok {
+		return false
+	}
+
+	re := f.re
+	if re == nil {
+		pattern, ok := f.args[1].value.value(current, root)
+		if !ok {
+			return false
+		}
+		patternStr, ok := pattern.(string)
+		if !ok {
+			return false
+		}
+		var err error
+		if re, err = compileRegexp(f.name, patternStr); err != nil {
+			return false
+		}
+	}
+	return re.MatchString(s)
+}
+
+// compileRegexp compiles the pattern of match(), which matches whole strings,
+// or of search(), which matches any of their substrings.
+func compileRegexp(name, pattern string) (*regexp.Regexp, error) {
+	if name == "match" {
+		pattern = `\A(?:` + pattern + `)\z`
+	}
+	return regexp.Compile(pattern)
+}
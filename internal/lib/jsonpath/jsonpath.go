@@ -0,0 +1,233 @@
+// Package jsonpath implements the JSONPath queries of RFC 9535, which select
+// values of JSON documents, with names, indexes, wildcards, slices, descendants
+// and filters.
+//
+// The documents are expected to be decoded by encoding/json into interface{},
+// i.e. to consist of map[string]interface{}, []interface{}, string, float64,
+// bool and nil values.
+package jsonpath
+
+import (
+	"sort"
+	"strings"
+)
+
+// Path is a compiled JSONPath query.
+type Path struct {
+	query    string
+	segments []segment
+}
+
+// IsQuery reports whether the expression looks like a JSONPath query, i.e.
+// whether it's the root identifier followed by the end of the expression or
+// by a segment.
+func IsQuery(expr string) bool {
+	return expr == "$" || strings.HasPrefix(expr, "$.") || strings.HasPrefix(expr, "$[")
+}
+
+// Compile parses the JSONPath query.
+func Compile(query string) (*Path, error) {
+	p := &parser{query: query}
+	if !p.consume("$") {
+		return nil, p.errorf("the query must start with '$'")
+	}
+	segments, err := p.parseSegments()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if !p.done() {
+		return nil, p.errorf("unexpected %q", p.query[p.pos])
+	}
+	return &Path{query: query, segments: segments}, nil
+}
+
+// String returns the query.
+func (p *Path) String() string {
+	return p.query
+}
+
+// IsSingular reports whether the query selects at most one value, because it
+// only has name and index selectors.
+func (p *Path) IsSingular() bool {
+	return isSingular(p.segments)
+}
+
+// Select returns the values of the document, which are selected by the query,
+// in the order they're selected. The members of the objects are visited in the
+// order of their names.
+func (p *Path) Select(doc interface{}) []interface{} {
+	return selectSegments(p.segments, doc, doc)
+}
+
+type segment struct {
+	descendant bool
+	selectors  []selector
+}
+
+type selector interface {
+	// selectFrom appends the children of the value, which are selected, to
+	// the nodes.
+	selectFrom(value, root interface{}, nodes []interface{}) []interface{}
+}
+
+func isSingular(segments []segment) bool {
+	for _, seg := range segments {
+		if seg.descendant || len(seg.selectors) != 1 {
+			return false
+		}
+		switch seg.selectors[0].(type) {
+		case nameSelector, indexSelector:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func selectSegments(segments []segment, value, root interface{}) []interface{} {
+	nodes := []interface{}{value}
+	for _, seg := range segments {
+		var next []interface{}
+		for _, node := range nodes {
+			next = seg.selectFrom(node, root, next)
+		}
+		nodes = next
+	}
+	return nodes
+}
+
+func (seg segment) selectFrom(value, root interface{}, nodes []interface{}) []interface{} {
+	for _, sel := range seg.selectors {
+		nodes = sel.selectFrom(value, root, nodes)
+	}
+	if seg.descendant {
+		for _, child := range children(value) {
+			nodes = seg.selectFrom(child, root, nodes)
+		}
+	}
+	return nodes
+}
+
+// children returns the elements of an array or the member values of an object,
+// in the order of their names, or nothing for the rest of the values.
+func children(value interface{}) []interface{} {
+	switch value := value.(type) {
+	case []interface{}:
+		return value
+	case map[string]interface{}:
+		result := make([]interface{}, 0, len(value))
+		for _, name := range sortedNames(value) {
+			result = append(result, value[name])
+		}
+		return result
+	default:
+		return nil
+	}
+}
+
+func sortedNames(obj map[string]interface{}) []string {
+	names := make([]string, 0, len(obj))
+	for name := range obj {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+type nameSelector string
+
+func (s nameSelector) selectFrom(value, _ interface{}, nodes []interface{}) []interface{} {
+	if obj, ok := value.(map[string]interface{}); ok {
+		if member, ok := obj[string(s)]; ok {
+			nodes = append(nodes, member)
+		}
+	}
+	return nodes
+}
+
+type wildcardSelector struct{}
+
+func (wildcardSelector) selectFrom(value, _ interface{}, nodes []interface{}) []interface{} {
+	return append(nodes, children(value)...)
+}
+
+type indexSelector int
+
+func (s indexSelector) selectFrom(value, _ interface{}, nodes []interface{}) []interface{} {
+	arr, ok := value.([]interface{})
+	if !ok {
+		return nodes
+	}
+	i := int(s)
+	if i < 0 {
+		i += len(arr)
+	}
+	if i >= 0 && i < len(arr) {
+		nodes = append(nodes, arr[i])
+	}
+	return nodes
+}
+
+type sliceSelector struct {
+	start, end       int
+	hasStart, hasEnd bool
+	step             int
+}
+
+func (s sliceSelector) selectFrom(value, _ interface{}, nodes []interface{}) []interface{} {
+	arr, ok := value.([]interface{})
+	if !ok || s.step == 0 {
+		return nodes
+	}
+	length := len(arr)
+	normalize := func(i int) int {
+		if i < 0 {
+			return length + i
+		}
+		return i
+	}
+
+	if s.step > 0 {
+		start, end := 0, length
+		if s.hasStart {
+			start = clamp(normalize(s.start), 0, length)
+		}
+		if s.hasEnd {
+			end = clamp(normalize(s.end), 0, length)
+		}
+		for i := start; i < end; i += s.step {
+			nodes = append(nodes, arr[i])
+		}
+		return nodes
+	}
+
+	start, end := length-1, -1
+	if s.hasStart {
+		start = clamp(normalize(s.start), -1, length-1)
+	}
+	if s.hasEnd {
+		end = clamp(normalize(s.end), -1, length-1)
+	}
+	for i := start; i > end; i += s.step {
+		nodes = append(nodes, arr[i])
+	}
+	return nodes
+}
+
+func clamp(i, lower, upper int) int {
+	return min(max(i, lower), upper)
+}
+
+type filterSelector struct {
+	expr logicalExpr
+}
+
+func (s filterSelector) selectFrom(value, root interface{}, nodes []interface{}) []interface{} {
+	for _, child := range children(value) {
+		if s.expr.test(child, root) {
+			nodes = append(nodes, child)
+		}
+	}
+	return nodes
+}
@@ -0,0 +1,169 @@
+package jsonpath
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testDoc = `{
+	"store": {
+		"book": [
+			{"category": "reference", "author": "Nigel Rees", "title": "Sayings of the Century", "price": 8.95},
+			{"category": "fiction", "author": "Evelyn Waugh", "title": "Sword of Honour", "price": 12.99},
+			{"category": "fiction", "author": "Herman Melville", "title": "Moby Dick", "isbn": "0-553-21311-3", "price": 8.99},
+			{"category": "fiction", "author": "J. R. R. Tolkien", "title": "The Lord of the Rings", "isbn": "0-395-19395-8", "price": 22.99}
+		],
+		"bicycle": {"color": "red", "price": 399}
+	},
+	"limit": 10,
+	"letters": ["a", "b", "c", "d", "e", "f", "g"],
+	"o": {"j j": {"k.k": 3}, "'": 4, "\"": 5},
+	"empty": {}
+}`
+
+func TestSelect(t *testing.T) {
+	t.Parallel()
+
+	var doc interface{}
+	require.NoError(t, json.Unmarshal([]byte(testDoc), &doc))
+
+	testCases := []struct {
+		query    string
+		expected string
+		singular bool
+	}{
+		{query: `$`, expected: `[` + testDoc + `]`, singular: true},
+		{query: `$.limit`, expected: `[10]`, singular: true},
+		{query: `$.missing`, expected: `[]`, singular: true},
+		{query: `$.store.book[0].title`, expected: `["Sayings of the Century"]`, singular: true},
+		{query: `$['store']["book"][-1].author`, expected: `["J. R. R. Tolkien"]`, singular: true},
+		{query: `$.store.book[4]`, expected: `[]`, singular: true},
+		{query: `$.store.book[*].author`, expected: `["Nigel Rees","Evelyn Waugh","Herman Melville","J. R. R. Tolkien"]`},
+		{query: `$..author`, expected: `["Nigel Rees","Evelyn Waugh","Herman Melville","J. R. R. Tolkien"]`},
+		{query: `$.store.*`, expected: `[{"color":"red","price":399},` +
+			`[{"category":"reference","author":"Nigel Rees","title":"Sayings of the Century","price":8.95},` +
+			`{"category":"fiction","author":"Evelyn Waugh","title":"Sword of Honour","price":12.99},` +
+			`{"category":"fiction","author":"Herman Melville","title":"Moby Dick","isbn":"0-553-21311-3","price":8.99},` +
+			`{"category":"fiction","author":"J. R. R. Tolkien","title":"The Lord of the Rings","isbn":"0-395-19395-8",` +
+			`"price":22.99}]]`},
+		{query: `$.store..price`, expected: `[399,8.95,12.99,8.99,22.99]`},
+		{query: `$..book[2].title`, expected: `["Moby Dick"]`},
+		{query: `$..book[-1:].title`, expected: `["The Lord of the Rings"]`},
+		{query: `$..book[0,1].title`, expected: `["Sayings of the Century","Sword of Honour"]`},
+		{query: `$..book[:2].title`, expected: `["Sayings of the Century","Sword of Honour"]`},
+		{query: `$..book[?@.isbn].title`, expected: `["Moby Dick","The Lord of the Rings"]`},
+		{query: `$..book[?!@.isbn].title`, expected: `["Sayings of the Century","Sword of Honour"]`},
+		{query: `$..book[?@.price<10].title`, expected: `["Sayings of the Century","Moby Dick"]`},
+		{query: `$..book[?@.price <= $.limit].title`, expected: `["Sayings of the Century","Moby Dick"]`},
+		{query: `$..book[?(@.price > 10 && @.category == 'fiction')].title`, expected: `["Sword of Honour","The Lord of the Rings"]`},
+		{query: `$..book[?@.price > 20 || @.author == "Nigel Rees"].title`, expected: `["Sayings of the Century","The Lord of the Rings"]`},
+		{query: `$..book[?!(@.category == 'fiction')].title`, expected: `["Sayings of the Century"]`},
+		{query: `$..book[?@.missing == @.other].title`, expected: `["Sayings of the Century","Sword of Honour","Moby Dick","The Lord of the Rings"]`},
+		{query: `$..book[?@.missing != null].title`, expected: `["Sayings of the Century","Sword of Honour","Moby Dick","The Lord of the Rings"]`},
+		{query: `$..book[?length(@.title) > 15].title`, expected: `["Sayings of the Century","The Lord of the Rings"]`},
+		{query: `$..book[?match(@.author, 'H.*')].title`, expected: `["Moby Dick"]`},
+		{query: `$..book[?search(@.author, 'R')].title`, expected: `["Sayings of the Century","The Lord of the Rings"]`},
+		{query: `$.store[?count(@.*) == 2]`, expected: `[{"color":"red","price":399}]`},
+		{query: `$.store.book[?value(@..isbn) == '0-553-21311-3'].title`, expected: `["Moby Dick"]`},
+		{query: `$.store.book[?@.price == 8.95e0].title`, expected: `["Sayings of the Century"]`},
+		{query: `$.letters[1:3]`, expected: `["b","c"]`},
+		{query: `$.letters[5:]`, expected: `["f","g"]`},
+		{query: `$.letters[1:5:2]`, expected: `["b","d"]`},
+		{query: `$.letters[5:1:-2]`, expected: `["f","d"]`},
+		{query: `$.letters[::-1]`, expected: `["g","f","e","d","c","b","a"]`},
+		{query: `$.letters[::0]`, expected: `[]`},
+		{query: `$.letters[-100:100]`, expected: `["a","b","c","d","e","f","g"]`},
+		{query: `$.letters[0, 0, 3:4]`, expected: `["a","a","d"]`},
+		{query: `$.o['j j']['k.k']`, expected: `[3]`, singular: true},
+		{query: `$.o["'"]`, expected: `[4]`, singular: true},
+		{query: `$.o['\'']`, expected: `[4]`, singular: true},
+		{query: `$.o["\""]`, expected: `[5]`, singular: true},
+		{query: `$.o['"']`, expected: `[5]`, singular: true},
+		{query: `$.o['\"']`, expected: ``},
+		{query: `$.empty.*`, expected: `[]`},
+		{query: `$.limit.*`, expected: `[]`},
+		{query: ` $.limit`, expected: ``},
+		{query: `$ .store .bicycle . color`, expected: ``},
+		{query: `$ .store .bicycle .color`, expected: `["red"]`, singular: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.query, func(t *testing.T) {
+			t.Parallel()
+
+			path, err := Compile(tc.query)
+			if tc.expected == "" {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.singular, path.IsSingular())
+
+			var expected []interface{}
+			require.NoError(t, json.Unmarshal([]byte(tc.expected), &expected))
+			actual := path.Select(doc)
+			if actual == nil {
+				actual = []interface{}{}
+			}
+			assert.Equal(t, expected, actual)
+		})
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]string{
+		``:                            `the query must start with '$'`,
+		`store`:                       `the query must start with '$'`,
+		`$.`:                          `expected a member name or '*'`,
+		`$.1a`:                        `expected a member name or '*'`,
+		`$[`:                          `expected a selector`,
+		`$[0`:                         `expected ",", but the query ended`,
+		`$[01]`:                       `the integer "01" can't have leading zeros or be negative zero`,
+		`$[-0]`:                       `the integer "-0" can't have leading zeros or be negative zero`,
+		`$['a]`:                       `the string isn't terminated`,
+		`$['\a']`:                     `invalid escape sequence`,
+		`$.a b`:                       `unexpected 'b'`,
+		`$[?@.a = 1]`:                 `expected ",", but got '='`,
+		`$[?@.* == 1]`:                `only literals, singular queries and functions returning values can be compared`,
+		`$[?1]`:                       `expected a comparison, a query or a function returning a logical value`,
+		`$[?length(@)]`:               `expected a comparison, a query or a function returning a logical value`,
+		`$[?match(@.a, 'a') == true]`: `only literals, singular queries and functions returning values can be compared`,
+		`$[?foo(@)]`:                  `unknown function "foo"`,
+		`$[?count(1) == 1]`:           `the argument of count() must be a query`,
+		`$[?length(@.*) == 1]`:        `the arguments of length() must be literals, singular queries or functions returning values`,
+		`$[?match(@.a, '(')]`:         `invalid regular expression of match()`,
+		`$[?@.a == 01]`:               `invalid number "01"`,
+		`$[?@.a == 1.]`:               `invalid number "1."`,
+	}
+
+	for query, expected := range testCases {
+		t.Run(query, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := Compile(query)
+			require.ErrorContains(t, err, expected)
+		})
+	}
+}
+
+func TestIsQuery(t *testing.T) {
+	t.Parallel()
+
+	for expr, expected := range map[string]bool{
+		`$`:          true,
+		`$.a`:        true,
+		`$..a`:       true,
+		`$[0]`:       true,
+		`$ref`:       false,
+		`a.b`:        false,
+		`friends.#`:  false,
+		`friends.$a`: false,
+	} {
+		assert.Equal(t, expected, IsQuery(expr), expr)
+	}
+}
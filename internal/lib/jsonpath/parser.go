@@ -0,0 +1,623 @@
+package jsonpath
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+type parser struct {
+	query string
+	pos   int
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("invalid JSONPath query %q at position %d: %s", p.query, p.pos, fmt.Sprintf(format, args...))
+}
+
+func (p *parser) done() bool {
+	return p.pos >= len(p.query)
+}
+
+func (p *parser) peek() byte {
+	if p.done() {
+		return 0
+	}
+	return p.query[p.pos]
+}
+
+func (p *parser) hasPrefix(s string) bool {
+	return strings.HasPrefix(p.query[p.pos:], s)
+}
+
+func (p *parser) consume(s string) bool {
+	if !p.hasPrefix(s) {
+		return false
+	}
+	p.pos += len(s)
+	return true
+}
+
+func (p *parser) expect(s string) error {
+	if !p.consume(s) {
+		if p.done() {
+			return p.errorf("expected %q, but the query ended", s)
+		}
+		return p.errorf("expected %q, but got %q", s, p.query[p.pos])
+	}
+	return nil
+}
+
+func (p *parser) skipSpace() {
+	for !p.done() {
+		switch p.query[p.pos] {
+		case ' ', '\t', '\n', '\r':
+			p.pos++
+		default:
+			return
+		}
+	}
+}
+
+// parseSegments parses the segments of a query, until there are no more,
+// leaving the blank space after the last one unparsed.
+func (p *parser) parseSegments() ([]segment, error) {
+	var segments []segment
+	for {
+		start := p.pos
+		p.skipSpace()
+		if c := p.peek(); c != '.' && c != '[' {
+			p.pos = start
+			return segments, nil
+		}
+		seg, err := p.parseSegment()
+		if err != nil {
+			return nil, err
+		}
+		segments = append(segments, seg)
+	}
+}
+
+func (p *parser) parseSegment() (segment, error) {
+	var seg segment
+	switch {
+	case p.consume(".."):
+		seg.descendant = true
+		if p.peek() == '[' {
+			break
+		}
+		sel, err := p.parseShorthand()
+		if err != nil {
+			return seg, err
+		}
+		seg.selectors = []selector{sel}
+		return seg, nil
+	case p.consume("."):
+		sel, err := p.parseShorthand()
+		if err != nil {
+			return seg, err
+		}
+		seg.selectors = []selector{sel}
+		return seg, nil
+	}
+
+	var err error
+	seg.selectors, err = p.parseBracketedSelection()
+	return seg, err
+}
+
+// parseShorthand parses the wildcard or the member name after a dot.
+func (p *parser) parseShorthand() (selector, error) {
+	if p.consume("*") {
+		return wildcardSelector{}, nil
+	}
+	name := p.parseName(false)
+	if name == "" {
+		return nil, p.errorf("expected a member name or '*'")
+	}
+	return nameSelector(name), nil
+}
+
+// parseName parses a member name, which starts with a letter, an underscore or
+// a non-ASCII character, and continues with them or digits. The names of the
+// functions are parsed as well, but they may only have lowercase letters, digits
+// and underscores.
+func (p *parser) parseName(isFunction bool) string {
+	start := p.pos
+	for !p.done() {
+		r, size := utf8.DecodeRuneInString(p.query[p.pos:])
+		isFirst := p.pos == start
+		switch {
+		case r >= 'a' && r <= 'z', r == '_':
+		case r >= '0' && r <= '9' && !isFirst:
+		case !isFunction && (r >= 'A' && r <= 'Z' || r >= utf8.RuneSelf):
+		default:
+			return p.query[start:p.pos]
+		}
+		p.pos += size
+	}
+	return p.query[start:]
+}
+
+func (p *parser) parseBracketedSelection() ([]selector, error) {
+	if err := p.expect("["); err != nil {
+		return nil, err
+	}
+	var selectors []selector
+	for {
+		p.skipSpace()
+		sel, err := p.parseSelector()
+		if err != nil {
+			return nil, err
+		}
+		selectors = append(selectors, sel)
+		p.skipSpace()
+		if p.consume("]") {
+			return selectors, nil
+		}
+		if err := p.expect(","); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (p *parser) parseSelector() (selector, error) {
+	switch c := p.peek(); {
+	case c == '\'' || c == '"':
+		name, err := p.parseString()
+		if err != nil {
+			return nil, err
+		}
+		return nameSelector(name), nil
+	case p.consume("*"):
+		return wildcardSelector{}, nil
+	case p.consume("?"):
+		p.skipSpace()
+		expr, err := p.parseLogicalOr()
+		if err != nil {
+			return nil, err
+		}
+		return filterSelector{expr: expr}, nil
+	default:
+		return p.parseIndexOrSlice()
+	}
+}
+
+func (p *parser) parseIndexOrSlice() (selector, error) {
+	var sel sliceSelector
+	var err error
+	if c := p.peek(); c == '-' || c >= '0' && c <= '9' {
+		if sel.start, err = p.parseInt(); err != nil {
+			return nil, err
+		}
+		sel.hasStart = true
+	}
+	p.skipSpace()
+	if !p.consume(":") {
+		if !sel.hasStart {
+			return nil, p.errorf("expected a selector")
+		}
+		return indexSelector(sel.start), nil
+	}
+
+	p.skipSpace()
+	if c := p.peek(); c == '-' || c >= '0' && c <= '9' {
+		if sel.end, err = p.parseInt(); err != nil {
+			return nil, err
+		}
+		sel.hasEnd = true
+	}
+	p.skipSpace()
+	sel.step = 1
+	if p.consume(":") {
+		p.skipSpace()
+		if c := p.peek(); c == '-' || c >= '0' && c <= '9' {
+			if sel.step, err = p.parseInt(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return sel, nil
+}
+
+func (p *parser) parseInt() (int, error) {
+	start := p.pos
+	p.consume("-")
+	digits := p.pos
+	for c := p.peek(); c >= '0' && c <= '9'; c = p.peek() {
+		p.pos++
+	}
+	s := p.query[start:p.pos]
+	switch {
+	case p.pos == digits:
+		return 0, p.errorf("expected an integer")
+	case p.pos-digits > 1 && p.query[digits] == '0', s == "-0":
+		return 0, p.errorf("the integer %q can't have leading zeros or be negative zero", s)
+	}
+	i, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, p.errorf("the integer %q is out of range", s)
+	}
+	return i, nil
+}
+
+// parseString parses a string literal in single or double quotes, with the
+// escape sequences of JSON strings, as well as \' in the single quoted ones.
+func (p *parser) parseString() (string, error) {
+	quote := p.query[p.pos]
+	p.pos++
+	var sb strings.Builder
+	for {
+		if p.done() {
+			return "", p.errorf("the string isn't terminated")
+		}
+		c := p.query[p.pos]
+		switch {
+		case c == quote:
+			p.pos++
+			return sb.String(), nil
+		case c == '\\':
+			p.pos++
+			if err := p.parseEscape(quote, &sb); err != nil {
+				return "", err
+			}
+		case c < 0x20:
+			return "", p.errorf("the control character %q must be escaped", c)
+		default:
+			sb.WriteByte(c)
+			p.pos++
+		}
+	}
+}
+
+func (p *parser) parseEscape(quote byte, sb *strings.Builder) error {
+	c := p.peek()
+	p.pos++
+	switch c {
+	case 'b':
+		sb.WriteByte('\b')
+	case 'f':
+		sb.WriteByte('\f')
+	case 'n':
+		sb.WriteByte('\n')
+	case 'r':
+		sb.WriteByte('\r')
+	case 't':
+		sb.WriteByte('\t')
+	case '/', '\\':
+		sb.WriteByte(c)
+	case 'u':
+		r, err := p.parseHex4()
+		if err != nil {
+			return err
+		}
+		if utf16.IsSurrogate(r) {
+			if !p.consume(`\u`) {
+				return p.errorf("expected the low surrogate of %U", r)
+			}
+			low, err := p.parseHex4()
+			if err != nil {
+				return err
+			}
+			if r = utf16.DecodeRune(r, low); r == utf8.RuneError {
+				return p.errorf("invalid surrogate pair")
+			}
+		}
+		sb.WriteRune(r)
+	default:
+		if c != quote {
+			p.pos--
+			return p.errorf("invalid escape sequence")
+		}
+		sb.WriteByte(c)
+	}
+	return nil
+}
+
+func (p *parser) parseHex4() (rune, error) {
+	if p.pos+4 > len(p.query) {
+		return 0, p.errorf("expected 4 hexadecimal digits")
+	}
+	v, err := strconv.ParseUint(p.query[p.pos:p.pos+4], 16, 32)
+	if err != nil {
+		return 0, p.errorf("expected 4 hexadecimal digits")
+	}
+	p.pos += 4
+	return rune(v), nil
+}
+
+func (p *parser) parseLogicalOr() (logicalExpr, error) {
+	var exprs orExpr
+	for {
+		expr, err := p.parseLogicalAnd()
+		if err != nil {
+			return nil, err
+		}
+		exprs = append(exprs, expr)
+		p.skipSpace()
+		if !p.consume("||") {
+			break
+		}
+		p.skipSpace()
+	}
+	if len(exprs) == 1 {
+		return exprs[0], nil
+	}
+	return exprs, nil
+}
+
+func (p *parser) parseLogicalAnd() (logicalExpr, error) {
+	var exprs andExpr
+	for {
+		expr, err := p.parseBasicExpr()
+		if err != nil {
+			return nil, err
+		}
+		exprs = append(exprs, expr)
+		p.skipSpace()
+		if !p.consume("&&") {
+			break
+		}
+		p.skipSpace()
+	}
+	if len(exprs) == 1 {
+		return exprs[0], nil
+	}
+	return exprs, nil
+}
+
+func (p *parser) parseBasicExpr() (logicalExpr, error) {
+	if p.consume("!") {
+		p.skipSpace()
+		var expr logicalExpr
+		var err error
+		if p.peek() == '(' {
+			expr, err = p.parseParenExpr()
+		} else {
+			expr, err = p.parseTestExpr()
+		}
+		if err != nil {
+			return nil, err
+		}
+		return notExpr{expr: expr}, nil
+	}
+	if p.peek() == '(' {
+		return p.parseParenExpr()
+	}
+
+	start := p.pos
+	left, isTest, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	afterOperand := p.pos
+	p.skipSpace()
+	op := p.parseComparisonOp()
+	if op == "" {
+		p.pos = afterOperand
+		if isTest != nil {
+			return isTest, nil
+		}
+		p.pos = start
+		return nil, p.errorf("expected a comparison, a query or a function returning a logical value")
+	}
+	if left == nil {
+		p.pos = start
+		return nil, p.errorf("only literals, singular queries and functions returning values can be compared")
+	}
+
+	p.skipSpace()
+	rightStart := p.pos
+	right, _, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	if right == nil {
+		p.pos = rightStart
+		return nil, p.errorf("only literals, singular queries and functions returning values can be compared")
+	}
+	return comparisonExpr{op: op, left: left, right: right}, nil
+}
+
+func (p *parser) parseParenExpr() (logicalExpr, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	expr, err := p.parseLogicalOr()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if err := p.expect(")"); err != nil {
+		return nil, err
+	}
+	return expr, nil
+}
+
+// parseTestExpr parses a query or a function returning a logical value, which
+// can be negated.
+func (p *parser) parseTestExpr() (logicalExpr, error) {
+	start := p.pos
+	_, test, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	if test == nil {
+		p.pos = start
+		return nil, p.errorf("expected a query or a function returning a logical value")
+	}
+	return test, nil
+}
+
+func (p *parser) parseComparisonOp() string {
+	for _, op := range []string{"==", "!=", "<=", ">=", "<", ">"} {
+		if p.consume(op) {
+			return op
+		}
+	}
+	return ""
+}
+
+// parseOperand parses a query, a function or a literal, returning it as a
+// comparable, if it can be compared, and as a logical expression, if it can be
+// tested.
+func (p *parser) parseOperand() (comparable, logicalExpr, error) {
+	switch c := p.peek(); {
+	case c == '@' || c == '$':
+		query, err := p.parseFilterQuery()
+		if err != nil {
+			return nil, nil, err
+		}
+		if isSingular(query.segments) {
+			return query, query, nil
+		}
+		return nil, query, nil
+	case c >= 'a' && c <= 'z':
+		start := p.pos
+		name := p.parseName(true)
+		if p.peek() == '(' {
+			fn, err := p.parseFunction(name)
+			if err != nil {
+				return nil, nil, err
+			}
+			if functions[name].result == logicalType {
+				return nil, fn, nil
+			}
+			return fn, nil, nil
+		}
+		p.pos = start
+	}
+	lit, err := p.parseLiteral()
+	if err != nil {
+		return nil, nil, err
+	}
+	return lit, nil, nil
+}
+
+func (p *parser) parseFilterQuery() (*filterQuery, error) {
+	query := &filterQuery{relative: p.peek() == '@'}
+	p.pos++
+	var err error
+	query.segments, err = p.parseSegments()
+	return query, err
+}
+
+func (p *parser) parseFunction(name string) (*funcExpr, error) {
+	signature, ok := functions[name]
+	if !ok {
+		return nil, p.errorf("unknown function %q", name)
+	}
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+
+	fn := &funcExpr{name: name}
+	for i, param := range signature.params {
+		p.skipSpace()
+		if i > 0 {
+			if err := p.expect(","); err != nil {
+				return nil, err
+			}
+			p.skipSpace()
+		}
+		arg, err := p.parseFuncArg(name, param)
+		if err != nil {
+			return nil, err
+		}
+		fn.args = append(fn.args, arg)
+	}
+	p.skipSpace()
+	if err := p.expect(")"); err != nil {
+		return nil, err
+	}
+
+	if signature.result == logicalType {
+		if pattern, ok := fn.args[1].value.(literal); ok {
+			patternStr, ok := pattern.v.(string)
+			if !ok {
+				return nil, p.errorf("the regular expression of %s() must be a string", name)
+			}
+			re, err := compileRegexp(name, patternStr)
+			if err != nil {
+				return nil, p.errorf("invalid regular expression of %s(): %s", name, err)
+			}
+			fn.re = re
+		}
+	}
+	return fn, nil
+}
+
+func (p *parser) parseFuncArg(name string, param funcType) (funcArg, error) {
+	start := p.pos
+	if param == nodesType {
+		if c := p.peek(); c != '@' && c != '$' {
+			return funcArg{}, p.errorf("the argument of %s() must be a query", name)
+		}
+		query, err := p.parseFilterQuery()
+		return funcArg{query: query}, err
+	}
+
+	value, _, err := p.parseOperand()
+	if err != nil {
+		return funcArg{}, err
+	}
+	if value == nil {
+		p.pos = start
+		return funcArg{}, p.errorf("the arguments of %s() must be literals, singular queries or functions returning values",
+			name)
+	}
+	return funcArg{value: value}, nil
+}
+
+func (p *parser) parseLiteral() (literal, error) {
+	switch c := p.peek(); {
+	case c == '\'' || c == '"':
+		s, err := p.parseString()
+		return literal{v: s}, err
+	case p.consume("true"):
+		return literal{v: true}, nil
+	case p.consume("false"):
+		return literal{v: false}, nil
+	case p.consume("null"):
+		return literal{v: nil}, nil
+	case c == '-' || c >= '0' && c <= '9':
+		return p.parseNumber()
+	default:
+		return literal{}, p.errorf("expected a literal, a query or a function")
+	}
+}
+
+// parseNumber parses a number literal, which has the syntax of the JSON ones.
+func (p *parser) parseNumber() (literal, error) {
+	start := p.pos
+	p.consume("-")
+	digits := func() int {
+		from := p.pos
+		for c := p.peek(); c >= '0' && c <= '9'; c = p.peek() {
+			p.pos++
+		}
+		return p.pos - from
+	}
+	if n := digits(); n == 0 || n > 1 && p.query[p.pos-n] == '0' {
+		return literal{}, p.errorf("invalid number %q", p.query[start:p.pos])
+	}
+	if p.consume(".") && digits() == 0 {
+		return literal{}, p.errorf("invalid number %q", p.query[start:p.pos])
+	}
+	if p.consume("e") || p.consume("E") {
+		if !p.consume("-") {
+			p.consume("+")
+		}
+		if digits() == 0 {
+			return literal{}, p.errorf("invalid number %q", p.query[start:p.pos])
+		}
+	}
+	v, err := strconv.ParseFloat(p.query[start:p.pos], 64)
+	if err != nil {
+		return literal{}, p.errorf("invalid number %q", p.query[start:p.pos])
+	}
+	return literal{v: v}, nil
+}
@@ -17,6 +17,8 @@ import (
 	protov1 "github.com/golang/protobuf/proto" //nolint:staticcheck,nolintlint // this is the old v1 version
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/connectivity"
+	_ "google.golang.org/grpc/encoding/gzip" // registers the gzip compressor
 	"google.golang.org/grpc/metadata"
 	grpcstats "google.golang.org/grpc/stats"
 	"google.golang.org/grpc/status"
@@ -38,6 +40,9 @@ type InvokeRequest struct {
 	DiscardResponseMessage bool
 	Message                []byte
 	Metadata               metadata.MD
+	// Compression is the name of the registered compressor of the messages,
+	// e.g. gzip, or empty if they aren't compressed.
+	Compression string
 }
 
 // InvokeResponse represents a gRPC response.
@@ -57,6 +62,9 @@ type StreamRequest struct {
 	DiscardResponseMessage bool
 	TagsAndMeta            *metrics.TagsAndMeta
 	Metadata               metadata.MD
+	// Compression is the name of the registered compressor of the messages,
+	// e.g. gzip, or empty if they aren't compressed.
+	Compression string
 }
 
 type clientConnCloser interface {
@@ -99,9 +107,11 @@ func Dial(ctx context.Context, addr string, options ...grpc.DialOption) (*Conn,
 }
 
 // Reflect returns using the reflection the FileDescriptorSet describing the service.
-func (c *Conn) Reflect(ctx context.Context) (*descriptorpb.FileDescriptorSet, error) {
+// If services are given, only they are described, instead of all the services
+// listed by the server.
+func (c *Conn) Reflect(ctx context.Context, services ...string) (*descriptorpb.FileDescriptorSet, error) {
 	rc := reflectionClient{Conn: c.raw}
-	return rc.Reflect(ctx)
+	return rc.Reflect(ctx, services...)
 }
 
 // Invoke executes a unary gRPC request.
@@ -134,7 +144,7 @@ func (c *Conn) Invoke(
 		return nil, fmt.Errorf("unable to serialise request object to protocol buffer: %w", err)
 	}
 
-	ctx = withRPCState(ctx, &rpcState{tagsAndMeta: req.TagsAndMeta})
+	ctx = withRPCState(ctx, &rpcState{tagsAndMeta: req.TagsAndMeta, compressed: req.Compression != ""})
 
 	var resp *dynamicpb.Message
 	if req.DiscardResponseMessage {
@@ -145,9 +155,12 @@ func (c *Conn) Invoke(
 
 	header, trailer := metadata.New(nil), metadata.New(nil)
 
-	copts := make([]grpc.CallOption, 0, len(opts)+2)
+	copts := make([]grpc.CallOption, 0, len(opts)+3)
 	copts = append(copts, opts...)
 	copts = append(copts, grpc.Header(&header), grpc.Trailer(&trailer))
+	if req.Compression != "" {
+		copts = append(copts, grpc.UseCompressor(req.Compression))
+	}
 
 	err := c.raw.Invoke(ctx, req.Method, reqdm, resp, copts...)
 
@@ -193,7 +206,10 @@ func (c *Conn) NewStream(
 ) (*Stream, error) {
 	ctx = metadata.NewOutgoingContext(ctx, req.Metadata)
 
-	ctx = withRPCState(ctx, &rpcState{tagsAndMeta: req.TagsAndMeta})
+	ctx = withRPCState(ctx, &rpcState{tagsAndMeta: req.TagsAndMeta, compressed: req.Compression != ""})
+	if req.Compression != "" {
+		opts = append(opts, grpc.UseCompressor(req.Compression))
+	}
 
 	stream, err := c.raw.NewStream(ctx, &grpc.StreamDesc{
 		StreamName:    string(req.MethodDescriptor.Name()),
@@ -217,6 +233,33 @@ func (c *Conn) Close() error {
 	return c.raw.Close()
 }
 
+// stateWatcher is implemented by the connections, whose connectivity state can
+// be watched, like the grpc.ClientConn.
+type stateWatcher interface {
+	GetState() connectivity.State
+	WaitForStateChange(ctx context.Context, sourceState connectivity.State) bool
+}
+
+// ConnectivityState returns the connectivity state of the connection. The
+// connections, whose state can't be watched, are always ready.
+func (c *Conn) ConnectivityState() connectivity.State {
+	if sw, ok := c.raw.(stateWatcher); ok {
+		return sw.GetState()
+	}
+	return connectivity.Ready
+}
+
+// WaitForStateChange waits until the connectivity state of the connection is
+// different from the given one, and returns true, or until the context is
+// done, and returns false.
+func (c *Conn) WaitForStateChange(ctx context.Context, state connectivity.State) bool {
+	if sw, ok := c.raw.(stateWatcher); ok {
+		return sw.WaitForStateChange(ctx, state)
+	}
+	<-ctx.Done()
+	return false
+}
+
 type statsHandler struct {
 	getState func() *lib.State
 }
@@ -258,6 +301,16 @@ func (h statsHandler) HandleRPC(ctx context.Context, stat grpcstats.RPCStats) {
 				stateRPC.tagsAndMeta.SetSystemTagOrMeta(metrics.TagIP, ip)
 			}
 		}
+	case *grpcstats.OutPayload:
+		if stateRPC.compressed {
+			pushMessageSizes(ctx, state, stateRPC, s.SentTime, s.Length, s.CompressedLength,
+				state.BuiltinMetrics.GRPCMessageBytesSent, state.BuiltinMetrics.GRPCMessageCompressedBytesSent)
+		}
+	case *grpcstats.InPayload:
+		if stateRPC.compressed {
+			pushMessageSizes(ctx, state, stateRPC, s.RecvTime, s.Length, s.CompressedLength,
+				state.BuiltinMetrics.GRPCMessageBytesReceived, state.BuiltinMetrics.GRPCMessageCompressedBytesReceived)
+		}
 	case *grpcstats.End:
 		if state.Options.SystemTags.Has(metrics.TagStatus) {
 			stateRPC.tagsAndMeta.SetSystemTagOrMeta(metrics.TagStatus, strconv.Itoa(int(status.Code(s.Error))))
@@ -282,6 +335,33 @@ func (h statsHandler) HandleRPC(ctx context.Context, stat grpcstats.RPCStats) {
 	}
 }
 
+// pushMessageSizes emits the sizes of a message of a compressed call, before
+// and after the compression. If the message wasn't compressed, e.g. because
+// the server doesn't support the compressor, the sizes are the same.
+func pushMessageSizes(
+	ctx context.Context, state *lib.State, stateRPC *rpcState, t time.Time,
+	length, compressedLength int, lengthMetric, compressedLengthMetric *metrics.Metric,
+) {
+	metrics.PushIfNotDone(ctx, state.Samples, metrics.ConnectedSamples{
+		Samples: []metrics.Sample{
+			{
+				TimeSeries: metrics.TimeSeries{Metric: lengthMetric, Tags: stateRPC.tagsAndMeta.Tags},
+				Time:       t,
+				Metadata:   stateRPC.tagsAndMeta.Metadata,
+				Value:      float64(length),
+			},
+			{
+				TimeSeries: metrics.TimeSeries{Metric: compressedLengthMetric, Tags: stateRPC.tagsAndMeta.Tags},
+				Time:       t,
+				Metadata:   stateRPC.tagsAndMeta.Metadata,
+				Value:      float64(compressedLength),
+			},
+		},
+		Tags: stateRPC.tagsAndMeta.Tags,
+		Time: t,
+	})
+}
+
 // DebugStat prints debugging information based on RPCStats.
 func DebugStat(logger logrus.FieldLogger, stat grpcstats.RPCStats, httpDebugOption string) {
 	switch s := stat.(type) {
@@ -353,6 +433,9 @@ var ctxKeyRPCState = contextKey("rpcState") //nolint:gochecknoglobals
 
 type rpcState struct {
 	tagsAndMeta *metrics.TagsAndMeta
+	// compressed is set for the calls with compressed messages, whose sizes
+	// are measured before and after the compression
+	compressed bool
 }
 
 func withRPCState(ctx context.Context, rpcState *rpcState) context.Context {
@@ -17,13 +17,17 @@ type reflectionClient struct {
 
 // Reflect will use the grpc reflection api to make the file descriptors available to request.
 // It is called in the connect function the first time the Client.Connect function is called.
-func (rc *reflectionClient) Reflect(ctx context.Context) (*descriptorpb.FileDescriptorSet, error) {
+// Only the given services are resolved, or all the listed ones, if there are none.
+func (rc *reflectionClient) Reflect(ctx context.Context, services ...string) (*descriptorpb.FileDescriptorSet, error) {
 	client := grpcreflect.NewClientAuto(ctx, rc.Conn)
 	client.AllowMissingFileDescriptors()
 
-	services, err := client.ListServices()
-	if err != nil {
-		return nil, fmt.Errorf("can't list services: %w", err)
+	if len(services) == 0 {
+		var err error
+		services, err = client.ListServices()
+		if err != nil {
+			return nil, fmt.Errorf("can't list services: %w", err)
+		}
 	}
 
 	seen := make(map[fileDescriptorLookupKey]bool, len(services))
@@ -24,6 +24,7 @@ type MiniRunner struct {
 	Fn              func(ctx context.Context, state *lib.State, out chan<- metrics.SampleContainer) error
 	SetupFn         func(ctx context.Context, out chan<- metrics.SampleContainer) ([]byte, error)
 	TeardownFn      func(ctx context.Context, out chan<- metrics.SampleContainer) error
+	OnAbortFn       func(ctx context.Context, out chan<- metrics.SampleContainer) error
 	HandleSummaryFn func(context.Context, *lib.Summary) (map[string]io.Reader, error)
 
 	SetupData []byte
@@ -85,6 +86,14 @@ func (r MiniRunner) Teardown(ctx context.Context, out chan<- metrics.SampleConta
 	return nil
 }
 
+// OnAbort calls the supplied mock onAbort() function, if present.
+func (r MiniRunner) OnAbort(ctx context.Context, out chan<- metrics.SampleContainer) error {
+	if fn := r.OnAbortFn; fn != nil {
+		return fn(ctx, out)
+	}
+	return nil
+}
+
 // IsExecutable satisfies lib.Runner, but is mocked for MiniRunner since
 // it doesn't deal with JS.
 func (r MiniRunner) IsExecutable(_ string) bool {
@@ -101,7 +110,7 @@ func (r *MiniRunner) SetOptions(opts lib.Options) error {
 	r.Options = opts
 
 	if r.PreInitState != nil {
-		r.runTags = r.PreInitState.Registry.RootTagSet().WithTagsFromMap(r.Options.RunTags)
+		r.runTags = r.PreInitState.Registry.RootTagSet().WithTagsFromMap(r.Options.RunTagsWithMetadata())
 	}
 
 	return nil
@@ -0,0 +1,170 @@
+package testutils
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// SOCKS5Server is a minimal SOCKS5 proxy for tests, which supports only the
+// CONNECT command, with or without username and password authentication.
+type SOCKS5Server struct {
+	Addr string
+
+	user, password string
+
+	mx      sync.Mutex
+	targets []string
+}
+
+// NewSOCKS5Server starts a SOCKS5 proxy, which requires the given credentials,
+// if the user isn't empty. It's stopped when the test finishes.
+func NewSOCKS5Server(t testing.TB, user, password string) *SOCKS5Server {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = listener.Close() })
+
+	s := &SOCKS5Server{Addr: listener.Addr().String(), user: user, password: password}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+// Targets returns the addresses that the proxy was asked to connect to, as
+// they were sent by the clients, i.e. with hostnames or IPs.
+func (s *SOCKS5Server) Targets() []string {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	return append([]string(nil), s.targets...)
+}
+
+func (s *SOCKS5Server) serve(conn net.Conn) {
+	defer func() { _ = conn.Close() }()
+
+	target, err := s.handshake(conn)
+	if err != nil {
+		return
+	}
+	s.mx.Lock()
+	s.targets = append(s.targets, target)
+	s.mx.Unlock()
+
+	targetConn, err := net.Dial("tcp", target)
+	if err != nil {
+		_, _ = conn.Write([]byte{5, 5, 0, 1, 0, 0, 0, 0, 0, 0}) // connection refused
+		return
+	}
+	defer func() { _ = targetConn.Close() }()
+	if _, err = conn.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0}); err != nil {
+		return
+	}
+
+	go func() { _, _ = io.Copy(targetConn, conn) }()
+	_, _ = io.Copy(conn, targetConn)
+}
+
+func (s *SOCKS5Server) handshake(conn net.Conn) (string, error) {
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return "", err
+	}
+	methods := make([]byte, header[1])
+	if _, err := io.ReadFull(conn, methods); err != nil {
+		return "", err
+	}
+
+	if s.user == "" {
+		if _, err := conn.Write([]byte{5, 0}); err != nil {
+			return "", err
+		}
+	} else if err := s.authenticate(conn); err != nil {
+		return "", err
+	}
+
+	request := make([]byte, 4)
+	if _, err := io.ReadFull(conn, request); err != nil {
+		return "", err
+	}
+	if request[1] != 1 {
+		return "", errors.New("only the CONNECT command is supported")
+	}
+	var host string
+	switch request[3] {
+	case 1, 4:
+		ip := make(net.IP, 4)
+		if request[3] == 4 {
+			ip = make(net.IP, 16)
+		}
+		if _, err := io.ReadFull(conn, ip); err != nil {
+			return "", err
+		}
+		host = ip.String()
+	case 3:
+		length := make([]byte, 1)
+		if _, err := io.ReadFull(conn, length); err != nil {
+			return "", err
+		}
+		name := make([]byte, length[0])
+		if _, err := io.ReadFull(conn, name); err != nil {
+			return "", err
+		}
+		host = string(name)
+	default:
+		return "", errors.New("unsupported address type")
+	}
+	port := make([]byte, 2)
+	if _, err := io.ReadFull(conn, port); err != nil {
+		return "", err
+	}
+	return net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(port)))), nil
+}
+
+// authenticate does the username and password authentication from RFC 1929.
+func (s *SOCKS5Server) authenticate(conn net.Conn) error {
+	if _, err := conn.Write([]byte{5, 2}); err != nil {
+		return err
+	}
+	readString := func() (string, error) {
+		length := make([]byte, 1)
+		if _, err := io.ReadFull(conn, length); err != nil {
+			return "", err
+		}
+		value := make([]byte, length[0])
+		_, err := io.ReadFull(conn, value)
+		return string(value), err
+	}
+
+	version := make([]byte, 1)
+	if _, err := io.ReadFull(conn, version); err != nil {
+		return err
+	}
+	user, err := readString()
+	if err != nil {
+		return err
+	}
+	password, err := readString()
+	if err != nil {
+		return err
+	}
+	if user != s.user || password != s.password {
+		_, _ = conn.Write([]byte{1, 1})
+		return errors.New("invalid credentials")
+	}
+	_, err = conn.Write([]byte{1, 0})
+	return err
+}
@@ -0,0 +1,140 @@
+package log
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/time/rate"
+)
+
+// Filter decides which log entries are written. The level can be overridden
+// for the entries with a given source field, e.g. console=debug, and the
+// entries of each VU can be rate limited, so one noisy VU can't flood the logs.
+type Filter struct {
+	level     logrus.Level
+	levels    map[string]logrus.Level
+	ratePerVU int
+
+	mx  sync.Mutex
+	vus map[any]*vuLimit
+}
+
+type vuLimit struct {
+	limiter *rate.Limiter
+	dropped int
+}
+
+// NewFilter returns a filter with the given level for the entries without an
+// override. The overrides are comma-separated source=level pairs, and
+// ratePerVU is the number of entries per second that each VU can write, or 0
+// for no limit.
+func NewFilter(level logrus.Level, overrides string, ratePerVU int) (*Filter, error) {
+	if ratePerVU < 0 {
+		return nil, fmt.Errorf("the log rate limit must not be negative, but it was %d", ratePerVU)
+	}
+	f := &Filter{
+		level:     level,
+		levels:    make(map[string]logrus.Level),
+		ratePerVU: ratePerVU,
+		vus:       make(map[any]*vuLimit),
+	}
+	if overrides == "" {
+		return f, nil
+	}
+	for _, override := range strings.Split(overrides, ",") {
+		source, levelName, ok := strings.Cut(strings.TrimSpace(override), "=")
+		if !ok || source == "" {
+			return nil, fmt.Errorf("log level overrides should be in the form source=level, but one was '%s'", override)
+		}
+		lvl, err := logrus.ParseLevel(levelName)
+		if err != nil {
+			return nil, fmt.Errorf("unknown log level %s", levelName) // specifically use a custom error
+		}
+		f.levels[source] = lvl
+	}
+	return f, nil
+}
+
+// MaxLevel returns the most verbose level of the allowed entries, which the
+// level of the logger should be set to.
+func (f *Filter) MaxLevel() logrus.Level {
+	maxLevel := f.level
+	for _, lvl := range f.levels {
+		if lvl > maxLevel {
+			maxLevel = lvl
+		}
+	}
+	return maxLevel
+}
+
+// Allow returns whether the entry should be written. When some entries of a
+// VU were dropped because of the rate limit, their number is added to the
+// next allowed one as the dropped_lines field.
+func (f *Filter) Allow(entry *logrus.Entry) bool {
+	level := f.level
+	if source, ok := entry.Data["source"].(string); ok {
+		if lvl, ok := f.levels[source]; ok {
+			level = lvl
+		}
+	}
+	if entry.Level > level {
+		return false
+	}
+
+	vu, ok := entry.Data["vu"]
+	if f.ratePerVU == 0 || !ok {
+		return true
+	}
+	f.mx.Lock()
+	defer f.mx.Unlock()
+	limit, ok := f.vus[vu]
+	if !ok {
+		limit = &vuLimit{limiter: rate.NewLimiter(rate.Limit(f.ratePerVU), f.ratePerVU)}
+		f.vus[vu] = limit
+	}
+	if !limit.limiter.Allow() {
+		limit.dropped++
+		return false
+	}
+	if limit.dropped > 0 {
+		entry.Data["dropped_lines"] = limit.dropped
+		limit.dropped = 0
+	}
+	return true
+}
+
+// Formatter returns a formatter, which formats only the allowed entries.
+func (f *Filter) Formatter(formatter logrus.Formatter) logrus.Formatter {
+	return &filterFormatter{Formatter: formatter, filter: f}
+}
+
+// Hook returns a hook, which is fired only with the allowed entries.
+func (f *Filter) Hook(hook AsyncHook) AsyncHook {
+	return &filterHook{AsyncHook: hook, filter: f}
+}
+
+type filterFormatter struct {
+	logrus.Formatter
+	filter *Filter
+}
+
+func (ff *filterFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	if !ff.filter.Allow(entry) {
+		return nil, nil
+	}
+	return ff.Formatter.Format(entry)
+}
+
+type filterHook struct {
+	AsyncHook
+	filter *Filter
+}
+
+func (fh *filterHook) Fire(entry *logrus.Entry) error {
+	if !fh.filter.Allow(entry) {
+		return nil
+	}
+	return fh.AsyncHook.Fire(entry)
+}
@@ -0,0 +1,69 @@
+package log
+
+import (
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+)
+
+func TestNewFilter(t *testing.T) {
+	t.Parallel()
+
+	f, err := NewFilter(logrus.InfoLevel, "console=debug, http-debug=error", 0)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]logrus.Level{"console": logrus.DebugLevel, "http-debug": logrus.ErrorLevel}, f.levels)
+	assert.Equal(t, logrus.DebugLevel, f.MaxLevel())
+
+	_, err = NewFilter(logrus.InfoLevel, "console", 0)
+	require.ErrorContains(t, err, "should be in the form source=level")
+	_, err = NewFilter(logrus.InfoLevel, "console=tea", 0)
+	require.ErrorContains(t, err, "unknown log level tea")
+	_, err = NewFilter(logrus.InfoLevel, "", -1)
+	require.ErrorContains(t, err, "must not be negative")
+}
+
+func TestFilterAllow(t *testing.T) {
+	t.Parallel()
+
+	newEntry := func(level logrus.Level, fields logrus.Fields) *logrus.Entry {
+		entry := logrus.NewEntry(logrus.New()).WithFields(fields)
+		entry.Level = level
+		return entry
+	}
+
+	t.Run("levels", func(t *testing.T) {
+		t.Parallel()
+
+		f, err := NewFilter(logrus.InfoLevel, "console=debug,http-debug=error", 0)
+		require.NoError(t, err)
+		assert.True(t, f.Allow(newEntry(logrus.InfoLevel, nil)))
+		assert.False(t, f.Allow(newEntry(logrus.DebugLevel, nil)))
+		assert.True(t, f.Allow(newEntry(logrus.DebugLevel, logrus.Fields{"source": "console"})))
+		assert.False(t, f.Allow(newEntry(logrus.WarnLevel, logrus.Fields{"source": "http-debug"})))
+		assert.True(t, f.Allow(newEntry(logrus.ErrorLevel, logrus.Fields{"source": "http-debug"})))
+	})
+
+	t.Run("rate", func(t *testing.T) {
+		t.Parallel()
+
+		f, err := NewFilter(logrus.InfoLevel, "", 2)
+		require.NoError(t, err)
+		for range 2 {
+			assert.True(t, f.Allow(newEntry(logrus.InfoLevel, logrus.Fields{"vu": uint64(1)})))
+		}
+		assert.False(t, f.Allow(newEntry(logrus.InfoLevel, logrus.Fields{"vu": uint64(1)})))
+		assert.False(t, f.Allow(newEntry(logrus.InfoLevel, logrus.Fields{"vu": uint64(1)})))
+
+		// the other VUs and the entries without a VU aren't affected
+		assert.True(t, f.Allow(newEntry(logrus.InfoLevel, logrus.Fields{"vu": uint64(2)})))
+		assert.True(t, f.Allow(newEntry(logrus.InfoLevel, nil)))
+
+		f.vus[uint64(1)].limiter.SetLimit(rate.Inf)
+		entry := newEntry(logrus.InfoLevel, logrus.Fields{"vu": uint64(1)})
+		assert.True(t, f.Allow(entry))
+		assert.Equal(t, 2, entry.Data["dropped_lines"])
+	})
+}
@@ -0,0 +1,434 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+
+	"go.k6.io/k6/internal/lib/strvals"
+	"go.k6.io/k6/lib/consts"
+)
+
+// the fields of the entries, which are the trace context of their records,
+// when their context doesn't have one, instead of their attributes
+const (
+	traceIDField = "trace_id"
+	spanIDField  = "span_id"
+)
+
+// otelHook is a Logrus hook, which exports the entries as OTLP log records,
+// with the OTLP/HTTP protocol and its JSON encoding. The records have the
+// trace context of the span of the entries' context, or of their trace_id and
+// span_id fields, so they are correlated with the traces of the same test.
+type otelHook struct {
+	fallbackLogger logrus.FieldLogger
+	addr           string
+	headers        [][2]string
+	resource       [][2]string
+	ch             chan *logrus.Entry
+	limit          int
+	levels         []logrus.Level
+	pushPeriod     time.Duration
+	client         *http.Client
+}
+
+func getDefaultOTel() *otelHook {
+	return &otelHook{
+		addr:       "http://127.0.0.1:4318/v1/logs",
+		limit:      100,
+		levels:     logrus.AllLevels,
+		pushPeriod: time.Second * 1,
+		ch:         make(chan *logrus.Entry, 1000),
+	}
+}
+
+// OTelFromConfigLine returns a new logrus.Hook, which exports the
+// logrus.Entrys with OTLP, and is configured through the provided line.
+//
+// Supported format is: otel[=<URL>,<other opts>]
+// Where the URL defaults to http://127.0.0.1:4318/v1/logs, and its path to
+// /v1/logs, and other opts accept:
+//   - pushPeriod: how often the records are exported, 1s by default.
+//   - limit: the maximum number of records exported at once, 100 by default.
+//   - level: the minimum level of the exported entries.
+//   - header.<header_name>
+//   - resource.<attribute_name>: the resource attributes, besides service.name.
+//
+// Example: otel=https://collector:4318,header.Authorization=token ***,resource.env=staging
+func OTelFromConfigLine(fallbackLogger logrus.FieldLogger, line string) (AsyncHook, error) {
+	h := getDefaultOTel()
+	h.fallbackLogger = fallbackLogger
+
+	if line != "otel" {
+		logOutput, _, _ := strings.Cut(line, "=")
+		if logOutput != "otel" {
+			return nil, fmt.Errorf("otel configuration should be in the form `otel=url-to-push` but is `%s`", line)
+		}
+
+		err := h.parseArgs(line)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	h.client = &http.Client{Timeout: h.pushPeriod}
+	return h, nil
+}
+
+func (h *otelHook) parseArgs(line string) error {
+	tokens, err := strvals.Parse(line)
+	if err != nil {
+		return fmt.Errorf("error while parsing otel configuration %w", err)
+	}
+
+	for _, token := range tokens {
+		key := token.Key
+		value := token.Value
+
+		var err error
+		switch key {
+		case "otel":
+			h.addr, err = parseOTelURL(value)
+			if err != nil {
+				return err
+			}
+		case "pushPeriod":
+			h.pushPeriod, err = time.ParseDuration(value)
+			if err != nil {
+				return fmt.Errorf("couldn't parse the otel pushPeriod %w", err)
+			}
+			if h.pushPeriod <= 0 {
+				return fmt.Errorf("otel pushPeriod needs to be positive, is %s", h.pushPeriod)
+			}
+		case "limit":
+			h.limit, err = strconv.Atoi(value)
+			if err != nil {
+				return fmt.Errorf("couldn't parse the otel limit as a number %w", err)
+			}
+			if h.limit < 1 {
+				return fmt.Errorf("otel limit needs to be a positive number, is %d", h.limit)
+			}
+		case "level":
+			h.levels, err = parseLevels(value)
+			if err != nil {
+				return err
+			}
+		default:
+			if strings.HasPrefix(key, "header.") {
+				h.headers = append(h.headers, [2]string{strings.TrimPrefix(key, "header."), value})
+
+				continue
+			} else if strings.HasPrefix(key, "resource.") {
+				h.resource = append(h.resource, [2]string{strings.TrimPrefix(key, "resource."), value})
+
+				continue
+			}
+
+			return fmt.Errorf("unknown otel config key %s", key)
+		}
+	}
+
+	return nil
+}
+
+// parseOTelURL returns the URL of the logs endpoint, whose path is the
+// default one, if the given URL doesn't have any.
+func parseOTelURL(s string) (string, error) {
+	u, err := url.Parse(s)
+	if err != nil {
+		return "", fmt.Errorf("couldn't parse the otel URL %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", fmt.Errorf("otel URL should have the http or https scheme, but it's %q", u.Scheme)
+	}
+	if u.Path == "" || u.Path == "/" {
+		u.Path = "/v1/logs"
+	}
+	return u.String(), nil
+}
+
+// Listen buffers the records of the entries, and exports them every push
+// period, or once there are limit of them. The entries fired before the
+// context is done are exported before it returns.
+func (h *otelHook) Listen(ctx context.Context) {
+	ticker := time.NewTicker(h.pushPeriod)
+	defer ticker.Stop()
+
+	records := make([]otelLogRecord, 0, h.limit)
+	push := func() {
+		if len(records) == 0 {
+			return
+		}
+		if err := h.push(records); err != nil {
+			h.fallbackLogger.WithError(err).Error("Error while exporting logs with otel")
+		}
+		records = records[:0]
+	}
+
+	for {
+		select {
+		case entry := <-h.ch:
+			records = append(records, newOTelLogRecord(entry))
+			if len(records) == h.limit {
+				push()
+			}
+		case <-ticker.C:
+			push()
+		case <-ctx.Done():
+			for {
+				select {
+				case entry := <-h.ch:
+					records = append(records, newOTelLogRecord(entry))
+					if len(records) == h.limit {
+						push()
+					}
+				default:
+					push()
+					return
+				}
+			}
+		}
+	}
+}
+
+func (h *otelHook) push(records []otelLogRecord) error {
+	attributes := make([]otelKeyValue, 0, len(h.resource)+1)
+	attributes = append(attributes, newOTelKeyValue("service.name", "k6"))
+	for _, attribute := range h.resource {
+		attributes = append(attributes, newOTelKeyValue(attribute[0], attribute[1]))
+	}
+	body, err := json.Marshal(otelLogsRequest{
+		ResourceLogs: []otelResourceLogs{{
+			Resource: otelResource{Attributes: attributes},
+			ScopeLogs: []otelScopeLogs{{
+				Scope:      otelScope{Name: "k6", Version: consts.Version},
+				LogRecords: records,
+			}},
+		}},
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, h.addr, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for _, header := range h.headers {
+		req.Header.Add(header[0], header[1])
+	}
+
+	res, err := h.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = res.Body.Close() }()
+	if res.StatusCode >= 400 {
+		r, _ := io.ReadAll(io.LimitReader(res.Body, 1000))
+
+		return fmt.Errorf("got %d from the otel collector: %s", res.StatusCode, string(r))
+	}
+	_, _ = io.Copy(io.Discard, res.Body)
+
+	return nil
+}
+
+// Fire implements logrus.Hook.
+func (h *otelHook) Fire(entry *logrus.Entry) error {
+	h.ch <- entry
+
+	return nil
+}
+
+// Levels implements logrus.Hook.
+func (h *otelHook) Levels() []logrus.Level {
+	return h.levels
+}
+
+/*
+	{
+	  "resourceLogs": [{
+	    "resource": {"attributes": [{"key": "service.name", "value": {"stringValue": "k6"}}]},
+	    "scopeLogs": [{
+	      "scope": {"name": "k6", "version": "<k6 version>"},
+	      "logRecords": [{
+	        "timeUnixNano": "<unix epoch in nanoseconds>",
+	        "severityNumber": 9,
+	        "severityText": "info",
+	        "body": {"stringValue": "<log line>"},
+	        "attributes": [{"key": "source", "value": {"stringValue": "console"}}],
+	        "traceId": "<hex>",
+	        "spanId": "<hex>"
+	      }]
+	    }]
+	  }]
+	}
+*/
+type otelLogsRequest struct {
+	ResourceLogs []otelResourceLogs `json:"resourceLogs"`
+}
+
+type otelResourceLogs struct {
+	Resource  otelResource    `json:"resource"`
+	ScopeLogs []otelScopeLogs `json:"scopeLogs"`
+}
+
+type otelResource struct {
+	Attributes []otelKeyValue `json:"attributes"`
+}
+
+type otelScopeLogs struct {
+	Scope      otelScope       `json:"scope"`
+	LogRecords []otelLogRecord `json:"logRecords"`
+}
+
+type otelScope struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type otelLogRecord struct {
+	TimeUnixNano   string         `json:"timeUnixNano"`
+	SeverityNumber int            `json:"severityNumber"`
+	SeverityText   string         `json:"severityText"`
+	Body           otelAnyValue   `json:"body"`
+	Attributes     []otelKeyValue `json:"attributes,omitempty"`
+	TraceID        string         `json:"traceId,omitempty"`
+	SpanID         string         `json:"spanId,omitempty"`
+	Flags          uint32         `json:"flags,omitempty"`
+}
+
+type otelKeyValue struct {
+	Key   string       `json:"key"`
+	Value otelAnyValue `json:"value"`
+}
+
+// otelAnyValue has one of its values, the integer one being a string, since
+// it's a 64-bit integer.
+type otelAnyValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"`
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+}
+
+// otelSeverities are the severity numbers of the levels, which are the first
+// ones of their OTel severity ranges.
+var otelSeverities = map[logrus.Level]int{ //nolint:gochecknoglobals
+	logrus.TraceLevel: 1,
+	logrus.DebugLevel: 5,
+	logrus.InfoLevel:  9,
+	logrus.WarnLevel:  13,
+	logrus.ErrorLevel: 17,
+	logrus.FatalLevel: 21,
+	logrus.PanicLevel: 21,
+}
+
+func newOTelLogRecord(entry *logrus.Entry) otelLogRecord {
+	record := otelLogRecord{
+		TimeUnixNano:   strconv.FormatInt(entry.Time.UnixNano(), 10),
+		SeverityNumber: otelSeverities[entry.Level],
+		SeverityText:   entry.Level.String(),
+		Body:           newOTelAnyValue(entry.Message),
+	}
+
+	var sc trace.SpanContext
+	if entry.Context != nil {
+		sc = trace.SpanContextFromContext(entry.Context)
+	}
+	fromFields := false
+	if !sc.IsValid() {
+		sc, fromFields = spanContextFromFields(entry.Data)
+	}
+	if sc.IsValid() {
+		record.TraceID = sc.TraceID().String()
+		record.SpanID = sc.SpanID().String()
+		record.Flags = uint32(sc.TraceFlags())
+	}
+
+	keys := make([]string, 0, len(entry.Data))
+	for k := range entry.Data {
+		if fromFields && (k == traceIDField || k == spanIDField) {
+			continue
+		}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		record.Attributes = append(record.Attributes, newOTelKeyValue(k, entry.Data[k]))
+	}
+
+	return record
+}
+
+// spanContextFromFields returns the span context of the trace_id and the
+// span_id fields, if they are valid hex IDs.
+func spanContextFromFields(fields logrus.Fields) (trace.SpanContext, bool) {
+	rawTraceID, _ := fields[traceIDField].(string)
+	rawSpanID, _ := fields[spanIDField].(string)
+	traceID, err := trace.TraceIDFromHex(rawTraceID)
+	if err != nil {
+		return trace.SpanContext{}, false
+	}
+	spanID, err := trace.SpanIDFromHex(rawSpanID)
+	if err != nil {
+		return trace.SpanContext{}, false
+	}
+	return trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID, SpanID: spanID}), true
+}
+
+func newOTelKeyValue(key string, value any) otelKeyValue {
+	return otelKeyValue{Key: key, Value: newOTelAnyValue(value)}
+}
+
+func newOTelAnyValue(value any) otelAnyValue {
+	switch v := value.(type) {
+	case bool:
+		return otelAnyValue{BoolValue: &v}
+	case int:
+		return newOTelIntValue(int64(v))
+	case int32:
+		return newOTelIntValue(int64(v))
+	case int64:
+		return newOTelIntValue(v)
+	case uint32:
+		return newOTelIntValue(int64(v))
+	case float32:
+		return newOTelDoubleValue(float64(v))
+	case float64:
+		return newOTelDoubleValue(v)
+	case string:
+		return otelAnyValue{StringValue: &v}
+	default:
+		s := fmt.Sprint(v)
+		return otelAnyValue{StringValue: &s}
+	}
+}
+
+// newOTelDoubleValue returns the string value of NaN and the infinities, which
+// JSON doesn't have.
+func newOTelDoubleValue(v float64) otelAnyValue {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		s := strconv.FormatFloat(v, 'g', -1, 64)
+		return otelAnyValue{StringValue: &s}
+	}
+	return otelAnyValue{DoubleValue: &v}
+}
+
+func newOTelIntValue(v int64) otelAnyValue {
+	s := strconv.FormatInt(v, 10)
+	return otelAnyValue{IntValue: &s}
+}
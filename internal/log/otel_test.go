@@ -0,0 +1,287 @@
+package log
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+
+	"go.k6.io/k6/lib/consts"
+)
+
+func TestOTelFromConfigLine(t *testing.T) {
+	t.Parallel()
+	tests := [...]struct {
+		line string
+		err  bool
+		res  otelHook
+	}{
+		{
+			line: "otel", // default settings
+			res: otelHook{
+				addr:       "http://127.0.0.1:4318/v1/logs",
+				limit:      100,
+				pushPeriod: time.Second * 1,
+				levels:     logrus.AllLevels,
+			},
+		},
+		{
+			line: "otel=https://collector:4318,limit=32,level=info,pushPeriod=5s,header.authorization=token foobar,resource.env=staging",
+			res: otelHook{
+				addr:       "https://collector:4318/v1/logs",
+				headers:    [][2]string{{"authorization", "token foobar"}},
+				resource:   [][2]string{{"env", "staging"}},
+				limit:      32,
+				pushPeriod: time.Second * 5,
+				levels:     logrus.AllLevels[:5],
+			},
+		},
+		{
+			line: "otel=http://collector:4318/custom/logs",
+			res: otelHook{
+				addr:       "http://collector:4318/custom/logs",
+				limit:      100,
+				pushPeriod: time.Second * 1,
+				levels:     logrus.AllLevels,
+			},
+		},
+		{
+			line: "otelno",
+			err:  true,
+		},
+		{
+			line: "otel=collector:4318",
+			err:  true,
+		},
+		{
+			line: "otel=http://collector,limit=0",
+			err:  true,
+		},
+		{
+			line: "otel=http://collector,pushPeriod=0s",
+			err:  true,
+		},
+		{
+			line: "otel=http://collector,level=notlevel",
+			err:  true,
+		},
+		{
+			line: "otel=http://collector,unknownoption",
+			err:  true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.line, func(t *testing.T) {
+			t.Parallel()
+
+			res, err := OTelFromConfigLine(nil, test.line)
+			if test.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			hook, ok := res.(*otelHook)
+			require.True(t, ok)
+
+			test.res.client = hook.client
+			test.res.ch = hook.ch
+			require.Equal(t, &test.res, res)
+		})
+	}
+}
+
+func TestOTelLogRecord(t *testing.T) {
+	t.Parallel()
+	now := time.Unix(1700000000, 123)
+	traceID := trace.TraceID{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f, 0x10}
+	spanID := trace.SpanID{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08}
+
+	t.Run("attributes", func(t *testing.T) {
+		t.Parallel()
+		record := newOTelLogRecord(&logrus.Entry{
+			Time:    now,
+			Level:   logrus.WarnLevel,
+			Message: "test message",
+			Data: logrus.Fields{
+				"source":    "console",
+				"iteration": int64(3),
+				"vu":        uint32(1),
+				"ok":        true,
+				"rate":      0.5,
+				"nan":       math.NaN(),
+				"error":     fmt.Errorf("some error"),
+			},
+		})
+		assert.Equal(t, "1700000000000000123", record.TimeUnixNano)
+		assert.Equal(t, 13, record.SeverityNumber)
+		assert.Equal(t, "warning", record.SeverityText)
+		assert.Equal(t, "test message", *record.Body.StringValue)
+		assert.Empty(t, record.TraceID)
+
+		attributes := make(map[string]otelAnyValue, len(record.Attributes))
+		keys := make([]string, 0, len(record.Attributes))
+		for _, kv := range record.Attributes {
+			attributes[kv.Key] = kv.Value
+			keys = append(keys, kv.Key)
+		}
+		assert.Equal(t, []string{"error", "iteration", "nan", "ok", "rate", "source", "vu"}, keys)
+		assert.Equal(t, "console", *attributes["source"].StringValue)
+		assert.Equal(t, "3", *attributes["iteration"].IntValue)
+		assert.Equal(t, "1", *attributes["vu"].IntValue)
+		assert.True(t, *attributes["ok"].BoolValue)
+		assert.Equal(t, 0.5, *attributes["rate"].DoubleValue) //nolint:testifylint
+		assert.Equal(t, "NaN", *attributes["nan"].StringValue)
+		assert.Equal(t, "some error", *attributes["error"].StringValue)
+	})
+
+	t.Run("context", func(t *testing.T) {
+		t.Parallel()
+		ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+			TraceID:    traceID,
+			SpanID:     spanID,
+			TraceFlags: trace.FlagsSampled,
+		}))
+		record := newOTelLogRecord(&logrus.Entry{
+			Context: ctx,
+			Time:    now,
+			Level:   logrus.InfoLevel,
+			Message: "test message",
+		})
+		assert.Equal(t, "0102030405060708090a0b0c0d0e0f10", record.TraceID)
+		assert.Equal(t, "0102030405060708", record.SpanID)
+		assert.Equal(t, uint32(1), record.Flags)
+		assert.Empty(t, record.Attributes)
+	})
+
+	t.Run("fields", func(t *testing.T) {
+		t.Parallel()
+		record := newOTelLogRecord(&logrus.Entry{
+			Time:    now,
+			Level:   logrus.InfoLevel,
+			Message: "test message",
+			Data: logrus.Fields{
+				"trace_id": traceID.String(),
+				"span_id":  spanID.String(),
+				"source":   "console",
+			},
+		})
+		assert.Equal(t, "0102030405060708090a0b0c0d0e0f10", record.TraceID)
+		assert.Equal(t, "0102030405060708", record.SpanID)
+		require.Len(t, record.Attributes, 1)
+		assert.Equal(t, "source", record.Attributes[0].Key)
+	})
+
+	t.Run("invalid fields", func(t *testing.T) {
+		t.Parallel()
+		record := newOTelLogRecord(&logrus.Entry{
+			Time:    now,
+			Level:   logrus.InfoLevel,
+			Message: "test message",
+			Data:    logrus.Fields{"trace_id": "invalid", "span_id": spanID.String()},
+		})
+		assert.Empty(t, record.TraceID)
+		assert.Len(t, record.Attributes, 2)
+	})
+}
+
+func TestOTelFlushingOnStop(t *testing.T) {
+	t.Parallel()
+	receivedData := make(chan string, 1)
+	receivedHeaders := make(chan http.Header, 1)
+	srv := httptest.NewServer(
+		http.HandlerFunc(func(_ http.ResponseWriter, req *http.Request) {
+			b, err := io.ReadAll(req.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			receivedHeaders <- req.Header
+			receivedData <- string(b)
+			close(receivedData) // see comment in TestLokiFlushingOnStop
+		}),
+	)
+	t.Cleanup(srv.Close)
+	configLine := fmt.Sprintf("otel=%s,pushPeriod=1h,header.X-Foo=bar,resource.env=test", srv.URL)
+	h, err := OTelFromConfigLine(nil, configLine)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	wg := new(sync.WaitGroup)
+	now := time.Now()
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		err = h.Fire(&logrus.Entry{
+			Time: now, Level: logrus.ErrorLevel, Message: "test message", Data: logrus.Fields{"source": "console"},
+		})
+		time.Sleep(time.Millisecond * 10)
+		cancel()
+	}()
+	h.Listen(ctx)
+	wg.Wait()
+	require.NoError(t, err)
+
+	select {
+	case data := <-receivedData:
+		require.JSONEq(t, fmt.Sprintf(`{"resourceLogs":[{
+			"resource":{"attributes":[
+				{"key":"service.name","value":{"stringValue":"k6"}},
+				{"key":"env","value":{"stringValue":"test"}}
+			]},
+			"scopeLogs":[{
+				"scope":{"name":"k6","version":%q},
+				"logRecords":[{
+					"timeUnixNano":"%d",
+					"severityNumber":17,
+					"severityText":"error",
+					"body":{"stringValue":"test message"},
+					"attributes":[{"key":"source","value":{"stringValue":"console"}}]
+				}]
+			}]
+		}]}`, consts.Version, now.UnixNano()), data)
+		headers := <-receivedHeaders
+		assert.Equal(t, "application/json", headers.Get("Content-Type"))
+		assert.Equal(t, "bar", headers.Get("X-Foo"))
+	default:
+		t.Fatal("No logs were received from otel before hook has finished")
+	}
+}
+
+func TestOTelLimit(t *testing.T) {
+	t.Parallel()
+	var mx sync.Mutex
+	var pushes int
+	srv := httptest.NewServer(
+		http.HandlerFunc(func(_ http.ResponseWriter, _ *http.Request) {
+			mx.Lock()
+			pushes++
+			mx.Unlock()
+		}),
+	)
+	t.Cleanup(srv.Close)
+	h, err := OTelFromConfigLine(nil, fmt.Sprintf("otel=%s,pushPeriod=1h,limit=2", srv.URL))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		for i := 0; i < 5; i++ {
+			_ = h.Fire(&logrus.Entry{Time: time.Now(), Level: logrus.InfoLevel, Message: "test message"})
+		}
+		cancel()
+	}()
+	h.Listen(ctx)
+
+	mx.Lock()
+	defer mx.Unlock()
+	assert.Equal(t, 3, pushes)
+}
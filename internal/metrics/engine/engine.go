@@ -13,6 +13,7 @@ import (
 	"github.com/sirupsen/logrus"
 	"go.k6.io/k6/errext"
 	"go.k6.io/k6/errext/exitcodes"
+	"go.k6.io/k6/internal/event"
 	"go.k6.io/k6/lib"
 	"go.k6.io/k6/metrics"
 	"gopkg.in/guregu/null.v3"
@@ -38,6 +39,21 @@ type MetricsEngine struct {
 	//     the metrics are decoupled from their types
 	MetricsLock     sync.Mutex
 	ObservedMetrics map[string]*metrics.Metric
+	// ScenarioMetrics are the metrics aggregated from the samples of each
+	// scenario, by the scenario name, if they're enabled
+	ScenarioMetrics map[string]map[string]*metrics.Metric
+	// TimeBuckets are the key metrics aggregated from the samples of each
+	// time bucket, by the start of the bucket, if they're enabled
+	TimeBuckets map[time.Time]map[string]*metrics.Metric
+
+	timeBucketInterval time.Duration
+	timeBucketMetrics  map[string]bool
+
+	// if positive, trend metrics use a t-digest with this compression
+	trendCompression float64
+
+	// if set, the crossed thresholds are emitted as ThresholdCrossed events
+	events *event.System
 }
 
 // NewMetricsEngine creates a new metrics Engine with the given parameters.
@@ -105,10 +121,134 @@ func (me *MetricsEngine) getThresholdMetricOrSubmetric(name string) (*metrics.Me
 	return sm.Metric, nil
 }
 
+// SetTrendCompression makes the sinks of all trend metrics that are observed
+// from now on estimate their percentiles with a t-digest with the given
+// compression, instead of storing every value.
+func (me *MetricsEngine) SetTrendCompression(compression float64) {
+	me.MetricsLock.Lock()
+	defer me.MetricsLock.Unlock()
+	me.trendCompression = compression
+}
+
+// SetEventSystem makes the engine emit the ThresholdCrossed events to the
+// given event system, when the thresholds of a metric start failing.
+func (me *MetricsEngine) SetEventSystem(events *event.System) {
+	me.MetricsLock.Lock()
+	defer me.MetricsLock.Unlock()
+	me.events = events
+}
+
+// EnableScenarioMetrics makes the engine also aggregate the samples of each
+// scenario separately, in ScenarioMetrics, for the handleSummary() function.
+// Since this duplicates the sinks, it isn't done for the default summary.
+func (me *MetricsEngine) EnableScenarioMetrics() {
+	me.MetricsLock.Lock()
+	defer me.MetricsLock.Unlock()
+	if me.ScenarioMetrics == nil {
+		me.ScenarioMetrics = make(map[string]map[string]*metrics.Metric)
+	}
+}
+
+// addScenarioSample adds the sample to the sink of its metric for its
+// scenario, if the scenario metrics are enabled and it has a scenario.
+func (me *MetricsEngine) addScenarioSample(sample metrics.Sample) {
+	if me.ScenarioMetrics == nil {
+		return
+	}
+	scenario, ok := sample.Tags.Get(metrics.TagScenario.String())
+	if !ok {
+		return
+	}
+	scenarioMetrics, ok := me.ScenarioMetrics[scenario]
+	if !ok {
+		scenarioMetrics = make(map[string]*metrics.Metric)
+		me.ScenarioMetrics[scenario] = scenarioMetrics
+	}
+	me.addToMetrics(scenarioMetrics, sample)
+}
+
+// timeBucketKeyMetrics are the builtin metrics, which are aggregated in the
+// time buckets, besides the ones with thresholds.
+var timeBucketKeyMetrics = []string{ //nolint:gochecknoglobals
+	metrics.VUsName,
+	metrics.IterationsName,
+	metrics.IterationDurationName,
+	metrics.ChecksName,
+	metrics.HTTPReqsName,
+	metrics.HTTPReqFailedName,
+	metrics.HTTPReqDurationName,
+	metrics.DataSentName,
+	metrics.DataReceivedName,
+}
+
+// EnableTimeBuckets makes the engine also aggregate the samples of the key
+// metrics, i.e. the main builtin ones and the ones with thresholds, in time
+// buckets of the given interval, in TimeBuckets, for the summary export. The
+// buckets are aligned to the interval, like with time.Truncate, and only the
+// ones with samples are kept. It should be called after the thresholds are
+// initialized.
+func (me *MetricsEngine) EnableTimeBuckets(interval time.Duration) {
+	me.MetricsLock.Lock()
+	defer me.MetricsLock.Unlock()
+	me.timeBucketInterval = interval
+	me.timeBucketMetrics = make(map[string]bool, len(timeBucketKeyMetrics)+len(me.metricsWithThresholds))
+	for _, name := range timeBucketKeyMetrics {
+		me.timeBucketMetrics[name] = true
+	}
+	for _, m := range me.metricsWithThresholds {
+		if m.Sub != nil {
+			m = m.Sub.Parent
+		}
+		me.timeBucketMetrics[m.Name] = true
+	}
+	if me.TimeBuckets == nil {
+		me.TimeBuckets = make(map[time.Time]map[string]*metrics.Metric)
+	}
+}
+
+// addTimeBucketSample adds the sample to the sink of its metric for its time
+// bucket, if the time buckets are enabled and it's one of their metrics.
+func (me *MetricsEngine) addTimeBucketSample(sample metrics.Sample) {
+	if me.TimeBuckets == nil || !me.timeBucketMetrics[sample.Metric.Name] {
+		return
+	}
+	start := sample.Time.Truncate(me.timeBucketInterval)
+	bucketMetrics, ok := me.TimeBuckets[start]
+	if !ok {
+		bucketMetrics = make(map[string]*metrics.Metric)
+		me.TimeBuckets[start] = bucketMetrics
+	}
+	me.addToMetrics(bucketMetrics, sample)
+}
+
+// addToMetrics adds the sample to the sink of its metric in the given metrics,
+// which is created, without any thresholds, if it isn't there yet.
+func (me *MetricsEngine) addToMetrics(ms map[string]*metrics.Metric, sample metrics.Sample) {
+	m, ok := ms[sample.Metric.Name]
+	if !ok {
+		m = &metrics.Metric{
+			Name:     sample.Metric.Name,
+			Type:     sample.Metric.Type,
+			Contains: sample.Metric.Contains,
+			Sink:     metrics.NewSink(sample.Metric.Type),
+			Observed: true,
+		}
+		if ts, ok := m.Sink.(*metrics.TrendSink); ok && me.trendCompression > 0 {
+			ts.UseCompression(me.trendCompression)
+		}
+		ms[m.Name] = m
+	}
+	m.Sink.Add(sample)
+}
+
 func (me *MetricsEngine) markObserved(metric *metrics.Metric) {
 	if !metric.Observed {
 		metric.Observed = true
 		me.ObservedMetrics[metric.Name] = metric
+
+		if ts, ok := metric.Sink.(*metrics.TrendSink); ok && me.trendCompression > 0 {
+			ts.UseCompression(me.trendCompression)
+		}
 	}
 }
 
@@ -210,13 +350,28 @@ func (me *MetricsEngine) StartThresholdCalculations(
 	}
 }
 
-// evaluateThresholds processes all of the thresholds.
-//
-// TODO: refactor, optimize
+// evaluateThresholds processes all of the thresholds, and emits the
+// ThresholdCrossed events for the metrics whose thresholds started failing.
 func (me *MetricsEngine) evaluateThresholds(
 	ignoreEmptySinks bool,
 	getCurrentTestRunDuration func() time.Duration,
 ) (breachedThresholds []string, shouldAbort bool) {
+	breachedThresholds, shouldAbort, crossed := me.runThresholds(ignoreEmptySinks, getCurrentTestRunDuration)
+	// The events are emitted without holding the lock, since emitting blocks
+	// while the buffers of the subscribers are full.
+	for _, data := range crossed {
+		me.events.Emit(&event.Event{Type: event.ThresholdCrossed, Data: data})
+	}
+	return breachedThresholds, shouldAbort
+}
+
+// runThresholds runs the thresholds of all metrics.
+//
+// TODO: refactor, optimize
+func (me *MetricsEngine) runThresholds(
+	ignoreEmptySinks bool,
+	getCurrentTestRunDuration func() time.Duration,
+) (breachedThresholds []string, shouldAbort bool, crossed []*event.ThresholdData) {
 	me.MetricsLock.Lock()
 	defer me.MetricsLock.Unlock()
 
@@ -229,6 +384,7 @@ func (me *MetricsEngine) evaluateThresholds(
 		if len(m.Thresholds.Thresholds) == 0 || (ignoreEmptySinks && m.Sink.IsEmpty()) {
 			continue
 		}
+		wasTainted := m.Tainted.Bool
 		m.Tainted = null.BoolFrom(false)
 
 		succ, err := m.Thresholds.Run(m.Sink, t)
@@ -244,13 +400,28 @@ func (me *MetricsEngine) evaluateThresholds(
 		if m.Thresholds.Abort {
 			shouldAbort = true
 		}
+		if !wasTainted && me.events != nil {
+			crossed = append(crossed, newThresholdData(m))
+		}
 	}
 	if len(breachedThresholds) > 0 {
 		sort.Strings(breachedThresholds)
 		me.logger.Debugf("Thresholds on %d metrics crossed: %v", len(breachedThresholds), breachedThresholds)
 	}
 	atomic.StoreUint32(&me.breachedThresholdsCount, uint32(len(breachedThresholds))) //nolint:gosec
-	return breachedThresholds, shouldAbort
+	return breachedThresholds, shouldAbort, crossed
+}
+
+func newThresholdData(m *metrics.Metric) *event.ThresholdData {
+	data := &event.ThresholdData{Metric: m.Name}
+	for _, threshold := range m.Thresholds.Thresholds {
+		if !threshold.LastFailed {
+			continue
+		}
+		data.Thresholds = append(data.Thresholds, threshold.Source)
+		data.AbortOnFail = data.AbortOnFail || threshold.AbortOnFail
+	}
+	return data
 }
 
 // GetMetricsWithBreachedThresholdsCount returns the number of metrics for which
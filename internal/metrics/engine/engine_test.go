@@ -6,6 +6,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.k6.io/k6/internal/event"
 	"go.k6.io/k6/internal/lib/testutils"
 	"go.k6.io/k6/lib"
 	"go.k6.io/k6/metrics"
@@ -153,6 +154,38 @@ func TestMetricsEngineEvaluateIgnoreEmptySink(t *testing.T) {
 	assert.Empty(t, breached)
 }
 
+func TestMetricsEngineEvaluateThresholdsEvents(t *testing.T) {
+	t.Parallel()
+
+	me := newTestMetricsEngine(t)
+	events := event.NewEventSystem(10, testutils.NewLogger(t))
+	me.SetEventSystem(events)
+	_, evtCh := events.Subscribe(event.ThresholdCrossed)
+
+	m1, err := me.registry.NewMetric("m1", metrics.Counter)
+	require.NoError(t, err)
+	ths := metrics.NewThresholds([]string{"count>5", "count<5"})
+	require.NoError(t, ths.Parse())
+	m1.Thresholds = ths
+	m1.Thresholds.Thresholds[1].AbortOnFail = true
+	me.metricsWithThresholds = []*metrics.Metric{m1}
+	m1.Sink.Add(metrics.Sample{Value: 6.0})
+
+	// only the first evaluation, after which the thresholds fail, is emitted
+	for range 2 {
+		breached, _ := me.evaluateThresholds(false, zeroTestRunDuration)
+		require.Equal(t, []string{"m1"}, breached)
+	}
+	require.Len(t, evtCh, 1)
+	evt := <-evtCh
+	evt.Done()
+	assert.Equal(t, &event.ThresholdData{
+		Metric:      "m1",
+		Thresholds:  []string{"count<5"},
+		AbortOnFail: true,
+	}, evt.Data)
+}
+
 func newTestMetricsEngine(t *testing.T) *MetricsEngine {
 	m, err := NewMetricsEngine(metrics.NewRegistry(), testutils.NewLogger(t))
 	require.NoError(t, err)
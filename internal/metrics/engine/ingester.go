@@ -29,6 +29,8 @@ type OutputIngester struct {
 	metricsEngine   *MetricsEngine
 	periodicFlusher *output.PeriodicFlusher
 	cardinality     *cardinalityControl
+	// attributes the memory of the time series, if it's enabled
+	memoryProfile *memoryProfile
 }
 
 // Description returns a human-readable description of the output.
@@ -58,6 +60,23 @@ func (oi *OutputIngester) Stop() error {
 	return nil
 }
 
+// EnableMemoryProfile makes the ingester attribute the estimated memory of the
+// time series to their metrics and tags, see MemoryProfileReport(). It must be
+// called before the ingester is started.
+func (oi *OutputIngester) EnableMemoryProfile() {
+	oi.memoryProfile = newMemoryProfile()
+}
+
+// MemoryProfileReport returns the report of the memory of the time series of
+// each metric and tag, from the largest, or an empty string if the memory
+// profile isn't enabled. It must be called after the ingester is stopped.
+func (oi *OutputIngester) MemoryProfileReport() string {
+	if oi.memoryProfile == nil {
+		return ""
+	}
+	return oi.memoryProfile.report()
+}
+
 // flushMetrics Writes samples to the MetricsEngine
 func (oi *OutputIngester) flushMetrics() {
 	sampleContainers := oi.GetBufferedSamples()
@@ -97,8 +116,12 @@ func (oi *OutputIngester) flushMetrics() {
 				oi.metricsEngine.markObserved(sm.Metric)
 				sm.Metric.Sink.Add(sample)
 			}
+			oi.metricsEngine.addScenarioSample(sample)
+			oi.metricsEngine.addTimeBucketSample(sample)
 
-			oi.cardinality.Add(sample.TimeSeries)
+			if oi.cardinality.Add(sample.TimeSeries) && oi.memoryProfile != nil {
+				oi.memoryProfile.add(sample.TimeSeries)
+			}
 		}
 	}
 
@@ -132,12 +155,14 @@ func newCardinalityControl() *cardinalityControl {
 	}
 }
 
-// Add adds the passed time series to the list of seen items.
-func (cc *cardinalityControl) Add(ts metrics.TimeSeries) {
+// Add adds the passed time series to the list of seen items, and returns
+// whether it wasn't seen before.
+func (cc *cardinalityControl) Add(ts metrics.TimeSeries) bool {
 	if _, ok := cc.seen[ts]; ok {
-		return
+		return false
 	}
 	cc.seen[ts] = struct{}{}
+	return true
 }
 
 // LimitHit checks if the cardinality limit has been hit.
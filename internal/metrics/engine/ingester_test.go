@@ -2,7 +2,9 @@ package engine
 
 import (
 	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
@@ -47,6 +49,110 @@ func TestIngesterOutputFlushMetrics(t *testing.T) {
 	assert.Equal(t, 42.0, sink.Total())
 }
 
+func TestIngesterOutputFlushMetricsWithTrendCompression(t *testing.T) {
+	t.Parallel()
+
+	piState := newTestPreInitState(t)
+	testMetric, err := piState.Registry.NewMetric("test_metric", metrics.Trend)
+	require.NoError(t, err)
+
+	me := &MetricsEngine{ObservedMetrics: make(map[string]*metrics.Metric)}
+	me.SetTrendCompression(100)
+	ingester := OutputIngester{
+		logger:        piState.Logger,
+		metricsEngine: me,
+		cardinality:   newCardinalityControl(),
+	}
+	require.NoError(t, ingester.Start())
+	for i := 1; i <= 1000; i++ {
+		ingester.AddMetricSamples([]metrics.SampleContainer{metrics.Sample{
+			TimeSeries: metrics.TimeSeries{Metric: testMetric},
+			Value:      float64(i),
+		}})
+	}
+	require.NoError(t, ingester.Stop())
+
+	sink := testMetric.Sink.(*metrics.TrendSink)
+	assert.Equal(t, uint64(1000), sink.Count())
+	assert.Equal(t, 1.0, sink.Min())
+	assert.Equal(t, 1000.0, sink.Max())
+	assert.InEpsilon(t, 950.0, sink.P(0.95), 0.01)
+	assert.False(t, sink.UseCompression(100), "the sink is not empty anymore")
+}
+
+func TestIngesterOutputFlushScenarioMetrics(t *testing.T) {
+	t.Parallel()
+
+	piState := newTestPreInitState(t)
+	testMetric, err := piState.Registry.NewMetric("test_metric", metrics.Trend)
+	require.NoError(t, err)
+
+	me := &MetricsEngine{ObservedMetrics: make(map[string]*metrics.Metric)}
+	me.EnableScenarioMetrics()
+	ingester := OutputIngester{
+		logger:        piState.Logger,
+		metricsEngine: me,
+		cardinality:   newCardinalityControl(),
+	}
+	require.NoError(t, ingester.Start())
+	for i, scenario := range []string{"browse", "checkout", "browse", ""} {
+		tags := piState.Registry.RootTagSet()
+		if scenario != "" {
+			tags = tags.With("scenario", scenario)
+		}
+		ingester.AddMetricSamples([]metrics.SampleContainer{metrics.Sample{
+			TimeSeries: metrics.TimeSeries{Metric: testMetric, Tags: tags},
+			Value:      float64(i + 1),
+		}})
+	}
+	require.NoError(t, ingester.Stop())
+
+	assert.Equal(t, 10.0, testMetric.Sink.(*metrics.TrendSink).Total())
+	require.Len(t, me.ScenarioMetrics, 2)
+	browse := me.ScenarioMetrics["browse"]["test_metric"]
+	require.NotNil(t, browse)
+	assert.Equal(t, metrics.Trend, browse.Type)
+	assert.Equal(t, 4.0, browse.Sink.(*metrics.TrendSink).Total())
+	assert.Equal(t, 2.0, me.ScenarioMetrics["checkout"]["test_metric"].Sink.(*metrics.TrendSink).Total())
+}
+
+func TestIngesterOutputFlushTimeBuckets(t *testing.T) {
+	t.Parallel()
+
+	piState := newTestPreInitState(t)
+	reqDuration, err := piState.Registry.NewMetric(metrics.HTTPReqDurationName, metrics.Trend)
+	require.NoError(t, err)
+	other, err := piState.Registry.NewMetric("other_metric", metrics.Counter)
+	require.NoError(t, err)
+
+	me := &MetricsEngine{ObservedMetrics: make(map[string]*metrics.Metric)}
+	me.EnableTimeBuckets(time.Minute)
+	ingester := OutputIngester{
+		logger:        piState.Logger,
+		metricsEngine: me,
+		cardinality:   newCardinalityControl(),
+	}
+	require.NoError(t, ingester.Start())
+	start := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	for i, offset := range []time.Duration{10 * time.Second, 50 * time.Second, 70 * time.Second} {
+		for _, m := range []*metrics.Metric{reqDuration, other} {
+			ingester.AddMetricSamples([]metrics.SampleContainer{metrics.Sample{
+				TimeSeries: metrics.TimeSeries{Metric: m, Tags: piState.Registry.RootTagSet()},
+				Time:       start.Add(offset),
+				Value:      float64(i + 1),
+			}})
+		}
+	}
+	require.NoError(t, ingester.Stop())
+
+	require.Len(t, me.TimeBuckets, 2)
+	first := me.TimeBuckets[start]
+	require.Len(t, first, 1, "only the key metrics are in the buckets")
+	assert.Equal(t, 3.0, first[metrics.HTTPReqDurationName].Sink.(*metrics.TrendSink).Total())
+	second := me.TimeBuckets[start.Add(time.Minute)]
+	assert.Equal(t, 3.0, second[metrics.HTTPReqDurationName].Sink.(*metrics.TrendSink).Total())
+}
+
 func TestIngesterOutputFlushSubmetrics(t *testing.T) {
 	t.Parallel()
 
@@ -195,6 +301,60 @@ func TestCardinalityControlLimitHit(t *testing.T) {
 	assert.Equal(t, 2, cc.timeSeriesLimit, "the limit is expected to be raised")
 }
 
+func TestIngesterMemoryProfile(t *testing.T) {
+	t.Parallel()
+
+	piState := newTestPreInitState(t)
+	me, err := NewMetricsEngine(piState.Registry, piState.Logger)
+	require.NoError(t, err)
+	ingester := me.CreateIngester()
+	ingester.EnableMemoryProfile()
+
+	reqs := piState.BuiltinMetrics.HTTPReqs
+	root := piState.Registry.RootTagSet()
+	require.NoError(t, ingester.Start())
+	for i := 0; i < 3; i++ {
+		ingester.AddMetricSamples([]metrics.SampleContainer{
+			metrics.Sample{
+				TimeSeries: metrics.TimeSeries{Metric: reqs, Tags: root.With("url", "/user/"+strconv.Itoa(i)).With("method", "GET")},
+				Value:      1,
+			},
+			// the same tags in another order are the same time series
+			metrics.Sample{
+				TimeSeries: metrics.TimeSeries{Metric: reqs, Tags: root.With("method", "GET").With("url", "/user/"+strconv.Itoa(i))},
+				Value:      1,
+			},
+			metrics.Sample{
+				TimeSeries: metrics.TimeSeries{Metric: piState.BuiltinMetrics.Iterations, Tags: root.With("method", "GET")},
+				Value:      1,
+			},
+		})
+	}
+	require.NoError(t, ingester.Stop())
+
+	assert.Equal(t, 4, ingester.cardinality.Count())
+	profile := ingester.memoryProfile
+	assert.Equal(t, 3, profile.metrics["http_reqs"].series)
+	assert.Equal(t, 1, profile.metrics["iterations"].series)
+	assert.Len(t, profile.tags["url"].values, 3)
+	assert.Equal(t, 4, profile.tags["method"].series)
+	assert.Len(t, profile.tags["method"].values, 1)
+
+	report := ingester.MemoryProfileReport()
+	assert.Contains(t, report, "metrics memory profile: 4 time series")
+	assert.Regexp(t, `http_reqs\s+3\s+`, report)
+	assert.Regexp(t, `url\s+3\s+3\s+`, report)
+	assert.Less(t, strings.Index(report, "http_reqs"), strings.Index(report, "iterations"), "the largest metrics are first")
+}
+
+func TestFormatBytes(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "512 B", formatBytes(512))
+	assert.Equal(t, "1.5 KiB", formatBytes(1536))
+	assert.Equal(t, "2.0 MiB", formatBytes(2<<20))
+}
+
 func newTestPreInitState(tb testing.TB) *lib.TestPreInitState {
 	reg := metrics.NewRegistry()
 	logger := testutils.NewLogger(tb)
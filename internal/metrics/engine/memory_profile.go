@@ -0,0 +1,125 @@
+package engine
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"go.k6.io/k6/metrics"
+)
+
+// The estimated memory overheads of a time series and of each of its tags,
+// e.g. of their map entries and atlas nodes, besides the tag names and values.
+const (
+	timeSeriesMemoryOverhead = 64
+	tagMemoryOverhead        = 96
+)
+
+// memoryProfile attributes the estimated memory, which the distinct time series
+// of the metrics take, to the metrics and to the tags, so that the cardinality
+// problems can be pinpointed.
+type memoryProfile struct {
+	metrics map[string]*memoryUsage
+	tags    map[string]*memoryUsage
+}
+
+// memoryUsage is the memory attributed to a metric or a tag.
+type memoryUsage struct {
+	name   string
+	series int
+	bytes  int64
+	// the distinct values of a tag
+	values map[string]struct{}
+}
+
+func newMemoryProfile() *memoryProfile {
+	return &memoryProfile{
+		metrics: make(map[string]*memoryUsage),
+		tags:    make(map[string]*memoryUsage),
+	}
+}
+
+func usageOf(usages map[string]*memoryUsage, name string) *memoryUsage {
+	usage, ok := usages[name]
+	if !ok {
+		usage = &memoryUsage{name: name, values: make(map[string]struct{})}
+		usages[name] = usage
+	}
+	return usage
+}
+
+// add attributes the memory of a new time series to its metric and its tags.
+func (mp *memoryProfile) add(ts metrics.TimeSeries) {
+	bytes := int64(timeSeriesMemoryOverhead)
+	if ts.Tags != nil {
+		for k, v := range ts.Tags.Map() {
+			tagBytes := int64(tagMemoryOverhead + len(k) + len(v))
+			bytes += tagBytes
+
+			tag := usageOf(mp.tags, k)
+			tag.series++
+			tag.bytes += tagBytes
+			tag.values[v] = struct{}{}
+		}
+	}
+	metric := usageOf(mp.metrics, ts.Metric.Name)
+	metric.series++
+	metric.bytes += bytes
+}
+
+func sortedUsages(usages map[string]*memoryUsage) []*memoryUsage {
+	sorted := make([]*memoryUsage, 0, len(usages))
+	for _, usage := range usages {
+		sorted = append(sorted, usage)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].bytes != sorted[j].bytes {
+			return sorted[i].bytes > sorted[j].bytes
+		}
+		return sorted[i].name < sorted[j].name
+	})
+	return sorted
+}
+
+// formatBytes returns the number of bytes with a binary unit, e.g. 1.5 MiB.
+func formatBytes(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(bytes)/float64(div), "KMGTPE"[exp])
+}
+
+// report returns the memory of the metrics and of the tags, from the largest.
+func (mp *memoryProfile) report() string {
+	var total int64
+	var series int
+	for _, usage := range mp.metrics {
+		total += usage.bytes
+		series += usage.series
+	}
+
+	buf := &strings.Builder{}
+	fmt.Fprintf(buf, "\n     metrics memory profile: %d time series, ~%s estimated\n\n", series, formatBytes(total))
+	tw := tabwriter.NewWriter(buf, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(tw, "     METRIC\tSERIES\tMEMORY")
+	for _, usage := range sortedUsages(mp.metrics) {
+		fmt.Fprintf(tw, "     %s\t%d\t%s\n", usage.name, usage.series, formatBytes(usage.bytes))
+	}
+	_ = tw.Flush()
+
+	buf.WriteString("\n")
+	tw = tabwriter.NewWriter(buf, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(tw, "     TAG\tVALUES\tSERIES\tMEMORY")
+	for _, usage := range sortedUsages(mp.tags) {
+		fmt.Fprintf(tw, "     %s\t%d\t%d\t%s\n", usage.name, len(usage.values), usage.series, formatBytes(usage.bytes))
+	}
+	_ = tw.Flush()
+	return buf.String()
+}
@@ -105,6 +105,7 @@ func newOutput(params output.Params) (*Output, error) {
 	if warn != "" {
 		params.Logger.Warn(warn)
 	}
+	conf = applyOutputTuning(conf, params.ScriptOptions.OutputTuning["cloud"])
 
 	if err := validateRequiredSystemTags(params.ScriptOptions.SystemTags); err != nil {
 		return nil, err
@@ -154,6 +155,21 @@ func newOutput(params output.Params) (*Output, error) {
 	}, nil
 }
 
+// applyOutputTuning sets the values of the outputTuning option, which aren't set
+// in the config of the output.
+func applyOutputTuning(conf cloudapi.Config, tuning lib.OutputTuning) cloudapi.Config {
+	if !conf.MetricPushInterval.Valid && tuning.PushInterval.Valid {
+		conf.MetricPushInterval = tuning.PushInterval
+	}
+	if !conf.MaxTimeSeriesInBatch.Valid && tuning.MaxBatchSize.Valid {
+		conf.MaxTimeSeriesInBatch = tuning.MaxBatchSize
+	}
+	if !conf.MetricPushConcurrency.Valid && tuning.MaxInFlight.Valid {
+		conf.MetricPushConcurrency = tuning.MaxInFlight
+	}
+	return conf
+}
+
 // validateRequiredSystemTags checks if all required tags are present.
 func validateRequiredSystemTags(scriptTags *metrics.SystemTagSet) error {
 	missingRequiredTags := []string{}
@@ -332,7 +348,7 @@ func (out *Output) getRunStatus(testErr error) cloudapi.RunStatus {
 			return cloudapi.RunStatusAbortedScriptError
 		case errext.AbortedByScriptAbort:
 			return cloudapi.RunStatusAbortedUser // TODO: have a better value than this?
-		case errext.AbortedByTimeout:
+		case errext.AbortedByTimeout, errext.AbortedByResourceBudget:
 			return cloudapi.RunStatusAbortedLimit
 		case errext.AbortedByOutput:
 			return cloudapi.RunStatusAbortedSystem
@@ -89,6 +89,35 @@ func TestCloudOutputRequireScriptName(t *testing.T) {
 	assert.Contains(t, err.Error(), "script name not set")
 }
 
+func TestOutputTuning(t *testing.T) {
+	t.Parallel()
+	out, err := newOutput(output.Params{
+		Logger: testutils.NewLogger(t),
+		Environment: map[string]string{
+			"K6_CLOUD_METRIC_PUSH_CONCURRENCY": "2",
+		},
+		ScriptOptions: lib.Options{
+			Duration:   types.NullDurationFrom(1 * time.Second),
+			SystemTags: &metrics.DefaultSystemTagSet,
+			OutputTuning: lib.OutputTunings{
+				"cloud": {
+					PushInterval: types.NullDurationFrom(3 * time.Second),
+					MaxBatchSize: null.IntFrom(10),
+					MaxInFlight:  null.IntFrom(5),
+				},
+				"influxdb": {MaxBatchSize: null.IntFrom(20)},
+			},
+		},
+		ScriptPath: &url.URL{Path: "/script.js"},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, types.NullDurationFrom(3*time.Second), out.config.MetricPushInterval)
+	assert.Equal(t, null.IntFrom(10), out.config.MaxTimeSeriesInBatch)
+	// the output's own config takes precedence
+	assert.Equal(t, null.IntFrom(2), out.config.MetricPushConcurrency)
+}
+
 func TestOutputCreateTestWithConfigOverwrite(t *testing.T) {
 	t.Parallel()
 
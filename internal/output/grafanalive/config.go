@@ -0,0 +1,126 @@
+package grafanalive
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/mstoykov/envconfig"
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/lib/types"
+)
+
+// Config is the configuration of the Grafana Live output.
+type Config struct {
+	// URL is the base URL of the Grafana instance.
+	URL null.String `json:"url" envconfig:"K6_GRAFANA_LIVE_URL"`
+	// Token is the token of a service account, which can push to the stream.
+	Token null.String `json:"token,omitempty" envconfig:"K6_GRAFANA_LIVE_TOKEN"`
+	// Stream is the ID of the stream, whose channels get the metrics, i.e.
+	// stream/<Stream>/<metric name>.
+	Stream       null.String        `json:"stream" envconfig:"K6_GRAFANA_LIVE_STREAM"`
+	PushInterval types.NullDuration `json:"pushInterval,omitempty" envconfig:"K6_GRAFANA_LIVE_PUSH_INTERVAL"`
+	// Tags are the tags, by which the metrics are aggregated, in addition to
+	// their names.
+	Tags []string `json:"tags,omitempty" envconfig:"K6_GRAFANA_LIVE_TAGS"`
+}
+
+// NewConfig creates a new Grafana Live output config with some default values.
+func NewConfig() Config {
+	return Config{
+		URL:          null.NewString("http://localhost:3000", false),
+		Stream:       null.NewString("k6", false),
+		PushInterval: types.NewNullDuration(time.Second, false),
+	}
+}
+
+// Apply applies a valid config options to the receiver.
+func (c Config) Apply(cfg Config) Config {
+	if cfg.URL.Valid {
+		c.URL = cfg.URL
+	}
+	if cfg.Token.Valid {
+		c.Token = cfg.Token
+	}
+	if cfg.Stream.Valid {
+		c.Stream = cfg.Stream
+	}
+	if cfg.PushInterval.Valid {
+		c.PushInterval = cfg.PushInterval
+	}
+	if len(cfg.Tags) > 0 {
+		c.Tags = cfg.Tags
+	}
+	return c
+}
+
+func (c Config) validate() error {
+	u, err := url.Parse(c.URL.String)
+	if err != nil {
+		return fmt.Errorf("invalid Grafana URL: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("the Grafana URL must be an http:// or https:// URL, but it was %q", c.URL.String)
+	}
+	if c.Stream.String == "" || strings.Contains(c.Stream.String, "/") {
+		return fmt.Errorf("the stream must be a non-empty ID without slashes, but it was %q", c.Stream.String)
+	}
+	if c.PushInterval.Duration <= 0 {
+		return errors.New("the push interval must be positive")
+	}
+	return nil
+}
+
+// ParseURL parses the argument of the output, which is the URL of Grafana,
+// whose stream query parameter is the stream ID.
+func ParseURL(text string) (Config, error) {
+	c := Config{}
+	u, err := url.Parse(text)
+	if err != nil {
+		return c, err
+	}
+	query := u.Query()
+	if stream := query.Get("stream"); stream != "" {
+		c.Stream = null.StringFrom(stream)
+	}
+	query.Del("stream")
+	u.RawQuery = query.Encode()
+	c.URL = null.StringFrom(strings.TrimSuffix(u.String(), "/"))
+	return c, nil
+}
+
+// GetConsolidatedConfig combines the default config values with the JSON
+// config, the environment variables and the argument of the output.
+func GetConsolidatedConfig(jsonRawConf json.RawMessage, env map[string]string, arg string) (Config, error) {
+	result := NewConfig()
+	if jsonRawConf != nil {
+		jsonConf := Config{}
+		if err := json.Unmarshal(jsonRawConf, &jsonConf); err != nil {
+			return result, err
+		}
+		result = result.Apply(jsonConf)
+	}
+
+	envConfig := Config{}
+	if err := envconfig.Process("", &envConfig, func(key string) (string, bool) {
+		v, ok := env[key]
+		return v, ok
+	}); err != nil {
+		return result, err
+	}
+	result = result.Apply(envConfig)
+
+	if arg != "" {
+		argConf, err := ParseURL(arg)
+		if err != nil {
+			return result, err
+		}
+		result = result.Apply(argConf)
+	}
+
+	return result, result.validate()
+}
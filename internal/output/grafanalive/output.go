@@ -0,0 +1,270 @@
+// Package grafanalive provides an output, which pushes the aggregated metrics
+// to the channels of a Grafana Live stream, so the dashboards show the test
+// runs live, without storing the metrics anywhere in between.
+package grafanalive
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"go.k6.io/k6/metrics"
+	"go.k6.io/k6/output"
+)
+
+// outputName is the name of the output, e.g. in the outputTuning option.
+const outputName = "experimental-grafana-live"
+
+// pushTimeout is how long a push to Grafana can take at most.
+const pushTimeout = 10 * time.Second
+
+// Output pushes the metrics to Grafana Live, aggregated over the push
+// interval, in the InfluxDB line protocol.
+type Output struct {
+	output.SampleBuffer
+
+	config          Config
+	pushURL         string
+	client          *http.Client
+	logger          logrus.FieldLogger
+	periodicFlusher *output.PeriodicFlusher
+	lastFlush       time.Time
+}
+
+var _ output.Output = &Output{}
+
+// New returns a new Grafana Live output.
+func New(params output.Params) (output.Output, error) {
+	return newOutput(params)
+}
+
+func newOutput(params output.Params) (*Output, error) {
+	conf, err := GetConsolidatedConfig(params.JSONConfig, params.Environment, params.ConfigArgument)
+	if err != nil {
+		return nil, err
+	}
+	if tuning := params.ScriptOptions.OutputTuning[outputName]; !conf.PushInterval.Valid && tuning.PushInterval.Valid {
+		conf.PushInterval = tuning.PushInterval
+	}
+	return &Output{
+		config:  conf,
+		pushURL: conf.URL.String + "/api/live/push/" + url.PathEscape(conf.Stream.String),
+		client:  &http.Client{Timeout: pushTimeout},
+		logger:  params.Logger.WithField("output", "GrafanaLive"),
+	}, nil
+}
+
+// Description returns a human-readable description of the output.
+func (o *Output) Description() string {
+	return fmt.Sprintf("Grafana Live (%s, stream %s)", o.config.URL.String, o.config.Stream.String)
+}
+
+// Start starts the goroutine, which pushes the metrics periodically.
+func (o *Output) Start() error {
+	o.logger.Debug("Starting...")
+	o.lastFlush = time.Now()
+	pf, err := output.NewPeriodicFlusher(o.config.PushInterval.TimeDuration(), o.flushMetrics)
+	if err != nil {
+		return err
+	}
+	o.periodicFlusher = pf
+	o.logger.Debug("Started!")
+	return nil
+}
+
+// Stop pushes the remaining metrics and stops the goroutine.
+func (o *Output) Stop() error {
+	o.logger.Debug("Stopping...")
+	defer o.logger.Debug("Stopped!")
+	o.periodicFlusher.Stop()
+	return nil
+}
+
+func (o *Output) flushMetrics() {
+	now := time.Now()
+	interval := now.Sub(o.lastFlush)
+	o.lastFlush = now
+
+	containers := o.GetBufferedSamples()
+	if len(containers) == 0 {
+		return
+	}
+	body := o.aggregate(containers, now, interval)
+	if err := o.push(body); err != nil {
+		o.logger.WithError(err).Error("Couldn't push the metrics to Grafana Live")
+	}
+}
+
+func (o *Output) push(body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, o.pushURL, bytes.NewReader(body)) //nolint:noctx
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	if o.config.Token.String != "" {
+		req.Header.Set("Authorization", "Bearer "+o.config.Token.String)
+	}
+	res, err := o.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = res.Body.Close() }()
+	if res.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+		return fmt.Errorf("%s responded with %s: %s", o.pushURL, res.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// series is the aggregate of the samples of a metric with the same tags.
+type series struct {
+	metric           *metrics.Metric
+	tags             string
+	count, nonZero   int
+	sum, last        float64
+	minimum, maximum float64
+	trend            *metrics.TrendSink
+}
+
+func (s *series) add(sample metrics.Sample) {
+	value := sample.Value
+	if s.count == 0 || value < s.minimum {
+		s.minimum = value
+	}
+	if s.count == 0 || value > s.maximum {
+		s.maximum = value
+	}
+	s.count++
+	s.sum += value
+	s.last = value
+	if value != 0 {
+		s.nonZero++
+	}
+	if s.trend != nil {
+		s.trend.Add(sample)
+	}
+}
+
+// fields returns the aggregated fields of the series, by the type of its
+// metric, in the line protocol.
+func (s *series) fields(interval time.Duration) string {
+	var fields []string
+	field := func(name string, value float64) {
+		fields = append(fields, name+"="+strconv.FormatFloat(value, 'f', -1, 64))
+	}
+	switch s.metric.Type {
+	case metrics.Counter:
+		field("count", s.sum)
+		if seconds := interval.Seconds(); seconds > 0 {
+			field("rate", s.sum/seconds)
+		}
+	case metrics.Gauge:
+		field("value", s.last)
+		field("min", s.minimum)
+		field("max", s.maximum)
+	case metrics.Rate:
+		field("rate", float64(s.nonZero)/float64(s.count))
+		field("passes", float64(s.nonZero))
+		field("fails", float64(s.count-s.nonZero))
+	case metrics.Trend:
+		field("avg", s.trend.Avg())
+		field("min", s.trend.Min())
+		field("max", s.trend.Max())
+		field("med", s.trend.P(0.5))
+		field("p90", s.trend.P(0.9))
+		field("p95", s.trend.P(0.95))
+		field("p99", s.trend.P(0.99))
+		field("count", float64(s.count))
+	}
+	return strings.Join(fields, ",")
+}
+
+// aggregate aggregates the samples by their metrics and the tags of the
+// config, and returns the lines of the aggregates.
+func (o *Output) aggregate(containers []metrics.SampleContainer, now time.Time, interval time.Duration) []byte {
+	all := make(map[string]*series)
+	for _, container := range containers {
+		for _, sample := range container.GetSamples() {
+			tags := o.lineTags(sample.Tags)
+			key := sample.Metric.Name + tags
+			s, ok := all[key]
+			if !ok {
+				s = &series{metric: sample.Metric, tags: tags}
+				if sample.Metric.Type == metrics.Trend {
+					s.trend = metrics.NewTrendSink()
+				}
+				all[key] = s
+			}
+			s.add(sample)
+		}
+	}
+
+	keys := make([]string, 0, len(all))
+	for key := range all {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var buf bytes.Buffer
+	timestamp := strconv.FormatInt(now.UnixNano(), 10)
+	for _, key := range keys {
+		s := all[key]
+		buf.WriteString(escape(s.metric.Name, ", "))
+		buf.WriteString(s.tags)
+		buf.WriteByte(' ')
+		buf.WriteString(s.fields(interval))
+		buf.WriteByte(' ')
+		buf.WriteString(timestamp)
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes()
+}
+
+// lineTags returns the tags of the config, which the tag set has, in the
+// line protocol, sorted by their keys.
+func (o *Output) lineTags(tagSet *metrics.TagSet) string {
+	if len(o.config.Tags) == 0 || tagSet == nil {
+		return ""
+	}
+	tags := tagSet.Map()
+	keys := make([]string, 0, len(o.config.Tags))
+	for _, key := range o.config.Tags {
+		if value, ok := tags[key]; ok && value != "" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, key := range keys {
+		b.WriteByte(',')
+		b.WriteString(escape(key, ",= "))
+		b.WriteByte('=')
+		b.WriteString(escape(tags[key], ",= "))
+	}
+	return b.String()
+}
+
+// escape escapes the characters with a backslash, as the line protocol
+// requires.
+func escape(s, chars string) string {
+	if !strings.ContainsAny(s, chars) {
+		return s
+	}
+	var b strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune(chars, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
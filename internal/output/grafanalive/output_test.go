@@ -0,0 +1,120 @@
+package grafanalive
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/internal/lib/testutils"
+	"go.k6.io/k6/metrics"
+	"go.k6.io/k6/output"
+)
+
+func TestOutput(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu     sync.Mutex
+		bodies []string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/grafana/api/live/push/load-test", r.URL.Path)
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		mu.Lock()
+		bodies = append(bodies, string(body))
+		mu.Unlock()
+	}))
+	t.Cleanup(srv.Close)
+
+	o, err := New(output.Params{
+		Logger:         testutils.NewLogger(t),
+		JSONConfig:     json.RawMessage(`{"tags":["scenario","status"],"pushInterval":"1h"}`),
+		Environment:    map[string]string{"K6_GRAFANA_LIVE_TOKEN": "secret"},
+		ConfigArgument: srv.URL + "/grafana?stream=load-test",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Grafana Live ("+srv.URL+"/grafana, stream load-test)", o.Description())
+	require.NoError(t, o.Start())
+
+	registry := metrics.NewRegistry()
+	reqs := registry.MustNewMetric("http_reqs", metrics.Counter)
+	duration := registry.MustNewMetric("http_req_duration", metrics.Trend, metrics.Time)
+	checks := registry.MustNewMetric("checks", metrics.Rate)
+	vus := registry.MustNewMetric("vus", metrics.Gauge)
+
+	now := time.Now()
+	sample := func(metric *metrics.Metric, value float64, tags map[string]string) metrics.Sample {
+		return metrics.Sample{
+			TimeSeries: metrics.TimeSeries{Metric: metric, Tags: registry.RootTagSet().WithTagsFromMap(tags)},
+			Time:       now,
+			Value:      value,
+		}
+	}
+	ok := map[string]string{"scenario": "shop, eu", "status": "200", "url": "https://example.com"}
+	notFound := map[string]string{"scenario": "shop, eu", "status": "404"}
+	o.AddMetricSamples([]metrics.SampleContainer{metrics.Samples{
+		sample(reqs, 1, ok), sample(reqs, 1, ok), sample(reqs, 1, notFound),
+		sample(duration, 100, ok), sample(duration, 200, ok), sample(duration, 300, ok),
+		sample(checks, 1, nil), sample(checks, 0, nil), sample(checks, 1, nil), sample(checks, 1, nil),
+		sample(vus, 5, nil), sample(vus, 10, nil), sample(vus, 8, nil),
+	}})
+	require.NoError(t, o.Stop())
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, bodies, 1)
+	lines := strings.Split(strings.TrimSuffix(bodies[0], "\n"), "\n")
+	require.Len(t, lines, 5)
+
+	// the timestamps and the rates of the counters depend on when the
+	// metrics were pushed
+	for i, line := range lines {
+		line = line[:strings.LastIndex(line, " ")]
+		if rate := strings.Index(line, ",rate="); strings.HasPrefix(line, "http_reqs") && rate > 0 {
+			line = line[:rate]
+		}
+		lines[i] = line
+	}
+	assert.Equal(t, []string{
+		"checks rate=0.75,passes=3,fails=1",
+		`http_req_duration,scenario=shop\,\ eu,status=200 avg=200,min=100,max=300,med=200,p90=280,p95=290,p99=298,count=3`,
+		`http_reqs,scenario=shop\,\ eu,status=200 count=2`,
+		`http_reqs,scenario=shop\,\ eu,status=404 count=1`,
+		"vus value=8,min=5,max=10",
+	}, lines)
+}
+
+func TestConfig(t *testing.T) {
+	t.Parallel()
+
+	conf, err := GetConsolidatedConfig(nil, nil, "")
+	require.NoError(t, err)
+	assert.Equal(t, "http://localhost:3000", conf.URL.String)
+	assert.Equal(t, "k6", conf.Stream.String)
+	assert.Equal(t, time.Second, conf.PushInterval.TimeDuration())
+
+	conf, err = GetConsolidatedConfig(nil, map[string]string{
+		"K6_GRAFANA_LIVE_STREAM": "from-env",
+		"K6_GRAFANA_LIVE_TAGS":   "scenario,name",
+	}, "https://grafana.example.com/")
+	require.NoError(t, err)
+	assert.Equal(t, "https://grafana.example.com", conf.URL.String)
+	assert.Equal(t, "from-env", conf.Stream.String)
+	assert.Equal(t, []string{"scenario", "name"}, conf.Tags)
+
+	_, err = GetConsolidatedConfig(nil, nil, "https://grafana.example.com?stream=a/b")
+	assert.ErrorContains(t, err, `the stream must be a non-empty ID without slashes, but it was "a/b"`)
+	_, err = GetConsolidatedConfig(nil, nil, "grafana.example.com")
+	assert.ErrorContains(t, err, `the Grafana URL must be an http:// or https:// URL, but it was "grafana.example.com"`)
+}
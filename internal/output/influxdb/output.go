@@ -47,6 +47,10 @@ type Output struct {
 	periodicFlusher *output.PeriodicFlusher
 	semaphoreCh     chan struct{}
 	wg              sync.WaitGroup
+
+	// maxBatchSize is the maximum number of points written at once, from the
+	// outputTuning option, or 0 for all the points of a flush.
+	maxBatchSize int
 }
 
 // New returns new influxdb output
@@ -59,6 +63,13 @@ func newOutput(params output.Params) (*Output, error) {
 	if err != nil {
 		return nil, err
 	}
+	tuning := params.ScriptOptions.OutputTuning["influxdb"]
+	if !conf.PushInterval.Valid && tuning.PushInterval.Valid {
+		conf.PushInterval = tuning.PushInterval
+	}
+	if !conf.ConcurrentWrites.Valid && tuning.MaxInFlight.Valid {
+		conf.ConcurrentWrites = tuning.MaxInFlight
+	}
 	cl, err := MakeClient(conf)
 	if err != nil {
 		return nil, err
@@ -79,6 +90,8 @@ func newOutput(params output.Params) (*Output, error) {
 		fieldKinds:  fldKinds,
 		semaphoreCh: make(chan struct{}, conf.ConcurrentWrites.Int64),
 		wg:          sync.WaitGroup{},
+
+		maxBatchSize: int(tuning.MaxBatchSize.Int64),
 	}, err
 }
 
@@ -152,6 +165,25 @@ func (o *Output) batchFromSamples(containers []metrics.SampleContainer) (client.
 	return batch, nil
 }
 
+// splitBatch splits the batch into the batches of at most maxBatchSize points.
+func (o *Output) splitBatch(batch client.BatchPoints) ([]client.BatchPoints, error) {
+	points := batch.Points()
+	if o.maxBatchSize <= 0 || len(points) <= o.maxBatchSize {
+		return []client.BatchPoints{batch}, nil
+	}
+
+	batches := make([]client.BatchPoints, 0, (len(points)+o.maxBatchSize-1)/o.maxBatchSize)
+	for start := 0; start < len(points); start += o.maxBatchSize {
+		b, err := client.NewBatchPoints(o.BatchConf)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't make a batch: %w", err)
+		}
+		b.AddPoints(points[start:min(start+o.maxBatchSize, len(points))])
+		batches = append(batches, b)
+	}
+	return batches, nil
+}
+
 // Description returns a human-readable description of the output.
 func (o *Output) Description() string {
 	return fmt.Sprintf("InfluxDBv1 (%s)", o.Config.Addr.String)
@@ -210,15 +242,23 @@ func (o *Output) flushMetrics() {
 			return
 		}
 
+		batches, err := o.splitBatch(batch)
+		if err != nil {
+			o.logger.WithError(err).Error("Couldn't split the batch")
+			return
+		}
+
 		o.logger.WithField("points", len(batch.Points())).Debug("Writing...")
 		startTime := time.Now()
-		if err := o.Client.Write(batch); err != nil {
-			msg := "Couldn't write stats"
-			if strings.Contains(err.Error(), "unauthorized access") {
-				msg += ", InfluxDB v2.x isn't supported by this output, if you are using it you may consider to use the extension https://github.com/grafana/xk6-output-influxdb" //nolint:lll
+		for _, b := range batches {
+			if err := o.Client.Write(b); err != nil {
+				msg := "Couldn't write stats"
+				if strings.Contains(err.Error(), "unauthorized access") {
+					msg += ", InfluxDB v2.x isn't supported by this output, if you are using it you may consider to use the extension https://github.com/grafana/xk6-output-influxdb" //nolint:lll
+				}
+				o.logger.WithError(err).Error(msg)
+				return
 			}
-			o.logger.WithError(err).Error(msg)
-			return
 		}
 		t := time.Since(startTime)
 		o.logger.WithField("t", t).Debug("Batch written!")
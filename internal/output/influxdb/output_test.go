@@ -14,8 +14,11 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
 
 	"go.k6.io/k6/internal/lib/testutils"
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/types"
 	"go.k6.io/k6/metrics"
 	"go.k6.io/k6/output"
 )
@@ -53,6 +56,49 @@ func TestBadConcurrentWrites(t *testing.T) {
 	})
 }
 
+func TestOutputTuning(t *testing.T) {
+	t.Parallel()
+	o, err := newOutput(output.Params{
+		Logger:         testutils.NewLogger(t),
+		ConfigArgument: "?concurrentWrites=2",
+		ScriptOptions: lib.Options{
+			OutputTuning: lib.OutputTunings{
+				"influxdb": {
+					PushInterval: types.NullDurationFrom(3 * time.Second),
+					MaxBatchSize: null.IntFrom(4),
+					MaxInFlight:  null.IntFrom(5),
+				},
+			},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, types.NullDurationFrom(3*time.Second), o.Config.PushInterval)
+	// the output's own config takes precedence
+	assert.Equal(t, null.IntFrom(2), o.Config.ConcurrentWrites)
+
+	registry := metrics.NewRegistry()
+	metric, err := registry.NewMetric("test_counter", metrics.Counter)
+	require.NoError(t, err)
+	samples := make(metrics.Samples, 10)
+	for i := range samples {
+		samples[i] = metrics.Sample{
+			TimeSeries: metrics.TimeSeries{Metric: metric, Tags: registry.RootTagSet()},
+			Time:       time.Now(),
+			Value:      1,
+		}
+	}
+	batch, err := o.batchFromSamples([]metrics.SampleContainer{samples})
+	require.NoError(t, err)
+	batches, err := o.splitBatch(batch)
+	require.NoError(t, err)
+
+	sizes := make([]int, 0, len(batches))
+	for _, b := range batches {
+		sizes = append(sizes, len(b.Points()))
+	}
+	assert.Equal(t, []int{4, 4, 2}, sizes)
+}
+
 func testOutputCycle(t testing.TB, handler http.HandlerFunc, body func(testing.TB, *Output)) {
 	s := &http.Server{
 		Addr:              ":",
@@ -0,0 +1,40 @@
+package common
+
+import (
+	"github.com/grafana/sobek"
+)
+
+// AsyncIteratorSymbol returns the Symbol.asyncIterator of the runtime, defining
+// it first, since Sobek doesn't. The scripts, which use the async iteration, are
+// transformed to look the async iterators up with it.
+func AsyncIteratorSymbol(rt *sobek.Runtime) *sobek.Symbol {
+	symbol := rt.Get("Symbol").ToObject(rt)
+	if s, ok := symbol.Get("asyncIterator").(*sobek.Symbol); ok {
+		return s
+	}
+	s := sobek.NewSymbol("Symbol.asyncIterator")
+	if err := symbol.DefineDataProperty("asyncIterator", s, sobek.FLAG_FALSE, sobek.FLAG_FALSE, sobek.FLAG_FALSE); err != nil {
+		Throw(rt, err)
+	}
+	return s
+}
+
+// NewAsyncIterator returns an async iterator, which is also its own async
+// iterable, so it can be used in the for await loops. The iterator has the
+// return method only if ret isn't nil.
+func NewAsyncIterator(
+	rt *sobek.Runtime, next func() *sobek.Promise, ret func(value sobek.Value) *sobek.Promise,
+) *sobek.Object {
+	it := rt.NewObject()
+	must := func(err error) {
+		if err != nil {
+			Throw(rt, err)
+		}
+	}
+	must(it.Set("next", next))
+	if ret != nil {
+		must(it.Set("return", ret))
+	}
+	must(it.SetSymbol(AsyncIteratorSymbol(rt), func() *sobek.Object { return it }))
+	return it
+}
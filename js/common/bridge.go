@@ -45,10 +45,11 @@ func FieldName(_ reflect.Type, f reflect.StructField) string {
 //
 //nolint:gochecknoglobals
 var methodNameExceptions = map[string]string{
-	"JSON": "json",
-	"HTML": "html",
-	"URL":  "url",
-	"OCSP": "ocsp",
+	"JSON":     "json",
+	"JSONPath": "jsonPath",
+	"HTML":     "html",
+	"URL":      "url",
+	"OCSP":     "ocsp",
 }
 
 // MethodName Returns the JS name for an exported method. The first letter of the method's name is
@@ -268,11 +268,11 @@ func (e Element) GetElementsByTagName(name string) []sobek.Value {
 }
 
 func (e Element) QuerySelector(selector string) sobek.Value {
-	return selToElement(Selection{e.sel.rt, e.sel.sel.Find(selector), e.sel.URL})
+	return selToElement(Selection{e.sel.rt, e.sel.sel.Find(rewriteSelector(selector)), e.sel.URL})
 }
 
 func (e Element) QuerySelectorAll(selector string) []sobek.Value {
-	return elemList(Selection{e.sel.rt, e.sel.sel.Find(selector), e.sel.URL})
+	return elemList(Selection{e.sel.rt, e.sel.sel.Find(rewriteSelector(selector)), e.sel.URL})
 }
 
 func (e Element) NodeName() string {
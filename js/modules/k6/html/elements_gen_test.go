@@ -3,6 +3,7 @@ package html
 import (
 	"testing"
 
+	"github.com/grafana/sobek"
 	"github.com/stretchr/testify/require"
 )
 
@@ -392,7 +393,7 @@ func TestGenElementsURLProperties(t *testing.T) {
 	t.Parallel()
 	rt, mi := getTestRuntimeAndModuleInstanceWithDoc(t, testGenElems)
 
-	sel, parseError := mi.parseHTML(testGenElems)
+	sel, parseError := mi.parseHTML(testGenElems, sobek.Undefined())
 	if parseError != nil {
 		t.Errorf("Unable to parse html")
 	}
@@ -55,8 +55,27 @@ func (r *RootModule) NewModuleInstance(vu modules.VU) modules.Instance {
 	return mi
 }
 
-func (mi *ModuleInstance) parseHTML(src string) (Selection, error) {
-	return ParseHTML(mi.vu.Runtime(), src)
+// parseHTML parses the source, or with the only option, just the elements
+// with the given tag names, e.g. parseHTML(src, { only: ['form'] }).
+func (mi *ModuleInstance) parseHTML(src string, options sobek.Value) (Selection, error) {
+	rt := mi.vu.Runtime()
+	if common.IsNullish(options) {
+		return ParseHTML(rt, src)
+	}
+	var only []string
+	if v := options.ToObject(rt).Get("only"); !common.IsNullish(v) {
+		if err := rt.ExportTo(v, &only); err != nil {
+			return Selection{}, fmt.Errorf("the only option must be an array of tag names: %w", err)
+		}
+	}
+	if only == nil {
+		return ParseHTML(rt, src)
+	}
+	doc, err := parseSubtrees(src, only)
+	if err != nil {
+		return Selection{}, err
+	}
+	return Selection{rt: rt, sel: doc.Selection}, nil
 }
 
 // ParseHTML parses the provided HTML source into a Selection object.
@@ -100,7 +119,7 @@ func (s Selection) varargFnCall(arg interface{},
 		return Selection{s.rt, selFilter(v.sel), s.URL}
 
 	case string:
-		return Selection{s.rt, strFilter(v), s.URL}
+		return Selection{s.rt, strFilter(rewriteSelector(v)), s.URL}
 
 	case Element:
 		return Selection{s.rt, nodeFilter(v.node), s.URL}
@@ -119,7 +138,7 @@ func (s Selection) adjacent(unfiltered func() *goquery.Selection,
 	def ...string,
 ) Selection {
 	if len(def) > 0 {
-		return Selection{s.rt, filtered(def[0]), s.URL}
+		return Selection{s.rt, filtered(rewriteSelector(def[0])), s.URL}
 	}
 
 	return Selection{s.rt, unfiltered(), s.URL}
@@ -137,7 +156,7 @@ func (s Selection) adjacentUntil(until func(string) *goquery.Selection,
 	case 1:
 		switch selector := def[0].Export().(type) {
 		case string:
-			return Selection{s.rt, until(selector), s.URL}
+			return Selection{s.rt, until(rewriteSelector(selector)), s.URL}
 
 		case Selection:
 			return Selection{s.rt, untilSelection(selector.sel), s.URL}
@@ -146,11 +165,11 @@ func (s Selection) adjacentUntil(until func(string) *goquery.Selection,
 			return Selection{s.rt, until(""), s.URL}
 		}
 	case 2:
-		filter := def[1].String()
+		filter := rewriteSelector(def[1].String())
 
 		switch selector := def[0].Export().(type) {
 		case string:
-			return Selection{s.rt, filteredUntil(filter, selector), s.URL}
+			return Selection{s.rt, filteredUntil(filter, rewriteSelector(selector)), s.URL}
 
 		case Selection:
 			return Selection{s.rt, filteredUntilSelection(filter, selector.sel), s.URL}
@@ -353,7 +372,7 @@ func (s Selection) Children(def ...string) Selection {
 		return Selection{s.rt, s.sel.Children(), s.URL}
 	}
 
-	return Selection{s.rt, s.sel.ChildrenFiltered(def[0]), s.URL}
+	return Selection{s.rt, s.sel.ChildrenFiltered(rewriteSelector(def[0])), s.URL}
 }
 
 func (s Selection) Each(v sobek.Value) Selection {
@@ -374,7 +393,7 @@ func (s Selection) Each(v sobek.Value) Selection {
 func (s Selection) Filter(v sobek.Value) Selection {
 	switch val := v.Export().(type) {
 	case string:
-		return Selection{s.rt, s.sel.Filter(val), s.URL}
+		return Selection{s.rt, s.sel.Filter(rewriteSelector(val)), s.URL}
 
 	case Selection:
 		return Selection{s.rt, s.sel.FilterSelection(val.sel), s.URL}
@@ -391,7 +410,7 @@ func (s Selection) Filter(v sobek.Value) Selection {
 func (s Selection) Is(v sobek.Value) bool {
 	switch val := v.Export().(type) {
 	case string:
-		return s.sel.Is(val)
+		return s.sel.Is(rewriteSelector(val))
 
 	case Selection:
 		return s.sel.IsSelection(val.sel)
@@ -471,7 +490,7 @@ func (s Selection) Index(def ...sobek.Value) int {
 		return s.sel.IndexOfSelection(v.sel)
 
 	case string:
-		return s.sel.IndexSelector(v)
+		return s.sel.IndexSelector(rewriteSelector(v))
 
 	case Element:
 		return s.sel.IndexOfNode(v.node)
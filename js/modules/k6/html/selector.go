@@ -0,0 +1,125 @@
+package html
+
+import (
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	gohtml "golang.org/x/net/html"
+)
+
+// rewriteSelector rewrites the :is() and :where() pseudo-classes, which aren't
+// supported by the selector engine, as the equivalent :not(:not()). They
+// match the same elements, and their different specificity doesn't matter
+// for selecting.
+func rewriteSelector(s string) string {
+	lower := strings.ToLower(s)
+	if !strings.Contains(lower, ":is(") && !strings.Contains(lower, ":where(") {
+		return s
+	}
+
+	var b strings.Builder
+	var quote byte
+	var parens []bool // whether each open parenthesis was rewritten
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '\\' && i+1 < len(s):
+			b.WriteByte(c)
+			i++
+			c = s[i]
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == ':' && (i == 0 || s[i-1] != ':'):
+			if name, ok := rewrittenPseudoClass(s[i+1:]); ok {
+				b.WriteString(":not(:not(")
+				parens = append(parens, true)
+				i += len(name) + 1
+				continue
+			}
+		case c == '(':
+			parens = append(parens, false)
+		case c == ')' && len(parens) > 0:
+			if parens[len(parens)-1] {
+				b.WriteByte(')')
+			}
+			parens = parens[:len(parens)-1]
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+func rewrittenPseudoClass(s string) (string, bool) {
+	for _, name := range []string{"is", "where"} {
+		if len(s) > len(name) && strings.EqualFold(s[:len(name)], name) && s[len(name)] == '(' {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// voidElements can't have any content, so they don't have end tags.
+var voidElements = map[string]bool{ //nolint:gochecknoglobals
+	"area": true, "base": true, "br": true, "col": true, "embed": true, "hr": true, "img": true,
+	"input": true, "keygen": true, "link": true, "meta": true, "param": true, "source": true,
+	"track": true, "wbr": true,
+}
+
+// parseSubtrees parses only the elements with the given tag names, together
+// with their content. The rest of the source is only tokenized, without
+// building its tree, which is much faster for big documents, when only a few
+// elements, like the forms, are needed.
+func parseSubtrees(src string, tags []string) (*goquery.Document, error) {
+	wanted := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		wanted[strings.ToLower(tag)] = true
+	}
+
+	var subtrees strings.Builder
+	var current string // the tag name of the subtree being copied
+	var depth int
+	tokenizer := gohtml.NewTokenizer(strings.NewReader(src))
+	for {
+		tokenType := tokenizer.Next()
+		if tokenType == gohtml.ErrorToken {
+			if err := tokenizer.Err(); !errors.Is(err, io.EOF) {
+				return nil, err
+			}
+			break
+		}
+		raw := string(tokenizer.Raw())
+		if tokenType != gohtml.StartTagToken && tokenType != gohtml.EndTagToken &&
+			tokenType != gohtml.SelfClosingTagToken {
+			if current != "" {
+				subtrees.WriteString(raw)
+			}
+			continue
+		}
+
+		name, _ := tokenizer.TagName()
+		switch {
+		case current == "" && wanted[string(name)]:
+			subtrees.WriteString(raw)
+			if tokenType == gohtml.StartTagToken && !voidElements[string(name)] {
+				current, depth = string(name), 1
+			}
+		case current != "":
+			subtrees.WriteString(raw)
+			if string(name) != current || tokenType == gohtml.SelfClosingTagToken {
+				continue
+			}
+			if tokenType == gohtml.StartTagToken {
+				depth++
+			} else if depth--; depth == 0 {
+				current = ""
+			}
+		}
+	}
+	return goquery.NewDocumentFromReader(strings.NewReader(subtrees.String()))
+}
@@ -0,0 +1,74 @@
+package html
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRewriteSelector(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]string{
+		"form input[name=csrf]":        "form input[name=csrf]",
+		":is(h1, h2) > a":              ":not(:not(h1, h2)) > a",
+		"div:WHERE(.a, :is(.b)) p":     "div:not(:not(.a, :not(:not(.b)))) p",
+		`a[title=":is(x)"]:is(.c)`:     `a[title=":is(x)"]:not(:not(.c))`,
+		"li:not(:is(.x)):nth-child(2)": "li:not(:not(:not(.x))):nth-child(2)",
+		`a\:is(b)`:                     `a\:is(b)`,
+	}
+	for input, expected := range testCases {
+		assert.Equal(t, expected, rewriteSelector(input), input)
+	}
+}
+
+func TestModernSelectors(t *testing.T) {
+	t.Parallel()
+	rt := getTestRuntimeWithDoc(t, `<html><body>
+		<ul>
+			<li class="a">one</li>
+			<li lang="en-US">two</li>
+			<li class="b" data-id="item-3">three</li>
+		</ul>
+		<h2 class="x">four</h2>
+	</body></html>`)
+
+	testCases := map[string]string{
+		`doc.find("ul :is(.a, .b)").text()`:               "onethree",
+		`doc.find(":where(ul, h2):is(.x, ul)").size()`:    "2",
+		`doc.find("li:nth-of-type(2)").text()`:            "two",
+		`doc.find("li[lang|=en]").text()`:                 "two",
+		`doc.find("li[data-id^=item][class=B i]").text()`: "three",
+		`doc.find("li").filter(":is(.b)").text()`:         "three",
+		`doc.find("li").is(":where(.a)")`:                 "true",
+		`doc.find("ul").children(":is(.a)").text()`:       "one",
+	}
+	for script, expected := range testCases {
+		v, err := rt.RunString(script)
+		require.NoError(t, err, script)
+		assert.Equal(t, expected, v.String(), script)
+	}
+}
+
+func TestParseHTMLOnly(t *testing.T) {
+	t.Parallel()
+	rt, _ := getTestModuleInstance(t)
+
+	v, err := rt.RunString(`
+		const doc = html.parseHTML(` + "`" + `<html><head><meta name="csrf" content="t0k3n"><script>"<form>"</script></head>
+			<body><div><form id="f"><div><input name="a" value="1"></div><br/></form></div>
+			<p>not parsed</p><FORM id="g"></FORM></body></html>` + "`" + `, { only: ['form', 'meta'] });
+		[
+			doc.find('meta[name=csrf]').attr('content'),
+			doc.find('form').map((_, f) => f.attr('id')).join(),
+			doc.find('#f input').attr('value'),
+			doc.find('p, script').size(),
+		].join(' ')
+	`)
+	require.NoError(t, err)
+	assert.Equal(t, "t0k3n f,g 1 0", v.String())
+
+	_, err = rt.RunString(`html.parseHTML('<p></p>', { only: 'p' })`)
+	assert.ErrorContains(t, err, "the only option must be an array of tag names")
+}
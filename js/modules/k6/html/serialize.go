@@ -1,10 +1,14 @@
 package html
 
 import (
+	"errors"
 	neturl "net/url"
+	"strings"
 
 	"github.com/PuerkitoBio/goquery"
 	"github.com/grafana/sobek"
+
+	"go.k6.io/k6/js/common"
 )
 
 type FormValue struct {
@@ -59,7 +63,10 @@ func (s Selection) SerializeObject() map[string]sobek.Value {
 }
 
 func (s Selection) Serialize() string {
-	formValues := s.SerializeArray()
+	return encodeFormValues(s.SerializeArray()).Encode()
+}
+
+func encodeFormValues(formValues []FormValue) neturl.Values {
 	urlValues := make(neturl.Values, len(formValues))
 	for i := range formValues {
 		formValue := formValues[i]
@@ -71,5 +78,78 @@ func (s Selection) Serialize() string {
 			urlValues[formValue.Name] = v
 		}
 	}
-	return urlValues.Encode()
+	return urlValues
+}
+
+// FormRequest returns the method, the URL and the body of the request, which
+// submits the first selected form, or the form of the first selected element,
+// e.g. a button. The values of the fields, like a CSRF token, are kept, and
+// the given ones override or extend them, e.g. formRequest({ user: 'admin' }).
+// The fields of GET forms are sent in the query of the URL, without a body.
+func (s Selection) FormRequest(fields ...sobek.Value) map[string]any {
+	form := s.sel.First().Closest("form")
+	if form.Length() == 0 {
+		common.Throw(s.rt, errors.New("the selection doesn't contain a form"))
+	}
+
+	formValues := Selection{s.rt, form, s.URL}.SerializeArray()
+	if len(fields) > 0 && !common.IsNullish(fields[0]) {
+		obj := fields[0].ToObject(s.rt)
+		for _, name := range obj.Keys() {
+			formValues = setFormValue(formValues, FormValue{Name: name, Value: obj.Get(name)})
+		}
+	}
+
+	method := strings.ToUpper(form.AttrOr("method", "GET"))
+	if method != "POST" {
+		method = "GET"
+	}
+	url := s.resolveURL(form.AttrOr("action", ""))
+	if method == "GET" {
+		if u, err := neturl.Parse(url); err == nil {
+			u.RawQuery = encodeFormValues(formValues).Encode()
+			url = u.String()
+		}
+		return map[string]any{"method": method, "url": url, "body": nil}
+	}
+
+	body := make(map[string]sobek.Value, len(formValues))
+	for _, formValue := range formValues {
+		body[formValue.Name] = formValue.Value
+	}
+	return map[string]any{"method": method, "url": url, "body": body}
+}
+
+// setFormValue replaces the first value with the same name, and removes the
+// others, or appends the value, if there isn't one.
+func setFormValue(formValues []FormValue, value FormValue) []FormValue {
+	result := formValues[:0]
+	found := false
+	for _, formValue := range formValues {
+		if formValue.Name != value.Name {
+			result = append(result, formValue)
+		} else if !found {
+			result = append(result, value)
+			found = true
+		}
+	}
+	if !found {
+		result = append(result, value)
+	}
+	return result
+}
+
+// resolveURL resolves the URL relative to the one of the document, if it's
+// known, and an empty URL as the one of the document, like the browsers do
+// with the form actions.
+func (s Selection) resolveURL(url string) string {
+	base, err := neturl.Parse(s.URL)
+	if s.URL == "" || err != nil {
+		return url
+	}
+	ref, err := neturl.Parse(url)
+	if err != nil {
+		return url
+	}
+	return base.ResolveReference(ref).String()
 }
@@ -5,6 +5,7 @@ import (
 
 	"github.com/grafana/sobek"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 const testSerializeHTML = `
@@ -183,3 +184,52 @@ func TestSerialize(t *testing.T) {
 		}
 	})
 }
+
+func TestFormRequest(t *testing.T) {
+	t.Parallel()
+
+	const loginHTML = `<html><body>
+	<form id="login" method="post" action="/login?next=home">
+		<input type="hidden" name="csrf" value="t0k3n" />
+		<input type="text" name="user" value="" />
+		<input type="password" name="password" />
+		<button id="submit" type="submit">Log in</button>
+	</form>
+	<form id="search" action="search">
+		<input type="text" name="q" value="k6" />
+	</form>
+	</body></html>`
+
+	rt, mi := getTestModuleInstance(t)
+	sel, err := mi.parseHTML(loginHTML, sobek.Undefined())
+	require.NoError(t, err)
+	sel.URL = "https://example.com/account/"
+	require.NoError(t, rt.Set("doc", sel))
+
+	t.Run("post", func(t *testing.T) {
+		v, err := rt.RunString(`JSON.stringify(doc.find("#submit").formRequest({ user: "admin", password: "secret" }))`)
+		if assert.NoError(t, err) {
+			assert.JSONEq(t, `{
+				"method": "POST",
+				"url": "https://example.com/login?next=home",
+				"body": {"csrf": "t0k3n", "user": "admin", "password": "secret"}
+			}`, v.String())
+		}
+	})
+
+	t.Run("get", func(t *testing.T) {
+		v, err := rt.RunString(`JSON.stringify(doc.find("#search").formRequest({ page: "2" }))`)
+		if assert.NoError(t, err) {
+			assert.JSONEq(t, `{
+				"method": "GET",
+				"url": "https://example.com/account/search?page=2&q=k6",
+				"body": null
+			}`, v.String())
+		}
+	})
+
+	t.Run("no form", func(t *testing.T) {
+		_, err := rt.RunString(`doc.find("body").formRequest()`)
+		assert.ErrorContains(t, err, "the selection doesn't contain a form")
+	})
+}
@@ -54,6 +54,37 @@ func TestAsyncRequest(t *testing.T) {
 				`)))
 		assert.NoError(t, err)
 	})
+	t.Run("SubmitFormAndClickLink", func(t *testing.T) {
+		t.Parallel()
+		ts := newTestCase(t)
+
+		sr := ts.tb.Replacer.Replace
+		_, err := ts.runtime.RunOnEventLoop(wrapInAsyncLambda(sr(`
+				var res = await http.asyncRequest("GET", "HTTPBIN_URL/forms/post");
+				res = await res.submitFormAsync({
+					fields: { custname: "test" },
+					fieldsBySelector: { "input[type=email]": "test@example.com", ":is(textarea)": "hi" },
+				});
+				var data = res.json().form;
+				if (data.custname[0] !== "test" || data.custemail[0] !== "test@example.com" || data.comments[0] !== "hi") {
+					throw new Error("incorrect body: " + JSON.stringify(data));
+				}
+
+				var links = await http.asyncRequest("GET", "HTTPBIN_URL/links/10/0");
+				res = await links.clickLinkAsync({ selector: "a:nth-child(4)" });
+				if (res.url != "HTTPBIN_URL/links/10/4") { throw new Error("wrong url: " + res.url); }
+
+				var rejected = await links.clickLinkAsync({ selector: "a#doesNotExist" }).catch((e) => e);
+				if (!String(rejected).includes("no element found for selector 'a#doesNotExist'")) {
+					throw new Error("unexpected result: " + rejected);
+				}
+				rejected = await links.submitFormAsync({ fieldsBySelector: { "#nope": "x" } }).catch((e) => e);
+				if (!String(rejected).includes("no form found for selector 'form'")) {
+					throw new Error("unexpected result: " + rejected);
+				}
+				`)))
+		assert.NoError(t, err)
+	})
 	t.Run("Concurrent", func(t *testing.T) {
 		t.Parallel()
 		ts := newTestCase(t)
@@ -0,0 +1,168 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/grafana/sobek"
+
+	"go.k6.io/k6/js/common"
+	"go.k6.io/k6/lib/netext/httpext"
+	"go.k6.io/k6/lib/types"
+)
+
+// clientDefaults are the defaults of the requests of a client made with
+// http.newClient(), which the params of each request can override.
+type clientDefaults struct {
+	baseURL string
+	headers map[string]string
+	tags    map[string]sobek.Value
+	timeout time.Duration
+}
+
+// exportMethods sets the request methods of the client with the given
+// function, both for the module's default client and the new ones.
+func (c *Client) exportMethods(set func(name string, value any)) {
+	set("get", func(url sobek.Value, args ...sobek.Value) (*Response, error) {
+		// http.get(url, params) doesn't have a body argument, so we add undefined
+		// as the third argument to http.request(method, url, body, params)
+		args = append([]sobek.Value{sobek.Undefined()}, args...)
+		return c.Request(http.MethodGet, url, args...)
+	})
+	set("head", func(url sobek.Value, args ...sobek.Value) (*Response, error) {
+		// http.head(url, params) doesn't have a body argument, so we add undefined
+		// as the third argument to http.request(method, url, body, params)
+		args = append([]sobek.Value{sobek.Undefined()}, args...)
+		return c.Request(http.MethodHead, url, args...)
+	})
+	set("post", c.getMethodClosure(http.MethodPost))
+	set("put", c.getMethodClosure(http.MethodPut))
+	set("patch", c.getMethodClosure(http.MethodPatch))
+	set("del", c.getMethodClosure(http.MethodDelete))
+	set("options", c.getMethodClosure(http.MethodOptions))
+	set("request", c.Request)
+	set("asyncRequest", c.asyncRequest)
+	set("batch", c.Batch)
+	set("poll", c.Poll)
+	set("paginate", c.Paginate)
+	set("sequence", c.Sequence)
+	set("setResponseCallback", c.SetResponseCallback)
+	set("setRequestInterceptor", c.SetRequestInterceptor)
+	set("setResponseInterceptor", c.SetResponseInterceptor)
+}
+
+// newClient returns a client, whose requests have the given defaults. It
+// starts with the response callback and the interceptors of the module's
+// default client.
+func (mi *ModuleInstance) newClient(options sobek.Value) (*sobek.Object, error) {
+	rt := mi.vu.Runtime()
+	defaults, err := parseClientDefaults(rt, options)
+	if err != nil {
+		return nil, err
+	}
+	c := &Client{
+		moduleInstance:      mi,
+		responseCallback:    mi.defaultClient.responseCallback,
+		requestInterceptor:  mi.defaultClient.requestInterceptor,
+		responseInterceptor: mi.defaultClient.responseInterceptor,
+		defaults:            defaults,
+	}
+
+	obj := rt.NewObject()
+	c.exportMethods(func(name string, value any) {
+		if err == nil {
+			err = obj.Set(name, value)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+func parseClientDefaults(rt *sobek.Runtime, options sobek.Value) (*clientDefaults, error) {
+	defaults := &clientDefaults{}
+	if common.IsNullish(options) {
+		return defaults, nil
+	}
+	opts := options.ToObject(rt)
+	for _, k := range opts.Keys() {
+		v := opts.Get(k)
+		if common.IsNullish(v) {
+			continue
+		}
+		switch k {
+		case "baseURL":
+			u, err := url.Parse(v.String())
+			if err != nil {
+				return nil, fmt.Errorf("invalid baseURL: %w", err)
+			}
+			if !u.IsAbs() {
+				return nil, fmt.Errorf("the baseURL %q must be an absolute URL", v.String())
+			}
+			defaults.baseURL = v.String()
+		case "headers":
+			headers := v.ToObject(rt)
+			defaults.headers = make(map[string]string, len(headers.Keys()))
+			for _, key := range headers.Keys() {
+				defaults.headers[key] = headers.Get(key).String()
+			}
+		case "tags":
+			tags := v.ToObject(rt)
+			defaults.tags = make(map[string]sobek.Value, len(tags.Keys()))
+			for _, key := range tags.Keys() {
+				defaults.tags[key] = tags.Get(key)
+			}
+		case "timeout":
+			timeout, err := types.GetDurationValue(v.Export())
+			if err != nil {
+				return nil, fmt.Errorf("invalid timeout value: %w", err)
+			}
+			defaults.timeout = timeout
+		default:
+			return nil, fmt.Errorf("unknown client option %q", k)
+		}
+	}
+	return defaults, nil
+}
+
+// apply sets the defaults on the request, before its params are applied.
+func (d *clientDefaults) apply(result *httpext.ParsedHTTPRequest) error {
+	for key, value := range d.headers {
+		if strings.ToLower(key) == "host" {
+			result.Req.Host = value
+		}
+		result.Req.Header.Set(key, value)
+	}
+	for key, value := range d.tags {
+		if err := common.ApplyCustomUserTag(&result.TagsAndMeta, key, value); err != nil {
+			return fmt.Errorf("invalid HTTP request metric tags of the client: %w", err)
+		}
+	}
+	if d.timeout > 0 {
+		result.Timeout = d.timeout
+	}
+	return nil
+}
+
+// resolveURL joins the relative URLs with the base URL, by appending them to
+// its path, so both "users" and "/users" are under https://example.com/api.
+// Together with the URL, the name of a templated one is joined too.
+func (d *clientDefaults) resolveURL(u httpext.URL) (httpext.URL, error) {
+	if d.baseURL == "" || u.GetURL().IsAbs() || u.GetURL().Host != "" {
+		return u, nil
+	}
+	join := func(s string) string {
+		if s == "" {
+			return d.baseURL
+		}
+		if strings.HasPrefix(s, "?") || strings.HasPrefix(s, "#") {
+			return d.baseURL + s
+		}
+		return strings.TrimRight(d.baseURL, "/") + "/" + strings.TrimLeft(s, "/")
+	}
+	return httpext.NewURL(join(u.URL), join(u.Name))
+}
@@ -0,0 +1,152 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/metrics"
+)
+
+// newClientTestCase returns a test case, whose /api/ endpoints respond with
+// the path and the headers of the requests.
+func newClientTestCase(t *testing.T) *httpTestCase {
+	t.Helper()
+	ts := newTestCase(t)
+	ts.tb.Mux.HandleFunc("/api/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"path":    r.URL.RequestURI(),
+			"headers": r.Header,
+		})
+	})
+	return ts
+}
+
+func TestNewClient(t *testing.T) {
+	t.Parallel()
+
+	t.Run("defaults", func(t *testing.T) {
+		t.Parallel()
+		ts := newClientTestCase(t)
+		sr := ts.tb.Replacer.Replace
+
+		_, err := ts.runtime.VU.Runtime().RunString(sr(`
+			var api = http.newClient({
+				baseURL: "HTTPBIN_URL/api/v1",
+				headers: { "X-Team": "checkout", "Accept": "application/json" },
+				tags: { team: "checkout", endpoint: "default" },
+				timeout: "5s",
+			});
+			var res = api.get("/items?page=1", { headers: { "Accept": "text/plain" }, tags: { endpoint: "items" } });
+			var body = res.json();
+			if (body.path != "/api/v1/items?page=1") {
+				throw new Error("unexpected path: " + body.path);
+			}
+			if (body.headers["X-Team"] != "checkout") {
+				throw new Error("unexpected X-Team header: " + body.headers["X-Team"]);
+			}
+			if (body.headers["Accept"] != "text/plain") {
+				throw new Error("unexpected Accept header: " + body.headers["Accept"]);
+			}
+
+			res = api.post("HTTPBIN_URL/api/absolute", "data");
+			if (res.json().path != "/api/absolute") {
+				throw new Error("unexpected path: " + res.json().path);
+			}
+
+			res = http.get("HTTPBIN_URL/headers");
+			if (res.json().headers["X-Team"] !== undefined) {
+				throw new Error("the defaults were applied to the module's requests");
+			}
+		`))
+		require.NoError(t, err)
+
+		var endpoints []string
+		for _, sc := range metrics.GetBufferedSamples(ts.samples) {
+			for _, s := range sc.GetSamples() {
+				if s.Metric.Name != metrics.HTTPReqsName {
+					continue
+				}
+				team, _ := s.Tags.Get("team")
+				endpoint, _ := s.Tags.Get("endpoint")
+				endpoints = append(endpoints, team+"/"+endpoint)
+			}
+		}
+		assert.Equal(t, []string{"checkout/items", "checkout/default", "/"}, endpoints)
+	})
+
+	t.Run("templated URL", func(t *testing.T) {
+		t.Parallel()
+		ts := newClientTestCase(t)
+		sr := ts.tb.Replacer.Replace
+
+		_, err := ts.runtime.VU.Runtime().RunString(sr(`
+			var api = http.newClient({ baseURL: "HTTPBIN_URL/api/" });
+			var id = 42;
+			var res = api.get(http.url` + "`/items/${id}`" + `);
+			if (res.json().path != "/api/items/42") {
+				throw new Error("unexpected path: " + res.json().path);
+			}
+		`))
+		require.NoError(t, err)
+
+		var names []string
+		for _, sc := range metrics.GetBufferedSamples(ts.samples) {
+			for _, s := range sc.GetSamples() {
+				if s.Metric.Name == metrics.HTTPReqsName {
+					name, _ := s.Tags.Get("name")
+					names = append(names, name)
+				}
+			}
+		}
+		assert.Equal(t, []string{sr("HTTPBIN_URL/api/items/${}")}, names)
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		t.Parallel()
+		ts := newTestCase(t)
+		sr := ts.tb.Replacer.Replace
+
+		start := time.Now()
+		_, err := ts.runtime.VU.Runtime().RunString(sr(`
+			var api = http.newClient({ timeout: 500 });
+			api.get("HTTPBIN_URL/delay/10");
+		`))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "request timeout")
+		assert.Less(t, time.Since(start), 5*time.Second)
+	})
+
+	t.Run("invalid options", func(t *testing.T) {
+		t.Parallel()
+		ts := newTestCase(t)
+
+		for script, msg := range map[string]string{
+			`http.newClient({ baseURL: "/api" })`:     `the baseURL "/api" must be an absolute URL`,
+			`http.newClient({ timeout: "forever" })`:  "invalid timeout value",
+			`http.newClient({ baseUrl: "http://a" })`: `unknown client option "baseUrl"`,
+		} {
+			_, err := ts.runtime.VU.Runtime().RunString(script)
+			require.Error(t, err, script)
+			assert.Contains(t, err.Error(), msg)
+		}
+	})
+
+	t.Run("init context", func(t *testing.T) {
+		t.Parallel()
+		runtime, _ := getTestModuleInstance(t)
+
+		_, err := runtime.VU.Runtime().RunString(`
+			var api = http.newClient({ baseURL: "https://example.com" });
+			if (typeof api.batch !== "function" || typeof api.asyncRequest !== "function") {
+				throw new Error("the client doesn't have all the request methods");
+			}
+		`)
+		require.NoError(t, err)
+	})
+}
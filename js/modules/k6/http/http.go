@@ -1,7 +1,6 @@
 package http
 
 import (
-	"net/http"
 	"net/http/cookiejar"
 
 	"github.com/grafana/sobek"
@@ -24,6 +23,9 @@ type ModuleInstance struct {
 	rootModule    *RootModule
 	defaultClient *Client
 	exports       *sobek.Object
+	// intercepting is set while an interceptor is called, so the requests it
+	// makes aren't intercepted
+	intercepting bool
 }
 
 var (
@@ -68,27 +70,8 @@ func (r *RootModule) NewModuleInstance(vu modules.VU) modules.Instance {
 	// TODO: refactor so the Client actually has better APIs and these are
 	// wrappers (facades) that convert the old k6 idiosyncratic APIs to the new
 	// proper Client ones that accept Request objects and don't suck
-	mustExport("get", func(url sobek.Value, args ...sobek.Value) (*Response, error) {
-		// http.get(url, params) doesn't have a body argument, so we add undefined
-		// as the third argument to http.request(method, url, body, params)
-		args = append([]sobek.Value{sobek.Undefined()}, args...)
-		return mi.defaultClient.Request(http.MethodGet, url, args...)
-	})
-	mustExport("head", func(url sobek.Value, args ...sobek.Value) (*Response, error) {
-		// http.head(url, params) doesn't have a body argument, so we add undefined
-		// as the third argument to http.request(method, url, body, params)
-		args = append([]sobek.Value{sobek.Undefined()}, args...)
-		return mi.defaultClient.Request(http.MethodHead, url, args...)
-	})
-	mustExport("post", mi.defaultClient.getMethodClosure(http.MethodPost))
-	mustExport("put", mi.defaultClient.getMethodClosure(http.MethodPut))
-	mustExport("patch", mi.defaultClient.getMethodClosure(http.MethodPatch))
-	mustExport("del", mi.defaultClient.getMethodClosure(http.MethodDelete))
-	mustExport("options", mi.defaultClient.getMethodClosure(http.MethodOptions))
-	mustExport("request", mi.defaultClient.Request)
-	mustExport("asyncRequest", mi.defaultClient.asyncRequest)
-	mustExport("batch", mi.defaultClient.Batch)
-	mustExport("setResponseCallback", mi.defaultClient.SetResponseCallback)
+	mi.defaultClient.exportMethods(mustExport)
+	mustExport("newClient", mi.newClient)
 
 	mustExport("expectedStatuses", mi.expectedStatuses) // TODO: refactor?
 
@@ -180,4 +163,9 @@ func (mi *ModuleInstance) URL(parts []string, pieces ...string) (httpext.URL, er
 type Client struct {
 	moduleInstance   *ModuleInstance
 	responseCallback func(int) bool
+	// interceptors, set by setRequestInterceptor and setResponseInterceptor
+	requestInterceptor  sobek.Callable
+	responseInterceptor sobek.Callable
+	// defaults of the requests, if the client was made with http.newClient()
+	defaults *clientDefaults
 }
@@ -0,0 +1,154 @@
+package http
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/grafana/sobek"
+
+	"go.k6.io/k6/js/common"
+	"go.k6.io/k6/lib/netext/httpext"
+)
+
+// interceptorError is an exception thrown by an interceptor, which is always
+// thrown again, instead of failing only the request.
+type interceptorError struct {
+	err error
+}
+
+func (e interceptorError) Error() string {
+	return e.err.Error()
+}
+
+func (e interceptorError) Unwrap() error {
+	return e.err
+}
+
+// SetRequestInterceptor sets the function, which is called with every request
+// of the client before it's sent, and which can change its method, url,
+// headers and body, e.g. to set a refreshed token. null removes it.
+func (c *Client) SetRequestInterceptor(val sobek.Value) {
+	c.requestInterceptor = c.toInterceptor(val)
+}
+
+// SetResponseInterceptor sets the function, which is called with every
+// response of the client before it's returned, e.g. to check them centrally.
+// null removes it.
+func (c *Client) SetResponseInterceptor(val sobek.Value) {
+	c.responseInterceptor = c.toInterceptor(val)
+}
+
+func (c *Client) toInterceptor(val sobek.Value) sobek.Callable {
+	if common.IsNullish(val) {
+		return nil
+	}
+	fn, ok := sobek.AssertFunction(val)
+	if !ok {
+		common.Throw(c.moduleInstance.vu.Runtime(), fmt.Errorf("unsupported argument, expected a function or null"))
+	}
+	return fn
+}
+
+// intercept calls the interceptor, unless an interceptor of the VU is already
+// being called, so the requests made by the interceptors, e.g. for refreshing
+// a token, aren't intercepted.
+func (c *Client) intercept(fn sobek.Callable, args ...sobek.Value) error {
+	mi := c.moduleInstance
+	if fn == nil || mi.intercepting {
+		return nil
+	}
+	mi.intercepting = true
+	defer func() { mi.intercepting = false }()
+	if _, err := fn(sobek.Undefined(), args...); err != nil {
+		return interceptorError{err: err}
+	}
+	return nil
+}
+
+// interceptRequest calls the request interceptor with the method, the url, the
+// headers and the body of the request, and applies their changes to it.
+func (c *Client) interceptRequest(result *httpext.ParsedHTTPRequest) error {
+	if c.requestInterceptor == nil || c.moduleInstance.intercepting {
+		return nil
+	}
+	rt := c.moduleInstance.vu.Runtime()
+
+	headers := rt.NewObject()
+	for name, values := range result.Req.Header {
+		if err := headers.Set(name, strings.Join(values, ", ")); err != nil {
+			return err
+		}
+	}
+	var body sobek.Value = sobek.Null()
+	if result.Body != nil {
+		body = rt.ToValue(result.Body.String())
+	}
+	req := rt.NewObject()
+	for name, value := range map[string]any{
+		"method":  result.Req.Method,
+		"url":     result.URL.URL,
+		"headers": headers,
+		"body":    body,
+	} {
+		if err := req.Set(name, value); err != nil {
+			return err
+		}
+	}
+
+	if err := c.intercept(c.requestInterceptor, req); err != nil {
+		return err
+	}
+
+	if method := req.Get("method"); !common.IsNullish(method) {
+		result.Req.Method = strings.ToUpper(method.String())
+	}
+	if reqURL := req.Get("url").String(); reqURL != result.URL.URL {
+		// the name of a changed url is changed too, unless it was templated
+		name := result.URL.Name
+		if name == result.URL.URL {
+			name = reqURL
+		}
+		u, err := httpext.NewURL(reqURL, name)
+		if err != nil {
+			return interceptorError{err: fmt.Errorf("the request interceptor set an invalid url: %w", err)}
+		}
+		*result.URL = u
+		result.Req.URL = u.GetURL()
+	}
+	if v := req.Get("headers"); !common.IsNullish(v) {
+		headers := v.ToObject(rt)
+		result.Req.Header = make(http.Header, len(headers.Keys()))
+		result.Req.Host = ""
+		for _, name := range headers.Keys() {
+			value := headers.Get(name).String()
+			if strings.ToLower(name) == "host" {
+				result.Req.Host = value
+			}
+			result.Req.Header.Set(name, value)
+		}
+	}
+	// the body is replaced only when it was changed, so the binary ones aren't
+	// converted to strings
+	switch v := req.Get("body"); {
+	case common.IsNullish(v):
+		result.Body = nil
+	case body.StrictEquals(v):
+	default:
+		data, err := common.ToBytes(v.Export())
+		if err != nil {
+			return interceptorError{err: fmt.Errorf("the request interceptor set an invalid body: %w", err)}
+		}
+		result.Body = bytes.NewBuffer(data)
+	}
+	return nil
+}
+
+// interceptResponse calls the response interceptor with the response.
+func (c *Client) interceptResponse(resp *httpext.Response) error {
+	if c.responseInterceptor == nil {
+		return nil
+	}
+	return c.intercept(c.responseInterceptor, c.moduleInstance.vu.Runtime().ToValue(c.responseFromHTTPext(resp)))
+}
@@ -0,0 +1,159 @@
+package http
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/metrics"
+)
+
+func TestInterceptors(t *testing.T) {
+	t.Parallel()
+
+	t.Run("request", func(t *testing.T) {
+		t.Parallel()
+		ts := newClientTestCase(t)
+		sr := ts.tb.Replacer.Replace
+
+		_, err := ts.runtime.VU.Runtime().RunString(sr(`
+			var refreshes = 0;
+			var token = null;
+			http.setRequestInterceptor(function (req) {
+				if (token === null) {
+					// the requests of the interceptor aren't intercepted
+					var res = http.post("HTTPBIN_URL/api/token");
+					if (res.json().headers["Authorization"] !== undefined) {
+						throw new Error("the request of the interceptor was intercepted");
+					}
+					token = "token-" + (++refreshes);
+				}
+				req.headers["Authorization"] = "Bearer " + token;
+				delete req.headers["X-Debug"];
+				req.url = req.url.replace("/v1/", "/v2/");
+			});
+
+			var res = http.get("HTTPBIN_URL/api/v1/items", { headers: { "X-Debug": "1" } });
+			var body = res.json();
+			if (body.path != "/api/v2/items") {
+				throw new Error("unexpected path: " + body.path);
+			}
+			if (body.headers["Authorization"][0] != "Bearer token-1") {
+				throw new Error("unexpected Authorization header: " + body.headers["Authorization"]);
+			}
+			if (body.headers["X-Debug"] !== undefined) {
+				throw new Error("the removed header was sent");
+			}
+
+			token = null;
+			var api = http.newClient({ baseURL: "HTTPBIN_URL/api" });
+			body = api.get("/v1/users").json();
+			if (body.path != "/api/v2/users" || body.headers["Authorization"][0] != "Bearer token-2") {
+				throw new Error("the new client didn't start with the interceptor: " + JSON.stringify(body));
+			}
+
+			http.setRequestInterceptor(function (req) {
+				req.method = "post";
+				req.body = "changed";
+			});
+			res = http.request("PUT", "HTTPBIN_URL/post", "original");
+			if (res.json().data != "changed") {
+				throw new Error("unexpected request: " + res.body);
+			}
+
+			http.setRequestInterceptor(null);
+			body = http.get("HTTPBIN_URL/api/v1/items").json();
+			if (body.path != "/api/v1/items" || body.headers["Authorization"] !== undefined) {
+				throw new Error("the removed interceptor was called");
+			}
+		`))
+		require.NoError(t, err)
+
+		var names []string
+		for _, sc := range metrics.GetBufferedSamples(ts.samples) {
+			for _, s := range sc.GetSamples() {
+				if s.Metric.Name != metrics.HTTPReqsName {
+					continue
+				}
+				name, _ := s.Tags.Get("name")
+				names = append(names, name)
+			}
+		}
+		assert.Equal(t, []string{
+			sr("HTTPBIN_URL/api/token"),
+			sr("HTTPBIN_URL/api/v2/items"),
+			sr("HTTPBIN_URL/api/token"),
+			sr("HTTPBIN_URL/api/v2/users"),
+			sr("HTTPBIN_URL/post"),
+			sr("HTTPBIN_URL/api/v1/items"),
+		}, names)
+	})
+
+	t.Run("response", func(t *testing.T) {
+		t.Parallel()
+		ts := newClientTestCase(t)
+		sr := ts.tb.Replacer.Replace
+
+		_, err := ts.runtime.VU.Runtime().RunString(sr(`
+			var statuses = [];
+			http.setResponseInterceptor(function (res) {
+				statuses.push(res.status);
+			});
+			http.get("HTTPBIN_URL/status/200");
+			http.batch(["HTTPBIN_URL/status/404", "HTTPBIN_URL/status/500"]);
+			if (JSON.stringify(statuses) != "[200,404,500]") {
+				throw new Error("unexpected statuses: " + JSON.stringify(statuses));
+			}
+		`))
+		require.NoError(t, err)
+	})
+
+	t.Run("async", func(t *testing.T) {
+		t.Parallel()
+		ts := newClientTestCase(t)
+		sr := ts.tb.Replacer.Replace
+
+		err := ts.runtime.EventLoop.Start(func() error {
+			_, err := ts.runtime.VU.Runtime().RunString(sr(`
+				http.setRequestInterceptor(function (req) {
+					req.headers["X-Async"] = "yes";
+				});
+				var intercepted = false;
+				http.setResponseInterceptor(function (res) {
+					intercepted = res.json().headers["X-Async"][0] == "yes";
+				});
+				http.asyncRequest("GET", "HTTPBIN_URL/api/async");
+			`))
+			return err
+		})
+		require.NoError(t, err)
+		assert.True(t, ts.runtime.VU.Runtime().Get("intercepted").ToBoolean())
+	})
+
+	t.Run("exceptions", func(t *testing.T) {
+		t.Parallel()
+		ts := newClientTestCase(t)
+		sr := ts.tb.Replacer.Replace
+
+		_, err := ts.runtime.VU.Runtime().RunString(sr(`
+			http.setRequestInterceptor(function () {
+				throw new Error("no token");
+			});
+			http.get("HTTPBIN_URL/api/v1/items");
+		`))
+		require.ErrorContains(t, err, "no token")
+
+		_, err = ts.runtime.VU.Runtime().RunString(sr(`
+			http.setRequestInterceptor(null);
+			http.setResponseInterceptor(function () {
+				throw new Error("unexpected response");
+			});
+			http.get("HTTPBIN_URL/api/v1/items");
+		`))
+		require.ErrorContains(t, err, "unexpected response")
+
+		_, err = ts.runtime.VU.Runtime().RunString(`http.setRequestInterceptor("header")`)
+		require.ErrorContains(t, err, "expected a function or null")
+	})
+}
@@ -0,0 +1,524 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/sobek"
+
+	"go.k6.io/k6/internal/lib/jsonpath"
+	"go.k6.io/k6/js/common"
+	"go.k6.io/k6/lib/netext/httpext"
+	"go.k6.io/k6/lib/types"
+	"go.k6.io/k6/metrics"
+)
+
+// operationTag is the tag of the requests of a logical operation, like
+// polling, and of its http_operation_duration sample, with its name.
+const operationTag = "operation"
+
+const (
+	defaultPollInterval = time.Second
+	defaultPollTimeout  = 60 * time.Second
+)
+
+// operationOptions are the options common to all the logical operations.
+type operationOptions struct {
+	// name of the operation, the name of the URL of its first request by default
+	name string
+	// params of each request of the operation
+	params sobek.Value
+}
+
+// operation is a logical operation, made of multiple requests.
+type operation struct {
+	client *Client
+	operationOptions
+	start time.Time
+}
+
+func (c *Client) newOperation(options operationOptions) *operation {
+	return &operation{client: c, operationOptions: options, start: time.Now()}
+}
+
+// request makes a GET request of the operation, with its params.
+func (o *operation) request(url sobek.Value) (*Response, error) {
+	return o.do(http.MethodGet, url, nil, o.params)
+}
+
+// do makes a request of the operation, which is tagged with its name.
+func (o *operation) do(method string, url sobek.Value, body any, params sobek.Value) (*Response, error) {
+	c := o.client
+	state := c.moduleInstance.vu.State()
+	if state == nil {
+		return nil, ErrHTTPForbiddenInInitContext
+	}
+
+	req, err := c.parseRequest(method, url, body, params)
+	if err != nil {
+		return c.handleParseRequestError(err)
+	}
+	if o.name == "" {
+		o.name = req.URL.Name
+	}
+	req.TagsAndMeta.SetTag(operationTag, o.name)
+
+	resp, err := httpext.MakeRequest(c.moduleInstance.vu.Context(), state, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.processResponse(resp, req.ResponseType); err != nil {
+		return nil, err
+	}
+	return c.responseFromHTTPext(resp), nil
+}
+
+// finish emits the duration of the operation, since its start.
+func (o *operation) finish() {
+	state := o.client.moduleInstance.vu.State()
+	now := time.Now()
+	ctm := state.Tags.GetCurrentValues()
+	metrics.PushIfNotDone(o.client.moduleInstance.vu.Context(), state.Samples, metrics.Sample{
+		TimeSeries: metrics.TimeSeries{
+			Metric: state.BuiltinMetrics.HTTPOperationDuration,
+			Tags:   ctm.Tags.With(operationTag, o.name),
+		},
+		Time:     now,
+		Value:    metrics.D(now.Sub(o.start)),
+		Metadata: ctm.Metadata,
+	})
+}
+
+// parseOperationOptions parses the options of an operation, passing the ones
+// specific to it to the given function.
+func parseOperationOptions(
+	rt *sobek.Runtime, options sobek.Value, parse func(key string, value sobek.Value) error,
+) (operationOptions, error) {
+	var opts operationOptions
+	if common.IsNullish(options) {
+		return opts, nil
+	}
+	obj := options.ToObject(rt)
+	for _, k := range obj.Keys() {
+		v := obj.Get(k)
+		switch k {
+		case "name":
+			opts.name = v.String()
+		case "params":
+			opts.params = v
+		default:
+			if err := parse(k, v); err != nil {
+				return opts, err
+			}
+		}
+	}
+	return opts, nil
+}
+
+// Poll requests the URL every interval, until the until function returns true
+// for the response, which is returned. Since it's measured as one operation,
+// it's an error if it doesn't happen before the timeout.
+func (c *Client) Poll(url sobek.Value, options sobek.Value) (*Response, error) {
+	rt := c.moduleInstance.vu.Runtime()
+	var until sobek.Callable
+	interval, timeout := defaultPollInterval, defaultPollTimeout
+	opts, err := parseOperationOptions(rt, options, func(key string, value sobek.Value) error {
+		var err error
+		switch key {
+		case "until":
+			var ok bool
+			if until, ok = sobek.AssertFunction(value); !ok {
+				return errors.New("the until option must be a function")
+			}
+		case "interval":
+			interval, err = types.GetDurationValue(value.Export())
+		case "timeout":
+			timeout, err = types.GetDurationValue(value.Export())
+		default:
+			return fmt.Errorf("unknown poll option %q", key)
+		}
+		if err != nil {
+			return fmt.Errorf("invalid %s value: %w", key, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if until == nil {
+		return nil, errors.New("polling requires the until option")
+	}
+
+	op := c.newOperation(opts)
+	ctx := c.moduleInstance.vu.Context()
+	deadline := op.start.Add(timeout)
+	for {
+		resp, err := op.request(url)
+		if err != nil {
+			return nil, err
+		}
+		done, err := until(sobek.Undefined(), rt.ToValue(resp))
+		if err != nil {
+			return nil, err
+		}
+		if done.ToBoolean() {
+			op.finish()
+			return resp, nil
+		}
+
+		if time.Until(deadline) < interval {
+			return nil, fmt.Errorf("polling %s timed out after %s", op.name, timeout)
+		}
+		select {
+		case <-time.After(interval):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// Paginate returns an iterator over the responses of the pages, which starts
+// with the URL. The next function returns the URL of the page after the one of
+// the response, or a falsy value if it was the last one. The whole pagination
+// is measured as one operation, when the iteration is done or stopped.
+func (c *Client) Paginate(url sobek.Value, options sobek.Value) (*sobek.Object, error) {
+	rt := c.moduleInstance.vu.Runtime()
+	var next sobek.Callable
+	opts, err := parseOperationOptions(rt, options, func(key string, value sobek.Value) error {
+		if key != "next" {
+			return fmt.Errorf("unknown paginate option %q", key)
+		}
+		var ok bool
+		if next, ok = sobek.AssertFunction(value); !ok {
+			return errors.New("the next option must be a function")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if next == nil {
+		return nil, errors.New("pagination requires the next option")
+	}
+
+	var op *operation
+	var last *Response
+	done := false
+	result := func(value any, done bool) map[string]any {
+		return map[string]any{"value": value, "done": done}
+	}
+	stop := func() map[string]any {
+		if !done && op != nil {
+			op.finish()
+		}
+		done = true
+		return result(sobek.Undefined(), true)
+	}
+
+	iterator := rt.NewObject()
+	err = iterator.Set("next", func() (map[string]any, error) {
+		if done {
+			return result(sobek.Undefined(), true), nil
+		}
+		pageURL := url
+		if op == nil {
+			op = c.newOperation(opts)
+		} else {
+			nextURL, err := next(sobek.Undefined(), rt.ToValue(last))
+			if err != nil {
+				return nil, err
+			}
+			if !nextURL.ToBoolean() {
+				return stop(), nil
+			}
+			pageURL = nextURL
+		}
+		resp, err := op.request(pageURL)
+		if err != nil {
+			done = true
+			return nil, err
+		}
+		last = resp
+		return result(resp, false), nil
+	})
+	if err == nil {
+		err = iterator.Set("return", stop)
+	}
+	if err == nil {
+		err = iterator.SetSymbol(sobek.SymIterator, func() *sobek.Object { return iterator })
+	}
+	if err != nil {
+		return nil, err
+	}
+	return iterator, nil
+}
+
+// sequenceVariable matches the references to the variables of a sequence, like
+// ${token}, in the URLs, the bodies and the headers of its requests.
+var sequenceVariable = regexp.MustCompile(`\$\{(\w+)\}`)
+
+// headerSelector is the prefix of the selectors of the values to extract from
+// the headers of the responses, instead of their JSON bodies.
+const headerSelector = "header:"
+
+// sequenceStep is a request of a sequence, with the variables to extract from
+// its response.
+type sequenceStep struct {
+	method string
+	url    sobek.Value
+	body   any
+	params sobek.Value
+	// extract are the selectors of the variables, by their names
+	extract     map[string]sobek.Value
+	extractKeys []string
+}
+
+// Sequence makes the requests of the steps in order, where each request can
+// use the variables extracted from the responses before it. The whole chain is
+// measured as one operation, and its requests reuse the connections of the VU.
+// It returns the responses of the steps and the variables.
+func (c *Client) Sequence(steps sobek.Value, options sobek.Value) (*sobek.Object, error) {
+	rt := c.moduleInstance.vu.Runtime()
+	if c.moduleInstance.vu.State() == nil {
+		return nil, ErrHTTPForbiddenInInitContext
+	}
+	vars := make(map[string]sobek.Value)
+	opts, err := parseOperationOptions(rt, options, func(key string, value sobek.Value) error {
+		if key != "vars" {
+			return fmt.Errorf("unknown sequence option %q", key)
+		}
+		if common.IsNullish(value) {
+			return nil
+		}
+		obj := value.ToObject(rt)
+		for _, k := range obj.Keys() {
+			vars[k] = obj.Get(k)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if common.IsNullish(steps) || steps.ExportType().Kind() != reflect.Slice {
+		return nil, errors.New("the steps of a sequence must be an array")
+	}
+	stepsObj := steps.ToObject(rt)
+	n := int(stepsObj.Get("length").ToInteger())
+	if n == 0 {
+		return nil, errors.New("a sequence needs at least one step")
+	}
+
+	op := c.newOperation(opts)
+	responses := make([]*Response, 0, n)
+	for i := range n {
+		step, err := c.parseSequenceStep(stepsObj.Get(strconv.Itoa(i)), vars)
+		if err != nil {
+			return nil, fmt.Errorf("invalid step %d of the sequence: %w", i, err)
+		}
+		params := step.params
+		if common.IsNullish(params) {
+			params = op.params
+		}
+		if params, err = interpolateHeaders(rt, params, vars); err != nil {
+			return nil, fmt.Errorf("invalid step %d of the sequence: %w", i, err)
+		}
+		resp, err := op.do(step.method, step.url, step.body, params)
+		if err != nil {
+			return nil, err
+		}
+		if resp.Error != "" {
+			// the request failed, without throwing, so the next steps can't
+			// get the variables they depend on
+			return nil, fmt.Errorf("step %d of the sequence %s failed: %s", i, op.name, resp.Error)
+		}
+		responses = append(responses, resp)
+		for _, name := range step.extractKeys {
+			value, err := extractSequenceVariable(rt, resp, step.extract[name])
+			if err != nil {
+				return nil, fmt.Errorf("can't extract %q in step %d of the sequence %s: %w", name, i, op.name, err)
+			}
+			vars[name] = value
+		}
+	}
+	op.finish()
+
+	result := rt.NewObject()
+	if err := result.Set("responses", responses); err != nil {
+		return nil, err
+	}
+	if err := result.Set("vars", vars); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// parseSequenceStep parses a step, which is either an object with the request
+// and the variables to extract, or a function returning one, which is called
+// with the variables extracted so far.
+func (c *Client) parseSequenceStep(value sobek.Value, vars map[string]sobek.Value) (*sequenceStep, error) {
+	rt := c.moduleInstance.vu.Runtime()
+	if fn, ok := sobek.AssertFunction(value); ok {
+		var err error
+		if value, err = fn(sobek.Undefined(), rt.ToValue(vars)); err != nil {
+			return nil, err
+		}
+	}
+	if common.IsNullish(value) {
+		return nil, errors.New("it must be an object")
+	}
+
+	step := &sequenceStep{method: http.MethodGet}
+	obj := value.ToObject(rt)
+	for _, k := range obj.Keys() {
+		v := obj.Get(k)
+		var err error
+		switch k {
+		case "method":
+			step.method = strings.ToUpper(v.String())
+		case "url":
+			var url string
+			if url, err = interpolate(v.String(), vars); err == nil {
+				step.url = rt.ToValue(url)
+			}
+		case "body":
+			step.body, err = interpolateBody(v.Export(), vars)
+		case "params":
+			step.params = v
+		case "extract":
+			if common.IsNullish(v) {
+				continue
+			}
+			extract := v.ToObject(rt)
+			step.extractKeys = extract.Keys()
+			step.extract = make(map[string]sobek.Value, len(step.extractKeys))
+			for _, name := range step.extractKeys {
+				step.extract[name] = extract.Get(name)
+			}
+		default:
+			err = fmt.Errorf("unknown step option %q", k)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if step.url == nil {
+		return nil, errors.New("it must have an url")
+	}
+	return step, nil
+}
+
+// extractSequenceVariable extracts a variable from the response, with a
+// selector, which is either a function of the response, a header name after
+// the header: prefix, or a JSONPath query or GJSON path of the JSON body.
+func extractSequenceVariable(rt *sobek.Runtime, resp *Response, selector sobek.Value) (sobek.Value, error) {
+	var value sobek.Value
+	if fn, ok := sobek.AssertFunction(selector); ok {
+		var err error
+		if value, err = fn(sobek.Undefined(), rt.ToValue(resp)); err != nil {
+			return nil, err
+		}
+	} else {
+		sel := selector.String()
+		if name, ok := strings.CutPrefix(sel, headerSelector); ok {
+			header, found := resp.Headers[http.CanonicalHeaderKey(strings.TrimSpace(name))]
+			if !found {
+				return nil, fmt.Errorf("the response doesn't have the %s header", name)
+			}
+			value = rt.ToValue(header)
+		} else if jsonpath.IsQuery(sel) {
+			value = resp.JSONPath(sel)
+		} else {
+			value = resp.JSON(sel)
+		}
+	}
+	if common.IsNullish(value) {
+		return nil, errors.New("the selector didn't match any value")
+	}
+	return value, nil
+}
+
+// interpolate replaces the references to the variables in s with their values.
+func interpolate(s string, vars map[string]sobek.Value) (string, error) {
+	var err error
+	result := sequenceVariable.ReplaceAllStringFunc(s, func(ref string) string {
+		name := sequenceVariable.FindStringSubmatch(ref)[1]
+		value, ok := vars[name]
+		if !ok {
+			if err == nil {
+				err = fmt.Errorf("the variable %q isn't defined", name)
+			}
+			return ref
+		}
+		return value.String()
+	})
+	return result, err
+}
+
+// interpolateBody replaces the references to the variables in the body, when
+// it's a string, or in the string values of an object or an array.
+func interpolateBody(body any, vars map[string]sobek.Value) (any, error) {
+	var err error
+	switch data := body.(type) {
+	case string:
+		return interpolate(data, vars)
+	case map[string]any:
+		result := make(map[string]any, len(data))
+		for k, v := range data {
+			if result[k], err = interpolateBody(v, vars); err != nil {
+				return nil, err
+			}
+		}
+		return result, nil
+	case []any:
+		result := make([]any, len(data))
+		for i, v := range data {
+			if result[i], err = interpolateBody(v, vars); err != nil {
+				return nil, err
+			}
+		}
+		return result, nil
+	default:
+		return body, nil
+	}
+}
+
+// interpolateHeaders returns a copy of the params, with the references to the
+// variables in the values of their headers replaced.
+func interpolateHeaders(rt *sobek.Runtime, params sobek.Value, vars map[string]sobek.Value) (sobek.Value, error) {
+	if common.IsNullish(params) {
+		return params, nil
+	}
+	obj := params.ToObject(rt)
+	headers := obj.Get("headers")
+	if common.IsNullish(headers) {
+		return params, nil
+	}
+
+	result := rt.NewObject()
+	for _, k := range obj.Keys() {
+		if err := result.Set(k, obj.Get(k)); err != nil {
+			return nil, err
+		}
+	}
+	headersObj := headers.ToObject(rt)
+	interpolated := rt.NewObject()
+	for _, k := range headersObj.Keys() {
+		value, err := interpolate(headersObj.Get(k).String(), vars)
+		if err != nil {
+			return nil, err
+		}
+		if err = interpolated.Set(k, value); err != nil {
+			return nil, err
+		}
+	}
+	if err := result.Set("headers", interpolated); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
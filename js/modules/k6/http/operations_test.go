@@ -0,0 +1,284 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/metrics"
+)
+
+// operationSamples returns the operation tags of the requests and of the
+// operation durations in the samples.
+func operationSamples(t *testing.T, ts *httpTestCase) (requests []string, operations []string) {
+	t.Helper()
+	for _, sc := range metrics.GetBufferedSamples(ts.samples) {
+		for _, s := range sc.GetSamples() {
+			operation, _ := s.Tags.Get(operationTag)
+			switch s.Metric.Name {
+			case metrics.HTTPReqsName:
+				requests = append(requests, operation)
+			case metrics.HTTPOperationDurationName:
+				assert.Positive(t, s.Value)
+				operations = append(operations, operation)
+			}
+		}
+	}
+	return requests, operations
+}
+
+func TestPoll(t *testing.T) {
+	t.Parallel()
+
+	newPollTestCase := func(t *testing.T) *httpTestCase {
+		ts := newTestCase(t)
+		var polls atomic.Int64
+		ts.tb.Mux.HandleFunc("/job", func(w http.ResponseWriter, _ *http.Request) {
+			status := "pending"
+			if polls.Add(1) >= 3 {
+				status = "done"
+			}
+			_, _ = fmt.Fprintf(w, `{"status": %q}`, status)
+		})
+		return ts
+	}
+
+	t.Run("until", func(t *testing.T) {
+		t.Parallel()
+		ts := newPollTestCase(t)
+
+		_, err := ts.runtime.VU.Runtime().RunString(ts.tb.Replacer.Replace(`
+			var res = http.poll("HTTPBIN_URL/job", {
+				until: (res) => res.json().status === "done",
+				interval: "10ms",
+				name: "job",
+				params: { headers: { "X-Poll": "1" } },
+			});
+			if (res.json().status !== "done") {
+				throw new Error("unexpected status: " + res.json().status);
+			}
+			if (res.request.headers["X-Poll"][0] !== "1") {
+				throw new Error("the params weren't used");
+			}
+		`))
+		require.NoError(t, err)
+
+		requests, operations := operationSamples(t, ts)
+		assert.Equal(t, []string{"job", "job", "job"}, requests)
+		assert.Equal(t, []string{"job"}, operations)
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		t.Parallel()
+		ts := newPollTestCase(t)
+
+		_, err := ts.runtime.VU.Runtime().RunString(ts.tb.Replacer.Replace(`
+			http.poll("HTTPBIN_URL/job", { until: () => false, interval: "10ms", timeout: "15ms" });
+		`))
+		require.ErrorContains(t, err, ts.tb.Replacer.Replace("polling HTTPBIN_URL/job timed out after 15ms"))
+
+		requests, operations := operationSamples(t, ts)
+		assert.NotEmpty(t, requests)
+		assert.Empty(t, operations)
+	})
+
+	t.Run("invalid options", func(t *testing.T) {
+		t.Parallel()
+		ts := newPollTestCase(t)
+
+		for script, msg := range map[string]string{
+			`http.poll("HTTPBIN_URL/job")`:                                       "polling requires the until option",
+			`http.poll("HTTPBIN_URL/job", { until: true })`:                      "the until option must be a function",
+			`http.poll("HTTPBIN_URL/job", { until: () => true, every: "1s" })`:   `unknown poll option "every"`,
+			`http.poll("HTTPBIN_URL/job", { until: () => true, interval: "x" })`: "invalid interval value",
+		} {
+			_, err := ts.runtime.VU.Runtime().RunString(ts.tb.Replacer.Replace(script))
+			assert.ErrorContains(t, err, msg, script)
+		}
+	})
+}
+
+func TestPaginate(t *testing.T) {
+	t.Parallel()
+
+	newPaginateTestCase := func(t *testing.T) *httpTestCase {
+		ts := newTestCase(t)
+		ts.tb.Mux.HandleFunc("/items", func(w http.ResponseWriter, r *http.Request) {
+			page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+			next := "null"
+			if page < 3 {
+				next = strconv.Quote("/items?page=" + strconv.Itoa(page+1))
+			}
+			_, _ = fmt.Fprintf(w, `{"page": %d, "next": %s}`, page, next)
+		})
+		return ts
+	}
+
+	t.Run("all pages", func(t *testing.T) {
+		t.Parallel()
+		ts := newPaginateTestCase(t)
+
+		_, err := ts.runtime.VU.Runtime().RunString(ts.tb.Replacer.Replace(`
+			var pages = [];
+			var items = http.paginate("HTTPBIN_URL/items?page=1", {
+				next: (res) => res.json().next && "HTTPBIN_URL" + res.json().next,
+				name: "items",
+			});
+			for (var res of items) {
+				pages.push(res.json().page);
+			}
+			if (pages.join() !== "1,2,3") {
+				throw new Error("unexpected pages: " + pages.join());
+			}
+			if (!items.next().done) {
+				throw new Error("the iterator wasn't done");
+			}
+		`))
+		require.NoError(t, err)
+
+		requests, operations := operationSamples(t, ts)
+		assert.Equal(t, []string{"items", "items", "items"}, requests)
+		assert.Equal(t, []string{"items"}, operations)
+	})
+
+	t.Run("break", func(t *testing.T) {
+		t.Parallel()
+		ts := newPaginateTestCase(t)
+
+		_, err := ts.runtime.VU.Runtime().RunString(ts.tb.Replacer.Replace(`
+			var api = http.newClient({ baseURL: "HTTPBIN_URL" });
+			for (var res of api.paginate("/items?page=1", { next: (res) => res.json().next })) {
+				if (res.json().page === 2) {
+					break;
+				}
+			}
+		`))
+		require.NoError(t, err)
+
+		requests, operations := operationSamples(t, ts)
+		name := ts.tb.Replacer.Replace("HTTPBIN_URL/items?page=1")
+		assert.Equal(t, []string{name, name}, requests)
+		assert.Equal(t, []string{name}, operations)
+	})
+
+	t.Run("invalid options", func(t *testing.T) {
+		t.Parallel()
+		ts := newPaginateTestCase(t)
+
+		_, err := ts.runtime.VU.Runtime().RunString(`http.paginate("http://example.com")`)
+		assert.ErrorContains(t, err, "pagination requires the next option")
+		_, err = ts.runtime.VU.Runtime().RunString(`http.paginate("http://example.com", { next: "" })`)
+		assert.ErrorContains(t, err, "the next option must be a function")
+	})
+}
+
+func TestSequence(t *testing.T) {
+	t.Parallel()
+
+	newSequenceTestCase := func(t *testing.T, remoteAddrs *[]string) *httpTestCase {
+		ts := newTestCase(t)
+		var mx sync.Mutex
+		record := func(r *http.Request) {
+			mx.Lock()
+			defer mx.Unlock()
+			*remoteAddrs = append(*remoteAddrs, r.RemoteAddr)
+		}
+		ts.tb.Mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+			record(r)
+			if r.Method != http.MethodPost || r.FormValue("user") != "admin" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Header().Set("X-Session", "s1")
+			_, _ = fmt.Fprint(w, `{"token": "t1", "user": {"id": 42}}`)
+		})
+		ts.tb.Mux.HandleFunc("/users/", func(w http.ResponseWriter, r *http.Request) {
+			record(r)
+			if r.Header.Get("Authorization") != "Bearer t1" || r.Header.Get("X-Session") != "s1" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			_, _ = fmt.Fprintf(w, `{"path": %q}`, r.URL.Path)
+		})
+		return ts
+	}
+
+	t.Run("dependent requests", func(t *testing.T) {
+		t.Parallel()
+		var remoteAddrs []string
+		ts := newSequenceTestCase(t, &remoteAddrs)
+
+		_, err := ts.runtime.VU.Runtime().RunString(ts.tb.Replacer.Replace(`
+			var result = http.sequence([
+				{
+					method: "POST",
+					url: "HTTPBIN_URL/login",
+					body: { user: "${user}" },
+					extract: { token: "$.token", id: "user.id", session: "header:x-session" },
+				},
+				(vars) => ({
+					url: "HTTPBIN_URL/users/${id}",
+					params: { headers: { Authorization: "Bearer ${token}", "X-Session": vars.session } },
+					extract: { path: (res) => res.json().path },
+				}),
+			], { name: "login", vars: { user: "admin" } });
+
+			if (result.responses.length !== 2 || result.responses[1].status !== 200) {
+				throw new Error("unexpected responses: " + JSON.stringify(result.responses.map((r) => r.status)));
+			}
+			if (result.vars.path !== "/users/42" || result.vars.token !== "t1") {
+				throw new Error("unexpected vars: " + JSON.stringify(result.vars));
+			}
+		`))
+		require.NoError(t, err)
+
+		requests, operations := operationSamples(t, ts)
+		assert.Equal(t, []string{"login", "login"}, requests)
+		assert.Equal(t, []string{"login"}, operations)
+		require.Len(t, remoteAddrs, 2)
+		assert.Equal(t, remoteAddrs[0], remoteAddrs[1], "the connection wasn't reused")
+	})
+
+	t.Run("failed extraction", func(t *testing.T) {
+		t.Parallel()
+		var remoteAddrs []string
+		ts := newSequenceTestCase(t, &remoteAddrs)
+
+		_, err := ts.runtime.VU.Runtime().RunString(ts.tb.Replacer.Replace(`
+			http.sequence([
+				{ method: "POST", url: "HTTPBIN_URL/login", body: { user: "admin" }, extract: { token: "$.missing" } },
+				{ url: "HTTPBIN_URL/users/1", params: { headers: { Authorization: "Bearer ${token}" } } },
+			], { name: "login" });
+		`))
+		require.ErrorContains(t, err, `can't extract "token" in step 0 of the sequence login`)
+
+		requests, operations := operationSamples(t, ts)
+		assert.Equal(t, []string{"login"}, requests)
+		assert.Empty(t, operations)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		t.Parallel()
+		var remoteAddrs []string
+		ts := newSequenceTestCase(t, &remoteAddrs)
+
+		for script, msg := range map[string]string{
+			`http.sequence({})`:                                          "the steps of a sequence must be an array",
+			`http.sequence([])`:                                          "a sequence needs at least one step",
+			`http.sequence([{ method: "GET" }])`:                         "invalid step 0 of the sequence: it must have an url",
+			`http.sequence([{ url: "HTTPBIN_URL", retry: 1 }])`:          `unknown step option "retry"`,
+			`http.sequence([{ url: "HTTPBIN_URL/users/${id}" }])`:        `the variable "id" isn't defined`,
+			`http.sequence([{ url: "HTTPBIN_URL" }], { timeout: "1s" })`: `unknown sequence option "timeout"`,
+		} {
+			_, err := ts.runtime.VU.Runtime().RunString(ts.tb.Replacer.Replace(script))
+			assert.ErrorContains(t, err, msg, script)
+		}
+		assert.Empty(t, remoteAddrs)
+	})
+}
@@ -2,6 +2,10 @@ package http
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"mime/multipart"
@@ -16,6 +20,7 @@ import (
 	"gopkg.in/guregu/null.v3"
 
 	"go.k6.io/k6/js/common"
+	"go.k6.io/k6/lib"
 	"go.k6.io/k6/lib/netext/httpext"
 	"go.k6.io/k6/lib/types"
 )
@@ -52,7 +57,9 @@ func (c *Client) Request(method string, url sobek.Value, args ...sobek.Value) (*
 	if err != nil {
 		return nil, err
 	}
-	c.processResponse(resp, req.ResponseType)
+	if err := c.processResponse(resp, req.ResponseType); err != nil {
+		return nil, err
+	}
 	return c.responseFromHTTPext(resp), nil
 }
 
@@ -69,7 +76,8 @@ func splitRequestArgs(args []sobek.Value) (body interface{}, params sobek.Value)
 func (c *Client) handleParseRequestError(err error) (*Response, error) {
 	state := c.moduleInstance.vu.State()
 
-	if state.Options.Throw.Bool {
+	var ie interceptorError
+	if state.Options.Throw.Bool || errors.As(err, &ie) {
 		return nil, err
 	}
 	state.Logger.WithField("error", err).Warn("Request Failed")
@@ -112,7 +120,9 @@ func (c *Client) asyncRequest(method string, url sobek.Value, args ...sobek.Valu
 			if err != nil {
 				return reject(err)
 			}
-			c.processResponse(resp, req.ResponseType)
+			if err := c.processResponse(resp, req.ResponseType); err != nil {
+				return reject(err)
+			}
 			return resolve(c.responseFromHTTPext(resp))
 		})
 	}()
@@ -122,8 +132,9 @@ func (c *Client) asyncRequest(method string, url sobek.Value, args ...sobek.Valu
 
 // processResponse stores the body as an ArrayBuffer if indicated by
 // respType. This is done here instead of in httpext.readResponseBody to avoid
-// a reverse dependency on js/common or sobek.
-func (c *Client) processResponse(resp *httpext.Response, respType httpext.ResponseType) {
+// a reverse dependency on js/common or sobek. Then, the response interceptor
+// is called.
+func (c *Client) processResponse(resp *httpext.Response, respType httpext.ResponseType) error {
 	if respType == httpext.ResponseTypeBinary && resp.Body != nil {
 		b, ok := resp.Body.([]byte)
 		if !ok {
@@ -131,6 +142,7 @@ func (c *Client) processResponse(resp *httpext.Response, respType httpext.Respon
 		}
 		resp.Body = c.moduleInstance.vu.Runtime().NewArrayBuffer(b)
 	}
+	return c.interceptResponse(resp)
 }
 
 func (c *Client) responseFromHTTPext(resp *httpext.Response) *Response {
@@ -156,6 +168,11 @@ func (c *Client) parseRequest(
 	if err != nil {
 		return nil, err
 	}
+	if c.defaults != nil {
+		if u, err = c.defaults.resolveURL(u); err != nil {
+			return nil, err
+		}
+	}
 
 	result := &httpext.ParsedHTTPRequest{
 		URL: &u,
@@ -279,12 +296,16 @@ func (c *Client) parseRequest(
 		}
 	}
 
-	result.Req.Header.Set("User-Agent", state.Options.UserAgent.String)
-
 	if state.CookieJar != nil {
 		result.ActiveJar = state.CookieJar
 	}
 
+	if c.defaults != nil {
+		if err := c.defaults.apply(result); err != nil {
+			return nil, err
+		}
+	}
+
 	// TODO: ditch sobek.Value, reflections and Object and use a simple go map and type assertions?
 	//nolint: nestif
 	if params != nil && !sobek.IsUndefined(params) && !sobek.IsNull(params) {
@@ -369,6 +390,22 @@ func (c *Client) parseRequest(
 				}
 			case "auth":
 				result.Auth = params.Get(k).String()
+			case "tlsAuth":
+				result.TLSAuth = params.Get(k).String()
+			case "proxy":
+				result.Proxy = params.Get(k).String()
+			case "hosts":
+				hosts, err := parseHosts(rt, params.Get(k))
+				if err != nil {
+					return nil, err
+				}
+				result.Hosts = hosts
+			case "profile":
+				result.Profile = params.Get(k).String()
+				if !lib.IsHTTPProfile(result.Profile) {
+					return nil, fmt.Errorf("unknown profile %q, it must be one of %s",
+						result.Profile, strings.Join(lib.HTTPProfileNames(), ", "))
+				}
 			case "timeout":
 				t, err := types.GetDurationValue(params.Get(k).Export())
 				if err != nil {
@@ -377,6 +414,20 @@ func (c *Client) parseRequest(
 				result.Timeout = t
 			case "throw":
 				result.Throw = params.Get(k).ToBoolean()
+			case "awsSigV4":
+				sigV4, err := parseAWSSigV4(rt, params.Get(k))
+				if err != nil {
+					return nil, err
+				}
+				result.AWSSigV4 = sigV4
+			case "integrity":
+				integrity, err := parseIntegrity(rt, params.Get(k))
+				if err != nil {
+					return nil, err
+				}
+				result.Integrity = integrity
+			case "decompress":
+				result.NoDecompression = !params.Get(k).ToBoolean()
 			case "responseType":
 				responseType, err := httpext.ResponseTypeString(params.Get(k).String())
 				if err != nil {
@@ -396,6 +447,20 @@ func (c *Client) parseRequest(
 		}
 	}
 
+	if err := c.interceptRequest(result); err != nil {
+		return nil, err
+	}
+
+	// The profile's user agent is sent instead of the default one, but not
+	// instead of one set by the userAgent option or the request.
+	profile := result.Profile
+	if profile == "" {
+		profile = state.HTTPProfile
+	}
+	if _, ok := result.Req.Header["User-Agent"]; !ok && (profile == "" || state.Options.UserAgent.Valid) {
+		result.Req.Header.Set("User-Agent", state.Options.UserAgent.String)
+	}
+
 	if result.ActiveJar != nil {
 		httpext.SetRequestCookies(result.Req, result.ActiveJar, result.Cookies)
 	}
@@ -512,7 +577,9 @@ func (c *Client) Batch(reqsV ...sobek.Value) (interface{}, error) {
 	}
 	for _, req := range batchReqs {
 		if req.Response != nil {
-			c.processResponse(req.Response, req.ParsedHTTPRequest.ResponseType)
+			if e := c.processResponse(req.Response, req.ParsedHTTPRequest.ResponseType); e != nil {
+				return nil, e
+			}
 		}
 	}
 	return results, err
@@ -583,3 +650,116 @@ func requestContainsFile(data map[string]interface{}) bool {
 	}
 	return false
 }
+
+// parseAWSSigV4 parses the awsSigV4 param, i.e. the credentials, with which
+// the request is signed.
+func parseAWSSigV4(rt *sobek.Runtime, v sobek.Value) (*httpext.AWSSigV4, error) {
+	if common.IsNullish(v) {
+		return nil, nil //nolint:nilnil
+	}
+	obj := v.ToObject(rt)
+	field := func(name string) string {
+		if value := obj.Get(name); !common.IsNullish(value) {
+			return value.String()
+		}
+		return ""
+	}
+	sigV4 := &httpext.AWSSigV4{
+		AccessKeyID:     field("accessKeyId"),
+		SecretAccessKey: field("secretAccessKey"),
+		SessionToken:    field("sessionToken"),
+		Region:          field("region"),
+		Service:         field("service"),
+	}
+	if err := sigV4.Validate(); err != nil {
+		return nil, err
+	}
+	return sigV4, nil
+}
+
+// parseHosts parses the hosts param, which maps the hostnames to the
+// addresses to connect to, like the hosts option, and returns its JSON.
+func parseHosts(rt *sobek.Runtime, v sobek.Value) (string, error) {
+	if common.IsNullish(v) {
+		return "", nil
+	}
+	obj := v.ToObject(rt)
+	source := make(map[string]string, len(obj.Keys()))
+	for _, k := range obj.Keys() {
+		address := obj.Get(k).String()
+		if !strings.HasPrefix(k, "/") {
+			// the rewrites are validated with their patterns
+			if err := new(types.Host).UnmarshalText([]byte(address)); err != nil {
+				return "", fmt.Errorf("invalid hosts address of %q: %w", k, err)
+			}
+		}
+		source[k] = address
+	}
+	data, err := json.Marshal(source)
+	if err != nil {
+		return "", err
+	}
+	var hosts types.NullHosts
+	if err := hosts.UnmarshalJSON(data); err != nil {
+		return "", fmt.Errorf("invalid hosts: %w", err)
+	}
+	// the marshaled hosts have sorted keys, so the same hosts share a transport
+	data, err = hosts.MarshalJSON()
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// parseIntegrity parses the integrity param, i.e. either the SHA-256 digest
+// of the response body, or an object with its sha256 and its etag.
+func parseIntegrity(rt *sobek.Runtime, v sobek.Value) (*httpext.Integrity, error) {
+	if common.IsNullish(v) {
+		return nil, nil //nolint:nilnil
+	}
+	integrity := &httpext.Integrity{}
+	if _, ok := v.Export().(string); ok {
+		digest, err := parseSHA256(v.String())
+		if err != nil {
+			return nil, fmt.Errorf("invalid integrity: %w", err)
+		}
+		integrity.SHA256 = digest
+		return integrity, nil
+	}
+	obj := v.ToObject(rt)
+	for _, k := range obj.Keys() {
+		value := obj.Get(k)
+		if common.IsNullish(value) {
+			continue
+		}
+		switch k {
+		case "sha256":
+			digest, err := parseSHA256(value.String())
+			if err != nil {
+				return nil, fmt.Errorf("invalid integrity sha256: %w", err)
+			}
+			integrity.SHA256 = digest
+		case "etag":
+			integrity.ETag = value.String()
+		default:
+			return nil, fmt.Errorf("unknown integrity option %q", k)
+		}
+	}
+	if len(integrity.SHA256) == 0 && integrity.ETag == "" {
+		return nil, errors.New("the integrity param requires a sha256 or an etag")
+	}
+	return integrity, nil
+}
+
+// parseSHA256 parses a SHA-256 digest, in hex, in base64, or like in the
+// Subresource Integrity, i.e. the base64 one prefixed with sha256-.
+func parseSHA256(s string) ([]byte, error) {
+	s = strings.TrimPrefix(s, "sha256-")
+	if digest, err := hex.DecodeString(s); err == nil && len(digest) == sha256.Size {
+		return digest, nil
+	}
+	if digest, err := base64.StdEncoding.DecodeString(s); err == nil && len(digest) == sha256.Size {
+		return digest, nil
+	}
+	return nil, fmt.Errorf("%q isn't a SHA-256 digest in hex or in base64", s)
+}
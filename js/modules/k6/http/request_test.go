@@ -8,9 +8,12 @@ import (
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/rand"
+	"crypto/sha256"
 	"crypto/tls"
 	"crypto/x509"
 	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/pem"
 	"fmt"
 	"io"
@@ -257,6 +260,19 @@ func TestRequest(t *testing.T) {
 			`))
 			assert.NoError(t, err)
 		})
+		t.Run("chain", func(t *testing.T) {
+			_, err := rt.RunString(sr(`
+			var res = http.get("HTTPBIN_URL/redirect/2");
+			if (res.status != 200) { throw new Error("wrong status: " + res.status) }
+			var chain = res.redirects.map((r) => r.status + " " + r.url + " -> " + r.location).join("; ");
+			if (chain != "302 HTTPBIN_URL/redirect/2 -> /relative-redirect/1; 302 HTTPBIN_URL/relative-redirect/1 -> /get") {
+				throw new Error("incorrect redirect chain: " + chain)
+			}
+			if (!(res.redirects[0].timings.duration > 0)) { throw new Error("no timings: " + JSON.stringify(res.redirects[0])) }
+			if (http.get("HTTPBIN_URL/get").redirects.length != 0) { throw new Error("unexpected redirects") }
+			`))
+			assert.NoError(t, err)
+		})
 		t.Run("requestScopeNoRedirects", func(t *testing.T) {
 			_, err := rt.RunString(sr(`
 			var res = http.get("HTTPBIN_URL/redirect/1", {redirects: 0});
@@ -395,6 +411,92 @@ func TestRequest(t *testing.T) {
 			assert.NoError(t, err)
 		})
 	})
+	t.Run("Profile", func(t *testing.T) {
+		t.Run("request", func(t *testing.T) {
+			_, err := rt.RunString(sr(`
+				var res = http.get("HTTPBIN_URL/redirect/1", {
+					profile: "chrome",
+					headers: { "Accept-Language": "de-DE" },
+				});
+				var headers = res.json()["headers"];
+				if (headers['Sec-Ch-Ua-Platform'] != '"Windows"') {
+					throw new Error("incorrect sec-ch-ua-platform: " + headers['Sec-Ch-Ua-Platform']);
+				}
+				if (headers['Sec-Fetch-Mode'] != "navigate") {
+					throw new Error("incorrect sec-fetch-mode: " + headers['Sec-Fetch-Mode']);
+				}
+				if (headers['Accept-Language'] != "de-DE") {
+					throw new Error("incorrect accept-language: " + headers['Accept-Language']);
+				}
+				if (headers['User-Agent'] != "TestUserAgent") {
+					throw new Error("incorrect user agent: " + headers['User-Agent']);
+				}
+				if (res.request.headers['Sec-Ch-Ua'] !== undefined) {
+					throw new Error("the profile headers were set on the request object");
+				}
+			`))
+			assert.NoError(t, err)
+		})
+
+		t.Run("VU", func(t *testing.T) {
+			oldUserAgent := state.Options.UserAgent
+			defer func() {
+				state.Options.UserAgent = oldUserAgent
+				state.HTTPProfile = ""
+			}()
+
+			state.Options.UserAgent = null.NewString("Default one", false)
+			state.HTTPProfile = "firefox"
+			_, err := rt.RunString(sr(`
+				var res = http.get("HTTPBIN_URL/headers");
+				var headers = res.json()["headers"];
+				if (!/ Firefox\//.test(headers['User-Agent'])) {
+					throw new Error("incorrect user agent: " + headers['User-Agent']);
+				}
+				if (headers['Sec-Ch-Ua'] !== undefined) {
+					throw new Error("unexpected sec-ch-ua: " + headers['Sec-Ch-Ua']);
+				}
+
+				res = http.get("HTTPBIN_URL/headers", { profile: "safari" });
+				headers = res.json()["headers"];
+				if (!/ Version\/[\d.]+ Safari\//.test(headers['User-Agent'])) {
+					throw new Error("incorrect user agent: " + headers['User-Agent']);
+				}
+			`))
+			assert.NoError(t, err)
+		})
+
+		t.Run("unknown", func(t *testing.T) {
+			_, err := rt.RunString(sr(`http.get("HTTPBIN_URL/headers", { profile: "netscape" });`))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), `unknown profile "netscape"`)
+		})
+	})
+	t.Run("AWSSigV4", func(t *testing.T) {
+		t.Run("signed", func(t *testing.T) {
+			_, err := rt.RunString(sr(`
+				var res = http.get("HTTPBIN_URL/headers", { awsSigV4: {
+					accessKeyId: "AKIDEXAMPLE", secretAccessKey: "secret", sessionToken: "token",
+					region: "eu-west-1", service: "execute-api",
+				}});
+				var headers = res.json()["headers"];
+				if (!/^AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE\/\d{8}\/eu-west-1\/execute-api\/aws4_request, SignedHeaders=host;x-amz-date;x-amz-security-token, Signature=[0-9a-f]{64}$/.test(headers["Authorization"])) {
+					throw new Error("unexpected authorization: " + headers["Authorization"]);
+				}
+				if (headers["X-Amz-Security-Token"] != "token") {
+					throw new Error("unexpected security token: " + headers["X-Amz-Security-Token"]);
+				}
+			`))
+			assert.NoError(t, err)
+		})
+
+		t.Run("missing region", func(t *testing.T) {
+			_, err := rt.RunString(sr(`
+				http.get("HTTPBIN_URL/headers", { awsSigV4: { accessKeyId: "a", secretAccessKey: "b", service: "s3" } });
+			`))
+			assert.ErrorContains(t, err, "the region of awsSigV4 is required")
+		})
+	})
 	t.Run("Compression", func(t *testing.T) {
 		t.Run("gzip", func(t *testing.T) {
 			_, err := rt.RunString(sr(`
@@ -457,6 +559,110 @@ func TestRequest(t *testing.T) {
 			`))
 			assert.NoError(t, err)
 		})
+		t.Run("decompressing", func(t *testing.T) {
+			metrics.GetBufferedSamples(samples)
+			_, err := rt.RunString(sr(`
+				// the test transport decompresses the responses to the requests
+				// without an Accept-Encoding header by itself
+				var res = http.get("HTTPBIN_URL/gzip", { headers: { "Accept-Encoding": "gzip" } });
+				if (!(res.timings.decompressing > 0) || res.timings.decompressing > res.timings.receiving) {
+					throw new Error("unexpected decompressing timing: " + res.timings.decompressing);
+				}
+			`))
+			require.NoError(t, err)
+
+			var decompressing int
+			for _, sampleC := range metrics.GetBufferedSamples(samples) {
+				for _, sample := range sampleC.GetSamples() {
+					if sample.Metric.Name == metrics.HTTPReqDecompressingName {
+						decompressing++
+					}
+				}
+			}
+			assert.Equal(t, 1, decompressing)
+		})
+		t.Run("decompress false", func(t *testing.T) {
+			_, err := rt.RunString(sr(`
+				var res = http.get("HTTPBIN_URL/gzip", {
+					headers: { "Accept-Encoding": "gzip" }, decompress: false, responseType: "binary",
+				});
+				var body = new Uint8Array(res.body);
+				if (body[0] != 0x1f || body[1] != 0x8b) {
+					throw new Error("the body was decompressed: " + body.slice(0, 2));
+				}
+				if (res.timings.decompressing !== 0) {
+					throw new Error("unexpected decompressing timing: " + res.timings.decompressing);
+				}
+			`))
+			assert.NoError(t, err)
+		})
+	})
+	t.Run("Integrity", func(t *testing.T) {
+		body := []byte("the cached asset")
+		sum := sha256.Sum256(body)
+		tb.Mux.HandleFunc("/integrity", http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("ETag", `"v1"`)
+			_, err := w.Write(body)
+			assert.NoError(t, err)
+		}))
+		integrityFailures := func() int {
+			var failures int
+			for _, sampleC := range metrics.GetBufferedSamples(samples) {
+				for _, sample := range sampleC.GetSamples() {
+					if sample.Metric.Name == metrics.IntegrityFailuresName {
+						failures++
+					}
+				}
+			}
+			return failures
+		}
+
+		t.Run("match", func(t *testing.T) {
+			metrics.GetBufferedSamples(samples)
+			_, err := rt.RunString(sr(fmt.Sprintf(`
+				var res = http.get("HTTPBIN_URL/integrity", {
+					integrity: { sha256: "%s", etag: '"v1"' }, responseType: "none",
+				});
+				if (res.integrity_error !== "") {
+					throw new Error("unexpected integrity error: " + res.integrity_error);
+				}
+				res = http.get("HTTPBIN_URL/integrity", { integrity: "sha256-%s" });
+				if (res.integrity_error !== "" || res.body !== "the cached asset") {
+					throw new Error("unexpected response: " + res.integrity_error + " " + res.body);
+				}
+			`, hex.EncodeToString(sum[:]), base64.StdEncoding.EncodeToString(sum[:]))))
+			require.NoError(t, err)
+			assert.Equal(t, 0, integrityFailures())
+		})
+		t.Run("digest mismatch", func(t *testing.T) {
+			metrics.GetBufferedSamples(samples)
+			other := sha256.Sum256([]byte("another asset"))
+			_, err := rt.RunString(sr(fmt.Sprintf(`
+				var res = http.get("HTTPBIN_URL/integrity", { integrity: { sha256: "%s" }, responseType: "none" });
+				if (res.status !== 200 || res.body !== null || res.integrity_error.indexOf("SHA-256") < 0) {
+					throw new Error("unexpected response: " + res.status + " " + res.integrity_error);
+				}
+			`, hex.EncodeToString(other[:]))))
+			require.NoError(t, err)
+			assert.Equal(t, 1, integrityFailures())
+		})
+		t.Run("etag mismatch", func(t *testing.T) {
+			metrics.GetBufferedSamples(samples)
+			_, err := rt.RunString(sr(`
+				var res = http.get("HTTPBIN_URL/integrity", { integrity: { etag: '"v2"' } });
+				if (res.integrity_error.indexOf("ETag") < 0) {
+					throw new Error("unexpected integrity error: " + res.integrity_error);
+				}
+			`))
+			require.NoError(t, err)
+			assert.Equal(t, 1, integrityFailures())
+		})
+		t.Run("invalid", func(t *testing.T) {
+			_, err := rt.RunString(sr(`http.get("HTTPBIN_URL/integrity", { integrity: "sha256-abc" });`))
+			require.ErrorContains(t, err, "isn't a SHA-256 digest")
+			_, err = rt.RunString(sr(`http.get("HTTPBIN_URL/integrity", { integrity: { md5: "abc" } });`))
+			require.ErrorContains(t, err, `unknown integrity option "md5"`)
+		})
 	})
 	t.Run("CompressionWithAcceptEncodingHeader", func(t *testing.T) {
 		t.Run("gzip", func(t *testing.T) {
@@ -1846,6 +2052,59 @@ func TestResponseWaitingAndReceivingTimings(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestResponseEarlyHints(t *testing.T) {
+	t.Parallel()
+	ts := newTestCase(t)
+	tb := ts.tb
+	rt := ts.runtime.VU.Runtime()
+
+	tb.Mux.HandleFunc("/early-hints", http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Add("Link", "</style.css>; rel=preload; as=style")
+		w.WriteHeader(http.StatusEarlyHints)
+		time.Sleep(100 * time.Millisecond)
+		w.Header().Set("Link", "</script.js>; rel=preload; as=script")
+		w.WriteHeader(http.StatusEarlyHints)
+		time.Sleep(100 * time.Millisecond)
+		_, err := w.Write([]byte("ok"))
+		assert.NoError(t, err)
+	}))
+
+	for _, url := range []string{"HTTPBIN_URL", "HTTP2BIN_URL"} {
+		_, err := rt.RunString(tb.Replacer.Replace(`
+			var res = http.get("` + url + `/early-hints");
+			if (res.status !== 200 || res.body !== "ok") {
+				throw new Error("wrong response: " + res.status + " " + res.body);
+			}
+			var hints = res.early_hints;
+			if (hints.length !== 2) {
+				throw new Error("wrong early hints: " + JSON.stringify(hints));
+			}
+			if (hints[0].headers["Link"] !== "</style.css>; rel=preload; as=style" ||
+				hints[1].headers["Link"] !== "</script.js>; rel=preload; as=script") {
+				throw new Error("wrong headers of the early hints: " + JSON.stringify(hints));
+			}
+			if (!(hints[1].waiting >= hints[0].waiting + 100)) {
+				throw new Error("wrong timings of the early hints: " + JSON.stringify(hints));
+			}
+			if (http.get("` + url + `/get").early_hints.length !== 0) {
+				throw new Error("unexpected early hints");
+			}
+		`))
+		require.NoError(t, err, url)
+	}
+
+	var earlyHints []float64
+	for _, sc := range metrics.GetBufferedSamples(ts.samples) {
+		for _, s := range sc.GetSamples() {
+			if s.Metric.Name == metrics.HTTPReqEarlyHintsName {
+				earlyHints = append(earlyHints, s.Value)
+			}
+		}
+	}
+	require.Len(t, earlyHints, 4)
+	assert.GreaterOrEqual(t, earlyHints[1], earlyHints[0]+100)
+}
+
 func TestResponseTimingsWhenTimeout(t *testing.T) {
 	t.Parallel()
 	ts := newTestCase(t)
@@ -11,6 +11,7 @@ import (
 	"github.com/grafana/sobek"
 	"github.com/tidwall/gjson"
 
+	"go.k6.io/k6/internal/lib/jsonpath"
 	"go.k6.io/k6/js/common"
 	"go.k6.io/k6/js/modules/k6/html"
 	"go.k6.io/k6/lib/netext/httpext"
@@ -62,6 +63,7 @@ func (res *Response) HTML(selector ...string) html.Selection {
 }
 
 // JSON parses the body of a response as JSON and returns it to the Sobek VM.
+// The selector is a GJSON path, see JSONPath for the JSONPath queries.
 func (res *Response) JSON(selector ...string) sobek.Value {
 	rt := res.client.moduleInstance.vu.Runtime()
 
@@ -110,6 +112,32 @@ func (res *Response) JSON(selector ...string) sobek.Value {
 	return rt.ToValue(res.cachedJSON)
 }
 
+// JSONPath returns the values of the JSON body, which are selected by the
+// JSONPath query: the value, or undefined, for the singular queries, which have
+// only names and indexes, and an array of the values for the rest.
+func (res *Response) JSONPath(query string) sobek.Value {
+	rt := res.client.moduleInstance.vu.Runtime()
+	path, err := jsonpath.Compile(query)
+	if err != nil {
+		common.Throw(rt, err)
+	}
+	if res.cachedJSON == nil {
+		res.JSON() // it parses and caches the body, or throws
+	}
+
+	values := path.Select(res.cachedJSON)
+	if !path.IsSingular() {
+		if values == nil {
+			values = []interface{}{}
+		}
+		return rt.ToValue(values)
+	}
+	if len(values) == 0 {
+		return sobek.Undefined()
+	}
+	return rt.ToValue(values[0])
+}
+
 func checkErrorInJSON(input []byte, offset int, err error) error {
 	lf := '\n'
 	str := string(input)
@@ -135,12 +163,37 @@ func checkErrorInJSON(input []byte, offset int, err error) error {
 // SubmitForm parses the body as an html looking for a from and then submitting it
 // TODO: document the actual arguments that can be provided
 func (res *Response) SubmitForm(args ...sobek.Value) (*Response, error) {
+	method, target, body, params, err := res.formSubmission(args...)
+	if err != nil {
+		return nil, err
+	}
+	return res.client.Request(method, target, body, params)
+}
+
+// SubmitFormAsync is like SubmitForm, but it returns a promise of the response.
+func (res *Response) SubmitFormAsync(args ...sobek.Value) (*sobek.Promise, error) {
+	method, target, body, params, err := res.formSubmission(args...)
+	if err != nil {
+		return res.rejectedPromise(err)
+	}
+	return res.client.asyncRequest(method, target, body, params)
+}
+
+// formSubmission returns the arguments of the request, which submits the
+// form. The fields option overrides the values of the fields with the given
+// names, and the fieldsBySelector one the values of the fields matching the
+// given selectors, e.g. { '#username': 'admin' }.
+//
+//nolint:funlen,gocognit,cyclop
+func (res *Response) formSubmission(args ...sobek.Value) (
+	method string, target, body, requestParams sobek.Value, err error,
+) {
 	rt := res.client.moduleInstance.vu.Runtime()
 
 	formSelector := "form"
 	submitSelector := "[type=\"submit\"]"
-	var fields map[string]sobek.Value
-	requestParams := sobek.Null()
+	var fields, fieldsBySelector map[string]sobek.Value
+	requestParams = sobek.Null()
 	if len(args) > 0 {
 		params := args[0].ToObject(rt)
 		for _, k := range params.Keys() {
@@ -153,6 +206,10 @@ func (res *Response) SubmitForm(args ...sobek.Value) (*Response, error) {
 				if rt.ExportTo(params.Get(k), &fields) != nil {
 					fields = nil
 				}
+			case "fieldsBySelector":
+				if rt.ExportTo(params.Get(k), &fieldsBySelector) != nil {
+					fieldsBySelector = nil
+				}
 			case "params":
 				requestParams = params.Get(k)
 			}
@@ -161,7 +218,7 @@ func (res *Response) SubmitForm(args ...sobek.Value) (*Response, error) {
 
 	form := res.HTML(formSelector)
 	if form.Size() == 0 {
-		common.Throw(rt, fmt.Errorf("no form found for selector '%s' in response '%s'", formSelector, res.URL))
+		return "", nil, nil, nil, fmt.Errorf("no form found for selector '%s' in response '%s'", formSelector, res.URL)
 	}
 
 	methodAttr := form.Attr("method")
@@ -175,7 +232,7 @@ func (res *Response) SubmitForm(args ...sobek.Value) (*Response, error) {
 
 	responseURL, err := url.Parse(res.URL)
 	if err != nil {
-		common.Throw(rt, err)
+		return "", nil, nil, nil, err
 	}
 
 	actionAttr := form.Attr("action")
@@ -186,7 +243,7 @@ func (res *Response) SubmitForm(args ...sobek.Value) (*Response, error) {
 	} else {
 		actionURL, err := url.Parse(actionAttr.String())
 		if err != nil {
-			common.Throw(rt, err)
+			return "", nil, nil, nil, err
 		}
 		requestURL = responseURL.ResolveReference(actionURL)
 	}
@@ -206,6 +263,17 @@ func (res *Response) SubmitForm(args ...sobek.Value) (*Response, error) {
 	for k, v := range fields {
 		values[k] = v
 	}
+	for selector, v := range fieldsBySelector {
+		field := form.Find(selector)
+		if field.Size() == 0 {
+			return "", nil, nil, nil, fmt.Errorf("no form field found for selector '%s' in response '%s'", selector, res.URL)
+		}
+		name := field.Attr("name")
+		if name == sobek.Undefined() {
+			return "", nil, nil, nil, fmt.Errorf("the form field found for selector '%s' doesn't have a name", selector)
+		}
+		values[name.String()] = v
+	}
 
 	if requestMethod == http.MethodGet {
 		q := url.Values{}
@@ -213,21 +281,37 @@ func (res *Response) SubmitForm(args ...sobek.Value) (*Response, error) {
 			q.Add(k, v.String())
 		}
 		requestURL.RawQuery = q.Encode()
-		return res.client.Request(requestMethod, rt.ToValue(requestURL.String()), sobek.Null(), requestParams)
+		return requestMethod, rt.ToValue(requestURL.String()), sobek.Null(), requestParams, nil
 	}
-	return res.client.Request(
-		requestMethod, rt.ToValue(requestURL.String()),
-		rt.ToValue(values), requestParams,
-	)
+	return requestMethod, rt.ToValue(requestURL.String()), rt.ToValue(values), requestParams, nil
 }
 
 // ClickLink parses the body as an html, looks for a link and than makes a request as if the link was
 // clicked
 func (res *Response) ClickLink(args ...sobek.Value) (*Response, error) {
+	target, params, err := res.linkClick(args...)
+	if err != nil {
+		return nil, err
+	}
+	return res.client.Request(http.MethodGet, target, sobek.Undefined(), params)
+}
+
+// ClickLinkAsync is like ClickLink, but it returns a promise of the response.
+func (res *Response) ClickLinkAsync(args ...sobek.Value) (*sobek.Promise, error) {
+	target, params, err := res.linkClick(args...)
+	if err != nil {
+		return res.rejectedPromise(err)
+	}
+	return res.client.asyncRequest(http.MethodGet, target, sobek.Undefined(), params)
+}
+
+// linkClick returns the URL and the params of the request, which clicks the
+// link.
+func (res *Response) linkClick(args ...sobek.Value) (target, requestParams sobek.Value, err error) {
 	rt := res.client.moduleInstance.vu.Runtime()
 
 	selector := "a[href]"
-	requestParams := sobek.Null()
+	requestParams = sobek.Null()
 	if len(args) > 0 {
 		params := args[0].ToObject(rt)
 		for _, k := range params.Keys() {
@@ -242,23 +326,28 @@ func (res *Response) ClickLink(args ...sobek.Value) (*Response, error) {
 
 	responseURL, err := url.Parse(res.URL)
 	if err != nil {
-		common.Throw(rt, err)
+		return nil, nil, err
 	}
 
 	link := res.HTML(selector)
 	if link.Size() == 0 {
-		common.Throw(rt, fmt.Errorf("no element found for selector '%s' in response '%s'", selector, res.URL))
+		return nil, nil, fmt.Errorf("no element found for selector '%s' in response '%s'", selector, res.URL)
 	}
 	hrefAttr := link.Attr("href")
 	if hrefAttr == sobek.Undefined() {
-		errmsg := fmt.Errorf("no valid href attribute value found on element '%s' in response '%s'", selector, res.URL)
-		common.Throw(rt, errmsg)
+		return nil, nil, fmt.Errorf("no valid href attribute value found on element '%s' in response '%s'", selector, res.URL)
 	}
 	hrefURL, err := url.Parse(hrefAttr.String())
 	if err != nil {
-		common.Throw(rt, err)
+		return nil, nil, err
 	}
 	requestURL := responseURL.ResolveReference(hrefURL)
 
-	return res.client.Request(http.MethodGet, rt.ToValue(requestURL.String()), sobek.Undefined(), requestParams)
+	return rt.ToValue(requestURL.String()), requestParams, nil
+}
+
+// rejectedPromise returns a promise, which is rejected with the error.
+func (res *Response) rejectedPromise(err error) (*sobek.Promise, error) {
+	p, _, reject := res.client.moduleInstance.vu.Runtime().NewPromise()
+	return p, reject(err)
 }
@@ -115,6 +115,10 @@ func TestResponse(t *testing.T) {
 	tb.Mux.HandleFunc("/myforms/get", myFormHandler)
 	tb.Mux.HandleFunc("/json", jsonHandler)
 	tb.Mux.HandleFunc("/invalidjson", invalidJSONHandler)
+	tb.Mux.HandleFunc("/dollarjson", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"$":{"ref":1}}`))
+	})
 
 	t.Run("Html", func(t *testing.T) {
 		_, err := rt.RunString(sr(`
@@ -243,6 +247,46 @@ func TestResponse(t *testing.T) {
 		assert.NoError(t, err)
 		assertRequestMetricsEmitted(t, metrics.GetBufferedSamples(samples), "GET", sr("HTTPBIN_URL/json"), 200, "")
 	})
+	t.Run("JsonPathSelector", func(t *testing.T) {
+		_, err := rt.RunString(sr(`
+			var res = http.request("GET", "HTTPBIN_URL/json");
+			if (res.status != 200) { throw new Error("wrong status: " + res.status); }
+
+			var value = res.jsonPath("$.glossary.friends[-1].first")
+			if (value != "Jane")
+				{ throw new Error("Expected 'Jane', but got: " + value); }
+
+			value = res.jsonPath("$.glossary.missing")
+			if (value !== undefined)
+				{ throw new Error("Expected undefined, but got: " + value); }
+
+			value = res.jsonPath("$.glossary.friends[?@.age > 45 && @.last == 'Murphy'].first")
+			if (JSON.stringify(value) != '["Jane"]')
+				{ throw new Error("Expected ['Jane'], but got: " + JSON.stringify(value)); }
+
+			value = res.jsonPath("$..intArray[1:]")
+			if (JSON.stringify(value) != '[2,3]')
+				{ throw new Error("Expected [2,3], but got: " + JSON.stringify(value)); }
+
+			value = res.jsonPath("$..nothing[*]")
+			if (JSON.stringify(value) != '[]')
+				{ throw new Error("Expected [], but got: " + JSON.stringify(value)); }
+
+			if (res.json().glossary.GlossDiv.title != "S")
+				{ throw new Error("the parsed body changed"); }
+		`))
+		assert.NoError(t, err)
+
+		// the selectors of res.json() are still GJSON paths, even when they start with $
+		_, err = rt.RunString(sr(`
+			var value = http.request("GET", "HTTPBIN_URL/dollarjson").json("$.ref");
+			if (value !== 1) { throw new Error("Expected 1, but got: " + value); }
+		`))
+		assert.NoError(t, err)
+
+		_, err = rt.RunString(sr(`http.request("GET", "HTTPBIN_URL/json").jsonPath("$.glossary[?@.a = 1]");`))
+		require.ErrorContains(t, err, `invalid JSONPath query "$.glossary[?@.a = 1]" at position 16`)
+	})
 
 	t.Run("SubmitForm", func(t *testing.T) {
 		t.Run("withoutArgs", func(t *testing.T) {
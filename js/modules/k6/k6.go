@@ -3,6 +3,7 @@ package k6
 
 import (
 	"errors"
+	"fmt"
 	"math/rand"
 	"strings"
 	"time"
@@ -71,9 +72,16 @@ func (*K6) Fail(msg string) (sobek.Value, error) {
 // Sleep waits the provided seconds before continuing the execution.
 func (mi *K6) Sleep(secs float64) {
 	ctx := mi.vu.Context()
-	timer := time.NewTimer(time.Duration(secs * float64(time.Second)))
+	d := time.Duration(secs * float64(time.Second))
+	// a frozen clock of the VU is advanced by the sleep
+	fired := func() {}
+	if state := mi.vu.State(); state != nil && state.Clock != nil {
+		fired = state.Clock.After(d)
+	}
+	timer := time.NewTimer(d)
 	select {
 	case <-timer.C:
+		fired()
 	case <-ctx.Done():
 		timer.Stop()
 	}
@@ -116,12 +124,15 @@ func (mi *K6) Group(name string, val sobek.Value) (sobek.Value, error) {
 			tagsAndMeta.SetSystemTagOrMeta(metrics.TagGroup, newGroupName)
 		})
 	}
+	oldLogger := state.Logger
+	state.Logger = oldLogger.WithField("group", newGroupName)
 	defer func() {
 		if shouldUpdateTag {
 			state.Tags.Modify(func(tagsAndMeta *metrics.TagsAndMeta) {
 				tagsAndMeta.SetSystemTagOrMeta(metrics.TagGroup, oldGroupName)
 			})
 		}
+		state.Logger = oldLogger
 	}()
 
 	startTime := time.Now()
@@ -162,6 +173,9 @@ func (mi *K6) Check(arg0, checks sobek.Value, extras ...sobek.Value) (bool, erro
 		if err := common.ApplyCustomUserTags(rt, &commonTagsAndMeta, extras[0]); err != nil {
 			return false, err
 		}
+		if err := validateCheckSeverity(rt, extras[0]); err != nil {
+			return false, err
+		}
 	}
 
 	succ := true
@@ -198,6 +212,9 @@ func (mi *K6) Check(arg0, checks sobek.Value, extras ...sobek.Value) (bool, erro
 		if !booleanVal {
 			// A single failure makes the return value false.
 			succ = false
+			if state.FlightRecorder != nil {
+				state.FlightRecorder.Fail()
+			}
 		}
 
 		sample := metrics.Sample{
@@ -221,3 +238,17 @@ func (mi *K6) Check(arg0, checks sobek.Value, extras ...sobek.Value) (bool, erro
 
 	return succ, nil
 }
+
+// validateCheckSeverity makes sure the severity tag of a check, if it has one,
+// is one of the severities of the checks.
+func validateCheckSeverity(rt *sobek.Runtime, tags sobek.Value) error {
+	if common.IsNullish(tags) {
+		return nil
+	}
+	severity := tags.ToObject(rt).Get(lib.CheckSeverityTag)
+	if common.IsNullish(severity) || lib.IsValidCheckSeverity(severity.String()) {
+		return nil
+	}
+	return fmt.Errorf("the %s tag of a check must be one of %q, %q or %q, but is %q", lib.CheckSeverityTag,
+		lib.CheckSeverityInfo, lib.CheckSeverityWarn, lib.CheckSeverityCritical, severity.String())
+}
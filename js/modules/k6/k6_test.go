@@ -5,9 +5,11 @@ import (
 	"testing"
 	"time"
 
+	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"go.k6.io/k6/internal/lib/testutils"
 	"go.k6.io/k6/js/modulestest"
 	"go.k6.io/k6/lib"
 	"go.k6.io/k6/metrics"
@@ -96,6 +98,9 @@ func TestGroup(t *testing.T) {
 			groupTag, ok := state.Tags.GetCurrentValues().Tags.Get("group")
 			require.True(t, ok)
 			assert.Equal(t, groupTag, "::my group")
+			logger, ok := state.Logger.(*logrus.Entry)
+			require.True(t, ok)
+			assert.Equal(t, "::my group", logger.Data["group"])
 		}))
 		_, err := tc.testRuntime.RunOnEventLoop(`k6.group("my group", fn)`)
 		assert.NoError(t, err)
@@ -369,6 +374,27 @@ func TestCheckTags(t *testing.T) {
 	}, sample.Tags.Map())
 }
 
+func TestCheckSeverity(t *testing.T) {
+	t.Parallel()
+	tc := testCaseRuntime(t)
+
+	v, err := tc.testRuntime.RunOnEventLoop(`k6.check(null, {"check": true}, {severity: "critical"})`)
+	require.NoError(t, err)
+	assert.Equal(t, true, v.Export())
+
+	bufSamples := metrics.GetBufferedSamples(tc.samples)
+	require.Len(t, bufSamples, 1)
+	sample, ok := bufSamples[0].(metrics.Sample)
+	require.True(t, ok)
+	severity, ok := sample.Tags.Get("severity")
+	require.True(t, ok)
+	assert.Equal(t, "critical", severity)
+
+	_, err = tc.testRuntime.RunOnEventLoop(`k6.check(null, {"check": true}, {severity: "blocker"})`)
+	require.ErrorContains(t, err, `the severity tag of a check must be one of "info", "warn" or "critical", but is "blocker"`)
+	assert.Empty(t, metrics.GetBufferedSamples(tc.samples))
+}
+
 type testCase struct {
 	samples     chan metrics.SampleContainer
 	testRuntime *modulestest.Runtime
@@ -386,6 +412,7 @@ func testCaseRuntime(t testing.TB) *testCase {
 		Options: lib.Options{
 			SystemTags: &metrics.DefaultSystemTagSet,
 		},
+		Logger:         testutils.NewLogger(t),
 		Samples:        samples,
 		Tags:           lib.NewVUStateTags(registry.RootTagSet().WithTagsFromMap(map[string]string{"group": lib.RootGroupPath})),
 		BuiltinMetrics: metrics.RegisterBuiltinMetrics(registry),
@@ -19,6 +19,10 @@ type VU struct {
 	StateField            *lib.State
 	RuntimeField          *sobek.Runtime
 	RegisterCallbackField func() func(f func() error)
+	// RegisterDaemonCallbackField is optional, like the method of the VUs
+	RegisterDaemonCallbackField func(stop func()) func(f func() error)
+	// QueueCallbackField is optional, like the method of the VUs
+	QueueCallbackField func(f func() error)
 }
 
 // Context returns internally set field to conform to modules.VU interface
@@ -53,6 +57,22 @@ func (m *VU) RegisterCallback() func(f func() error) {
 	return m.RegisterCallbackField()
 }
 
+// RegisterDaemonCallback is not really implemented, without the field it's
+// the same as RegisterCallback
+func (m *VU) RegisterDaemonCallback(stop func()) func(f func() error) {
+	if m.RegisterDaemonCallbackField == nil {
+		return m.RegisterCallbackField()
+	}
+	return m.RegisterDaemonCallbackField(stop)
+}
+
+// QueueCallback calls the field, without it the callback is dropped
+func (m *VU) QueueCallback(f func() error) {
+	if m.QueueCallbackField != nil {
+		m.QueueCallbackField(f)
+	}
+}
+
 func (m *VU) checkIntegrity() {
 	if m.InitEnvField != nil && m.StateField != nil {
 		panic("there is a bug in the test: InitEnvField and StateField are not allowed at the same time")
@@ -48,6 +48,8 @@ func NewRuntime(t testing.TB) *Runtime {
 
 	eventloop := eventloop.New(vu)
 	vu.RegisterCallbackField = eventloop.RegisterCallback
+	vu.RegisterDaemonCallbackField = eventloop.RegisterDaemonCallback
+	vu.QueueCallbackField = eventloop.QueueCallback
 	result := &Runtime{
 		VU:             vu,
 		EventLoop:      eventloop,
@@ -81,6 +83,17 @@ func (r *Runtime) SetupModuleSystem(goModules map[string]any, loader modules.Fil
 	return r.innerSetupModuleSystem()
 }
 
+// SetupModule sets up the modules system for the Runtime with only the given Go module
+// and runs the init script, which usually requires it, in the init context.
+func (r *Runtime) SetupModule(name string, module any, initScript string) error {
+	err := r.SetupModuleSystem(map[string]any{name: module}, nil, compiler.New(r.VU.InitEnv().Logger))
+	if err != nil {
+		return err
+	}
+	_, err = r.VU.Runtime().RunString(initScript)
+	return err
+}
+
 // SetupModuleSystemFromAnother sets up the modules system for the Runtime by using the resolver of another runtime.
 func (r *Runtime) SetupModuleSystemFromAnother(another *Runtime) error {
 	r.mr = another.mr
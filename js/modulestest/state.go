@@ -0,0 +1,35 @@
+package modulestest
+
+import (
+	"net"
+	"testing"
+
+	"go.k6.io/k6/internal/lib/testutils"
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/netext"
+	"go.k6.io/k6/lib/types"
+	"go.k6.io/k6/metrics"
+)
+
+// NewVUState returns a VU state with the default system tags, the builtin metrics and
+// a logger, which sends the emitted samples to the returned channel.
+func NewVUState(t testing.TB, samplesBuffer int) (*lib.State, chan metrics.SampleContainer) {
+	registry := metrics.NewRegistry()
+	samples := make(chan metrics.SampleContainer, samplesBuffer)
+	return &lib.State{
+		Options:        lib.Options{SystemTags: &metrics.DefaultSystemTagSet},
+		Logger:         testutils.NewLogger(t),
+		Samples:        samples,
+		Tags:           lib.NewVUStateTags(registry.RootTagSet()),
+		BuiltinMetrics: metrics.RegisterBuiltinMetrics(registry),
+	}, samples
+}
+
+// NewLocalDialer returns a dialer resolving every host to 127.0.0.1,
+// for the tests connecting to local test servers by name.
+func NewLocalDialer() *netext.Dialer {
+	resolver := netext.NewResolver(func(string) ([]net.IP, error) {
+		return []net.IP{net.ParseIP("127.0.0.1")}, nil
+	}, 0, types.DNSfirst, types.DNSpreferIPv4)
+	return netext.NewDialer(net.Dialer{}, resolver)
+}
@@ -9,11 +9,14 @@ import (
 	"io"
 	"io/fs"
 	"net/url"
+	"os"
 	"path"
 	"path/filepath"
 	"regexp"
+	"runtime"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"go.k6.io/k6/internal/loader"
@@ -108,12 +111,40 @@ func (arc *Archive) loadMetadataJSON(data []byte) (err error) {
 	return nil
 }
 
+// ArchiveExtractionSize is the size of the files of an archive, above which ReadArchiveTo
+// extracts them on disk, instead of keeping them in memory, and Archive.Write streams them, instead
+// of reading them in memory first.
+const ArchiveExtractionSize = 4 << 20
+
 // ReadArchive reads an archive created by Archive.Write from a reader.
-//
-//nolint:gocognit
 func ReadArchive(in io.Reader) (*Archive, error) {
+	return readArchive(in, nil, "")
+}
+
+// ReadArchiveTo reads an archive like ReadArchive, but it streams the files bigger than
+// ArchiveExtractionSize to the directory of the disk filesystem, where they're read from later,
+// instead of keeping them in memory. It's for the archives with big data files, which don't fit in
+// memory, and the caller should remove the directory when it's done with the archive.
+func ReadArchiveTo(in io.Reader, disk fsext.Fs, dir string) (*Archive, error) {
+	return readArchive(in, disk, dir)
+}
+
+//nolint:gocognit,funlen
+func readArchive(in io.Reader, disk fsext.Fs, dir string) (*Archive, error) {
 	r := tar.NewReader(in)
 	arc := &Archive{Filesystems: make(map[string]fsext.Fs, 2)}
+	// the files bigger than ArchiveExtractionSize are written directly in these
+	extracted := make(map[string]fsext.Fs, 2)
+	if disk != nil {
+		for _, scheme := range [...]string{"https", "file"} {
+			extracted[scheme] = fsext.NewBasePathFs(disk, filepath.Join(dir, scheme))
+			fs := fsext.NewCopyOnWriteFs(extracted[scheme], fsext.NewMemMapFs())
+			if scheme == "file" {
+				fs = newNormalizedFs(fs)
+			}
+			arc.Filesystems[scheme] = fs
+		}
+	}
 	// initialize both fses
 	_ = arc.getFs("https")
 	_ = arc.getFs("file")
@@ -129,19 +160,20 @@ func ReadArchive(in io.Reader) (*Archive, error) {
 			continue
 		}
 
-		data, err := io.ReadAll(r)
-		if err != nil {
-			return nil, err
-		}
-
 		switch hdr.Name {
 		case "metadata.json":
+			data, err := io.ReadAll(r)
+			if err != nil {
+				return nil, err
+			}
 			if err = arc.loadMetadataJSON(data); err != nil {
 				return nil, err
 			}
 			continue
 		case "data":
-			arc.Data = data
+			if arc.Data, err = io.ReadAll(r); err != nil {
+				return nil, err
+			}
 			continue
 		}
 
@@ -165,8 +197,12 @@ func ReadArchive(in io.Reader) (*Archive, error) {
 			fallthrough
 		case "https", "file":
 			fileSystem := arc.getFs(pfx)
+			if disk != nil && hdr.Size > ArchiveExtractionSize {
+				// the normalization of the paths is already done
+				fileSystem = extracted[pfx]
+			}
 			name = filepath.FromSlash(name)
-			if err = fsext.WriteFile(fileSystem, name, data, fs.FileMode(hdr.Mode)); err != nil { //nolint:gosec
+			if err = extractFile(fileSystem, name, r, fs.FileMode(hdr.Mode)); err != nil { //nolint:gosec
 				return nil, err
 			}
 			if err = fileSystem.Chtimes(name, hdr.AccessTime, hdr.ModTime); err != nil {
@@ -182,7 +218,7 @@ func ReadArchive(in io.Reader) (*Archive, error) {
 	if err != nil {
 		return nil, err
 	}
-	err = fsext.WriteFile(arc.getFs(scheme), pathOnFs, arc.Data, 0o644) // TODO fix the mode ?
+	err = extractFile(arc.getFs(scheme), pathOnFs, bytes.NewReader(arc.Data), 0o644) // TODO fix the mode ?
 	if err != nil {
 		return nil, err
 	}
@@ -190,6 +226,23 @@ func ReadArchive(in io.Reader) (*Archive, error) {
 	return arc, nil
 }
 
+// extractFile streams the contents of a file from the reader to the filesystem.
+func extractFile(fileSystem fsext.Fs, name string, r io.Reader, mode fs.FileMode) error {
+	// the copy on write filesystems return an error, if the directory exists in their base
+	if err := fileSystem.MkdirAll(filepath.Dir(name), 0o755); err != nil && !errors.Is(err, fs.ErrExist) {
+		return err
+	}
+	f, err := fileSystem.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(f, r); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
 func normalizeAndAnonymizeURL(u *url.URL) {
 	if u.Scheme == "file" {
 		u.Path = NormalizeAndAnonymizePath(u.Path)
@@ -281,7 +334,7 @@ func (arc *Archive) Write(out io.Writer) error {
 		foundDirs := make(map[string]bool)
 		paths := make([]string, 0, 10)
 		infos := make(map[string]fs.FileInfo) // ... fix this ?
-		files := make(map[string][]byte)
+		sources := make(map[string]string)
 
 		walkFunc := filepath.WalkFunc(func(filePath string, info fs.FileInfo, err error) error {
 			if err != nil {
@@ -296,16 +349,27 @@ func (arc *Archive) Write(out io.Writer) error {
 			}
 
 			paths = append(paths, normalizedPath)
-			files[normalizedPath], err = fsext.ReadFile(filesystem, filePath)
-			return err
+			sources[normalizedPath] = filePath
+			return nil
 		})
 
 		if err = fsext.Walk(filesystem, fsext.FilePathSeparator, walkFunc); err != nil {
 			return err
 		}
-		if len(files) == 0 {
+		if len(sources) == 0 {
 			continue // we don't need to write anything for this fs, if this is not done the root will be written
 		}
+		// the big files are streamed when they're written, instead of being read in memory
+		smallSources := make(map[string]string, len(sources))
+		for normalizedPath, filePath := range sources {
+			if infos[normalizedPath].Size() <= ArchiveExtractionSize {
+				smallSources[normalizedPath] = filePath
+			}
+		}
+		var files map[string][]byte
+		if files, err = readFilesConcurrently(filesystem, smallSources); err != nil {
+			return err
+		}
 		dirs := make([]string, 0, len(foundDirs))
 		for dirpath := range foundDirs {
 			dirs = append(dirs, dirpath)
@@ -336,17 +400,26 @@ func (arc *Archive) Write(out io.Writer) error {
 					Linkname: "data",
 				})
 			} else {
+				data, ok := files[filePath]
+				size := int64(len(data))
+				if !ok {
+					size = infos[filePath].Size()
+				}
 				err = w.WriteHeader(&tar.Header{
 					Name:       fullFilePath,
 					Mode:       0o644, // MemMapFs is buggy
-					Size:       int64(len(files[filePath])),
+					Size:       size,
 					AccessTime: infos[filePath].ModTime(),
 					ChangeTime: infos[filePath].ModTime(),
 					ModTime:    infos[filePath].ModTime(),
 					Typeflag:   tar.TypeReg,
 				})
-				if err == nil {
-					_, err = w.Write(files[filePath])
+				switch {
+				case err != nil:
+				case ok:
+					_, err = w.Write(data)
+				default:
+					err = streamFile(w, filesystem, sources[filePath])
 				}
 			}
 			if err != nil {
@@ -362,6 +435,65 @@ func (arc *Archive) Write(out io.Writer) error {
 	return w.Close()
 }
 
+// streamFile copies the contents of a file of the filesystem to the writer.
+func streamFile(w io.Writer, filesystem fsext.Fs, name string) error {
+	f, err := filesystem.Open(name)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+	_, err = io.Copy(w, f)
+	return err
+}
+
+// readFilesConcurrently reads the given files, with the keys of the returned map
+// matching the keys of the sources map. Big scripts can have thousands of
+// dependencies, so the files are read by multiple goroutines, though the archive
+// itself is still written sequentially, so its contents stay deterministic.
+func readFilesConcurrently(filesystem fsext.Fs, sources map[string]string) (map[string][]byte, error) {
+	type result struct {
+		key  string
+		data []byte
+		err  error
+	}
+
+	keys := make(chan string)
+	results := make(chan result)
+	workers := min(runtime.GOMAXPROCS(0), len(sources))
+	wg := &sync.WaitGroup{}
+	wg.Add(workers)
+	for range workers {
+		go func() {
+			defer wg.Done()
+			for key := range keys {
+				data, err := fsext.ReadFile(filesystem, sources[key])
+				results <- result{key: key, data: data, err: err}
+			}
+		}()
+	}
+	go func() {
+		for key := range sources {
+			keys <- key
+		}
+		close(keys)
+		wg.Wait()
+		close(results)
+	}()
+
+	var firstErr error
+	files := make(map[string][]byte, len(sources))
+	for r := range results {
+		if r.err != nil && firstErr == nil {
+			firstErr = r.err
+		}
+		files[r.key] = r.data
+	}
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return files, nil
+}
+
 func (arc *Archive) json() ([]byte, error) {
 	buffer := &bytes.Buffer{}
 	encoder := json.NewEncoder(buffer)
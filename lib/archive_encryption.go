@@ -0,0 +1,210 @@
+package lib
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// encryptedArchiveMagic is the start of the encrypted archives, which is
+// followed by the salt and the iterations of the key derivation, and then by
+// the chunks of the encrypted tar archive.
+const encryptedArchiveMagic = "k6-encrypted-archive/v1\n"
+
+const (
+	archiveSaltSize   = 16
+	archiveIterations = 600_000
+	// archiveMaxIterations bounds the iterations read from the archives, so
+	// a crafted one can't keep k6 deriving its key for hours.
+	archiveMaxIterations = 10_000_000
+	// archiveChunkSize is the size of the plaintext of the chunks, which are
+	// encrypted separately, so archives of any size can be streamed.
+	archiveChunkSize = 64 << 10
+	// archiveNonceSize is the size of the nonces of the chunks, made of their
+	// counter and a flag, which is set only for the last chunk, so truncated
+	// archives can't be decrypted.
+	archiveNonceSize = 12
+)
+
+// ErrWrongArchivePassphrase is returned when an encrypted archive can't be
+// decrypted, because of a wrong passphrase or because it was modified.
+var ErrWrongArchivePassphrase = errors.New("the passphrase of the encrypted archive is wrong, or the archive is corrupted")
+
+// IsEncryptedArchive returns whether the data starts like an encrypted archive.
+func IsEncryptedArchive(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(encryptedArchiveMagic))
+}
+
+// NewArchiveEncrypter returns a writer, which writes the encrypted archive of
+// the data written to it, with a key derived from the passphrase. It has to be
+// closed, to write the last chunk of the archive.
+func NewArchiveEncrypter(out io.Writer, passphrase string) (io.WriteCloser, error) {
+	if passphrase == "" {
+		return nil, errors.New("the passphrase of an encrypted archive can't be empty")
+	}
+	salt := make([]byte, archiveSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	aead, err := newArchiveAEAD(passphrase, salt, archiveIterations)
+	if err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, 0, len(encryptedArchiveMagic)+archiveSaltSize+4)
+	header = append(header, encryptedArchiveMagic...)
+	header = append(header, salt...)
+	header = binary.BigEndian.AppendUint32(header, archiveIterations)
+	if _, err = out.Write(header); err != nil {
+		return nil, err
+	}
+	return &archiveEncrypter{out: out, aead: aead, buf: make([]byte, 0, archiveChunkSize+1)}, nil
+}
+
+// NewArchiveDecrypter returns a reader of the tar archive, which is decrypted
+// from the encrypted archive with the key derived from the passphrase.
+func NewArchiveDecrypter(in io.Reader, passphrase string) (io.Reader, error) {
+	header := make([]byte, len(encryptedArchiveMagic)+archiveSaltSize+4)
+	if _, err := io.ReadFull(in, header); err != nil || !IsEncryptedArchive(header) {
+		return nil, errors.New("it's not an encrypted archive")
+	}
+	salt := header[len(encryptedArchiveMagic) : len(encryptedArchiveMagic)+archiveSaltSize]
+	iterations := binary.BigEndian.Uint32(header[len(encryptedArchiveMagic)+archiveSaltSize:])
+	aead, err := newArchiveAEAD(passphrase, salt, int(iterations))
+	if err != nil {
+		return nil, err
+	}
+	return &archiveDecrypter{
+		in:    bufio.NewReaderSize(in, archiveChunkSize+aead.Overhead()+1),
+		aead:  aead,
+		chunk: make([]byte, archiveChunkSize+aead.Overhead()),
+	}, nil
+}
+
+type archiveEncrypter struct {
+	out     io.Writer
+	aead    cipher.AEAD
+	counter uint64
+	// buf is the plaintext of the next chunk, which is only encrypted when
+	// more data follows it, so the last chunk is known when closing
+	buf    []byte
+	closed bool
+}
+
+func (e *archiveEncrypter) Write(p []byte) (int, error) {
+	if e.closed {
+		return 0, errors.New("the encrypted archive is closed")
+	}
+	n := len(p)
+	for len(p) > 0 {
+		if len(e.buf) == archiveChunkSize {
+			if err := e.seal(false); err != nil {
+				return n - len(p), err
+			}
+		}
+		m := min(len(p), archiveChunkSize-len(e.buf))
+		e.buf = append(e.buf, p[:m]...)
+		p = p[m:]
+	}
+	return n, nil
+}
+
+// Close writes the last chunk, without closing the underlying writer.
+func (e *archiveEncrypter) Close() error {
+	if e.closed {
+		return nil
+	}
+	e.closed = true
+	return e.seal(true)
+}
+
+func (e *archiveEncrypter) seal(last bool) error {
+	chunk := e.aead.Seal(nil, archiveNonce(e.counter, last), e.buf, nil)
+	e.counter++
+	e.buf = e.buf[:0]
+	_, err := e.out.Write(chunk)
+	return err
+}
+
+type archiveDecrypter struct {
+	in      *bufio.Reader
+	aead    cipher.AEAD
+	counter uint64
+	chunk   []byte
+	// plain is the decrypted data of the current chunk, which wasn't read yet
+	plain []byte
+	done  bool
+}
+
+func (d *archiveDecrypter) Read(p []byte) (int, error) {
+	for len(d.plain) == 0 {
+		if d.done {
+			return 0, io.EOF
+		}
+		if err := d.open(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, d.plain)
+	d.plain = d.plain[n:]
+	return n, nil
+}
+
+// open decrypts the next chunk, which is the last one, if it's shorter than
+// a full chunk, or if nothing follows it.
+func (d *archiveDecrypter) open() error {
+	n, err := io.ReadFull(d.in, d.chunk)
+	switch {
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		d.done = true
+	case errors.Is(err, io.EOF):
+		return fmt.Errorf("the encrypted archive is truncated: %w", io.ErrUnexpectedEOF)
+	case err != nil:
+		return err
+	default:
+		if _, err = d.in.Peek(1); errors.Is(err, io.EOF) {
+			d.done = true
+		} else if err != nil {
+			return err
+		}
+	}
+
+	d.plain, err = d.aead.Open(d.chunk[:0], archiveNonce(d.counter, d.done), d.chunk[:n], nil)
+	if err != nil {
+		if d.counter > 0 {
+			return errors.New("the encrypted archive is corrupted or truncated")
+		}
+		return ErrWrongArchivePassphrase
+	}
+	d.counter++
+	return nil
+}
+
+func newArchiveAEAD(passphrase string, salt []byte, iterations int) (cipher.AEAD, error) {
+	if iterations <= 0 || iterations > archiveMaxIterations {
+		return nil, fmt.Errorf("invalid key derivation iterations %d of the encrypted archive", iterations)
+	}
+	block, err := aes.NewCipher(pbkdf2.Key([]byte(passphrase), salt, iterations, 32, sha256.New))
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func archiveNonce(counter uint64, last bool) []byte {
+	nonce := make([]byte, archiveNonceSize)
+	binary.BigEndian.PutUint64(nonce[archiveNonceSize-9:], counter)
+	if last {
+		nonce[archiveNonceSize-1] = 1
+	}
+	return nonce
+}
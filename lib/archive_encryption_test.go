@@ -0,0 +1,80 @@
+package lib
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArchiveEncryption(t *testing.T) {
+	t.Parallel()
+
+	encrypt := func(t *testing.T, data []byte) []byte {
+		t.Helper()
+		var buf bytes.Buffer
+		w, err := NewArchiveEncrypter(&buf, "secret")
+		require.NoError(t, err)
+		// written in parts, which don't match the chunks
+		for len(data) > 0 {
+			n := min(len(data), 1000)
+			_, err = w.Write(data[:n])
+			require.NoError(t, err)
+			data = data[n:]
+		}
+		require.NoError(t, w.Close())
+		return buf.Bytes()
+	}
+	decrypt := func(encrypted []byte, passphrase string) ([]byte, error) {
+		r, err := NewArchiveDecrypter(bytes.NewReader(encrypted), passphrase)
+		if err != nil {
+			return nil, err
+		}
+		return io.ReadAll(r)
+	}
+
+	t.Run("round trip", func(t *testing.T) {
+		t.Parallel()
+		for _, size := range []int{0, 1, archiveChunkSize, archiveChunkSize + 1, 3*archiveChunkSize - 7} {
+			data := bytes.Repeat([]byte{'k', '6'}, size/2+1)[:size]
+			encrypted := encrypt(t, data)
+			assert.True(t, IsEncryptedArchive(encrypted))
+			decrypted, err := decrypt(encrypted, "secret")
+			require.NoError(t, err, size)
+			assert.Equal(t, data, decrypted, size)
+		}
+	})
+
+	t.Run("wrong passphrase", func(t *testing.T) {
+		t.Parallel()
+		_, err := decrypt(encrypt(t, []byte("data")), "wrong")
+		require.ErrorIs(t, err, ErrWrongArchivePassphrase)
+	})
+
+	t.Run("truncated", func(t *testing.T) {
+		t.Parallel()
+		encrypted := encrypt(t, make([]byte, 2*archiveChunkSize+10))
+		// without the last chunk, the chunk before it isn't the last one
+		_, err := decrypt(encrypted[:len(encrypted)-26], "secret")
+		require.ErrorContains(t, err, "the encrypted archive is corrupted or truncated")
+	})
+
+	t.Run("too many iterations", func(t *testing.T) {
+		t.Parallel()
+		encrypted := encrypt(t, []byte("data"))
+		binary.BigEndian.PutUint32(encrypted[len(encryptedArchiveMagic)+archiveSaltSize:], archiveMaxIterations+1)
+		_, err := decrypt(encrypted, "secret")
+		require.ErrorContains(t, err, "invalid key derivation iterations 10000001")
+	})
+
+	t.Run("not encrypted", func(t *testing.T) {
+		t.Parallel()
+		_, err := NewArchiveDecrypter(bytes.NewReader([]byte("plain tar")), "secret")
+		require.ErrorContains(t, err, "it's not an encrypted archive")
+		_, err = NewArchiveEncrypter(io.Discard, "")
+		require.Error(t, err)
+	})
+}
@@ -232,6 +232,53 @@ func TestArchiveReadWrite(t *testing.T) {
 	})
 }
 
+func TestArchiveExtraction(t *testing.T) {
+	t.Parallel()
+	big := bytes.Repeat([]byte("0123456789abcdef"), ArchiveExtractionSize/16+1)
+	arc1 := &Archive{
+		Type:        "js",
+		K6Version:   consts.Version,
+		FilenameURL: &url.URL{Scheme: "file", Path: "/path/to/a.js"},
+		Data:        []byte(`// a contents`),
+		PwdURL:      &url.URL{Scheme: "file", Path: "/path/to"},
+		Filesystems: map[string]fsext.Fs{
+			"file": testutils.MakeMemMapFs(t, map[string][]byte{
+				"/path/to/a.js":          []byte(`// a contents`),
+				"/path/to/data/big.bin":  big,
+				"/path/to/data/small.js": []byte(`// small`),
+			}),
+			"https": testutils.MakeMemMapFs(t, map[string][]byte{
+				"/example.com/big.bin": big,
+			}),
+		},
+	}
+
+	buf := bytes.NewBuffer(nil)
+	require.NoError(t, arc1.Write(buf))
+
+	disk := fsext.NewMemMapFs()
+	arc2, err := ReadArchiveTo(buf, disk, "/tmp/k6-archive")
+	require.NoError(t, err)
+	diffMapFilesystems(t, arc1.Filesystems, arc2.Filesystems)
+
+	// only the big files are extracted on disk
+	for _, name := range []string{"/tmp/k6-archive/file/path/to/data/big.bin", "/tmp/k6-archive/https/example.com/big.bin"} {
+		data, err := fsext.ReadFile(disk, filepath.FromSlash(name))
+		require.NoError(t, err)
+		assert.Equal(t, big, data)
+	}
+	exists, err := fsext.Exists(disk, filepath.FromSlash("/tmp/k6-archive/file/path/to/data/small.js"))
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	// the extracted files are written again in the archives
+	buf2 := bytes.NewBuffer(nil)
+	require.NoError(t, arc2.Write(buf2))
+	arc3, err := ReadArchive(buf2)
+	require.NoError(t, err)
+	diffMapFilesystems(t, arc1.Filesystems, arc3.Filesystems)
+}
+
 func TestArchiveJSONEscape(t *testing.T) {
 	t.Parallel()
 
@@ -0,0 +1,66 @@
+package lib
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"go.k6.io/k6/lib/fsext"
+)
+
+// DefaultArtifactsDir is the directory of the artifact directories of the test
+// runs, if the artifactsDir option isn't set.
+const DefaultArtifactsDir = "k6-artifacts"
+
+// Artifacts is the directory of a test run, where the script writes its
+// files, like screenshots, HAR files or debug dumps.
+type Artifacts struct {
+	fs      fsext.Fs
+	dir     string
+	written atomic.Bool
+}
+
+// NewArtifacts returns the artifacts of a test run, which was started at the
+// time, in a directory named after it in the base directory. The directory is
+// only created with the first artifact.
+func NewArtifacts(fs fsext.Fs, base string, started time.Time) *Artifacts {
+	return &Artifacts{
+		fs:  fs,
+		dir: filepath.Join(base, started.UTC().Format("20060102T150405Z")),
+	}
+}
+
+// Dir returns the path of the directory.
+func (a *Artifacts) Dir() string {
+	return a.dir
+}
+
+// Written returns whether any artifact was written.
+func (a *Artifacts) Written() bool {
+	return a.written.Load()
+}
+
+// Write writes the artifact and returns its path. Its name is a path relative
+// to the directory, which can't be outside of it.
+func (a *Artifacts) Write(name string, data []byte) (string, error) {
+	rel := filepath.Clean(filepath.FromSlash(name))
+	if name == "" || rel == "." {
+		return "", errors.New("the name of the artifact is empty")
+	}
+	if filepath.IsAbs(rel) || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("the artifact %q is outside of the artifact directory", name)
+	}
+
+	path := filepath.Join(a.dir, rel)
+	if err := a.fs.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return "", fmt.Errorf("couldn't create the directory of the artifact %q: %w", name, err)
+	}
+	if err := fsext.WriteFile(a.fs, path, data, 0o600); err != nil {
+		return "", fmt.Errorf("couldn't write the artifact %q: %w", name, err)
+	}
+	a.written.Store(true)
+	return path, nil
+}
@@ -0,0 +1,35 @@
+package lib
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/lib/fsext"
+)
+
+func TestArtifacts(t *testing.T) {
+	t.Parallel()
+
+	fs := fsext.NewMemMapFs()
+	started := time.Date(2024, 3, 5, 10, 30, 0, 0, time.FixedZone("CET", 3600))
+	artifacts := NewArtifacts(fs, "/results", started)
+	assert.Equal(t, filepath.FromSlash("/results/20240305T093000Z"), artifacts.Dir())
+	assert.False(t, artifacts.Written())
+
+	path, err := artifacts.Write("screenshots/login.png", []byte("png"))
+	require.NoError(t, err)
+	assert.Equal(t, filepath.FromSlash("/results/20240305T093000Z/screenshots/login.png"), path)
+	assert.True(t, artifacts.Written())
+	data, err := fsext.ReadFile(fs, path)
+	require.NoError(t, err)
+	assert.Equal(t, "png", string(data))
+
+	for _, name := range []string{"", ".", "a/..", "../escape.txt", "a/../../escape.txt", "/etc/passwd"} {
+		_, err := artifacts.Write(name, nil)
+		assert.Error(t, err, name)
+	}
+}
@@ -0,0 +1,340 @@
+package lib
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.k6.io/k6/lib/types"
+)
+
+// The OAuth2 flows, with which an auth session gets its access tokens.
+const (
+	AuthFlowClientCredentials = "client_credentials"
+	AuthFlowPassword          = "password"
+	AuthFlowRefreshToken      = "refresh_token"
+)
+
+// The caches of the tokens of an auth session.
+const (
+	// AuthCacheShared shares the token of the session between the VUs.
+	AuthCacheShared = "shared"
+	// AuthCacheVU gets a token for every VU, e.g. when the VUs log in as
+	// different users.
+	AuthCacheVU = "vu"
+)
+
+// defaultAuthRefreshBefore is how long before its expiration a token is
+// refreshed, unless the refreshBefore option overrides it.
+const defaultAuthRefreshBefore = 30 * time.Second
+
+// authTokenTimeout is how long the requests for the tokens and the OpenID
+// configuration can take at most.
+const authTokenTimeout = 30 * time.Second
+
+// AuthSessionOptions configure an auth session, whose access tokens are added
+// automatically to the HTTP, gRPC and WebSocket requests to its hosts, so the
+// tests don't have to get and refresh them on their own.
+type AuthSessionOptions struct {
+	// Flow is the OAuth2 grant type, with which the tokens are got.
+	Flow string `json:"flow"`
+	// TokenURL is the token endpoint. It's discovered from the OpenID
+	// configuration of the Issuer, if it isn't set.
+	TokenURL string `json:"tokenURL"`
+	Issuer   string `json:"issuer"`
+
+	ClientID     string   `json:"clientID"`
+	ClientSecret string   `json:"clientSecret"`
+	Username     string   `json:"username"`
+	Password     string   `json:"password"`
+	RefreshToken string   `json:"refreshToken"`
+	Scopes       []string `json:"scopes"`
+	Audience     string   `json:"audience"`
+
+	// Hosts are the hosts of the requests, which get the tokens. A host can
+	// have a port and start with a "*." wildcard for its subdomains.
+	Hosts []string `json:"hosts"`
+	// Cache is whether the VUs share the tokens or get their own ones.
+	Cache string `json:"cache"`
+	// RefreshBefore is how long before their expiration the tokens are
+	// refreshed.
+	RefreshBefore types.NullDuration `json:"refreshBefore"`
+}
+
+func (o AuthSessionOptions) validate(name string) []error {
+	var errs []error
+	switch o.Flow {
+	case AuthFlowClientCredentials:
+	case AuthFlowPassword:
+		if o.Username == "" {
+			errs = append(errs, fmt.Errorf("authSessions.%s.username is required by the password flow", name))
+		}
+	case AuthFlowRefreshToken:
+		if o.RefreshToken == "" {
+			errs = append(errs, fmt.Errorf("authSessions.%s.refreshToken is required by the refresh_token flow", name))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("authSessions.%s.flow must be one of %q, %q or %q, but it was %q",
+			name, AuthFlowClientCredentials, AuthFlowPassword, AuthFlowRefreshToken, o.Flow))
+	}
+	if o.TokenURL == "" && o.Issuer == "" {
+		errs = append(errs, fmt.Errorf("authSessions.%s needs either a tokenURL or an issuer", name))
+	}
+	if len(o.Hosts) == 0 {
+		errs = append(errs, fmt.Errorf("authSessions.%s.hosts can't be empty", name))
+	}
+	if o.Cache != "" && o.Cache != AuthCacheShared && o.Cache != AuthCacheVU {
+		errs = append(errs, fmt.Errorf("authSessions.%s.cache must be %q or %q, but it was %q",
+			name, AuthCacheShared, AuthCacheVU, o.Cache))
+	}
+	if o.RefreshBefore.Valid && o.RefreshBefore.Duration < 0 {
+		errs = append(errs, fmt.Errorf("authSessions.%s.refreshBefore can't be negative", name))
+	}
+	return errs
+}
+
+func (o *Options) validateAuthSessions() []error {
+	names := make([]string, 0, len(o.AuthSessions))
+	for name := range o.AuthSessions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var errs []error
+	for _, name := range names {
+		errs = append(errs, o.AuthSessions[name].validate(name)...)
+	}
+	return errs
+}
+
+// AuthSessions are the auth sessions of a VU. The sessions with the shared
+// cache are the same for all the VUs, the others are the VU's own.
+type AuthSessions struct {
+	sessions []*authSession
+}
+
+// NewAuthSessions returns the auth sessions with the options, sorted by their
+// names, so the first one, whose hosts match a request, is deterministic.
+func NewAuthSessions(opts map[string]AuthSessionOptions) *AuthSessions {
+	names := make([]string, 0, len(opts))
+	for name := range opts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	s := &AuthSessions{sessions: make([]*authSession, 0, len(names))}
+	for _, name := range names {
+		s.sessions = append(s.sessions, &authSession{name: name, opts: opts[name]})
+	}
+	return s
+}
+
+// ForVU returns the auth sessions of a new VU, which share the tokens of the
+// sessions with the shared cache.
+func (s *AuthSessions) ForVU() *AuthSessions {
+	vu := &AuthSessions{sessions: make([]*authSession, len(s.sessions))}
+	for i, session := range s.sessions {
+		if session.opts.Cache == AuthCacheVU {
+			session = &authSession{name: session.name, opts: session.opts}
+		}
+		vu.sessions[i] = session
+	}
+	return vu
+}
+
+// Authorization returns the value of the Authorization header of a request to
+// the host, with the access token of the first session whose hosts match it,
+// getting or refreshing the token with the transport if it's needed. It
+// returns an empty string if no session matches the host.
+func (s *AuthSessions) Authorization(ctx context.Context, transport http.RoundTripper, host string) (string, error) {
+	for _, session := range s.sessions {
+		if !session.matches(host) {
+			continue
+		}
+		token, err := session.accessToken(ctx, &http.Client{Transport: transport, Timeout: authTokenTimeout})
+		if err != nil {
+			return "", fmt.Errorf("getting the access token of the auth session %q: %w", session.name, err)
+		}
+		return "Bearer " + token, nil
+	}
+	return "", nil
+}
+
+// authSession gets and caches the access tokens of a session.
+type authSession struct {
+	name string
+	opts AuthSessionOptions
+
+	// mu is held while a token is got, so concurrent requests wait for it,
+	// instead of getting their own tokens.
+	mu           sync.Mutex
+	tokenURL     string
+	accessTok    string
+	refreshTok   string
+	expiresAt    time.Time
+	hasExpiresAt bool
+}
+
+// matches returns whether the host, with or without a port, is one of the
+// hosts of the session.
+func (s *authSession) matches(host string) bool {
+	hostname := host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		hostname = h
+	}
+	for _, pattern := range s.opts.Hosts {
+		if pattern == host || pattern == hostname {
+			return true
+		}
+		if suffix, ok := strings.CutPrefix(pattern, "*"); ok && strings.HasPrefix(suffix, ".") &&
+			(strings.HasSuffix(hostname, suffix) || strings.HasSuffix(host, suffix)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *authSession) accessToken(ctx context.Context, client *http.Client) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	refreshBefore := defaultAuthRefreshBefore
+	if s.opts.RefreshBefore.Valid {
+		refreshBefore = s.opts.RefreshBefore.TimeDuration()
+	}
+	if s.accessTok != "" && (!s.hasExpiresAt || time.Until(s.expiresAt) > refreshBefore) {
+		return s.accessTok, nil
+	}
+
+	if s.tokenURL == "" {
+		tokenURL, err := s.discoverTokenURL(ctx, client)
+		if err != nil {
+			return "", err
+		}
+		s.tokenURL = tokenURL
+	}
+
+	// a refresh token, which the token endpoint returned, is tried first, but
+	// the configured flow is still used, if it's rejected
+	if s.refreshTok != "" {
+		if err := s.requestToken(ctx, client, s.refreshForm(s.refreshTok)); err == nil {
+			return s.accessTok, nil
+		}
+		s.refreshTok = ""
+	}
+	if err := s.requestToken(ctx, client, s.flowForm()); err != nil {
+		return "", err
+	}
+	return s.accessTok, nil
+}
+
+func (s *authSession) discoverTokenURL(ctx context.Context, client *http.Client) (string, error) {
+	if s.opts.TokenURL != "" {
+		return s.opts.TokenURL, nil
+	}
+	discoveryURL := strings.TrimSuffix(s.opts.Issuer, "/") + "/.well-known/openid-configuration"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, discoveryURL, nil)
+	if err != nil {
+		return "", err
+	}
+	var config struct {
+		TokenEndpoint string `json:"token_endpoint"`
+	}
+	if err = doAuthRequest(client, req, &config); err != nil {
+		return "", fmt.Errorf("discovering the OpenID configuration: %w", err)
+	}
+	if config.TokenEndpoint == "" {
+		return "", errors.New("the OpenID configuration doesn't have a token_endpoint")
+	}
+	return config.TokenEndpoint, nil
+}
+
+func (s *authSession) flowForm() url.Values {
+	if s.opts.Flow == AuthFlowRefreshToken {
+		return s.refreshForm(s.opts.RefreshToken)
+	}
+	form := s.clientForm(s.opts.Flow)
+	if s.opts.Flow == AuthFlowPassword {
+		form.Set("username", s.opts.Username)
+		form.Set("password", s.opts.Password)
+	}
+	return form
+}
+
+func (s *authSession) refreshForm(refreshToken string) url.Values {
+	form := s.clientForm(AuthFlowRefreshToken)
+	form.Set("refresh_token", refreshToken)
+	return form
+}
+
+func (s *authSession) clientForm(grantType string) url.Values {
+	form := url.Values{"grant_type": {grantType}}
+	if s.opts.ClientID != "" {
+		form.Set("client_id", s.opts.ClientID)
+	}
+	if s.opts.ClientSecret != "" {
+		form.Set("client_secret", s.opts.ClientSecret)
+	}
+	if len(s.opts.Scopes) > 0 {
+		form.Set("scope", strings.Join(s.opts.Scopes, " "))
+	}
+	if s.opts.Audience != "" {
+		form.Set("audience", s.opts.Audience)
+	}
+	return form
+}
+
+func (s *authSession) requestToken(ctx context.Context, client *http.Client, form url.Values) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	var token struct {
+		AccessToken  string `json:"access_token"`
+		RefreshToken string `json:"refresh_token"`
+		ExpiresIn    int64  `json:"expires_in"`
+	}
+	if err = doAuthRequest(client, req, &token); err != nil {
+		return err
+	}
+	if token.AccessToken == "" {
+		return errors.New("the token response doesn't have an access_token")
+	}
+
+	s.accessTok = token.AccessToken
+	s.hasExpiresAt = token.ExpiresIn > 0
+	s.expiresAt = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	if token.RefreshToken != "" {
+		s.refreshTok = token.RefreshToken
+	}
+	return nil
+}
+
+func doAuthRequest(client *http.Client, req *http.Request, v any) error {
+	req.Header.Set("Accept", "application/json")
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = res.Body.Close() }()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s responded with %s: %s", req.URL.Redacted(), res.Status, strings.TrimSpace(string(body)))
+	}
+	return json.Unmarshal(body, v)
+}
@@ -0,0 +1,154 @@
+package lib
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/lib/types"
+)
+
+// newTokenServer returns a server, which issues numbered access tokens with
+// the expiration, and counts the token requests by their grant types.
+func newTokenServer(t *testing.T, expiresIn int) (*httptest.Server, map[string]*atomic.Int64) {
+	t.Helper()
+
+	grants := map[string]*atomic.Int64{
+		AuthFlowClientCredentials: {},
+		AuthFlowPassword:          {},
+		AuthFlowRefreshToken:      {},
+	}
+	var issued atomic.Int64
+	mux := http.NewServeMux()
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{"token_endpoint": srv.URL + "/token"})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		grant := r.PostForm.Get("grant_type")
+		if grant == AuthFlowPassword && r.PostForm.Get("password") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error":"invalid_grant"}`))
+			return
+		}
+		grants[grant].Add(1)
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"access_token":  fmt.Sprintf("token-%d", issued.Add(1)),
+			"refresh_token": "refresh",
+			"expires_in":    expiresIn,
+		})
+	})
+	return srv, grants
+}
+
+func TestAuthSessionsSharedAndPerVU(t *testing.T) {
+	t.Parallel()
+
+	srv, grants := newTokenServer(t, 3600)
+	sessions := NewAuthSessions(map[string]AuthSessionOptions{
+		"api": {
+			Flow:     AuthFlowClientCredentials,
+			TokenURL: srv.URL + "/token",
+			Hosts:    []string{"api.example.com"},
+		},
+		"users": {
+			Flow:     AuthFlowPassword,
+			Issuer:   srv.URL,
+			Username: "alice",
+			Password: "secret",
+			Hosts:    []string{"*.users.example.com:8443"},
+			Cache:    AuthCacheVU,
+		},
+	})
+	vu1, vu2 := sessions.ForVU(), sessions.ForVU()
+	ctx := context.Background()
+
+	authorization := func(vu *AuthSessions, host string) string {
+		value, err := vu.Authorization(ctx, http.DefaultTransport, host)
+		require.NoError(t, err)
+		return value
+	}
+
+	// the shared token is got once
+	assert.Equal(t, "Bearer token-1", authorization(vu1, "api.example.com"))
+	assert.Equal(t, "Bearer token-1", authorization(vu2, "api.example.com:443"))
+	assert.Equal(t, int64(1), grants[AuthFlowClientCredentials].Load())
+
+	// every VU gets its own token
+	assert.Equal(t, "Bearer token-2", authorization(vu1, "eu.users.example.com:8443"))
+	assert.Equal(t, "Bearer token-2", authorization(vu1, "eu.users.example.com:8443"))
+	assert.Equal(t, "Bearer token-3", authorization(vu2, "us.users.example.com:8443"))
+	assert.Equal(t, int64(2), grants[AuthFlowPassword].Load())
+
+	assert.Empty(t, authorization(vu1, "example.com"))
+	assert.Empty(t, authorization(vu1, "eu.users.example.com"))
+}
+
+func TestAuthSessionsRefresh(t *testing.T) {
+	t.Parallel()
+
+	srv, grants := newTokenServer(t, 60)
+	sessions := NewAuthSessions(map[string]AuthSessionOptions{
+		"api": {
+			Flow:          AuthFlowClientCredentials,
+			TokenURL:      srv.URL + "/token",
+			Hosts:         []string{"api.example.com"},
+			RefreshBefore: types.NullDurationFrom(time.Hour),
+		},
+	})
+
+	// the tokens expire within the refreshBefore duration, so they are
+	// refreshed with the refresh token on every request
+	for i := 1; i <= 3; i++ {
+		value, err := sessions.Authorization(context.Background(), http.DefaultTransport, "api.example.com")
+		require.NoError(t, err)
+		assert.Equal(t, fmt.Sprintf("Bearer token-%d", i), value)
+	}
+	assert.Equal(t, int64(1), grants[AuthFlowClientCredentials].Load())
+	assert.Equal(t, int64(2), grants[AuthFlowRefreshToken].Load())
+}
+
+func TestAuthSessionsError(t *testing.T) {
+	t.Parallel()
+
+	srv, _ := newTokenServer(t, 60)
+	sessions := NewAuthSessions(map[string]AuthSessionOptions{
+		"users": {
+			Flow:     AuthFlowPassword,
+			TokenURL: srv.URL + "/token",
+			Username: "alice",
+			Password: "wrong",
+			Hosts:    []string{"users.example.com"},
+		},
+	})
+	_, err := sessions.Authorization(context.Background(), http.DefaultTransport, "users.example.com")
+	require.ErrorContains(t, err, `getting the access token of the auth session "users"`)
+	assert.ErrorContains(t, err, `401 Unauthorized: {"error":"invalid_grant"}`)
+}
+
+func TestAuthSessionOptionsValidate(t *testing.T) {
+	t.Parallel()
+
+	opts := Options{AuthSessions: map[string]AuthSessionOptions{
+		"a": {Flow: "implicit", TokenURL: "https://example.com/token", Hosts: []string{"example.com"}},
+		"b": {Flow: AuthFlowPassword, Cache: "global"},
+	}}
+	errs := opts.Validate()
+	require.Len(t, errs, 5)
+	assert.ErrorContains(t, errs[0], `authSessions.a.flow must be one of`)
+	assert.ErrorContains(t, errs[1], "authSessions.b.username is required by the password flow")
+	assert.ErrorContains(t, errs[2], "authSessions.b needs either a tokenURL or an issuer")
+	assert.ErrorContains(t, errs[3], "authSessions.b.hosts can't be empty")
+	assert.ErrorContains(t, errs[4], `authSessions.b.cache must be "shared" or "vu", but it was "global"`)
+}
@@ -0,0 +1,132 @@
+package lib
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/lib/types"
+)
+
+// ClockOptions virtualize the clocks of the VUs, i.e. what Date.now() and new
+// Date() return in the scripts, e.g. so that the payloads with timestamps are
+// reproducible.
+type ClockOptions struct {
+	// Start is the time, in the RFC 3339 format, which the clocks show when
+	// the VUs are initialized, instead of the real time.
+	Start null.String `json:"start"`
+	// Frozen stops the clocks, so they advance only by the timers and the
+	// sleep() calls of the VUs, and when the scripts advance them.
+	Frozen null.Bool `json:"frozen"`
+	// Skew is added to the clocks, e.g. to test how a system handles clients
+	// with wrong clocks. The scripts can change it for their VUs.
+	Skew types.NullDuration `json:"skew"`
+}
+
+// parseStart returns the start time, or the zero time if it isn't set.
+func (co ClockOptions) parseStart() (time.Time, error) {
+	if !co.Start.Valid || co.Start.String == "" {
+		return time.Time{}, nil
+	}
+	start, err := time.Parse(time.RFC3339Nano, co.Start.String)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("clock.start must be a time in the RFC 3339 format, e.g. "+
+			"2024-01-01T00:00:00Z, but it was %q", co.Start.String)
+	}
+	return start, nil
+}
+
+// Clock is the clock of the scripts of a VU. It's the real time, unless the
+// clock option virtualizes it, plus the skew of the VU.
+type Clock struct {
+	mx sync.Mutex
+	// virtualStart is what the clock showed at realStart, if it's virtual
+	realStart, virtualStart time.Time
+	frozen                  bool
+	elapsed                 time.Duration // the virtual time since the start of a frozen clock
+	skew                    time.Duration
+}
+
+// NewClock returns a clock with the given options, which starts at realStart.
+// Without any options, it's the real time.
+func NewClock(opts *ClockOptions, realStart time.Time) (*Clock, error) {
+	c := &Clock{realStart: realStart}
+	if opts == nil {
+		return c, nil
+	}
+	start, err := opts.parseStart()
+	if err != nil {
+		return nil, err
+	}
+	c.virtualStart = start
+	c.frozen = opts.Frozen.Bool
+	if c.frozen && start.IsZero() {
+		c.virtualStart = realStart
+	}
+	c.skew = opts.Skew.TimeDuration()
+	return c, nil
+}
+
+// Now returns the time of the clock.
+func (c *Clock) Now() time.Time {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	return c.now().Add(c.skew)
+}
+
+// now returns the time of the clock without its skew.
+func (c *Clock) now() time.Time {
+	switch {
+	case c.frozen:
+		return c.virtualStart.Add(c.elapsed)
+	case !c.virtualStart.IsZero():
+		return c.virtualStart.Add(time.Since(c.realStart))
+	default:
+		return time.Now()
+	}
+}
+
+// Skew returns the skew of the clock.
+func (c *Clock) Skew() time.Duration {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	return c.skew
+}
+
+// SetSkew sets the skew of the clock.
+func (c *Clock) SetSkew(skew time.Duration) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	c.skew = skew
+}
+
+// Advance moves a frozen clock forward by d. The clocks, which aren't frozen,
+// are skewed by d instead.
+func (c *Clock) Advance(d time.Duration) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	if c.frozen {
+		c.elapsed += d
+	} else {
+		c.skew += d
+	}
+}
+
+// After returns a function, which advances a frozen clock to d after the
+// time it shows now, unless it's already past that. The timers call it when
+// they fire, so that they see the time they were scheduled for.
+func (c *Clock) After(d time.Duration) (fired func()) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	if !c.frozen {
+		return func() {}
+	}
+	target := c.elapsed + d
+	return func() {
+		c.mx.Lock()
+		defer c.mx.Unlock()
+		c.elapsed = max(c.elapsed, target)
+	}
+}
@@ -0,0 +1,86 @@
+package lib
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/lib/types"
+)
+
+func TestClock(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("Real", func(t *testing.T) {
+		t.Parallel()
+		c, err := NewClock(nil, time.Now())
+		require.NoError(t, err)
+		assert.WithinDuration(t, time.Now(), c.Now(), time.Second)
+
+		c.SetSkew(-time.Hour)
+		assert.Equal(t, -time.Hour, c.Skew())
+		assert.WithinDuration(t, time.Now().Add(-time.Hour), c.Now(), time.Second)
+		c.After(time.Minute)()
+		assert.WithinDuration(t, time.Now().Add(-time.Hour), c.Now(), time.Second, "only frozen clocks are advanced by timers")
+		c.Advance(time.Hour)
+		assert.Equal(t, time.Duration(0), c.Skew())
+	})
+
+	t.Run("Start", func(t *testing.T) {
+		t.Parallel()
+		c, err := NewClock(&ClockOptions{
+			Start: null.StringFrom(start.Format(time.RFC3339)),
+			Skew:  types.NullDurationFrom(time.Second),
+		}, time.Now())
+		require.NoError(t, err)
+		assert.WithinDuration(t, start.Add(time.Second), c.Now(), time.Second)
+	})
+
+	t.Run("Frozen", func(t *testing.T) {
+		t.Parallel()
+		c, err := NewClock(&ClockOptions{
+			Start:  null.StringFrom(start.Format(time.RFC3339)),
+			Frozen: null.BoolFrom(true),
+		}, time.Now())
+		require.NoError(t, err)
+		assert.Equal(t, start, c.Now())
+
+		// two concurrent timers advance the clock to the later one
+		fired1 := c.After(100 * time.Millisecond)
+		fired2 := c.After(300 * time.Millisecond)
+		fired1()
+		assert.Equal(t, start.Add(100*time.Millisecond), c.Now())
+		fired2()
+		assert.Equal(t, start.Add(300*time.Millisecond), c.Now())
+		c.After(0)()
+		assert.Equal(t, start.Add(300*time.Millisecond), c.Now())
+
+		c.Advance(time.Minute)
+		c.SetSkew(-time.Second)
+		assert.Equal(t, start.Add(time.Minute+300*time.Millisecond-time.Second), c.Now())
+	})
+
+	t.Run("FrozenAtTheRealStart", func(t *testing.T) {
+		t.Parallel()
+		realStart := time.Now()
+		c, err := NewClock(&ClockOptions{Frozen: null.BoolFrom(true)}, realStart)
+		require.NoError(t, err)
+		assert.Equal(t, realStart, c.Now())
+	})
+
+	t.Run("InvalidStart", func(t *testing.T) {
+		t.Parallel()
+		opts := &ClockOptions{Start: null.StringFrom("yesterday")}
+		_, err := NewClock(opts, time.Now())
+		require.ErrorContains(t, err, `clock.start must be a time in the RFC 3339 format`)
+
+		errs := Options{Clock: opts}.Validate()
+		require.Len(t, errs, 1)
+		assert.ErrorContains(t, errs[0], `but it was "yesterday"`)
+	})
+}
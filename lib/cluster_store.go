@@ -0,0 +1,117 @@
+package lib
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// TokenBucket configures a token bucket of a ClusterStore, which is refilled
+// at the rate, up to the burst.
+type TokenBucket struct {
+	// Rate is how many tokens the bucket is refilled with per second.
+	Rate float64
+	// Burst is how many tokens the bucket holds at most, and starts with.
+	Burst float64
+}
+
+// ClusterStore keeps the counters and the token buckets, which all the VUs of
+// all the instances of a test share, so the scripts can keep test-wide
+// invariants, like how many orders are created in total.
+type ClusterStore interface {
+	// AddToCounter adds the delta to the counter, unless that would make it
+	// more than the limit, and returns its value and whether it was added.
+	AddToCounter(ctx context.Context, name string, delta, limit int64) (int64, bool, error)
+	// Counter returns the value of the counter.
+	Counter(ctx context.Context, name string) (int64, error)
+	// TakeTokens takes the tokens from the bucket, if it has them, and
+	// otherwise returns how long it will take until it has them.
+	TakeTokens(ctx context.Context, name string, bucket TokenBucket, tokens float64) (bool, time.Duration, error)
+}
+
+// MemoryClusterStore is the ClusterStore of a single instance, whose counters
+// and token buckets are kept in memory.
+type MemoryClusterStore struct {
+	mu       sync.Mutex
+	counters map[string]int64
+	buckets  map[string]*memoryTokenBucket
+	now      func() time.Time
+}
+
+type memoryTokenBucket struct {
+	tokens    float64
+	updatedAt time.Time
+}
+
+var _ ClusterStore = &MemoryClusterStore{}
+
+// NewMemoryClusterStore returns a new in-memory ClusterStore.
+func NewMemoryClusterStore() *MemoryClusterStore {
+	return &MemoryClusterStore{
+		counters: make(map[string]int64),
+		buckets:  make(map[string]*memoryTokenBucket),
+		now:      time.Now,
+	}
+}
+
+// AddToCounter implements the ClusterStore interface.
+func (s *MemoryClusterStore) AddToCounter(_ context.Context, name string, delta, limit int64) (int64, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	value := s.counters[name]
+	if delta > 0 && value > limit-delta {
+		return value, false, nil
+	}
+	value += delta
+	s.counters[name] = value
+	return value, true, nil
+}
+
+// Counter implements the ClusterStore interface.
+func (s *MemoryClusterStore) Counter(_ context.Context, name string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.counters[name], nil
+}
+
+// TakeTokens implements the ClusterStore interface.
+func (s *MemoryClusterStore) TakeTokens(
+	_ context.Context, name string, bucket TokenBucket, tokens float64,
+) (bool, time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	b, ok := s.buckets[name]
+	if !ok {
+		b = &memoryTokenBucket{tokens: bucket.Burst, updatedAt: now}
+		s.buckets[name] = b
+	}
+	b.tokens = math.Min(bucket.Burst, b.tokens+now.Sub(b.updatedAt).Seconds()*bucket.Rate)
+	b.updatedAt = now
+
+	if b.tokens >= tokens {
+		b.tokens -= tokens
+		return true, 0, nil
+	}
+	return false, time.Duration((tokens - b.tokens) / bucket.Rate * float64(time.Second)), nil
+}
+
+func (o *Options) validateClusterStore() error {
+	if !o.ClusterStore.Valid || o.ClusterStore.String == "" {
+		return nil
+	}
+	u, err := url.Parse(o.ClusterStore.String)
+	if err != nil {
+		return fmt.Errorf("invalid clusterStore URL: %w", err)
+	}
+	if u.Scheme != "redis" && u.Scheme != "rediss" {
+		return fmt.Errorf("clusterStore must be a redis:// or rediss:// URL, but it was %q", u.Redacted())
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package lib
+
+import (
+	"context"
+	"math"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
+)
+
+func TestMemoryClusterStoreCounter(t *testing.T) {
+	t.Parallel()
+
+	s := NewMemoryClusterStore()
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	added := 0
+	for range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, ok, err := s.AddToCounter(ctx, "orders", 1, 30)
+			assert.NoError(t, err)
+			if ok {
+				mu.Lock()
+				added++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 30, added)
+
+	value, err := s.Counter(ctx, "orders")
+	require.NoError(t, err)
+	assert.Equal(t, int64(30), value)
+
+	value, ok, err := s.AddToCounter(ctx, "orders", -5, 30)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, int64(25), value)
+
+	value, ok, err = s.AddToCounter(ctx, "orders", math.MaxInt64, math.MaxInt64)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, int64(25), value)
+}
+
+func TestMemoryClusterStoreTokens(t *testing.T) {
+	t.Parallel()
+
+	s := NewMemoryClusterStore()
+	now := time.Unix(0, 0)
+	s.now = func() time.Time { return now }
+	ctx := context.Background()
+	bucket := TokenBucket{Rate: 10, Burst: 2}
+
+	take := func(tokens float64) (bool, time.Duration) {
+		taken, wait, err := s.TakeTokens(ctx, "api", bucket, tokens)
+		require.NoError(t, err)
+		return taken, wait
+	}
+
+	// the bucket starts full
+	taken, _ := take(2)
+	assert.True(t, taken)
+	taken, wait := take(1)
+	assert.False(t, taken)
+	assert.Equal(t, 100*time.Millisecond, wait)
+
+	now = now.Add(150 * time.Millisecond)
+	taken, _ = take(1)
+	assert.True(t, taken)
+	taken, wait = take(1)
+	assert.False(t, taken)
+	assert.Equal(t, 50*time.Millisecond, wait)
+
+	// it's refilled up to the burst
+	now = now.Add(time.Hour)
+	taken, _ = take(2)
+	assert.True(t, taken)
+	taken, _ = take(1)
+	assert.False(t, taken)
+}
+
+func TestClusterStoreOptionValidate(t *testing.T) {
+	t.Parallel()
+
+	assert.Empty(t, Options{ClusterStore: null.StringFrom("redis://localhost:6379/0")}.Validate())
+	errs := Options{ClusterStore: null.StringFrom("http://localhost:6379")}.Validate()
+	require.Len(t, errs, 1)
+	assert.ErrorContains(t, errs[0], `clusterStore must be a redis:// or rediss:// URL, but it was "http://localhost:6379"`)
+}
@@ -6,5 +6,6 @@ const (
 	Options         = "options"
 	SetupFn         = "setup"
 	TeardownFn      = "teardown"
+	OnAbortFn       = "onAbort"
 	HandleSummaryFn = "handleSummary"
 )
@@ -9,6 +9,7 @@ type ctxKey int
 const (
 	ctxKeyExecState ctxKey = iota
 	ctxKeyScenario
+	ctxKeyAbortReason
 )
 
 // WithExecutionState embeds an ExecutionState in ctx.
@@ -39,3 +40,19 @@ func GetScenarioState(ctx context.Context) *ScenarioState {
 	}
 	return v.(*ScenarioState) //nolint:forcetypeassert
 }
+
+// WithAbortReason embeds the reason of the abort of the test run in ctx, for
+// the functions which run after it, like teardown().
+func WithAbortReason(ctx context.Context, reason error) context.Context {
+	return context.WithValue(ctx, ctxKeyAbortReason, reason)
+}
+
+// GetAbortReason returns the reason of the abort of the test run from ctx, or
+// nil if it wasn't aborted.
+func GetAbortReason(ctx context.Context) error {
+	v := ctx.Value(ctxKeyAbortReason)
+	if v == nil {
+		return nil
+	}
+	return v.(error) //nolint:forcetypeassert
+}
@@ -80,6 +80,17 @@ type ExecutionState struct {
 
 	ExecutionTuple *ExecutionTuple // TODO Rename, possibly move
 
+	// ThrottleScenario is set by the execution scheduler, when some scenarios
+	// have resource budgets or health gates. It's called before each iteration
+	// of a scenario and blocks while the scenario is over its budget or the
+	// system under test is degraded, or until ctx is done. The returned done
+	// function, if it isn't nil, is called when the iteration ends.
+	ThrottleScenario func(ctx context.Context, scenario string) (done func())
+
+	// Timeline records when the iterations of the scenarios start, if it's
+	// set, so it can be exported and replayed by a later test run.
+	Timeline *Timeline
+
 	// vus is the shared channel buffer that contains all of the VUs that have
 	// been initialized and aren't currently being used by a executor.
 	//
@@ -353,6 +364,16 @@ func (es *ExecutionState) HasStarted() bool {
 	return atomic.LoadInt64(es.startTime) != 0
 }
 
+// GetStartTime returns the time at which the test started executing, or the
+// zero time if it hasn't started yet.
+func (es *ExecutionState) GetStartTime() time.Time {
+	startTime := atomic.LoadInt64(es.startTime)
+	if startTime == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, startTime)
+}
+
 // HasEnded returns true if the test has finished executing. It will return
 // false until MarkEnded() is called.
 func (es *ExecutionState) HasEnded() bool {
@@ -21,6 +21,12 @@ var DefaultGracefulStopValue = 30 * time.Second //nolint:gochecknoglobals
 
 var scenarioNameWhitelist = regexp.MustCompile(`^[0-9a-zA-Z_-]+$`)
 
+// The actions, which can be taken when an iteration times out.
+const (
+	iterationTimeoutFail = "fail"
+	iterationTimeoutSkip = "skip"
+)
+
 const scenarioNameErr = "the scenario name should contain only numbers, latin letters, underscores, and dashes"
 
 // BaseConfig contains the common config fields for all executors
@@ -34,6 +40,12 @@ type BaseConfig struct {
 	Tags         map[string]string    `json:"tags"`
 	Options      *lib.ScenarioOptions `json:"options,omitempty"`
 
+	// IterationTimeout interrupts the iterations running longer, so a hung
+	// dependency doesn't stretch them. Their interruptions are either logged as
+	// errors, or skipped, as set by OnIterationTimeout.
+	IterationTimeout   types.NullDuration `json:"iterationTimeout"`
+	OnIterationTimeout null.String        `json:"onIterationTimeout"`
+
 	// TODO: future extensions like distribution, others?
 }
 
@@ -69,6 +81,14 @@ func (bc BaseConfig) Validate() (result []error) {
 	if bc.GracefulStop.Duration < 0 {
 		result = append(result, errors.New("the gracefulStop timeout can't be negative"))
 	}
+	if bc.IterationTimeout.Duration < 0 {
+		result = append(result, errors.New("the iterationTimeout can't be negative"))
+	}
+	if bc.OnIterationTimeout.Valid &&
+		bc.OnIterationTimeout.String != iterationTimeoutFail && bc.OnIterationTimeout.String != iterationTimeoutSkip {
+		result = append(result, fmt.Errorf("the onIterationTimeout must be either %q or %q, but is %q",
+			iterationTimeoutFail, iterationTimeoutSkip, bc.OnIterationTimeout.String))
+	}
 	return result
 }
 
@@ -130,6 +150,12 @@ func (bc BaseConfig) IsDistributable() bool {
 }
 
 // getBaseInfo is a helper method for the "parent" String methods.
+// getBaseConfig returns the base config, so the executor of a scenario can be
+// replaced, while its other options are kept.
+func (bc BaseConfig) getBaseConfig() BaseConfig {
+	return bc
+}
+
 func (bc BaseConfig) getBaseInfo(facts ...string) string {
 	if bc.Exec.Valid {
 		facts = append(facts, fmt.Sprintf("exec: %s", bc.Exec.String))
@@ -273,7 +273,7 @@ func (car ConstantArrivalRate) Run(parentCtx context.Context, out chan<- metrics
 		activeVUsWg.Done()
 	}
 
-	runIterationBasic := getIterationRunner(car.executionState, car.logger)
+	runIterationBasic := getIterationRunner(car.executionState, car.config.BaseConfig, car.logger)
 	activateVU := func(initVU lib.InitializedVU) lib.ActiveVU {
 		activeVUsWg.Add(1)
 		activeVU := initVU.Activate(getVUActivationParams(
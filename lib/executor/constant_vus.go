@@ -168,7 +168,7 @@ func (clv ConstantVUs) Run(parentCtx context.Context, _ chan<- metrics.SampleCon
 	defer activeVUs.Wait()
 
 	regDurationDone := regDurationCtx.Done()
-	runIteration := getIterationRunner(clv.executionState, clv.logger)
+	runIteration := getIterationRunner(clv.executionState, clv.config.BaseConfig, clv.logger)
 
 	returnVU := func(u lib.InitializedVU) {
 		clv.executionState.ReturnVU(u, true)
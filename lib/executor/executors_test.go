@@ -3,6 +3,7 @@ package executor
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/url"
 	"testing"
@@ -105,6 +106,9 @@ var configMapTestCases = []configMapTestCase{
 	{`{"aname": {"executor": "constant-vus", "vus": 10, "duration": "10s", "startTime": "-10s"}}`, exp{validationError: true}},
 	{`{"aname": {"executor": "constant-vus", "vus": 10, "duration": "10s", "exec": ""}}`, exp{validationError: true}},
 	{`{"aname": {"executor": "constant-vus", "vus": 10, "duration": "10s", "gracefulStop": "-2s"}}`, exp{validationError: true}},
+	{`{"aname": {"executor": "constant-vus", "vus": 10, "duration": "10s", "iterationTimeout": "2s", "onIterationTimeout": "skip"}}`, exp{}},
+	{`{"aname": {"executor": "constant-vus", "vus": 10, "duration": "10s", "iterationTimeout": "-2s"}}`, exp{validationError: true}},
+	{`{"aname": {"executor": "constant-vus", "vus": 10, "duration": "10s", "onIterationTimeout": "retry"}}`, exp{validationError: true}},
 	// ramping-vus
 	{
 		`{"varloops": {"executor": "ramping-vus", "startVUs": 20, "gracefulStop": "15s", "gracefulRampDown": "10s",
@@ -408,6 +412,38 @@ var configMapTestCases = []configMapTestCase{
 	{`{"varrival": {"executor": "ramping-arrival-rate", "preAllocatedVUs": 20, "maxVUs": 50, "stages": [{"duration": "5m", "target": 10}], "timeUnit": "-1s"}}`, exp{validationError: true}},
 	{`{"varrival": {"executor": "ramping-arrival-rate", "preAllocatedVUs": 20, "maxVUs": 50, "stages": [{"duration": "5m", "target": 10}], "timeUnit": "0s"}}`, exp{validationError: true}},
 	{`{"varrival": {"executor": "ramping-arrival-rate", "preAllocatedVUs": 30, "maxVUs": 20, "stages": [{"duration": "5m", "target": 10}]}}`, exp{validationError: true}},
+	// ramping-throughput
+	{
+		`{"cdn": {"executor": "ramping-throughput", "direction": "sent", "startThroughput": 1000000, "preAllocatedVUs": 20,
+		"maxVUs": 50, "stages": [{"duration": "3m", "target": 5000000}, {"duration": "5m", "target": 0}]}}`,
+		exp{custom: func(t *testing.T, cm lib.ScenarioConfigs) {
+			sched := NewRampingThroughputConfig("cdn")
+			sched.Direction = null.StringFrom("sent")
+			sched.StartThroughput = null.IntFrom(1000000)
+			sched.Stages = []Stage{
+				{Target: null.IntFrom(5000000), Duration: types.NullDurationFrom(180 * time.Second)},
+				{Target: null.IntFrom(0), Duration: types.NullDurationFrom(300 * time.Second)},
+			}
+			sched.PreAllocatedVUs = null.IntFrom(20)
+			sched.MaxVUs = null.IntFrom(50)
+			require.Equal(t, cm, lib.ScenarioConfigs{"cdn": sched})
+
+			assert.Empty(t, cm.Validate())
+
+			et, err := lib.NewExecutionTuple(nil, nil)
+			require.NoError(t, err)
+			assert.Equal(t, "Up to 5.00 MB/s of sent data for 8m0s over 2 stages (maxVUs: 20-50, gracefulStop: 30s)", cm["cdn"].GetDescription(et))
+
+			schedReqs := cm["cdn"].GetExecutionRequirements(et)
+			endOffset, isFinal := lib.GetEndOffset(schedReqs)
+			assert.Equal(t, 510*time.Second, endOffset)
+			assert.Equal(t, true, isFinal)
+			assert.Equal(t, uint64(20), lib.GetMaxPlannedVUs(schedReqs))
+			assert.Equal(t, uint64(50), lib.GetMaxPossibleVUs(schedReqs))
+		}},
+	},
+	{`{"cdn": {"executor": "ramping-throughput", "direction": "both", "preAllocatedVUs": 20, "stages": [{"duration": "5m", "target": 10}]}}`, exp{validationError: true}},
+	{`{"cdn": {"executor": "ramping-throughput", "startThroughput": -1, "preAllocatedVUs": 20, "stages": [{"duration": "5m", "target": 10}]}}`, exp{validationError: true}},
 	// TODO: more tests of mixed executors and execution plans
 
 	// scenario options
@@ -421,6 +457,54 @@ var configMapTestCases = []configMapTestCase{
 			assert.EqualValues(t, true, siCfg.Options.Browser["someBrowserOption"])
 		}},
 	},
+	{
+		`{"ui": {"executor": "constant-vus", "vus": 2, "duration": "1m", "options": {"resources": {"maxCPU": 1.5, "maxMemory": 2000000000, "onExceed": "abort"}}}}`,
+		exp{custom: func(t *testing.T, cm lib.ScenarioConfigs) {
+			require.Empty(t, lib.Options{Scenarios: cm}.Validate())
+			budget := cm["ui"].GetScenarioOptions().Resources
+			require.NotNil(t, budget)
+			assert.Equal(t, lib.ResourceBudget{
+				MaxCPU:    null.FloatFrom(1.5),
+				MaxMemory: null.IntFrom(2000000000),
+				OnExceed:  lib.ResourceBudgetAbort,
+			}, *budget)
+		}},
+	},
+	{
+		`{"ui": {"executor": "constant-vus", "vus": 2, "duration": "1m", "options": {"resources": {"maxOpenFiles": 0, "onExceed": "kill"}}}}`,
+		exp{custom: func(t *testing.T, cm lib.ScenarioConfigs) {
+			errs := lib.Options{Scenarios: cm}.Validate()
+			require.Len(t, errs, 2)
+			assert.ErrorContains(t, errors.Join(errs...), "resources.maxOpenFiles of scenario ui must be positive")
+			assert.ErrorContains(t, errors.Join(errs...), "resources.onExceed of scenario ui must be either throttle or abort")
+		}},
+	},
+	{
+		`{"ramp": {"executor": "ramping-vus", "stages": [{"duration": "5m", "target": 100}], "options": {"healthGate": {"url": "http://prometheus:9090", "query": "job:errors:rate1m", "maxValue": 0.05, "interval": "10s", "onUnhealthy": "hold"}}}}`,
+		exp{custom: func(t *testing.T, cm lib.ScenarioConfigs) {
+			require.Empty(t, lib.Options{Scenarios: cm}.Validate())
+			gate := cm["ramp"].GetScenarioOptions().HealthGate
+			require.NotNil(t, gate)
+			assert.Equal(t, lib.HealthGate{
+				URL:         "http://prometheus:9090",
+				Query:       "job:errors:rate1m",
+				MaxValue:    null.FloatFrom(0.05),
+				Interval:    types.NullDurationFrom(10 * time.Second),
+				OnUnhealthy: lib.HealthGateHold,
+			}, *gate)
+		}},
+	},
+	{
+		`{"ramp": {"executor": "ramping-vus", "stages": [{"duration": "5m", "target": 100}], "options": {"healthGate": {"url": "staging/health", "maxValue": 1, "timeout": "0s", "onUnhealthy": "stop"}}}}`,
+		exp{custom: func(t *testing.T, cm lib.ScenarioConfigs) {
+			errs := lib.Options{Scenarios: cm}.Validate()
+			require.Len(t, errs, 4)
+			assert.ErrorContains(t, errors.Join(errs...), `healthGate.url of scenario ramp must be an http or https URL, but it was "staging/health"`)
+			assert.ErrorContains(t, errors.Join(errs...), "healthGate.maxValue of scenario ramp can be used only with a query")
+			assert.ErrorContains(t, errors.Join(errs...), "healthGate.timeout of scenario ramp must be positive")
+			assert.ErrorContains(t, errors.Join(errs...), "healthGate.onUnhealthy of scenario ramp must be either pause or hold")
+		}},
+	},
 	// only the "browser" scenario option is supported
 	{`{"ui": {"executor": "shared-iterations", "iterations": 22, "vus": 12, "maxDuration": "100s", "options": {"unsupported": {}}}}`, exp{parseError: true}},
 }
@@ -523,7 +523,7 @@ func (mex *ExternallyControlled) Run(parentCtx context.Context, _ chan<- metrics
 		currentlyPaused: false,
 		activeVUsCount:  new(int64),
 		maxVUs:          new(int64),
-		runIteration:    getIterationRunner(mex.executionState, mex.logger),
+		runIteration:    getIterationRunner(mex.executionState, mex.config.BaseConfig, mex.logger),
 	}
 	ss.ProgressFn = runState.progressFn
 
@@ -98,13 +98,30 @@ func handleInterrupt(ctx context.Context, err error) bool {
 
 // getIterationRunner is a helper function that returns an iteration executor
 // closure. It takes care of updating the execution state statistics and
-// warning messages. And returns whether a full iteration was finished or not
+// warning messages, and of throttling the scenario. And returns whether a full
+// iteration was finished or not
 //
 // TODO: emit the end-of-test iteration metrics here (https://github.com/k6io/k6/issues/1250)
 func getIterationRunner(
-	executionState *lib.ExecutionState, logger *logrus.Entry,
+	executionState *lib.ExecutionState, conf BaseConfig, logger *logrus.Entry,
 ) func(context.Context, lib.ActiveVU) bool {
+	skipTimeouts := conf.OnIterationTimeout.String == iterationTimeoutSkip
 	return func(ctx context.Context, vu lib.ActiveVU) bool {
+		if executionState.ThrottleScenario != nil {
+			if done := executionState.ThrottleScenario(ctx, conf.Name); done != nil {
+				defer done()
+			}
+			if ctx.Err() != nil {
+				return false
+			}
+		}
+		if executionState.Timeline != nil {
+			if scState := lib.GetScenarioState(ctx); scState != nil {
+				ended := executionState.Timeline.IterationStarted(conf.Name, time.Since(scState.StartTime))
+				defer ended()
+			}
+		}
+
 		err := vu.RunOnce()
 
 		// TODO: track (non-ramp-down) errors from script iterations as a metric,
@@ -123,6 +140,12 @@ func getIterationRunner(
 					return false
 				}
 
+				var timeoutErr *lib.IterationTimeoutError
+				if skipTimeouts && errors.As(err, &timeoutErr) {
+					executionState.AddInterruptedIterations(1)
+					return false
+				}
+
 				var exception errext.Exception
 				if errors.As(err, &exception) {
 					// TODO don't count this as a full iteration?
@@ -260,5 +283,6 @@ func getVUActivationParams(
 		Tags:                     conf.GetTags(),
 		DeactivateCallback:       deactivateCallback,
 		GetNextIterationCounters: nextIterationCounters,
+		IterationTimeout:         conf.IterationTimeout.TimeDuration(),
 	}
 }
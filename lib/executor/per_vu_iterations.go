@@ -192,7 +192,7 @@ func (pvi PerVUIterations) Run(parentCtx context.Context, out chan<- metrics.Sam
 	defer activeVUs.Wait()
 
 	regDurationDone := regDurationCtx.Done()
-	runIteration := getIterationRunner(pvi.executionState, pvi.logger)
+	runIteration := getIterationRunner(pvi.executionState, pvi.config.BaseConfig, pvi.logger)
 
 	returnVU := func(u lib.InitializedVU) {
 		pvi.executionState.ReturnVU(u, true)
@@ -393,7 +393,7 @@ func (varr RampingArrivalRate) Run(parentCtx context.Context, out chan<- metrics
 		activeVUsWg.Done()
 	}
 
-	runIterationBasic := getIterationRunner(varr.executionState, varr.logger)
+	runIterationBasic := getIterationRunner(varr.executionState, varr.config.BaseConfig, varr.logger)
 
 	activateVU := func(initVU lib.InitializedVU) lib.ActiveVU {
 		activeVUsWg.Add(1)
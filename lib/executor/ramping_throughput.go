@@ -0,0 +1,438 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/internal/ui/pb"
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/types"
+	"go.k6.io/k6/metrics"
+)
+
+const rampingThroughputType = "ramping-throughput"
+
+// The data, whose throughput the ramping-throughput executor targets.
+const (
+	throughputReceived = "received"
+	throughputSent     = "sent"
+)
+
+func init() {
+	lib.RegisterExecutorConfigType(
+		rampingThroughputType,
+		func(name string, rawJSON []byte) (lib.ExecutorConfig, error) {
+			config := NewRampingThroughputConfig(name)
+			err := lib.StrictJSONUnmarshal(rawJSON, &config)
+			return config, err
+		},
+	)
+}
+
+// RampingThroughputConfig stores config for the ramping throughput executor,
+// which targets a throughput of data, instead of a rate of iterations.
+type RampingThroughputConfig struct {
+	BaseConfig
+	// The data, whose throughput is targeted, either received or sent
+	Direction null.String `json:"direction"`
+	// The throughput targets are in bytes per timeUnit
+	StartThroughput null.Int           `json:"startThroughput"`
+	TimeUnit        types.NullDuration `json:"timeUnit"`
+	Stages          []Stage            `json:"stages"`
+
+	// The iterations are started at `StartRate` per timeUnit, until the data of
+	// some of them is measured. Then, every `AdjustInterval`, their rate is
+	// adjusted to the average data of the iterations and the current target.
+	StartRate      null.Int           `json:"startRate"`
+	AdjustInterval types.NullDuration `json:"adjustInterval"`
+
+	// Initialize `PreAllocatedVUs` number of VUs, and if more than that are needed,
+	// they will be dynamically allocated, until `MaxVUs` is reached, which is an
+	// absolutely hard limit on the number of VUs the executor will use
+	PreAllocatedVUs null.Int `json:"preAllocatedVUs"`
+	MaxVUs          null.Int `json:"maxVUs"`
+}
+
+// NewRampingThroughputConfig returns a RampingThroughputConfig with default values
+func NewRampingThroughputConfig(name string) *RampingThroughputConfig {
+	return &RampingThroughputConfig{
+		BaseConfig:     NewBaseConfig(name, rampingThroughputType),
+		Direction:      null.NewString(throughputReceived, false),
+		TimeUnit:       types.NewNullDuration(1*time.Second, false),
+		StartRate:      null.NewInt(1, false),
+		AdjustInterval: types.NewNullDuration(1*time.Second, false),
+	}
+}
+
+// Make sure we implement the lib.ExecutorConfig interface
+var _ lib.ExecutorConfig = &RampingThroughputConfig{}
+
+// GetPreAllocatedVUs is just a helper method that returns the scaled pre-allocated VUs.
+func (rtc RampingThroughputConfig) GetPreAllocatedVUs(et *lib.ExecutionTuple) int64 {
+	return et.ScaleInt64(rtc.PreAllocatedVUs.Int64)
+}
+
+// GetMaxVUs is just a helper method that returns the scaled max VUs.
+func (rtc RampingThroughputConfig) GetMaxVUs(et *lib.ExecutionTuple) int64 {
+	return et.ScaleInt64(rtc.MaxVUs.Int64)
+}
+
+// getThroughputPerSec returns the throughput target, in bytes per second, of
+// the given execution segment at the given time.
+func (rtc RampingThroughputConfig) getThroughputPerSec(segment *lib.ExecutionSegment, t time.Duration) float64 {
+	from := float64(rtc.StartThroughput.Int64)
+	for _, stage := range rtc.Stages {
+		to, dur := float64(stage.Target.Int64), stage.Duration.TimeDuration()
+		if t < dur {
+			from += (to - from) * float64(t) / float64(dur)
+			break
+		}
+		t -= dur
+		from = to
+	}
+	return from * segment.FloatLength() * float64(time.Second) / float64(rtc.TimeUnit.TimeDuration())
+}
+
+// GetDescription returns a human-readable description of the executor options
+func (rtc RampingThroughputConfig) GetDescription(et *lib.ExecutionTuple) string {
+	maxVUsRange := fmt.Sprintf("maxVUs: %d", et.ScaleInt64(rtc.PreAllocatedVUs.Int64))
+	if rtc.MaxVUs.Int64 > rtc.PreAllocatedVUs.Int64 {
+		maxVUsRange += fmt.Sprintf("-%d", et.ScaleInt64(rtc.MaxVUs.Int64))
+	}
+	maxThroughput := float64(getStagesUnscaledMaxTarget(rtc.StartThroughput.Int64, rtc.Stages)) *
+		et.Segment.FloatLength() * float64(time.Second) / float64(rtc.TimeUnit.TimeDuration())
+
+	return fmt.Sprintf("Up to %s of %s data for %s over %d stages%s",
+		formatThroughput(maxThroughput), rtc.Direction.String, sumStagesDuration(rtc.Stages),
+		len(rtc.Stages), rtc.getBaseInfo(maxVUsRange))
+}
+
+// Validate makes sure all options are configured and valid
+func (rtc *RampingThroughputConfig) Validate() []error {
+	errors := rtc.BaseConfig.Validate()
+
+	if rtc.Direction.String != throughputReceived && rtc.Direction.String != throughputSent {
+		errors = append(errors, fmt.Errorf("the direction must be either %q or %q, but is %q",
+			throughputReceived, throughputSent, rtc.Direction.String))
+	}
+
+	if rtc.StartThroughput.Int64 < 0 {
+		errors = append(errors, fmt.Errorf("the startThroughput value can't be negative"))
+	}
+
+	if rtc.TimeUnit.TimeDuration() <= 0 {
+		errors = append(errors, fmt.Errorf("the timeUnit must be more than 0"))
+	}
+
+	errors = append(errors, validateStages(rtc.Stages)...)
+
+	if rtc.StartRate.Int64 <= 0 {
+		errors = append(errors, fmt.Errorf("the startRate must be more than 0"))
+	}
+
+	if rtc.AdjustInterval.TimeDuration() <= 0 {
+		errors = append(errors, fmt.Errorf("the adjustInterval must be more than 0"))
+	}
+
+	if !rtc.PreAllocatedVUs.Valid {
+		errors = append(errors, fmt.Errorf("the number of preAllocatedVUs isn't specified"))
+	} else if rtc.PreAllocatedVUs.Int64 < 0 {
+		errors = append(errors, fmt.Errorf("the number of preAllocatedVUs can't be negative"))
+	}
+
+	if !rtc.MaxVUs.Valid {
+		// TODO: don't change the config while validating
+		rtc.MaxVUs.Int64 = rtc.PreAllocatedVUs.Int64
+	} else if rtc.MaxVUs.Int64 < rtc.PreAllocatedVUs.Int64 {
+		errors = append(errors, fmt.Errorf("maxVUs can't be less than preAllocatedVUs"))
+	}
+
+	return errors
+}
+
+// GetExecutionRequirements returns the number of required VUs to run the
+// executor for its whole duration (disregarding any startTime), including the
+// maximum waiting time for any iterations to gracefully stop. This is used by
+// the execution scheduler in its VU reservation calculations, so it knows how
+// many VUs to pre-initialize.
+func (rtc RampingThroughputConfig) GetExecutionRequirements(et *lib.ExecutionTuple) []lib.ExecutionStep {
+	return []lib.ExecutionStep{
+		{
+			TimeOffset:      0,
+			PlannedVUs:      uint64(et.ScaleInt64(rtc.PreAllocatedVUs.Int64)),                                   //nolint:gosec
+			MaxUnplannedVUs: uint64(et.ScaleInt64(rtc.MaxVUs.Int64) - et.ScaleInt64(rtc.PreAllocatedVUs.Int64)), //nolint:gosec
+		},
+		{
+			TimeOffset:      sumStagesDuration(rtc.Stages) + rtc.GracefulStop.TimeDuration(),
+			PlannedVUs:      0,
+			MaxUnplannedVUs: 0,
+		},
+	}
+}
+
+// NewExecutor creates a new RampingThroughput executor
+func (rtc RampingThroughputConfig) NewExecutor(
+	es *lib.ExecutionState, logger *logrus.Entry,
+) (lib.Executor, error) {
+	return &RampingThroughput{
+		BaseExecutor: NewBaseExecutor(&rtc, es, logger),
+		config:       rtc,
+	}, nil
+}
+
+// HasWork reports whether there is any work to be done for the given execution segment.
+func (rtc RampingThroughputConfig) HasWork(et *lib.ExecutionTuple) bool {
+	return rtc.GetMaxVUs(et) > 0
+}
+
+// RampingThroughput starts iterations at the rate, which reaches the targeted
+// throughput of data with the average data of the finished iterations. It's
+// meant for bandwidth testing, e.g. of CDNs, where the number of requests isn't
+// the right control variable.
+type RampingThroughput struct {
+	*BaseExecutor
+	config RampingThroughputConfig
+}
+
+// Make sure we implement the lib.Executor interface.
+var _ lib.Executor = &RampingThroughput{}
+
+// throughputController adjusts the rate of the iterations to the data of the
+// finished ones.
+type throughputController struct {
+	bytesPerIteration float64 // the moving average, 0 until it's measured
+	iterations, bytes int64   // the counters at the previous adjustment
+}
+
+// adjust returns the rate of the iterations per second, which reaches the
+// target throughput in bytes per second, with the counters of the finished
+// iterations and their data. It returns the current rate, until some data is
+// measured.
+func (tc *throughputController) adjust(target, current float64, iterations, bytes int64) float64 {
+	newIterations, newBytes := iterations-tc.iterations, bytes-tc.bytes
+	tc.iterations, tc.bytes = iterations, bytes
+	if newIterations > 0 {
+		measured := float64(newBytes) / float64(newIterations)
+		if tc.bytesPerIteration == 0 {
+			tc.bytesPerIteration = measured
+		} else {
+			// smooth out the iterations with different data
+			tc.bytesPerIteration = (tc.bytesPerIteration + measured) / 2
+		}
+	}
+	if tc.bytesPerIteration <= 0 {
+		return current
+	}
+	return target / tc.bytesPerIteration
+}
+
+// formatThroughput formats a throughput in bytes per second.
+func formatThroughput(bytesPerSec float64) string {
+	return fmt.Sprintf("%.2f MB/s", bytesPerSec/1e6)
+}
+
+// Run executes iterations at the rate reaching the targeted data throughput.
+//
+//nolint:funlen,cyclop
+func (rt RampingThroughput) Run(parentCtx context.Context, out chan<- metrics.SampleContainer) (err error) {
+	segment := rt.executionState.ExecutionTuple.Segment
+	gracefulStop := rt.config.GetGracefulStop()
+	duration := sumStagesDuration(rt.config.Stages)
+	preAllocatedVUs := rt.config.GetPreAllocatedVUs(rt.executionState.ExecutionTuple)
+	maxVUs := rt.config.GetMaxVUs(rt.executionState.ExecutionTuple)
+	adjustInterval := rt.config.AdjustInterval.TimeDuration()
+	rate := float64(rt.config.StartRate.Int64) * segment.FloatLength() *
+		float64(time.Second) / float64(rt.config.TimeUnit.TimeDuration())
+
+	// Make sure the log and the progress bar have accurate information
+	rt.logger.WithFields(logrus.Fields{
+		"maxVUs": maxVUs, "preAllocatedVUs": preAllocatedVUs, "duration": duration,
+		"numStages": len(rt.config.Stages), "adjustInterval": adjustInterval, "type": rt.config.GetType(),
+	}).Debug("Starting executor run...")
+
+	activeVUsWg := &sync.WaitGroup{}
+
+	returnedVUs := make(chan struct{})
+	waitOnProgressChannel := make(chan struct{})
+	startTime, maxDurationCtx, regDurationCtx, cancel := getDurationContexts(parentCtx, duration, gracefulStop)
+
+	vusPool := newActiveVUPool(rt.executionState)
+
+	defer func() {
+		// Make sure all VUs aren't executing iterations anymore, for the cancel()
+		// below to deactivate them.
+		<-returnedVUs
+		// first close the vusPool so we wait for the gracefulShutdown
+		vusPool.Close()
+		cancel()
+		activeVUsWg.Wait()
+		<-waitOnProgressChannel
+	}()
+
+	activeVUsCount := uint64(0)
+	var currentRate, currentThroughput atomic.Uint64 // the bits of the float64 values
+	currentRate.Store(math.Float64bits(rate))
+	vusFmt := pb.GetFixedLengthIntFormat(maxVUs)
+
+	progressFn := func() (float64, []string) {
+		currActiveVUs := atomic.LoadUint64(&activeVUsCount)
+		progVUs := fmt.Sprintf(vusFmt+"/"+vusFmt+" VUs", vusPool.Running(), currActiveVUs)
+		progThroughput := fmt.Sprintf("%s, %.2f iters/s",
+			formatThroughput(math.Float64frombits(currentThroughput.Load())),
+			math.Float64frombits(currentRate.Load()))
+
+		right := []string{progVUs, duration.String(), progThroughput}
+
+		spent := time.Since(startTime)
+		if spent > duration {
+			return 1, right
+		}
+
+		spentDuration := pb.GetFixedLengthDuration(spent, duration)
+		right[1] = fmt.Sprintf("%s/%s", spentDuration, duration)
+
+		return math.Min(1, float64(spent)/float64(duration)), right
+	}
+
+	rt.progress.Modify(pb.WithProgress(progressFn))
+	dataTransfer := new(lib.DataTransfer)
+	maxDurationCtx = lib.WithScenarioState(maxDurationCtx, &lib.ScenarioState{
+		Name:         rt.config.Name,
+		Executor:     rt.config.Type,
+		StartTime:    startTime,
+		ProgressFn:   progressFn,
+		DataTransfer: dataTransfer,
+	})
+	go func() {
+		trackProgress(parentCtx, maxDurationCtx, regDurationCtx, &rt, progressFn)
+		close(waitOnProgressChannel)
+	}()
+
+	returnVU := func(u lib.InitializedVU) {
+		// Return the VU without decreasing the global active VU counter, which
+		// is done in the goroutine started by activeVUPool.AddVU, whenever the
+		// VU finishes running an iteration.
+		rt.executionState.ReturnVU(u, false)
+		activeVUsWg.Done()
+	}
+
+	runIterationBasic := getIterationRunner(rt.executionState, rt.config.BaseConfig, rt.logger)
+
+	activateVU := func(initVU lib.InitializedVU) lib.ActiveVU {
+		activeVUsWg.Add(1)
+		activeVU := initVU.Activate(
+			getVUActivationParams(
+				maxDurationCtx, rt.config.BaseConfig, returnVU,
+				rt.nextIterationCounters))
+		atomic.AddUint64(&activeVUsCount, 1)
+
+		vusPool.AddVU(maxDurationCtx, activeVU, runIterationBasic)
+		return activeVU
+	}
+
+	remainingUnplannedVUs := maxVUs - preAllocatedVUs
+	makeUnplannedVUCh := make(chan struct{})
+	defer close(makeUnplannedVUCh)
+	go func() {
+		defer close(returnedVUs)
+
+		for range makeUnplannedVUCh {
+			rt.logger.Debug("Starting initialization of an unplanned VU...")
+			initVU, err := rt.executionState.GetUnplannedVU(maxDurationCtx, rt.logger)
+			if err != nil {
+				rt.logger.WithError(err).Error("Error while allocating unplanned VU")
+			} else {
+				rt.logger.Debug("The unplanned VU finished initializing successfully!")
+				activateVU(initVU)
+			}
+		}
+	}()
+
+	// Get the pre-allocated VUs in the local buffer
+	for i := int64(0); i < preAllocatedVUs; i++ {
+		initVU, err := rt.executionState.GetPlannedVU(rt.logger, false)
+		if err != nil {
+			return err
+		}
+		activateVU(initVU)
+	}
+
+	regDurationDone := regDurationCtx.Done()
+	adjustTicker := time.NewTicker(adjustInterval)
+	defer adjustTicker.Stop()
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	timerActive := true
+	controller := &throughputController{}
+	var prevBytes int64
+	shownWarning := false
+	metricTags := rt.getMetricTags(nil)
+	for {
+		select {
+		case <-regDurationDone:
+			return nil
+		case <-adjustTicker.C:
+			iterations, sent, received := dataTransfer.Get()
+			bytes := received
+			if rt.config.Direction.String == throughputSent {
+				bytes = sent
+			}
+			currentThroughput.Store(math.Float64bits(
+				float64(bytes-prevBytes) * float64(time.Second) / float64(adjustInterval)))
+			prevBytes = bytes
+
+			target := rt.config.getThroughputPerSec(segment, time.Since(startTime))
+			rate = controller.adjust(target, rate, iterations, bytes)
+			currentRate.Store(math.Float64bits(rate))
+			if !timerActive && rate > 0 {
+				timer.Reset(time.Duration(float64(time.Second) / rate))
+				timerActive = true
+			}
+			continue
+		case <-timer.C:
+		}
+
+		timerActive = rate > 0
+		if timerActive {
+			timer.Reset(time.Duration(float64(time.Second) / rate))
+		}
+
+		if vusPool.TryRunIteration() {
+			continue
+		}
+
+		// Since there aren't any free VUs available, consider this iteration
+		// dropped - we aren't going to try to recover it
+		metrics.PushIfNotDone(parentCtx, out, metrics.Sample{
+			TimeSeries: metrics.TimeSeries{
+				Metric: rt.executionState.Test.BuiltinMetrics.DroppedIterations,
+				Tags:   metricTags,
+			},
+			Time:  time.Now(),
+			Value: 1,
+		})
+
+		// We'll try to start allocating another VU in the background,
+		// non-blockingly, if we have remainingUnplannedVUs...
+		if remainingUnplannedVUs == 0 {
+			if !shownWarning {
+				rt.logger.Warningf("Insufficient VUs, reached %d active VUs and cannot initialize more", maxVUs)
+				shownWarning = true
+			}
+			continue
+		}
+
+		select {
+		case makeUnplannedVUCh <- struct{}{}: // great!
+			remainingUnplannedVUs--
+		default: // we're already allocating a new VU
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package executor
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/types"
+	"go.k6.io/k6/metrics"
+)
+
+func getTestRampingThroughputConfig() *RampingThroughputConfig {
+	config := NewRampingThroughputConfig("test")
+	config.GracefulStop = types.NullDurationFrom(1 * time.Second)
+	config.StartThroughput = null.IntFrom(10000)
+	config.Stages = []Stage{
+		{Duration: types.NullDurationFrom(3 * time.Second), Target: null.IntFrom(10000)},
+	}
+	config.AdjustInterval = types.NullDurationFrom(200 * time.Millisecond)
+	config.PreAllocatedVUs = null.IntFrom(5)
+	config.MaxVUs = null.IntFrom(5)
+	return config
+}
+
+func TestRampingThroughputConfigValidation(t *testing.T) {
+	t.Parallel()
+
+	config := NewRampingThroughputConfig("default")
+	errs := config.Validate()
+	require.Len(t, errs, 2)
+	assert.Contains(t, errs[0].Error(), "one stage has to be specified")
+	assert.Contains(t, errs[1].Error(), "the number of preAllocatedVUs isn't specified")
+
+	config = getTestRampingThroughputConfig()
+	require.Empty(t, config.Validate())
+
+	config.Direction = null.StringFrom("both")
+	config.StartRate = null.IntFrom(0)
+	config.AdjustInterval = types.NullDurationFrom(0)
+	errs = config.Validate()
+	require.Len(t, errs, 3)
+	assert.Contains(t, errs[0].Error(), `the direction must be either "received" or "sent", but is "both"`)
+	assert.Contains(t, errs[1].Error(), "the startRate must be more than 0")
+	assert.Contains(t, errs[2].Error(), "the adjustInterval must be more than 0")
+}
+
+func TestRampingThroughputGetThroughputPerSec(t *testing.T) {
+	t.Parallel()
+
+	config := NewRampingThroughputConfig("default")
+	config.StartThroughput = null.IntFrom(1000)
+	config.TimeUnit = types.NullDurationFrom(2 * time.Second)
+	config.Stages = []Stage{
+		{Duration: types.NullDurationFrom(10 * time.Second), Target: null.IntFrom(3000)},
+		{Duration: types.NullDurationFrom(10 * time.Second), Target: null.IntFrom(3000)},
+	}
+
+	assert.InDelta(t, 500, config.getThroughputPerSec(nil, 0), 0.001)
+	assert.InDelta(t, 1000, config.getThroughputPerSec(nil, 5*time.Second), 0.001)
+	assert.InDelta(t, 1500, config.getThroughputPerSec(nil, 15*time.Second), 0.001)
+	assert.InDelta(t, 1500, config.getThroughputPerSec(nil, time.Minute), 0.001)
+
+	segment, err := lib.NewExecutionSegmentFromString("0:1/2")
+	require.NoError(t, err)
+	assert.InDelta(t, 500, config.getThroughputPerSec(segment, 5*time.Second), 0.001)
+}
+
+func TestThroughputControllerAdjust(t *testing.T) {
+	t.Parallel()
+
+	tc := &throughputController{}
+	// the current rate is kept until some data is measured
+	assert.Equal(t, 2.0, tc.adjust(1000, 2, 0, 0))
+	assert.Equal(t, 2.0, tc.adjust(1000, 2, 3, 0))
+	// 100 bytes per iteration
+	assert.InDelta(t, 10.0, tc.adjust(1000, 2, 5, 200), 0.001)
+	// the average of 100 and 300 bytes per iteration
+	assert.InDelta(t, 5.0, tc.adjust(1000, 10, 6, 500), 0.001)
+	// no finished iterations, so the average stays the same
+	assert.InDelta(t, 10.0, tc.adjust(2000, 5, 6, 500), 0.001)
+}
+
+func TestRampingThroughputRunCorrectRate(t *testing.T) {
+	t.Parallel()
+
+	var count int64
+	runner := simpleRunner(func(ctx context.Context, _ *lib.State) error {
+		atomic.AddInt64(&count, 1)
+		// each iteration receives 1000 bytes, so 10 iterations/s reach the target
+		lib.GetScenarioState(ctx).DataTransfer.Add(100, 1000)
+		return nil
+	})
+
+	test := setupExecutorTest(t, "", "", lib.Options{}, runner, getTestRampingThroughputConfig())
+	defer test.cancel()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		time.Sleep(time.Second)
+		atomic.StoreInt64(&count, 0)
+		time.Sleep(time.Second)
+		assert.InDelta(t, 10, atomic.LoadInt64(&count), 2)
+	}()
+	engineOut := make(chan metrics.SampleContainer, 1000)
+	require.NoError(t, test.executor.Run(test.ctx, engineOut))
+	wg.Wait()
+	require.Empty(t, test.logHook.Drain())
+}
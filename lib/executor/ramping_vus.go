@@ -522,7 +522,7 @@ func (vlv *RampingVUs) Run(ctx context.Context, _ chan<- metrics.SampleContainer
 		maxVUs:         maxVUs,
 		activeVUsCount: new(int64),
 		started:        startTime,
-		runIteration:   getIterationRunner(vlv.executionState, vlv.logger),
+		runIteration:   getIterationRunner(vlv.executionState, vlv.config.BaseConfig, vlv.logger),
 	}
 
 	progressFn := runState.makeProgressFn(regularDuration)
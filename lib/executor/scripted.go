@@ -0,0 +1,403 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/internal/ui/pb"
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/types"
+	"go.k6.io/k6/metrics"
+)
+
+const scriptedType = "experimental-scripted"
+
+const (
+	// defaultScriptedMaxIterations is how many iterations the hook can
+	// schedule at most by default.
+	defaultScriptedMaxIterations = 1000000
+	// defaultScriptedHookTimeout is how long a call of the hook can take at
+	// most by default.
+	defaultScriptedHookTimeout = time.Second
+)
+
+func init() {
+	lib.RegisterExecutorConfigType(
+		scriptedType,
+		func(name string, rawJSON []byte) (lib.ExecutorConfig, error) {
+			config := NewScriptedConfig(name)
+			err := lib.StrictJSONUnmarshal(rawJSON, &config)
+			return config, err
+		},
+	)
+}
+
+// ScriptedConfig stores the config for the scripted executor, which starts the
+// iterations at the offsets, which an exported function of the script returns
+// one by one, e.g. the ones of the requests of a production log.
+type ScriptedConfig struct {
+	BaseConfig
+	// The exported function, which returns the offset of the start of the next
+	// iteration from the start of the scenario, in milliseconds, or null, if
+	// there are no more iterations.
+	NextIteration null.String `json:"nextIteration"`
+	// The iterations can't start later than the duration, nor can there be
+	// more of them than maxIterations.
+	Duration      types.NullDuration `json:"duration"`
+	MaxIterations null.Int           `json:"maxIterations"`
+	// How long a call of the nextIteration function can take at most.
+	HookTimeout types.NullDuration `json:"hookTimeout"`
+
+	// Initialize `PreAllocatedVUs` number of VUs, and if more than that are needed,
+	// they will be dynamically allocated, until `MaxVUs` is reached, which is an
+	// absolutely hard limit on the number of VUs the executor will use
+	PreAllocatedVUs null.Int `json:"preAllocatedVUs"`
+	MaxVUs          null.Int `json:"maxVUs"`
+}
+
+// NewScriptedConfig returns a ScriptedConfig with default values
+func NewScriptedConfig(name string) *ScriptedConfig {
+	return &ScriptedConfig{
+		BaseConfig:    NewBaseConfig(name, scriptedType),
+		MaxIterations: null.NewInt(defaultScriptedMaxIterations, false),
+		HookTimeout:   types.NewNullDuration(defaultScriptedHookTimeout, false),
+	}
+}
+
+// Make sure we implement the lib.ExecutorConfig interface
+var _ lib.ExecutorConfig = &ScriptedConfig{}
+
+// GetPreAllocatedVUs is just a helper method that returns the scaled pre-allocated VUs.
+func (sc ScriptedConfig) GetPreAllocatedVUs(et *lib.ExecutionTuple) int64 {
+	return et.ScaleInt64(sc.PreAllocatedVUs.Int64)
+}
+
+// GetMaxVUs is just a helper method that returns the scaled max VUs.
+func (sc ScriptedConfig) GetMaxVUs(et *lib.ExecutionTuple) int64 {
+	return et.ScaleInt64(sc.MaxVUs.Int64)
+}
+
+// GetScheduleHook returns the name of the exported function, which schedules
+// the iterations.
+func (sc ScriptedConfig) GetScheduleHook() string {
+	return sc.NextIteration.String
+}
+
+// GetDescription returns a human-readable description of the executor options
+func (sc ScriptedConfig) GetDescription(et *lib.ExecutionTuple) string {
+	preAllocatedVUs, maxVUs := sc.GetPreAllocatedVUs(et), sc.GetMaxVUs(et)
+	maxVUsRange := fmt.Sprintf("maxVUs: %d", preAllocatedVUs)
+	if maxVUs > preAllocatedVUs {
+		maxVUsRange += fmt.Sprintf("-%d", maxVUs)
+	}
+
+	return fmt.Sprintf("Iterations scheduled by %s() for %s%s", sc.NextIteration.String,
+		sc.Duration.Duration, sc.getBaseInfo(maxVUsRange))
+}
+
+// Validate makes sure all options are configured and valid
+func (sc *ScriptedConfig) Validate() []error {
+	errs := sc.BaseConfig.Validate()
+	if sc.NextIteration.String == "" {
+		errs = append(errs, errors.New("the nextIteration function isn't specified"))
+	}
+	if !sc.Duration.Valid {
+		errs = append(errs, errors.New("the duration is unspecified"))
+	} else if sc.Duration.TimeDuration() < minDuration {
+		errs = append(errs, fmt.Errorf(
+			"the duration must be at least %s, but is %s", minDuration, sc.Duration,
+		))
+	}
+	if sc.MaxIterations.Int64 <= 0 {
+		errs = append(errs, errors.New("maxIterations must be positive"))
+	}
+	if sc.HookTimeout.TimeDuration() <= 0 {
+		errs = append(errs, errors.New("hookTimeout must be positive"))
+	}
+
+	if !sc.PreAllocatedVUs.Valid {
+		errs = append(errs, errors.New("the number of preAllocatedVUs isn't specified"))
+	} else if sc.PreAllocatedVUs.Int64 < 0 {
+		errs = append(errs, errors.New("the number of preAllocatedVUs can't be negative"))
+	}
+
+	if !sc.MaxVUs.Valid {
+		// TODO: don't change the config while validating
+		sc.MaxVUs.Int64 = sc.PreAllocatedVUs.Int64
+	} else if sc.MaxVUs.Int64 < sc.PreAllocatedVUs.Int64 {
+		errs = append(errs, errors.New("maxVUs can't be less than preAllocatedVUs"))
+	}
+
+	return errs
+}
+
+// GetExecutionRequirements returns the number of required VUs to run the
+// executor for its whole duration (disregarding any startTime), including the
+// maximum waiting time for any iterations to gracefully stop. This is used by
+// the execution scheduler in its VU reservation calculations, so it knows how
+// many VUs to pre-initialize.
+func (sc ScriptedConfig) GetExecutionRequirements(et *lib.ExecutionTuple) []lib.ExecutionStep {
+	return []lib.ExecutionStep{
+		{
+			TimeOffset:      0,
+			PlannedVUs:      uint64(sc.GetPreAllocatedVUs(et)),                    //nolint:gosec
+			MaxUnplannedVUs: uint64(sc.GetMaxVUs(et) - sc.GetPreAllocatedVUs(et)), //nolint:gosec
+		}, {
+			TimeOffset:      sc.Duration.TimeDuration() + sc.GracefulStop.TimeDuration(),
+			PlannedVUs:      0,
+			MaxUnplannedVUs: 0,
+		},
+	}
+}
+
+// NewExecutor creates a new Scripted executor
+func (sc ScriptedConfig) NewExecutor(
+	es *lib.ExecutionState, logger *logrus.Entry,
+) (lib.Executor, error) {
+	return &Scripted{
+		BaseExecutor: NewBaseExecutor(&sc, es, logger),
+		config:       sc,
+	}, nil
+}
+
+// HasWork reports whether there is any work to be done for the given execution segment.
+func (sc ScriptedConfig) HasWork(et *lib.ExecutionTuple) bool {
+	return sc.GetMaxVUs(et) > 0
+}
+
+// Scripted starts the iterations at the offsets, which a function of the
+// script returns.
+type Scripted struct {
+	*BaseExecutor
+	config ScriptedConfig
+	et     *lib.ExecutionTuple
+}
+
+// Make sure we implement the lib.Executor interface.
+var _ lib.Executor = &Scripted{}
+
+// Init values needed for the execution
+func (s *Scripted) Init(_ context.Context) error {
+	// err should always be nil, because Init() won't be called for executors
+	// with no work, as determined by their config's HasWork() method.
+	et, err := s.BaseExecutor.executionState.ExecutionTuple.GetNewExecutionTupleFromValue(s.config.MaxVUs.Int64)
+	s.et = et
+	s.iterSegIndex = lib.NewSegmentedIndex(et)
+
+	return err
+}
+
+// Run calls the nextIteration function for the offset of every iteration and
+// starts the iteration at it. The function is called for all iterations, but
+// every instance only starts its own part of them. Like with the arrival-rate
+// executors, the iterations, which can't start on time because all VUs are
+// busy, are dropped.
+//
+//nolint:funlen,gocognit,cyclop
+func (s Scripted) Run(parentCtx context.Context, out chan<- metrics.SampleContainer) (err error) {
+	hookRunner, ok := s.executionState.Test.Runner.(lib.ScheduleHookRunner)
+	if !ok {
+		return fmt.Errorf("the %s executor isn't supported by this runner", scriptedType)
+	}
+
+	gracefulStop := s.config.GetGracefulStop()
+	duration := s.config.Duration.TimeDuration()
+	preAllocatedVUs := s.config.GetPreAllocatedVUs(s.executionState.ExecutionTuple)
+	maxVUs := s.config.GetMaxVUs(s.executionState.ExecutionTuple)
+
+	// Make sure the log and the progress bar have accurate information
+	s.logger.WithFields(logrus.Fields{
+		"maxVUs": maxVUs, "preAllocatedVUs": preAllocatedVUs, "duration": duration,
+		"nextIteration": s.config.NextIteration.String, "type": s.config.GetType(),
+	}).Debug("Starting executor run...")
+
+	activeVUsWg := &sync.WaitGroup{}
+
+	returnedVUs := make(chan struct{})
+	waitOnProgressChannel := make(chan struct{})
+	startTime, maxDurationCtx, regDurationCtx, cancel := getDurationContexts(parentCtx, duration, gracefulStop)
+	defer func() {
+		cancel()
+		<-waitOnProgressChannel
+	}()
+
+	hook, err := hookRunner.NewScheduleHook(regDurationCtx, s.config.NextIteration.String, out)
+	if err != nil {
+		close(waitOnProgressChannel)
+		return err
+	}
+
+	vusPool := newActiveVUPool(s.executionState)
+	defer func() {
+		// Make sure all VUs aren't executing iterations anymore, for the cancel()
+		// below to deactivate them.
+		<-returnedVUs
+		// first close the vusPool so we wait for the gracefulShutdown
+		vusPool.Close()
+		cancel()
+		activeVUsWg.Wait()
+	}()
+	activeVUsCount := uint64(0)
+	startedIterations := uint64(0)
+
+	vusFmt := pb.GetFixedLengthIntFormat(maxVUs)
+	progressFn := func() (float64, []string) {
+		spent := time.Since(startTime)
+		currActiveVUs := atomic.LoadUint64(&activeVUsCount)
+		progVUs := fmt.Sprintf(vusFmt+"/"+vusFmt+" VUs", vusPool.Running(), currActiveVUs)
+		progIters := fmt.Sprintf("%d iters", atomic.LoadUint64(&startedIterations))
+
+		right := []string{progVUs, duration.String(), progIters}
+		if spent > duration {
+			return 1, right
+		}
+
+		spentDuration := pb.GetFixedLengthDuration(spent, duration)
+		right[1] = fmt.Sprintf("%s/%s", spentDuration, duration)
+
+		return math.Min(1, float64(spent)/float64(duration)), right
+	}
+	s.progress.Modify(pb.WithProgress(progressFn))
+	maxDurationCtx = lib.WithScenarioState(maxDurationCtx, &lib.ScenarioState{
+		Name:       s.config.Name,
+		Executor:   s.config.Type,
+		StartTime:  startTime,
+		ProgressFn: progressFn,
+	})
+
+	go func() {
+		trackProgress(parentCtx, maxDurationCtx, regDurationCtx, &s, progressFn)
+		close(waitOnProgressChannel)
+	}()
+
+	returnVU := func(u lib.InitializedVU) {
+		s.executionState.ReturnVU(u, false)
+		activeVUsWg.Done()
+	}
+
+	runIterationBasic := getIterationRunner(s.executionState, s.config.BaseConfig, s.logger)
+	activateVU := func(initVU lib.InitializedVU) lib.ActiveVU {
+		activeVUsWg.Add(1)
+		activeVU := initVU.Activate(getVUActivationParams(
+			maxDurationCtx, s.config.BaseConfig, returnVU,
+			s.nextIterationCounters,
+		))
+		atomic.AddUint64(&activeVUsCount, 1)
+		vusPool.AddVU(maxDurationCtx, activeVU, runIterationBasic)
+		return activeVU
+	}
+
+	remainingUnplannedVUs := maxVUs - preAllocatedVUs
+	makeUnplannedVUCh := make(chan struct{})
+	defer close(makeUnplannedVUCh)
+	go func() {
+		defer close(returnedVUs)
+		for range makeUnplannedVUCh {
+			s.logger.Debug("Starting initialization of an unplanned VU...")
+			initVU, err := s.executionState.GetUnplannedVU(maxDurationCtx, s.logger)
+			if err != nil {
+				// TODO figure out how to return it to the Run goroutine
+				s.logger.WithError(err).Error("Error while allocating unplanned VU")
+			} else {
+				s.logger.Debug("The unplanned VU finished initializing successfully!")
+				activateVU(initVU)
+			}
+		}
+	}()
+
+	// Get the pre-allocated VUs in the local buffer
+	for i := int64(0); i < preAllocatedVUs; i++ {
+		initVU, err := s.executionState.GetPlannedVU(s.logger, false)
+		if err != nil {
+			return err
+		}
+		activateVU(initVU)
+	}
+
+	// every instance starts its own part of the iterations
+	start, offsets, _ := s.et.GetStripedOffsets()
+	next, li := start, 0
+	timer := time.NewTimer(time.Hour * 24)
+	droppedIterationMetric := s.executionState.Test.BuiltinMetrics.DroppedIterations
+	shownWarning := false
+	metricTags := s.getMetricTags(nil)
+	previous := time.Duration(-1)
+	for gi := int64(0); gi < s.config.MaxIterations.Int64; gi++ {
+		offset, ok, err := s.callHook(regDurationCtx, hook, lib.ScheduleContext{
+			Iteration: gi,
+			Elapsed:   time.Since(startTime),
+			Previous:  previous,
+		})
+		if err != nil || !ok || offset > duration {
+			return err
+		}
+		if offset < previous {
+			return fmt.Errorf("%s() returned the offset %s, which is before the offset %s of the previous iteration",
+				s.config.NextIteration.String, offset, previous)
+		}
+		previous = offset
+		if gi != next {
+			continue
+		}
+		next += offsets[li%len(offsets)]
+		li++
+
+		timer.Reset(offset - time.Since(startTime))
+		select {
+		case <-timer.C:
+			atomic.AddUint64(&startedIterations, 1)
+			if vusPool.TryRunIteration() {
+				continue
+			}
+
+			metrics.PushIfNotDone(parentCtx, out, metrics.Sample{
+				TimeSeries: metrics.TimeSeries{
+					Metric: droppedIterationMetric,
+					Tags:   metricTags,
+				},
+				Time:  time.Now(),
+				Value: 1,
+			})
+
+			if remainingUnplannedVUs == 0 {
+				if !shownWarning {
+					s.logger.Warningf("Insufficient VUs, reached %d active VUs and cannot initialize more", maxVUs)
+					shownWarning = true
+				}
+				continue
+			}
+
+			select {
+			case makeUnplannedVUCh <- struct{}{}: // great!
+				remainingUnplannedVUs--
+			default: // we're already allocating a new VU
+			}
+
+		case <-regDurationCtx.Done():
+			return nil
+		}
+	}
+	return nil
+}
+
+// callHook calls the hook with its timeout. Its interruption at the end of
+// the scenario isn't an error.
+func (s Scripted) callHook(
+	ctx context.Context, hook lib.ScheduleHook, sc lib.ScheduleContext,
+) (time.Duration, bool, error) {
+	hookCtx, cancel := context.WithTimeout(ctx, s.config.HookTimeout.TimeDuration())
+	defer cancel()
+	offset, ok, err := hook(hookCtx, sc)
+	if err != nil && ctx.Err() != nil {
+		return 0, false, nil
+	}
+	return offset, ok, err
+}
@@ -0,0 +1,135 @@
+package executor
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/internal/lib/testutils/minirunner"
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/types"
+	"go.k6.io/k6/metrics"
+)
+
+// scheduleHookRunner is a MiniRunner, whose schedule hooks return the offsets.
+type scheduleHookRunner struct {
+	*minirunner.MiniRunner
+	offsets []time.Duration
+	calls   []lib.ScheduleContext
+}
+
+func (r *scheduleHookRunner) NewScheduleHook(
+	_ context.Context, _ string, _ chan<- metrics.SampleContainer,
+) (lib.ScheduleHook, error) {
+	return func(ctx context.Context, sc lib.ScheduleContext) (time.Duration, bool, error) {
+		r.calls = append(r.calls, sc)
+		if int(sc.Iteration) >= len(r.offsets) {
+			return 0, false, nil
+		}
+		if r.offsets[sc.Iteration] < 0 {
+			<-ctx.Done()
+			return 0, false, ctx.Err()
+		}
+		return r.offsets[sc.Iteration], true, nil
+	}, nil
+}
+
+func newScheduleHookRunner(count *int64, offsets ...time.Duration) *scheduleHookRunner {
+	return &scheduleHookRunner{
+		MiniRunner: &minirunner.MiniRunner{
+			Fn: func(_ context.Context, _ *lib.State, _ chan<- metrics.SampleContainer) error {
+				atomic.AddInt64(count, 1)
+				return nil
+			},
+		},
+		offsets: offsets,
+	}
+}
+
+func getTestScriptedConfig() *ScriptedConfig {
+	config := NewScriptedConfig("scripted")
+	config.GracefulStop = types.NullDurationFrom(time.Second)
+	config.NextIteration = null.StringFrom("nextIteration")
+	config.Duration = types.NullDurationFrom(time.Second)
+	config.PreAllocatedVUs = null.IntFrom(2)
+	config.MaxVUs = null.IntFrom(2)
+	config.HookTimeout = types.NullDurationFrom(100 * time.Millisecond)
+	return config
+}
+
+func TestScriptedRun(t *testing.T) {
+	t.Parallel()
+
+	var count int64
+	runner := newScheduleHookRunner(&count, 0, 100*time.Millisecond, 100*time.Millisecond, 200*time.Millisecond)
+	test := setupExecutorTest(t, "", "", lib.Options{}, runner, getTestScriptedConfig())
+	defer test.cancel()
+
+	engineOut := make(chan metrics.SampleContainer, 1000)
+	start := time.Now()
+	require.NoError(t, test.executor.Run(test.ctx, engineOut))
+	elapsed := time.Since(start)
+	assert.GreaterOrEqual(t, elapsed, 200*time.Millisecond)
+	assert.Less(t, elapsed, time.Second)
+	assert.Equal(t, int64(4), atomic.LoadInt64(&count))
+	require.Empty(t, test.logHook.Drain())
+
+	// the hook is called once more, to end the schedule
+	require.Len(t, runner.calls, 5)
+	assert.Equal(t, time.Duration(-1), runner.calls[0].Previous)
+	assert.Equal(t, int64(3), runner.calls[3].Iteration)
+	assert.Equal(t, 100*time.Millisecond, runner.calls[3].Previous)
+}
+
+func TestScriptedRunSegment(t *testing.T) {
+	t.Parallel()
+
+	var count int64
+	runner := newScheduleHookRunner(&count, 0, 0, 10*time.Millisecond, 20*time.Millisecond)
+	test := setupExecutorTest(t, "0:1/2", "0,1/2,1", lib.Options{}, runner, getTestScriptedConfig())
+	defer test.cancel()
+
+	engineOut := make(chan metrics.SampleContainer, 1000)
+	require.NoError(t, test.executor.Run(test.ctx, engineOut))
+	assert.Equal(t, int64(2), atomic.LoadInt64(&count))
+	// the hook is still called for all iterations
+	assert.Len(t, runner.calls, 5)
+}
+
+func TestScriptedRunInvalidSchedule(t *testing.T) {
+	t.Parallel()
+
+	var count int64
+	test := setupExecutorTest(t, "", "", lib.Options{},
+		newScheduleHookRunner(&count, 100*time.Millisecond, 0), getTestScriptedConfig())
+	defer test.cancel()
+	err := test.executor.Run(test.ctx, make(chan metrics.SampleContainer, 1000))
+	assert.ErrorContains(t, err,
+		"nextIteration() returned the offset 0s, which is before the offset 100ms of the previous iteration")
+
+	test = setupExecutorTest(t, "", "", lib.Options{},
+		newScheduleHookRunner(&count, 0, -1), getTestScriptedConfig())
+	defer test.cancel()
+	err = test.executor.Run(test.ctx, make(chan metrics.SampleContainer, 1000))
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestScriptedConfigValidate(t *testing.T) {
+	t.Parallel()
+
+	assert.Empty(t, getTestScriptedConfig().Validate())
+
+	config := NewScriptedConfig("scripted")
+	config.PreAllocatedVUs = null.IntFrom(1)
+	config.MaxIterations = null.IntFrom(0)
+	errs := config.Validate()
+	require.Len(t, errs, 3)
+	assert.ErrorContains(t, errs[0], "the nextIteration function isn't specified")
+	assert.ErrorContains(t, errs[1], "the duration is unspecified")
+	assert.ErrorContains(t, errs[2], "maxIterations must be positive")
+}
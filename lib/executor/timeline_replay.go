@@ -0,0 +1,353 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/internal/ui/pb"
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/types"
+	"go.k6.io/k6/metrics"
+)
+
+const timelineReplayType = "timeline-replay"
+
+func init() {
+	lib.RegisterExecutorConfigType(
+		timelineReplayType,
+		func(name string, rawJSON []byte) (lib.ExecutorConfig, error) {
+			config := NewTimelineReplayConfig(name)
+			err := lib.StrictJSONUnmarshal(rawJSON, &config)
+			return config, err
+		},
+	)
+}
+
+// TimelineReplayConfig stores the config for the timeline replay executor,
+// which starts the iterations at the given offsets, usually the ones achieved
+// by a previous test run, exported with --export-timeline.
+type TimelineReplayConfig struct {
+	BaseConfig
+	// The offsets of the starts of the iterations from the start of the
+	// scenario, in order.
+	Iterations []types.Duration `json:"iterations"`
+
+	// Initialize `PreAllocatedVUs` number of VUs, and if more than that are needed,
+	// they will be dynamically allocated, until `MaxVUs` is reached, which is an
+	// absolutely hard limit on the number of VUs the executor will use
+	PreAllocatedVUs null.Int `json:"preAllocatedVUs"`
+	MaxVUs          null.Int `json:"maxVUs"`
+}
+
+// NewTimelineReplayConfig returns a TimelineReplayConfig with default values
+func NewTimelineReplayConfig(name string) *TimelineReplayConfig {
+	return &TimelineReplayConfig{
+		BaseConfig: NewBaseConfig(name, timelineReplayType),
+	}
+}
+
+// NewTimelineReplayConfigFrom returns a TimelineReplayConfig, which replays the
+// given timeline of a scenario, with the other options of its given config, e.g.
+// its startTime, exec and tags.
+func NewTimelineReplayConfigFrom(
+	conf lib.ExecutorConfig, timeline *lib.ScenarioTimeline,
+) (*TimelineReplayConfig, error) {
+	withBase, ok := conf.(interface{ getBaseConfig() BaseConfig })
+	if !ok {
+		return nil, fmt.Errorf("the executor %s of the scenario %s can't be replaced", conf.GetType(), conf.GetName())
+	}
+	trc := &TimelineReplayConfig{
+		BaseConfig:      withBase.getBaseConfig(),
+		Iterations:      timeline.Iterations,
+		PreAllocatedVUs: null.IntFrom(timeline.MaxVUs),
+		MaxVUs:          null.IntFrom(timeline.MaxVUs),
+	}
+	trc.Type = timelineReplayType
+	return trc, nil
+}
+
+// Make sure we implement the lib.ExecutorConfig interface
+var _ lib.ExecutorConfig = &TimelineReplayConfig{}
+
+// GetPreAllocatedVUs is just a helper method that returns the scaled pre-allocated VUs.
+func (trc TimelineReplayConfig) GetPreAllocatedVUs(et *lib.ExecutionTuple) int64 {
+	return et.ScaleInt64(trc.PreAllocatedVUs.Int64)
+}
+
+// GetMaxVUs is just a helper method that returns the scaled max VUs.
+func (trc TimelineReplayConfig) GetMaxVUs(et *lib.ExecutionTuple) int64 {
+	return et.ScaleInt64(trc.MaxVUs.Int64)
+}
+
+// getDuration returns the offset of the last iteration.
+func (trc TimelineReplayConfig) getDuration() time.Duration {
+	if len(trc.Iterations) == 0 {
+		return 0
+	}
+	return time.Duration(trc.Iterations[len(trc.Iterations)-1])
+}
+
+// GetDescription returns a human-readable description of the executor options
+func (trc TimelineReplayConfig) GetDescription(et *lib.ExecutionTuple) string {
+	preAllocatedVUs, maxVUs := trc.GetPreAllocatedVUs(et), trc.GetMaxVUs(et)
+	maxVUsRange := fmt.Sprintf("maxVUs: %d", preAllocatedVUs)
+	if maxVUs > preAllocatedVUs {
+		maxVUsRange += fmt.Sprintf("-%d", maxVUs)
+	}
+
+	return fmt.Sprintf("%d replayed iterations over %s%s", len(trc.Iterations), trc.getDuration(),
+		trc.getBaseInfo(maxVUsRange))
+}
+
+// Validate makes sure all options are configured and valid
+func (trc *TimelineReplayConfig) Validate() []error {
+	errs := trc.BaseConfig.Validate()
+	for i, offset := range trc.Iterations {
+		if offset < 0 || (i > 0 && offset < trc.Iterations[i-1]) {
+			errs = append(errs, errors.New("the iterations must be in order and not negative"))
+			break
+		}
+	}
+
+	if !trc.PreAllocatedVUs.Valid {
+		errs = append(errs, errors.New("the number of preAllocatedVUs isn't specified"))
+	} else if trc.PreAllocatedVUs.Int64 < 0 {
+		errs = append(errs, errors.New("the number of preAllocatedVUs can't be negative"))
+	}
+
+	if !trc.MaxVUs.Valid {
+		// TODO: don't change the config while validating
+		trc.MaxVUs.Int64 = trc.PreAllocatedVUs.Int64
+	} else if trc.MaxVUs.Int64 < trc.PreAllocatedVUs.Int64 {
+		errs = append(errs, errors.New("maxVUs can't be less than preAllocatedVUs"))
+	}
+
+	return errs
+}
+
+// GetExecutionRequirements returns the number of required VUs to run the
+// executor for its whole duration (disregarding any startTime), including the
+// maximum waiting time for any iterations to gracefully stop. This is used by
+// the execution scheduler in its VU reservation calculations, so it knows how
+// many VUs to pre-initialize.
+func (trc TimelineReplayConfig) GetExecutionRequirements(et *lib.ExecutionTuple) []lib.ExecutionStep {
+	return []lib.ExecutionStep{
+		{
+			TimeOffset:      0,
+			PlannedVUs:      uint64(trc.GetPreAllocatedVUs(et)),                     //nolint:gosec
+			MaxUnplannedVUs: uint64(trc.GetMaxVUs(et) - trc.GetPreAllocatedVUs(et)), //nolint:gosec
+		}, {
+			TimeOffset:      trc.getDuration() + trc.GracefulStop.TimeDuration(),
+			PlannedVUs:      0,
+			MaxUnplannedVUs: 0,
+		},
+	}
+}
+
+// NewExecutor creates a new TimelineReplay executor
+func (trc TimelineReplayConfig) NewExecutor(
+	es *lib.ExecutionState, logger *logrus.Entry,
+) (lib.Executor, error) {
+	return &TimelineReplay{
+		BaseExecutor: NewBaseExecutor(&trc, es, logger),
+		config:       trc,
+	}, nil
+}
+
+// HasWork reports whether there is any work to be done for the given execution segment.
+func (trc TimelineReplayConfig) HasWork(et *lib.ExecutionTuple) bool {
+	return len(trc.Iterations) > 0 && trc.GetMaxVUs(et) > 0
+}
+
+// TimelineReplay starts the iterations at the offsets of a timeline.
+type TimelineReplay struct {
+	*BaseExecutor
+	config TimelineReplayConfig
+	et     *lib.ExecutionTuple
+}
+
+// Make sure we implement the lib.Executor interface.
+var _ lib.Executor = &TimelineReplay{}
+
+// Init values needed for the execution
+func (tr *TimelineReplay) Init(_ context.Context) error {
+	// err should always be nil, because Init() won't be called for executors
+	// with no work, as determined by their config's HasWork() method.
+	et, err := tr.BaseExecutor.executionState.ExecutionTuple.GetNewExecutionTupleFromValue(tr.config.MaxVUs.Int64)
+	tr.et = et
+	tr.iterSegIndex = lib.NewSegmentedIndex(et)
+
+	return err
+}
+
+// Run starts the iterations at the offsets of the timeline. Like with the
+// arrival-rate executors, the iterations, which can't start on time because
+// all VUs are busy, are dropped.
+//
+//nolint:funlen
+func (tr TimelineReplay) Run(parentCtx context.Context, out chan<- metrics.SampleContainer) (err error) {
+	gracefulStop := tr.config.GetGracefulStop()
+	duration := tr.config.getDuration()
+	preAllocatedVUs := tr.config.GetPreAllocatedVUs(tr.executionState.ExecutionTuple)
+	maxVUs := tr.config.GetMaxVUs(tr.executionState.ExecutionTuple)
+
+	// Make sure the log and the progress bar have accurate information
+	tr.logger.WithFields(logrus.Fields{
+		"maxVUs": maxVUs, "preAllocatedVUs": preAllocatedVUs, "duration": duration,
+		"iterations": len(tr.config.Iterations), "type": tr.config.GetType(),
+	}).Debug("Starting executor run...")
+
+	activeVUsWg := &sync.WaitGroup{}
+
+	returnedVUs := make(chan struct{})
+	waitOnProgressChannel := make(chan struct{})
+	startTime, maxDurationCtx, regDurationCtx, cancel := getDurationContexts(parentCtx, duration, gracefulStop)
+	defer func() {
+		cancel()
+		<-waitOnProgressChannel
+	}()
+
+	vusPool := newActiveVUPool(tr.executionState)
+	defer func() {
+		// Make sure all VUs aren't executing iterations anymore, for the cancel()
+		// below to deactivate them.
+		<-returnedVUs
+		// first close the vusPool so we wait for the gracefulShutdown
+		vusPool.Close()
+		cancel()
+		activeVUsWg.Wait()
+	}()
+	activeVUsCount := uint64(0)
+	startedIterations := uint64(0)
+
+	vusFmt := pb.GetFixedLengthIntFormat(maxVUs)
+	itersFmt := pb.GetFixedLengthIntFormat(int64(len(tr.config.Iterations)))
+	progressFn := func() (float64, []string) {
+		spent := time.Since(startTime)
+		currActiveVUs := atomic.LoadUint64(&activeVUsCount)
+		progVUs := fmt.Sprintf(vusFmt+"/"+vusFmt+" VUs", vusPool.Running(), currActiveVUs)
+		progIters := fmt.Sprintf(itersFmt+"/"+itersFmt+" iters",
+			atomic.LoadUint64(&startedIterations), len(tr.config.Iterations))
+
+		right := []string{progVUs, duration.String(), progIters}
+		if spent > duration {
+			return 1, right
+		}
+
+		spentDuration := pb.GetFixedLengthDuration(spent, duration)
+		right[1] = fmt.Sprintf("%s/%s", spentDuration, duration)
+
+		return math.Min(1, float64(spent)/float64(duration)), right
+	}
+	tr.progress.Modify(pb.WithProgress(progressFn))
+	maxDurationCtx = lib.WithScenarioState(maxDurationCtx, &lib.ScenarioState{
+		Name:       tr.config.Name,
+		Executor:   tr.config.Type,
+		StartTime:  startTime,
+		ProgressFn: progressFn,
+	})
+
+	go func() {
+		trackProgress(parentCtx, maxDurationCtx, regDurationCtx, &tr, progressFn)
+		close(waitOnProgressChannel)
+	}()
+
+	returnVU := func(u lib.InitializedVU) {
+		tr.executionState.ReturnVU(u, false)
+		activeVUsWg.Done()
+	}
+
+	runIterationBasic := getIterationRunner(tr.executionState, tr.config.BaseConfig, tr.logger)
+	activateVU := func(initVU lib.InitializedVU) lib.ActiveVU {
+		activeVUsWg.Add(1)
+		activeVU := initVU.Activate(getVUActivationParams(
+			maxDurationCtx, tr.config.BaseConfig, returnVU,
+			tr.nextIterationCounters,
+		))
+		atomic.AddUint64(&activeVUsCount, 1)
+		vusPool.AddVU(maxDurationCtx, activeVU, runIterationBasic)
+		return activeVU
+	}
+
+	remainingUnplannedVUs := maxVUs - preAllocatedVUs
+	makeUnplannedVUCh := make(chan struct{})
+	defer close(makeUnplannedVUCh)
+	go func() {
+		defer close(returnedVUs)
+		for range makeUnplannedVUCh {
+			tr.logger.Debug("Starting initialization of an unplanned VU...")
+			initVU, err := tr.executionState.GetUnplannedVU(maxDurationCtx, tr.logger)
+			if err != nil {
+				// TODO figure out how to return it to the Run goroutine
+				tr.logger.WithError(err).Error("Error while allocating unplanned VU")
+			} else {
+				tr.logger.Debug("The unplanned VU finished initializing successfully!")
+				activateVU(initVU)
+			}
+		}
+	}()
+
+	// Get the pre-allocated VUs in the local buffer
+	for i := int64(0); i < preAllocatedVUs; i++ {
+		initVU, err := tr.executionState.GetPlannedVU(tr.logger, false)
+		if err != nil {
+			return err
+		}
+		activateVU(initVU)
+	}
+
+	// every instance replays its own part of the iterations
+	start, offsets, _ := tr.et.GetStripedOffsets()
+	timer := time.NewTimer(time.Hour * 24)
+	droppedIterationMetric := tr.executionState.Test.BuiltinMetrics.DroppedIterations
+	shownWarning := false
+	metricTags := tr.getMetricTags(nil)
+	iterations := int64(len(tr.config.Iterations))
+	for li, gi := 0, start; gi < iterations; li, gi = li+1, gi+offsets[li%len(offsets)] {
+		timer.Reset(time.Duration(tr.config.Iterations[gi]) - time.Since(startTime))
+		select {
+		case <-timer.C:
+			atomic.AddUint64(&startedIterations, 1)
+			if vusPool.TryRunIteration() {
+				continue
+			}
+
+			metrics.PushIfNotDone(parentCtx, out, metrics.Sample{
+				TimeSeries: metrics.TimeSeries{
+					Metric: droppedIterationMetric,
+					Tags:   metricTags,
+				},
+				Time:  time.Now(),
+				Value: 1,
+			})
+
+			if remainingUnplannedVUs == 0 {
+				if !shownWarning {
+					tr.logger.Warningf("Insufficient VUs, reached %d active VUs and cannot initialize more", maxVUs)
+					shownWarning = true
+				}
+				continue
+			}
+
+			select {
+			case makeUnplannedVUCh <- struct{}{}: // great!
+				remainingUnplannedVUs--
+			default: // we're already allocating a new VU
+			}
+
+		// the last iteration starts at the end of the regular duration, so
+		// only the end of the graceful stop stops the replay
+		case <-maxDurationCtx.Done():
+			return nil
+		}
+	}
+	return nil
+}
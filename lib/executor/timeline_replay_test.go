@@ -0,0 +1,105 @@
+package executor
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/types"
+	"go.k6.io/k6/metrics"
+)
+
+func getTestTimelineReplayConfig() *TimelineReplayConfig {
+	return &TimelineReplayConfig{
+		BaseConfig: BaseConfig{Name: "replay", GracefulStop: types.NullDurationFrom(1 * time.Second)},
+		Iterations: []types.Duration{
+			0, types.Duration(100 * time.Millisecond), types.Duration(100 * time.Millisecond),
+			types.Duration(300 * time.Millisecond),
+		},
+		PreAllocatedVUs: null.IntFrom(2),
+		MaxVUs:          null.IntFrom(2),
+	}
+}
+
+func TestTimelineReplayRun(t *testing.T) {
+	t.Parallel()
+
+	var count int64
+	runner := simpleRunner(func(_ context.Context, _ *lib.State) error {
+		atomic.AddInt64(&count, 1)
+		time.Sleep(50 * time.Millisecond)
+		return nil
+	})
+
+	test := setupExecutorTest(t, "", "", lib.Options{}, runner, getTestTimelineReplayConfig())
+	defer test.cancel()
+	test.state.Timeline = lib.NewTimeline("replay")
+
+	engineOut := make(chan metrics.SampleContainer, 1000)
+	start := time.Now()
+	require.NoError(t, test.executor.Run(test.ctx, engineOut))
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, int64(4), atomic.LoadInt64(&count))
+	require.Empty(t, test.logHook.Drain())
+
+	// the replayed run achieves the same timeline
+	recorded := test.state.Timeline.Scenarios["replay"]
+	require.Len(t, recorded.Iterations, 4)
+	for i, offset := range getTestTimelineReplayConfig().Iterations {
+		assert.InDelta(t, time.Duration(offset), time.Duration(recorded.Iterations[i]), float64(30*time.Millisecond))
+	}
+	assert.Equal(t, int64(2), recorded.MaxVUs)
+}
+
+func TestTimelineReplayRunSegment(t *testing.T) {
+	t.Parallel()
+
+	var count int64
+	runner := simpleRunner(func(_ context.Context, _ *lib.State) error {
+		atomic.AddInt64(&count, 1)
+		return nil
+	})
+
+	test := setupExecutorTest(t, "0:1/2", "0,1/2,1", lib.Options{}, runner, getTestTimelineReplayConfig())
+	defer test.cancel()
+
+	engineOut := make(chan metrics.SampleContainer, 1000)
+	require.NoError(t, test.executor.Run(test.ctx, engineOut))
+	assert.Equal(t, int64(2), atomic.LoadInt64(&count))
+}
+
+func TestNewTimelineReplayConfigFrom(t *testing.T) {
+	t.Parallel()
+
+	conf := NewConstantVUsConfig("default")
+	conf.VUs = null.IntFrom(5)
+	conf.Duration = types.NullDurationFrom(10 * time.Second)
+	conf.StartTime = types.NullDurationFrom(2 * time.Second)
+	conf.Exec = null.StringFrom("run")
+
+	timeline := &lib.ScenarioTimeline{
+		Iterations: []types.Duration{0, types.Duration(time.Second)},
+		MaxVUs:     3,
+	}
+	replayConf, err := NewTimelineReplayConfigFrom(conf, timeline)
+	require.NoError(t, err)
+	require.Empty(t, replayConf.Validate())
+
+	assert.Equal(t, timelineReplayType, replayConf.GetType())
+	assert.Equal(t, "default", replayConf.GetName())
+	assert.Equal(t, 2*time.Second, replayConf.GetStartTime())
+	assert.Equal(t, "run", replayConf.GetExec())
+	assert.Equal(t, timeline.Iterations, replayConf.Iterations)
+	assert.Equal(t, null.IntFrom(3), replayConf.MaxVUs)
+
+	et, err := lib.NewExecutionTuple(nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "2 replayed iterations over 1s (maxVUs: 3, exec: run, startTime: 2s, gracefulStop: 30s)",
+		replayConf.GetDescription(et))
+}
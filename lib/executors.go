@@ -7,11 +7,14 @@ import (
 	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/sirupsen/logrus"
+	"gopkg.in/guregu/null.v3"
 
 	"go.k6.io/k6/internal/ui/pb"
+	"go.k6.io/k6/lib/types"
 	"go.k6.io/k6/metrics"
 )
 
@@ -97,6 +100,85 @@ type ExecutorConfig interface {
 // options, which are validated by the browser module, and not by k6 core.
 type ScenarioOptions struct {
 	Browser map[string]any `json:"browser"`
+	// DNS overrides the global DNS options for the VUs running the scenario.
+	DNS *types.DNSConfig `json:"dns,omitempty"`
+	// TLSSessionResumption overrides the global TLS session resumption options
+	// for the VUs running the scenario.
+	TLSSessionResumption *TLSSessionResumption `json:"tlsSessionResumption,omitempty"`
+	// TLSAuth is the name of the tlsAuth certificate that the requests of the
+	// scenario present, instead of the one matching their domains.
+	TLSAuth string `json:"tlsAuth,omitempty"`
+	// Proxy overrides the global proxy option for the VUs running the scenario.
+	Proxy string `json:"proxy,omitempty"`
+	// BlacklistIPs and BlockHostnames override the global blacklistIPs and
+	// blockHostnames options for the VUs running the scenario, so e.g. an
+	// empty list lets them reach the addresses, which are blocked globally.
+	BlacklistIPs   *[]*IPNet               `json:"blacklistIPs,omitempty"`
+	BlockHostnames *types.NullHostnameTrie `json:"blockHostnames,omitempty"`
+	// NetworkConditions override the fields of the global networkConditions
+	// option for the VUs running the scenario.
+	NetworkConditions *NetworkConditions `json:"networkConditions,omitempty"`
+	// HTTPProfile overrides the global httpProfile option for the VUs running
+	// the scenario.
+	HTTPProfile string `json:"httpProfile,omitempty"`
+	// Resources is the budget of the local resources that can be used while
+	// the scenario is running.
+	Resources *ResourceBudget `json:"resources,omitempty"`
+	// HealthGate is the probe of the health of the system under test, which
+	// pauses or holds the scenario while the system is degraded.
+	HealthGate *HealthGate `json:"healthGate,omitempty"`
+}
+
+// The actions, which can be taken when a scenario is over its resource budget.
+const (
+	ResourceBudgetThrottle = "throttle"
+	ResourceBudgetAbort    = "abort"
+)
+
+// ResourceBudget limits the CPU, memory and open files, which can be used while
+// a scenario is running. Since the VUs of all scenarios run in the same
+// process, the usage is measured for the whole k6 process, together with its
+// child processes, e.g. the browsers. When it's over one of the limits, new
+// iterations of the scenario are delayed until it isn't, or the test is aborted,
+// so a heavy scenario can't starve the others running on the same machine.
+type ResourceBudget struct {
+	// MaxCPU is the number of CPU cores, e.g. 1.5.
+	MaxCPU null.Float `json:"maxCPU"`
+	// MaxMemory is the resident memory in bytes.
+	MaxMemory    null.Int `json:"maxMemory"`
+	MaxOpenFiles null.Int `json:"maxOpenFiles"`
+	// OnExceed is either throttle, the default, or abort.
+	OnExceed string `json:"onExceed,omitempty"`
+}
+
+// The actions, which can be taken when the health gate of a scenario reports
+// that the system under test is degraded.
+const (
+	HealthGatePause = "pause"
+	HealthGateHold  = "hold"
+)
+
+// HealthGate periodically probes the health of the system under test, either
+// with an HTTP endpoint, which is healthy when it responds with a 2xx status,
+// or with a PromQL query, which is healthy when none of its results are over
+// MaxValue, or when it has no results, if MaxValue isn't set. While the system
+// is degraded, the new iterations of the scenario are paused or held at their
+// current concurrency, so a ramping scenario can't tip a fragile environment
+// into a total collapse, and they're resumed when it's healthy again.
+type HealthGate struct {
+	// URL is the health endpoint, or the Prometheus server if Query is set.
+	URL string `json:"url"`
+	// Query is the PromQL query, e.g. the error rate or the firing alerts.
+	Query    string     `json:"query,omitempty"`
+	MaxValue null.Float `json:"maxValue"`
+	// Interval is the time between the probes, 5s by default.
+	Interval types.NullDuration `json:"interval"`
+	// Timeout is the timeout of a probe, the interval by default.
+	Timeout types.NullDuration `json:"timeout"`
+	// OnUnhealthy is either pause, the default, which doesn't start any new
+	// iterations, or hold, which doesn't start more concurrent iterations than
+	// were running when the system got degraded.
+	OnUnhealthy string `json:"onUnhealthy,omitempty"`
 }
 
 // ScenarioState holds runtime scenario information returned by the k6/execution
@@ -105,6 +187,29 @@ type ScenarioState struct {
 	Name, Executor string
 	StartTime      time.Time
 	ProgressFn     func() (float64, []string)
+	// DataTransfer is nil, unless the executor needs the data of the iterations.
+	DataTransfer *DataTransfer
+}
+
+// DataTransfer counts the finished iterations of a scenario and the bytes they
+// sent and received.
+type DataTransfer struct {
+	iterations, sent, received atomic.Int64
+}
+
+// Add counts a finished iteration with the bytes it sent and received.
+func (dt *DataTransfer) Add(sent, received int64) {
+	dt.sent.Add(sent)
+	dt.received.Add(received)
+	dt.iterations.Add(1)
+}
+
+// Get returns the number of the finished iterations and the bytes they sent
+// and received.
+func (dt *DataTransfer) Get() (iterations, sent, received int64) {
+	// the iterations are loaded first, so the bytes include all of them
+	iterations = dt.iterations.Load()
+	return iterations, dt.sent.Load(), dt.received.Load()
 }
 
 // InitVUFunc is just a shorthand so we don't have to type the function
@@ -157,6 +262,19 @@ func RegisterExecutorConfigType(configType string, constructor ExecutorConfigCon
 	executorConfigConstructors[configType] = constructor
 }
 
+// GetExecutorConfigTypes returns the sorted types of all registered executors.
+func GetExecutorConfigTypes() []string {
+	executorConfigTypesMutex.RLock()
+	defer executorConfigTypesMutex.RUnlock()
+
+	configTypes := make([]string, 0, len(executorConfigConstructors))
+	for configType := range executorConfigConstructors {
+		configTypes = append(configTypes, configType)
+	}
+	sort.Strings(configTypes)
+	return configTypes
+}
+
 // ScenarioConfigs can contain mixed executor config types
 type ScenarioConfigs map[string]ExecutorConfig
 
@@ -0,0 +1,69 @@
+package lib
+
+import (
+	"sync"
+)
+
+// FlightRecorder keeps the last records, e.g. the dumps of the HTTP requests and
+// responses, of a VU in a ring buffer, so they can be logged only when an
+// iteration fails, instead of logging everything like --http-debug does.
+type FlightRecorder struct {
+	mu      sync.Mutex
+	records []string
+	next    int
+	full    bool
+	failed  bool
+}
+
+// NewFlightRecorder returns a new [FlightRecorder], which keeps the given
+// number of the last records.
+func NewFlightRecorder(size int) *FlightRecorder {
+	return &FlightRecorder{records: make([]string, size)}
+}
+
+// Record adds a record, overwriting the oldest one, if the recorder is full.
+func (fr *FlightRecorder) Record(record string) {
+	fr.mu.Lock()
+	defer fr.mu.Unlock()
+
+	if len(fr.records) == 0 {
+		return
+	}
+	fr.records[fr.next] = record
+	fr.next = (fr.next + 1) % len(fr.records)
+	if fr.next == 0 {
+		fr.full = true
+	}
+}
+
+// Fail marks the current iteration as failed, e.g. because of a failed check.
+func (fr *FlightRecorder) Fail() {
+	fr.mu.Lock()
+	defer fr.mu.Unlock()
+
+	fr.failed = true
+}
+
+// Flush returns the records, from the oldest to the newest, if the iteration
+// failed or the given error isn't nil, and clears them, so they aren't returned
+// again after the next failed iteration. Otherwise, it returns nil and keeps
+// them. In both cases, the next iteration starts as not failed.
+func (fr *FlightRecorder) Flush(err error) []string {
+	fr.mu.Lock()
+	defer fr.mu.Unlock()
+
+	failed := fr.failed || err != nil
+	fr.failed = false
+	if !failed {
+		return nil
+	}
+
+	var records []string
+	if fr.full {
+		records = append(records, fr.records[fr.next:]...)
+	}
+	records = append(records, fr.records[:fr.next]...)
+	clear(fr.records)
+	fr.next, fr.full = 0, false
+	return records
+}
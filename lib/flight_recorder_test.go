@@ -0,0 +1,32 @@
+package lib
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFlightRecorder(t *testing.T) {
+	t.Parallel()
+
+	fr := NewFlightRecorder(3)
+	fr.Record("1")
+	fr.Record("2")
+	assert.Nil(t, fr.Flush(nil))
+
+	fr.Record("3")
+	fr.Record("4")
+	fr.Fail()
+	assert.Equal(t, []string{"2", "3", "4"}, fr.Flush(nil))
+	assert.Nil(t, fr.Flush(nil))
+
+	fr.Record("5")
+	assert.Equal(t, []string{"5"}, fr.Flush(errors.New("iteration error")))
+	assert.Empty(t, fr.Flush(errors.New("iteration error")))
+
+	empty := NewFlightRecorder(0)
+	empty.Record("1")
+	empty.Fail()
+	assert.Empty(t, empty.Flush(nil))
+}
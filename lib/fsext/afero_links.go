@@ -54,3 +54,20 @@ func IsDir(fs Fs, path string) (bool, error) {
 	// TODO move fix here
 	return afero.IsDir(fs, path)
 }
+
+// NewBasePathFs returns a Fs, which restricts all the operations to the path in the provided one.
+func NewBasePathFs(fs Fs, path string) Fs {
+	return afero.NewBasePathFs(fs, path)
+}
+
+// NewCopyOnWriteFs returns a Fs, which reads the files from the layer, or from the base if they're
+// not in it, and which writes them only in the layer.
+func NewCopyOnWriteFs(base, layer Fs) Fs {
+	return afero.NewCopyOnWriteFs(base, layer)
+}
+
+// TempDir creates a new temporary directory in the provided fs, in the directory of the
+// temporary files of the OS, and returns its path.
+func TempDir(fs Fs, prefix string) (string, error) {
+	return afero.TempDir(fs, "", prefix)
+}
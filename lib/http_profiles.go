@@ -0,0 +1,103 @@
+package lib
+
+import (
+	"net/http"
+	"sort"
+)
+
+// httpProfileHeader is a header that the requests of an HTTP profile send.
+type httpProfileHeader struct {
+	name, value string
+}
+
+const (
+	chromeUserAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 " +
+		"(KHTML, like Gecko) Chrome/130.0.0.0 Safari/537.36"
+	chromeAccept = "text/html,application/xhtml+xml,application/xml;q=0.9,image/avif,image/webp," +
+		"image/apng,*/*;q=0.8,application/signed-exchange;v=b3;q=0.7"
+	geckoAccept = "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8"
+)
+
+// chromiumHeaders returns the headers of a Chromium based browser, in the
+// order in which it sends them.
+func chromiumHeaders(brands, mobile, platform, userAgent string) []httpProfileHeader {
+	return []httpProfileHeader{
+		{"sec-ch-ua", brands},
+		{"sec-ch-ua-mobile", mobile},
+		{"sec-ch-ua-platform", platform},
+		{"Upgrade-Insecure-Requests", "1"},
+		{"User-Agent", userAgent},
+		{"Accept", chromeAccept},
+		{"Sec-Fetch-Site", "none"},
+		{"Sec-Fetch-Mode", "navigate"},
+		{"Sec-Fetch-User", "?1"},
+		{"Sec-Fetch-Dest", "document"},
+		{"Accept-Encoding", "gzip, deflate, br, zstd"},
+		{"Accept-Language", "en-US,en;q=0.9"},
+	}
+}
+
+// httpProfiles are the headers, which the requests send to mimic the
+// navigation requests of the browsers, by the profile names.
+var httpProfiles = map[string][]httpProfileHeader{ //nolint:gochecknoglobals
+	"chrome": chromiumHeaders(
+		`"Chromium";v="130", "Google Chrome";v="130", "Not?A_Brand";v="99"`, "?0", `"Windows"`, chromeUserAgent,
+	),
+	"chrome-android": chromiumHeaders(
+		`"Chromium";v="130", "Google Chrome";v="130", "Not?A_Brand";v="99"`, "?1", `"Android"`,
+		"Mozilla/5.0 (Linux; Android 10; K) AppleWebKit/537.36 (KHTML, like Gecko) "+
+			"Chrome/130.0.0.0 Mobile Safari/537.36",
+	),
+	"edge": chromiumHeaders(
+		`"Chromium";v="130", "Microsoft Edge";v="130", "Not?A_Brand";v="99"`, "?0", `"Windows"`,
+		chromeUserAgent+" Edg/130.0.0.0",
+	),
+	"firefox": {
+		{"User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:131.0) Gecko/20100101 Firefox/131.0"},
+		{"Accept", geckoAccept},
+		{"Accept-Language", "en-US,en;q=0.5"},
+		{"Accept-Encoding", "gzip, deflate, br, zstd"},
+		{"Upgrade-Insecure-Requests", "1"},
+		{"Sec-Fetch-Dest", "document"},
+		{"Sec-Fetch-Mode", "navigate"},
+		{"Sec-Fetch-Site", "none"},
+		{"Sec-Fetch-User", "?1"},
+	},
+	"safari": {
+		{"Sec-Fetch-Dest", "document"},
+		{"User-Agent", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 " +
+			"(KHTML, like Gecko) Version/17.6 Safari/605.1.15"},
+		{"Accept", geckoAccept},
+		{"Sec-Fetch-Site", "none"},
+		{"Sec-Fetch-Mode", "navigate"},
+		{"Accept-Language", "en-US,en;q=0.9"},
+		{"Accept-Encoding", "gzip, deflate, br"},
+	},
+}
+
+// HTTPProfileNames returns the sorted names of the HTTP profiles.
+func HTTPProfileNames() []string {
+	names := make([]string, 0, len(httpProfiles))
+	for name := range httpProfiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// IsHTTPProfile returns whether there is an HTTP profile with the name.
+func IsHTTPProfile(name string) bool {
+	_, ok := httpProfiles[name]
+	return ok
+}
+
+// ApplyHTTPProfile sets the headers of the HTTP profile, which aren't already
+// set. Go's HTTP client writes the headers in its own order, so unlike their
+// values, the order of the browser's headers can't be mimicked.
+func ApplyHTTPProfile(name string, header http.Header) {
+	for _, h := range httpProfiles[name] {
+		if _, ok := header[http.CanonicalHeaderKey(h.name)]; !ok {
+			header.Set(h.name, h.value)
+		}
+	}
+}
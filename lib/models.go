@@ -8,11 +8,13 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"gopkg.in/guregu/null.v3"
 
 	"go.k6.io/k6/lib/types"
+	"go.k6.io/k6/metrics"
 )
 
 // GroupSeparator for group IDs.
@@ -24,6 +26,28 @@ const GroupSeparator = "::"
 // Changing this will be a breaking change and in this way it will be more obvious.
 const RootGroupPath = ""
 
+// CheckSeverityTag is the tag of the checks with their severity, which is one
+// of the CheckSeverity* values. The checks without it have no severity.
+const CheckSeverityTag = "severity"
+
+// The severities of the checks, from the least to the most severe.
+const (
+	CheckSeverityInfo     = "info"
+	CheckSeverityWarn     = "warn"
+	CheckSeverityCritical = "critical"
+)
+
+// IsValidCheckSeverity reports whether the given value is one of the severities
+// of the checks.
+func IsValidCheckSeverity(severity string) bool {
+	switch severity {
+	case CheckSeverityInfo, CheckSeverityWarn, CheckSeverityCritical:
+		return true
+	default:
+		return false
+	}
+}
+
 // ErrNameContainsGroupSeparator is emitted if you attempt to instantiate a Group or Check that contains the separator.
 var ErrNameContainsGroupSeparator = errors.New("group and check names may not contain '" + GroupSeparator + "'")
 
@@ -104,6 +128,10 @@ type Group struct {
 	Checks        map[string]*Check `json:"checks"`
 	OrderedChecks []*Check          `json:"-"`
 
+	// The durations of the runs of this group, from its group_duration samples.
+	durations     *metrics.TrendSink
+	durationMutex sync.Mutex
+
 	groupMutex sync.Mutex
 	checkMutex sync.Mutex
 }
@@ -135,6 +163,47 @@ func NewGroup(name string, parent *Group) (*Group, error) {
 	}, nil
 }
 
+// AddDuration adds the duration of a run of the group, in milliseconds.
+// This is safe to call from multiple goroutines simultaneously.
+func (g *Group) AddDuration(duration float64) {
+	g.durationMutex.Lock()
+	defer g.durationMutex.Unlock()
+	if g.durations == nil {
+		g.durations = metrics.NewTrendSink()
+	}
+	g.durations.Add(metrics.Sample{Value: duration})
+}
+
+// Durations calls fn with the durations of the runs of the group, or with nil
+// if there weren't any. This is safe to call from multiple goroutines
+// simultaneously.
+func (g *Group) Durations(fn func(*metrics.TrendSink)) {
+	g.durationMutex.Lock()
+	defer g.durationMutex.Unlock()
+	fn(g.durations)
+}
+
+// CheckTotals returns how many times the checks of this group and of all of
+// its subgroups passed and failed.
+func (g *Group) CheckTotals() (passes, fails int64) {
+	g.checkMutex.Lock()
+	for _, check := range g.OrderedChecks {
+		passes += atomic.LoadInt64(&check.Passes)
+		fails += atomic.LoadInt64(&check.Fails)
+	}
+	g.checkMutex.Unlock()
+
+	g.groupMutex.Lock()
+	groups := append([]*Group(nil), g.OrderedGroups...)
+	g.groupMutex.Unlock()
+	for _, group := range groups {
+		groupPasses, groupFails := group.CheckTotals()
+		passes += groupPasses
+		fails += groupFails
+	}
+	return passes, fails
+}
+
 // Group creates a child group belonging to this group.
 // This is safe to call from multiple goroutines simultaneously.
 func (g *Group) Group(name string) (*Group, error) {
@@ -202,6 +271,10 @@ type Check struct {
 	// or length may change.
 	ID string `json:"id"`
 
+	// The severity of the check, from the severity tag of its first run, or
+	// empty if it doesn't have one.
+	Severity string `json:"severity,omitempty"`
+
 	// Counters for how many times this check has passed and failed respectively.
 	Passes int64 `json:"passes"`
 	Fails  int64 `json:"fails"`
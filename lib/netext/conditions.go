@@ -0,0 +1,167 @@
+package netext
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net"
+	"sync"
+	"time"
+
+	"go.k6.io/k6/lib"
+)
+
+// ErrSimulatedNetworkError is returned by the dials and the connections, which
+// failed because of the errorRate of the network conditions.
+var ErrSimulatedNetworkError = errors.New("the connection failed because of the simulated network conditions")
+
+// networkLink is the simulated link of a dialer, whose bandwidth is shared by
+// all of its connections.
+type networkLink struct {
+	mx sync.Mutex
+	// sendFree and receiveFree are when the bytes, which were already sent
+	// and received, will have passed through the link
+	sendFree, receiveFree time.Time
+}
+
+// reserve returns when the bytes will have passed through the link in the
+// direction, after the ones before them.
+func (l *networkLink) reserve(free *time.Time, bytes int, bandwidth int64) time.Time {
+	l.mx.Lock()
+	defer l.mx.Unlock()
+	start := time.Now()
+	if free.After(start) {
+		start = *free
+	}
+	*free = start.Add(time.Duration(float64(bytes) / float64(bandwidth) * float64(time.Second)))
+	return *free
+}
+
+// conditionsDelay returns the latency of the conditions, changed by a random
+// part of their jitter.
+func conditionsDelay(conditions *lib.NetworkConditions) time.Duration {
+	delay := conditions.Latency.TimeDuration()
+	if jitter := int64(conditions.Jitter.Duration); jitter > 0 {
+		delay += time.Duration(rand.Int64N(2*jitter+1) - jitter) //nolint:gosec
+	}
+	return max(delay, 0)
+}
+
+// conditionsFail returns whether something fails, with the error rate of the
+// conditions.
+func conditionsFail(conditions *lib.NetworkConditions) bool {
+	return conditions.ErrorRate.Float64 > 0 && rand.Float64() < conditions.ErrorRate.Float64 //nolint:gosec
+}
+
+// dialWithConditions waits for the latency of the conditions, before a dial,
+// which fails with their error rate.
+func dialWithConditions(ctx context.Context, conditions *lib.NetworkConditions) error {
+	timer := time.NewTimer(conditionsDelay(conditions))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	if conditionsFail(conditions) {
+		return ErrSimulatedNetworkError
+	}
+	return nil
+}
+
+// conditionedConn is a connection, which simulates the network conditions. The
+// data read after writing a request is delayed by the latency, the data is
+// sent and received no faster than the bandwidth of the link of the dialer,
+// and writing a new request fails with the error rate.
+type conditionedConn struct {
+	net.Conn
+
+	conditions *lib.NetworkConditions
+	link       *networkLink
+
+	mx sync.Mutex
+	// writing is whether a request is being written, i.e. whether the last
+	// data was written rather than read
+	writing bool
+	delay   time.Duration
+	// respondAt is when the response to the written request can be read
+	respondAt time.Time
+
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+func newConditionedConn(conn net.Conn, conditions *lib.NetworkConditions, link *networkLink) *conditionedConn {
+	return &conditionedConn{Conn: conn, conditions: conditions, link: link, closed: make(chan struct{})}
+}
+
+func (c *conditionedConn) Write(b []byte) (int, error) {
+	c.mx.Lock()
+	request := !c.writing
+	if request {
+		c.writing = true
+		c.delay = conditionsDelay(c.conditions)
+	}
+	c.mx.Unlock()
+	if request && conditionsFail(c.conditions) {
+		_ = c.Close()
+		return 0, ErrSimulatedNetworkError
+	}
+
+	if bandwidth := c.conditions.Bandwidth.Int64; bandwidth > 0 {
+		if err := c.waitUntil(c.link.reserve(&c.link.sendFree, len(b), bandwidth)); err != nil {
+			return 0, err
+		}
+	}
+	n, err := c.Conn.Write(b)
+
+	c.mx.Lock()
+	c.respondAt = time.Now().Add(c.delay)
+	c.mx.Unlock()
+	return n, err
+}
+
+func (c *conditionedConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	if n == 0 {
+		return n, err
+	}
+
+	c.mx.Lock()
+	until := time.Time{}
+	if c.writing {
+		c.writing = false
+		until = c.respondAt
+	}
+	c.mx.Unlock()
+	if bandwidth := c.conditions.Bandwidth.Int64; bandwidth > 0 {
+		if free := c.link.reserve(&c.link.receiveFree, n, bandwidth); free.After(until) {
+			until = free
+		}
+	}
+	if waitErr := c.waitUntil(until); waitErr != nil {
+		return 0, waitErr
+	}
+	return n, err
+}
+
+// waitUntil waits until the time, unless the connection is closed.
+func (c *conditionedConn) waitUntil(t time.Time) error {
+	wait := time.Until(t)
+	if wait <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-c.closed:
+		return net.ErrClosed
+	}
+}
+
+func (c *conditionedConn) Close() error {
+	c.closeOnce.Do(func() { close(c.closed) })
+	return c.Conn.Close()
+}
@@ -0,0 +1,83 @@
+package netext
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/types"
+)
+
+func TestDialerConditions(t *testing.T) {
+	t.Parallel()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer func() { _ = conn.Close() }()
+				_, _ = io.Copy(conn, conn)
+			}()
+		}
+	}()
+
+	dial := func(t *testing.T, conditions *lib.NetworkConditions) (net.Conn, error) {
+		t.Helper()
+		dialer := NewDialer(net.Dialer{}, NewResolver(net.LookupIP, 0, types.DNSfirst, types.DNSpreferIPv4))
+		dialer.Conditions = conditions
+		return dialer.DialContext(context.Background(), "tcp", listener.Addr().String())
+	}
+
+	t.Run("latency", func(t *testing.T) {
+		t.Parallel()
+		start := time.Now()
+		conn, err := dial(t, &lib.NetworkConditions{Latency: types.NullDurationFrom(100 * time.Millisecond)})
+		require.NoError(t, err)
+		defer func() { _ = conn.Close() }()
+		assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+
+		start = time.Now()
+		_, err = conn.Write([]byte("ping"))
+		require.NoError(t, err)
+		_, err = io.ReadFull(conn, make([]byte, 4))
+		require.NoError(t, err)
+		assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+	})
+
+	t.Run("bandwidth", func(t *testing.T) {
+		t.Parallel()
+		conn, err := dial(t, &lib.NetworkConditions{Bandwidth: null.IntFrom(20000)})
+		require.NoError(t, err)
+		defer func() { _ = conn.Close() }()
+
+		// the echoed bytes are received while the next ones are sent
+		start := time.Now()
+		go func() {
+			for range 4 {
+				_, _ = conn.Write(make([]byte, 1000))
+			}
+		}()
+		_, err = io.ReadFull(conn, make([]byte, 4000))
+		require.NoError(t, err)
+		assert.GreaterOrEqual(t, time.Since(start), 200*time.Millisecond)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		t.Parallel()
+		_, err := dial(t, &lib.NetworkConditions{ErrorRate: null.FloatFrom(1)})
+		require.ErrorIs(t, err, ErrSimulatedNetworkError)
+	})
+}
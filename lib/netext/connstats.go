@@ -0,0 +1,118 @@
+package netext
+
+import (
+	"context"
+	"sync"
+)
+
+// ConnStats counts the open connections of the dialers per host, both the
+// active ones and the idle ones, which are kept in the pools of the HTTP
+// transports. The same ConnStats can be shared by the dialers of all VUs.
+type ConnStats struct {
+	mx    sync.Mutex
+	hosts map[string]*hostConns
+}
+
+type hostConns struct {
+	open, idle int64
+}
+
+// NewConnStats returns a new ConnStats without connections.
+func NewConnStats() *ConnStats {
+	return &ConnStats{hosts: make(map[string]*hostConns)}
+}
+
+// Get returns the numbers of the active and the idle connections to the host.
+func (s *ConnStats) Get(host string) (active, idle int64) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	h, ok := s.hosts[host]
+	if !ok {
+		return 0, 0
+	}
+	return h.open - h.idle, h.idle
+}
+
+func (s *ConnStats) opened(host string) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	h, ok := s.hosts[host]
+	if !ok {
+		h = &hostConns{}
+		s.hosts[host] = h
+	}
+	h.open++
+}
+
+// connStats is the state of a connection in its ConnStats.
+type connStats struct {
+	stats        *ConnStats
+	idle, closed bool
+}
+
+func (c *Conn) setIdle(idle bool) {
+	s := c.stats.stats
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	if c.stats.closed || c.stats.idle == idle {
+		return
+	}
+	c.stats.idle = idle
+	if idle {
+		s.hosts[c.host].idle++
+	} else {
+		s.hosts[c.host].idle--
+	}
+}
+
+func (c *Conn) closed() {
+	s := c.stats.stats
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	if c.stats.closed {
+		return
+	}
+	c.stats.closed = true
+	h := s.hosts[c.host]
+	h.open--
+	if c.stats.idle {
+		h.idle--
+	}
+	if h.open == 0 {
+		delete(s.hosts, c.host)
+	}
+}
+
+// SetIdle marks the connection as idle, when it's put back in the pool of an
+// HTTP transport, or as active, when it's taken from there, if its dialer
+// counts the connections.
+func (c *Conn) SetIdle(idle bool) {
+	if c.stats != nil {
+		c.setIdle(idle)
+	}
+}
+
+// Stats returns the host of the connection and the numbers of the active and
+// the idle connections to it, if its dialer counts the connections.
+func (c *Conn) Stats() (host string, active, idle int64, ok bool) {
+	if c.stats == nil {
+		return "", 0, 0, false
+	}
+	active, idle = c.stats.stats.Get(c.host)
+	return c.host, active, idle, true
+}
+
+type dialStartKey struct{}
+
+// WithDialStart returns a context, with which the dialer calls the function
+// when it starts dialing a connection, e.g. for measuring how long a request
+// waited for a connection of a pool.
+func WithDialStart(ctx context.Context, fn func()) context.Context {
+	return context.WithValue(ctx, dialStartKey{}, fn)
+}
+
+func dialStarted(ctx context.Context) {
+	if fn, ok := ctx.Value(dialStartKey{}).(func()); ok {
+		fn()
+	}
+}
@@ -2,9 +2,13 @@ package netext
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net"
+	"net/url"
+	"sort"
 	"strconv"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -22,9 +26,78 @@ type Dialer struct {
 	Blacklist        []*lib.IPNet
 	BlockedHostnames *types.HostnameTrie
 	Hosts            *types.Hosts
+	// Proxy is the SOCKS5 proxy that the connections are made through, if any.
+	Proxy *url.URL
 
 	BytesRead    int64
 	BytesWritten int64
+
+	// DataAttribution, if it's set, attributes the data of the connections to
+	// the groups and the hosts, whose tags the data samples get.
+	DataAttribution *DataAttribution
+
+	// ConnStats, if it's set, counts the open connections of the dialer.
+	ConnStats *ConnStats
+
+	// Conditions, if they're set, are the simulated network conditions of the
+	// new connections, whose bandwidth is shared by all of them.
+	Conditions *lib.NetworkConditions
+	link       networkLink
+
+	dataMx sync.Mutex
+	data   map[dataKey]*dataCount
+
+	dnsLookupsMx sync.Mutex
+	dnsLookups   []dnsLookup
+
+	dnsCacheHits, dnsCacheMisses int64
+
+	// blockedMx guards the times of the dials, which were blocked by the
+	// blacklist or the blocked hostnames, for the blocked_requests metric.
+	blockedMx sync.Mutex
+	blocked   []time.Time
+}
+
+// DNSCacheStats are the numbers of the DNS lookups of a dialer, which were
+// served from the DNS cache of its resolver, and the ones, which weren't.
+type DNSCacheStats struct {
+	Hits   int64 `js:"hits"`
+	Misses int64 `js:"misses"`
+}
+
+// DataAttribution is how a dialer attributes the data of its connections.
+type DataAttribution struct {
+	// Group returns the group, which the data read and written at the moment
+	// is attributed to. The data isn't attributed to the groups, if it's nil.
+	Group func() string
+	// Hosts is whether the data is attributed to the hosts of the connections.
+	Hosts bool
+}
+
+// dataKey is what the data is attributed to.
+type dataKey struct {
+	group, host string
+}
+
+// dataCount is the data attributed to a dataKey.
+type dataCount struct {
+	read, written int64
+}
+
+// dnsLookup is a lookup done by the dialer, for the dns_lookup_duration metric.
+type dnsLookup struct {
+	start    time.Time
+	duration time.Duration
+	// server is the DNSServersTag of the servers the lookup was sent to, if
+	// the resolver reports them.
+	server string
+	cached bool
+}
+
+// serversResolver is implemented by the resolvers, which report the DNS
+// servers their lookups are sent to.
+type serversResolver interface {
+	Servers() string
 }
 
 // NewDialer constructs a new Dialer with the given DNS resolver.
@@ -57,16 +130,94 @@ func (b BlockedHostError) Error() string {
 
 // DialContext wraps the net.Dialer.DialContext and handles the k6 specifics
 func (d *Dialer) DialContext(ctx context.Context, proto, addr string) (net.Conn, error) {
-	dialAddr, err := d.getDialAddr(addr)
+	dialStarted(ctx)
+	conditions := d.Conditions
+	var conn net.Conn
+	var err error
+	if conditions != nil {
+		err = dialWithConditions(ctx, conditions)
+	}
+	if err == nil {
+		conn, err = d.dial(ctx, proto, addr)
+	}
 	if err != nil {
+		var blacklisted BlackListedIPError
+		var blockedHost BlockedHostError
+		if errors.As(err, &blacklisted) || errors.As(err, &blockedHost) {
+			d.blockedMx.Lock()
+			d.blocked = append(d.blocked, time.Now())
+			d.blockedMx.Unlock()
+		}
 		return nil, err
 	}
-	conn, err := d.Dialer.DialContext(ctx, proto, dialAddr)
+	if conditions != nil {
+		conn = newConditionedConn(conn, conditions, &d.link)
+	}
+	c := &Conn{Conn: conn, BytesRead: &d.BytesRead, BytesWritten: &d.BytesWritten}
+	if d.DataAttribution != nil {
+		c.dialer, c.host = d, dataHost(addr)
+	}
+	if d.ConnStats != nil {
+		c.host = dataHost(addr)
+		c.stats = &connStats{stats: d.ConnStats}
+		d.ConnStats.opened(c.host)
+	}
+	return c, nil
+}
+
+// dial connects to the address, directly or through the proxy, unless it's
+// blocked.
+func (d *Dialer) dial(ctx context.Context, proto, addr string) (net.Conn, error) {
+	addr, err := d.mapContextHosts(ctx, addr)
 	if err != nil {
 		return nil, err
 	}
-	conn = &Conn{conn, &d.BytesRead, &d.BytesWritten}
-	return conn, err
+	if proxyURL := d.proxyFor(ctx); proxyURL != nil {
+		dialAddr, err := d.getProxyDialAddr(proxyURL, addr)
+		if err != nil {
+			return nil, err
+		}
+		return d.dialProxy(ctx, proxyURL, proto, dialAddr)
+	}
+	dialAddr, err := d.getDialAddr(addr)
+	if err != nil {
+		return nil, err
+	}
+	return d.Dialer.DialContext(ctx, proto, dialAddr)
+}
+
+// dataHost returns the host of the address, which the data of its connections
+// is attributed to. Like in the Host headers, the default HTTP ports are
+// omitted.
+func dataHost(addr string) string {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil || port == "80" || port == "443" {
+		return host
+	}
+	return addr
+}
+
+// addData attributes the data, which a connection to the host read and wrote.
+func (d *Dialer) addData(host string, read, written int64) {
+	key := dataKey{}
+	if d.DataAttribution.Group != nil {
+		key.group = d.DataAttribution.Group()
+	}
+	if d.DataAttribution.Hosts {
+		key.host = host
+	}
+	d.dataMx.Lock()
+	defer d.dataMx.Unlock()
+	if d.data == nil {
+		d.data = make(map[dataKey]*dataCount)
+	}
+	count, ok := d.data[key]
+	if !ok {
+		count = &dataCount{}
+		d.data[key] = count
+	}
+	count.read += read
+	count.written += written
 }
 
 // IOSamples returns samples for data send and received since it last call and zeros out.
@@ -76,26 +227,150 @@ func (d *Dialer) IOSamples(
 ) metrics.SampleContainer {
 	bytesWritten := atomic.SwapInt64(&d.BytesWritten, 0)
 	bytesRead := atomic.SwapInt64(&d.BytesRead, 0)
-	return metrics.Samples([]metrics.Sample{
+	samples := d.attributedDataSamples(sampleTime, ctm, builtinMetrics)
+	if samples == nil {
+		samples = dataSamples(sampleTime, ctm.Tags, ctm.Metadata, bytesWritten, bytesRead, builtinMetrics)
+	}
+
+	d.dnsLookupsMx.Lock()
+	lookups := d.dnsLookups
+	d.dnsLookups = nil
+	d.dnsLookupsMx.Unlock()
+	for _, lookup := range lookups {
+		tags := ctm.Tags.With("dns_cache", "miss")
+		if lookup.cached {
+			tags = ctm.Tags.With("dns_cache", "hit")
+		}
+		if lookup.server != "" {
+			tags = tags.With("server", lookup.server)
+		}
+		samples = append(samples, metrics.Sample{
+			TimeSeries: metrics.TimeSeries{
+				Metric: builtinMetrics.DNSLookupDuration,
+				Tags:   tags,
+			},
+			Time:     lookup.start,
+			Metadata: ctm.Metadata,
+			Value:    metrics.D(lookup.duration),
+		})
+	}
+
+	d.blockedMx.Lock()
+	blocked := d.blocked
+	d.blocked = nil
+	d.blockedMx.Unlock()
+	for _, t := range blocked {
+		samples = append(samples, metrics.Sample{
+			TimeSeries: metrics.TimeSeries{
+				Metric: builtinMetrics.BlockedRequests,
+				Tags:   ctm.Tags,
+			},
+			Time:     t,
+			Metadata: ctm.Metadata,
+			Value:    1,
+		})
+	}
+	return samples
+}
+
+// attributedDataSamples returns the data samples of the attributed data since
+// the last call, with the tags of what it was attributed to, sorted by them.
+// It returns nil, if the data isn't attributed or no data was transferred.
+func (d *Dialer) attributedDataSamples(
+	sampleTime time.Time, ctm metrics.TagsAndMeta, builtinMetrics *metrics.BuiltinMetrics,
+) metrics.Samples {
+	if d.DataAttribution == nil {
+		return nil
+	}
+	d.dataMx.Lock()
+	data := d.data
+	d.data = nil
+	d.dataMx.Unlock()
+	if len(data) == 0 {
+		return nil
+	}
+
+	keys := make([]dataKey, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].group != keys[j].group {
+			return keys[i].group < keys[j].group
+		}
+		return keys[i].host < keys[j].host
+	})
+
+	samples := make(metrics.Samples, 0, 2*len(keys))
+	for _, key := range keys {
+		tags := ctm.Tags
+		if d.DataAttribution.Group != nil {
+			tags = tags.With(metrics.TagGroup.String(), key.group)
+		}
+		if d.DataAttribution.Hosts {
+			tags = tags.With("host", key.host)
+		}
+		count := data[key]
+		samples = append(samples,
+			dataSamples(sampleTime, tags, ctm.Metadata, count.written, count.read, builtinMetrics)...)
+	}
+	return samples
+}
+
+func dataSamples(
+	sampleTime time.Time, tags *metrics.TagSet, metadata map[string]string, sent, received int64,
+	builtinMetrics *metrics.BuiltinMetrics,
+) metrics.Samples {
+	return metrics.Samples{
 		{
 			TimeSeries: metrics.TimeSeries{
 				Metric: builtinMetrics.DataSent,
-				Tags:   ctm.Tags,
+				Tags:   tags,
 			},
 			Time:     sampleTime,
-			Metadata: ctm.Metadata,
-			Value:    float64(bytesWritten),
+			Metadata: metadata,
+			Value:    float64(sent),
 		},
 		{
 			TimeSeries: metrics.TimeSeries{
 				Metric: builtinMetrics.DataReceived,
-				Tags:   ctm.Tags,
+				Tags:   tags,
 			},
 			Time:     sampleTime,
-			Metadata: ctm.Metadata,
-			Value:    float64(bytesRead),
+			Metadata: metadata,
+			Value:    float64(received),
 		},
-	})
+	}
+}
+
+// DNSCacheStats returns the numbers of the DNS lookups of the dialer, which
+// were served from the DNS cache or not. Without a DNS cache, all of the
+// lookups are misses.
+func (d *Dialer) DNSCacheStats() DNSCacheStats {
+	return DNSCacheStats{
+		Hits:   atomic.LoadInt64(&d.dnsCacheHits),
+		Misses: atomic.LoadInt64(&d.dnsCacheMisses),
+	}
+}
+
+// FlushDNSCache removes the cached lookups of the host, or of all hosts if
+// it's empty, from the DNS cache of the resolver of the dialer, if it has one.
+// The resolvers are shared by the VUs, so this affects all of them.
+func (d *Dialer) FlushDNSCache(host string) {
+	if cr, ok := d.Resolver.(CachingResolver); ok {
+		cr.Flush(host)
+	}
+}
+
+// Lookup returns the IP address, which the connections of the dialer to the
+// host would be made to, i.e. the one of its hosts or of its resolver. The
+// lookups, which use the resolver, are measured like the ones of the dials.
+func (d *Dialer) Lookup(host string) (net.IP, error) {
+	remote, err := d.findRemote(net.JoinHostPort(host, "0"))
+	if err != nil {
+		return nil, err
+	}
+	return remote.IP, nil
 }
 
 func (d *Dialer) getDialAddr(addr string) (string, error) {
@@ -137,7 +412,25 @@ func (d *Dialer) findRemote(addr string) (*types.Host, error) {
 		return types.NewHost(ip, port)
 	}
 
-	ip, err = d.Resolver.LookupIP(host)
+	start := time.Now()
+	var cached bool
+	if cr, ok := d.Resolver.(CachingResolver); ok {
+		ip, cached, err = cr.LookupIPCached(host)
+	} else {
+		ip, err = d.Resolver.LookupIP(host)
+	}
+	if cached {
+		atomic.AddInt64(&d.dnsCacheHits, 1)
+	} else {
+		atomic.AddInt64(&d.dnsCacheMisses, 1)
+	}
+	lookup := dnsLookup{start: start, duration: time.Since(start), cached: cached}
+	if sr, ok := d.Resolver.(serversResolver); ok {
+		lookup.server = sr.Servers()
+	}
+	d.dnsLookupsMx.Lock()
+	d.dnsLookups = append(d.dnsLookups, lookup)
+	d.dnsLookupsMx.Unlock()
 	if err != nil {
 		return nil, err
 	}
@@ -149,12 +442,53 @@ func (d *Dialer) findRemote(addr string) (*types.Host, error) {
 	return types.NewHost(ip, port)
 }
 
-func (d *Dialer) getConfiguredHost(addr, host, port string) (*types.Host, error) {
-	if remote := d.Hosts.Match(addr); remote != nil {
-		return remote, nil
+type hostsKey struct{}
+
+// WithHosts returns a context, dialing with which makes the Dialer connect to
+// the addresses, which the given hosts map the hostnames to, instead of the
+// ones of its own hosts or of its resolver.
+func WithHosts(ctx context.Context, hosts *types.Hosts) context.Context {
+	return context.WithValue(ctx, hostsKey{}, hosts)
+}
+
+// mapContextHosts returns the IP address, which the hosts of the context map
+// the address to, or the address itself, if they don't. The blocked hostnames
+// still apply to the mapped hostnames, and the blacklist to their IPs.
+func (d *Dialer) mapContextHosts(ctx context.Context, addr string) (string, error) {
+	hosts, ok := ctx.Value(hostsKey{}).(*types.Hosts)
+	if !ok {
+		return addr, nil
 	}
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil || net.ParseIP(host) != nil {
+		return addr, nil //nolint:nilerr // the invalid addresses fail when they're dialed
+	}
+	remote, err := matchHosts(hosts, addr, host, port)
+	if err != nil || remote == nil {
+		return addr, err
+	}
+	if d.BlockedHostnames != nil {
+		if match, blocked := d.BlockedHostnames.Contains(host); blocked {
+			return "", BlockedHostError{hostname: host, match: match}
+		}
+	}
+	return remote.String(), nil
+}
+
+func (d *Dialer) getConfiguredHost(addr, host, port string) (*types.Host, error) {
+	return matchHosts(d.Hosts, addr, host, port)
+}
 
-	if remote := d.Hosts.Match(host); remote != nil {
+// matchHosts returns the address, which the hosts map the address, or its
+// host, to, with the port of the address, if the mapped one doesn't have any.
+func matchHosts(hosts *types.Hosts, addr, host, port string) (*types.Host, error) {
+	remote := hosts.Match(addr)
+	if remote == nil {
+		remote = hosts.Match(host)
+	}
+	if remote != nil {
+		// entries without a port, including rewrites that matched the whole
+		// address, keep the original port
 		if remote.Port != 0 || port == "" {
 			return remote, nil
 		}
@@ -178,12 +512,21 @@ type Conn struct {
 	net.Conn
 
 	BytesRead, BytesWritten *int64
+
+	// dialer attributes the data to the host, if it's set.
+	dialer *Dialer
+	host   string
+	// stats counts the connection in the ConnStats of the dialer, if it's set.
+	stats *connStats
 }
 
 func (c *Conn) Read(b []byte) (int, error) {
 	n, err := c.Conn.Read(b)
 	if n > 0 {
 		atomic.AddInt64(c.BytesRead, int64(n))
+		if c.dialer != nil {
+			c.dialer.addData(c.host, int64(n), 0)
+		}
 	}
 	return n, err
 }
@@ -192,6 +535,17 @@ func (c *Conn) Write(b []byte) (int, error) {
 	n, err := c.Conn.Write(b)
 	if n > 0 {
 		atomic.AddInt64(c.BytesWritten, int64(n))
+		if c.dialer != nil {
+			c.dialer.addData(c.host, 0, int64(n))
+		}
 	}
 	return n, err
 }
+
+// Close closes the connection and stops counting it.
+func (c *Conn) Close() error {
+	if c.stats != nil {
+		c.closed()
+	}
+	return c.Conn.Close()
+}
@@ -1,14 +1,19 @@
 package netext
 
 import (
+	"context"
+	"fmt"
 	"net"
 	"testing"
+	"time"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"go.k6.io/k6/internal/lib/testutils/mockresolver"
 	"go.k6.io/k6/lib"
 	"go.k6.io/k6/lib/types"
+	"go.k6.io/k6/metrics"
 )
 
 func TestDialerAddr(t *testing.T) {
@@ -74,6 +79,74 @@ func TestDialerAddr(t *testing.T) {
 	}
 }
 
+func TestDialerAddrHostRewrites(t *testing.T) {
+	t.Parallel()
+	dialer := NewDialer(net.Dialer{}, newResolver())
+	hosts, err := types.NewHostsWithRewrites(nil, map[string]string{
+		`/tenant-(\d+)\.local/`:   "10.0.0.$1",
+		`/.+\.svc:8080/`:          "10.1.0.1:9090",
+		`/.+\.any-port\.local.*/`: "10.2.0.1",
+	})
+	require.NoError(t, err)
+	dialer.Hosts = hosts
+
+	testCases := []struct {
+		address, expAddress string
+	}{
+		{"tenant-5.local:443", "10.0.0.5:443"},
+		{"users.svc:8080", "10.1.0.1:9090"},
+		{"users.any-port.local:8443", "10.2.0.1:8443"},
+		{"example-resolver.com:80", "1.2.3.4:80"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.address, func(t *testing.T) {
+			t.Parallel()
+			addr, err := dialer.getDialAddr(tc.address)
+			require.NoError(t, err)
+			require.Equal(t, tc.expAddress, addr)
+		})
+	}
+}
+
+func TestDialerContextHosts(t *testing.T) {
+	t.Parallel()
+	dialer := NewDialer(net.Dialer{}, newResolver())
+	blocked, err := types.NewHostnameTrie([]string{"blocked.local"})
+	require.NoError(t, err)
+	dialer.BlockedHostnames = blocked
+	hosts, err := types.NewHosts(map[string]types.Host{
+		"example-resolver.com": {IP: net.ParseIP("5.6.7.8")},
+		"example.com:443":      {IP: net.ParseIP("5.6.7.8"), Port: 8443},
+		"blocked.local":        {IP: net.ParseIP("5.6.7.8")},
+	})
+	require.NoError(t, err)
+	ctx := WithHosts(context.Background(), hosts)
+
+	testCases := []struct {
+		address, expAddress, expErr string
+	}{
+		{"example-resolver.com:80", "5.6.7.8:80", ""},
+		{"example.com:443", "5.6.7.8:8443", ""},
+		{"example.com:80", "example.com:80", ""},
+		{"1.2.3.4:80", "1.2.3.4:80", ""},
+		{"blocked.local:80", "", "hostname (blocked.local) is in a blocked pattern (blocked.local)"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.address, func(t *testing.T) {
+			t.Parallel()
+			addr, err := dialer.mapContextHosts(ctx, tc.address)
+			if tc.expErr != "" {
+				require.EqualError(t, err, tc.expErr)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tc.expAddress, addr)
+			}
+		})
+	}
+}
+
 func TestDialerAddrBlockHostnamesStar(t *testing.T) {
 	t.Parallel()
 	dialer := NewDialer(net.Dialer{}, newResolver())
@@ -114,6 +187,70 @@ func TestDialerAddrBlockHostnamesStar(t *testing.T) {
 }
 
 // Benchmarks /etc/hosts like hostname mapping
+func TestDialerDNSLookupSamples(t *testing.T) {
+	t.Parallel()
+	dialer := NewDialer(net.Dialer{}, newResolver())
+	hosts, err := types.NewHosts(map[string]types.Host{"example.com": {IP: net.ParseIP("3.4.5.6")}})
+	require.NoError(t, err)
+	dialer.Hosts = hosts
+
+	_, err = dialer.getDialAddr("example-resolver.com:80")
+	require.NoError(t, err)
+	// neither IPs nor hosts entries are looked up
+	_, err = dialer.getDialAddr("1.2.3.4:80")
+	require.NoError(t, err)
+	_, err = dialer.getDialAddr("example.com:80")
+	require.NoError(t, err)
+
+	registry := metrics.NewRegistry()
+	builtinMetrics := metrics.RegisterBuiltinMetrics(registry)
+	ctm := metrics.TagsAndMeta{Tags: registry.RootTagSet()}
+
+	var lookups int
+	for _, sample := range dialer.IOSamples(time.Now(), ctm, builtinMetrics).GetSamples() {
+		if sample.Metric == builtinMetrics.DNSLookupDuration {
+			lookups++
+			// the mock resolver doesn't report its servers
+			assert.Equal(t, map[string]string{"dns_cache": "miss"}, sample.Tags.Map())
+		}
+	}
+	require.Equal(t, 1, lookups)
+
+	// the lookups are only reported once
+	require.Len(t, dialer.IOSamples(time.Now(), ctm, builtinMetrics).GetSamples(), 2)
+}
+
+func TestDialerDNSCacheStats(t *testing.T) {
+	t.Parallel()
+	var lookups int
+	dialer := NewDialer(net.Dialer{}, NewCachingResolver(func(string) ([]net.IP, error) {
+		lookups++
+		return []net.IP{net.ParseIP("127.0.0.10")}, nil
+	}, ResolverCache{TTL: time.Hour}, types.DNSfirst, types.DNSany))
+
+	for range 3 {
+		_, err := dialer.getDialAddr("example.com:80")
+		require.NoError(t, err)
+	}
+	// IPs aren't looked up
+	_, err := dialer.getDialAddr("1.2.3.4:80")
+	require.NoError(t, err)
+	assert.Equal(t, DNSCacheStats{Hits: 2, Misses: 1}, dialer.DNSCacheStats())
+
+	dialer.FlushDNSCache("example.com")
+	_, err = dialer.getDialAddr("example.com:80")
+	require.NoError(t, err)
+	assert.Equal(t, DNSCacheStats{Hits: 2, Misses: 2}, dialer.DNSCacheStats())
+	assert.Equal(t, 2, lookups)
+
+	// without a cache, all of the lookups are misses
+	dialer = NewDialer(net.Dialer{}, newResolver())
+	dialer.FlushDNSCache("")
+	_, err = dialer.getDialAddr("example-resolver.com:80")
+	require.NoError(t, err)
+	assert.Equal(t, DNSCacheStats{Misses: 1}, dialer.DNSCacheStats())
+}
+
 func BenchmarkDialerHosts(b *testing.B) {
 	hosts, err := types.NewHosts(map[string]types.Host{
 		"k6.io":                {IP: []byte("192.168.1.1"), Port: 80},
@@ -148,3 +285,107 @@ func newResolver() *mockresolver.MockResolver {
 		},
 	)
 }
+
+func TestDialerDataAttribution(t *testing.T) {
+	t.Parallel()
+	group := "::checkout"
+	dialer := NewDialer(net.Dialer{}, newResolver())
+	dialer.DataAttribution = &DataAttribution{Group: func() string { return group }, Hosts: true}
+
+	transfer := func(host string, sent, received int) {
+		client, server := net.Pipe()
+		defer func() { _ = client.Close(); _ = server.Close() }()
+		conn := &Conn{Conn: client, BytesRead: &dialer.BytesRead, BytesWritten: &dialer.BytesWritten}
+		conn.dialer, conn.host = dialer, dataHost(host)
+		go func() {
+			_, _ = server.Read(make([]byte, sent))
+			_, _ = server.Write(make([]byte, received))
+		}()
+		_, err := conn.Write(make([]byte, sent))
+		require.NoError(t, err)
+		_, err = conn.Read(make([]byte, received))
+		require.NoError(t, err)
+	}
+	transfer("api.example.com:443", 10, 100)
+	transfer("api.example.com:443", 5, 50)
+	transfer("localhost:8080", 1, 2)
+	group = ""
+	transfer("api.example.com:443", 3, 30)
+
+	registry := metrics.NewRegistry()
+	builtinMetrics := metrics.RegisterBuiltinMetrics(registry)
+	ctm := metrics.TagsAndMeta{Tags: registry.RootTagSet().With("scenario", "default")}
+
+	var results []string
+	for _, sample := range dialer.IOSamples(time.Now(), ctm, builtinMetrics).GetSamples() {
+		tags := sample.Tags.Map()
+		results = append(results, fmt.Sprintf("%s{scenario:%s,group:%s,host:%s}=%v",
+			sample.Metric.Name, tags["scenario"], tags["group"], tags["host"], sample.Value))
+	}
+	assert.Equal(t, []string{
+		"data_sent{scenario:default,group:,host:api.example.com}=3",
+		"data_received{scenario:default,group:,host:api.example.com}=30",
+		"data_sent{scenario:default,group:::checkout,host:api.example.com}=15",
+		"data_received{scenario:default,group:::checkout,host:api.example.com}=150",
+		"data_sent{scenario:default,group:::checkout,host:localhost:8080}=1",
+		"data_received{scenario:default,group:::checkout,host:localhost:8080}=2",
+	}, results)
+
+	// without any data, the samples are zeros without the attributed tags
+	samples := dialer.IOSamples(time.Now(), ctm, builtinMetrics).GetSamples()
+	require.Len(t, samples, 2)
+	_, hasHost := samples[0].Tags.Get("host")
+	assert.False(t, hasHost)
+	assert.Zero(t, samples[0].Value)
+}
+
+func TestDialerConnStats(t *testing.T) {
+	t.Parallel()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			t.Cleanup(func() { _ = conn.Close() })
+		}
+	}()
+
+	dialer := NewDialer(net.Dialer{}, newResolver())
+	dialer.ConnStats = NewConnStats()
+	var dialStarts int
+	ctx := WithDialStart(context.Background(), func() { dialStarts++ })
+	addr := listener.Addr().String()
+
+	first, err := dialer.DialContext(ctx, "tcp", addr)
+	require.NoError(t, err)
+	second, err := dialer.DialContext(ctx, "tcp", addr)
+	require.NoError(t, err)
+	assert.Equal(t, 2, dialStarts)
+
+	active, idle := dialer.ConnStats.Get(addr)
+	assert.Equal(t, [2]int64{2, 0}, [2]int64{active, idle})
+
+	firstConn, ok := first.(*Conn)
+	require.True(t, ok)
+	secondConn, ok := second.(*Conn)
+	require.True(t, ok)
+	firstConn.SetIdle(true)
+	firstConn.SetIdle(true)
+	host, active, idle, ok := secondConn.Stats()
+	assert.True(t, ok)
+	assert.Equal(t, addr, host)
+	assert.Equal(t, [2]int64{1, 1}, [2]int64{active, idle})
+
+	require.NoError(t, first.Close())
+	_ = first.Close()
+	active, idle = dialer.ConnStats.Get(addr)
+	assert.Equal(t, [2]int64{1, 0}, [2]int64{active, idle})
+
+	require.NoError(t, second.Close())
+	active, idle = dialer.ConnStats.Get(addr)
+	assert.Equal(t, [2]int64{0, 0}, [2]int64{active, idle})
+}
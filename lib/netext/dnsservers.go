@@ -0,0 +1,199 @@
+package netext
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.k6.io/k6/lib/types"
+)
+
+// dohMaxMessageSize is the maximum size of a DNS message, which is limited by
+// the two byte length prefix used when DNS is sent over a stream.
+const dohMaxMessageSize = 65535
+
+// SystemDNSServers is the DNSServersTag of the DNS servers configured in the
+// system.
+const SystemDNSServers = "system"
+
+// DNSServersTag returns the value of the server tag of the DNS lookups, which
+// are sent to the given servers, or to the system ones, if there aren't any.
+func DNSServersTag(servers []types.DNSServer) string {
+	if len(servers) == 0 {
+		return SystemDNSServers
+	}
+	names := make([]string, len(servers))
+	for i, s := range servers {
+		names[i] = s.String()
+	}
+	return strings.Join(names, ",")
+}
+
+// NewServersMultiResolver returns a MultiResolver that sends its queries to the
+// given upstream DNS servers, instead of the ones configured in the system.
+// It uses Go's own DNS client, only replacing how it connects to the servers,
+// which are used in a round-robin fashion, so retried queries go to the next one.
+func NewServersMultiResolver(servers []types.DNSServer) MultiResolver {
+	resolver := NewServersResolver(servers)
+	return func(host string) ([]net.IP, error) {
+		return resolver.LookupIP(context.Background(), "ip", host)
+	}
+}
+
+// NewServersResolver returns a Go resolver that sends all of its queries, of
+// any record type, to the given upstream DNS servers.
+func NewServersResolver(servers []types.DNSServer) *net.Resolver {
+	d := &dnsServersDialer{
+		servers: servers,
+		client:  &http.Client{Timeout: 10 * time.Second},
+	}
+	return &net.Resolver{PreferGo: true, Dial: d.dial}
+}
+
+type dnsServersDialer struct {
+	servers []types.DNSServer
+	next    atomic.Uint64
+	dialer  net.Dialer
+	client  *http.Client
+}
+
+// dial ignores the address of the system DNS server that Go's resolver wants
+// to connect to and connects to one of the configured servers instead.
+func (d *dnsServersDialer) dial(ctx context.Context, network, _ string) (net.Conn, error) {
+	if len(d.servers) == 0 {
+		return nil, errors.New("no DNS servers are configured")
+	}
+	server := d.servers[(d.next.Add(1)-1)%uint64(len(d.servers))]
+
+	switch server.Protocol {
+	case "udp":
+		// the network is normally udp, but Go's resolver can switch to tcp
+		// when the response was truncated
+		return d.dialer.DialContext(ctx, network, server.Address)
+	case "tcp":
+		return d.dialer.DialContext(ctx, "tcp", server.Address)
+	case "tls":
+		host, _, err := net.SplitHostPort(server.Address)
+		if err != nil {
+			return nil, err
+		}
+		tlsDialer := &tls.Dialer{
+			NetDialer: &d.dialer,
+			Config:    &tls.Config{ServerName: host, MinVersion: tls.VersionTLS12},
+		}
+		return tlsDialer.DialContext(ctx, "tcp", server.Address)
+	case "https":
+		return &dohConn{ctx: ctx, url: server.Address, client: d.client}, nil
+	default:
+		return nil, fmt.Errorf("unsupported DNS server protocol %q", server.Protocol)
+	}
+}
+
+// dohConn sends the DNS queries written to it over HTTPS, as described in
+// RFC 8484. Since it isn't a net.PacketConn, Go's resolver treats it as a
+// stream and prefixes every message with its length, like it does for TCP.
+type dohConn struct {
+	ctx    context.Context //nolint:containedctx
+	url    string
+	client *http.Client
+
+	mx       sync.Mutex
+	query    bytes.Buffer
+	response bytes.Buffer
+	deadline time.Time
+}
+
+var _ net.Conn = &dohConn{}
+
+func (c *dohConn) Write(b []byte) (int, error) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	return c.query.Write(b)
+}
+
+func (c *dohConn) Read(b []byte) (int, error) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	if c.response.Len() == 0 {
+		if err := c.roundTrip(); err != nil {
+			return 0, err
+		}
+	}
+	return c.response.Read(b)
+}
+
+// roundTrip sends the buffered query and buffers the response, both without
+// and with the stream length prefix respectively.
+func (c *dohConn) roundTrip() error {
+	if c.query.Len() < 2 {
+		return io.EOF
+	}
+	size := int(binary.BigEndian.Uint16(c.query.Bytes()))
+	if c.query.Len() < 2+size {
+		return io.ErrUnexpectedEOF
+	}
+	c.query.Next(2)
+	msg := c.query.Next(size)
+
+	ctx := c.ctx
+	if !c.deadline.IsZero() {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, c.deadline)
+		defer cancel()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(msg))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/dns-message")
+	req.Header.Set("Accept", "application/dns-message")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("the DNS over HTTPS server %s responded with status %d", c.url, resp.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, dohMaxMessageSize+1))
+	if err != nil {
+		return err
+	}
+	if len(body) > dohMaxMessageSize {
+		return fmt.Errorf("the response from the DNS over HTTPS server %s is too big", c.url)
+	}
+
+	_ = binary.Write(&c.response, binary.BigEndian, uint16(len(body))) //nolint:gosec
+	c.response.Write(body)
+	return nil
+}
+
+func (c *dohConn) Close() error                     { return nil }
+func (c *dohConn) LocalAddr() net.Addr              { return dohAddr(c.url) }
+func (c *dohConn) RemoteAddr() net.Addr             { return dohAddr(c.url) }
+func (c *dohConn) SetReadDeadline(time.Time) error  { return nil }
+func (c *dohConn) SetWriteDeadline(time.Time) error { return nil }
+
+func (c *dohConn) SetDeadline(t time.Time) error {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	c.deadline = t
+	return nil
+}
+
+type dohAddr string
+
+func (a dohAddr) Network() string { return "https" }
+func (a dohAddr) String() string  { return string(a) }
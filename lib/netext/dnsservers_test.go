@@ -0,0 +1,132 @@
+package netext
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/lib/types"
+	"go.k6.io/k6/metrics"
+)
+
+// testDNSResponse answers A queries with the given IP and all other ones with
+// no records. It's far from a complete DNS implementation, but it's enough for
+// the queries Go's resolver sends.
+func testDNSResponse(t *testing.T, query []byte, ip net.IP) []byte {
+	t.Helper()
+	require.Greater(t, len(query), 12)
+
+	// skip the labels of the question name, then its type and class
+	end := 12
+	for query[end] != 0 {
+		end += int(query[end]) + 1
+	}
+	end += 5
+	qtype := binary.BigEndian.Uint16(query[end-4:])
+
+	resp := make([]byte, 0, 512)
+	resp = append(resp, query[0], query[1], 0x81, 0x80) // same ID, standard response
+	resp = binary.BigEndian.AppendUint16(resp, 1)       // questions
+	if qtype == 1 {
+		resp = binary.BigEndian.AppendUint16(resp, 1) // answers
+	} else {
+		resp = binary.BigEndian.AppendUint16(resp, 0)
+	}
+	resp = append(resp, 0, 0, 0, 0) // authority and additional records
+	resp = append(resp, query[12:end]...)
+	if qtype == 1 {
+		resp = append(resp, 0xc0, 0x0c, 0, 1, 0, 1) // the question name, A, IN
+		resp = binary.BigEndian.AppendUint32(resp, 60)
+		resp = binary.BigEndian.AppendUint16(resp, 4)
+		resp = append(resp, ip.To4()...)
+	}
+	return resp
+}
+
+func TestServersMultiResolver(t *testing.T) {
+	t.Parallel()
+
+	expIP := net.ParseIP("10.1.2.3")
+
+	t.Run("udp", func(t *testing.T) {
+		t.Parallel()
+		conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = conn.Close() })
+		go func() {
+			buf := make([]byte, 512)
+			for {
+				n, addr, err := conn.ReadFrom(buf)
+				if err != nil {
+					return
+				}
+				_, _ = conn.WriteTo(testDNSResponse(t, buf[:n], expIP), addr)
+			}
+		}()
+
+		server, err := types.ParseDNSServer(conn.LocalAddr().String())
+		require.NoError(t, err)
+		ips, err := NewServersMultiResolver([]types.DNSServer{server})("example.test")
+		require.NoError(t, err)
+		require.Len(t, ips, 1)
+		assert.True(t, expIP.Equal(ips[0]))
+
+		// the dialers tag the lookups of the resolvers with their servers
+		dialer := NewDialer(net.Dialer{}, NewServersCachingResolver(
+			[]types.DNSServer{server}, ResolverCache{TTL: time.Hour}, types.DNSfirst, types.DNSany))
+		for range 2 {
+			_, err = dialer.getDialAddr("example.test:80")
+			require.NoError(t, err)
+		}
+		registry := metrics.NewRegistry()
+		builtinMetrics := metrics.RegisterBuiltinMetrics(registry)
+		samples := dialer.IOSamples(time.Now(), metrics.TagsAndMeta{Tags: registry.RootTagSet()}, builtinMetrics)
+		var cacheTags []string
+		for _, sample := range samples.GetSamples() {
+			if sample.Metric == builtinMetrics.DNSLookupDuration {
+				tags := sample.Tags.Map()
+				assert.Equal(t, "udp://"+conn.LocalAddr().String(), tags["server"])
+				cacheTags = append(cacheTags, tags["dns_cache"])
+			}
+		}
+		assert.Equal(t, []string{"miss", "hit"}, cacheTags)
+	})
+
+	t.Run("https", func(t *testing.T) {
+		t.Parallel()
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "application/dns-message", r.Header.Get("Content-Type"))
+			query, err := io.ReadAll(r.Body)
+			require.NoError(t, err)
+			w.Header().Set("Content-Type", "application/dns-message")
+			_, _ = w.Write(testDNSResponse(t, query, expIP))
+		}))
+		t.Cleanup(srv.Close)
+
+		// the test server is plain HTTP, but the protocol is the same
+		server := types.DNSServer{Protocol: "https", Address: srv.URL + "/dns-query"}
+		ips, err := NewServersMultiResolver([]types.DNSServer{server})("example.test")
+		require.NoError(t, err)
+		require.Len(t, ips, 1)
+		assert.True(t, expIP.Equal(ips[0]))
+	})
+
+	t.Run("https error", func(t *testing.T) {
+		t.Parallel()
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+		}))
+		t.Cleanup(srv.Close)
+
+		server := types.DNSServer{Protocol: "https", Address: srv.URL}
+		_, err := NewServersMultiResolver([]types.DNSServer{server})("example.test")
+		require.Error(t, err)
+	})
+}
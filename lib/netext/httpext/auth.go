@@ -0,0 +1,130 @@
+package httpext
+
+import (
+	"bufio"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/Azure/go-ntlmssp"
+)
+
+// authCredentials are the credentials of the digest and of the NTLM
+// authentication, which are taken from the URL of the request, and which are
+// only sent to its host, like the Authorization header of the redirects.
+type authCredentials struct {
+	username, password string
+	host               string
+}
+
+// newAuthCredentials returns the credentials of the URL, and removes them from
+// it, so they aren't sent with the basic authentication.
+func newAuthCredentials(u *url.URL) authCredentials {
+	credentials := authCredentials{host: u.Host}
+	if u.User != nil {
+		credentials.username = u.User.Username()
+		credentials.password, _ = u.User.Password()
+		u.User = nil
+	}
+	return credentials
+}
+
+// matches is whether the credentials are sent with the request to the URL.
+func (c authCredentials) matches(u *url.URL) bool {
+	return c.username != "" && u.Host == c.host
+}
+
+// ntlmTransport authenticates the requests to the host of the credentials,
+// i.e. the first request and its redirects to the same host, with the NTLM
+// authentication, which is negotiated by ntlmssp from their basic one.
+type ntlmTransport struct {
+	originalTransport http.RoundTripper
+	credentials       authCredentials
+}
+
+// RoundTrip is the implementation of http.RoundTripper
+func (t ntlmTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.credentials.matches(req.URL) && req.Header.Get("Authorization") == "" {
+		req = req.Clone(req.Context())
+		req.SetBasicAuth(t.credentials.username, t.credentials.password)
+	}
+	return ntlmssp.Negotiator{RoundTripper: t.originalTransport}.RoundTrip(req)
+}
+
+// challengeTransport marks the 401 responses, which are answered with another
+// request by the authentication, as its expected challenges, instead of
+// failed requests.
+type challengeTransport struct {
+	originalTransport http.RoundTripper
+	challenged        func()
+	// unauthorized is whether the last response was a 401 one
+	unauthorized *bool
+}
+
+// RoundTrip is the implementation of http.RoundTripper
+func (t challengeTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if *t.unauthorized {
+		// only the authentication makes another request after a 401 response,
+		// since it isn't redirected
+		t.challenged()
+	}
+	res, err := t.originalTransport.RoundTrip(req)
+	*t.unauthorized = err == nil && res.StatusCode == http.StatusUnauthorized
+	return res, err
+}
+
+// NegotiateNTLM negotiates the NTLM authentication of the request on the
+// connection, e.g. before the handshake of a WebSocket, and returns the
+// Authorization header, which authenticates the next request on it. It
+// returns an empty one, if the server didn't challenge the negotiation, i.e.
+// it doesn't require the authentication.
+func NegotiateNTLM(conn net.Conn, req *http.Request, username, password string) (string, error) {
+	user, domain, domainNeeded := ntlmssp.GetDomain(username)
+	negotiate, err := ntlmssp.NewNegotiateMessage(domain, "")
+	if err != nil {
+		return "", err
+	}
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "NTLM "+base64.StdEncoding.EncodeToString(negotiate))
+	if err = req.Write(conn); err != nil {
+		return "", err
+	}
+	res, err := http.ReadResponse(bufio.NewReader(conn), req)
+	if err != nil {
+		return "", err
+	}
+	// the connection is reused, so the whole body has to be read
+	_, err = io.Copy(io.Discard, res.Body)
+	_ = res.Body.Close()
+	if err != nil {
+		return "", err
+	}
+	if res.StatusCode != http.StatusUnauthorized {
+		return "", nil
+	}
+	if res.Close {
+		return "", errors.New("the server closed the connection of the NTLM negotiation")
+	}
+
+	var challenge []byte
+	for _, value := range res.Header.Values("Www-Authenticate") {
+		if data, ok := strings.CutPrefix(value, "NTLM "); ok {
+			if challenge, err = base64.StdEncoding.DecodeString(data); err != nil {
+				return "", fmt.Errorf("the NTLM challenge is malformed: %w", err)
+			}
+		}
+	}
+	if len(challenge) == 0 {
+		return "", errors.New("the server didn't send an NTLM challenge")
+	}
+	authenticate, err := ntlmssp.ProcessChallenge(challenge, user, password, domainNeeded)
+	if err != nil {
+		return "", err
+	}
+	return "NTLM " + base64.StdEncoding.EncodeToString(authenticate), nil
+}
@@ -0,0 +1,217 @@
+package httpext
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mccutchen/go-httpbin/httpbin"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/metrics"
+	"gopkg.in/guregu/null.v3"
+)
+
+type ntlmConnKey struct{}
+
+// ntlmChallenge is a minimal NTLM challenge message, without a target.
+func ntlmChallenge() []byte {
+	var msg bytes.Buffer
+	msg.WriteString("NTLMSSP\x00")
+	_ = binary.Write(&msg, binary.LittleEndian, uint32(2))
+	msg.Write(make([]byte, 8))                                      // the target name
+	_ = binary.Write(&msg, binary.LittleEndian, uint32(0x00000201)) // unicode and NTLM
+	msg.WriteString("12345678")                                     // the server challenge
+	msg.Write(make([]byte, 16))                                     // reserved, and the target info
+	return msg.Bytes()
+}
+
+// ntlmHandler authenticates the connections with the NTLM negotiation, without
+// verifying the credentials.
+func ntlmHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authenticated, _ := r.Context().Value(ntlmConnKey{}).(*atomic.Bool)
+		if authenticated.Load() {
+			next.ServeHTTP(w, r)
+			return
+		}
+		data, ok := strings.CutPrefix(r.Header.Get("Authorization"), "NTLM ")
+		msg, err := base64.StdEncoding.DecodeString(data)
+		switch {
+		case !ok || err != nil || len(msg) < 12:
+			w.Header().Set("Www-Authenticate", "NTLM")
+		case msg[8] == 1:
+			w.Header().Set("Www-Authenticate", "NTLM "+base64.StdEncoding.EncodeToString(ntlmChallenge()))
+		case msg[8] == 3:
+			authenticated.Store(true)
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+}
+
+func newNTLMServer(t *testing.T, handler http.Handler) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewUnstartedServer(ntlmHandler(handler))
+	srv.Config.ConnContext = func(ctx context.Context, _ net.Conn) context.Context {
+		return context.WithValue(ctx, ntlmConnKey{}, new(atomic.Bool))
+	}
+	srv.Start()
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// makeAuthRequest makes the request with the authentication, and returns its
+// response, with the statuses and whether they failed, of all of its requests.
+func makeAuthRequest(t *testing.T, auth, rawURL string) (*Response, []int, []bool) {
+	t.Helper()
+	samples := make(chan metrics.SampleContainer, 10)
+	registry := metrics.NewRegistry()
+	state := &lib.State{
+		Options: lib.Options{
+			SystemTags: &metrics.DefaultSystemTagSet,
+		},
+		Transport:      &http.Transport{},
+		Samples:        samples,
+		Logger:         logrus.New(),
+		BufferPool:     lib.NewBufferPool(),
+		BuiltinMetrics: metrics.RegisterBuiltinMetrics(registry),
+		Tags:           lib.NewVUStateTags(registry.RootTagSet()),
+	}
+	req, err := http.NewRequest(http.MethodGet, rawURL, nil)
+	require.NoError(t, err)
+	preq := &ParsedHTTPRequest{
+		Req:              req,
+		URL:              &URL{u: req.URL, URL: rawURL},
+		Body:             new(bytes.Buffer),
+		Timeout:          10 * time.Second,
+		Auth:             auth,
+		Redirects:        null.IntFrom(10),
+		ResponseType:     ResponseTypeText,
+		ResponseCallback: func(status int) bool { return status >= 200 && status < 400 },
+		TagsAndMeta:      state.Tags.GetCurrentValues(),
+	}
+	res, err := MakeRequest(context.Background(), state, preq)
+	require.NoError(t, err)
+	close(samples)
+
+	var statuses []int
+	var failed []bool
+	for sample := range samples {
+		trail, ok := sample.(*Trail)
+		require.True(t, ok)
+		status, _ := trail.Tags.Get(metrics.TagStatus.String())
+		code, err := strconv.Atoi(status)
+		require.NoError(t, err)
+		statuses = append(statuses, code)
+		failed = append(failed, trail.Failed.Bool)
+	}
+	return res, statuses, failed
+}
+
+func TestDigestAuthRedirects(t *testing.T) {
+	t.Parallel()
+	mux := http.NewServeMux()
+	mux.Handle("/", httpbin.New().Handler())
+	mux.HandleFunc("/redirect", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, r.URL.Query().Get("to"), http.StatusFound)
+	})
+	var stale atomic.Bool
+	mux.HandleFunc("/stale", func(w http.ResponseWriter, r *http.Request) {
+		authorization := r.Header.Get("Authorization")
+		switch {
+		case strings.Contains(authorization, `nonce="second"`):
+			w.WriteHeader(http.StatusOK)
+			return
+		case authorization != "":
+			stale.Store(true)
+			w.Header().Set("Www-Authenticate", `Digest realm="k6", nonce="second", qop="auth", stale=true`)
+		default:
+			w.Header().Set("Www-Authenticate", `Digest realm="k6", nonce="first", qop="auth"`)
+		}
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	withCredentials := strings.Replace(srv.URL, "http://", "http://bob:pass@", 1)
+
+	t.Run("same host", func(t *testing.T) {
+		t.Parallel()
+		res, statuses, failed := makeAuthRequest(t, "digest", withCredentials+"/redirect?to=/digest-auth/auth/bob/pass")
+		assert.Equal(t, http.StatusOK, res.Status)
+		assert.Equal(t, []int{302, 401, 200}, statuses)
+		assert.Equal(t, []bool{false, false, false}, failed)
+	})
+	t.Run("other host", func(t *testing.T) {
+		t.Parallel()
+		other := strings.Replace(srv.URL, "127.0.0.1", "localhost", 1)
+		res, statuses, failed := makeAuthRequest(t, "digest",
+			withCredentials+"/redirect?to="+other+"/digest-auth/auth/bob/pass")
+		assert.Equal(t, http.StatusUnauthorized, res.Status)
+		assert.Equal(t, []int{302, 401}, statuses)
+		assert.Equal(t, []bool{false, true}, failed)
+	})
+	t.Run("stale nonce", func(t *testing.T) {
+		t.Parallel()
+		res, statuses, failed := makeAuthRequest(t, "digest", withCredentials+"/stale")
+		assert.Equal(t, http.StatusOK, res.Status)
+		assert.True(t, stale.Load())
+		assert.Equal(t, []int{401, 401, 200}, statuses)
+		assert.Equal(t, []bool{false, false, false}, failed)
+	})
+}
+
+func TestNTLMAuthRedirects(t *testing.T) {
+	t.Parallel()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ntlm", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("authenticated"))
+	})
+	mux.HandleFunc("/redirect", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/ntlm", http.StatusFound)
+	})
+	srv := newNTLMServer(t, mux)
+	redirect := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, srv.URL+"/ntlm", http.StatusFound)
+	}))
+	t.Cleanup(redirect.Close)
+
+	t.Run("authenticated", func(t *testing.T) {
+		t.Parallel()
+		res, statuses, failed := makeAuthRequest(t, "ntlm",
+			strings.Replace(srv.URL, "http://", "http://DOMAIN%5Cbob:pass@", 1)+"/ntlm")
+		assert.Equal(t, http.StatusOK, res.Status)
+		assert.Equal(t, "authenticated", res.Body)
+		assert.Equal(t, []int{401, 401, 200}, statuses)
+		assert.Equal(t, []bool{false, false, false}, failed)
+	})
+	t.Run("same host", func(t *testing.T) {
+		t.Parallel()
+		res, statuses, failed := makeAuthRequest(t, "ntlm",
+			strings.Replace(srv.URL, "http://", "http://bob:pass@", 1)+"/redirect")
+		assert.Equal(t, http.StatusOK, res.Status)
+		assert.Equal(t, "authenticated", res.Body)
+		assert.Equal(t, []int{401, 401, 302, 200}, statuses)
+		assert.Equal(t, []bool{false, false, false, false}, failed)
+	})
+	t.Run("other host", func(t *testing.T) {
+		t.Parallel()
+		res, statuses, failed := makeAuthRequest(t, "ntlm",
+			strings.Replace(redirect.URL, "http://", "http://bob:pass@", 1))
+		assert.Equal(t, http.StatusUnauthorized, res.Status)
+		assert.Equal(t, []int{302, 401}, statuses)
+		assert.Equal(t, []bool{false, true}, failed)
+	})
+}
@@ -9,6 +9,7 @@ import (
 	"io"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/andybalholm/brotli"
 	"github.com/klauspost/compress/zstd"
@@ -115,21 +116,41 @@ func wrapDecompressionError(err error) error {
 	return err
 }
 
+// timedReader measures the time spent reading from its reader.
+type timedReader struct {
+	io.Reader
+	spent time.Duration
+}
+
+func (r *timedReader) Read(p []byte) (int, error) {
+	start := time.Now()
+	n, err := r.Reader.Read(p)
+	r.spent += time.Since(start)
+	return n, err
+}
+
+// readResponseBody reads the body of the response, decompressed by its
+// Content-Encoding, unless decompress is false, and writes it to the digest,
+// if it isn't nil. It also returns how long the decompression took, i.e. the
+// time reading the body took, except the time spent reading the compressed
+// body from the network.
 func readResponseBody(
 	state *lib.State,
 	respType ResponseType,
+	decompress bool,
+	digest io.Writer,
 	resp *http.Response,
 	respErr error,
-) (interface{}, error) {
+) (interface{}, time.Duration, error) {
 	if resp == nil || respErr != nil {
-		return nil, respErr
+		return nil, 0, respErr
 	}
 
-	if respType == ResponseTypeNone {
+	if respType == ResponseTypeNone && digest == nil {
 		_, err := io.Copy(io.Discard, resp.Body)
 		_ = resp.Body.Close()
 
-		return nil, err
+		return nil, 0, err
 	}
 
 	rc := &readCloser{resp.Body}
@@ -139,34 +160,52 @@ func readResponseBody(
 		_ = respBody.Close()
 	}(resp.Body)
 
-	if (resp.StatusCode >= 100 && resp.StatusCode <= 199) || // 1xx
-		resp.StatusCode == http.StatusNoContent || resp.StatusCode == http.StatusNotModified {
+	if hasNoBody(resp.StatusCode) {
 		// for all three of this status code there is always no content
-		// https://www.rfc-editor.org/rfc/rfc9110.html#section-6.4.1-8
 		// this also prevents trying to read
-		return nil, nil //nolint:nilnil
+		return nil, 0, nil
 	}
-	contentEncodings := strings.Split(resp.Header.Get("Content-Encoding"), ",")
-	// Transparently decompress the body if it's has a content-encoding we
-	// support. If not, simply return it as it is.
-	for i := len(contentEncodings) - 1; i >= 0; i-- {
-		contentEncoding := strings.TrimSpace(contentEncodings[i])
-		if compression, err := CompressionTypeString(contentEncoding); err == nil {
-			decoder, err := pickDecoder(compression, rc)
-			if err != nil {
-				return nil, newDecompressionError(err)
-			}
+	start := time.Now()
+	var network *timedReader
+	if decompress {
+		contentEncodings := strings.Split(resp.Header.Get("Content-Encoding"), ",")
+		// Transparently decompress the body if it's has a content-encoding we
+		// support. If not, simply return it as it is.
+		for i := len(contentEncodings) - 1; i >= 0; i-- {
+			contentEncoding := strings.TrimSpace(contentEncodings[i])
+			if compression, err := CompressionTypeString(contentEncoding); err == nil {
+				if network == nil {
+					network = &timedReader{Reader: rc.Reader}
+					rc = &readCloser{network}
+				}
+				decoder, err := pickDecoder(compression, rc)
+				if err != nil {
+					return nil, 0, newDecompressionError(err)
+				}
 
-			rc = &readCloser{decoder}
+				rc = &readCloser{decoder}
+			}
 		}
 	}
 
 	buf := state.BufferPool.Get()
 	defer state.BufferPool.Put(buf)
-	_, err := io.Copy(buf, rc.Reader)
+	var dst io.Writer = buf
+	if respType == ResponseTypeNone {
+		// the body is only read for its digest
+		dst = io.Discard
+	}
+	if digest != nil {
+		dst = io.MultiWriter(dst, digest)
+	}
+	_, err := io.Copy(dst, rc.Reader)
 	if err != nil {
 		respErr = wrapDecompressionError(err)
 	}
+	var decompressing time.Duration
+	if network != nil {
+		decompressing = max(time.Since(start)-network.spent, 0)
+	}
 
 	err = rc.Close()
 	if err != nil && respErr == nil { // Don't overwrite previous errors
@@ -176,6 +215,7 @@ func readResponseBody(
 	var result interface{}
 	// Binary or string
 	switch respType {
+	case ResponseTypeNone:
 	case ResponseTypeText:
 		result = buf.String()
 	case ResponseTypeBinary:
@@ -190,7 +230,7 @@ func readResponseBody(
 		respErr = fmt.Errorf("unknown responseType %s", respType)
 	}
 
-	return result, respErr
+	return result, decompressing, respErr
 }
 
 func pickDecoder(compression CompressionType, rc *readCloser) (io.Reader, error) {
@@ -3,12 +3,17 @@ package httpext
 import (
 	"io"
 	"net/http"
+	"strings"
 
 	digest "github.com/Soontao/goHttpDigestClient"
 )
 
+// digestTransport authenticates the requests to the host of the credentials,
+// i.e. the first request and its redirects to the same host, with the digest
+// authentication.
 type digestTransport struct {
 	originalTransport http.RoundTripper
+	credentials       authCredentials
 }
 
 // RoundTrip handles digest auth by behaving like an http.RoundTripper
@@ -20,45 +25,64 @@ type digestTransport struct {
 //
 // Github issue: https://github.com/k6io/k6/issues/800
 func (t digestTransport) RoundTrip(req *http.Request) (*http.Response, error) {
-	// Make the initial request authentication params to compute the
-	// authorization header
-	username := req.URL.User.Username()
-	password, _ := req.URL.User.Password()
-
-	// Remove the user data from the URL to avoid sending the authorization
-	// header for basic auth
-	req.URL.User = nil
-
-	noAuthResponse, err := t.originalTransport.RoundTrip(req)
-	if err != nil || noAuthResponse.StatusCode != http.StatusUnauthorized {
-		// If there was an error, or if the remote server didn't respond with
-		// status 401, we simply return, so the upstream code can deal with it.
-		return noAuthResponse, err
-	}
-
-	respBody, err := io.ReadAll(noAuthResponse.Body)
-	if err != nil {
-		return nil, err
+	if !t.credentials.matches(req.URL) {
+		return t.originalTransport.RoundTrip(req)
 	}
-	_ = noAuthResponse.Body.Close()
 
-	// Calculate the Authorization header
-	// TODO: determine if we actually need the body, since I'm not sure that's
-	// what the `entity` means... maybe a moot point if we change the used
-	// digest auth library...
-	challenge := digest.GetChallengeFromHeader(&noAuthResponse.Header)
-	challenge.ComputeResponse(req.Method, req.URL.RequestURI(), string(respBody), username, password)
-	authorization := challenge.ToAuthorizationStr()
-	req.Header.Set(digest.KEY_AUTHORIZATION, authorization)
+	res, err := t.originalTransport.RoundTrip(req)
+	// The server may reject the authorization with a stale nonce, e.g. the
+	// one of a redirected request, with which it's re-authenticated once.
+	for attempt := 0; attempt < 2; attempt++ {
+		if err != nil || res.StatusCode != http.StatusUnauthorized {
+			// If there was an error, or if the remote server didn't respond with
+			// status 401, we simply return, so the upstream code can deal with it.
+			return res, err
+		}
+		challenge := digest.GetChallengeFromHeader(&res.Header)
+		if attempt > 0 && !strings.EqualFold(challenge.GetChallengeItemPure("stale"), "true") {
+			return res, nil
+		}
 
-	if req.GetBody != nil {
-		// Reset the request body if we need to
-		req.Body, err = req.GetBody()
-		if err != nil {
+		var respBody []byte
+		if respBody, err = io.ReadAll(res.Body); err != nil {
 			return nil, err
 		}
+		_ = res.Body.Close()
+
+		req = req.Clone(req.Context())
+		req.Header.Set(digest.KEY_AUTHORIZATION, digestAuthorization(
+			challenge, req.Method, req.URL.RequestURI(), string(respBody), t.credentials))
+		if req.GetBody != nil {
+			// Reset the request body if we need to
+			req.Body, err = req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		// Actually make the HTTP request with the proper Authorization
+		res, err = t.originalTransport.RoundTrip(req)
 	}
+	return res, err
+}
 
-	// Actually make the HTTP request with the proper Authorization
-	return t.originalTransport.RoundTrip(req)
+// DigestAuthorization returns the Authorization header, which answers the
+// digest challenge of the 401 response to the request with the method and the
+// URI, e.g. to the handshake of a WebSocket.
+func DigestAuthorization(res *http.Response, method, requestURI, username, password string) string {
+	return digestAuthorization(digest.GetChallengeFromHeader(&res.Header), method, requestURI, "",
+		authCredentials{username: username, password: password})
+}
+
+// digestAuthorization returns the Authorization header, which answers the
+// challenge.
+//
+// TODO: determine if we actually need the body, since I'm not sure that's
+// what the `entity` means... maybe a moot point if we change the used digest
+// auth library...
+func digestAuthorization(
+	challenge digest.Challenge, method, requestURI, entity string, credentials authCredentials,
+) string {
+	challenge.ComputeResponse(method, requestURI, entity, credentials.username, credentials.password)
+	return challenge.ToAuthorizationStr()
 }
@@ -47,6 +47,8 @@ const (
 	tlsHeaderErrorCode            errCode = 1301
 	x509UnknownAuthorityErrorCode errCode = 1310
 	x509HostnameErrorCode         errCode = 1311
+	ocspRevokedErrorCode          errCode = 1320
+	ocspUnverifiedErrorCode       errCode = 1321
 
 	// HTTP2 errors
 	// defaultHTTP2ErrorCode errCode = 1600 // commented because of golint
@@ -190,6 +192,11 @@ func errorCodeForError(err error) (errCode, string) {
 		return x509HostnameErrorCode, x509HostnameErrorCodeMsg
 	case tls.RecordHeaderError:
 		return tlsHeaderErrorCode, err.Error()
+	case netext.OCSPError:
+		if e.Status == netext.OCSP_STATUS_REVOKED {
+			return ocspRevokedErrorCode, err.Error()
+		}
+		return ocspUnverifiedErrorCode, err.Error()
 	case *url.Error:
 		return errorCodeForError(e.Err)
 	default:
@@ -4,9 +4,12 @@ import (
 	"bytes"
 	"net/http"
 	"net/http/httputil"
+	"strings"
 
 	uuid "github.com/nu7hatch/gouuid"
 	"github.com/sirupsen/logrus"
+
+	"go.k6.io/k6/lib"
 )
 
 type httpDebugTransport struct {
@@ -48,3 +51,70 @@ func (t httpDebugTransport) debugResponse(res *http.Response, requestID string)
 			bytes.ReplaceAll(dump, []byte("\r\n"), []byte{'\n'}))
 	}
 }
+
+// maxFlightRecordSize is how many bytes of the dump of a request or a response,
+// including its body, the flight recorder keeps.
+const maxFlightRecordSize = 16 << 10
+
+// redactedHeaders are the headers, whose values the flight recorder replaces,
+// since its records are logged and may end up in shared CI logs.
+//
+//nolint:gochecknoglobals
+var redactedHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+
+// flightRecorderTransport records the passing HTTP requests and the received
+// responses in the flight recorder of the VU, which logs them only if the
+// iteration fails.
+type flightRecorderTransport struct {
+	originalTransport http.RoundTripper
+	recorder          *lib.FlightRecorder
+}
+
+func (t flightRecorderTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqDump, err := httputil.DumpRequestOut(req, true)
+	if err != nil {
+		reqDump = []byte(err.Error())
+	}
+	resp, err := t.originalTransport.RoundTrip(req)
+
+	var resDump []byte
+	switch {
+	case err != nil:
+		resDump = []byte(err.Error())
+	case resp != nil:
+		var dumpErr error
+		if resDump, dumpErr = httputil.DumpResponse(resp, true); dumpErr != nil {
+			resDump = []byte(dumpErr.Error())
+		}
+	}
+	t.recorder.Record("Request:\n" + redactDump(reqDump) + "\nResponse:\n" + redactDump(resDump))
+	return resp, err
+}
+
+// redactDump replaces the values of the redacted headers in the given dump of a
+// request or a response and truncates it to maxFlightRecordSize bytes.
+func redactDump(dump []byte) string {
+	head, body, hasBody := strings.Cut(strings.ReplaceAll(string(dump), "\r\n", "\n"), "\n\n")
+
+	lines := strings.Split(head, "\n")
+	for i, line := range lines {
+		name, _, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		for _, h := range redactedHeaders {
+			if strings.EqualFold(name, h) {
+				lines[i] = name + ": [REDACTED]"
+				break
+			}
+		}
+	}
+	result := strings.Join(lines, "\n")
+	if hasBody {
+		result += "\n\n" + body
+	}
+	if len(result) > maxFlightRecordSize {
+		result = result[:maxFlightRecordSize] + "\n[TRUNCATED]"
+	}
+	return result
+}
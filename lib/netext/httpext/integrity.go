@@ -0,0 +1,58 @@
+package httpext
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"net/http"
+	"strings"
+)
+
+// Integrity is what the body of the response of a request is verified
+// against, while it's read, so it's verified without being retained, even if
+// the responseType is none.
+type Integrity struct {
+	// SHA256 is the SHA-256 digest of the body, after its decompression, if
+	// it's set.
+	SHA256 []byte
+	// ETag is the ETag header, which the response should have, if it's set.
+	// It's compared strongly, so a weak ETag never matches it.
+	ETag string
+}
+
+// newHash returns the hash of the body, or nil, if its digest isn't verified.
+func (i *Integrity) newHash() hash.Hash {
+	if i == nil || len(i.SHA256) == 0 {
+		return nil
+	}
+	return sha256.New()
+}
+
+// verify returns the mismatch of the response with the headers and the body,
+// whose hash is the digest, or nil, if they match.
+func (i *Integrity) verify(header http.Header, digest hash.Hash) error {
+	if i.ETag != "" {
+		etag := header.Get("ETag")
+		if etag == "" {
+			return fmt.Errorf("the response doesn't have the ETag %s", i.ETag)
+		}
+		if etag != i.ETag || strings.HasPrefix(etag, "W/") {
+			return fmt.Errorf("the ETag of the response is %s, instead of %s", etag, i.ETag)
+		}
+	}
+	if digest != nil {
+		if sum := digest.Sum(nil); !bytes.Equal(sum, i.SHA256) {
+			return fmt.Errorf("the SHA-256 digest of the response body is %s, instead of %s",
+				hex.EncodeToString(sum), hex.EncodeToString(i.SHA256))
+		}
+	}
+	return nil
+}
+
+// hasNoBody is whether the responses with the status never have a body.
+// https://www.rfc-editor.org/rfc/rfc9110.html#section-6.4.1-8
+func hasNoBody(status int) bool {
+	return (status >= 100 && status <= 199) || status == http.StatusNoContent || status == http.StatusNotModified
+}
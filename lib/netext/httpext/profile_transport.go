@@ -0,0 +1,23 @@
+package httpext
+
+import (
+	"net/http"
+
+	"go.k6.io/k6/lib"
+)
+
+// profileTransport sends the headers of a browser profile, which the requests
+// don't set themselves. Since it's a transport, the redirected requests send
+// them too, like a browser would.
+type profileTransport struct {
+	originalTransport http.RoundTripper
+	profile           string
+}
+
+// RoundTrip sets the missing headers of the profile on a copy of the request,
+// since the original one must not be modified by a transport.
+func (t profileTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	lib.ApplyHTTPProfile(t.profile, req.Header)
+	return t.originalTransport.RoundTrip(req)
+}
@@ -13,7 +13,6 @@ import (
 	"strings"
 	"time"
 
-	"github.com/Azure/go-ntlmssp"
 	"github.com/sirupsen/logrus"
 	"gopkg.in/guregu/null.v3"
 
@@ -51,6 +50,27 @@ type ParsedHTTPRequest struct {
 	ActiveJar        *cookiejar.Jar
 	Cookies          map[string]*HTTPRequestCookie
 	TagsAndMeta      metrics.TagsAndMeta
+	// TLSAuth is the name of the tlsAuth certificate to present, if it
+	// shouldn't be chosen by the domain of the request.
+	TLSAuth string
+	// Proxy is the URL of the SOCKS5 proxy that the request should be made
+	// through, instead of the VU's one.
+	Proxy string
+	// Hosts is the JSON of the hosts, which map the hostnames of the request,
+	// and of its redirects, to the addresses to connect to.
+	Hosts string
+	// Profile is the browser profile, whose headers the request sends, instead
+	// of the VU's one.
+	Profile string
+	// NoDecompression is whether the response body is returned as it was
+	// received, instead of decompressed by its Content-Encoding.
+	NoDecompression bool
+	// AWSSigV4 are the credentials, with which the request is signed by the
+	// AWS Signature Version 4, if it's set.
+	AWSSigV4 *AWSSigV4
+	// Integrity is what the body of the response is verified against, if
+	// it's set.
+	Integrity *Integrity
 }
 
 // Matches non-compliant io.Closer implementations (e.g. zstd.Decoder)
@@ -95,7 +115,28 @@ func updateK6Response(k6Response *Response, finishedReq *finishedRequest) {
 		k6Response.RemoteIP = remoteHost
 		k6Response.RemotePort = remotePort
 	}
-	k6Response.Timings = ResponseTimings{
+	k6Response.Timings = trailTimings(trail)
+	k6Response.EarlyHints = make([]ResponseEarlyHint, 0, len(trail.EarlyHints))
+	for _, hint := range trail.EarlyHints {
+		k6Response.EarlyHints = append(k6Response.EarlyHints, ResponseEarlyHint{
+			Headers: joinHeaders(hint.Header),
+			Waiting: metrics.D(hint.Waiting),
+		})
+	}
+}
+
+// joinHeaders returns the headers with their values joined, as they're in the
+// responses.
+func joinHeaders(header http.Header) map[string]string {
+	headers := make(map[string]string, len(header))
+	for k, vs := range header {
+		headers[k] = strings.Join(vs, ", ")
+	}
+	return headers
+}
+
+func trailTimings(trail *Trail) ResponseTimings {
+	return ResponseTimings{
 		Duration:       metrics.D(trail.Duration),
 		Blocked:        metrics.D(trail.Blocked),
 		Connecting:     metrics.D(trail.Connecting),
@@ -103,6 +144,7 @@ func updateK6Response(k6Response *Response, finishedReq *finishedRequest) {
 		Sending:        metrics.D(trail.Sending),
 		Waiting:        metrics.D(trail.Waiting),
 		Receiving:      metrics.D(trail.Receiving),
+		Decompressing:  metrics.D(trail.Decompressing),
 	}
 }
 
@@ -174,6 +216,16 @@ func MakeRequest(ctx context.Context, state *lib.State, preq *ParsedHTTPRequest)
 		preq.TagsAndMeta.SetSystemTagOrMeta(metrics.TagName, preq.URL.Name)
 	}
 
+	if state.AuthSessions != nil && preq.Auth == "" && preq.Req.URL.User == nil && preq.Req.Header.Get("Authorization") == "" {
+		authorization, err := state.AuthSessions.Authorization(ctx, state.Transport, preq.Req.URL.Host)
+		if err != nil {
+			return nil, err
+		}
+		if authorization != "" {
+			preq.Req.Header.Set("Authorization", authorization)
+		}
+	}
+
 	// Check rate limit *after* we've prepared a request; no need to wait with that part.
 	if rpsLimit := state.RPSLimit; rpsLimit != nil {
 		if err := rpsLimit.Wait(ctx); err != nil {
@@ -182,7 +234,28 @@ func MakeRequest(ctx context.Context, state *lib.State, preq *ParsedHTTPRequest)
 	}
 
 	tracerTransport := newTransport(ctx, state, &preq.TagsAndMeta, preq.ResponseCallback)
+	if preq.TLSAuth != "" || preq.Proxy != "" || preq.Hosts != "" {
+		if state.TransportFor == nil {
+			return nil, errors.New("selecting the tlsAuth certificate, proxy or hosts of requests isn't supported here")
+		}
+		roundTripper, err := state.TransportFor(lib.TransportOptions{
+			TLSAuth: preq.TLSAuth, Proxy: preq.Proxy, Hosts: preq.Hosts,
+		})
+		if err != nil {
+			return nil, err
+		}
+		tracerTransport.roundTripper = roundTripper
+	}
 	var transport http.RoundTripper = tracerTransport
+	if profile := preq.Profile; profile != "" || state.HTTPProfile != "" {
+		if profile == "" {
+			profile = state.HTTPProfile
+		}
+		transport = profileTransport{originalTransport: transport, profile: profile}
+	}
+	if preq.AWSSigV4 != nil {
+		transport = sigV4Transport{originalTransport: transport, credentials: *preq.AWSSigV4, now: time.Now}
+	}
 
 	if state.Options.HTTPDebug.String != "" {
 		// Combine tags with common log fields
@@ -204,28 +277,25 @@ func MakeRequest(ctx context.Context, state *lib.State, preq *ParsedHTTPRequest)
 		}
 	}
 
-	if preq.Auth == "digest" {
-		// Until digest authentication is refactored, the first response will always
-		// be a 401 error, so we expect that.
-		if tracerTransport.responseCallback != nil {
-			originalResponseCallback := tracerTransport.responseCallback
-			tracerTransport.responseCallback = func(status int) bool {
-				tracerTransport.responseCallback = originalResponseCallback
-				return status == 401
-			}
+	if state.FlightRecorder != nil {
+		transport = flightRecorderTransport{originalTransport: transport, recorder: state.FlightRecorder}
+	}
+
+	if preq.Auth == "digest" || preq.Auth == "ntlm" {
+		// The credentials are sent to the host of the request, also after its
+		// redirects to it, and the 401 responses, which challenge them, are
+		// expected.
+		credentials := newAuthCredentials(preq.Req.URL)
+		transport = challengeTransport{
+			originalTransport: transport,
+			challenged:        tracerTransport.setChallenged,
+			unauthorized:      new(bool),
 		}
-		transport = digestTransport{originalTransport: transport}
-	} else if preq.Auth == "ntlm" {
-		// The first response of NTLM auth may be a 401 error.
-		if tracerTransport.responseCallback != nil {
-			originalResponseCallback := tracerTransport.responseCallback
-			tracerTransport.responseCallback = func(status int) bool {
-				tracerTransport.responseCallback = originalResponseCallback
-				// ntlm is connection-level based so we could've already authorized the connection and to now reuse it
-				return status == 401 || originalResponseCallback(status)
-			}
+		if preq.Auth == "digest" {
+			transport = digestTransport{originalTransport: transport, credentials: credentials}
+		} else {
+			transport = ntlmTransport{originalTransport: transport, credentials: credentials}
 		}
-		transport = ntlmssp.Negotiator{RoundTripper: transport}
 	}
 
 	resp := &Response{URL: preq.URL.URL, Request: respReq}
@@ -274,7 +344,17 @@ func MakeRequest(ctx context.Context, state *lib.State, preq *ParsedHTTPRequest)
 	}
 
 	if resErr == nil {
-		resp.Body, resErr = readResponseBody(state, preq.ResponseType, res, resErr)
+		var decompressing time.Duration
+		digest := preq.Integrity.newHash()
+		resp.Body, decompressing, resErr = readResponseBody(
+			state, preq.ResponseType, !preq.NoDecompression, digest, res, resErr)
+		tracerTransport.setDecompressing(decompressing)
+		if resErr == nil && preq.Integrity != nil && !hasNoBody(res.StatusCode) {
+			if err := preq.Integrity.verify(res.Header, digest); err != nil {
+				resp.IntegrityError = err.Error()
+				tracerTransport.setIntegrityFailed()
+			}
+		}
 		if resErr != nil && errors.Is(resErr, context.DeadlineExceeded) {
 			// TODO This can be more specific that the timeout happened in the middle of the reading of the body
 			resErr = NewK6Error(requestTimeoutErrorCode, requestTimeoutErrorCodeMsg, resErr)
@@ -284,6 +364,7 @@ func MakeRequest(ctx context.Context, state *lib.State, preq *ParsedHTTPRequest)
 	if finishedReq != nil {
 		updateK6Response(resp, finishedReq)
 	}
+	resp.Redirects = append([]ResponseRedirect{}, tracerTransport.redirects...)
 
 	if resErr == nil {
 		if preq.ActiveJar != nil {
@@ -301,10 +382,7 @@ func MakeRequest(ctx context.Context, state *lib.State, preq *ParsedHTTPRequest)
 			resp.setTLSInfo(res.TLS)
 		}
 
-		resp.Headers = make(map[string]string, len(res.Header))
-		for k, vs := range res.Header {
-			resp.Headers[k] = strings.Join(vs, ", ")
-		}
+		resp.Headers = joinHeaders(res.Header)
 
 		resCookies := res.Cookies()
 		resp.Cookies = make(map[string][]*HTTPCookie, len(resCookies))
@@ -41,6 +41,7 @@ type ResponseTimings struct {
 	Sending        float64 `json:"sending"`
 	Waiting        float64 `json:"waiting"`
 	Receiving      float64 `json:"receiving"`
+	Decompressing  float64 `json:"decompressing"`
 }
 
 // HTTPCookie is a representation of an http cookies used in the Response object
@@ -65,10 +66,45 @@ type Response struct {
 	Timings        ResponseTimings          `json:"timings"`
 	TLSVersion     string                   `json:"tls_version"`
 	TLSCipherSuite string                   `json:"tls_cipher_suite"`
+	TLSGroup       string                   `json:"tls_group"`
 	OCSP           netext.OCSP              `json:"ocsp"`
+	TLS            ResponseTLS              `json:"tls"`
 	Error          string                   `json:"error"`
 	ErrorCode      int                      `json:"error_code"`
 	Request        *Request                 `json:"request"`
+	// EarlyHints are the 103 Early Hints responses, which were received
+	// before the final one, in order.
+	EarlyHints []ResponseEarlyHint `json:"early_hints"`
+	// Redirects are the responses, which redirected the request to the
+	// final one, in order. The timings of the response are only of the final
+	// one, so the ones of the whole chain are the sum of all of them.
+	Redirects []ResponseRedirect `json:"redirects"`
+	// IntegrityError is the mismatch of the response with the integrity param
+	// of the request, if there's one.
+	IntegrityError string `json:"integrity_error"`
+}
+
+// ResponseRedirect is a response in the redirect chain of a request.
+type ResponseRedirect struct {
+	URL      string          `json:"url"`
+	Status   int             `json:"status"`
+	Location string          `json:"location"`
+	Timings  ResponseTimings `json:"timings"`
+}
+
+// ResponseEarlyHint is a 103 Early Hints response of a request.
+type ResponseEarlyHint struct {
+	Headers map[string]string `json:"headers"`
+	// The time from sending the request to receiving the response.
+	Waiting float64 `json:"waiting"`
+}
+
+// ResponseTLS keeps the details of the TLS connection of a response.
+type ResponseTLS struct {
+	Version     string             `json:"version"`
+	CipherSuite string             `json:"cipher_suite"`
+	Group       string             `json:"group"`
+	OCSP        netext.StapledOCSP `json:"ocsp"`
 }
 
 // NewResponse returns an empty Response instance.
@@ -82,5 +118,12 @@ func (res *Response) setTLSInfo(tlsState *tls.ConnectionState) {
 	tlsInfo, oscp := netext.ParseTLSConnState(tlsState)
 	res.TLSVersion = tlsInfo.Version
 	res.TLSCipherSuite = tlsInfo.CipherSuite
+	res.TLSGroup = tlsInfo.Group
 	res.OCSP = oscp
+	res.TLS = ResponseTLS{
+		Version:     tlsInfo.Version,
+		CipherSuite: tlsInfo.CipherSuite,
+		Group:       tlsInfo.Group,
+		OCSP:        netext.ParseStapledOCSP(tlsState),
+	}
 }
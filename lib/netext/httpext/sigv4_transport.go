@@ -0,0 +1,203 @@
+package httpext
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	sigV4Algorithm  = "AWS4-HMAC-SHA256"
+	sigV4TimeFormat = "20060102T150405Z"
+	sigV4DateFormat = "20060102"
+)
+
+// AWSSigV4 are the credentials and the scope, with which the requests are
+// signed by the AWS Signature Version 4.
+type AWSSigV4 struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	// SessionToken is the token of the temporary credentials, if they are.
+	SessionToken string
+	Region       string
+	Service      string
+}
+
+// Validate returns an error, if a required field is missing.
+func (s AWSSigV4) Validate() error {
+	switch {
+	case s.AccessKeyID == "":
+		return errors.New("the accessKeyId of awsSigV4 is required")
+	case s.SecretAccessKey == "":
+		return errors.New("the secretAccessKey of awsSigV4 is required")
+	case s.Region == "":
+		return errors.New("the region of awsSigV4 is required")
+	case s.Service == "":
+		return errors.New("the service of awsSigV4 is required")
+	}
+	return nil
+}
+
+// sigV4Transport signs the requests by the AWS Signature Version 4. Since it's
+// a transport, the redirected and retried requests are signed too.
+type sigV4Transport struct {
+	originalTransport http.RoundTripper
+	credentials       AWSSigV4
+	now               func() time.Time
+}
+
+// RoundTrip signs a copy of the request, since the original one must not be
+// modified by a transport.
+func (t sigV4Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	payload := sha256.New()
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		_, err = io.Copy(payload, body)
+		_ = body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	t.sign(req, hex.EncodeToString(payload.Sum(nil)), t.now().UTC())
+	return t.originalTransport.RoundTrip(req)
+}
+
+// sign sets the X-Amz-* headers and the Authorization header of the request.
+func (t sigV4Transport) sign(req *http.Request, payloadHash string, now time.Time) {
+	c := t.credentials
+	req.Header.Set("X-Amz-Date", now.Format(sigV4TimeFormat))
+	if c.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", c.SessionToken)
+	}
+	if c.Service == "s3" {
+		req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	}
+
+	headers, signedHeaders := sigV4CanonicalHeaders(req)
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		sigV4CanonicalURI(req.URL, c.Service),
+		sigV4CanonicalQuery(req.URL),
+		headers,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	date := now.Format(sigV4DateFormat)
+	scope := date + "/" + c.Region + "/" + c.Service + "/aws4_request"
+	stringToSign := sigV4Algorithm + "\n" + now.Format(sigV4TimeFormat) + "\n" + scope + "\n" +
+		hex.EncodeToString(sha256Sum(canonicalRequest))
+
+	key := hmacSHA256([]byte("AWS4"+c.SecretAccessKey), date)
+	for _, part := range []string{c.Region, c.Service, "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", sigV4Algorithm+" Credential="+c.AccessKeyID+"/"+scope+
+		", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+// sigV4CanonicalURI returns the URI-encoded path. Except for S3, its segments
+// are encoded twice, i.e. the already escaped path is encoded again.
+func sigV4CanonicalURI(u *url.URL, service string) string {
+	path := u.EscapedPath()
+	if path == "" {
+		return "/"
+	}
+	if service == "s3" {
+		return path
+	}
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = sigV4Escape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// sigV4CanonicalQuery returns the URI-encoded query parameters, sorted by
+// their names and values.
+func sigV4CanonicalQuery(u *url.URL) string {
+	query := u.Query()
+	params := make([]string, 0, len(query))
+	for name, values := range query {
+		for _, value := range values {
+			params = append(params, sigV4Escape(name)+"="+sigV4Escape(value))
+		}
+	}
+	sort.Strings(params)
+	return strings.Join(params, "&")
+}
+
+// sigV4CanonicalHeaders returns the canonical headers and the names of the
+// signed ones, which are the host, the content type and the X-Amz-* headers,
+// since the others can be changed by proxies.
+func sigV4CanonicalHeaders(req *http.Request) (string, string) {
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	values := map[string]string{"host": host}
+	for name, vs := range req.Header {
+		name = strings.ToLower(name)
+		if name != "content-type" && name != "content-md5" && !strings.HasPrefix(name, "x-amz-") {
+			continue
+		}
+		trimmed := make([]string, len(vs))
+		for i, v := range vs {
+			trimmed[i] = strings.Join(strings.Fields(v), " ")
+		}
+		values[name] = strings.Join(trimmed, ",")
+	}
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var b strings.Builder
+	for _, name := range names {
+		b.WriteString(name + ":" + values[name] + "\n")
+	}
+	return b.String(), strings.Join(names, ";")
+}
+
+// sigV4Escape URI-encodes every byte except the unreserved characters.
+func sigV4Escape(s string) string {
+	const hexDigits = "0123456789ABCDEF"
+	var b strings.Builder
+	for i := range len(s) {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' ||
+			c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+			continue
+		}
+		b.WriteByte('%')
+		b.WriteByte(hexDigits[c>>4])
+		b.WriteByte(hexDigits[c&15])
+	}
+	return b.String()
+}
+
+func sha256Sum(s string) []byte {
+	sum := sha256.Sum256([]byte(s))
+	return sum[:]
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,105 @@
+package httpext
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingTransport struct {
+	requests []*http.Request
+}
+
+func (t *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.requests = append(t.requests, req)
+	return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: req}, nil
+}
+
+func TestSigV4Transport(t *testing.T) {
+	t.Parallel()
+
+	// the examples of the AWS documentation and the signature test suite
+	now, err := time.Parse(sigV4TimeFormat, "20150830T123600Z")
+	require.NoError(t, err)
+	credentials := AWSSigV4{
+		AccessKeyID:     "AKIDEXAMPLE",
+		SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+		Region:          "us-east-1",
+	}
+
+	tests := map[string]struct {
+		service, url, contentType, authorization string
+	}{
+		"iam": {
+			service:     "iam",
+			url:         "https://iam.amazonaws.com/?Version=2010-05-08&Action=ListUsers",
+			contentType: "application/x-www-form-urlencoded; charset=utf-8",
+			authorization: "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/iam/aws4_request, " +
+				"SignedHeaders=content-type;host;x-amz-date, " +
+				"Signature=5d672d79c15b13162d9279b0855cfba6789a8edb4c82c400e06b5924a6f2b5d7",
+		},
+		"get-vanilla": {
+			service: "service",
+			url:     "https://example.amazonaws.com/",
+			authorization: "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, " +
+				"SignedHeaders=host;x-amz-date, " +
+				"Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31",
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			recorder := &recordingTransport{}
+			c := credentials
+			c.Service = tc.service
+			transport := sigV4Transport{originalTransport: recorder, credentials: c, now: func() time.Time { return now }}
+			req, err := http.NewRequest(http.MethodGet, tc.url, nil)
+			require.NoError(t, err)
+			if tc.contentType != "" {
+				req.Header.Set("Content-Type", tc.contentType)
+			}
+			_, err = transport.RoundTrip(req)
+			require.NoError(t, err)
+
+			require.Len(t, recorder.requests, 1)
+			assert.Equal(t, tc.authorization, recorder.requests[0].Header.Get("Authorization"))
+			assert.Equal(t, "20150830T123600Z", recorder.requests[0].Header.Get("X-Amz-Date"))
+			// the original request isn't modified
+			assert.Empty(t, req.Header.Get("Authorization"))
+		})
+	}
+}
+
+func TestSigV4TransportS3(t *testing.T) {
+	t.Parallel()
+
+	recorder := &recordingTransport{}
+	transport := sigV4Transport{originalTransport: recorder, now: time.Now, credentials: AWSSigV4{
+		AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "secret", SessionToken: "token", Region: "eu-west-1", Service: "s3",
+	}}
+	body := []byte("hello")
+	req, err := http.NewRequest(http.MethodPut, "https://bucket.s3.amazonaws.com/my%20file.txt", bytes.NewReader(body))
+	require.NoError(t, err)
+	_, err = transport.RoundTrip(req)
+	require.NoError(t, err)
+
+	signed := recorder.requests[0]
+	assert.Equal(t, "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824",
+		signed.Header.Get("X-Amz-Content-Sha256"))
+	assert.Equal(t, "token", signed.Header.Get("X-Amz-Security-Token"))
+	assert.Contains(t, signed.Header.Get("Authorization"),
+		"SignedHeaders=host;x-amz-content-sha256;x-amz-date;x-amz-security-token, ")
+	// the body is still sent
+	sent, err := io.ReadAll(signed.Body)
+	require.NoError(t, err)
+	assert.Equal(t, body, sent)
+
+	assert.Equal(t, "/my%20file.txt", sigV4CanonicalURI(signed.URL, "s3"))
+	assert.Equal(t, "/my%2520file.txt", sigV4CanonicalURI(signed.URL, "execute-api"))
+}
@@ -3,10 +3,14 @@ package httpext
 import (
 	"crypto/tls"
 	"net"
+	"net/http"
 	"net/http/httptrace"
+	"net/textproto"
+	"sync"
 	"sync/atomic"
 	"time"
 
+	"go.k6.io/k6/lib/netext"
 	"go.k6.io/k6/metrics"
 	"gopkg.in/guregu/null.v3"
 )
@@ -29,10 +33,27 @@ type Trail struct {
 	Waiting        time.Duration // Waiting for first byte.
 	Receiving      time.Duration // Receiving response.
 
+	// The part of Receiving spent decompressing the response body, if it was
+	// compressed and decompressed.
+	Decompressing time.Duration
+
+	// The part of Blocked spent waiting for a free connection of the pool,
+	// instead of connecting.
+	ConnWaiting time.Duration
+
 	// Detailed connection information.
 	ConnReused     bool
 	ConnRemoteAddr net.Addr
 
+	// The host of the connection and the numbers of the active and the idle
+	// connections to it, when the request was done, if they're counted.
+	ConnHost    string
+	ConnsActive int64
+	ConnsIdle   int64
+
+	// The 103 Early Hints responses, which were received before the final one.
+	EarlyHints []EarlyHint
+
 	Failed null.Bool
 	// Populated by SaveSamples()
 	Tags     *metrics.TagSet
@@ -40,11 +61,20 @@ type Trail struct {
 	Samples  []metrics.Sample
 }
 
+// EarlyHint is a 103 Early Hints response of a request.
+type EarlyHint struct {
+	Header http.Header
+	// The time from sending the request to receiving the response.
+	Waiting time.Duration
+}
+
 // SaveSamples populates the Trail's sample slice so they're accessible via GetSamples()
 func (tr *Trail) SaveSamples(builtinMetrics *metrics.BuiltinMetrics, ctm *metrics.TagsAndMeta) {
 	tr.Tags = ctm.Tags
 	tr.Metadata = ctm.Metadata
-	tr.Samples = make([]metrics.Sample, 0, 9) // this is with 1 more for a possible HTTPReqFailed
+	// this is with 1 more for a possible HTTPReqFailed, 1 more for a possible
+	// HTTPReqDecompressing and 3 more for the possible metrics of the pool
+	tr.Samples = make([]metrics.Sample, 0, 14+len(tr.EarlyHints))
 	tr.Samples = append(tr.Samples, []metrics.Sample{
 		{
 			TimeSeries: metrics.TimeSeries{
@@ -119,6 +149,52 @@ func (tr *Trail) SaveSamples(builtinMetrics *metrics.BuiltinMetrics, ctm *metric
 			Value:    metrics.D(tr.Receiving),
 		},
 	}...)
+	if tr.Decompressing > 0 {
+		tr.Samples = append(tr.Samples, metrics.Sample{
+			TimeSeries: metrics.TimeSeries{
+				Metric: builtinMetrics.HTTPReqDecompressing,
+				Tags:   ctm.Tags,
+			},
+			Time:     tr.EndTime,
+			Metadata: ctm.Metadata,
+			Value:    metrics.D(tr.Decompressing),
+		})
+	}
+	// the metrics of the connection pool are emitted, if its connections are
+	// counted, with the tags of the request to the host of the connections
+	if tr.ConnHost != "" {
+		tr.Samples = append(tr.Samples,
+			metrics.Sample{
+				TimeSeries: metrics.TimeSeries{Metric: builtinMetrics.HTTPReqConnWaiting, Tags: ctm.Tags},
+				Time:       tr.EndTime,
+				Metadata:   ctm.Metadata,
+				Value:      metrics.D(tr.ConnWaiting),
+			},
+			metrics.Sample{
+				TimeSeries: metrics.TimeSeries{Metric: builtinMetrics.HTTPConnsActive, Tags: ctm.Tags},
+				Time:       tr.EndTime,
+				Metadata:   ctm.Metadata,
+				Value:      float64(tr.ConnsActive),
+			},
+			metrics.Sample{
+				TimeSeries: metrics.TimeSeries{Metric: builtinMetrics.HTTPConnsIdle, Tags: ctm.Tags},
+				Time:       tr.EndTime,
+				Metadata:   ctm.Metadata,
+				Value:      float64(tr.ConnsIdle),
+			},
+		)
+	}
+	for _, hint := range tr.EarlyHints {
+		tr.Samples = append(tr.Samples, metrics.Sample{
+			TimeSeries: metrics.TimeSeries{
+				Metric: builtinMetrics.HTTPReqEarlyHints,
+				Tags:   ctm.Tags,
+			},
+			Time:     tr.EndTime,
+			Metadata: ctm.Metadata,
+			Value:    metrics.D(hint.Waiting),
+		})
+	}
 }
 
 // GetSamples implements the metrics.SampleContainer interface.
@@ -153,9 +229,20 @@ type Tracer struct {
 	gotConn              int64
 	wroteRequest         int64
 	gotFirstResponseByte int64
+	dialStart            int64
 
 	connReused     bool
 	connRemoteAddr net.Addr
+	// conn is the connection of the dialer, if the request got one
+	conn atomic.Pointer[netext.Conn]
+
+	earlyHintsMu sync.Mutex
+	earlyHints   []tracedEarlyHint
+}
+
+type tracedEarlyHint struct {
+	header http.Header
+	time   int64
 }
 
 // Trace returns a premade ClientTrace that calls all of the Tracer's hooks.
@@ -167,8 +254,10 @@ func (t *Tracer) Trace() *httptrace.ClientTrace {
 		TLSHandshakeStart:    t.TLSHandshakeStart,
 		TLSHandshakeDone:     t.TLSHandshakeDone,
 		GotConn:              t.GotConn,
+		PutIdleConn:          t.PutIdleConn,
 		WroteRequest:         t.WroteRequest,
 		GotFirstResponseByte: t.GotFirstResponseByte,
+		Got1xxResponse:       t.Got1xxResponse,
 	}
 }
 
@@ -262,6 +351,15 @@ func (t *Tracer) GotConn(info httptrace.GotConnInfo) {
 	t.connReused = info.Reused
 	t.connRemoteAddr = info.Conn.RemoteAddr()
 
+	conn := info.Conn
+	if tlsConn, ok := conn.(*tls.Conn); ok {
+		conn = tlsConn.NetConn()
+	}
+	if conn, ok := conn.(*netext.Conn); ok {
+		conn.SetIdle(false)
+		t.conn.Store(conn)
+	}
+
 	// The Go stdlib's http module can start connecting to a remote server, only
 	// to abandon that connection even before it was fully established and reuse
 	// a recently freed already existing connection.
@@ -293,6 +391,20 @@ func (t *Tracer) GotConn(info httptrace.GotConnInfo) {
 	}
 }
 
+// PutIdleConn is called when the connection is returned to the idle pool,
+// so it's counted as idle until it's taken again by GotConn().
+func (t *Tracer) PutIdleConn(err error) {
+	if conn := t.conn.Load(); conn != nil && err == nil {
+		conn.SetIdle(true)
+	}
+}
+
+// DialStart is called by the dialer, when it starts dialing a new connection
+// for the request, so the time before it was spent waiting for the pool.
+func (t *Tracer) DialStart() {
+	atomic.CompareAndSwapInt64(&t.dialStart, 0, now())
+}
+
 // WroteRequest is called with the result of writing the
 // request and any body. It may be called multiple times
 // in the case of retried requests.
@@ -311,6 +423,17 @@ func (t *Tracer) GotFirstResponseByte() {
 	atomic.CompareAndSwapInt64(&t.gotFirstResponseByte, 0, now())
 }
 
+// Got1xxResponse is called for each informational response before the final
+// one, and it keeps the 103 Early Hints ones.
+func (t *Tracer) Got1xxResponse(code int, header textproto.MIMEHeader) error {
+	if code == http.StatusEarlyHints {
+		t.earlyHintsMu.Lock()
+		t.earlyHints = append(t.earlyHints, tracedEarlyHint{header: http.Header(header).Clone(), time: now()})
+		t.earlyHintsMu.Unlock()
+	}
+	return nil
+}
+
 // Done calculates all metrics and should be called when the request is finished.
 func (t *Tracer) Done() *Trail {
 	done := time.Now()
@@ -323,6 +446,9 @@ func (t *Tracer) Done() *Trail {
 	if t.gotConn != 0 && t.getConn != 0 && t.gotConn > t.getConn {
 		trail.Blocked = time.Duration(t.gotConn - t.getConn)
 	}
+	if conn := t.conn.Load(); conn != nil {
+		trail.ConnHost, trail.ConnsActive, trail.ConnsIdle, _ = conn.Stats()
+	}
 
 	// It's possible for some of the methods of httptrace.ClientTrace to
 	// actually be called after the http.Client or http.RoundTripper have
@@ -375,10 +501,32 @@ func (t *Tracer) Done() *Trail {
 		trail.Receiving = done.Sub(time.Unix(0, gotFirstResponseByte))
 	}
 
+	t.earlyHintsMu.Lock()
+	for _, hint := range t.earlyHints {
+		var waiting time.Duration
+		if wroteRequest != 0 && hint.time > wroteRequest {
+			waiting = time.Duration(hint.time - wroteRequest)
+		}
+		trail.EarlyHints = append(trail.EarlyHints, EarlyHint{Header: hint.header, Waiting: waiting})
+	}
+	t.earlyHintsMu.Unlock()
+
 	// Calculate total times using adjusted values.
 	trail.EndTime = done
 	trail.ConnDuration = trail.Connecting + trail.TLSHandshaking
 	trail.Duration = trail.Sending + trail.Waiting + trail.Receiving
 
+	// A new connection was waited for until it started to be dialed, but
+	// without the dialer's hook, only the connecting time can be excluded.
+	dialStart := atomic.LoadInt64(&t.dialStart)
+	switch {
+	case trail.ConnReused:
+		trail.ConnWaiting = trail.Blocked
+	case dialStart != 0 && t.getConn != 0 && dialStart > t.getConn:
+		trail.ConnWaiting = min(time.Duration(dialStart-t.getConn), trail.Blocked)
+	case dialStart == 0:
+		trail.ConnWaiting = max(trail.Blocked-trail.ConnDuration, 0)
+	}
+
 	return &trail
 }
@@ -2,13 +2,17 @@
 package httpext
 
 import (
+	"bytes"
 	"context"
+	"crypto/tls"
 	"errors"
+	"io"
 	"net"
 	"net/http"
 	"net/http/httptrace"
 	"strconv"
 	"sync"
+	"time"
 
 	"go.k6.io/k6/lib"
 	"go.k6.io/k6/lib/netext"
@@ -22,9 +26,15 @@ type transport struct {
 	state            *lib.State
 	tagsAndMeta      *metrics.TagsAndMeta
 	responseCallback func(int) bool
+	// roundTripper makes the actual requests, it's the VU's transport,
+	// unless the request selects a tlsAuth certificate
+	roundTripper http.RoundTripper
 
 	lastRequest     *unfinishedRequest
 	lastRequestLock *sync.Mutex
+
+	// redirects are the finished requests with redirect responses
+	redirects []ResponseRedirect
 }
 
 // unfinishedRequest stores the request and the raw result returned from the
@@ -35,6 +45,16 @@ type unfinishedRequest struct {
 	request  *http.Request
 	response *http.Response
 	err      error
+	// cached is whether the response was served from the static asset cache
+	cached bool
+	// decompressing is how long the decompression of the response body took
+	decompressing time.Duration
+	// integrityFailed is whether the response didn't match the integrity
+	// param of the request
+	integrityFailed bool
+	// challenged is whether the response was a 401 one, which was answered
+	// by the authentication
+	challenged bool
 }
 
 // finishedRequest is produced once the request has been finalized; it is
@@ -66,6 +86,7 @@ func newTransport(
 		state:            state,
 		tagsAndMeta:      tagsAndMeta,
 		responseCallback: responseCallback,
+		roundTripper:     state.Transport,
 		lastRequestLock:  new(sync.Mutex),
 	}
 }
@@ -74,6 +95,12 @@ func newTransport(
 // the metric samples for the supplied unfinished request.
 func (t *transport) measureAndEmitMetrics(unfReq *unfinishedRequest) *finishedRequest {
 	trail := unfReq.tracer.Done()
+	if unfReq.cached {
+		// there wasn't a connection, so only the synthetic timing is reported
+		timing := t.state.StaticAssetCache.Timing()
+		trail = &Trail{EndTime: trail.EndTime, Duration: timing, Waiting: timing, ConnReused: true}
+	}
+	trail.Decompressing = unfReq.decompressing
 
 	result := &finishedRequest{
 		unfinishedRequest: unfReq,
@@ -119,6 +146,10 @@ func (t *transport) measureAndEmitMetrics(unfReq *unfinishedRequest) *finishedRe
 			tlsInfo, oscp := netext.ParseTLSConnState(unfReq.response.TLS)
 			tagsAndMeta.SetSystemTagOrMetaIfEnabled(enabledTags, metrics.TagTLSVersion, tlsInfo.Version)
 			tagsAndMeta.SetSystemTagOrMetaIfEnabled(enabledTags, metrics.TagOCSPStatus, oscp.Status)
+			tagsAndMeta.SetSystemTagOrMetaIfEnabled(enabledTags, metrics.TagTLSCipher, tlsInfo.CipherSuite)
+			if tlsInfo.Group != "" {
+				tagsAndMeta.SetSystemTagOrMetaIfEnabled(enabledTags, metrics.TagTLSGroup, tlsInfo.Group)
+			}
 			result.tlsInfo = tlsInfo
 		}
 	}
@@ -133,7 +164,7 @@ func (t *transport) measureAndEmitMetrics(unfReq *unfinishedRequest) *finishedRe
 		if unfReq.err == nil {
 			statusCode = unfReq.response.StatusCode
 		}
-		expected := t.responseCallback(statusCode)
+		expected := unfReq.challenged || t.responseCallback(statusCode)
 		if !expected {
 			failed = 1
 		}
@@ -159,10 +190,59 @@ func (t *transport) measureAndEmitMetrics(unfReq *unfinishedRequest) *finishedRe
 			},
 		)
 	}
+	if unfReq.integrityFailed {
+		trail.Samples = append(trail.Samples,
+			metrics.Sample{
+				TimeSeries: metrics.TimeSeries{
+					Metric: t.state.BuiltinMetrics.IntegrityFailures,
+					Tags:   tagsAndMeta.Tags,
+				},
+				Time:     trail.EndTime,
+				Metadata: tagsAndMeta.Metadata,
+				Value:    1,
+			},
+		)
+	}
+	if unfReq.err == nil && unfReq.response.TLS != nil && !trail.ConnReused {
+		trail.Samples = append(trail.Samples, t.tlsHandshakeSample(unfReq.response.TLS, trail, tagsAndMeta))
+		if t.state.TLSRevocationCheck != nil {
+			if duration, ok := t.state.TLSRevocationCheck(unfReq.response.TLS); ok {
+				trail.Samples = append(trail.Samples, metrics.Sample{
+					TimeSeries: metrics.TimeSeries{
+						Metric: t.state.BuiltinMetrics.TLSRevocationCheckDuration,
+						Tags:   tagsAndMeta.Tags,
+					},
+					Time:     trail.EndTime,
+					Metadata: tagsAndMeta.Metadata,
+					Value:    metrics.D(duration),
+				})
+			}
+		}
+	}
 	metrics.PushIfNotDone(t.ctx, t.state.Samples, trail)
 	return result
 }
 
+// tlsHandshakeSample returns the sample counting the TLS handshake of the new
+// connection of a request, as either a full or a resumed one.
+func (t *transport) tlsHandshakeSample(
+	state *tls.ConnectionState, trail *Trail, tagsAndMeta metrics.TagsAndMeta,
+) metrics.Sample {
+	metric := t.state.BuiltinMetrics.TLSHandshakeFull
+	if state.DidResume {
+		metric = t.state.BuiltinMetrics.TLSHandshakeResumed
+	}
+	return metrics.Sample{
+		TimeSeries: metrics.TimeSeries{
+			Metric: metric,
+			Tags:   tagsAndMeta.Tags,
+		},
+		Time:     trail.EndTime,
+		Metadata: tagsAndMeta.Metadata,
+		Value:    1,
+	}
+}
+
 func (t *transport) saveCurrentRequest(currentRequest *unfinishedRequest) {
 	t.lastRequestLock.Lock()
 	unprocessedRequest := t.lastRequest
@@ -176,6 +256,36 @@ func (t *transport) saveCurrentRequest(currentRequest *unfinishedRequest) {
 	}
 }
 
+// setDecompressing sets how long the decompression of the body of the last
+// response took, once it was read.
+func (t *transport) setDecompressing(decompressing time.Duration) {
+	t.lastRequestLock.Lock()
+	defer t.lastRequestLock.Unlock()
+	if t.lastRequest != nil {
+		t.lastRequest.decompressing = decompressing
+	}
+}
+
+// setIntegrityFailed marks the last response as one, which didn't match the
+// integrity param of the request, once its body was read.
+func (t *transport) setIntegrityFailed() {
+	t.lastRequestLock.Lock()
+	defer t.lastRequestLock.Unlock()
+	if t.lastRequest != nil {
+		t.lastRequest.integrityFailed = true
+	}
+}
+
+// setChallenged marks the last response as the challenge of the
+// authentication, before the request, which answers it.
+func (t *transport) setChallenged() {
+	t.lastRequestLock.Lock()
+	defer t.lastRequestLock.Unlock()
+	if t.lastRequest != nil {
+		t.lastRequest.challenged = true
+	}
+}
+
 func (t *transport) processLastSavedRequest(lastErr error) *finishedRequest {
 	t.lastRequestLock.Lock()
 	unprocessedRequest := t.lastRequest
@@ -195,14 +305,58 @@ func (t *transport) processLastSavedRequest(lastErr error) *finishedRequest {
 	return nil
 }
 
+// saveRedirect saves the finished request, if it was redirected, e.g. not
+// retried for authentication.
+func (t *transport) saveRedirect(finished *finishedRequest) {
+	res := finished.response
+	if res == nil || res.StatusCode < 300 || res.StatusCode >= 400 || res.Header.Get("Location") == "" {
+		return
+	}
+	t.redirects = append(t.redirects, ResponseRedirect{
+		URL:      finished.request.URL.String(),
+		Status:   res.StatusCode,
+		Location: res.Header.Get("Location"),
+		Timings:  trailTimings(finished.trail),
+	})
+}
+
 // RoundTrip is the implementation of http.RoundTripper
 func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
-	t.processLastSavedRequest(nil)
+	if finished := t.processLastSavedRequest(nil); finished != nil {
+		t.saveRedirect(finished)
+	}
 
 	ctx := req.Context()
 	tracer := &Tracer{}
-	reqWithTracer := req.WithContext(httptrace.WithClientTrace(ctx, tracer.Trace()))
-	resp, err := t.state.Transport.RoundTrip(reqWithTracer)
+	cache := t.state.StaticAssetCache
+	cacheable := cache != nil && cache.Cacheable(req)
+	if cacheable {
+		if cached, ok := cache.Get(req); ok {
+			resp := newCachedResponse(req, cached)
+			t.saveCurrentRequest(&unfinishedRequest{
+				ctx:      ctx,
+				tracer:   tracer,
+				request:  req,
+				response: resp,
+				cached:   true,
+			})
+			return resp, nil
+		}
+	}
+
+	traceCtx := httptrace.WithClientTrace(netext.WithDialStart(ctx, tracer.DialStart), tracer.Trace())
+	reqWithTracer := req.WithContext(traceCtx)
+	resp, err := t.roundTripper.RoundTrip(reqWithTracer)
+	if cacheable && err == nil && resp.StatusCode == http.StatusOK {
+		resp.Body = &cachingBody{ReadCloser: resp.Body, store: func(body []byte) {
+			cache.Put(req, &lib.CachedResponse{
+				StatusCode: resp.StatusCode,
+				Proto:      resp.Proto,
+				Header:     resp.Header,
+				Body:       body,
+			})
+		}}
+	}
 
 	var netError net.Error
 	if errors.As(err, &netError) && netError.Timeout() {
@@ -224,3 +378,37 @@ func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
 
 	return resp, err
 }
+
+// newCachedResponse returns the response to the request from its cached copy.
+func newCachedResponse(req *http.Request, cached *lib.CachedResponse) *http.Response {
+	major, minor, _ := http.ParseHTTPVersion(cached.Proto)
+	return &http.Response{
+		Status:        strconv.Itoa(cached.StatusCode) + " " + http.StatusText(cached.StatusCode),
+		StatusCode:    cached.StatusCode,
+		Proto:         cached.Proto,
+		ProtoMajor:    major,
+		ProtoMinor:    minor,
+		Header:        cached.Header,
+		Body:          io.NopCloser(bytes.NewReader(cached.Body)),
+		ContentLength: int64(len(cached.Body)),
+		Request:       req,
+	}
+}
+
+// cachingBody is the body of a response to a static asset request, which is
+// cached once it's read completely.
+type cachingBody struct {
+	io.ReadCloser
+	buf   bytes.Buffer
+	store func(body []byte)
+}
+
+func (b *cachingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.buf.Write(p[:n])
+	if errors.Is(err, io.EOF) && b.store != nil {
+		b.store(b.buf.Bytes())
+		b.store = nil
+	}
+	return n, err
+}
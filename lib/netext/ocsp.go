@@ -0,0 +1,160 @@
+package netext
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+
+	"go.k6.io/k6/lib"
+)
+
+// OCSPError is returned by the TLS handshakes which fail the verification of
+// the stapled OCSP response.
+type OCSPError struct {
+	Status string
+	Reason string
+}
+
+func (e OCSPError) Error() string {
+	return fmt.Sprintf("ocsp: %s (status %s)", e.Reason, e.Status)
+}
+
+// ocspCheckMaxAge is how long the duration of a check is kept for a connection
+// which is never used, e.g. by the websockets or gRPC clients, or because its
+// request failed.
+const ocspCheckMaxAge = time.Minute
+
+type ocspCheck struct {
+	duration time.Duration
+	at       time.Time
+}
+
+// OCSPVerifier verifies the OCSP responses stapled in the TLS handshakes, and
+// keeps how long the verification took until the connection is first used.
+// The resumed sessions aren't tracked, since they share the leaf certificates
+// of the connections they resume.
+type OCSPVerifier struct {
+	strict bool
+
+	mx sync.Mutex
+	// the durations of the checks, by the leaf certificates of the connections
+	checks    map[*x509.Certificate]ocspCheck
+	lastPrune time.Time
+}
+
+// NewOCSPVerifier returns a verifier with the given mode, i.e. either
+// lib.OCSPStaplingSoftFail or lib.OCSPStaplingStrict.
+func NewOCSPVerifier(mode string) *OCSPVerifier {
+	return &OCSPVerifier{
+		strict:    mode == lib.OCSPStaplingStrict,
+		checks:    make(map[*x509.Certificate]ocspCheck),
+		lastPrune: time.Now(),
+	}
+}
+
+// VerifyConnection implements the tls.Config.VerifyConnection callback.
+func (v *OCSPVerifier) VerifyConnection(cs tls.ConnectionState) error {
+	if len(cs.PeerCertificates) == 0 {
+		return nil
+	}
+	start := time.Now()
+	if err := v.verify(&cs); err != nil {
+		return err
+	}
+	if cs.DidResume {
+		return nil
+	}
+	now := time.Now()
+	v.mx.Lock()
+	defer v.mx.Unlock()
+	v.checks[cs.PeerCertificates[0]] = ocspCheck{duration: now.Sub(start), at: now}
+	if now.Sub(v.lastPrune) > ocspCheckMaxAge {
+		v.prune(now)
+	}
+	return nil
+}
+
+// prune drops the checks of the connections which weren't used in time.
+func (v *OCSPVerifier) prune(now time.Time) {
+	for cert, check := range v.checks {
+		if now.Sub(check.at) > ocspCheckMaxAge {
+			delete(v.checks, cert)
+		}
+	}
+	v.lastPrune = now
+}
+
+// CheckDuration returns how long the verification of the stapled OCSP response
+// of the connection took, only the first time it's called for a connection.
+func (v *OCSPVerifier) CheckDuration(cs *tls.ConnectionState) (time.Duration, bool) {
+	if len(cs.PeerCertificates) == 0 || cs.DidResume {
+		return 0, false
+	}
+	v.mx.Lock()
+	defer v.mx.Unlock()
+	check, ok := v.checks[cs.PeerCertificates[0]]
+	if !ok {
+		return 0, false
+	}
+	delete(v.checks, cs.PeerCertificates[0])
+	return check.duration, true
+}
+
+func (v *OCSPVerifier) verify(cs *tls.ConnectionState) error {
+	res, err := parseVerifiedOCSP(cs)
+	if err == nil && !res.NextUpdate.IsZero() && time.Now().After(res.NextUpdate) {
+		err = errors.New("the stapled response has expired")
+	}
+	if err != nil {
+		if v.strict {
+			return OCSPError{Status: OCSP_STATUS_UNKNOWN, Reason: err.Error()}
+		}
+		return nil
+	}
+
+	switch res.Status {
+	case ocsp.Good:
+		return nil
+	case ocsp.Revoked:
+		return OCSPError{Status: OCSP_STATUS_REVOKED, Reason: "the certificate is revoked"}
+	default:
+		if v.strict {
+			status := parseOCSPResponse(res).Status
+			return OCSPError{Status: status, Reason: "the certificate isn't known to be good"}
+		}
+		return nil
+	}
+}
+
+// parseVerifiedOCSP parses the stapled OCSP response and checks that it was
+// signed for the leaf certificate by its issuer.
+func parseVerifiedOCSP(cs *tls.ConnectionState) (*ocsp.Response, error) {
+	if len(cs.OCSPResponse) == 0 {
+		return nil, errors.New("no response was stapled")
+	}
+	issuer := ocspIssuer(cs)
+	if issuer == nil {
+		return nil, errors.New("the issuer of the certificate is unknown")
+	}
+	return ocsp.ParseResponseForCert(cs.OCSPResponse, cs.PeerCertificates[0], issuer)
+}
+
+// ocspIssuer returns the issuer of the leaf certificate, preferably from the
+// verified chain, since the certificates sent by the server aren't checked
+// with the insecureSkipTLSVerify option.
+func ocspIssuer(cs *tls.ConnectionState) *x509.Certificate {
+	for _, chain := range cs.VerifiedChains {
+		if len(chain) > 1 {
+			return chain[1]
+		}
+	}
+	if len(cs.PeerCertificates) > 1 {
+		return cs.PeerCertificates[1]
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package netext
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ocsp"
+
+	"go.k6.io/k6/internal/lib/testutils"
+	"go.k6.io/k6/lib"
+)
+
+func stapledConnState(t *testing.T, status int, nextUpdate time.Time) tls.ConnectionState {
+	t.Helper()
+	cert := testutils.NewStapledCertificate(t, status, nextUpdate)
+	cs := tls.ConnectionState{OCSPResponse: cert.OCSPStaple}
+	for _, der := range cert.Certificate {
+		c, err := x509.ParseCertificate(der)
+		require.NoError(t, err)
+		cs.PeerCertificates = append(cs.PeerCertificates, c)
+	}
+	return cs
+}
+
+func TestOCSPVerifier(t *testing.T) {
+	t.Parallel()
+
+	future, past := time.Now().Add(time.Hour), time.Now().Add(-time.Second)
+	good := stapledConnState(t, ocsp.Good, future)
+	expired := stapledConnState(t, ocsp.Good, past)
+	revoked := stapledConnState(t, ocsp.Revoked, future)
+	unknown := stapledConnState(t, ocsp.Unknown, future)
+	notStapled := stapledConnState(t, ocsp.Good, future)
+	notStapled.OCSPResponse = nil
+	badSignature := stapledConnState(t, ocsp.Revoked, future)
+	badSignature.OCSPResponse = append([]byte(nil), badSignature.OCSPResponse...)
+	badSignature.OCSPResponse[len(badSignature.OCSPResponse)-1] ^= 0xff
+	noIssuer := stapledConnState(t, ocsp.Good, future)
+	noIssuer.PeerCertificates = noIssuer.PeerCertificates[:1]
+
+	testCases := []struct {
+		name           string
+		cs             tls.ConnectionState
+		softFailStatus string // empty when the handshake shouldn't fail
+		strictStatus   string
+	}{
+		{name: "good", cs: good},
+		{name: "expired", cs: expired, strictStatus: OCSP_STATUS_UNKNOWN},
+		{name: "revoked", cs: revoked, softFailStatus: OCSP_STATUS_REVOKED, strictStatus: OCSP_STATUS_REVOKED},
+		{name: "unknown", cs: unknown, strictStatus: OCSP_STATUS_UNKNOWN},
+		{name: "not stapled", cs: notStapled, strictStatus: OCSP_STATUS_UNKNOWN},
+		{name: "bad signature", cs: badSignature, strictStatus: OCSP_STATUS_UNKNOWN},
+		{name: "no issuer", cs: noIssuer, strictStatus: OCSP_STATUS_UNKNOWN},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			for mode, expStatus := range map[string]string{
+				lib.OCSPStaplingSoftFail: tc.softFailStatus,
+				lib.OCSPStaplingStrict:   tc.strictStatus,
+			} {
+				verifier := NewOCSPVerifier(mode)
+				err := verifier.VerifyConnection(tc.cs)
+				_, checked := verifier.CheckDuration(&tc.cs)
+				if expStatus == "" {
+					require.NoError(t, err, mode)
+					assert.True(t, checked, mode)
+					continue
+				}
+				var ocspErr OCSPError
+				require.ErrorAs(t, err, &ocspErr, mode)
+				assert.Equal(t, expStatus, ocspErr.Status, mode)
+				assert.False(t, checked, mode)
+			}
+		})
+	}
+
+	t.Run("duration only once", func(t *testing.T) {
+		t.Parallel()
+		verifier := NewOCSPVerifier(lib.OCSPStaplingStrict)
+		require.NoError(t, verifier.VerifyConnection(good))
+		duration, ok := verifier.CheckDuration(&good)
+		assert.True(t, ok)
+		assert.Positive(t, duration)
+		_, ok = verifier.CheckDuration(&good)
+		assert.False(t, ok)
+	})
+
+	t.Run("resumed sessions aren't tracked", func(t *testing.T) {
+		t.Parallel()
+		resumed := good
+		resumed.DidResume = true
+		verifier := NewOCSPVerifier(lib.OCSPStaplingStrict)
+		require.NoError(t, verifier.VerifyConnection(resumed))
+		_, ok := verifier.CheckDuration(&resumed)
+		assert.False(t, ok)
+		assert.Empty(t, verifier.checks)
+	})
+
+	t.Run("unused checks are pruned", func(t *testing.T) {
+		t.Parallel()
+		verifier := NewOCSPVerifier(lib.OCSPStaplingStrict)
+		require.NoError(t, verifier.VerifyConnection(good))
+		verifier.prune(time.Now().Add(ocspCheckMaxAge + time.Second))
+		assert.Empty(t, verifier.checks)
+		_, ok := verifier.CheckDuration(&good)
+		assert.False(t, ok)
+	})
+}
+
+func TestParseStapledOCSP(t *testing.T) {
+	t.Parallel()
+
+	revoked := stapledConnState(t, ocsp.Revoked, time.Now().Add(time.Hour))
+	stapled := ParseStapledOCSP(&revoked)
+	assert.True(t, stapled.Stapled)
+	assert.Equal(t, OCSP_STATUS_REVOKED, stapled.Status)
+	assert.Equal(t, OCSP_REASON_KEY_COMPROMISE, stapled.RevocationReason)
+	assert.Equal(t, "2", stapled.SerialNumber)
+	assert.Equal(t, "ECDSA-SHA256", stapled.SignatureAlgorithm)
+	assert.NotEmpty(t, stapled.Responder)
+	assert.True(t, stapled.Verified)
+
+	revoked.PeerCertificates = revoked.PeerCertificates[:1]
+	stapled = ParseStapledOCSP(&revoked)
+	assert.True(t, stapled.Stapled)
+	assert.Equal(t, OCSP_STATUS_REVOKED, stapled.Status)
+	assert.False(t, stapled.Verified)
+
+	revoked.OCSPResponse = nil
+	stapled = ParseStapledOCSP(&revoked)
+	assert.False(t, stapled.Stapled)
+	assert.Equal(t, OCSP_STATUS_UNKNOWN, stapled.Status)
+}
@@ -17,8 +17,20 @@ type Resolver interface {
 	LookupIP(host string) (net.IP, error)
 }
 
+// CachingResolver is a Resolver, which caches its lookups.
+type CachingResolver interface {
+	Resolver
+	// LookupIPCached is like LookupIP, but it also returns whether the IP, or
+	// the error, was served from the cache.
+	LookupIPCached(host string) (ip net.IP, cached bool, err error)
+	// Flush removes the cached lookups of the host, or of all hosts if it's
+	// empty, so they're resolved again.
+	Flush(host string)
+}
+
 type resolver struct {
 	resolve     MultiResolver
+	servers     string // the DNSServersTag of the servers the lookups are sent to
 	selectIndex types.DNSSelect
 	policy      types.DNSPolicy
 	rrm         *sync.Mutex
@@ -28,14 +40,29 @@ type resolver struct {
 
 type cacheRecord struct {
 	ips        []net.IP
+	err        error // set for cached failed lookups
 	lastLookup time.Time
 }
 
 type cacheResolver struct {
 	resolver
-	ttl   time.Duration
-	cm    *sync.Mutex
-	cache map[string]cacheRecord
+	ttl         time.Duration
+	negativeTTL time.Duration
+	maxStale    time.Duration
+	cm          *sync.Mutex
+	cache       map[string]cacheRecord
+}
+
+// ResolverCache configures how a resolver caches its lookups.
+type ResolverCache struct {
+	// TTL is how long successful lookups are cached.
+	TTL time.Duration
+	// NegativeTTL is how long failed lookups are cached, so that the failing
+	// lookups aren't repeated for every connection.
+	NegativeTTL time.Duration
+	// MaxStale is how long after their TTL has expired the cached IPs can
+	// still be used, if refreshing them fails.
+	MaxStale time.Duration
 }
 
 // NewResolver returns a new DNS resolver. If ttl is not 0, responses
@@ -43,6 +70,15 @@ type cacheResolver struct {
 // LookupIP() will be selected based on the given sel and pol values.
 func NewResolver(
 	actRes MultiResolver, ttl time.Duration, sel types.DNSSelect, pol types.DNSPolicy,
+) Resolver {
+	return NewCachingResolver(actRes, ResolverCache{TTL: ttl}, sel, pol)
+}
+
+// NewCachingResolver returns a new DNS resolver, like NewResolver, but with
+// more control over how the lookups are cached. If all of the cache durations
+// are 0, nothing is cached.
+func NewCachingResolver(
+	actRes MultiResolver, cache ResolverCache, sel types.DNSSelect, pol types.DNSPolicy,
 ) Resolver {
 	r := rand.New(rand.NewSource(time.Now().UnixNano())) //nolint:gosec
 	res := resolver{
@@ -53,15 +89,42 @@ func NewResolver(
 		rand:        r,
 		roundRobin:  make(map[string]uint8),
 	}
-	if ttl == 0 {
+	if cache == (ResolverCache{}) {
 		return &res
 	}
 	return &cacheResolver{
-		resolver: res,
-		ttl:      ttl,
-		cm:       &sync.Mutex{},
-		cache:    make(map[string]cacheRecord),
+		resolver:    res,
+		ttl:         cache.TTL,
+		negativeTTL: cache.NegativeTTL,
+		maxStale:    cache.MaxStale,
+		cm:          &sync.Mutex{},
+		cache:       make(map[string]cacheRecord),
+	}
+}
+
+// NewServersCachingResolver returns a new DNS resolver, like
+// NewCachingResolver, which sends its lookups to the given DNS servers,
+// instead of the ones configured in the system.
+func NewServersCachingResolver(
+	servers []types.DNSServer, cache ResolverCache, sel types.DNSSelect, pol types.DNSPolicy,
+) Resolver {
+	res := NewCachingResolver(NewServersMultiResolver(servers), cache, sel, pol)
+	switch r := res.(type) {
+	case *resolver:
+		r.servers = DNSServersTag(servers)
+	case *cacheResolver:
+		r.servers = DNSServersTag(servers)
 	}
+	return res
+}
+
+// Servers returns the DNSServersTag of the DNS servers, which the lookups of
+// the resolver are sent to.
+func (r *resolver) Servers() string {
+	if r.servers == "" {
+		return SystemDNSServers
+	}
+	return r.servers
 }
 
 // LookupIP returns a single IP resolved for host, selected according to the
@@ -79,29 +142,64 @@ func (r *resolver) LookupIP(host string) (net.IP, error) {
 // LookupIP returns a single IP resolved for host, selected according to the
 // configured select and policy options. Results are cached per host and will be
 // refreshed if the last lookup time exceeds the configured TTL (not the TTL
-// returned in the DNS record).
+// returned in the DNS record). Failed lookups are cached for the negative TTL
+// and, if a refresh fails, the previous IPs are used for up to the max staleness.
 func (r *cacheResolver) LookupIP(host string) (net.IP, error) {
+	ip, _, err := r.LookupIPCached(host)
+	return ip, err
+}
+
+// LookupIPCached is like LookupIP, but it also returns whether the IP, or the
+// error, was served from the cache. The stale IPs, which are used because a
+// refresh failed, aren't counted as cached, since they were looked up.
+func (r *cacheResolver) LookupIPCached(host string) (net.IP, bool, error) {
 	r.cm.Lock()
 
 	var ips []net.IP
-	// TODO: Invalidate? When?
-	if cr, ok := r.cache[host]; ok && time.Now().Before(cr.lastLookup.Add(r.ttl)) {
+	cached := false
+	cr, found := r.cache[host]
+	switch {
+	case found && cr.err != nil && time.Now().Before(cr.lastLookup.Add(r.negativeTTL)):
+		r.cm.Unlock()
+		return nil, true, cr.err
+	case found && cr.err == nil && time.Now().Before(cr.lastLookup.Add(r.ttl)):
 		ips = cr.ips
-	} else {
+		cached = true
+	default:
 		r.cm.Unlock() // The lookup could take some time, so unlock momentarily.
 		var err error
 		ips, err = r.resolve(host)
+		r.cm.Lock()
 		if err != nil {
-			return nil, err
+			if found && cr.err == nil && time.Now().Before(cr.lastLookup.Add(r.ttl+r.maxStale)) {
+				ips = cr.ips
+				break
+			}
+			if r.negativeTTL > 0 {
+				r.cache[host] = cacheRecord{err: err, lastLookup: time.Now()}
+			}
+			r.cm.Unlock()
+			return nil, false, err
 		}
 		ips = r.applyPolicy(ips)
-		r.cm.Lock()
 		r.cache[host] = cacheRecord{ips: ips, lastLookup: time.Now()}
 	}
 
 	r.cm.Unlock()
 
-	return r.selectOne(host, ips), nil
+	return r.selectOne(host, ips), cached, nil
+}
+
+// Flush removes the cached lookups of the host, or of all hosts if it's empty,
+// including the ones, which could still be used while stale.
+func (r *cacheResolver) Flush(host string) {
+	r.cm.Lock()
+	defer r.cm.Unlock()
+	if host == "" {
+		r.cache = make(map[string]cacheRecord)
+		return
+	}
+	delete(r.cache, host)
 }
 
 func (r *resolver) selectOne(host string, ips []net.IP) net.IP {
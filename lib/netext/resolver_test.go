@@ -1,6 +1,7 @@
 package netext
 
 import (
+	"errors"
 	"fmt"
 	"net"
 	"testing"
@@ -97,3 +98,101 @@ func TestResolver(t *testing.T) {
 		}
 	})
 }
+
+func TestResolverNegativeCacheAndMaxStale(t *testing.T) {
+	t.Parallel()
+
+	host := "myhost"
+	ip := net.ParseIP("127.0.0.10")
+
+	t.Run("negative TTL", func(t *testing.T) {
+		t.Parallel()
+		var lookups int
+		r := NewCachingResolver(func(string) ([]net.IP, error) {
+			lookups++
+			return nil, fmt.Errorf("lookup %d failed", lookups)
+		}, ResolverCache{NegativeTTL: time.Hour}, types.DNSfirst, types.DNSany)
+
+		_, err := r.LookupIP(host)
+		require.ErrorContains(t, err, "lookup 1 failed")
+		_, err = r.LookupIP(host)
+		require.ErrorContains(t, err, "lookup 1 failed")
+		assert.Equal(t, 1, lookups)
+	})
+
+	t.Run("no negative TTL", func(t *testing.T) {
+		t.Parallel()
+		var lookups int
+		r := NewCachingResolver(func(string) ([]net.IP, error) {
+			lookups++
+			return nil, fmt.Errorf("lookup %d failed", lookups)
+		}, ResolverCache{TTL: time.Hour}, types.DNSfirst, types.DNSany)
+
+		_, err := r.LookupIP(host)
+		require.ErrorContains(t, err, "lookup 1 failed")
+		_, err = r.LookupIP(host)
+		require.ErrorContains(t, err, "lookup 2 failed")
+	})
+
+	t.Run("max stale", func(t *testing.T) {
+		t.Parallel()
+		var fail bool
+		r := NewCachingResolver(func(string) ([]net.IP, error) {
+			if fail {
+				return nil, errors.New("lookup failed")
+			}
+			return []net.IP{ip}, nil
+		}, ResolverCache{TTL: time.Nanosecond, MaxStale: time.Hour}, types.DNSfirst, types.DNSany)
+
+		resolved, err := r.LookupIP(host)
+		require.NoError(t, err)
+		assert.Equal(t, ip, resolved)
+
+		fail = true
+		time.Sleep(time.Millisecond)
+		resolved, err = r.LookupIP(host)
+		require.NoError(t, err)
+		assert.Equal(t, ip, resolved)
+
+		cr, ok := r.(*cacheResolver)
+		require.True(t, ok)
+		cr.maxStale = 0
+		_, err = r.LookupIP(host)
+		require.ErrorContains(t, err, "lookup failed")
+	})
+}
+
+func TestResolverFlush(t *testing.T) {
+	t.Parallel()
+
+	lookups := make(map[string]int)
+	r := NewCachingResolver(func(host string) ([]net.IP, error) {
+		lookups[host]++
+		return []net.IP{net.ParseIP("127.0.0.10")}, nil
+	}, ResolverCache{TTL: time.Hour, MaxStale: time.Hour}, types.DNSfirst, types.DNSany)
+	cr, ok := r.(CachingResolver)
+	require.True(t, ok)
+
+	lookupIP := func(host string) bool {
+		t.Helper()
+		_, cached, err := cr.LookupIPCached(host)
+		require.NoError(t, err)
+		return cached
+	}
+	assert.False(t, lookupIP("a"))
+	assert.False(t, lookupIP("b"))
+	assert.True(t, lookupIP("a"))
+
+	cr.Flush("a")
+	assert.False(t, lookupIP("a"))
+	assert.True(t, lookupIP("b"))
+	assert.Equal(t, map[string]int{"a": 2, "b": 1}, lookups)
+
+	cr.Flush("")
+	assert.False(t, lookupIP("a"))
+	assert.False(t, lookupIP("b"))
+	assert.Equal(t, map[string]int{"a": 3, "b": 2}, lookups)
+
+	_, ok = NewResolver(net.LookupIP, 0, types.DNSfirst, types.DNSany).(CachingResolver)
+	assert.False(t, ok, "the resolvers without a cache can't be flushed")
+}
@@ -0,0 +1,72 @@
+package netext
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/url"
+
+	"golang.org/x/net/proxy"
+)
+
+type proxyKey struct{}
+
+// WithProxy returns a context, dialing with which makes the Dialer connect
+// through the given SOCKS5 proxy, instead of its own one.
+func WithProxy(ctx context.Context, proxyURL *url.URL) context.Context {
+	return context.WithValue(ctx, proxyKey{}, proxyURL)
+}
+
+func (d *Dialer) proxyFor(ctx context.Context) *url.URL {
+	if proxyURL, ok := ctx.Value(proxyKey{}).(*url.URL); ok {
+		return proxyURL
+	}
+	return d.Proxy
+}
+
+// dialProxy connects to the address through the SOCKS5 proxy. Only TCP is
+// supported, since the proxy is asked to connect, not to associate.
+func (d *Dialer) dialProxy(ctx context.Context, proxyURL *url.URL, proto, addr string) (net.Conn, error) {
+	var auth *proxy.Auth
+	if proxyURL.User != nil {
+		password, _ := proxyURL.User.Password()
+		auth = &proxy.Auth{User: proxyURL.User.Username(), Password: password}
+	}
+	socksDialer, err := proxy.SOCKS5("tcp", proxyURL.Host, auth, &d.Dialer)
+	if err != nil {
+		return nil, err
+	}
+	contextDialer, ok := socksDialer.(proxy.ContextDialer)
+	if !ok {
+		return nil, errors.New("the SOCKS5 dialer doesn't support contexts")
+	}
+	return contextDialer.DialContext(ctx, proto, addr)
+}
+
+// getProxyDialAddr returns the address that the proxy should connect to. With
+// the socks5h scheme, the hostnames are resolved by the proxy, so only the
+// blocked hostnames and the hosts option apply to them, but not the blacklist.
+func (d *Dialer) getProxyDialAddr(proxyURL *url.URL, addr string) (string, error) {
+	if proxyURL.Scheme != "socks5h" {
+		return d.getDialAddr(addr)
+	}
+
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "", err
+	}
+	if net.ParseIP(host) != nil {
+		return d.getDialAddr(addr)
+	}
+	if d.BlockedHostnames != nil {
+		if match, blocked := d.BlockedHostnames.Contains(host); blocked {
+			return "", BlockedHostError{hostname: host, match: match}
+		}
+	}
+	if d.Hosts != nil {
+		if remote, err := d.getConfiguredHost(addr, host, port); err != nil || remote != nil {
+			return d.getDialAddr(addr)
+		}
+	}
+	return addr, nil
+}
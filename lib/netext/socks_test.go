@@ -0,0 +1,129 @@
+package netext
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/internal/lib/testutils"
+	"go.k6.io/k6/lib/types"
+)
+
+func startEchoServer(t *testing.T) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer func() { _ = conn.Close() }()
+				_, _ = io.Copy(conn, conn)
+			}()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+func requireEcho(t *testing.T, conn net.Conn) {
+	t.Helper()
+	_, err := conn.Write([]byte("hello"))
+	require.NoError(t, err)
+	buf := make([]byte, 5)
+	_, err = io.ReadFull(conn, buf)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(buf))
+}
+
+func TestDialerSOCKS5Proxy(t *testing.T) {
+	t.Parallel()
+
+	echoAddr := startEchoServer(t)
+	_, echoPort, err := net.SplitHostPort(echoAddr)
+	require.NoError(t, err)
+
+	t.Run("socks5", func(t *testing.T) {
+		t.Parallel()
+		proxy := testutils.NewSOCKS5Server(t, "user", "pass")
+		proxyURL, err := types.ParseSOCKSProxy("socks5://user:pass@" + proxy.Addr)
+		require.NoError(t, err)
+
+		dialer := NewDialer(net.Dialer{}, newResolver())
+		hosts, err := types.NewHosts(map[string]types.Host{"echo.test": {IP: net.ParseIP("127.0.0.1")}})
+		require.NoError(t, err)
+		dialer.Hosts = hosts
+		dialer.Proxy = proxyURL
+
+		conn, err := dialer.DialContext(context.Background(), "tcp", "echo.test:"+echoPort)
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = conn.Close() })
+		requireEcho(t, conn)
+
+		// the hostname was resolved locally
+		assert.Equal(t, []string{echoAddr}, proxy.Targets())
+		assert.Positive(t, dialer.BytesWritten)
+	})
+
+	t.Run("socks5h", func(t *testing.T) {
+		t.Parallel()
+		proxy := testutils.NewSOCKS5Server(t, "", "")
+		proxyURL, err := types.ParseSOCKSProxy("socks5h://" + proxy.Addr)
+		require.NoError(t, err)
+
+		dialer := NewDialer(net.Dialer{}, newResolver())
+		dialer.Proxy = proxyURL
+		conn, err := dialer.DialContext(context.Background(), "tcp", "localhost:"+echoPort)
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = conn.Close() })
+		requireEcho(t, conn)
+
+		// the hostname was sent to the proxy
+		assert.Equal(t, []string{"localhost:" + echoPort}, proxy.Targets())
+	})
+
+	t.Run("blocked hostname", func(t *testing.T) {
+		t.Parallel()
+		proxy := testutils.NewSOCKS5Server(t, "", "")
+		proxyURL, err := types.ParseSOCKSProxy("socks5h://" + proxy.Addr)
+		require.NoError(t, err)
+
+		dialer := NewDialer(net.Dialer{}, newResolver())
+		dialer.Proxy = proxyURL
+		dialer.BlockedHostnames, err = types.NewHostnameTrie([]string{"*.blocked.test"})
+		require.NoError(t, err)
+		_, err = dialer.DialContext(context.Background(), "tcp", "a.blocked.test:80")
+		require.ErrorAs(t, err, &BlockedHostError{})
+		assert.Empty(t, proxy.Targets())
+	})
+
+	t.Run("context proxy", func(t *testing.T) {
+		t.Parallel()
+		proxy := testutils.NewSOCKS5Server(t, "user", "pass")
+
+		dialer := NewDialer(net.Dialer{}, newResolver())
+		dialer.Proxy = &url.URL{Scheme: "socks5", Host: "127.0.0.1:1"} // not listening
+
+		ctx := WithProxy(context.Background(), &url.URL{
+			Scheme: "socks5", Host: proxy.Addr, User: url.UserPassword("user", "wrong"),
+		})
+		_, err := dialer.DialContext(ctx, "tcp", echoAddr)
+		require.Error(t, err)
+
+		ctx = WithProxy(context.Background(), &url.URL{
+			Scheme: "socks5", Host: proxy.Addr, User: url.UserPassword("user", "pass"),
+		})
+		conn, err := dialer.DialContext(ctx, "tcp", echoAddr)
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = conn.Close() })
+		requireEcho(t, conn)
+	})
+}
@@ -3,6 +3,9 @@ package netext
 
 import (
 	"crypto/tls"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/hex"
 
 	"golang.org/x/crypto/ocsp"
 
@@ -35,6 +38,7 @@ const (
 type TLSInfo struct {
 	Version     string
 	CipherSuite string
+	Group       string
 }
 
 // OCSP keeps Online Certificate Status Protocol (OCSP) details
@@ -62,46 +66,108 @@ func ParseTLSConnState(tlsState *tls.ConnectionState) (TLSInfo, OCSP) {
 	}
 
 	tlsInfo.CipherSuite = lib.SupportedTLSCipherSuitesToString[tlsState.CipherSuite]
+	tlsInfo.Group = negotiatedGroup(tlsState)
 	ocspStapledRes := OCSP{Status: OCSP_STATUS_UNKNOWN}
-
 	if ocspRes, err := ocsp.ParseResponse(tlsState.OCSPResponse, nil); err == nil {
-		switch ocspRes.Status {
-		case ocsp.Good:
-			ocspStapledRes.Status = OCSP_STATUS_GOOD
-		case ocsp.Revoked:
-			ocspStapledRes.Status = OCSP_STATUS_REVOKED
-		case ocsp.ServerFailed:
-			ocspStapledRes.Status = OCSP_STATUS_SERVER_FAILED
-		case ocsp.Unknown:
-			ocspStapledRes.Status = OCSP_STATUS_UNKNOWN
-		}
-		switch ocspRes.RevocationReason {
-		case ocsp.Unspecified:
-			ocspStapledRes.RevocationReason = OCSP_REASON_UNSPECIFIED
-		case ocsp.KeyCompromise:
-			ocspStapledRes.RevocationReason = OCSP_REASON_KEY_COMPROMISE
-		case ocsp.CACompromise:
-			ocspStapledRes.RevocationReason = OCSP_REASON_CA_COMPROMISE
-		case ocsp.AffiliationChanged:
-			ocspStapledRes.RevocationReason = OCSP_REASON_AFFILIATION_CHANGED
-		case ocsp.Superseded:
-			ocspStapledRes.RevocationReason = OCSP_REASON_SUPERSEDED
-		case ocsp.CessationOfOperation:
-			ocspStapledRes.RevocationReason = OCSP_REASON_CESSATION_OF_OPERATION
-		case ocsp.CertificateHold:
-			ocspStapledRes.RevocationReason = OCSP_REASON_CERTIFICATE_HOLD
-		case ocsp.RemoveFromCRL:
-			ocspStapledRes.RevocationReason = OCSP_REASON_REMOVE_FROM_CRL
-		case ocsp.PrivilegeWithdrawn:
-			ocspStapledRes.RevocationReason = OCSP_REASON_PRIVILEGE_WITHDRAWN
-		case ocsp.AACompromise:
-			ocspStapledRes.RevocationReason = OCSP_REASON_AA_COMPROMISE
-		}
-		ocspStapledRes.ProducedAt = ocspRes.ProducedAt.Unix()
-		ocspStapledRes.ThisUpdate = ocspRes.ThisUpdate.Unix()
-		ocspStapledRes.NextUpdate = ocspRes.NextUpdate.Unix()
-		ocspStapledRes.RevokedAt = ocspRes.RevokedAt.Unix()
+		ocspStapledRes = parseOCSPResponse(ocspRes)
 	}
 
 	return tlsInfo, ocspStapledRes
 }
+
+// parseOCSPResponse returns the details of the OCSP response.
+func parseOCSPResponse(ocspRes *ocsp.Response) OCSP {
+	ocspStapledRes := OCSP{Status: OCSP_STATUS_UNKNOWN}
+	switch ocspRes.Status {
+	case ocsp.Good:
+		ocspStapledRes.Status = OCSP_STATUS_GOOD
+	case ocsp.Revoked:
+		ocspStapledRes.Status = OCSP_STATUS_REVOKED
+	case ocsp.ServerFailed:
+		ocspStapledRes.Status = OCSP_STATUS_SERVER_FAILED
+	case ocsp.Unknown:
+		ocspStapledRes.Status = OCSP_STATUS_UNKNOWN
+	}
+	switch ocspRes.RevocationReason {
+	case ocsp.Unspecified:
+		ocspStapledRes.RevocationReason = OCSP_REASON_UNSPECIFIED
+	case ocsp.KeyCompromise:
+		ocspStapledRes.RevocationReason = OCSP_REASON_KEY_COMPROMISE
+	case ocsp.CACompromise:
+		ocspStapledRes.RevocationReason = OCSP_REASON_CA_COMPROMISE
+	case ocsp.AffiliationChanged:
+		ocspStapledRes.RevocationReason = OCSP_REASON_AFFILIATION_CHANGED
+	case ocsp.Superseded:
+		ocspStapledRes.RevocationReason = OCSP_REASON_SUPERSEDED
+	case ocsp.CessationOfOperation:
+		ocspStapledRes.RevocationReason = OCSP_REASON_CESSATION_OF_OPERATION
+	case ocsp.CertificateHold:
+		ocspStapledRes.RevocationReason = OCSP_REASON_CERTIFICATE_HOLD
+	case ocsp.RemoveFromCRL:
+		ocspStapledRes.RevocationReason = OCSP_REASON_REMOVE_FROM_CRL
+	case ocsp.PrivilegeWithdrawn:
+		ocspStapledRes.RevocationReason = OCSP_REASON_PRIVILEGE_WITHDRAWN
+	case ocsp.AACompromise:
+		ocspStapledRes.RevocationReason = OCSP_REASON_AA_COMPROMISE
+	}
+	ocspStapledRes.ProducedAt = ocspRes.ProducedAt.Unix()
+	ocspStapledRes.ThisUpdate = ocspRes.ThisUpdate.Unix()
+	ocspStapledRes.NextUpdate = ocspRes.NextUpdate.Unix()
+	ocspStapledRes.RevokedAt = ocspRes.RevokedAt.Unix()
+
+	return ocspStapledRes
+}
+
+// StapledOCSP keeps the details of the OCSP response stapled in a TLS handshake.
+type StapledOCSP struct {
+	// Stapled is false, if no valid OCSP response was stapled.
+	Stapled            bool   `json:"stapled"`
+	Status             string `json:"status"`
+	RevocationReason   string `json:"revocation_reason"`
+	ProducedAt         int64  `json:"produced_at"`
+	ThisUpdate         int64  `json:"this_update"`
+	NextUpdate         int64  `json:"next_update"`
+	RevokedAt          int64  `json:"revoked_at"`
+	SerialNumber       string `json:"serial_number"`
+	Responder          string `json:"responder"`
+	SignatureAlgorithm string `json:"signature_algorithm"`
+	// Verified is true when the response was signed for the certificate by its issuer.
+	Verified bool `json:"verified"`
+}
+
+// ParseStapledOCSP returns the details of the OCSP response stapled in the TLS
+// handshake.
+func ParseStapledOCSP(tlsState *tls.ConnectionState) StapledOCSP {
+	ocspRes, err := parseVerifiedOCSP(tlsState)
+	verified := err == nil
+	if !verified {
+		if ocspRes, err = ocsp.ParseResponse(tlsState.OCSPResponse, nil); err != nil {
+			return StapledOCSP{Status: OCSP_STATUS_UNKNOWN}
+		}
+	}
+
+	details := parseOCSPResponse(ocspRes)
+	stapled := StapledOCSP{
+		Stapled:            true,
+		Status:             details.Status,
+		RevocationReason:   details.RevocationReason,
+		ProducedAt:         details.ProducedAt,
+		ThisUpdate:         details.ThisUpdate,
+		NextUpdate:         details.NextUpdate,
+		RevokedAt:          details.RevokedAt,
+		SignatureAlgorithm: ocspRes.SignatureAlgorithm.String(),
+		Verified:           verified,
+	}
+	if ocspRes.SerialNumber != nil {
+		stapled.SerialNumber = ocspRes.SerialNumber.String()
+	}
+	if len(ocspRes.RawResponderName) > 0 {
+		var name pkix.RDNSequence
+		if _, err := asn1.Unmarshal(ocspRes.RawResponderName, &name); err == nil {
+			stapled.Responder = name.String()
+		}
+	} else {
+		stapled.Responder = hex.EncodeToString(ocspRes.ResponderKeyHash)
+	}
+	return stapled
+}
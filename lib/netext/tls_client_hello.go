@@ -0,0 +1,272 @@
+package netext
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"sync"
+
+	utls "github.com/refraction-networking/utls"
+)
+
+// tlsClientHelloSessions is how many sessions the uTLS session caches keep.
+const tlsClientHelloSessions = 64
+
+// clientHelloIDs are the uTLS specs of the lib.SupportedTLSClientHellos.
+//
+//nolint:gochecknoglobals
+var clientHelloIDs = map[string]utls.ClientHelloID{
+	"chrome":  utls.HelloChrome_Auto,
+	"edge":    utls.HelloEdge_Auto,
+	"firefox": utls.HelloFirefox_Auto,
+	"ios":     utls.HelloIOS_Auto,
+	"safari":  utls.HelloSafari_Auto,
+}
+
+// TLSClientHelloDialer makes TLS connections which send the ClientHello of a
+// browser, built with uTLS, instead of the one of crypto/tls. The cipher suites
+// and the curves of the browser can be replaced, e.g. with the tlsCipherSuites
+// and tlsCurves options, which then keep their order.
+//
+// Only http/1.1 is offered with ALPN, since the HTTP/2 transport can only use
+// the connections of crypto/tls.
+type TLSClientHelloDialer struct {
+	dialContext  func(ctx context.Context, network, addr string) (net.Conn, error)
+	tlsConfig    *tls.Config
+	id           utls.ClientHelloID
+	cipherSuites []uint16
+	curves       []tls.CurveID
+
+	mx sync.Mutex
+	// the uTLS session caches, by the crypto/tls ones they replace, which
+	// are switched in the tlsConfig, e.g. between the scenarios
+	sessionCaches map[tls.ClientSessionCache]utls.ClientSessionCache
+}
+
+// NewTLSClientHelloDialer returns a dialer which makes the TCP connections
+// with dialContext and the TLS handshakes with the ClientHello of the given
+// browser and the settings of tlsConfig, which is read on every dial.
+func NewTLSClientHelloDialer(
+	dialContext func(ctx context.Context, network, addr string) (net.Conn, error),
+	tlsConfig *tls.Config, browser string, cipherSuites []uint16, curves []tls.CurveID,
+) (*TLSClientHelloDialer, error) {
+	id, ok := clientHelloIDs[browser]
+	if !ok {
+		return nil, fmt.Errorf("there is no TLS ClientHello of %q", browser)
+	}
+	d := &TLSClientHelloDialer{
+		dialContext:   dialContext,
+		tlsConfig:     tlsConfig,
+		id:            id,
+		cipherSuites:  cipherSuites,
+		curves:        curves,
+		sessionCaches: make(map[tls.ClientSessionCache]utls.ClientSessionCache),
+	}
+	if _, err := d.spec(false); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// DialTLSContext implements the http.Transport.DialTLSContext callback. The
+// handshake is left to the transport, so it's traced like the crypto/tls ones.
+func (d *TLSClientHelloDialer) DialTLSContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	config := d.config(host)
+	spec, err := d.spec(config.ClientSessionCache != nil)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := d.dialContext(ctx, network, addr)
+	if err != nil {
+		return nil, err
+	}
+	uconn := utls.UClient(conn, config, utls.HelloCustom)
+	if err := uconn.ApplyPreset(&spec); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	return &clientHelloConn{UConn: uconn}, nil
+}
+
+// spec returns the ClientHello of the browser, with the configured cipher
+// suites, curves and TLS versions. A new one is needed for every connection,
+// since the extensions keep the state of the handshakes. The TLS 1.3 sessions
+// can only be resumed with the pre_shared_key extension, which the browsers
+// send last and only when they resume a session.
+func (d *TLSClientHelloDialer) spec(resumption bool) (utls.ClientHelloSpec, error) {
+	spec, err := utls.UTLSIdToSpec(d.id)
+	if err != nil {
+		return spec, err
+	}
+	if len(d.cipherSuites) > 0 {
+		spec.CipherSuites = append(greaseOf(spec.CipherSuites), d.cipherSuites...)
+	}
+	for _, ext := range spec.Extensions {
+		switch ext := ext.(type) {
+		case *utls.ALPNExtension:
+			ext.AlpnProtocols = []string{"http/1.1"}
+		case *utls.SupportedCurvesExtension:
+			if len(d.curves) > 0 {
+				ext.Curves = append(greaseOf(ext.Curves), utlsCurves(d.curves)...)
+			}
+		case *utls.KeyShareExtension:
+			if len(d.curves) > 0 {
+				ext.KeyShares = append(greaseOf(ext.KeyShares), utls.KeyShare{Group: utls.CurveID(d.curves[0])})
+			}
+		case *utls.SupportedVersionsExtension:
+			ext.Versions = slices.DeleteFunc(ext.Versions, func(v uint16) bool {
+				return !isGREASE(v) && !d.supportsVersion(v)
+			})
+			if !slices.ContainsFunc(ext.Versions, func(v uint16) bool { return !isGREASE(v) }) {
+				return spec, errors.New("the tlsVersion excludes all of the TLS versions of the tlsClientHello")
+			}
+		}
+	}
+	if resumption && !slices.ContainsFunc(spec.Extensions, func(ext utls.TLSExtension) bool {
+		_, ok := ext.(utls.PreSharedKeyExtension)
+		return ok
+	}) {
+		spec.Extensions = append(spec.Extensions, &utls.UtlsPreSharedKeyExtension{})
+	}
+	return spec, nil
+}
+
+func (d *TLSClientHelloDialer) supportsVersion(version uint16) bool {
+	return (d.tlsConfig.MinVersion == 0 || version >= d.tlsConfig.MinVersion) &&
+		(d.tlsConfig.MaxVersion == 0 || version <= d.tlsConfig.MaxVersion)
+}
+
+// config returns the uTLS config with the settings of the crypto/tls one.
+func (d *TLSClientHelloDialer) config(serverName string) *utls.Config {
+	tlsConfig := d.tlsConfig
+	config := &utls.Config{
+		ServerName:         serverName,
+		InsecureSkipVerify: tlsConfig.InsecureSkipVerify, //nolint:gosec
+		RootCAs:            tlsConfig.RootCAs,
+		KeyLogWriter:       tlsConfig.KeyLogWriter,
+		Renegotiation:      utls.RenegotiationSupport(tlsConfig.Renegotiation),
+		ClientSessionCache: d.sessionCache(tlsConfig.ClientSessionCache),
+		OmitEmptyPsk:       true,
+	}
+	for _, cert := range tlsConfig.Certificates {
+		config.Certificates = append(config.Certificates, utlsCertificate(&cert))
+	}
+	if getClientCertificate := tlsConfig.GetClientCertificate; getClientCertificate != nil {
+		config.GetClientCertificate = func(cri *utls.CertificateRequestInfo) (*utls.Certificate, error) {
+			info := &tls.CertificateRequestInfo{AcceptableCAs: cri.AcceptableCAs, Version: cri.Version}
+			for _, scheme := range cri.SignatureSchemes {
+				info.SignatureSchemes = append(info.SignatureSchemes, tls.SignatureScheme(scheme))
+			}
+			cert, err := getClientCertificate(info)
+			if err != nil || cert == nil {
+				return nil, err
+			}
+			ucert := utlsCertificate(cert)
+			return &ucert, nil
+		}
+	}
+	if verifyConnection := tlsConfig.VerifyConnection; verifyConnection != nil {
+		config.VerifyConnection = func(cs utls.ConnectionState) error {
+			return verifyConnection(tlsConnectionState(cs))
+		}
+	}
+	return config
+}
+
+// sessionCache returns the uTLS session cache which replaces the given one,
+// or nil if the session resumption is disabled. Unlike the crypto/tls ones,
+// it always resumes the sessions it has.
+func (d *TLSClientHelloDialer) sessionCache(cache tls.ClientSessionCache) utls.ClientSessionCache {
+	if cache == nil {
+		return nil
+	}
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	ucache, ok := d.sessionCaches[cache]
+	if !ok {
+		ucache = utls.NewLRUClientSessionCache(tlsClientHelloSessions)
+		d.sessionCaches[cache] = ucache
+	}
+	return ucache
+}
+
+// clientHelloConn is a uTLS connection which the HTTP transport can trace and
+// read the state of, as if it was a crypto/tls one.
+type clientHelloConn struct {
+	*utls.UConn
+}
+
+// ConnectionState returns the state of the connection, without the key
+// exchange group, which uTLS doesn't expose.
+func (c *clientHelloConn) ConnectionState() tls.ConnectionState {
+	return tlsConnectionState(c.UConn.ConnectionState())
+}
+
+func tlsConnectionState(cs utls.ConnectionState) tls.ConnectionState {
+	return tls.ConnectionState{
+		Version:                     cs.Version,
+		HandshakeComplete:           cs.HandshakeComplete,
+		DidResume:                   cs.DidResume,
+		CipherSuite:                 cs.CipherSuite,
+		NegotiatedProtocol:          cs.NegotiatedProtocol,
+		ServerName:                  cs.ServerName,
+		PeerCertificates:            cs.PeerCertificates,
+		VerifiedChains:              cs.VerifiedChains,
+		SignedCertificateTimestamps: cs.SignedCertificateTimestamps,
+		OCSPResponse:                cs.OCSPResponse,
+	}
+}
+
+func utlsCertificate(cert *tls.Certificate) utls.Certificate {
+	ucert := utls.Certificate{
+		Certificate:                 cert.Certificate,
+		PrivateKey:                  cert.PrivateKey,
+		OCSPStaple:                  cert.OCSPStaple,
+		SignedCertificateTimestamps: cert.SignedCertificateTimestamps,
+		Leaf:                        cert.Leaf,
+	}
+	for _, scheme := range cert.SupportedSignatureAlgorithms {
+		ucert.SupportedSignatureAlgorithms = append(ucert.SupportedSignatureAlgorithms, utls.SignatureScheme(scheme))
+	}
+	return ucert
+}
+
+func utlsCurves(curves []tls.CurveID) []utls.CurveID {
+	ucurves := make([]utls.CurveID, 0, len(curves))
+	for _, curve := range curves {
+		ucurves = append(ucurves, utls.CurveID(curve))
+	}
+	return ucurves
+}
+
+// greaseOf returns the leading GREASE values of the browser's list, which are
+// kept when the rest of it is replaced.
+func greaseOf[T any](values []T) []T {
+	i := 0
+	for i < len(values) && isGREASE(values[i]) {
+		i++
+	}
+	return slices.Clone(values[:i])
+}
+
+func isGREASE(value any) bool {
+	var v uint16
+	switch value := value.(type) {
+	case uint16:
+		v = value
+	case utls.CurveID:
+		v = uint16(value)
+	case utls.KeyShare:
+		v = uint16(value.Group)
+	default:
+		return false
+	}
+	return v&0x0f0f == 0x0a0a && v>>8 == v&0xff
+}
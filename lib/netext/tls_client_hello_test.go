@@ -0,0 +1,63 @@
+package netext
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/lib"
+)
+
+func TestTLSClientHelloDialer(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	t.Cleanup(srv.Close)
+
+	for _, browser := range lib.SupportedTLSClientHellos {
+		t.Run(browser, func(t *testing.T) {
+			t.Parallel()
+
+			tlsConfig := srv.Client().Transport.(*http.Transport).TLSClientConfig.Clone() //nolint:forcetypeassert
+			tlsConfig.ClientSessionCache = NewTLSSessionCache(0)
+			d, err := NewTLSClientHelloDialer((&net.Dialer{}).DialContext, tlsConfig, browser, nil, nil)
+			require.NoError(t, err)
+			client := &http.Client{Transport: &http.Transport{DialTLSContext: d.DialTLSContext, DisableKeepAlives: true}}
+
+			resumed := make([]bool, 2)
+			for i := range resumed {
+				resp, err := client.Get(srv.URL)
+				require.NoError(t, err)
+				require.NoError(t, resp.Body.Close())
+				require.NotNil(t, resp.TLS)
+				assert.Equal(t, "HTTP/1.1", resp.Proto)
+				assert.NotEmpty(t, resp.TLS.PeerCertificates)
+				resumed[i] = resp.TLS.DidResume
+			}
+			assert.Equal(t, []bool{false, true}, resumed)
+		})
+	}
+
+	t.Run("unknown browser", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := NewTLSClientHelloDialer((&net.Dialer{}).DialContext, &tls.Config{}, "netscape", nil, nil) //nolint:gosec
+		require.ErrorContains(t, err, `there is no TLS ClientHello of "netscape"`)
+	})
+
+	t.Run("excluded versions", func(t *testing.T) {
+		t.Parallel()
+
+		tlsConfig := &tls.Config{MinVersion: tls.VersionTLS13, MaxVersion: tls.VersionTLS13} //nolint:gosec
+		_, err := NewTLSClientHelloDialer((&net.Dialer{}).DialContext, tlsConfig, "ios", nil, nil)
+		require.NoError(t, err)
+		tlsConfig.MinVersion, tlsConfig.MaxVersion = tls.VersionTLS10, tls.VersionTLS11
+		_, err = NewTLSClientHelloDialer((&net.Dialer{}).DialContext, tlsConfig, "chrome", nil, nil)
+		require.ErrorContains(t, err, "the tlsVersion excludes all of the TLS versions")
+	})
+}
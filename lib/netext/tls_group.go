@@ -0,0 +1,21 @@
+//go:build go1.25
+
+package netext
+
+import (
+	"crypto/tls"
+
+	"go.k6.io/k6/lib"
+)
+
+// negotiatedGroup returns the name of the key exchange group of the
+// connection, which is empty for the resumed TLS 1.2 sessions.
+func negotiatedGroup(tlsState *tls.ConnectionState) string {
+	if tlsState.CurveID == 0 {
+		return ""
+	}
+	if name, ok := lib.SupportedTLSCurvesToString[tlsState.CurveID]; ok {
+		return name
+	}
+	return tlsState.CurveID.String()
+}
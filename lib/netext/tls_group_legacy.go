@@ -0,0 +1,11 @@
+//go:build !go1.25
+
+package netext
+
+import "crypto/tls"
+
+// negotiatedGroup always returns an empty string, since the key exchange group
+// of the connection is only exposed by Go 1.25 and newer.
+func negotiatedGroup(*tls.ConnectionState) string {
+	return ""
+}
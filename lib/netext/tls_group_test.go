@@ -0,0 +1,25 @@
+//go:build go1.25
+
+package netext
+
+import (
+	"crypto/tls"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTLSConnStateGroup(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[tls.CurveID]string{
+		0:                  "",
+		tls.X25519:         "X25519",
+		tls.CurveP384:      "P-384",
+		tls.X25519MLKEM768: "X25519MLKEM768",
+	}
+	for curveID, expGroup := range testCases {
+		tlsInfo, _ := ParseTLSConnState(&tls.ConnectionState{Version: tls.VersionTLS13, CurveID: curveID})
+		assert.Equal(t, expGroup, tlsInfo.Group)
+	}
+}
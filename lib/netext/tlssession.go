@@ -0,0 +1,33 @@
+package netext
+
+import (
+	"crypto/tls"
+	"sync/atomic"
+)
+
+// tlsSessionCache is a TLS client session cache that can force some of the
+// handshakes to be full ones, by pretending that it has no session for them.
+type tlsSessionCache struct {
+	tls.ClientSessionCache
+
+	fullHandshakeEvery uint64
+	handshakes         atomic.Uint64
+}
+
+// NewTLSSessionCache returns a TLS client session cache, which makes every Nth
+// handshake a full one, or all of them resumed, when possible, if N is 0.
+func NewTLSSessionCache(fullHandshakeEvery uint64) tls.ClientSessionCache {
+	return &tlsSessionCache{
+		ClientSessionCache: tls.NewLRUClientSessionCache(0),
+		fullHandshakeEvery: fullHandshakeEvery,
+	}
+}
+
+// Get is called once for every handshake, to look up a session to resume.
+func (c *tlsSessionCache) Get(sessionKey string) (*tls.ClientSessionState, bool) {
+	n := c.handshakes.Add(1)
+	if c.fullHandshakeEvery > 0 && n%c.fullHandshakeEvery == 0 {
+		return nil, false
+	}
+	return c.ClientSessionCache.Get(sessionKey)
+}
@@ -0,0 +1,31 @@
+package netext
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTLSSessionCache(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	t.Cleanup(srv.Close)
+
+	tlsConfig := srv.Client().Transport.(*http.Transport).TLSClientConfig.Clone() //nolint:forcetypeassert
+	tlsConfig.ClientSessionCache = NewTLSSessionCache(3)
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: tlsConfig, DisableKeepAlives: true}}
+
+	resumed := make([]bool, 7)
+	for i := range resumed {
+		resp, err := client.Get(srv.URL)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+		resumed[i] = resp.TLS.DidResume
+	}
+	// the first handshake has no session to resume and every third one is forced to be a full one
+	assert.Equal(t, []bool{false, true, false, true, true, false, true}, resumed)
+}
@@ -69,6 +69,18 @@ func TestOptions(t *testing.T) {
 		assert.Equal(t, oneStage, Options{}.Apply(opts).Apply(Options{Stages: oneStage}).Apply(Options{Stages: oneStage}).Stages)
 	})
 	// Execution overwriting is tested by the config consolidation test in cmd
+	t.Run("OnAbortTimeout", func(t *testing.T) {
+		t.Parallel()
+		opts := Options{}.Apply(Options{OnAbortTimeout: types.NullDurationFrom(10 * time.Second)})
+		assert.True(t, opts.OnAbortTimeout.Valid)
+		assert.Equal(t, types.Duration(10*time.Second), opts.OnAbortTimeout.Duration)
+	})
+	t.Run("ScenarioSetupData", func(t *testing.T) {
+		t.Parallel()
+		opts := Options{}.Apply(Options{ScenarioSetupData: null.BoolFrom(true)})
+		assert.True(t, opts.ScenarioSetupData.Valid)
+		assert.True(t, opts.ScenarioSetupData.Bool)
+	})
 	t.Run("RPS", func(t *testing.T) {
 		t.Parallel()
 		opts := Options{}.Apply(Options{RPS: null.IntFrom(12345)})
@@ -87,6 +99,12 @@ func TestOptions(t *testing.T) {
 		assert.True(t, opts.UserAgent.Valid)
 		assert.Equal(t, "foo", opts.UserAgent.String)
 	})
+	t.Run("HTTPProfile", func(t *testing.T) {
+		t.Parallel()
+		opts := Options{}.Apply(Options{HTTPProfile: null.StringFrom("chrome")})
+		assert.True(t, opts.HTTPProfile.Valid)
+		assert.Equal(t, "chrome", opts.HTTPProfile.String)
+	})
 	t.Run("Batch", func(t *testing.T) {
 		t.Parallel()
 		opts := Options{}.Apply(Options{Batch: null.IntFrom(12345)})
@@ -158,6 +176,32 @@ func TestOptions(t *testing.T) {
 			})
 		})
 	})
+	t.Run("TLSCurves", func(t *testing.T) {
+		t.Parallel()
+		opts := Options{}.Apply(Options{TLSCurves: &TLSCurves{tls.X25519, tls.CurveP256}})
+		assert.Equal(t, &TLSCurves{tls.X25519, tls.CurveP256}, opts.TLSCurves)
+
+		t.Run("JSON", func(t *testing.T) {
+			t.Parallel()
+			var opts Options
+			jsonStr := `{"tlsCurves":["X25519MLKEM768","X25519"]}`
+			require.NoError(t, json.Unmarshal([]byte(jsonStr), &opts))
+			assert.Equal(t, &TLSCurves{4588, tls.X25519}, opts.TLSCurves)
+
+			data, err := json.Marshal(opts.TLSCurves)
+			require.NoError(t, err)
+			assert.Equal(t, `["X25519MLKEM768","X25519"]`, string(data))
+
+			assert.ErrorContains(t, json.Unmarshal([]byte(`{"tlsCurves":["foo"]}`), &opts), "unknown TLS curve 'foo'")
+		})
+		t.Run("Text", func(t *testing.T) {
+			t.Parallel()
+			var curves TLSCurves
+			require.NoError(t, curves.UnmarshalText([]byte("P-384, X25519")))
+			assert.Equal(t, TLSCurves{tls.CurveP384, tls.X25519}, curves)
+			assert.Error(t, curves.UnmarshalText([]byte("P-384,")))
+		})
+	})
 	t.Run("TLSVersion", func(t *testing.T) {
 		t.Parallel()
 		versions := TLSVersions{Min: tls.VersionSSL30, Max: tls.VersionTLS12} //nolint:staticcheck
@@ -417,6 +461,12 @@ func TestOptions(t *testing.T) {
 		assert.True(t, opts.NoCookiesReset.Valid)
 		assert.True(t, opts.NoCookiesReset.Bool)
 	})
+	t.Run("MetricSamplesPerVUBufferSize", func(t *testing.T) {
+		t.Parallel()
+		opts := Options{}.Apply(Options{MetricSamplesPerVUBufferSize: null.IntFrom(100)})
+		assert.True(t, opts.MetricSamplesPerVUBufferSize.Valid)
+		assert.Equal(t, int64(100), opts.MetricSamplesPerVUBufferSize.Int64)
+	})
 	t.Run("BlacklistIPs", func(t *testing.T) {
 		t.Parallel()
 		opts := Options{}.Apply(Options{
@@ -537,6 +587,17 @@ func TestOptions(t *testing.T) {
 			})
 		})
 	})
+	t.Run("MetricSystemTags", func(t *testing.T) {
+		t.Parallel()
+		var opts Options
+		jsonStr := `{"metricSystemTags":{"data_sent":[],"http_req_duration":["url","method"]}}`
+		require.NoError(t, json.Unmarshal([]byte(jsonStr), &opts))
+		opts = Options{}.Apply(opts)
+		assert.Equal(t, map[string]*metrics.SystemTagSet{
+			"data_sent":         metrics.NewSystemTagSet(),
+			"http_req_duration": metrics.NewSystemTagSet(metrics.TagURL, metrics.TagMethod),
+		}, opts.MetricSystemTags)
+	})
 	t.Run("SummaryTrendStats", func(t *testing.T) {
 		t.Parallel()
 		stats := []string{"myStat1", "myStat2"}
@@ -555,6 +616,11 @@ func TestOptions(t *testing.T) {
 		assert.True(t, opts.DiscardResponseBodies.Valid)
 		assert.True(t, opts.DiscardResponseBodies.Bool)
 	})
+	t.Run("ArtifactsDir", func(t *testing.T) {
+		t.Parallel()
+		opts := Options{}.Apply(Options{ArtifactsDir: null.StringFrom("results")})
+		assert.Equal(t, null.StringFrom("results"), opts.ArtifactsDir)
+	})
 	t.Run("ClientIPRanges", func(t *testing.T) {
 		t.Parallel()
 		clientIPRanges := types.NullIPPool{}
@@ -616,11 +682,24 @@ func TestOptionsEnv(t *testing.T) {
 			"true":  null.BoolFrom(true),
 			"false": null.BoolFrom(false),
 		},
+		{"OnAbortTimeout", "K6_ON_ABORT_TIMEOUT"}: {
+			"":    types.NullDuration{},
+			"10s": types.NullDurationFrom(10 * time.Second),
+		},
+		{"ScenarioSetupData", "K6_SCENARIO_SETUP_DATA"}: {
+			"":      null.Bool{},
+			"true":  null.BoolFrom(true),
+			"false": null.BoolFrom(false),
+		},
 		{"NoTeardown", "K6_NO_TEARDOWN"}: {
 			"":      null.Bool{},
 			"true":  null.BoolFrom(true),
 			"false": null.BoolFrom(false),
 		},
+		{"ArtifactsDir", "K6_ARTIFACTS_DIR"}: {
+			"":        null.String{},
+			"results": null.StringFrom("results"),
+		},
 		{"InsecureSkipTLSVerify", "K6_INSECURE_SKIP_TLS_VERIFY"}: {
 			"":      null.Bool{},
 			"true":  null.BoolFrom(true),
@@ -643,6 +722,10 @@ func TestOptionsEnv(t *testing.T) {
 			"":    null.String{},
 			"Hi!": null.StringFrom("Hi!"),
 		},
+		{"HTTPProfile", "K6_HTTP_PROFILE"}: {
+			"":       null.String{},
+			"chrome": null.StringFrom("chrome"),
+		},
 		{"LocalIPs", "K6_LOCAL_IPS"}: {
 			"":                 types.NullIPPool{},
 			"192.168.220.2":    mustNullIPPool("192.168.220.2"),
@@ -829,4 +912,121 @@ func TestValidate(t *testing.T) {
 			})
 		}
 	})
+	t.Run("sharedConnectionPool", func(t *testing.T) {
+		t.Parallel()
+		opts := Options{SharedConnectionPool: null.BoolFrom(true), MaxConnsPerHost: null.IntFrom(10)}
+		assert.Empty(t, opts.Validate())
+
+		opts.NoVUConnectionReuse = null.BoolFrom(true)
+		errorsSlice := opts.Validate()
+		require.Len(t, errorsSlice, 1)
+		assert.ErrorContains(t, errorsSlice[0], "noVUConnectionReuse")
+
+		opts.NoVUConnectionReuse = null.Bool{}
+		opts.MaxConnsPerHost = null.IntFrom(-1)
+		errorsSlice = opts.Validate()
+		require.Len(t, errorsSlice, 1)
+		assert.ErrorContains(t, errorsSlice[0], "maxConnsPerHost")
+	})
+	t.Run("connectionPool", func(t *testing.T) {
+		t.Parallel()
+		opts := Options{
+			MaxIdleConns:        null.IntFrom(0),
+			MaxIdleConnsPerHost: null.IntFrom(4),
+			IdleConnTimeout:     types.NullDurationFrom(30 * time.Second),
+		}
+		assert.Empty(t, opts.Validate())
+
+		opts = Options{
+			MaxIdleConns:        null.IntFrom(-1),
+			MaxIdleConnsPerHost: null.IntFrom(0),
+			IdleConnTimeout:     types.NullDurationFrom(-time.Second),
+		}
+		errorsSlice := opts.Validate()
+		require.Len(t, errorsSlice, 3)
+		assert.ErrorContains(t, errorsSlice[0], "maxIdleConns")
+		assert.ErrorContains(t, errorsSlice[1], "maxIdleConnsPerHost")
+		assert.ErrorContains(t, errorsSlice[2], "idleConnTimeout")
+	})
+	t.Run("tlsSessionResumption", func(t *testing.T) {
+		t.Parallel()
+		opts := Options{TLSSessionResumption: &TLSSessionResumption{
+			Enabled:            null.BoolFrom(true),
+			FullHandshakeEvery: null.IntFrom(3),
+		}}
+		assert.Empty(t, opts.Validate())
+
+		opts.TLSSessionResumption.FullHandshakeEvery = null.IntFrom(-1)
+		errorsSlice := opts.Validate()
+		require.Len(t, errorsSlice, 1)
+		assert.ErrorContains(t, errorsSlice[0], "fullHandshakeEvery")
+	})
+	t.Run("tlsAuth names", func(t *testing.T) {
+		t.Parallel()
+		opts := Options{TLSAuth: []*TLSAuth{
+			{TLSAuthFields: TLSAuthFields{Name: "a"}},
+			{TLSAuthFields: TLSAuthFields{Name: "b"}},
+			{TLSAuthFields: TLSAuthFields{}},
+			{TLSAuthFields: TLSAuthFields{}},
+		}}
+		assert.Empty(t, opts.Validate())
+
+		opts.TLSAuth[1].Name = "a"
+		errorsSlice := opts.Validate()
+		require.Len(t, errorsSlice, 1)
+		assert.ErrorContains(t, errorsSlice[0], `more than one tlsAuth certificate with the name "a"`)
+	})
+	t.Run("ocspStapling", func(t *testing.T) {
+		t.Parallel()
+		for _, mode := range []string{OCSPStaplingSoftFail, OCSPStaplingStrict} {
+			assert.Empty(t, Options{OCSPStapling: null.StringFrom(mode)}.Validate())
+		}
+
+		errorsSlice := Options{OCSPStapling: null.StringFrom("hard-fail")}.Validate()
+		require.Len(t, errorsSlice, 1)
+		assert.ErrorContains(t, errorsSlice[0], "ocspStapling must be either soft-fail or strict")
+	})
+	t.Run("vuMemoryLimit", func(t *testing.T) {
+		t.Parallel()
+		assert.Empty(t, Options{VUMemoryLimit: null.IntFrom(256 << 20)}.Validate())
+
+		errorsSlice := Options{VUMemoryLimit: null.IntFrom(0)}.Validate()
+		require.Len(t, errorsSlice, 1)
+		assert.ErrorContains(t, errorsSlice[0], "vuMemoryLimit must be positive, but it was 0")
+	})
+	t.Run("tlsClientHello", func(t *testing.T) {
+		t.Parallel()
+		for _, browser := range SupportedTLSClientHellos {
+			assert.Empty(t, Options{TLSClientHello: null.StringFrom(browser)}.Validate())
+		}
+
+		errorsSlice := Options{TLSClientHello: null.StringFrom("netscape")}.Validate()
+		require.Len(t, errorsSlice, 1)
+		assert.ErrorContains(t, errorsSlice[0], "tlsClientHello must be one of chrome, edge, firefox, ios, safari")
+
+		errorsSlice = Options{
+			TLSClientHello: null.StringFrom("chrome"),
+			TLSCurves:      &TLSCurves{tlsCurveX25519MLKEM768, tls.X25519},
+		}.Validate()
+		require.Len(t, errorsSlice, 1)
+		assert.ErrorContains(t, errorsSlice[0], "tlsCurves can't include X25519MLKEM768")
+	})
+	t.Run("httpProfile", func(t *testing.T) {
+		t.Parallel()
+		for _, profile := range HTTPProfileNames() {
+			assert.Empty(t, Options{HTTPProfile: null.StringFrom(profile)}.Validate())
+		}
+
+		errorsSlice := Options{HTTPProfile: null.StringFrom("netscape")}.Validate()
+		require.Len(t, errorsSlice, 1)
+		assert.ErrorContains(t, errorsSlice[0], "httpProfile must be one of chrome, chrome-android, edge, firefox, safari")
+	})
+	t.Run("dataAttribution", func(t *testing.T) {
+		t.Parallel()
+		assert.Empty(t, Options{DataAttribution: []string{"scenario", "group", "host"}}.Validate())
+
+		errorsSlice := Options{DataAttribution: []string{"group", "url"}}.Validate()
+		require.Len(t, errorsSlice, 1)
+		assert.ErrorContains(t, errorsSlice[0], `dataAttribution can only have the scenario, group and host tags, but it had "url"`)
+	})
 }
@@ -0,0 +1,88 @@
+package lib
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/lib/types"
+)
+
+// OutputTuning is how an output pushes the metrics. The unset values are left
+// to the output's own config and defaults.
+type OutputTuning struct {
+	// PushInterval is the time between the pushes of the metrics.
+	PushInterval types.NullDuration `json:"pushInterval"`
+	// MaxBatchSize is the maximum number of samples, or time series for the
+	// outputs which aggregate them, in a single push request.
+	MaxBatchSize null.Int `json:"maxBatchSize"`
+	// MaxInFlight is the maximum number of concurrent push requests.
+	MaxInFlight null.Int `json:"maxInFlight"`
+}
+
+// OutputTunings are the OutputTuning values by the output types, e.g. cloud or
+// influxdb.
+type OutputTunings map[string]OutputTuning
+
+// UnmarshalText parses the output tunings from a comma-separated list of
+// output.key=value pairs, e.g. "cloud.pushInterval=5s,influxdb.maxInFlight=2",
+// which is how they're set with the K6_OUTPUT_TUNING environment variable.
+func (t *OutputTunings) UnmarshalText(data []byte) error {
+	tunings := make(OutputTunings)
+	for _, pair := range strings.Split(string(data), ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(pair, "=")
+		output, name, hasOutput := strings.Cut(key, ".")
+		if !ok || !hasOutput || output == "" {
+			return fmt.Errorf("the output tuning %q isn't in the output.key=value format", pair)
+		}
+
+		tuning := tunings[output]
+		switch name {
+		case "pushInterval":
+			if err := tuning.PushInterval.UnmarshalText([]byte(value)); err != nil {
+				return fmt.Errorf("invalid pushInterval of the output %s: %w", output, err)
+			}
+		case "maxBatchSize", "maxInFlight":
+			n, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid %s of the output %s: %w", name, output, err)
+			}
+			if name == "maxBatchSize" {
+				tuning.MaxBatchSize = null.IntFrom(n)
+			} else {
+				tuning.MaxInFlight = null.IntFrom(n)
+			}
+		default:
+			return fmt.Errorf("unknown output tuning %q, it must be one of pushInterval, maxBatchSize or maxInFlight", name)
+		}
+		tunings[output] = tuning
+	}
+	*t = tunings
+	return nil
+}
+
+// validate checks that the set values are positive.
+func (t OutputTunings) validate() []error {
+	var validationErrors []error
+	for output, tuning := range t {
+		if tuning.PushInterval.Valid && tuning.PushInterval.Duration <= 0 {
+			validationErrors = append(validationErrors, fmt.Errorf(
+				"outputTuning.%s.pushInterval must be positive", output))
+		}
+		if tuning.MaxBatchSize.Valid && tuning.MaxBatchSize.Int64 < 1 {
+			validationErrors = append(validationErrors, fmt.Errorf(
+				"outputTuning.%s.maxBatchSize must be positive", output))
+		}
+		if tuning.MaxInFlight.Valid && tuning.MaxInFlight.Int64 < 1 {
+			validationErrors = append(validationErrors, fmt.Errorf(
+				"outputTuning.%s.maxInFlight must be positive", output))
+		}
+	}
+	return validationErrors
+}
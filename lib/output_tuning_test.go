@@ -0,0 +1,54 @@
+package lib
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/lib/types"
+)
+
+func TestOutputTuningsUnmarshalText(t *testing.T) {
+	t.Parallel()
+
+	var tunings OutputTunings
+	require.NoError(t, tunings.UnmarshalText(
+		[]byte("cloud.pushInterval=5s, cloud.maxBatchSize=100,influxdb.maxInFlight=2")))
+	assert.Equal(t, OutputTunings{
+		"cloud": {
+			PushInterval: types.NullDurationFrom(5 * time.Second),
+			MaxBatchSize: null.IntFrom(100),
+		},
+		"influxdb": {MaxInFlight: null.IntFrom(2)},
+	}, tunings)
+
+	for input, expected := range map[string]string{
+		"cloud":                  `isn't in the output.key=value format`,
+		"pushInterval=5s":        `isn't in the output.key=value format`,
+		"cloud.timeout=5s":       `unknown output tuning "timeout"`,
+		"cloud.pushInterval=abc": `invalid pushInterval of the output cloud`,
+		"cloud.maxInFlight=1.5":  `invalid maxInFlight of the output cloud`,
+	} {
+		require.ErrorContains(t, tunings.UnmarshalText([]byte(input)), expected, input)
+	}
+}
+
+func TestOutputTuningsValidate(t *testing.T) {
+	t.Parallel()
+
+	errs := Options{OutputTuning: OutputTunings{
+		"cloud": {
+			PushInterval: types.NullDurationFrom(0),
+			MaxBatchSize: null.IntFrom(0),
+			MaxInFlight:  null.IntFrom(-1),
+		},
+		"influxdb": {MaxInFlight: null.IntFrom(1)},
+	}}.Validate()
+	require.Len(t, errs, 3)
+	assert.EqualError(t, errs[0], "outputTuning.cloud.pushInterval must be positive")
+	assert.EqualError(t, errs[1], "outputTuning.cloud.maxBatchSize must be positive")
+	assert.EqualError(t, errs[2], "outputTuning.cloud.maxInFlight must be positive")
+}
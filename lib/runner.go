@@ -2,6 +2,7 @@ package lib
 
 import (
 	"context"
+	"fmt"
 	"io"
 	"time"
 
@@ -36,6 +37,29 @@ type VUActivationParams struct {
 	Env, Tags                map[string]string
 	Exec, Scenario           string
 	GetNextIterationCounters func() (uint64, uint64)
+	// IterationTimeout interrupts the iterations running longer, if positive.
+	IterationTimeout time.Duration
+}
+
+// IterationTimeoutError is returned by ActiveVU.RunOnce(), when the iteration
+// was interrupted, because it ran longer than the iteration timeout.
+type IterationTimeoutError struct {
+	Timeout time.Duration
+}
+
+func (e *IterationTimeoutError) Error() string {
+	return fmt.Sprintf("the iteration was interrupted, because it ran longer than the iterationTimeout of %s", e.Timeout)
+}
+
+// VUMemoryLimitError is returned by ActiveVU.RunOnce(), when the VU's share of
+// the live heap was over the vuMemoryLimit during or at the end of the iteration.
+type VUMemoryLimitError struct {
+	Usage, Limit int64
+}
+
+func (e *VUMemoryLimitError) Error() string {
+	return fmt.Sprintf("the iteration was interrupted, because the VU's share of the heap of %d bytes "+
+		"was over the vuMemoryLimit of %d bytes", e.Usage, e.Limit)
 }
 
 // A Runner is a factory for VUs. It should precompute as much as possible upon
@@ -68,9 +92,14 @@ type Runner interface {
 	// Saves the externally supplied setup data as json in the runner
 	SetSetupData([]byte)
 
-	// Runs post-test teardown, if applicable.
+	// Runs post-test teardown, if applicable. If the test run was aborted, its
+	// reason is in the context, see GetAbortReason().
 	Teardown(ctx context.Context, out chan<- metrics.SampleContainer) error
 
+	// Runs the handler of the abort of the test run, if applicable, with its
+	// reason in the context.
+	OnAbort(ctx context.Context, out chan<- metrics.SampleContainer) error
+
 	// Get and set options. The initial value will be whatever the script specifies (for JS,
 	// `export let options = {}`); cmd/run.go will mix this in with CLI-, config- and env-provided
 	// values and write it back to the runner.
@@ -84,6 +113,33 @@ type Runner interface {
 	HandleSummary(context.Context, *Summary) (map[string]io.Reader, error)
 }
 
+// ScheduleContext is what the hooks, which schedule the iterations of the
+// scripted executors, get about the iteration to schedule.
+type ScheduleContext struct {
+	// Iteration is the index of the iteration in the scenario, across all
+	// instances of the test.
+	Iteration int64
+	// Elapsed is the time since the start of the scenario.
+	Elapsed time.Duration
+	// Previous is the offset of the previous iteration from the start of the
+	// scenario, or negative for the first iteration.
+	Previous time.Duration
+}
+
+// A ScheduleHook returns the offset of the start of the iteration from the
+// start of the scenario, or false, if there are no more iterations. It's
+// interrupted when the context is done.
+type ScheduleHook func(ctx context.Context, sc ScheduleContext) (time.Duration, bool, error)
+
+// A ScheduleHookRunner is a Runner, which can call the exported functions,
+// which schedule the iterations of the scripted executors.
+type ScheduleHookRunner interface {
+	// NewScheduleHook returns the hook calling the exported function. The
+	// hook isn't safe for concurrent use and can't be used after the context
+	// is done.
+	NewScheduleHook(ctx context.Context, name string, out chan<- metrics.SampleContainer) (ScheduleHook, error)
+}
+
 // UIState describes the state of the UI, which might influence what
 // handleSummary() returns.
 type UIState struct {
@@ -98,4 +154,26 @@ type Summary struct {
 	TestRunDuration time.Duration // TODO: use lib.ExecutionState-based interface instead?
 	NoColor         bool          // TODO: drop this when noColor is part of the (runtime) options
 	UIState         UIState
+	ArtifactsDir    string // empty if no artifacts were written
+	StartTime       time.Time
+	Scenarios       map[string]ScenarioSummary
+	TimeBuckets     []TimeBucketSummary // sorted by their start times
+}
+
+// TimeBucketSummary contains the key metrics aggregated from the samples of a
+// single time bucket, for the summary export.
+type TimeBucketSummary struct {
+	StartTime time.Time
+	Duration  time.Duration
+	Metrics   map[string]*metrics.Metric
+}
+
+// ScenarioSummary contains the data of a single scenario for the summary
+// handler.
+type ScenarioSummary struct {
+	Executor  string
+	StartTime time.Duration // the offset from the start of the test run
+	// Metrics are aggregated from the samples of the scenario only, and are
+	// nil, unless the script has a handleSummary() function.
+	Metrics map[string]*metrics.Metric
 }
@@ -5,6 +5,8 @@ import (
 	"strings"
 
 	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/lib/types"
 )
 
 // CompatibilityMode specifies the JS compatibility mode
@@ -44,6 +46,14 @@ type RuntimeOptions struct {
 	SummaryExport null.String `json:"summaryExport"`
 	KeyWriter     null.String `json:"-"`
 	TracesOutput  null.String `json:"tracesOutput"`
+
+	// The interval of the time buckets, which the key metrics are aggregated
+	// in for the summary export. They aren't aggregated over time by default.
+	SummaryExportInterval types.NullDuration `json:"summaryExportInterval"`
+
+	// The command uploading the artifacts after the test run. It isn't saved in
+	// the archives, so they can't run commands.
+	ArtifactsUploadCommand null.String `json:"-"`
 }
 
 // ValidateCompatibilityMode checks if the provided val is a valid compatibility mode
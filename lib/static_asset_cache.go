@@ -0,0 +1,170 @@
+package lib
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/guregu/null.v3"
+
+	"go.k6.io/k6/lib/types"
+)
+
+// DefaultStaticAssetExtensions are the extensions of the paths of the static
+// assets, which are cached, unless the extensions option overrides them.
+var DefaultStaticAssetExtensions = []string{ //nolint:gochecknoglobals
+	".css", ".js", ".mjs", ".map", ".png", ".jpg", ".jpeg", ".gif", ".webp", ".avif", ".svg", ".ico",
+	".woff", ".woff2", ".ttf", ".otf", ".eot",
+}
+
+// defaultStaticAssetCacheMaxSize is how many bytes of response bodies the cache
+// keeps by default.
+const defaultStaticAssetCacheMaxSize = 64 << 20
+
+// StaticAssetCacheOptions configure the cache of the static assets, which the
+// VUs share, so that a protocol test, which targets the API endpoints, doesn't
+// spend the bandwidth of the load generator on downloading the same assets.
+type StaticAssetCacheOptions struct {
+	// Extensions are the extensions of the paths of the cached assets, e.g.
+	// ".css" and ".png".
+	Extensions []string `json:"extensions"`
+	// Timing is the synthetic duration of the cached responses, which their
+	// http_req_duration and http_req_waiting metrics report.
+	Timing types.NullDuration `json:"timing"`
+	// MaxSize is how many bytes of response bodies are cached at most.
+	MaxSize null.Int `json:"maxSize"`
+}
+
+func (o StaticAssetCacheOptions) validate() []error {
+	var errs []error
+	for _, ext := range o.Extensions {
+		if !strings.HasPrefix(ext, ".") || len(ext) < 2 {
+			errs = append(errs, fmt.Errorf("staticAssetCache.extensions must be like \".css\", but one was %q", ext))
+		}
+	}
+	if o.Timing.Valid && o.Timing.Duration < 0 {
+		errs = append(errs, errors.New("staticAssetCache.timing can't be negative"))
+	}
+	if o.MaxSize.Valid && o.MaxSize.Int64 < 0 {
+		errs = append(errs, errors.New("staticAssetCache.maxSize can't be negative"))
+	}
+	return errs
+}
+
+// CachedResponse is a response to a static asset request, which is served from
+// a [StaticAssetCache].
+type CachedResponse struct {
+	StatusCode int
+	Proto      string
+	Header     http.Header
+	Body       []byte
+}
+
+// cachedAsset is a cached response, whose body is stored once per its content,
+// no matter how many URLs return it.
+type cachedAsset struct {
+	statusCode int
+	proto      string
+	header     http.Header
+	digest     [sha256.Size]byte
+}
+
+// StaticAssetCache is an in-memory cache of the responses to static asset
+// requests, which the VUs share. The bodies are content-addressed, so the same
+// asset at different URLs, e.g. with cache busting query strings, is stored
+// only once.
+type StaticAssetCache struct {
+	extensions map[string]bool
+	timing     time.Duration
+	maxSize    int64
+
+	mx     sync.RWMutex
+	assets map[string]cachedAsset
+	bodies map[[sha256.Size]byte][]byte
+	size   int64
+}
+
+// NewStaticAssetCache returns a new, empty [StaticAssetCache] with the options.
+func NewStaticAssetCache(opts StaticAssetCacheOptions) *StaticAssetCache {
+	extensions := opts.Extensions
+	if extensions == nil {
+		extensions = DefaultStaticAssetExtensions
+	}
+	c := &StaticAssetCache{
+		extensions: make(map[string]bool, len(extensions)),
+		timing:     opts.Timing.TimeDuration(),
+		maxSize:    defaultStaticAssetCacheMaxSize,
+		assets:     make(map[string]cachedAsset),
+		bodies:     make(map[[sha256.Size]byte][]byte),
+	}
+	for _, ext := range extensions {
+		c.extensions[strings.ToLower(ext)] = true
+	}
+	if opts.MaxSize.Valid {
+		c.maxSize = opts.MaxSize.Int64
+	}
+	return c
+}
+
+// Timing returns the synthetic duration of the cached responses.
+func (c *StaticAssetCache) Timing() time.Duration {
+	return c.timing
+}
+
+// Cacheable returns whether the request is for a static asset, i.e. a GET
+// request without a body or a range for a path with one of the extensions.
+func (c *StaticAssetCache) Cacheable(req *http.Request) bool {
+	if req.Method != http.MethodGet || req.ContentLength > 0 || req.Header.Get("Range") != "" {
+		return false
+	}
+	return c.extensions[strings.ToLower(path.Ext(req.URL.Path))]
+}
+
+// Get returns the cached response to the request, if there is one.
+func (c *StaticAssetCache) Get(req *http.Request) (*CachedResponse, bool) {
+	c.mx.RLock()
+	defer c.mx.RUnlock()
+	asset, ok := c.assets[req.URL.String()]
+	if !ok {
+		return nil, false
+	}
+	return &CachedResponse{
+		StatusCode: asset.statusCode,
+		Proto:      asset.proto,
+		Header:     asset.header.Clone(),
+		Body:       c.bodies[asset.digest],
+	}, true
+}
+
+// Put caches the response to the request, if it's a successful one, which
+// doesn't forbid it, and the cache isn't full.
+func (c *StaticAssetCache) Put(req *http.Request, res *CachedResponse) {
+	if res.StatusCode != http.StatusOK || strings.Contains(res.Header.Get("Cache-Control"), "no-store") {
+		return
+	}
+	digest := sha256.Sum256(res.Body)
+
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	if _, ok := c.assets[req.URL.String()]; ok {
+		return // another VU cached it first
+	}
+	if _, ok := c.bodies[digest]; !ok {
+		if c.size+int64(len(res.Body)) > c.maxSize {
+			return
+		}
+		c.bodies[digest] = res.Body
+		c.size += int64(len(res.Body))
+	}
+	c.assets[req.URL.String()] = cachedAsset{
+		statusCode: res.StatusCode,
+		proto:      res.Proto,
+		header:     res.Header.Clone(),
+		digest:     digest,
+	}
+}
@@ -0,0 +1,78 @@
+package lib
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
+)
+
+func TestStaticAssetCacheCacheable(t *testing.T) {
+	t.Parallel()
+
+	cache := NewStaticAssetCache(StaticAssetCacheOptions{})
+	assert.True(t, cache.Cacheable(httptest.NewRequest(http.MethodGet, "https://example.com/app.CSS?v=2", nil)))
+	assert.True(t, cache.Cacheable(httptest.NewRequest(http.MethodGet, "https://example.com/img/logo.png", nil)))
+	assert.False(t, cache.Cacheable(httptest.NewRequest(http.MethodGet, "https://example.com/api/users", nil)))
+	assert.False(t, cache.Cacheable(httptest.NewRequest(http.MethodPost, "https://example.com/app.css", nil)))
+
+	req := httptest.NewRequest(http.MethodGet, "https://example.com/app.css", nil)
+	req.Header.Set("Range", "bytes=0-10")
+	assert.False(t, cache.Cacheable(req))
+
+	cache = NewStaticAssetCache(StaticAssetCacheOptions{Extensions: []string{".bin"}})
+	assert.True(t, cache.Cacheable(httptest.NewRequest(http.MethodGet, "https://example.com/blob.bin", nil)))
+	assert.False(t, cache.Cacheable(httptest.NewRequest(http.MethodGet, "https://example.com/app.css", nil)))
+}
+
+func TestStaticAssetCache(t *testing.T) {
+	t.Parallel()
+
+	cache := NewStaticAssetCache(StaticAssetCacheOptions{MaxSize: null.IntFrom(10)})
+	get := func(url string) *http.Request { return httptest.NewRequest(http.MethodGet, url, nil) }
+	put := func(url string, status int, body string, header http.Header) {
+		cache.Put(get(url), &CachedResponse{StatusCode: status, Proto: "HTTP/1.1", Header: header, Body: []byte(body)})
+	}
+
+	put("https://example.com/a.css", http.StatusOK, "12345678", http.Header{"Content-Type": {"text/css"}})
+	res, ok := cache.Get(get("https://example.com/a.css"))
+	require.True(t, ok)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "text/css", res.Header.Get("Content-Type"))
+	assert.Equal(t, "12345678", string(res.Body))
+
+	// the same content at another URL is stored only once, so it fits
+	put("https://example.com/a.css?v=2", http.StatusOK, "12345678", nil)
+	_, ok = cache.Get(get("https://example.com/a.css?v=2"))
+	assert.True(t, ok)
+	assert.Len(t, cache.bodies, 1)
+
+	// different content doesn't fit anymore
+	put("https://example.com/b.css", http.StatusOK, "abc", nil)
+	_, ok = cache.Get(get("https://example.com/b.css"))
+	assert.False(t, ok)
+
+	put("https://example.com/c.css", http.StatusNotFound, "", nil)
+	_, ok = cache.Get(get("https://example.com/c.css"))
+	assert.False(t, ok)
+
+	put("https://example.com/d.css", http.StatusOK, "", http.Header{"Cache-Control": {"no-store"}})
+	_, ok = cache.Get(get("https://example.com/d.css"))
+	assert.False(t, ok)
+}
+
+func TestStaticAssetCacheOptionsValidate(t *testing.T) {
+	t.Parallel()
+
+	opts := Options{StaticAssetCache: &StaticAssetCacheOptions{
+		Extensions: []string{"css"},
+		MaxSize:    null.IntFrom(-1),
+	}}
+	errs := opts.Validate()
+	require.Len(t, errs, 2)
+	assert.ErrorContains(t, errs[0], `staticAssetCache.extensions must be like ".css", but one was "css"`)
+	assert.ErrorContains(t, errs[1], "staticAssetCache.maxSize can't be negative")
+}
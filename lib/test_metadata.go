@@ -0,0 +1,72 @@
+package lib
+
+import (
+	"maps"
+
+	"gopkg.in/guregu/null.v3"
+)
+
+// The tags of the metrics with the metadata of the test.
+const (
+	TestNameTag        = "test_name"
+	TestVersionTag     = "test_version"
+	TestEnvironmentTag = "environment"
+	TestGitSHATag      = "git_sha"
+)
+
+// TestMetadata identifies the test and the run, so the results of the runs of
+// the same test can be told apart and compared by all outputs.
+type TestMetadata struct {
+	// The name of the test, like the name of the user flow it tests
+	Name null.String `json:"name"`
+
+	// The version of the test or of the system under test
+	Version null.String `json:"version"`
+
+	// The environment the test runs against, like staging or production
+	Environment null.String `json:"environment"`
+
+	// The git commit of the script or of the system under test
+	GitSHA null.String `json:"gitSHA" split_words:"true"`
+}
+
+// Apply overwrites the fields of the metadata with the valid ones of the other.
+func (m TestMetadata) Apply(other TestMetadata) TestMetadata {
+	if other.Name.Valid {
+		m.Name = other.Name
+	}
+	if other.Version.Valid {
+		m.Version = other.Version
+	}
+	if other.Environment.Valid {
+		m.Environment = other.Environment
+	}
+	if other.GitSHA.Valid {
+		m.GitSHA = other.GitSHA
+	}
+	return m
+}
+
+// RunTagsWithMetadata returns the tags applied to all samples for the run,
+// where the explicitly set ones take precedence over the ones of the metadata.
+func (o Options) RunTagsWithMetadata() map[string]string {
+	tags := o.Metadata.Tags()
+	maps.Copy(tags, o.RunTags)
+	return tags
+}
+
+// Tags returns the non-empty fields of the metadata as the tags of the metrics.
+func (m TestMetadata) Tags() map[string]string {
+	tags := make(map[string]string, 4)
+	for name, value := range map[string]null.String{
+		TestNameTag:        m.Name,
+		TestVersionTag:     m.Version,
+		TestEnvironmentTag: m.Environment,
+		TestGitSHATag:      m.GitSHA,
+	} {
+		if value.String != "" {
+			tags[name] = value.String
+		}
+	}
+	return tags
+}
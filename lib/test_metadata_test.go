@@ -0,0 +1,81 @@
+package lib
+
+import (
+	"testing"
+
+	"github.com/mstoykov/envconfig"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
+)
+
+func TestTestMetadataApply(t *testing.T) {
+	t.Parallel()
+
+	m := TestMetadata{Name: null.StringFrom("checkout"), Version: null.StringFrom("1.0")}.Apply(TestMetadata{
+		Version:     null.StringFrom("1.1"),
+		Environment: null.StringFrom("staging"),
+	})
+	assert.Equal(t, TestMetadata{
+		Name:        null.StringFrom("checkout"),
+		Version:     null.StringFrom("1.1"),
+		Environment: null.StringFrom("staging"),
+	}, m)
+}
+
+func TestTestMetadataTags(t *testing.T) {
+	t.Parallel()
+
+	assert.Empty(t, TestMetadata{}.Tags())
+	assert.Equal(t, map[string]string{
+		"test_name":   "checkout",
+		"environment": "staging",
+		"git_sha":     "4f5e283",
+	}, TestMetadata{
+		Name:        null.StringFrom("checkout"),
+		Version:     null.NewString("", true),
+		Environment: null.StringFrom("staging"),
+		GitSHA:      null.StringFrom("4f5e283"),
+	}.Tags())
+}
+
+func TestTestMetadataEnv(t *testing.T) {
+	t.Parallel()
+
+	env := map[string]string{
+		"K6_TEST_NAME":        "checkout",
+		"K6_TEST_VERSION":     "1.1",
+		"K6_TEST_ENVIRONMENT": "staging",
+		"K6_TEST_GIT_SHA":     "4f5e283",
+		// without the prefix, they aren't the metadata
+		"NAME":    "other",
+		"GIT_SHA": "other",
+	}
+	var opts Options
+	require.NoError(t, envconfig.Process("", &opts, func(k string) (string, bool) {
+		v, ok := env[k]
+		return v, ok
+	}))
+	assert.Equal(t, TestMetadata{
+		Name:        null.StringFrom("checkout"),
+		Version:     null.StringFrom("1.1"),
+		Environment: null.StringFrom("staging"),
+		GitSHA:      null.StringFrom("4f5e283"),
+	}, opts.Metadata)
+}
+
+func TestOptionsRunTagsWithMetadata(t *testing.T) {
+	t.Parallel()
+
+	opts := Options{
+		RunTags: map[string]string{"environment": "prod", "team": "shop"},
+	}.Apply(Options{Metadata: TestMetadata{
+		Name:        null.StringFrom("checkout"),
+		Environment: null.StringFrom("staging"),
+	}})
+	assert.Equal(t, map[string]string{
+		"test_name":   "checkout",
+		"environment": "prod",
+		"team":        "shop",
+	}, opts.RunTagsWithMetadata())
+}
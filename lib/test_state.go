@@ -37,6 +37,9 @@ type TestRunState struct {
 
 	GroupSummary *GroupSummary // TODO(@mstoykov): move and rename
 
+	// Artifacts is the directory of the files the script writes
+	Artifacts *Artifacts
+
 	// TODO: add other properties that are computed or derived after init, e.g.
 	// thresholds?
 }
@@ -101,6 +104,7 @@ func (gs *GroupSummary) handleSample(sample metrics.Sample) error {
 		}
 
 		if sample.Metric.Name != "checks" {
+			group.AddDuration(sample.Value)
 			return nil
 		}
 
@@ -112,6 +116,9 @@ func (gs *GroupSummary) handleSample(sample metrics.Sample) error {
 		if err != nil {
 			return err
 		}
+		if check.Severity == "" {
+			check.Severity, _ = sample.Tags.Get(CheckSeverityTag)
+		}
 		if sample.Value == 0 {
 			atomic.AddInt64(&check.Fails, 1)
 		} else {
@@ -0,0 +1,103 @@
+package lib
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"go.k6.io/k6/lib/types"
+)
+
+// Timeline is the achieved execution timeline of a test run: when the
+// iterations of each scenario actually started, and how many VUs were running
+// them at most. It's exported with --export-timeline and replayed in a later
+// test run with --replay-timeline, so the comparisons of the runs aren't
+// confounded by the differences of their scheduling.
+type Timeline struct {
+	mu        sync.Mutex
+	Scenarios map[string]*ScenarioTimeline `json:"scenarios"`
+}
+
+// ScenarioTimeline is the achieved execution timeline of a scenario.
+type ScenarioTimeline struct {
+	// Iterations are the offsets of the starts of the iterations from the
+	// start of the scenario, in order.
+	Iterations []types.Duration `json:"iterations"`
+	// MaxVUs is the maximum number of VUs, which ran iterations at once.
+	MaxVUs int64 `json:"maxVUs"`
+
+	running int64
+}
+
+// NewTimeline returns a new [Timeline] for recording the given scenarios, so
+// the ones without any iterations are in it too.
+func NewTimeline(scenarios ...string) *Timeline {
+	t := &Timeline{Scenarios: make(map[string]*ScenarioTimeline, len(scenarios))}
+	for _, name := range scenarios {
+		t.Scenarios[name] = &ScenarioTimeline{Iterations: []types.Duration{}}
+	}
+	return t
+}
+
+// IterationStarted records the start of an iteration of the given scenario at
+// the given offset from the start of the scenario. It returns a function, which
+// records the end of the iteration.
+func (t *Timeline) IterationStarted(scenario string, offset time.Duration) (ended func()) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	st, ok := t.Scenarios[scenario]
+	if !ok {
+		st = &ScenarioTimeline{}
+		t.Scenarios[scenario] = st
+	}
+	st.Iterations = append(st.Iterations, types.Duration(offset))
+	st.running++
+	st.MaxVUs = max(st.MaxVUs, st.running)
+
+	return func() {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		st.running--
+	}
+}
+
+// MarshalJSON returns the JSON of the timeline, with the iterations in order,
+// since the concurrent ones may have been recorded out of it.
+func (t *Timeline) MarshalJSON() ([]byte, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, st := range t.Scenarios {
+		slices.Sort(st.Iterations)
+	}
+	type timeline Timeline
+	return json.Marshal((*timeline)(t)) //nolint:govet // the mutex is locked and isn't marshaled
+}
+
+// ParseTimeline parses and validates an exported timeline.
+func ParseTimeline(data []byte) (*Timeline, error) {
+	t := &Timeline{}
+	if err := json.Unmarshal(data, t); err != nil {
+		return nil, fmt.Errorf("couldn't parse the timeline: %w", err)
+	}
+	if len(t.Scenarios) == 0 {
+		return nil, fmt.Errorf("the timeline doesn't have any scenarios")
+	}
+	for name, st := range t.Scenarios {
+		if st == nil {
+			return nil, fmt.Errorf("the timeline of the scenario %s is empty", name)
+		}
+		for i, offset := range st.Iterations {
+			if offset < 0 || (i > 0 && offset < st.Iterations[i-1]) {
+				return nil, fmt.Errorf("the iterations of the scenario %s must be in order and not negative", name)
+			}
+		}
+		if st.MaxVUs < 0 || (st.MaxVUs == 0 && len(st.Iterations) > 0) {
+			return nil, fmt.Errorf("the maxVUs of the scenario %s must be positive", name)
+		}
+	}
+	return t, nil
+}
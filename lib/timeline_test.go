@@ -0,0 +1,48 @@
+package lib
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimeline(t *testing.T) {
+	t.Parallel()
+
+	timeline := NewTimeline("a", "idle")
+	endFirst := timeline.IterationStarted("a", 200*time.Millisecond)
+	endSecond := timeline.IterationStarted("a", 100*time.Millisecond)
+	endFirst()
+	endSecond()
+	timeline.IterationStarted("a", time.Second)()
+
+	data, err := json.Marshal(timeline)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"scenarios":{
+		"a":{"iterations":["100ms","200ms","1s"],"maxVUs":2},
+		"idle":{"iterations":[],"maxVUs":0}
+	}}`, string(data))
+
+	parsed, err := ParseTimeline(data)
+	require.NoError(t, err)
+	assert.Equal(t, timeline.Scenarios, parsed.Scenarios)
+}
+
+func TestParseTimelineErrors(t *testing.T) {
+	t.Parallel()
+
+	for data, expected := range map[string]string{
+		`[]`:                       "couldn't parse the timeline",
+		`{"scenarios":{}}`:         "the timeline doesn't have any scenarios",
+		`{"scenarios":{"a":null}}`: "the timeline of the scenario a is empty",
+		`{"scenarios":{"a":{"iterations":["1s","0s"],"maxVUs":1}}}`: "the iterations of the scenario a must be in order",
+		`{"scenarios":{"a":{"iterations":["-1s"],"maxVUs":1}}}`:     "the iterations of the scenario a must be in order",
+		`{"scenarios":{"a":{"iterations":["1s"],"maxVUs":0}}}`:      "the maxVUs of the scenario a must be positive",
+	} {
+		_, err := ParseTimeline([]byte(data))
+		require.ErrorContains(t, err, expected, data)
+	}
+}
@@ -88,3 +88,37 @@ var SupportedTLSCipherSuitesToString = map[uint16]string{
 	tls.TLS_AES_256_GCM_SHA384:                  "TLS_AES_256_GCM_SHA384",
 	tls.TLS_CHACHA20_POLY1305_SHA256:            "TLS_CHACHA20_POLY1305_SHA256",
 }
+
+// tlsCurveX25519MLKEM768 is the hybrid post-quantum key exchange, which is
+// only supported by Go 1.24 and newer and isn't exported before it.
+const tlsCurveX25519MLKEM768 tls.CurveID = 4588
+
+// SupportedTLSCurves is string-to-constant map of available TLS key exchange
+// groups, which were called curves before TLS 1.3.
+//
+//nolint:gochecknoglobals
+var SupportedTLSCurves = map[string]tls.CurveID{
+	"X25519":         tls.X25519,
+	"P-256":          tls.CurveP256,
+	"P-384":          tls.CurveP384,
+	"P-521":          tls.CurveP521,
+	"X25519MLKEM768": tlsCurveX25519MLKEM768,
+}
+
+// SupportedTLSClientHellos are the browsers whose TLS ClientHello can be sent
+// with the tlsClientHello option.
+//
+//nolint:gochecknoglobals
+var SupportedTLSClientHellos = []string{"chrome", "edge", "firefox", "ios", "safari"}
+
+// SupportedTLSCurvesToString is constant-to-string map of available TLS key
+// exchange groups.
+//
+//nolint:gochecknoglobals
+var SupportedTLSCurvesToString = map[tls.CurveID]string{
+	tls.X25519:             "X25519",
+	tls.CurveP256:          "P-256",
+	tls.CurveP384:          "P-384",
+	tls.CurveP521:          "P-521",
+	tlsCurveX25519MLKEM768: "X25519MLKEM768",
+}
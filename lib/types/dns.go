@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"net"
 	"strings"
 
 	"gopkg.in/guregu/null.v3"
@@ -17,6 +18,13 @@ type DNSConfig struct {
 	Select NullDNSSelect `json:"select"`
 	// Policy specifies how to handle returning of IPv4 or IPv6 addresses.
 	Policy NullDNSPolicy `json:"policy"`
+	// Servers are the upstream DNS servers to use instead of the system resolver.
+	Servers []DNSServer `json:"servers"`
+	// If positive, defines how long failed DNS lookups should be returned from the cache.
+	NegativeTTL null.String `json:"negativeTTL"`
+	// If positive, defines how long after the TTL expired cached IPs can still
+	// be used, when looking them up again fails.
+	MaxStale null.String `json:"maxStale"`
 	// FIXME: Valid is unused and is only added to satisfy some logic in
 	// lib.Options.ForEachSpecified(), otherwise it would panic with
 	// `reflect: call of reflect.Value.Bool on zero Value`.
@@ -169,16 +177,33 @@ func (d NullDNSSelect) MarshalJSON() ([]byte, error) {
 
 // String implements fmt.Stringer.
 func (c DNSConfig) String() string {
-	return fmt.Sprintf("ttl=%s,select=%s,policy=%s",
+	s := fmt.Sprintf("ttl=%s,select=%s,policy=%s",
 		c.TTL.String, c.Select.String(), c.Policy.String())
+	if c.NegativeTTL.Valid {
+		s += ",negativeTTL=" + c.NegativeTTL.String
+	}
+	if c.MaxStale.Valid {
+		s += ",maxStale=" + c.MaxStale.String
+	}
+	if len(c.Servers) > 0 {
+		servers := make([]string, len(c.Servers))
+		for i, server := range c.Servers {
+			servers[i] = server.String()
+		}
+		s += ",servers=" + strings.Join(servers, ";")
+	}
+	return s
 }
 
 // UnmarshalJSON implements json.Unmarshaler.
 func (c *DNSConfig) UnmarshalJSON(data []byte) error {
 	var s struct {
-		TTL    null.String   `json:"ttl"`
-		Select NullDNSSelect `json:"select"`
-		Policy NullDNSPolicy `json:"policy"`
+		TTL         null.String   `json:"ttl"`
+		Select      NullDNSSelect `json:"select"`
+		Policy      NullDNSPolicy `json:"policy"`
+		Servers     []DNSServer   `json:"servers"`
+		NegativeTTL null.String   `json:"negativeTTL"`
+		MaxStale    null.String   `json:"maxStale"`
 	}
 	if err := json.Unmarshal(data, &s); err != nil {
 		return err
@@ -186,9 +211,36 @@ func (c *DNSConfig) UnmarshalJSON(data []byte) error {
 	c.TTL = s.TTL
 	c.Select = s.Select
 	c.Policy = s.Policy
+	c.Servers = s.Servers
+	c.NegativeTTL = s.NegativeTTL
+	c.MaxStale = s.MaxStale
 	return nil
 }
 
+// Apply returns a copy of the config, with the values that are set in the
+// given config overwriting the current ones.
+func (c DNSConfig) Apply(cfg DNSConfig) DNSConfig {
+	if cfg.TTL.Valid {
+		c.TTL = cfg.TTL
+	}
+	if cfg.Select.Valid {
+		c.Select = cfg.Select
+	}
+	if cfg.Policy.Valid {
+		c.Policy = cfg.Policy
+	}
+	if cfg.Servers != nil {
+		c.Servers = cfg.Servers
+	}
+	if cfg.NegativeTTL.Valid {
+		c.NegativeTTL = cfg.NegativeTTL
+	}
+	if cfg.MaxStale.Valid {
+		c.MaxStale = cfg.MaxStale
+	}
+	return c
+}
+
 // UnmarshalText implements encoding.TextUnmarshaler.
 func (c *DNSConfig) UnmarshalText(text []byte) error {
 	if string(text) == DefaultDNSConfig().String() {
@@ -226,9 +278,88 @@ func (c *DNSConfig) unmarshal(params map[string]string) error {
 			c.Select.Valid = true
 		case "ttl":
 			c.TTL = null.StringFrom(v)
+		case "negativeTTL":
+			c.NegativeTTL = null.StringFrom(v)
+		case "maxStale":
+			c.MaxStale = null.StringFrom(v)
+		case "servers":
+			c.Servers = nil
+			for _, server := range strings.Split(v, ";") {
+				var s DNSServer
+				if err := s.UnmarshalText([]byte(server)); err != nil {
+					return err
+				}
+				c.Servers = append(c.Servers, s)
+			}
 		default:
 			return fmt.Errorf("unknown DNS configuration field: %s", k)
 		}
 	}
 	return nil
 }
+
+// DNSServer is an upstream DNS server and the protocol used to query it.
+type DNSServer struct {
+	// Protocol is one of "udp", "tcp", "tls" (DNS over TLS) or "https" (DNS over HTTPS).
+	Protocol string
+	// Address is the host:port of the server, or its full URL for DNS over HTTPS.
+	Address string
+}
+
+// ParseDNSServer parses a DNS server in one of the following formats:
+// "host[:port]" and "udp://host[:port]" for plain DNS over UDP,
+// "tcp://host[:port]" for plain DNS over TCP, "tls://host[:port]" for DNS over
+// TLS and "https://host[:port]/path" for DNS over HTTPS.
+func ParseDNSServer(s string) (DNSServer, error) {
+	protocol, address, found := strings.Cut(s, "://")
+	if !found {
+		protocol, address = "udp", s
+	}
+
+	var defaultPort string
+	switch protocol {
+	case "udp", "tcp":
+		defaultPort = "53"
+	case "tls":
+		defaultPort = "853"
+	case "https":
+		if address == "" {
+			return DNSServer{}, fmt.Errorf("missing host in DNS server %q", s)
+		}
+		return DNSServer{Protocol: protocol, Address: s}, nil
+	default:
+		return DNSServer{}, fmt.Errorf("unsupported protocol %q for DNS server %q, "+
+			"it should be one of udp, tcp, tls or https", protocol, s)
+	}
+
+	if address == "" {
+		return DNSServer{}, fmt.Errorf("missing host in DNS server %q", s)
+	}
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		address = net.JoinHostPort(strings.Trim(address, "[]"), defaultPort)
+	}
+	return DNSServer{Protocol: protocol, Address: address}, nil
+}
+
+// String returns the DNS server in the same format as ParseDNSServer accepts.
+func (s DNSServer) String() string {
+	if s.Protocol == "https" {
+		return s.Address
+	}
+	return s.Protocol + "://" + s.Address
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (s DNSServer) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (s *DNSServer) UnmarshalText(text []byte) error {
+	server, err := ParseDNSServer(string(text))
+	if err != nil {
+		return err
+	}
+	*s = server
+	return nil
+}
@@ -0,0 +1,102 @@
+package types
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDNSServer(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		input, protocol, address, err string
+	}{
+		{input: "10.0.0.2", protocol: "udp", address: "10.0.0.2:53"},
+		{input: "10.0.0.2:5353", protocol: "udp", address: "10.0.0.2:5353"},
+		{input: "udp://[2001:db8::1]", protocol: "udp", address: "[2001:db8::1]:53"},
+		{input: "tcp://dns.local", protocol: "tcp", address: "dns.local:53"},
+		{input: "tls://1.1.1.1", protocol: "tls", address: "1.1.1.1:853"},
+		{input: "tls://1.1.1.1:8853", protocol: "tls", address: "1.1.1.1:8853"},
+		{
+			input: "https://dns.google/dns-query", protocol: "https",
+			address: "https://dns.google/dns-query",
+		},
+		{input: "quic://1.1.1.1", err: "unsupported protocol"},
+		{input: "tcp://", err: "missing host"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			t.Parallel()
+			server, err := ParseDNSServer(tc.input)
+			if tc.err != "" {
+				require.ErrorContains(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.protocol, server.Protocol)
+			assert.Equal(t, tc.address, server.Address)
+
+			again, err := ParseDNSServer(server.String())
+			require.NoError(t, err)
+			assert.Equal(t, server, again)
+		})
+	}
+}
+
+func TestDNSConfigServers(t *testing.T) {
+	t.Parallel()
+
+	t.Run("text", func(t *testing.T) {
+		t.Parallel()
+		var c DNSConfig
+		require.NoError(t, c.UnmarshalText([]byte("ttl=1m,select=first,policy=any,servers=10.0.0.2;tls://1.1.1.1")))
+		assert.Equal(t, []DNSServer{
+			{Protocol: "udp", Address: "10.0.0.2:53"},
+			{Protocol: "tls", Address: "1.1.1.1:853"},
+		}, c.Servers)
+		assert.Equal(t, "ttl=1m,select=first,policy=any,servers=udp://10.0.0.2:53;tls://1.1.1.1:853", c.String())
+	})
+
+	t.Run("json", func(t *testing.T) {
+		t.Parallel()
+		var c DNSConfig
+		require.NoError(t, json.Unmarshal([]byte(`{"servers":["https://dns.google/dns-query"]}`), &c))
+		assert.Equal(t, []DNSServer{{Protocol: "https", Address: "https://dns.google/dns-query"}}, c.Servers)
+
+		data, err := json.Marshal(c)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{
+			"ttl":null,"select":null,"policy":null,"servers":["https://dns.google/dns-query"],
+			"negativeTTL":null,"maxStale":null
+		}`, string(data))
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		t.Parallel()
+		var c DNSConfig
+		require.Error(t, c.UnmarshalText([]byte("servers=ftp://1.1.1.1")))
+	})
+}
+
+func TestDNSConfigApply(t *testing.T) {
+	t.Parallel()
+
+	var base DNSConfig
+	require.NoError(t, base.UnmarshalText([]byte("ttl=5m,select=random,policy=preferIPv4,servers=10.0.0.2")))
+
+	var override DNSConfig
+	require.NoError(t, override.UnmarshalText([]byte("select=first,negativeTTL=10s,maxStale=1m")))
+
+	result := base.Apply(override)
+	assert.Equal(t, "5m", result.TTL.String)
+	assert.Equal(t, DNSfirst, result.Select.DNSSelect)
+	assert.Equal(t, DNSpreferIPv4, result.Policy.DNSPolicy)
+	assert.Equal(t, base.Servers, result.Servers)
+	assert.Equal(t, "10s", result.NegativeTTL.String)
+	assert.Equal(t, "1m", result.MaxStale.String)
+	assert.Equal(t, DNSrandom, base.Select.DNSSelect, "the base config shouldn't be modified")
+}
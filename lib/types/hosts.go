@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"net"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 )
@@ -38,6 +39,9 @@ func (n NullHosts) MarshalJSON() ([]byte, error) {
 	}
 
 	jsonMap := make(map[string]string)
+	for _, rw := range n.Trie.rewrites {
+		jsonMap[rw.pattern] = rw.target
+	}
 	for k, v := range n.Trie.source {
 		var right string
 		if v.Port != 0 {
@@ -65,7 +69,12 @@ func (n *NullHosts) UnmarshalJSON(data []byte) error {
 	}
 
 	source := make(map[string]Host)
+	rewrites := make(map[string]string)
 	for k, v := range jsonSource {
+		if isHostRewritePattern(k) {
+			rewrites[k] = v
+			continue
+		}
 		ip, port, err := net.SplitHostPort(v)
 		if err == nil {
 			pInt, err := strconv.Atoi(port)
@@ -79,7 +88,7 @@ func (n *NullHosts) UnmarshalJSON(data []byte) error {
 		}
 	}
 
-	hosts, err := NewHosts(source)
+	hosts, err := NewHostsWithRewrites(source, rewrites)
 	if err != nil {
 		return err
 	}
@@ -90,12 +99,32 @@ func (n *NullHosts) UnmarshalJSON(data []byte) error {
 
 // Hosts is wrapper around trieNode to integrate with net.TCPAddr
 type Hosts struct {
-	n      *trieNode
-	source map[string]Host
+	n        *trieNode
+	source   map[string]Host
+	rewrites []hostRewrite
+}
+
+// hostRewrite maps all of the hosts matching a regular expression to an
+// address, which can reference the submatches of the expression, e.g. $1.
+type hostRewrite struct {
+	pattern string // as specified, i.e. with the slashes around it
+	re      *regexp.Regexp
+	target  string
 }
 
 // NewHosts returns new Hosts from given addresses.
 func NewHosts(source map[string]Host) (*Hosts, error) {
+	return NewHostsWithRewrites(source, nil)
+}
+
+// NewHostsWithRewrites returns new Hosts from given addresses and rewrites.
+// The keys of the rewrites are regular expressions between slashes, e.g.
+// `/^tenant-(\d+)\.local$/`, which should match the whole hostname, or the whole
+// hostname:port if they contain a port. The values are IP or IP:port addresses,
+// which can reference the submatches of the expressions, e.g. `10.0.0.$1`.
+// The rewrites are only checked if the hostname doesn't match any of the normal
+// host entries, in the alphabetical order of their patterns.
+func NewHostsWithRewrites(source map[string]Host, rewrites map[string]string) (*Hosts, error) {
 	h := &Hosts{
 		source: toLowerKeys(source),
 		n: &trieNode{
@@ -110,9 +139,60 @@ func NewHosts(source map[string]Host) (*Hosts, error) {
 		}
 	}
 
+	for pattern, target := range rewrites {
+		if err := h.addRewrite(pattern, target); err != nil {
+			return nil, err
+		}
+	}
+	sort.Slice(h.rewrites, func(i, j int) bool { return h.rewrites[i].pattern < h.rewrites[j].pattern })
+
 	return h, nil
 }
 
+// isHostRewritePattern checks whether the given hosts key is a regular
+// expression for a rewrite, instead of a hostname.
+func isHostRewritePattern(s string) bool {
+	return len(s) > 2 && strings.HasPrefix(s, "/") && strings.HasSuffix(s, "/")
+}
+
+// submatchReference matches the submatch references in the rewrite targets.
+var submatchReference = regexp.MustCompile(`\$(\{\w+\}|\w+)`)
+
+func (t *Hosts) addRewrite(pattern, target string) error {
+	if !isHostRewritePattern(pattern) {
+		return fmt.Errorf("invalid host rewrite pattern '%s', it should be a regular expression between slashes", pattern)
+	}
+	re, err := regexp.Compile("(?i)^(?:" + pattern[1:len(pattern)-1] + ")$")
+	if err != nil {
+		return fmt.Errorf("invalid host rewrite pattern '%s': %w", pattern, err)
+	}
+
+	// the actual submatches aren't known in advance, so the target is
+	// validated with a placeholder value for all of them
+	if _, err = parseHostAddress(submatchReference.ReplaceAllString(target, "1")); err != nil {
+		return fmt.Errorf("invalid target '%s' for host rewrite pattern '%s': %w", target, pattern, err)
+	}
+
+	t.rewrites = append(t.rewrites, hostRewrite{pattern: pattern, re: re, target: target})
+	return nil
+}
+
+// parseHostAddress parses an IP or IP:port address.
+func parseHostAddress(s string) (*Host, error) {
+	if host, port, err := net.SplitHostPort(s); err == nil {
+		ip := net.ParseIP(host)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid IP '%s'", host)
+		}
+		return NewHost(ip, port)
+	}
+	ip := net.ParseIP(s)
+	if ip == nil {
+		return nil, fmt.Errorf("invalid IP '%s'", s)
+	}
+	return &Host{IP: ip}, nil
+}
+
 func toLowerKeys(source map[string]Host) map[string]Host {
 	result := make(map[string]Host, len(source))
 	for k, v := range source {
@@ -156,10 +236,24 @@ func (t *Hosts) Match(s string) *Host {
 	match, ok := t.n.contains(s)
 
 	if !ok {
-		return nil
+		return t.matchRewrite(s)
 	}
 
 	address := t.source[match]
 
 	return &address
 }
+
+func (t *Hosts) matchRewrite(s string) *Host {
+	for _, rw := range t.rewrites {
+		submatches := rw.re.FindStringSubmatchIndex(s)
+		if submatches == nil {
+			continue
+		}
+		target := rw.re.ExpandString(nil, rw.target, s, submatches)
+		if host, err := parseHostAddress(string(target)); err == nil {
+			return host
+		}
+	}
+	return nil
+}
@@ -192,6 +192,52 @@ func runTcs(t *testing.T, at *Hosts, tcs []HostTestCase) {
 	}
 }
 
+func TestHostsRewrites(t *testing.T) {
+	t.Parallel()
+
+	hosts, err := NewHostsWithRewrites(map[string]Host{
+		"exact.tenant-7.local": {IP: net.ParseIP("1.2.3.4")},
+	}, map[string]string{
+		`/tenant-(\d+)\.local/`:         "10.0.0.$1",
+		`/.+\.tenant-(\d+)\.local/`:     "10.1.0.$1",
+		`/(\w+)\.svc:8080/`:             "10.2.0.5:9090",
+		`/v6-(?P<id>[0-9a-f]+)\.local/`: "[aa::${id}]:8443",
+	})
+	require.NoError(t, err)
+
+	runTcs(t, hosts, []HostTestCase{
+		{"tenant-5.local", "10.0.0.5:0", "rewrite with a submatch"},
+		{"TENANT-6.local", "10.0.0.6:0", "case insensitive rewrite"},
+		{"api.tenant-7.local", "10.1.0.7:0", "rewrite of a subdomain"},
+		{"exact.tenant-7.local", "1.2.3.4:0", "exact match before rewrites"},
+		{"tenant-5.local.com", "", "the whole hostname should match"},
+		{"tenant-999.local", "", "invalid rewritten address"},
+		{"users.svc:8080", "10.2.0.5:9090", "rewrite with a port"},
+		{"users.svc:9090", "", "different port"},
+		{"v6-bb.local", "[aa::bb]:8443", "named submatch"},
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		t.Parallel()
+		_, err := NewHostsWithRewrites(nil, map[string]string{`/tenant-(\d+/`: "10.0.0.$1"})
+		require.ErrorContains(t, err, "invalid host rewrite pattern")
+		_, err = NewHostsWithRewrites(nil, map[string]string{`/tenant-(\d+)/`: "host-$1"})
+		require.ErrorContains(t, err, "invalid target")
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		t.Parallel()
+		data := `{"/tenant-(\\d+)\\.local/":"10.0.0.$1","simple.io":"1.2.3.4"}`
+		var nh NullHosts
+		require.NoError(t, json.Unmarshal([]byte(data), &nh))
+		require.Equal(t, "10.0.0.3:0", nh.Trie.Match("tenant-3.local").String())
+
+		marshaled, err := json.Marshal(nh)
+		require.NoError(t, err)
+		assert.JSONEq(t, data, string(marshaled))
+	})
+}
+
 func TestHostsJSON(t *testing.T) {
 	t.Parallel()
 
@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"net/http/cookiejar"
 	"sync"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"go.opentelemetry.io/otel/trace"
@@ -52,6 +53,38 @@ type State struct {
 	CookieJar *cookiejar.Jar
 	TLSConfig *tls.Config
 
+	// TransportFor returns the transport for the requests which select their
+	// own tlsAuth certificate, proxy or hosts.
+	TransportFor func(TransportOptions) (http.RoundTripper, error)
+
+	// TLSRevocationCheck returns how long the verification of the stapled
+	// OCSP response of a new connection took, if the ocspStapling option is set.
+	TLSRevocationCheck func(*tls.ConnectionState) (time.Duration, bool)
+
+	// HTTPProfile is the browser profile, whose headers the HTTP requests send
+	// in the current scenario, if any. It's set on VU activation.
+	HTTPProfile string
+
+	// FlightRecorder keeps the last HTTP requests and responses of the VU, if
+	// the httpFlightRecorder option is set.
+	FlightRecorder *FlightRecorder
+
+	// StaticAssetCache is the cache of the static assets, which all the VUs
+	// share, if the staticAssetCache option is set.
+	StaticAssetCache *StaticAssetCache
+
+	// AuthSessions add the access tokens to the requests to their hosts, if
+	// the authSessions option is set.
+	AuthSessions *AuthSessions
+
+	// ClusterStore keeps the counters and the rate limiters, which the VUs of
+	// all the instances of the test share.
+	ClusterStore ClusterStore
+
+	// Clock is what Date.now() and the timers of the VU use, which is
+	// virtualized by the clock option.
+	Clock *Clock
+
 	// Rate limits.
 	RPSLimit *rate.Limiter
 
@@ -125,3 +158,15 @@ func (tg *VUStateTags) Modify(callback func(tagsAndMeta *metrics.TagsAndMeta)) {
 	defer tg.mutex.Unlock()
 	callback(tg.tagsAndMeta)
 }
+
+// TransportOptions are the options of the requests which need a transport
+// other than the VU's one.
+type TransportOptions struct {
+	// TLSAuth is the name of the tlsAuth certificate to present.
+	TLSAuth string
+	// Proxy is the URL of the SOCKS5 proxy to connect through.
+	Proxy string
+	// Hosts is the JSON of the hosts, which map the hostnames to the
+	// addresses to connect to, instead of the ones of the hosts option.
+	Hosts string
+}
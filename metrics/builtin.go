@@ -8,10 +8,13 @@ const (
 	IterationsName        = "iterations"
 	IterationDurationName = "iteration_duration"
 	DroppedIterationsName = "dropped_iterations"
+	IterationTimeoutName  = "iteration_timeout"
 
 	ChecksName        = "checks"
 	GroupDurationName = "group_duration"
 
+	AnnotationsName = "annotations"
+
 	HTTPReqsName              = "http_reqs"
 	HTTPReqFailedName         = "http_req_failed"
 	HTTPReqDurationName       = "http_req_duration"
@@ -21,6 +24,13 @@ const (
 	HTTPReqSendingName        = "http_req_sending"
 	HTTPReqWaitingName        = "http_req_waiting"
 	HTTPReqReceivingName      = "http_req_receiving"
+	HTTPReqEarlyHintsName     = "http_req_early_hints"
+	HTTPReqDecompressingName  = "http_req_decompressing"
+	HTTPReqConnWaitingName    = "http_req_conn_waiting"
+	HTTPConnsActiveName       = "http_conns_active"
+	HTTPConnsIdleName         = "http_conns_idle"
+	HTTPOperationDurationName = "http_operation_duration"
+	IntegrityFailuresName     = "integrity_failures"
 
 	WSSessionsName         = "ws_sessions"
 	WSMessagesSentName     = "ws_msgs_sent"
@@ -28,11 +38,39 @@ const (
 	WSPingName             = "ws_ping"
 	WSSessionDurationName  = "ws_session_duration"
 	WSConnectingName       = "ws_connecting"
+	WSRequestDurationName  = "ws_request_duration"
+
+	WSReconnectsName         = "ws_reconnects"
+	WSConnectionsResumedName = "ws_connections_resumed"
+	WSReconnectGapName       = "ws_reconnect_gap"
+
+	WSMessageBytesSentName               = "ws_msg_bytes_sent"
+	WSMessageCompressedBytesSentName     = "ws_msg_compressed_bytes_sent"
+	WSMessageBytesReceivedName           = "ws_msg_bytes_received"
+	WSMessageCompressedBytesReceivedName = "ws_msg_compressed_bytes_received"
 
 	GRPCReqDurationName = "grpc_req_duration"
 
-	DataSentName     = "data_sent"
-	DataReceivedName = "data_received"
+	GRPCMessageBytesSentName               = "grpc_msg_bytes_sent"
+	GRPCMessageCompressedBytesSentName     = "grpc_msg_compressed_bytes_sent"
+	GRPCMessageBytesReceivedName           = "grpc_msg_bytes_received"
+	GRPCMessageCompressedBytesReceivedName = "grpc_msg_compressed_bytes_received"
+
+	DataSentName            = "data_sent"
+	DataReceivedName        = "data_received"
+	DNSLookupDurationName   = "dns_lookup_duration"
+	BlockedRequestsName     = "blocked_requests"
+	TLSHandshakeFullName    = "tls_handshake_full"
+	TLSHandshakeResumedName = "tls_handshake_resumed"
+
+	TLSRevocationCheckDurationName = "tls_revocation_check_duration"
+
+	ResourceCPUName            = "resource_cpu"
+	ResourceMemoryName         = "resource_memory"
+	ResourceOpenFilesName      = "resource_open_files"
+	ResourceBudgetExceededName = "resource_budget_exceeded"
+
+	VUHeapBytesName = "vu_heap_bytes"
 )
 
 // BuiltinMetrics represent all the builtin metrics of k6
@@ -42,11 +80,16 @@ type BuiltinMetrics struct {
 	Iterations        *Metric
 	IterationDuration *Metric
 	DroppedIterations *Metric
+	IterationTimeout  *Metric
 
 	// Runner-emitted.
 	Checks        *Metric
 	GroupDuration *Metric
 
+	// The events, like deploys, which external tooling marked through the
+	// REST API, so they can be correlated with the other metrics.
+	Annotations *Metric
+
 	// HTTP-related.
 	HTTPReqs              *Metric
 	HTTPReqFailed         *Metric
@@ -57,6 +100,25 @@ type BuiltinMetrics struct {
 	HTTPReqSending        *Metric
 	HTTPReqWaiting        *Metric
 	HTTPReqReceiving      *Metric
+	// The time from sending the requests to receiving their 103 Early Hints
+	// responses, if the servers sent any.
+	HTTPReqEarlyHints *Metric
+	// The part of receiving the compressed responses, which was spent
+	// decompressing their bodies, instead of reading them from the network.
+	HTTPReqDecompressing *Metric
+	// The part of http_req_blocked, which the requests waited for a free
+	// connection of the pool, without connecting.
+	HTTPReqConnWaiting *Metric
+	// The numbers of the active and the idle connections of all VUs to the
+	// hosts of the requests, when they were done.
+	HTTPConnsActive *Metric
+	HTTPConnsIdle   *Metric
+	// The whole duration of the logical operations, like polling, made of
+	// multiple requests.
+	HTTPOperationDuration *Metric
+	// The responses, whose bodies didn't match the digest or the ETag of the
+	// integrity param of their requests.
+	IntegrityFailures *Metric
 
 	// Websocket-related
 	WSSessions         *Metric
@@ -65,13 +127,52 @@ type BuiltinMetrics struct {
 	WSPing             *Metric
 	WSSessionDuration  *Metric
 	WSConnecting       *Metric
+	// The time from sending a message until its matching reply, see the
+	// request method of the experimental WebSocket.
+	WSRequestDuration *Metric
+	// The attempts to reconnect the experimental WebSockets, the connections
+	// which were reconnected after they were lost, and how long they were
+	// disconnected.
+	WSReconnects         *Metric
+	WSConnectionsResumed *Metric
+	WSReconnectGap       *Metric
+	// The sizes of the messages of the experimental WebSockets with the
+	// permessage-deflate compression, and of their frames on the wire.
+	WSMessageBytesSent               *Metric
+	WSMessageCompressedBytesSent     *Metric
+	WSMessageBytesReceived           *Metric
+	WSMessageCompressedBytesReceived *Metric
 
 	// gRPC-related
 	GRPCReqDuration *Metric
+	// The sizes of the messages of the gRPC calls with compression, before
+	// and after they're compressed.
+	GRPCMessageBytesSent               *Metric
+	GRPCMessageCompressedBytesSent     *Metric
+	GRPCMessageBytesReceived           *Metric
+	GRPCMessageCompressedBytesReceived *Metric
 
 	// Network-related; used for future protocols as well.
-	DataSent     *Metric
-	DataReceived *Metric
+	DataSent          *Metric
+	DataReceived      *Metric
+	DNSLookupDuration *Metric
+	// The connections, which weren't made because their hosts were blocked
+	// by the blacklistIPs or the blockHostnames options.
+	BlockedRequests     *Metric
+	TLSHandshakeFull    *Metric
+	TLSHandshakeResumed *Metric
+
+	TLSRevocationCheckDuration *Metric
+
+	// Resource-related, emitted only when a scenario has a resource budget.
+	ResourceCPU            *Metric
+	ResourceMemory         *Metric
+	ResourceOpenFiles      *Metric
+	ResourceBudgetExceeded *Metric
+
+	// VUHeapBytes is the VU's share of the live heap, emitted only with the
+	// vuMemoryLimit option.
+	VUHeapBytes *Metric
 }
 
 // RegisterBuiltinMetrics register and returns the builtin metrics in the provided registry
@@ -82,10 +183,13 @@ func RegisterBuiltinMetrics(registry *Registry) *BuiltinMetrics {
 		Iterations:        registry.MustNewMetric(IterationsName, Counter),
 		IterationDuration: registry.MustNewMetric(IterationDurationName, Trend, Time),
 		DroppedIterations: registry.MustNewMetric(DroppedIterationsName, Counter),
+		IterationTimeout:  registry.MustNewMetric(IterationTimeoutName, Counter),
 
 		Checks:        registry.MustNewMetric(ChecksName, Rate),
 		GroupDuration: registry.MustNewMetric(GroupDurationName, Trend, Time),
 
+		Annotations: registry.MustNewMetric(AnnotationsName, Counter),
+
 		HTTPReqs:              registry.MustNewMetric(HTTPReqsName, Counter),
 		HTTPReqFailed:         registry.MustNewMetric(HTTPReqFailedName, Rate),
 		HTTPReqDuration:       registry.MustNewMetric(HTTPReqDurationName, Trend, Time),
@@ -95,6 +199,13 @@ func RegisterBuiltinMetrics(registry *Registry) *BuiltinMetrics {
 		HTTPReqSending:        registry.MustNewMetric(HTTPReqSendingName, Trend, Time),
 		HTTPReqWaiting:        registry.MustNewMetric(HTTPReqWaitingName, Trend, Time),
 		HTTPReqReceiving:      registry.MustNewMetric(HTTPReqReceivingName, Trend, Time),
+		HTTPReqEarlyHints:     registry.MustNewMetric(HTTPReqEarlyHintsName, Trend, Time),
+		HTTPReqDecompressing:  registry.MustNewMetric(HTTPReqDecompressingName, Trend, Time),
+		HTTPReqConnWaiting:    registry.MustNewMetric(HTTPReqConnWaitingName, Trend, Time),
+		HTTPConnsActive:       registry.MustNewMetric(HTTPConnsActiveName, Gauge),
+		HTTPConnsIdle:         registry.MustNewMetric(HTTPConnsIdleName, Gauge),
+		HTTPOperationDuration: registry.MustNewMetric(HTTPOperationDurationName, Trend, Time),
+		IntegrityFailures:     registry.MustNewMetric(IntegrityFailuresName, Counter),
 
 		WSSessions:         registry.MustNewMetric(WSSessionsName, Counter),
 		WSMessagesSent:     registry.MustNewMetric(WSMessagesSentName, Counter),
@@ -102,10 +213,38 @@ func RegisterBuiltinMetrics(registry *Registry) *BuiltinMetrics {
 		WSPing:             registry.MustNewMetric(WSPingName, Trend, Time),
 		WSSessionDuration:  registry.MustNewMetric(WSSessionDurationName, Trend, Time),
 		WSConnecting:       registry.MustNewMetric(WSConnectingName, Trend, Time),
+		WSRequestDuration:  registry.MustNewMetric(WSRequestDurationName, Trend, Time),
+
+		WSReconnects:         registry.MustNewMetric(WSReconnectsName, Counter),
+		WSConnectionsResumed: registry.MustNewMetric(WSConnectionsResumedName, Counter),
+		WSReconnectGap:       registry.MustNewMetric(WSReconnectGapName, Trend, Time),
+
+		WSMessageBytesSent:               registry.MustNewMetric(WSMessageBytesSentName, Counter, Data),
+		WSMessageCompressedBytesSent:     registry.MustNewMetric(WSMessageCompressedBytesSentName, Counter, Data),
+		WSMessageBytesReceived:           registry.MustNewMetric(WSMessageBytesReceivedName, Counter, Data),
+		WSMessageCompressedBytesReceived: registry.MustNewMetric(WSMessageCompressedBytesReceivedName, Counter, Data),
 
 		GRPCReqDuration: registry.MustNewMetric(GRPCReqDurationName, Trend, Time),
 
-		DataSent:     registry.MustNewMetric(DataSentName, Counter, Data),
-		DataReceived: registry.MustNewMetric(DataReceivedName, Counter, Data),
+		GRPCMessageBytesSent:               registry.MustNewMetric(GRPCMessageBytesSentName, Counter, Data),
+		GRPCMessageCompressedBytesSent:     registry.MustNewMetric(GRPCMessageCompressedBytesSentName, Counter, Data),
+		GRPCMessageBytesReceived:           registry.MustNewMetric(GRPCMessageBytesReceivedName, Counter, Data),
+		GRPCMessageCompressedBytesReceived: registry.MustNewMetric(GRPCMessageCompressedBytesReceivedName, Counter, Data),
+
+		DataSent:            registry.MustNewMetric(DataSentName, Counter, Data),
+		DataReceived:        registry.MustNewMetric(DataReceivedName, Counter, Data),
+		DNSLookupDuration:   registry.MustNewMetric(DNSLookupDurationName, Trend, Time),
+		BlockedRequests:     registry.MustNewMetric(BlockedRequestsName, Counter),
+		TLSHandshakeFull:    registry.MustNewMetric(TLSHandshakeFullName, Counter),
+		TLSHandshakeResumed: registry.MustNewMetric(TLSHandshakeResumedName, Counter),
+
+		TLSRevocationCheckDuration: registry.MustNewMetric(TLSRevocationCheckDurationName, Trend, Time),
+
+		ResourceCPU:            registry.MustNewMetric(ResourceCPUName, Gauge),
+		ResourceMemory:         registry.MustNewMetric(ResourceMemoryName, Gauge, Data),
+		ResourceOpenFiles:      registry.MustNewMetric(ResourceOpenFilesName, Gauge),
+		ResourceBudgetExceeded: registry.MustNewMetric(ResourceBudgetExceededName, Counter),
+
+		VUHeapBytes: registry.MustNewMetric(VUHeapBytesName, Gauge, Data),
 	}
 }
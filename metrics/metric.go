@@ -42,7 +42,7 @@ func (m *Metric) AddSubmetric(keyValues string) (*Submetric, error) {
 	if len(keyValues) == 0 {
 		return nil, fmt.Errorf("submetric criteria for metric '%s' cannot be empty", m.Name)
 	}
-	kvs := strings.Split(keyValues, ",")
+	kvs := splitTagExpressions(keyValues)
 	tags := m.registry.RootTagSet()
 	for _, kv := range kvs {
 		if kv == "" {
@@ -57,6 +57,12 @@ func (m *Metric) AddSubmetric(keyValues string) (*Submetric, error) {
 		}
 
 		value := strings.Trim(strings.TrimSpace(v), `"'`)
+		if key == TagGroup.String() && value != "" && !strings.HasPrefix(value, groupSeparator) {
+			// the group paths always start with the separator, so
+			// group:login::submit means the same as group:::login::submit,
+			// while group:"" is still the root group
+			value = groupSeparator + value
+		}
 		tags = tags.With(key, value)
 	}
 
@@ -129,7 +135,7 @@ func ParseMetricName(name string) (string, []string, error) {
 	// We already know the position of the opening and closing curly brace
 	// tokens. Thus, we extract the string in between them, and split its
 	// content to obtain the tags key values.
-	tags := strings.Split(name[openingTokenPos+1:closingTokenPos], ",")
+	tags := splitTagExpressions(name[openingTokenPos+1 : closingTokenPos])
 
 	// For each tag definition, ensure it is correctly formed
 	for i, t := range tags {
@@ -144,3 +150,40 @@ func ParseMetricName(name string) (string, []string, error) {
 
 	return name[0:openingTokenPos], tags, nil
 }
+
+// groupSeparator separates the names of the groups in their paths, like
+// lib.GroupSeparator.
+const groupSeparator = "::"
+
+// splitTagExpressions splits the comma-separated tag expressions of a
+// submetric, except for the commas in quoted keys and values, e.g.
+// group:"::a,b". Only the quotes at the start of a key or a value are
+// treated as such, so apostrophes in the unquoted values are allowed.
+func splitTagExpressions(s string) []string {
+	var (
+		result []string
+		quote  rune
+		start  int
+		// the last non-space character of the current expression
+		last rune
+	)
+	for i, r := range s {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case (r == '"' || r == '\'') && (last == 0 || last == ':'):
+			quote = r
+		case r == ',':
+			result = append(result, s[start:i])
+			start = i + 1
+			last = 0
+			continue
+		}
+		if r != ' ' {
+			last = r
+		}
+	}
+	return append(result, s[start:])
+}
@@ -48,6 +48,11 @@ func TestAddSubmetric(t *testing.T) {
 		` a : 1, b : 2 `:          {false, map[string]string{"a": "1", "b": "2"}},
 		`a : '1' , b : "2"`:       {false, map[string]string{"a": "1", "b": "2"}},
 		`" a" : ' 1' , b : "2 " `: {false, map[string]string{" a": " 1", "b": "2 "}}, //nolint:gocritic
+		`group:"::a,b",c:it's`:    {false, map[string]string{"group": "::a,b", "c": "it's"}},
+		`group:::login::submit`:   {false, map[string]string{"group": "::login::submit"}},
+		`group:login::submit`:     {false, map[string]string{"group": "::login::submit"}},
+		`group:`:                  {false, map[string]string{"group": ""}},
+		`group:""`:                {false, map[string]string{"group": ""}},
 	}
 
 	for name, expected := range testdata {
@@ -113,6 +118,13 @@ func TestParseMetricName(t *testing.T) {
 			wantTags:             []string{"group:::mygroup"},
 			wantErr:              false,
 		},
+		{
+			name:                 "metric name with a nested group tag with a comma",
+			metricNameExpression: `test_metric{group:"::my group::a, b",status:200}`,
+			wantMetricName:       "test_metric",
+			wantTags:             []string{`group:"::my group::a, b"`, "status:200"},
+			wantErr:              false,
+		},
 		{
 			name:                 "metric name with valid name and repeated curly braces tokens in tags definition",
 			metricNameExpression: "http_req_duration{name:http://${}.com}",
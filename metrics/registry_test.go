@@ -101,3 +101,15 @@ func TestRegistryAll(t *testing.T) {
 		assert.ElementsMatch(t, exp, names(metrics))
 	})
 }
+
+func TestRegistryTagSetsAreInterned(t *testing.T) {
+	t.Parallel()
+
+	r := NewRegistry()
+	ab := r.RootTagSet().With("a", "1").With("b", "2")
+	ba := r.RootTagSet().With("b", "2").With("a", "1")
+	assert.Same(t, ab, ba, "the same tags added in different orders are the same tag set")
+	assert.Same(t, ab, r.RootTagSet().WithTagsFromMap(map[string]string{"b": "2", "a": "1"}))
+	assert.Same(t, ab, ab.With("c", "3").Without("c"))
+	assert.NotSame(t, ab, r.RootTagSet().With("a", "1"))
+}
@@ -100,11 +100,21 @@ func NewTrendSink() *TrendSink {
 	return &TrendSink{}
 }
 
+// NewTrendSinkWithCompression makes a Trend sink that doesn't store every
+// value, but estimates its percentiles with a t-digest with the given
+// compression. The count, min, max, sum and average values are still exact.
+func NewTrendSinkWithCompression(compression float64) *TrendSink {
+	return &TrendSink{digest: newTDigest(compression)}
+}
+
 // TrendSink is a sink for a Trend
 type TrendSink struct {
 	values []float64
 	sorted bool
 
+	// if set, values aren't stored and the percentiles are estimated
+	digest *tDigest
+
 	count    uint64
 	min, max float64
 	sum      float64
@@ -113,6 +123,45 @@ type TrendSink struct {
 // IsEmpty indicates whether the TrendSink is empty.
 func (t *TrendSink) IsEmpty() bool { return t.count == 0 }
 
+// UseCompression makes the sink stop storing every value and estimate the
+// percentiles with a t-digest with the given compression instead. It can be
+// called only for an empty sink, otherwise it returns false.
+func (t *TrendSink) UseCompression(compression float64) bool {
+	if t.count != 0 {
+		return false
+	}
+	t.values = nil
+	t.digest = newTDigest(compression)
+	return true
+}
+
+// Merge adds all of the values from the other sink to this one. Both sinks
+// must either store every value or use a t-digest.
+func (t *TrendSink) Merge(other *TrendSink) error {
+	if (t.digest == nil) != (other.digest == nil) {
+		return fmt.Errorf("can't merge trend sinks with a different compression")
+	}
+	if other.count == 0 {
+		return nil
+	}
+	if t.count == 0 || other.min < t.min {
+		t.min = other.min
+	}
+	if t.count == 0 || other.max > t.max {
+		t.max = other.max
+	}
+	t.count += other.count
+	t.sum += other.sum
+
+	if t.digest != nil {
+		t.digest.merge(other.digest)
+		return nil
+	}
+	t.values = append(t.values, other.values...)
+	t.sorted = false
+	return nil
+}
+
 // Add a single sample into the trend
 func (t *TrendSink) Add(s Sample) {
 	if t.count == 0 {
@@ -126,19 +175,25 @@ func (t *TrendSink) Add(s Sample) {
 		}
 	}
 
-	t.values = append(t.values, s.Value)
-	t.sorted = false
+	if t.digest != nil {
+		t.digest.add(s.Value)
+	} else {
+		t.values = append(t.values, s.Value)
+		t.sorted = false
+	}
 	t.count++
 	t.sum += s.Value
 }
 
 // P calculates the given percentile from sink values.
 func (t *TrendSink) P(pct float64) float64 {
-	switch t.count {
-	case 0:
+	switch {
+	case t.count == 0:
 		return 0
-	case 1:
-		return t.values[0]
+	case t.count == 1:
+		return t.min
+	case t.digest != nil:
+		return t.digest.quantile(math.Max(0, math.Min(1, pct)), t.min, t.max)
 	default:
 		if !t.sorted {
 			sort.Float64s(t.values)
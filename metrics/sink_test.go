@@ -2,6 +2,7 @@ package metrics
 
 import (
 	"math"
+	"math/rand"
 	"testing"
 	"time"
 
@@ -236,6 +237,74 @@ func TestTrendSink(t *testing.T) {
 	})
 }
 
+func TestTrendSinkWithCompression(t *testing.T) {
+	t.Parallel()
+
+	t.Run("percentiles", func(t *testing.T) {
+		t.Parallel()
+
+		exact := NewTrendSink()
+		sink := NewTrendSinkWithCompression(100)
+		r := rand.New(rand.NewSource(42)) //nolint:gosec
+		for i := 0; i < 100_000; i++ {
+			s := Sample{TimeSeries: TimeSeries{Metric: &Metric{}}, Value: r.ExpFloat64() * 100}
+			exact.Add(s)
+			sink.Add(s)
+		}
+
+		assert.Equal(t, exact.Count(), sink.Count())
+		assert.Equal(t, exact.Min(), sink.Min())
+		assert.Equal(t, exact.Max(), sink.Max())
+		assert.InDelta(t, exact.Avg(), sink.Avg(), 0.000001)
+		for _, pct := range []float64{0.1, 0.5, 0.9, 0.95, 0.99} {
+			assert.InEpsilon(t, exact.P(pct), sink.P(pct), 0.01, "p(%v)", pct*100)
+		}
+		for _, pct := range []float64{0.01, 0.999} {
+			assert.InEpsilon(t, exact.P(pct), sink.P(pct), 0.1, "p(%v)", pct*100)
+		}
+		assert.Equal(t, sink.Min(), sink.P(0))
+		assert.Equal(t, sink.Max(), sink.P(1))
+		assert.Less(t, len(sink.digest.centroids), 100)
+		assert.Nil(t, sink.values)
+	})
+
+	t.Run("one value", func(t *testing.T) {
+		t.Parallel()
+
+		sink := NewTrendSinkWithCompression(100)
+		sink.Add(Sample{TimeSeries: TimeSeries{Metric: &Metric{}}, Value: 7})
+		assert.Equal(t, 7.0, sink.P(0.5))
+		assert.Equal(t, 7.0, sink.P(0.99))
+	})
+
+	t.Run("use compression", func(t *testing.T) {
+		t.Parallel()
+
+		sink := NewTrendSink()
+		require.True(t, sink.UseCompression(50))
+		sink.Add(Sample{TimeSeries: TimeSeries{Metric: &Metric{}}, Value: 1})
+		assert.False(t, sink.UseCompression(100))
+		assert.NotNil(t, sink.digest)
+	})
+
+	t.Run("merge", func(t *testing.T) {
+		t.Parallel()
+
+		a, b := NewTrendSinkWithCompression(100), NewTrendSinkWithCompression(100)
+		for i := 1; i <= 1000; i++ {
+			a.Add(Sample{TimeSeries: TimeSeries{Metric: &Metric{}}, Value: float64(i)})
+			b.Add(Sample{TimeSeries: TimeSeries{Metric: &Metric{}}, Value: float64(i + 1000)})
+		}
+		require.NoError(t, a.Merge(b))
+		assert.Equal(t, uint64(2000), a.Count())
+		assert.Equal(t, 1.0, a.Min())
+		assert.Equal(t, 2000.0, a.Max())
+		assert.InEpsilon(t, 1000.5, a.P(0.5), 0.01)
+
+		require.Error(t, a.Merge(NewTrendSink()))
+	})
+}
+
 func TestRateSink(t *testing.T) {
 	t.Parallel()
 	samples6 := []float64{1.0, 0.0, 1.0, 0.0, 0.0, 1.0}
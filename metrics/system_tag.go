@@ -38,10 +38,12 @@ const (
 	TagVU   // non-indexable
 	TagOCSPStatus
 	TagIP
+	TagTLSCipher
+	TagTLSGroup
 )
 
 // DefaultSystemTagSet includes all of the system tags emitted with metrics by default.
-// Other tags that are not enabled by default include: iter, vu, ocsp_status, ip
+// Other tags that are not enabled by default include: iter, vu, ocsp_status, ip, tls_cipher, tls_group
 //
 //nolint:gochecknoglobals
 var DefaultSystemTagSet = SystemTagSet(
@@ -153,3 +155,31 @@ func (i *SystemTagSet) UnmarshalText(data []byte) error {
 	}
 	return nil
 }
+
+// FilterSample returns the sample without the system tags, which aren't in the
+// set, in its tags and its metadata. The metadata is copied, if it has to be
+// changed, since it can be shared by the samples of a container.
+func (i *SystemTagSet) FilterSample(s Sample) Sample {
+	copied := false
+	for _, tag := range SystemTagValues() {
+		if i.Has(tag) {
+			continue
+		}
+		name := tag.String()
+		if _, ok := s.Tags.Get(name); ok {
+			s.Tags = s.Tags.Without(name)
+		}
+		if _, ok := s.Metadata[name]; !ok {
+			continue
+		}
+		if !copied {
+			metadata := make(map[string]string, len(s.Metadata))
+			for k, v := range s.Metadata {
+				metadata[k] = v
+			}
+			s.Metadata, copied = metadata, true
+		}
+		delete(s.Metadata, name)
+	}
+	return s
+}
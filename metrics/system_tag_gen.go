@@ -6,7 +6,7 @@ import (
 	"fmt"
 )
 
-const _SystemTagName = "protosubprotostatusmethodurlnamegroupcheckerrorerror_codetls_versionscenarioserviceexpected_responseitervuocsp_statusip"
+const _SystemTagName = "protosubprotostatusmethodurlnamegroupcheckerrorerror_codetls_versionscenarioserviceexpected_responseitervuocsp_statusiptls_ciphertls_group"
 
 var _SystemTagMap = map[SystemTag]string{
 	1:      _SystemTagName[0:5],
@@ -27,6 +27,8 @@ var _SystemTagMap = map[SystemTag]string{
 	32768:  _SystemTagName[104:106],
 	65536:  _SystemTagName[106:117],
 	131072: _SystemTagName[117:119],
+	262144: _SystemTagName[119:129],
+	524288: _SystemTagName[129:138],
 }
 
 func (i SystemTag) String() string {
@@ -36,7 +38,7 @@ func (i SystemTag) String() string {
 	return fmt.Sprintf("SystemTag(%d)", i)
 }
 
-var _SystemTagValues = []SystemTag{1, 2, 4, 8, 16, 32, 64, 128, 256, 512, 1024, 2048, 4096, 8192, 16384, 32768, 65536, 131072}
+var _SystemTagValues = []SystemTag{1, 2, 4, 8, 16, 32, 64, 128, 256, 512, 1024, 2048, 4096, 8192, 16384, 32768, 65536, 131072, 262144, 524288}
 
 var _SystemTagNameToValueMap = map[string]SystemTag{
 	_SystemTagName[0:5]:     1,
@@ -57,6 +59,8 @@ var _SystemTagNameToValueMap = map[string]SystemTag{
 	_SystemTagName[104:106]: 32768,
 	_SystemTagName[106:117]: 65536,
 	_SystemTagName[117:119]: 131072,
+	_SystemTagName[119:129]: 262144,
+	_SystemTagName[129:138]: 524288,
 }
 
 // SystemTagString retrieves an enum value from the enum constants string name.
@@ -127,3 +127,28 @@ func TestTagSetTextUnmarshal(t *testing.T) {
 		require.Equal(t, expected, *set)
 	}
 }
+
+func TestSystemTagSetFilterSample(t *testing.T) {
+	t.Parallel()
+
+	registry := NewRegistry()
+	metric := registry.MustNewMetric("my_metric", Counter)
+	metadata := map[string]string{"vu": "1", "iter": "2", "trace_id": "abc"}
+	sample := Sample{
+		TimeSeries: TimeSeries{
+			Metric: metric,
+			Tags: registry.RootTagSet().WithTagsFromMap(map[string]string{
+				"url": "http://example.com", "method": "GET", "custom": "value",
+			}),
+		},
+		Metadata: metadata,
+	}
+
+	filtered := NewSystemTagSet(TagMethod, TagIter).FilterSample(sample)
+	assert.Equal(t, map[string]string{"method": "GET", "custom": "value"}, filtered.Tags.Map())
+	assert.Equal(t, map[string]string{"iter": "2", "trace_id": "abc"}, filtered.Metadata)
+	assert.Equal(t, map[string]string{"vu": "1", "iter": "2", "trace_id": "abc"}, metadata)
+
+	filtered = NewSystemTagSet(TagMethod, TagURL, TagIter, TagVU).FilterSample(sample)
+	assert.Equal(t, sample, filtered)
+}
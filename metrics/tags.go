@@ -20,7 +20,9 @@ import (
 // Assuming all tag sets start from the same root (see Registry.RootTagSet()),
 // you can compare *TagSet values of different metric Samples with the `==` Go
 // operator to check if they have the same tags, and you can also use *TagSet
-// values for map indexes and caching.
+// values for map indexes and caching. The tag sets are interned, i.e. the same
+// tags are always the same *TagSet, no matter in which order they were added,
+// since the atlas keeps the tags of each of its nodes sorted by their keys.
 //
 // See also the TimeSeries type for comparing a Sample's {metric+tags} for
 // equality at the same time.
@@ -0,0 +1,123 @@
+package metrics
+
+import (
+	"math"
+	"sort"
+)
+
+// tDigest is a merging t-digest, as described by Ted Dunning in "Computing
+// Extremely Accurate Quantiles Using t-Digests". It's used by the TrendSink to
+// estimate percentiles in a bounded amount of memory, regardless of how many
+// values were added to it. Higher compression values mean more centroids are
+// kept, so more memory is used, but the percentile estimates are more accurate.
+//
+// It's not thread-safe and it doesn't track the min and max values, since the
+// TrendSink already keeps them.
+type tDigest struct {
+	compression float64
+	centroids   []centroid // always sorted by their mean
+	buffer      []centroid // unmerged values
+	totalWeight float64
+}
+
+type centroid struct {
+	mean, weight float64
+}
+
+func newTDigest(compression float64) *tDigest {
+	return &tDigest{
+		compression: compression,
+		buffer:      make([]centroid, 0, int(compression)*5),
+	}
+}
+
+// add records a single value in the digest.
+func (d *tDigest) add(v float64) {
+	d.buffer = append(d.buffer, centroid{mean: v, weight: 1})
+	d.totalWeight++
+	if len(d.buffer) == cap(d.buffer) {
+		d.compress()
+	}
+}
+
+// merge adds all of the values from the other digest to this one.
+func (d *tDigest) merge(other *tDigest) {
+	other.compress()
+	d.buffer = append(d.buffer, other.centroids...)
+	d.totalWeight += other.totalWeight
+	d.compress()
+}
+
+// scale is the k1 scale function from the paper, it makes the centroids at the
+// tails smaller, so the extreme percentiles are more accurate.
+func (d *tDigest) scale(q float64) float64 {
+	return d.compression / (2 * math.Pi) * math.Asin(2*q-1)
+}
+
+// compress merges the buffered values with the existing centroids, combining
+// neighbouring centroids as long as they span at most one unit of the scale
+// function, so the number of centroids is bounded by the compression.
+func (d *tDigest) compress() {
+	if len(d.buffer) == 0 {
+		return
+	}
+
+	all := append(d.buffer, d.centroids...) //nolint:gocritic
+	sort.Slice(all, func(i, j int) bool { return all[i].mean < all[j].mean })
+
+	merged := make([]centroid, 0, len(d.centroids)+1)
+	current := all[0]
+	weightSoFar := 0.0
+	for _, next := range all[1:] {
+		qLeft := weightSoFar / d.totalWeight
+		qRight := (weightSoFar + current.weight + next.weight) / d.totalWeight
+		if d.scale(qRight)-d.scale(qLeft) <= 1 {
+			newWeight := current.weight + next.weight
+			current.mean += (next.mean - current.mean) * next.weight / newWeight
+			current.weight = newWeight
+			continue
+		}
+		merged = append(merged, current)
+		weightSoFar += current.weight
+		current = next
+	}
+	merged = append(merged, current)
+
+	d.centroids = merged
+	d.buffer = d.buffer[:0]
+}
+
+// quantile returns an estimate of the value at the given quantile, which should
+// be between 0 and 1, by interpolating between the centroids. The min and max
+// values are used as the bounds of the interpolation at the ends.
+func (d *tDigest) quantile(q, lowest, highest float64) float64 {
+	d.compress()
+	if len(d.centroids) == 0 {
+		return 0
+	}
+	if len(d.centroids) == 1 {
+		return d.centroids[0].mean
+	}
+
+	target := q * d.totalWeight
+	first, last := d.centroids[0], d.centroids[len(d.centroids)-1]
+	if target <= first.weight/2 {
+		return lowest + (first.mean-lowest)*target/(first.weight/2)
+	}
+	if target >= d.totalWeight-last.weight/2 {
+		return last.mean + (highest-last.mean)*(target-(d.totalWeight-last.weight/2))/(last.weight/2)
+	}
+
+	// each centroid's "center" is at the cumulative weight before it plus
+	// half of its own weight, find the two centers the target is between
+	cumulative := first.weight / 2
+	for i := 1; i < len(d.centroids); i++ {
+		prev, cur := d.centroids[i-1], d.centroids[i]
+		step := (prev.weight + cur.weight) / 2
+		if cumulative+step >= target {
+			return prev.mean + (cur.mean-prev.mean)*(target-cumulative)/step
+		}
+		cumulative += step
+	}
+	return last.mean
+}
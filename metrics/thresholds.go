@@ -24,10 +24,22 @@ type Threshold struct {
 	// AbortGracePeriod is a the minimum amount of time a test should be running before a failing
 	// this threshold will abort the test
 	AbortGracePeriod types.NullDuration
+	// Evaluations are the results of the runs of this threshold, which had
+	// samples to evaluate, in order
+	Evaluations []ThresholdEvaluation
 	// parsed is the threshold expression parsed from the Source
 	parsed *thresholdExpression
 }
 
+// ThresholdEvaluation is the result of a single run of a threshold.
+type ThresholdEvaluation struct {
+	// Time is the time spent in the test, when the threshold was run
+	Time time.Duration
+	// Value is the actual value of the aggregation method of the threshold
+	Value float64
+	OK    bool
+}
+
 func newThreshold(src string, abortOnFail bool, gracePeriod types.NullDuration) *Threshold {
 	return &Threshold{
 		Source:           src,
@@ -79,9 +91,12 @@ func (t *Threshold) runNoTaint(sinks map[string]float64) (bool, error) {
 	return passes, nil
 }
 
-func (t *Threshold) run(sinks map[string]float64) (bool, error) {
+func (t *Threshold) run(sinks map[string]float64, timeSpentInTest time.Duration) (bool, error) {
 	passes, err := t.runNoTaint(sinks)
 	t.LastFailed = !passes
+	if value, ok := sinks[t.parsed.SinkKey()]; ok && err == nil {
+		t.Evaluations = append(t.Evaluations, ThresholdEvaluation{Time: timeSpentInTest, Value: value, OK: passes})
+	}
 	return passes, err
 }
 
@@ -145,7 +160,7 @@ func newThresholdsWithConfig(configs []thresholdConfig) Thresholds {
 func (ts *Thresholds) runAll(timeSpentInTest time.Duration) (bool, error) {
 	succeeded := true
 	for i, threshold := range ts.Thresholds {
-		b, err := threshold.run(ts.sinked)
+		b, err := threshold.run(ts.sinked, timeSpentInTest)
 		if err != nil {
 			return false, fmt.Errorf("threshold %d run error: %w", i, err)
 		}
@@ -202,7 +202,7 @@ func TestThresholdRun(t *testing.T) {
 		t.Run("taint", func(t *testing.T) {
 			t.Parallel()
 
-			b, err := threshold.run(sinks)
+			b, err := threshold.run(sinks, 0)
 			assert.NoError(t, err)
 			assert.True(t, b)
 			assert.False(t, threshold.LastFailed)
@@ -226,7 +226,7 @@ func TestThresholdRun(t *testing.T) {
 		})
 
 		t.Run("taint", func(t *testing.T) {
-			b, err := threshold.run(sinks)
+			b, err := threshold.run(sinks, 0)
 			assert.NoError(t, err)
 			assert.False(t, b)
 			assert.True(t, threshold.LastFailed)
@@ -749,6 +749,31 @@ func TestThresholdsRun(t *testing.T) {
 	}
 }
 
+func TestThresholdsRunEvaluations(t *testing.T) {
+	t.Parallel()
+
+	thresholds := NewThresholds([]string{"rate<0.1"})
+	require.NoError(t, thresholds.Parse())
+
+	sink := &RateSink{}
+	_, err := thresholds.Run(sink, time.Second)
+	require.NoError(t, err)
+	assert.Empty(t, thresholds.Thresholds[0].Evaluations, "a run without samples isn't an evaluation")
+
+	sink.Add(Sample{Value: 0})
+	_, err = thresholds.Run(sink, 2*time.Second)
+	require.NoError(t, err)
+	sink.Add(Sample{Value: 1})
+	ok, err := thresholds.Run(sink, 4*time.Second)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	assert.Equal(t, []ThresholdEvaluation{
+		{Time: 2 * time.Second, Value: 0, OK: true},
+		{Time: 4 * time.Second, Value: 0.5, OK: false},
+	}, thresholds.Thresholds[0].Evaluations)
+}
+
 func TestThresholdsJSON(t *testing.T) {
 	t.Parallel()
 
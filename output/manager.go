@@ -16,6 +16,8 @@ type Manager struct {
 	outputs []Output
 	logger  logrus.FieldLogger
 
+	metricSystemTags map[string]*metrics.SystemTagSet
+
 	testStopCallback func(error)
 }
 
@@ -28,6 +30,34 @@ func NewManager(outputs []Output, logger logrus.FieldLogger, testStopCallback fu
 	}
 }
 
+// SetMetricSystemTags sets the system tags, which are kept in the samples of
+// the metrics, before they are sent to the outputs. It has to be called before
+// Start().
+func (om *Manager) SetMetricSystemTags(metricSystemTags map[string]*metrics.SystemTagSet) {
+	om.metricSystemTags = metricSystemTags
+}
+
+// filterSystemTags drops the system tags, which aren't enabled for their
+// metrics, from the samples of the containers. The samples are replaced in the
+// slices of the containers, so they keep their types, except for the single
+// samples, which are replaced by the filtered ones.
+func (om *Manager) filterSystemTags(sampleContainers []metrics.SampleContainer) {
+	for i, container := range sampleContainers {
+		if sample, ok := container.(metrics.Sample); ok {
+			if tags := om.metricSystemTags[sample.Metric.Name]; tags != nil {
+				sampleContainers[i] = tags.FilterSample(sample)
+			}
+			continue
+		}
+		samples := container.GetSamples()
+		for j, sample := range samples {
+			if tags := om.metricSystemTags[sample.Metric.Name]; tags != nil {
+				samples[j] = tags.FilterSample(sample)
+			}
+		}
+	}
+}
+
 // Start spins up all configured outputs and then starts a new goroutine that
 // pipes metrics from the given samples channel to them.
 //
@@ -48,6 +78,9 @@ func (om *Manager) Start(samplesChan chan metrics.SampleContainer) (wait func(),
 	wg.Add(1)
 
 	sendToOutputs := func(sampleContainers []metrics.SampleContainer) {
+		if len(om.metricSystemTags) != 0 {
+			om.filterSystemTags(sampleContainers)
+		}
 		for _, out := range om.outputs {
 			out.AddMetricSamples(sampleContainers)
 		}
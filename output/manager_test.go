@@ -0,0 +1,44 @@
+package output
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.k6.io/k6/internal/lib/testutils"
+	"go.k6.io/k6/internal/lib/testutils/mockoutput"
+	"go.k6.io/k6/metrics"
+)
+
+func TestManagerMetricSystemTags(t *testing.T) {
+	t.Parallel()
+
+	registry := metrics.NewRegistry()
+	dataSent := registry.MustNewMetric("data_sent", metrics.Counter)
+	duration := registry.MustNewMetric("http_req_duration", metrics.Trend)
+	tags := registry.RootTagSet().WithTagsFromMap(map[string]string{"url": "http://example.com", "scenario": "default"})
+
+	out := mockoutput.New()
+	manager := NewManager([]Output{out}, testutils.NewLogger(t), func(error) {})
+	manager.SetMetricSystemTags(map[string]*metrics.SystemTagSet{
+		"data_sent": metrics.NewSystemTagSet(metrics.TagScenario),
+	})
+
+	samples := make(chan metrics.SampleContainer, 2)
+	wait, finish, err := manager.Start(samples)
+	require.NoError(t, err)
+	samples <- metrics.Sample{TimeSeries: metrics.TimeSeries{Metric: dataSent, Tags: tags}, Value: 1}
+	samples <- metrics.ConnectedSamples{Samples: []metrics.Sample{
+		{TimeSeries: metrics.TimeSeries{Metric: dataSent, Tags: tags}, Value: 2},
+		{TimeSeries: metrics.TimeSeries{Metric: duration, Tags: tags}, Value: 3},
+	}}
+	close(samples)
+	wait()
+	finish(nil)
+
+	require.Len(t, out.Samples, 3)
+	assert.Equal(t, map[string]string{"scenario": "default"}, out.Samples[0].Tags.Map())
+	assert.Equal(t, map[string]string{"scenario": "default"}, out.Samples[1].Tags.Map())
+	assert.Equal(t, tags, out.Samples[2].Tags)
+}
@@ -10,6 +10,7 @@ import (
 
 	"github.com/sirupsen/logrus"
 
+	"go.k6.io/k6/internal/event"
 	"go.k6.io/k6/internal/usage"
 	"go.k6.io/k6/lib"
 	"go.k6.io/k6/lib/fsext"
@@ -32,6 +33,10 @@ type Params struct {
 	RuntimeOptions lib.RuntimeOptions
 	ExecutionPlan  []lib.ExecutionStep
 	Usage          *usage.Usage
+
+	// Events allows the outputs to subscribe to the global events, e.g. to
+	// the ScenarioStart or ThresholdCrossed ones, instead of polling the REST API.
+	Events event.Subscriber
 }
 
 // TODO: make v2 with buffered channels?